@@ -33,3 +33,21 @@ type BPFInstruction struct {
 	// K is a constant parameter. The meaning depends on the value of OpCode.
 	K uint32
 }
+
+// UserSockFprog is equivalent to struct sock_fprog from <linux/filter.h> on
+// 64-bit architectures, as passed by applications to seccomp(2) and
+// setsockopt(2).
+//
+// +marshal
+type UserSockFprog struct {
+	// Len is the length of the filter in BPF instructions.
+	Len uint16
+
+	_ [6]byte // padding for alignment
+
+	// Filter is a user pointer to the struct sock_filter array that makes up
+	// the filter program. Filter is a uint64 rather than a hostarch.Addr
+	// because hostarch.Addr is actually uintptr, which is not a fixed-size
+	// type.
+	Filter uint64
+}
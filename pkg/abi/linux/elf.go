@@ -100,6 +100,18 @@ const (
 	// NT_PRFPREG is for float point register.
 	NT_PRFPREG = 0x2
 
+	// NT_PRPSINFO is for process information (struct elf_prpsinfo).
+	NT_PRPSINFO = 0x3
+
+	// NT_AUXV is for the auxiliary vector.
+	NT_AUXV = 0x6
+
+	// NT_SIGINFO is for the siginfo_t of the signal that caused a core dump.
+	NT_SIGINFO = 0x53494749
+
+	// NT_FILE is for the files mapped by a process.
+	NT_FILE = 0x46494c45
+
 	// NT_X86_XSTATE is for x86 extended state using xsave.
 	NT_X86_XSTATE = 0x202
 
@@ -156,3 +168,65 @@ type ElfProg64 struct {
 	Memsz  uint64 // Size of contents in memory.
 	Align  uint64 // Alignment in memory and file.
 }
+
+// ElfNote64 is the ELF64 note header.
+//
+// +marshal
+type ElfNote64 struct {
+	Namesz uint32 // Length of the note's name.
+	Descsz uint32 // Length of the note's descriptor.
+	Type   uint32 // Type of the note.
+}
+
+// ElfSiginfo is equivalent to struct elf_siginfo.
+//
+// +marshal
+type ElfSiginfo struct {
+	Signo int32 // Signal number.
+	Code  int32 // Extra code.
+	Errno int32 // Errno.
+}
+
+// ElfPrstatusCommon is equivalent to struct elf_prstatus_common. In a
+// NT_PRSTATUS note, it is followed by the general purpose registers
+// (elf_gregset_t) and int pr_fpvalid.
+//
+// +marshal
+type ElfPrstatusCommon struct {
+	Info    ElfSiginfo // Info associated with signal.
+	Cursig  int16      // Current signal.
+	_       [2]byte
+	Sigpend uint64  // Set of pending signals.
+	Sighold uint64  // Set of held signals.
+	Pid     int32   // Thread ID.
+	Ppid    int32   // Parent process ID.
+	Pgrp    int32   // Process group ID.
+	Sid     int32   // Session ID.
+	Utime   Timeval // User time.
+	Stime   Timeval // System time.
+	Cutime  Timeval // Cumulative user time.
+	Cstime  Timeval // Cumulative system time.
+}
+
+// ElfPrargsz is the size of ElfPrpsinfo.Psargs, ELF_PRARGSZ.
+const ElfPrargsz = 80
+
+// ElfPrpsinfo is equivalent to struct elf_prpsinfo.
+//
+// +marshal
+type ElfPrpsinfo struct {
+	State  uint8 // Numeric process state.
+	Sname  uint8 // Character for State.
+	Zomb   uint8 // Zombie.
+	Nice   int8  // Nice value.
+	_      [4]byte
+	Flag   uint64           // Flags.
+	UID    uint32           // Real user ID.
+	GID    uint32           // Real group ID.
+	Pid    int32            // Process ID.
+	Ppid   int32            // Parent process ID.
+	Pgrp   int32            // Process group ID.
+	Sid    int32            // Session ID.
+	Fname  [16]byte         // Filename of executable.
+	Psargs [ElfPrargsz]byte // Initial part of arg list.
+}
@@ -20,10 +20,33 @@ import (
 
 // Socket error origin codes as defined in include/uapi/linux/errqueue.h.
 const (
-	SO_EE_ORIGIN_NONE  = 0
-	SO_EE_ORIGIN_LOCAL = 1
-	SO_EE_ORIGIN_ICMP  = 2
-	SO_EE_ORIGIN_ICMP6 = 3
+	SO_EE_ORIGIN_NONE     = 0
+	SO_EE_ORIGIN_LOCAL    = 1
+	SO_EE_ORIGIN_ICMP     = 2
+	SO_EE_ORIGIN_ICMP6    = 3
+	SO_EE_ORIGIN_ZEROCOPY = 5
+	SO_EE_ORIGIN_TXTIME   = 6
+)
+
+// Socket error codes of SO_EE_ORIGIN_ZEROCOPY as defined in
+// include/uapi/linux/errqueue.h.
+const (
+	// SO_EE_CODE_ZEROCOPY_COPIED indicates that the data of the completed
+	// MSG_ZEROCOPY sends was copied.
+	SO_EE_CODE_ZEROCOPY_COPIED = 1
+)
+
+// Socket error codes of SO_EE_ORIGIN_TXTIME as defined in
+// include/uapi/linux/errqueue.h.
+const (
+	// SO_EE_CODE_TXTIME_INVALID_PARAM indicates that a packet was dropped
+	// because its transmit time or the SO_TXTIME configuration of its
+	// socket was rejected.
+	SO_EE_CODE_TXTIME_INVALID_PARAM = 1
+
+	// SO_EE_CODE_TXTIME_MISSED indicates that a packet was dropped because
+	// it missed its transmit time.
+	SO_EE_CODE_TXTIME_MISSED = 2
 )
 
 // SockExtendedErr represents struct sock_extended_err in Linux defined in
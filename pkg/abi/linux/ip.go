@@ -42,6 +42,7 @@ const (
 	IPPROTO_UDPLITE = 136
 	IPPROTO_MPLS    = 137
 	IPPROTO_RAW     = 255
+	IPPROTO_MPTCP   = 262
 )
 
 // Socket options from uapi/linux/in.h
@@ -95,6 +96,13 @@ const (
 	IP_LOCAL_PORT_RANGE       = 51
 )
 
+// Source filter modes for IP_MSFILTER and MCAST_MSFILTER, from
+// uapi/linux/in.h.
+const (
+	MCAST_EXCLUDE = 0
+	MCAST_INCLUDE = 1
+)
+
 // IP_MTU_DISCOVER values from uapi/linux/in.h
 const (
 	IP_PMTUDISC_DONT      = 0
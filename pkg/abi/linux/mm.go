@@ -49,6 +49,14 @@ const (
 	MAP_HUGETLB    = 1 << 18
 )
 
+// Access rights for pkey_alloc(2), from
+// include/uapi/asm-generic/mman-common.h.
+const (
+	PKEY_DISABLE_ACCESS = 0x1
+	PKEY_DISABLE_WRITE  = 0x2
+	PKEY_ACCESS_MASK    = PKEY_DISABLE_ACCESS | PKEY_DISABLE_WRITE
+)
+
 // Flags for mremap(2).
 const (
 	MREMAP_MAYMOVE = 1 << 0
@@ -96,6 +104,23 @@ const (
 	MS_SYNC       = 1 << 2
 )
 
+// Bits in /proc/[pid]/coredump_filter, from include/linux/sched/coredump.h
+// (without MMF_DUMP_FILTER_SHIFT applied). See core(5).
+const (
+	MMF_DUMP_ANON_PRIVATE    = 1 << 0
+	MMF_DUMP_ANON_SHARED     = 1 << 1
+	MMF_DUMP_MAPPED_PRIVATE  = 1 << 2
+	MMF_DUMP_MAPPED_SHARED   = 1 << 3
+	MMF_DUMP_ELF_HEADERS     = 1 << 4
+	MMF_DUMP_HUGETLB_PRIVATE = 1 << 5
+	MMF_DUMP_HUGETLB_SHARED  = 1 << 6
+	MMF_DUMP_DAX_PRIVATE     = 1 << 7
+	MMF_DUMP_DAX_SHARED      = 1 << 8
+
+	MMF_DUMP_FILTER_MASK    = 1<<9 - 1
+	MMF_DUMP_FILTER_DEFAULT = MMF_DUMP_ANON_PRIVATE | MMF_DUMP_ANON_SHARED | MMF_DUMP_ELF_HEADERS | MMF_DUMP_HUGETLB_PRIVATE
+)
+
 // NumaPolicy is the NUMA memory policy for a memory range. See numa(7).
 //
 // +marshal
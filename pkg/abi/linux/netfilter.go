@@ -436,6 +436,42 @@ type XTNATTargetV2 struct {
 // SizeOfXTNATTargetV2 is the size of an XTNATTargetV2.
 const SizeOfXTNATTargetV2 = SizeOfXTEntryTarget + SizeOfNFNATRange2
 
+// XTTPROXYTargetV0 steers packets to a local transparent socket when reached.
+// It corresponds to struct xt_tproxy_target_info in
+// include/uapi/linux/netfilter/xt_TPROXY.h, followed by padding to make the
+// struct 8 byte aligned.
+//
+// +marshal
+type XTTPROXYTargetV0 struct {
+	Target    XTEntryTarget
+	MarkMask  uint32
+	MarkValue uint32
+	LAddr     [4]byte
+	LPort     uint16
+	_         [2]byte
+}
+
+// SizeOfXTTPROXYTargetV0 is the size of an XTTPROXYTargetV0.
+const SizeOfXTTPROXYTargetV0 = 48
+
+// XTTPROXYTargetV1 steers packets to a local transparent socket when reached.
+// It corresponds to struct xt_tproxy_target_info_v1 in
+// include/uapi/linux/netfilter/xt_TPROXY.h, followed by padding to make the
+// struct 8 byte aligned.
+//
+// +marshal
+type XTTPROXYTargetV1 struct {
+	Target    XTEntryTarget
+	MarkMask  uint32
+	MarkValue uint32
+	LAddr     [16]byte
+	LPort     uint16
+	_         [6]byte
+}
+
+// SizeOfXTTPROXYTargetV1 is the size of an XTTPROXYTargetV1.
+const SizeOfXTTPROXYTargetV1 = 64
+
 // IPTGetinfo is the argument for the IPT_SO_GET_INFO sockopt. It corresponds
 // to struct ipt_getinfo in include/uapi/linux/netfilter_ipv4/ip_tables.h.
 //
@@ -787,3 +823,28 @@ const SizeOfXTMultiport = 2 + (XT_MULTI_PORTS * 2)
 
 // SizeOfXTMultiportV1 is the size of XTMultiportV1 (in bytes).
 const SizeOfXTMultiportV1 = SizeOfXTMultiport + XT_MULTI_PORTS + 1
+
+// XTSocketMatchInfo holds data for matching packets that belong to a local
+// socket. It corresponds to struct xt_socket_mtinfo1 (and the identical
+// xt_socket_mtinfo2 and xt_socket_mtinfo3) in
+// include/uapi/linux/netfilter/xt_socket.h.
+//
+// +marshal
+type XTSocketMatchInfo struct {
+	// Flags is a combination of the XT_SOCKET_* flags below.
+	Flags uint8
+}
+
+// SizeOfXTSocketMatchInfo is the size of an XTSocketMatchInfo.
+const SizeOfXTSocketMatchInfo = 1
+
+// Flags in XTSocketMatchInfo.Flags. Corresponding constants are in
+// include/uapi/linux/netfilter/xt_socket.h.
+const (
+	// Only match sockets with IP_TRANSPARENT set.
+	XT_SOCKET_TRANSPARENT = 1 << 0
+	// Don't match sockets bound to a wildcard address.
+	XT_SOCKET_NOWILDCARD = 1 << 1
+	// Restore the packet mark from the socket mark.
+	XT_SOCKET_RESTORESKMARK = 1 << 2
+)
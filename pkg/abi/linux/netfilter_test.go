@@ -40,6 +40,9 @@ func TestSizes(t *testing.T) {
 		{IP6TIP{}, SizeOfIP6TIP},
 		{XTMultiport{}, SizeOfXTMultiport},
 		{XTMultiportV1{}, SizeOfXTMultiportV1},
+		{XTTPROXYTargetV0{}, SizeOfXTTPROXYTargetV0},
+		{XTTPROXYTargetV1{}, SizeOfXTTPROXYTargetV1},
+		{XTSocketMatchInfo{}, SizeOfXTSocketMatchInfo},
 	}
 
 	for _, tc := range testCases {
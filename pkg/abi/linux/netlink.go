@@ -115,6 +115,55 @@ const NetlinkAttrHeaderSize = 4
 // uapi/linux/netlink.h.
 const NLA_ALIGNTO = 4
 
+// Netlink attribute type flags, from uapi/linux/netlink.h.
+const (
+	NLA_F_NESTED        = 1 << 15
+	NLA_F_NET_BYTEORDER = 1 << 14
+	NLA_TYPE_MASK       = ^uint16(NLA_F_NESTED | NLA_F_NET_BYTEORDER)
+)
+
+// Netlink attribute types reported in policy dumps, from
+// uapi/linux/netlink.h.
+const (
+	NL_ATTR_TYPE_INVALID      = 0
+	NL_ATTR_TYPE_FLAG         = 1
+	NL_ATTR_TYPE_U8           = 2
+	NL_ATTR_TYPE_U16          = 3
+	NL_ATTR_TYPE_U32          = 4
+	NL_ATTR_TYPE_U64          = 5
+	NL_ATTR_TYPE_S8           = 6
+	NL_ATTR_TYPE_S16          = 7
+	NL_ATTR_TYPE_S32          = 8
+	NL_ATTR_TYPE_S64          = 9
+	NL_ATTR_TYPE_BINARY       = 10
+	NL_ATTR_TYPE_STRING       = 11
+	NL_ATTR_TYPE_NUL_STRING   = 12
+	NL_ATTR_TYPE_NESTED       = 13
+	NL_ATTR_TYPE_NESTED_ARRAY = 14
+	NL_ATTR_TYPE_BITFIELD32   = 15
+)
+
+// Netlink policy dump attributes, from uapi/linux/netlink.h.
+const (
+	NL_POLICY_TYPE_ATTR_UNSPEC          = 0
+	NL_POLICY_TYPE_ATTR_TYPE            = 1
+	NL_POLICY_TYPE_ATTR_MIN_VALUE_S     = 2
+	NL_POLICY_TYPE_ATTR_MAX_VALUE_S     = 3
+	NL_POLICY_TYPE_ATTR_MIN_VALUE_U     = 4
+	NL_POLICY_TYPE_ATTR_MAX_VALUE_U     = 5
+	NL_POLICY_TYPE_ATTR_MIN_LENGTH      = 6
+	NL_POLICY_TYPE_ATTR_MAX_LENGTH      = 7
+	NL_POLICY_TYPE_ATTR_POLICY_IDX      = 8
+	NL_POLICY_TYPE_ATTR_POLICY_MAXTYPE  = 9
+	NL_POLICY_TYPE_ATTR_BITFIELD32_MASK = 10
+	NL_POLICY_TYPE_ATTR_PAD             = 11
+	NL_POLICY_TYPE_ATTR_MASK            = 12
+)
+
+// NLMSG_GOODSIZE is the size of the datagrams in which netlink dumps are
+// sent, from include/linux/netlink.h for 4K pages.
+const NLMSG_GOODSIZE = 3776
+
 // Socket options, from uapi/linux/netlink.h.
 const (
 	NETLINK_ADD_MEMBERSHIP   = 1
@@ -183,6 +183,175 @@ const (
 	VETH_INFO_PEER = 1
 )
 
+// VXLAN attributes, from uapi/linux/if_link.h.
+const (
+	IFLA_VXLAN_UNSPEC     = 0
+	IFLA_VXLAN_ID         = 1
+	IFLA_VXLAN_GROUP      = 2
+	IFLA_VXLAN_LINK       = 3
+	IFLA_VXLAN_LOCAL      = 4
+	IFLA_VXLAN_TTL        = 5
+	IFLA_VXLAN_TOS        = 6
+	IFLA_VXLAN_LEARNING   = 7
+	IFLA_VXLAN_AGEING     = 8
+	IFLA_VXLAN_LIMIT      = 9
+	IFLA_VXLAN_PORT_RANGE = 10
+	IFLA_VXLAN_PROXY      = 11
+	IFLA_VXLAN_RSC        = 12
+	IFLA_VXLAN_L2MISS     = 13
+	IFLA_VXLAN_L3MISS     = 14
+	IFLA_VXLAN_PORT       = 15
+	IFLA_VXLAN_GROUP6     = 16
+	IFLA_VXLAN_LOCAL6     = 17
+)
+
+// Geneve attributes, from uapi/linux/if_link.h.
+const (
+	IFLA_GENEVE_UNSPEC           = 0
+	IFLA_GENEVE_ID               = 1
+	IFLA_GENEVE_REMOTE           = 2
+	IFLA_GENEVE_TTL              = 3
+	IFLA_GENEVE_TOS              = 4
+	IFLA_GENEVE_PORT             = 5
+	IFLA_GENEVE_COLLECT_METADATA = 6
+	IFLA_GENEVE_REMOTE6          = 7
+)
+
+// IP tunnel attributes, from uapi/linux/if_tunnel.h.
+const (
+	IFLA_IPTUN_UNSPEC      = 0
+	IFLA_IPTUN_LINK        = 1
+	IFLA_IPTUN_LOCAL       = 2
+	IFLA_IPTUN_REMOTE      = 3
+	IFLA_IPTUN_TTL         = 4
+	IFLA_IPTUN_TOS         = 5
+	IFLA_IPTUN_ENCAP_LIMIT = 6
+	IFLA_IPTUN_FLOWINFO    = 7
+	IFLA_IPTUN_FLAGS       = 8
+	IFLA_IPTUN_PROTO       = 9
+	IFLA_IPTUN_PMTUDISC    = 10
+)
+
+// GRE tunnel attributes, from uapi/linux/if_tunnel.h.
+const (
+	IFLA_GRE_UNSPEC   = 0
+	IFLA_GRE_LINK     = 1
+	IFLA_GRE_IFLAGS   = 2
+	IFLA_GRE_OFLAGS   = 3
+	IFLA_GRE_IKEY     = 4
+	IFLA_GRE_OKEY     = 5
+	IFLA_GRE_LOCAL    = 6
+	IFLA_GRE_REMOTE   = 7
+	IFLA_GRE_TTL      = 8
+	IFLA_GRE_TOS      = 9
+	IFLA_GRE_PMTUDISC = 10
+)
+
+// GRE flags of IFLA_GRE_IFLAGS and IFLA_GRE_OFLAGS, from uapi/linux/if_tunnel.h.
+// They are in network byte order.
+const (
+	GRE_CSUM = 0x8000
+	GRE_KEY  = 0x2000
+	GRE_SEQ  = 0x1000
+)
+
+// VLAN attributes, from uapi/linux/if_link.h.
+const (
+	IFLA_VLAN_UNSPEC      = 0
+	IFLA_VLAN_ID          = 1
+	IFLA_VLAN_FLAGS       = 2
+	IFLA_VLAN_EGRESS_QOS  = 3
+	IFLA_VLAN_INGRESS_QOS = 4
+	IFLA_VLAN_PROTOCOL    = 5
+)
+
+// Macvlan attributes, from uapi/linux/if_link.h.
+const (
+	IFLA_MACVLAN_UNSPEC            = 0
+	IFLA_MACVLAN_MODE              = 1
+	IFLA_MACVLAN_FLAGS             = 2
+	IFLA_MACVLAN_MACADDR_MODE      = 3
+	IFLA_MACVLAN_MACADDR           = 4
+	IFLA_MACVLAN_MACADDR_DATA      = 5
+	IFLA_MACVLAN_MACADDR_COUNT     = 6
+	IFLA_MACVLAN_BC_QUEUE_LEN      = 7
+	IFLA_MACVLAN_BC_QUEUE_LEN_USED = 8
+	IFLA_MACVLAN_BC_CUTOFF         = 9
+)
+
+// Macvlan modes of IFLA_MACVLAN_MODE, from uapi/linux/if_link.h.
+const (
+	MACVLAN_MODE_PRIVATE  = 1
+	MACVLAN_MODE_VEPA     = 2
+	MACVLAN_MODE_BRIDGE   = 4
+	MACVLAN_MODE_PASSTHRU = 8
+	MACVLAN_MODE_SOURCE   = 16
+)
+
+// Ipvlan attributes, from uapi/linux/if_link.h.
+const (
+	IFLA_IPVLAN_UNSPEC = 0
+	IFLA_IPVLAN_MODE   = 1
+	IFLA_IPVLAN_FLAGS  = 2
+)
+
+// Ipvlan modes of IFLA_IPVLAN_MODE, from uapi/linux/if_link.h.
+const (
+	IPVLAN_MODE_L2  = 0
+	IPVLAN_MODE_L3  = 1
+	IPVLAN_MODE_L3S = 2
+)
+
+// Ipvlan flags of IFLA_IPVLAN_FLAGS, from uapi/linux/if_link.h.
+const (
+	IPVLAN_F_PRIVATE = 0x01
+	IPVLAN_F_VEPA    = 0x02
+)
+
+// Bridge attributes, from uapi/linux/if_link.h.
+const (
+	IFLA_BR_UNSPEC         = 0
+	IFLA_BR_FORWARD_DELAY  = 1
+	IFLA_BR_HELLO_TIME     = 2
+	IFLA_BR_MAX_AGE        = 3
+	IFLA_BR_AGEING_TIME    = 4
+	IFLA_BR_STP_STATE      = 5
+	IFLA_BR_PRIORITY       = 6
+	IFLA_BR_VLAN_FILTERING = 7
+	IFLA_BR_VLAN_PROTOCOL  = 8
+)
+
+// Bridge attributes of IFLA_AF_SPEC, from uapi/linux/if_bridge.h.
+const (
+	IFLA_BRIDGE_FLAGS     = 0
+	IFLA_BRIDGE_MODE      = 1
+	IFLA_BRIDGE_VLAN_INFO = 2
+)
+
+// Flags of IFLA_BRIDGE_FLAGS, from uapi/linux/if_bridge.h.
+const (
+	BRIDGE_FLAGS_MASTER = 1
+	BRIDGE_FLAGS_SELF   = 2
+)
+
+// Flags of BridgeVLANInfo, from uapi/linux/if_bridge.h.
+const (
+	BRIDGE_VLAN_INFO_MASTER      = 1 << 0
+	BRIDGE_VLAN_INFO_PVID        = 1 << 1
+	BRIDGE_VLAN_INFO_UNTAGGED    = 1 << 2
+	BRIDGE_VLAN_INFO_RANGE_BEGIN = 1 << 3
+	BRIDGE_VLAN_INFO_RANGE_END   = 1 << 4
+	BRIDGE_VLAN_INFO_BRENTRY     = 1 << 5
+)
+
+// BridgeVLANInfo is struct bridge_vlan_info, from uapi/linux/if_bridge.h.
+//
+// +marshal
+type BridgeVLANInfo struct {
+	Flags uint16
+	VID   uint16
+}
+
 // InterfaceAddrMessage is struct ifaddrmsg, from uapi/linux/if_addr.h.
 //
 // +marshal
@@ -214,7 +383,10 @@ const (
 const (
 	ARPHRD_NONE     = 65534
 	ARPHRD_ETHER    = 1
+	ARPHRD_TUNNEL   = 768
 	ARPHRD_LOOPBACK = 772
+	ARPHRD_SIT      = 776
+	ARPHRD_IPGRE    = 778
 )
 
 // RouteMessage is struct rtmsg, from uapi/linux/rtnetlink.h.
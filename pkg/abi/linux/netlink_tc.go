@@ -0,0 +1,178 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linux
+
+// TcMessage is struct tcmsg, from uapi/linux/rtnetlink.h.
+//
+// +marshal
+type TcMessage struct {
+	Family uint8
+	_      uint8
+	_      uint16
+	Index  int32
+	Handle uint32
+	Parent uint32
+	Info   uint32
+}
+
+// TcMessageSize is the size of TcMessage.
+const TcMessageSize = 20
+
+// Traffic control attributes, from uapi/linux/rtnetlink.h.
+const (
+	TCA_UNSPEC  = 0
+	TCA_KIND    = 1
+	TCA_OPTIONS = 2
+	TCA_STATS   = 3
+	TCA_XSTATS  = 4
+	TCA_RATE    = 5
+	TCA_FCNT    = 6
+	TCA_STATS2  = 7
+	TCA_STAB    = 8
+	TCA_PAD     = 9
+)
+
+// Traffic control handles, from uapi/linux/pkt_sched.h.
+const (
+	TC_H_MAJ_MASK = 0xFFFF0000
+	TC_H_MIN_MASK = 0x0000FFFF
+	TC_H_UNSPEC   = 0
+	TC_H_ROOT     = 0xFFFFFFFF
+	TC_H_INGRESS  = 0xFFFFFFF1
+)
+
+// Statistics attributes, from uapi/linux/gen_stats.h.
+const (
+	TCA_STATS_UNSPEC   = 0
+	TCA_STATS_BASIC    = 1
+	TCA_STATS_RATE_EST = 2
+	TCA_STATS_QUEUE    = 3
+	TCA_STATS_APP      = 4
+)
+
+// GnetStatsBasic is struct gnet_stats_basic, from uapi/linux/gen_stats.h.
+//
+// +marshal
+type GnetStatsBasic struct {
+	Bytes   uint64
+	Packets uint32
+	_       uint32
+}
+
+// GnetStatsQueue is struct gnet_stats_queue, from uapi/linux/gen_stats.h.
+//
+// +marshal
+type GnetStatsQueue struct {
+	Qlen       uint32
+	Backlog    uint32
+	Drops      uint32
+	Requeues   uint32
+	Overlimits uint32
+}
+
+// TcRateSpec is struct tc_ratespec, from uapi/linux/pkt_sched.h.
+//
+// +marshal
+type TcRateSpec struct {
+	CellLog   uint8
+	LinkLayer uint8
+	Overhead  uint16
+	CellAlign int16
+	MPU       uint16
+	Rate      uint32
+}
+
+// TBF attributes, from uapi/linux/pkt_sched.h.
+const (
+	TCA_TBF_UNSPEC  = 0
+	TCA_TBF_PARMS   = 1
+	TCA_TBF_RTAB    = 2
+	TCA_TBF_PTAB    = 3
+	TCA_TBF_RATE64  = 4
+	TCA_TBF_PRATE64 = 5
+	TCA_TBF_BURST   = 6
+	TCA_TBF_PBURST  = 7
+	TCA_TBF_PAD     = 8
+)
+
+// TcTBFQopt is struct tc_tbf_qopt, from uapi/linux/pkt_sched.h. Buffer and
+// MTU are in psched ticks.
+//
+// +marshal
+type TcTBFQopt struct {
+	Rate     TcRateSpec
+	PeakRate TcRateSpec
+	Limit    uint32
+	Buffer   uint32
+	MTU      uint32
+}
+
+// FQ attributes, from uapi/linux/pkt_sched.h.
+const (
+	TCA_FQ_UNSPEC             = 0
+	TCA_FQ_PLIMIT             = 1
+	TCA_FQ_FLOW_PLIMIT        = 2
+	TCA_FQ_QUANTUM            = 3
+	TCA_FQ_INITIAL_QUANTUM    = 4
+	TCA_FQ_RATE_ENABLE        = 5
+	TCA_FQ_FLOW_DEFAULT_RATE  = 6
+	TCA_FQ_FLOW_MAX_RATE      = 7
+	TCA_FQ_BUCKETS_LOG        = 8
+	TCA_FQ_FLOW_REFILL_DELAY  = 9
+	TCA_FQ_ORPHAN_MASK        = 10
+	TCA_FQ_LOW_RATE_THRESHOLD = 11
+)
+
+// HTB attributes, from uapi/linux/pkt_sched.h.
+const (
+	TCA_HTB_UNSPEC      = 0
+	TCA_HTB_PARMS       = 1
+	TCA_HTB_INIT        = 2
+	TCA_HTB_CTAB        = 3
+	TCA_HTB_RTAB        = 4
+	TCA_HTB_DIRECT_QLEN = 5
+	TCA_HTB_RATE64      = 6
+	TCA_HTB_CEIL64      = 7
+	TCA_HTB_PAD         = 8
+)
+
+// TcHTBOpt is struct tc_htb_opt, from uapi/linux/pkt_sched.h. Buffer and
+// CBuffer are in psched ticks.
+//
+// +marshal
+type TcHTBOpt struct {
+	Rate    TcRateSpec
+	Ceil    TcRateSpec
+	Buffer  uint32
+	CBuffer uint32
+	Quantum uint32
+	Level   uint32
+	Prio    uint32
+}
+
+// TcHTBGlob is struct tc_htb_glob, from uapi/linux/pkt_sched.h.
+//
+// +marshal
+type TcHTBGlob struct {
+	Version      uint32
+	Rate2Quantum uint32
+	DefCls       uint32
+	Debug        uint32
+	DirectPkts   uint32
+}
+
+// PschedTickNS is the length of a psched tick in nanoseconds, as reported by
+// /proc/net/psched.
+const PschedTickNS = 64
@@ -0,0 +1,220 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linux
+
+// Socket options from uapi/linux/sctp.h.
+const (
+	SCTP_RTOINFO               = 0
+	SCTP_ASSOCINFO             = 1
+	SCTP_INITMSG               = 2
+	SCTP_NODELAY               = 3
+	SCTP_AUTOCLOSE             = 4
+	SCTP_SET_PEER_PRIMARY_ADDR = 5
+	SCTP_PRIMARY_ADDR          = 6
+	SCTP_ADAPTATION_LAYER      = 7
+	SCTP_DISABLE_FRAGMENTS     = 8
+	SCTP_PEER_ADDR_PARAMS      = 9
+	SCTP_DEFAULT_SEND_PARAM    = 10
+	SCTP_EVENTS                = 11
+	SCTP_I_WANT_MAPPED_V4_ADDR = 12
+	SCTP_MAXSEG                = 13
+	SCTP_STATUS                = 14
+	SCTP_GET_PEER_ADDR_INFO    = 15
+)
+
+// Control message types from uapi/linux/sctp.h.
+const (
+	SCTP_INIT   = 0
+	SCTP_SNDRCV = 1
+)
+
+// Flags for SCTPSndRcvInfo.Flags, from uapi/linux/sctp.h.
+const (
+	SCTP_UNORDERED        = 1
+	SCTP_ADDR_OVER        = 2
+	SCTP_ABORT            = 4
+	SCTP_SACK_IMMEDIATELY = 8
+	SCTP_SENDALL          = 64
+	SCTP_EOF              = MSG_FIN
+)
+
+// Special association IDs, from uapi/linux/sctp.h.
+const (
+	SCTP_FUTURE_ASSOC  = 0
+	SCTP_CURRENT_ASSOC = 1
+	SCTP_ALL_ASSOC     = 2
+)
+
+// Association states reported by SCTP_STATUS, from uapi/linux/sctp.h.
+const (
+	SCTP_EMPTY             = 0
+	SCTP_CLOSED            = 1
+	SCTP_COOKIE_WAIT       = 2
+	SCTP_COOKIE_ECHOED     = 3
+	SCTP_ESTABLISHED       = 4
+	SCTP_SHUTDOWN_PENDING  = 5
+	SCTP_SHUTDOWN_SENT     = 6
+	SCTP_SHUTDOWN_RECEIVED = 7
+	SCTP_SHUTDOWN_ACK_SENT = 8
+)
+
+// Peer address states, from uapi/linux/sctp.h.
+const (
+	SCTP_INACTIVE    = 0
+	SCTP_PF          = 1
+	SCTP_ACTIVE      = 2
+	SCTP_UNCONFIRMED = 3
+)
+
+// Flags for SCTPPaddrParams.Flags, from uapi/linux/sctp.h.
+const (
+	SPP_HB_ENABLE         = 1 << 0
+	SPP_HB_DISABLE        = 1 << 1
+	SPP_HB_DEMAND         = 1 << 2
+	SPP_PMTUD_ENABLE      = 1 << 3
+	SPP_PMTUD_DISABLE     = 1 << 4
+	SPP_SACKDELAY_ENABLE  = 1 << 5
+	SPP_SACKDELAY_DISABLE = 1 << 6
+	SPP_HB_TIME_IS_ZERO   = 1 << 7
+	SPP_IPV6_FLOWLABEL    = 1 << 8
+	SPP_DSCP              = 1 << 9
+)
+
+// SCTPInitMsg is struct sctp_initmsg, from uapi/linux/sctp.h.
+//
+// +marshal
+type SCTPInitMsg struct {
+	NumOstreams  uint16
+	MaxInstreams uint16
+	MaxAttempts  uint16
+	MaxInitTimeo uint16
+}
+
+// SCTPRTOInfo is struct sctp_rtoinfo, from uapi/linux/sctp.h.
+//
+// +marshal
+type SCTPRTOInfo struct {
+	AssocID int32
+	Initial uint32
+	Max     uint32
+	Min     uint32
+}
+
+// SCTPAssocParams is struct sctp_assocparams, from uapi/linux/sctp.h.
+//
+// +marshal
+type SCTPAssocParams struct {
+	AssocID                int32
+	AssocMaxRxt            uint16
+	NumberPeerDestinations uint16
+	PeerRwnd               uint32
+	LocalRwnd              uint32
+	CookieLife             uint32
+}
+
+// SCTPSndRcvInfo is struct sctp_sndrcvinfo, from uapi/linux/sctp.h.
+//
+// +marshal
+type SCTPSndRcvInfo struct {
+	Stream     uint16
+	SSN        uint16
+	Flags      uint16
+	_          uint16
+	PPID       uint32
+	Context    uint32
+	TimeToLive uint32
+	TSN        uint32
+	CumTSN     uint32
+	AssocID    int32
+}
+
+// SizeOfSCTPSndRcvInfo is the size of a SCTPSndRcvInfo struct.
+var SizeOfSCTPSndRcvInfo = (*SCTPSndRcvInfo)(nil).SizeBytes()
+
+// SCTPEventSubscribe is struct sctp_event_subscribe, from uapi/linux/sctp.h.
+// Only DataIO has an effect.
+//
+// +marshal
+type SCTPEventSubscribe struct {
+	DataIO           uint8
+	Association      uint8
+	Address          uint8
+	SendFailure      uint8
+	PeerError        uint8
+	Shutdown         uint8
+	PartialDelivery  uint8
+	AdaptationLayer  uint8
+	Authentication   uint8
+	SenderDry        uint8
+	StreamReset      uint8
+	AssocReset       uint8
+	StreamChange     uint8
+	SendFailureEvent uint8
+}
+
+// SCTPPaddrParams is struct sctp_paddrparams, from uapi/linux/sctp.h.
+//
+// The C struct is packed, so the fields following PathMaxRxt are unaligned and
+// are represented as byte arrays in host byte order.
+//
+// +marshal
+type SCTPPaddrParams struct {
+	AssocID    int32
+	Address    [128]byte
+	HBInterval uint32
+	PathMaxRxt uint16
+	PathMTU    [4]byte
+	SackDelay  [4]byte
+	Flags      [4]byte
+	FlowLabel  [4]byte
+	DSCP       uint8
+	_          uint8
+}
+
+// SCTPPaddrInfo is struct sctp_paddrinfo, from uapi/linux/sctp.h.
+//
+// +marshal
+type SCTPPaddrInfo struct {
+	AssocID int32
+	Address [128]byte
+	State   int32
+	Cwnd    uint32
+	SRTT    uint32
+	RTO     uint32
+	MTU     uint32
+}
+
+// SCTPStatus is struct sctp_status, from uapi/linux/sctp.h.
+//
+// +marshal
+type SCTPStatus struct {
+	AssocID            int32
+	State              int32
+	Rwnd               uint32
+	UnackData          uint16
+	PendData           uint16
+	InStreams          uint16
+	OutStreams         uint16
+	FragmentationPoint uint32
+	Primary            SCTPPaddrInfo
+}
+
+// SCTPAssocValue is struct sctp_assoc_value, from uapi/linux/sctp.h.
+//
+// +marshal
+type SCTPAssocValue struct {
+	AssocID int32
+	Value   uint32
+}
@@ -265,6 +265,22 @@ const (
 	CLD_CONTINUED = 6
 )
 
+// SEGV_* codes are only meaningful for SIGSEGV.
+const (
+	// SEGV_MAPERR indicates that the address is not mapped.
+	SEGV_MAPERR = 1
+
+	// SEGV_ACCERR indicates that the mapping does not permit the access.
+	SEGV_ACCERR = 2
+
+	// SEGV_BNDERR indicates that an MPX bounds check failed.
+	SEGV_BNDERR = 3
+
+	// SEGV_PKUERR indicates that the access was denied by a memory
+	// protection key.
+	SEGV_PKUERR = 4
+)
+
 // SYS_* codes are only meaningful for SIGSYS.
 const (
 	// SYS_SECCOMP indicates that a signal originates from seccomp.
@@ -385,7 +401,14 @@ type SignalInfo struct {
 	// 	/* SIGILL, SIGFPE, SIGSEGV, SIGBUS */
 	// 	struct {
 	// 		void *_addr; /* faulting insn/memory ref. */
-	// 		short _addr_lsb; /* LSB of the reported address */
+	// 		union {
+	// 			short _addr_lsb; /* LSB of the reported address */
+	// 			/* used when si_code=SEGV_PKUERR */
+	// 			struct {
+	// 				char _dummy_pkey[__ADDR_BND_PKEY_PAD];
+	// 				__u32 _pkey;
+	// 			} _addr_pkey;
+	// 		};
 	// 	} _sigfault;
 	//
 	// 	/* SIGPOLL */
@@ -480,6 +503,16 @@ func (s *SignalInfo) SetAddr(val uint64) {
 	hostarch.ByteOrder.PutUint64(s.Fields[0:8], val)
 }
 
+// Pkey returns the si_pkey field.
+func (s *SignalInfo) Pkey() uint32 {
+	return hostarch.ByteOrder.Uint32(s.Fields[16:20])
+}
+
+// SetPkey sets the si_pkey field.
+func (s *SignalInfo) SetPkey(val uint32) {
+	hostarch.ByteOrder.PutUint32(s.Fields[16:20], val)
+}
+
 // Status returns the si_status field.
 func (s *SignalInfo) Status() int32 {
 	return int32(hostarch.ByteOrder.Uint32(s.Fields[8:12]))
@@ -98,9 +98,11 @@ const (
 	SOL_UDP     = 17
 	SOL_IPV6    = 41
 	SOL_ICMPV6  = 58
+	SOL_SCTP    = 132
 	SOL_RAW     = 255
 	SOL_PACKET  = 263
 	SOL_NETLINK = 270
+	SOL_TLS     = 282
 )
 
 // A SockType is a type (as opposed to family) of sockets. These are enumerated
@@ -403,6 +405,63 @@ type InetMulticastRequestWithNIC struct {
 	InterfaceIndex int32
 }
 
+// InetMulticastSourceRequest is struct ip_mreq_source, from uapi/linux/in.h.
+//
+// +marshal
+type InetMulticastSourceRequest struct {
+	MulticastAddr InetAddr
+	InterfaceAddr InetAddr
+	SourceAddr    InetAddr
+}
+
+// InetMulticastFilter is struct ip_msfilter, from uapi/linux/in.h, without
+// its trailing list of NumSources sources.
+//
+// +marshal
+type InetMulticastFilter struct {
+	MulticastAddr InetAddr
+	InterfaceAddr InetAddr
+	Mode          uint32
+	NumSources    uint32
+}
+
+// SockAddrStorage is struct __kernel_sockaddr_storage, from
+// uapi/linux/socket.h.
+//
+// +marshal
+type SockAddrStorage [SockAddrMax]byte
+
+// GroupRequest is struct group_req, from uapi/linux/in.h.
+//
+// +marshal
+type GroupRequest struct {
+	InterfaceIndex uint32
+	_              uint32
+	Group          SockAddrStorage
+}
+
+// GroupSourceRequest is struct group_source_req, from uapi/linux/in.h.
+//
+// +marshal
+type GroupSourceRequest struct {
+	InterfaceIndex uint32
+	_              uint32
+	Group          SockAddrStorage
+	Source         SockAddrStorage
+}
+
+// GroupFilter is struct group_filter, from uapi/linux/in.h, without its
+// trailing list of NumSources sources.
+//
+// +marshal
+type GroupFilter struct {
+	InterfaceIndex uint32
+	_              uint32
+	Group          SockAddrStorage
+	Mode           uint32
+	NumSources     uint32
+}
+
 // Inet6Addr is struct in6_addr, from uapi/linux/in6.h.
 //
 // +marshal
@@ -632,6 +691,7 @@ var SizeOfTCPInfo = (*TCPInfo)(nil).SizeBytes()
 const (
 	SCM_CREDENTIALS = 0x2
 	SCM_RIGHTS      = 0x1
+	SCM_TXTIME      = SO_TXTIME
 )
 
 // A ControlMessageHeader is the header for a socket control message.
@@ -681,6 +741,27 @@ type ControlMessageIPv6PacketInfo struct {
 	NIC  uint32
 }
 
+// SockTxtime is the value of the SO_TXTIME socket option.
+//
+// SockTxtime represents struct sock_txtime from linux/net_tstamp.h.
+//
+// +marshal
+type SockTxtime struct {
+	ClockID int32
+	Flags   uint32
+}
+
+// SizeOfSockTxtime is the binary size of a SockTxtime struct.
+var SizeOfSockTxtime = (*SockTxtime)(nil).SizeBytes()
+
+// Flags of SockTxtime, from linux/net_tstamp.h.
+const (
+	SOF_TXTIME_DEADLINE_MODE = 1 << 0
+	SOF_TXTIME_REPORT_ERRORS = 1 << 1
+
+	SOF_TXTIME_FLAGS_MASK = SOF_TXTIME_DEADLINE_MODE | SOF_TXTIME_REPORT_ERRORS
+)
+
 // SizeOfControlMessageCredentials is the binary size of a
 // ControlMessageCredentials struct.
 var SizeOfControlMessageCredentials = (*ControlMessageCredentials)(nil).SizeBytes()
@@ -712,6 +793,9 @@ const SizeOfControlMessageIPPacketInfo = 12
 // ControlMessageIPv6PacketInfo.
 const SizeOfControlMessageIPv6PacketInfo = 20
 
+// SizeOfControlMessageTxTime is the size of an SCM_TXTIME control message.
+const SizeOfControlMessageTxTime = 8
+
 // SCM_MAX_FD is the maximum number of FDs accepted in a single sendmsg call.
 // From net/scm.h.
 const SCM_MAX_FD = 253
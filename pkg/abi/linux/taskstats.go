@@ -0,0 +1,130 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linux
+
+// Taskstats generic netlink family, from uapi/linux/taskstats.h.
+const (
+	TASKSTATS_GENL_NAME    = "TASKSTATS"
+	TASKSTATS_GENL_VERSION = 0x1
+)
+
+// TASKSTATS_VERSION is the version of Taskstats.
+const TASKSTATS_VERSION = 14
+
+// TS_COMM_LEN is the size of Taskstats.Comm.
+const TS_COMM_LEN = 32
+
+// Taskstats commands, from uapi/linux/taskstats.h.
+const (
+	TASKSTATS_CMD_UNSPEC = 0
+	TASKSTATS_CMD_GET    = 1
+	TASKSTATS_CMD_NEW    = 2
+)
+
+// Taskstats reply attributes, from uapi/linux/taskstats.h.
+const (
+	TASKSTATS_TYPE_UNSPEC    = 0
+	TASKSTATS_TYPE_PID       = 1
+	TASKSTATS_TYPE_TGID      = 2
+	TASKSTATS_TYPE_STATS     = 3
+	TASKSTATS_TYPE_AGGR_PID  = 4
+	TASKSTATS_TYPE_AGGR_TGID = 5
+	TASKSTATS_TYPE_NULL      = 6
+)
+
+// Taskstats request attributes, from uapi/linux/taskstats.h.
+const (
+	TASKSTATS_CMD_ATTR_UNSPEC             = 0
+	TASKSTATS_CMD_ATTR_PID                = 1
+	TASKSTATS_CMD_ATTR_TGID               = 2
+	TASKSTATS_CMD_ATTR_REGISTER_CPUMASK   = 3
+	TASKSTATS_CMD_ATTR_DEREGISTER_CPUMASK = 4
+)
+
+// Taskstats is struct taskstats, from uapi/linux/taskstats.h.
+//
+// +marshal
+type Taskstats struct {
+	Version  uint16
+	_        uint16
+	ExitCode uint32
+	Flag     uint8
+	Nice     uint8
+	_        [6]byte
+
+	// Delay accounting fields.
+	CPUCount           uint64
+	CPUDelayTotal      uint64
+	BlkioCount         uint64
+	BlkioDelayTotal    uint64
+	SwapinCount        uint64
+	SwapinDelayTotal   uint64
+	CPURunRealTotal    uint64
+	CPURunVirtualTotal uint64
+
+	// Basic accounting fields.
+	Comm  [TS_COMM_LEN]byte
+	Sched uint8
+	_     [7]byte
+	UID   uint32
+	GID   uint32
+	PID   uint32
+	PPID  uint32
+	BTime uint32
+	_     [4]byte
+	ETime uint64
+	UTime uint64
+	STime uint64
+
+	// Extended accounting fields.
+	Coremem       uint64
+	Virtmem       uint64
+	HiwaterRSS    uint64
+	HiwaterVM     uint64
+	ReadChar      uint64
+	WriteChar     uint64
+	ReadSyscalls  uint64
+	WriteSyscalls uint64
+
+	// I/O accounting fields.
+	ReadBytes           uint64
+	WriteBytes          uint64
+	CancelledWriteBytes uint64
+
+	NVCSw                 uint64
+	NIVCSw                uint64
+	UTimeScaled           uint64
+	STimeScaled           uint64
+	CPUScaledRunRealTotal uint64
+	FreepagesCount        uint64
+	FreepagesDelayTotal   uint64
+	ThrashingCount        uint64
+	ThrashingDelayTotal   uint64
+	BTime64               uint64
+	CompactCount          uint64
+	CompactDelayTotal     uint64
+	TGID                  uint32
+	_                     [4]byte
+	TGETime               uint64
+	ExeDev                uint64
+	ExeInode              uint64
+	WpcopyCount           uint64
+	WpcopyDelayTotal      uint64
+	IRQCount              uint64
+	IRQDelayTotal         uint64
+}
+
+// SizeOfTaskstats is the size of Taskstats.
+const SizeOfTaskstats = 416
@@ -54,6 +54,71 @@ const (
 	TCP_TX_DELAY             = 37
 )
 
+// TCP_REPAIR values from uapi/linux/tcp.h.
+const (
+	TCP_REPAIR_ON        = 1
+	TCP_REPAIR_OFF       = 0
+	TCP_REPAIR_OFF_NO_WP = -1
+)
+
+// TCP_REPAIR_QUEUE values from uapi/linux/tcp.h.
+const (
+	TCP_NO_QUEUE   = 0
+	TCP_RECV_QUEUE = 1
+	TCP_SEND_QUEUE = 2
+)
+
+// TCPRepairOpt is struct tcp_repair_opt, from uapi/linux/tcp.h.
+//
+// +marshal
+type TCPRepairOpt struct {
+	OptCode uint32
+	OptVal  uint32
+}
+
+// SizeOfTCPRepairOpt is the size of a TCPRepairOpt struct.
+var SizeOfTCPRepairOpt = (*TCPRepairOpt)(nil).SizeBytes()
+
+// TCPRepairWindow is struct tcp_repair_window, from uapi/linux/tcp.h.
+//
+// +marshal
+type TCPRepairWindow struct {
+	SndWL1    uint32
+	SndWnd    uint32
+	MaxWindow uint32
+	RcvWnd    uint32
+	RcvWUP    uint32
+}
+
+// SizeOfTCPRepairWindow is the size of a TCPRepairWindow struct.
+var SizeOfTCPRepairWindow = (*TCPRepairWindow)(nil).SizeBytes()
+
+// TCP_MD5SIG_MAXKEYLEN is the maximum length of a TCP MD5 signature key, from
+// uapi/linux/tcp.h.
+const TCP_MD5SIG_MAXKEYLEN = 80
+
+// Flags for TCPMD5Sig.Flags, from uapi/linux/tcp.h.
+const (
+	TCP_MD5SIG_FLAG_PREFIX  = 0x1
+	TCP_MD5SIG_FLAG_IFINDEX = 0x2
+)
+
+// TCPMD5Sig is struct tcp_md5sig, from uapi/linux/tcp.h.
+//
+// +marshal
+type TCPMD5Sig struct {
+	// Addr is a struct sockaddr_storage.
+	Addr      [128]byte
+	Flags     uint8
+	PrefixLen uint8
+	KeyLen    uint16
+	IfIndex   int32
+	Key       [TCP_MD5SIG_MAXKEYLEN]byte
+}
+
+// SizeOfTCPMD5Sig is the size of a TCPMD5Sig struct.
+var SizeOfTCPMD5Sig = (*TCPMD5Sig)(nil).SizeBytes()
+
 // Socket constants from include/net/tcp.h.
 const (
 	MAX_TCP_KEEPIDLE  = 32767
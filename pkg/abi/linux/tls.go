@@ -0,0 +1,107 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package linux
+
+// Socket options from uapi/linux/tls.h.
+const (
+	TLS_TX               = 1
+	TLS_RX               = 2
+	TLS_TX_ZEROCOPY_RO   = 3
+	TLS_RX_EXPECT_NO_PAD = 4
+)
+
+// Control message types from uapi/linux/tls.h.
+const (
+	TLS_SET_RECORD_TYPE = 1
+	TLS_GET_RECORD_TYPE = 2
+)
+
+// TLS versions from uapi/linux/tls.h.
+const (
+	TLS_1_2_VERSION = 0x0303
+	TLS_1_3_VERSION = 0x0304
+)
+
+// Cipher types and parameters from uapi/linux/tls.h.
+const (
+	TLS_CIPHER_AES_GCM_128              = 51
+	TLS_CIPHER_AES_GCM_128_IV_SIZE      = 8
+	TLS_CIPHER_AES_GCM_128_KEY_SIZE     = 16
+	TLS_CIPHER_AES_GCM_128_SALT_SIZE    = 4
+	TLS_CIPHER_AES_GCM_128_TAG_SIZE     = 16
+	TLS_CIPHER_AES_GCM_128_REC_SEQ_SIZE = 8
+
+	TLS_CIPHER_AES_GCM_256              = 52
+	TLS_CIPHER_AES_GCM_256_IV_SIZE      = 8
+	TLS_CIPHER_AES_GCM_256_KEY_SIZE     = 32
+	TLS_CIPHER_AES_GCM_256_SALT_SIZE    = 4
+	TLS_CIPHER_AES_GCM_256_TAG_SIZE     = 16
+	TLS_CIPHER_AES_GCM_256_REC_SEQ_SIZE = 8
+
+	TLS_CIPHER_CHACHA20_POLY1305              = 54
+	TLS_CIPHER_CHACHA20_POLY1305_IV_SIZE      = 12
+	TLS_CIPHER_CHACHA20_POLY1305_KEY_SIZE     = 32
+	TLS_CIPHER_CHACHA20_POLY1305_SALT_SIZE    = 0
+	TLS_CIPHER_CHACHA20_POLY1305_TAG_SIZE     = 16
+	TLS_CIPHER_CHACHA20_POLY1305_REC_SEQ_SIZE = 8
+)
+
+// TLSCryptoInfo is struct tls_crypto_info, from uapi/linux/tls.h. It is the
+// header of the crypto info structures passed with TLS_TX and TLS_RX.
+//
+// +marshal
+type TLSCryptoInfo struct {
+	Version    uint16
+	CipherType uint16
+}
+
+// TLS12CryptoInfoAESGCM128 is struct tls12_crypto_info_aes_gcm_128, from
+// uapi/linux/tls.h.
+//
+// +marshal
+type TLS12CryptoInfoAESGCM128 struct {
+	Info   TLSCryptoInfo
+	IV     [TLS_CIPHER_AES_GCM_128_IV_SIZE]byte
+	Key    [TLS_CIPHER_AES_GCM_128_KEY_SIZE]byte
+	Salt   [TLS_CIPHER_AES_GCM_128_SALT_SIZE]byte
+	RecSeq [TLS_CIPHER_AES_GCM_128_REC_SEQ_SIZE]byte
+}
+
+// TLS12CryptoInfoAESGCM256 is struct tls12_crypto_info_aes_gcm_256, from
+// uapi/linux/tls.h.
+//
+// +marshal
+type TLS12CryptoInfoAESGCM256 struct {
+	Info   TLSCryptoInfo
+	IV     [TLS_CIPHER_AES_GCM_256_IV_SIZE]byte
+	Key    [TLS_CIPHER_AES_GCM_256_KEY_SIZE]byte
+	Salt   [TLS_CIPHER_AES_GCM_256_SALT_SIZE]byte
+	RecSeq [TLS_CIPHER_AES_GCM_256_REC_SEQ_SIZE]byte
+}
+
+// TLS12CryptoInfoChaCha20Poly1305 is struct
+// tls12_crypto_info_chacha20_poly1305, from uapi/linux/tls.h.
+//
+// +marshal
+type TLS12CryptoInfoChaCha20Poly1305 struct {
+	Info   TLSCryptoInfo
+	IV     [TLS_CIPHER_CHACHA20_POLY1305_IV_SIZE]byte
+	Key    [TLS_CIPHER_CHACHA20_POLY1305_KEY_SIZE]byte
+	RecSeq [TLS_CIPHER_CHACHA20_POLY1305_REC_SEQ_SIZE]byte
+}
+
+// SizeOfControlMessageTLSRecordType is the size of a TLS_SET_RECORD_TYPE or
+// TLS_GET_RECORD_TYPE control message.
+const SizeOfControlMessageTLSRecordType = 1
@@ -420,6 +420,23 @@ func (b *Buffer) Clone() Buffer {
 	return other
 }
 
+// CloneOwned is like Clone, but copies the data of views created with
+// NewViewWithExternalData, such that the clone doesn't reference any memory
+// that b doesn't own.
+func (b *Buffer) CloneOwned() Buffer {
+	other := Buffer{
+		size: b.size,
+	}
+	for v := b.data.Front(); v != nil; v = v.Next() {
+		if v.chunk.release != nil {
+			other.data.PushBack(NewViewWithData(v.AsSlice()))
+			continue
+		}
+		other.data.PushBack(v.Clone())
+	}
+	return other
+}
+
 // DeepClone creates a deep clone of b, copying data such that no bytes are
 // shared with any other Buffers.
 func (b *Buffer) DeepClone() Buffer {
@@ -735,6 +735,24 @@ func TestBufferClone(t *testing.T) {
 	}
 }
 
+func TestBufferCloneOwned(t *testing.T) {
+	external := []byte("external")
+	released := false
+	var b Buffer
+	b.Append(NewViewWithData([]byte("owned ")))
+	b.Append(NewViewWithExternalData(external, func() { released = true }))
+
+	clonedB := b.CloneOwned()
+	b.Release()
+	if !released {
+		t.Errorf("the clone references the external data")
+	}
+	if got, want := string(clonedB.Flatten()), "owned external"; got != want {
+		t.Errorf("got clonedB.Flatten() = %q, want %q", got, want)
+	}
+	clonedB.Release()
+}
+
 func TestBufferSubApply(t *testing.T) {
 	var b Buffer
 	defer b.Release()
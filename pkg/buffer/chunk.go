@@ -76,6 +76,11 @@ func getChunkPool(size int) *sync.Pool {
 type chunk struct {
 	chunkRefs
 	data []byte
+
+	// release is called when the chunk is destroyed if data is owned by
+	// the caller of NewViewWithExternalData rather than by the chunk. It
+	// isn't saved, as a restored chunk owns a copy of data.
+	release func() `state:"nosave"`
 }
 
 func newChunk(size int) *chunk {
@@ -94,6 +99,12 @@ func newChunk(size int) *chunk {
 }
 
 func (c *chunk) destroy() {
+	if c.release != nil {
+		c.release()
+		c.release = nil
+		c.data = nil
+		return
+	}
 	if len(c.data) > MaxChunkSize {
 		c.data = nil
 		return
@@ -83,6 +83,21 @@ func NewViewWithData(data []byte) *View {
 	return v
 }
 
+// NewViewWithExternalData creates a new view of data without copying it. The
+// view does not own data: release is called once the view and all its clones
+// are released, and the view is never written in place, as its chunk is
+// considered shared with the caller.
+func NewViewWithExternalData(data []byte, release func()) *View {
+	c := &chunk{
+		data:    data,
+		release: release,
+	}
+	c.InitRefs()
+	v := viewPool.Get().(*View)
+	*v = View{chunk: c, write: len(data)}
+	return v
+}
+
 // Clone creates a shallow clone of v where the underlying chunk is shared.
 //
 // The caller must own the View to call Clone. It is not safe to call Clone
@@ -119,7 +134,7 @@ func (v *View) Reset() {
 }
 
 func (v *View) sharesChunk() bool {
-	return v.chunk.refCount.Load() > 1
+	return v.chunk.release != nil || v.chunk.refCount.Load() > 1
 }
 
 // Full indicates the chunk is full.
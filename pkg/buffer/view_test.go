@@ -151,6 +151,37 @@ func TestWriteToCloned(t *testing.T) {
 	}
 }
 
+func TestExternalData(t *testing.T) {
+	data := []byte("external")
+	released := false
+	v := NewViewWithExternalData(data, func() { released = true })
+	if !cmp.Equal(v.AsSlice(), data) {
+		t.Errorf("got v.AsSlice() = %v, want %v", v.AsSlice(), data)
+	}
+	if &v.AsSlice()[0] != &data[0] {
+		t.Errorf("v.AsSlice() does not reference the external data")
+	}
+
+	clone := v.Clone()
+	if _, err := clone.WriteAt([]byte("X"), 0); err != nil {
+		t.Fatalf("WriteAt failed: %s", err)
+	}
+	if want := []byte("external"); !cmp.Equal(data, want) {
+		t.Errorf("got data = %s after writing to a clone, want %s", data, want)
+	}
+	if _, err := v.WriteAt([]byte("X"), 0); err != nil {
+		t.Fatalf("WriteAt failed: %s", err)
+	}
+	if want := []byte("external"); !cmp.Equal(data, want) {
+		t.Errorf("got data = %s after writing to the view, want %s", data, want)
+	}
+	if !released {
+		t.Errorf("external data was not released once no view referenced it")
+	}
+	v.Release()
+	clone.Release()
+}
+
 func TestWriteAt(t *testing.T) {
 	size := 10
 	off := 5
@@ -88,6 +88,7 @@ var (
 	hasXSAVEOPT   bool
 	hasXSAVE      bool
 	hasFSGSBASE   bool
+	hasPKU        bool
 	validXCR0Mask uintptr
 	localXCR0     uintptr
 )
@@ -121,9 +122,15 @@ func Init(fs cpuid.FeatureSet) {
 	hasXSAVEOPT = fs.UseXsaveopt()
 	hasXSAVE = fs.UseXsave()
 	hasFSGSBASE = fs.HasFeature(cpuid.X86FeatureFSGSBase)
+	hasPKU = hasXSAVE && fs.HasFeature(cpuid.X86FeaturePKU)
 	validXCR0Mask = uintptr(fs.ValidXCR0Mask())
 	if hasXSAVE {
-		XCR0DisabledMask := uintptr((1 << 9) | (1 << 17) | (1 << 18))
+		XCR0DisabledMask := uintptr(cpuid.XSAVEFeaturePKRU | (1 << 17) | (1 << 18))
+		if hasPKU {
+			// PKRU is part of the application's floating point state, and
+			// must be switched along with it.
+			XCR0DisabledMask &^= cpuid.XSAVEFeaturePKRU
+		}
 		localXCR0 = xgetbv(0) &^ XCR0DisabledMask
 	}
 }
@@ -56,7 +56,10 @@ const (
 	dirty      = 0x040
 	super      = 0x080
 	global     = 0x100
-	optionMask = executeDisable | 0xfff
+	optionMask = executeDisable | protectionKeyMask | 0xfff
+
+	protectionKeyShift = 59
+	protectionKeyMask  = 0xf << protectionKeyShift
 
 	writeThroughShift = 3
 	patIndexMask      = 0x3
@@ -75,6 +78,10 @@ type MapOpts struct {
 
 	// MemoryType is the memory type.
 	MemoryType hostarch.MemoryType
+
+	// ProtectionKey is the memory protection key for user pages. It is only
+	// enforced if CR4.PKE is set.
+	ProtectionKey uint8
 }
 
 // PTE is a page table entry.
@@ -107,9 +114,10 @@ func (p *PTE) Opts() MapOpts {
 			Write:   v&writable != 0,
 			Execute: v&executeDisable == 0,
 		},
-		Global:     v&global != 0,
-		User:       v&user != 0,
-		MemoryType: hostarch.MemoryType((v >> writeThroughShift) & patIndexMask),
+		Global:        v&global != 0,
+		User:          v&user != 0,
+		MemoryType:    hostarch.MemoryType((v >> writeThroughShift) & patIndexMask),
+		ProtectionKey: uint8((v & protectionKeyMask) >> protectionKeyShift),
 	}
 }
 
@@ -160,6 +168,7 @@ func (p *PTE) Set(addr uintptr, opts MapOpts) {
 		v |= writable | dirty
 	}
 	v |= uintptr(opts.MemoryType&patIndexMask) << writeThroughShift
+	v |= (uintptr(opts.ProtectionKey) << protectionKeyShift) & protectionKeyMask
 	if p.IsSuper() {
 		// Note that this is inherited from the previous instance. Set
 		// does not change the value of Super. See above.
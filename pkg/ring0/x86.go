@@ -36,6 +36,7 @@ const (
 	_CR4_OSXSAVE    = 1 << 18
 	_CR4_SMEP       = 1 << 20
 	_CR4_SMAP       = 1 << 21
+	_CR4_PKE        = 1 << 22
 
 	_RFLAGS_AC       = 1 << 18
 	_RFLAGS_NT       = 1 << 14
@@ -22,6 +22,7 @@ import (
 	"fmt"
 
 	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/hostarch"
 	"gvisor.dev/gvisor/pkg/marshal"
 	"gvisor.dev/gvisor/pkg/marshal/primitive"
@@ -319,3 +320,42 @@ func (c *Context64) PtracePokeUser(addr, data uintptr) error {
 	// Note: x86 debug registers are missing.
 	return nil
 }
+
+// PKRU bits for each protection key. See Intel SDM Vol. 3, Section 4.6.2
+// "Protection Keys".
+const (
+	pkruAccessDisable = 0x1
+	pkruWriteDisable  = 0x2
+	pkruBitsPerPkey   = 2
+)
+
+// defaultPKRU is the value of the PKRU register on execve() and on entry to
+// signal handlers: access is disabled for all protection keys other than 0.
+// This is Linux's init_pkru_value.
+const defaultPKRU = 0x55555554
+
+// SetPkeyAccess sets the access rights of the given memory protection key,
+// a combination of linux.PKEY_DISABLE_ACCESS and linux.PKEY_DISABLE_WRITE.
+// Compare Linux's arch/x86/kernel/fpu/xstate.c:arch_set_user_pkey_access().
+//
+// Preconditions: Protection keys are supported.
+func (c *Context64) SetPkeyAccess(pkey int, rights uint32) {
+	var bits uint32
+	if rights&linux.PKEY_DISABLE_ACCESS != 0 {
+		bits |= pkruAccessDisable
+	}
+	if rights&linux.PKEY_DISABLE_WRITE != 0 {
+		bits |= pkruWriteDisable
+	}
+	shift := pkey * pkruBitsPerPkey
+	pkru := c.fpState.PKRU() &^ ((pkruAccessDisable | pkruWriteDisable) << shift)
+	c.fpState.SetPKRU(pkru | bits<<shift)
+}
+
+// ResetPkeyAccess resets the access rights of all memory protection keys to
+// their defaults.
+//
+// Preconditions: Protection keys are supported.
+func (c *Context64) ResetPkeyAccess() {
+	c.fpState.SetPKRU(defaultPKRU)
+}
@@ -287,3 +287,18 @@ func (c *Context64) PtracePokeUser(addr, data uintptr) error {
 func (c *Context64) FloatingPointData() *fpu.State {
 	return &c.State.fpState
 }
+
+// SetPkeyAccess sets the access rights of the given memory protection key.
+//
+// Protection keys are not supported on arm64, so this is never called.
+func (c *Context64) SetPkeyAccess(pkey int, rights uint32) {
+	panic("memory protection keys are not supported on arm64")
+}
+
+// ResetPkeyAccess resets the access rights of all memory protection keys to
+// their defaults.
+//
+// Protection keys are not supported on arm64, so this is never called.
+func (c *Context64) ResetPkeyAccess() {
+	panic("memory protection keys are not supported on arm64")
+}
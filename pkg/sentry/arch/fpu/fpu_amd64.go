@@ -316,6 +316,57 @@ func (s *State) GetMXCSR() uint32 {
 	return hostarch.ByteOrder.Uint32((*s)[mxcsrOffset:])
 }
 
+var (
+	pkruOffset     int
+	initPKRUOffset sync.Once
+)
+
+// getPKRUOffset returns the offset in bytes of the PKRU state component in
+// the (standard format) XSAVE area, or 0 if the host does not support it.
+func getPKRUOffset() int {
+	initPKRUOffset.Do(func() {
+		fs := cpuid.HostFeatureSet()
+		if !fs.UseXsave() || fs.ValidXCR0Mask()&cpuid.XSAVEFeaturePKRU == 0 {
+			return
+		}
+		// "CPUID.(EAX=0DH,ECX=i):EBX reports the offset ... of the section
+		// used for state component i" - Intel SDM Vol. 1, Section 13.2
+		// "Enumeration of CPU Support for XSAVE Instructions and
+		// XSAVE-Supported Features"
+		out := fs.Query(cpuid.In{Eax: 0xd, Ecx: 9})
+		pkruOffset = int(out.Ebx)
+	})
+	return pkruOffset
+}
+
+// PKRU returns the value of the PKRU register in the state.
+func (s *State) PKRU() uint32 {
+	f := *s
+	off := getPKRUOffset()
+	if off == 0 || len(f) < off+4 {
+		return 0
+	}
+	if hostarch.ByteOrder.Uint64(f[xstateBVOffset:])&cpuid.XSAVEFeaturePKRU == 0 {
+		// PKRU is in its initial configuration, which permits all accesses.
+		return 0
+	}
+	return hostarch.ByteOrder.Uint32(f[off:])
+}
+
+// SetPKRU sets the PKRU register in the state. It returns false if the host
+// does not support PKRU.
+func (s *State) SetPKRU(pkru uint32) bool {
+	f := *s
+	off := getPKRUOffset()
+	if off == 0 || len(f) < off+4 {
+		return false
+	}
+	hostarch.ByteOrder.PutUint32(f[off:], pkru)
+	xstateBV := hostarch.ByteOrder.Uint64(f[xstateBVOffset:])
+	hostarch.ByteOrder.PutUint64(f[xstateBVOffset:], xstateBV|cpuid.XSAVEFeaturePKRU)
+	return true
+}
+
 // BytePointer returns a pointer to the first byte of the state.
 //
 //go:nosplit
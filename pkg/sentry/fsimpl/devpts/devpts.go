@@ -66,8 +66,8 @@ func (fstype *FilesystemType) GetFilesystem(ctx context.Context, vfsObj *vfs.Vir
 	fsOpts := fileSystemOpts{
 		mode:     0555,
 		ptmxMode: 0666,
-		uid:      creds.EffectiveKUID,
-		gid:      creds.EffectiveKGID,
+		uid:      creds.FilesystemKUID,
+		gid:      creds.FilesystemKGID,
 	}
 	if modeStr, ok := mopts["mode"]; ok {
 		delete(mopts, "mode")
@@ -310,7 +310,12 @@ func (fs *filesystem) newInode(ctx context.Context, out linux.FUSEEntryOut) (ker
 	i.attrMu.Lock()
 	defer i.attrMu.Unlock()
 
-	creds := auth.Credentials{EffectiveKGID: auth.KGID(attr.UID), EffectiveKUID: auth.KUID(attr.UID)}
+	creds := auth.Credentials{
+		EffectiveKGID:  auth.KGID(attr.UID),
+		EffectiveKUID:  auth.KUID(attr.UID),
+		FilesystemKGID: auth.KGID(attr.UID),
+		FilesystemKUID: auth.KUID(attr.UID),
+	}
 	i.init(&creds, linux.UNNAMED_MAJOR, fs.devMinor, out.NodeID, linux.FileMode(attr.Mode), attr.Nlink)
 	i.updateAttrs(attr, int64(out.AttrValid), int64(out.AttrValidNSec))
 	i.updateEntryTime(int64(out.EntryValid), int64(out.EntryValidNSec))
@@ -456,8 +456,8 @@ func (d *dentry) connect(ctx context.Context, sockType linux.SockType) (int, err
 	euid := lisafs.NoUID
 	egid := lisafs.NoGID
 	if creds != nil {
-		euid = lisafs.UID(creds.EffectiveKUID)
-		egid = lisafs.GID(creds.EffectiveKGID)
+		euid = lisafs.UID(creds.FilesystemKUID)
+		egid = lisafs.GID(creds.FilesystemKGID)
 	}
 	switch dt := d.impl.(type) {
 	case *lisafsDentry:
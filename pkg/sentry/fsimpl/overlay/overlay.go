@@ -881,8 +881,8 @@ func (d *dentry) mayDelete(creds *auth.Credentials, child *dentry) error {
 func (d *dentry) newChildOwnerStat(mode linux.FileMode, creds *auth.Credentials) linux.Statx {
 	stat := linux.Statx{
 		Mask: uint32(linux.STATX_UID | linux.STATX_GID),
-		UID:  uint32(creds.EffectiveKUID),
-		GID:  uint32(creds.EffectiveKGID),
+		UID:  uint32(creds.FilesystemKUID),
+		GID:  uint32(creds.FilesystemKGID),
 	}
 	// Set GID and possibly the SGID bit if the parent is an SGID directory.
 	d.copyMu.RLock()
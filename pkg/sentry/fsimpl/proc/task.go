@@ -93,6 +93,7 @@ func (fs *filesystem) newTaskInode(ctx context.Context, task *kernel.Task, pidns
 		"uid_map":       fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0644, &idMapData{task: task, gids: false}),
 	}
 	if isThreadGroup {
+		contents["coredump_filter"] = fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0644, &coreDumpFilter{task: task})
 		contents["task"] = fs.newSubtasks(ctx, task, pidns, fakeCgroupControllers)
 	} else {
 		contents["children"] = fs.newTaskOwnedInode(ctx, task, fs.NextIno(), 0644, &childrenData{task: task, pidns: pidns})
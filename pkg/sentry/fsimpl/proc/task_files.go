@@ -846,9 +846,11 @@ func (s *statusFD) Generate(ctx context.Context, buf *bytes.Buffer) error {
 	ruid := creds.RealKUID.In(s.userns).OrOverflow()
 	euid := creds.EffectiveKUID.In(s.userns).OrOverflow()
 	suid := creds.SavedKUID.In(s.userns).OrOverflow()
+	fsuid := creds.FilesystemKUID.In(s.userns).OrOverflow()
 	rgid := creds.RealKGID.In(s.userns).OrOverflow()
 	egid := creds.EffectiveKGID.In(s.userns).OrOverflow()
 	sgid := creds.SavedKGID.In(s.userns).OrOverflow()
+	fsgid := creds.FilesystemKGID.In(s.userns).OrOverflow()
 	var fds int
 	var vss, rss, data uint64
 	s.task.WithMuLocked(func(t *kernel.Task) {
@@ -861,10 +863,8 @@ func (s *statusFD) Generate(ctx context.Context, buf *bytes.Buffer) error {
 		rss = mm.ResidentSetSize()
 		data = mm.VirtualDataSize()
 	}
-	// Filesystem user/group IDs aren't implemented; effective UID/GID are used
-	// instead.
-	fmt.Fprintf(buf, "Uid:\t%d\t%d\t%d\t%d\n", ruid, euid, suid, euid)
-	fmt.Fprintf(buf, "Gid:\t%d\t%d\t%d\t%d\n", rgid, egid, sgid, egid)
+	fmt.Fprintf(buf, "Uid:\t%d\t%d\t%d\t%d\n", ruid, euid, suid, fsuid)
+	fmt.Fprintf(buf, "Gid:\t%d\t%d\t%d\t%d\n", rgid, egid, sgid, fsgid)
 	fmt.Fprintf(buf, "FDSize:\t%d\n", fds)
 	buf.WriteString("Groups:\t")
 	// There is a space between each pair of supplemental GIDs, as well as an
@@ -973,6 +973,58 @@ func (o *oomScoreAdj) Write(ctx context.Context, _ *vfs.FileDescription, src use
 	return src.NumBytes(), nil
 }
 
+// coreDumpFilter implements the /proc/[pid]/coredump_filter file.
+//
+// +stateify savable
+type coreDumpFilter struct {
+	kernfs.DynamicBytesFile
+
+	task *kernel.Task
+}
+
+var _ vfs.WritableDynamicBytesSource = (*coreDumpFilter)(nil)
+
+// Generate implements vfs.DynamicBytesSource.Generate.
+func (c *coreDumpFilter) Generate(ctx context.Context, buf *bytes.Buffer) error {
+	m, err := getMMIncRef(c.task)
+	if err != nil {
+		return linuxerr.ESRCH
+	}
+	defer m.DecUsers(ctx)
+	fmt.Fprintf(buf, "%08x\n", m.CoreDumpFilter())
+	return nil
+}
+
+// Write implements vfs.WritableDynamicBytesSource.Write.
+func (c *coreDumpFilter) Write(ctx context.Context, _ *vfs.FileDescription, src usermem.IOSequence, offset int64) (int64, error) {
+	if src.NumBytes() == 0 {
+		return 0, nil
+	}
+
+	// Limit input size so as not to impact performance if input size is large.
+	src = src.TakeFirst(hostarch.PageSize - 1)
+
+	str, err := usermem.CopyStringIn(ctx, src.IO, src.Addrs.Head().Start, int(src.Addrs.Head().Length()), src.Opts)
+	if err != nil && err != linuxerr.ENAMETOOLONG {
+		return 0, err
+	}
+
+	str = strings.TrimSpace(str)
+	v, err := strconv.ParseUint(str, 0, 32)
+	if err != nil {
+		return 0, linuxerr.EINVAL
+	}
+
+	m, err := getMMIncRef(c.task)
+	if err != nil {
+		return 0, linuxerr.ESRCH
+	}
+	defer m.DecUsers(ctx)
+	m.SetCoreDumpFilter(uint32(v))
+
+	return src.NumBytes(), nil
+}
+
 // exeSymlink is an symlink for the /proc/[pid]/exe file.
 //
 // +stateify savable
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"strings"
 
 	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/atomicbitops"
@@ -49,6 +50,7 @@ func (fs *filesystem) newSysDir(ctx context.Context, root *auth.Credentials, k *
 	return fs.newStaticDir(ctx, root, map[string]kernfs.Inode{
 		"kernel": fs.newStaticDir(ctx, root, map[string]kernfs.Inode{
 			"cap_last_cap": fs.newInode(ctx, root, 0444, newStaticFile(fmt.Sprintf("%d\n", linux.CAP_LAST_CAP))),
+			"core_pattern": fs.newInode(ctx, root, 0644, &corePatternData{k: k}),
 			"hostname":     fs.newInode(ctx, root, 0444, &hostnameData{}),
 			"overflowgid":  fs.newInode(ctx, root, 0444, newStaticFile(fmt.Sprintf("%d\n", auth.OverflowGID))),
 			"overflowuid":  fs.newInode(ctx, root, 0444, newStaticFile(fmt.Sprintf("%d\n", auth.OverflowUID))),
@@ -90,6 +92,7 @@ func (fs *filesystem) newSysNetDir(ctx context.Context, root *auth.Credentials,
 			"ipv4": fs.newStaticDir(ctx, root, map[string]kernfs.Inode{
 				"ip_forward":          fs.newInode(ctx, root, 0444, &ipForwarding{stack: stack}),
 				"ip_local_port_range": fs.newInode(ctx, root, 0644, &portRange{stack: stack}),
+				"tcp_fastopen":        fs.newInode(ctx, root, 0644, &tcpFastOpenData{stack: stack}),
 				"tcp_recovery":        fs.newInode(ctx, root, 0644, &tcpRecoveryData{stack: stack}),
 				"tcp_rmem":            fs.newInode(ctx, root, 0644, &tcpMemData{stack: stack, dir: tcpRMem}),
 				"tcp_sack":            fs.newInode(ctx, root, 0644, &tcpSackData{stack: stack}),
@@ -117,7 +120,6 @@ func (fs *filesystem) newSysNetDir(ctx context.Context, root *auth.Credentials,
 				"tcp_dsack":                 fs.newInode(ctx, root, 0444, newStaticFile("0")),
 				"tcp_early_retrans":         fs.newInode(ctx, root, 0444, newStaticFile("0")),
 				"tcp_fack":                  fs.newInode(ctx, root, 0444, newStaticFile("0")),
-				"tcp_fastopen":              fs.newInode(ctx, root, 0444, newStaticFile("0")),
 				"tcp_fastopen_key":          fs.newInode(ctx, root, 0444, newStaticFile("")),
 				"tcp_invalid_ratelimit":     fs.newInode(ctx, root, 0444, newStaticFile("0")),
 				"tcp_keepalive_intvl":       fs.newInode(ctx, root, 0444, newStaticFile("0")),
@@ -188,6 +190,52 @@ func (*hostnameData) Generate(ctx context.Context, buf *bytes.Buffer) error {
 	return nil
 }
 
+// corePatternData implements vfs.WritableDynamicBytesSource for
+// /proc/sys/kernel/core_pattern.
+//
+// +stateify savable
+type corePatternData struct {
+	kernfs.DynamicBytesFile
+
+	k *kernel.Kernel
+}
+
+var _ vfs.WritableDynamicBytesSource = (*corePatternData)(nil)
+
+// Generate implements vfs.DynamicBytesSource.Generate.
+func (d *corePatternData) Generate(ctx context.Context, buf *bytes.Buffer) error {
+	buf.WriteString(d.k.CorePattern())
+	buf.WriteString("\n")
+	return nil
+}
+
+// Write implements vfs.WritableDynamicBytesSource.Write.
+func (d *corePatternData) Write(ctx context.Context, _ *vfs.FileDescription, src usermem.IOSequence, offset int64) (int64, error) {
+	if offset != 0 {
+		// No need to handle partial writes thus far.
+		return 0, linuxerr.EINVAL
+	}
+	if src.NumBytes() == 0 {
+		return 0, nil
+	}
+
+	// Limit input size; longer patterns are truncated, as in Linux.
+	src = src.TakeFirst(hostarch.PageSize - 1)
+	buf := make([]byte, src.NumBytes())
+	n, err := src.CopyIn(ctx, buf)
+	if err != nil {
+		return 0, err
+	}
+	// As for Linux's proc_dostring(), the pattern ends at the first newline
+	// or NUL.
+	pattern := string(buf[:n])
+	if i := strings.IndexAny(pattern, "\n\x00"); i >= 0 {
+		pattern = pattern[:i]
+	}
+	d.k.SetCorePattern(pattern)
+	return int64(n), nil
+}
+
 // tcpSackData implements vfs.WritableDynamicBytesSource for
 // /proc/sys/net/tcp_sack.
 //
@@ -280,6 +328,46 @@ func (d *tcpRecoveryData) Write(ctx context.Context, _ *vfs.FileDescription, src
 	return n, nil
 }
 
+// tcpFastOpenData implements vfs.WritableDynamicBytesSource for
+// /proc/sys/net/ipv4/tcp_fastopen.
+//
+// +stateify savable
+type tcpFastOpenData struct {
+	kernfs.DynamicBytesFile
+
+	stack inet.Stack `state:"wait"`
+}
+
+var _ vfs.WritableDynamicBytesSource = (*tcpFastOpenData)(nil)
+
+// Generate implements vfs.DynamicBytesSource.Generate.
+func (d *tcpFastOpenData) Generate(ctx context.Context, buf *bytes.Buffer) error {
+	mode, err := d.stack.TCPFastOpen()
+	if err != nil {
+		return err
+	}
+
+	_, err = buf.WriteString(fmt.Sprintf("%d\n", mode))
+	return err
+}
+
+// Write implements vfs.WritableDynamicBytesSource.Write.
+func (d *tcpFastOpenData) Write(ctx context.Context, _ *vfs.FileDescription, src usermem.IOSequence, offset int64) (int64, error) {
+	if offset != 0 {
+		// No need to handle partial writes thus far.
+		return 0, linuxerr.EINVAL
+	}
+	buf := make([]int32, 1)
+	n, err := ParseInt32Vec(ctx, src, buf)
+	if err != nil || n == 0 {
+		return 0, err
+	}
+	if err := d.stack.SetTCPFastOpen(buf[0]); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
 // tcpMemData implements vfs.WritableDynamicBytesSource for
 // /proc/sys/net/ipv4/tcp_rmem and /proc/sys/net/ipv4/tcp_wmem.
 //
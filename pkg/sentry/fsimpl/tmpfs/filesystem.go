@@ -304,7 +304,7 @@ func (fs *filesystem) MkdirAt(ctx context.Context, rp *vfs.ResolvingPath, opts v
 			return linuxerr.EMLINK
 		}
 		parentDir.inode.incLinksLocked() // from child's ".."
-		childDir := fs.newDirectory(creds.EffectiveKUID, creds.EffectiveKGID, opts.Mode, parentDir)
+		childDir := fs.newDirectory(creds.FilesystemKUID, creds.FilesystemKGID, opts.Mode, parentDir)
 		parentDir.insertChildLocked(&childDir.dentry, name)
 		return nil
 	})
@@ -317,13 +317,13 @@ func (fs *filesystem) MknodAt(ctx context.Context, rp *vfs.ResolvingPath, opts v
 		var childInode *inode
 		switch opts.Mode.FileType() {
 		case linux.S_IFREG:
-			childInode = fs.newRegularFile(creds.EffectiveKUID, creds.EffectiveKGID, opts.Mode, parentDir)
+			childInode = fs.newRegularFile(creds.FilesystemKUID, creds.FilesystemKGID, opts.Mode, parentDir)
 		case linux.S_IFIFO:
-			childInode = fs.newNamedPipe(creds.EffectiveKUID, creds.EffectiveKGID, opts.Mode, parentDir)
+			childInode = fs.newNamedPipe(creds.FilesystemKUID, creds.FilesystemKGID, opts.Mode, parentDir)
 		case linux.S_IFBLK, linux.S_IFCHR:
-			childInode = fs.newDeviceFileLocked(creds.EffectiveKUID, creds.EffectiveKGID, opts.Mode, opts.DevMajor, opts.DevMinor, parentDir)
+			childInode = fs.newDeviceFileLocked(creds.FilesystemKUID, creds.FilesystemKGID, opts.Mode, opts.DevMajor, opts.DevMinor, parentDir)
 		case linux.S_IFSOCK:
-			childInode = fs.newSocketFile(creds.EffectiveKUID, creds.EffectiveKGID, opts.Mode, opts.Endpoint, parentDir)
+			childInode = fs.newSocketFile(creds.FilesystemKUID, creds.FilesystemKGID, opts.Mode, opts.Endpoint, parentDir)
 		default:
 			return linuxerr.EINVAL
 		}
@@ -418,7 +418,7 @@ afterTrailingSymlink:
 		defer rp.Mount().EndWrite()
 		// Create and open the child.
 		creds := rp.Credentials()
-		child := fs.newDentry(fs.newRegularFile(creds.EffectiveKUID, creds.EffectiveKGID, opts.Mode, parentDir))
+		child := fs.newDentry(fs.newRegularFile(creds.FilesystemKUID, creds.FilesystemKGID, opts.Mode, parentDir))
 		parentDir.insertChildLocked(child, name)
 		child.IncRef()
 		defer child.DecRef(ctx)
@@ -794,7 +794,7 @@ func (fs *filesystem) SymlinkAt(ctx context.Context, rp *vfs.ResolvingPath, targ
 			}
 		}
 		creds := rp.Credentials()
-		child := fs.newDentry(fs.newSymlink(creds.EffectiveKUID, creds.EffectiveKGID, 0777, target, parentDir))
+		child := fs.newDentry(fs.newSymlink(creds.FilesystemKUID, creds.FilesystemKGID, 0777, target, parentDir))
 		parentDir.insertChildLocked(child, name)
 		return nil
 	})
@@ -229,7 +229,7 @@ func (fstype FilesystemType) GetFilesystem(ctx context.Context, vfsObj *vfs.Virt
 		}
 		rootMode = linux.FileMode(mode & 07777)
 	}
-	rootKUID := creds.EffectiveKUID
+	rootKUID := creds.FilesystemKUID
 	uidStr, ok := mopts["uid"]
 	if ok {
 		delete(mopts, "uid")
@@ -245,7 +245,7 @@ func (fstype FilesystemType) GetFilesystem(ctx context.Context, vfsObj *vfs.Virt
 		}
 		rootKUID = kuid
 	}
-	rootKGID := creds.EffectiveKGID
+	rootKGID := creds.FilesystemKGID
 	gidStr, ok := mopts["gid"]
 	if ok {
 		delete(mopts, "gid")
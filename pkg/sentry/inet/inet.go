@@ -42,7 +42,9 @@ type Stack interface {
 	// idx.
 	AddInterfaceAddr(idx int32, addr InterfaceAddr) error
 
-	// SetInterface modifies or adds a new interface.
+	// SetInterface modifies or adds a new interface. It also handles
+	// RTM_DELLINK requests of the AF_BRIDGE family, which remove the VLANs
+	// of bridges.
 	SetInterface(ctx context.Context, msg *nlmsg.Message) *syserr.Error
 
 	// RemoveInterfaceAddr removes an address from the network interface
@@ -79,6 +81,13 @@ type Stack interface {
 	// SetTCPRecovery attempts to change TCP loss detection algorithm.
 	SetTCPRecovery(recovery TCPLossRecovery) error
 
+	// TCPFastOpen returns the TCP Fast Open mode, a bitmask of the flags
+	// accepted by net.ipv4.tcp_fastopen.
+	TCPFastOpen() (int32, error)
+
+	// SetTCPFastOpen attempts to change the TCP Fast Open mode.
+	SetTCPFastOpen(mode int32) error
+
 	// Statistics reports stack statistics.
 	Statistics(stat any, arg string) error
 
@@ -91,6 +100,30 @@ type Stack interface {
 	// NewRoute adds the given route to the network stack's route table.
 	NewRoute(ctx context.Context, msg *nlmsg.Message) *syserr.Error
 
+	// QDiscs returns the root queueing disciplines of the network
+	// interfaces.
+	QDiscs() []TrafficControl
+
+	// NewQDisc handles RTM_NEWQDISC requests, which add or change the root
+	// queueing discipline of a network interface.
+	NewQDisc(ctx context.Context, msg *nlmsg.Message) *syserr.Error
+
+	// RemoveQDisc handles RTM_DELQDISC requests, which restore the default
+	// queueing discipline of a network interface.
+	RemoveQDisc(ctx context.Context, msg *nlmsg.Message) *syserr.Error
+
+	// TrafficClasses returns the classes of the queueing disciplines of the
+	// network interface identified by idx, or of all interfaces if idx is
+	// zero.
+	TrafficClasses(idx int32) []TrafficControl
+
+	// NewTrafficClass handles RTM_NEWTCLASS requests, which add or change
+	// a class of a queueing discipline.
+	NewTrafficClass(ctx context.Context, msg *nlmsg.Message) *syserr.Error
+
+	// RemoveTrafficClass handles RTM_DELTCLASS requests.
+	RemoveTrafficClass(ctx context.Context, msg *nlmsg.Message) *syserr.Error
+
 	// Pause pauses the network stack before save.
 	Pause()
 
@@ -160,6 +193,10 @@ type Interface struct {
 	// MTU is the maximum transmission unit.
 	MTU uint32
 
+	// Kind is the kind of a virtual device, such as "wireguard", which is
+	// reported as IFLA_INFO_KIND. It is empty for other devices.
+	Kind string
+
 	// Features are the device features queried from the host at
 	// stack creation time. These are immutable after startup.
 	Features []linux.EthtoolGetFeaturesBlock
@@ -240,6 +277,41 @@ type Route struct {
 	GatewayAddr []byte
 }
 
+// TrafficControl describes a queueing discipline or one of its classes.
+type TrafficControl struct {
+	// Index is the index of the network interface.
+	Index int32
+
+	// Handle is the handle of the queueing discipline or the ID of the
+	// class.
+	Handle uint32
+
+	// Parent is the handle of the parent, a Linux TC_H_* constant for root
+	// queueing disciplines.
+	Parent uint32
+
+	// Kind is the name of the queueing discipline (TCA_KIND).
+	Kind string
+
+	// Options are the serialized attributes specific to the kind
+	// (TCA_OPTIONS).
+	Options []byte
+
+	// Stats are the statistics of the queueing discipline or the class.
+	Stats TrafficControlStats
+}
+
+// TrafficControlStats contains the statistics of a queueing discipline or a
+// class (TCA_STATS2).
+type TrafficControlStats struct {
+	Bytes      uint64
+	Packets    uint64
+	Drops      uint64
+	Overlimits uint64
+	Qlen       uint32
+	Backlog    uint32
+}
+
 // Below SNMP metrics are from Linux/usr/include/linux/snmp.h.
 
 // StatSNMPIP describes Ip line of /proc/net/snmp.
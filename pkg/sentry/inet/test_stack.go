@@ -38,6 +38,7 @@ type TestStack struct {
 	TCPSendBufSize    TCPBufferSize
 	TCPSACKFlag       bool
 	Recovery          TCPLossRecovery
+	FastOpen          int32
 	IPForwarding      bool
 }
 
@@ -149,6 +150,17 @@ func (s *TestStack) SetTCPRecovery(recovery TCPLossRecovery) error {
 	return nil
 }
 
+// TCPFastOpen implements Stack.
+func (s *TestStack) TCPFastOpen() (int32, error) {
+	return s.FastOpen, nil
+}
+
+// SetTCPFastOpen implements Stack.
+func (s *TestStack) SetTCPFastOpen(mode int32) error {
+	s.FastOpen = mode
+	return nil
+}
+
 // Statistics implements Stack.
 func (s *TestStack) Statistics(stat any, arg string) error {
 	return nil
@@ -169,6 +181,36 @@ func (s *TestStack) NewRoute(ctx context.Context, msg *nlmsg.Message) *syserr.Er
 	return syserr.ErrNotPermitted
 }
 
+// QDiscs implements Stack.
+func (s *TestStack) QDiscs() []TrafficControl {
+	return nil
+}
+
+// NewQDisc implements Stack.
+func (s *TestStack) NewQDisc(ctx context.Context, msg *nlmsg.Message) *syserr.Error {
+	return syserr.ErrNotPermitted
+}
+
+// RemoveQDisc implements Stack.
+func (s *TestStack) RemoveQDisc(ctx context.Context, msg *nlmsg.Message) *syserr.Error {
+	return syserr.ErrNotPermitted
+}
+
+// TrafficClasses implements Stack.
+func (s *TestStack) TrafficClasses(idx int32) []TrafficControl {
+	return nil
+}
+
+// NewTrafficClass implements Stack.
+func (s *TestStack) NewTrafficClass(ctx context.Context, msg *nlmsg.Message) *syserr.Error {
+	return syserr.ErrNotPermitted
+}
+
+// RemoveTrafficClass implements Stack.
+func (s *TestStack) RemoveTrafficClass(ctx context.Context, msg *nlmsg.Message) *syserr.Error {
+	return syserr.ErrNotPermitted
+}
+
 // Pause implements Stack.
 func (s *TestStack) Pause() {}
 
@@ -39,6 +39,20 @@ type VfsCapData struct {
 // AllCapabilities is a CapabilitySet containing all valid capabilities.
 var AllCapabilities = CapabilitySetOf(linux.CAP_LAST_CAP+1) - 1
 
+// FilesystemCapabilities is the set of capabilities that are dropped from, or
+// restored to, the effective set when the filesystem user ID changes from or
+// to 0. It is equivalent to Linux's CAP_FS_SET.
+var FilesystemCapabilities = CapabilitySetOfMany([]linux.Capability{
+	linux.CAP_CHOWN,
+	linux.CAP_DAC_OVERRIDE,
+	linux.CAP_DAC_READ_SEARCH,
+	linux.CAP_FOWNER,
+	linux.CAP_FSETID,
+	linux.CAP_LINUX_IMMUTABLE,
+	linux.CAP_MAC_OVERRIDE,
+	linux.CAP_MKNOD,
+})
+
 // CapabilitySetOf returns a CapabilitySet containing only the given
 // capability.
 func CapabilitySetOf(cp linux.Capability) CapabilitySet {
@@ -35,9 +35,12 @@ type Credentials struct {
 	EffectiveKGID KGID
 	SavedKGID     KGID
 
-	// Filesystem user/group IDs are not implemented. "... setfsuid() is
-	// nowadays unneeded and should be avoided in new applications (likewise
-	// for setfsgid(2))." - setfsuid(2)
+	// Filesystem user/group IDs in the root user namespace, used for
+	// filesystem permission checks and as the owner of newly-created files.
+	// These normally track EffectiveKUID/EffectiveKGID, but may be changed
+	// independently by setfsuid(2)/setfsgid(2). Neither should ever be NoID.
+	FilesystemKUID KUID
+	FilesystemKGID KGID
 
 	// Supplementary groups used by set/getgroups.
 	//
@@ -70,13 +73,15 @@ func NewAnonymousCredentials() *Credentials {
 	// hierarchy, the returned credentials do not have any capabilities in any
 	// other namespace.
 	return &Credentials{
-		RealKUID:      NobodyKUID,
-		EffectiveKUID: NobodyKUID,
-		SavedKUID:     NobodyKUID,
-		RealKGID:      NobodyKGID,
-		EffectiveKGID: NobodyKGID,
-		SavedKGID:     NobodyKGID,
-		UserNamespace: NewRootUserNamespace(),
+		RealKUID:       NobodyKUID,
+		EffectiveKUID:  NobodyKUID,
+		SavedKUID:      NobodyKUID,
+		FilesystemKUID: NobodyKUID,
+		RealKGID:       NobodyKGID,
+		EffectiveKGID:  NobodyKGID,
+		SavedKGID:      NobodyKGID,
+		FilesystemKGID: NobodyKGID,
+		UserNamespace:  NewRootUserNamespace(),
 	}
 }
 
@@ -87,16 +92,18 @@ func NewRootCredentials(ns *UserNamespace) *Credentials {
 	// inheritable capability set to be initially empty (the capabilities test
 	// checks for this property).
 	return &Credentials{
-		RealKUID:      RootKUID,
-		EffectiveKUID: RootKUID,
-		SavedKUID:     RootKUID,
-		RealKGID:      RootKGID,
-		EffectiveKGID: RootKGID,
-		SavedKGID:     RootKGID,
-		PermittedCaps: AllCapabilities,
-		EffectiveCaps: AllCapabilities,
-		BoundingCaps:  AllCapabilities,
-		UserNamespace: ns,
+		RealKUID:       RootKUID,
+		EffectiveKUID:  RootKUID,
+		SavedKUID:      RootKUID,
+		FilesystemKUID: RootKUID,
+		RealKGID:       RootKGID,
+		EffectiveKGID:  RootKGID,
+		SavedKGID:      RootKGID,
+		FilesystemKGID: RootKGID,
+		PermittedCaps:  AllCapabilities,
+		EffectiveCaps:  AllCapabilities,
+		BoundingCaps:   AllCapabilities,
+		UserNamespace:  ns,
 	}
 }
 
@@ -111,12 +118,14 @@ func NewUserCredentials(kuid KUID, kgid KGID, extraKGIDs []KGID, capabilities *T
 	creds.RealKUID = uid
 	creds.EffectiveKUID = uid
 	creds.SavedKUID = uid
+	creds.FilesystemKUID = uid
 
 	// Set GID.
 	gid := kgid
 	creds.RealKGID = gid
 	creds.EffectiveKGID = gid
 	creds.SavedKGID = gid
+	creds.FilesystemKGID = gid
 
 	// Set additional GIDs.
 	creds.ExtraKGIDs = append(creds.ExtraKGIDs, extraKGIDs...)
@@ -155,7 +164,7 @@ func (c *Credentials) Fork() *Credentials {
 // InGroup returns true if c is in group kgid. Compare Linux's
 // kernel/groups.c:in_group_p().
 func (c *Credentials) InGroup(kgid KGID) bool {
-	if c.EffectiveKGID == kgid {
+	if c.FilesystemKGID == kgid {
 		return true
 	}
 	for _, extraKGID := range c.ExtraKGIDs {
@@ -246,6 +255,7 @@ func (c *Credentials) SetUID(uid UID) error {
 	c.RealKUID = kuid
 	c.EffectiveKUID = kuid
 	c.SavedKUID = kuid
+	c.FilesystemKUID = kuid
 	return nil
 }
 
@@ -260,9 +270,44 @@ func (c *Credentials) SetGID(gid GID) error {
 	c.RealKGID = kgid
 	c.EffectiveKGID = kgid
 	c.SavedKGID = kgid
+	c.FilesystemKGID = kgid
 	return nil
 }
 
+// UseFSUID checks that c can use uid as its filesystem user ID, then
+// translates it to the root user namespace. Compare Linux's
+// kernel/sys.c:__sys_setfsuid().
+func (c *Credentials) UseFSUID(uid UID) (KUID, error) {
+	kuid := c.UserNamespace.MapToKUID(uid)
+	if !kuid.Ok() {
+		return NoID, linuxerr.EINVAL
+	}
+	if kuid == c.RealKUID || kuid == c.EffectiveKUID || kuid == c.SavedKUID || kuid == c.FilesystemKUID {
+		return kuid, nil
+	}
+	if c.HasCapability(linux.CAP_SETUID) {
+		return kuid, nil
+	}
+	return NoID, linuxerr.EPERM
+}
+
+// UseFSGID checks that c can use gid as its filesystem group ID, then
+// translates it to the root user namespace. Compare Linux's
+// kernel/sys.c:__sys_setfsgid().
+func (c *Credentials) UseFSGID(gid GID) (KGID, error) {
+	kgid := c.UserNamespace.MapToKGID(gid)
+	if !kgid.Ok() {
+		return NoID, linuxerr.EINVAL
+	}
+	if kgid == c.RealKGID || kgid == c.EffectiveKGID || kgid == c.SavedKGID || kgid == c.FilesystemKGID {
+		return kgid, nil
+	}
+	if c.HasCapability(linux.CAP_SETGID) {
+		return kgid, nil
+	}
+	return NoID, linuxerr.EPERM
+}
+
 // LoadSeccheckData sets credential data based on mask.
 func (c *Credentials) LoadSeccheckData(mask seccheck.FieldMask, info *pb.ContextData) {
 	if mask.Contains(seccheck.FieldCtxtCredentials) {
@@ -0,0 +1,96 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+)
+
+func TestUseFSUID(t *testing.T) {
+	ns := NewRootUserNamespace()
+	unprivileged := NewUserCredentials(1000, 1000, nil, nil, ns)
+	unprivileged.SavedKUID = 1001
+	unprivileged.SavedKGID = 1001
+	for _, tc := range []struct {
+		name    string
+		creds   *Credentials
+		uid     UID
+		gid     GID
+		wantErr error
+	}{
+		{
+			name:  "root can use any ID",
+			creds: NewRootCredentials(ns),
+			uid:   1234,
+			gid:   1234,
+		},
+		{
+			name:  "unprivileged can use current ID",
+			creds: unprivileged,
+			uid:   1000,
+			gid:   1000,
+		},
+		{
+			name:  "unprivileged can use saved ID",
+			creds: unprivileged,
+			uid:   1001,
+			gid:   1001,
+		},
+		{
+			name:    "unprivileged cannot use other ID",
+			creds:   unprivileged,
+			uid:     1234,
+			gid:     1234,
+			wantErr: linuxerr.EPERM,
+		},
+		{
+			name:    "invalid ID",
+			creds:   NewRootCredentials(ns),
+			uid:     NoID,
+			gid:     NoID,
+			wantErr: linuxerr.EINVAL,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			kuid, err := tc.creds.UseFSUID(tc.uid)
+			if err != tc.wantErr {
+				t.Fatalf("UseFSUID(%d) returned error %v, want %v", tc.uid, err, tc.wantErr)
+			}
+			if err == nil && kuid != KUID(tc.uid) {
+				t.Errorf("UseFSUID(%d) = %d, want %d", tc.uid, kuid, tc.uid)
+			}
+			kgid, err := tc.creds.UseFSGID(tc.gid)
+			if err != tc.wantErr {
+				t.Fatalf("UseFSGID(%d) returned error %v, want %v", tc.gid, err, tc.wantErr)
+			}
+			if err == nil && kgid != KGID(tc.gid) {
+				t.Errorf("UseFSGID(%d) = %d, want %d", tc.gid, kgid, tc.gid)
+			}
+		})
+	}
+}
+
+func TestInGroupUsesFilesystemGID(t *testing.T) {
+	creds := NewUserCredentials(1000, 1000, nil, nil, NewRootUserNamespace())
+	creds.FilesystemKGID = 2000
+	if !creds.InGroup(2000) {
+		t.Errorf("InGroup(2000) = false, want true for fsgid 2000")
+	}
+	if creds.InGroup(1000) {
+		t.Errorf("InGroup(1000) = true, want false for fsgid 2000")
+	}
+}
@@ -644,9 +644,8 @@ func (m *Manager) WaitComplete(w *Waiter, t Target) {
 // calling task is set to 'addr' to indicate the futex is owned. It returns true
 // if the futex was successfully acquired.
 //
-// FUTEX_OWNER_DIED is only set by the Linux when robust lists are in use (see
-// exit_robust_list()). Given we don't support robust lists, although handled
-// below, it's never set.
+// FUTEX_OWNER_DIED is only set when robust lists are in use (see
+// HandleOwnerDeath), and is preserved when the futex is acquired.
 func (m *Manager) LockPI(w *Waiter, t Target, addr hostarch.Addr, tid uint32, private, try bool) (bool, error) {
 	k, err := getKey(t, addr, private)
 	if err != nil {
@@ -764,21 +763,7 @@ func (m *Manager) unlockPILocked(t Target, addr hostarch.Addr, tid uint32, b *bu
 		return linuxerr.EPERM
 	}
 
-	var next *Waiter  // Who's the next owner?
-	var next2 *Waiter // Who's the one after that?
-	for w := b.waiters.Front(); w != nil; w = w.Next() {
-		if !w.key.matches(key) {
-			continue
-		}
-
-		if next == nil {
-			next = w
-		} else {
-			next2 = w
-			break
-		}
-	}
-
+	next, next2 := nextPIWaitersLocked(b, key)
 	if next == nil {
 		// It's safe to set 0 because there are no waiters, no new owner, and the
 		// executing task is the current owner (no owner died bit).
@@ -812,3 +797,128 @@ func (m *Manager) unlockPILocked(t Target, addr hostarch.Addr, tid uint32, b *bu
 	b.wakeWaiterLocked(next)
 	return nil
 }
+
+// nextPIWaitersLocked returns the next owner of the PI futex represented by
+// key, and the waiter after that, either of which may be nil.
+//
+// Preconditions: b.mu must be locked.
+func nextPIWaitersLocked(b *bucket, key *Key) (next, next2 *Waiter) {
+	for w := b.waiters.Front(); w != nil; w = w.Next() {
+		if !w.key.matches(key) {
+			continue
+		}
+
+		if next == nil {
+			next = w
+		} else {
+			next2 = w
+			break
+		}
+	}
+	return next, next2
+}
+
+// HandleOwnerDeath releases the robust futex at addr on behalf of the exiting
+// task tid, setting FUTEX_OWNER_DIED so that the next owner can recover the
+// state protected by the lock. pi indicates that the futex is a PI futex, and
+// pendingOp indicates that addr is the robust list's pending-op entry, which
+// the exiting task may have been in the middle of acquiring or releasing.
+//
+// It corresponds to Linux's kernel/futex/core.c:handle_futex_death(), combined
+// with the PI owner handoff of exit_pi_state_list().
+func (m *Manager) HandleOwnerDeath(t Target, addr hostarch.Addr, tid uint32, pi, pendingOp bool) error {
+	addr = hostarch.UntaggedUserAddr(addr)
+	if addr&0x3 != 0 {
+		return linuxerr.EINVAL
+	}
+
+	for {
+		cur, err := t.LoadUint32(addr)
+		if err != nil {
+			return err
+		}
+
+		// The task may have died after releasing the futex (setting it to 0)
+		// but before waking a waiter, which would otherwise sleep forever. As
+		// in Linux, wake a waiter unconditionally; it will retry the lock.
+		if pendingOp && !pi && cur == 0 {
+			_, err := m.Wake(t, addr, false /* private */, linux.FUTEX_BITSET_MATCH_ANY, 1)
+			return err
+		}
+
+		// Is this held by someone else?
+		if cur&linux.FUTEX_TID_MASK != tid {
+			return nil
+		}
+
+		// A PI futex with waiters is handed off directly to the next waiter,
+		// which observes FUTEX_OWNER_DIED when its FUTEX_LOCK_PI returns.
+		if pi && cur&linux.FUTEX_WAITERS != 0 {
+			done, err := m.handOffPIOwnerDied(t, addr, tid, cur)
+			if err != nil {
+				return err
+			}
+			if done {
+				return nil
+			}
+		}
+
+		// Set the owner died bit, preserving the waiters bit so that the next
+		// unlock wakes any remaining waiters.
+		newVal := (cur & linux.FUTEX_WAITERS) | linux.FUTEX_OWNER_DIED
+		prev, err := t.CompareAndSwapUint32(addr, cur, newVal)
+		if err != nil {
+			return err
+		}
+		if prev != cur {
+			// Futex changed out from under us. Try again...
+			continue
+		}
+
+		// Robust futexes are always woken as shared futexes, since their
+		// memory may be shared with other processes; userspace is expected
+		// to wait on them accordingly.
+		if !pi && cur&linux.FUTEX_WAITERS != 0 {
+			_, err := m.Wake(t, addr, false /* private */, linux.FUTEX_BITSET_MATCH_ANY, 1)
+			return err
+		}
+		return nil
+	}
+}
+
+// handOffPIOwnerDied transfers ownership of the PI futex at addr, whose
+// current value is cur, from the dead task tid to the next waiter. It returns
+// true if ownership was transferred, and false if there were no waiters or the
+// futex value changed concurrently.
+func (m *Manager) handOffPIOwnerDied(t Target, addr hostarch.Addr, tid, cur uint32) (bool, error) {
+	// PI waiters may have used either private or shared futex operations.
+	for _, private := range []bool{false, true} {
+		k, err := getKey(t, addr, private)
+		if err != nil {
+			return false, err
+		}
+		b := m.lockBucket(&k)
+		next, next2 := nextPIWaitersLocked(b, &k)
+		if next == nil {
+			k.release(t)
+			b.mu.Unlock()
+			continue
+		}
+
+		val := next.tid | linux.FUTEX_OWNER_DIED
+		if next2 != nil {
+			val |= linux.FUTEX_WAITERS
+		}
+		prev, err := t.CompareAndSwapUint32(addr, cur, val)
+		if err == nil && prev == cur {
+			b.wakeWaiterLocked(next)
+		}
+		k.release(t)
+		b.mu.Unlock()
+		if err != nil {
+			return false, err
+		}
+		return prev == cur, nil
+	}
+	return false, nil
+}
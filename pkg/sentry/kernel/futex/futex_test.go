@@ -20,6 +20,7 @@ import (
 	"testing"
 	"unsafe"
 
+	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/atomicbitops"
 	"gvisor.dev/gvisor/pkg/context"
 	"gvisor.dev/gvisor/pkg/errors/linuxerr"
@@ -452,6 +453,114 @@ func TestWakeOpSameAddressFailingOp(t *testing.T) {
 	}
 }
 
+func (t testData) storeUint32(addr hostarch.Addr, val uint32) {
+	(*atomicbitops.Uint32)(unsafe.Pointer(&t.data[addr])).Store(val)
+}
+
+func TestHandleOwnerDeath(t *testing.T) {
+	const (
+		deadTID  = 10
+		otherTID = 11
+	)
+	for _, tc := range []struct {
+		name      string
+		val       uint32
+		pendingOp bool
+		wantVal   uint32
+		wantWoken bool
+	}{
+		{
+			name:    "held by dead task",
+			val:     deadTID,
+			wantVal: linux.FUTEX_OWNER_DIED,
+		},
+		{
+			name:      "held by dead task with waiters",
+			val:       deadTID | linux.FUTEX_WAITERS,
+			wantVal:   linux.FUTEX_OWNER_DIED | linux.FUTEX_WAITERS,
+			wantWoken: true,
+		},
+		{
+			name:    "held by another task",
+			val:     otherTID | linux.FUTEX_WAITERS,
+			wantVal: otherTID | linux.FUTEX_WAITERS,
+		},
+		{
+			name:    "unlocked",
+			val:     0,
+			wantVal: 0,
+		},
+		{
+			name:      "unlocked pending op",
+			val:       0,
+			pendingOp: true,
+			wantVal:   0,
+			wantWoken: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewManager()
+			d := newTestData(sizeofInt32)
+			d.storeUint32(0, tc.val)
+
+			w := newPreparedTestWaiter(t, m, d, 0, false /* private */, tc.val, ^uint32(0))
+			defer m.WaitComplete(w, d)
+
+			if err := m.HandleOwnerDeath(d, 0, deadTID, false /* pi */, tc.pendingOp); err != nil {
+				t.Fatalf("HandleOwnerDeath failed: %v", err)
+			}
+			if got, _ := d.LoadUint32(0); got != tc.wantVal {
+				t.Errorf("futex value: got %#x, wanted %#x", got, tc.wantVal)
+			}
+			if got := w.woken(); got != tc.wantWoken {
+				t.Errorf("waiter woken: got %t, wanted %t", got, tc.wantWoken)
+			}
+		})
+	}
+}
+
+func TestHandleOwnerDeathPI(t *testing.T) {
+	const (
+		deadTID    = 10
+		waiterTID1 = 11
+		waiterTID2 = 12
+	)
+	for _, private := range []bool{false, true} {
+		t.Run(futexKind(private), func(t *testing.T) {
+			m := NewManager()
+			d := newTestData(sizeofInt32)
+			d.storeUint32(0, deadTID)
+
+			// Queue two PI waiters behind the dead owner.
+			var ws [2]*Waiter
+			for i, tid := range []uint32{waiterTID1, waiterTID2} {
+				ws[i] = NewWaiter()
+				if ok, err := m.LockPI(ws[i], d, 0, tid, private, false /* try */); err != nil || ok {
+					t.Fatalf("LockPI: got (%t, %v), wanted (false, nil)", ok, err)
+				}
+				defer m.WaitComplete(ws[i], d)
+			}
+
+			if err := m.HandleOwnerDeath(d, 0, deadTID, true /* pi */, false /* pendingOp */); err != nil {
+				t.Fatalf("HandleOwnerDeath failed: %v", err)
+			}
+
+			// Ownership passes to the first waiter, which must see that the
+			// previous owner died.
+			want := uint32(waiterTID1) | linux.FUTEX_OWNER_DIED | linux.FUTEX_WAITERS
+			if got, _ := d.LoadUint32(0); got != want {
+				t.Errorf("futex value: got %#x, wanted %#x", got, want)
+			}
+			if !ws[0].woken() {
+				t.Error("first waiter not woken")
+			}
+			if ws[1].woken() {
+				t.Error("second waiter woken")
+			}
+		})
+	}
+}
+
 const (
 	testMutexSize            = sizeofInt32
 	testMutexLocked   uint32 = 1
@@ -143,6 +143,19 @@ func (t *Task) IPCNamespace() *IPCNamespace {
 	return t.ipcns
 }
 
+// SemUndoList returns the task's System V semaphore undo list, creating it if
+// needed.
+//
+// Preconditions: The caller must be running on the task goroutine.
+func (t *Task) SemUndoList() *semaphore.UndoList {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.semUndos == nil {
+		t.semUndos = semaphore.NewUndoList()
+	}
+	return t.semUndos
+}
+
 // GetIPCNamespace takes a reference on the task IPC namespace and
 // returns it. It will return nil if the task isn't alive.
 func (t *Task) GetIPCNamespace() *IPCNamespace {
@@ -318,6 +318,17 @@ type Kernel struct {
 	// YAMAPtraceScope is the current level of YAMA ptrace restrictions.
 	YAMAPtraceScope atomicbitops.Int32
 
+	// coreDumpMu protects corePattern and coreDumpHostDir.
+	coreDumpMu sync.Mutex `state:"nosave"`
+
+	// corePattern is the template used to name core dumps, as in
+	// /proc/sys/kernel/core_pattern.
+	corePattern string
+
+	// If coreDumpHostDir is not nil, it is a host directory to which core
+	// dumps are written instead of the sandbox filesystem.
+	coreDumpHostDir *fd.FD `state:"nosave"`
+
 	// cgroupRegistry contains the set of active cgroup controllers on the
 	// system. It is controller by cgroupfs. Nil if cgroupfs is unavailable on
 	// the system.
@@ -478,6 +489,7 @@ func (k *Kernel) Init(args InitKernelArgs) error {
 	k.netlinkPorts = port.New()
 	k.ptraceExceptions = make(map[*Task]*Task)
 	k.YAMAPtraceScope = atomicbitops.FromInt32(linux.YAMA_SCOPE_RELATIONAL)
+	k.corePattern = defaultCorePattern
 	k.userCountersMap = make(map[auth.KUID]*UserCounters)
 	if args.MaxFDLimit == 0 {
 		args.MaxFDLimit = MaxFdLimit
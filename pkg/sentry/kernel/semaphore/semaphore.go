@@ -25,7 +25,6 @@ import (
 	"gvisor.dev/gvisor/pkg/sentry/kernel/ipc"
 	"gvisor.dev/gvisor/pkg/sentry/ktime"
 	"gvisor.dev/gvisor/pkg/sentry/vfs"
-	"gvisor.dev/gvisor/pkg/sync"
 )
 
 const (
@@ -47,7 +46,7 @@ const (
 // +stateify savable
 type Registry struct {
 	// mu protects all fields below.
-	mu sync.Mutex `state:"nosave"`
+	mu registryMutex `state:"nosave"`
 
 	// reg defines basic fields and operations needed for all SysV registries.
 	reg *ipc.Registry
@@ -65,7 +64,7 @@ type Set struct {
 	registry *Registry
 
 	// mu protects all fields below.
-	mu sync.Mutex `state:"nosave"`
+	mu setMutex `state:"nosave"`
 
 	obj *ipc.Object
 
@@ -79,6 +78,9 @@ type Set struct {
 	// dead is set to true when the set is removed and can't be reached anymore.
 	// All waiters must wake up and fail when set is dead.
 	dead bool
+
+	// undos holds the SEM_UNDO adjustments made to the set by each UndoList.
+	undos []*undo
 }
 
 // sem represents a single semaphore from a set.
@@ -403,7 +405,9 @@ func (s *Set) SetVal(ctx context.Context, num int32, val int16, creds *auth.Cred
 		return linuxerr.ERANGE
 	}
 
-	// TODO(gvisor.dev/issue/137): Clear undo entries in all processes.
+	// "Undo entries are cleared for altered semaphores in all processes." -
+	// semctl(2)
+	s.clearUndosLocked(num)
 	sem.value = val
 	sem.pid = pid
 	s.changeTime = ktime.NowFromContext(ctx)
@@ -434,10 +438,9 @@ func (s *Set) SetValAll(ctx context.Context, vals []uint16, creds *auth.Credenti
 		return linuxerr.EACCES
 	}
 
+	s.clearUndosLocked(-1)
 	for i, val := range vals {
 		sem := &s.sems[i]
-
-		// TODO(gvisor.dev/issue/137): Clear undo entries in all processes.
 		sem.value = int16(val)
 		sem.pid = pid
 		sem.wakeWaiters()
@@ -519,14 +522,16 @@ func (s *Set) countWaiters(num int32, creds *auth.Credentials, pred func(w *wait
 	return cnt, nil
 }
 
-// CountZeroWaiters returns number of waiters waiting for the sem's value to increase.
+// CountZeroWaiters returns number of waiters waiting for the sem to go to zero.
+// See semctl(GETZCNT).
 func (s *Set) CountZeroWaiters(num int32, creds *auth.Credentials) (uint16, error) {
 	return s.countWaiters(num, creds, func(w *waiter) bool {
 		return w.value == 0
 	})
 }
 
-// CountNegativeWaiters returns number of waiters waiting for the sem to go to zero.
+// CountNegativeWaiters returns number of waiters waiting for the sem's value to
+// increase. See semctl(GETNCNT).
 func (s *Set) CountNegativeWaiters(num int32, creds *auth.Credentials) (uint16, error) {
 	return s.countWaiters(num, creds, func(w *waiter) bool {
 		return w.value < 0
@@ -538,7 +543,10 @@ func (s *Set) CountNegativeWaiters(num int32, creds *auth.Credentials) (uint16,
 //
 // On failure, it may return an error (retries are hopeless) or it may return
 // a channel that can be waited on before attempting again.
-func (s *Set) ExecuteOps(ctx context.Context, ops []linux.Sembuf, creds *auth.Credentials, pid int32) (chan struct{}, int32, error) {
+//
+// undos records the adjustments made by operations with SEM_UNDO set. It may
+// be nil if no operation has SEM_UNDO set.
+func (s *Set) ExecuteOps(ctx context.Context, ops []linux.Sembuf, creds *auth.Credentials, pid int32, undos *UndoList) (chan struct{}, int32, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -549,6 +557,7 @@ func (s *Set) ExecuteOps(ctx context.Context, ops []linux.Sembuf, creds *auth.Cr
 
 	// Validate the operations.
 	readOnly := true
+	hasUndo := false
 	for _, op := range ops {
 		if s.findSem(int32(op.SemNum)) == nil {
 			return nil, 0, linuxerr.EFBIG
@@ -556,6 +565,9 @@ func (s *Set) ExecuteOps(ctx context.Context, ops []linux.Sembuf, creds *auth.Cr
 		if op.SemOp != 0 {
 			readOnly = false
 		}
+		if op.SemFlg&linux.SEM_UNDO != 0 {
+			hasUndo = true
+		}
 	}
 
 	ats := vfs.MayRead
@@ -566,19 +578,33 @@ func (s *Set) ExecuteOps(ctx context.Context, ops []linux.Sembuf, creds *auth.Cr
 		return nil, 0, linuxerr.EACCES
 	}
 
-	ch, num, err := s.executeOps(ctx, ops, pid)
+	var u *undo
+	if hasUndo {
+		if undos == nil {
+			panic("Set.ExecuteOps called with SEM_UNDO and no UndoList")
+		}
+		u = undos.get(s)
+	}
+
+	ch, num, err := s.executeOps(ctx, ops, pid, u)
 	if err != nil {
 		return nil, 0, err
 	}
 	return ch, num, nil
 }
 
-func (s *Set) executeOps(ctx context.Context, ops []linux.Sembuf, pid int32) (chan struct{}, int32, error) {
+func (s *Set) executeOps(ctx context.Context, ops []linux.Sembuf, pid int32, u *undo) (chan struct{}, int32, error) {
 	// Changes to semaphores go to this slice temporarily until they all succeed.
 	tmpVals := make([]int16, len(s.sems))
 	for i := range s.sems {
 		tmpVals[i] = s.sems[i].value
 	}
+	// Likewise for undo adjustments.
+	var tmpAdj []int16
+	if u != nil {
+		tmpAdj = make([]int16, len(u.adj))
+		copy(tmpAdj, u.adj)
+	}
 
 	for _, op := range ops {
 		sem := &s.sems[op.SemNum]
@@ -617,12 +643,22 @@ func (s *Set) executeOps(ctx context.Context, ops []linux.Sembuf, pid int32) (ch
 				}
 			}
 
+			if op.SemFlg&linux.SEM_UNDO != 0 {
+				adj, ok := adjustUndo(tmpAdj[op.SemNum], op.SemOp)
+				if !ok {
+					return nil, 0, linuxerr.ERANGE
+				}
+				tmpAdj[op.SemNum] = adj
+			}
+
 			tmpVals[op.SemNum] += op.SemOp
 		}
 	}
 
 	// All operations succeeded, apply them.
-	// TODO(gvisor.dev/issue/137): handle undo operations.
+	if u != nil {
+		copy(u.adj, tmpAdj)
+	}
 	for i, v := range tmpVals {
 		s.sems[i].value = v
 		s.sems[i].wakeWaiters()
@@ -655,6 +691,7 @@ func (s *Set) Destroy() {
 	// Notify all waiters. They will fail on the next attempt to execute
 	// operations and return error.
 	s.dead = true
+	s.destroyUndosLocked()
 	for _, s := range s.sems {
 		for w := s.waiters.Front(); w != nil; w = w.Next() {
 			w.ch <- struct{}{}
@@ -26,7 +26,7 @@ import (
 )
 
 func executeOps(ctx context.Context, t *testing.T, set *Set, ops []linux.Sembuf, block bool) chan struct{} {
-	ch, _, err := set.executeOps(ctx, ops, 123)
+	ch, _, err := set.executeOps(ctx, ops, 123, nil)
 	if err != nil {
 		t.Fatalf("ExecuteOps(ops) failed, err: %v, ops: %+v", err, ops)
 	}
@@ -124,13 +124,13 @@ func TestNoWait(t *testing.T) {
 
 	ops[0].SemOp = -2
 	ops[0].SemFlg = linux.IPC_NOWAIT
-	if _, _, err := set.executeOps(ctx, ops, 123); err != linuxerr.ErrWouldBlock {
+	if _, _, err := set.executeOps(ctx, ops, 123, nil); err != linuxerr.ErrWouldBlock {
 		t.Fatalf("ExecuteOps(ops) wrong result, got: %v, expected: %v", err, linuxerr.ErrWouldBlock)
 	}
 
 	ops[0].SemOp = 0
 	ops[0].SemFlg = linux.IPC_NOWAIT
-	if _, _, err := set.executeOps(ctx, ops, 123); err != linuxerr.ErrWouldBlock {
+	if _, _, err := set.executeOps(ctx, ops, 123, nil); err != linuxerr.ErrWouldBlock {
 		t.Fatalf("ExecuteOps(ops) wrong result, got: %v, expected: %v", err, linuxerr.ErrWouldBlock)
 	}
 }
@@ -172,3 +172,139 @@ func TestUnregister(t *testing.T) {
 		}
 	}
 }
+
+func executeUndoOps(ctx context.Context, t *testing.T, set *Set, l *UndoList, ops []linux.Sembuf) error {
+	set.mu.Lock()
+	defer set.mu.Unlock()
+	for i := range ops {
+		ops[i].SemFlg |= linux.SEM_UNDO
+	}
+	ch, _, err := set.executeOps(ctx, ops, 123, l.get(set))
+	if ch != nil {
+		t.Fatalf("ExecuteOps(ops) got: %v, expected: nil, ops: %+v", ch, ops)
+	}
+	return err
+}
+
+func TestUndo(t *testing.T) {
+	ctx := contexttest.Context(t)
+	set := &Set{obj: &ipc.Object{ID: 123}, sems: make([]sem, 2)}
+	set.sems[0].value = 1
+
+	l := NewUndoList()
+	ops := []linux.Sembuf{
+		{SemNum: 0, SemOp: -1},
+		{SemNum: 1, SemOp: 2},
+	}
+	if err := executeUndoOps(ctx, t, set, l, ops); err != nil {
+		t.Fatalf("ExecuteOps(ops) failed, err: %v, ops: %+v", err, ops)
+	}
+	if set.sems[0].value != 0 || set.sems[1].value != 2 {
+		t.Fatalf("wrong values after ops, got: [%d %d], expected: [0 2]", set.sems[0].value, set.sems[1].value)
+	}
+
+	// Adjustments are only applied once the last reference is dropped.
+	l.IncRef()
+	l.DecRef(456)
+	if set.sems[0].value != 0 || set.sems[1].value != 2 {
+		t.Fatalf("undo applied with outstanding references, got: [%d %d], expected: [0 2]", set.sems[0].value, set.sems[1].value)
+	}
+	l.DecRef(456)
+	if set.sems[0].value != 1 || set.sems[1].value != 0 {
+		t.Fatalf("wrong values after undo, got: [%d %d], expected: [1 0]", set.sems[0].value, set.sems[1].value)
+	}
+	if set.sems[0].pid != 456 {
+		t.Errorf("wrong pid after undo, got: %d, expected: 456", set.sems[0].pid)
+	}
+	if len(set.undos) != 0 {
+		t.Errorf("undo entries not released, got: %d, expected: 0", len(set.undos))
+	}
+}
+
+func TestUndoClamped(t *testing.T) {
+	ctx := contexttest.Context(t)
+	set := &Set{obj: &ipc.Object{ID: 123}, sems: make([]sem, 1)}
+
+	l := NewUndoList()
+	ops := []linux.Sembuf{{SemOp: 2}}
+	if err := executeUndoOps(ctx, t, set, l, ops); err != nil {
+		t.Fatalf("ExecuteOps(ops) failed, err: %v, ops: %+v", err, ops)
+	}
+	// Another process consumes the semaphore without SEM_UNDO.
+	executeOps(ctx, t, set, []linux.Sembuf{{SemOp: -2}}, false)
+
+	l.DecRef(456)
+	if set.sems[0].value != 0 {
+		t.Fatalf("undo should not make the value negative, got: %d", set.sems[0].value)
+	}
+}
+
+func TestUndoRange(t *testing.T) {
+	ctx := contexttest.Context(t)
+	set := &Set{obj: &ipc.Object{ID: 123}, sems: make([]sem, 1)}
+
+	l := NewUndoList()
+	defer l.DecRef(456)
+	executeOps(ctx, t, set, []linux.Sembuf{{SemOp: valueMax}}, false)
+	ops := []linux.Sembuf{{SemOp: -valueMax}}
+	if err := executeUndoOps(ctx, t, set, l, ops); err != nil {
+		t.Fatalf("ExecuteOps(ops) failed, err: %v, ops: %+v", err, ops)
+	}
+	executeOps(ctx, t, set, []linux.Sembuf{{SemOp: 1}}, false)
+
+	// The adjustment is already SEMAEM, so it can't grow any further.
+	ops = []linux.Sembuf{{SemOp: -1}}
+	if err := executeUndoOps(ctx, t, set, l, ops); err != linuxerr.ERANGE {
+		t.Fatalf("ExecuteOps(ops) wrong result, got: %v, expected: %v", err, linuxerr.ERANGE)
+	}
+	if set.sems[0].value != 1 {
+		t.Fatalf("failed ops should not change values, got: %d, expected: 1", set.sems[0].value)
+	}
+}
+
+func TestUndoClearedBySetVal(t *testing.T) {
+	ctx := contexttest.Context(t)
+	r := NewRegistry(auth.NewRootUserNamespace())
+	set, err := r.FindOrCreate(ctx, 123, 1, linux.FileMode(0600), true, true, true)
+	if err != nil {
+		t.Fatalf("FindOrCreate() failed, err: %v", err)
+	}
+
+	l := NewUndoList()
+	ops := []linux.Sembuf{{SemOp: 1}}
+	if err := executeUndoOps(ctx, t, set, l, ops); err != nil {
+		t.Fatalf("ExecuteOps(ops) failed, err: %v, ops: %+v", err, ops)
+	}
+	creds := auth.CredentialsFromContext(ctx)
+	if err := set.SetVal(ctx, 0, 5, creds, 123); err != nil {
+		t.Fatalf("SetVal() failed, err: %v", err)
+	}
+
+	l.DecRef(456)
+	if set.sems[0].value != 5 {
+		t.Fatalf("undo entry should have been cleared by SetVal, got: %d, expected: 5", set.sems[0].value)
+	}
+}
+
+func TestUndoRemovedSet(t *testing.T) {
+	ctx := contexttest.Context(t)
+	r := NewRegistry(auth.NewRootUserNamespace())
+	set, err := r.FindOrCreate(ctx, 123, 1, linux.FileMode(0600), true, true, true)
+	if err != nil {
+		t.Fatalf("FindOrCreate() failed, err: %v", err)
+	}
+
+	l := NewUndoList()
+	ops := []linux.Sembuf{{SemOp: 1}}
+	if err := executeUndoOps(ctx, t, set, l, ops); err != nil {
+		t.Fatalf("ExecuteOps(ops) failed, err: %v, ops: %+v", err, ops)
+	}
+	creds := auth.CredentialsFromContext(ctx)
+	if err := r.Remove(set.obj.ID, creds); err != nil {
+		t.Fatalf("Remove(%d) failed, err: %v", set.obj.ID, err)
+	}
+	if len(l.undos) != 0 {
+		t.Fatalf("undo entries not released on removal, got: %d, expected: 0", len(l.undos))
+	}
+	l.DecRef(456)
+}
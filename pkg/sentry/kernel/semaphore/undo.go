@@ -0,0 +1,209 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semaphore
+
+import (
+	"fmt"
+
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/ipc"
+)
+
+// UndoList holds the semaphore adjustments made with SEM_UNDO by a set of
+// tasks sharing System V semaphore undo state (see CLONE_SYSVSEM). The
+// adjustments are applied when the last task sharing the UndoList releases
+// it. It corresponds to Linux's struct sem_undo_list.
+//
+// Lock order: Registry.mu -> Set.mu -> UndoList.mu.
+//
+// +stateify savable
+type UndoList struct {
+	// mu protects all fields below.
+	mu undoListMutex `state:"nosave"`
+
+	// refs is the number of tasks sharing the UndoList.
+	refs int64
+
+	// undos maps the IDs of semaphore sets to the adjustments made to them.
+	// Entries are removed when the set is removed.
+	undos map[ipc.ID]*undo
+}
+
+// undo holds an UndoList's adjustments for a single Set. It corresponds to
+// Linux's struct sem_undo.
+//
+// +stateify savable
+type undo struct {
+	// list and set are immutable.
+	list *UndoList
+	set  *Set
+
+	// adj holds the adjustment to apply to each semaphore in set. adj is
+	// protected by set.mu.
+	adj []int16
+}
+
+// NewUndoList returns a new UndoList with a single reference.
+func NewUndoList() *UndoList {
+	return &UndoList{
+		refs:  1,
+		undos: make(map[ipc.ID]*undo),
+	}
+}
+
+// IncRef increments the UndoList's reference count.
+func (l *UndoList) IncRef() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.refs <= 0 {
+		panic(fmt.Sprintf("UndoList.IncRef called with refs = %d", l.refs))
+	}
+	l.refs++
+}
+
+// DecRef decrements the UndoList's reference count. When the last reference
+// is dropped, all adjustments are applied to their semaphores, which are
+// attributed to pid. It corresponds to Linux's ipc/sem.c:exit_sem().
+func (l *UndoList) DecRef(pid int32) {
+	l.mu.Lock()
+	l.refs--
+	if l.refs < 0 {
+		panic("UndoList.DecRef called with no references")
+	}
+	if l.refs > 0 {
+		l.mu.Unlock()
+		return
+	}
+	undos := make([]*undo, 0, len(l.undos))
+	for _, u := range l.undos {
+		undos = append(undos, u)
+	}
+	l.mu.Unlock()
+
+	// No other task can add entries to l now, but sets may still be removed
+	// concurrently; Set.applyUndo handles this.
+	for _, u := range undos {
+		u.set.applyUndo(u, pid)
+	}
+}
+
+// get returns the undo entry for set, creating it if needed.
+//
+// Preconditions: set.mu must be locked.
+func (l *UndoList) get(set *Set) *undo {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if u, ok := l.undos[set.obj.ID]; ok && u.set == set {
+		return u
+	}
+	u := &undo{
+		list: l,
+		set:  set,
+		adj:  make([]int16, set.Size()),
+	}
+	l.undos[set.obj.ID] = u
+	set.undos = append(set.undos, u)
+	return u
+}
+
+// remove removes u from its UndoList.
+//
+// Preconditions: u.set.mu must be locked.
+func (u *undo) remove() {
+	l := u.list
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.undos[u.set.obj.ID] == u {
+		delete(l.undos, u.set.obj.ID)
+	}
+}
+
+// applyUndo applies the adjustments in u to s, and removes u. It corresponds
+// to the per-set loop body of Linux's ipc/sem.c:exit_sem().
+func (s *Set) applyUndo(u *undo, pid int32) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// If the set has been removed, Destroy has already discarded u.
+	if s.dead {
+		return
+	}
+	for i, adj := range u.adj {
+		if adj == 0 {
+			continue
+		}
+		sem := &s.sems[i]
+		// As in Linux, the resulting value is clamped to [0, SEMVMX].
+		val := int32(sem.value) + int32(adj)
+		if val < 0 {
+			val = 0
+		}
+		if val > valueMax {
+			val = valueMax
+		}
+		sem.value = int16(val)
+		sem.pid = pid
+		sem.wakeWaiters()
+	}
+	s.removeUndoLocked(u)
+}
+
+// removeUndoLocked removes u from s and from its UndoList.
+//
+// Preconditions: s.mu must be locked.
+func (s *Set) removeUndoLocked(u *undo) {
+	for i, su := range s.undos {
+		if su == u {
+			s.undos = append(s.undos[:i], s.undos[i+1:]...)
+			break
+		}
+	}
+	u.remove()
+}
+
+// clearUndosLocked discards all adjustments for semaphore num, or for all
+// semaphores if num is negative. It is used when semaphore values are set
+// explicitly with semctl(SETVAL or SETALL).
+//
+// Preconditions: s.mu must be locked.
+func (s *Set) clearUndosLocked(num int32) {
+	for _, u := range s.undos {
+		if num < 0 {
+			clear(u.adj)
+		} else {
+			u.adj[num] = 0
+		}
+	}
+}
+
+// destroyUndosLocked discards all adjustments for s, which is being removed.
+//
+// Preconditions: s.mu must be locked.
+func (s *Set) destroyUndosLocked() {
+	for _, u := range s.undos {
+		u.remove()
+	}
+	s.undos = nil
+}
+
+// adjustUndo returns the new adjustment for a semaphore with adjustment adj
+// after applying a SEM_UNDO operation of op, and whether it is in range.
+func adjustUndo(adj, op int16) (int16, bool) {
+	newAdj := int32(adj) - int32(op)
+	if newAdj < -linux.SEMAEM-1 || newAdj > linux.SEMAEM {
+		return 0, false
+	}
+	return int16(newAdj), true
+}
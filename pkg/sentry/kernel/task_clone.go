@@ -23,6 +23,7 @@ import (
 	"gvisor.dev/gvisor/pkg/sentry/fsimpl/kernfs"
 	"gvisor.dev/gvisor/pkg/sentry/fsimpl/nsfs"
 	"gvisor.dev/gvisor/pkg/sentry/inet"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/semaphore"
 	"gvisor.dev/gvisor/pkg/sentry/seccheck"
 	pb "gvisor.dev/gvisor/pkg/sentry/seccheck/points/points_go_proto"
 	"gvisor.dev/gvisor/pkg/sentry/vfs"
@@ -118,6 +119,12 @@ func (t *Task) Clone(args *linux.CloneArgs) (ThreadID, *SyscallControl, error) {
 	if args.Flags&(linux.CLONE_NEWPID|linux.CLONE_NEWNET|linux.CLONE_NEWUTS|linux.CLONE_NEWIPC) != 0 && !creds.HasCapabilityIn(linux.CAP_SYS_ADMIN, userns) {
 		return 0, nil, linuxerr.EPERM
 	}
+	// Semaphore adjustments refer to sets by their ID in the IPC namespace, so
+	// the task's adjustments can't be shared with a child in a new IPC
+	// namespace. Compare Linux's kernel/nsproxy.c:copy_namespaces().
+	if args.Flags&(linux.CLONE_SYSVSEM|linux.CLONE_NEWIPC) == linux.CLONE_SYSVSEM|linux.CLONE_NEWIPC {
+		return 0, nil, linuxerr.EINVAL
+	}
 
 	cu := cleanup.Make(func() {})
 	defer cu.Clean()
@@ -147,6 +154,18 @@ func (t *Task) Clone(args *linux.CloneArgs) (ThreadID, *SyscallControl, error) {
 		ipcns.DecRef(t)
 	})
 
+	var semUndos *semaphore.UndoList
+	if args.Flags&linux.CLONE_SYSVSEM != 0 {
+		// "If CLONE_SYSVSEM is set, then the child and the calling process
+		// share a single list of System V semaphore adjustment (semadj)
+		// values" - clone(2).
+		semUndos = t.SemUndoList()
+		semUndos.IncRef()
+		cu.Add(func() {
+			semUndos.DecRef(0)
+		})
+	}
+
 	netns := t.netns
 	if args.Flags&linux.CLONE_NEWNET != 0 {
 		netns = inet.NewNamespace(netns, userns)
@@ -259,6 +278,7 @@ func (t *Task) Clone(args *linux.CloneArgs) (ThreadID, *SyscallControl, error) {
 		AllowedCPUMask:   t.CPUMask(),
 		UTSNamespace:     utsns,
 		IPCNamespace:     ipcns,
+		SemUndoList:      semUndos,
 		MountNamespace:   mntns,
 		RSeqAddr:         rseqAddr,
 		RSeqSignature:    rseqSignature,
@@ -657,6 +677,16 @@ func (t *Task) Unshare(flags int32) error {
 		t.ipcns.SetInode(nsfs.NewInode(t, t.k.nsfsMount, t.ipcns))
 		cu.Add(func() { oldIPCNS.DecRef(t) })
 	}
+	// "CLONE_SYSVSEM: This flag reverses the effect of the clone(2)
+	// CLONE_SYSVSEM flag. If CLONE_SYSVSEM is specified in flags, then the
+	// calling process's existing System V semaphore undo list is released" -
+	// unshare(2). As in Linux, CLONE_NEWIPC implies CLONE_SYSVSEM.
+	if flags&(linux.CLONE_SYSVSEM|linux.CLONE_NEWIPC) != 0 && t.semUndos != nil {
+		oldSemUndos := t.semUndos
+		t.semUndos = nil
+		pid := int32(t.k.GlobalInit().PIDNamespace().IDOfThreadGroup(t.tg))
+		cu.Add(func() { oldSemUndos.DecRef(pid) })
+	}
 	if flags&linux.CLONE_FILES != 0 {
 		oldFDTable := t.fdTable
 		t.fdTable = oldFDTable.Fork(t, MaxFdLimit)
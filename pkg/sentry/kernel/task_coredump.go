@@ -0,0 +1,740 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kernel
+
+// This file implements core dumps for signals whose default action is
+// SignalActionCore. In brief, the task that dequeues the signal kills all
+// other tasks in its thread group (as for a group exit), waits for them to
+// stop participating in the thread group while collecting their register
+// state, and then writes an ELF core file describing its address space as
+// specified by /proc/sys/kernel/core_pattern. This is analogous to Linux's
+// fs/coredump.c:do_coredump() and fs/binfmt_elf.c:elf_core_dump().
+
+import (
+	"bytes"
+	"debug/elf"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/errors/linuxerr"
+	"gvisor.dev/gvisor/pkg/fd"
+	"gvisor.dev/gvisor/pkg/fspath"
+	"gvisor.dev/gvisor/pkg/hostarch"
+	"gvisor.dev/gvisor/pkg/marshal"
+	"gvisor.dev/gvisor/pkg/sentry/arch"
+	"gvisor.dev/gvisor/pkg/sentry/fsimpl/pipefs"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
+	"gvisor.dev/gvisor/pkg/sentry/limits"
+	"gvisor.dev/gvisor/pkg/sentry/mm"
+	"gvisor.dev/gvisor/pkg/sentry/vfs"
+	"gvisor.dev/gvisor/pkg/usermem"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+const (
+	// defaultCorePattern is the default value of
+	// /proc/sys/kernel/core_pattern.
+	defaultCorePattern = "core"
+
+	// CorePatternMaxLen is the maximum length of
+	// /proc/sys/kernel/core_pattern, CORENAME_MAX_SIZE.
+	CorePatternMaxLen = 128
+
+	// coreNoteName is the name of all notes in a core file.
+	coreNoteName = "CORE"
+
+	// coreRegSetMaxLen is the maximum length of a register set included in a
+	// core file.
+	coreRegSetMaxLen = hostarch.PageSize
+
+	// coreChunkSize is the size of the chunks in which application memory is
+	// copied into a core file.
+	coreChunkSize = 16 * hostarch.PageSize
+
+	// coreMaxPhnum is the number of program headers, PN_XNUM, at which Linux
+	// switches to extended numbering, which is not supported.
+	coreMaxPhnum = 0xffff
+)
+
+// CorePattern returns the current value of /proc/sys/kernel/core_pattern.
+func (k *Kernel) CorePattern() string {
+	k.coreDumpMu.Lock()
+	defer k.coreDumpMu.Unlock()
+	return k.corePattern
+}
+
+// SetCorePattern sets the value of /proc/sys/kernel/core_pattern.
+func (k *Kernel) SetCorePattern(pattern string) {
+	if len(pattern) > CorePatternMaxLen-1 {
+		pattern = pattern[:CorePatternMaxLen-1]
+	}
+	k.coreDumpMu.Lock()
+	defer k.coreDumpMu.Unlock()
+	k.corePattern = pattern
+}
+
+// SetCoreDumpHostDir causes core dumps that would be written to files in the
+// sandbox to instead be written to the given host directory. Core dumps
+// written to a pipe are unaffected. The caller retains ownership of dir, which
+// must remain valid for the lifetime of k.
+func (k *Kernel) SetCoreDumpHostDir(dir *fd.FD) {
+	k.coreDumpMu.Lock()
+	defer k.coreDumpMu.Unlock()
+	k.coreDumpHostDir = dir
+}
+
+// coreDumpStop is a TaskStop that a task sets on itself when it wants to
+// write a core dump and is waiting for the other tasks in its thread group to
+// exit first.
+//
+// +stateify savable
+type coreDumpStop struct{}
+
+// Killable implements TaskStop.Killable.
+func (*coreDumpStop) Killable() bool { return true }
+
+// beginCoreDump initiates a group exit for a core dump caused by the signal
+// described by info. If beginCoreDump returns false, no core dump should be
+// written, and the caller should perform an ordinary group exit.
+//
+// Preconditions: The caller must be running on the task goroutine.
+func (t *Task) beginCoreDump(info *linux.SignalInfo) bool {
+	if !t.shouldCoreDump() {
+		return false
+	}
+
+	t.tg.pidns.owner.mu.Lock()
+	defer t.tg.pidns.owner.mu.Unlock()
+	t.tg.signalHandlers.mu.Lock()
+	defer t.tg.signalHandlers.mu.Unlock()
+
+	if t.tg.exiting || t.tg.execing != nil {
+		// We lost to a racing group-exit, kill, or exec from another thread.
+		return false
+	}
+	t.prepareGroupExitLocked(linux.WaitStatusTerminationSignal(linux.Signal(info.Signo)))
+	t.tg.coreDumper = t
+	if t.tg.activeTasks > 1 {
+		// The last sibling to exit will wake t.
+		t.beginInternalStopLocked((*coreDumpStop)(nil))
+	}
+	return true
+}
+
+// shouldCoreDump returns true if a fatal signal should produce a core dump.
+func (t *Task) shouldCoreDump() bool {
+	m := t.MemoryManager()
+	if m == nil || m.Dumpability() == mm.NotDumpable {
+		return false
+	}
+	pattern := t.k.CorePattern()
+	if pattern == "" {
+		return false
+	}
+	limit := t.tg.limits.Get(limits.Core).Cur
+	if strings.HasPrefix(pattern, "|") {
+		// "Since kernel 2.6.33 ... a RLIMIT_CORE limit of 1 is a special case
+		// to avoid recursive core dumps from the pipe helper." - core(5)
+		return limit != 1
+	}
+	// "[A core dump file is not produced if] The RLIMIT_CORE (core file size)
+	// ... resource limit for the process is set to zero" - core(5). Linux
+	// actually requires room for at least one page.
+	return limit >= hostarch.PageSize
+}
+
+// The runCoreDump state writes a core dump after all siblings of the task
+// have exited, then exits the task.
+//
+// +stateify savable
+type runCoreDump struct {
+	info linux.SignalInfo
+}
+
+func (r *runCoreDump) execute(t *Task) taskRunState {
+	t.tg.pidns.owner.mu.Lock()
+	t.tg.signalHandlers.mu.Lock()
+	t.tg.coreDumper = nil
+	threads := t.tg.coreDumpThreads
+	t.tg.coreDumpThreads = nil
+	var notes []byte
+	killed := t.killedLocked()
+	if !killed {
+		notes = t.coreThreadNotesLocked(&r.info)
+	}
+	t.tg.signalHandlers.mu.Unlock()
+	t.tg.pidns.owner.mu.Unlock()
+
+	if killed {
+		return (*runExit)(nil)
+	}
+	if err := t.coreDump(&r.info, notes, threads); err != nil {
+		t.Debugf("Failed to write core dump for signal %d: %v", r.info.Signo, err)
+		return (*runExit)(nil)
+	}
+	t.tg.signalHandlers.mu.Lock()
+	t.tg.exitStatus = t.tg.exitStatus.WithCoreDump()
+	t.exitStatus = t.tg.exitStatus
+	t.tg.signalHandlers.mu.Unlock()
+	return (*runExit)(nil)
+}
+
+// exitCoreDumpLocked is called by exitThreadGroup to record t's register state
+// for a sibling's core dump, and to wake the sibling if t is the last task it
+// was waiting for.
+//
+// Preconditions:
+//   - The TaskSet mutex must be locked for writing.
+//   - The signal mutex must be locked.
+func (t *Task) exitCoreDumpLocked() {
+	d := t.tg.coreDumper
+	if d == nil || d == t {
+		return
+	}
+	t.tg.coreDumpThreads = append(t.tg.coreDumpThreads, t.coreThreadNotesLocked(nil))
+	if t.tg.activeTasks == 1 {
+		// coreDumper blocks the addition of new tasks to the thread group (by
+		// way of tg.exiting), so the sole active task must be the dumping one.
+		if _, ok := d.stop.(*coreDumpStop); ok {
+			d.endInternalStopLocked()
+		}
+	}
+}
+
+// coreThreadNotesLocked returns the per-thread notes describing t in a core
+// dump: NT_PRSTATUS, and NT_PRFPREG where supported. If info is not nil, t is
+// the dumping task, and info is the signal that caused the dump.
+//
+// Preconditions:
+//   - The TaskSet mutex must be locked.
+//   - The signal mutex must be locked.
+//   - t must not be running application code.
+func (t *Task) coreThreadNotesLocked(info *linux.SignalInfo) []byte {
+	var prstatus linux.ElfPrstatusCommon
+	if info != nil {
+		prstatus.Info.Signo = info.Signo
+		prstatus.Cursig = int16(info.Signo)
+	}
+	prstatus.Sigpend = uint64(t.pendingSignals.pendingSet | t.tg.pendingSignals.pendingSet)
+	prstatus.Sighold = t.signalMask.Load()
+	pidns := t.tg.pidns
+	prstatus.Pid = int32(pidns.tids[t])
+	if parent := t.tg.leader.parent; parent != nil {
+		prstatus.Ppid = int32(pidns.tgids[parent.tg])
+	}
+	if pg := t.tg.processGroup; pg != nil {
+		prstatus.Pgrp = int32(pidns.pgids[pg])
+		prstatus.Sid = int32(pidns.sids[pg.session])
+	}
+	stats := t.CPUStats()
+	prstatus.Utime = linux.DurationToTimeval(stats.UserTime)
+	prstatus.Stime = linux.DurationToTimeval(stats.SysTime)
+	prstatus.Cutime = linux.DurationToTimeval(t.tg.childCPUStats.UserTime)
+	prstatus.Cstime = linux.DurationToTimeval(t.tg.childCPUStats.SysTime)
+
+	var regs bytes.Buffer
+	if _, err := t.Arch().PtraceGetRegSet(linux.NT_PRSTATUS, &regs, coreRegSetMaxLen, t.k.featureSet); err != nil {
+		t.Warningf("Failed to get registers for core dump: %v", err)
+	}
+	var fpregs bytes.Buffer
+	_, fpErr := t.Arch().PtraceGetRegSet(linux.NT_PRFPREG, &fpregs, coreRegSetMaxLen, t.k.featureSet)
+	fpvalid := int32(0)
+	if fpErr == nil {
+		fpvalid = 1
+	}
+
+	// The NT_PRSTATUS descriptor is struct elf_prstatus, which consists of
+	// struct elf_prstatus_common, elf_gregset_t pr_reg, and int pr_fpvalid,
+	// padded to the alignment of the structure.
+	desc := marshal.Marshal(&prstatus)
+	desc = append(desc, regs.Bytes()...)
+	desc = hostarch.ByteOrder.AppendUint32(desc, uint32(fpvalid))
+	for len(desc)%8 != 0 {
+		desc = append(desc, 0)
+	}
+	notes := appendCoreNote(nil, linux.NT_PRSTATUS, desc)
+	if fpErr == nil {
+		notes = appendCoreNote(notes, linux.NT_PRFPREG, fpregs.Bytes())
+	}
+	return notes
+}
+
+// appendCoreNote appends an ELF note of the given type with name
+// coreNoteName to buf and returns the extended buffer.
+func appendCoreNote(buf []byte, typ uint32, desc []byte) []byte {
+	hdr := linux.ElfNote64{
+		Namesz: uint32(len(coreNoteName) + 1),
+		Descsz: uint32(len(desc)),
+		Type:   typ,
+	}
+	buf = append(buf, marshal.Marshal(&hdr)...)
+	buf = append(buf, coreNoteName...)
+	buf = appendCorePadding(append(buf, 0), 4)
+	buf = append(buf, desc...)
+	return appendCorePadding(buf, 4)
+}
+
+// appendCorePadding appends zero bytes to buf until its length is a multiple
+// of align.
+func appendCorePadding(buf []byte, align int) []byte {
+	for len(buf)%align != 0 {
+		buf = append(buf, 0)
+	}
+	return buf
+}
+
+// coreProcessNotes returns the process-wide notes in a core dump, which
+// follow the NT_PRSTATUS note of the dumping task: NT_PRPSINFO, NT_SIGINFO,
+// NT_AUXV, and NT_FILE.
+func (t *Task) coreProcessNotes(info *linux.SignalInfo, segs []mm.CoreDumpSegment) []byte {
+	m := t.MemoryManager()
+
+	psinfo := linux.ElfPrpsinfo{
+		Sname: 'R',
+		Nice:  int8(t.Niceness()),
+	}
+	creds := t.Credentials()
+	psinfo.UID = uint32(creds.RealKUID.In(creds.UserNamespace).OrOverflow())
+	psinfo.GID = uint32(creds.RealKGID.In(creds.UserNamespace).OrOverflow())
+	pidns := t.tg.pidns
+	psinfo.Pid = int32(pidns.IDOfThreadGroup(t.tg))
+	if parent := t.tg.Leader().Parent(); parent != nil {
+		psinfo.Ppid = int32(pidns.IDOfThreadGroup(parent.tg))
+	}
+	if pg := t.tg.ProcessGroup(); pg != nil {
+		psinfo.Pgrp = int32(pidns.IDOfProcessGroup(pg))
+		psinfo.Sid = int32(pidns.IDOfSession(pg.Session()))
+	}
+	copy(psinfo.Fname[:len(psinfo.Fname)-1], t.Name())
+	// "pr_psargs: initial part of arg list", with NUL separators replaced by
+	// spaces.
+	args := psinfo.Psargs[:linux.ElfPrargsz-1]
+	if argv := (hostarch.AddrRange{Start: m.ArgvStart(), End: m.ArgvEnd()}); argv.WellFormed() && argv.Length() > 0 {
+		n, _ := m.CopyIn(t, argv.Start, args[:min(uint64(len(args)), uint64(argv.Length()))], usermem.IOOpts{IgnorePermissions: true})
+		for i := 0; i < n; i++ {
+			if args[i] == 0 {
+				args[i] = ' '
+			}
+		}
+		args = bytes.TrimRight(args[:n], " ")
+		clear(psinfo.Psargs[len(args):])
+	}
+	notes := appendCoreNote(nil, linux.NT_PRPSINFO, marshal.Marshal(&psinfo))
+
+	notes = appendCoreNote(notes, linux.NT_SIGINFO, marshal.Marshal(info))
+
+	var auxv []byte
+	for _, e := range append(m.Auxv(), arch.AuxEntry{Key: linux.AT_NULL}) {
+		auxv = hostarch.ByteOrder.AppendUint64(auxv, e.Key)
+		auxv = hostarch.ByteOrder.AppendUint64(auxv, uint64(e.Value))
+	}
+	notes = appendCoreNote(notes, linux.NT_AUXV, auxv)
+
+	// The NT_FILE descriptor is long count, long page_size, count triples of
+	// long start, end, file_ofs (in units of page_size), followed by count
+	// NUL-terminated filenames.
+	var count uint64
+	var ranges, names []byte
+	for _, seg := range segs {
+		if seg.Path == "" {
+			continue
+		}
+		count++
+		ranges = hostarch.ByteOrder.AppendUint64(ranges, uint64(seg.Range.Start))
+		ranges = hostarch.ByteOrder.AppendUint64(ranges, uint64(seg.Range.End))
+		ranges = hostarch.ByteOrder.AppendUint64(ranges, seg.Offset/hostarch.PageSize)
+		names = append(append(names, seg.Path...), 0)
+	}
+	files := hostarch.ByteOrder.AppendUint64(nil, count)
+	files = hostarch.ByteOrder.AppendUint64(files, hostarch.PageSize)
+	files = append(append(files, ranges...), names...)
+	notes = appendCoreNote(notes, linux.NT_FILE, files)
+
+	return notes
+}
+
+// coreDump writes a core dump for t, which is the only remaining active task
+// in its thread group, as specified by core_pattern. threadNotes are the
+// per-thread notes for t, and siblingNotes are the per-thread notes for t's
+// exited siblings.
+//
+// Preconditions: The caller must be running on the task goroutine.
+func (t *Task) coreDump(info *linux.SignalInfo, threadNotes []byte, siblingNotes [][]byte) error {
+	m := t.MemoryManager()
+	suid := m.Dumpability() == mm.RootDumpable
+	pattern := t.k.CorePattern()
+
+	if helper, ok := strings.CutPrefix(pattern, "|"); ok {
+		var argv []string
+		for _, arg := range strings.Split(helper, " ") {
+			if arg != "" {
+				argv = append(argv, t.expandCorePattern(arg, info))
+			}
+		}
+		w, err := t.startCoreDumpHelper(argv)
+		if err != nil {
+			return err
+		}
+		defer w.DecRef(t)
+		// The core dump is written to the helper without a size limit, as in
+		// Linux.
+		return t.writeCore(&coreFileWriter{t: t, fd: w}, info, threadNotes, siblingNotes)
+	}
+
+	name := t.expandCorePattern(pattern, info)
+	limit := t.tg.limits.Get(limits.Core).Cur
+	t.k.coreDumpMu.Lock()
+	hostDir := t.k.coreDumpHostDir
+	if hostDir != nil {
+		base := path.Base(name)
+		if base == "/" || base == "." || base == ".." {
+			base = defaultCorePattern
+		}
+		// Create the core file while holding coreDumpMu, since hostDir may be
+		// replaced by SetCoreDumpHostDir.
+		unix.Unlinkat(hostDir.FD(), base, 0)
+		f, err := fd.OpenAt(hostDir, base, unix.O_CREAT|unix.O_EXCL|unix.O_WRONLY|unix.O_NOFOLLOW|unix.O_CLOEXEC, 0600)
+		t.k.coreDumpMu.Unlock()
+		if err != nil {
+			return fmt.Errorf("failed to create host core file %q: %w", base, err)
+		}
+		defer f.Close()
+		return t.writeCore(&coreLimitWriter{w: f, limit: limit}, info, threadNotes, siblingNotes)
+	}
+	t.k.coreDumpMu.Unlock()
+
+	creds := t.Credentials()
+	if suid {
+		// "[If] /proc/sys/fs/suid_dumpable [is 2], the core dump [is] owned by
+		// root ... the core_pattern file must either be an absolute pathname,
+		// or a pipe command." - proc(5)
+		if !strings.HasPrefix(name, "/") {
+			return fmt.Errorf("setuid process can only dump core to an absolute path, not %q", name)
+		}
+		creds = creds.Fork()
+		creds.EffectiveKUID = auth.RootKUID
+		creds.EffectiveKGID = auth.RootKGID
+	}
+	root := t.FSContext().RootDirectory()
+	defer root.DecRef(t)
+	wd := t.FSContext().WorkingDirectory()
+	defer wd.DecRef(t)
+	pop := vfs.PathOperation{
+		Root:  root,
+		Start: wd,
+		Path:  fspath.Parse(name),
+	}
+	if !suid {
+		// Unlink any existing file; if this fails, OpenAt will too.
+		t.k.VFS().UnlinkAt(t, creds, &pop)
+	}
+	f, err := t.k.VFS().OpenAt(t, creds, &pop, &vfs.OpenOptions{
+		Flags: linux.O_CREAT | linux.O_EXCL | linux.O_WRONLY | linux.O_NOFOLLOW | linux.O_LARGEFILE,
+		Mode:  linux.FileMode(0600 &^ t.FSContext().Umask()),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create core file %q: %w", name, err)
+	}
+	defer f.DecRef(t)
+	return t.writeCore(&coreLimitWriter{w: &coreFileWriter{t: t, fd: f}, limit: limit}, info, threadNotes, siblingNotes)
+}
+
+// startCoreDumpHelper starts the core dump helper described by argv, as for
+// a core_pattern beginning with "|", and returns the write end of a pipe
+// connected to its standard input.
+func (t *Task) startCoreDumpHelper(argv []string) (*vfs.FileDescription, error) {
+	if len(argv) == 0 || !strings.HasPrefix(argv[0], "/") {
+		return nil, fmt.Errorf("core dump helper %q is not an absolute path", argv)
+	}
+	r, w, err := pipefs.NewConnectedPipeFDs(t, t.k.PipeMount(), 0)
+	if err != nil {
+		return nil, err
+	}
+	defer r.DecRef(t)
+	fdTable := t.k.NewFDTable()
+	defer fdTable.DecRef(t)
+	if _, err := fdTable.NewFDAt(t, 0, r, FDFlags{}); err != nil {
+		w.DecRef(t)
+		return nil, err
+	}
+
+	// The helper runs as root in the initial namespaces, as for Linux's
+	// call_usermodehelper(). "[The] RLIMIT_CORE limit [of the helper is] set
+	// to 1 ... to avoid recursive core dumps" - core(5).
+	initTG := t.k.GlobalInit()
+	if initTG == nil {
+		initTG = t.tg
+	}
+	ls := initTG.Limits().GetCopy()
+	ls.SetUnchecked(limits.Core, limits.Limit{Cur: 1, Max: 1})
+	tg, _, err := t.k.CreateProcess(CreateProcessArgs{
+		Filename:             argv[0],
+		Argv:                 argv,
+		Envv:                 []string{"HOME=/", "PATH=/sbin:/bin:/usr/sbin:/usr/bin", "TERM=linux"},
+		WorkingDirectory:     "/",
+		Credentials:          auth.NewRootCredentials(t.k.RootUserNamespace()),
+		FDTable:              fdTable,
+		Umask:                0022,
+		Limits:               ls,
+		MaxSymlinkTraversals: linux.MaxSymlinkTraversals,
+		UTSNamespace:         t.k.RootUTSNamespace(),
+		IPCNamespace:         t.k.RootIPCNamespace(),
+		PIDNamespace:         t.k.RootPIDNamespace(),
+	})
+	if err != nil {
+		w.DecRef(t)
+		return nil, fmt.Errorf("failed to start core dump helper %q: %w", argv, err)
+	}
+	t.k.StartProcess(tg)
+	return w, nil
+}
+
+// writeCore writes an ELF core file describing t's address space to w.
+func (t *Task) writeCore(w io.Writer, info *linux.SignalInfo, threadNotes []byte, siblingNotes [][]byte) error {
+	m := t.MemoryManager()
+	segs := m.CoreDumpSegments(t)
+	phnum := len(segs) + 1
+	if phnum >= coreMaxPhnum {
+		return fmt.Errorf("too many mappings (%d) for core dump", len(segs))
+	}
+
+	// "The [dumping] thread's notes come first, followed by [notes] for all
+	// the other threads." - fs/binfmt_elf.c:write_note_info()
+	notes := append([]byte(nil), threadNotes[:coreFirstNoteLen(threadNotes)]...)
+	notes = append(notes, t.coreProcessNotes(info, segs)...)
+	notes = append(notes, threadNotes[coreFirstNoteLen(threadNotes):]...)
+	for _, n := range siblingNotes {
+		notes = append(notes, n...)
+	}
+
+	var ehdr linux.ElfHeader64
+	copy(ehdr.Ident[:], elf.ELFMAG)
+	ehdr.Ident[elf.EI_CLASS] = byte(elf.ELFCLASS64)
+	ehdr.Ident[elf.EI_DATA] = byte(elf.ELFDATA2LSB)
+	ehdr.Ident[elf.EI_VERSION] = byte(elf.EV_CURRENT)
+	ehdr.Ident[elf.EI_OSABI] = byte(elf.ELFOSABI_NONE)
+	ehdr.Type = uint16(elf.ET_CORE)
+	switch t.Arch().Arch() {
+	case arch.AMD64:
+		ehdr.Machine = uint16(elf.EM_X86_64)
+	case arch.ARM64:
+		ehdr.Machine = uint16(elf.EM_AARCH64)
+	default:
+		return fmt.Errorf("unsupported architecture %v", t.Arch().Arch())
+	}
+	ehdr.Version = uint32(elf.EV_CURRENT)
+	ehdr.Ehsize = uint16(ehdr.SizeBytes())
+	ehdr.Phoff = uint64(ehdr.SizeBytes())
+	ehdr.Phentsize = uint16((*linux.ElfProg64)(nil).SizeBytes())
+	ehdr.Phnum = uint16(phnum)
+
+	notesOff := ehdr.Phoff + uint64(phnum)*uint64(ehdr.Phentsize)
+	dataOff, _ := hostarch.PageRoundUp(notesOff + uint64(len(notes)))
+	hdrs := marshal.Marshal(&ehdr)
+	notesPhdr := linux.ElfProg64{
+		Type:   uint32(elf.PT_NOTE),
+		Off:    notesOff,
+		Filesz: uint64(len(notes)),
+	}
+	hdrs = append(hdrs, marshal.Marshal(&notesPhdr)...)
+	off := dataOff
+	for _, seg := range segs {
+		phdr := linux.ElfProg64{
+			Type:   uint32(elf.PT_LOAD),
+			Off:    off,
+			Vaddr:  uint64(seg.Range.Start),
+			Filesz: seg.DumpLength,
+			Memsz:  uint64(seg.Range.Length()),
+			Align:  hostarch.PageSize,
+		}
+		if seg.Perms.Read {
+			phdr.Flags |= uint32(elf.PF_R)
+		}
+		if seg.Perms.Write {
+			phdr.Flags |= uint32(elf.PF_W)
+		}
+		if seg.Perms.Execute {
+			phdr.Flags |= uint32(elf.PF_X)
+		}
+		hdrs = append(hdrs, marshal.Marshal(&phdr)...)
+		off += seg.DumpLength
+	}
+	hdrs = append(hdrs, notes...)
+	hdrs = append(hdrs, make([]byte, dataOff-uint64(len(hdrs)))...)
+	if _, err := w.Write(hdrs); err != nil {
+		return err
+	}
+
+	buf := make([]byte, coreChunkSize)
+	for _, seg := range segs {
+		for done := uint64(0); done < seg.DumpLength; {
+			chunk := buf[:min(uint64(len(buf)), seg.DumpLength-done)]
+			addr := seg.Range.Start + hostarch.Addr(done)
+			if _, err := m.CopyIn(t, addr, chunk, usermem.IOOpts{IgnorePermissions: true}); err != nil {
+				// Retry page by page, writing zeroes for pages that can't be
+				// read, as Linux does for pages that get_dump_page() fails to
+				// return.
+				for i := 0; i < len(chunk); i += hostarch.PageSize {
+					page := chunk[i : i+hostarch.PageSize]
+					if _, err := m.CopyIn(t, addr+hostarch.Addr(i), page, usermem.IOOpts{IgnorePermissions: true}); err != nil {
+						clear(page)
+					}
+				}
+			}
+			if _, err := w.Write(chunk); err != nil {
+				return err
+			}
+			done += uint64(len(chunk))
+		}
+	}
+	return nil
+}
+
+// coreFirstNoteLen returns the length of the first note in notes.
+func coreFirstNoteLen(notes []byte) int {
+	var hdr linux.ElfNote64
+	hdr.UnmarshalBytes(notes)
+	namesz := (hdr.Namesz + 3) &^ 3
+	descsz := (hdr.Descsz + 3) &^ 3
+	return hdr.SizeBytes() + int(namesz) + int(descsz)
+}
+
+// expandCorePattern returns pattern with core_pattern specifiers expanded for
+// a core dump of t caused by info. It is analogous to Linux's
+// fs/coredump.c:format_corename().
+func (t *Task) expandCorePattern(pattern string, info *linux.SignalInfo) string {
+	// "/" is replaced by "!" in specifiers that expand to names chosen by
+	// the application, as in Linux's cn_esc_printf().
+	escape := func(s string) string {
+		return strings.ReplaceAll(s, "/", "!")
+	}
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' {
+			b.WriteByte(pattern[i])
+			continue
+		}
+		i++
+		if i == len(pattern) {
+			break
+		}
+		switch pattern[i] {
+		case '%':
+			b.WriteByte('%')
+		case 'c':
+			b.WriteString(strconv.FormatUint(t.tg.limits.Get(limits.Core).Cur, 10))
+		case 'd':
+			b.WriteString(strconv.Itoa(int(t.MemoryManager().Dumpability())))
+		case 'e':
+			b.WriteString(escape(t.Name()))
+		case 'E':
+			if exe := t.MemoryManager().Executable(); exe != nil {
+				b.WriteString(escape(exe.MappedName(t)))
+				exe.DecRef(t)
+			}
+		case 'g':
+			b.WriteString(strconv.FormatUint(uint64(t.Credentials().RealKGID), 10))
+		case 'h':
+			b.WriteString(escape(t.UTSNamespace().HostName()))
+		case 'i':
+			b.WriteString(strconv.Itoa(int(t.tg.pidns.IDOfTask(t))))
+		case 'I':
+			b.WriteString(strconv.Itoa(int(t.tg.pidns.owner.Root.IDOfTask(t))))
+		case 'p':
+			b.WriteString(strconv.Itoa(int(t.tg.pidns.IDOfThreadGroup(t.tg))))
+		case 'P':
+			b.WriteString(strconv.Itoa(int(t.tg.pidns.owner.Root.IDOfThreadGroup(t.tg))))
+		case 's':
+			b.WriteString(strconv.Itoa(int(info.Signo)))
+		case 't':
+			b.WriteString(strconv.FormatInt(t.k.RealtimeClock().Now().Seconds(), 10))
+		case 'u':
+			b.WriteString(strconv.FormatUint(uint64(t.Credentials().RealKUID), 10))
+		default:
+			// Unknown specifiers are dropped, as in Linux.
+		}
+	}
+	return b.String()
+}
+
+// coreFileWriter is an io.Writer that writes to a vfs.FileDescription on
+// behalf of a dumping task, blocking if necessary.
+type coreFileWriter struct {
+	t  *Task
+	fd *vfs.FileDescription
+}
+
+// Write implements io.Writer.Write.
+func (w *coreFileWriter) Write(src []byte) (int, error) {
+	var (
+		e     waiter.Entry
+		ch    <-chan struct{}
+		total int
+	)
+	for total < len(src) {
+		n, err := w.fd.Write(w.t, usermem.BytesIOSequence(src[total:]), vfs.WriteOptions{})
+		total += int(n)
+		if err == nil {
+			continue
+		}
+		if !linuxerr.Equals(linuxerr.ErrWouldBlock, err) {
+			return total, err
+		}
+		if ch == nil {
+			e, ch = waiter.NewChannelEntry(waiter.WritableEvents)
+			if err := w.fd.EventRegister(&e); err != nil {
+				return total, err
+			}
+			defer w.fd.EventUnregister(&e)
+			continue
+		}
+		if err := w.t.Block(ch); err != nil && w.t.killed() {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// coreLimitWriter is an io.Writer that writes at most limit bytes, as for
+// RLIMIT_CORE. Data past the limit is dropped without failing the write, so
+// that the core file is truncated at the limit, as in Linux.
+type coreLimitWriter struct {
+	w       io.Writer
+	limit   uint64
+	written uint64
+}
+
+// Write implements io.Writer.Write.
+func (w *coreLimitWriter) Write(src []byte) (int, error) {
+	if rem := w.limit - w.written; uint64(len(src)) > rem {
+		if rem == 0 {
+			return len(src), nil
+		}
+		n, err := w.w.Write(src[:rem])
+		w.written += uint64(n)
+		if err != nil {
+			return n, err
+		}
+		return len(src), nil
+	}
+	n, err := w.w.Write(src)
+	w.written += uint64(n)
+	return n, err
+}
@@ -242,6 +242,9 @@ func (r *runSyscallAfterExecStop) execute(t *Task) taskRunState {
 	// allows us to unconditionally enable user dumpability on the new mm.
 	// See fs/exec.c:setup_new_exec.
 	r.image.MemoryManager.SetDumpability(mm.UserDumpable)
+	// "[The coredump_filter] value is ... preserved across an execve(2)." -
+	// core(5)
+	r.image.MemoryManager.SetCoreDumpFilter(t.MemoryManager().CoreDumpFilter())
 
 	// Switch to the new process.
 	t.MemoryManager().Deactivate()
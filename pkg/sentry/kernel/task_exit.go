@@ -250,6 +250,11 @@ func (*runExitMain) execute(t *Task) taskRunState {
 
 	t.ResetKcov()
 
+	// Handle the robust futex list before the cleartid, as Linux does in
+	// exit_mm_release(), so that robust futexes are marked dead before a
+	// thread joining t can observe t's exit.
+	t.exitRobustList()
+
 	// If the task has a cleartid, and the thread group wasn't killed by a
 	// signal, handle that before releasing the MM.
 	if t.cleartid != 0 {
@@ -265,9 +270,6 @@ func (*runExitMain) execute(t *Task) taskRunState {
 		}
 	}
 
-	// Handle the robust futex list.
-	t.exitRobustList()
-
 	// Deactivate the address space and update max RSS before releasing the
 	// task's MM.
 	t.Deactivate()
@@ -302,11 +304,18 @@ func (*runExitMain) execute(t *Task) taskRunState {
 	t.utsns = nil
 	ipcns := t.ipcns
 	t.ipcns = nil
+	semUndos := t.semUndos
+	t.semUndos = nil
 	netns := t.netns
 	t.netns = nil
 	t.mu.Unlock()
 	mntns.DecRef(t)
 	utsns.DecRef(t)
+	if semUndos != nil {
+		// Adjustments must be applied before the IPC namespace, and possibly
+		// its semaphores, are released.
+		semUndos.DecRef(int32(t.k.GlobalInit().PIDNamespace().IDOfThreadGroup(t.tg)))
+	}
 	ipcns.DecRef(t)
 	netns.DecRef(t)
 
@@ -342,6 +351,11 @@ func (t *Task) exitThreadGroup() bool {
 	t.tg.activeTasks--
 	last := t.tg.activeTasks == 0
 
+	// Check if this task's exit completes a sibling's wait to core dump. This
+	// must happen before t's signal mask is changed below, since the signal
+	// mask is included in the core dump.
+	t.exitCoreDumpLocked()
+
 	// Ensure that someone will handle the signals we can't.
 	t.setSignalMaskLocked(^linux.SignalSet(0))
 
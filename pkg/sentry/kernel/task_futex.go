@@ -89,28 +89,28 @@ func (t *Task) exitRobustList() {
 		return
 	}
 
-	next := primitive.Uint64(rl.List)
-	done := 0
-	var pendingLockAddr hostarch.Addr
-	if rl.ListOpPending != 0 {
-		pendingLockAddr = hostarch.Addr(rl.ListOpPending + rl.FutexOffset)
-	}
+	// Bit 0 of each list entry (including the pending-op entry) indicates a
+	// PI futex; compare Linux's fetch_robust_entry().
+	next, nextPI := robustListEntry(rl.List)
+	pending, pendingPI := robustListEntry(rl.ListOpPending)
 
-	// Wake up normal elements.
-	for hostarch.Addr(next) != addr {
+	done := 0
+	for next != addr {
 		// We traverse to the next element of the list before we
 		// actually wake anything. This prevents the race where waking
 		// this futex causes a modification of the list.
-		thisLockAddr := hostarch.Addr(uint64(next) + rl.FutexOffset)
+		this, thisPI := next, nextPI
 
 		// Try to decode the next element in the list before waking the
 		// current futex. But don't check the error until after we've
 		// woken the current futex. Linux does it in this order too
-		_, nextErr := next.CopyIn(t, hostarch.Addr(next))
+		var nextEntry primitive.Uint64
+		_, nextErr := nextEntry.CopyIn(t, this)
+		next, nextPI = robustListEntry(uint64(nextEntry))
 
 		// Wakeup the current futex if it's not pending.
-		if thisLockAddr != pendingLockAddr {
-			t.wakeRobustListOne(thisLockAddr)
+		if this != pending {
+			t.wakeRobustListOne(this, thisPI, false /* pendingOp */, rl.FutexOffset)
 		}
 
 		// If there was an error copying the next futex, we must bail.
@@ -128,54 +128,22 @@ func (t *Task) exitRobustList() {
 	}
 
 	// Is there a pending entry to wake?
-	if pendingLockAddr != 0 {
-		t.wakeRobustListOne(pendingLockAddr)
+	if pending != 0 {
+		t.wakeRobustListOne(pending, pendingPI, true /* pendingOp */, rl.FutexOffset)
 	}
 }
 
-// wakeRobustListOne wakes a single futex from the robust list.
-func (t *Task) wakeRobustListOne(addr hostarch.Addr) {
-	// Bit 0 in address signals PI futex.
-	pi := addr&1 == 1
-	addr = addr &^ 1
-
-	// Load the futex.
-	f, err := t.LoadUint32(addr)
-	if err != nil {
-		// Can't read this single value? Ignore the problem.
-		// We can wake the other futexes in the list.
-		return
-	}
-
-	tid := uint32(t.ThreadID())
-	for {
-		// Is this held by someone else?
-		if f&linux.FUTEX_TID_MASK != tid {
-			return
-		}
-
-		// This thread is dying and it's holding this futex. We need to
-		// set the owner died bit and wake up any waiters.
-		newF := (f & linux.FUTEX_WAITERS) | linux.FUTEX_OWNER_DIED
-		if curF, err := t.CompareAndSwapUint32(addr, f, newF); err != nil {
-			return
-		} else if curF != f {
-			// Futex changed out from under us. Try again...
-			f = curF
-			continue
-		}
-
-		// Wake waiters if there are any.
-		if f&linux.FUTEX_WAITERS != 0 {
-			private := f&linux.FUTEX_PRIVATE_FLAG != 0
-			if pi {
-				t.Futex().UnlockPI(t, addr, tid, private)
-				return
-			}
-			t.Futex().Wake(t, addr, private, linux.FUTEX_BITSET_MATCH_ANY, 1)
-		}
+// robustListEntry decodes a robust list entry into the entry's address and
+// whether it refers to a PI futex.
+func robustListEntry(entry uint64) (hostarch.Addr, bool) {
+	return hostarch.Addr(entry &^ 1), entry&1 != 0
+}
 
-		// Done.
-		return
-	}
+// wakeRobustListOne releases a single futex from the robust list, whose list
+// entry is at entry.
+func (t *Task) wakeRobustListOne(entry hostarch.Addr, pi, pendingOp bool, futexOffset uint64) {
+	addr := entry + hostarch.Addr(futexOffset)
+	// Errors affect only this futex; we can still wake the other futexes in
+	// the list.
+	_ = t.Futex().HandleOwnerDeath(t, addr, uint32(t.ThreadID()), pi, pendingOp)
 }
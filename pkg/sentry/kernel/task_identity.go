@@ -162,8 +162,11 @@ func (t *Task) SetRESUID(r, e, s auth.UID) error {
 func (t *Task) setKUIDsUncheckedLocked(newR, newE, newS auth.KUID) {
 	creds := t.Credentials().Fork() // The credentials object is immutable. See doc for creds.
 	root := creds.UserNamespace.MapToKUID(auth.RootUID)
-	oldR, oldE, oldS := creds.RealKUID, creds.EffectiveKUID, creds.SavedKUID
+	oldR, oldE, oldS, oldFS := creds.RealKUID, creds.EffectiveKUID, creds.SavedKUID, creds.FilesystemKUID
 	creds.RealKUID, creds.EffectiveKUID, creds.SavedKUID = newR, newE, newS
+	// "Whenever the effective user ID is changed, fsuid will also be changed
+	// to the new value of the effective user ID." - setfsuid(2)
+	creds.FilesystemKUID = newE
 
 	// "1. If one or more of the real, effective or saved set user IDs was
 	// previously 0, and as a result of the UID changes all of these IDs have a
@@ -198,13 +201,11 @@ func (t *Task) setKUIDsUncheckedLocked(newR, newE, newS auth.KUID) {
 	} else if oldE != root && newE == root {
 		creds.EffectiveCaps = creds.PermittedCaps
 	}
-	// "4. If the filesystem user ID is changed from 0 to nonzero (see
-	// setfsuid(2)), then the following capabilities are cleared from the
-	// effective set: ..."
-	// (filesystem UIDs aren't implemented, nor are any of the capabilities in
-	// question)
+	// Rule 4 (filesystem user ID changes) is applied only by setfsuid(2);
+	// see Task.SetFSUID. Here, the changes to the effective set made by rules
+	// 2 and 3 already subsume it.
 
-	if oldE != newE {
+	if oldE != newE || oldFS != newE {
 		// "[dumpability] is reset to the current value contained in
 		// the file /proc/sys/fs/suid_dumpable (which by default has
 		// the value 0), in the following circumstances: The process's
@@ -316,10 +317,13 @@ func (t *Task) SetRESGID(r, e, s auth.GID) error {
 
 func (t *Task) setKGIDsUncheckedLocked(newR, newE, newS auth.KGID) {
 	creds := t.Credentials().Fork() // The credentials object is immutable. See doc for creds.
-	oldE := creds.EffectiveKGID
+	oldE, oldFS := creds.EffectiveKGID, creds.FilesystemKGID
 	creds.RealKGID, creds.EffectiveKGID, creds.SavedKGID = newR, newE, newS
+	// "Whenever the effective group ID is changed, fsgid will also be changed
+	// to the new value of the effective group ID." - setfsgid(2)
+	creds.FilesystemKGID = newE
 
-	if oldE != newE {
+	if oldE != newE || oldFS != newE {
 		// "[dumpability] is reset to the current value contained in
 		// the file /proc/sys/fs/suid_dumpable (which by default has
 		// the value 0), in the following circumstances: The process's
@@ -336,6 +340,78 @@ func (t *Task) setKGIDsUncheckedLocked(newR, newE, newS auth.KGID) {
 	t.creds.Store(creds)
 }
 
+// SetFSUID implements the semantics of setfsuid(2). It returns t's previous
+// filesystem user ID in its user namespace.
+//
+// "On success, the previous value of fsuid is returned. On error, the current
+// value of fsuid is returned." - setfsuid(2)
+func (t *Task) SetFSUID(uid auth.UID) auth.UID {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	creds := t.Credentials()
+	oldFS := creds.FilesystemKUID
+	oldUID := oldFS.In(creds.UserNamespace).OrOverflow()
+	// setfsuid(-1) is the conventional way to query the current fsuid.
+	if !uid.Ok() {
+		return oldUID
+	}
+	newFS, err := creds.UseFSUID(uid)
+	if err != nil || newFS == oldFS {
+		return oldUID
+	}
+
+	creds = creds.Fork() // The credentials object is immutable. See doc for creds.
+	creds.FilesystemKUID = newFS
+	// "4. If the filesystem user ID is changed from 0 to nonzero (see
+	// setfsuid(2)), then the following capabilities are cleared from the
+	// effective set: CAP_CHOWN, CAP_DAC_OVERRIDE, CAP_DAC_READ_SEARCH,
+	// CAP_FOWNER, CAP_FSETID, CAP_LINUX_IMMUTABLE (since Linux 2.6.30),
+	// CAP_MAC_OVERRIDE, and CAP_MKNOD (since Linux 2.6.30). If the filesystem
+	// UID is changed from nonzero to 0, then any of these capabilities that
+	// are enabled in the permitted set are enabled in the effective set." -
+	// capabilities(7)
+	root := creds.UserNamespace.MapToKUID(auth.RootUID)
+	if oldFS == root && newFS != root {
+		creds.EffectiveCaps &^= auth.FilesystemCapabilities
+	} else if oldFS != root && newFS == root {
+		creds.EffectiveCaps |= creds.PermittedCaps & auth.FilesystemCapabilities
+	}
+
+	// Compare Linux's kernel/cred.c:commit_creds().
+	t.MemoryManager().SetDumpability(mm.NotDumpable)
+	t.parentDeathSignal = 0
+	t.creds.Store(creds)
+	return oldUID
+}
+
+// SetFSGID implements the semantics of setfsgid(2). It returns t's previous
+// filesystem group ID in its user namespace.
+func (t *Task) SetFSGID(gid auth.GID) auth.GID {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	creds := t.Credentials()
+	oldFS := creds.FilesystemKGID
+	oldGID := oldFS.In(creds.UserNamespace).OrOverflow()
+	if !gid.Ok() {
+		return oldGID
+	}
+	newFS, err := creds.UseFSGID(gid)
+	if err != nil || newFS == oldFS {
+		return oldGID
+	}
+
+	creds = creds.Fork() // The credentials object is immutable. See doc for creds.
+	creds.FilesystemKGID = newFS
+
+	// Compare Linux's kernel/cred.c:commit_creds().
+	t.MemoryManager().SetDumpability(mm.NotDumpable)
+	t.parentDeathSignal = 0
+	t.creds.Store(creds)
+	return oldGID
+}
+
 // SetExtraGIDs attempts to change t's supplemental groups. All IDs are
 // interpreted as being in t's user namespace.
 func (t *Task) SetExtraGIDs(gids []auth.GID) error {
@@ -599,6 +675,9 @@ func (t *Task) updateCredsForExecLocked() {
 	// the above.)
 	creds.SavedKUID = creds.RealKUID
 	creds.SavedKGID = creds.RealKGID
+	// The filesystem IDs likewise follow the new effective IDs.
+	creds.FilesystemKUID = creds.EffectiveKUID
+	creds.FilesystemKGID = creds.EffectiveKGID
 	creds.PermittedCaps &= newPermitted
 	if fileEffective {
 		creds.EffectiveCaps = creds.PermittedCaps
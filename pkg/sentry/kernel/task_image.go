@@ -154,6 +154,9 @@ func (k *Kernel) LoadTaskImage(ctx context.Context, args loader.LoadArgs) (*Task
 	if err != nil {
 		return nil, err
 	}
+	if k.Platform.SupportsProtectionKeys() {
+		info.Arch.ResetPkeyAccess()
+	}
 
 	// Lookup our new syscall table.
 	st, ok := LookupSyscallTable(info.OS, info.Arch.Arch())
@@ -322,6 +322,12 @@ func (app *runApp) execute(t *Task) taskRunState {
 			}
 		}
 
+		// Protection key faults are reported by the platform without the
+		// faulting key, which only the sentry's vmas know.
+		if sig == linux.SIGSEGV && info.Code == linux.SEGV_PKUERR {
+			info.SetPkey(uint32(t.MemoryManager().PkeyAt(hostarch.Addr(info.Addr()))))
+		}
+
 		switch sig {
 		case linux.SIGILL, linux.SIGSEGV, linux.SIGBUS, linux.SIGFPE, linux.SIGTRAP:
 			// Synchronous signal. Send it to ourselves. Assume the signal is
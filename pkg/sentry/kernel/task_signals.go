@@ -202,6 +202,9 @@ func (t *Task) deliverSignal(info *linux.SignalInfo, act linux.SigAction) taskRu
 		t.Debugf("Signal %d, PID: %d, TID: %d, fault addr: %#x: terminating thread group", info.Signo, ucs.Pid, ucs.Tid, ucs.FaultAddr)
 		eventchannel.Emit(ucs)
 
+		if sigact == SignalActionCore && t.beginCoreDump(info) {
+			return &runCoreDump{info: *info}
+		}
 		t.PrepareGroupExit(linux.WaitStatusTerminationSignal(sig))
 		return (*runExit)(nil)
 
@@ -282,6 +285,11 @@ func (t *Task) deliverSignalToHandler(info *linux.SignalInfo, act linux.SigActio
 	if err := t.Arch().SignalSetup(st, &act, info, &alt, mask, t.k.featureSet); err != nil {
 		return err
 	}
+	// Signal handlers run with the default protection key rights, as in
+	// Linux's get_sigframe().
+	if t.k.Platform.SupportsProtectionKeys() {
+		t.Arch().ResetPkeyAccess()
+	}
 	t.p.FullStateChanged()
 	t.haveSavedSignalMask = false
 
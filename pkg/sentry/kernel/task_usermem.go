@@ -413,3 +413,16 @@ func (cc *ownTaskCopyContext) CopyInBytes(addr hostarch.Addr, dst []byte) (int,
 func (cc *ownTaskCopyContext) CopyOutBytes(addr hostarch.Addr, src []byte) (int, error) {
 	return cc.t.MemoryManager().CopyOut(cc.t, addr, src, cc.opts)
 }
+
+// SetPkeyAccess sets t's access rights for memory protection key pkey to
+// rights, a mask of linux.PKEY_DISABLE_* bits.
+//
+// Preconditions: The caller must be running on the task goroutine.
+func (t *Task) SetPkeyAccess(pkey int, rights uint32) error {
+	if err := t.p.PullFullState(t.MemoryManager().AddressSpace(), t.Arch()); err != nil {
+		return err
+	}
+	t.Arch().SetPkeyAccess(pkey, rights)
+	t.p.FullStateChanged()
+	return nil
+}
@@ -406,6 +406,21 @@ type threadGroupNode struct {
 	// execing is protected by the TaskSet mutex.
 	execing *Task
 
+	// If coreDumper is not nil, it is a task in the thread group that has
+	// killed all other tasks so that it can write a core dump.
+	//
+	// coreDumper is analogous to Linux's signal_struct::core_state.
+	//
+	// coreDumper is protected by both the TaskSet mutex and the signal mutex.
+	coreDumper *Task
+
+	// coreDumpThreads contains the per-thread notes of tasks that exited while
+	// coreDumper was not nil, for inclusion in the core dump.
+	//
+	// coreDumpThreads is protected by both the TaskSet mutex and the signal
+	// mutex, as with coreDumper.
+	coreDumpThreads [][]byte
+
 	// tasks is all tasks in the thread group that have not yet been reaped.
 	//
 	// tasks is protected by both the TaskSet mutex and the signal mutex:
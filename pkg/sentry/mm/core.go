@@ -0,0 +1,158 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mm
+
+import (
+	"bytes"
+	"strings"
+
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/hostarch"
+	"gvisor.dev/gvisor/pkg/usermem"
+)
+
+// elfMagic is the ELF file identification prefix.
+var elfMagic = []byte{0x7f, 'E', 'L', 'F'}
+
+// CoreDumpSegment describes a vma for the purpose of core dumping.
+type CoreDumpSegment struct {
+	// Range is the range of addresses spanned by the vma.
+	Range hostarch.AddrRange
+
+	// Perms are the vma's application-visible permissions.
+	Perms hostarch.AccessType
+
+	// DumpLength is the number of bytes at the start of Range whose contents
+	// should be included in the core dump.
+	DumpLength uint64
+
+	// If Path is not empty, the vma maps the file at Path, starting at offset
+	// Offset.
+	Path   string
+	Offset uint64
+}
+
+// CoreDumpSegments returns a description of each vma in mm, in address
+// order, as filtered by mm's core dump filter and MADV_DONTDUMP. It is
+// analogous to Linux's fs/coredump.c:dump_vma_snapshot().
+func (mm *MemoryManager) CoreDumpSegments(ctx context.Context) []CoreDumpSegment {
+	filter := mm.CoreDumpFilter()
+	var segs []CoreDumpSegment
+	// elfHeaderSegs are the indexes in segs of vmas whose first page should
+	// be dumped if it contains an ELF header.
+	var elfHeaderSegs []int
+
+	mm.mappingMu.RLock()
+	for vseg := mm.vmas.FirstSegment(); vseg.Ok(); vseg = vseg.NextSegment() {
+		vma := vseg.ValuePtr()
+		seg := CoreDumpSegment{
+			Range: vseg.Range(),
+			Perms: vma.realPerms,
+		}
+		if vma.id != nil {
+			if path := vma.id.MappedName(ctx); strings.HasPrefix(path, "/") {
+				seg.Path = path
+				seg.Offset = vma.off
+			}
+		}
+		var elfHeader bool
+		seg.DumpLength, elfHeader = mm.vmaDumpLengthLocked(vseg, &seg, filter)
+		if elfHeader {
+			elfHeaderSegs = append(elfHeaderSegs, len(segs))
+		}
+		segs = append(segs, seg)
+	}
+	mm.mappingMu.RUnlock()
+
+	// Reading application memory requires mm.mappingMu, so ELF headers can
+	// only be checked for after unlocking it above.
+	for _, i := range elfHeaderSegs {
+		var magic [4]byte
+		if _, err := mm.CopyIn(ctx, segs[i].Range.Start, magic[:], usermem.IOOpts{IgnorePermissions: true}); err != nil {
+			continue
+		}
+		if bytes.Equal(magic[:], elfMagic) {
+			segs[i].DumpLength = hostarch.PageSize
+		}
+	}
+	return segs
+}
+
+// vmaDumpLengthLocked returns the number of bytes at the start of the vma
+// iterated by vseg that should be included in a core dump, and whether the
+// first page of the vma should be dumped if it contains an ELF header. It is
+// analogous to Linux's fs/coredump.c:vma_dump_size().
+//
+// Preconditions: mm.mappingMu must be locked.
+func (mm *MemoryManager) vmaDumpLengthLocked(vseg vmaIterator, seg *CoreDumpSegment, filter uint32) (uint64, bool) {
+	vma := vseg.ValuePtr()
+	whole := uint64(seg.Range.Length())
+
+	// Always dump special mappings such as the VDSO.
+	if _, ok := vma.mappable.(*SpecialMappable); ok {
+		return whole, false
+	}
+	if vma.dontDump {
+		return 0, false
+	}
+	if !vma.maxPerms.Read {
+		// The vma's contents can't be read, even with IgnorePermissions.
+		return 0, false
+	}
+
+	// Dump shared memory if it is mapped from an anonymous (unlinked) file,
+	// as Linux does based on i_nlink.
+	if !vma.private {
+		anon := vma.mappable == nil || seg.Path == "" || strings.HasSuffix(seg.Path, " (deleted)")
+		if (anon && filter&linux.MMF_DUMP_ANON_SHARED != 0) || (!anon && filter&linux.MMF_DUMP_MAPPED_SHARED != 0) {
+			return whole, false
+		}
+		return 0, false
+	}
+
+	// Dump private vmas that contain anonymous memory, i.e. private pages that
+	// have been allocated or copied-on-write. Anonymous vmas that have never
+	// been touched are not dumped, as in Linux.
+	if filter&linux.MMF_DUMP_ANON_PRIVATE != 0 && mm.hasPrivatePMAsInRange(seg.Range) {
+		return whole, false
+	}
+	if vma.mappable == nil {
+		return 0, false
+	}
+	if filter&linux.MMF_DUMP_MAPPED_PRIVATE != 0 {
+		return whole, false
+	}
+
+	// If this is the beginning of a file mapping, the first page may be dumped
+	// to help determine what was mapped here.
+	return 0, filter&linux.MMF_DUMP_ELF_HEADERS != 0 && vma.off == 0 && vma.realPerms.Read
+}
+
+// hasPrivatePMAsInRange returns true if any pma in ar maps private memory,
+// i.e. memory that was copied or allocated on behalf of a private mapping.
+// This is analogous to Linux's vma->anon_vma != NULL.
+//
+// Preconditions: mm.mappingMu must be locked.
+func (mm *MemoryManager) hasPrivatePMAsInRange(ar hostarch.AddrRange) bool {
+	mm.activeMu.RLock()
+	defer mm.activeMu.RUnlock()
+	for pseg := mm.pmas.LowerBoundSegment(ar.Start); pseg.Ok() && pseg.Start() < ar.End; pseg = pseg.NextSegment() {
+		if pseg.ValuePtr().private {
+			return true
+		}
+	}
+	return false
+}
@@ -17,6 +17,7 @@ package mm
 import (
 	"fmt"
 
+	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/atomicbitops"
 	"gvisor.dev/gvisor/pkg/context"
 	"gvisor.dev/gvisor/pkg/hostarch"
@@ -29,6 +30,11 @@ import (
 
 // NewMemoryManager returns a new MemoryManager with no mappings and 1 user.
 func NewMemoryManager(p platform.Platform, mf *pgalloc.MemoryFile, sleepForActivation bool) *MemoryManager {
+	var pkeys uint16
+	if p.SupportsProtectionKeys() {
+		// Protection key 0 is allocated implicitly and used by default.
+		pkeys = 1
+	}
 	return &MemoryManager{
 		p:                  p,
 		mf:                 mf,
@@ -36,6 +42,8 @@ func NewMemoryManager(p platform.Platform, mf *pgalloc.MemoryFile, sleepForActiv
 		users:              atomicbitops.FromInt32(1),
 		auxv:               arch.Auxv{},
 		dumpability:        atomicbitops.FromInt32(int32(UserDumpable)),
+		pkeys:              pkeys,
+		coreDumpFilter:     atomicbitops.FromUint32(linux.MMF_DUMP_FILTER_DEFAULT),
 		aioManager:         aioManager{contexts: make(map[uint64]*AIOContext)},
 		sleepForActivation: sleepForActivation,
 	}
@@ -80,6 +88,7 @@ func (mm *MemoryManager) Fork(ctx context.Context) (*MemoryManager, error) {
 		brk:      mm.brk,
 		usageAS:  mm.usageAS,
 		dataAS:   mm.dataAS,
+		pkeys:    mm.pkeys,
 		// "The child does not inherit its parent's memory locks (mlock(2),
 		// mlockall(2))." - fork(2). So lockedAS is 0 and defMLockMode is
 		// MLockNone, both of which are zero values. vma.mlockMode is reset
@@ -91,6 +100,7 @@ func (mm *MemoryManager) Fork(ctx context.Context) (*MemoryManager, error) {
 		// IncRef'd below, once we know that there isn't an error.
 		executable:         mm.executable,
 		dumpability:        atomicbitops.FromInt32(mm.dumpability.Load()),
+		coreDumpFilter:     atomicbitops.FromUint32(mm.coreDumpFilter.Load()),
 		aioManager:         aioManager{contexts: make(map[uint64]*AIOContext)},
 		sleepForActivation: mm.sleepForActivation,
 		vdsoSigReturnAddr:  mm.vdsoSigReturnAddr,
@@ -15,6 +15,7 @@
 package mm
 
 import (
+	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/context"
 	"gvisor.dev/gvisor/pkg/hostarch"
 	"gvisor.dev/gvisor/pkg/sentry/arch"
@@ -47,6 +48,18 @@ func (mm *MemoryManager) SetDumpability(d Dumpability) {
 	mm.dumpability.Store(int32(d))
 }
 
+// CoreDumpFilter returns the set of MMF_DUMP_* bits controlling which vmas are
+// included in core dumps.
+func (mm *MemoryManager) CoreDumpFilter() uint32 {
+	return mm.coreDumpFilter.Load()
+}
+
+// SetCoreDumpFilter sets the set of MMF_DUMP_* bits controlling which vmas
+// are included in core dumps.
+func (mm *MemoryManager) SetCoreDumpFilter(filter uint32) {
+	mm.coreDumpFilter.Store(filter & linux.MMF_DUMP_FILTER_MASK)
+}
+
 // ArgvStart returns the start of the application argument vector.
 //
 // There is no guarantee that this value is sensible w.r.t. ArgvEnd.
@@ -123,6 +123,13 @@ type MemoryManager struct {
 	// defMLockMode is protected by mappingMu.
 	defMLockMode memmap.MLockMode
 
+	// pkeys is a bitmap of allocated memory protection keys, analogous to
+	// Linux's mm_context_t::pkey_allocation_map. If the platform does not
+	// support protection keys, pkeys is always 0.
+	//
+	// pkeys is protected by mappingMu.
+	pkeys uint16
+
 	// activeMu is loosely analogous to Linux's struct
 	// mm_struct::page_table_lock.
 	activeMu activeRWMutex `state:"nosave"`
@@ -197,6 +204,10 @@ type MemoryManager struct {
 	// by metadataMu.
 	dumpability atomicbitops.Int32
 
+	// coreDumpFilter is the set of MMF_DUMP_* bits controlling which vmas are
+	// included in core dumps, as set by /proc/[pid]/coredump_filter.
+	coreDumpFilter atomicbitops.Uint32
+
 	metadataMu metadataMutex `state:"nosave"`
 
 	// argv is the application argv. This is set up by the loader and may be
@@ -300,8 +311,16 @@ type vma struct {
 	// dontfork is the MADV_DONTFORK setting for this vma configured by madvise().
 	dontfork bool
 
+	// dontDump is the MADV_DONTDUMP setting for this vma configured by
+	// madvise().
+	dontDump bool
+
 	mlockMode memmap.MLockMode
 
+	// pkey is the memory protection key for this vma, set by
+	// pkey_mprotect().
+	pkey int
+
 	// numaPolicy is the NUMA policy for this vma set by mbind().
 	numaPolicy linux.NumaPolicy
 
@@ -337,7 +356,9 @@ func (v *vma) copy() vma {
 		growsDown:      v.growsDown,
 		isStack:        v.isStack,
 		dontfork:       v.dontfork,
+		dontDump:       v.dontDump,
 		mlockMode:      v.mlockMode,
+		pkey:           v.pkey,
 		numaPolicy:     v.numaPolicy,
 		numaNodemask:   v.numaNodemask,
 		id:             v.id,
@@ -374,6 +395,10 @@ type pma struct {
 	effectivePerms hostarch.AccessType
 	maxPerms       hostarch.AccessType
 
+	// pkey is vma.pkey, stored in the pma so that it can be passed to
+	// platform.AddressSpace.MapFile without iterating mm.vmas.
+	pkey int
+
 	// needCOW is true if writes to the mapping must be propagated to a copy.
 	needCOW bool
 
@@ -235,8 +235,105 @@ func TestIOAfterMProtect(t *testing.T) {
 	}
 }
 
+// TestPkeysUnsupported tests memory protection key syscalls on a platform
+// that does not support them.
+func TestPkeysUnsupported(t *testing.T) {
+	ctx := contexttest.Context(t)
+	mm := testMemoryManager(ctx)
+	defer mm.DecUsers(ctx)
+	if mm.p.SupportsProtectionKeys() {
+		t.Skip("platform supports protection keys")
+	}
+
+	if _, err := mm.PkeyAlloc(); !linuxerr.Equals(linuxerr.ENOSPC, err) {
+		t.Errorf("PkeyAlloc got err %v want ENOSPC", err)
+	}
+	if err := mm.PkeyFree(0); !linuxerr.Equals(linuxerr.EINVAL, err) {
+		t.Errorf("PkeyFree(0) got err %v want EINVAL", err)
+	}
+
+	addr, err := mm.MMap(ctx, memmap.MMapOpts{
+		Length:   hostarch.PageSize,
+		Private:  true,
+		Perms:    hostarch.ReadWrite,
+		MaxPerms: hostarch.AnyAccess,
+	})
+	if err != nil {
+		t.Fatalf("MMap got err %v want nil", err)
+	}
+
+	// The default key is always usable.
+	if err := mm.PkeyMProtect(addr, hostarch.PageSize, hostarch.Read, false, 0); err != nil {
+		t.Errorf("PkeyMProtect(pkey=0) got err %v want nil", err)
+	}
+	if err := mm.PkeyMProtect(addr, hostarch.PageSize, hostarch.Read, false, 1); !linuxerr.Equals(linuxerr.EINVAL, err) {
+		t.Errorf("PkeyMProtect(pkey=1) got err %v want EINVAL", err)
+	}
+	if got := mm.PkeyAt(addr); got != 0 {
+		t.Errorf("PkeyAt got %d want 0", got)
+	}
+}
+
 // TestAIOPrepareAfterDestroy tests that AIOContext should not be able to be
 // prepared after destruction.
+func TestCoreDumpSegments(t *testing.T) {
+	ctx := contexttest.Context(t)
+	mm := testMemoryManager(ctx)
+	defer mm.DecUsers(ctx)
+
+	mmapAndTouch := func(length uint64, perms hostarch.AccessType, touch bool) hostarch.Addr {
+		addr, err := mm.MMap(ctx, memmap.MMapOpts{
+			Length:   length,
+			Private:  true,
+			Perms:    perms,
+			MaxPerms: hostarch.AnyAccess,
+		})
+		if err != nil {
+			t.Fatalf("MMap got err %v want nil", err)
+		}
+		if touch {
+			if _, err := mm.CopyOut(ctx, addr, []byte{1}, usermem.IOOpts{IgnorePermissions: true}); err != nil {
+				t.Fatalf("CopyOut got err %v want nil", err)
+			}
+		}
+		return addr
+	}
+	touched := mmapAndTouch(2*hostarch.PageSize, hostarch.ReadWrite, true)
+	untouched := mmapAndTouch(hostarch.PageSize, hostarch.Read, false)
+	dontDump := mmapAndTouch(hostarch.PageSize, hostarch.ReadWrite, true)
+	if err := mm.SetDontDump(dontDump, hostarch.PageSize, true); err != nil {
+		t.Fatalf("SetDontDump got err %v want nil", err)
+	}
+
+	dumpLengths := func() map[hostarch.Addr]uint64 {
+		m := make(map[hostarch.Addr]uint64)
+		for _, seg := range mm.CoreDumpSegments(ctx) {
+			m[seg.Range.Start] = seg.DumpLength
+		}
+		return m
+	}
+	got := dumpLengths()
+	for _, test := range []struct {
+		name string
+		addr hostarch.Addr
+		want uint64
+	}{
+		{"touched", touched, 2 * hostarch.PageSize},
+		{"untouched", untouched, 0},
+		{"MADV_DONTDUMP", dontDump, 0},
+	} {
+		if l, ok := got[test.addr]; !ok || l != test.want {
+			t.Errorf("%s vma: got dump length %d (found %t), want %d", test.name, l, ok, test.want)
+		}
+	}
+
+	// Without MMF_DUMP_ANON_PRIVATE, no private anonymous memory is dumped.
+	mm.SetCoreDumpFilter(0)
+	if l := dumpLengths()[touched]; l != 0 {
+		t.Errorf("touched vma with empty filter: got dump length %d, want 0", l)
+	}
+}
+
 func TestAIOPrepareAfterDestroy(t *testing.T) {
 	ctx := contexttest.Context(t)
 	mm := testMemoryManager(ctx)
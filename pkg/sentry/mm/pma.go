@@ -315,6 +315,7 @@ func (mm *MemoryManager) getPMAsInternalLocked(ctx context.Context, vseg vmaIter
 						translatePerms: hostarch.AnyAccess,
 						effectivePerms: vma.effectivePerms,
 						maxPerms:       vma.maxPerms,
+						pkey:           vma.pkey,
 						// Since we just allocated this memory and have the
 						// only reference, the new pma does not need
 						// copy-on-write.
@@ -358,6 +359,7 @@ func (mm *MemoryManager) getPMAsInternalLocked(ctx context.Context, vseg vmaIter
 							translatePerms: t.Perms,
 							effectivePerms: vma.effectivePerms.Intersect(t.Perms),
 							maxPerms:       vma.maxPerms.Intersect(t.Perms),
+							pkey:           vma.pkey,
 						}
 						if vma.private {
 							newpma.effectivePerms.Write = false
@@ -534,6 +536,7 @@ func (mm *MemoryManager) getPMAsInternalLocked(ctx context.Context, vseg vmaIter
 							translatePerms: t.Perms,
 							effectivePerms: vma.effectivePerms.Intersect(t.Perms),
 							maxPerms:       vma.maxPerms.Intersect(t.Perms),
+							pkey:           vma.pkey,
 						}
 						if vma.private {
 							newpma.effectivePerms.Write = false
@@ -945,6 +948,7 @@ func (pmaSetFunctions) Merge(ar1 hostarch.AddrRange, pma1 pma, ar2 hostarch.Addr
 		pma1.translatePerms != pma2.translatePerms ||
 		pma1.effectivePerms != pma2.effectivePerms ||
 		pma1.maxPerms != pma2.maxPerms ||
+		pma1.pkey != pma2.pkey ||
 		pma1.needCOW != pma2.needCOW ||
 		pma1.private != pma2.private ||
 		pma1.huge != pma2.huge {
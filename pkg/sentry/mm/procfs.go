@@ -212,6 +212,9 @@ func (mm *MemoryManager) vmaSmapsEntryIntoLocked(ctx context.Context, vseg vmaIt
 		locked = 0
 	}
 	fmt.Fprintf(b, "Locked:         %8d kB\n", locked/1024)
+	if mm.p.SupportsProtectionKeys() {
+		fmt.Fprintf(b, "ProtectionKey:  %8d\n", vma.pkey)
+	}
 
 	b.WriteString("VmFlags: ")
 	if vma.realPerms.Read {
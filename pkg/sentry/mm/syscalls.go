@@ -16,6 +16,7 @@ package mm
 
 import (
 	"fmt"
+	"math/bits"
 	mrand "math/rand"
 
 	"gvisor.dev/gvisor/pkg/abi/linux"
@@ -631,6 +632,12 @@ func (mm *MemoryManager) MRemap(ctx context.Context, oldAddr hostarch.Addr, oldS
 
 // MProtect implements the semantics of Linux's mprotect(2).
 func (mm *MemoryManager) MProtect(addr hostarch.Addr, length uint64, realPerms hostarch.AccessType, growsDown bool) error {
+	return mm.PkeyMProtect(addr, length, realPerms, growsDown, -1)
+}
+
+// PkeyMProtect implements the semantics of Linux's pkey_mprotect(2). If pkey
+// is -1, the protection keys of affected vmas are unchanged.
+func (mm *MemoryManager) PkeyMProtect(addr hostarch.Addr, length uint64, realPerms hostarch.AccessType, growsDown bool, pkey int) error {
 	addr = hostarch.UntaggedUserAddr(addr)
 	if addr.RoundDown() != addr {
 		return linuxerr.EINVAL
@@ -650,6 +657,9 @@ func (mm *MemoryManager) MProtect(addr hostarch.Addr, length uint64, realPerms h
 
 	mm.mappingMu.Lock()
 	defer mm.mappingMu.Unlock()
+	if pkey != -1 && !mm.pkeyIsAllocatedLocked(pkey) {
+		return linuxerr.EINVAL
+	}
 	// Non-growsDown mprotect requires that all of ar is mapped, and stops at
 	// the first non-empty gap. growsDown mprotect requires that the first vma
 	// be growsDown, but does not require it to extend all the way to ar.Start;
@@ -700,22 +710,26 @@ func (mm *MemoryManager) MProtect(addr hostarch.Addr, length uint64, realPerms h
 
 		vma.realPerms = realPerms
 		vma.effectivePerms = effectivePerms
+		if pkey != -1 {
+			vma.pkey = pkey
+		}
 		if vma.isPrivateDataLocked() {
 			mm.dataAS += uint64(vmaLength)
 		}
 
-		// Propagate vma permission changes to pmas.
+		// Propagate vma permission and protection key changes to pmas.
 		for pseg.Ok() && pseg.Start() < vseg.End() {
 			if pseg.Range().Overlaps(vseg.Range()) {
 				pseg = mm.pmas.Isolate(pseg, vseg.Range())
 				pma := pseg.ValuePtr()
-				if !effectivePerms.SupersetOf(pma.effectivePerms) && !didUnmapAS {
+				if (!effectivePerms.SupersetOf(pma.effectivePerms) || pma.pkey != vma.pkey) && !didUnmapAS {
 					// Unmap all of ar, not just vseg.Range(), to minimize host
 					// syscalls.
 					mm.unmapASLocked(ar)
 					didUnmapAS = true
 				}
 				pma.effectivePerms = effectivePerms.Intersect(pma.translatePerms)
+				pma.pkey = vma.pkey
 				if pma.needCOW {
 					pma.effectivePerms.Write = false
 				}
@@ -734,6 +748,59 @@ func (mm *MemoryManager) MProtect(addr hostarch.Addr, length uint64, realPerms h
 	}
 }
 
+// numPkeys is the number of memory protection keys supported by the
+// hardware, including the default key 0. Compare Linux's
+// arch/x86/include/asm/pkeys.h:arch_max_pkey().
+const numPkeys = 16
+
+// PkeyAlloc implements the semantics of Linux's pkey_alloc(2), except that
+// setting the new key's access rights is left to the caller.
+func (mm *MemoryManager) PkeyAlloc() (int, error) {
+	mm.mappingMu.Lock()
+	defer mm.mappingMu.Unlock()
+	if !mm.p.SupportsProtectionKeys() || mm.pkeys == 1<<numPkeys-1 {
+		return 0, linuxerr.ENOSPC
+	}
+	pkey := bits.TrailingZeros16(^mm.pkeys)
+	mm.pkeys |= 1 << pkey
+	return pkey, nil
+}
+
+// PkeyFree implements the semantics of Linux's pkey_free(2).
+func (mm *MemoryManager) PkeyFree(pkey int) error {
+	mm.mappingMu.Lock()
+	defer mm.mappingMu.Unlock()
+	if !mm.p.SupportsProtectionKeys() || !mm.pkeyIsAllocatedLocked(pkey) {
+		return linuxerr.EINVAL
+	}
+	// As in Linux, vmas that still use pkey keep it, and it may be returned
+	// by a future call to PkeyAlloc.
+	mm.pkeys &^= 1 << pkey
+	return nil
+}
+
+// pkeyIsAllocatedLocked returns true if pkey has been allocated in mm.
+//
+// Preconditions: mm.mappingMu must be locked.
+func (mm *MemoryManager) pkeyIsAllocatedLocked(pkey int) bool {
+	if !mm.p.SupportsProtectionKeys() {
+		// As in Linux's include/linux/pkeys.h, only the default key exists.
+		return pkey == 0
+	}
+	return pkey >= 0 && pkey < numPkeys && mm.pkeys&(1<<pkey) != 0
+}
+
+// PkeyAt returns the memory protection key of the vma containing addr, or 0
+// if no vma contains addr.
+func (mm *MemoryManager) PkeyAt(addr hostarch.Addr) int {
+	mm.mappingMu.RLock()
+	defer mm.mappingMu.RUnlock()
+	if vseg := mm.vmas.FindSegment(hostarch.UntaggedUserAddr(addr)); vseg.Ok() {
+		return vseg.ValuePtr().pkey
+	}
+	return 0
+}
+
 // BrkSetup sets mm's brk address to addr and its brk size to 0.
 func (mm *MemoryManager) BrkSetup(ctx context.Context, addr hostarch.Addr) {
 	var droppedIDs []memmap.MappingIdentity
@@ -1307,6 +1374,18 @@ func (mm *MemoryManager) SetDontFork(addr hostarch.Addr, length uint64, dontfork
 	})
 }
 
+// SetDontDump implements the semantics of madvise MADV_DONTDUMP and
+// MADV_DODUMP.
+//
+// Preconditions: addr and length are page-aligned.
+func (mm *MemoryManager) SetDontDump(addr hostarch.Addr, length uint64, dontDump bool) error {
+	addr = hostarch.UntaggedUserAddr(addr)
+	return mm.madviseMutateVMAs(addr, length, func(vseg vmaIterator) error {
+		vseg.ValuePtr().dontDump = dontDump
+		return nil
+	})
+}
+
 // SetVMAAnonName implements the semantics of Linux's
 // prctl(PR_SET_VMA, PR_SET_VMA_ANON_NAME).
 func (mm *MemoryManager) SetVMAAnonName(addr hostarch.Addr, length uint64, name string, nameIsNil bool) error {
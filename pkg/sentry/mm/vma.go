@@ -469,9 +469,11 @@ func (vmaSetFunctions) Merge(ar1 hostarch.AddrRange, vma1 vma, ar2 hostarch.Addr
 		vma1.growsDown != vma2.growsDown ||
 		vma1.isStack != vma2.isStack ||
 		vma1.mlockMode != vma2.mlockMode ||
+		vma1.pkey != vma2.pkey ||
 		vma1.numaPolicy != vma2.numaPolicy ||
 		vma1.numaNodemask != vma2.numaNodemask ||
 		vma1.dontfork != vma2.dontfork ||
+		vma1.dontDump != vma2.dontDump ||
 		vma1.id != vma2.id ||
 		vma1.name != vma2.name ||
 		vma1.nameMut != vma2.nameMut {
@@ -70,3 +70,16 @@ func TryCPUIDEmulate(ctx context.Context, mm MemoryManager, ac *arch.Context64)
 	s.Regs.Rip += uint64(len(inst))
 	return true
 }
+
+// FeatureSet returns the fixed host feature set, adjusted to reflect the
+// features that p is able to provide to applications.
+func FeatureSet(p Platform) cpuid.FeatureSet {
+	fs := cpuid.HostFeatureSet().Fixed()
+	if !p.SupportsProtectionKeys() {
+		if s, ok := fs.Function.(cpuid.Static); ok {
+			s.Remove(cpuid.X86FeaturePKU)
+			s.Remove(cpuid.X86FeatureOSPKE)
+		}
+	}
+	return fs
+}
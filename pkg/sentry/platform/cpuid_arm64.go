@@ -19,6 +19,7 @@ package platform
 
 import (
 	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/cpuid"
 	"gvisor.dev/gvisor/pkg/sentry/arch"
 )
 
@@ -26,3 +27,8 @@ import (
 func TryCPUIDEmulate(ctx context.Context, mm MemoryManager, ac *arch.Context64) bool {
 	return false
 }
+
+// FeatureSet returns the fixed host feature set.
+func FeatureSet(p Platform) cpuid.FeatureSet {
+	return cpuid.HostFeatureSet().Fixed()
+}
@@ -108,7 +108,7 @@ type hostMapEntry struct {
 // +checkescape:hard,stack
 //
 //go:nosplit
-func (as *addressSpace) mapLocked(addr hostarch.Addr, m hostMapEntry, at hostarch.AccessType) (inv bool) {
+func (as *addressSpace) mapLocked(addr hostarch.Addr, m hostMapEntry, at hostarch.AccessType, pkey int) (inv bool) {
 	for m.length > 0 {
 		physical, length, ok := translateToPhysical(m.addr)
 		if !ok {
@@ -128,11 +128,7 @@ func (as *addressSpace) mapLocked(addr hostarch.Addr, m hostMapEntry, at hostarc
 		// important; if the pagetable mappings were installed before
 		// ensuring the physical pages were available, then some other
 		// thread could theoretically access them.
-		inv = as.pageTables.Map(addr, length, pagetables.MapOpts{
-			AccessType: at,
-			User:       true,
-			MemoryType: m.memType,
-		}, physical) || inv
+		inv = as.pageTables.Map(addr, length, userMapOpts(at, m.memType, pkey), physical) || inv
 		m.addr += length
 		m.length -= length
 		addr += hostarch.Addr(length)
@@ -142,7 +138,7 @@ func (as *addressSpace) mapLocked(addr hostarch.Addr, m hostMapEntry, at hostarc
 }
 
 // MapFile implements platform.AddressSpace.MapFile.
-func (as *addressSpace) MapFile(addr hostarch.Addr, f memmap.File, fr memmap.FileRange, at hostarch.AccessType, precommit bool) error {
+func (as *addressSpace) MapFile(addr hostarch.Addr, f memmap.File, fr memmap.FileRange, at hostarch.AccessType, pkey int, precommit bool) error {
 	as.mu.Lock()
 	defer as.mu.Unlock()
 
@@ -192,7 +188,7 @@ func (as *addressSpace) MapFile(addr hostarch.Addr, f memmap.File, fr memmap.Fil
 			addr:    b.Addr(),
 			length:  uintptr(b.Len()),
 			memType: mt,
-		}, at)
+		}, at, pkey)
 		inv = inv || prev
 		addr += hostarch.Addr(b.Len())
 	}
@@ -14,6 +14,11 @@
 
 package kvm
 
+import (
+	"gvisor.dev/gvisor/pkg/hostarch"
+	"gvisor.dev/gvisor/pkg/ring0/pagetables"
+)
+
 // invalidate is the implementation for Invalidate.
 func (as *addressSpace) invalidate() {
 	timer := asInvalidateDuration.Start()
@@ -24,3 +29,15 @@ func (as *addressSpace) invalidate() {
 	})
 	timer.Finish()
 }
+
+// userMapOpts returns the page table options for an application mapping.
+//
+//go:nosplit
+func userMapOpts(at hostarch.AccessType, memType hostarch.MemoryType, pkey int) pagetables.MapOpts {
+	return pagetables.MapOpts{
+		AccessType:    at,
+		User:          true,
+		MemoryType:    memType,
+		ProtectionKey: uint8(pkey),
+	}
+}
@@ -15,7 +15,9 @@
 package kvm
 
 import (
+	"gvisor.dev/gvisor/pkg/hostarch"
 	"gvisor.dev/gvisor/pkg/ring0"
+	"gvisor.dev/gvisor/pkg/ring0/pagetables"
 )
 
 // invalidate is the implementation for Invalidate.
@@ -23,3 +25,16 @@ func (as *addressSpace) invalidate() {
 	bluepill(as.pageTables.Allocator.(*allocator).cpu)
 	ring0.FlushTlbAll()
 }
+
+// userMapOpts returns the page table options for an application mapping.
+//
+// Protection keys are not supported on arm64, so pkey is always 0.
+//
+//go:nosplit
+func userMapOpts(at hostarch.AccessType, memType hostarch.MemoryType, pkey int) pagetables.MapOpts {
+	return pagetables.MapOpts{
+		AccessType: at,
+		User:       true,
+		MemoryType: memType,
+	}
+}
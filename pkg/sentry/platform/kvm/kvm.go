@@ -136,6 +136,11 @@ func (*KVM) SupportsAddressSpaceIO() bool {
 	return false
 }
 
+// SupportsProtectionKeys implements platform.Platform.SupportsProtectionKeys.
+func (*KVM) SupportsProtectionKeys() bool {
+	return hasGuestPKU
+}
+
 // CooperativelySchedulesAddressSpace implements platform.Platform.CooperativelySchedulesAddressSpace.
 func (*KVM) CooperativelySchedulesAddressSpace() bool {
 	return false
@@ -234,6 +234,9 @@ func updateGlobalOnce(fd int) error {
 	}
 	// Calculate whether guestPCID is supported.
 	hasGuestPCID = fs.HasFeature(cpuid.X86FeaturePCID)
+	// KVM only advertises PKU if the host has enabled it (OSPKE), and then
+	// switches PKRU along with the rest of the guest's extended state.
+	hasGuestPKU = fs.HasFeature(cpuid.X86FeaturePKU) && cpuid.HostFeatureSet().UseXsave()
 	// Create a static feature set from the KVM entries. Then, we
 	// explicitly set OSXSAVE, since this does not come in the feature
 	// entries, but can be provided when the relevant CR4 bit is set.
@@ -28,6 +28,7 @@ import (
 var (
 	runDataSize    int
 	hasGuestPCID   bool
+	hasGuestPKU    bool
 	cpuidSupported = cpuidEntries{nr: _KVM_NR_CPUID_ENTRIES}
 )
 
@@ -27,6 +27,7 @@ import (
 var (
 	runDataSize  int
 	hasGuestPCID bool
+	hasGuestPKU  bool
 )
 
 func updateSystemValues(fd int) error {
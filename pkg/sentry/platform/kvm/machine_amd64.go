@@ -307,6 +307,12 @@ func (c *vCPU) fault(signal int32, info *linux.SignalInfo) (hostarch.AccessType,
 	// Reset the pointed SignalInfo.
 	*info = linux.SignalInfo{Signo: signal}
 	info.SetAddr(uint64(faultAddr))
+	if signal == int32(unix.SIGSEGV) && code&(1<<5) != 0 {
+		// The access was denied by the page's protection key, which the
+		// sentry can't fix by (re)mapping the page.
+		info.Code = linux.SEGV_PKUERR
+		return hostarch.NoAccess, platform.ErrContextSignal
+	}
 	accessType := hostarch.AccessType{}
 	if signal == int32(unix.SIGSEGV) {
 		accessType = hostarch.AccessType{
@@ -43,6 +43,15 @@ type Platform interface {
 	// unchanged over the lifetime of the Platform.
 	SupportsAddressSpaceIO() bool
 
+	// SupportsProtectionKeys returns true if AddressSpaces returned by this
+	// Platform enforce memory protection keys passed to
+	// AddressSpace.MapFile, subject to the PKRU register in the floating
+	// point state of the executing Context.
+	//
+	// The value returned by SupportsProtectionKeys is guaranteed to remain
+	// unchanged over the lifetime of the Platform.
+	SupportsProtectionKeys() bool
+
 	// CooperativelySchedulesAddressSpace returns true if the Platform has a
 	// limited number of AddressSpaces, such that mm.MemoryManager.Deactivate
 	// should call AddressSpace.Release when there are no goroutines that
@@ -307,6 +316,8 @@ type AddressSpace interface {
 	// MapFile creates a shared mapping of offsets fr from f at address addr.
 	// Any existing overlapping mappings are silently replaced.
 	//
+	// pkey is the memory protection key with which the mapping is tagged.
+	//
 	// If precommit is true, the platform should eagerly commit resources (e.g.
 	// physical memory) to the mapping. The precommit flag is advisory and
 	// implementations may choose to ignore it.
@@ -315,9 +326,10 @@ type AddressSpace interface {
 	//	* addr and fr must be page-aligned.
 	//	* fr.Length() > 0.
 	//	* at.Any() == true.
+	//	* pkey == 0, unless Platform.SupportsProtectionKeys() == true.
 	//	* At least one reference must be held on all pages in fr, and must
 	//		continue to be held as long as pages are mapped.
-	MapFile(addr hostarch.Addr, f memmap.File, fr memmap.FileRange, at hostarch.AccessType, precommit bool) error
+	MapFile(addr hostarch.Addr, f memmap.File, fr memmap.FileRange, at hostarch.AccessType, pkey int, precommit bool) error
 
 	// Unmap unmaps the given range.
 	//
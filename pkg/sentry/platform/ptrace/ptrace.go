@@ -234,6 +234,13 @@ func (*PTrace) SupportsAddressSpaceIO() bool {
 	return false
 }
 
+// SupportsProtectionKeys implements platform.Platform.SupportsProtectionKeys.
+func (*PTrace) SupportsProtectionKeys() bool {
+	// Application memory is mapped into a host process, where protection
+	// keys would have to be allocated from the host.
+	return false
+}
+
 // CooperativelySchedulesAddressSpace implements platform.Platform.CooperativelySchedulesAddressSpace.
 func (*PTrace) CooperativelySchedulesAddressSpace() bool {
 	return false
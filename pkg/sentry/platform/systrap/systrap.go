@@ -292,6 +292,13 @@ func (*Systrap) SupportsAddressSpaceIO() bool {
 	return false
 }
 
+// SupportsProtectionKeys implements platform.Platform.SupportsProtectionKeys.
+func (*Systrap) SupportsProtectionKeys() bool {
+	// Application memory is mapped into a host process, where protection
+	// keys would have to be allocated from the host.
+	return false
+}
+
 // CooperativelySchedulesAddressSpace implements platform.Platform.CooperativelySchedulesAddressSpace.
 func (*Systrap) CooperativelySchedulesAddressSpace() bool {
 	return false
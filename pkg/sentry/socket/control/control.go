@@ -229,6 +229,28 @@ func PackTOS(t *kernel.Task, tos uint8, buf []byte) []byte {
 	)
 }
 
+// PackTLSRecordType packs a TLS_GET_RECORD_TYPE socket control message.
+func PackTLSRecordType(t *kernel.Task, recordType uint8, buf []byte) []byte {
+	return putCmsgStruct(
+		buf,
+		linux.SOL_TLS,
+		linux.TLS_GET_RECORD_TYPE,
+		t.Arch().Width(),
+		primitive.AllocateUint8(recordType),
+	)
+}
+
+// PackSCTPSndRcvInfo packs an SCTP_SNDRCV socket control message.
+func PackSCTPSndRcvInfo(t *kernel.Task, info *linux.SCTPSndRcvInfo, buf []byte) []byte {
+	return putCmsgStruct(
+		buf,
+		linux.SOL_SCTP,
+		linux.SCTP_SNDRCV,
+		t.Arch().Width(),
+		info,
+	)
+}
+
 // PackTClass packs an IPV6_TCLASS socket control message.
 func PackTClass(t *kernel.Task, tClass uint32, buf []byte) []byte {
 	return putCmsgStruct(
@@ -361,6 +383,14 @@ func PackControlMessages(t *kernel.Task, cmsgs socket.ControlMessages, buf []byt
 		buf = PackSockExtendedErr(t, cmsgs.IP.SockErr, buf)
 	}
 
+	if cmsgs.IP.HasTLSRecordType {
+		buf = PackTLSRecordType(t, cmsgs.IP.TLSRecordType, buf)
+	}
+
+	if cmsgs.IP.HasSCTPSndRcvInfo {
+		buf = PackSCTPSndRcvInfo(t, &cmsgs.IP.SCTPSndRcvInfo, buf)
+	}
+
 	return buf
 }
 
@@ -414,6 +444,14 @@ func CmsgsSpace(t *kernel.Task, cmsgs socket.ControlMessages) int {
 		space += cmsgSpace(t, cmsgs.IP.SockErr.SizeBytes())
 	}
 
+	if cmsgs.IP.HasTLSRecordType {
+		space += cmsgSpace(t, linux.SizeOfControlMessageTLSRecordType)
+	}
+
+	if cmsgs.IP.HasSCTPSndRcvInfo {
+		space += cmsgSpace(t, linux.SizeOfSCTPSndRcvInfo)
+	}
+
 	return space
 }
 
@@ -482,6 +520,15 @@ func Parse(t *kernel.Task, socketOrEndpoint any, buf []byte, width uint) (socket
 				cmsgs.IP.Timestamp = ts.ToTime()
 				cmsgs.IP.HasTimestamp = true
 
+			case linux.SCM_TXTIME:
+				if length != linux.SizeOfControlMessageTxTime {
+					return socket.ControlMessages{}, linuxerr.EINVAL
+				}
+				var txTime primitive.Uint64
+				txTime.UnmarshalUnsafe(buf)
+				cmsgs.IP.HasTxTime = true
+				cmsgs.IP.TxTime = uint64(txTime)
+
 			default:
 				// Unknown message type.
 				return socket.ControlMessages{}, linuxerr.EINVAL
@@ -545,10 +592,16 @@ func Parse(t *kernel.Task, socketOrEndpoint any, buf []byte, width uint) (socket
 				if length < linux.SizeOfControlMessageTClass {
 					return socket.ControlMessages{}, linuxerr.EINVAL
 				}
-				cmsgs.IP.HasTClass = true
-				var tclass primitive.Uint32
+				var tclass primitive.Int32
 				tclass.UnmarshalUnsafe(buf)
-				cmsgs.IP.TClass = uint32(tclass)
+				if tclass < -1 || tclass > math.MaxUint8 {
+					return socket.ControlMessages{}, linuxerr.EINVAL
+				}
+				// -1 selects the traffic class of the socket.
+				if tclass != -1 {
+					cmsgs.IP.HasTClass = true
+					cmsgs.IP.TClass = uint32(tclass)
+				}
 
 			case linux.IPV6_PKTINFO:
 				if length < linux.SizeOfControlMessageIPv6PacketInfo {
@@ -564,13 +617,16 @@ func Parse(t *kernel.Task, socketOrEndpoint any, buf []byte, width uint) (socket
 				if length < linux.SizeOfControlMessageHopLimit {
 					return socket.ControlMessages{}, linuxerr.EINVAL
 				}
-				var hoplimit primitive.Uint32
+				var hoplimit primitive.Int32
 				hoplimit.UnmarshalUnsafe(buf)
-				if hoplimit > math.MaxUint8 {
+				if hoplimit < -1 || hoplimit > math.MaxUint8 {
 					return socket.ControlMessages{}, linuxerr.EINVAL
 				}
-				cmsgs.IP.HasHopLimit = true
-				cmsgs.IP.HopLimit = uint32(hoplimit)
+				// -1 selects the hop limit of the socket.
+				if hoplimit != -1 {
+					cmsgs.IP.HasHopLimit = true
+					cmsgs.IP.HopLimit = uint32(hoplimit)
+				}
 
 			case linux.IPV6_RECVORIGDSTADDR:
 				var addr linux.SockAddrInet6
@@ -589,6 +645,32 @@ func Parse(t *kernel.Task, socketOrEndpoint any, buf []byte, width uint) (socket
 				errCmsg.UnmarshalBytes(buf)
 				cmsgs.IP.SockErr = &errCmsg
 
+			default:
+				return socket.ControlMessages{}, linuxerr.EINVAL
+			}
+		case linux.SOL_TLS:
+			switch h.Type {
+			case linux.TLS_SET_RECORD_TYPE:
+				if length < linux.SizeOfControlMessageTLSRecordType {
+					return socket.ControlMessages{}, linuxerr.EINVAL
+				}
+				var recordType primitive.Uint8
+				recordType.UnmarshalUnsafe(buf)
+				cmsgs.IP.HasTLSRecordType = true
+				cmsgs.IP.TLSRecordType = uint8(recordType)
+
+			default:
+				return socket.ControlMessages{}, linuxerr.EINVAL
+			}
+		case linux.SOL_SCTP:
+			switch h.Type {
+			case linux.SCTP_SNDRCV:
+				if length < linux.SizeOfSCTPSndRcvInfo {
+					return socket.ControlMessages{}, linuxerr.EINVAL
+				}
+				cmsgs.IP.HasSCTPSndRcvInfo = true
+				cmsgs.IP.SCTPSndRcvInfo.UnmarshalUnsafe(buf)
+
 			default:
 				return socket.ControlMessages{}, linuxerr.EINVAL
 			}
@@ -54,6 +54,7 @@ type Stack struct {
 	tcpRecvBufSize inet.TCPBufferSize
 	tcpSendBufSize inet.TCPBufferSize
 	tcpSACKEnabled bool
+	tcpFastOpen    int32
 	netDevFile     *os.File
 	netSNMPFile    *os.File
 	// allowedSocketTypes is the list of allowed socket types
@@ -98,6 +99,16 @@ func (s *Stack) Configure(allowRawSockets bool) error {
 		log.Warningf("Failed to read if TCP SACK if enabled, setting to true")
 	}
 
+	// Linux enables client-side Fast Open by default.
+	s.tcpFastOpen = 1
+	if fastOpen, err := os.ReadFile("/proc/sys/net/ipv4/tcp_fastopen"); err == nil {
+		if mode, err := strconv.ParseInt(strings.TrimSpace(string(fastOpen)), 10, 32); err == nil {
+			s.tcpFastOpen = int32(mode)
+		}
+	} else {
+		log.Warningf("Failed to read TCP Fast Open mode, using the default")
+	}
+
 	if f, err := os.Open("/proc/net/dev"); err != nil {
 		log.Warningf("Failed to open /proc/net/dev: %v", err)
 	} else {
@@ -279,6 +290,16 @@ func (*Stack) SetTCPRecovery(inet.TCPLossRecovery) error {
 	return linuxerr.EACCES
 }
 
+// TCPFastOpen implements inet.Stack.TCPFastOpen.
+func (s *Stack) TCPFastOpen() (int32, error) {
+	return s.tcpFastOpen, nil
+}
+
+// SetTCPFastOpen implements inet.Stack.SetTCPFastOpen.
+func (*Stack) SetTCPFastOpen(int32) error {
+	return linuxerr.EACCES
+}
+
 // getLine reads one line from proc file, with specified prefix.
 // The last argument, withHeader, specifies if it contains line header.
 func getLine(f *os.File, prefix string, withHeader bool) string {
@@ -392,6 +413,36 @@ func (*Stack) RemoveRoute(context.Context, *nlmsg.Message) *syserr.Error {
 	return syserr.ErrNotSupported
 }
 
+// QDiscs implements inet.Stack.QDiscs.
+func (*Stack) QDiscs() []inet.TrafficControl {
+	return nil
+}
+
+// NewQDisc implements inet.Stack.NewQDisc.
+func (*Stack) NewQDisc(context.Context, *nlmsg.Message) *syserr.Error {
+	return syserr.ErrNotSupported
+}
+
+// RemoveQDisc implements inet.Stack.RemoveQDisc.
+func (*Stack) RemoveQDisc(context.Context, *nlmsg.Message) *syserr.Error {
+	return syserr.ErrNotSupported
+}
+
+// TrafficClasses implements inet.Stack.TrafficClasses.
+func (*Stack) TrafficClasses(int32) []inet.TrafficControl {
+	return nil
+}
+
+// NewTrafficClass implements inet.Stack.NewTrafficClass.
+func (*Stack) NewTrafficClass(context.Context, *nlmsg.Message) *syserr.Error {
+	return syserr.ErrNotSupported
+}
+
+// RemoveTrafficClass implements inet.Stack.RemoveTrafficClass.
+func (*Stack) RemoveTrafficClass(context.Context, *nlmsg.Message) *syserr.Error {
+	return syserr.ErrNotSupported
+}
+
 // Pause implements inet.Stack.Pause.
 func (*Stack) Pause() {}
 
@@ -192,6 +192,8 @@ func SetEntries(mapper IDMapper, stk *stack.Stack, optVal []byte, ipv6 bool) *sy
 		table = stack.EmptyFilterTable()
 	case natTable:
 		table = stack.EmptyNATTable()
+	case mangleTable:
+		table = stack.EmptyMangleTable()
 	default:
 		nflog("unknown iptables table %q", replace.Name.String())
 		return syserr.ErrInvalidArgument
@@ -238,6 +240,16 @@ func SetEntries(mapper IDMapper, stk *stack.Stack, optVal []byte, ipv6 bool) *sy
 		}
 	}
 
+	// TPROXY targets are only valid in the mangle table.
+	if replace.Name.String() != mangleTable {
+		for _, rule := range table.Rules {
+			if _, ok := rule.Target.(*tproxyTarget); ok {
+				nflog("TPROXY target used outside of the mangle table")
+				return syserr.ErrInvalidArgument
+			}
+		}
+	}
+
 	// Check the user chains.
 	for ruleIdx, rule := range table.Rules {
 		if _, ok := rule.Target.(*stack.UserChainTarget); !ok {
@@ -288,7 +300,7 @@ func SetEntries(mapper IDMapper, stk *stack.Stack, optVal []byte, ipv6 bool) *sy
 
 // parseMatchers parses 0 or more matchers from optVal. optVal should contain
 // only the matchers.
-func parseMatchers(mapper IDMapper, filter stack.IPHeaderFilter, optVal []byte) ([]stack.Matcher, error) {
+func parseMatchers(mapper IDMapper, stk *stack.Stack, filter stack.IPHeaderFilter, optVal []byte) ([]stack.Matcher, error) {
 	nflog("set entries: parsing matchers of size %d", len(optVal))
 	var matchers []stack.Matcher
 	for len(optVal) > 0 {
@@ -312,7 +324,7 @@ func parseMatchers(mapper IDMapper, filter stack.IPHeaderFilter, optVal []byte)
 
 		// Starting with the highest supported revision, try to unmarshal
 		// with each revision down to 0; if all revisions fail, give up.
-		matcher, err := unmarshalMatcherRevs(mapper, &match, filter, optVal)
+		matcher, err := unmarshalMatcherRevs(mapper, stk, &match, filter, optVal)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create matcher: %v", match)
 		}
@@ -335,7 +347,7 @@ func parseMatchers(mapper IDMapper, filter stack.IPHeaderFilter, optVal []byte)
 // starting with the highest revision down to 0. If all revisions fail,
 // it returns the most recent (lowest revision's) "unmarshalMatcher"
 // error.
-func unmarshalMatcherRevs(mapper IDMapper, match *linux.XTEntryMatch, filter stack.IPHeaderFilter, optVal []byte) (stack.Matcher, error) {
+func unmarshalMatcherRevs(mapper IDMapper, stk *stack.Stack, match *linux.XTEntryMatch, filter stack.IPHeaderFilter, optVal []byte) (stack.Matcher, error) {
 	var (
 		matcher stack.Matcher
 		err     error
@@ -355,7 +367,7 @@ func unmarshalMatcherRevs(mapper IDMapper, match *linux.XTEntryMatch, filter sta
 
 		nflog("unmarshalMatcherRevs: attempting to find matcher: %+v", match)
 		matcher, err = unmarshalMatcher(
-			mapper, match, filter,
+			mapper, stk, match, filter,
 			optVal[linux.SizeOfXTEntryMatch:match.MatchSize],
 		)
 
@@ -0,0 +1,173 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netfilter
+
+import (
+	"fmt"
+
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/marshal"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+const matcherNameSocket = "socket"
+
+// socketMaxRevision is the highest supported revision of the socket matcher.
+const socketMaxRevision = 3
+
+func init() {
+	for rev := uint8(0); rev <= socketMaxRevision; rev++ {
+		registerMatchMaker(socketMarshaler{rev: rev})
+	}
+}
+
+// socketMarshaler implements matchMaker for socket matching.
+type socketMarshaler struct {
+	rev uint8
+}
+
+// name implements matchMaker.name.
+func (socketMarshaler) name() string {
+	return matcherNameSocket
+}
+
+// revision implements matchMaker.revision.
+func (sm socketMarshaler) revision() uint8 {
+	return sm.rev
+}
+
+// marshal implements matchMaker.marshal.
+func (socketMarshaler) marshal(mr matcher) []byte {
+	matcher := mr.(*SocketMatcher)
+	if matcher.rev == 0 {
+		return marshalEntryMatch(matcherNameSocket, nil)
+	}
+	var info linux.XTSocketMatchInfo
+	if matcher.transparent {
+		info.Flags |= linux.XT_SOCKET_TRANSPARENT
+	}
+	if matcher.noWildcard {
+		info.Flags |= linux.XT_SOCKET_NOWILDCARD
+	}
+	return marshalEntryMatch(matcherNameSocket, marshal.Marshal(&info))
+}
+
+// unmarshal implements matchMaker.unmarshal.
+func (sm socketMarshaler) unmarshal(_ IDMapper, stk *stack.Stack, buf []byte, _ stack.IPHeaderFilter) (stack.Matcher, error) {
+	matcher := SocketMatcher{
+		stk: stk,
+		rev: sm.rev,
+	}
+	if sm.rev == 0 {
+		return &matcher, nil
+	}
+
+	if len(buf) < linux.SizeOfXTSocketMatchInfo {
+		return nil, fmt.Errorf("buf has insufficient size for socket match: %d", len(buf))
+	}
+	var info linux.XTSocketMatchInfo
+	info.UnmarshalUnsafe(buf)
+	nflog("parsed XTSocketMatchInfo: %+v", info)
+
+	// Netstack doesn't support packet marks, so XT_SOCKET_RESTORESKMARK is
+	// rejected.
+	supported := uint8(linux.XT_SOCKET_TRANSPARENT)
+	if sm.rev >= 2 {
+		supported |= linux.XT_SOCKET_NOWILDCARD
+	}
+	if info.Flags&^supported != 0 {
+		return nil, fmt.Errorf("unsupported socket matcher flags set: %#x", info.Flags)
+	}
+	matcher.transparent = info.Flags&linux.XT_SOCKET_TRANSPARENT != 0
+	matcher.noWildcard = info.Flags&linux.XT_SOCKET_NOWILDCARD != 0
+	return &matcher, nil
+}
+
+// SocketMatcher matches incoming packets that belong to a local socket. It
+// implements Matcher.
+type SocketMatcher struct {
+	stk         *stack.Stack
+	rev         uint8
+	transparent bool
+	noWildcard  bool
+}
+
+// name implements matcher.name.
+func (*SocketMatcher) name() string {
+	return matcherNameSocket
+}
+
+// revision implements matcher.revision.
+func (sm *SocketMatcher) revision() uint8 {
+	return sm.rev
+}
+
+// Match implements Matcher.Match.
+func (sm *SocketMatcher) Match(hook stack.Hook, pkt *stack.PacketBuffer, _, _ string) (bool, bool) {
+	// Support only for PREROUTING and INPUT chains.
+	if hook != stack.Prerouting && hook != stack.Input {
+		return false, true
+	}
+
+	var srcPort, dstPort uint16
+	switch pkt.TransportProtocolNumber {
+	case header.TCPProtocolNumber:
+		tcp := header.TCP(pkt.TransportHeader().Slice())
+		if len(tcp) < header.TCPMinimumSize {
+			return false, true
+		}
+		srcPort, dstPort = tcp.SourcePort(), tcp.DestinationPort()
+	case header.UDPProtocolNumber:
+		udp := header.UDP(pkt.TransportHeader().Slice())
+		if len(udp) < header.UDPMinimumSize {
+			return false, true
+		}
+		srcPort, dstPort = udp.SourcePort(), udp.DestinationPort()
+	default:
+		return false, false
+	}
+
+	net := pkt.Network()
+	id := stack.TransportEndpointID{
+		LocalPort:     dstPort,
+		LocalAddress:  net.DestinationAddress(),
+		RemotePort:    srcPort,
+		RemoteAddress: net.SourceAddress(),
+	}
+	var ep stack.TransportEndpoint
+	if sm.noWildcard {
+		ep = sm.stk.FindBoundTransportEndpoint(pkt.NetworkProtocolNumber, pkt.TransportProtocolNumber, id, pkt.NICID)
+	} else {
+		ep = sm.stk.FindTransportEndpoint(pkt.NetworkProtocolNumber, pkt.TransportProtocolNumber, id, pkt.NICID)
+	}
+	if ep == nil {
+		return false, false
+	}
+	if sm.transparent {
+		if tep, ok := ep.(tcpip.Endpoint); !ok || !tep.SocketOptions().GetTransparent() {
+			return false, false
+		}
+	}
+
+	// On Linux, matched packets are marked and policy routing delivers them
+	// locally. Netstack has neither, so steer the packet to the socket
+	// directly.
+	if hook == stack.Prerouting {
+		pkt.SetTProxied(id.LocalAddress, id.LocalPort)
+	}
+	return true, false
+}
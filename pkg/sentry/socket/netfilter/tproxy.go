@@ -0,0 +1,180 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netfilter
+
+import (
+	"fmt"
+
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/marshal"
+	"gvisor.dev/gvisor/pkg/syserr"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+// TPROXYTargetName is used to mark targets as TPROXY targets. TPROXY targets
+// should be reached only in the PREROUTING chain of the mangle table. These
+// targets steer packets to a local transparent socket without modifying them.
+const TPROXYTargetName = "TPROXY"
+
+func init() {
+	registerTargetMaker(&tproxyTargetMakerV0{
+		NetworkProtocol: header.IPv4ProtocolNumber,
+	})
+	registerTargetMaker(&tproxyTargetMakerV1{
+		NetworkProtocol: header.IPv4ProtocolNumber,
+	})
+	registerTargetMaker(&tproxyTargetMakerV1{
+		NetworkProtocol: header.IPv6ProtocolNumber,
+	})
+}
+
+// +stateify savable
+type tproxyTarget struct {
+	stack.TPROXYTarget
+	revision uint8
+
+	// markMask and markValue describe the mark set on steered packets.
+	// Netstack doesn't support packet marks, so they are only kept to report
+	// the rule back to userspace unchanged.
+	markMask  uint32
+	markValue uint32
+}
+
+func (tt *tproxyTarget) id() targetID {
+	return targetID{
+		name:            TPROXYTargetName,
+		networkProtocol: tt.NetworkProtocol,
+		revision:        tt.revision,
+	}
+}
+
+// checkTPROXYFilter checks that filter only matches TCP or UDP packets, as
+// TPROXY targets must.
+func checkTPROXYFilter(filter stack.IPHeaderFilter) *syserr.Error {
+	if p := filter.Protocol; !filter.CheckProtocol || (p != header.TCPProtocolNumber && p != header.UDPProtocolNumber) {
+		nflog("tproxy target: bad proto %d", p)
+		return syserr.ErrInvalidArgument
+	}
+	return nil
+}
+
+type tproxyTargetMakerV0 struct {
+	NetworkProtocol tcpip.NetworkProtocolNumber
+}
+
+func (tt *tproxyTargetMakerV0) id() targetID {
+	return targetID{
+		name:            TPROXYTargetName,
+		networkProtocol: tt.NetworkProtocol,
+	}
+}
+
+func (*tproxyTargetMakerV0) marshal(target target) []byte {
+	tt := target.(*tproxyTarget)
+	xt := linux.XTTPROXYTargetV0{
+		Target: linux.XTEntryTarget{
+			TargetSize: linux.SizeOfXTTPROXYTargetV0,
+		},
+		MarkMask:  tt.markMask,
+		MarkValue: tt.markValue,
+		LPort:     htons(tt.Port),
+	}
+	copy(xt.Target.Name[:], TPROXYTargetName)
+	copy(xt.LAddr[:], tt.Addr.AsSlice())
+	return marshal.Marshal(&xt)
+}
+
+func (*tproxyTargetMakerV0) unmarshal(buf []byte, filter stack.IPHeaderFilter) (target, *syserr.Error) {
+	if len(buf) < linux.SizeOfXTTPROXYTargetV0 {
+		nflog("tproxyTargetMakerV0: buf has insufficient size for tproxy target %d", len(buf))
+		return nil, syserr.ErrInvalidArgument
+	}
+	if err := checkTPROXYFilter(filter); err != nil {
+		return nil, err
+	}
+
+	var xt linux.XTTPROXYTargetV0
+	xt.UnmarshalUnsafe(buf)
+	return &tproxyTarget{
+		TPROXYTarget: stack.TPROXYTarget{
+			Addr:            tcpip.AddrFrom4(xt.LAddr),
+			Port:            ntohs(xt.LPort),
+			NetworkProtocol: filter.NetworkProtocol(),
+		},
+		markMask:  xt.MarkMask,
+		markValue: xt.MarkValue,
+	}, nil
+}
+
+type tproxyTargetMakerV1 struct {
+	NetworkProtocol tcpip.NetworkProtocolNumber
+}
+
+func (tt *tproxyTargetMakerV1) id() targetID {
+	return targetID{
+		name:            TPROXYTargetName,
+		networkProtocol: tt.NetworkProtocol,
+		revision:        1,
+	}
+}
+
+func (*tproxyTargetMakerV1) marshal(target target) []byte {
+	tt := target.(*tproxyTarget)
+	xt := linux.XTTPROXYTargetV1{
+		Target: linux.XTEntryTarget{
+			TargetSize: linux.SizeOfXTTPROXYTargetV1,
+			Revision:   1,
+		},
+		MarkMask:  tt.markMask,
+		MarkValue: tt.markValue,
+		LPort:     htons(tt.Port),
+	}
+	copy(xt.Target.Name[:], TPROXYTargetName)
+	copy(xt.LAddr[:], tt.Addr.AsSlice())
+	return marshal.Marshal(&xt)
+}
+
+func (tm *tproxyTargetMakerV1) unmarshal(buf []byte, filter stack.IPHeaderFilter) (target, *syserr.Error) {
+	if len(buf) < linux.SizeOfXTTPROXYTargetV1 {
+		nflog("tproxyTargetMakerV1: buf has insufficient size for tproxy target %d", len(buf))
+		return nil, syserr.ErrInvalidArgument
+	}
+	if err := checkTPROXYFilter(filter); err != nil {
+		return nil, err
+	}
+
+	var xt linux.XTTPROXYTargetV1
+	xt.UnmarshalUnsafe(buf)
+	target := tproxyTarget{
+		TPROXYTarget: stack.TPROXYTarget{
+			Port:            ntohs(xt.LPort),
+			NetworkProtocol: filter.NetworkProtocol(),
+		},
+		revision:  1,
+		markMask:  xt.MarkMask,
+		markValue: xt.MarkValue,
+	}
+	switch tm.NetworkProtocol {
+	case header.IPv4ProtocolNumber:
+		target.Addr = tcpip.AddrFrom4Slice(xt.LAddr[:header.IPv4AddressSize])
+	case header.IPv6ProtocolNumber:
+		target.Addr = tcpip.AddrFrom16(xt.LAddr)
+	default:
+		panic(fmt.Sprintf("invalid protocol number: %d", tm.NetworkProtocol))
+	}
+	return &target, nil
+}
@@ -0,0 +1,220 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genetlink
+
+import (
+	"fmt"
+
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/sentry/inet"
+	"gvisor.dev/gvisor/pkg/sentry/socket/netlink"
+	"gvisor.dev/gvisor/pkg/sentry/socket/netlink/nlmsg"
+	"gvisor.dev/gvisor/pkg/syserr"
+)
+
+// Request is a request to a family.
+type Request struct {
+	// Family is the family of the request, with which replies are added.
+	Family *Family
+
+	// Header is the netlink header of the request.
+	Header linux.NetlinkMessageHeader
+
+	// GenericHeader is the generic netlink header of the request.
+	GenericHeader linux.GenericNetlinkHeader
+
+	// Attrs are the attributes of the request, which follow the family
+	// header.
+	Attrs nlmsg.AttrsView
+}
+
+// Handler handles the requests of an operation. Replies are added to ms with
+// req.Family.AddMessage.
+type Handler func(ctx context.Context, s *netlink.Socket, req *Request, ms *nlmsg.MessageSet) *syserr.Error
+
+// Op is an operation of a family.
+type Op struct {
+	// Cmd is the command of the operation.
+	Cmd uint8
+
+	// Flags are the GENL_ADMIN_PERM and GENL_UNS_ADMIN_PERM flags of the
+	// operation. Either of them requires CAP_NET_ADMIN.
+	Flags uint32
+
+	// DoIt handles requests without NLM_F_DUMP. If nil, they aren't
+	// supported.
+	DoIt Handler
+
+	// DumpIt handles requests with NLM_F_DUMP. If nil, they aren't
+	// supported.
+	DumpIt Handler
+}
+
+// Family is a generic netlink family.
+type Family struct {
+	// Name is the name by which userspace resolves the family.
+	Name string
+
+	// Version is the version of the family.
+	Version uint8
+
+	// HdrSize is the size of the family header, which follows the generic
+	// netlink header.
+	HdrSize uint32
+
+	// MaxAttr is the greatest attribute type of the family.
+	MaxAttr uint32
+
+	// Ops are the operations of the family.
+	Ops []Op
+
+	// Policy is the policy of the attributes of requests, which are
+	// validated before they are handled. If nil, attributes aren't
+	// validated.
+	Policy Policy
+
+	// MulticastGroups are the multicast groups of the family.
+	MulticastGroups []MulticastGroup
+
+	// id is the netlink message type of the family, allocated when it is
+	// registered.
+	id uint16
+}
+
+// MulticastGroup is a multicast group of a family.
+type MulticastGroup struct {
+	// Name is the name by which userspace resolves the group.
+	Name string
+
+	// Flags are the GENL_ADMIN_PERM and GENL_UNS_ADMIN_PERM flags of the
+	// group. Either of them requires CAP_NET_ADMIN to join the group.
+	Flags uint32
+
+	// id is the netlink multicast group, allocated when the family is
+	// registered.
+	id uint32
+}
+
+// ID returns the identifier of f, which is the type of its netlink messages.
+func (f *Family) ID() uint16 {
+	return f.id
+}
+
+// op returns the operation of cmd, or nil if f has none.
+func (f *Family) op(cmd uint8) *Op {
+	for i := range f.Ops {
+		if f.Ops[i].Cmd == cmd {
+			return &f.Ops[i]
+		}
+	}
+	return nil
+}
+
+// AddMessage adds a message of f with the command cmd to ms, and returns it
+// for the addition of attributes.
+func (f *Family) AddMessage(ms *nlmsg.MessageSet, cmd uint8) *nlmsg.Message {
+	m := ms.AddMessage(linux.NetlinkMessageHeader{
+		Type: f.id,
+	})
+	m.Put(&linux.GenericNetlinkHeader{
+		Cmd:     cmd,
+		Version: f.Version,
+	})
+	return m
+}
+
+// Multicast sends the messages in ms, which are added with AddMessage, to the
+// members of the group'th multicast group of f in netns, or in any network
+// namespace if netns is nil.
+func (f *Family) Multicast(ctx context.Context, group int, netns *inet.Namespace, ms *nlmsg.MessageSet) {
+	netlink.Multicast(ctx, linux.NETLINK_GENERIC, f.MulticastGroups[group].id, netns, ms)
+}
+
+// genStartAlloc is the first identifier allocated to families other than the
+// controller, as identifiers up to it are reserved by Linux.
+const genStartAlloc = linux.GENL_ID_CTRL + 3
+
+var (
+	// families holds the registered families in the order of their
+	// identifiers.
+	families []*Family
+
+	// familiesByName maps the names of the registered families to them.
+	familiesByName = make(map[string]*Family)
+
+	// nextID is the identifier of the next registered family.
+	nextID uint16 = genStartAlloc
+
+	// nextGroup is the first multicast group that may be allocated to the
+	// next registered family. Groups 0 and 1 are reserved by Linux.
+	nextGroup uint32 = 2
+)
+
+// lastReservedGroup is the last of the multicast groups from GENL_ID_CTRL that
+// Linux reserves for the controller and other families with fixed groups.
+const lastReservedGroup = linux.GENL_ID_CTRL + 2
+
+// RegisterFamily registers f, so that it can be resolved and used by
+// NETLINK_GENERIC sockets.
+//
+// Preconditions: May only be called before any netlink sockets are created.
+func RegisterFamily(f *Family) {
+	if _, ok := familiesByName[f.Name]; ok {
+		panic(fmt.Sprintf("generic netlink family %q already registered", f.Name))
+	}
+	if len(f.Name) >= linux.GENL_NAMSIZ {
+		panic(fmt.Sprintf("generic netlink family name %q is too long", f.Name))
+	}
+	if f != &controller {
+		f.id = nextID
+		nextID++
+		// The groups of a family are contiguous, and don't overlap the
+		// reserved ones.
+		n := uint32(len(f.MulticastGroups))
+		if n > 0 && nextGroup <= lastReservedGroup && nextGroup+n > linux.GENL_ID_CTRL {
+			nextGroup = lastReservedGroup + 1
+		}
+		for i := range f.MulticastGroups {
+			f.MulticastGroups[i].id = nextGroup
+			nextGroup++
+		}
+	}
+	families = append(families, f)
+	familiesByName[f.Name] = f
+}
+
+// groupByID returns the registered multicast group of id, or nil if there is
+// none.
+func groupByID(id uint32) *MulticastGroup {
+	for _, f := range families {
+		for i := range f.MulticastGroups {
+			if f.MulticastGroups[i].id == id {
+				return &f.MulticastGroups[i]
+			}
+		}
+	}
+	return nil
+}
+
+// familyByID returns the registered family of id, or nil if there is none.
+func familyByID(id uint16) *Family {
+	for _, f := range families {
+		if f.id == id {
+			return f
+		}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genetlink
+
+import (
+	"bytes"
+	"sort"
+
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/marshal/primitive"
+	"gvisor.dev/gvisor/pkg/sentry/socket/netlink/nlmsg"
+	"gvisor.dev/gvisor/pkg/syserr"
+)
+
+// AttrPolicy is the policy of an attribute, like struct nla_policy.
+type AttrPolicy struct {
+	// Type is the type of the attribute, one of linux.NL_ATTR_TYPE_*.
+	Type uint32
+
+	// MinLen and MaxLen bound the length of binary attributes, and MaxLen
+	// bounds the length of strings, excluding the terminating NUL. Zero
+	// means no bound.
+	MinLen int
+	MaxLen int
+
+	// Nested is the policy of the attributes in NL_ATTR_TYPE_NESTED
+	// attributes, or in the entries of NL_ATTR_TYPE_NESTED_ARRAY ones. If
+	// nil, they aren't validated.
+	Nested Policy
+}
+
+// Policy maps attribute types to their policy. Attributes of greater types
+// than the greatest type in the policy are rejected, and the ones of other
+// types without policy aren't validated.
+type Policy map[uint16]AttrPolicy
+
+// maxType returns the greatest attribute type of p.
+func (p Policy) maxType() uint16 {
+	var max uint16
+	for atype := range p {
+		if atype > max {
+			max = atype
+		}
+	}
+	return max
+}
+
+// intSize returns the size of integer attributes of type t, or 0 for other
+// types.
+func intSize(t uint32) int {
+	switch t {
+	case linux.NL_ATTR_TYPE_U8, linux.NL_ATTR_TYPE_S8:
+		return 1
+	case linux.NL_ATTR_TYPE_U16, linux.NL_ATTR_TYPE_S16:
+		return 2
+	case linux.NL_ATTR_TYPE_U32, linux.NL_ATTR_TYPE_S32:
+		return 4
+	case linux.NL_ATTR_TYPE_U64, linux.NL_ATTR_TYPE_S64, linux.NL_ATTR_TYPE_BITFIELD32:
+		return 8
+	default:
+		return 0
+	}
+}
+
+// validate checks the attributes in v against p and maxType, like
+// lib/nlattr.c:__nla_validate_parse. As in strict mode, attributes of unknown
+// types are rejected.
+func (p Policy) validate(v nlmsg.AttrsView, maxType uint16) *syserr.Error {
+	for !v.Empty() {
+		hdr, value, rest, ok := v.ParseFirst()
+		if !ok {
+			return syserr.ErrInvalidArgument
+		}
+		v = rest
+		atype := hdr.Type & linux.NLA_TYPE_MASK
+		if atype == 0 || atype > maxType {
+			return syserr.ErrInvalidArgument
+		}
+		if ap, ok := p[atype]; ok {
+			if err := ap.validate(value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validate checks the value of an attribute against ap.
+func (ap *AttrPolicy) validate(value []byte) *syserr.Error {
+	if size := intSize(ap.Type); size != 0 {
+		if len(value) < size {
+			return syserr.ErrRange
+		}
+		return nil
+	}
+	switch ap.Type {
+	case linux.NL_ATTR_TYPE_FLAG:
+		if len(value) != 0 {
+			return syserr.ErrRange
+		}
+	case linux.NL_ATTR_TYPE_STRING:
+		if ap.MaxLen != 0 && len(bytes.TrimSuffix(value, []byte{0})) > ap.MaxLen {
+			return syserr.ErrRange
+		}
+	case linux.NL_ATTR_TYPE_NUL_STRING:
+		n := len(value)
+		if ap.MaxLen != 0 && n > ap.MaxLen+1 {
+			n = ap.MaxLen + 1
+		}
+		if n == 0 || bytes.IndexByte(value[:n], 0) < 0 {
+			return syserr.ErrInvalidArgument
+		}
+	case linux.NL_ATTR_TYPE_BINARY:
+		if (ap.MaxLen != 0 && len(value) > ap.MaxLen) || len(value) < ap.MinLen {
+			return syserr.ErrRange
+		}
+	case linux.NL_ATTR_TYPE_NESTED:
+		if ap.Nested != nil && len(value) != 0 {
+			return ap.Nested.validate(nlmsg.AttrsView(value), ap.Nested.maxType())
+		}
+	case linux.NL_ATTR_TYPE_NESTED_ARRAY:
+		for entries := nlmsg.AttrsView(value); !entries.Empty(); {
+			_, entry, rest, ok := entries.ParseFirst()
+			if !ok {
+				return syserr.ErrInvalidArgument
+			}
+			entries = rest
+			if ap.Nested != nil {
+				if err := ap.Nested.validate(nlmsg.AttrsView(entry), ap.Nested.maxType()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// policyTable holds the policies of a family and the policies nested in them,
+// which are identified by their index in policy dumps.
+type policyTable struct {
+	policies []Policy
+}
+
+// add adds p to t, and returns its index.
+func (t *policyTable) add(p Policy) uint32 {
+	t.policies = append(t.policies, p)
+	return uint32(len(t.policies) - 1)
+}
+
+// putAttrs adds the attributes describing the policy of atype in the idx'th
+// policy of t to attrs, and adds the policies nested in it to t.
+func (t *policyTable) putAttrs(attrs *nlmsg.Attrs, idx uint32, atype uint16) {
+	ap := t.policies[idx][atype]
+	attrs.PutAttr(linux.NL_POLICY_TYPE_ATTR_TYPE, primitive.AllocateUint32(ap.Type))
+	if size := intSize(ap.Type); size != 0 && ap.Type != linux.NL_ATTR_TYPE_BITFIELD32 {
+		// Integers are valid in the whole range of their type.
+		switch ap.Type {
+		case linux.NL_ATTR_TYPE_U8, linux.NL_ATTR_TYPE_U16, linux.NL_ATTR_TYPE_U32, linux.NL_ATTR_TYPE_U64:
+			attrs.PutAttr(linux.NL_POLICY_TYPE_ATTR_MIN_VALUE_U, primitive.AllocateUint64(0))
+			attrs.PutAttr(linux.NL_POLICY_TYPE_ATTR_MAX_VALUE_U, primitive.AllocateUint64(^uint64(0)>>(64-8*size)))
+		default:
+			attrs.PutAttr(linux.NL_POLICY_TYPE_ATTR_MIN_VALUE_S, primitive.AllocateInt64(-1<<(8*size-1)))
+			attrs.PutAttr(linux.NL_POLICY_TYPE_ATTR_MAX_VALUE_S, primitive.AllocateInt64(int64(^uint64(0)>>(65-8*size))))
+		}
+		return
+	}
+	switch ap.Type {
+	case linux.NL_ATTR_TYPE_STRING, linux.NL_ATTR_TYPE_NUL_STRING, linux.NL_ATTR_TYPE_BINARY:
+		if ap.MinLen != 0 {
+			attrs.PutAttr(linux.NL_POLICY_TYPE_ATTR_MIN_LENGTH, primitive.AllocateUint32(uint32(ap.MinLen)))
+		}
+		if ap.MaxLen != 0 {
+			attrs.PutAttr(linux.NL_POLICY_TYPE_ATTR_MAX_LENGTH, primitive.AllocateUint32(uint32(ap.MaxLen)))
+		}
+	case linux.NL_ATTR_TYPE_NESTED, linux.NL_ATTR_TYPE_NESTED_ARRAY:
+		if ap.Nested != nil {
+			maxType := ap.Nested.maxType()
+			attrs.PutAttr(linux.NL_POLICY_TYPE_ATTR_POLICY_IDX, primitive.AllocateUint32(t.add(ap.Nested)))
+			attrs.PutAttr(linux.NL_POLICY_TYPE_ATTR_POLICY_MAXTYPE, primitive.AllocateUint32(uint32(maxType)))
+		}
+	}
+}
+
+// sortedTypes returns the attribute types of p in increasing order.
+func (p Policy) sortedTypes() []uint16 {
+	types := make([]uint16, 0, len(p))
+	for atype := range p {
+		types = append(types, atype)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
@@ -0,0 +1,172 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genetlink
+
+import (
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/marshal/primitive"
+	"gvisor.dev/gvisor/pkg/sentry/socket/netlink/nlmsg"
+	"gvisor.dev/gvisor/pkg/syserr"
+)
+
+const (
+	attrU32 = iota + 1
+	attrName
+	attrKey
+	attrFlag
+	attrNested
+	attrArray
+)
+
+var testPolicy = Policy{
+	attrU32:  {Type: linux.NL_ATTR_TYPE_U32},
+	attrName: {Type: linux.NL_ATTR_TYPE_NUL_STRING, MaxLen: 7},
+	attrKey:  {Type: linux.NL_ATTR_TYPE_BINARY, MinLen: 4, MaxLen: 4},
+	attrFlag: {Type: linux.NL_ATTR_TYPE_FLAG},
+	attrNested: {
+		Type:   linux.NL_ATTR_TYPE_NESTED,
+		Nested: Policy{attrU32: {Type: linux.NL_ATTR_TYPE_U32}},
+	},
+	attrArray: {
+		Type:   linux.NL_ATTR_TYPE_NESTED_ARRAY,
+		Nested: Policy{attrU32: {Type: linux.NL_ATTR_TYPE_U32}},
+	},
+}
+
+func nested(atype uint16, v []byte) nlmsg.Attrs {
+	var a nlmsg.Attrs
+	a.PutAttr(atype, primitive.AsByteSlice(v))
+	return a
+}
+
+func TestPolicyValidate(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		attrs func(a *nlmsg.Attrs)
+		want  *syserr.Error
+	}{
+		{
+			name: "valid",
+			attrs: func(a *nlmsg.Attrs) {
+				a.PutAttr(attrU32, primitive.AllocateUint32(1))
+				a.PutAttrString(attrName, "eth0")
+				a.PutAttr(attrKey, primitive.AsByteSlice([]byte{1, 2, 3, 4}))
+				a.PutAttr(attrFlag, primitive.AsByteSlice(nil))
+				a.PutAttr(attrNested|linux.NLA_F_NESTED, primitive.AsByteSlice(nested(attrU32, []byte{1, 2, 3, 4})))
+				entry := nested(attrU32, []byte{1, 2, 3, 4})
+				a.PutAttr(attrArray|linux.NLA_F_NESTED, primitive.AsByteSlice(nested(linux.NLA_F_NESTED, entry)))
+			},
+		},
+		{
+			name: "short integer",
+			attrs: func(a *nlmsg.Attrs) {
+				a.PutAttr(attrU32, primitive.AllocateUint16(1))
+			},
+			want: syserr.ErrRange,
+		},
+		{
+			name: "long string",
+			attrs: func(a *nlmsg.Attrs) {
+				a.PutAttrString(attrName, "too long name")
+			},
+			want: syserr.ErrInvalidArgument,
+		},
+		{
+			name: "string without NUL",
+			attrs: func(a *nlmsg.Attrs) {
+				a.PutAttr(attrName, primitive.AsByteSlice([]byte("eth0")))
+			},
+			want: syserr.ErrInvalidArgument,
+		},
+		{
+			name: "short binary",
+			attrs: func(a *nlmsg.Attrs) {
+				a.PutAttr(attrKey, primitive.AsByteSlice([]byte{1, 2, 3}))
+			},
+			want: syserr.ErrRange,
+		},
+		{
+			name: "flag with value",
+			attrs: func(a *nlmsg.Attrs) {
+				a.PutAttr(attrFlag, primitive.AllocateUint32(1))
+			},
+			want: syserr.ErrRange,
+		},
+		{
+			name: "invalid nested attribute",
+			attrs: func(a *nlmsg.Attrs) {
+				a.PutAttr(attrNested, primitive.AsByteSlice(nested(attrU32, []byte{1})))
+			},
+			want: syserr.ErrRange,
+		},
+		{
+			name: "invalid array entry",
+			attrs: func(a *nlmsg.Attrs) {
+				entry := nested(attrName, []byte{1, 2, 3, 4})
+				a.PutAttr(attrArray, primitive.AsByteSlice(nested(1, entry)))
+			},
+			want: syserr.ErrInvalidArgument,
+		},
+		{
+			name: "unknown attribute",
+			attrs: func(a *nlmsg.Attrs) {
+				a.PutAttr(attrArray+1, primitive.AllocateUint32(1))
+			},
+			want: syserr.ErrInvalidArgument,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var a nlmsg.Attrs
+			test.attrs(&a)
+			if got := testPolicy.validate(nlmsg.AttrsView(a), attrArray); got != test.want {
+				t.Errorf("validate() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRegisterFamilyGroups(t *testing.T) {
+	// Allocate groups that would overlap the reserved ones.
+	savedID, savedGroup := nextID, nextGroup
+	defer func() { nextID, nextGroup = savedID, savedGroup }()
+	nextGroup = linux.GENL_ID_CTRL - 1
+
+	f := &Family{
+		Name: "test_groups",
+		MulticastGroups: []MulticastGroup{
+			{Name: "first"},
+			{Name: "second"},
+		},
+	}
+	RegisterFamily(f)
+	defer func() {
+		families = families[:len(families)-1]
+		delete(familiesByName, f.Name)
+	}()
+
+	for i, g := range f.MulticastGroups {
+		if want := uint32(lastReservedGroup + 1 + i); g.id != want {
+			t.Errorf("group %q has id %d, want %d", g.Name, g.id, want)
+		}
+		if got := groupByID(g.id); got != &f.MulticastGroups[i] {
+			t.Errorf("groupByID(%d) = %v, want %v", g.id, got, &f.MulticastGroups[i])
+		}
+	}
+	if got := groupByID(linux.GENL_ID_CTRL); got != &controller.MulticastGroups[0] {
+		t.Errorf("groupByID(GENL_ID_CTRL) = %v, want the controller notify group", got)
+	}
+}
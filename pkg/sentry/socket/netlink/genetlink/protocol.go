@@ -0,0 +1,318 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package genetlink provides a NETLINK_GENERIC socket protocol.
+//
+// Generic netlink multiplexes families, which sentry subsystems register with
+// RegisterFamily. Userspace resolves the identifiers of families by name
+// with the controller family, which is always registered.
+package genetlink
+
+import (
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/marshal/primitive"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
+	"gvisor.dev/gvisor/pkg/sentry/socket/netlink"
+	"gvisor.dev/gvisor/pkg/sentry/socket/netlink/nlmsg"
+	"gvisor.dev/gvisor/pkg/syserr"
+)
+
+// Protocol implements netlink.Protocol.
+//
+// +stateify savable
+type Protocol struct{}
+
+var _ netlink.MulticastProtocol = (*Protocol)(nil)
+
+// NewProtocol creates a NETLINK_GENERIC netlink.Protocol.
+func NewProtocol(t *kernel.Task) (netlink.Protocol, *syserr.Error) {
+	return &Protocol{}, nil
+}
+
+// Protocol implements netlink.Protocol.Protocol.
+func (p *Protocol) Protocol() int {
+	return linux.NETLINK_GENERIC
+}
+
+// CanSend implements netlink.Protocol.CanSend.
+func (p *Protocol) CanSend() bool {
+	return true
+}
+
+// ProcessMessage implements netlink.Protocol.ProcessMessage.
+func (p *Protocol) ProcessMessage(ctx context.Context, s *netlink.Socket, msg *nlmsg.Message, ms *nlmsg.MessageSet) *syserr.Error {
+	hdr := msg.Header()
+	f := familyByID(hdr.Type)
+	if f == nil {
+		return syserr.ErrNoFileOrDir
+	}
+	req := Request{
+		Family: f,
+		Header: hdr,
+	}
+	attrs, ok := msg.GetData(&req.GenericHeader)
+	if !ok {
+		return syserr.ErrInvalidArgument
+	}
+	b := nlmsg.BytesView(attrs)
+	if _, ok := b.Extract(int(f.HdrSize)); !ok {
+		return syserr.ErrInvalidArgument
+	}
+	req.Attrs = nlmsg.AttrsView(b)
+
+	op := f.op(req.GenericHeader.Cmd)
+	if op == nil {
+		return syserr.ErrNotSupported
+	}
+	if op.Flags&(linux.GENL_ADMIN_PERM|linux.GENL_UNS_ADMIN_PERM) != 0 {
+		creds := auth.CredentialsFromContext(ctx)
+		if !creds.HasCapability(linux.CAP_NET_ADMIN) {
+			return syserr.ErrPermissionDenied
+		}
+	}
+	if f.Policy != nil {
+		if err := f.Policy.validate(req.Attrs, uint16(f.MaxAttr)); err != nil {
+			return err
+		}
+	}
+
+	if hdr.Flags&linux.NLM_F_DUMP == linux.NLM_F_DUMP {
+		if op.DumpIt == nil {
+			return syserr.ErrNotSupported
+		}
+		ms.Multi = true
+		if err := op.DumpIt(ctx, s, &req, ms); err != nil {
+			// Like in Linux, failed dumps are only answered with
+			// the error.
+			ms.Multi = false
+			ms.Messages = nil
+			return err
+		}
+		return nil
+	}
+	if op.DoIt == nil {
+		return syserr.ErrNotSupported
+	}
+	return op.DoIt(ctx, s, &req, ms)
+}
+
+// JoinGroup implements netlink.MulticastProtocol.JoinGroup.
+func (p *Protocol) JoinGroup(ctx context.Context, s *netlink.Socket, group uint32) *syserr.Error {
+	// Like in Linux, groups that aren't registered can be joined, and
+	// never receive messages.
+	g := groupByID(group)
+	if g == nil {
+		return nil
+	}
+	if g.Flags&(linux.GENL_ADMIN_PERM|linux.GENL_UNS_ADMIN_PERM) != 0 {
+		creds := auth.CredentialsFromContext(ctx)
+		if !creds.HasCapability(linux.CAP_NET_ADMIN) {
+			return syserr.ErrPermissionDenied
+		}
+	}
+	return nil
+}
+
+// controller is the family that describes the registered families.
+var controller = Family{
+	Name:    "nlctrl",
+	Version: 2,
+	MaxAttr: linux.CTRL_ATTR_OP,
+	Ops: []Op{
+		{
+			Cmd:    linux.CTRL_CMD_GETFAMILY,
+			DoIt:   getFamily,
+			DumpIt: dumpFamilies,
+		},
+		{
+			Cmd:    linux.CTRL_CMD_GETPOLICY,
+			DumpIt: dumpPolicy,
+		},
+	},
+	Policy: Policy{
+		linux.CTRL_ATTR_FAMILY_ID:   {Type: linux.NL_ATTR_TYPE_U16},
+		linux.CTRL_ATTR_FAMILY_NAME: {Type: linux.NL_ATTR_TYPE_NUL_STRING, MaxLen: linux.GENL_NAMSIZ - 1},
+		linux.CTRL_ATTR_OP:          {Type: linux.NL_ATTR_TYPE_U32},
+	},
+	MulticastGroups: []MulticastGroup{
+		{
+			Name: "notify",
+			id:   linux.GENL_ID_CTRL,
+		},
+	},
+	id: linux.GENL_ID_CTRL,
+}
+
+// addFamilyMessage adds a CTRL_CMD_NEWFAMILY message describing f to ms in
+// reply to req.
+func addFamilyMessage(req *Request, ms *nlmsg.MessageSet, f *Family) {
+	m := req.Family.AddMessage(ms, linux.CTRL_CMD_NEWFAMILY)
+	m.PutAttrString(linux.CTRL_ATTR_FAMILY_NAME, f.Name)
+	m.PutAttr(linux.CTRL_ATTR_FAMILY_ID, primitive.AllocateUint16(f.id))
+	m.PutAttr(linux.CTRL_ATTR_VERSION, primitive.AllocateUint32(uint32(f.Version)))
+	m.PutAttr(linux.CTRL_ATTR_HDRSIZE, primitive.AllocateUint32(f.HdrSize))
+	m.PutAttr(linux.CTRL_ATTR_MAXATTR, primitive.AllocateUint32(f.MaxAttr))
+
+	if len(f.Ops) == 0 {
+		return
+	}
+	var ops nlmsg.Attrs
+	for i, op := range f.Ops {
+		flags := op.Flags
+		if op.DoIt != nil {
+			flags |= linux.GENL_CMD_CAP_DO
+		}
+		if op.DumpIt != nil {
+			flags |= linux.GENL_CMD_CAP_DUMP
+		}
+		if f.Policy != nil {
+			flags |= linux.GENL_CMD_CAP_HASPOL
+		}
+		var attrs nlmsg.Attrs
+		attrs.PutAttr(linux.CTRL_ATTR_OP_ID, primitive.AllocateUint32(uint32(op.Cmd)))
+		attrs.PutAttr(linux.CTRL_ATTR_OP_FLAGS, primitive.AllocateUint32(flags))
+		ops.PutAttr(uint16(i+1), primitive.AsByteSlice(attrs))
+	}
+	m.PutAttr(linux.CTRL_ATTR_OPS, primitive.AsByteSlice(ops))
+
+	if len(f.MulticastGroups) == 0 {
+		return
+	}
+	var groups nlmsg.Attrs
+	for i, g := range f.MulticastGroups {
+		var attrs nlmsg.Attrs
+		attrs.PutAttr(linux.CTRL_ATTR_MCAST_GRP_ID, primitive.AllocateUint32(g.id))
+		attrs.PutAttrString(linux.CTRL_ATTR_MCAST_GRP_NAME, g.Name)
+		groups.PutAttr(uint16(i+1), primitive.AsByteSlice(attrs))
+	}
+	m.PutAttr(linux.CTRL_ATTR_MCAST_GROUPS, primitive.AsByteSlice(groups))
+}
+
+// lookupFamily returns the family named by CTRL_ATTR_FAMILY_ID or
+// CTRL_ATTR_FAMILY_NAME.
+func lookupFamily(attrs map[uint16]nlmsg.BytesView) (*Family, *syserr.Error) {
+	var f *Family
+	if v, ok := attrs[linux.CTRL_ATTR_FAMILY_ID]; ok {
+		id, ok := v.Uint16()
+		if !ok {
+			return nil, syserr.ErrInvalidArgument
+		}
+		f = familyByID(id)
+	} else if v, ok := attrs[linux.CTRL_ATTR_FAMILY_NAME]; ok {
+		f = familiesByName[v.String()]
+	} else {
+		return nil, syserr.ErrInvalidArgument
+	}
+	if f == nil {
+		return nil, syserr.ErrNoFileOrDir
+	}
+	return f, nil
+}
+
+// getFamily handles CTRL_CMD_GETFAMILY requests, which look a family up by
+// identifier or by name.
+func getFamily(ctx context.Context, s *netlink.Socket, req *Request, ms *nlmsg.MessageSet) *syserr.Error {
+	attrs, ok := req.Attrs.Parse()
+	if !ok {
+		return syserr.ErrInvalidArgument
+	}
+	f, err := lookupFamily(attrs)
+	if err != nil {
+		return err
+	}
+	addFamilyMessage(req, ms, f)
+	return nil
+}
+
+// dumpFamilies handles CTRL_CMD_GETFAMILY dump requests.
+func dumpFamilies(ctx context.Context, s *netlink.Socket, req *Request, ms *nlmsg.MessageSet) *syserr.Error {
+	for _, f := range families {
+		addFamilyMessage(req, ms, f)
+	}
+	return nil
+}
+
+// dumpPolicy handles CTRL_CMD_GETPOLICY dump requests, which describe the
+// policy of a family, or of one of its operations if CTRL_ATTR_OP is set.
+func dumpPolicy(ctx context.Context, s *netlink.Socket, req *Request, ms *nlmsg.MessageSet) *syserr.Error {
+	attrs, ok := req.Attrs.Parse()
+	if !ok {
+		return syserr.ErrInvalidArgument
+	}
+	f, err := lookupFamily(attrs)
+	if err != nil {
+		return err
+	}
+	ops := f.Ops
+	if v, ok := attrs[linux.CTRL_ATTR_OP]; ok {
+		cmd, ok := v.Uint32()
+		if !ok {
+			return syserr.ErrInvalidArgument
+		}
+		if cmd > 0xff {
+			return syserr.ErrNoFileOrDir
+		}
+		op := f.op(uint8(cmd))
+		if op == nil {
+			return syserr.ErrNoFileOrDir
+		}
+		ops = []Op{*op}
+	}
+	if f.Policy == nil {
+		return syserr.ErrNoDataAvailable
+	}
+
+	// All operations share the policy of the family, whose index is 0.
+	for _, op := range ops {
+		var policies nlmsg.Attrs
+		if op.DoIt != nil {
+			policies.PutAttr(linux.CTRL_ATTR_POLICY_DO, primitive.AllocateUint32(0))
+		}
+		if op.DumpIt != nil {
+			policies.PutAttr(linux.CTRL_ATTR_POLICY_DUMP, primitive.AllocateUint32(0))
+		}
+		var opAttrs nlmsg.Attrs
+		opAttrs.PutAttr(uint16(op.Cmd)|linux.NLA_F_NESTED, primitive.AsByteSlice(policies))
+		m := req.Family.AddMessage(ms, linux.CTRL_CMD_GETPOLICY)
+		m.PutAttr(linux.CTRL_ATTR_FAMILY_ID, primitive.AllocateUint16(f.id))
+		m.PutAttr(linux.CTRL_ATTR_OP_POLICY|linux.NLA_F_NESTED, primitive.AsByteSlice(opAttrs))
+	}
+
+	// Like in Linux, each attribute of each policy is described in its own
+	// message. Nested policies are added to t while they are described.
+	var t policyTable
+	t.add(f.Policy)
+	for idx := 0; idx < len(t.policies); idx++ {
+		for _, atype := range t.policies[idx].sortedTypes() {
+			var attr nlmsg.Attrs
+			t.putAttrs(&attr, uint32(idx), atype)
+			var policy nlmsg.Attrs
+			policy.PutAttr(atype|linux.NLA_F_NESTED, primitive.AsByteSlice(attr))
+			var policies nlmsg.Attrs
+			policies.PutAttr(uint16(idx)|linux.NLA_F_NESTED, primitive.AsByteSlice(policy))
+			m := req.Family.AddMessage(ms, linux.CTRL_CMD_GETPOLICY)
+			m.PutAttr(linux.CTRL_ATTR_FAMILY_ID, primitive.AllocateUint16(f.id))
+			m.PutAttr(linux.CTRL_ATTR_POLICY|linux.NLA_F_NESTED, primitive.AsByteSlice(policies))
+		}
+	}
+	return nil
+}
+
+// init registers the NETLINK_GENERIC provider and the controller family.
+func init() {
+	RegisterFamily(&controller)
+	netlink.RegisterProvider(linux.NETLINK_GENERIC, NewProtocol)
+}
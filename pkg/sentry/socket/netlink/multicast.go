@@ -0,0 +1,153 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netlink
+
+import (
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/sentry/inet"
+	"gvisor.dev/gvisor/pkg/sentry/socket/netlink/nlmsg"
+	"gvisor.dev/gvisor/pkg/sync"
+	"gvisor.dev/gvisor/pkg/syserr"
+)
+
+// MulticastProtocol is a Protocol with multicast groups, which sockets join
+// with bind(2) or NETLINK_ADD_MEMBERSHIP.
+//
+// Sockets of other protocols can't join multicast groups.
+type MulticastProtocol interface {
+	Protocol
+
+	// JoinGroup checks that the socket may join group, which is never 0.
+	JoinGroup(ctx context.Context, s *Socket, group uint32) *syserr.Error
+}
+
+// members holds the sockets that are members of multicast groups. It isn't
+// saved, as sockets add themselves back to it on restore.
+var members struct {
+	mu sync.Mutex
+
+	// groups maps protocols and their groups to the member sockets.
+	groups map[int]map[uint32]map[*Socket]struct{}
+}
+
+// addMember adds s to the members of group.
+func addMember(protocol int, group uint32, s *Socket) {
+	members.mu.Lock()
+	defer members.mu.Unlock()
+	if members.groups == nil {
+		members.groups = make(map[int]map[uint32]map[*Socket]struct{})
+	}
+	groups, ok := members.groups[protocol]
+	if !ok {
+		groups = make(map[uint32]map[*Socket]struct{})
+		members.groups[protocol] = groups
+	}
+	sockets, ok := groups[group]
+	if !ok {
+		sockets = make(map[*Socket]struct{})
+		groups[group] = sockets
+	}
+	sockets[s] = struct{}{}
+}
+
+// removeMember removes s from the members of group.
+func removeMember(protocol int, group uint32, s *Socket) {
+	members.mu.Lock()
+	defer members.mu.Unlock()
+	groups := members.groups[protocol]
+	delete(groups[group], s)
+	if len(groups[group]) == 0 {
+		delete(groups, group)
+	}
+}
+
+// Multicast sends the messages in ms to the sockets of protocol that are
+// members of group in netns, or in any network namespace if netns is nil.
+//
+// Like in Linux, messages are dropped for members whose receive buffer is
+// full.
+func Multicast(ctx context.Context, protocol int, group uint32, netns *inet.Namespace, ms *nlmsg.MessageSet) {
+	members.mu.Lock()
+	var sockets []*Socket
+	for s := range members.groups[protocol][group] {
+		if netns == nil || s.netns == netns {
+			sockets = append(sockets, s)
+		}
+	}
+	members.mu.Unlock()
+
+	for _, s := range sockets {
+		s.sendResponse(ctx, ms)
+	}
+}
+
+// joinGroup adds s to the members of group.
+//
+// Preconditions: s.mu is locked.
+func (s *Socket) joinGroup(ctx context.Context, group uint32) *syserr.Error {
+	p, ok := s.protocol.(MulticastProtocol)
+	if !ok {
+		return syserr.ErrPermissionDenied
+	}
+	if group == 0 {
+		return syserr.ErrInvalidArgument
+	}
+	if _, ok := s.groups[group]; ok {
+		return nil
+	}
+	if err := p.JoinGroup(ctx, s, group); err != nil {
+		return err
+	}
+	if s.groups == nil {
+		s.groups = make(map[uint32]struct{})
+	}
+	s.groups[group] = struct{}{}
+	addMember(p.Protocol(), group, s)
+	return nil
+}
+
+// leaveGroup removes s from the members of group.
+//
+// Preconditions: s.mu is locked.
+func (s *Socket) leaveGroup(group uint32) {
+	if _, ok := s.groups[group]; !ok {
+		return
+	}
+	delete(s.groups, group)
+	removeMember(s.protocol.Protocol(), group, s)
+}
+
+// memberships returns the bitmap of the groups of s, as returned by
+// NETLINK_LIST_MEMBERSHIPS.
+//
+// Preconditions: s.mu is locked.
+func (s *Socket) memberships() []uint32 {
+	var bitmap []uint32
+	for group := range s.groups {
+		i := (group - 1) / 32
+		for uint32(len(bitmap)) <= i {
+			bitmap = append(bitmap, 0)
+		}
+		bitmap[i] |= 1 << ((group - 1) % 32)
+	}
+	return bitmap
+}
+
+// afterLoad is invoked by stateify.
+func (s *Socket) afterLoad(context.Context) {
+	for group := range s.groups {
+		addMember(s.protocol.Protocol(), group, s)
+	}
+}
@@ -157,37 +157,63 @@ func (m *Message) Put(v marshal.Marshallable) {
 // Preconditions: The serialized attribute (linux.NetlinkAttrHeaderSize +
 // v.SizeBytes()) fits in math.MaxUint16 bytes.
 func (m *Message) PutAttr(atype uint16, v marshal.Marshallable) {
+	m.buf = appendAttr(m.buf, atype, v)
+}
+
+// appendAttr appends v to buf as a netlink attribute.
+func appendAttr(buf []byte, atype uint16, v marshal.Marshallable) []byte {
 	l := linux.NetlinkAttrHeaderSize + v.SizeBytes()
 	if l > math.MaxUint16 {
 		panic(fmt.Sprintf("attribute too large: %d", l))
 	}
 
-	m.Put(&linux.NetlinkAttrHeader{
+	buf = append(buf, marshal.Marshal(&linux.NetlinkAttrHeader{
 		Type:   atype,
 		Length: uint16(l),
-	})
-	m.Put(v)
+	})...)
+	buf = append(buf, marshal.Marshal(v)...)
 
 	// Align the attribute.
 	aligned := bits.AlignUp(l, linux.NLA_ALIGNTO)
-	m.putZeros(aligned - l)
+	return append(buf, make([]byte, aligned-l)...)
 }
 
 // PutAttrString adds s to the message as a netlink attribute.
 func (m *Message) PutAttrString(atype uint16, s string) {
+	m.buf = appendAttrString(m.buf, atype, s)
+}
+
+// appendAttrString appends s to buf as a NUL-terminated netlink attribute.
+func appendAttrString(buf []byte, atype uint16, s string) []byte {
 	l := linux.NetlinkAttrHeaderSize + len(s) + 1
-	m.Put(&linux.NetlinkAttrHeader{
+	buf = append(buf, marshal.Marshal(&linux.NetlinkAttrHeader{
 		Type:   atype,
 		Length: uint16(l),
-	})
+	})...)
 
 	// String + NUL-termination.
-	m.Put(primitive.AsByteSlice([]byte(s)))
-	m.putZeros(1)
+	buf = append(buf, s...)
+	buf = append(buf, 0)
 
 	// Align the attribute.
 	aligned := bits.AlignUp(l, linux.NLA_ALIGNTO)
-	m.putZeros(aligned - l)
+	return append(buf, make([]byte, aligned-l)...)
+}
+
+// Attrs is a serialized list of netlink attributes, which is the value of a
+// nested attribute.
+type Attrs []byte
+
+// PutAttr adds v to the list as a netlink attribute.
+//
+// Preconditions: Same as Message.PutAttr.
+func (a *Attrs) PutAttr(atype uint16, v marshal.Marshallable) {
+	*a = appendAttr(*a, atype, v)
+}
+
+// PutAttrString adds s to the list as a netlink attribute.
+func (a *Attrs) PutAttrString(atype uint16, s string) {
+	*a = appendAttrString(*a, atype, s)
 }
 
 // MessageSet contains a series of netlink messages.
@@ -269,7 +295,8 @@ func (v AttrsView) ParseFirst() (hdr linux.NetlinkAttrHeader, value []byte, rest
 	return hdr, value, AttrsView(b), ok
 }
 
-// Parse parses netlink attributes.
+// Parse parses netlink attributes. The attributes are keyed by their type
+// without the NLA_F_NESTED and NLA_F_NET_BYTEORDER flags, like in Linux.
 func (v AttrsView) Parse() (map[uint16]BytesView, bool) {
 	attrs := make(map[uint16]BytesView)
 	attrsView := v
@@ -280,7 +307,7 @@ func (v AttrsView) Parse() (map[uint16]BytesView, bool) {
 			return nil, false
 		}
 		attrsView = rest
-		attrs[ahdr.Type] = BytesView(value)
+		attrs[ahdr.Type&linux.NLA_TYPE_MASK] = BytesView(value)
 	}
 	return attrs, true
 
@@ -312,6 +339,17 @@ func (v *BytesView) String() string {
 	return string(b)
 }
 
+// Uint16 converts the raw attribute value to uint16.
+func (v *BytesView) Uint16() (uint16, bool) {
+	attr := []byte(*v)
+	val := primitive.Uint16(0)
+	if len(attr) != val.SizeBytes() {
+		return 0, false
+	}
+	val.UnmarshalBytes(attr)
+	return uint16(val), true
+}
+
 // Uint32 converts the raw attribute value to uint32.
 func (v *BytesView) Uint32() (uint32, bool) {
 	attr := []byte(*v)
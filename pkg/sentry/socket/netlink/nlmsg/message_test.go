@@ -302,6 +302,21 @@ func TestAttrView(t *testing.T) {
 	}
 }
 
+func TestAttrViewParseMasksFlags(t *testing.T) {
+	attrs := nlmsg.AttrsView([]byte{
+		0x08, 0x00, // Length
+		0x08, 0x80, // Type 8 with NLA_F_NESTED
+		0x30, 0x31, 0x32, 0x33, // Data
+	})
+	parsed, ok := attrs.Parse()
+	if !ok {
+		t.Fatalf("Parse failed")
+	}
+	if got, want := []byte(parsed[8]), []byte{0x30, 0x31, 0x32, 0x33}; !bytes.Equal(got, want) {
+		t.Errorf("got attribute 8 = %v, want = %v", got, want)
+	}
+}
+
 type bytesViewTest[T any] struct {
 	desc  string
 	input nlmsg.BytesView
@@ -201,6 +201,11 @@ func (p *Protocol) delLink(ctx context.Context, s *netlink.Socket, msg *nlmsg.Me
 	if !ok {
 		return syserr.ErrInvalidArgument
 	}
+	if ifinfomsg.Family == linux.AF_BRIDGE {
+		// AF_BRIDGE requests remove the VLANs of bridges and bridge
+		// ports rather than interfaces.
+		return stack.SetInterface(ctx, msg)
+	}
 	if ifinfomsg.Index == 0 {
 		// The index is unspecified, search by the interface name.
 		ahdr, value, _, ok := attrs.ParseFirst()
@@ -255,6 +260,12 @@ func addNewLinkMessage(ms *nlmsg.MessageSet, idx int32, i inet.Interface) {
 	m.PutAttr(linux.IFLA_ADDRESS, primitive.AsByteSlice(mac))
 	m.PutAttr(linux.IFLA_BROADCAST, primitive.AsByteSlice(brd))
 
+	if i.Kind != "" {
+		var info nlmsg.Attrs
+		info.PutAttrString(linux.IFLA_INFO_KIND, i.Kind)
+		m.PutAttr(linux.IFLA_LINKINFO, primitive.AsByteSlice(info))
+	}
+
 	// TODO(gvisor.dev/issue/578): There are many more attributes.
 }
 
@@ -599,6 +610,113 @@ func (p *Protocol) delAddr(ctx context.Context, s *netlink.Socket, msg *nlmsg.Me
 	return nil
 }
 
+// addTcMessage adds a RTM_NEWQDISC or RTM_NEWTCLASS message describing tc to
+// ms.
+func addTcMessage(ms *nlmsg.MessageSet, typ uint16, tc inet.TrafficControl) {
+	m := ms.AddMessage(linux.NetlinkMessageHeader{
+		Type: typ,
+	})
+
+	m.Put(&linux.TcMessage{
+		Family: linux.AF_UNSPEC,
+		Index:  tc.Index,
+		Handle: tc.Handle,
+		Parent: tc.Parent,
+	})
+
+	m.PutAttrString(linux.TCA_KIND, tc.Kind)
+	if len(tc.Options) != 0 {
+		m.PutAttr(linux.TCA_OPTIONS, primitive.AsByteSlice(tc.Options))
+	}
+
+	var stats nlmsg.Attrs
+	stats.PutAttr(linux.TCA_STATS_BASIC, &linux.GnetStatsBasic{
+		Bytes:   tc.Stats.Bytes,
+		Packets: uint32(tc.Stats.Packets),
+	})
+	stats.PutAttr(linux.TCA_STATS_QUEUE, &linux.GnetStatsQueue{
+		Qlen:       tc.Stats.Qlen,
+		Backlog:    tc.Stats.Backlog,
+		Drops:      uint32(tc.Stats.Drops),
+		Overlimits: uint32(tc.Stats.Overlimits),
+	})
+	m.PutAttr(linux.TCA_STATS2, primitive.AsByteSlice(stats))
+}
+
+// dumpQDiscs handles RTM_GETQDISC dump requests.
+func (p *Protocol) dumpQDiscs(ctx context.Context, s *netlink.Socket, msg *nlmsg.Message, ms *nlmsg.MessageSet) *syserr.Error {
+	// We always send back an NLMSG_DONE.
+	ms.Multi = true
+
+	stack := s.Stack()
+	if stack == nil {
+		// No network devices.
+		return nil
+	}
+
+	for _, tc := range stack.QDiscs() {
+		addTcMessage(ms, linux.RTM_NEWQDISC, tc)
+	}
+	return nil
+}
+
+// dumpTrafficClasses handles RTM_GETTCLASS dump requests.
+func (p *Protocol) dumpTrafficClasses(ctx context.Context, s *netlink.Socket, msg *nlmsg.Message, ms *nlmsg.MessageSet) *syserr.Error {
+	// We always send back an NLMSG_DONE.
+	ms.Multi = true
+
+	stack := s.Stack()
+	if stack == nil {
+		// No network devices.
+		return nil
+	}
+
+	// The request may be filtered by interface. Requests that only contain
+	// the family dump the classes of all interfaces.
+	var tcm linux.TcMessage
+	msg.GetData(&tcm)
+	for _, tc := range stack.TrafficClasses(tcm.Index) {
+		addTcMessage(ms, linux.RTM_NEWTCLASS, tc)
+	}
+	return nil
+}
+
+// newQDisc handles RTM_NEWQDISC requests.
+func (p *Protocol) newQDisc(ctx context.Context, s *netlink.Socket, msg *nlmsg.Message, ms *nlmsg.MessageSet) *syserr.Error {
+	stack := s.Stack()
+	if stack == nil {
+		return syserr.ErrNoDevice
+	}
+	return stack.NewQDisc(ctx, msg)
+}
+
+// delQDisc handles RTM_DELQDISC requests.
+func (p *Protocol) delQDisc(ctx context.Context, s *netlink.Socket, msg *nlmsg.Message, ms *nlmsg.MessageSet) *syserr.Error {
+	stack := s.Stack()
+	if stack == nil {
+		return syserr.ErrNoDevice
+	}
+	return stack.RemoveQDisc(ctx, msg)
+}
+
+// newTrafficClass handles RTM_NEWTCLASS requests.
+func (p *Protocol) newTrafficClass(ctx context.Context, s *netlink.Socket, msg *nlmsg.Message, ms *nlmsg.MessageSet) *syserr.Error {
+	stack := s.Stack()
+	if stack == nil {
+		return syserr.ErrNoDevice
+	}
+	return stack.NewTrafficClass(ctx, msg)
+}
+
+// delTrafficClass handles RTM_DELTCLASS requests.
+func (p *Protocol) delTrafficClass(ctx context.Context, s *netlink.Socket, msg *nlmsg.Message, ms *nlmsg.MessageSet) *syserr.Error {
+	stack := s.Stack()
+	if stack == nil {
+		return syserr.ErrNoDevice
+	}
+	return stack.RemoveTrafficClass(ctx, msg)
+}
+
 // ProcessMessage implements netlink.Protocol.ProcessMessage.
 func (p *Protocol) ProcessMessage(ctx context.Context, s *netlink.Socket, msg *nlmsg.Message, ms *nlmsg.MessageSet) *syserr.Error {
 	hdr := msg.Header()
@@ -629,6 +747,10 @@ func (p *Protocol) ProcessMessage(ctx context.Context, s *netlink.Socket, msg *n
 			return p.dumpAddrs(ctx, s, msg, ms)
 		case linux.RTM_GETROUTE:
 			return p.dumpRoutes(ctx, s, msg, ms)
+		case linux.RTM_GETQDISC:
+			return p.dumpQDiscs(ctx, s, msg, ms)
+		case linux.RTM_GETTCLASS:
+			return p.dumpTrafficClasses(ctx, s, msg, ms)
 		default:
 			return syserr.ErrNotSupported
 		}
@@ -653,6 +775,14 @@ func (p *Protocol) ProcessMessage(ctx context.Context, s *netlink.Socket, msg *n
 			return p.newAddr(ctx, s, msg, ms)
 		case linux.RTM_DELADDR:
 			return p.delAddr(ctx, s, msg, ms)
+		case linux.RTM_NEWQDISC:
+			return p.newQDisc(ctx, s, msg, ms)
+		case linux.RTM_DELQDISC:
+			return p.delQDisc(ctx, s, msg, ms)
+		case linux.RTM_NEWTCLASS:
+			return p.newTrafficClass(ctx, s, msg, ms)
+		case linux.RTM_DELTCLASS:
+			return p.delTrafficClass(ctx, s, msg, ms)
 		default:
 			return syserr.ErrNotSupported
 		}
@@ -113,6 +113,9 @@ type Socket struct {
 
 	// netns is the network namespace associated with the socket.
 	netns *inet.Namespace
+
+	// groups holds the multicast groups of which the socket is a member.
+	groups map[uint32]struct{}
 }
 
 var _ socket.Socket = (*Socket)(nil)
@@ -162,9 +165,14 @@ func (s *Socket) Release(ctx context.Context) {
 	s.connection.Release(ctx)
 	s.ep.Close(ctx)
 
+	s.mu.Lock()
+	for group := range s.groups {
+		s.leaveGroup(group)
+	}
 	if s.bound {
 		s.ports.Release(s.protocol.Protocol(), s.portID)
 	}
+	s.mu.Unlock()
 	s.netns.DecRef(ctx)
 }
 
@@ -311,15 +319,30 @@ func (s *Socket) Bind(t *kernel.Task, sockaddr []byte) *syserr.Error {
 		return err
 	}
 
-	// No support for multicast groups yet.
-	if a.Groups != 0 {
+	if _, ok := s.protocol.(MulticastProtocol); !ok && a.Groups != 0 {
 		return syserr.ErrPermissionDenied
 	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	return s.bindPort(t, int32(a.PortID))
+	if err := s.bindPort(t, int32(a.PortID)); err != nil {
+		return err
+	}
+
+	// Like in Linux, bind(2) sets the membership of the first 32 groups.
+	if a.Groups == 0 && len(s.groups) == 0 {
+		return nil
+	}
+	for i := 0; i < 32; i++ {
+		group := uint32(i + 1)
+		if a.Groups&(1<<i) == 0 {
+			s.leaveGroup(group)
+		} else if err := s.joinGroup(t, group); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Connect implements socket.Socket.Connect.
@@ -417,11 +440,26 @@ func (s *Socket) GetSockOpt(t *kernel.Task, level int, name int, outPtr hostarch
 		}
 	case linux.SOL_NETLINK:
 		switch name {
+		case linux.NETLINK_LIST_MEMBERSHIPS:
+			if _, ok := s.protocol.(MulticastProtocol); !ok {
+				break
+			}
+			s.mu.Lock()
+			bitmap := s.memberships()
+			s.mu.Unlock()
+			if n := outLen / sizeOfInt32; n < len(bitmap) {
+				bitmap = bitmap[:n]
+			}
+			b := make([]byte, len(bitmap)*sizeOfInt32)
+			for i, v := range bitmap {
+				hostarch.ByteOrder.PutUint32(b[i*sizeOfInt32:], v)
+			}
+			return primitive.AsByteSlice(b), nil
+
 		case linux.NETLINK_BROADCAST_ERROR,
 			linux.NETLINK_CAP_ACK,
 			linux.NETLINK_DUMP_STRICT_CHK,
 			linux.NETLINK_EXT_ACK,
-			linux.NETLINK_LIST_MEMBERSHIPS,
 			linux.NETLINK_NO_ENOBUFS,
 			linux.NETLINK_PKTINFO:
 			// Not supported.
@@ -528,10 +566,27 @@ func (s *Socket) SetSockOpt(t *kernel.Task, level int, name int, opt []byte) *sy
 		}
 	case linux.SOL_NETLINK:
 		switch name {
-		case linux.NETLINK_ADD_MEMBERSHIP,
-			linux.NETLINK_BROADCAST_ERROR,
+		case linux.NETLINK_ADD_MEMBERSHIP, linux.NETLINK_DROP_MEMBERSHIP:
+			if _, ok := s.protocol.(MulticastProtocol); !ok {
+				break
+			}
+			if len(opt) < sizeOfInt32 {
+				return syserr.ErrInvalidArgument
+			}
+			group := hostarch.ByteOrder.Uint32(opt)
+			if group == 0 {
+				return syserr.ErrInvalidArgument
+			}
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if name == linux.NETLINK_DROP_MEMBERSHIP {
+				s.leaveGroup(group)
+				return nil
+			}
+			return s.joinGroup(t, group)
+
+		case linux.NETLINK_BROADCAST_ERROR,
 			linux.NETLINK_CAP_ACK,
-			linux.NETLINK_DROP_MEMBERSHIP,
 			linux.NETLINK_DUMP_STRICT_CHK,
 			linux.NETLINK_EXT_ACK,
 			linux.NETLINK_LISTEN_ALL_NSID,
@@ -554,6 +609,9 @@ func (s *Socket) GetSockName(t *kernel.Task) (linux.SockAddr, uint32, *syserr.Er
 		Family: linux.AF_NETLINK,
 		PortID: uint32(s.portID),
 	}
+	if bitmap := s.memberships(); len(bitmap) > 0 {
+		sa.Groups = bitmap[0]
+	}
 	return sa, uint32(sa.SizeBytes()), nil
 }
 
@@ -663,10 +721,18 @@ var kernelCreds = &kernelSCM{}
 
 // sendResponse sends the response messages in ms back to userspace.
 func (s *Socket) sendResponse(ctx context.Context, ms *nlmsg.MessageSet) *syserr.Error {
-	// Linux combines multiple netlink messages into a single datagram.
-	bufs := make([][]byte, 0, len(ms.Messages))
+	// Linux combines multiple netlink messages into a single datagram, up
+	// to the size of the buffer it allocates for dumps.
+	var datagrams [][][]byte
+	size := 0
 	for _, m := range ms.Messages {
-		bufs = append(bufs, m.Finalize())
+		b := m.Finalize()
+		if len(datagrams) == 0 || size+len(b) > linux.NLMSG_GOODSIZE {
+			datagrams = append(datagrams, nil)
+			size = 0
+		}
+		datagrams[len(datagrams)-1] = append(datagrams[len(datagrams)-1], b)
+		size += len(b)
 	}
 
 	// All messages are from the kernel.
@@ -674,7 +740,7 @@ func (s *Socket) sendResponse(ctx context.Context, ms *nlmsg.MessageSet) *syserr
 		Credentials: kernelCreds,
 	}
 
-	if len(bufs) > 0 {
+	for _, bufs := range datagrams {
 		// RecvMsg never receives the address, so we don't need to send
 		// one.
 		_, notify, err := s.connection.Send(ctx, bufs, cms, transport.Address{})
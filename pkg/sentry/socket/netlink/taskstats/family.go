@@ -0,0 +1,162 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package taskstats provides the TASKSTATS generic netlink family, which
+// reports the accounting statistics of tasks and thread groups like in Linux.
+//
+// Delay accounting isn't supported, and neither are the listeners of exit
+// statistics that Linux registers by CPU mask.
+package taskstats
+
+import (
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/marshal/primitive"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/sentry/mm"
+	"gvisor.dev/gvisor/pkg/sentry/socket/netlink"
+	"gvisor.dev/gvisor/pkg/sentry/socket/netlink/genetlink"
+	"gvisor.dev/gvisor/pkg/sentry/socket/netlink/nlmsg"
+	"gvisor.dev/gvisor/pkg/sentry/usage"
+	"gvisor.dev/gvisor/pkg/syserr"
+)
+
+// genlFamily is the TASKSTATS generic netlink family.
+var genlFamily = genetlink.Family{
+	Name:    linux.TASKSTATS_GENL_NAME,
+	Version: linux.TASKSTATS_GENL_VERSION,
+	MaxAttr: linux.TASKSTATS_CMD_ATTR_DEREGISTER_CPUMASK,
+	Ops: []genetlink.Op{
+		{
+			Cmd:   linux.TASKSTATS_CMD_GET,
+			Flags: linux.GENL_ADMIN_PERM,
+			DoIt:  get,
+		},
+	},
+	Policy: genetlink.Policy{
+		linux.TASKSTATS_CMD_ATTR_PID:                {Type: linux.NL_ATTR_TYPE_U32},
+		linux.TASKSTATS_CMD_ATTR_TGID:               {Type: linux.NL_ATTR_TYPE_U32},
+		linux.TASKSTATS_CMD_ATTR_REGISTER_CPUMASK:   {Type: linux.NL_ATTR_TYPE_STRING},
+		linux.TASKSTATS_CMD_ATTR_DEREGISTER_CPUMASK: {Type: linux.NL_ATTR_TYPE_STRING},
+	},
+}
+
+// get handles TASKSTATS_CMD_GET requests.
+func get(ctx context.Context, s *netlink.Socket, req *genetlink.Request, ms *nlmsg.MessageSet) *syserr.Error {
+	attrs, ok := req.Attrs.Parse()
+	if !ok {
+		return syserr.ErrInvalidArgument
+	}
+	t := kernel.TaskFromContext(ctx)
+	if t == nil {
+		return syserr.ErrInvalidArgument
+	}
+	pidns := t.PIDNamespace()
+
+	var (
+		aggrType uint16
+		idType   uint16
+		id       uint32
+		stats    linux.Taskstats
+	)
+	if _, ok := attrs[linux.TASKSTATS_CMD_ATTR_REGISTER_CPUMASK]; ok {
+		return syserr.ErrNotSupported
+	} else if _, ok := attrs[linux.TASKSTATS_CMD_ATTR_DEREGISTER_CPUMASK]; ok {
+		return syserr.ErrNotSupported
+	} else if v, ok := attrs[linux.TASKSTATS_CMD_ATTR_PID]; ok {
+		id, _ = v.Uint32()
+		target := pidns.TaskWithID(kernel.ThreadID(id))
+		if target == nil {
+			return syserr.ErrNoProcess
+		}
+		fillStats(t, target, &stats)
+		aggrType, idType = linux.TASKSTATS_TYPE_AGGR_PID, linux.TASKSTATS_TYPE_PID
+	} else if v, ok := attrs[linux.TASKSTATS_CMD_ATTR_TGID]; ok {
+		id, _ = v.Uint32()
+		tg := pidns.ThreadGroupWithID(kernel.ThreadID(id))
+		if tg == nil {
+			return syserr.ErrNoProcess
+		}
+		leader := tg.Leader()
+		if leader == nil {
+			return syserr.ErrNoProcess
+		}
+		fillStats(t, leader, &stats)
+		fillUsage(&stats, tg.CPUStats(), tg.IOUsage())
+		aggrType, idType = linux.TASKSTATS_TYPE_AGGR_TGID, linux.TASKSTATS_TYPE_TGID
+	} else {
+		return syserr.ErrInvalidArgument
+	}
+
+	var aggr nlmsg.Attrs
+	aggr.PutAttr(idType, primitive.AllocateUint32(id))
+	aggr.PutAttr(linux.TASKSTATS_TYPE_STATS, &stats)
+	m := req.Family.AddMessage(ms, linux.TASKSTATS_CMD_NEW)
+	m.PutAttr(aggrType, primitive.AsByteSlice(aggr))
+	return nil
+}
+
+// fillStats fills stats with the statistics of target, as seen by t.
+func fillStats(t, target *kernel.Task, stats *linux.Taskstats) {
+	pidns := t.PIDNamespace()
+	userns := t.UserNamespace()
+	creds := target.Credentials()
+	now := t.Kernel().RealtimeClock().Now()
+	start := target.StartTime()
+
+	stats.Version = linux.TASKSTATS_VERSION
+	stats.Nice = uint8(int8(target.Niceness()))
+	copy(stats.Comm[:], target.Name())
+	stats.UID = uint32(creds.RealKUID.In(userns).OrOverflow())
+	stats.GID = uint32(creds.RealKGID.In(userns).OrOverflow())
+	stats.PID = uint32(pidns.IDOfTask(target))
+	stats.TGID = uint32(pidns.IDOfThreadGroup(target.ThreadGroup()))
+	if parent := target.Parent(); parent != nil {
+		stats.PPID = uint32(pidns.IDOfThreadGroup(parent.ThreadGroup()))
+	}
+	stats.BTime = uint32(start.Seconds())
+	stats.BTime64 = uint64(start.Seconds())
+	stats.ETime = uint64(now.Sub(start).Microseconds())
+	stats.TGETime = stats.ETime
+	stats.HiwaterRSS = target.MaxRSS(linux.RUSAGE_SELF) / 1024
+	var m *mm.MemoryManager
+	target.WithMuLocked(func(target *kernel.Task) {
+		m = target.MemoryManager()
+	})
+	if m != nil {
+		stats.HiwaterVM = m.VirtualMemorySize() / 1024
+	}
+	fillUsage(stats, target.CPUStats(), target.IOUsage())
+}
+
+// fillUsage fills the CPU and I/O usage of stats.
+func fillUsage(stats *linux.Taskstats, cpu usage.CPUStats, io *usage.IO) {
+	stats.UTime = uint64(cpu.UserTime.Microseconds())
+	stats.STime = uint64(cpu.SysTime.Microseconds())
+	stats.UTimeScaled = stats.UTime
+	stats.STimeScaled = stats.STime
+	stats.NVCSw = cpu.VoluntarySwitches
+	stats.ReadChar = io.CharsRead.Load()
+	stats.WriteChar = io.CharsWritten.Load()
+	stats.ReadSyscalls = io.ReadSyscalls.Load()
+	stats.WriteSyscalls = io.WriteSyscalls.Load()
+	stats.ReadBytes = io.BytesRead.Load()
+	stats.WriteBytes = io.BytesWritten.Load()
+	stats.CancelledWriteBytes = io.BytesWriteCancelled.Load()
+}
+
+// init registers the TASKSTATS family.
+func init() {
+	genetlink.RegisterFamily(&genlFamily)
+}
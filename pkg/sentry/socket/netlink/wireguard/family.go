@@ -0,0 +1,437 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package wireguard provides the wireguard generic netlink family, which
+// configures the WireGuard interfaces of netstack like in Linux, so that wg(8)
+// works unmodified.
+package wireguard
+
+import (
+	"time"
+
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/marshal/primitive"
+	"gvisor.dev/gvisor/pkg/sentry/socket"
+	"gvisor.dev/gvisor/pkg/sentry/socket/netlink"
+	"gvisor.dev/gvisor/pkg/sentry/socket/netlink/genetlink"
+	"gvisor.dev/gvisor/pkg/sentry/socket/netlink/nlmsg"
+	"gvisor.dev/gvisor/pkg/sentry/socket/netstack"
+	"gvisor.dev/gvisor/pkg/syserr"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/link/wireguard"
+)
+
+// genlFamily is the wireguard generic netlink family.
+var genlFamily = genetlink.Family{
+	Name:    linux.WG_GENL_NAME,
+	Version: linux.WG_GENL_VERSION,
+	MaxAttr: linux.WGDEVICE_A_PEERS,
+	Ops: []genetlink.Op{
+		{
+			Cmd:    linux.WG_CMD_GET_DEVICE,
+			Flags:  linux.GENL_UNS_ADMIN_PERM,
+			DumpIt: getDevice,
+		},
+		{
+			Cmd:   linux.WG_CMD_SET_DEVICE,
+			Flags: linux.GENL_UNS_ADMIN_PERM,
+			DoIt:  setDevice,
+		},
+	},
+	Policy: genetlink.Policy{
+		linux.WGDEVICE_A_IFINDEX:     {Type: linux.NL_ATTR_TYPE_U32},
+		linux.WGDEVICE_A_IFNAME:      {Type: linux.NL_ATTR_TYPE_NUL_STRING, MaxLen: linux.IFNAMSIZ - 1},
+		linux.WGDEVICE_A_PRIVATE_KEY: keyPolicy,
+		linux.WGDEVICE_A_PUBLIC_KEY:  keyPolicy,
+		linux.WGDEVICE_A_FLAGS:       {Type: linux.NL_ATTR_TYPE_U32},
+		linux.WGDEVICE_A_LISTEN_PORT: {Type: linux.NL_ATTR_TYPE_U16},
+		linux.WGDEVICE_A_FWMARK:      {Type: linux.NL_ATTR_TYPE_U32},
+		linux.WGDEVICE_A_PEERS: {
+			Type: linux.NL_ATTR_TYPE_NESTED_ARRAY,
+			Nested: genetlink.Policy{
+				linux.WGPEER_A_PUBLIC_KEY:                    keyPolicy,
+				linux.WGPEER_A_PRESHARED_KEY:                 keyPolicy,
+				linux.WGPEER_A_FLAGS:                         {Type: linux.NL_ATTR_TYPE_U32},
+				linux.WGPEER_A_ENDPOINT:                      {Type: linux.NL_ATTR_TYPE_BINARY, MinLen: sockAddrSize},
+				linux.WGPEER_A_PERSISTENT_KEEPALIVE_INTERVAL: {Type: linux.NL_ATTR_TYPE_U16},
+				linux.WGPEER_A_LAST_HANDSHAKE_TIME:           {Type: linux.NL_ATTR_TYPE_BINARY, MinLen: timespecSize, MaxLen: timespecSize},
+				linux.WGPEER_A_RX_BYTES:                      {Type: linux.NL_ATTR_TYPE_U64},
+				linux.WGPEER_A_TX_BYTES:                      {Type: linux.NL_ATTR_TYPE_U64},
+				linux.WGPEER_A_ALLOWEDIPS: {
+					Type: linux.NL_ATTR_TYPE_NESTED_ARRAY,
+					Nested: genetlink.Policy{
+						linux.WGALLOWEDIP_A_FAMILY:    {Type: linux.NL_ATTR_TYPE_U16},
+						linux.WGALLOWEDIP_A_IPADDR:    {Type: linux.NL_ATTR_TYPE_BINARY, MinLen: header.IPv4AddressSize},
+						linux.WGALLOWEDIP_A_CIDR_MASK: {Type: linux.NL_ATTR_TYPE_U8},
+					},
+				},
+				linux.WGPEER_A_PROTOCOL_VERSION: {Type: linux.NL_ATTR_TYPE_U32},
+			},
+		},
+	},
+}
+
+// keyPolicy is the policy of key attributes.
+var keyPolicy = genetlink.AttrPolicy{
+	Type:   linux.NL_ATTR_TYPE_BINARY,
+	MinLen: linux.WG_KEY_LEN,
+	MaxLen: linux.WG_KEY_LEN,
+}
+
+// protocolVersion is the version of the WireGuard protocol.
+const protocolVersion = 1
+
+var (
+	sockAddrInetSize  = (*linux.SockAddrInet)(nil).SizeBytes()
+	sockAddrInet6Size = (*linux.SockAddrInet6)(nil).SizeBytes()
+	timespecSize      = (*linux.Timespec)(nil).SizeBytes()
+)
+
+// sockAddrSize is the size of struct sockaddr.
+const sockAddrSize = 16
+
+// maxEntrySize is the size of the peer entries in a device message, which
+// leaves room for the headers and the attributes of the device in a datagram.
+const maxEntrySize = linux.NLMSG_GOODSIZE - 256
+
+// device is a WireGuard interface.
+type device struct {
+	ep    *wireguard.Endpoint
+	index int32
+	name  string
+}
+
+// lookupDevice returns the WireGuard interface named by WGDEVICE_A_IFINDEX or
+// WGDEVICE_A_IFNAME.
+func lookupDevice(s *netlink.Socket, attrs map[uint16]nlmsg.BytesView) (device, *syserr.Error) {
+	indexAttr, hasIndex := attrs[linux.WGDEVICE_A_IFINDEX]
+	nameAttr, hasName := attrs[linux.WGDEVICE_A_IFNAME]
+	if hasIndex == hasName {
+		return device{}, syserr.ErrInvalidRequestDescriptor
+	}
+	stack, ok := s.Stack().(*netstack.Stack)
+	if !ok {
+		return device{}, syserr.ErrNoDevice
+	}
+	var index uint32
+	if hasIndex {
+		if index, ok = indexAttr.Uint32(); !ok {
+			return device{}, syserr.ErrInvalidArgument
+		}
+	}
+	name := nameAttr.String()
+	for id, ni := range stack.Stack.NICInfo() {
+		if (hasIndex && uint32(id) != index) || (hasName && ni.Name != name) {
+			continue
+		}
+		ep, ok := ni.Context.(*wireguard.Endpoint)
+		if !ok {
+			return device{}, syserr.ErrNotSupported
+		}
+		return device{ep: ep, index: int32(id), name: ni.Name}, nil
+	}
+	return device{}, syserr.ErrNoDevice
+}
+
+// getDevice handles WG_CMD_GET_DEVICE dump requests.
+func getDevice(ctx context.Context, s *netlink.Socket, req *genetlink.Request, ms *nlmsg.MessageSet) *syserr.Error {
+	attrs, ok := req.Attrs.Parse()
+	if !ok {
+		return syserr.ErrInvalidArgument
+	}
+	dev, err := lookupDevice(s, attrs)
+	if err != nil {
+		return err
+	}
+	info := dev.ep.Info()
+
+	var devAttrs nlmsg.Attrs
+	devAttrs.PutAttr(linux.WGDEVICE_A_LISTEN_PORT, primitive.AllocateUint16(info.ListenPort))
+	devAttrs.PutAttr(linux.WGDEVICE_A_FWMARK, primitive.AllocateUint32(info.FwMark))
+	devAttrs.PutAttr(linux.WGDEVICE_A_IFINDEX, primitive.AllocateUint32(uint32(dev.index)))
+	devAttrs.PutAttrString(linux.WGDEVICE_A_IFNAME, dev.name)
+	if !info.PrivateKey.IsZero() {
+		devAttrs.PutAttr(linux.WGDEVICE_A_PRIVATE_KEY, primitive.AsByteSlice(info.PrivateKey[:]))
+		devAttrs.PutAttr(linux.WGDEVICE_A_PUBLIC_KEY, primitive.AsByteSlice(info.PublicKey[:]))
+	}
+
+	// Like in Linux, the device attributes are only in the first message,
+	// and peers are spread over as many messages as needed.
+	var peers nlmsg.Attrs
+	addMessage := func() {
+		m := req.Family.AddMessage(ms, linux.WG_CMD_GET_DEVICE)
+		m.Put(primitive.AsByteSlice(devAttrs))
+		if len(peers) > 0 {
+			m.PutAttr(linux.WGDEVICE_A_PEERS|linux.NLA_F_NESTED, primitive.AsByteSlice(peers))
+		}
+		devAttrs = nil
+		peers = nil
+	}
+	const hdrSize = linux.NetlinkMessageHeaderSize + linux.GenericNetlinkHeaderSize + 2*linux.NetlinkAttrHeaderSize
+	for i := range info.Peers {
+		for _, entry := range peerEntries(&info.Peers[i]) {
+			if len(peers) > 0 && hdrSize+len(devAttrs)+len(peers)+len(entry) > linux.NLMSG_GOODSIZE {
+				addMessage()
+			}
+			peers.PutAttr(linux.NLA_F_NESTED, primitive.AsByteSlice(entry))
+		}
+	}
+	addMessage()
+	return nil
+}
+
+// peerEntries returns the entries of p in WGDEVICE_A_PEERS. The allowed IPs
+// of p are split into several entries if they don't fit in a message. The
+// entries after the first one only hold the public key and allowed IPs, and
+// are merged by userspace.
+func peerEntries(p *wireguard.PeerInfo) []nlmsg.Attrs {
+	var entry nlmsg.Attrs
+	entry.PutAttr(linux.WGPEER_A_PUBLIC_KEY, primitive.AsByteSlice(p.PublicKey[:]))
+	entry.PutAttr(linux.WGPEER_A_PRESHARED_KEY, primitive.AsByteSlice(p.PresharedKey[:]))
+	var ts linux.Timespec
+	if !p.LastHandshake.IsZero() {
+		ts = linux.NsecToTimespec(p.LastHandshake.UnixNano())
+	}
+	entry.PutAttr(linux.WGPEER_A_LAST_HANDSHAKE_TIME, &ts)
+	entry.PutAttr(linux.WGPEER_A_PERSISTENT_KEEPALIVE_INTERVAL, primitive.AllocateUint16(uint16(p.PersistentKeepalive/time.Second)))
+	entry.PutAttr(linux.WGPEER_A_TX_BYTES, primitive.AllocateUint64(p.TxBytes))
+	entry.PutAttr(linux.WGPEER_A_RX_BYTES, primitive.AllocateUint64(p.RxBytes))
+	entry.PutAttr(linux.WGPEER_A_PROTOCOL_VERSION, primitive.AllocateUint32(protocolVersion))
+	if p.Endpoint.Addr.Len() != 0 {
+		family := linux.AF_INET
+		if p.Endpoint.Addr.Len() == header.IPv6AddressSize {
+			family = linux.AF_INET6
+		}
+		addr, _ := socket.ConvertAddress(family, p.Endpoint)
+		entry.PutAttr(linux.WGPEER_A_ENDPOINT, addr)
+	}
+
+	var (
+		entries []nlmsg.Attrs
+		ips     nlmsg.Attrs
+	)
+	for _, subnet := range p.AllowedIPs {
+		ip := allowedIPAttrs(subnet)
+		if len(ips) > 0 && len(entry)+len(ips)+len(ip)+3*linux.NetlinkAttrHeaderSize > maxEntrySize {
+			entry.PutAttr(linux.WGPEER_A_ALLOWEDIPS|linux.NLA_F_NESTED, primitive.AsByteSlice(ips))
+			entries = append(entries, entry)
+			entry, ips = nil, nil
+			entry.PutAttr(linux.WGPEER_A_PUBLIC_KEY, primitive.AsByteSlice(p.PublicKey[:]))
+		}
+		ips.PutAttr(linux.NLA_F_NESTED, primitive.AsByteSlice(ip))
+	}
+	if len(ips) > 0 {
+		entry.PutAttr(linux.WGPEER_A_ALLOWEDIPS|linux.NLA_F_NESTED, primitive.AsByteSlice(ips))
+	}
+	return append(entries, entry)
+}
+
+func allowedIPAttrs(subnet tcpip.Subnet) nlmsg.Attrs {
+	addr := subnet.ID()
+	family := uint16(linux.AF_INET)
+	if addr.Len() == header.IPv6AddressSize {
+		family = linux.AF_INET6
+	}
+	var attrs nlmsg.Attrs
+	attrs.PutAttr(linux.WGALLOWEDIP_A_CIDR_MASK, primitive.AllocateUint8(uint8(subnet.Prefix())))
+	attrs.PutAttr(linux.WGALLOWEDIP_A_IPADDR, primitive.AsByteSlice(addr.AsSlice()))
+	attrs.PutAttr(linux.WGALLOWEDIP_A_FAMILY, primitive.AllocateUint16(family))
+	return attrs
+}
+
+// setDevice handles WG_CMD_SET_DEVICE requests.
+func setDevice(ctx context.Context, s *netlink.Socket, req *genetlink.Request, ms *nlmsg.MessageSet) *syserr.Error {
+	attrs, ok := req.Attrs.Parse()
+	if !ok {
+		return syserr.ErrInvalidArgument
+	}
+	dev, err := lookupDevice(s, attrs)
+	if err != nil {
+		return err
+	}
+
+	var c wireguard.Config
+	if v, ok := attrs[linux.WGDEVICE_A_FLAGS]; ok {
+		flags, ok := v.Uint32()
+		if !ok {
+			return syserr.ErrInvalidArgument
+		}
+		if flags&^linux.WGDEVICE_F_REPLACE_PEERS != 0 {
+			return syserr.ErrNotSupported
+		}
+		c.ReplacePeers = flags&linux.WGDEVICE_F_REPLACE_PEERS != 0
+	}
+	if v, ok := attrs[linux.WGDEVICE_A_LISTEN_PORT]; ok {
+		port, ok := v.Uint16()
+		if !ok {
+			return syserr.ErrInvalidArgument
+		}
+		c.ListenPort = &port
+	}
+	if v, ok := attrs[linux.WGDEVICE_A_FWMARK]; ok {
+		mark, ok := v.Uint32()
+		if !ok {
+			return syserr.ErrInvalidArgument
+		}
+		c.FwMark = &mark
+	}
+	if v, ok := attrs[linux.WGDEVICE_A_PRIVATE_KEY]; ok {
+		key, err := parseKey(v)
+		if err != nil {
+			return err
+		}
+		c.PrivateKey = &key
+	}
+	if v, ok := attrs[linux.WGDEVICE_A_PEERS]; ok {
+		for entries := nlmsg.AttrsView(v); !entries.Empty(); {
+			_, entry, rest, ok := entries.ParseFirst()
+			if !ok {
+				return syserr.ErrInvalidArgument
+			}
+			entries = rest
+			p, err := parsePeer(entry)
+			if err != nil {
+				return err
+			}
+			c.Peers = append(c.Peers, p)
+		}
+	}
+	return syserr.TranslateNetstackError(dev.ep.Configure(&c))
+}
+
+func parseKey(v nlmsg.BytesView) (wireguard.Key, *syserr.Error) {
+	var key wireguard.Key
+	if len(v) != linux.WG_KEY_LEN {
+		return key, syserr.ErrInvalidArgument
+	}
+	copy(key[:], v)
+	return key, nil
+}
+
+// parsePeer parses an entry of WGDEVICE_A_PEERS.
+func parsePeer(b []byte) (wireguard.PeerConfig, *syserr.Error) {
+	var c wireguard.PeerConfig
+	attrs, ok := nlmsg.AttrsView(b).Parse()
+	if !ok {
+		return c, syserr.ErrInvalidArgument
+	}
+	v, ok := attrs[linux.WGPEER_A_PUBLIC_KEY]
+	if !ok {
+		return c, syserr.ErrInvalidArgument
+	}
+	var err *syserr.Error
+	if c.PublicKey, err = parseKey(v); err != nil {
+		return c, err
+	}
+	if v, ok := attrs[linux.WGPEER_A_PROTOCOL_VERSION]; ok {
+		version, ok := v.Uint32()
+		if !ok {
+			return c, syserr.ErrInvalidArgument
+		}
+		if version != protocolVersion {
+			return c, syserr.ErrProtocolNotSupported
+		}
+	}
+	if v, ok := attrs[linux.WGPEER_A_FLAGS]; ok {
+		flags, ok := v.Uint32()
+		if !ok {
+			return c, syserr.ErrInvalidArgument
+		}
+		const all = linux.WGPEER_F_REMOVE_ME | linux.WGPEER_F_REPLACE_ALLOWEDIPS | linux.WGPEER_F_UPDATE_ONLY
+		if flags&^all != 0 {
+			return c, syserr.ErrNotSupported
+		}
+		c.Remove = flags&linux.WGPEER_F_REMOVE_ME != 0
+		c.ReplaceAllowedIPs = flags&linux.WGPEER_F_REPLACE_ALLOWEDIPS != 0
+		c.UpdateOnly = flags&linux.WGPEER_F_UPDATE_ONLY != 0
+	}
+	if v, ok := attrs[linux.WGPEER_A_PRESHARED_KEY]; ok {
+		key, err := parseKey(v)
+		if err != nil {
+			return c, err
+		}
+		c.PresharedKey = &key
+	}
+	if v, ok := attrs[linux.WGPEER_A_ENDPOINT]; ok {
+		// Like in Linux, endpoints of other sizes or families are
+		// ignored.
+		if addr, family, err := socket.AddressAndFamily(v); err == nil &&
+			(family == linux.AF_INET && len(v) == sockAddrInetSize || family == linux.AF_INET6 && len(v) == sockAddrInet6Size) {
+			if v4 := addr.Addr.To4(); v4.Len() != 0 {
+				addr.Addr = v4
+			}
+			addr.NIC = 0
+			c.Endpoint = &addr
+		}
+	}
+	if v, ok := attrs[linux.WGPEER_A_PERSISTENT_KEEPALIVE_INTERVAL]; ok {
+		interval, ok := v.Uint16()
+		if !ok {
+			return c, syserr.ErrInvalidArgument
+		}
+		d := time.Duration(interval) * time.Second
+		c.PersistentKeepalive = &d
+	}
+	if v, ok := attrs[linux.WGPEER_A_ALLOWEDIPS]; ok {
+		for entries := nlmsg.AttrsView(v); !entries.Empty(); {
+			_, entry, rest, ok := entries.ParseFirst()
+			if !ok {
+				return c, syserr.ErrInvalidArgument
+			}
+			entries = rest
+			subnet, err := parseAllowedIP(entry)
+			if err != nil {
+				return c, err
+			}
+			c.AllowedIPs = append(c.AllowedIPs, subnet)
+		}
+	}
+	return c, nil
+}
+
+// parseAllowedIP parses an entry of WGPEER_A_ALLOWEDIPS.
+func parseAllowedIP(b []byte) (tcpip.Subnet, *syserr.Error) {
+	attrs, ok := nlmsg.AttrsView(b).Parse()
+	if !ok {
+		return tcpip.Subnet{}, syserr.ErrInvalidArgument
+	}
+	familyAttr, ok1 := attrs[linux.WGALLOWEDIP_A_FAMILY]
+	addr, ok2 := attrs[linux.WGALLOWEDIP_A_IPADDR]
+	maskAttr, ok3 := attrs[linux.WGALLOWEDIP_A_CIDR_MASK]
+	if !ok1 || !ok2 || !ok3 {
+		return tcpip.Subnet{}, syserr.ErrInvalidArgument
+	}
+	family, ok1 := familyAttr.Uint16()
+	if !ok1 || len(maskAttr) != 1 {
+		return tcpip.Subnet{}, syserr.ErrInvalidArgument
+	}
+	prefixLen := int(maskAttr[0])
+	switch {
+	case family == linux.AF_INET && len(addr) == header.IPv4AddressSize && prefixLen <= header.IPv4AddressSizeBits:
+	case family == linux.AF_INET6 && len(addr) == header.IPv6AddressSize && prefixLen <= header.IPv6AddressSizeBits:
+	default:
+		return tcpip.Subnet{}, syserr.ErrInvalidArgument
+	}
+	return tcpip.AddressWithPrefix{
+		Address:   tcpip.AddrFromSlice(addr),
+		PrefixLen: prefixLen,
+	}.Subnet(), nil
+}
+
+// init registers the wireguard family.
+func init() {
+	genetlink.RegisterFamily(&genlFamily)
+}
@@ -338,6 +338,9 @@ var Metrics = tcpip.Stats{
 		SpuriousRecovery:                   mustCreateMetric("/netstack/tcp/spurious_recovery", "Number of times the connection entered loss recovery spuriously."),
 		SpuriousRTORecovery:                mustCreateMetric("/netstack/tcp/spurious_rto_recovery", "Number of times the connection entered RTO spuriously."),
 		ForwardMaxInFlightDrop:             mustCreateMetric("/netstack/tcp/forward_max_in_flight_drop", "Number of connection requests dropped due to exceeding in-flight limit."),
+		MD5NotFound:                        mustCreateMetric("/netstack/tcp/md5_not_found", "Number of segments dropped due to a missing TCP MD5 signature."),
+		MD5Unexpected:                      mustCreateMetric("/netstack/tcp/md5_unexpected", "Number of segments dropped due to an unexpected TCP MD5 signature."),
+		MD5Failure:                         mustCreateMetric("/netstack/tcp/md5_failure", "Number of segments dropped due to an invalid TCP MD5 signature."),
 	},
 	UDP: tcpip.UDPStats{
 		PacketsReceived:          mustCreateMetric("/netstack/udp/packets_received", "Number of UDP datagrams received via HandlePacket."),
@@ -348,6 +351,20 @@ var Metrics = tcpip.Stats{
 		PacketSendErrors:         mustCreateMetric("/netstack/udp/packet_send_errors", "Number of UDP datagrams failed to be sent."),
 		ChecksumErrors:           mustCreateMetric("/netstack/udp/checksum_errors", "Number of UDP datagrams dropped due to bad checksums."),
 	},
+	SCTP: tcpip.SCTPStats{
+		PacketsReceived:             mustCreateMetric("/netstack/sctp/packets_received", "Number of SCTP packets received via HandlePacket."),
+		PacketsSent:                 mustCreateMetric("/netstack/sctp/packets_sent", "Number of SCTP packets sent."),
+		PacketSendErrors:            mustCreateMetric("/netstack/sctp/packet_send_errors", "Number of SCTP packets failed to be sent."),
+		ChecksumErrors:              mustCreateMetric("/netstack/sctp/checksum_errors", "Number of SCTP packets dropped due to bad checksums."),
+		MalformedPacketsReceived:    mustCreateMetric("/netstack/sctp/malformed_packets_received", "Number of SCTP packets dropped because their chunks could not be parsed."),
+		OutOfTheBluePacketsReceived: mustCreateMetric("/netstack/sctp/out_of_the_blue_packets_received", "Number of SCTP packets received that did not belong to an association."),
+		ActiveEstablishes:           mustCreateMetric("/netstack/sctp/active_establishes", "Number of SCTP associations established by sending an INIT chunk."),
+		PassiveEstablishes:          mustCreateMetric("/netstack/sctp/passive_establishes", "Number of SCTP associations established by accepting an INIT chunk."),
+		Aborteds:                    mustCreateMetric("/netstack/sctp/aborteds", "Number of SCTP associations terminated by an ABORT chunk."),
+		Shutdowns:                   mustCreateMetric("/netstack/sctp/shutdowns", "Number of SCTP associations terminated gracefully."),
+		T3RtxExpireds:               mustCreateMetric("/netstack/sctp/t3_rtx_expireds", "Number of times the retransmission timer of an SCTP association expired."),
+		FastRetransmits:             mustCreateMetric("/netstack/sctp/fast_retransmits", "Number of SCTP DATA chunks fast retransmitted."),
+	},
 }
 
 // DefaultTTL is linux's default TTL. All network protocols in all stacks used
@@ -516,7 +533,7 @@ func (s *sock) Read(ctx context.Context, dst usermem.IOSequence, opts vfs.ReadOp
 	if dst.NumBytes() == 0 {
 		return 0, nil
 	}
-	n, _, _, _, _, err := s.nonBlockingRead(ctx, dst, false, false, false)
+	n, _, _, _, _, err := s.nonBlockingRead(ctx, dst, false, false, false, false)
 	if err == syserr.ErrWouldBlock {
 		return int64(n), linuxerr.ErrWouldBlock
 	}
@@ -947,6 +964,13 @@ func GetSockOpt(t *kernel.Task, s socket.Socket, ep commonEndpoint, family int,
 
 	case linux.SOL_PACKET:
 		return getSockOptPacket(t, s, ep, name, outPtr, outLen)
+
+	case linux.SOL_TLS:
+		return getSockOptTLS(t, s, ep, name, outLen)
+
+	case linux.SOL_SCTP:
+		return getSockOptSCTP(t, s, ep, name, outPtr, outLen)
+
 	case linux.SOL_UDP, linux.SOL_RAW:
 		// Not supported.
 	}
@@ -1147,6 +1171,29 @@ func getSockOptSocket(t *kernel.Task, s socket.Socket, ep commonEndpoint, family
 
 		v := primitive.Int32(ep.SocketOptions().GetRcvlowat())
 		return &v, nil
+
+	case linux.SO_ZEROCOPY:
+		if outLen < sizeOfInt32 {
+			return nil, syserr.ErrInvalidArgument
+		}
+
+		v := primitive.Int32(boolToInt32(ep.SocketOptions().GetZeroCopy()))
+		return &v, nil
+
+	case linux.SO_TXTIME:
+		if outLen < linux.SizeOfSockTxtime {
+			return nil, syserr.ErrInvalidArgument
+		}
+
+		txTime := ep.SocketOptions().GetTxTime()
+		v := linux.SockTxtime{ClockID: txTime.ClockID}
+		if txTime.DeadlineMode {
+			v.Flags |= linux.SOF_TXTIME_DEADLINE_MODE
+		}
+		if txTime.ReportErrors {
+			v.Flags |= linux.SOF_TXTIME_REPORT_ERRORS
+		}
+		return &v, nil
 	default:
 		if v, err, handled := getSockOptSocketCustom(t, s, ep, name, outLen); handled {
 			return v, err
@@ -1320,6 +1367,27 @@ func getSockOptTCP(t *kernel.Task, s socket.Socket, ep commonEndpoint, name, out
 		bP := primitive.ByteSlice(b)
 		return &bP, nil
 
+	case linux.TCP_ULP:
+		var v tcpip.TCPULPOption
+		if err := ep.GetSockOpt(&v); err != nil {
+			return nil, syserr.TranslateNetstackError(err)
+		}
+
+		// Like Linux, return an empty name if no upper layer protocol
+		// is attached, or else the lower of TCP_ULP_NAME_MAX bytes or
+		// the value of the option length.
+		//
+		// This is Linux's net/tcp.h TCP_ULP_NAME_MAX.
+		const tcpULPNameMax = 16
+
+		var b []byte
+		if v != "" {
+			b = make([]byte, min(outLen, tcpULPNameMax))
+			copy(b, v)
+		}
+		bP := primitive.ByteSlice(b)
+		return &bP, nil
+
 	case linux.TCP_LINGER2:
 		if outLen < sizeOfInt32 {
 			return nil, syserr.ErrInvalidArgument
@@ -1373,6 +1441,109 @@ func getSockOptTCP(t *kernel.Task, s socket.Socket, ep commonEndpoint, name, out
 		}
 		vP := primitive.Int32(v)
 		return &vP, nil
+
+	case linux.TCP_FASTOPEN:
+		if outLen < sizeOfInt32 {
+			return nil, syserr.ErrInvalidArgument
+		}
+
+		v, err := ep.GetSockOptInt(tcpip.TCPFastOpenQueueLenOption)
+		if err != nil {
+			return nil, syserr.TranslateNetstackError(err)
+		}
+		vP := primitive.Int32(v)
+		return &vP, nil
+
+	case linux.TCP_FASTOPEN_CONNECT:
+		if outLen < sizeOfInt32 {
+			return nil, syserr.ErrInvalidArgument
+		}
+
+		v, err := ep.GetSockOptInt(tcpip.TCPFastOpenConnectOption)
+		if err != nil {
+			return nil, syserr.TranslateNetstackError(err)
+		}
+		vP := primitive.Int32(v)
+		return &vP, nil
+
+	case linux.TCP_FASTOPEN_NO_COOKIE:
+		if outLen < sizeOfInt32 {
+			return nil, syserr.ErrInvalidArgument
+		}
+
+		v, err := ep.GetSockOptInt(tcpip.TCPFastOpenNoCookieOption)
+		if err != nil {
+			return nil, syserr.TranslateNetstackError(err)
+		}
+		vP := primitive.Int32(v)
+		return &vP, nil
+
+	case linux.TCP_FASTOPEN_KEY:
+		var v tcpip.TCPFastOpenKeyOption
+		if err := ep.GetSockOpt(&v); err != nil {
+			return nil, syserr.TranslateNetstackError(err)
+		}
+
+		// Linux truncates the output to outLen.
+		if len(v) > outLen {
+			v = v[:outLen]
+		}
+		bufP := primitive.ByteSlice(v)
+		return &bufP, nil
+
+	case linux.TCP_REPAIR:
+		if outLen < sizeOfInt32 {
+			return nil, syserr.ErrInvalidArgument
+		}
+
+		v, err := ep.GetSockOptInt(tcpip.TCPRepairOption)
+		if err != nil {
+			return nil, syserr.TranslateNetstackError(err)
+		}
+		vP := primitive.Int32(v)
+		return &vP, nil
+
+	case linux.TCP_REPAIR_QUEUE:
+		if outLen < sizeOfInt32 {
+			return nil, syserr.ErrInvalidArgument
+		}
+
+		v, err := ep.GetSockOptInt(tcpip.TCPRepairQueueOption)
+		if err != nil {
+			return nil, syserr.TranslateNetstackError(err)
+		}
+		vP := primitive.Int32(v)
+		return &vP, nil
+
+	case linux.TCP_QUEUE_SEQ:
+		if outLen < sizeOfInt32 {
+			return nil, syserr.ErrInvalidArgument
+		}
+
+		v, err := ep.GetSockOptInt(tcpip.TCPQueueSeqOption)
+		if err != nil {
+			return nil, syserr.TranslateNetstackError(err)
+		}
+		vP := primitive.Int32(v)
+		return &vP, nil
+
+	case linux.TCP_REPAIR_WINDOW:
+		if outLen < linux.SizeOfTCPRepairWindow {
+			return nil, syserr.ErrInvalidArgument
+		}
+
+		var v tcpip.TCPRepairWindowOption
+		if err := ep.GetSockOpt(&v); err != nil {
+			return nil, syserr.TranslateNetstackError(err)
+		}
+		w := linux.TCPRepairWindow{
+			SndWL1:    v.SndWL1,
+			SndWnd:    v.SndWnd,
+			MaxWindow: v.MaxWindow,
+			RcvWnd:    v.RcvWnd,
+			RcvWUP:    v.RcvWUP,
+		}
+		return &w, nil
 	}
 	return nil, syserr.ErrProtocolNotAvailable
 }
@@ -1483,6 +1654,9 @@ func getSockOptIPv6(t *kernel.Task, s socket.Socket, ep commonEndpoint, name int
 	case linux.IPV6_PATHMTU:
 		// Not supported.
 
+	case linux.MCAST_MSFILTER:
+		return getSockOptMulticastFilter(t, ep, linux.AF_INET6, outPtr, outLen)
+
 	case linux.IPV6_TCLASS:
 		// Length handling for parity with Linux.
 		if outLen == 0 {
@@ -1528,6 +1702,14 @@ func getSockOptIPv6(t *kernel.Task, s socket.Socket, ep commonEndpoint, name int
 		v := primitive.Int32(boolToInt32(ep.SocketOptions().GetReceiveOriginalDstAddress()))
 		return &v, nil
 
+	case linux.IPV6_TRANSPARENT:
+		if outLen < sizeOfInt32 {
+			return nil, syserr.ErrInvalidArgument
+		}
+
+		v := primitive.Int32(boolToInt32(ep.SocketOptions().GetTransparent()))
+		return &v, nil
+
 	case linux.IPV6_RECVPKTINFO:
 		if outLen < sizeOfInt32 {
 			return nil, syserr.ErrInvalidArgument
@@ -1699,6 +1881,39 @@ func getSockOptIP(t *kernel.Task, s socket.Socket, ep commonEndpoint, name int,
 
 		return &a.(*linux.SockAddrInet).Addr, nil
 
+	case linux.IP_MSFILTER:
+		if outLen < inetMulticastFilterSize {
+			return nil, syserr.ErrInvalidArgument
+		}
+		var req linux.InetMulticastFilter
+		if _, err := req.CopyIn(t, outPtr); err != nil {
+			return nil, syserr.FromError(err)
+		}
+
+		opt := tcpip.MulticastSourceFilterOption{
+			InterfaceAddr: tcpip.AddrFrom4(req.InterfaceAddr),
+			MulticastAddr: tcpip.AddrFrom4(req.MulticastAddr),
+		}
+		if err := ep.GetSockOpt(&opt); err != nil {
+			return nil, syserr.TranslateNetstackError(err)
+		}
+
+		// As in Linux, the source count passed in is the number of sources to
+		// return and the returned source count is the total number of sources.
+		n := min(int(req.NumSources), len(opt.Filter.Sources), (outLen-inetMulticastFilterSize)/len(linux.InetAddr{}))
+		req.Mode = linuxMulticastFilterMode(opt.Filter.Mode)
+		req.NumSources = uint32(len(opt.Filter.Sources))
+		buf := make([]byte, inetMulticastFilterSize+n*len(linux.InetAddr{}))
+		req.MarshalUnsafe(buf)
+		for i, source := range opt.Filter.Sources[:n] {
+			copy(buf[inetMulticastFilterSize+i*len(linux.InetAddr{}):], source.AsSlice())
+		}
+		bufP := primitive.ByteSlice(buf)
+		return &bufP, nil
+
+	case linux.MCAST_MSFILTER:
+		return getSockOptMulticastFilter(t, ep, linux.AF_INET, outPtr, outLen)
+
 	case linux.IP_MULTICAST_LOOP:
 		if outLen < sizeOfInt32 {
 			return nil, syserr.ErrInvalidArgument
@@ -1764,6 +1979,14 @@ func getSockOptIP(t *kernel.Task, s socket.Socket, ep commonEndpoint, name int,
 		v := primitive.Int32(boolToInt32(ep.SocketOptions().GetReceiveOriginalDstAddress()))
 		return &v, nil
 
+	case linux.IP_TRANSPARENT:
+		if outLen < sizeOfInt32 {
+			return nil, syserr.ErrInvalidArgument
+		}
+
+		v := primitive.Int32(boolToInt32(ep.SocketOptions().GetTransparent()))
+		return &v, nil
+
 	case linux.SO_ORIGINAL_DST:
 		if outLen < sockAddrInetSize {
 			return nil, syserr.ErrInvalidArgument
@@ -1943,6 +2166,12 @@ func SetSockOpt(t *kernel.Task, s socket.Socket, ep commonEndpoint, level int, n
 	case linux.SOL_PACKET:
 		return setSockOptPacket(t, s, ep, name, optVal)
 
+	case linux.SOL_TLS:
+		return setSockOptTLS(t, s, ep, name, optVal)
+
+	case linux.SOL_SCTP:
+		return setSockOptSCTP(t, s, ep, name, optVal)
+
 	case linux.SOL_UDP,
 		linux.SOL_RAW:
 		// Not supported.
@@ -2151,6 +2380,53 @@ func setSockOptSocket(t *kernel.Task, s socket.Socket, ep commonEndpoint, name i
 		var v tcpip.SocketDetachFilterOption
 		return syserr.TranslateNetstackError(ep.SetSockOpt(&v))
 
+	case linux.SO_ATTACH_REUSEPORT_CBPF:
+		return attachReusePortProgram(t, ep, optVal)
+
+	case linux.SO_DETACH_REUSEPORT_BPF:
+		// optval is ignored.
+		return detachReusePortProgram(ep)
+
+	case linux.SO_ZEROCOPY:
+		if !socket.IsTCP(s) && !socket.IsUDP(s) {
+			return syserr.ErrEndpointOperation
+		}
+		if len(optVal) < sizeOfInt32 {
+			return syserr.ErrInvalidArgument
+		}
+
+		v := int32(hostarch.ByteOrder.Uint32(optVal))
+		if v < 0 || v > 1 {
+			return syserr.ErrInvalidArgument
+		}
+		ep.SocketOptions().SetZeroCopy(v != 0)
+		return nil
+
+	case linux.SO_TXTIME:
+		if len(optVal) != linux.SizeOfSockTxtime {
+			return syserr.ErrInvalidArgument
+		}
+
+		var v linux.SockTxtime
+		v.UnmarshalBytes(optVal)
+		if v.Flags&^linux.SOF_TXTIME_FLAGS_MASK != 0 {
+			return syserr.ErrInvalidArgument
+		}
+		// As in Linux, only the monotonic clock, which is the one of the
+		// queueing disciplines, is available to unprivileged sockets.
+		if v.ClockID != linux.CLOCK_MONOTONIC {
+			if creds := auth.CredentialsFromContext(t); !creds.HasCapability(linux.CAP_NET_ADMIN) {
+				return syserr.ErrNotPermitted
+			}
+		}
+		ep.SocketOptions().SetTxTime(tcpip.TxTimeOption{
+			Enabled:      true,
+			ClockID:      v.ClockID,
+			DeadlineMode: v.Flags&linux.SOF_TXTIME_DEADLINE_MODE != 0,
+			ReportErrors: v.Flags&linux.SOF_TXTIME_REPORT_ERRORS != 0,
+		})
+		return nil
+
 	// TODO(b/226603727): Add support for SO_RCVLOWAT option. For now, only
 	// the unsupported syscall message is removed.
 	case linux.SO_RCVLOWAT:
@@ -2192,22 +2468,18 @@ func setSockOptSocket(t *kernel.Task, s socket.Socket, ep commonEndpoint, name i
 		linux.SO_BPF_EXTENSIONS,
 		linux.SO_INCOMING_CPU,
 		linux.SO_ATTACH_BPF,
-		linux.SO_ATTACH_REUSEPORT_CBPF,
 		linux.SO_ATTACH_REUSEPORT_EBPF,
 		linux.SO_CNX_ADVICE,
 		linux.SO_MEMINFO,
 		linux.SO_INCOMING_NAPI_ID,
 		linux.SO_COOKIE,
 		linux.SO_PEERGROUPS,
-		linux.SO_ZEROCOPY,
-		linux.SO_TXTIME,
 		linux.SO_BINDTOIFINDEX,
 		linux.SO_TIMESTAMP_NEW,
 		linux.SO_TIMESTAMPNS_NEW,
 		linux.SO_TIMESTAMPING_NEW,
 		linux.SO_RCVTIMEO_NEW,
 		linux.SO_SNDTIMEO_NEW,
-		linux.SO_DETACH_REUSEPORT_BPF,
 		linux.SO_PREFER_BUSY_POLL,
 		linux.SO_BUSY_POLL_BUDGET,
 		linux.SO_NETNS_COOKIE,
@@ -2330,6 +2602,20 @@ func setSockOptTCP(t *kernel.Task, s socket.Socket, ep commonEndpoint, name int,
 		}
 		return nil
 
+	case linux.TCP_ULP:
+		if i := bytes.IndexByte(optVal, 0); i >= 0 {
+			optVal = optVal[:i]
+		}
+		v := tcpip.TCPULPOption(optVal)
+		var cur tcpip.TCPULPOption
+		if err := ep.GetSockOpt(&cur); err != nil {
+			return syserr.TranslateNetstackError(err)
+		}
+		if v == tcpip.TCPULPTLS && cur != "" {
+			return syserr.ErrExists
+		}
+		return syserr.TranslateNetstackError(ep.SetSockOpt(&v))
+
 	case linux.TCP_LINGER2:
 		if len(optVal) < sizeOfInt32 {
 			return syserr.ErrInvalidArgument
@@ -2366,26 +2652,141 @@ func setSockOptTCP(t *kernel.Task, s socket.Socket, ep commonEndpoint, name int,
 
 		return syserr.TranslateNetstackError(ep.SetSockOptInt(tcpip.TCPWindowClampOption, int(v)))
 
+	case linux.TCP_FASTOPEN:
+		if len(optVal) < sizeOfInt32 {
+			return syserr.ErrInvalidArgument
+		}
+		v := int32(hostarch.ByteOrder.Uint32(optVal))
+
+		return syserr.TranslateNetstackError(ep.SetSockOptInt(tcpip.TCPFastOpenQueueLenOption, int(v)))
+
+	case linux.TCP_FASTOPEN_CONNECT:
+		if len(optVal) < sizeOfInt32 {
+			return syserr.ErrInvalidArgument
+		}
+		v := int32(hostarch.ByteOrder.Uint32(optVal))
+
+		return syserr.TranslateNetstackError(ep.SetSockOptInt(tcpip.TCPFastOpenConnectOption, int(v)))
+
+	case linux.TCP_FASTOPEN_NO_COOKIE:
+		if len(optVal) < sizeOfInt32 {
+			return syserr.ErrInvalidArgument
+		}
+		v := int32(hostarch.ByteOrder.Uint32(optVal))
+
+		return syserr.TranslateNetstackError(ep.SetSockOptInt(tcpip.TCPFastOpenNoCookieOption, int(v)))
+
+	case linux.TCP_FASTOPEN_KEY:
+		opt := tcpip.TCPFastOpenKeyOption(optVal)
+		return syserr.TranslateNetstackError(ep.SetSockOpt(&opt))
+
+	case linux.TCP_REPAIR:
+		if len(optVal) < sizeOfInt32 {
+			return syserr.ErrInvalidArgument
+		}
+
+		if creds := auth.CredentialsFromContext(t); !creds.HasCapability(linux.CAP_NET_ADMIN) {
+			return syserr.ErrNotPermitted
+		}
+
+		v := int32(hostarch.ByteOrder.Uint32(optVal))
+		return syserr.TranslateNetstackError(ep.SetSockOptInt(tcpip.TCPRepairOption, int(v)))
+
+	case linux.TCP_REPAIR_QUEUE:
+		if len(optVal) < sizeOfInt32 {
+			return syserr.ErrInvalidArgument
+		}
+
+		v := hostarch.ByteOrder.Uint32(optVal)
+		return syserr.TranslateNetstackError(ep.SetSockOptInt(tcpip.TCPRepairQueueOption, int(v)))
+
+	case linux.TCP_QUEUE_SEQ:
+		if len(optVal) < sizeOfInt32 {
+			return syserr.ErrInvalidArgument
+		}
+
+		v := hostarch.ByteOrder.Uint32(optVal)
+		return syserr.TranslateNetstackError(ep.SetSockOptInt(tcpip.TCPQueueSeqOption, int(v)))
+
+	case linux.TCP_REPAIR_OPTIONS:
+		// Like Linux, ignore any trailing partial option.
+		var opts tcpip.TCPRepairOptionsOption
+		for ; len(optVal) >= linux.SizeOfTCPRepairOpt; optVal = optVal[linux.SizeOfTCPRepairOpt:] {
+			var opt linux.TCPRepairOpt
+			opt.UnmarshalUnsafe(optVal)
+			opts = append(opts, tcpip.TCPRepairOpt{
+				Code:  opt.OptCode,
+				Value: opt.OptVal,
+			})
+		}
+		return syserr.TranslateNetstackError(ep.SetSockOpt(&opts))
+
+	case linux.TCP_REPAIR_WINDOW:
+		if len(optVal) != linux.SizeOfTCPRepairWindow {
+			return syserr.ErrInvalidArgument
+		}
+
+		var w linux.TCPRepairWindow
+		w.UnmarshalUnsafe(optVal)
+		opt := tcpip.TCPRepairWindowOption{
+			SndWL1:    w.SndWL1,
+			SndWnd:    w.SndWnd,
+			MaxWindow: w.MaxWindow,
+			RcvWnd:    w.RcvWnd,
+			RcvWUP:    w.RcvWUP,
+		}
+		return syserr.TranslateNetstackError(ep.SetSockOpt(&opt))
+
+	case linux.TCP_MD5SIG, linux.TCP_MD5SIG_EXT:
+		if len(optVal) < linux.SizeOfTCPMD5Sig {
+			return syserr.ErrInvalidArgument
+		}
+
+		var sig linux.TCPMD5Sig
+		sig.UnmarshalUnsafe(optVal)
+		if sig.KeyLen > linux.TCP_MD5SIG_MAXKEYLEN {
+			return syserr.ErrInvalidArgument
+		}
+		addr, family, err := socket.AddressAndFamily(sig.Addr[:])
+		if err != nil {
+			return err
+		}
+		if family != linux.AF_INET && family != linux.AF_INET6 {
+			return syserr.ErrInvalidArgument
+		}
+
+		opt := tcpip.TCPMD5SigOption{
+			Addr:      addr.Addr,
+			PrefixLen: addr.Addr.BitLen(),
+			Key:       sig.Key[:sig.KeyLen],
+		}
+		if name == linux.TCP_MD5SIG_EXT {
+			if sig.Flags&linux.TCP_MD5SIG_FLAG_PREFIX != 0 {
+				if int(sig.PrefixLen) > addr.Addr.BitLen() {
+					return syserr.ErrInvalidArgument
+				}
+				opt.PrefixLen = int(sig.PrefixLen)
+			}
+			if sig.Flags&linux.TCP_MD5SIG_FLAG_IFINDEX != 0 {
+				opt.NIC = tcpip.NICID(sig.IfIndex)
+			}
+		}
+		// Like Linux, keys for IPv4-mapped IPv6 addresses apply to IPv4
+		// peers.
+		if v4 := addr.Addr.To4(); addr.Addr.Len() == header.IPv6AddressSize && v4 != (tcpip.Address{}) {
+			opt.Addr = v4
+			opt.PrefixLen = max(opt.PrefixLen-(header.IPv6AddressSizeBits-header.IPv4AddressSizeBits), 0)
+		}
+		return syserr.TranslateNetstackError(ep.SetSockOpt(&opt))
+
 	case linux.TCP_INFO,
-		linux.TCP_MD5SIG,
 		linux.TCP_THIN_LINEAR_TIMEOUTS,
 		linux.TCP_THIN_DUPACK,
-		linux.TCP_REPAIR,
-		linux.TCP_REPAIR_QUEUE,
-		linux.TCP_QUEUE_SEQ,
-		linux.TCP_REPAIR_OPTIONS,
-		linux.TCP_FASTOPEN,
 		linux.TCP_TIMESTAMP,
 		linux.TCP_NOTSENT_LOWAT,
 		linux.TCP_CC_INFO,
 		linux.TCP_SAVE_SYN,
 		linux.TCP_SAVED_SYN,
-		linux.TCP_REPAIR_WINDOW,
-		linux.TCP_FASTOPEN_CONNECT,
-		linux.TCP_ULP,
-		linux.TCP_MD5SIG_EXT,
-		linux.TCP_FASTOPEN_KEY,
-		linux.TCP_FASTOPEN_NO_COOKIE,
 		linux.TCP_ZEROCOPY_RECEIVE,
 		linux.TCP_INQ,
 		linux.TCP_TX_DELAY:
@@ -2493,20 +2894,33 @@ func setSockOptIPv6(t *kernel.Task, s socket.Socket, ep commonEndpoint, name int
 			MulticastAddr: tcpip.AddrFrom16(req.MulticastAddr),
 		}))
 
+	case linux.IPV6_PKTINFO:
+		if len(optVal) < linux.SizeOfControlMessageIPv6PacketInfo {
+			return syserr.ErrInvalidArgument
+		}
+		var info linux.ControlMessageIPv6PacketInfo
+		info.UnmarshalUnsafe(optVal)
+		if dev := ep.SocketOptions().GetBindToDevice(); info.NIC != 0 && dev != 0 && info.NIC != uint32(dev) {
+			return syserr.ErrInvalidArgument
+		}
+		opt := tcpip.IPv6PacketInfoOption(socket.IPv6PacketInfoFromLinux(info))
+		return syserr.TranslateNetstackError(ep.SetSockOpt(&opt))
+
 	case linux.IPV6_IPSEC_POLICY,
 		linux.IPV6_JOIN_ANYCAST,
 		linux.IPV6_LEAVE_ANYCAST,
-		// TODO(b/148887420): Add support for IPV6_PKTINFO.
-		linux.IPV6_PKTINFO,
 		linux.IPV6_ROUTER_ALERT,
-		linux.IPV6_XFRM_POLICY,
-		linux.MCAST_BLOCK_SOURCE,
-		linux.MCAST_JOIN_GROUP,
-		linux.MCAST_JOIN_SOURCE_GROUP,
+		linux.IPV6_XFRM_POLICY:
+		// Not supported.
+
+	case linux.MCAST_JOIN_GROUP,
 		linux.MCAST_LEAVE_GROUP,
+		linux.MCAST_JOIN_SOURCE_GROUP,
 		linux.MCAST_LEAVE_SOURCE_GROUP,
-		linux.MCAST_UNBLOCK_SOURCE:
-		// Not supported.
+		linux.MCAST_BLOCK_SOURCE,
+		linux.MCAST_UNBLOCK_SOURCE,
+		linux.MCAST_MSFILTER:
+		return setSockOptMulticastGroup(ep, linux.AF_INET6, name, optVal)
 
 	case linux.IPV6_RECVORIGDSTADDR:
 		if len(optVal) < sizeOfInt32 {
@@ -2517,6 +2931,18 @@ func setSockOptIPv6(t *kernel.Task, s socket.Socket, ep commonEndpoint, name int
 		ep.SocketOptions().SetReceiveOriginalDstAddress(v != 0)
 		return nil
 
+	case linux.IPV6_TRANSPARENT:
+		if len(optVal) < sizeOfInt32 {
+			return syserr.ErrInvalidArgument
+		}
+		v := int32(hostarch.ByteOrder.Uint32(optVal))
+
+		if v != 0 && !canSetTransparent(t) {
+			return syserr.ErrNotPermitted
+		}
+		ep.SocketOptions().SetTransparent(v != 0)
+		return nil
+
 	case linux.IPV6_RECVPKTINFO:
 		if len(optVal) < sizeOfInt32 {
 			return syserr.ErrInvalidArgument
@@ -2622,14 +3048,12 @@ func setSockOptIPv6(t *kernel.Task, s socket.Socket, ep commonEndpoint, name int
 		linux.IPV6_2292DSTOPTS,
 		linux.IPV6_FLOWINFO,
 		linux.IPV6_RECVPATHMTU,
-		linux.IPV6_TRANSPARENT,
 		linux.IPV6_FREEBIND,
 		linux.IPV6_HOPOPTS,
 		linux.IPV6_RTHDRDSTOPTS,
 		linux.IPV6_RTHDR,
 		linux.IPV6_DSTOPTS,
 		linux.IPV6_2292PKTOPTIONS,
-		linux.MCAST_MSFILTER,
 		linux.IPV6_FLOWLABEL_MGR,
 		linux.IPV6_RECVFRAGSIZE:
 		// Not supported, but we choose to silently ignore these for compatibility
@@ -2654,6 +3078,12 @@ var (
 	inetMulticastRequestSize        = (*linux.InetMulticastRequest)(nil).SizeBytes()
 	inetMulticastRequestWithNICSize = (*linux.InetMulticastRequestWithNIC)(nil).SizeBytes()
 	inet6MulticastRequestSize       = (*linux.Inet6MulticastRequest)(nil).SizeBytes()
+	inetMulticastSourceRequestSize  = (*linux.InetMulticastSourceRequest)(nil).SizeBytes()
+	inetMulticastFilterSize         = (*linux.InetMulticastFilter)(nil).SizeBytes()
+	sockAddrStorageSize             = (*linux.SockAddrStorage)(nil).SizeBytes()
+	groupRequestSize                = (*linux.GroupRequest)(nil).SizeBytes()
+	groupSourceRequestSize          = (*linux.GroupSourceRequest)(nil).SizeBytes()
+	groupFilterSize                 = (*linux.GroupFilter)(nil).SizeBytes()
 )
 
 // copyInMulticastRequest copies in a variable-size multicast request. The
@@ -2697,6 +3127,190 @@ func copyInMulticastV6Request(optVal []byte) (linux.Inet6MulticastRequest, *syse
 	return req, nil
 }
 
+// groupAddress returns the address held by a sockaddr_storage of a group_req,
+// group_source_req or group_filter, which must be of the given family.
+func groupAddress(family int, sa []byte) (tcpip.Address, *syserr.Error) {
+	addr, saFamily, err := socket.AddressAndFamily(sa)
+	if err != nil {
+		return tcpip.Address{}, err
+	}
+	if int(saFamily) != family {
+		return tcpip.Address{}, syserr.ErrInvalidArgument
+	}
+	return addr.Addr, nil
+}
+
+// multicastFilterMode converts a MCAST_INCLUDE or MCAST_EXCLUDE filter mode to
+// its netstack counterpart.
+func multicastFilterMode(mode uint32) (tcpip.MulticastFilterMode, *syserr.Error) {
+	switch mode {
+	case linux.MCAST_INCLUDE:
+		return tcpip.MulticastFilterInclude, nil
+	case linux.MCAST_EXCLUDE:
+		return tcpip.MulticastFilterExclude, nil
+	default:
+		return 0, syserr.ErrInvalidArgument
+	}
+}
+
+// linuxMulticastFilterMode converts a netstack filter mode to MCAST_INCLUDE or
+// MCAST_EXCLUDE.
+func linuxMulticastFilterMode(mode tcpip.MulticastFilterMode) uint32 {
+	if mode == tcpip.MulticastFilterExclude {
+		return linux.MCAST_EXCLUDE
+	}
+	return linux.MCAST_INCLUDE
+}
+
+// setSockOptMulticastSource implements the options that add or remove a
+// single source of a multicast group.
+func setSockOptMulticastSource(ep commonEndpoint, name int, opt tcpip.SourceMembershipOption) *syserr.Error {
+	switch name {
+	case linux.IP_ADD_SOURCE_MEMBERSHIP, linux.MCAST_JOIN_SOURCE_GROUP:
+		addOpt := tcpip.AddSourceMembershipOption(opt)
+		return syserr.TranslateNetstackError(ep.SetSockOpt(&addOpt))
+	case linux.IP_DROP_SOURCE_MEMBERSHIP, linux.MCAST_LEAVE_SOURCE_GROUP:
+		removeOpt := tcpip.RemoveSourceMembershipOption(opt)
+		return syserr.TranslateNetstackError(ep.SetSockOpt(&removeOpt))
+	case linux.IP_BLOCK_SOURCE, linux.MCAST_BLOCK_SOURCE:
+		blockOpt := tcpip.BlockSourceOption(opt)
+		return syserr.TranslateNetstackError(ep.SetSockOpt(&blockOpt))
+	case linux.IP_UNBLOCK_SOURCE, linux.MCAST_UNBLOCK_SOURCE:
+		unblockOpt := tcpip.UnblockSourceOption(opt)
+		return syserr.TranslateNetstackError(ep.SetSockOpt(&unblockOpt))
+	default:
+		panic(fmt.Sprintf("unknown multicast source option = %d", name))
+	}
+}
+
+// setSockOptMulticastGroup implements the protocol-independent multicast
+// options (MCAST_*), which Linux supports at both SOL_IP and SOL_IPV6. The
+// addresses they hold must be of the socket's family.
+func setSockOptMulticastGroup(ep commonEndpoint, family, name int, optVal []byte) *syserr.Error {
+	switch name {
+	case linux.MCAST_JOIN_GROUP, linux.MCAST_LEAVE_GROUP:
+		if len(optVal) < groupRequestSize {
+			return syserr.ErrInvalidArgument
+		}
+		var req linux.GroupRequest
+		req.UnmarshalUnsafe(optVal)
+		group, err := groupAddress(family, req.Group[:])
+		if err != nil {
+			return err
+		}
+
+		opt := tcpip.MembershipOption{
+			NIC:           tcpip.NICID(req.InterfaceIndex),
+			MulticastAddr: group,
+		}
+		if name == linux.MCAST_JOIN_GROUP {
+			addOpt := tcpip.AddMembershipOption(opt)
+			return syserr.TranslateNetstackError(ep.SetSockOpt(&addOpt))
+		}
+		removeOpt := tcpip.RemoveMembershipOption(opt)
+		return syserr.TranslateNetstackError(ep.SetSockOpt(&removeOpt))
+
+	case linux.MCAST_JOIN_SOURCE_GROUP, linux.MCAST_LEAVE_SOURCE_GROUP, linux.MCAST_BLOCK_SOURCE, linux.MCAST_UNBLOCK_SOURCE:
+		if len(optVal) < groupSourceRequestSize {
+			return syserr.ErrInvalidArgument
+		}
+		var req linux.GroupSourceRequest
+		req.UnmarshalUnsafe(optVal)
+		group, err := groupAddress(family, req.Group[:])
+		if err != nil {
+			return err
+		}
+		source, err := groupAddress(family, req.Source[:])
+		if err != nil {
+			return err
+		}
+
+		return setSockOptMulticastSource(ep, name, tcpip.SourceMembershipOption{
+			NIC:           tcpip.NICID(req.InterfaceIndex),
+			MulticastAddr: group,
+			SourceAddr:    source,
+		})
+
+	case linux.MCAST_MSFILTER:
+		if len(optVal) < groupFilterSize {
+			return syserr.ErrInvalidArgument
+		}
+		var req linux.GroupFilter
+		req.UnmarshalUnsafe(optVal)
+		if uint64(req.NumSources) > uint64((len(optVal)-groupFilterSize)/sockAddrStorageSize) {
+			return syserr.ErrInvalidArgument
+		}
+		group, err := groupAddress(family, req.Group[:])
+		if err != nil {
+			return err
+		}
+		mode, err := multicastFilterMode(req.Mode)
+		if err != nil {
+			return err
+		}
+
+		sources := make([]tcpip.Address, 0, req.NumSources)
+		for i := 0; i < int(req.NumSources); i++ {
+			off := groupFilterSize + i*sockAddrStorageSize
+			source, err := groupAddress(family, optVal[off:off+sockAddrStorageSize])
+			if err != nil {
+				return err
+			}
+			sources = append(sources, source)
+		}
+
+		return syserr.TranslateNetstackError(ep.SetSockOpt(&tcpip.MulticastSourceFilterOption{
+			NIC:           tcpip.NICID(req.InterfaceIndex),
+			MulticastAddr: group,
+			Filter: tcpip.MulticastSourceFilter{
+				Mode:    mode,
+				Sources: sources,
+			},
+		}))
+
+	default:
+		panic(fmt.Sprintf("unknown protocol-independent multicast option = %d", name))
+	}
+}
+
+// getSockOptMulticastFilter implements getsockopt(MCAST_MSFILTER). As in
+// Linux, the group_filter passed in identifies the group, its source count is
+// the number of sources to return and the returned source count is the total
+// number of sources.
+func getSockOptMulticastFilter(t *kernel.Task, ep commonEndpoint, family int, outPtr hostarch.Addr, outLen int) (marshal.Marshallable, *syserr.Error) {
+	if outLen < groupFilterSize {
+		return nil, syserr.ErrInvalidArgument
+	}
+	var req linux.GroupFilter
+	if _, err := req.CopyIn(t, outPtr); err != nil {
+		return nil, syserr.FromError(err)
+	}
+	group, err := groupAddress(family, req.Group[:])
+	if err != nil {
+		return nil, err
+	}
+
+	opt := tcpip.MulticastSourceFilterOption{
+		NIC:           tcpip.NICID(req.InterfaceIndex),
+		MulticastAddr: group,
+	}
+	if err := ep.GetSockOpt(&opt); err != nil {
+		return nil, syserr.TranslateNetstackError(err)
+	}
+
+	n := min(int(req.NumSources), len(opt.Filter.Sources), (outLen-groupFilterSize)/sockAddrStorageSize)
+	req.Mode = linuxMulticastFilterMode(opt.Filter.Mode)
+	req.NumSources = uint32(len(opt.Filter.Sources))
+	buf := make([]byte, groupFilterSize+n*sockAddrStorageSize)
+	req.MarshalUnsafe(buf)
+	for i, source := range opt.Filter.Sources[:n] {
+		sa, _ := socket.ConvertAddress(family, tcpip.FullAddress{Addr: source})
+		sa.MarshalUnsafe(buf[groupFilterSize+i*sockAddrStorageSize:])
+	}
+	bufP := primitive.ByteSlice(buf)
+	return &bufP, nil
+}
+
 // parseIntOrChar copies either a 32-bit int or an 8-bit uint out of buf.
 //
 // net/ipv4/ip_sockglue.c:do_ip_setsockopt does this for its socket options.
@@ -2712,6 +3326,13 @@ func parseIntOrChar(buf []byte) (int32, *syserr.Error) {
 	return int32(buf[0]), nil
 }
 
+// canSetTransparent returns true if t may enable IP(V6)_TRANSPARENT, which
+// requires CAP_NET_RAW or CAP_NET_ADMIN.
+func canSetTransparent(t *kernel.Task) bool {
+	creds := auth.CredentialsFromContext(t)
+	return creds.HasCapability(linux.CAP_NET_RAW) || creds.HasCapability(linux.CAP_NET_ADMIN)
+}
+
 // setSockOptIP implements SetSockOpt when level is SOL_IP.
 func setSockOptIP(t *kernel.Task, s socket.Socket, ep commonEndpoint, name int, optVal []byte) *syserr.Error {
 	if _, ok := ep.(tcpip.Endpoint); !ok {
@@ -2783,9 +3404,56 @@ func setSockOptIP(t *kernel.Task, s socket.Socket, ep commonEndpoint, name int,
 		ep.SocketOptions().SetMulticastLoop(v != 0)
 		return nil
 
-	case linux.MCAST_JOIN_GROUP:
-		// FIXME(b/124219304): Implement MCAST_JOIN_GROUP.
-		return syserr.ErrInvalidArgument
+	case linux.IP_ADD_SOURCE_MEMBERSHIP, linux.IP_DROP_SOURCE_MEMBERSHIP, linux.IP_BLOCK_SOURCE, linux.IP_UNBLOCK_SOURCE:
+		if len(optVal) < inetMulticastSourceRequestSize {
+			return syserr.ErrInvalidArgument
+		}
+		var req linux.InetMulticastSourceRequest
+		req.UnmarshalUnsafe(optVal)
+
+		return setSockOptMulticastSource(ep, name, tcpip.SourceMembershipOption{
+			InterfaceAddr: tcpip.AddrFrom4(req.InterfaceAddr),
+			MulticastAddr: tcpip.AddrFrom4(req.MulticastAddr),
+			SourceAddr:    tcpip.AddrFrom4(req.SourceAddr),
+		})
+
+	case linux.IP_MSFILTER:
+		if len(optVal) < inetMulticastFilterSize {
+			return syserr.ErrInvalidArgument
+		}
+		var req linux.InetMulticastFilter
+		req.UnmarshalUnsafe(optVal)
+		if uint64(req.NumSources) > uint64((len(optVal)-inetMulticastFilterSize)/len(linux.InetAddr{})) {
+			return syserr.ErrInvalidArgument
+		}
+		mode, err := multicastFilterMode(req.Mode)
+		if err != nil {
+			return err
+		}
+
+		sources := make([]tcpip.Address, 0, req.NumSources)
+		for i := 0; i < int(req.NumSources); i++ {
+			off := inetMulticastFilterSize + i*len(linux.InetAddr{})
+			sources = append(sources, tcpip.AddrFromSlice(optVal[off:off+len(linux.InetAddr{})]))
+		}
+
+		return syserr.TranslateNetstackError(ep.SetSockOpt(&tcpip.MulticastSourceFilterOption{
+			InterfaceAddr: tcpip.AddrFrom4(req.InterfaceAddr),
+			MulticastAddr: tcpip.AddrFrom4(req.MulticastAddr),
+			Filter: tcpip.MulticastSourceFilter{
+				Mode:    mode,
+				Sources: sources,
+			},
+		}))
+
+	case linux.MCAST_JOIN_GROUP,
+		linux.MCAST_LEAVE_GROUP,
+		linux.MCAST_JOIN_SOURCE_GROUP,
+		linux.MCAST_LEAVE_SOURCE_GROUP,
+		linux.MCAST_BLOCK_SOURCE,
+		linux.MCAST_UNBLOCK_SOURCE,
+		linux.MCAST_MSFILTER:
+		return setSockOptMulticastGroup(ep, linux.AF_INET, name, optVal)
 
 	case linux.IP_TTL:
 		v, err := parseIntOrChar(optVal)
@@ -2872,6 +3540,18 @@ func setSockOptIP(t *kernel.Task, s socket.Socket, ep commonEndpoint, name int,
 		ep.SocketOptions().SetReceiveOriginalDstAddress(v != 0)
 		return nil
 
+	case linux.IP_TRANSPARENT:
+		v, err := parseIntOrChar(optVal)
+		if err != nil {
+			return err
+		}
+
+		if v != 0 && !canSetTransparent(t) {
+			return syserr.ErrNotPermitted
+		}
+		ep.SocketOptions().SetTransparent(v != 0)
+		return nil
+
 	case linux.IPT_SO_SET_REPLACE:
 		if len(optVal) < linux.SizeOfIPTReplace {
 			return syserr.ErrInvalidArgument
@@ -2917,7 +3597,6 @@ func setSockOptIP(t *kernel.Task, s socket.Socket, ep commonEndpoint, name int,
 		linux.IP_ROUTER_ALERT,
 		linux.IP_FREEBIND,
 		linux.IP_PASSSEC,
-		linux.IP_TRANSPARENT,
 		linux.IP_MINTTL,
 		linux.IP_NODEFRAG,
 		linux.IP_BIND_ADDRESS_NO_PORT,
@@ -2928,17 +3607,6 @@ func setSockOptIP(t *kernel.Task, s socket.Socket, ep commonEndpoint, name int,
 		linux.IP_RECVERR_RFC4884,
 		linux.IP_LOCAL_PORT_RANGE,
 		linux.IP_OPTIONS,
-		linux.IP_MSFILTER,
-		linux.IP_BLOCK_SOURCE,
-		linux.IP_UNBLOCK_SOURCE,
-		linux.IP_ADD_SOURCE_MEMBERSHIP,
-		linux.IP_DROP_SOURCE_MEMBERSHIP,
-		linux.MCAST_LEAVE_GROUP,
-		linux.MCAST_JOIN_SOURCE_GROUP,
-		linux.MCAST_LEAVE_SOURCE_GROUP,
-		linux.MCAST_BLOCK_SOURCE,
-		linux.MCAST_UNBLOCK_SOURCE,
-		linux.MCAST_MSFILTER,
 		linux.IP_IPSEC_POLICY,
 		linux.IP_XFRM_POLICY,
 		linux.IPT_SO_SET_ADD_COUNTERS:
@@ -3078,13 +3746,14 @@ func toLinuxPacketType(pktType tcpip.PacketType) uint8 {
 // nonBlockingRead issues a non-blocking read.
 //
 // TODO(b/78348848): Support timestamps for stream sockets.
-func (s *sock) nonBlockingRead(ctx context.Context, dst usermem.IOSequence, peek, trunc, senderRequested bool) (int, int, linux.SockAddr, uint32, socket.ControlMessages, *syserr.Error) {
+func (s *sock) nonBlockingRead(ctx context.Context, dst usermem.IOSequence, peek, trunc, senderRequested, needTLSRecordType bool) (int, int, linux.SockAddr, uint32, socket.ControlMessages, *syserr.Error) {
 	isPacket := s.isPacketBased()
 
 	readOptions := tcpip.ReadOptions{
 		Peek:               peek,
 		NeedRemoteAddr:     senderRequested,
 		NeedLinkPacketInfo: isPacket,
+		NeedTLSRecordType:  needTLSRecordType,
 	}
 
 	// TCP sockets discard the data if MSG_TRUNC is set.
@@ -3101,7 +3770,7 @@ func (s *sock) nonBlockingRead(ctx context.Context, dst usermem.IOSequence, peek
 	s.readMu.Lock()
 	defer s.readMu.Unlock()
 
-	if !isPacket && trunc {
+	if !isPacket && trunc && !socket.IsSCTP(s) {
 		w = &tcpip.LimitedWriter{
 			W: io.Discard,
 			N: dst.NumBytes(),
@@ -3128,6 +3797,21 @@ func (s *sock) nonBlockingRead(ctx context.Context, dst usermem.IOSequence, peek
 	// Set the control message, even if 0 bytes were read.
 	s.updateTimestamp(res.ControlMessages)
 
+	if socket.IsSCTP(s) {
+		// SCTP messages may be read in parts, and MSG_EOR marks the read
+		// that completes one.
+		var addr linux.SockAddr
+		var addrLen uint32
+		if senderRequested {
+			addr, addrLen = socket.ConvertAddress(s.family, res.RemoteAddr)
+		}
+		var flags int
+		if res.Count == res.Total {
+			flags |= linux.MSG_EOR
+		}
+		return res.Count, flags, addr, addrLen, s.netstackToLinuxControlMessages(res.ControlMessages), nil
+	}
+
 	if isPacket {
 		var addr linux.SockAddr
 		var addrLen uint32
@@ -3200,16 +3884,30 @@ func (s *sock) netstackToLinuxControlMessages(cm tcpip.ReceivableControlMessages
 			IPv6PacketInfo:     readCM.IPv6PacketInfo,
 			OriginalDstAddress: readCM.OriginalDstAddress,
 			SockErr:            readCM.SockErr,
+			HasTLSRecordType:   readCM.HasTLSRecordType,
+			TLSRecordType:      readCM.TLSRecordType,
+			HasSCTPSndRcvInfo:  readCM.HasSCTPSndRcvInfo,
+			SCTPSndRcvInfo:     readCM.SCTPSndRcvInfo,
 		},
 	}
 }
 
 func (s *sock) linuxToNetstackControlMessages(cm socket.ControlMessages) tcpip.SendableControlMessages {
 	return tcpip.SendableControlMessages{
-		HasTTL:      cm.IP.HasTTL,
-		TTL:         uint8(cm.IP.TTL),
-		HasHopLimit: cm.IP.HasHopLimit,
-		HopLimit:    uint8(cm.IP.HopLimit),
+		HasTTL:            cm.IP.HasTTL,
+		TTL:               uint8(cm.IP.TTL),
+		HasHopLimit:       cm.IP.HasHopLimit,
+		HopLimit:          uint8(cm.IP.HopLimit),
+		HasTClass:         cm.IP.HasTClass,
+		TClass:            uint8(cm.IP.TClass),
+		HasIPv6PacketInfo: cm.IP.HasIPv6PacketInfo,
+		IPv6PacketInfo:    socket.IPv6PacketInfoFromLinux(cm.IP.IPv6PacketInfo),
+		HasTLSRecordType:  cm.IP.HasTLSRecordType,
+		TLSRecordType:     cm.IP.TLSRecordType,
+		HasSCTPSndRcvInfo: cm.IP.HasSCTPSndRcvInfo,
+		SCTPSndRcvInfo:    socket.SCTPSndRcvInfoFromLinux(cm.IP.SCTPSndRcvInfo),
+		HasTxTime:         cm.IP.HasTxTime,
+		TxTime:            int64(cm.IP.TxTime),
 	}
 }
 
@@ -3274,16 +3972,23 @@ func (s *sock) recvErr(t *kernel.Task, dst usermem.IOSequence) (int, int, linux.
 	}
 	n, err := dst.CopyOut(t, sockErr.Payload.AsSlice())
 
+	cmgs := socket.ControlMessages{IP: socket.NewIPControlMessages(s.family, tcpip.ReceivableControlMessages{SockErr: sockErr})}
+
+	// The completions of MSG_ZEROCOPY sends and the errors of packets sent
+	// with SCM_TXTIME have no address.
+	if origin := sockErr.Cause.Origin(); origin == tcpip.SockExtErrorOriginZeroCopy || origin == tcpip.SockExtErrorOriginTxTime {
+		return n, msgFlags, nil, 0, cmgs, syserr.FromError(err)
+	}
+
 	// The original destination address of the datagram that caused the error is
 	// supplied via msg_name.  -- recvmsg(2)
 	dstAddr, dstAddrLen := socket.ConvertAddress(addrFamilyFromNetProto(sockErr.NetProto), sockErr.Dst)
-	cmgs := socket.ControlMessages{IP: socket.NewIPControlMessages(s.family, tcpip.ReceivableControlMessages{SockErr: sockErr})}
 	return n, msgFlags, dstAddr, dstAddrLen, cmgs, syserr.FromError(err)
 }
 
 // RecvMsg implements the linux syscall recvmsg(2) for sockets backed by
 // tcpip.Endpoint.
-func (s *sock) RecvMsg(t *kernel.Task, dst usermem.IOSequence, flags int, haveDeadline bool, deadline ktime.Time, senderRequested bool, _ uint64) (n int, msgFlags int, senderAddr linux.SockAddr, senderAddrLen uint32, controlMessages socket.ControlMessages, err *syserr.Error) {
+func (s *sock) RecvMsg(t *kernel.Task, dst usermem.IOSequence, flags int, haveDeadline bool, deadline ktime.Time, senderRequested bool, controlDataLen uint64) (n int, msgFlags int, senderAddr linux.SockAddr, senderAddrLen uint32, controlMessages socket.ControlMessages, err *syserr.Error) {
 	if flags&linux.MSG_ERRQUEUE != 0 {
 		return s.recvErr(t, dst)
 	}
@@ -3292,11 +3997,14 @@ func (s *sock) RecvMsg(t *kernel.Task, dst usermem.IOSequence, flags int, haveDe
 	peek := flags&linux.MSG_PEEK != 0
 	dontWait := flags&linux.MSG_DONTWAIT != 0
 	waitAll := flags&linux.MSG_WAITALL != 0
-	if senderRequested && !s.isPacketBased() {
+	if senderRequested && !s.isPacketBased() && !socket.IsSCTP(s) {
 		// Stream sockets ignore the sender address.
 		senderRequested = false
 	}
-	n, msgFlags, senderAddr, senderAddrLen, controlMessages, err = s.nonBlockingRead(t, dst, peek, trunc, senderRequested)
+	// Records of kernel TLS sockets that don't hold application data can
+	// only be received along with a TLS_GET_RECORD_TYPE control message.
+	needTLSRecordType := controlDataLen >= uint64(linux.SizeOfControlMessageHeader+linux.SizeOfControlMessageTLSRecordType)
+	n, msgFlags, senderAddr, senderAddrLen, controlMessages, err = s.nonBlockingRead(t, dst, peek, trunc, senderRequested, needTLSRecordType)
 
 	if s.isPacketBased() && err == syserr.ErrClosedForReceive && flags&linux.MSG_DONTWAIT != 0 {
 		// In this situation we should return EAGAIN.
@@ -3308,7 +4016,7 @@ func (s *sock) RecvMsg(t *kernel.Task, dst usermem.IOSequence, flags int, haveDe
 		return 0, 0, nil, 0, socket.ControlMessages{}, err
 	}
 
-	if err == nil && (dontWait || !waitAll || s.isPacketBased() || int64(n) >= dst.NumBytes()) {
+	if err == nil && (dontWait || !waitAll || s.isPacketBased() || socket.IsSCTP(s) || int64(n) >= dst.NumBytes()) {
 		// We got all the data we need.
 		return
 	}
@@ -3324,7 +4032,7 @@ func (s *sock) RecvMsg(t *kernel.Task, dst usermem.IOSequence, flags int, haveDe
 
 	for {
 		var rn int
-		rn, msgFlags, senderAddr, senderAddrLen, controlMessages, err = s.nonBlockingRead(t, dst, peek, trunc, senderRequested)
+		rn, msgFlags, senderAddr, senderAddrLen, controlMessages, err = s.nonBlockingRead(t, dst, peek, trunc, senderRequested, needTLSRecordType)
 		n += rn
 		if err != nil && err != syserr.ErrWouldBlock {
 			// Always stop on errors other than would block as we generally
@@ -3335,7 +4043,7 @@ func (s *sock) RecvMsg(t *kernel.Task, dst usermem.IOSequence, flags int, haveDe
 			}
 			return
 		}
-		if err == nil && (s.isPacketBased() || !waitAll || int64(rn) >= dst.NumBytes()) {
+		if err == nil && (s.isPacketBased() || socket.IsSCTP(s) || !waitAll || int64(rn) >= dst.NumBytes()) {
 			// We got all the data we need.
 			return
 		}
@@ -3361,6 +4069,11 @@ func (s *sock) SendMsg(t *kernel.Task, src usermem.IOSequence, to []byte, flags
 		return 0, syserr.ErrInvalidArgument
 	}
 
+	// As in Linux, departure times can only be set once SO_TXTIME is set.
+	if controlMessages.IP.HasTxTime && !s.Endpoint.SocketOptions().GetTxTime().Enabled {
+		return 0, syserr.ErrInvalidArgument
+	}
+
 	var addr *tcpip.FullAddress
 	if len(to) > 0 {
 		addrBuf, family, err := socket.AddressAndFamily(to)
@@ -3380,17 +4093,36 @@ func (s *sock) SendMsg(t *kernel.Task, src usermem.IOSequence, to []byte, flags
 		More:            flags&linux.MSG_MORE != 0,
 		EndOfRecord:     flags&linux.MSG_EOR != 0,
 		ControlMessages: s.linuxToNetstackControlMessages(controlMessages),
+		FastOpen:        flags&linux.MSG_FASTOPEN != 0,
 	}
 
-	r := src.Reader(t)
 	var (
+		r     tcpip.Payloader = src.Reader(t)
 		total int64
 		entry waiter.Entry
 		ch    <-chan struct{}
 	)
+
+	// As in Linux, MSG_ZEROCOPY is ignored unless SO_ZEROCOPY is set. Each
+	// send with data takes an ID, which is released if nothing is sent. The
+	// data is sent from the pages of the application if the endpoint
+	// supports it, and copied otherwise.
+	if so := s.Endpoint.SocketOptions(); flags&linux.MSG_ZEROCOPY != 0 && src.NumBytes() > 0 && so.GetZeroCopy() {
+		r = &zeroCopyReader{t: t, src: src}
+		opts.ZeroCopy = true
+		opts.ZeroCopyID = so.AllocZeroCopyID()
+		defer func() {
+			if total == 0 {
+				so.ReleaseZeroCopyID(opts.ZeroCopyID)
+			}
+		}()
+	}
+
 	for {
 		n, err := s.Endpoint.Write(r, opts)
 		total += n
+		// Only the first write may connect the endpoint.
+		opts.FastOpen = false
 		if flags&linux.MSG_DONTWAIT != 0 {
 			return int(total), syserr.TranslateNetstackError(err)
 		}
@@ -3398,7 +4130,9 @@ func (s *sock) SendMsg(t *kernel.Task, src usermem.IOSequence, to []byte, flags
 		switch err.(type) {
 		case nil:
 			block = total != src.NumBytes()
-		case *tcpip.ErrWouldBlock:
+		case *tcpip.ErrWouldBlock, *tcpip.ErrConnectStarted:
+			// A MSG_FASTOPEN write that started a connect without
+			// data waits for the connection to complete.
 		default:
 			block = false
 		}
@@ -3821,6 +4555,8 @@ func (s *sock) State() uint32 {
 		}
 	case socket.IsICMP(s):
 		// We don't support this yet.
+	case socket.IsSCTP(s):
+		// We don't support this yet.
 	case socket.IsRaw(s):
 		// We don't support this yet.
 	default:
@@ -30,6 +30,7 @@ import (
 	"gvisor.dev/gvisor/pkg/tcpip/header"
 	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
 	"gvisor.dev/gvisor/pkg/tcpip/network/ipv6"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/sctp"
 	"gvisor.dev/gvisor/pkg/tcpip/transport/tcp"
 	"gvisor.dev/gvisor/pkg/tcpip/transport/udp"
 	"gvisor.dev/gvisor/pkg/waiter"
@@ -43,17 +44,25 @@ type provider struct {
 
 var rawMissingLogger = log.BasicRateLimitedLogger(time.Minute)
 
-// getTransportProtocol figures out transport protocol. Currently only TCP,
-// UDP, and ICMP are supported. The bool return value is true when this socket
-// is associated with a transport protocol. This is only false for SOCK_RAW,
-// IPPROTO_IP sockets.
+// getTransportProtocol figures out transport protocol. Currently only TCP
+// (including Multipath TCP), UDP, SCTP and ICMP are supported. The bool return
+// value is true when this socket is associated with a transport protocol. This
+// is only false for SOCK_RAW, IPPROTO_IP sockets.
 func getTransportProtocol(ctx context.Context, stype linux.SockType, protocol int) (tcpip.TransportProtocolNumber, bool, *syserr.Error) {
 	switch stype {
 	case linux.SOCK_STREAM:
-		if protocol != 0 && protocol != unix.IPPROTO_TCP {
-			return 0, true, syserr.ErrInvalidArgument
+		switch protocol {
+		case 0, unix.IPPROTO_TCP, linux.IPPROTO_MPTCP:
+			return tcp.ProtocolNumber, true, nil
+		case unix.IPPROTO_SCTP:
+			return sctp.ProtocolNumber, true, nil
+		}
+		return 0, true, syserr.ErrInvalidArgument
+
+	case linux.SOCK_SEQPACKET:
+		if protocol == unix.IPPROTO_SCTP {
+			return sctp.ProtocolNumber, true, nil
 		}
-		return tcp.ProtocolNumber, true, nil
 
 	case linux.SOCK_DGRAM:
 		switch protocol {
@@ -132,6 +141,16 @@ func (p *provider) Socket(t *kernel.Task, stype linux.SockType, protocol int) (*
 		// iptables owner matching.
 		if e == nil {
 			ep.SetOwner(t)
+			// One-to-many style SCTP sockets are SOCK_SEQPACKET sockets.
+			if sep, ok := ep.(*sctp.Endpoint); ok && stype == linux.SOCK_SEQPACKET {
+				sep.SetOneToMany()
+			}
+			// Multipath TCP sockets are TCP endpoints, but report
+			// their own protocol.
+			if protocol == linux.IPPROTO_MPTCP {
+				ep.(*tcp.Endpoint).EnableMPTCP()
+				return New(t, p.family, stype, protocol, wq, ep)
+			}
 		}
 	}
 	if e != nil {
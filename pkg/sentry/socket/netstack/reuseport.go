@@ -0,0 +1,89 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netstack
+
+import (
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/bpf"
+	"gvisor.dev/gvisor/pkg/hostarch"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/syserr"
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+// reusePortProgram is a classic BPF program attached by
+// SO_ATTACH_REUSEPORT_CBPF.
+//
+// +stateify savable
+type reusePortProgram struct {
+	prog bpf.Program
+}
+
+var _ tcpip.ReusePortProgram = (*reusePortProgram)(nil)
+
+// Select implements tcpip.ReusePortProgram.Select. As in Linux, the program
+// loads the payload in network byte order; loads out of the payload, such as
+// the ancillary data loads of Linux, fail the program.
+func (p *reusePortProgram) Select(payload []byte) (uint32, bool) {
+	idx, err := bpf.Exec[bpf.BigEndian](p.prog, bpf.Input(payload))
+	return idx, err == nil
+}
+
+// attachReusePortProgram implements SO_ATTACH_REUSEPORT_CBPF: optVal holds a
+// struct sock_fprog whose program is copied in from t.
+func attachReusePortProgram(t *kernel.Task, ep commonEndpoint, optVal []byte) *syserr.Error {
+	var fprog linux.UserSockFprog
+	if len(optVal) != fprog.SizeBytes() {
+		return syserr.ErrInvalidArgument
+	}
+	// The program is attached to the SO_REUSEPORT group of the socket, so
+	// SO_REUSEPORT must be set.
+	if !ep.SocketOptions().GetReusePort() {
+		return syserr.ErrInvalidArgument
+	}
+
+	fprog.UnmarshalBytes(optVal)
+	if fprog.Len == 0 || fprog.Len > bpf.MaxInstructions {
+		return syserr.ErrInvalidArgument
+	}
+	insns := make([]linux.BPFInstruction, fprog.Len)
+	if _, err := linux.CopyBPFInstructionSliceIn(t, hostarch.Addr(fprog.Filter), insns); err != nil {
+		return syserr.FromError(err)
+	}
+	filter := make([]bpf.Instruction, len(insns))
+	for i, ins := range insns {
+		filter[i] = bpf.Instruction(ins)
+	}
+	prog, err := bpf.Compile(filter, true /* optimize */)
+	if err != nil {
+		t.Debugf("Invalid SO_ATTACH_REUSEPORT_CBPF program: %v", err)
+		return syserr.ErrInvalidArgument
+	}
+	ep.SocketOptions().SetReusePortProgram(&reusePortProgram{prog: prog})
+	return nil
+}
+
+// detachReusePortProgram implements SO_DETACH_REUSEPORT_BPF.
+func detachReusePortProgram(ep commonEndpoint) *syserr.Error {
+	so := ep.SocketOptions()
+	if !so.GetReusePort() {
+		return syserr.ErrInvalidArgument
+	}
+	if so.GetReusePortProgram() == nil {
+		return syserr.ErrNoFileOrDir
+	}
+	so.SetReusePortProgram(nil)
+	return nil
+}
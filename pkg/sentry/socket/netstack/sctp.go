@@ -0,0 +1,378 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netstack
+
+import (
+	"time"
+
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/hostarch"
+	"gvisor.dev/gvisor/pkg/marshal"
+	"gvisor.dev/gvisor/pkg/marshal/primitive"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/sentry/socket"
+	"gvisor.dev/gvisor/pkg/syserr"
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+// msToDuration converts a value in milliseconds, as used by SCTP socket
+// options, to a time.Duration.
+func msToDuration(ms uint32) time.Duration {
+	return time.Duration(ms) * time.Millisecond
+}
+
+// durationToMs converts a time.Duration to milliseconds, as used by SCTP
+// socket options.
+func durationToMs(d time.Duration) uint32 {
+	return uint32(d / time.Millisecond)
+}
+
+// sctpAddress returns the sockaddr_storage representation of addr.
+func sctpAddress(family int, addr tcpip.FullAddress) [128]byte {
+	var b [128]byte
+	if sa, _ := socket.ConvertAddress(family, addr); sa != nil {
+		sa.MarshalBytes(b[:sa.SizeBytes()])
+	}
+	return b
+}
+
+// getSockOptSCTP implements GetSockOpt when level is SOL_SCTP.
+func getSockOptSCTP(t *kernel.Task, s socket.Socket, ep commonEndpoint, name int, outPtr hostarch.Addr, outLen int) (marshal.Marshallable, *syserr.Error) {
+	if !socket.IsSCTP(s) {
+		return nil, syserr.ErrProtocolNotAvailable
+	}
+	family, _, _ := s.Type()
+
+	switch name {
+	case linux.SCTP_NODELAY, linux.SCTP_DISABLE_FRAGMENTS:
+		if outLen < sizeOfInt32 {
+			return nil, syserr.ErrInvalidArgument
+		}
+		opt := tcpip.SCTPNoDelayOption
+		if name == linux.SCTP_DISABLE_FRAGMENTS {
+			opt = tcpip.SCTPDisableFragmentsOption
+		}
+		v, err := ep.GetSockOptInt(opt)
+		if err != nil {
+			return nil, syserr.TranslateNetstackError(err)
+		}
+		vP := primitive.Int32(v)
+		return &vP, nil
+
+	case linux.SCTP_MAXSEG:
+		v, err := ep.GetSockOptInt(tcpip.MaxSegOption)
+		if err != nil {
+			return nil, syserr.TranslateNetstackError(err)
+		}
+		// Like Linux, also accept the deprecated int form.
+		if outLen == sizeOfInt32 {
+			vP := primitive.Int32(v)
+			return &vP, nil
+		}
+		var av linux.SCTPAssocValue
+		if outLen < av.SizeBytes() {
+			return nil, syserr.ErrInvalidArgument
+		}
+		if _, err := av.CopyIn(t, outPtr); err != nil {
+			return nil, syserr.FromError(err)
+		}
+		av.Value = uint32(v)
+		return &av, nil
+
+	case linux.SCTP_INITMSG:
+		var v tcpip.SCTPInitMsgOption
+		if outLen < (*linux.SCTPInitMsg)(nil).SizeBytes() {
+			return nil, syserr.ErrInvalidArgument
+		}
+		if err := ep.GetSockOpt(&v); err != nil {
+			return nil, syserr.TranslateNetstackError(err)
+		}
+		return &linux.SCTPInitMsg{
+			NumOstreams:  v.NumOutStreams,
+			MaxInstreams: v.MaxInStreams,
+			MaxAttempts:  v.MaxAttempts,
+			MaxInitTimeo: uint16(min(durationToMs(v.MaxInitTimeout), 0xffff)),
+		}, nil
+
+	case linux.SCTP_RTOINFO:
+		var ri linux.SCTPRTOInfo
+		if outLen < ri.SizeBytes() {
+			return nil, syserr.ErrInvalidArgument
+		}
+		if _, err := ri.CopyIn(t, outPtr); err != nil {
+			return nil, syserr.FromError(err)
+		}
+		v := tcpip.SCTPRTOInfoOption{AssocID: tcpip.SCTPAssocID(ri.AssocID)}
+		if err := ep.GetSockOpt(&v); err != nil {
+			return nil, syserr.TranslateNetstackError(err)
+		}
+		ri.Initial = durationToMs(v.Initial)
+		ri.Max = durationToMs(v.Max)
+		ri.Min = durationToMs(v.Min)
+		return &ri, nil
+
+	case linux.SCTP_ASSOCINFO:
+		var ap linux.SCTPAssocParams
+		if outLen < ap.SizeBytes() {
+			return nil, syserr.ErrInvalidArgument
+		}
+		if _, err := ap.CopyIn(t, outPtr); err != nil {
+			return nil, syserr.FromError(err)
+		}
+		v := tcpip.SCTPAssocInfoOption{AssocID: tcpip.SCTPAssocID(ap.AssocID)}
+		if err := ep.GetSockOpt(&v); err != nil {
+			return nil, syserr.TranslateNetstackError(err)
+		}
+		ap.AssocMaxRxt = v.MaxRetrans
+		ap.NumberPeerDestinations = v.NumPeerDestinations
+		ap.PeerRwnd = v.PeerRwnd
+		ap.LocalRwnd = v.LocalRwnd
+		ap.CookieLife = durationToMs(v.CookieLife)
+		return &ap, nil
+
+	case linux.SCTP_DEFAULT_SEND_PARAM:
+		var info linux.SCTPSndRcvInfo
+		if outLen < info.SizeBytes() {
+			return nil, syserr.ErrInvalidArgument
+		}
+		var v tcpip.SCTPDefaultSendParamOption
+		if err := ep.GetSockOpt(&v); err != nil {
+			return nil, syserr.TranslateNetstackError(err)
+		}
+		info = socket.SCTPSndRcvInfoToLinux(tcpip.SCTPSndRcvInfo(v))
+		return &info, nil
+
+	case linux.SCTP_EVENTS:
+		var v tcpip.SCTPEventsOption
+		if err := ep.GetSockOpt(&v); err != nil {
+			return nil, syserr.TranslateNetstackError(err)
+		}
+		ev := linux.SCTPEventSubscribe{}
+		if v.DataIO {
+			ev.DataIO = 1
+		}
+		if outLen > ev.SizeBytes() {
+			return nil, syserr.ErrInvalidArgument
+		}
+		// Older callers pass a shorter struct; return its prefix.
+		buf := make([]byte, ev.SizeBytes())
+		ev.MarshalBytes(buf)
+		b := primitive.ByteSlice(buf[:outLen])
+		return &b, nil
+
+	case linux.SCTP_PEER_ADDR_PARAMS:
+		var pp linux.SCTPPaddrParams
+		if outLen < pp.SizeBytes() {
+			return nil, syserr.ErrInvalidArgument
+		}
+		if _, err := pp.CopyIn(t, outPtr); err != nil {
+			return nil, syserr.FromError(err)
+		}
+		v := tcpip.SCTPPeerAddrParamsOption{AssocID: tcpip.SCTPAssocID(pp.AssocID)}
+		if err := ep.GetSockOpt(&v); err != nil {
+			return nil, syserr.TranslateNetstackError(err)
+		}
+		flags := uint32(linux.SPP_PMTUD_ENABLE)
+		if v.HeartbeatEnabled {
+			flags |= linux.SPP_HB_ENABLE
+		} else {
+			flags |= linux.SPP_HB_DISABLE
+		}
+		if v.SackDelay != 0 {
+			flags |= linux.SPP_SACKDELAY_ENABLE
+		} else {
+			flags |= linux.SPP_SACKDELAY_DISABLE
+		}
+		pp.HBInterval = durationToMs(v.HeartbeatInterval)
+		pp.PathMaxRxt = v.PathMaxRetrans
+		hostarch.ByteOrder.PutUint32(pp.PathMTU[:], v.PathMTU)
+		hostarch.ByteOrder.PutUint32(pp.SackDelay[:], durationToMs(v.SackDelay))
+		hostarch.ByteOrder.PutUint32(pp.Flags[:], flags)
+		return &pp, nil
+
+	case linux.SCTP_STATUS:
+		var st linux.SCTPStatus
+		if outLen < st.SizeBytes() {
+			return nil, syserr.ErrInvalidArgument
+		}
+		if _, err := st.CopyIn(t, outPtr); err != nil {
+			return nil, syserr.FromError(err)
+		}
+		v := tcpip.SCTPStatusOption{AssocID: tcpip.SCTPAssocID(st.AssocID)}
+		if err := ep.GetSockOpt(&v); err != nil {
+			return nil, syserr.TranslateNetstackError(err)
+		}
+		pathState := int32(linux.SCTP_INACTIVE)
+		if v.PrimaryActive {
+			pathState = linux.SCTP_ACTIVE
+		}
+		return &linux.SCTPStatus{
+			AssocID:            int32(v.AssocID),
+			State:              int32(v.State),
+			Rwnd:               v.Rwnd,
+			UnackData:          v.UnackedData,
+			PendData:           v.PendingData,
+			InStreams:          v.InStreams,
+			OutStreams:         v.OutStreams,
+			FragmentationPoint: v.FragmentationPoint,
+			Primary: linux.SCTPPaddrInfo{
+				AssocID: int32(v.AssocID),
+				Address: sctpAddress(family, v.PrimaryAddr),
+				State:   pathState,
+				Cwnd:    v.PrimaryCwnd,
+				SRTT:    durationToMs(v.PrimarySRTT),
+				RTO:     durationToMs(v.PrimaryRTO),
+				MTU:     v.PrimaryMTU,
+			},
+		}, nil
+	}
+	return nil, syserr.ErrProtocolNotAvailable
+}
+
+// setSockOptSCTP implements SetSockOpt when level is SOL_SCTP.
+func setSockOptSCTP(t *kernel.Task, s socket.Socket, ep commonEndpoint, name int, optVal []byte) *syserr.Error {
+	if !socket.IsSCTP(s) {
+		return syserr.ErrProtocolNotAvailable
+	}
+
+	switch name {
+	case linux.SCTP_NODELAY, linux.SCTP_DISABLE_FRAGMENTS:
+		if len(optVal) < sizeOfInt32 {
+			return syserr.ErrInvalidArgument
+		}
+		opt := tcpip.SCTPNoDelayOption
+		if name == linux.SCTP_DISABLE_FRAGMENTS {
+			opt = tcpip.SCTPDisableFragmentsOption
+		}
+		v := hostarch.ByteOrder.Uint32(optVal)
+		return syserr.TranslateNetstackError(ep.SetSockOptInt(opt, int(v)))
+
+	case linux.SCTP_MAXSEG:
+		var v int32
+		switch {
+		case len(optVal) == sizeOfInt32:
+			// The deprecated int form.
+			v = int32(hostarch.ByteOrder.Uint32(optVal))
+		case len(optVal) >= (*linux.SCTPAssocValue)(nil).SizeBytes():
+			var av linux.SCTPAssocValue
+			av.UnmarshalUnsafe(optVal)
+			v = int32(av.Value)
+		default:
+			return syserr.ErrInvalidArgument
+		}
+		if v < 0 {
+			return syserr.ErrInvalidArgument
+		}
+		return syserr.TranslateNetstackError(ep.SetSockOptInt(tcpip.MaxSegOption, int(v)))
+
+	case linux.SCTP_INITMSG:
+		var im linux.SCTPInitMsg
+		if len(optVal) < im.SizeBytes() {
+			return syserr.ErrInvalidArgument
+		}
+		im.UnmarshalUnsafe(optVal)
+		return syserr.TranslateNetstackError(ep.SetSockOpt(&tcpip.SCTPInitMsgOption{
+			NumOutStreams:  im.NumOstreams,
+			MaxInStreams:   im.MaxInstreams,
+			MaxAttempts:    im.MaxAttempts,
+			MaxInitTimeout: msToDuration(uint32(im.MaxInitTimeo)),
+		}))
+
+	case linux.SCTP_RTOINFO:
+		var ri linux.SCTPRTOInfo
+		if len(optVal) < ri.SizeBytes() {
+			return syserr.ErrInvalidArgument
+		}
+		ri.UnmarshalUnsafe(optVal)
+		return syserr.TranslateNetstackError(ep.SetSockOpt(&tcpip.SCTPRTOInfoOption{
+			AssocID: tcpip.SCTPAssocID(ri.AssocID),
+			Initial: msToDuration(ri.Initial),
+			Max:     msToDuration(ri.Max),
+			Min:     msToDuration(ri.Min),
+		}))
+
+	case linux.SCTP_ASSOCINFO:
+		var ap linux.SCTPAssocParams
+		if len(optVal) < ap.SizeBytes() {
+			return syserr.ErrInvalidArgument
+		}
+		ap.UnmarshalUnsafe(optVal)
+		return syserr.TranslateNetstackError(ep.SetSockOpt(&tcpip.SCTPAssocInfoOption{
+			AssocID:    tcpip.SCTPAssocID(ap.AssocID),
+			MaxRetrans: ap.AssocMaxRxt,
+			CookieLife: msToDuration(ap.CookieLife),
+		}))
+
+	case linux.SCTP_DEFAULT_SEND_PARAM:
+		var info linux.SCTPSndRcvInfo
+		if len(optVal) < info.SizeBytes() {
+			return syserr.ErrInvalidArgument
+		}
+		info.UnmarshalUnsafe(optVal)
+		v := tcpip.SCTPDefaultSendParamOption(socket.SCTPSndRcvInfoFromLinux(info))
+		return syserr.TranslateNetstackError(ep.SetSockOpt(&v))
+
+	case linux.SCTP_EVENTS:
+		var ev linux.SCTPEventSubscribe
+		if len(optVal) > ev.SizeBytes() {
+			return syserr.ErrInvalidArgument
+		}
+		buf := make([]byte, ev.SizeBytes())
+		copy(buf, optVal)
+		ev.UnmarshalBytes(buf)
+		return syserr.TranslateNetstackError(ep.SetSockOpt(&tcpip.SCTPEventsOption{
+			DataIO: ev.DataIO != 0,
+		}))
+
+	case linux.SCTP_PEER_ADDR_PARAMS:
+		var pp linux.SCTPPaddrParams
+		if len(optVal) < pp.SizeBytes() {
+			return syserr.ErrInvalidArgument
+		}
+		pp.UnmarshalBytes(optVal)
+		flags := hostarch.ByteOrder.Uint32(pp.Flags[:])
+		if flags&linux.SPP_HB_ENABLE != 0 && flags&linux.SPP_HB_DISABLE != 0 ||
+			flags&linux.SPP_SACKDELAY_ENABLE != 0 && flags&linux.SPP_SACKDELAY_DISABLE != 0 {
+			return syserr.ErrInvalidArgument
+		}
+
+		// Only the parameters the caller asks to change are updated.
+		v := tcpip.SCTPPeerAddrParamsOption{AssocID: tcpip.SCTPAssocID(pp.AssocID)}
+		if err := ep.GetSockOpt(&v); err != nil {
+			return syserr.TranslateNetstackError(err)
+		}
+		switch {
+		case flags&linux.SPP_HB_ENABLE != 0:
+			v.HeartbeatEnabled = true
+		case flags&linux.SPP_HB_DISABLE != 0:
+			v.HeartbeatEnabled = false
+		}
+		if flags&linux.SPP_HB_TIME_IS_ZERO != 0 {
+			v.HeartbeatInterval = 0
+		} else if pp.HBInterval != 0 {
+			v.HeartbeatInterval = msToDuration(pp.HBInterval)
+		}
+		if pp.PathMaxRxt != 0 {
+			v.PathMaxRetrans = pp.PathMaxRxt
+		}
+		if sackDelay := hostarch.ByteOrder.Uint32(pp.SackDelay[:]); sackDelay != 0 {
+			v.SackDelay = msToDuration(sackDelay)
+		} else if flags&linux.SPP_SACKDELAY_DISABLE != 0 {
+			v.SackDelay = 0
+		}
+		return syserr.TranslateNetstackError(ep.SetSockOpt(&v))
+	}
+	return syserr.ErrProtocolNotAvailable
+}
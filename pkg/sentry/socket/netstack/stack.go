@@ -15,6 +15,7 @@
 package netstack
 
 import (
+	"encoding/binary"
 	"fmt"
 
 	"gvisor.dev/gvisor/pkg/abi/linux"
@@ -28,8 +29,14 @@ import (
 	"gvisor.dev/gvisor/pkg/tcpip"
 	"gvisor.dev/gvisor/pkg/tcpip/header"
 	"gvisor.dev/gvisor/pkg/tcpip/link/ethernet"
+	"gvisor.dev/gvisor/pkg/tcpip/link/iptunnel"
+	"gvisor.dev/gvisor/pkg/tcpip/link/ipvlan"
+	"gvisor.dev/gvisor/pkg/tcpip/link/macvlan"
 	"gvisor.dev/gvisor/pkg/tcpip/link/packetsocket"
+	"gvisor.dev/gvisor/pkg/tcpip/link/udptunnel"
 	"gvisor.dev/gvisor/pkg/tcpip/link/veth"
+	"gvisor.dev/gvisor/pkg/tcpip/link/vlan"
+	"gvisor.dev/gvisor/pkg/tcpip/link/wireguard"
 	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
 	"gvisor.dev/gvisor/pkg/tcpip/network/ipv6"
 	"gvisor.dev/gvisor/pkg/tcpip/stack"
@@ -81,6 +88,12 @@ func toLinuxARPHardwareType(t header.ARPHardwareType) uint16 {
 		return linux.ARPHRD_LOOPBACK
 	case header.ARPHardwareEther:
 		return linux.ARPHRD_ETHER
+	case header.ARPHardwareTunnel:
+		return linux.ARPHRD_TUNNEL
+	case header.ARPHardwareSIT:
+		return linux.ARPHRD_SIT
+	case header.ARPHardwareIPGRE:
+		return linux.ARPHRD_IPGRE
 	default:
 		panic(fmt.Sprintf("unknown ARPHRD type: %d", t))
 	}
@@ -90,13 +103,22 @@ func toLinuxARPHardwareType(t header.ARPHardwareType) uint16 {
 func (s *Stack) Interfaces() map[int32]inet.Interface {
 	is := make(map[int32]inet.Interface)
 	for id, ni := range s.Stack.NICInfo() {
-		is[int32(id)] = inet.Interface{
+		i := inet.Interface{
 			Name:       ni.Name,
 			Addr:       []byte(ni.LinkAddress),
 			Flags:      uint32(nicStateFlagsToLinux(ni.Flags)),
 			DeviceType: toLinuxARPHardwareType(ni.ARPHardwareType),
 			MTU:        ni.MTU,
 		}
+		switch ni.Context.(type) {
+		case *wireguard.Endpoint:
+			i.Kind = "wireguard"
+		case *macvlan.Endpoint:
+			i.Kind = "macvlan"
+		case *ipvlan.Endpoint:
+			i.Kind = "ipvlan"
+		}
+		is[int32(id)] = i
 	}
 	return is
 }
@@ -152,6 +174,8 @@ func (s *Stack) SetInterface(ctx context.Context, msg *nlmsg.Message) *syserr.Er
 		case linux.IFLA_MTU:
 		case linux.IFLA_NET_NS_FD:
 		case linux.IFLA_TXQLEN:
+		case linux.IFLA_LINK:
+		case linux.IFLA_AF_SPEC:
 		default:
 			ctx.Warningf("unexpected attribute: %x", attr)
 			return syserr.ErrNotSupported
@@ -168,6 +192,9 @@ func (s *Stack) SetInterface(ctx context.Context, msg *nlmsg.Message) *syserr.Er
 	if flags&(linux.NLM_F_EXCL|linux.NLM_F_REPLACE) != 0 {
 		return syserr.ErrExists
 	}
+	if ifinfomsg.Family == linux.AF_BRIDGE {
+		return s.setBridgeVLANs(tcpip.NICID(ifinfomsg.Index), attrs, msg.Header().Type != linux.RTM_DELLINK)
+	}
 	if ifinfomsg.Flags != 0 || ifinfomsg.Change != 0 {
 		if ifinfomsg.Change & ^uint32(linux.IFF_UP) != 0 {
 			ctx.Warningf("Unsupported ifi_change flags: %x", ifinfomsg.Change)
@@ -225,6 +252,13 @@ func (s *Stack) setLink(ctx context.Context, id tcpip.NICID, linkAttrs map[uint1
 				return syserr.ErrInvalidArgument
 			}
 			addr := tcpip.LinkAddress(v)
+			if ep, ok := s.nicContext(id).(*macvlan.Endpoint); ok {
+				// The address of a macvlan interface must not be
+				// used by its parent or siblings.
+				if err := ep.SetAddress(addr); err != nil {
+					return syserr.TranslateNetstackError(err)
+				}
+			}
 			if err := s.Stack.SetNICAddress(id, addr); err != nil {
 				return syserr.TranslateNetstackError(err)
 			}
@@ -242,11 +276,115 @@ func (s *Stack) setLink(ctx context.Context, id tcpip.NICID, linkAttrs map[uint1
 			}
 		case linux.IFLA_TXQLEN:
 			// TODO(b/340388892): support IFLA_TXQLEN.
+		case linux.IFLA_LINKINFO:
+			if err := s.setLinkInfo(id, v); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
 }
 
+// setLinkInfo applies the IFLA_LINKINFO attribute v to the interface id. Only
+// bridge attributes can be changed.
+func (s *Stack) setLinkInfo(id tcpip.NICID, v nlmsg.BytesView) *syserr.Error {
+	linkInfoAttrs, ok := nlmsg.AttrsView(v).Parse()
+	if !ok {
+		return syserr.ErrInvalidArgument
+	}
+	kind := linkInfoAttrs[linux.IFLA_INFO_KIND]
+	if kind.String() != "bridge" {
+		return nil
+	}
+	data, ok := linkInfoAttrs[linux.IFLA_INFO_DATA]
+	if !ok {
+		return nil
+	}
+	bridgeAttrs, ok := nlmsg.AttrsView(data).Parse()
+	if !ok {
+		return syserr.ErrInvalidArgument
+	}
+	if v, ok := bridgeAttrs[linux.IFLA_BR_VLAN_FILTERING]; ok {
+		enabled, err := parseUint8(v)
+		if err != nil {
+			return err
+		}
+		if err := s.Stack.SetBridgeVLANFiltering(id, enabled != 0); err != nil {
+			return syserr.TranslateNetstackError(err)
+		}
+	}
+	return nil
+}
+
+// setBridgeVLANs adds or removes the VLANs of the IFLA_AF_SPEC attribute of an
+// AF_BRIDGE request to the interface id, which is either a bridge port or a
+// bridge.
+func (s *Stack) setBridgeVLANs(id tcpip.NICID, attrs map[uint16]nlmsg.BytesView, add bool) *syserr.Error {
+	v, ok := attrs[linux.IFLA_AF_SPEC]
+	if !ok {
+		return nil
+	}
+	// The attribute can hold several IFLA_BRIDGE_VLAN_INFO attributes, and
+	// their order matters for ranges, so it can't be parsed into a map.
+	var rangeBegin uint16
+	for spec := nlmsg.AttrsView(v); !spec.Empty(); {
+		hdr, value, rest, ok := spec.ParseFirst()
+		if !ok {
+			return syserr.ErrInvalidArgument
+		}
+		spec = rest
+		if hdr.Type != linux.IFLA_BRIDGE_VLAN_INFO {
+			// IFLA_BRIDGE_FLAGS only tells whether the request is
+			// for a port or for the bridge itself, which id
+			// already does.
+			continue
+		}
+		var info linux.BridgeVLANInfo
+		if len(value) != info.SizeBytes() {
+			return syserr.ErrInvalidArgument
+		}
+		info.UnmarshalUnsafe(value)
+		if info.VID == 0 || info.VID > header.VLANMaxVID {
+			return syserr.ErrInvalidArgument
+		}
+		first := info.VID
+		switch {
+		case info.Flags&linux.BRIDGE_VLAN_INFO_RANGE_BEGIN != 0:
+			if rangeBegin != 0 {
+				return syserr.ErrInvalidArgument
+			}
+			rangeBegin = info.VID
+			continue
+		case info.Flags&linux.BRIDGE_VLAN_INFO_RANGE_END != 0:
+			if rangeBegin == 0 || rangeBegin > info.VID {
+				return syserr.ErrInvalidArgument
+			}
+			first, rangeBegin = rangeBegin, 0
+		case rangeBegin != 0:
+			return syserr.ErrInvalidArgument
+		}
+		flags := stack.BridgeVLANFlags{
+			PVID:     info.Flags&linux.BRIDGE_VLAN_INFO_PVID != 0,
+			Untagged: info.Flags&linux.BRIDGE_VLAN_INFO_UNTAGGED != 0,
+		}
+		for vid := first; vid <= info.VID; vid++ {
+			var err tcpip.Error
+			if add {
+				err = s.Stack.AddBridgeVLAN(id, vid, flags)
+			} else {
+				err = s.Stack.RemoveBridgeVLAN(id, vid)
+			}
+			if err != nil {
+				return syserr.TranslateNetstackError(err)
+			}
+		}
+	}
+	if rangeBegin != 0 {
+		return syserr.ErrInvalidArgument
+	}
+	return nil
+}
+
 const defaultMTU = 1500
 
 func (s *Stack) newVeth(ctx context.Context, linkAttrs map[uint16]nlmsg.BytesView, linkInfoAttrs map[uint16]nlmsg.BytesView) *syserr.Error {
@@ -357,6 +495,574 @@ func (s *Stack) newBridge(ctx context.Context, linkAttrs map[uint16]nlmsg.BytesV
 	return nil
 }
 
+// vxlanLinuxDefaultPort is the VXLAN port that Linux uses when none is given.
+// It predates the IANA assigned port.
+const vxlanLinuxDefaultPort = 8472
+
+// parseTunnelAddr parses an IPv4 or IPv6 address attribute of a tunnel.
+func parseTunnelAddr(v nlmsg.BytesView, size int) (tcpip.Address, *syserr.Error) {
+	if len(v) != size {
+		return tcpip.Address{}, syserr.ErrInvalidArgument
+	}
+	return tcpip.AddrFromSlice(v), nil
+}
+
+// parseTunnelPort parses a UDP port attribute of a tunnel, which is in network
+// byte order.
+func parseTunnelPort(v nlmsg.BytesView) (uint16, *syserr.Error) {
+	if len(v) != 2 {
+		return 0, syserr.ErrInvalidArgument
+	}
+	return binary.BigEndian.Uint16(v), nil
+}
+
+func parseVXLANOptions(data map[uint16]nlmsg.BytesView) (udptunnel.Options, *syserr.Error) {
+	opts := udptunnel.Options{
+		Kind:     udptunnel.VXLAN,
+		Port:     vxlanLinuxDefaultPort,
+		Learning: true,
+	}
+	if data == nil {
+		return opts, syserr.ErrInvalidArgument
+	}
+	var err *syserr.Error
+	for t, v := range data {
+		switch t {
+		case linux.IFLA_VXLAN_ID:
+			var ok bool
+			if opts.VNI, ok = v.Uint32(); !ok {
+				return opts, syserr.ErrInvalidArgument
+			}
+			if opts.VNI > header.VXLANMaxVNI {
+				return opts, syserr.ErrRange
+			}
+		case linux.IFLA_VXLAN_GROUP:
+			opts.Remote, err = parseTunnelAddr(v, header.IPv4AddressSize)
+		case linux.IFLA_VXLAN_GROUP6:
+			opts.Remote, err = parseTunnelAddr(v, header.IPv6AddressSize)
+		case linux.IFLA_VXLAN_LOCAL:
+			opts.Local, err = parseTunnelAddr(v, header.IPv4AddressSize)
+		case linux.IFLA_VXLAN_LOCAL6:
+			opts.Local, err = parseTunnelAddr(v, header.IPv6AddressSize)
+		case linux.IFLA_VXLAN_LINK:
+			nicID, ok := v.Uint32()
+			if !ok {
+				return opts, syserr.ErrInvalidArgument
+			}
+			opts.NIC = tcpip.NICID(nicID)
+		case linux.IFLA_VXLAN_PORT:
+			opts.Port, err = parseTunnelPort(v)
+		case linux.IFLA_VXLAN_LEARNING:
+			if len(v) != 1 {
+				return opts, syserr.ErrInvalidArgument
+			}
+			opts.Learning = v[0] != 0
+		default:
+			// Other attributes, such as the TTL and the FDB ageing
+			// time, aren't supported and are ignored.
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+func parseGeneveOptions(data map[uint16]nlmsg.BytesView) (udptunnel.Options, *syserr.Error) {
+	opts := udptunnel.Options{
+		Kind: udptunnel.Geneve,
+		Port: header.GeneveDefaultPort,
+	}
+	if data == nil {
+		return opts, syserr.ErrInvalidArgument
+	}
+	var err *syserr.Error
+	for t, v := range data {
+		switch t {
+		case linux.IFLA_GENEVE_ID:
+			var ok bool
+			if opts.VNI, ok = v.Uint32(); !ok {
+				return opts, syserr.ErrInvalidArgument
+			}
+			if opts.VNI > header.GeneveMaxVNI {
+				return opts, syserr.ErrRange
+			}
+		case linux.IFLA_GENEVE_REMOTE:
+			opts.Remote, err = parseTunnelAddr(v, header.IPv4AddressSize)
+		case linux.IFLA_GENEVE_REMOTE6:
+			opts.Remote, err = parseTunnelAddr(v, header.IPv6AddressSize)
+		case linux.IFLA_GENEVE_PORT:
+			opts.Port, err = parseTunnelPort(v)
+		case linux.IFLA_GENEVE_COLLECT_METADATA:
+			// Metadata based tunnels are configured by routes, which
+			// aren't supported.
+			return opts, syserr.ErrNotSupported
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+	// Geneve tunnels are point-to-point.
+	if opts.Remote.Len() == 0 {
+		return opts, syserr.ErrInvalidArgument
+	}
+	return opts, nil
+}
+
+// newTunnel creates a VXLAN or Geneve interface, which encapsulates Ethernet
+// frames over a UDP endpoint of s.
+func (s *Stack) newTunnel(ctx context.Context, kind udptunnel.Kind, linkAttrs map[uint16]nlmsg.BytesView, linkInfoAttrs map[uint16]nlmsg.BytesView) *syserr.Error {
+	var linkInfoData map[uint16]nlmsg.BytesView
+	if value, ok := linkInfoAttrs[linux.IFLA_INFO_DATA]; ok {
+		linkInfoData, ok = nlmsg.AttrsView(value).Parse()
+		if !ok {
+			return syserr.ErrInvalidArgument
+		}
+	}
+	var (
+		opts udptunnel.Options
+		serr *syserr.Error
+	)
+	switch kind {
+	case udptunnel.VXLAN:
+		opts, serr = parseVXLANOptions(linkInfoData)
+	case udptunnel.Geneve:
+		opts, serr = parseGeneveOptions(linkInfoData)
+	}
+	if serr != nil {
+		return serr
+	}
+	if opts.NIC != 0 {
+		if _, ok := s.Stack.NICInfo()[opts.NIC]; !ok {
+			return syserr.ErrNoDevice
+		}
+	}
+
+	ep, err := udptunnel.New(s.Stack, opts)
+	if err != nil {
+		return syserr.TranslateNetstackError(err)
+	}
+	id := s.Stack.NextNICID()
+	ifname := ""
+	if v, ok := linkAttrs[linux.IFLA_IFNAME]; ok {
+		ifname = v.String()
+	}
+	if ifname == "" {
+		ifname = fmt.Sprintf("%s%d", kind, id)
+	}
+	err = s.Stack.CreateNICWithOptions(id, packetsocket.New(ethernet.New(ep)), stack.NICOptions{
+		Name: ifname,
+	})
+	if err != nil {
+		ep.Close()
+		return syserr.TranslateNetstackError(err)
+	}
+	return s.setLink(ctx, id, linkAttrs)
+}
+
+// parseIPTunnelAddr parses the IPv4 address of an IP tunnel, which is
+// unspecified if it is the "any" address.
+func parseIPTunnelAddr(v nlmsg.BytesView) (tcpip.Address, *syserr.Error) {
+	addr, err := parseTunnelAddr(v, header.IPv4AddressSize)
+	if err != nil || addr.Unspecified() {
+		return tcpip.Address{}, err
+	}
+	return addr, nil
+}
+
+// parseUint8 parses an attribute that holds a single byte.
+func parseUint8(v nlmsg.BytesView) (uint8, *syserr.Error) {
+	if len(v) != 1 {
+		return 0, syserr.ErrInvalidArgument
+	}
+	return v[0], nil
+}
+
+func parseIPIPOptions(kind iptunnel.Kind, data map[uint16]nlmsg.BytesView) (iptunnel.Options, *syserr.Error) {
+	opts := iptunnel.Options{Kind: kind}
+	var err *syserr.Error
+	for t, v := range data {
+		switch t {
+		case linux.IFLA_IPTUN_LINK:
+			nicID, ok := v.Uint32()
+			if !ok {
+				return opts, syserr.ErrInvalidArgument
+			}
+			opts.NIC = tcpip.NICID(nicID)
+		case linux.IFLA_IPTUN_LOCAL:
+			opts.Local, err = parseIPTunnelAddr(v)
+		case linux.IFLA_IPTUN_REMOTE:
+			opts.Remote, err = parseIPTunnelAddr(v)
+		case linux.IFLA_IPTUN_TTL:
+			opts.TTL, err = parseUint8(v)
+		case linux.IFLA_IPTUN_TOS:
+			opts.TOS, err = parseUint8(v)
+		case linux.IFLA_IPTUN_PROTO:
+			// Only the protocol of the kind is supported, which is
+			// what iproute2 requests by default.
+			proto, perr := parseUint8(v)
+			if perr != nil {
+				return opts, perr
+			}
+			if proto != 0 && proto != uint8(kind.Protocol()) {
+				return opts, syserr.ErrNotSupported
+			}
+		default:
+			// Other attributes, such as path MTU discovery and 6rd
+			// settings, aren't supported and are ignored.
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+func parseGREOptions(data map[uint16]nlmsg.BytesView) (iptunnel.Options, *syserr.Error) {
+	opts := iptunnel.Options{Kind: iptunnel.GRE}
+	var err *syserr.Error
+	for t, v := range data {
+		switch t {
+		case linux.IFLA_GRE_LINK:
+			nicID, ok := v.Uint32()
+			if !ok {
+				return opts, syserr.ErrInvalidArgument
+			}
+			opts.NIC = tcpip.NICID(nicID)
+		case linux.IFLA_GRE_IFLAGS, linux.IFLA_GRE_OFLAGS:
+			if len(v) != 2 {
+				return opts, syserr.ErrInvalidArgument
+			}
+			flags := binary.BigEndian.Uint16(v)
+			if flags&^(linux.GRE_CSUM|linux.GRE_KEY|linux.GRE_SEQ) != 0 {
+				return opts, syserr.ErrInvalidArgument
+			}
+			if t == linux.IFLA_GRE_IFLAGS {
+				opts.IFlags = flags
+			} else {
+				opts.OFlags = flags
+			}
+		case linux.IFLA_GRE_IKEY, linux.IFLA_GRE_OKEY:
+			if len(v) != 4 {
+				return opts, syserr.ErrInvalidArgument
+			}
+			if t == linux.IFLA_GRE_IKEY {
+				opts.IKey = binary.BigEndian.Uint32(v)
+			} else {
+				opts.OKey = binary.BigEndian.Uint32(v)
+			}
+		case linux.IFLA_GRE_LOCAL:
+			opts.Local, err = parseIPTunnelAddr(v)
+		case linux.IFLA_GRE_REMOTE:
+			opts.Remote, err = parseIPTunnelAddr(v)
+		case linux.IFLA_GRE_TTL:
+			opts.TTL, err = parseUint8(v)
+		case linux.IFLA_GRE_TOS:
+			opts.TOS, err = parseUint8(v)
+		default:
+			// Other attributes, such as path MTU discovery, aren't
+			// supported and are ignored.
+		}
+		if err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+// newIPTunnel creates an IP-in-IP, SIT or GRE interface, which encapsulates
+// IP packets in IPv4 packets routed by s.
+func (s *Stack) newIPTunnel(ctx context.Context, kind iptunnel.Kind, linkAttrs map[uint16]nlmsg.BytesView, linkInfoAttrs map[uint16]nlmsg.BytesView) *syserr.Error {
+	var linkInfoData map[uint16]nlmsg.BytesView
+	if value, ok := linkInfoAttrs[linux.IFLA_INFO_DATA]; ok {
+		linkInfoData, ok = nlmsg.AttrsView(value).Parse()
+		if !ok {
+			return syserr.ErrInvalidArgument
+		}
+	}
+	var (
+		opts iptunnel.Options
+		serr *syserr.Error
+	)
+	if kind == iptunnel.GRE {
+		opts, serr = parseGREOptions(linkInfoData)
+	} else {
+		opts, serr = parseIPIPOptions(kind, linkInfoData)
+	}
+	if serr != nil {
+		return serr
+	}
+	if opts.NIC != 0 {
+		if _, ok := s.Stack.NICInfo()[opts.NIC]; !ok {
+			return syserr.ErrNoDevice
+		}
+	}
+
+	ep, err := iptunnel.New(s.Stack, opts)
+	if err != nil {
+		return syserr.TranslateNetstackError(err)
+	}
+	id := s.Stack.NextNICID()
+	ifname := ""
+	if v, ok := linkAttrs[linux.IFLA_IFNAME]; ok {
+		ifname = v.String()
+	}
+	if ifname == "" {
+		ifname = fmt.Sprintf("%s%d", kind, id)
+	}
+	err = s.Stack.CreateNICWithOptions(id, packetsocket.New(ep), stack.NICOptions{
+		Name: ifname,
+	})
+	if err != nil {
+		ep.Close()
+		return syserr.TranslateNetstackError(err)
+	}
+	return s.setLink(ctx, id, linkAttrs)
+}
+
+// newVLAN creates a VLAN sub-interface of the interface of IFLA_LINK.
+func (s *Stack) newVLAN(ctx context.Context, linkAttrs map[uint16]nlmsg.BytesView, linkInfoAttrs map[uint16]nlmsg.BytesView) *syserr.Error {
+	var opts vlan.Options
+	v, ok := linkAttrs[linux.IFLA_LINK]
+	if !ok {
+		return syserr.ErrInvalidArgument
+	}
+	parent, ok := v.Uint32()
+	if !ok {
+		return syserr.ErrInvalidArgument
+	}
+	opts.Parent = tcpip.NICID(parent)
+	if _, ok := s.Stack.NICInfo()[opts.Parent]; !ok {
+		return syserr.ErrNoDevice
+	}
+
+	var linkInfoData map[uint16]nlmsg.BytesView
+	if value, ok := linkInfoAttrs[linux.IFLA_INFO_DATA]; ok {
+		linkInfoData, ok = nlmsg.AttrsView(value).Parse()
+		if !ok {
+			return syserr.ErrInvalidArgument
+		}
+	}
+	v, ok = linkInfoData[linux.IFLA_VLAN_ID]
+	if !ok {
+		return syserr.ErrInvalidArgument
+	}
+	if opts.VID, ok = v.Uint16(); !ok || opts.VID > header.VLANMaxVID {
+		return syserr.ErrInvalidArgument
+	}
+	if v, ok := linkInfoData[linux.IFLA_VLAN_PROTOCOL]; ok {
+		// The protocol is in network byte order.
+		if len(v) != 2 {
+			return syserr.ErrInvalidArgument
+		}
+		opts.Protocol = tcpip.NetworkProtocolNumber(binary.BigEndian.Uint16(v))
+	}
+
+	ep, err := vlan.New(s.Stack, opts)
+	if err != nil {
+		return syserr.TranslateNetstackError(err)
+	}
+	id := s.Stack.NextNICID()
+	ifname := ""
+	if v, ok := linkAttrs[linux.IFLA_IFNAME]; ok {
+		ifname = v.String()
+	}
+	if ifname == "" {
+		ifname = fmt.Sprintf("vlan%d", id)
+	}
+	err = s.Stack.CreateNICWithOptions(id, packetsocket.New(ethernet.New(ep)), stack.NICOptions{
+		Name: ifname,
+	})
+	if err != nil {
+		ep.Close()
+		return syserr.TranslateNetstackError(err)
+	}
+	return s.setLink(ctx, id, linkAttrs)
+}
+
+// newMACVLAN creates a macvlan interface of the interface of IFLA_LINK.
+func (s *Stack) newMACVLAN(ctx context.Context, linkAttrs map[uint16]nlmsg.BytesView, linkInfoAttrs map[uint16]nlmsg.BytesView) *syserr.Error {
+	// Like on Linux, the default mode is VEPA.
+	opts := macvlan.Options{Mode: stack.MACVLANModeVEPA}
+	parent, serr := s.linkParent(linkAttrs)
+	if serr != nil {
+		return serr
+	}
+	opts.Parent = parent
+	if v, ok := linkAttrs[linux.IFLA_ADDRESS]; ok {
+		if len(v) != tcpip.LinkAddressSize {
+			return syserr.ErrInvalidArgument
+		}
+		opts.LinkAddress = tcpip.LinkAddress(v)
+	}
+
+	var linkInfoData map[uint16]nlmsg.BytesView
+	if value, ok := linkInfoAttrs[linux.IFLA_INFO_DATA]; ok {
+		linkInfoData, ok = nlmsg.AttrsView(value).Parse()
+		if !ok {
+			return syserr.ErrInvalidArgument
+		}
+	}
+	if v, ok := linkInfoData[linux.IFLA_MACVLAN_MODE]; ok {
+		mode, ok := v.Uint32()
+		if !ok {
+			return syserr.ErrInvalidArgument
+		}
+		switch mode {
+		case linux.MACVLAN_MODE_PRIVATE:
+			opts.Mode = stack.MACVLANModePrivate
+		case linux.MACVLAN_MODE_VEPA:
+			opts.Mode = stack.MACVLANModeVEPA
+		case linux.MACVLAN_MODE_BRIDGE:
+			opts.Mode = stack.MACVLANModeBridge
+		case linux.MACVLAN_MODE_PASSTHRU, linux.MACVLAN_MODE_SOURCE:
+			return syserr.ErrNotSupported
+		default:
+			return syserr.ErrInvalidArgument
+		}
+	}
+
+	ep, err := macvlan.New(s.Stack, opts)
+	if err != nil {
+		return syserr.TranslateNetstackError(err)
+	}
+	id := s.Stack.NextNICID()
+	ifname := ""
+	if v, ok := linkAttrs[linux.IFLA_IFNAME]; ok {
+		ifname = v.String()
+	}
+	if ifname == "" {
+		ifname = fmt.Sprintf("macvlan%d", id)
+	}
+	err = s.Stack.CreateNICWithOptions(id, packetsocket.New(ethernet.New(ep)), stack.NICOptions{
+		Name:    ifname,
+		Context: ep,
+	})
+	if err != nil {
+		ep.Close()
+		return syserr.TranslateNetstackError(err)
+	}
+	return s.setLink(ctx, id, linkAttrs)
+}
+
+// newIPVLAN creates an ipvlan interface of the interface of IFLA_LINK.
+func (s *Stack) newIPVLAN(ctx context.Context, linkAttrs map[uint16]nlmsg.BytesView, linkInfoAttrs map[uint16]nlmsg.BytesView) *syserr.Error {
+	var opts ipvlan.Options
+	parent, serr := s.linkParent(linkAttrs)
+	if serr != nil {
+		return serr
+	}
+	opts.Parent = parent
+
+	var linkInfoData map[uint16]nlmsg.BytesView
+	if value, ok := linkInfoAttrs[linux.IFLA_INFO_DATA]; ok {
+		linkInfoData, ok = nlmsg.AttrsView(value).Parse()
+		if !ok {
+			return syserr.ErrInvalidArgument
+		}
+	}
+	if v, ok := linkInfoData[linux.IFLA_IPVLAN_MODE]; ok {
+		mode, ok := v.Uint16()
+		if !ok {
+			return syserr.ErrInvalidArgument
+		}
+		switch mode {
+		case linux.IPVLAN_MODE_L2:
+			opts.Mode = stack.IPVLANModeL2
+		case linux.IPVLAN_MODE_L3:
+			opts.Mode = stack.IPVLANModeL3
+		case linux.IPVLAN_MODE_L3S:
+			return syserr.ErrNotSupported
+		default:
+			return syserr.ErrInvalidArgument
+		}
+	}
+	if v, ok := linkInfoData[linux.IFLA_IPVLAN_FLAGS]; ok {
+		flags, ok := v.Uint16()
+		if !ok {
+			return syserr.ErrInvalidArgument
+		}
+		// Only the default bridge behavior is supported.
+		if flags != 0 {
+			return syserr.ErrNotSupported
+		}
+	}
+
+	ep, err := ipvlan.New(s.Stack, opts)
+	if err != nil {
+		return syserr.TranslateNetstackError(err)
+	}
+	id := s.Stack.NextNICID()
+	ifname := ""
+	if v, ok := linkAttrs[linux.IFLA_IFNAME]; ok {
+		ifname = v.String()
+	}
+	if ifname == "" {
+		ifname = fmt.Sprintf("ipvlan%d", id)
+	}
+	var linkEP stack.LinkEndpoint = ep
+	if opts.Mode == stack.IPVLANModeL2 {
+		linkEP = ethernet.New(ep)
+	}
+	err = s.Stack.CreateNICWithOptions(id, packetsocket.New(linkEP), stack.NICOptions{
+		Name:    ifname,
+		Context: ep,
+	})
+	if err != nil {
+		ep.Close()
+		return syserr.TranslateNetstackError(err)
+	}
+	return s.setLink(ctx, id, linkAttrs)
+}
+
+// linkParent returns the interface of IFLA_LINK.
+func (s *Stack) linkParent(linkAttrs map[uint16]nlmsg.BytesView) (tcpip.NICID, *syserr.Error) {
+	v, ok := linkAttrs[linux.IFLA_LINK]
+	if !ok {
+		return 0, syserr.ErrInvalidArgument
+	}
+	parent, ok := v.Uint32()
+	if !ok {
+		return 0, syserr.ErrInvalidArgument
+	}
+	if _, ok := s.Stack.NICInfo()[tcpip.NICID(parent)]; !ok {
+		return 0, syserr.ErrNoDevice
+	}
+	return tcpip.NICID(parent), nil
+}
+
+// nicContext returns the context of the NIC id, or nil if it doesn't exist.
+func (s *Stack) nicContext(id tcpip.NICID) stack.NICContext {
+	return s.Stack.NICInfo()[id].Context
+}
+
+// newWireGuard creates a WireGuard interface, which is configured through the
+// wireguard generic netlink family. The endpoint of the interface is its NIC
+// context, where the family finds it.
+func (s *Stack) newWireGuard(ctx context.Context, linkAttrs map[uint16]nlmsg.BytesView) *syserr.Error {
+	ep, err := wireguard.New(s.Stack, wireguard.Options{})
+	if err != nil {
+		return syserr.TranslateNetstackError(err)
+	}
+	id := s.Stack.NextNICID()
+	ifname := ""
+	if v, ok := linkAttrs[linux.IFLA_IFNAME]; ok {
+		ifname = v.String()
+	}
+	if ifname == "" {
+		ifname = fmt.Sprintf("wg%d", id)
+	}
+	err = s.Stack.CreateNICWithOptions(id, packetsocket.New(ep), stack.NICOptions{
+		Name:    ifname,
+		Context: ep,
+	})
+	if err != nil {
+		ep.Close()
+		return syserr.TranslateNetstackError(err)
+	}
+	return s.setLink(ctx, id, linkAttrs)
+}
+
 func (s *Stack) newInterface(ctx context.Context, msg *nlmsg.Message, linkAttrs map[uint16]nlmsg.BytesView) *syserr.Error {
 	var (
 		linkInfoAttrs map[uint16]nlmsg.BytesView
@@ -388,6 +1094,24 @@ func (s *Stack) newInterface(ctx context.Context, msg *nlmsg.Message, linkAttrs
 		return s.newBridge(ctx, linkAttrs, linkInfoAttrs)
 	case "veth":
 		return s.newVeth(ctx, linkAttrs, linkInfoAttrs)
+	case "vxlan":
+		return s.newTunnel(ctx, udptunnel.VXLAN, linkAttrs, linkInfoAttrs)
+	case "geneve":
+		return s.newTunnel(ctx, udptunnel.Geneve, linkAttrs, linkInfoAttrs)
+	case "ipip":
+		return s.newIPTunnel(ctx, iptunnel.IPIP, linkAttrs, linkInfoAttrs)
+	case "sit":
+		return s.newIPTunnel(ctx, iptunnel.SIT, linkAttrs, linkInfoAttrs)
+	case "gre":
+		return s.newIPTunnel(ctx, iptunnel.GRE, linkAttrs, linkInfoAttrs)
+	case "vlan":
+		return s.newVLAN(ctx, linkAttrs, linkInfoAttrs)
+	case "wireguard":
+		return s.newWireGuard(ctx, linkAttrs)
+	case "macvlan":
+		return s.newMACVLAN(ctx, linkAttrs, linkInfoAttrs)
+	case "ipvlan":
+		return s.newIPVLAN(ctx, linkAttrs, linkInfoAttrs)
 	}
 	return syserr.ErrNotSupported
 }
@@ -471,7 +1195,18 @@ func (s *Stack) AddInterfaceAddr(idx int32, addr inet.InterfaceAddr) error {
 
 	// Attach address to interface.
 	nicID := tcpip.NICID(idx)
+	ipvlanEP, _ := s.nicContext(nicID).(*ipvlan.Endpoint)
+	if ipvlanEP != nil {
+		// The parent of an ipvlan interface hands it the packets
+		// destined to its addresses.
+		if err := ipvlanEP.AddAddress(protocolAddress.AddressWithPrefix.Address); err != nil {
+			return syserr.TranslateNetstackError(err).ToError()
+		}
+	}
 	if err := s.Stack.AddProtocolAddress(nicID, protocolAddress, stack.AddressProperties{}); err != nil {
+		if ipvlanEP != nil {
+			ipvlanEP.RemoveAddress(protocolAddress.AddressWithPrefix.Address)
+		}
 		return syserr.TranslateNetstackError(err).ToError()
 	}
 
@@ -506,6 +1241,9 @@ func (s *Stack) RemoveInterfaceAddr(idx int32, addr inet.InterfaceAddr) error {
 	if err := s.Stack.RemoveAddress(nicID, protocolAddress.AddressWithPrefix.Address); err != nil {
 		return syserr.TranslateNetstackError(err).ToError()
 	}
+	if ep, ok := s.nicContext(nicID).(*ipvlan.Endpoint); ok {
+		ep.RemoveAddress(protocolAddress.AddressWithPrefix.Address)
+	}
 
 	// Remove the corresponding local network route if it exists.
 	localRoute := tcpip.Route{
@@ -590,6 +1328,21 @@ func (s *Stack) SetTCPRecovery(recovery inet.TCPLossRecovery) error {
 	return syserr.TranslateNetstackError(s.Stack.SetTransportProtocolOption(tcp.ProtocolNumber, &opt)).ToError()
 }
 
+// TCPFastOpen implements inet.Stack.TCPFastOpen.
+func (s *Stack) TCPFastOpen() (int32, error) {
+	var mode tcpip.TCPFastOpen
+	if err := s.Stack.TransportProtocolOption(tcp.ProtocolNumber, &mode); err != nil {
+		return 0, syserr.TranslateNetstackError(err).ToError()
+	}
+	return int32(mode), nil
+}
+
+// SetTCPFastOpen implements inet.Stack.SetTCPFastOpen.
+func (s *Stack) SetTCPFastOpen(mode int32) error {
+	opt := tcpip.TCPFastOpen(mode)
+	return syserr.TranslateNetstackError(s.Stack.SetTransportProtocolOption(tcp.ProtocolNumber, &opt)).ToError()
+}
+
 // Statistics implements inet.Stack.Statistics.
 func (s *Stack) Statistics(stat any, arg string) error {
 	netStats := s.Stats()
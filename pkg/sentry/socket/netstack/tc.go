@@ -0,0 +1,647 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netstack
+
+import (
+	"math"
+	"math/bits"
+	"sort"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/context"
+	"gvisor.dev/gvisor/pkg/marshal/primitive"
+	"gvisor.dev/gvisor/pkg/sentry/inet"
+	"gvisor.dev/gvisor/pkg/sentry/socket/netlink/nlmsg"
+	"gvisor.dev/gvisor/pkg/syserr"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/link/qdisc/fq"
+	"gvisor.dev/gvisor/pkg/tcpip/link/qdisc/htb"
+	"gvisor.dev/gvisor/pkg/tcpip/link/qdisc/shaping"
+	"gvisor.dev/gvisor/pkg/tcpip/link/qdisc/tbf"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+// autoQDiscHandle is the handle of the queueing disciplines added without
+// one, which is the first handle allocated by Linux.
+const autoQDiscHandle = 0x80010000
+
+// htbVersion is the version of the HTB implementation reported to tc(8).
+const htbVersion = 3
+
+// qDisc is a root queueing discipline added through rtnetlink, which keeps
+// the handle chosen by the user.
+//
+// +stateify savable
+type qDisc struct {
+	stack.QueueingDiscipline
+	handle uint32
+}
+
+// unwrapQDisc returns the handle of q and the queueing discipline that it
+// wraps.
+func unwrapQDisc(q stack.QueueingDiscipline) (uint32, stack.QueueingDiscipline) {
+	if q, ok := q.(*qDisc); ok {
+		return q.handle, q.QueueingDiscipline
+	}
+	return 0, q
+}
+
+// qDiscKind returns the name of the kind of q for tc(8).
+func qDiscKind(q stack.QueueingDiscipline) string {
+	switch q.(type) {
+	case nil:
+		return "noqueue"
+	case *tbf.Discipline:
+		return "tbf"
+	case *fq.Discipline:
+		return "fq"
+	case *htb.Discipline:
+		return "htb"
+	default:
+		return "pfifo_fast"
+	}
+}
+
+// ticksToBytes returns the number of bytes sent at rate in the given number
+// of psched ticks.
+func ticksToBytes(rate uint64, ticks uint32) uint32 {
+	hi, lo := bits.Mul64(rate, uint64(ticks)*linux.PschedTickNS)
+	if hi >= uint64(time.Second) {
+		return math.MaxUint32
+	}
+	n, _ := bits.Div64(hi, lo, uint64(time.Second))
+	return uint32(min(n, math.MaxUint32))
+}
+
+// bytesToTicks returns the number of psched ticks that it takes to send size
+// bytes at rate.
+func bytesToTicks(rate uint64, size uint32) uint32 {
+	if rate == 0 {
+		return 0
+	}
+	hi, lo := bits.Mul64(uint64(size), uint64(time.Second)/linux.PschedTickNS)
+	if hi >= rate {
+		return math.MaxUint32
+	}
+	n, _ := bits.Div64(hi, lo, rate)
+	return uint32(min(n, math.MaxUint32))
+}
+
+// rateSpec returns the 32-bit rate of a tc_ratespec, and whether the rate
+// must be reported in a 64-bit attribute.
+func rateSpec(rate uint64) (linux.TcRateSpec, bool) {
+	if rate > math.MaxUint32 {
+		return linux.TcRateSpec{Rate: math.MaxUint32}, true
+	}
+	return linux.TcRateSpec{Rate: uint32(rate)}, false
+}
+
+// parseTcMessage parses a RTM_*QDISC or RTM_*TCLASS message, and returns its
+// kind and options.
+func parseTcMessage(msg *nlmsg.Message) (linux.TcMessage, string, map[uint16]nlmsg.BytesView, *syserr.Error) {
+	var tcm linux.TcMessage
+	attrs, ok := msg.GetData(&tcm)
+	if !ok {
+		return tcm, "", nil, syserr.ErrInvalidArgument
+	}
+	var kind string
+	opts := make(map[uint16]nlmsg.BytesView)
+	for !attrs.Empty() {
+		ahdr, value, rest, ok := attrs.ParseFirst()
+		if !ok {
+			return tcm, "", nil, syserr.ErrInvalidArgument
+		}
+		attrs = rest
+		switch ahdr.Type {
+		case linux.TCA_KIND:
+			v := nlmsg.BytesView(value)
+			kind = v.String()
+		case linux.TCA_OPTIONS:
+			if opts, ok = nlmsg.AttrsView(value).Parse(); !ok {
+				return tcm, "", nil, syserr.ErrInvalidArgument
+			}
+		}
+	}
+	return tcm, kind, opts, nil
+}
+
+// uint32Opt returns the value of the uint32 attribute typ, or def if it is
+// missing.
+func uint32Opt(opts map[uint16]nlmsg.BytesView, typ uint16, def uint32) (uint32, *syserr.Error) {
+	v, ok := opts[typ]
+	if !ok {
+		return def, nil
+	}
+	n, ok := v.Uint32()
+	if !ok {
+		return 0, syserr.ErrInvalidArgument
+	}
+	return n, nil
+}
+
+// rateOpt returns the rate of spec, or the value of the uint64 attribute typ
+// if it is present.
+func rateOpt(opts map[uint16]nlmsg.BytesView, typ uint16, spec linux.TcRateSpec) (uint64, *syserr.Error) {
+	v, ok := opts[typ]
+	if !ok {
+		return uint64(spec.Rate), nil
+	}
+	var rate primitive.Uint64
+	if len(v) < rate.SizeBytes() {
+		return 0, syserr.ErrInvalidArgument
+	}
+	rate.UnmarshalUnsafe(v)
+	return uint64(rate), nil
+}
+
+func parseTBFOptions(opts map[uint16]nlmsg.BytesView) (tbf.Options, *syserr.Error) {
+	v, ok := opts[linux.TCA_TBF_PARMS]
+	var qopt linux.TcTBFQopt
+	if !ok || len(v) < qopt.SizeBytes() {
+		return tbf.Options{}, syserr.ErrInvalidArgument
+	}
+	qopt.UnmarshalUnsafe(v)
+	rate, err := rateOpt(opts, linux.TCA_TBF_RATE64, qopt.Rate)
+	if err != nil {
+		return tbf.Options{}, err
+	}
+	peakRate, err := rateOpt(opts, linux.TCA_TBF_PRATE64, qopt.PeakRate)
+	if err != nil {
+		return tbf.Options{}, err
+	}
+	burst, err := uint32Opt(opts, linux.TCA_TBF_BURST, ticksToBytes(rate, qopt.Buffer))
+	if err != nil {
+		return tbf.Options{}, err
+	}
+	mtu, err := uint32Opt(opts, linux.TCA_TBF_PBURST, ticksToBytes(peakRate, qopt.MTU))
+	if err != nil {
+		return tbf.Options{}, err
+	}
+	return tbf.Options{
+		Rate:     rate,
+		Burst:    burst,
+		Limit:    qopt.Limit,
+		PeakRate: peakRate,
+		MTU:      mtu,
+	}, nil
+}
+
+func tbfOptions(d *tbf.Discipline) []byte {
+	opts := d.Options()
+	qopt := linux.TcTBFQopt{
+		Limit:  opts.Limit,
+		Buffer: bytesToTicks(opts.Rate, opts.Burst),
+		MTU:    bytesToTicks(opts.PeakRate, opts.MTU),
+	}
+	var rate64, peakRate64 bool
+	qopt.Rate, rate64 = rateSpec(opts.Rate)
+	qopt.PeakRate, peakRate64 = rateSpec(opts.PeakRate)
+
+	var attrs nlmsg.Attrs
+	attrs.PutAttr(linux.TCA_TBF_PARMS, &qopt)
+	if rate64 {
+		attrs.PutAttr(linux.TCA_TBF_RATE64, primitive.AllocateUint64(opts.Rate))
+	}
+	if peakRate64 {
+		attrs.PutAttr(linux.TCA_TBF_PRATE64, primitive.AllocateUint64(opts.PeakRate))
+	}
+	return attrs
+}
+
+func parseFQOptions(opts map[uint16]nlmsg.BytesView, cur fq.Options) (fq.Options, *syserr.Error) {
+	var err *syserr.Error
+	for _, opt := range []struct {
+		typ uint16
+		val *uint32
+	}{
+		{linux.TCA_FQ_PLIMIT, &cur.Limit},
+		{linux.TCA_FQ_FLOW_PLIMIT, &cur.FlowLimit},
+		{linux.TCA_FQ_QUANTUM, &cur.Quantum},
+		{linux.TCA_FQ_INITIAL_QUANTUM, &cur.InitialQuantum},
+	} {
+		if *opt.val, err = uint32Opt(opts, opt.typ, *opt.val); err != nil {
+			return fq.Options{}, err
+		}
+	}
+	maxRate := uint32(math.MaxUint32)
+	if cur.MaxRate != 0 {
+		maxRate = uint32(min(cur.MaxRate, math.MaxUint32-1))
+	}
+	if maxRate, err = uint32Opt(opts, linux.TCA_FQ_FLOW_MAX_RATE, maxRate); err != nil {
+		return fq.Options{}, err
+	}
+	cur.MaxRate = 0
+	if maxRate != math.MaxUint32 {
+		cur.MaxRate = uint64(maxRate)
+	}
+	return cur, nil
+}
+
+func fqOptions(d *fq.Discipline) []byte {
+	opts := d.Options()
+	maxRate := uint32(math.MaxUint32)
+	if opts.MaxRate != 0 {
+		maxRate = uint32(min(opts.MaxRate, math.MaxUint32-1))
+	}
+	var attrs nlmsg.Attrs
+	attrs.PutAttr(linux.TCA_FQ_PLIMIT, primitive.AllocateUint32(opts.Limit))
+	attrs.PutAttr(linux.TCA_FQ_FLOW_PLIMIT, primitive.AllocateUint32(opts.FlowLimit))
+	attrs.PutAttr(linux.TCA_FQ_QUANTUM, primitive.AllocateUint32(opts.Quantum))
+	attrs.PutAttr(linux.TCA_FQ_INITIAL_QUANTUM, primitive.AllocateUint32(opts.InitialQuantum))
+	attrs.PutAttr(linux.TCA_FQ_FLOW_MAX_RATE, primitive.AllocateUint32(maxRate))
+	return attrs
+}
+
+func parseHTBOptions(opts map[uint16]nlmsg.BytesView, handle uint32, cur htb.Options) (htb.Options, *syserr.Error) {
+	if v, ok := opts[linux.TCA_HTB_INIT]; ok {
+		var glob linux.TcHTBGlob
+		if len(v) < glob.SizeBytes() {
+			return htb.Options{}, syserr.ErrInvalidArgument
+		}
+		glob.UnmarshalUnsafe(v)
+		if glob.Version != htbVersion {
+			return htb.Options{}, syserr.ErrInvalidArgument
+		}
+		cur.DefaultClass = 0
+		if glob.DefCls != 0 {
+			cur.DefaultClass = handle&linux.TC_H_MAJ_MASK | glob.DefCls&linux.TC_H_MIN_MASK
+		}
+	}
+	var err *syserr.Error
+	cur.DirectQueueLen, err = uint32Opt(opts, linux.TCA_HTB_DIRECT_QLEN, cur.DirectQueueLen)
+	return cur, err
+}
+
+func htbOptions(d *htb.Discipline) []byte {
+	opts := d.Options()
+	glob := linux.TcHTBGlob{
+		Version:      htbVersion,
+		Rate2Quantum: 10,
+		DefCls:       opts.DefaultClass & linux.TC_H_MIN_MASK,
+	}
+	var attrs nlmsg.Attrs
+	attrs.PutAttr(linux.TCA_HTB_INIT, &glob)
+	attrs.PutAttr(linux.TCA_HTB_DIRECT_QLEN, primitive.AllocateUint32(opts.DirectQueueLen))
+	return attrs
+}
+
+func parseHTBClassOptions(opts map[uint16]nlmsg.BytesView, parent uint32) (htb.ClassOptions, *syserr.Error) {
+	v, ok := opts[linux.TCA_HTB_PARMS]
+	var opt linux.TcHTBOpt
+	if !ok || len(v) < opt.SizeBytes() {
+		return htb.ClassOptions{}, syserr.ErrInvalidArgument
+	}
+	opt.UnmarshalUnsafe(v)
+	rate, err := rateOpt(opts, linux.TCA_HTB_RATE64, opt.Rate)
+	if err != nil {
+		return htb.ClassOptions{}, err
+	}
+	ceil, err := rateOpt(opts, linux.TCA_HTB_CEIL64, opt.Ceil)
+	if err != nil {
+		return htb.ClassOptions{}, err
+	}
+	return htb.ClassOptions{
+		Parent:  parent,
+		Rate:    rate,
+		Ceil:    ceil,
+		Burst:   ticksToBytes(rate, opt.Buffer),
+		CBurst:  ticksToBytes(ceil, opt.CBuffer),
+		Quantum: opt.Quantum,
+		Prio:    opt.Prio,
+	}, nil
+}
+
+func htbClassOptions(opts htb.ClassOptions) []byte {
+	opt := linux.TcHTBOpt{
+		Buffer:  bytesToTicks(opts.Rate, opts.Burst),
+		CBuffer: bytesToTicks(opts.Ceil, opts.CBurst),
+		Quantum: opts.Quantum,
+		Prio:    opts.Prio,
+	}
+	var rate64, ceil64 bool
+	opt.Rate, rate64 = rateSpec(opts.Rate)
+	opt.Ceil, ceil64 = rateSpec(opts.Ceil)
+
+	var attrs nlmsg.Attrs
+	attrs.PutAttr(linux.TCA_HTB_PARMS, &opt)
+	if rate64 {
+		attrs.PutAttr(linux.TCA_HTB_RATE64, primitive.AllocateUint64(opts.Rate))
+	}
+	if ceil64 {
+		attrs.PutAttr(linux.TCA_HTB_CEIL64, primitive.AllocateUint64(opts.Ceil))
+	}
+	return attrs
+}
+
+// newQDisc returns a function that creates a queueing discipline of the given
+// kind for SetNICQueueingDiscipline.
+func (s *Stack) newQDisc(kind string, handle uint32, opts map[uint16]nlmsg.BytesView) (func(stack.LinkWriter) (stack.QueueingDiscipline, tcpip.Error), *syserr.Error) {
+	clock := s.Stack.Clock()
+	switch kind {
+	case "tbf":
+		tbfOpts, err := parseTBFOptions(opts)
+		if err != nil {
+			return nil, err
+		}
+		return func(lower stack.LinkWriter) (stack.QueueingDiscipline, tcpip.Error) {
+			d, err := tbf.New(lower, clock, tbfOpts)
+			if err != nil {
+				return nil, err
+			}
+			return &qDisc{QueueingDiscipline: d, handle: handle}, nil
+		}, nil
+	case "fq":
+		fqOpts, err := parseFQOptions(opts, fq.Options{})
+		if err != nil {
+			return nil, err
+		}
+		return func(lower stack.LinkWriter) (stack.QueueingDiscipline, tcpip.Error) {
+			return &qDisc{QueueingDiscipline: fq.New(lower, clock, fqOpts), handle: handle}, nil
+		}, nil
+	case "htb":
+		// Like on Linux, the global options are required.
+		if _, ok := opts[linux.TCA_HTB_INIT]; !ok {
+			return nil, syserr.ErrInvalidArgument
+		}
+		htbOpts, err := parseHTBOptions(opts, handle, htb.Options{})
+		if err != nil {
+			return nil, err
+		}
+		return func(lower stack.LinkWriter) (stack.QueueingDiscipline, tcpip.Error) {
+			return &qDisc{QueueingDiscipline: htb.New(lower, clock, htbOpts), handle: handle}, nil
+		}, nil
+	default:
+		// Linux fails to load the module of unknown kinds.
+		return nil, syserr.ErrNoFileOrDir
+	}
+}
+
+// changeQDisc changes the options of q.
+func changeQDisc(q stack.QueueingDiscipline, handle uint32, opts map[uint16]nlmsg.BytesView) *syserr.Error {
+	switch d := q.(type) {
+	case *tbf.Discipline:
+		tbfOpts, err := parseTBFOptions(opts)
+		if err != nil {
+			return err
+		}
+		return syserr.TranslateNetstackError(d.SetOptions(tbfOpts))
+	case *fq.Discipline:
+		fqOpts, err := parseFQOptions(opts, d.Options())
+		if err != nil {
+			return err
+		}
+		d.SetOptions(fqOpts)
+	case *htb.Discipline:
+		htbOpts, err := parseHTBOptions(opts, handle, d.Options())
+		if err != nil {
+			return err
+		}
+		d.SetOptions(htbOpts)
+	default:
+		return syserr.ErrNotSupported
+	}
+	return nil
+}
+
+// NewQDisc implements inet.Stack.NewQDisc.
+func (s *Stack) NewQDisc(ctx context.Context, msg *nlmsg.Message) *syserr.Error {
+	tcm, kind, opts, err := parseTcMessage(msg)
+	if err != nil {
+		return err
+	}
+	// Only root queueing disciplines are supported.
+	if tcm.Parent != linux.TC_H_ROOT {
+		return syserr.ErrNotSupported
+	}
+	if tcm.Handle&linux.TC_H_MIN_MASK != 0 {
+		return syserr.ErrInvalidArgument
+	}
+	nicID := tcpip.NICID(tcm.Index)
+	cur, tcpipErr := s.Stack.NICQueueingDiscipline(nicID)
+	if tcpipErr != nil {
+		return syserr.TranslateNetstackError(tcpipErr)
+	}
+
+	flags := msg.Header().Flags
+	if q, ok := cur.(*qDisc); ok && (tcm.Handle == 0 || tcm.Handle == q.handle) {
+		if flags&linux.NLM_F_EXCL != 0 {
+			return syserr.ErrExists
+		}
+		if qDiscKind(q.QueueingDiscipline) == kind {
+			return changeQDisc(q.QueueingDiscipline, q.handle, opts)
+		}
+		if flags&linux.NLM_F_REPLACE == 0 {
+			return syserr.ErrInvalidArgument
+		}
+	} else if flags&linux.NLM_F_CREATE == 0 {
+		return syserr.ErrNoFileOrDir
+	}
+
+	handle := tcm.Handle
+	if handle == 0 {
+		handle = autoQDiscHandle
+	}
+	newQDisc, err := s.newQDisc(kind, handle, opts)
+	if err != nil {
+		return err
+	}
+	return syserr.TranslateNetstackError(s.Stack.SetNICQueueingDiscipline(nicID, newQDisc))
+}
+
+// RemoveQDisc implements inet.Stack.RemoveQDisc.
+func (s *Stack) RemoveQDisc(ctx context.Context, msg *nlmsg.Message) *syserr.Error {
+	tcm, _, _, err := parseTcMessage(msg)
+	if err != nil {
+		return err
+	}
+	if tcm.Parent != linux.TC_H_ROOT {
+		return syserr.ErrNotSupported
+	}
+	nicID := tcpip.NICID(tcm.Index)
+	cur, tcpipErr := s.Stack.NICQueueingDiscipline(nicID)
+	if tcpipErr != nil {
+		return syserr.TranslateNetstackError(tcpipErr)
+	}
+	// The default queueing disciplines can't be removed.
+	q, ok := cur.(*qDisc)
+	if !ok || (tcm.Handle != 0 && tcm.Handle != q.handle) {
+		return syserr.ErrNoFileOrDir
+	}
+	return syserr.TranslateNetstackError(s.Stack.SetNICQueueingDiscipline(nicID, nil))
+}
+
+// qDiscStats returns the statistics of q.
+func qDiscStats(q stack.QueueingDiscipline) inet.TrafficControlStats {
+	d, ok := q.(interface{ Stats() shaping.Stats })
+	if !ok {
+		return inet.TrafficControlStats{}
+	}
+	stats := d.Stats()
+	return inet.TrafficControlStats{
+		Bytes:      stats.Bytes,
+		Packets:    stats.Packets,
+		Drops:      stats.Drops,
+		Overlimits: stats.Overlimits,
+		Qlen:       stats.Qlen,
+		Backlog:    stats.Backlog,
+	}
+}
+
+// sortedNICs returns the IDs of the NICs in increasing order.
+func (s *Stack) sortedNICs() []tcpip.NICID {
+	var ids []tcpip.NICID
+	for id := range s.Stack.NICInfo() {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// QDiscs implements inet.Stack.QDiscs.
+func (s *Stack) QDiscs() []inet.TrafficControl {
+	var qDiscs []inet.TrafficControl
+	for _, id := range s.sortedNICs() {
+		cur, err := s.Stack.NICQueueingDiscipline(id)
+		if err != nil {
+			continue
+		}
+		handle, q := unwrapQDisc(cur)
+		tc := inet.TrafficControl{
+			Index:  int32(id),
+			Handle: handle,
+			Parent: linux.TC_H_ROOT,
+			Kind:   qDiscKind(q),
+			Stats:  qDiscStats(q),
+		}
+		switch d := q.(type) {
+		case *tbf.Discipline:
+			tc.Options = tbfOptions(d)
+		case *fq.Discipline:
+			tc.Options = fqOptions(d)
+		case *htb.Discipline:
+			tc.Options = htbOptions(d)
+		}
+		qDiscs = append(qDiscs, tc)
+	}
+	return qDiscs
+}
+
+// htbQDisc returns the HTB queueing discipline of a NIC and its handle.
+func (s *Stack) htbQDisc(idx int32) (*htb.Discipline, uint32, *syserr.Error) {
+	cur, err := s.Stack.NICQueueingDiscipline(tcpip.NICID(idx))
+	if err != nil {
+		return nil, 0, syserr.TranslateNetstackError(err)
+	}
+	handle, q := unwrapQDisc(cur)
+	d, ok := q.(*htb.Discipline)
+	if !ok {
+		// Only HTB has classes.
+		return nil, 0, syserr.ErrNotSupported
+	}
+	return d, handle, nil
+}
+
+// NewTrafficClass implements inet.Stack.NewTrafficClass.
+func (s *Stack) NewTrafficClass(ctx context.Context, msg *nlmsg.Message) *syserr.Error {
+	tcm, kind, opts, err := parseTcMessage(msg)
+	if err != nil {
+		return err
+	}
+	d, handle, err := s.htbQDisc(tcm.Index)
+	if err != nil {
+		return err
+	}
+	if kind != "" && kind != "htb" {
+		return syserr.ErrInvalidArgument
+	}
+	// Class IDs belong to the queueing discipline.
+	if tcm.Handle&linux.TC_H_MIN_MASK == 0 || tcm.Handle&linux.TC_H_MAJ_MASK != handle&linux.TC_H_MAJ_MASK {
+		return syserr.ErrInvalidArgument
+	}
+	parent := tcm.Parent
+	if parent == linux.TC_H_ROOT || parent == handle {
+		parent = 0
+	}
+
+	flags := msg.Header().Flags
+	if _, ok := d.Classes()[tcm.Handle]; ok {
+		if flags&linux.NLM_F_EXCL != 0 {
+			return syserr.ErrExists
+		}
+	} else if flags&linux.NLM_F_CREATE == 0 {
+		return syserr.ErrNoFileOrDir
+	}
+	classOpts, err := parseHTBClassOptions(opts, parent)
+	if err != nil {
+		return err
+	}
+	return syserr.TranslateNetstackError(d.AddClass(tcm.Handle, classOpts))
+}
+
+// RemoveTrafficClass implements inet.Stack.RemoveTrafficClass.
+func (s *Stack) RemoveTrafficClass(ctx context.Context, msg *nlmsg.Message) *syserr.Error {
+	tcm, _, _, err := parseTcMessage(msg)
+	if err != nil {
+		return err
+	}
+	d, _, err := s.htbQDisc(tcm.Index)
+	if err != nil {
+		return err
+	}
+	return syserr.TranslateNetstackError(d.RemoveClass(tcm.Handle))
+}
+
+// TrafficClasses implements inet.Stack.TrafficClasses.
+func (s *Stack) TrafficClasses(idx int32) []inet.TrafficControl {
+	var classes []inet.TrafficControl
+	for _, id := range s.sortedNICs() {
+		if idx != 0 && tcpip.NICID(idx) != id {
+			continue
+		}
+		d, _, err := s.htbQDisc(int32(id))
+		if err != nil {
+			continue
+		}
+		infos := d.Classes()
+		ids := make([]uint32, 0, len(infos))
+		for classID := range infos {
+			ids = append(ids, classID)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		for _, classID := range ids {
+			info := infos[classID]
+			parent := info.Options.Parent
+			if parent == 0 {
+				parent = linux.TC_H_ROOT
+			}
+			classes = append(classes, inet.TrafficControl{
+				Index:   int32(id),
+				Handle:  classID,
+				Parent:  parent,
+				Kind:    "htb",
+				Options: htbClassOptions(info.Options),
+				Stats: inet.TrafficControlStats{
+					Bytes:   info.Stats.Bytes,
+					Packets: info.Stats.Packets,
+					Drops:   info.Stats.Drops,
+					Qlen:    info.Stats.Qlen,
+					Backlog: info.Stats.Backlog,
+				},
+			})
+		}
+	}
+	return classes
+}
@@ -0,0 +1,189 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netstack
+
+import (
+	"encoding/binary"
+
+	"gvisor.dev/gvisor/pkg/abi/linux"
+	"gvisor.dev/gvisor/pkg/marshal"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/sentry/socket"
+	"gvisor.dev/gvisor/pkg/syserr"
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+// tlsULPAttached returns true if the kernel TLS upper layer protocol is
+// attached to ep. Until it is, SOL_TLS options are unknown, as in Linux.
+func tlsULPAttached(s socket.Socket, ep commonEndpoint) bool {
+	if !socket.IsTCP(s) {
+		return false
+	}
+	var ulp tcpip.TCPULPOption
+	if err := ep.GetSockOpt(&ulp); err != nil {
+		return false
+	}
+	return ulp == tcpip.TCPULPTLS
+}
+
+// getSockOptTLS implements GetSockOpt when level is SOL_TLS.
+func getSockOptTLS(t *kernel.Task, s socket.Socket, ep commonEndpoint, name, outLen int) (marshal.Marshallable, *syserr.Error) {
+	if !tlsULPAttached(s, ep) {
+		return nil, syserr.ErrProtocolNotAvailable
+	}
+
+	var info tcpip.TLSCryptoInfo
+	switch name {
+	case linux.TLS_TX:
+		var v tcpip.TLSTxOption
+		if err := ep.GetSockOpt(&v); err != nil {
+			return nil, syserr.TranslateNetstackError(err)
+		}
+		info = tcpip.TLSCryptoInfo(v)
+
+	case linux.TLS_RX:
+		var v tcpip.TLSRxOption
+		if err := ep.GetSockOpt(&v); err != nil {
+			return nil, syserr.TranslateNetstackError(err)
+		}
+		info = tcpip.TLSCryptoInfo(v)
+
+	default:
+		return nil, syserr.ErrProtocolNotAvailable
+	}
+
+	hdr := linux.TLSCryptoInfo{
+		Version:    info.Version,
+		CipherType: info.CipherType,
+	}
+	if outLen < hdr.SizeBytes() {
+		return nil, syserr.ErrInvalidArgument
+	}
+	// Like Linux, only return the version and the cipher if the caller
+	// asks for them.
+	if outLen == hdr.SizeBytes() {
+		return &hdr, nil
+	}
+
+	var v marshal.Marshallable
+	switch info.CipherType {
+	case linux.TLS_CIPHER_AES_GCM_128:
+		ci := linux.TLS12CryptoInfoAESGCM128{Info: hdr}
+		copy(ci.IV[:], info.IV)
+		copy(ci.Key[:], info.Key)
+		copy(ci.Salt[:], info.Salt)
+		binary.BigEndian.PutUint64(ci.RecSeq[:], info.RecSeq)
+		v = &ci
+
+	case linux.TLS_CIPHER_AES_GCM_256:
+		ci := linux.TLS12CryptoInfoAESGCM256{Info: hdr}
+		copy(ci.IV[:], info.IV)
+		copy(ci.Key[:], info.Key)
+		copy(ci.Salt[:], info.Salt)
+		binary.BigEndian.PutUint64(ci.RecSeq[:], info.RecSeq)
+		v = &ci
+
+	case linux.TLS_CIPHER_CHACHA20_POLY1305:
+		ci := linux.TLS12CryptoInfoChaCha20Poly1305{Info: hdr}
+		copy(ci.IV[:], info.IV)
+		copy(ci.Key[:], info.Key)
+		binary.BigEndian.PutUint64(ci.RecSeq[:], info.RecSeq)
+		v = &ci
+
+	default:
+		return nil, syserr.ErrInvalidArgument
+	}
+	if outLen != v.SizeBytes() {
+		return nil, syserr.ErrInvalidArgument
+	}
+	return v, nil
+}
+
+// setSockOptTLS implements SetSockOpt when level is SOL_TLS.
+func setSockOptTLS(t *kernel.Task, s socket.Socket, ep commonEndpoint, name int, optVal []byte) *syserr.Error {
+	if !tlsULPAttached(s, ep) {
+		return syserr.ErrProtocolNotAvailable
+	}
+
+	switch name {
+	case linux.TLS_TX:
+		info, err := parseTLSCryptoInfo(optVal)
+		if err != nil {
+			return err
+		}
+		v := tcpip.TLSTxOption(info)
+		return syserr.TranslateNetstackError(ep.SetSockOpt(&v))
+
+	case linux.TLS_RX:
+		info, err := parseTLSCryptoInfo(optVal)
+		if err != nil {
+			return err
+		}
+		v := tcpip.TLSRxOption(info)
+		return syserr.TranslateNetstackError(ep.SetSockOpt(&v))
+
+	default:
+		return syserr.ErrProtocolNotAvailable
+	}
+}
+
+// parseTLSCryptoInfo parses the crypto info passed with TLS_TX or TLS_RX.
+func parseTLSCryptoInfo(optVal []byte) (tcpip.TLSCryptoInfo, *syserr.Error) {
+	var hdr linux.TLSCryptoInfo
+	if len(optVal) < hdr.SizeBytes() {
+		return tcpip.TLSCryptoInfo{}, syserr.ErrInvalidArgument
+	}
+	hdr.UnmarshalUnsafe(optVal)
+	if hdr.Version != linux.TLS_1_2_VERSION && hdr.Version != linux.TLS_1_3_VERSION {
+		return tcpip.TLSCryptoInfo{}, syserr.ErrInvalidArgument
+	}
+
+	info := tcpip.TLSCryptoInfo{
+		Version:    hdr.Version,
+		CipherType: hdr.CipherType,
+	}
+	switch hdr.CipherType {
+	case linux.TLS_CIPHER_AES_GCM_128:
+		var ci linux.TLS12CryptoInfoAESGCM128
+		if len(optVal) != ci.SizeBytes() {
+			return tcpip.TLSCryptoInfo{}, syserr.ErrInvalidArgument
+		}
+		ci.UnmarshalUnsafe(optVal)
+		info.IV, info.Key, info.Salt = ci.IV[:], ci.Key[:], ci.Salt[:]
+		info.RecSeq = binary.BigEndian.Uint64(ci.RecSeq[:])
+
+	case linux.TLS_CIPHER_AES_GCM_256:
+		var ci linux.TLS12CryptoInfoAESGCM256
+		if len(optVal) != ci.SizeBytes() {
+			return tcpip.TLSCryptoInfo{}, syserr.ErrInvalidArgument
+		}
+		ci.UnmarshalUnsafe(optVal)
+		info.IV, info.Key, info.Salt = ci.IV[:], ci.Key[:], ci.Salt[:]
+		info.RecSeq = binary.BigEndian.Uint64(ci.RecSeq[:])
+
+	case linux.TLS_CIPHER_CHACHA20_POLY1305:
+		var ci linux.TLS12CryptoInfoChaCha20Poly1305
+		if len(optVal) != ci.SizeBytes() {
+			return tcpip.TLSCryptoInfo{}, syserr.ErrInvalidArgument
+		}
+		ci.UnmarshalUnsafe(optVal)
+		info.IV, info.Key = ci.IV[:], ci.Key[:]
+		info.RecSeq = binary.BigEndian.Uint64(ci.RecSeq[:])
+
+	default:
+		return tcpip.TLSCryptoInfo{}, syserr.ErrInvalidArgument
+	}
+	return info, nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package netstack
+
+import (
+	"io"
+
+	"gvisor.dev/gvisor/pkg/atomicbitops"
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/hostarch"
+	"gvisor.dev/gvisor/pkg/sentry/kernel"
+	"gvisor.dev/gvisor/pkg/sentry/memmap"
+	"gvisor.dev/gvisor/pkg/sentry/mm"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/usermem"
+)
+
+// zeroCopyReader is a tcpip.PinnedPayloader for the data of a send requested
+// with MSG_ZEROCOPY. Rather than copying the data, it pins the application
+// pages that hold it, as get_user_pages() does in Linux, and netstack sends
+// directly from them. The pages are released once no packet references them,
+// which for TCP is once the data is acknowledged.
+//
+// The data is copied if it isn't held by memory that the sentry can access
+// directly, such as memory mapped from a host file, which may fault.
+type zeroCopyReader struct {
+	t   *kernel.Task
+	src usermem.IOSequence
+}
+
+var _ tcpip.PinnedPayloader = (*zeroCopyReader)(nil)
+
+// Read implements io.Reader.Read.
+func (r *zeroCopyReader) Read(dst []byte) (int, error) {
+	n, err := r.src.CopyIn(r.t, dst)
+	r.src = r.src.DropFirst(n)
+	if err == nil && r.src.NumBytes() == 0 {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Len implements tcpip.Payloader.Len.
+func (r *zeroCopyReader) Len() int {
+	return int(r.src.NumBytes())
+}
+
+// ReadPinned implements tcpip.PinnedPayloader.ReadPinned.
+func (r *zeroCopyReader) ReadPinned(n int, release func()) (buffer.Buffer, bool, error) {
+	src := r.src.TakeFirst(n)
+	if buf, ok := r.pin(src, release); ok {
+		r.src = r.src.DropFirst64(src.NumBytes())
+		return buf, true, nil
+	}
+
+	// Fall back to copying the data, which is never referenced.
+	if release != nil {
+		defer release()
+	}
+	var buf buffer.Buffer
+	if _, err := buf.WriteFromReader(r, src.NumBytes()); err != nil {
+		return buf, false, err
+	}
+	return buf, false, nil
+}
+
+// zeroCopyBlock is a contiguous part of the data of a send that is referenced
+// in place.
+type zeroCopyBlock struct {
+	data []byte
+
+	// file and fr hold the reference on the pages that hold data.
+	file memmap.File
+	fr   memmap.FileRange
+}
+
+// pin returns a buffer whose views reference the data of src in place, or
+// false if the data can't be referenced in place.
+func (r *zeroCopyReader) pin(src usermem.IOSequence, release func()) (buffer.Buffer, bool) {
+	memoryManager, ok := src.IO.(*mm.MemoryManager)
+	if !ok || src.NumBytes() == 0 {
+		return buffer.Buffer{}, false
+	}
+
+	var (
+		pinned []mm.PinnedRange
+		blocks []zeroCopyBlock
+	)
+	for addrs := src.Addrs; !addrs.IsEmpty(); addrs = addrs.Tail() {
+		ar := addrs.Head()
+		if ar.Length() == 0 {
+			continue
+		}
+		end, ok := ar.End.RoundUp()
+		if !ok {
+			mm.Unpin(pinned)
+			return buffer.Buffer{}, false
+		}
+		prs, err := memoryManager.Pin(r.t, hostarch.AddrRange{Start: ar.Start.RoundDown(), End: end}, hostarch.Read, false /* ignorePermissions */)
+		pinned = append(pinned, prs...)
+		if err != nil {
+			mm.Unpin(pinned)
+			return buffer.Buffer{}, false
+		}
+		for _, pr := range prs {
+			bs, err := pr.File.MapInternal(pr.FileRange(), hostarch.Read)
+			if err != nil {
+				mm.Unpin(pinned)
+				return buffer.Buffer{}, false
+			}
+			// Blocks start at page boundaries, so each page is
+			// released by exactly one view.
+			addr, off := pr.Source.Start, pr.Offset
+			for ; !bs.IsEmpty(); bs = bs.Tail() {
+				b := bs.Head()
+				if b.NeedSafecopy() {
+					mm.Unpin(pinned)
+					return buffer.Buffer{}, false
+				}
+				bar := hostarch.AddrRange{Start: addr, End: addr + hostarch.Addr(b.Len())}
+				dar := bar.Intersect(ar)
+				blocks = append(blocks, zeroCopyBlock{
+					data: b.ToSlice()[dar.Start-addr : dar.End-addr],
+					file: pr.File,
+					fr:   memmap.FileRange{Start: off, End: off + uint64(b.Len())},
+				})
+				addr = bar.End
+				off += uint64(b.Len())
+			}
+		}
+	}
+
+	// release is called once all views are released.
+	var pending atomicbitops.Int32
+	pending.Store(int32(len(blocks)))
+	var buf buffer.Buffer
+	for _, zb := range blocks {
+		buf.Append(buffer.NewViewWithExternalData(zb.data, func() {
+			zb.file.DecRef(zb.fr)
+			if pending.Add(-1) == 0 && release != nil {
+				release()
+			}
+		}))
+	}
+	return buf, true
+}
@@ -65,6 +65,69 @@ func ipv6PacketInfoToLinux(packetInfo tcpip.IPv6PacketInfo) linux.ControlMessage
 	return p
 }
 
+// IPv6PacketInfoFromLinux converts IPv6PacketInfo from Linux format to tcpip
+// format. The unspecified address leaves the address unset.
+func IPv6PacketInfoFromLinux(l linux.ControlMessageIPv6PacketInfo) tcpip.IPv6PacketInfo {
+	p := tcpip.IPv6PacketInfo{NIC: tcpip.NICID(l.NIC)}
+	if l.Addr != (linux.Inet6Addr{}) {
+		p.Addr = tcpip.AddrFrom16(l.Addr)
+	}
+	return p
+}
+
+// sctpFlags maps the flags of tcpip.SCTPSndRcvInfo to Linux flags.
+var sctpFlags = []struct {
+	netstack tcpip.SCTPSndRcvFlags
+	linux    uint16
+}{
+	{tcpip.SCTPUnordered, linux.SCTP_UNORDERED},
+	{tcpip.SCTPSackImmediately, linux.SCTP_SACK_IMMEDIATELY},
+	{tcpip.SCTPAbort, linux.SCTP_ABORT},
+	{tcpip.SCTPEOF, linux.SCTP_EOF},
+}
+
+// SCTPSndRcvInfoToLinux converts SCTPSndRcvInfo from tcpip format to Linux
+// format.
+func SCTPSndRcvInfoToLinux(info tcpip.SCTPSndRcvInfo) linux.SCTPSndRcvInfo {
+	l := linux.SCTPSndRcvInfo{
+		Stream:     info.Stream,
+		SSN:        info.SSN,
+		PPID:       info.PPID,
+		Context:    info.Context,
+		TimeToLive: info.TimeToLive,
+		TSN:        info.TSN,
+		CumTSN:     info.CumTSN,
+		AssocID:    int32(info.AssocID),
+	}
+	for _, f := range sctpFlags {
+		if info.Flags&f.netstack != 0 {
+			l.Flags |= f.linux
+		}
+	}
+	return l
+}
+
+// SCTPSndRcvInfoFromLinux converts SCTPSndRcvInfo from Linux format to tcpip
+// format. Unsupported flags are ignored.
+func SCTPSndRcvInfoFromLinux(l linux.SCTPSndRcvInfo) tcpip.SCTPSndRcvInfo {
+	info := tcpip.SCTPSndRcvInfo{
+		Stream:     l.Stream,
+		SSN:        l.SSN,
+		PPID:       l.PPID,
+		Context:    l.Context,
+		TimeToLive: l.TimeToLive,
+		TSN:        l.TSN,
+		CumTSN:     l.CumTSN,
+		AssocID:    tcpip.SCTPAssocID(l.AssocID),
+	}
+	for _, f := range sctpFlags {
+		if l.Flags&f.linux != 0 {
+			info.Flags |= f.netstack
+		}
+	}
+	return info
+}
+
 // errOriginToLinux maps tcpip socket origin to Linux socket origin constants.
 func errOriginToLinux(origin tcpip.SockErrOrigin) uint8 {
 	switch origin {
@@ -76,6 +139,10 @@ func errOriginToLinux(origin tcpip.SockErrOrigin) uint8 {
 		return linux.SO_EE_ORIGIN_ICMP
 	case tcpip.SockExtErrorOriginICMP6:
 		return linux.SO_EE_ORIGIN_ICMP6
+	case tcpip.SockExtErrorOriginZeroCopy:
+		return linux.SO_EE_ORIGIN_ZEROCOPY
+	case tcpip.SockExtErrorOriginTxTime:
+		return linux.SO_EE_ORIGIN_TXTIME
 	default:
 		panic(fmt.Sprintf("unknown socket origin: %d", origin))
 	}
@@ -89,12 +156,24 @@ func sockErrCmsgToLinux(sockErr *tcpip.SockError) linux.SockErrCMsg {
 	}
 
 	ee := linux.SockExtendedErr{
-		Errno:  uint32(syserr.TranslateNetstackError(sockErr.Err).ToLinux()),
 		Origin: errOriginToLinux(sockErr.Cause.Origin()),
 		Type:   sockErr.Cause.Type(),
 		Code:   sockErr.Cause.Code(),
 		Info:   sockErr.Cause.Info(),
 	}
+	if sockErr.Err != nil {
+		ee.Errno = uint32(syserr.TranslateNetstackError(sockErr.Err).ToLinux())
+	}
+	// The completions of MSG_ZEROCOPY sends hold the range of their IDs in
+	// ee_info and ee_data.
+	if z, ok := sockErr.Cause.(*tcpip.ZeroCopySockError); ok {
+		ee.Data = z.Hi
+	}
+	// The errors of packets sent with SCM_TXTIME hold the high 32 bits of
+	// their departure time in ee_data, and the low ones in ee_info.
+	if t, ok := sockErr.Cause.(*tcpip.TxTimeSockError); ok {
+		ee.Data = uint32(uint64(t.Time) >> 32)
+	}
 
 	switch sockErr.NetProto {
 	case header.IPv4ProtocolNumber:
@@ -141,6 +220,13 @@ func NewIPControlMessages(family int, cmgs tcpip.ReceivableControlMessages) IPCo
 		HasIPv6PacketInfo:  cmgs.HasIPv6PacketInfo,
 		OriginalDstAddress: orgDstAddr,
 		SockErr:            sockErrCmsgToLinux(cmgs.SockErr),
+		HasTLSRecordType:   cmgs.HasTLSRecordType,
+		TLSRecordType:      cmgs.TLSRecordType,
+		HasSCTPSndRcvInfo:  cmgs.HasSCTPSndRcvInfo,
+	}
+
+	if cm.HasSCTPSndRcvInfo {
+		cm.SCTPSndRcvInfo = SCTPSndRcvInfoToLinux(cmgs.SCTPSndRcvInfo)
 	}
 
 	if cm.HasIPv6PacketInfo {
@@ -210,6 +296,24 @@ type IPControlMessages struct {
 
 	// SockErr is the dequeued socket error on recvmsg(MSG_ERRQUEUE).
 	SockErr linux.SockErrCMsg
+
+	// HasTLSRecordType indicates whether TLSRecordType is valid/set.
+	HasTLSRecordType bool
+
+	// TLSRecordType is the type of the TLS records sent or received.
+	TLSRecordType uint8
+
+	// HasSCTPSndRcvInfo indicates whether SCTPSndRcvInfo is valid/set.
+	HasSCTPSndRcvInfo bool
+
+	// SCTPSndRcvInfo describes the SCTP message sent or received.
+	SCTPSndRcvInfo linux.SCTPSndRcvInfo
+
+	// HasTxTime indicates whether TxTime is valid/set.
+	HasTxTime bool
+
+	// TxTime is the departure time of the message set with SCM_TXTIME.
+	TxTime uint64
 }
 
 // Release releases Unix domain socket credentials and rights.
@@ -589,7 +693,16 @@ func IsTCP(s Socket) bool {
 	if fam != linux.AF_INET && fam != linux.AF_INET6 {
 		return false
 	}
-	return typ == linux.SOCK_STREAM && (proto == 0 || proto == linux.IPPROTO_TCP)
+	return typ == linux.SOCK_STREAM && (proto == 0 || proto == linux.IPPROTO_TCP || proto == linux.IPPROTO_MPTCP)
+}
+
+// IsSCTP returns true if the socket is an SCTP socket.
+func IsSCTP(s Socket) bool {
+	fam, typ, proto := s.Type()
+	if fam != linux.AF_INET && fam != linux.AF_INET6 {
+		return false
+	}
+	return (typ == linux.SOCK_STREAM || typ == linux.SOCK_SEQPACKET) && proto == linux.IPPROTO_SCTP
 }
 
 // IsUDP returns true if the socket is a UDP socket.
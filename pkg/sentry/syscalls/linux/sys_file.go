@@ -186,6 +186,8 @@ func accessAt(t *kernel.Task, dirfd int32, pathAddr hostarch.Addr, mode uint32,
 		creds = creds.Fork()
 		creds.EffectiveKUID = creds.RealKUID
 		creds.EffectiveKGID = creds.RealKGID
+		creds.FilesystemKUID = creds.RealKUID
+		creds.FilesystemKGID = creds.RealKGID
 		if creds.EffectiveKUID.In(creds.UserNamespace) == auth.RootUID {
 			creds.EffectiveCaps = creds.PermittedCaps
 		} else {
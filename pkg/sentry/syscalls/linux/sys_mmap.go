@@ -180,6 +180,47 @@ func Mprotect(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintpt
 	return 0, nil, err
 }
 
+// PkeyMprotect implements linux syscall pkey_mprotect(2).
+func PkeyMprotect(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
+	length := args[1].Uint64()
+	prot := args[2].Int()
+	pkey := int(args[3].Int())
+	err := t.MemoryManager().PkeyMProtect(args[0].Pointer(), length, hostarch.AccessType{
+		Read:    linux.PROT_READ&prot != 0,
+		Write:   linux.PROT_WRITE&prot != 0,
+		Execute: linux.PROT_EXEC&prot != 0,
+	}, linux.PROT_GROWSDOWN&prot != 0, pkey)
+	return 0, nil, err
+}
+
+// PkeyAlloc implements linux syscall pkey_alloc(2).
+func PkeyAlloc(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
+	flags := args[0].Uint()
+	rights := args[1].Uint()
+
+	// "flags is reserved for future use and currently must always be
+	// specified as 0." - pkey_alloc(2)
+	if flags != 0 || rights&^linux.PKEY_ACCESS_MASK != 0 {
+		return 0, nil, linuxerr.EINVAL
+	}
+
+	mm := t.MemoryManager()
+	pkey, err := mm.PkeyAlloc()
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := t.SetPkeyAccess(pkey, rights); err != nil {
+		mm.PkeyFree(pkey)
+		return 0, nil, err
+	}
+	return uintptr(pkey), nil, nil
+}
+
+// PkeyFree implements linux syscall pkey_free(2).
+func PkeyFree(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
+	return 0, nil, t.MemoryManager().PkeyFree(int(args[0].Int()))
+}
+
 // Madvise implements linux syscall madvise(2).
 func Madvise(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr, *kernel.SyscallControl, error) {
 	addr := args[0].Pointer()
@@ -193,14 +234,14 @@ func Madvise(t *kernel.Task, sysno uintptr, args arch.SyscallArguments) (uintptr
 		return 0, nil, t.MemoryManager().SetDontFork(addr, length, false)
 	case linux.MADV_DONTFORK:
 		return 0, nil, t.MemoryManager().SetDontFork(addr, length, true)
+	case linux.MADV_DODUMP:
+		return 0, nil, t.MemoryManager().SetDontDump(addr, length, false)
+	case linux.MADV_DONTDUMP:
+		return 0, nil, t.MemoryManager().SetDontDump(addr, length, true)
 	case linux.MADV_HUGEPAGE, linux.MADV_NOHUGEPAGE:
 		fallthrough
 	case linux.MADV_MERGEABLE, linux.MADV_UNMERGEABLE:
 		fallthrough
-	case linux.MADV_DONTDUMP, linux.MADV_DODUMP:
-		// TODO(b/72045799): Core dumping isn't implemented, so these are
-		// no-ops.
-		fallthrough
 	case linux.MADV_NORMAL, linux.MADV_RANDOM, linux.MADV_SEQUENTIAL, linux.MADV_WILLNEED:
 		// Do nothing, we totally ignore the suggestions above.
 		return 0, nil, nil
@@ -23,22 +23,6 @@ import (
 	"gvisor.dev/gvisor/pkg/sentry/kernel"
 )
 
-// userSockFprog is equivalent to Linux's struct sock_fprog on amd64.
-//
-// +marshal
-type userSockFprog struct {
-	// Len is the length of the filter in BPF instructions.
-	Len uint16
-
-	_ [6]byte // padding for alignment
-
-	// Filter is a user pointer to the struct sock_filter array that makes up
-	// the filter program. Filter is a uint64 rather than a hostarch.Addr
-	// because hostarch.Addr is actually uintptr, which is not a fixed-size
-	// type.
-	Filter uint64
-}
-
 // seccomp applies a seccomp policy to the current task.
 func seccomp(t *kernel.Task, mode, flags uint64, addr hostarch.Addr) error {
 	// We only support SECCOMP_SET_MODE_FILTER at the moment.
@@ -55,7 +39,7 @@ func seccomp(t *kernel.Task, mode, flags uint64, addr hostarch.Addr) error {
 		return linuxerr.EINVAL
 	}
 
-	var fprog userSockFprog
+	var fprog linux.UserSockFprog
 	if _, err := fprog.CopyIn(t, addr); err != nil {
 		return err
 	}
@@ -26,6 +26,7 @@ import (
 	"gvisor.dev/gvisor/pkg/sentry/kernel"
 	"gvisor.dev/gvisor/pkg/sentry/kernel/auth"
 	"gvisor.dev/gvisor/pkg/sentry/kernel/ipc"
+	"gvisor.dev/gvisor/pkg/sentry/kernel/semaphore"
 )
 
 const opsMax = 500 // SEMOPM
@@ -117,8 +118,15 @@ func semTimedOp(t *kernel.Task, id ipc.ID, ops []linux.Sembuf, haveTimeout bool,
 	}
 	creds := auth.CredentialsFromContext(t)
 	pid := t.Kernel().GlobalInit().PIDNamespace().IDOfThreadGroup(t.ThreadGroup())
+	var undos *semaphore.UndoList
+	for _, op := range ops {
+		if op.SemFlg&linux.SEM_UNDO != 0 {
+			undos = t.SemUndoList()
+			break
+		}
+	}
 	for {
-		ch, num, err := set.ExecuteOps(t, ops, creds, int32(pid))
+		ch, num, err := set.ExecuteOps(t, ops, creds, int32(pid), undos)
 		if ch == nil || err != nil {
 			return err
 		}
@@ -152,6 +152,12 @@ func TranslateNetstackError(err tcpip.Error) *Error {
 		return ErrMissingRequiredFields
 	case *tcpip.ErrEndpointBusy:
 		return ErrEndpointBusy
+	case *tcpip.ErrBadMessage:
+		return ErrInvalidDataMessage
+	case *tcpip.ErrInputOutput:
+		return ErrIO
+	case *tcpip.ErrCanceled:
+		return ErrCanceled
 	default:
 		panic(fmt.Sprintf("unknown error %T", err))
 	}
@@ -0,0 +1,523 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dhcp
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"slices"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/sync"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/udp"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+const (
+	// defaultRetransmitTimeout is the default initial time to wait for a
+	// reply, from RFC 2131 section 4.1.
+	defaultRetransmitTimeout = 4 * time.Second
+
+	// defaultMaxRetransmitTimeout is the default maximum time to wait for
+	// a reply, from RFC 2131 section 4.1.
+	defaultMaxRetransmitTimeout = 64 * time.Second
+
+	// infiniteLease is the lease time of leases that don't expire, from
+	// RFC 2131 section 3.3.
+	infiniteLease = 0xffffffff
+)
+
+var (
+	errClosed  = errors.New("client closed")
+	errTimeout = errors.New("timed out")
+	errNAK     = errors.New("lease refused")
+)
+
+// Config is the configuration that a client leased from a DHCP server.
+type Config struct {
+	// ServerAddress is the address of the server.
+	ServerAddress tcpip.Address
+
+	// Address is the leased address, with the prefix length of its
+	// subnet.
+	Address tcpip.AddressWithPrefix
+
+	// Router is the default router, if any.
+	Router tcpip.Address
+
+	// DNS holds the addresses of the DNS servers.
+	DNS []tcpip.Address
+
+	// LeaseLength is the length of the lease, which is zero if the lease
+	// doesn't expire.
+	LeaseLength time.Duration
+
+	// RenewalTime is the time after which the lease is renewed with the
+	// server, T1 in RFC 2131.
+	RenewalTime time.Duration
+
+	// RebindingTime is the time after which the lease is renewed with any
+	// server, T2 in RFC 2131.
+	RebindingTime time.Duration
+}
+
+// newConfig returns the configuration of the DHCPACK m with options opts. It
+// returns false if m doesn't hold a valid lease.
+func newConfig(m message, opts options) (Config, bool) {
+	server, ok := opts.address(optServerID)
+	if !ok {
+		return Config{}, false
+	}
+	lease, ok := opts.uint32(optLeaseTime)
+	if !ok || lease == 0 {
+		return Config{}, false
+	}
+	addr := m.yiaddr()
+	if addr == header.IPv4Any || addr == header.IPv4Broadcast || header.IsV4MulticastAddress(addr) {
+		return Config{}, false
+	}
+	cfg := Config{
+		ServerAddress: server,
+		Address:       tcpip.AddressWithPrefix{Address: addr},
+		DNS:           opts.addresses(optDNS),
+	}
+	if mask, ok := opts.address(optSubnetMask); ok {
+		cfg.Address.PrefixLen = tcpip.MaskFromBytes(mask.AsSlice()).Prefix()
+	} else {
+		// Like other clients, use the mask of the class of the
+		// address.
+		ones, _ := net.IP(addr.AsSlice()).DefaultMask().Size()
+		cfg.Address.PrefixLen = ones
+	}
+	if routers := opts.addresses(optRouter); len(routers) > 0 {
+		cfg.Router = routers[0]
+	}
+	if lease == infiniteLease {
+		return cfg, true
+	}
+
+	// The default renewal and rebinding times are from RFC 2131 section
+	// 4.4.5.
+	cfg.LeaseLength = time.Duration(lease) * time.Second
+	cfg.RenewalTime = cfg.LeaseLength / 2
+	cfg.RebindingTime = cfg.LeaseLength * 7 / 8
+	if t, ok := opts.uint32(optRenewalTime); ok && time.Duration(t)*time.Second < cfg.LeaseLength {
+		cfg.RenewalTime = time.Duration(t) * time.Second
+	}
+	if t, ok := opts.uint32(optRebindingTime); ok && time.Duration(t)*time.Second < cfg.LeaseLength {
+		cfg.RebindingTime = time.Duration(t) * time.Second
+	}
+	if cfg.RenewalTime > cfg.RebindingTime {
+		cfg.RenewalTime = cfg.RebindingTime
+	}
+	return cfg, true
+}
+
+// routes returns the routes of the configuration through the NIC id.
+func (cfg *Config) routes(id tcpip.NICID) []tcpip.Route {
+	if cfg.Address.Address.BitLen() == 0 {
+		return nil
+	}
+	routes := []tcpip.Route{{Destination: cfg.Address.Subnet(), NIC: id}}
+	if cfg.Router.BitLen() != 0 {
+		routes = append(routes, tcpip.Route{
+			Destination: header.IPv4EmptySubnet,
+			Gateway:     cfg.Router,
+			NIC:         id,
+		})
+	}
+	return routes
+}
+
+// Options holds the options of a client.
+type Options struct {
+	// NIC is the NIC that the client configures.
+	NIC tcpip.NICID
+
+	// RetransmitTimeout is the initial time to wait for a reply, which is
+	// doubled after every retransmission. If zero, it defaults to four
+	// seconds, like in RFC 2131.
+	RetransmitTimeout time.Duration
+
+	// MaxRetransmitTimeout is the maximum time to wait for a reply. If
+	// zero, it defaults to 64 seconds, like in RFC 2131.
+	MaxRetransmitTimeout time.Duration
+
+	// Configured, if not nil, is called after the client changes the
+	// configuration of the NIC, with the new configuration, which is
+	// empty if the lease expired.
+	Configured func(Config)
+}
+
+// Client is a DHCPv4 client of a NIC. It configures the NIC with the leased
+// address, the route to its subnet and the default route through the leased
+// router.
+//
+// Like on Linux, the configuration of the NIC is kept when the client is
+// closed.
+type Client struct {
+	stack    *stack.Stack
+	opts     Options
+	linkAddr tcpip.LinkAddress
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// The following fields are only used by the goroutine of the client.
+	ep       tcpip.Endpoint
+	wq       waiter.Queue
+	readable chan struct{}
+
+	mu sync.Mutex
+	// +checklocks:mu
+	config Config
+}
+
+// NewClient returns a client of the NIC of opts in s.
+func NewClient(s *stack.Stack, opts Options) (*Client, tcpip.Error) {
+	nic, ok := s.NICInfo()[opts.NIC]
+	if !ok {
+		return nil, &tcpip.ErrUnknownNICID{}
+	}
+	if opts.RetransmitTimeout == 0 {
+		opts.RetransmitTimeout = defaultRetransmitTimeout
+	}
+	if opts.MaxRetransmitTimeout == 0 {
+		opts.MaxRetransmitTimeout = defaultMaxRetransmitTimeout
+	}
+	if opts.MaxRetransmitTimeout < opts.RetransmitTimeout {
+		opts.MaxRetransmitTimeout = opts.RetransmitTimeout
+	}
+	return &Client{
+		stack:    s,
+		opts:     opts,
+		linkAddr: nic.LinkAddress,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Start starts acquiring and renewing a lease in the background.
+func (c *Client) Start() tcpip.Error {
+	ep, err := c.stack.NewEndpoint(udp.ProtocolNumber, ipv4.ProtocolNumber, &c.wq)
+	if err != nil {
+		return err
+	}
+	ep.SocketOptions().SetBroadcast(true)
+	// Clients of other NICs use the same port.
+	if err := ep.SocketOptions().SetBindToDevice(int32(c.opts.NIC)); err != nil {
+		ep.Close()
+		return err
+	}
+	if err := ep.Bind(tcpip.FullAddress{NIC: c.opts.NIC, Port: ClientPort}); err != nil {
+		ep.Close()
+		return err
+	}
+	c.ep = ep
+	we, readable := waiter.NewChannelEntry(waiter.ReadableEvents)
+	c.wq.EventRegister(&we)
+	c.readable = readable
+	go func() { // S/R-SAFE: the client isn't saved.
+		defer close(c.done)
+		defer func() {
+			c.wq.EventUnregister(&we)
+			c.ep.Close()
+		}()
+		c.run()
+	}()
+	return nil
+}
+
+// Close stops the client, which must have been started.
+func (c *Client) Close() {
+	c.closeOnce.Do(func() { close(c.stop) })
+	<-c.done
+}
+
+// Config returns the current configuration of the NIC, which is empty if the
+// client doesn't have a lease.
+func (c *Client) Config() Config {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.config
+}
+
+// run acquires and renews leases, like in RFC 2131 section 4.4, until the
+// client is closed.
+func (c *Client) run() {
+	var (
+		cfg      Config
+		acquired tcpip.MonotonicTime
+		err      error
+	)
+	for {
+		if cfg.Address.Address.BitLen() == 0 {
+			if cfg, acquired, err = c.acquire(); err != nil {
+				return
+			}
+			log.Infof("DHCP: NIC %d leased %s from %s for %s", c.opts.NIC, cfg.Address, cfg.ServerAddress, cfg.LeaseLength)
+			c.configure(cfg)
+		}
+		if cfg.LeaseLength == 0 {
+			<-c.stop
+			return
+		}
+
+		if err := c.wait(acquired.Add(cfg.RenewalTime), nil /* readable */); err == errClosed {
+			return
+		}
+		next, at, err := c.renew(cfg, acquired.Add(cfg.RebindingTime), false /* rebind */)
+		if err == errTimeout {
+			next, at, err = c.renew(cfg, acquired.Add(cfg.LeaseLength), true /* rebind */)
+		}
+		switch err {
+		case nil:
+			cfg, acquired = next, at
+			log.Debugf("DHCP: NIC %d renewed %s for %s", c.opts.NIC, cfg.Address, cfg.LeaseLength)
+			c.configure(cfg)
+		case errClosed:
+			return
+		default:
+			log.Infof("DHCP: NIC %d lost %s: %v", c.opts.NIC, cfg.Address, err)
+			cfg = Config{}
+			c.configure(cfg)
+		}
+	}
+}
+
+// acquire acquires a lease, and returns its configuration and the time when
+// it was requested.
+func (c *Client) acquire() (Config, tcpip.MonotonicTime, error) {
+	// Until it is configured, the NIC sends from the unspecified address.
+	unspecified := tcpip.ProtocolAddress{
+		Protocol:          ipv4.ProtocolNumber,
+		AddressWithPrefix: header.IPv4Any.WithPrefix(),
+	}
+	if err := c.stack.AddProtocolAddress(c.opts.NIC, unspecified, stack.AddressProperties{PEB: stack.FirstPrimaryEndpoint}); err != nil {
+		log.Warningf("DHCP: AddProtocolAddress(%d, %+v, _): %s", c.opts.NIC, unspecified, err)
+	}
+	defer c.stack.RemoveAddress(c.opts.NIC, header.IPv4Any)
+
+	broadcast := tcpip.FullAddress{NIC: c.opts.NIC, Addr: header.IPv4Broadcast, Port: ServerPort}
+	for {
+		discover := c.newMessage(msgDiscover, nil)
+		offer, opts, err := c.transact(discover, broadcast, tcpip.MonotonicTime{}, func(m message, opts options) bool {
+			_, ok := opts.address(optServerID)
+			return ok && opts.messageType() == msgOffer
+		})
+		if err != nil {
+			return Config{}, tcpip.MonotonicTime{}, err
+		}
+
+		server, _ := opts.address(optServerID)
+		offered := offer.yiaddr()
+		request := c.newMessage(msgRequest, []option{
+			{code: optRequestedAddress, body: offered.AsSlice()},
+			{code: optServerID, body: server.AsSlice()},
+		})
+		now := c.stack.Clock().NowMonotonic()
+		ack, opts, err := c.transact(request, broadcast, now.Add(c.opts.MaxRetransmitTimeout), isLease)
+		switch err {
+		case nil:
+		case errTimeout:
+			continue
+		default:
+			return Config{}, tcpip.MonotonicTime{}, err
+		}
+		if opts.messageType() == msgNak {
+			log.Infof("DHCP: NIC %d was refused %s by %s", c.opts.NIC, offered, server)
+			continue
+		}
+		cfg, _ := newConfig(ack, opts)
+		return cfg, now, nil
+	}
+}
+
+// renew renews the lease of cfg with its server, or with any server if rebind
+// is true, until deadline. It returns the new configuration and the time when
+// it was requested.
+func (c *Client) renew(cfg Config, deadline tcpip.MonotonicTime, rebind bool) (Config, tcpip.MonotonicTime, error) {
+	request := c.newMessage(msgRequest, nil)
+	request.setCiaddr(cfg.Address.Address)
+	to := tcpip.FullAddress{NIC: c.opts.NIC, Addr: cfg.ServerAddress, Port: ServerPort}
+	if rebind {
+		to.Addr = header.IPv4Broadcast
+	}
+	now := c.stack.Clock().NowMonotonic()
+	ack, opts, err := c.transact(request, to, deadline, isLease)
+	if err != nil {
+		return Config{}, tcpip.MonotonicTime{}, err
+	}
+	if opts.messageType() == msgNak {
+		return Config{}, tcpip.MonotonicTime{}, errNAK
+	}
+	next, _ := newConfig(ack, opts)
+	return next, now, nil
+}
+
+// isLease returns true if m is a DHCPNAK, or a DHCPACK with a valid lease.
+func isLease(m message, opts options) bool {
+	switch opts.messageType() {
+	case msgNak:
+		return true
+	case msgAck:
+		_, ok := newConfig(m, opts)
+		return ok
+	default:
+		return false
+	}
+}
+
+// newMessage returns a client message of type t with a new transaction ID and
+// options opts.
+func (c *Client) newMessage(t messageType, opts []option) message {
+	opts = append([]option{
+		{code: optMessageType, body: []byte{byte(t)}},
+		{code: optParameterList, body: []byte{
+			byte(optSubnetMask),
+			byte(optRouter),
+			byte(optDNS),
+			byte(optLeaseTime),
+			byte(optRenewalTime),
+			byte(optRebindingTime),
+		}},
+	}, opts...)
+	rng := c.stack.SecureRNG()
+	return newMessage(opRequest, rng.Uint32(), c.linkAddr, opts)
+}
+
+// transact sends m to the address to until it receives a reply accepted by
+// accept, or until deadline if it isn't zero. Messages are retransmitted with
+// an exponential backoff, like in RFC 2131 section 4.1.
+func (c *Client) transact(m message, to tcpip.FullAddress, deadline tcpip.MonotonicTime, accept func(message, options) bool) (message, options, error) {
+	if m.ciaddr() == header.IPv4Any {
+		// The client can't receive unicast replies before it is
+		// configured.
+		m.setFlags(flagBroadcast)
+	}
+	timeout := c.opts.RetransmitTimeout
+	for {
+		var r bytes.Reader
+		r.Reset(m)
+		if _, err := c.ep.Write(&r, tcpip.WriteOptions{To: &to}); err != nil {
+			log.Debugf("DHCP: NIC %d failed to send to %s: %s", c.opts.NIC, to.Addr, err)
+		}
+
+		retransmit := c.stack.Clock().NowMonotonic().Add(timeout)
+		if deadline != (tcpip.MonotonicTime{}) && retransmit.After(deadline) {
+			retransmit = deadline
+		}
+		reply, opts, err := c.receive(m.xid(), retransmit, accept)
+		if err != errTimeout {
+			return reply, opts, err
+		}
+		if deadline != (tcpip.MonotonicTime{}) && !c.stack.Clock().NowMonotonic().Before(deadline) {
+			return nil, nil, errTimeout
+		}
+		timeout = min(2*timeout, c.opts.MaxRetransmitTimeout)
+	}
+}
+
+// receive returns the first reply to the transaction xid that is accepted by
+// accept, or errTimeout if none is received until the time until.
+func (c *Client) receive(xid uint32, until tcpip.MonotonicTime, accept func(message, options) bool) (message, options, error) {
+	for {
+		var b bytes.Buffer
+		if _, err := c.ep.Read(&b, tcpip.ReadOptions{}); err != nil {
+			if _, ok := err.(*tcpip.ErrWouldBlock); !ok {
+				log.Warningf("DHCP: NIC %d failed to receive: %s", c.opts.NIC, err)
+			}
+			if err := c.wait(until, c.readable); err != nil {
+				return nil, nil, err
+			}
+			continue
+		}
+		m := message(b.Bytes())
+		if !m.isValid() || m.op() != opReply || m.xid() != xid || m.chaddr() != c.linkAddr {
+			continue
+		}
+		opts, ok := m.options()
+		if ok && accept(m, opts) {
+			return m, opts, nil
+		}
+	}
+}
+
+// wait waits until the time until, or until readable is signaled. It returns
+// errTimeout if until is reached, and errClosed if the client is closed.
+func (c *Client) wait(until tcpip.MonotonicTime, readable <-chan struct{}) error {
+	d := until.Sub(c.stack.Clock().NowMonotonic())
+	if d <= 0 {
+		return errTimeout
+	}
+	expired := make(chan struct{})
+	t := c.stack.Clock().AfterFunc(d, func() { close(expired) })
+	defer t.Stop()
+	select {
+	case <-c.stop:
+		return errClosed
+	case <-expired:
+		return errTimeout
+	case <-readable:
+		return nil
+	}
+}
+
+// configure changes the configuration of the NIC to cfg.
+func (c *Client) configure(cfg Config) {
+	c.mu.Lock()
+	old := c.config
+	c.config = cfg
+	c.mu.Unlock()
+
+	id := c.opts.NIC
+	oldRoutes, newRoutes := old.routes(id), cfg.routes(id)
+	for _, r := range oldRoutes {
+		if !slices.ContainsFunc(newRoutes, r.Equal) {
+			c.stack.RemoveRoutes(r.Equal)
+		}
+	}
+	if old.Address != cfg.Address {
+		if old.Address.Address.BitLen() != 0 {
+			if err := c.stack.RemoveAddress(id, old.Address.Address); err != nil {
+				log.Warningf("DHCP: RemoveAddress(%d, %s): %s", id, old.Address.Address, err)
+			}
+		}
+		if cfg.Address.Address.BitLen() != 0 {
+			protocolAddr := tcpip.ProtocolAddress{
+				Protocol:          ipv4.ProtocolNumber,
+				AddressWithPrefix: cfg.Address,
+			}
+			if err := c.stack.AddProtocolAddress(id, protocolAddr, stack.AddressProperties{}); err != nil {
+				log.Warningf("DHCP: AddProtocolAddress(%d, %+v, {}): %s", id, protocolAddr, err)
+			}
+		}
+	}
+	for _, r := range newRoutes {
+		if !slices.ContainsFunc(oldRoutes, r.Equal) {
+			c.stack.AddRoute(r)
+		}
+	}
+	if c.opts.Configured != nil {
+		c.opts.Configured(cfg)
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dhcp implements a DHCPv4 client, as specified by RFC 2131, which
+// configures a NIC of a stack with the address and the routes that it leases,
+// and renews the lease until it is closed.
+package dhcp
+
+import (
+	"encoding/binary"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+const (
+	// ServerPort is the UDP port of DHCP servers.
+	ServerPort = 67
+
+	// ClientPort is the UDP port of DHCP clients.
+	ClientPort = 68
+)
+
+// op is the op field of a DHCP message.
+type op byte
+
+const (
+	opRequest op = 1
+	opReply   op = 2
+)
+
+// messageType is the value of the DHCP message type option, from RFC 2132
+// section 9.6.
+type messageType byte
+
+const (
+	msgDiscover messageType = 1
+	msgOffer    messageType = 2
+	msgRequest  messageType = 3
+	msgDecline  messageType = 4
+	msgAck      messageType = 5
+	msgNak      messageType = 6
+	msgRelease  messageType = 7
+)
+
+// optionCode is the code of a DHCP option, from RFC 2132.
+type optionCode byte
+
+const (
+	optPad              optionCode = 0
+	optSubnetMask       optionCode = 1
+	optRouter           optionCode = 3
+	optDNS              optionCode = 6
+	optRequestedAddress optionCode = 50
+	optLeaseTime        optionCode = 51
+	optMessageType      optionCode = 53
+	optServerID         optionCode = 54
+	optParameterList    optionCode = 55
+	optRenewalTime      optionCode = 58
+	optRebindingTime    optionCode = 59
+	optEnd              optionCode = 255
+)
+
+// option is a DHCP option.
+type option struct {
+	code optionCode
+	body []byte
+}
+
+// options holds the options of a DHCP message by their code. The bodies of
+// options that appear several times are concatenated, as specified by RFC
+// 3396.
+type options map[optionCode][]byte
+
+// address returns the address of the option code.
+func (o options) address(code optionCode) (tcpip.Address, bool) {
+	b := o[code]
+	if len(b) != header.IPv4AddressSize {
+		return tcpip.Address{}, false
+	}
+	return tcpip.AddrFrom4Slice(b), true
+}
+
+// addresses returns the list of addresses of the option code.
+func (o options) addresses(code optionCode) []tcpip.Address {
+	b := o[code]
+	var addrs []tcpip.Address
+	for len(b) >= header.IPv4AddressSize {
+		addrs = append(addrs, tcpip.AddrFrom4Slice(b[:header.IPv4AddressSize]))
+		b = b[header.IPv4AddressSize:]
+	}
+	return addrs
+}
+
+// uint32 returns the 32-bit integer of the option code.
+func (o options) uint32(code optionCode) (uint32, bool) {
+	b := o[code]
+	if len(b) != 4 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(b), true
+}
+
+// messageType returns the message type option.
+func (o options) messageType() messageType {
+	b := o[optMessageType]
+	if len(b) != 1 {
+		return 0
+	}
+	return messageType(b[0])
+}
+
+// Offsets of the fields of a DHCP message, from RFC 2131 section 2.
+const (
+	opOffset      = 0
+	htypeOffset   = 1
+	hlenOffset    = 2
+	xidOffset     = 4
+	flagsOffset   = 10
+	ciaddrOffset  = 12
+	yiaddrOffset  = 16
+	siaddrOffset  = 20
+	chaddrOffset  = 28
+	cookieOffset  = 236
+	optionsOffset = 240
+)
+
+// flagBroadcast asks servers to broadcast their replies, which clients can't
+// receive by unicast before they are configured.
+const flagBroadcast = 0x8000
+
+// htypeEthernet is the hardware type of Ethernet, from RFC 1700.
+const htypeEthernet = 1
+
+// magicCookie starts the options of DHCP messages.
+var magicCookie = [4]byte{99, 130, 83, 99}
+
+// message is a DHCP message.
+type message []byte
+
+// newMessage returns a DHCP message with the given fields and options.
+func newMessage(o op, xid uint32, chaddr tcpip.LinkAddress, opts []option) message {
+	size := optionsOffset + 1
+	for _, opt := range opts {
+		size += 2 + len(opt.body)
+	}
+	m := make(message, size)
+	m[opOffset] = byte(o)
+	m[htypeOffset] = htypeEthernet
+	m[hlenOffset] = byte(len(chaddr))
+	binary.BigEndian.PutUint32(m[xidOffset:], xid)
+	copy(m[chaddrOffset:chaddrOffset+16], chaddr)
+	copy(m[cookieOffset:], magicCookie[:])
+	b := m[optionsOffset:]
+	for _, opt := range opts {
+		b[0] = byte(opt.code)
+		b[1] = byte(len(opt.body))
+		copy(b[2:], opt.body)
+		b = b[2+len(opt.body):]
+	}
+	b[0] = byte(optEnd)
+	return m
+}
+
+// isValid returns true if m is long enough to hold a DHCP message.
+func (m message) isValid() bool {
+	return len(m) >= optionsOffset && [4]byte(m[cookieOffset:optionsOffset]) == magicCookie
+}
+
+func (m message) op() op {
+	return op(m[opOffset])
+}
+
+func (m message) xid() uint32 {
+	return binary.BigEndian.Uint32(m[xidOffset:])
+}
+
+func (m message) flags() uint16 {
+	return binary.BigEndian.Uint16(m[flagsOffset:])
+}
+
+func (m message) setFlags(flags uint16) {
+	binary.BigEndian.PutUint16(m[flagsOffset:], flags)
+}
+
+func (m message) ciaddr() tcpip.Address {
+	return tcpip.AddrFrom4Slice(m[ciaddrOffset : ciaddrOffset+header.IPv4AddressSize])
+}
+
+func (m message) setCiaddr(addr tcpip.Address) {
+	copy(m[ciaddrOffset:], addr.AsSlice())
+}
+
+func (m message) yiaddr() tcpip.Address {
+	return tcpip.AddrFrom4Slice(m[yiaddrOffset : yiaddrOffset+header.IPv4AddressSize])
+}
+
+func (m message) setYiaddr(addr tcpip.Address) {
+	copy(m[yiaddrOffset:], addr.AsSlice())
+}
+
+// chaddr returns the hardware address of the client.
+func (m message) chaddr() tcpip.LinkAddress {
+	n := int(m[hlenOffset])
+	if n > 16 {
+		n = 16
+	}
+	return tcpip.LinkAddress(m[chaddrOffset : chaddrOffset+n])
+}
+
+// options parses the options of m. It returns false if they are malformed.
+func (m message) options() (options, bool) {
+	opts := make(options)
+	b := m[optionsOffset:]
+	for len(b) > 0 {
+		code := optionCode(b[0])
+		switch code {
+		case optPad:
+			b = b[1:]
+			continue
+		case optEnd:
+			return opts, true
+		}
+		if len(b) < 2 || len(b) < 2+int(b[1]) {
+			return nil, false
+		}
+		opts[code] = append(opts[code], b[2:2+int(b[1])]...)
+		b = b[2+int(b[1]):]
+	}
+	// The end option is missing.
+	return nil, false
+}
@@ -0,0 +1,343 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dhcp
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"gvisor.dev/gvisor/pkg/sync"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/internal/linktest"
+	"gvisor.dev/gvisor/pkg/tcpip/link/ethernet"
+	"gvisor.dev/gvisor/pkg/tcpip/link/veth"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/testutil"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/udp"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+const (
+	nicID       = 1
+	testTimeout = 10 * time.Second
+)
+
+var (
+	serverAddr = testutil.MustParse4("192.168.100.1")
+	clientAddr = testutil.MustParse4("192.168.100.2")
+	otherAddr  = testutil.MustParse4("192.168.100.3")
+	dnsAddr    = testutil.MustParse4("192.168.100.53")
+)
+
+func newStack(t *testing.T, ep stack.LinkEndpoint) *stack.Stack {
+	t.Helper()
+	s := linktest.NewStack(t)
+	if err := s.CreateNIC(nicID, ethernet.New(ep)); err != nil {
+		t.Fatalf("CreateNIC(%d, _): %s", nicID, err)
+	}
+	return s
+}
+
+// server is a DHCP server which leases a single address.
+type server struct {
+	ep   tcpip.Endpoint
+	wq   waiter.Queue
+	done chan struct{}
+
+	mu sync.Mutex
+	// addr is the address that is leased.
+	addr tcpip.Address
+	// lease is the lease time in seconds.
+	lease uint32
+	// nak makes the server refuse requests.
+	nak bool
+	// renewals counts the requests to renew a lease.
+	renewals int
+}
+
+// newServer starts a server on the NIC of s.
+func newServer(t *testing.T, s *stack.Stack, addr tcpip.Address, lease uint32) *server {
+	t.Helper()
+	linktest.AddAddress(t, s, nicID, tcpip.AddressWithPrefix{Address: serverAddr, PrefixLen: 24})
+
+	srv := &server{
+		done:  make(chan struct{}),
+		addr:  addr,
+		lease: lease,
+	}
+	ep, err := s.NewEndpoint(udp.ProtocolNumber, ipv4.ProtocolNumber, &srv.wq)
+	if err != nil {
+		t.Fatalf("NewEndpoint: %s", err)
+	}
+	ep.SocketOptions().SetBroadcast(true)
+	if err := ep.Bind(tcpip.FullAddress{Port: ServerPort}); err != nil {
+		t.Fatalf("Bind: %s", err)
+	}
+	srv.ep = ep
+	stop := make(chan struct{})
+	go srv.serve(stop)
+	t.Cleanup(func() {
+		close(stop)
+		<-srv.done
+		ep.Close()
+	})
+	return srv
+}
+
+func (srv *server) serve(stop <-chan struct{}) {
+	defer close(srv.done)
+	we, ch := waiter.NewChannelEntry(waiter.ReadableEvents)
+	srv.wq.EventRegister(&we)
+	defer srv.wq.EventUnregister(&we)
+	for {
+		var b bytes.Buffer
+		if _, err := srv.ep.Read(&b, tcpip.ReadOptions{}); err != nil {
+			select {
+			case <-ch:
+				continue
+			case <-stop:
+				return
+			}
+		}
+		m := message(b.Bytes())
+		if !m.isValid() || m.op() != opRequest {
+			continue
+		}
+		opts, ok := m.options()
+		if !ok {
+			continue
+		}
+		if reply := srv.reply(m, opts); reply != nil {
+			to := tcpip.FullAddress{NIC: nicID, Addr: m.ciaddr(), Port: ClientPort}
+			if m.flags()&flagBroadcast != 0 || to.Addr == header.IPv4Any {
+				to.Addr = header.IPv4Broadcast
+			}
+			var r bytes.Reader
+			r.Reset(reply)
+			srv.ep.Write(&r, tcpip.WriteOptions{To: &to})
+		}
+	}
+}
+
+// reply returns the reply to the request m with options opts.
+func (srv *server) reply(m message, opts options) message {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	var t messageType
+	switch opts.messageType() {
+	case msgDiscover:
+		t = msgOffer
+	case msgRequest:
+		if m.ciaddr() != header.IPv4Any {
+			srv.renewals++
+		}
+		t = msgAck
+		if srv.nak {
+			t = msgNak
+		}
+	default:
+		return nil
+	}
+	lease := make([]byte, 4)
+	binary.BigEndian.PutUint32(lease, srv.lease)
+	replyOpts := []option{
+		{code: optMessageType, body: []byte{byte(t)}},
+		{code: optServerID, body: serverAddr.AsSlice()},
+	}
+	if t != msgNak {
+		replyOpts = append(replyOpts,
+			option{code: optLeaseTime, body: lease},
+			option{code: optSubnetMask, body: []byte{255, 255, 255, 0}},
+			option{code: optRouter, body: serverAddr.AsSlice()},
+			option{code: optDNS, body: dnsAddr.AsSlice()},
+		)
+	}
+	reply := newMessage(opReply, m.xid(), m.chaddr(), replyOpts)
+	reply.setFlags(m.flags())
+	if t != msgNak {
+		reply.setYiaddr(srv.addr)
+	}
+	return reply
+}
+
+// newClient starts a client on the NIC of s, and returns it with a channel
+// that receives its configurations.
+func newClient(t *testing.T, s *stack.Stack) (*Client, <-chan Config) {
+	t.Helper()
+	configs := make(chan Config, 10)
+	c, err := NewClient(s, Options{
+		NIC:                  nicID,
+		RetransmitTimeout:    100 * time.Millisecond,
+		MaxRetransmitTimeout: 500 * time.Millisecond,
+		Configured:           func(cfg Config) { configs <- cfg },
+	})
+	if err != nil {
+		t.Fatalf("NewClient(_, {NIC: %d}): %s", nicID, err)
+	}
+	if err := c.Start(); err != nil {
+		t.Fatalf("Start(): %s", err)
+	}
+	t.Cleanup(c.Close)
+	return c, configs
+}
+
+// waitConfig waits for a configuration from configs.
+func waitConfig(t *testing.T, configs <-chan Config) Config {
+	t.Helper()
+	select {
+	case cfg := <-configs:
+		return cfg
+	case <-time.After(testTimeout):
+		t.Fatalf("timed out waiting for a configuration")
+		return Config{}
+	}
+}
+
+// checkConfigured checks that the NIC of s has the address addr and routes
+// through the server.
+func checkConfigured(t *testing.T, s *stack.Stack, addr tcpip.Address) {
+	t.Helper()
+	want := tcpip.AddressWithPrefix{Address: addr, PrefixLen: 24}
+	if got, err := s.GetMainNICAddress(nicID, ipv4.ProtocolNumber); err != nil || got != want {
+		t.Errorf("got GetMainNICAddress(%d, %d) = (%s, %v), want = (%s, nil)", nicID, ipv4.ProtocolNumber, got, err, want)
+	}
+	wantRoutes := []tcpip.Route{
+		{Destination: want.Subnet(), NIC: nicID},
+		{Destination: header.IPv4EmptySubnet, Gateway: serverAddr, NIC: nicID},
+	}
+	if diff := cmp.Diff(wantRoutes, s.GetRouteTable()); diff != "" {
+		t.Errorf("route table mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	chaddr := tcpip.LinkAddress("\x02\x03\x04\x05\x06\x07")
+	m := newMessage(opRequest, 1234, chaddr, []option{
+		{code: optMessageType, body: []byte{byte(msgDiscover)}},
+		{code: optDNS, body: serverAddr.AsSlice()},
+		{code: optDNS, body: dnsAddr.AsSlice()},
+	})
+	if !m.isValid() {
+		t.Fatalf("got isValid() = false, want = true")
+	}
+	if m.op() != opRequest || m.xid() != 1234 || m.chaddr() != chaddr {
+		t.Errorf("got (op, xid, chaddr) = (%d, %d, %s), want = (%d, 1234, %s)", m.op(), m.xid(), m.chaddr(), opRequest, chaddr)
+	}
+	opts, ok := m.options()
+	if !ok {
+		t.Fatalf("got options() = (_, false), want = (_, true)")
+	}
+	if got := opts.messageType(); got != msgDiscover {
+		t.Errorf("got messageType() = %d, want = %d", got, msgDiscover)
+	}
+	if diff := cmp.Diff([]tcpip.Address{serverAddr, dnsAddr}, opts.addresses(optDNS)); diff != "" {
+		t.Errorf("DNS servers mismatch (-want +got):\n%s", diff)
+	}
+
+	// Truncate the end option.
+	if _, ok := m[:len(m)-1].options(); ok {
+		t.Errorf("got options() = (_, true) without the end option, want = (_, false)")
+	}
+}
+
+func TestNewClientUnknownNIC(t *testing.T) {
+	ep, _ := veth.NewPair(1500, veth.DefaultBacklogSize)
+	s := newStack(t, ep)
+	if _, err := NewClient(s, Options{NIC: nicID + 1}); err == nil {
+		t.Errorf("NewClient succeeded for an unknown NIC, want error")
+	}
+}
+
+func TestAcquire(t *testing.T) {
+	ep1, ep2 := veth.NewPair(1500, veth.DefaultBacklogSize)
+	newServer(t, newStack(t, ep1), clientAddr, 3600)
+	s := newStack(t, ep2)
+	c, configs := newClient(t, s)
+
+	cfg := waitConfig(t, configs)
+	want := Config{
+		ServerAddress: serverAddr,
+		Address:       tcpip.AddressWithPrefix{Address: clientAddr, PrefixLen: 24},
+		Router:        serverAddr,
+		DNS:           []tcpip.Address{dnsAddr},
+		LeaseLength:   time.Hour,
+		RenewalTime:   30 * time.Minute,
+		RebindingTime: time.Hour * 7 / 8,
+	}
+	if diff := cmp.Diff(want, cfg); diff != "" {
+		t.Errorf("configuration mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(want, c.Config()); diff != "" {
+		t.Errorf("Config() mismatch (-want +got):\n%s", diff)
+	}
+	checkConfigured(t, s, clientAddr)
+
+	// The configuration is kept when the client is closed.
+	c.Close()
+	checkConfigured(t, s, clientAddr)
+}
+
+func TestRenew(t *testing.T) {
+	ep1, ep2 := veth.NewPair(1500, veth.DefaultBacklogSize)
+	srv := newServer(t, newStack(t, ep1), clientAddr, 2)
+	s := newStack(t, ep2)
+	_, configs := newClient(t, s)
+
+	waitConfig(t, configs)
+	// Renewals are sent to the server after a second.
+	cfg := waitConfig(t, configs)
+	if cfg.Address.Address != clientAddr {
+		t.Errorf("got renewed address = %s, want = %s", cfg.Address.Address, clientAddr)
+	}
+	srv.mu.Lock()
+	renewals := srv.renewals
+	srv.mu.Unlock()
+	if renewals == 0 {
+		t.Errorf("got no renewal requests, want some")
+	}
+	checkConfigured(t, s, clientAddr)
+}
+
+func TestNAK(t *testing.T) {
+	ep1, ep2 := veth.NewPair(1500, veth.DefaultBacklogSize)
+	srv := newServer(t, newStack(t, ep1), clientAddr, 2)
+	s := newStack(t, ep2)
+	_, configs := newClient(t, s)
+
+	waitConfig(t, configs)
+	checkConfigured(t, s, clientAddr)
+
+	// Refuse the renewal, and lease another address afterwards.
+	srv.mu.Lock()
+	srv.nak = true
+	srv.mu.Unlock()
+	if cfg := waitConfig(t, configs); cfg.Address.Address.BitLen() != 0 {
+		t.Fatalf("got address %s after a refused renewal, want none", cfg.Address)
+	}
+	srv.mu.Lock()
+	srv.nak = false
+	srv.addr = otherAddr
+	srv.mu.Unlock()
+
+	if cfg := waitConfig(t, configs); cfg.Address.Address != otherAddr {
+		t.Errorf("got address %s, want = %s", cfg.Address.Address, otherAddr)
+	}
+	checkConfigured(t, s, otherAddr)
+}
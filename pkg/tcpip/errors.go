@@ -638,4 +638,49 @@ func (*ErrEndpointBusy) String() string {
 	return "operation cannot be completed because the endpoint is busy"
 }
 
+// ErrBadMessage indicates that received data failed validation, e.g. a TLS
+// record that could not be authenticated.
+//
+// +stateify savable
+type ErrBadMessage struct{}
+
+// isError implements Error.
+func (*ErrBadMessage) isError() {}
+
+// IgnoreStats implements Error.
+func (*ErrBadMessage) IgnoreStats() bool {
+	return false
+}
+func (*ErrBadMessage) String() string { return "bad message" }
+
+// ErrInputOutput indicates that the data can't be delivered to the caller,
+// e.g. a TLS record whose type the caller can't receive.
+//
+// +stateify savable
+type ErrInputOutput struct{}
+
+// isError implements Error.
+func (*ErrInputOutput) isError() {}
+
+// IgnoreStats implements Error.
+func (*ErrInputOutput) IgnoreStats() bool {
+	return true
+}
+func (*ErrInputOutput) String() string { return "input/output error" }
+
+// ErrCanceled indicates that an operation was canceled, e.g. the send of a
+// packet that missed its departure time.
+//
+// +stateify savable
+type ErrCanceled struct{}
+
+// isError implements Error.
+func (*ErrCanceled) isError() {}
+
+// IgnoreStats implements Error.
+func (*ErrCanceled) IgnoreStats() bool {
+	return true
+}
+func (*ErrCanceled) String() string { return "operation canceled" }
+
 // LINT.ThenChange(../syserr/netstack.go)
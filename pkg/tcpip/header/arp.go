@@ -41,6 +41,11 @@ const (
 	// https://www.iana.org/assignments/arp-parameters/arp-parameters.xhtml#arp-parameters-2
 	ARPHardwareEther    ARPHardwareType = 1
 	ARPHardwareLoopback ARPHardwareType = 2
+	// The following types are only used by link endpoints that don't run
+	// ARP, so they don't have IANA values.
+	ARPHardwareTunnel ARPHardwareType = 3
+	ARPHardwareSIT    ARPHardwareType = 4
+	ARPHardwareIPGRE  ARPHardwareType = 5
 )
 
 // ARPOp is an ARP opcode.
@@ -0,0 +1,104 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import "encoding/binary"
+
+const (
+	geneveVerOptLen = 0
+	geneveFlags     = 1
+	geneveProtocol  = 2
+	geneveVNI       = 4
+)
+
+const (
+	// GeneveMinimumSize is the size of a Geneve header without options.
+	GeneveMinimumSize = 8
+
+	// GeneveDefaultPort is the IANA assigned UDP port for Geneve.
+	GeneveDefaultPort = 6081
+
+	// GeneveProtocolTransparentEthernet is the protocol type of Ethernet
+	// frames carried by Geneve.
+	GeneveProtocolTransparentEthernet = 0x6558
+
+	// GeneveFlagOAM is the "O" flag of the Geneve header, which is set on
+	// control packets.
+	GeneveFlagOAM = 0x80
+
+	// GeneveFlagCritical is the "C" flag of the Geneve header, which is set
+	// when critical options are present.
+	GeneveFlagCritical = 0x40
+
+	// GeneveMaxVNI is the largest Geneve Virtual Network Identifier.
+	GeneveMaxVNI = 1<<24 - 1
+)
+
+// GeneveFields contains the fields of a Geneve header. It is used to describe
+// the fields of a header that needs to be encoded.
+type GeneveFields struct {
+	// Flags holds the "O" and "C" flags of the Geneve header.
+	Flags uint8
+
+	// Protocol is the "protocol type" field of the Geneve header.
+	Protocol uint16
+
+	// VNI is the "virtual network identifier" field of the Geneve header.
+	VNI uint32
+}
+
+// Geneve represents a Generic Network Virtualization Encapsulation header
+// stored in a byte array, the fields are described in RFC 8926 section 3.
+type Geneve []byte
+
+// Version returns the "version" field of the Geneve header.
+func (b Geneve) Version() uint8 {
+	return b[geneveVerOptLen] >> 6
+}
+
+// HeaderLength returns the total length of the Geneve header, including
+// options.
+func (b Geneve) HeaderLength() int {
+	return GeneveMinimumSize + 4*int(b[geneveVerOptLen]&0x3f)
+}
+
+// Flags returns the "O" and "C" flags of the Geneve header.
+func (b Geneve) Flags() uint8 {
+	return b[geneveFlags] & (GeneveFlagOAM | GeneveFlagCritical)
+}
+
+// Protocol returns the "protocol type" field of the Geneve header.
+func (b Geneve) Protocol() uint16 {
+	return binary.BigEndian.Uint16(b[geneveProtocol:])
+}
+
+// VNI returns the "virtual network identifier" field of the Geneve header.
+func (b Geneve) VNI() uint32 {
+	return binary.BigEndian.Uint32(b[geneveVNI:]) >> 8
+}
+
+// IsValid returns true if the Geneve header is a version 0 header that fits in
+// b.
+func (b Geneve) IsValid() bool {
+	return len(b) >= GeneveMinimumSize && b.Version() == 0 && len(b) >= b.HeaderLength()
+}
+
+// Encode encodes a Geneve header without options.
+func (b Geneve) Encode(f *GeneveFields) {
+	b[geneveVerOptLen] = 0
+	b[geneveFlags] = f.Flags
+	binary.BigEndian.PutUint16(b[geneveProtocol:], f.Protocol)
+	binary.BigEndian.PutUint32(b[geneveVNI:], f.VNI<<8)
+}
@@ -0,0 +1,175 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import (
+	"encoding/binary"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+const (
+	greFlagsVersion = 0
+	greProtocol     = 2
+	greOptions      = 4
+)
+
+const (
+	// GREMinimumSize is the size of a GRE header without optional fields.
+	GREMinimumSize = 4
+
+	// GREProtocolNumber is GRE's IP protocol number.
+	GREProtocolNumber tcpip.TransportProtocolNumber = 47
+
+	// GREFlagChecksum is the "C" flag of the GRE header, which is set when
+	// the checksum field is present.
+	GREFlagChecksum = 0x8000
+
+	// GREFlagRouting is the "R" flag of RFC 1701, which isn't supported.
+	GREFlagRouting = 0x4000
+
+	// GREFlagKey is the "K" flag of the GRE header, which is set when the
+	// key field is present.
+	GREFlagKey = 0x2000
+
+	// GREFlagSequence is the "S" flag of the GRE header, which is set when
+	// the sequence number field is present.
+	GREFlagSequence = 0x1000
+
+	greVersionMask = 0x7
+)
+
+// GREFields contains the fields of a GRE header. It is used to describe the
+// fields of a header that needs to be encoded.
+type GREFields struct {
+	// Flags holds the GREFlag* flags that select the optional fields of the
+	// header.
+	Flags uint16
+
+	// Protocol is the "protocol type" field of the GRE header, which is the
+	// EtherType of the payload.
+	Protocol tcpip.NetworkProtocolNumber
+
+	// Key is the "key" field of the GRE header. It is only encoded if
+	// GREFlagKey is set.
+	Key uint32
+
+	// Sequence is the "sequence number" field of the GRE header. It is only
+	// encoded if GREFlagSequence is set.
+	Sequence uint32
+}
+
+// GRE represents a Generic Routing Encapsulation header stored in a byte
+// array, the fields are described in RFC 2784 and RFC 2890.
+type GRE []byte
+
+// GREHeaderSize returns the size of a GRE header with the optional fields
+// selected by flags.
+func GREHeaderSize(flags uint16) int {
+	n := GREMinimumSize
+	if flags&GREFlagChecksum != 0 {
+		n += 4
+	}
+	if flags&GREFlagKey != 0 {
+		n += 4
+	}
+	if flags&GREFlagSequence != 0 {
+		n += 4
+	}
+	return n
+}
+
+// Flags returns the flags of the GRE header.
+func (b GRE) Flags() uint16 {
+	return binary.BigEndian.Uint16(b[greFlagsVersion:]) &^ greVersionMask
+}
+
+// Version returns the "version" field of the GRE header.
+func (b GRE) Version() uint8 {
+	return b[greFlagsVersion+1] & greVersionMask
+}
+
+// Protocol returns the "protocol type" field of the GRE header.
+func (b GRE) Protocol() tcpip.NetworkProtocolNumber {
+	return tcpip.NetworkProtocolNumber(binary.BigEndian.Uint16(b[greProtocol:]))
+}
+
+// HeaderLength returns the total length of the GRE header, including the
+// optional fields.
+func (b GRE) HeaderLength() int {
+	return GREHeaderSize(b.Flags())
+}
+
+// keyOffset returns the offset of the key field.
+func (b GRE) keyOffset() int {
+	if b.Flags()&GREFlagChecksum != 0 {
+		return greOptions + 4
+	}
+	return greOptions
+}
+
+// Checksum returns the "checksum" field of the GRE header. It must only be
+// called if GREFlagChecksum is set.
+func (b GRE) Checksum() uint16 {
+	return binary.BigEndian.Uint16(b[greOptions:])
+}
+
+// SetChecksum sets the "checksum" field of the GRE header. It must only be
+// called if GREFlagChecksum is set.
+func (b GRE) SetChecksum(checksum uint16) {
+	binary.BigEndian.PutUint16(b[greOptions:], checksum)
+}
+
+// Key returns the "key" field of the GRE header. It must only be called if
+// GREFlagKey is set.
+func (b GRE) Key() uint32 {
+	return binary.BigEndian.Uint32(b[b.keyOffset():])
+}
+
+// Sequence returns the "sequence number" field of the GRE header. It must
+// only be called if GREFlagSequence is set.
+func (b GRE) Sequence() uint32 {
+	off := b.keyOffset()
+	if b.Flags()&GREFlagKey != 0 {
+		off += 4
+	}
+	return binary.BigEndian.Uint32(b[off:])
+}
+
+// IsValid returns true if b is a version 0 GRE header without routing
+// information that fits in b.
+func (b GRE) IsValid() bool {
+	return len(b) >= GREMinimumSize && b.Version() == 0 && b.Flags()&GREFlagRouting == 0 && len(b) >= b.HeaderLength()
+}
+
+// Encode encodes all the fields of the GRE header. The checksum field, if
+// present, is zeroed and must be set after the payload is known.
+func (b GRE) Encode(f *GREFields) {
+	flags := f.Flags & (GREFlagChecksum | GREFlagKey | GREFlagSequence)
+	binary.BigEndian.PutUint16(b[greFlagsVersion:], flags)
+	binary.BigEndian.PutUint16(b[greProtocol:], uint16(f.Protocol))
+	off := greOptions
+	if flags&GREFlagChecksum != 0 {
+		binary.BigEndian.PutUint32(b[off:], 0)
+		off += 4
+	}
+	if flags&GREFlagKey != 0 {
+		binary.BigEndian.PutUint32(b[off:], f.Key)
+		off += 4
+	}
+	if flags&GREFlagSequence != 0 {
+		binary.BigEndian.PutUint32(b[off:], f.Sequence)
+	}
+}
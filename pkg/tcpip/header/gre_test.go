@@ -0,0 +1,103 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"bytes"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestGRE(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		fields  header.GREFields
+		encoded []byte
+	}{
+		{
+			name:    "NoOptions",
+			fields:  header.GREFields{Protocol: header.IPv4ProtocolNumber},
+			encoded: []byte{0, 0, 0x08, 0},
+		},
+		{
+			name: "Key",
+			fields: header.GREFields{
+				Flags:    header.GREFlagKey,
+				Protocol: header.IPv6ProtocolNumber,
+				Key:      0x01020304,
+			},
+			encoded: []byte{0x20, 0, 0x86, 0xdd, 1, 2, 3, 4},
+		},
+		{
+			name: "AllOptions",
+			fields: header.GREFields{
+				Flags:    header.GREFlagChecksum | header.GREFlagKey | header.GREFlagSequence,
+				Protocol: header.IPv4ProtocolNumber,
+				Key:      7,
+				Sequence: 9,
+			},
+			encoded: []byte{0xb0, 0, 0x08, 0, 0, 0, 0, 0, 0, 0, 0, 7, 0, 0, 0, 9},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			b := header.GRE(bytes.Repeat([]byte{0xff}, header.GREHeaderSize(tc.fields.Flags)))
+			b.Encode(&tc.fields)
+			if !bytes.Equal(b, tc.encoded) {
+				t.Fatalf("got encoded header %x, want %x", []byte(b), tc.encoded)
+			}
+			if !b.IsValid() {
+				t.Errorf("got b.IsValid() = false, want = true")
+			}
+			if got, want := b.HeaderLength(), len(tc.encoded); got != want {
+				t.Errorf("got b.HeaderLength() = %d, want = %d", got, want)
+			}
+			if got, want := b.Flags(), tc.fields.Flags; got != want {
+				t.Errorf("got b.Flags() = %#x, want = %#x", got, want)
+			}
+			if got, want := b.Protocol(), tc.fields.Protocol; got != want {
+				t.Errorf("got b.Protocol() = %#x, want = %#x", got, want)
+			}
+			if tc.fields.Flags&header.GREFlagKey != 0 {
+				if got, want := b.Key(), tc.fields.Key; got != want {
+					t.Errorf("got b.Key() = %d, want = %d", got, want)
+				}
+			}
+			if tc.fields.Flags&header.GREFlagSequence != 0 {
+				if got, want := b.Sequence(), tc.fields.Sequence; got != want {
+					t.Errorf("got b.Sequence() = %d, want = %d", got, want)
+				}
+			}
+			if b.IsValid() && len(b) > header.GREMinimumSize && header.GRE(b[:len(b)-1]).IsValid() {
+				t.Errorf("got IsValid() = true for a truncated header, want = false")
+			}
+		})
+	}
+}
+
+func TestGREInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		b    []byte
+	}{
+		{"Short", []byte{0, 0, 8}},
+		{"Version1", []byte{0, 1, 8, 0}},
+		{"Routing", []byte{0x40, 0, 8, 0, 0, 0, 0, 0}},
+	} {
+		if header.GRE(tc.b).IsValid() {
+			t.Errorf("%s: got IsValid() = true, want = false", tc.name)
+		}
+	}
+}
@@ -0,0 +1,454 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import (
+	"encoding/binary"
+)
+
+// MPTCP option subtypes, see RFC 8684, section 3.
+const (
+	MPTCPSubtypeCapable = 0
+	MPTCPSubtypeJoin    = 1
+	MPTCPSubtypeDSS     = 2
+)
+
+// MPTCPVersion is the version of the Multipath TCP protocol implemented here.
+// Version 0 (RFC 6824) is not supported.
+const MPTCPVersion = 1
+
+// Flags of the MP_CAPABLE option, see RFC 8684, section 3.1.
+const (
+	// MPTCPCapableChecksum is set if the sender requires DSS checksums.
+	MPTCPCapableChecksum = 0x80
+
+	// MPTCPCapableHMACSHA256 selects HMAC-SHA256 as the crypto algorithm.
+	MPTCPCapableHMACSHA256 = 0x01
+)
+
+// MP_CAPABLE option lengths, see RFC 8684, section 3.1.
+const (
+	MPTCPCapableSynLength      = 4
+	MPTCPCapableSynAckLength   = 12
+	MPTCPCapableAckLength      = 20
+	MPTCPCapableDataLength     = 22
+	MPTCPCapableDataCsumLength = 24
+)
+
+// MP_JOIN option lengths, see RFC 8684, section 3.2.
+const (
+	MPTCPJoinSynLength    = 12
+	MPTCPJoinSynAckLength = 16
+	MPTCPJoinAckLength    = 24
+)
+
+// MPTCPJoinHMACSize is the size of the HMAC carried by the third ACK of an
+// MP_JOIN handshake.
+const MPTCPJoinHMACSize = 20
+
+// MPTCPJoinBackup is the backup flag of the MP_JOIN option.
+const MPTCPJoinBackup = 0x01
+
+// Flags of the DSS option, see RFC 8684, section 3.3.
+const (
+	MPTCPDSSDataAck   = 0x01
+	MPTCPDSSDataAck64 = 0x02
+	MPTCPDSSMapping   = 0x04
+	MPTCPDSSMapping64 = 0x08
+	MPTCPDSSDataFin   = 0x10
+)
+
+// MPTCPDSSMaxLength is the length of a DSS option carrying a 64-bit data ACK
+// and a 64-bit mapping without checksum.
+const MPTCPDSSMaxLength = 4 + 8 + 8 + 4 + 2
+
+// MPTCPCapable holds the fields of an MP_CAPABLE option.
+//
+// +stateify savable
+type MPTCPCapable struct {
+	// Version is the MPTCP version advertised by the sender.
+	Version uint8
+
+	// Flags are the MP_CAPABLE flags.
+	Flags uint8
+
+	// NumKeys is the number of keys carried by the option: none in a SYN,
+	// the sender's key in a SYN-ACK and both keys afterwards.
+	NumKeys int
+
+	// SenderKey is the key of the sender of the option.
+	SenderKey uint64
+
+	// ReceiverKey is the key of the receiver of the option.
+	ReceiverKey uint64
+
+	// HasDataLen is true if the option maps the payload of the segment
+	// carrying it, which is then the first byte of data of the connection.
+	HasDataLen bool
+
+	// DataLen is the data-level length of the mapping.
+	DataLen uint16
+}
+
+// MPTCPJoin holds the fields of an MP_JOIN option. The fields which are set
+// depend on the segment carrying the option.
+//
+// +stateify savable
+type MPTCPJoin struct {
+	// Backup is true if the subflow should only be used as a backup.
+	Backup bool
+
+	// AddrID is the identifier of the sender's address. It is carried by
+	// the SYN and SYN-ACK.
+	AddrID uint8
+
+	// Token is the receiver's token, carried by the SYN.
+	Token uint32
+
+	// Nonce is the sender's random number, carried by the SYN and SYN-ACK.
+	Nonce uint32
+
+	// TruncatedHMAC is the leftmost 64 bits of the sender's HMAC, carried
+	// by the SYN-ACK.
+	TruncatedHMAC uint64
+
+	// HMAC is the leftmost 160 bits of the sender's HMAC, carried by the
+	// third ACK.
+	HMAC [MPTCPJoinHMACSize]byte
+}
+
+// MPTCPDSS holds the fields of a Data Sequence Signal option.
+//
+// +stateify savable
+type MPTCPDSS struct {
+	// HasAck is true if the option carries a data ACK.
+	HasAck bool
+
+	// Ack64 is true if the data ACK is 64 bits wide. Otherwise only the low
+	// 32 bits of Ack are valid.
+	Ack64 bool
+
+	// Ack is the data-level cumulative acknowledgement.
+	Ack uint64
+
+	// HasMapping is true if the option carries a data sequence mapping.
+	HasMapping bool
+
+	// DSN64 is true if the data sequence number is 64 bits wide. Otherwise
+	// only the low 32 bits of DSN are valid.
+	DSN64 bool
+
+	// DSN is the data sequence number of the first byte of the mapping.
+	DSN uint64
+
+	// SubflowSeq is the subflow sequence number of the first byte of the
+	// mapping, relative to the initial sequence number of the subflow.
+	SubflowSeq uint32
+
+	// DataLen is the length of the mapping.
+	DataLen uint16
+
+	// DataFin is true if the mapping ends with a DATA_FIN, which occupies
+	// the last byte of the mapping.
+	DataFin bool
+}
+
+// MPTCPOptions holds the Multipath TCP options carried by a segment.
+//
+// +stateify savable
+type MPTCPOptions struct {
+	// HasCapable is true if the segment carries an MP_CAPABLE option.
+	HasCapable bool
+	Capable    MPTCPCapable
+
+	// HasJoin is true if the segment carries an MP_JOIN option. JoinLength
+	// is the length of the option, which identifies its variant.
+	HasJoin    bool
+	JoinLength int
+	Join       MPTCPJoin
+
+	// HasDSS is true if the segment carries a DSS option.
+	HasDSS bool
+	DSS    MPTCPDSS
+}
+
+// ParseMPTCPOptions extracts the Multipath TCP options from the options of a
+// TCP segment. Malformed MPTCP options are ignored.
+func ParseMPTCPOptions(b []byte) MPTCPOptions {
+	var opts MPTCPOptions
+	limit := len(b)
+	for i := 0; i < limit; {
+		switch b[i] {
+		case TCPOptionEOL:
+			return opts
+		case TCPOptionNOP:
+			i++
+		default:
+			if i+2 > limit {
+				return opts
+			}
+			l := int(b[i+1])
+			if l < 2 || i+l > limit {
+				return opts
+			}
+			if b[i] == TCPOptionMPTCP && l >= 3 {
+				opts.parse(b[i : i+l])
+			}
+			i += l
+		}
+	}
+	return opts
+}
+
+// parse parses a single MPTCP option.
+func (opts *MPTCPOptions) parse(o []byte) {
+	l := len(o)
+	switch o[2] >> 4 {
+	case MPTCPSubtypeCapable:
+		if l < MPTCPCapableSynLength {
+			return
+		}
+		c := MPTCPCapable{
+			Version: o[2] & 0xf,
+			Flags:   o[3],
+		}
+		switch l {
+		case MPTCPCapableSynLength:
+		case MPTCPCapableSynAckLength:
+			c.NumKeys = 1
+			c.SenderKey = binary.BigEndian.Uint64(o[4:])
+		case MPTCPCapableAckLength, MPTCPCapableDataLength, MPTCPCapableDataCsumLength:
+			c.NumKeys = 2
+			c.SenderKey = binary.BigEndian.Uint64(o[4:])
+			c.ReceiverKey = binary.BigEndian.Uint64(o[12:])
+			if l > MPTCPCapableAckLength {
+				c.HasDataLen = true
+				c.DataLen = binary.BigEndian.Uint16(o[20:])
+			}
+		default:
+			return
+		}
+		opts.HasCapable = true
+		opts.Capable = c
+
+	case MPTCPSubtypeJoin:
+		var j MPTCPJoin
+		switch l {
+		case MPTCPJoinSynLength:
+			j.Backup = o[2]&MPTCPJoinBackup != 0
+			j.AddrID = o[3]
+			j.Token = binary.BigEndian.Uint32(o[4:])
+			j.Nonce = binary.BigEndian.Uint32(o[8:])
+		case MPTCPJoinSynAckLength:
+			j.Backup = o[2]&MPTCPJoinBackup != 0
+			j.AddrID = o[3]
+			j.TruncatedHMAC = binary.BigEndian.Uint64(o[4:])
+			j.Nonce = binary.BigEndian.Uint32(o[12:])
+		case MPTCPJoinAckLength:
+			copy(j.HMAC[:], o[4:])
+		default:
+			return
+		}
+		opts.HasJoin = true
+		opts.JoinLength = l
+		opts.Join = j
+
+	case MPTCPSubtypeDSS:
+		if l < 4 {
+			return
+		}
+		flags := o[3]
+		d := MPTCPDSS{
+			HasAck:     flags&MPTCPDSSDataAck != 0,
+			Ack64:      flags&MPTCPDSSDataAck64 != 0,
+			HasMapping: flags&MPTCPDSSMapping != 0,
+			DSN64:      flags&MPTCPDSSMapping64 != 0,
+			DataFin:    flags&MPTCPDSSDataFin != 0,
+		}
+		want := 4
+		if d.HasAck {
+			want += 4
+			if d.Ack64 {
+				want += 4
+			}
+		}
+		if d.HasMapping {
+			want += 4 + 4 + 2
+			if d.DSN64 {
+				want += 4
+			}
+		}
+		// The mapping may be followed by a 2 bytes checksum.
+		if l != want && !(d.HasMapping && l == want+2) {
+			return
+		}
+		off := 4
+		if d.HasAck {
+			if d.Ack64 {
+				d.Ack = binary.BigEndian.Uint64(o[off:])
+				off += 8
+			} else {
+				d.Ack = uint64(binary.BigEndian.Uint32(o[off:]))
+				off += 4
+			}
+		}
+		if d.HasMapping {
+			if d.DSN64 {
+				d.DSN = binary.BigEndian.Uint64(o[off:])
+				off += 8
+			} else {
+				d.DSN = uint64(binary.BigEndian.Uint32(o[off:]))
+				off += 4
+			}
+			d.SubflowSeq = binary.BigEndian.Uint32(o[off:])
+			d.DataLen = binary.BigEndian.Uint16(o[off+4:])
+		}
+		opts.HasDSS = true
+		opts.DSS = d
+	}
+}
+
+// MPTCPCapableLength returns the length of the MP_CAPABLE option encoding c.
+func MPTCPCapableLength(c MPTCPCapable) int {
+	switch {
+	case c.HasDataLen:
+		return MPTCPCapableDataLength
+	case c.NumKeys == 2:
+		return MPTCPCapableAckLength
+	case c.NumKeys == 1:
+		return MPTCPCapableSynAckLength
+	default:
+		return MPTCPCapableSynLength
+	}
+}
+
+// EncodeMPTCPCapableOption encodes an MP_CAPABLE option into the provided
+// buffer. The number of keys and the presence of the data-level length select
+// the variant of the option. If the buffer is smaller than required it just
+// returns without encoding anything. It returns the number of bytes written to
+// the provided buffer.
+func EncodeMPTCPCapableOption(c MPTCPCapable, b []byte) int {
+	l := MPTCPCapableLength(c)
+	if len(b) < l {
+		return 0
+	}
+	b[0], b[1] = TCPOptionMPTCP, byte(l)
+	b[2] = MPTCPSubtypeCapable<<4 | c.Version&0xf
+	b[3] = c.Flags
+	if l >= MPTCPCapableSynAckLength {
+		binary.BigEndian.PutUint64(b[4:], c.SenderKey)
+	}
+	if l >= MPTCPCapableAckLength {
+		binary.BigEndian.PutUint64(b[12:], c.ReceiverKey)
+	}
+	if l >= MPTCPCapableDataLength {
+		binary.BigEndian.PutUint16(b[20:], c.DataLen)
+	}
+	return l
+}
+
+// EncodeMPTCPJoinSynOption encodes the MP_JOIN option of a SYN into the
+// provided buffer. If the buffer is smaller than required it just returns
+// without encoding anything. It returns the number of bytes written to the
+// provided buffer.
+func EncodeMPTCPJoinSynOption(j MPTCPJoin, b []byte) int {
+	if len(b) < MPTCPJoinSynLength {
+		return 0
+	}
+	encodeMPTCPJoinHeader(j, MPTCPJoinSynLength, b)
+	b[3] = j.AddrID
+	binary.BigEndian.PutUint32(b[4:], j.Token)
+	binary.BigEndian.PutUint32(b[8:], j.Nonce)
+	return MPTCPJoinSynLength
+}
+
+// EncodeMPTCPJoinSynAckOption encodes the MP_JOIN option of a SYN-ACK into the
+// provided buffer. If the buffer is smaller than required it just returns
+// without encoding anything. It returns the number of bytes written to the
+// provided buffer.
+func EncodeMPTCPJoinSynAckOption(j MPTCPJoin, b []byte) int {
+	if len(b) < MPTCPJoinSynAckLength {
+		return 0
+	}
+	encodeMPTCPJoinHeader(j, MPTCPJoinSynAckLength, b)
+	b[3] = j.AddrID
+	binary.BigEndian.PutUint64(b[4:], j.TruncatedHMAC)
+	binary.BigEndian.PutUint32(b[12:], j.Nonce)
+	return MPTCPJoinSynAckLength
+}
+
+// EncodeMPTCPJoinAckOption encodes the MP_JOIN option of the third ACK of a
+// handshake into the provided buffer. If the buffer is smaller than required it
+// just returns without encoding anything. It returns the number of bytes
+// written to the provided buffer.
+func EncodeMPTCPJoinAckOption(j MPTCPJoin, b []byte) int {
+	if len(b) < MPTCPJoinAckLength {
+		return 0
+	}
+	b[0], b[1] = TCPOptionMPTCP, MPTCPJoinAckLength
+	b[2], b[3] = MPTCPSubtypeJoin<<4, 0
+	copy(b[4:], j.HMAC[:])
+	return MPTCPJoinAckLength
+}
+
+func encodeMPTCPJoinHeader(j MPTCPJoin, l int, b []byte) {
+	b[0], b[1] = TCPOptionMPTCP, byte(l)
+	b[2] = MPTCPSubtypeJoin << 4
+	if j.Backup {
+		b[2] |= MPTCPJoinBackup
+	}
+}
+
+// MPTCPDSSLength returns the length of the DSS option encoding d. Data ACKs
+// and data sequence numbers are always encoded on 64 bits.
+func MPTCPDSSLength(d MPTCPDSS) int {
+	l := 4
+	if d.HasAck {
+		l += 8
+	}
+	if d.HasMapping {
+		l += 8 + 4 + 2
+	}
+	return l
+}
+
+// EncodeMPTCPDSSOption encodes a DSS option into the provided buffer, using 64
+// bits data ACK and data sequence number. If the buffer is smaller than
+// required it just returns without encoding anything. It returns the number of
+// bytes written to the provided buffer.
+func EncodeMPTCPDSSOption(d MPTCPDSS, b []byte) int {
+	l := MPTCPDSSLength(d)
+	if len(b) < l {
+		return 0
+	}
+	b[0], b[1] = TCPOptionMPTCP, byte(l)
+	b[2], b[3] = MPTCPSubtypeDSS<<4, 0
+	off := 4
+	if d.HasAck {
+		b[3] |= MPTCPDSSDataAck | MPTCPDSSDataAck64
+		binary.BigEndian.PutUint64(b[off:], d.Ack)
+		off += 8
+	}
+	if d.HasMapping {
+		b[3] |= MPTCPDSSMapping | MPTCPDSSMapping64
+		if d.DataFin {
+			b[3] |= MPTCPDSSDataFin
+		}
+		binary.BigEndian.PutUint64(b[off:], d.DSN)
+		binary.BigEndian.PutUint32(b[off+8:], d.SubflowSeq)
+		binary.BigEndian.PutUint16(b[off+12:], d.DataLen)
+	}
+	return l
+}
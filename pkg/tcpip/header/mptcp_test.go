@@ -0,0 +1,149 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestMPTCPCapableOption(t *testing.T) {
+	for _, c := range []header.MPTCPCapable{
+		{Version: header.MPTCPVersion, Flags: header.MPTCPCapableHMACSHA256},
+		{Version: header.MPTCPVersion, Flags: header.MPTCPCapableHMACSHA256, NumKeys: 1, SenderKey: 0x0102030405060708},
+		{Version: header.MPTCPVersion, NumKeys: 2, SenderKey: 1, ReceiverKey: 2},
+		{Version: header.MPTCPVersion, NumKeys: 2, SenderKey: 1, ReceiverKey: 2, HasDataLen: true, DataLen: 1000},
+	} {
+		b := make([]byte, header.TCPOptionsMaximumSize)
+		// Prefix the option with a NOP to exercise the option walk.
+		b[0] = header.TCPOptionNOP
+		n := header.EncodeMPTCPCapableOption(c, b[1:])
+		if want := header.MPTCPCapableLength(c); n != want {
+			t.Fatalf("EncodeMPTCPCapableOption(%+v, _) = %d, want = %d", c, n, want)
+		}
+		opts := header.ParseMPTCPOptions(b[:1+n])
+		if !opts.HasCapable {
+			t.Fatalf("ParseMPTCPOptions(%x) did not return an MP_CAPABLE option", b[:1+n])
+		}
+		if diff := cmp.Diff(c, opts.Capable); diff != "" {
+			t.Errorf("MP_CAPABLE mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestMPTCPJoinOption(t *testing.T) {
+	j := header.MPTCPJoin{
+		Backup:        true,
+		AddrID:        3,
+		Token:         0xdeadbeef,
+		Nonce:         0xfeedface,
+		TruncatedHMAC: 0x1122334455667788,
+	}
+	for i := range j.HMAC {
+		j.HMAC[i] = byte(i)
+	}
+	for _, test := range []struct {
+		name   string
+		encode func(header.MPTCPJoin, []byte) int
+		want   header.MPTCPJoin
+	}{
+		{
+			name:   "SYN",
+			encode: header.EncodeMPTCPJoinSynOption,
+			want:   header.MPTCPJoin{Backup: true, AddrID: 3, Token: j.Token, Nonce: j.Nonce},
+		},
+		{
+			name:   "SYN-ACK",
+			encode: header.EncodeMPTCPJoinSynAckOption,
+			want:   header.MPTCPJoin{Backup: true, AddrID: 3, TruncatedHMAC: j.TruncatedHMAC, Nonce: j.Nonce},
+		},
+		{
+			name:   "ACK",
+			encode: header.EncodeMPTCPJoinAckOption,
+			want:   header.MPTCPJoin{HMAC: j.HMAC},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			b := make([]byte, header.TCPOptionsMaximumSize)
+			n := test.encode(j, b)
+			opts := header.ParseMPTCPOptions(b[:n])
+			if !opts.HasJoin || opts.JoinLength != n {
+				t.Fatalf("got HasJoin = %t, JoinLength = %d, want = true, %d", opts.HasJoin, opts.JoinLength, n)
+			}
+			if diff := cmp.Diff(test.want, opts.Join); diff != "" {
+				t.Errorf("MP_JOIN mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestMPTCPDSSOption(t *testing.T) {
+	for _, d := range []header.MPTCPDSS{
+		{HasAck: true, Ack: 1 << 40},
+		{HasMapping: true, DSN: 1<<33 + 5, SubflowSeq: 1, DataLen: 1400},
+		{HasAck: true, Ack: 7, HasMapping: true, DSN: 9, SubflowSeq: 0, DataLen: 1, DataFin: true},
+	} {
+		b := make([]byte, header.TCPOptionsMaximumSize)
+		n := header.EncodeMPTCPDSSOption(d, b)
+		if want := header.MPTCPDSSLength(d); n != want {
+			t.Fatalf("EncodeMPTCPDSSOption(%+v, _) = %d, want = %d", d, n, want)
+		}
+		opts := header.ParseMPTCPOptions(b[:n])
+		if !opts.HasDSS {
+			t.Fatalf("ParseMPTCPOptions(%x) did not return a DSS option", b[:n])
+		}
+		// Values are always encoded on 64 bits.
+		d.Ack64 = d.HasAck
+		d.DSN64 = d.HasMapping
+		if diff := cmp.Diff(d, opts.DSS); diff != "" {
+			t.Errorf("DSS mismatch (-want +got):\n%s", diff)
+		}
+	}
+}
+
+func TestMPTCPDSSOption32Bits(t *testing.T) {
+	// DSS with a 32 bits data ACK and mapping, followed by a checksum.
+	b := []byte{
+		header.TCPOptionMPTCP, 20, header.MPTCPSubtypeDSS << 4, header.MPTCPDSSDataAck | header.MPTCPDSSMapping,
+		0, 0, 0, 10,
+		0, 0, 1, 0,
+		0, 0, 0, 1,
+		0, 100,
+		0xab, 0xcd,
+	}
+	want := header.MPTCPDSS{
+		HasAck:     true,
+		Ack:        10,
+		HasMapping: true,
+		DSN:        256,
+		SubflowSeq: 1,
+		DataLen:    100,
+	}
+	opts := header.ParseMPTCPOptions(b)
+	if !opts.HasDSS {
+		t.Fatalf("ParseMPTCPOptions(%x) did not return a DSS option", b)
+	}
+	if diff := cmp.Diff(want, opts.DSS); diff != "" {
+		t.Errorf("DSS mismatch (-want +got):\n%s", diff)
+	}
+
+	// A truncated option is ignored.
+	b[1] = 12
+	if opts := header.ParseMPTCPOptions(b[:12]); opts.HasDSS {
+		t.Errorf("ParseMPTCPOptions(%x) returned a DSS option for a malformed option", b[:12])
+	}
+}
@@ -187,6 +187,16 @@ func TCP(pkt *stack.PacketBuffer) bool {
 	return ok
 }
 
+// SCTP parses an SCTP packet found in pkt.Data and populates pkt's transport
+// header with the SCTP common header.
+//
+// Returns true if the header was successfully parsed.
+func SCTP(pkt *stack.PacketBuffer) bool {
+	_, ok := pkt.TransportHeader().Consume(header.SCTPMinimumSize)
+	pkt.TransportProtocolNumber = header.SCTPProtocolNumber
+	return ok
+}
+
 // ICMPv4 populates the packet buffer's transport header with an ICMPv4 header,
 // if present.
 //
@@ -0,0 +1,592 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+const (
+	sctpSrcPort  = 0
+	sctpDstPort  = 2
+	sctpVerTag   = 4
+	sctpChecksum = 8
+)
+
+const (
+	// SCTPProtocolNumber is SCTP's transport protocol number.
+	SCTPProtocolNumber tcpip.TransportProtocolNumber = 132
+
+	// SCTPMinimumSize is the size of the SCTP common header.
+	SCTPMinimumSize = 12
+
+	// SCTPChunkHeaderSize is the size of the type, flags and length fields
+	// that start every SCTP chunk.
+	SCTPChunkHeaderSize = 4
+
+	// SCTPParameterHeaderSize is the size of the type and length fields that
+	// start every SCTP chunk parameter and error cause.
+	SCTPParameterHeaderSize = 4
+
+	// SCTPDataChunkHeaderSize is the size of a DATA chunk without user data.
+	SCTPDataChunkHeaderSize = 16
+
+	// SCTPInitChunkMinimumSize is the size of an INIT or INIT ACK chunk
+	// without parameters.
+	SCTPInitChunkMinimumSize = 20
+
+	// SCTPSackChunkMinimumSize is the size of a SACK chunk without gap ack
+	// blocks or duplicate TSNs.
+	SCTPSackChunkMinimumSize = 16
+
+	// SCTPShutdownChunkSize is the size of a SHUTDOWN chunk.
+	SCTPShutdownChunkSize = 8
+)
+
+// SCTPChunkType is the type of an SCTP chunk, as defined in RFC 9260
+// section 3.2.
+type SCTPChunkType uint8
+
+// SCTP chunk types.
+const (
+	SCTPChunkData             SCTPChunkType = 0
+	SCTPChunkInit             SCTPChunkType = 1
+	SCTPChunkInitAck          SCTPChunkType = 2
+	SCTPChunkSack             SCTPChunkType = 3
+	SCTPChunkHeartbeat        SCTPChunkType = 4
+	SCTPChunkHeartbeatAck     SCTPChunkType = 5
+	SCTPChunkAbort            SCTPChunkType = 6
+	SCTPChunkShutdown         SCTPChunkType = 7
+	SCTPChunkShutdownAck      SCTPChunkType = 8
+	SCTPChunkError            SCTPChunkType = 9
+	SCTPChunkCookieEcho       SCTPChunkType = 10
+	SCTPChunkCookieAck        SCTPChunkType = 11
+	SCTPChunkShutdownComplete SCTPChunkType = 14
+)
+
+// Unrecognized chunk types are handled according to the two high-order bits
+// of the chunk type, as per RFC 9260 section 3.2.
+const (
+	// SCTPChunkTypeSkipBit indicates that processing of the packet should
+	// continue past an unrecognized chunk.
+	SCTPChunkTypeSkipBit = 1 << 7
+
+	// SCTPChunkTypeReportBit indicates that an unrecognized chunk should be
+	// reported to the peer.
+	SCTPChunkTypeReportBit = 1 << 6
+)
+
+// SCTP chunk flags.
+const (
+	// SCTPDataFlagEnding marks the last fragment of a user message.
+	SCTPDataFlagEnding = 1 << 0
+
+	// SCTPDataFlagBeginning marks the first fragment of a user message.
+	SCTPDataFlagBeginning = 1 << 1
+
+	// SCTPDataFlagUnordered marks a DATA chunk carrying an unordered user
+	// message.
+	SCTPDataFlagUnordered = 1 << 2
+
+	// SCTPDataFlagImmediate asks the receiver to acknowledge a DATA chunk
+	// without delay (RFC 7053).
+	SCTPDataFlagImmediate = 1 << 3
+
+	// SCTPFlagTBit is set in ABORT and SHUTDOWN COMPLETE chunks that carry
+	// the peer's verification tag reflected from the packet being answered.
+	SCTPFlagTBit = 1 << 0
+)
+
+// SCTPParameterType is the type of an SCTP chunk parameter.
+type SCTPParameterType uint16
+
+// SCTP chunk parameter types.
+const (
+	SCTPParameterHeartbeatInfo         SCTPParameterType = 1
+	SCTPParameterIPv4Address           SCTPParameterType = 5
+	SCTPParameterIPv6Address           SCTPParameterType = 6
+	SCTPParameterStateCookie           SCTPParameterType = 7
+	SCTPParameterUnrecognized          SCTPParameterType = 8
+	SCTPParameterCookiePreservative    SCTPParameterType = 9
+	SCTPParameterSupportedAddressTypes SCTPParameterType = 12
+)
+
+// SCTPCauseCode is an SCTP error cause code, carried in ERROR and ABORT
+// chunks.
+type SCTPCauseCode uint16
+
+// SCTP error cause codes.
+const (
+	SCTPCauseInvalidStreamIdentifier SCTPCauseCode = 1
+	SCTPCauseMissingParameter        SCTPCauseCode = 2
+	SCTPCauseStaleCookie             SCTPCauseCode = 3
+	SCTPCauseOutOfResource           SCTPCauseCode = 4
+	SCTPCauseUnrecognizedChunkType   SCTPCauseCode = 6
+	SCTPCauseNoUserData              SCTPCauseCode = 9
+	SCTPCauseUserInitiatedAbort      SCTPCauseCode = 12
+	SCTPCauseProtocolViolation       SCTPCauseCode = 13
+)
+
+var sctpCRC32CTable = crc32.MakeTable(crc32.Castagnoli)
+
+// SCTPFields contains the fields of an SCTP common header. It is used to
+// describe the fields of a packet that needs to be encoded.
+type SCTPFields struct {
+	// SrcPort is the "source port" field of an SCTP packet.
+	SrcPort uint16
+
+	// DstPort is the "destination port" field of an SCTP packet.
+	DstPort uint16
+
+	// VerificationTag is the "verification tag" field of an SCTP packet.
+	VerificationTag uint32
+}
+
+// SCTP represents an SCTP packet stored in a byte array, starting with the
+// common header and followed by the packet's chunks.
+type SCTP []byte
+
+// SourcePort returns the "source port" field of the SCTP header.
+func (b SCTP) SourcePort() uint16 {
+	return binary.BigEndian.Uint16(b[sctpSrcPort:])
+}
+
+// DestinationPort returns the "destination port" field of the SCTP header.
+func (b SCTP) DestinationPort() uint16 {
+	return binary.BigEndian.Uint16(b[sctpDstPort:])
+}
+
+// VerificationTag returns the "verification tag" field of the SCTP header.
+func (b SCTP) VerificationTag() uint32 {
+	return binary.BigEndian.Uint32(b[sctpVerTag:])
+}
+
+// Checksum returns the "checksum" field of the SCTP header.
+func (b SCTP) Checksum() uint32 {
+	return binary.LittleEndian.Uint32(b[sctpChecksum:])
+}
+
+// Payload returns the chunks contained in the SCTP packet.
+func (b SCTP) Payload() []byte {
+	return b[SCTPMinimumSize:]
+}
+
+// SetSourcePort sets the "source port" field of the SCTP header.
+func (b SCTP) SetSourcePort(port uint16) {
+	binary.BigEndian.PutUint16(b[sctpSrcPort:], port)
+}
+
+// SetDestinationPort sets the "destination port" field of the SCTP header.
+func (b SCTP) SetDestinationPort(port uint16) {
+	binary.BigEndian.PutUint16(b[sctpDstPort:], port)
+}
+
+// SetVerificationTag sets the "verification tag" field of the SCTP header.
+func (b SCTP) SetVerificationTag(tag uint32) {
+	binary.BigEndian.PutUint32(b[sctpVerTag:], tag)
+}
+
+// SetChecksum sets the "checksum" field of the SCTP header.
+//
+// The CRC32c checksum is stored least significant byte first, as per RFC 9260
+// appendix A.
+func (b SCTP) SetChecksum(xsum uint32) {
+	binary.LittleEndian.PutUint32(b[sctpChecksum:], xsum)
+}
+
+// Encode encodes all the fields of the SCTP common header, leaving the
+// checksum zeroed.
+func (b SCTP) Encode(f *SCTPFields) {
+	b.SetSourcePort(f.SrcPort)
+	b.SetDestinationPort(f.DstPort)
+	b.SetVerificationTag(f.VerificationTag)
+	b.SetChecksum(0)
+}
+
+// CalculateChecksum calculates the CRC32c checksum of the whole SCTP packet,
+// treating the checksum field as zero.
+func (b SCTP) CalculateChecksum() uint32 {
+	return SCTPChecksum(b[:SCTPMinimumSize], b[SCTPMinimumSize:])
+}
+
+// SCTPChecksum calculates the CRC32c checksum of the SCTP packet made of the
+// common header hdr followed by the chunks in payload, treating the checksum
+// field as zero.
+func SCTPChecksum(hdr SCTP, payload []byte) uint32 {
+	var zero [4]byte
+	xsum := crc32.Update(0, sctpCRC32CTable, hdr[:sctpChecksum])
+	xsum = crc32.Update(xsum, sctpCRC32CTable, zero[:])
+	return crc32.Update(xsum, sctpCRC32CTable, payload)
+}
+
+// IsChecksumValid returns true iff the SCTP packet's checksum is valid.
+func (b SCTP) IsChecksumValid() bool {
+	return b.CalculateChecksum() == b.Checksum()
+}
+
+// SCTPPaddedLength returns l rounded up to the 4 byte boundary that SCTP
+// chunks and parameters are aligned to.
+func SCTPPaddedLength(l int) int {
+	return (l + 3) &^ 3
+}
+
+// SCTPChunk represents an SCTP chunk stored in a byte array. The byte array
+// holds exactly the number of bytes indicated by the chunk's length field,
+// without trailing padding.
+type SCTPChunk []byte
+
+// Type returns the "chunk type" field of the chunk.
+func (b SCTPChunk) Type() SCTPChunkType {
+	return SCTPChunkType(b[0])
+}
+
+// Flags returns the "chunk flags" field of the chunk.
+func (b SCTPChunk) Flags() uint8 {
+	return b[1]
+}
+
+// Length returns the "chunk length" field of the chunk.
+func (b SCTPChunk) Length() uint16 {
+	return binary.BigEndian.Uint16(b[2:])
+}
+
+// Value returns the chunk's value, following the chunk header.
+func (b SCTPChunk) Value() []byte {
+	return b[SCTPChunkHeaderSize:]
+}
+
+// EncodeHeader encodes the chunk header fields. The chunk's length field is
+// set to len(b).
+func (b SCTPChunk) EncodeHeader(typ SCTPChunkType, flags uint8) {
+	b[0] = uint8(typ)
+	b[1] = flags
+	binary.BigEndian.PutUint16(b[2:], uint16(len(b)))
+}
+
+// ParseSCTPChunks splits the chunks of an SCTP packet payload.
+//
+// Returns false if any chunk's length field is invalid.
+func ParseSCTPChunks(b []byte) ([]SCTPChunk, bool) {
+	var chunks []SCTPChunk
+	for len(b) > 0 {
+		if len(b) < SCTPChunkHeaderSize {
+			return nil, false
+		}
+		l := int(SCTPChunk(b).Length())
+		if l < SCTPChunkHeaderSize || l > len(b) {
+			return nil, false
+		}
+		chunks = append(chunks, SCTPChunk(b[:l]))
+		padded := SCTPPaddedLength(l)
+		if padded > len(b) {
+			// The padding of the last chunk may be omitted.
+			padded = len(b)
+		}
+		b = b[padded:]
+	}
+	return chunks, true
+}
+
+// SCTPDataChunkFields contains the fields of a DATA chunk header.
+type SCTPDataChunkFields struct {
+	// Flags holds the U, B and E bits of the chunk.
+	Flags uint8
+
+	// TSN is the transmission sequence number of the chunk.
+	TSN uint32
+
+	// StreamID is the stream the user message belongs to.
+	StreamID uint16
+
+	// StreamSequence is the stream sequence number of the user message.
+	StreamSequence uint16
+
+	// PayloadProtocolIdentifier is the opaque, application-specified protocol
+	// identifier of the user message.
+	PayloadProtocolIdentifier uint32
+}
+
+// SCTPDataChunk represents an SCTP DATA chunk stored in a byte array.
+type SCTPDataChunk SCTPChunk
+
+// TSN returns the "TSN" field of the DATA chunk.
+func (b SCTPDataChunk) TSN() uint32 {
+	return binary.BigEndian.Uint32(b[4:])
+}
+
+// StreamID returns the "stream identifier" field of the DATA chunk.
+func (b SCTPDataChunk) StreamID() uint16 {
+	return binary.BigEndian.Uint16(b[8:])
+}
+
+// StreamSequence returns the "stream sequence number" field of the DATA
+// chunk.
+func (b SCTPDataChunk) StreamSequence() uint16 {
+	return binary.BigEndian.Uint16(b[10:])
+}
+
+// PayloadProtocolIdentifier returns the "payload protocol identifier" field
+// of the DATA chunk.
+func (b SCTPDataChunk) PayloadProtocolIdentifier() uint32 {
+	return binary.BigEndian.Uint32(b[12:])
+}
+
+// Payload returns the user data carried in the DATA chunk.
+func (b SCTPDataChunk) Payload() []byte {
+	return b[SCTPDataChunkHeaderSize:]
+}
+
+// Encode encodes the DATA chunk header. The user data is expected to follow
+// the header in b.
+func (b SCTPDataChunk) Encode(f *SCTPDataChunkFields) {
+	SCTPChunk(b).EncodeHeader(SCTPChunkData, f.Flags)
+	binary.BigEndian.PutUint32(b[4:], f.TSN)
+	binary.BigEndian.PutUint16(b[8:], f.StreamID)
+	binary.BigEndian.PutUint16(b[10:], f.StreamSequence)
+	binary.BigEndian.PutUint32(b[12:], f.PayloadProtocolIdentifier)
+}
+
+// SCTPInitChunkFields contains the fields of an INIT or INIT ACK chunk.
+type SCTPInitChunkFields struct {
+	// InitiateTag is the verification tag the peer must use when sending to
+	// the chunk's sender.
+	InitiateTag uint32
+
+	// AdvertisedReceiverWindowCredit is the sender's initial receive window.
+	AdvertisedReceiverWindowCredit uint32
+
+	// OutboundStreams is the number of streams the sender wishes to open.
+	OutboundStreams uint16
+
+	// InboundStreams is the maximum number of streams the sender accepts.
+	InboundStreams uint16
+
+	// InitialTSN is the TSN the sender will use for its first DATA chunk.
+	InitialTSN uint32
+}
+
+// SCTPInitChunk represents an SCTP INIT or INIT ACK chunk stored in a byte
+// array.
+type SCTPInitChunk SCTPChunk
+
+// InitiateTag returns the "initiate tag" field of the chunk.
+func (b SCTPInitChunk) InitiateTag() uint32 {
+	return binary.BigEndian.Uint32(b[4:])
+}
+
+// AdvertisedReceiverWindowCredit returns the "advertised receiver window
+// credit" field of the chunk.
+func (b SCTPInitChunk) AdvertisedReceiverWindowCredit() uint32 {
+	return binary.BigEndian.Uint32(b[8:])
+}
+
+// OutboundStreams returns the "number of outbound streams" field of the
+// chunk.
+func (b SCTPInitChunk) OutboundStreams() uint16 {
+	return binary.BigEndian.Uint16(b[12:])
+}
+
+// InboundStreams returns the "number of inbound streams" field of the chunk.
+func (b SCTPInitChunk) InboundStreams() uint16 {
+	return binary.BigEndian.Uint16(b[14:])
+}
+
+// InitialTSN returns the "initial TSN" field of the chunk.
+func (b SCTPInitChunk) InitialTSN() uint32 {
+	return binary.BigEndian.Uint32(b[16:])
+}
+
+// Parameters returns the variable-length parameters of the chunk.
+func (b SCTPInitChunk) Parameters() []byte {
+	return b[SCTPInitChunkMinimumSize:]
+}
+
+// Encode encodes the fixed fields of an INIT or INIT ACK chunk. Parameters
+// are expected to follow the fixed fields in b.
+func (b SCTPInitChunk) Encode(typ SCTPChunkType, f *SCTPInitChunkFields) {
+	SCTPChunk(b).EncodeHeader(typ, 0)
+	binary.BigEndian.PutUint32(b[4:], f.InitiateTag)
+	binary.BigEndian.PutUint32(b[8:], f.AdvertisedReceiverWindowCredit)
+	binary.BigEndian.PutUint16(b[12:], f.OutboundStreams)
+	binary.BigEndian.PutUint16(b[14:], f.InboundStreams)
+	binary.BigEndian.PutUint32(b[16:], f.InitialTSN)
+}
+
+// SCTPGapAckBlock is a gap ack block of a SACK chunk. Start and End are
+// offsets from the cumulative TSN ack.
+type SCTPGapAckBlock struct {
+	Start uint16
+	End   uint16
+}
+
+// SCTPSackChunkFields contains the fields of a SACK chunk.
+type SCTPSackChunkFields struct {
+	// CumulativeTSNAck is the highest TSN received in sequence.
+	CumulativeTSNAck uint32
+
+	// AdvertisedReceiverWindowCredit is the sender's current receive window.
+	AdvertisedReceiverWindowCredit uint32
+
+	// GapAckBlocks holds the TSNs received out of sequence.
+	GapAckBlocks []SCTPGapAckBlock
+
+	// DuplicateTSNs holds the TSNs received more than once since the last
+	// SACK.
+	DuplicateTSNs []uint32
+}
+
+// SCTPSackChunkSize returns the size of a SACK chunk with the given number of
+// gap ack blocks and duplicate TSNs.
+func SCTPSackChunkSize(gaps, dups int) int {
+	return SCTPSackChunkMinimumSize + 4*gaps + 4*dups
+}
+
+// SCTPSackChunk represents an SCTP SACK chunk stored in a byte array.
+type SCTPSackChunk SCTPChunk
+
+// IsValid returns true iff the SACK chunk is large enough to hold its gap ack
+// blocks and duplicate TSNs.
+func (b SCTPSackChunk) IsValid() bool {
+	if len(b) < SCTPSackChunkMinimumSize {
+		return false
+	}
+	return len(b) >= SCTPSackChunkSize(int(b.NumGapAckBlocks()), int(b.NumDuplicateTSNs()))
+}
+
+// CumulativeTSNAck returns the "cumulative TSN ack" field of the chunk.
+func (b SCTPSackChunk) CumulativeTSNAck() uint32 {
+	return binary.BigEndian.Uint32(b[4:])
+}
+
+// AdvertisedReceiverWindowCredit returns the "advertised receiver window
+// credit" field of the chunk.
+func (b SCTPSackChunk) AdvertisedReceiverWindowCredit() uint32 {
+	return binary.BigEndian.Uint32(b[8:])
+}
+
+// NumGapAckBlocks returns the "number of gap ack blocks" field of the chunk.
+func (b SCTPSackChunk) NumGapAckBlocks() uint16 {
+	return binary.BigEndian.Uint16(b[12:])
+}
+
+// NumDuplicateTSNs returns the "number of duplicate TSNs" field of the chunk.
+func (b SCTPSackChunk) NumDuplicateTSNs() uint16 {
+	return binary.BigEndian.Uint16(b[14:])
+}
+
+// GapAckBlock returns the i-th gap ack block of the chunk.
+func (b SCTPSackChunk) GapAckBlock(i int) SCTPGapAckBlock {
+	off := SCTPSackChunkMinimumSize + 4*i
+	return SCTPGapAckBlock{
+		Start: binary.BigEndian.Uint16(b[off:]),
+		End:   binary.BigEndian.Uint16(b[off+2:]),
+	}
+}
+
+// DuplicateTSN returns the i-th duplicate TSN of the chunk.
+func (b SCTPSackChunk) DuplicateTSN(i int) uint32 {
+	off := SCTPSackChunkMinimumSize + 4*int(b.NumGapAckBlocks()) + 4*i
+	return binary.BigEndian.Uint32(b[off:])
+}
+
+// Encode encodes the SACK chunk. b must be SCTPSackChunkSize bytes long.
+func (b SCTPSackChunk) Encode(f *SCTPSackChunkFields) {
+	SCTPChunk(b).EncodeHeader(SCTPChunkSack, 0)
+	binary.BigEndian.PutUint32(b[4:], f.CumulativeTSNAck)
+	binary.BigEndian.PutUint32(b[8:], f.AdvertisedReceiverWindowCredit)
+	binary.BigEndian.PutUint16(b[12:], uint16(len(f.GapAckBlocks)))
+	binary.BigEndian.PutUint16(b[14:], uint16(len(f.DuplicateTSNs)))
+	off := SCTPSackChunkMinimumSize
+	for _, g := range f.GapAckBlocks {
+		binary.BigEndian.PutUint16(b[off:], g.Start)
+		binary.BigEndian.PutUint16(b[off+2:], g.End)
+		off += 4
+	}
+	for _, tsn := range f.DuplicateTSNs {
+		binary.BigEndian.PutUint32(b[off:], tsn)
+		off += 4
+	}
+}
+
+// SCTPShutdownChunk represents an SCTP SHUTDOWN chunk stored in a byte array.
+type SCTPShutdownChunk SCTPChunk
+
+// CumulativeTSNAck returns the "cumulative TSN ack" field of the chunk.
+func (b SCTPShutdownChunk) CumulativeTSNAck() uint32 {
+	return binary.BigEndian.Uint32(b[4:])
+}
+
+// Encode encodes the SHUTDOWN chunk.
+func (b SCTPShutdownChunk) Encode(cumulativeTSNAck uint32) {
+	SCTPChunk(b).EncodeHeader(SCTPChunkShutdown, 0)
+	binary.BigEndian.PutUint32(b[4:], cumulativeTSNAck)
+}
+
+// SCTPParameter represents an SCTP chunk parameter or error cause stored in a
+// byte array. Both share the same type-length-value layout. The byte array
+// holds exactly the number of bytes indicated by the length field.
+type SCTPParameter []byte
+
+// Type returns the "parameter type" field of the parameter.
+func (b SCTPParameter) Type() SCTPParameterType {
+	return SCTPParameterType(binary.BigEndian.Uint16(b[0:]))
+}
+
+// CauseCode returns the "cause code" field of an error cause.
+func (b SCTPParameter) CauseCode() SCTPCauseCode {
+	return SCTPCauseCode(binary.BigEndian.Uint16(b[0:]))
+}
+
+// Length returns the "length" field of the parameter.
+func (b SCTPParameter) Length() uint16 {
+	return binary.BigEndian.Uint16(b[2:])
+}
+
+// Value returns the parameter's value.
+func (b SCTPParameter) Value() []byte {
+	return b[SCTPParameterHeaderSize:]
+}
+
+// Encode encodes the parameter header. The length field is set to len(b).
+func (b SCTPParameter) Encode(typ uint16) {
+	binary.BigEndian.PutUint16(b[0:], typ)
+	binary.BigEndian.PutUint16(b[2:], uint16(len(b)))
+}
+
+// ParseSCTPParameters splits a list of SCTP chunk parameters or error causes.
+//
+// Returns false if any parameter's length field is invalid.
+func ParseSCTPParameters(b []byte) ([]SCTPParameter, bool) {
+	var params []SCTPParameter
+	for len(b) > 0 {
+		if len(b) < SCTPParameterHeaderSize {
+			return nil, false
+		}
+		l := int(SCTPParameter(b).Length())
+		if l < SCTPParameterHeaderSize || l > len(b) {
+			return nil, false
+		}
+		params = append(params, SCTPParameter(b[:l]))
+		padded := SCTPPaddedLength(l)
+		if padded > len(b) {
+			padded = len(b)
+		}
+		b = b[padded:]
+	}
+	return params, true
+}
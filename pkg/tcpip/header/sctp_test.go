@@ -0,0 +1,107 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"hash/crc32"
+	"slices"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestSCTPChecksum(t *testing.T) {
+	b := header.SCTP(make([]byte, header.SCTPMinimumSize+header.SCTPChunkHeaderSize))
+	b.Encode(&header.SCTPFields{
+		SrcPort:         1234,
+		DstPort:         5678,
+		VerificationTag: 0xdeadbeef,
+	})
+	header.SCTPChunk(b.Payload()).EncodeHeader(header.SCTPChunkCookieAck, 0)
+
+	want := crc32.Checksum(b, crc32.MakeTable(crc32.Castagnoli))
+	b.SetChecksum(b.CalculateChecksum())
+	if got := b.Checksum(); got != want {
+		t.Errorf("got b.Checksum() = %#x, want = %#x", got, want)
+	}
+	if !b.IsChecksumValid() {
+		t.Errorf("got b.IsChecksumValid() = false, want = true")
+	}
+
+	b.SetVerificationTag(0xfeedface)
+	if b.IsChecksumValid() {
+		t.Errorf("got b.IsChecksumValid() = true after modifying the packet, want = false")
+	}
+}
+
+func TestParseSCTPChunks(t *testing.T) {
+	data := make([]byte, header.SCTPDataChunkHeaderSize+3)
+	header.SCTPDataChunk(data).Encode(&header.SCTPDataChunkFields{
+		Flags:                     header.SCTPDataFlagBeginning | header.SCTPDataFlagEnding,
+		TSN:                       10,
+		StreamID:                  2,
+		StreamSequence:            7,
+		PayloadProtocolIdentifier: 46,
+	})
+	copy(header.SCTPDataChunk(data).Payload(), "abc")
+
+	sack := make([]byte, header.SCTPSackChunkSize(1, 1))
+	header.SCTPSackChunk(sack).Encode(&header.SCTPSackChunkFields{
+		CumulativeTSNAck:               9,
+		AdvertisedReceiverWindowCredit: 1 << 16,
+		GapAckBlocks:                   []header.SCTPGapAckBlock{{Start: 2, End: 3}},
+		DuplicateTSNs:                  []uint32{8},
+	})
+
+	// The DATA chunk is padded to a 4 byte boundary.
+	payload := slices.Concat(data, []byte{0}, sack)
+	chunks, ok := header.ParseSCTPChunks(payload)
+	if !ok {
+		t.Fatalf("header.ParseSCTPChunks(_) failed")
+	}
+	if len(chunks) != 2 {
+		t.Fatalf("got len(chunks) = %d, want = 2", len(chunks))
+	}
+
+	d := header.SCTPDataChunk(chunks[0])
+	if got := chunks[0].Type(); got != header.SCTPChunkData {
+		t.Errorf("got chunks[0].Type() = %d, want = %d", got, header.SCTPChunkData)
+	}
+	if d.TSN() != 10 || d.StreamID() != 2 || d.StreamSequence() != 7 || d.PayloadProtocolIdentifier() != 46 {
+		t.Errorf("got DATA (TSN, stream, SSN, PPID) = (%d, %d, %d, %d), want = (10, 2, 7, 46)", d.TSN(), d.StreamID(), d.StreamSequence(), d.PayloadProtocolIdentifier())
+	}
+	if got := string(d.Payload()); got != "abc" {
+		t.Errorf("got DATA payload = %q, want = %q", got, "abc")
+	}
+
+	s := header.SCTPSackChunk(chunks[1])
+	if !s.IsValid() {
+		t.Fatalf("got SACK IsValid() = false, want = true")
+	}
+	if got := s.CumulativeTSNAck(); got != 9 {
+		t.Errorf("got CumulativeTSNAck() = %d, want = 9", got)
+	}
+	if s.NumGapAckBlocks() != 1 || s.GapAckBlock(0) != (header.SCTPGapAckBlock{Start: 2, End: 3}) {
+		t.Errorf("got gap ack blocks = %d, %+v, want = 1, {2 3}", s.NumGapAckBlocks(), s.GapAckBlock(0))
+	}
+	if s.NumDuplicateTSNs() != 1 || s.DuplicateTSN(0) != 8 {
+		t.Errorf("got duplicate TSNs = %d, %d, want = 1, 8", s.NumDuplicateTSNs(), s.DuplicateTSN(0))
+	}
+
+	// A chunk whose length exceeds the packet is invalid.
+	if _, ok := header.ParseSCTPChunks(data[:len(data)-1]); ok {
+		t.Errorf("header.ParseSCTPChunks(_) on truncated chunk succeeded, want failure")
+	}
+}
@@ -91,6 +91,9 @@ const (
 	TCPOptionTS            = 8
 	TCPOptionSACKPermitted = 4
 	TCPOptionSACK          = 5
+	TCPOptionMD5           = 19
+	TCPOptionMPTCP         = 30
+	TCPOptionFastOpen      = 34
 )
 
 // Option Lengths.
@@ -99,6 +102,22 @@ const (
 	TCPOptionTSLength            = 10
 	TCPOptionWSLength            = 3
 	TCPOptionSackPermittedLength = 2
+	TCPOptionMD5Length           = 18
+)
+
+// TCPMD5DigestSize is the size of the digest carried by the TCP MD5 signature
+// option, see RFC 2385, section 3.0.
+const TCPMD5DigestSize = 16
+
+// Fast Open cookie lengths, see RFC 7413, section 4.1.1.
+const (
+	// TCPFastOpenMinCookieLength is the minimum length of a non-empty TCP
+	// Fast Open cookie.
+	TCPFastOpenMinCookieLength = 4
+
+	// TCPFastOpenMaxCookieLength is the maximum length of a TCP Fast Open
+	// cookie.
+	TCPFastOpenMaxCookieLength = 16
 )
 
 // TCPFields contains the fields of a TCP packet. It is used to describe the
@@ -158,6 +177,22 @@ type TCPSynOptions struct {
 	// SACKPermitted is true if the SACK option was provided in the SYN/SYN-ACK.
 	SACKPermitted bool
 
+	// FastOpen is true if the TCP Fast Open option was provided in the
+	// SYN/SYN-ACK.
+	FastOpen bool
+
+	// FastOpenCookie is the cookie carried by the TCP Fast Open option. It
+	// is empty if the option was a cookie request.
+	FastOpenCookie []byte
+
+	// MD5 is true if the segment carries a TCP MD5 signature option. When
+	// encoding, the option is emitted with a zeroed digest to be filled in
+	// once the segment is built.
+	MD5 bool
+
+	// MPTCP holds the encoded Multipath TCP option of the segment, if any.
+	MPTCP []byte
+
 	// Flags if specified are set on the outgoing SYN. The SYN flag is
 	// always set.
 	Flags TCPFlags
@@ -507,6 +542,31 @@ func ParseSynOptions(opts []byte, isAck bool) TCPSynOptions {
 			synOpts.SACKPermitted = true
 			i += 2
 
+		case TCPOptionMD5:
+			if i+TCPOptionMD5Length > limit || opts[i+1] != TCPOptionMD5Length {
+				return synOpts
+			}
+			synOpts.MD5 = true
+			i += TCPOptionMD5Length
+
+		case TCPOptionFastOpen:
+			if i+2 > limit {
+				return synOpts
+			}
+			l := int(opts[i+1])
+			if l < 2 || i+l > limit {
+				return synOpts
+			}
+			// Per RFC 7413, section 4.1.1, cookies shorter or longer than
+			// allowed, or of odd length, are ignored.
+			cookieLen := l - 2
+			if cookieLen == 0 || (cookieLen >= TCPFastOpenMinCookieLength && cookieLen <= TCPFastOpenMaxCookieLength && cookieLen%2 == 0) {
+				synOpts.FastOpen = true
+				synOpts.FastOpenCookie = make([]byte, cookieLen)
+				copy(synOpts.FastOpenCookie, opts[i+2:i+l])
+			}
+			i += l
+
 		default:
 			// We don't recognize this option, just skip over it.
 			if i+2 > limit {
@@ -633,6 +693,61 @@ func EncodeSACKPermittedOption(b []byte) int {
 	return int(b[1])
 }
 
+// EncodeMD5Option encodes a TCP MD5 signature option with a zeroed digest into
+// the provided buffer. If the buffer is smaller than required it just returns
+// without encoding anything. It returns the number of bytes written to the
+// provided buffer.
+func EncodeMD5Option(b []byte) int {
+	if len(b) < TCPOptionMD5Length {
+		return 0
+	}
+	b[0], b[1] = TCPOptionMD5, TCPOptionMD5Length
+	clear(b[2:TCPOptionMD5Length])
+	return TCPOptionMD5Length
+}
+
+// TCPMD5Option returns the offset of the TCP MD5 signature option within the
+// provided options and whether the option was found. A malformed option list
+// is treated as not carrying the option.
+func TCPMD5Option(opts []byte) (int, bool) {
+	limit := len(opts)
+	for i := 0; i < limit; {
+		switch opts[i] {
+		case TCPOptionEOL:
+			return 0, false
+		case TCPOptionNOP:
+			i++
+		default:
+			if i+2 > limit {
+				return 0, false
+			}
+			l := int(opts[i+1])
+			if l < 2 || i+l > limit {
+				return 0, false
+			}
+			if opts[i] == TCPOptionMD5 {
+				return i, l == TCPOptionMD5Length
+			}
+			i += l
+		}
+	}
+	return 0, false
+}
+
+// EncodeFastOpenOption encodes a TCP Fast Open option carrying cookie into the
+// provided buffer. An empty cookie encodes a cookie request. If the buffer is
+// smaller than required it just returns without encoding anything. It returns
+// the number of bytes written to the provided buffer.
+func EncodeFastOpenOption(cookie []byte, b []byte) int {
+	l := 2 + len(cookie)
+	if len(b) < l {
+		return 0
+	}
+	b[0], b[1] = TCPOptionFastOpen, byte(l)
+	copy(b[2:], cookie)
+	return l
+}
+
 // EncodeSACKBlocks encodes the provided SACK blocks as a TCP SACK option block
 // in the provided slice. It tries to fit in as many blocks as possible based on
 // number of bytes available in the provided buffer. It returns the number of
@@ -148,6 +148,43 @@ func TestTCPParseOptions(t *testing.T) {
 	}
 }
 
+func TestParseSynOptionsFastOpen(t *testing.T) {
+	encode := func(cookie []byte) []byte {
+		b := make([]byte, 2+len(cookie))
+		if n := header.EncodeFastOpenOption(cookie, b); n != len(b) {
+			t.Fatalf("EncodeFastOpenOption(%v, _) = %d, want %d", cookie, n, len(b))
+		}
+		return b
+	}
+
+	testCases := []struct {
+		name       string
+		b          []byte
+		wantFO     bool
+		wantCookie []byte
+	}{
+		{"no option", nil, false, nil},
+		{"cookie request", encode(nil), true, []byte{}},
+		{"min cookie", encode([]byte{1, 2, 3, 4}), true, []byte{1, 2, 3, 4}},
+		{"max cookie", encode(make([]byte, 16)), true, make([]byte, 16)},
+		{"short cookie", encode([]byte{1, 2}), false, nil},
+		{"odd cookie", encode([]byte{1, 2, 3, 4, 5}), false, nil},
+		{"long cookie", encode(make([]byte, 18)), false, nil},
+		{"truncated", []byte{header.TCPOptionFastOpen, 10, 1, 2}, false, nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := header.ParseSynOptions(tc.b, false /* isAck */)
+			if got.FastOpen != tc.wantFO {
+				t.Errorf("got FastOpen = %t, want %t", got.FastOpen, tc.wantFO)
+			}
+			if !reflect.DeepEqual(got.FastOpenCookie, tc.wantCookie) {
+				t.Errorf("got FastOpenCookie = %v, want %v", got.FastOpenCookie, tc.wantCookie)
+			}
+		})
+	}
+}
+
 func TestTCPFlags(t *testing.T) {
 	for _, tt := range []struct {
 		flags header.TCPFlags
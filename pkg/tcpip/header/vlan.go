@@ -0,0 +1,91 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import (
+	"encoding/binary"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+const (
+	vlanTCI  = 0
+	vlanType = 2
+)
+
+const (
+	// VLANHeaderSize is the size of the part of an 802.1Q tag that follows
+	// the EtherType field of the Ethernet header.
+	VLANHeaderSize = 4
+
+	// EthernetProtocol8021Q is the EtherType of frames with an 802.1Q tag.
+	EthernetProtocol8021Q tcpip.NetworkProtocolNumber = 0x8100
+
+	// EthernetProtocol8021AD is the EtherType of frames with an 802.1ad
+	// service tag.
+	EthernetProtocol8021AD tcpip.NetworkProtocolNumber = 0x88a8
+
+	// VLANMaxVID is the largest VLAN identifier that can be assigned. The
+	// value 4095 is reserved.
+	VLANMaxVID = 4094
+
+	vlanVIDMask       = 0x0fff
+	vlanPriorityShift = 13
+)
+
+// VLANFields contains the fields of an 802.1Q tag. It is used to describe the
+// fields of a tag that needs to be encoded.
+type VLANFields struct {
+	// Priority is the "priority code point" field of the tag.
+	Priority uint8
+
+	// VID is the "VLAN identifier" field of the tag.
+	VID uint16
+
+	// Type is the EtherType of the payload of the frame.
+	Type tcpip.NetworkProtocolNumber
+}
+
+// VLAN represents the part of an 802.1Q tag that follows the EtherType field
+// of the Ethernet header, which is the tag control information followed by the
+// EtherType of the payload.
+type VLAN []byte
+
+// TCI returns the "tag control information" field of the tag.
+func (b VLAN) TCI() uint16 {
+	return binary.BigEndian.Uint16(b[vlanTCI:])
+}
+
+// VID returns the "VLAN identifier" field of the tag.
+func (b VLAN) VID() uint16 {
+	return b.TCI() & vlanVIDMask
+}
+
+// Priority returns the "priority code point" field of the tag.
+func (b VLAN) Priority() uint8 {
+	return uint8(b.TCI() >> vlanPriorityShift)
+}
+
+// Type returns the EtherType of the payload of the frame.
+func (b VLAN) Type() tcpip.NetworkProtocolNumber {
+	return tcpip.NetworkProtocolNumber(binary.BigEndian.Uint16(b[vlanType:]))
+}
+
+// Encode encodes all the fields of the tag.
+func (b VLAN) Encode(f *VLANFields) {
+	tci := uint16(f.Priority)<<vlanPriorityShift | f.VID&vlanVIDMask
+	binary.BigEndian.PutUint16(b[vlanTCI:], tci)
+	binary.BigEndian.PutUint16(b[vlanType:], uint16(f.Type))
+}
@@ -0,0 +1,53 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"bytes"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestVLAN(t *testing.T) {
+	fields := header.VLANFields{
+		Priority: 5,
+		VID:      100,
+		Type:     header.IPv4ProtocolNumber,
+	}
+	b := header.VLAN(make([]byte, header.VLANHeaderSize))
+	b.Encode(&fields)
+	if want := []byte{0xa0, 0x64, 0x08, 0x00}; !bytes.Equal(b, want) {
+		t.Fatalf("got encoded tag %x, want %x", []byte(b), want)
+	}
+	if got := b.VID(); got != fields.VID {
+		t.Errorf("got b.VID() = %d, want = %d", got, fields.VID)
+	}
+	if got := b.Priority(); got != fields.Priority {
+		t.Errorf("got b.Priority() = %d, want = %d", got, fields.Priority)
+	}
+	if got := b.Type(); got != fields.Type {
+		t.Errorf("got b.Type() = %#x, want = %#x", got, fields.Type)
+	}
+
+	// Identifiers that don't fit in 12 bits are truncated.
+	b.Encode(&header.VLANFields{VID: 0x1001, Type: header.IPv6ProtocolNumber})
+	if got, want := b.VID(), uint16(1); got != want {
+		t.Errorf("got b.VID() = %d, want = %d", got, want)
+	}
+	if got := b.Priority(); got != 0 {
+		t.Errorf("got b.Priority() = %d, want = 0", got)
+	}
+}
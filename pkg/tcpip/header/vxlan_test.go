@@ -0,0 +1,87 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"bytes"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestVXLAN(t *testing.T) {
+	b := header.VXLAN(bytes.Repeat([]byte{0xff}, header.VXLANHeaderSize))
+	b.Encode(0x123456)
+	if want := []byte{0x08, 0, 0, 0, 0x12, 0x34, 0x56, 0}; !bytes.Equal(b, want) {
+		t.Errorf("got encoded header %x, want %x", []byte(b), want)
+	}
+	if !b.IsValid() {
+		t.Errorf("got b.IsValid() = false, want = true")
+	}
+	if got, want := b.VNI(), uint32(0x123456); got != want {
+		t.Errorf("got b.VNI() = %#x, want = %#x", got, want)
+	}
+
+	b[0] = 0
+	if b.IsValid() {
+		t.Errorf("got b.IsValid() = true without the I flag, want = false")
+	}
+	if header.VXLAN(b[:4]).IsValid() {
+		t.Errorf("got IsValid() = true for a truncated header, want = false")
+	}
+}
+
+func TestGeneve(t *testing.T) {
+	b := header.Geneve(make([]byte, header.GeneveMinimumSize+4))
+	b.Encode(&header.GeneveFields{
+		Flags:    header.GeneveFlagOAM,
+		Protocol: header.GeneveProtocolTransparentEthernet,
+		VNI:      0xabcdef,
+	})
+	if !b.IsValid() {
+		t.Errorf("got b.IsValid() = false, want = true")
+	}
+	if got, want := b.HeaderLength(), header.GeneveMinimumSize; got != want {
+		t.Errorf("got b.HeaderLength() = %d, want = %d", got, want)
+	}
+	if got, want := b.Flags(), uint8(header.GeneveFlagOAM); got != want {
+		t.Errorf("got b.Flags() = %#x, want = %#x", got, want)
+	}
+	if got, want := b.Protocol(), uint16(header.GeneveProtocolTransparentEthernet); got != want {
+		t.Errorf("got b.Protocol() = %#x, want = %#x", got, want)
+	}
+	if got, want := b.VNI(), uint32(0xabcdef); got != want {
+		t.Errorf("got b.VNI() = %#x, want = %#x", got, want)
+	}
+
+	// One word of options.
+	b[0] = 1
+	if got, want := b.HeaderLength(), header.GeneveMinimumSize+4; got != want {
+		t.Errorf("got b.HeaderLength() = %d, want = %d", got, want)
+	}
+	if !b.IsValid() {
+		t.Errorf("got b.IsValid() = false with options, want = true")
+	}
+	// Options that don't fit.
+	b[0] = 2
+	if b.IsValid() {
+		t.Errorf("got b.IsValid() = true with truncated options, want = false")
+	}
+	// Unknown version.
+	b[0] = 1 << 6
+	if b.IsValid() {
+		t.Errorf("got b.IsValid() = true with version 1, want = false")
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package linktest provides helpers for tests of link endpoints, which set up
+// stacks connected by the endpoints under test.
+package linktest
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/network/arp"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/udp"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+// Port is the UDP port that SendDatagrams sends datagrams to.
+const Port = 9000
+
+// NewStack returns a stack with the network protocols netProtos, or ARP and
+// IPv4 if none is given, and the UDP transport protocol. The stack is
+// destroyed when the test completes.
+func NewStack(t *testing.T, netProtos ...stack.NetworkProtocolFactory) *stack.Stack {
+	t.Helper()
+	if len(netProtos) == 0 {
+		netProtos = []stack.NetworkProtocolFactory{arp.NewProtocol, ipv4.NewProtocol}
+	}
+	s := stack.New(stack.Options{
+		NetworkProtocols:   netProtos,
+		TransportProtocols: []stack.TransportProtocolFactory{udp.NewProtocol},
+	})
+	t.Cleanup(func() {
+		s.Close()
+		s.Wait()
+		s.Destroy()
+	})
+	return s
+}
+
+// AddAddress adds addr to the NIC nicID of s, along with a route to its
+// subnet.
+func AddAddress(t *testing.T, s *stack.Stack, nicID tcpip.NICID, addr tcpip.AddressWithPrefix) {
+	t.Helper()
+	proto := header.IPv4ProtocolNumber
+	if addr.Address.Len() == header.IPv6AddressSize {
+		proto = header.IPv6ProtocolNumber
+	}
+	protocolAddr := tcpip.ProtocolAddress{Protocol: proto, AddressWithPrefix: addr}
+	if err := s.AddProtocolAddress(nicID, protocolAddr, stack.AddressProperties{}); err != nil {
+		t.Fatalf("AddProtocolAddress(%d, %+v, {}): %s", nicID, protocolAddr, err)
+	}
+	s.AddRoute(tcpip.Route{Destination: addr.Subnet(), NIC: nicID})
+}
+
+// NewHost returns a stack from NewStack with a NIC nicID on ep, which has the
+// addresses addrs.
+func NewHost(t *testing.T, nicID tcpip.NICID, ep stack.LinkEndpoint, addrs ...tcpip.AddressWithPrefix) *stack.Stack {
+	t.Helper()
+	s := NewStack(t)
+	if err := s.CreateNIC(nicID, ep); err != nil {
+		t.Fatalf("CreateNIC(%d, _): %s", nicID, err)
+	}
+	for _, addr := range addrs {
+		AddAddress(t, s, nicID, addr)
+	}
+	return s
+}
+
+// SendDatagrams sends UDP datagrams from s1 to addr on s2 until one is
+// received or the timeout expires, and returns true if one was received. The
+// first datagrams may be dropped while link addresses are resolved.
+func SendDatagrams(t *testing.T, s1, s2 *stack.Stack, addr tcpip.Address, timeout time.Duration) bool {
+	t.Helper()
+	netProto := header.IPv4ProtocolNumber
+	if addr.Len() == header.IPv6AddressSize {
+		netProto = header.IPv6ProtocolNumber
+	}
+	var wq waiter.Queue
+	rcv, err := s2.NewEndpoint(udp.ProtocolNumber, netProto, &wq)
+	if err != nil {
+		t.Fatalf("NewEndpoint: %s", err)
+	}
+	defer rcv.Close()
+	if err := rcv.Bind(tcpip.FullAddress{Port: Port}); err != nil {
+		t.Fatalf("Bind: %s", err)
+	}
+	we, ch := waiter.NewChannelEntry(waiter.ReadableEvents)
+	wq.EventRegister(&we)
+	defer wq.EventUnregister(&we)
+
+	var sndWQ waiter.Queue
+	snd, err := s1.NewEndpoint(udp.ProtocolNumber, netProto, &sndWQ)
+	if err != nil {
+		t.Fatalf("NewEndpoint: %s", err)
+	}
+	defer snd.Close()
+	data := []byte("datagram")
+	to := tcpip.FullAddress{Addr: addr, Port: Port}
+	deadline := time.Now().Add(timeout)
+	for time.Now().Before(deadline) {
+		var r bytes.Reader
+		r.Reset(data)
+		switch _, err := snd.Write(&r, tcpip.WriteOptions{To: &to}); err.(type) {
+		case nil, *tcpip.ErrWouldBlock:
+		default:
+			t.Fatalf("Write: %s", err)
+		}
+		select {
+		case <-ch:
+		case <-time.After(100 * time.Millisecond):
+		}
+		var buf bytes.Buffer
+		if _, err := rcv.Read(&buf, tcpip.ReadOptions{}); err == nil {
+			if !bytes.Equal(buf.Bytes(), data) {
+				t.Errorf("got %q, want %q", buf.Bytes(), data)
+			}
+			return true
+		}
+	}
+	return false
+}
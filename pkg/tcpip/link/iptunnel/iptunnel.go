@@ -0,0 +1,483 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package iptunnel provides point-to-point link endpoints that encapsulate IP
+// packets in IPv4, such as IP-in-IP, SIT and GRE devices.
+//
+// Encapsulated packets are routed by the stack that the tunnel is created
+// in, which also hands the tunnel the packets of its protocol.
+package iptunnel
+
+import (
+	"gvisor.dev/gvisor/pkg/atomicbitops"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/checksum"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+// Kind is the encapsulation used by a tunnel endpoint.
+type Kind int
+
+const (
+	// IPIP encapsulates IPv4 packets in IPv4, as described in RFC 2003.
+	IPIP Kind = iota
+
+	// SIT encapsulates IPv6 packets in IPv4, as described in RFC 4213.
+	SIT
+
+	// GRE encapsulates IPv4 and IPv6 packets in GRE over IPv4, as
+	// described in RFC 2784 and RFC 2890.
+	GRE
+)
+
+const (
+	// IPIPProtocolNumber is the IP protocol number of IP-in-IP packets.
+	IPIPProtocolNumber tcpip.TransportProtocolNumber = 4
+
+	// SITProtocolNumber is the IP protocol number of IPv6 packets
+	// encapsulated in IPv4.
+	SITProtocolNumber tcpip.TransportProtocolNumber = 41
+)
+
+// String implements fmt.Stringer.
+func (k Kind) String() string {
+	switch k {
+	case IPIP:
+		return "ipip"
+	case SIT:
+		return "sit"
+	case GRE:
+		return "gre"
+	default:
+		return "unknown"
+	}
+}
+
+// Protocol returns the IP protocol number of the encapsulated packets.
+func (k Kind) Protocol() tcpip.TransportProtocolNumber {
+	switch k {
+	case SIT:
+		return SITProtocolNumber
+	case GRE:
+		return header.GREProtocolNumber
+	default:
+		return IPIPProtocolNumber
+	}
+}
+
+// underlayMTU is the MTU of the underlay that is assumed when no MTU is
+// configured.
+const underlayMTU = 1500
+
+// Options holds the configuration of a tunnel endpoint.
+type Options struct {
+	// Kind is the encapsulation of the tunnel.
+	Kind Kind
+
+	// Local is the IPv4 source address of encapsulated packets. If
+	// unspecified, it is picked by the stack, and packets are received for
+	// any local address.
+	Local tcpip.Address
+
+	// Remote is the IPv4 address of the remote end of the tunnel. If
+	// unspecified, packets are received from any address, but none can be
+	// sent.
+	Remote tcpip.Address
+
+	// NIC is the underlay NIC used by the tunnel. If zero, packets are
+	// routed through any NIC.
+	NIC tcpip.NICID
+
+	// TTL is the TTL of encapsulated packets. If zero, the TTL of the
+	// tunneled packet is used.
+	TTL uint8
+
+	// TOS is the TOS of encapsulated packets. If its lowest bit is set, the
+	// TOS of the tunneled packet is used.
+	TOS uint8
+
+	// IFlags holds the GRE flags that received packets must have. Only
+	// header.GREFlagChecksum, header.GREFlagKey and header.GREFlagSequence
+	// are meaningful.
+	IFlags uint16
+
+	// OFlags holds the GRE flags of sent packets.
+	OFlags uint16
+
+	// IKey is the GRE key of received packets if IFlags has
+	// header.GREFlagKey.
+	IKey uint32
+
+	// OKey is the GRE key of sent packets if OFlags has header.GREFlagKey.
+	OKey uint32
+
+	// MTU is the MTU of the tunnel. If zero, it is computed from an
+	// Ethernet MTU and the encapsulation overhead.
+	MTU uint32
+}
+
+// headerSize returns the size of the tunneling protocol header of sent
+// packets.
+func (o *Options) headerSize() int {
+	if o.Kind == GRE {
+		return header.GREHeaderSize(o.OFlags)
+	}
+	return 0
+}
+
+var _ stack.LinkEndpoint = (*Endpoint)(nil)
+var _ stack.TunnelEndpoint = (*Endpoint)(nil)
+
+// Endpoint is a point-to-point link endpoint that encapsulates IP packets in
+// IPv4.
+//
+// +stateify savable
+type Endpoint struct {
+	stack *stack.Stack
+	opts  Options
+
+	// oseq is the sequence number of the next sent GRE packet.
+	oseq atomicbitops.Uint32
+
+	mu endpointRWMutex `state:"nosave"`
+	// +checklocks:mu
+	dispatcher stack.NetworkDispatcher
+	// +checklocks:mu
+	mtu uint32
+	// +checklocks:mu
+	closed bool
+	// +checklocks:mu
+	onCloseAction func() `state:"nosave"`
+}
+
+// New creates a tunnel endpoint and registers it with s to receive the
+// packets of its tunneling protocol.
+func New(s *stack.Stack, opts Options) (*Endpoint, tcpip.Error) {
+	for _, addr := range []tcpip.Address{opts.Local, opts.Remote} {
+		if addr.Len() != 0 && addr.Len() != header.IPv4AddressSize {
+			return nil, &tcpip.ErrBadAddress{}
+		}
+	}
+	if opts.Kind != GRE {
+		opts.IFlags, opts.OFlags = 0, 0
+	}
+	if opts.MTU == 0 {
+		opts.MTU = underlayMTU - header.IPv4MinimumSize - uint32(opts.headerSize())
+	}
+	e := &Endpoint{
+		stack: s,
+		opts:  opts,
+		mtu:   opts.MTU,
+	}
+	if err := s.RegisterTunnelEndpoint(header.IPv4ProtocolNumber, opts.Kind.Protocol(), e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// HandleTunnelPacket implements stack.TunnelEndpoint.HandleTunnelPacket.
+func (e *Endpoint) HandleTunnelPacket(pkt *stack.PacketBuffer) bool {
+	ipHdr := header.IPv4(pkt.NetworkHeader().Slice())
+	if e.opts.Remote.Len() != 0 && ipHdr.SourceAddress() != e.opts.Remote {
+		return false
+	}
+	if e.opts.Local.Len() != 0 && ipHdr.DestinationAddress() != e.opts.Local {
+		return false
+	}
+	if e.opts.NIC != 0 && pkt.NICID != e.opts.NIC {
+		return false
+	}
+
+	var proto tcpip.NetworkProtocolNumber
+	hdrLen := 0
+	switch e.opts.Kind {
+	case IPIP:
+		proto = header.IPv4ProtocolNumber
+	case SIT:
+		proto = header.IPv6ProtocolNumber
+	case GRE:
+		gre, ok := pullUpGRE(pkt)
+		if !ok {
+			// Drop malformed packets.
+			return true
+		}
+		// Packets with a key only belong to the tunnel with that key.
+		if !e.greKeyMatches(gre) {
+			return false
+		}
+		if !e.greValid(gre, pkt) {
+			return true
+		}
+		proto = gre.Protocol()
+		hdrLen = gre.HeaderLength()
+	}
+
+	e.mu.RLock()
+	d := e.dispatcher
+	e.mu.RUnlock()
+	if d == nil || pkt.Data().Size() <= hdrLen {
+		return true
+	}
+	payload := pkt.Data().ToBuffer()
+	payload.TrimFront(int64(hdrLen))
+	newPkt := stack.NewPacketBuffer(stack.PacketBufferOptions{
+		Payload: payload,
+	})
+	d.DeliverNetworkPacket(proto, newPkt)
+	newPkt.DecRef()
+	return true
+}
+
+// pullUpGRE returns the GRE header at the start of the data of pkt.
+func pullUpGRE(pkt *stack.PacketBuffer) (header.GRE, bool) {
+	v, ok := pkt.Data().PullUp(header.GREMinimumSize)
+	if !ok {
+		return nil, false
+	}
+	gre := header.GRE(v)
+	if v, ok = pkt.Data().PullUp(gre.HeaderLength()); !ok {
+		return nil, false
+	}
+	gre = header.GRE(v)
+	return gre, gre.IsValid()
+}
+
+// greKeyMatches returns true if the key of the GRE header matches the input
+// key of the tunnel.
+func (e *Endpoint) greKeyMatches(gre header.GRE) bool {
+	if gre.Flags()&header.GREFlagKey != e.opts.IFlags&header.GREFlagKey {
+		return false
+	}
+	return gre.Flags()&header.GREFlagKey == 0 || gre.Key() == e.opts.IKey
+}
+
+// greValid returns true if the GRE packet pkt has the options required by the
+// tunnel, a valid checksum and a supported payload.
+func (e *Endpoint) greValid(gre header.GRE, pkt *stack.PacketBuffer) bool {
+	flags := gre.Flags()
+	if flags&header.GREFlagChecksum != 0 {
+		if pkt.Data().Checksum() != 0xffff {
+			return false
+		}
+	} else if e.opts.IFlags&header.GREFlagChecksum != 0 {
+		return false
+	}
+	if flags&header.GREFlagSequence == 0 && e.opts.IFlags&header.GREFlagSequence != 0 {
+		return false
+	}
+	proto := gre.Protocol()
+	return proto == header.IPv4ProtocolNumber || proto == header.IPv6ProtocolNumber
+}
+
+// isOwnPacket returns true if pkt is an encapsulated packet of the tunnel,
+// which happens when the route to the remote end goes through the tunnel.
+func (e *Endpoint) isOwnPacket(pkt *stack.PacketBuffer) bool {
+	if pkt.NetworkProtocolNumber != header.IPv4ProtocolNumber {
+		return false
+	}
+	ipHdr := header.IPv4(pkt.NetworkHeader().Slice())
+	return ipHdr.TransportProtocol() == e.opts.Kind.Protocol() && ipHdr.DestinationAddress() == e.opts.Remote
+}
+
+// outerTTLAndTOS returns the TTL and TOS of the packet that encapsulates pkt.
+func (e *Endpoint) outerTTLAndTOS(pkt *stack.PacketBuffer, r *stack.Route) (uint8, uint8) {
+	ttl, tos := e.opts.TTL, e.opts.TOS
+	inheritTOS := tos&1 != 0
+	if inheritTOS {
+		tos = 0
+	}
+	nh := pkt.NetworkHeader().Slice()
+	switch pkt.NetworkProtocolNumber {
+	case header.IPv4ProtocolNumber:
+		if len(nh) >= header.IPv4MinimumSize {
+			if ttl == 0 {
+				ttl = header.IPv4(nh).TTL()
+			}
+			if inheritTOS {
+				tos, _ = header.IPv4(nh).TOS()
+			}
+		}
+	case header.IPv6ProtocolNumber:
+		if len(nh) >= header.IPv6MinimumSize {
+			if ttl == 0 {
+				ttl = header.IPv6(nh).HopLimit()
+			}
+			if inheritTOS {
+				tos, _ = header.IPv6(nh).TOS()
+			}
+		}
+	}
+	if ttl == 0 {
+		ttl = r.DefaultTTL()
+	}
+	return ttl, tos
+}
+
+// WritePackets implements stack.LinkEndpoint.WritePackets.
+func (e *Endpoint) WritePackets(pkts stack.PacketBufferList) (int, tcpip.Error) {
+	if e.opts.Remote.Len() == 0 {
+		return 0, &tcpip.ErrNetworkUnreachable{}
+	}
+	n := 0
+	for _, pkt := range pkts.AsSlice() {
+		switch {
+		case e.isOwnPacket(pkt):
+			// Like Linux, drop packets that would recurse into
+			// the tunnel.
+			n++
+			continue
+		case e.opts.Kind == IPIP && pkt.NetworkProtocolNumber != header.IPv4ProtocolNumber,
+			e.opts.Kind == SIT && pkt.NetworkProtocolNumber != header.IPv6ProtocolNumber:
+			// The tunnel can't carry the packet.
+			n++
+			continue
+		}
+		if err := e.writePacket(pkt); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+func (e *Endpoint) writePacket(pkt *stack.PacketBuffer) tcpip.Error {
+	r, err := e.stack.FindRoute(e.opts.NIC, e.opts.Local, e.opts.Remote, header.IPv4ProtocolNumber, false /* multicastLoop */)
+	if err != nil {
+		return err
+	}
+	defer r.Release()
+
+	hdrSize := e.opts.headerSize()
+	outer := stack.NewPacketBuffer(stack.PacketBufferOptions{
+		ReserveHeaderBytes: int(r.MaxHeaderLength()) + hdrSize,
+		Payload:            pkt.ToBuffer(),
+	})
+	defer outer.DecRef()
+	if e.opts.Kind == GRE {
+		gre := header.GRE(outer.TransportHeader().Push(hdrSize))
+		fields := header.GREFields{
+			Flags:    e.opts.OFlags,
+			Protocol: pkt.NetworkProtocolNumber,
+			Key:      e.opts.OKey,
+		}
+		if e.opts.OFlags&header.GREFlagSequence != 0 {
+			fields.Sequence = e.oseq.Add(1) - 1
+		}
+		gre.Encode(&fields)
+		if e.opts.OFlags&header.GREFlagChecksum != 0 {
+			gre.SetChecksum(^checksum.Combine(checksum.Checksum(gre, 0), outer.Data().Checksum()))
+		}
+	}
+	proto := e.opts.Kind.Protocol()
+	outer.TransportProtocolNumber = proto
+	ttl, tos := e.outerTTLAndTOS(pkt, r)
+	return r.WritePacket(stack.NetworkHeaderParams{Protocol: proto, TTL: ttl, TOS: tos}, outer)
+}
+
+// Close implements stack.LinkEndpoint.Close.
+func (e *Endpoint) Close() {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return
+	}
+	e.closed = true
+	action := e.onCloseAction
+	e.onCloseAction = nil
+	e.mu.Unlock()
+
+	e.stack.UnregisterTunnelEndpoint(header.IPv4ProtocolNumber, e.opts.Kind.Protocol(), e)
+	if action != nil {
+		action()
+	}
+}
+
+// Wait implements stack.LinkEndpoint.Wait.
+func (*Endpoint) Wait() {}
+
+// Attach implements stack.LinkEndpoint.Attach.
+func (e *Endpoint) Attach(dispatcher stack.NetworkDispatcher) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.dispatcher = dispatcher
+}
+
+// IsAttached implements stack.LinkEndpoint.IsAttached.
+func (e *Endpoint) IsAttached() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.dispatcher != nil
+}
+
+// MTU implements stack.LinkEndpoint.MTU.
+func (e *Endpoint) MTU() uint32 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.mtu
+}
+
+// SetMTU implements stack.LinkEndpoint.SetMTU.
+func (e *Endpoint) SetMTU(mtu uint32) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.mtu = mtu
+}
+
+// Capabilities implements stack.LinkEndpoint.Capabilities. Tunneled packets
+// come from the network, so their checksums aren't verified by the tunnel.
+func (*Endpoint) Capabilities() stack.LinkEndpointCapabilities {
+	return stack.CapabilitySaveRestore
+}
+
+// MaxHeaderLength implements stack.LinkEndpoint.MaxHeaderLength. Tunneled
+// packets are copied into new packets, so no space is reserved for the
+// encapsulation.
+func (*Endpoint) MaxHeaderLength() uint16 {
+	return 0
+}
+
+// LinkAddress implements stack.LinkEndpoint.LinkAddress. Tunnels have no link
+// address.
+func (*Endpoint) LinkAddress() tcpip.LinkAddress {
+	return ""
+}
+
+// SetLinkAddress implements stack.LinkEndpoint.SetLinkAddress.
+func (*Endpoint) SetLinkAddress(tcpip.LinkAddress) {}
+
+// ARPHardwareType implements stack.LinkEndpoint.ARPHardwareType.
+func (e *Endpoint) ARPHardwareType() header.ARPHardwareType {
+	switch e.opts.Kind {
+	case SIT:
+		return header.ARPHardwareSIT
+	case GRE:
+		return header.ARPHardwareIPGRE
+	default:
+		return header.ARPHardwareTunnel
+	}
+}
+
+// AddHeader implements stack.LinkEndpoint.AddHeader.
+func (*Endpoint) AddHeader(*stack.PacketBuffer) {}
+
+// ParseHeader implements stack.LinkEndpoint.ParseHeader.
+func (*Endpoint) ParseHeader(*stack.PacketBuffer) bool { return true }
+
+// SetOnCloseAction implements stack.LinkEndpoint.SetOnCloseAction.
+func (e *Endpoint) SetOnCloseAction(action func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onCloseAction = action
+}
@@ -0,0 +1,177 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package iptunnel_test
+
+import (
+	"testing"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/internal/linktest"
+	"gvisor.dev/gvisor/pkg/tcpip/link/ethernet"
+	"gvisor.dev/gvisor/pkg/tcpip/link/iptunnel"
+	"gvisor.dev/gvisor/pkg/tcpip/link/veth"
+	"gvisor.dev/gvisor/pkg/tcpip/network/arp"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv6"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/testutil"
+)
+
+const (
+	underlayNICID = 1
+	tunnelNICID   = 2
+	testTimeout   = 5 * time.Second
+)
+
+var (
+	underlay1 = testutil.MustParse4("10.0.0.1")
+	underlay2 = testutil.MustParse4("10.0.0.2")
+)
+
+// newTunnelHost creates a stack with an underlay NIC and a tunnel NIC that
+// has the address inner.
+func newTunnelHost(t *testing.T, underlayEP stack.LinkEndpoint, opts iptunnel.Options, inner tcpip.AddressWithPrefix) (*stack.Stack, *iptunnel.Endpoint) {
+	t.Helper()
+	s := linktest.NewStack(t, arp.NewProtocol, ipv4.NewProtocol, ipv6.NewProtocol)
+	if err := s.CreateNIC(underlayNICID, ethernet.New(underlayEP)); err != nil {
+		t.Fatalf("CreateNIC(%d, _): %s", underlayNICID, err)
+	}
+	linktest.AddAddress(t, s, underlayNICID, tcpip.AddressWithPrefix{Address: opts.Local, PrefixLen: 24})
+
+	tunnel, err := iptunnel.New(s, opts)
+	if err != nil {
+		t.Fatalf("iptunnel.New(_, %+v): %s", opts, err)
+	}
+	if err := s.CreateNIC(tunnelNICID, tunnel); err != nil {
+		t.Fatalf("CreateNIC(%d, _): %s", tunnelNICID, err)
+	}
+	linktest.AddAddress(t, s, tunnelNICID, inner)
+	return s, tunnel
+}
+
+func TestTunnel(t *testing.T) {
+	var (
+		inner4a = testutil.MustParse4("192.168.0.1")
+		inner4b = testutil.MustParse4("192.168.0.2")
+		inner6a = testutil.MustParse6("fd00::1")
+		inner6b = testutil.MustParse6("fd00::2")
+	)
+	for _, tc := range []struct {
+		name     string
+		opts     iptunnel.Options
+		netProto tcpip.NetworkProtocolNumber
+		a, b     tcpip.Address
+		mtu      uint32
+	}{
+		{
+			name:     "IPIP",
+			opts:     iptunnel.Options{Kind: iptunnel.IPIP},
+			netProto: ipv4.ProtocolNumber,
+			a:        inner4a,
+			b:        inner4b,
+			mtu:      1480,
+		},
+		{
+			name:     "SIT",
+			opts:     iptunnel.Options{Kind: iptunnel.SIT, TTL: 64},
+			netProto: ipv6.ProtocolNumber,
+			a:        inner6a,
+			b:        inner6b,
+			mtu:      1480,
+		},
+		{
+			name:     "GRE",
+			opts:     iptunnel.Options{Kind: iptunnel.GRE},
+			netProto: ipv4.ProtocolNumber,
+			a:        inner4a,
+			b:        inner4b,
+			mtu:      1476,
+		},
+		{
+			name: "GREv6WithOptions",
+			opts: iptunnel.Options{
+				Kind:   iptunnel.GRE,
+				IFlags: header.GREFlagKey | header.GREFlagChecksum,
+				OFlags: header.GREFlagKey | header.GREFlagChecksum | header.GREFlagSequence,
+				IKey:   7,
+				OKey:   7,
+			},
+			netProto: ipv6.ProtocolNumber,
+			a:        inner6a,
+			b:        inner6b,
+			mtu:      1464,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			prefixLen := 24
+			if tc.netProto == ipv6.ProtocolNumber {
+				prefixLen = 64
+			}
+			ep1, ep2 := veth.NewPair(1500, veth.DefaultBacklogSize)
+			opts1, opts2 := tc.opts, tc.opts
+			opts1.Local, opts1.Remote = underlay1, underlay2
+			opts2.Local, opts2.Remote = underlay2, underlay1
+			s1, tunnel := newTunnelHost(t, ep1, opts1, tcpip.AddressWithPrefix{Address: tc.a, PrefixLen: prefixLen})
+			s2, _ := newTunnelHost(t, ep2, opts2, tcpip.AddressWithPrefix{Address: tc.b, PrefixLen: prefixLen})
+
+			if got := tunnel.MTU(); got != tc.mtu {
+				t.Errorf("got MTU() = %d, want = %d", got, tc.mtu)
+			}
+			if !linktest.SendDatagrams(t, s1, s2, tc.b, testTimeout) {
+				t.Fatalf("timed out waiting for a datagram over the tunnel")
+			}
+			if !linktest.SendDatagrams(t, s2, s1, tc.a, testTimeout) {
+				t.Fatalf("timed out waiting for a datagram over the tunnel")
+			}
+		})
+	}
+}
+
+func TestGREKeyMismatch(t *testing.T) {
+	ep1, ep2 := veth.NewPair(1500, veth.DefaultBacklogSize)
+	inner1 := tcpip.AddressWithPrefix{Address: testutil.MustParse4("192.168.0.1"), PrefixLen: 24}
+	inner2 := tcpip.AddressWithPrefix{Address: testutil.MustParse4("192.168.0.2"), PrefixLen: 24}
+	s1, _ := newTunnelHost(t, ep1, iptunnel.Options{
+		Kind:   iptunnel.GRE,
+		Local:  underlay1,
+		Remote: underlay2,
+		OFlags: header.GREFlagKey,
+		OKey:   1,
+	}, inner1)
+	s2, _ := newTunnelHost(t, ep2, iptunnel.Options{
+		Kind:   iptunnel.GRE,
+		Local:  underlay2,
+		Remote: underlay1,
+		IFlags: header.GREFlagKey,
+		IKey:   2,
+	}, inner2)
+
+	if linktest.SendDatagrams(t, s1, s2, inner2.Address, 500*time.Millisecond) {
+		t.Errorf("got a datagram with a mismatched GRE key")
+	}
+	if got := s2.Stats().IP.PacketsDelivered.Value(); got == 0 {
+		t.Errorf("got no delivered underlay packets")
+	}
+}
+
+func TestInvalidAddress(t *testing.T) {
+	s := linktest.NewStack(t, arp.NewProtocol, ipv4.NewProtocol, ipv6.NewProtocol)
+	opts := iptunnel.Options{Kind: iptunnel.SIT, Remote: testutil.MustParse6("fd00::1")}
+	if _, err := iptunnel.New(s, opts); err == nil {
+		t.Errorf("iptunnel.New(_, %+v) succeeded, want error", opts)
+	}
+}
@@ -0,0 +1,321 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ipvlan provides ipvlan interfaces, which share the link address of
+// a parent NIC and are told apart by their IP addresses.
+//
+// In L2 mode, an ipvlan endpoint exchanges whole Ethernet frames, so it must be
+// wrapped by an ethernet endpoint. In L3 mode, it exchanges IP packets, which
+// are routed through the parent NIC by the stack of the parent NIC.
+//
+// Stacks don't notify link endpoints of the addresses of their NICs, so the
+// addresses of an interface must be added to its endpoint with AddAddress for
+// the packets destined to them to be received.
+package ipvlan
+
+import (
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+// Options holds the configuration of an ipvlan endpoint.
+type Options struct {
+	// Parent is the NIC that carries the packets of the endpoint.
+	Parent tcpip.NICID
+
+	// Mode is the mode of the endpoint, which must be the mode of the
+	// other ipvlan interfaces of the parent NIC.
+	Mode stack.IPVLANMode
+}
+
+var _ stack.LinkEndpoint = (*Endpoint)(nil)
+var _ stack.IPVLANEndpoint = (*Endpoint)(nil)
+
+// Endpoint is an ipvlan interface of a NIC.
+//
+// +stateify savable
+type Endpoint struct {
+	stack *stack.Stack
+	opts  Options
+
+	// linkAddr is the link address of the parent NIC.
+	linkAddr tcpip.LinkAddress
+
+	mu endpointRWMutex `state:"nosave"`
+	// +checklocks:mu
+	dispatcher stack.NetworkDispatcher
+	// +checklocks:mu
+	mtu uint32
+	// +checklocks:mu
+	closed bool
+	// +checklocks:mu
+	onCloseAction func() `state:"nosave"`
+}
+
+// New creates an ipvlan endpoint and registers it with the parent NIC. Like on
+// Linux, the endpoint inherits the link address and the MTU of its parent.
+func New(s *stack.Stack, opts Options) (*Endpoint, tcpip.Error) {
+	parent, ok := s.NICInfo()[opts.Parent]
+	if !ok {
+		return nil, &tcpip.ErrUnknownNICID{}
+	}
+	if parent.ARPHardwareType != header.ARPHardwareEther {
+		return nil, &tcpip.ErrNotSupported{}
+	}
+	e := &Endpoint{
+		stack:    s,
+		opts:     opts,
+		linkAddr: parent.LinkAddress,
+		mtu:      parent.MTU,
+	}
+	if err := s.RegisterIPVLANEndpoint(opts.Parent, opts.Mode, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Mode returns the mode of the endpoint.
+func (e *Endpoint) Mode() stack.IPVLANMode {
+	return e.opts.Mode
+}
+
+// Parent returns the NIC that carries the packets of the endpoint.
+func (e *Endpoint) Parent() tcpip.NICID {
+	return e.opts.Parent
+}
+
+// AddAddress adds addr to the addresses that the endpoint receives packets
+// for. It fails if addr is an address of another ipvlan interface of the
+// parent NIC.
+func (e *Endpoint) AddAddress(addr tcpip.Address) tcpip.Error {
+	return e.stack.AddIPVLANAddress(e.opts.Parent, e, addr)
+}
+
+// RemoveAddress removes addr from the addresses that the endpoint receives
+// packets for.
+func (e *Endpoint) RemoveAddress(addr tcpip.Address) {
+	e.stack.RemoveIPVLANAddress(e.opts.Parent, e, addr)
+}
+
+// HandleIPVLANPacket implements stack.IPVLANEndpoint.HandleIPVLANPacket.
+func (e *Endpoint) HandleIPVLANPacket(protocol tcpip.NetworkProtocolNumber, pkt *stack.PacketBuffer) {
+	e.mu.RLock()
+	d := e.dispatcher
+	e.mu.RUnlock()
+	if d == nil {
+		return
+	}
+	if e.opts.Mode == stack.IPVLANModeL3 {
+		newPkt := stack.NewPacketBuffer(stack.PacketBufferOptions{
+			Payload: pkt.Data().ToBuffer(),
+		})
+		newPkt.PktType = tcpip.PacketHost
+		d.DeliverNetworkPacket(protocol, newPkt)
+		newPkt.DecRef()
+		return
+	}
+	// The frame is parsed again by the ethernet endpoint of the interface.
+	newPkt := stack.NewPacketBuffer(stack.PacketBufferOptions{
+		Payload: stack.BufferSince(pkt.LinkHeader()),
+	})
+	d.DeliverNetworkPacket(0 /* protocol */, newPkt)
+	newPkt.DecRef()
+}
+
+// ParentRemoved implements stack.IPVLANEndpoint.ParentRemoved. Like on Linux,
+// the interface is removed with its parent.
+func (e *Endpoint) ParentRemoved() {
+	e.Close()
+}
+
+// WritePackets implements stack.LinkEndpoint.WritePackets. In L2 mode, the
+// packets hold Ethernet frames. Packets destined to the other ipvlan
+// interfaces of the parent NIC are handed to them, and the others are written
+// to the parent NIC in L2 mode, or routed through it in L3 mode.
+func (e *Endpoint) WritePackets(pkts stack.PacketBufferList) (int, tcpip.Error) {
+	n := 0
+	for _, pkt := range pkts.AsSlice() {
+		var err tcpip.Error
+		if e.opts.Mode == stack.IPVLANModeL3 {
+			err = e.writeL3(pkt.NetworkProtocolNumber, pkt.ToBuffer())
+		} else {
+			err = e.writeL2(pkt.ToBuffer())
+		}
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// writeL2 writes the Ethernet frame f.
+func (e *Endpoint) writeL2(f buffer.Buffer) tcpip.Error {
+	pkt := stack.NewPacketBuffer(stack.PacketBufferOptions{
+		Payload: f.Clone(),
+	})
+	defer pkt.DecRef()
+	hdr, ok := pkt.LinkHeader().Consume(header.EthernetMinimumSize)
+	if !ok {
+		f.Release()
+		return nil
+	}
+	protocol := header.Ethernet(hdr).Type()
+	if e.stack.ForwardIPVLANPacket(e.opts.Parent, e, protocol, pkt) {
+		f.Release()
+		return nil
+	}
+	return e.stack.WriteRawPacket(e.opts.Parent, protocol, f)
+}
+
+// writeL3 writes the IP packet p of the given protocol.
+func (e *Endpoint) writeL3(protocol tcpip.NetworkProtocolNumber, p buffer.Buffer) tcpip.Error {
+	pkt := stack.NewPacketBuffer(stack.PacketBufferOptions{
+		Payload: p,
+	})
+	defer pkt.DecRef()
+	if e.stack.ForwardIPVLANPacket(e.opts.Parent, e, protocol, pkt) {
+		return nil
+	}
+
+	var dst tcpip.Address
+	switch protocol {
+	case header.IPv4ProtocolNumber:
+		v, ok := pkt.Data().PullUp(header.IPv4MinimumSize)
+		if !ok {
+			return nil
+		}
+		dst = header.IPv4(v).DestinationAddress()
+		// Like on Linux, L3 mode interfaces don't send multicast and
+		// broadcast packets.
+		if dst == header.IPv4Broadcast || header.IsV4MulticastAddress(dst) {
+			return nil
+		}
+	case header.IPv6ProtocolNumber:
+		v, ok := pkt.Data().PullUp(header.IPv6MinimumSize)
+		if !ok {
+			return nil
+		}
+		dst = header.IPv6(v).DestinationAddress()
+		if header.IsV6MulticastAddress(dst) {
+			return nil
+		}
+	default:
+		return nil
+	}
+	r, err := e.stack.FindRoute(e.opts.Parent, tcpip.Address{}, dst, protocol, false /* multicastLoop */)
+	if err != nil {
+		return err
+	}
+	defer r.Release()
+	out := stack.NewPacketBuffer(stack.PacketBufferOptions{
+		ReserveHeaderBytes: int(r.MaxHeaderLength()),
+		Payload:            pkt.ToBuffer(),
+	})
+	defer out.DecRef()
+	return r.WriteHeaderIncludedPacket(out)
+}
+
+// Close implements stack.LinkEndpoint.Close.
+func (e *Endpoint) Close() {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return
+	}
+	e.closed = true
+	action := e.onCloseAction
+	e.onCloseAction = nil
+	e.mu.Unlock()
+
+	e.stack.UnregisterIPVLANEndpoint(e.opts.Parent, e)
+	if action != nil {
+		action()
+	}
+}
+
+// Wait implements stack.LinkEndpoint.Wait.
+func (*Endpoint) Wait() {}
+
+// Attach implements stack.LinkEndpoint.Attach.
+func (e *Endpoint) Attach(dispatcher stack.NetworkDispatcher) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if dispatcher == nil {
+		// The NIC of the interface is removed with its addresses, or
+		// moved to another stack without them.
+		e.stack.RemoveIPVLANAddresses(e.opts.Parent, e)
+	}
+	e.dispatcher = dispatcher
+}
+
+// IsAttached implements stack.LinkEndpoint.IsAttached.
+func (e *Endpoint) IsAttached() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.dispatcher != nil
+}
+
+// MTU implements stack.LinkEndpoint.MTU.
+func (e *Endpoint) MTU() uint32 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.mtu
+}
+
+// SetMTU implements stack.LinkEndpoint.SetMTU.
+func (e *Endpoint) SetMTU(mtu uint32) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.mtu = mtu
+}
+
+// Capabilities implements stack.LinkEndpoint.Capabilities.
+func (*Endpoint) Capabilities() stack.LinkEndpointCapabilities {
+	return stack.CapabilitySaveRestore
+}
+
+// MaxHeaderLength implements stack.LinkEndpoint.MaxHeaderLength.
+func (*Endpoint) MaxHeaderLength() uint16 {
+	return 0
+}
+
+// LinkAddress implements stack.LinkEndpoint.LinkAddress.
+func (e *Endpoint) LinkAddress() tcpip.LinkAddress {
+	return e.linkAddr
+}
+
+// SetLinkAddress implements stack.LinkEndpoint.SetLinkAddress. Like on Linux,
+// the link address of an ipvlan interface can't be changed.
+func (*Endpoint) SetLinkAddress(tcpip.LinkAddress) {}
+
+// ARPHardwareType implements stack.LinkEndpoint.ARPHardwareType.
+func (*Endpoint) ARPHardwareType() header.ARPHardwareType {
+	return header.ARPHardwareNone
+}
+
+// AddHeader implements stack.LinkEndpoint.AddHeader.
+func (*Endpoint) AddHeader(*stack.PacketBuffer) {}
+
+// ParseHeader implements stack.LinkEndpoint.ParseHeader.
+func (*Endpoint) ParseHeader(*stack.PacketBuffer) bool { return true }
+
+// SetOnCloseAction implements stack.LinkEndpoint.SetOnCloseAction.
+func (e *Endpoint) SetOnCloseAction(action func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onCloseAction = action
+}
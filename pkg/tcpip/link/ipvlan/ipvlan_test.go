@@ -0,0 +1,169 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipvlan_test
+
+import (
+	"testing"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/internal/linktest"
+	"gvisor.dev/gvisor/pkg/tcpip/link/ethernet"
+	"gvisor.dev/gvisor/pkg/tcpip/link/ipvlan"
+	"gvisor.dev/gvisor/pkg/tcpip/link/macvlan"
+	"gvisor.dev/gvisor/pkg/tcpip/link/veth"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/testutil"
+)
+
+const (
+	parentNICID = 1
+	nicID       = 2
+	testTimeout = 5 * time.Second
+)
+
+// newIPVLANHost creates an ipvlan interface of the parent NIC of parent in a
+// stack of its own, like in a network namespace, with the address addr.
+func newIPVLANHost(t *testing.T, parent *stack.Stack, mode stack.IPVLANMode, addr tcpip.Address) (*stack.Stack, *ipvlan.Endpoint) {
+	t.Helper()
+	ep, err := ipvlan.New(parent, ipvlan.Options{Parent: parentNICID, Mode: mode})
+	if err != nil {
+		t.Fatalf("ipvlan.New(_, {Parent: %d, Mode: %d}): %s", parentNICID, mode, err)
+	}
+	if err := ep.AddAddress(addr); err != nil {
+		t.Fatalf("AddAddress(%s): %s", addr, err)
+	}
+	var linkEP stack.LinkEndpoint = ep
+	if mode == stack.IPVLANModeL2 {
+		linkEP = ethernet.New(ep)
+	}
+	return linktest.NewHost(t, nicID, linkEP, tcpip.AddressWithPrefix{Address: addr, PrefixLen: 24}), ep
+}
+
+func TestIPVLANL2(t *testing.T) {
+	addr1 := testutil.MustParse4("192.168.100.1")
+	addr2 := testutil.MustParse4("192.168.100.2")
+	ep1, ep2 := veth.NewPair(1500, veth.DefaultBacklogSize)
+	parent := linktest.NewHost(t, parentNICID, ethernet.New(ep1))
+	s1, ipvlanEP := newIPVLANHost(t, parent, stack.IPVLANModeL2, addr1)
+	s2 := linktest.NewHost(t, nicID, ethernet.New(ep2), tcpip.AddressWithPrefix{Address: addr2, PrefixLen: 24})
+
+	if got, want := ipvlanEP.LinkAddress(), ep1.LinkAddress(); got != want {
+		t.Errorf("got LinkAddress() = %s, want = %s", got, want)
+	}
+	if !linktest.SendDatagrams(t, s1, s2, addr2, testTimeout) {
+		t.Fatalf("timed out waiting for a datagram from the ipvlan interface")
+	}
+	if !linktest.SendDatagrams(t, s2, s1, addr1, testTimeout) {
+		t.Fatalf("timed out waiting for a datagram to the ipvlan interface")
+	}
+}
+
+func TestIPVLANL3(t *testing.T) {
+	addr1 := testutil.MustParse4("192.168.100.1")
+	addr2 := testutil.MustParse4("192.168.100.2")
+	parentAddr := testutil.MustParse4("192.168.100.10")
+	ep1, ep2 := veth.NewPair(1500, veth.DefaultBacklogSize)
+	parent := linktest.NewHost(t, parentNICID, ethernet.New(ep1))
+	linktest.AddAddress(t, parent, parentNICID, tcpip.AddressWithPrefix{Address: parentAddr, PrefixLen: 24})
+	s1, _ := newIPVLANHost(t, parent, stack.IPVLANModeL3, addr1)
+	s2 := linktest.NewHost(t, nicID, ethernet.New(ep2), addr2.WithPrefix())
+	// L3 mode interfaces don't resolve neighbors, so the peer reaches them
+	// through the parent.
+	s2.SetRouteTable([]tcpip.Route{
+		{Destination: parentAddr.WithPrefix().Subnet(), NIC: nicID},
+		{Destination: header.IPv4EmptySubnet, Gateway: parentAddr, NIC: nicID},
+	})
+
+	if !linktest.SendDatagrams(t, s1, s2, addr2, testTimeout) {
+		t.Fatalf("timed out waiting for a datagram from the ipvlan interface")
+	}
+	if !linktest.SendDatagrams(t, s2, s1, addr1, testTimeout) {
+		t.Fatalf("timed out waiting for a datagram to the ipvlan interface")
+	}
+}
+
+func TestIPVLANSiblings(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		mode stack.IPVLANMode
+	}{
+		{name: "l2", mode: stack.IPVLANModeL2},
+		{name: "l3", mode: stack.IPVLANModeL3},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			addr1 := testutil.MustParse4("192.168.100.1")
+			addr2 := testutil.MustParse4("192.168.100.2")
+			ep1, _ := veth.NewPair(1500, veth.DefaultBacklogSize)
+			parent := linktest.NewHost(t, parentNICID, ethernet.New(ep1))
+			s1, _ := newIPVLANHost(t, parent, test.mode, addr1)
+			s2, _ := newIPVLANHost(t, parent, test.mode, addr2)
+
+			if !linktest.SendDatagrams(t, s1, s2, addr2, testTimeout) {
+				t.Fatalf("timed out waiting for a datagram between ipvlan interfaces")
+			}
+		})
+	}
+}
+
+func TestIPVLANConflicts(t *testing.T) {
+	addr := testutil.MustParse4("192.168.100.1")
+	ep1, _ := veth.NewPair(1500, veth.DefaultBacklogSize)
+	parent := linktest.NewHost(t, parentNICID, ethernet.New(ep1))
+	ep, err := ipvlan.New(parent, ipvlan.Options{Parent: parentNICID, Mode: stack.IPVLANModeL2})
+	if err != nil {
+		t.Fatalf("ipvlan.New(_, {Parent: %d}): %s", parentNICID, err)
+	}
+	if err := ep.AddAddress(addr); err != nil {
+		t.Fatalf("AddAddress(%s): %s", addr, err)
+	}
+	if _, err := ipvlan.New(parent, ipvlan.Options{Parent: parentNICID, Mode: stack.IPVLANModeL3}); err == nil {
+		t.Errorf("ipvlan.New succeeded for another mode, want error")
+	}
+	if _, err := macvlan.New(parent, macvlan.Options{Parent: parentNICID}); err == nil {
+		t.Errorf("macvlan.New succeeded for a parent with ipvlan interfaces, want error")
+	}
+
+	other, err := ipvlan.New(parent, ipvlan.Options{Parent: parentNICID, Mode: stack.IPVLANModeL2})
+	if err != nil {
+		t.Fatalf("ipvlan.New(_, {Parent: %d}): %s", parentNICID, err)
+	}
+	if err := other.AddAddress(addr); err == nil {
+		t.Errorf("AddAddress(%s) succeeded for the address of another ipvlan interface, want error", addr)
+	}
+	ep.RemoveAddress(addr)
+	if err := other.AddAddress(addr); err != nil {
+		t.Errorf("AddAddress(%s) after its removal: %s", addr, err)
+	}
+}
+
+func TestParentRemoved(t *testing.T) {
+	ep1, _ := veth.NewPair(1500, veth.DefaultBacklogSize)
+	s := linktest.NewHost(t, parentNICID, ethernet.New(ep1))
+	ep, err := ipvlan.New(s, ipvlan.Options{Parent: parentNICID})
+	if err != nil {
+		t.Fatalf("ipvlan.New(_, {Parent: %d}): %s", parentNICID, err)
+	}
+	if err := s.CreateNIC(nicID, ethernet.New(ep)); err != nil {
+		t.Fatalf("CreateNIC(%d, _): %s", nicID, err)
+	}
+	if err := s.RemoveNIC(parentNICID); err != nil {
+		t.Fatalf("RemoveNIC(%d): %s", parentNICID, err)
+	}
+	if _, ok := s.NICInfo()[nicID]; ok {
+		t.Errorf("got NIC %d after removing its parent, want it removed", nicID)
+	}
+}
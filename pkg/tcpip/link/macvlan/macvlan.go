@@ -0,0 +1,283 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package macvlan provides macvlan interfaces, which have their own link
+// address on the link of a parent NIC.
+//
+// A macvlan endpoint receives the frames destined to its link address and the
+// multicast frames from its parent NIC, and sends frames through the parent
+// NIC. It exchanges whole Ethernet frames, so it must be wrapped by an
+// ethernet endpoint.
+package macvlan
+
+import (
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+// Options holds the configuration of a macvlan endpoint.
+type Options struct {
+	// Parent is the NIC that carries the frames of the endpoint.
+	Parent tcpip.NICID
+
+	// Mode is the mode of the endpoint.
+	Mode stack.MACVLANMode
+
+	// LinkAddress is the link address of the endpoint. If unspecified, a
+	// random address is used.
+	LinkAddress tcpip.LinkAddress
+}
+
+var _ stack.LinkEndpoint = (*Endpoint)(nil)
+var _ stack.MACVLANEndpoint = (*Endpoint)(nil)
+
+// Endpoint is a macvlan interface of a NIC.
+//
+// +stateify savable
+type Endpoint struct {
+	stack *stack.Stack
+	opts  Options
+
+	mu endpointRWMutex `state:"nosave"`
+	// +checklocks:mu
+	dispatcher stack.NetworkDispatcher
+	// +checklocks:mu
+	linkAddr tcpip.LinkAddress
+	// +checklocks:mu
+	mtu uint32
+	// +checklocks:mu
+	closed bool
+	// +checklocks:mu
+	onCloseAction func() `state:"nosave"`
+}
+
+// New creates a macvlan endpoint and registers it with the parent NIC. Like on
+// Linux, the endpoint inherits the MTU of its parent.
+func New(s *stack.Stack, opts Options) (*Endpoint, tcpip.Error) {
+	parent, ok := s.NICInfo()[opts.Parent]
+	if !ok {
+		return nil, &tcpip.ErrUnknownNICID{}
+	}
+	if parent.ARPHardwareType != header.ARPHardwareEther {
+		return nil, &tcpip.ErrNotSupported{}
+	}
+	if opts.LinkAddress == "" {
+		opts.LinkAddress = tcpip.GetRandMacAddr()
+	}
+	e := &Endpoint{
+		stack:    s,
+		opts:     opts,
+		linkAddr: opts.LinkAddress,
+		mtu:      parent.MTU,
+	}
+	if err := s.RegisterMACVLANEndpoint(opts.Parent, opts.LinkAddress, opts.Mode, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Mode returns the mode of the endpoint.
+func (e *Endpoint) Mode() stack.MACVLANMode {
+	return e.opts.Mode
+}
+
+// Parent returns the NIC that carries the frames of the endpoint.
+func (e *Endpoint) Parent() tcpip.NICID {
+	return e.opts.Parent
+}
+
+// HandleMACVLANPacket implements stack.MACVLANEndpoint.HandleMACVLANPacket.
+func (e *Endpoint) HandleMACVLANPacket(pkt *stack.PacketBuffer) {
+	e.mu.RLock()
+	d := e.dispatcher
+	e.mu.RUnlock()
+	if d == nil {
+		return
+	}
+	// The frame is parsed again by the ethernet endpoint of the interface.
+	newPkt := stack.NewPacketBuffer(stack.PacketBufferOptions{
+		Payload: stack.BufferSince(pkt.LinkHeader()),
+	})
+	d.DeliverNetworkPacket(0 /* protocol */, newPkt)
+	newPkt.DecRef()
+}
+
+// ParentRemoved implements stack.MACVLANEndpoint.ParentRemoved. Like on Linux,
+// the interface is removed with its parent.
+func (e *Endpoint) ParentRemoved() {
+	e.Close()
+}
+
+// WritePackets implements stack.LinkEndpoint.WritePackets. The packets hold
+// Ethernet frames, which are switched to the other macvlan interfaces of the
+// parent NIC in bridge mode, or written to the parent NIC.
+func (e *Endpoint) WritePackets(pkts stack.PacketBufferList) (int, tcpip.Error) {
+	e.mu.RLock()
+	linkAddr := e.linkAddr
+	e.mu.RUnlock()
+	n := 0
+	for _, pkt := range pkts.AsSlice() {
+		out := pkt.ToBuffer()
+		hdr, ok := out.PullUp(0, header.EthernetMinimumSize)
+		if !ok {
+			out.Release()
+			n++
+			continue
+		}
+		protocol := header.Ethernet(hdr.AsSlice()).Type()
+		if e.opts.Mode == stack.MACVLANModeBridge && e.forward(linkAddr, out.Clone()) {
+			out.Release()
+			n++
+			continue
+		}
+		if err := e.stack.WriteRawPacket(e.opts.Parent, protocol, out); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// forward switches the frame f to the other macvlan interfaces of the parent
+// NIC, and returns true if it doesn't need to be sent by the parent NIC.
+func (e *Endpoint) forward(linkAddr tcpip.LinkAddress, f buffer.Buffer) bool {
+	pkt := stack.NewPacketBuffer(stack.PacketBufferOptions{
+		Payload: f,
+	})
+	defer pkt.DecRef()
+	if _, ok := pkt.LinkHeader().Consume(header.EthernetMinimumSize); !ok {
+		return false
+	}
+	return e.stack.ForwardMACVLANPacket(e.opts.Parent, linkAddr, pkt)
+}
+
+// Close implements stack.LinkEndpoint.Close.
+func (e *Endpoint) Close() {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return
+	}
+	e.closed = true
+	linkAddr := e.linkAddr
+	action := e.onCloseAction
+	e.onCloseAction = nil
+	e.mu.Unlock()
+
+	e.stack.UnregisterMACVLANEndpoint(e.opts.Parent, linkAddr)
+	if action != nil {
+		action()
+	}
+}
+
+// Wait implements stack.LinkEndpoint.Wait.
+func (*Endpoint) Wait() {}
+
+// Attach implements stack.LinkEndpoint.Attach.
+func (e *Endpoint) Attach(dispatcher stack.NetworkDispatcher) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.dispatcher = dispatcher
+}
+
+// IsAttached implements stack.LinkEndpoint.IsAttached.
+func (e *Endpoint) IsAttached() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.dispatcher != nil
+}
+
+// MTU implements stack.LinkEndpoint.MTU.
+func (e *Endpoint) MTU() uint32 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.mtu
+}
+
+// SetMTU implements stack.LinkEndpoint.SetMTU.
+func (e *Endpoint) SetMTU(mtu uint32) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.mtu = mtu
+}
+
+// Capabilities implements stack.LinkEndpoint.Capabilities.
+func (*Endpoint) Capabilities() stack.LinkEndpointCapabilities {
+	return stack.CapabilitySaveRestore
+}
+
+// MaxHeaderLength implements stack.LinkEndpoint.MaxHeaderLength.
+func (*Endpoint) MaxHeaderLength() uint16 {
+	return 0
+}
+
+// LinkAddress implements stack.LinkEndpoint.LinkAddress.
+func (e *Endpoint) LinkAddress() tcpip.LinkAddress {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.linkAddr
+}
+
+// SetLinkAddress implements stack.LinkEndpoint.SetLinkAddress. The address
+// isn't changed if it is used by another macvlan interface of the parent NIC.
+func (e *Endpoint) SetLinkAddress(addr tcpip.LinkAddress) {
+	e.setLinkAddress(addr)
+}
+
+// SetAddress changes the link address of the endpoint, like SetLinkAddress,
+// but fails if addr is used by the parent NIC or by another macvlan interface
+// of the parent NIC. Unlike SetLinkAddress, it must not be called with the
+// stack locked.
+func (e *Endpoint) SetAddress(addr tcpip.LinkAddress) tcpip.Error {
+	parent, ok := e.stack.NICInfo()[e.opts.Parent]
+	if !ok {
+		return &tcpip.ErrUnknownNICID{}
+	}
+	if parent.LinkAddress == addr {
+		return &tcpip.ErrDuplicateAddress{}
+	}
+	return e.setLinkAddress(addr)
+}
+
+func (e *Endpoint) setLinkAddress(addr tcpip.LinkAddress) tcpip.Error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.closed {
+		if err := e.stack.SetMACVLANAddress(e.opts.Parent, e.linkAddr, addr); err != nil {
+			return err
+		}
+	}
+	e.linkAddr = addr
+	return nil
+}
+
+// ARPHardwareType implements stack.LinkEndpoint.ARPHardwareType.
+func (*Endpoint) ARPHardwareType() header.ARPHardwareType {
+	return header.ARPHardwareNone
+}
+
+// AddHeader implements stack.LinkEndpoint.AddHeader.
+func (*Endpoint) AddHeader(*stack.PacketBuffer) {}
+
+// ParseHeader implements stack.LinkEndpoint.ParseHeader.
+func (*Endpoint) ParseHeader(*stack.PacketBuffer) bool { return true }
+
+// SetOnCloseAction implements stack.LinkEndpoint.SetOnCloseAction.
+func (e *Endpoint) SetOnCloseAction(action func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onCloseAction = action
+}
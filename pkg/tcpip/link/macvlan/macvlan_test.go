@@ -0,0 +1,143 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package macvlan_test
+
+import (
+	"testing"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/internal/linktest"
+	"gvisor.dev/gvisor/pkg/tcpip/link/ethernet"
+	"gvisor.dev/gvisor/pkg/tcpip/link/macvlan"
+	"gvisor.dev/gvisor/pkg/tcpip/link/veth"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/testutil"
+)
+
+const (
+	parentNICID = 1
+	nicID       = 2
+	testTimeout = 5 * time.Second
+)
+
+// newMACVLANHost creates a macvlan interface of the parent NIC of parent in
+// a stack of its own, like in a network namespace, with the address addr.
+func newMACVLANHost(t *testing.T, parent *stack.Stack, mode stack.MACVLANMode, addr tcpip.Address) (*stack.Stack, *macvlan.Endpoint) {
+	t.Helper()
+	ep, err := macvlan.New(parent, macvlan.Options{Parent: parentNICID, Mode: mode})
+	if err != nil {
+		t.Fatalf("macvlan.New(_, {Parent: %d, Mode: %d}): %s", parentNICID, mode, err)
+	}
+	return linktest.NewHost(t, nicID, ethernet.New(ep), tcpip.AddressWithPrefix{Address: addr, PrefixLen: 24}), ep
+}
+
+func TestMACVLAN(t *testing.T) {
+	addr1 := testutil.MustParse4("192.168.100.1")
+	addr2 := testutil.MustParse4("192.168.100.2")
+	ep1, ep2 := veth.NewPair(1500, veth.DefaultBacklogSize)
+	parent := linktest.NewHost(t, parentNICID, ethernet.New(ep1))
+	s1, macvlanEP := newMACVLANHost(t, parent, stack.MACVLANModeVEPA, addr1)
+	s2 := linktest.NewHost(t, nicID, ethernet.New(ep2), tcpip.AddressWithPrefix{Address: addr2, PrefixLen: 24})
+
+	if got, want := macvlanEP.MTU(), uint32(1500); got != want {
+		t.Errorf("got MTU() = %d, want = %d", got, want)
+	}
+	if macvlanEP.LinkAddress() == ep1.LinkAddress() {
+		t.Errorf("got LinkAddress() = %s, want an address other than the parent's", macvlanEP.LinkAddress())
+	}
+	if !linktest.SendDatagrams(t, s1, s2, addr2, testTimeout) {
+		t.Fatalf("timed out waiting for a datagram from the macvlan interface")
+	}
+	if !linktest.SendDatagrams(t, s2, s1, addr1, testTimeout) {
+		t.Fatalf("timed out waiting for a datagram to the macvlan interface")
+	}
+}
+
+func TestMACVLANModes(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		mode stack.MACVLANMode
+		want bool
+	}{
+		{name: "bridge", mode: stack.MACVLANModeBridge, want: true},
+		{name: "private", mode: stack.MACVLANModePrivate, want: false},
+		// The peer doesn't send frames back.
+		{name: "vepa", mode: stack.MACVLANModeVEPA, want: false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			addr1 := testutil.MustParse4("192.168.100.1")
+			addr2 := testutil.MustParse4("192.168.100.2")
+			ep1, _ := veth.NewPair(1500, veth.DefaultBacklogSize)
+			parent := linktest.NewHost(t, parentNICID, ethernet.New(ep1))
+			s1, _ := newMACVLANHost(t, parent, test.mode, addr1)
+			s2, _ := newMACVLANHost(t, parent, test.mode, addr2)
+
+			timeout := testTimeout
+			if !test.want {
+				timeout = 500 * time.Millisecond
+			}
+			if got := linktest.SendDatagrams(t, s1, s2, addr2, timeout); got != test.want {
+				t.Errorf("got linktest.SendDatagrams(...) = %t, want = %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMACVLANAddress(t *testing.T) {
+	ep1, _ := veth.NewPair(1500, veth.DefaultBacklogSize)
+	parent := linktest.NewHost(t, parentNICID, ethernet.New(ep1))
+	ep, err := macvlan.New(parent, macvlan.Options{Parent: parentNICID})
+	if err != nil {
+		t.Fatalf("macvlan.New(_, {Parent: %d}): %s", parentNICID, err)
+	}
+	if _, err := macvlan.New(parent, macvlan.Options{Parent: parentNICID, LinkAddress: ep.LinkAddress()}); err == nil {
+		t.Errorf("macvlan.New succeeded for the address of another macvlan interface, want error")
+	}
+	if _, err := macvlan.New(parent, macvlan.Options{Parent: parentNICID, LinkAddress: ep1.LinkAddress()}); err == nil {
+		t.Errorf("macvlan.New succeeded for the address of the parent, want error")
+	}
+	if err := ep.SetAddress(ep1.LinkAddress()); err == nil {
+		t.Errorf("SetAddress(%s) succeeded for the address of the parent, want error", ep1.LinkAddress())
+	}
+	addr := tcpip.LinkAddress("\x02\x03\x04\x05\x06\x07")
+	if err := ep.SetAddress(addr); err != nil {
+		t.Fatalf("SetAddress(%s): %s", addr, err)
+	}
+	if got := ep.LinkAddress(); got != addr {
+		t.Errorf("got LinkAddress() = %s, want = %s", got, addr)
+	}
+	if _, err := macvlan.New(parent, macvlan.Options{Parent: parentNICID, LinkAddress: addr}); err == nil {
+		t.Errorf("macvlan.New succeeded for the changed address of another macvlan interface, want error")
+	}
+}
+
+func TestParentRemoved(t *testing.T) {
+	ep1, _ := veth.NewPair(1500, veth.DefaultBacklogSize)
+	s := linktest.NewHost(t, parentNICID, ethernet.New(ep1))
+	ep, err := macvlan.New(s, macvlan.Options{Parent: parentNICID})
+	if err != nil {
+		t.Fatalf("macvlan.New(_, {Parent: %d}): %s", parentNICID, err)
+	}
+	if err := s.CreateNIC(nicID, ethernet.New(ep)); err != nil {
+		t.Fatalf("CreateNIC(%d, _): %s", nicID, err)
+	}
+	if err := s.RemoveNIC(parentNICID); err != nil {
+		t.Fatalf("RemoveNIC(%d): %s", parentNICID, err)
+	}
+	if _, ok := s.NICInfo()[nicID]; ok {
+		t.Errorf("got NIC %d after removing its parent, want it removed", nicID)
+	}
+}
@@ -0,0 +1,274 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package etf provides the implementation of the earliest txtime first
+// queueing discipline, which sends packets at the departure time set with
+// SCM_TXTIME, like the etf qdisc of Linux.
+//
+// Packets are ordered by departure time and sent Delta before it. As in
+// Linux, packets whose departure time is invalid are dropped when they are
+// queued, packets that miss their departure time are dropped when they are
+// dequeued, and both are reported to the socket that sent them if it asked
+// for it with SOF_TXTIME_REPORT_ERRORS. Unlike Linux, which drops them,
+// packets without a departure time are sent right away, so that the
+// discipline can be the root queueing discipline of an interface.
+package etf
+
+import (
+	"container/heap"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/link/qdisc/shaping"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+const (
+	// DefaultLimit is the default number of packets that can be queued.
+	DefaultLimit = 10000
+
+	// DefaultDelta is the default time before their departure time at
+	// which packets are sent. It covers the latency of the timers of the
+	// stack clock, which would otherwise make packets miss their departure
+	// time.
+	DefaultDelta = time.Millisecond
+)
+
+// Options holds the configuration of an earliest txtime first queue. Zero
+// values are replaced by defaults, except for ClockID and DeadlineMode.
+type Options struct {
+	// ClockID is the ID of the clock of the departure times that the
+	// discipline accepts, which must be the monotonic clock of the stack
+	// clock.
+	ClockID int32
+
+	// Delta is the time before their departure time at which packets are
+	// sent.
+	Delta time.Duration
+
+	// DeadlineMode makes the discipline send packets as soon as possible in
+	// the order of their departure time, which is a deadline. Only packets
+	// of sockets in the same mode are accepted.
+	DeadlineMode bool
+
+	// Limit is the number of packets that can be queued.
+	Limit uint32
+}
+
+func (o *Options) setDefaults() {
+	if o.Delta == 0 {
+		o.Delta = DefaultDelta
+	}
+	if o.Limit == 0 {
+		o.Limit = DefaultLimit
+	}
+}
+
+var _ stack.QueueingDiscipline = (*Discipline)(nil)
+
+// Discipline is an earliest txtime first queue.
+//
+// +stateify savable
+type Discipline struct {
+	*shaping.Discipline
+}
+
+// timedPacket is a packet with a departure time.
+//
+// +stateify savable
+type timedPacket struct {
+	pkt *stack.PacketBuffer
+	at  tcpip.MonotonicTime
+
+	// seq orders the packets with the same departure time in the order in
+	// which they were queued.
+	seq uint64
+}
+
+// timedPackets is a heap of packets ordered by departure time.
+type timedPackets []timedPacket
+
+var _ heap.Interface = (*timedPackets)(nil)
+
+// Len implements heap.Interface.Len.
+func (h timedPackets) Len() int {
+	return len(h)
+}
+
+// Less implements heap.Interface.Less.
+func (h timedPackets) Less(i, j int) bool {
+	if h[i].at == h[j].at {
+		return h[i].seq < h[j].seq
+	}
+	return h[i].at.Before(h[j].at)
+}
+
+// Swap implements heap.Interface.Swap.
+func (h timedPackets) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+// Push implements heap.Interface.Push.
+func (h *timedPackets) Push(x any) {
+	*h = append(*h, x.(timedPacket))
+}
+
+// Pop implements heap.Interface.Pop.
+func (h *timedPackets) Pop() any {
+	old := *h
+	n := len(old) - 1
+	p := old[n]
+	old[n] = timedPacket{}
+	*h = old[:n]
+	return p
+}
+
+// scheduler sends packets at their departure time.
+//
+// +stateify savable
+type scheduler struct {
+	opts  Options
+	clock tcpip.Clock
+
+	// untimed holds the packets without a departure time, and timed those
+	// with one.
+	untimed shaping.PacketQueue
+	timed   timedPackets
+	seq     uint64
+
+	// last is the departure time of the last packet sent from timed.
+	// Packets can't be sent before it.
+	last tcpip.MonotonicTime
+
+	backlog int
+
+	// missed counts the packets that missed their departure time.
+	missed uint64
+}
+
+// New creates an earliest txtime first queue that sends packets through
+// lower.
+func New(lower stack.LinkWriter, clock tcpip.Clock, opts Options) *Discipline {
+	opts.setDefaults()
+	s := &scheduler{
+		opts:  opts,
+		clock: clock,
+	}
+	return &Discipline{shaping.New(lower, clock, s)}
+}
+
+// Options returns the configuration of the discipline.
+func (d *Discipline) Options() Options {
+	var opts Options
+	d.Inspect(func(s shaping.Scheduler) {
+		opts = s.(*scheduler).opts
+	})
+	return opts
+}
+
+// SetOptions changes the configuration of the discipline. Queued packets are
+// kept even if they exceed the new limit or don't match the new clock or mode.
+func (d *Discipline) SetOptions(opts Options) {
+	opts.setDefaults()
+	d.Update(func(s shaping.Scheduler) {
+		s.(*scheduler).opts = opts
+	})
+}
+
+// Stats returns the statistics of the discipline. Drops include the packets
+// that missed their departure time.
+func (d *Discipline) Stats() shaping.Stats {
+	stats := d.Discipline.Stats()
+	d.Inspect(func(s shaping.Scheduler) {
+		stats.Drops += s.(*scheduler).missed
+	})
+	return stats
+}
+
+// Enqueue implements shaping.Scheduler.Enqueue.
+func (s *scheduler) Enqueue(pkt *stack.PacketBuffer) bool {
+	if s.Len() >= int(s.opts.Limit) {
+		return false
+	}
+	txTime := pkt.TxTime
+	if txTime == nil {
+		s.untimed.PushBack(pkt)
+		return true
+	}
+	// Like Linux, the departure time isn't converted between clocks.
+	at := txTime.MonotonicTime()
+	if txTime.ClockID != s.opts.ClockID || txTime.DeadlineMode != s.opts.DeadlineMode ||
+		at.Before(s.clock.NowMonotonic()) || at.Before(s.last) {
+		txTime.Owner.OnTxTimeDrop(txTime.Time, false /* missed */)
+		return false
+	}
+	heap.Push(&s.timed, timedPacket{
+		pkt: pkt.IncRef(),
+		at:  at,
+		seq: s.seq,
+	})
+	s.seq++
+	s.backlog += pkt.Size()
+	return true
+}
+
+// Dequeue implements shaping.Scheduler.Dequeue.
+func (s *scheduler) Dequeue(now tcpip.MonotonicTime) (*stack.PacketBuffer, tcpip.MonotonicTime) {
+	if pkt := s.untimed.PopFront(); pkt != nil {
+		return pkt, tcpip.MonotonicTime{}
+	}
+	for len(s.timed) > 0 {
+		p := s.timed[0]
+		if p.at.Before(now) {
+			s.pop()
+			s.missed++
+			p.pkt.TxTime.Owner.OnTxTimeDrop(p.pkt.TxTime.Time, true /* missed */)
+			p.pkt.DecRef()
+			continue
+		}
+		if next := p.at.Add(-s.opts.Delta); !s.opts.DeadlineMode && next.After(now) {
+			return nil, next
+		}
+		s.pop()
+		s.last = p.at
+		return p.pkt, tcpip.MonotonicTime{}
+	}
+	return nil, tcpip.MonotonicTime{}
+}
+
+// pop removes the packet with the earliest departure time.
+func (s *scheduler) pop() {
+	p := heap.Pop(&s.timed).(timedPacket)
+	s.backlog -= p.pkt.Size()
+}
+
+// Len implements shaping.Scheduler.Len.
+func (s *scheduler) Len() int {
+	return s.untimed.Len() + len(s.timed)
+}
+
+// Backlog implements shaping.Scheduler.Backlog.
+func (s *scheduler) Backlog() int {
+	return s.untimed.Backlog() + s.backlog
+}
+
+// Reset implements shaping.Scheduler.Reset.
+func (s *scheduler) Reset() {
+	s.untimed.Reset()
+	for _, p := range s.timed {
+		p.pkt.DecRef()
+	}
+	s.timed = nil
+	s.backlog = 0
+}
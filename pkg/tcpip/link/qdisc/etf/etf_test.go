@@ -0,0 +1,307 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etf_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/refs"
+	"gvisor.dev/gvisor/pkg/sync"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/faketime"
+	"gvisor.dev/gvisor/pkg/tcpip/link/qdisc/etf"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+const (
+	clockID      = 1
+	otherClockID = 11
+	noTxTime     = -1
+)
+
+// recordWriter implements stack.LinkWriter and records the departure times of
+// the packets it sends, or noTxTime.
+type recordWriter struct {
+	mu    sync.Mutex
+	times []int64
+}
+
+func (rw *recordWriter) WritePackets(pkts stack.PacketBufferList) (int, tcpip.Error) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	for _, pkt := range pkts.AsSlice() {
+		if pkt.TxTime == nil {
+			rw.times = append(rw.times, noTxTime)
+		} else {
+			rw.times = append(rw.times, pkt.TxTime.Time)
+		}
+	}
+	return pkts.Len(), nil
+}
+
+func (rw *recordWriter) sent() []int64 {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	return append([]int64(nil), rw.times...)
+}
+
+type drop struct {
+	txTime int64
+	missed bool
+}
+
+// recordOwner implements stack.TxTimeOwner.
+type recordOwner struct {
+	mu    sync.Mutex
+	drops []drop
+}
+
+func (ro *recordOwner) OnTxTimeDrop(txTime int64, missed bool) {
+	ro.mu.Lock()
+	defer ro.mu.Unlock()
+	ro.drops = append(ro.drops, drop{txTime: txTime, missed: missed})
+}
+
+func (ro *recordOwner) dropped() []drop {
+	ro.mu.Lock()
+	defer ro.mu.Unlock()
+	return append([]drop(nil), ro.drops...)
+}
+
+// lateClock is a clock whose timers fire late.
+type lateClock struct {
+	*faketime.ManualClock
+	late time.Duration
+}
+
+func (lc *lateClock) AfterFunc(d time.Duration, f func()) tcpip.Timer {
+	return lc.ManualClock.AfterFunc(d+lc.late, f)
+}
+
+func writePacket(t *testing.T, d stack.QueueingDiscipline, txTime *stack.TxTime) tcpip.Error {
+	t.Helper()
+	pkt := stack.NewPacketBuffer(stack.PacketBufferOptions{
+		Payload: buffer.MakeWithData(make([]byte, 100)),
+	})
+	defer pkt.DecRef()
+	pkt.TxTime = txTime
+	return d.WritePacket(pkt)
+}
+
+func TestDepartureTime(t *testing.T) {
+	const delta = time.Millisecond
+	clock := faketime.NewManualClock()
+	lower := &recordWriter{}
+	owner := &recordOwner{}
+	d := etf.New(lower, clock, etf.Options{ClockID: clockID, Delta: delta})
+	defer d.Close()
+
+	times := []time.Duration{30 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond, 10 * time.Millisecond}
+	for i, at := range times {
+		if err := writePacket(t, d, &stack.TxTime{Time: int64(at), ClockID: clockID, Owner: owner}); err != nil {
+			t.Fatalf("WritePacket #%d at %s: %s", i, at, err)
+		}
+	}
+	// Packets without a departure time are sent right away.
+	if err := writePacket(t, d, nil); err != nil {
+		t.Fatalf("WritePacket without departure time: %s", err)
+	}
+	if diff := cmp.Diff([]int64{noTxTime}, lower.sent()); diff != "" {
+		t.Fatalf("sent packets mismatch (-want +got):\n%s", diff)
+	}
+	if got, want := d.Stats().Qlen, uint32(len(times)); got != want {
+		t.Errorf("got Stats().Qlen = %d, want = %d", got, want)
+	}
+
+	// Packets are sent delta before their departure time, in its order.
+	clock.Advance(10*time.Millisecond - delta - time.Nanosecond)
+	if got := len(lower.sent()); got != 1 {
+		t.Fatalf("got %d packets sent before the first departure time, want = 1", got)
+	}
+	clock.Advance(time.Nanosecond)
+	want := []int64{noTxTime, int64(10 * time.Millisecond), int64(10 * time.Millisecond)}
+	if diff := cmp.Diff(want, lower.sent()); diff != "" {
+		t.Fatalf("sent packets mismatch (-want +got):\n%s", diff)
+	}
+	clock.Advance(20 * time.Millisecond)
+	want = append(want, int64(20*time.Millisecond), int64(30*time.Millisecond))
+	if diff := cmp.Diff(want, lower.sent()); diff != "" {
+		t.Errorf("sent packets mismatch (-want +got):\n%s", diff)
+	}
+	if got := owner.dropped(); len(got) != 0 {
+		t.Errorf("got drops %+v, want none", got)
+	}
+	stats := d.Stats()
+	if got, want := stats.Packets, uint64(len(want)); got != want {
+		t.Errorf("got Stats().Packets = %d, want = %d", got, want)
+	}
+	if stats.Qlen != 0 || stats.Backlog != 0 {
+		t.Errorf("got Stats().Qlen = %d and Stats().Backlog = %d, want = 0", stats.Qlen, stats.Backlog)
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	// A packet sent at last is sent at now, which is delta before last.
+	const (
+		last = 10 * time.Millisecond
+		now  = last - etf.DefaultDelta
+	)
+	for _, test := range []struct {
+		name   string
+		txTime stack.TxTime
+	}{
+		{
+			name:   "past",
+			txTime: stack.TxTime{Time: int64(now - time.Nanosecond), ClockID: clockID},
+		},
+		{
+			name:   "before last",
+			txTime: stack.TxTime{Time: int64(last - time.Nanosecond), ClockID: clockID},
+		},
+		{
+			name:   "other clock",
+			txTime: stack.TxTime{Time: int64(time.Second), ClockID: otherClockID},
+		},
+		{
+			name:   "deadline mode",
+			txTime: stack.TxTime{Time: int64(time.Second), ClockID: clockID, DeadlineMode: true},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			clock := faketime.NewManualClock()
+			lower := &recordWriter{}
+			owner := &recordOwner{}
+			d := etf.New(lower, clock, etf.Options{ClockID: clockID})
+			defer d.Close()
+
+			if err := writePacket(t, d, &stack.TxTime{Time: int64(last), ClockID: clockID, Owner: owner}); err != nil {
+				t.Fatalf("WritePacket: %s", err)
+			}
+			clock.Advance(now)
+			if diff := cmp.Diff([]int64{int64(last)}, lower.sent()); diff != "" {
+				t.Fatalf("sent packets mismatch (-want +got):\n%s", diff)
+			}
+
+			txTime := test.txTime
+			txTime.Owner = owner
+			if err := writePacket(t, d, &txTime); err == nil {
+				t.Errorf("WritePacket(%+v) succeeded, want error", txTime)
+			}
+			if diff := cmp.Diff([]drop{{txTime: txTime.Time}}, owner.dropped(), cmp.AllowUnexported(drop{})); diff != "" {
+				t.Errorf("drops mismatch (-want +got):\n%s", diff)
+			}
+			if got, want := d.Stats().Drops, uint64(1); got != want {
+				t.Errorf("got Stats().Drops = %d, want = %d", got, want)
+			}
+		})
+	}
+}
+
+func TestMissed(t *testing.T) {
+	const (
+		late = 2 * time.Millisecond
+		at   = 10 * time.Millisecond
+	)
+	clock := &lateClock{ManualClock: faketime.NewManualClock(), late: late}
+	lower := &recordWriter{}
+	owner := &recordOwner{}
+	d := etf.New(lower, clock, etf.Options{ClockID: clockID, Delta: late / 2})
+	defer d.Close()
+
+	// The timer fires after the departure time, so the packet is dropped.
+	if err := writePacket(t, d, &stack.TxTime{Time: int64(at), ClockID: clockID, Owner: owner}); err != nil {
+		t.Fatalf("WritePacket: %s", err)
+	}
+	clock.Advance(at + late)
+	if got := lower.sent(); len(got) != 0 {
+		t.Errorf("got packets %v sent, want none", got)
+	}
+	if diff := cmp.Diff([]drop{{txTime: int64(at), missed: true}}, owner.dropped(), cmp.AllowUnexported(drop{})); diff != "" {
+		t.Errorf("drops mismatch (-want +got):\n%s", diff)
+	}
+	if got, want := d.Stats().Drops, uint64(1); got != want {
+		t.Errorf("got Stats().Drops = %d, want = %d", got, want)
+	}
+
+	// A delta larger than the latency of the timer lets packets be sent.
+	d.SetOptions(etf.Options{ClockID: clockID, Delta: 2 * late})
+	next := int64(2*at + late)
+	if err := writePacket(t, d, &stack.TxTime{Time: next, ClockID: clockID, Owner: owner}); err != nil {
+		t.Fatalf("WritePacket: %s", err)
+	}
+	clock.Advance(at)
+	if diff := cmp.Diff([]int64{next}, lower.sent()); diff != "" {
+		t.Errorf("sent packets mismatch (-want +got):\n%s", diff)
+	}
+	if got := len(owner.dropped()); got != 1 {
+		t.Errorf("got %d drops, want = 1", got)
+	}
+}
+
+func TestDeadlineMode(t *testing.T) {
+	clock := faketime.NewManualClock()
+	lower := &recordWriter{}
+	owner := &recordOwner{}
+	d := etf.New(lower, clock, etf.Options{ClockID: clockID, DeadlineMode: true})
+	defer d.Close()
+
+	// Packets are sent as soon as possible, before their deadline.
+	deadline := int64(time.Second)
+	if err := writePacket(t, d, &stack.TxTime{Time: deadline, ClockID: clockID, DeadlineMode: true, Owner: owner}); err != nil {
+		t.Fatalf("WritePacket: %s", err)
+	}
+	if diff := cmp.Diff([]int64{deadline}, lower.sent()); diff != "" {
+		t.Errorf("sent packets mismatch (-want +got):\n%s", diff)
+	}
+	if got := owner.dropped(); len(got) != 0 {
+		t.Errorf("got drops %+v, want none", got)
+	}
+}
+
+func TestClose(t *testing.T) {
+	clock := faketime.NewManualClock()
+	lower := &recordWriter{}
+	owner := &recordOwner{}
+	d := etf.New(lower, clock, etf.Options{ClockID: clockID})
+	for i := 1; i <= 3; i++ {
+		if err := writePacket(t, d, &stack.TxTime{Time: int64(i) * int64(time.Second), ClockID: clockID, Owner: owner}); err != nil {
+			t.Fatalf("WritePacket #%d: %s", i, err)
+		}
+	}
+	d.Close()
+
+	// The queued packets are dropped without being reported.
+	clock.Advance(time.Minute)
+	if got := lower.sent(); len(got) != 0 {
+		t.Errorf("got packets %v sent, want none", got)
+	}
+	if got := owner.dropped(); len(got) != 0 {
+		t.Errorf("got drops %+v, want none", got)
+	}
+	if err := writePacket(t, d, nil); err == nil {
+		t.Errorf("WritePacket succeeded after Close, want error")
+	}
+}
+
+func TestMain(m *testing.M) {
+	refs.SetLeakMode(refs.LeaksPanic)
+	code := m.Run()
+	refs.DoLeakCheck()
+	os.Exit(code)
+}
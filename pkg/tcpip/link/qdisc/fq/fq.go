@@ -0,0 +1,295 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fq provides the implementation of the fair queue queueing
+// discipline, like the fq qdisc of Linux.
+//
+// Outbound packets are queued per flow, which is identified by the hash of the
+// packet that is set by the transport endpoint that sent it. Flows are served
+// in a deficit round robin, where new flows are favored over old ones, and the
+// rate of each flow can be limited, which paces the segments of TCP
+// connections.
+package fq
+
+import (
+	"time"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/link/qdisc/shaping"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+const (
+	// DefaultLimit is the default number of packets that can be queued.
+	DefaultLimit = 10000
+
+	// DefaultFlowLimit is the default number of packets that can be queued
+	// per flow.
+	DefaultFlowLimit = 100
+
+	// defaultMTU is the packet size that the default quantums are computed
+	// from, like on Linux.
+	defaultMTU = 1514
+
+	// DefaultQuantum is the default number of bytes that a flow can send
+	// each round.
+	DefaultQuantum = 2 * defaultMTU
+
+	// DefaultInitialQuantum is the default number of bytes that a new flow
+	// can send in its first round.
+	DefaultInitialQuantum = 10 * defaultMTU
+)
+
+// Options holds the configuration of a fair queue. Zero values are replaced
+// by defaults, except for MaxRate.
+type Options struct {
+	// Limit is the number of packets that can be queued.
+	Limit uint32
+
+	// FlowLimit is the number of packets that can be queued per flow.
+	FlowLimit uint32
+
+	// Quantum is the number of bytes that a flow can send each round.
+	Quantum uint32
+
+	// InitialQuantum is the number of bytes that a new flow can send in
+	// its first round.
+	InitialQuantum uint32
+
+	// MaxRate is the maximum rate of each flow in bytes per second, or
+	// zero if flows aren't paced.
+	MaxRate uint64
+}
+
+func (o *Options) setDefaults() {
+	if o.Limit == 0 {
+		o.Limit = DefaultLimit
+	}
+	if o.FlowLimit == 0 {
+		o.FlowLimit = DefaultFlowLimit
+	}
+	if o.Quantum == 0 {
+		o.Quantum = DefaultQuantum
+	}
+	if o.InitialQuantum == 0 {
+		o.InitialQuantum = DefaultInitialQuantum
+	}
+}
+
+var _ stack.QueueingDiscipline = (*Discipline)(nil)
+
+// Discipline is a fair queue.
+//
+// +stateify savable
+type Discipline struct {
+	*shaping.Discipline
+}
+
+// flow holds the packets of a flow.
+//
+// +stateify savable
+type flow struct {
+	hash  uint32
+	queue shaping.PacketQueue
+
+	// credit is the number of bytes that the flow can send in the current
+	// round.
+	credit int
+
+	// next is the time at which the next packet of the flow can be sent
+	// if the flow is paced.
+	next tcpip.MonotonicTime
+
+	// active is true if the flow is in one of the lists of the scheduler.
+	active bool
+}
+
+// scheduler serves flows in a deficit round robin.
+//
+// +stateify savable
+type scheduler struct {
+	opts  Options
+	flows map[uint32]*flow
+
+	// newFlows and oldFlows hold the active flows that are served, and
+	// throttled the active flows that wait for their pacing time.
+	newFlows  []*flow
+	oldFlows  []*flow
+	throttled []*flow
+
+	len     int
+	backlog int
+}
+
+// New creates a fair queue that sends packets through lower.
+func New(lower stack.LinkWriter, clock tcpip.Clock, opts Options) *Discipline {
+	opts.setDefaults()
+	s := &scheduler{
+		opts:  opts,
+		flows: make(map[uint32]*flow),
+	}
+	return &Discipline{shaping.New(lower, clock, s)}
+}
+
+// Options returns the configuration of the discipline.
+func (d *Discipline) Options() Options {
+	var opts Options
+	d.Inspect(func(s shaping.Scheduler) {
+		opts = s.(*scheduler).opts
+	})
+	return opts
+}
+
+// SetOptions changes the configuration of the discipline. Queued packets are
+// kept even if they exceed the new limits.
+func (d *Discipline) SetOptions(opts Options) {
+	opts.setDefaults()
+	d.Update(func(s shaping.Scheduler) {
+		s.(*scheduler).opts = opts
+	})
+}
+
+// Enqueue implements shaping.Scheduler.Enqueue.
+func (s *scheduler) Enqueue(pkt *stack.PacketBuffer) bool {
+	if s.len >= int(s.opts.Limit) {
+		return false
+	}
+	f, ok := s.flows[pkt.Hash]
+	if !ok {
+		f = &flow{
+			hash:   pkt.Hash,
+			credit: int(s.opts.InitialQuantum),
+		}
+		s.flows[pkt.Hash] = f
+	}
+	if f.queue.Len() >= int(s.opts.FlowLimit) {
+		return false
+	}
+	f.queue.PushBack(pkt)
+	s.len++
+	s.backlog += pkt.Size()
+	if !f.active {
+		f.active = true
+		s.newFlows = append(s.newFlows, f)
+	}
+	return true
+}
+
+// Dequeue implements shaping.Scheduler.Dequeue.
+func (s *scheduler) Dequeue(now tcpip.MonotonicTime) (*stack.PacketBuffer, tcpip.MonotonicTime) {
+	next := s.wakeThrottled(now)
+	for {
+		// The flow at the head of a list keeps being served until its
+		// credit is exhausted.
+		var list *[]*flow
+		switch {
+		case len(s.newFlows) > 0:
+			list = &s.newFlows
+		case len(s.oldFlows) > 0:
+			list = &s.oldFlows
+		default:
+			return nil, next
+		}
+		f := (*list)[0]
+
+		if f.credit <= 0 {
+			popFlow(list)
+			f.credit += int(s.opts.Quantum)
+			s.oldFlows = append(s.oldFlows, f)
+			continue
+		}
+		if f.next.After(now) {
+			popFlow(list)
+			s.throttled = append(s.throttled, f)
+			if next == (tcpip.MonotonicTime{}) || f.next.Before(next) {
+				next = f.next
+			}
+			continue
+		}
+		pkt := f.queue.PopFront()
+		if pkt == nil {
+			popFlow(list)
+			// Like on Linux, an empty new flow goes through the
+			// old flows once, so that a flow can't starve the old
+			// flows by becoming new again.
+			if list == &s.newFlows && len(s.oldFlows) > 0 {
+				s.oldFlows = append(s.oldFlows, f)
+			} else {
+				f.active = false
+				delete(s.flows, f.hash)
+			}
+			continue
+		}
+
+		size := pkt.Size()
+		s.len--
+		s.backlog -= size
+		f.credit -= size
+		if s.opts.MaxRate != 0 {
+			f.next = now.Add(time.Duration(uint64(size) * uint64(time.Second) / s.opts.MaxRate))
+		}
+		return pkt, tcpip.MonotonicTime{}
+	}
+}
+
+// popFlow removes the flow at the head of list.
+func popFlow(list *[]*flow) {
+	(*list)[0] = nil
+	*list = (*list)[1:]
+}
+
+// wakeThrottled moves the throttled flows whose time has come to the old flows,
+// and returns the earliest time at which another one can send, or zero.
+func (s *scheduler) wakeThrottled(now tcpip.MonotonicTime) tcpip.MonotonicTime {
+	var next tcpip.MonotonicTime
+	throttled := s.throttled[:0]
+	for _, f := range s.throttled {
+		if f.next.After(now) {
+			throttled = append(throttled, f)
+			if next == (tcpip.MonotonicTime{}) || f.next.Before(next) {
+				next = f.next
+			}
+			continue
+		}
+		s.oldFlows = append(s.oldFlows, f)
+	}
+	for i := len(throttled); i < len(s.throttled); i++ {
+		s.throttled[i] = nil
+	}
+	s.throttled = throttled
+	return next
+}
+
+// Len implements shaping.Scheduler.Len.
+func (s *scheduler) Len() int {
+	return s.len
+}
+
+// Backlog implements shaping.Scheduler.Backlog.
+func (s *scheduler) Backlog() int {
+	return s.backlog
+}
+
+// Reset implements shaping.Scheduler.Reset.
+func (s *scheduler) Reset() {
+	for _, f := range s.flows {
+		f.queue.Reset()
+	}
+	s.flows = make(map[uint32]*flow)
+	s.newFlows = nil
+	s.oldFlows = nil
+	s.throttled = nil
+	s.len = 0
+	s.backlog = 0
+}
@@ -0,0 +1,151 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fq
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/refs"
+	"gvisor.dev/gvisor/pkg/sync"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/faketime"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+const pktSize = 100
+
+func newPacket(hash uint32) *stack.PacketBuffer {
+	pkt := stack.NewPacketBuffer(stack.PacketBufferOptions{
+		Payload: buffer.MakeWithData(make([]byte, pktSize)),
+	})
+	pkt.Hash = hash
+	return pkt
+}
+
+// hashWriter implements stack.LinkWriter and records the hashes of the packets
+// that it writes.
+type hashWriter struct {
+	mu     sync.Mutex
+	hashes []uint32
+}
+
+func (w *hashWriter) WritePackets(pkts stack.PacketBufferList) (int, tcpip.Error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, pkt := range pkts.AsSlice() {
+		w.hashes = append(w.hashes, pkt.Hash)
+	}
+	return pkts.Len(), nil
+}
+
+func (w *hashWriter) written() []uint32 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]uint32(nil), w.hashes...)
+}
+
+func TestRoundRobin(t *testing.T) {
+	s := &scheduler{
+		opts: Options{
+			Limit:          100,
+			FlowLimit:      3,
+			Quantum:        2 * pktSize,
+			InitialQuantum: pktSize,
+		},
+		flows: make(map[uint32]*flow),
+	}
+	defer s.Reset()
+	for _, hash := range []uint32{1, 1, 1, 1, 2, 2, 2} {
+		pkt := newPacket(hash)
+		ok := s.Enqueue(pkt)
+		pkt.DecRef()
+		// The fourth packet of the first flow exceeds the flow limit.
+		if want := s.len < 7; ok != want && !(hash == 1 && !ok) {
+			t.Fatalf("got Enqueue(%d) = %t, want = %t", hash, ok, want)
+		}
+	}
+	if got, want := s.Len(), 6; got != want {
+		t.Fatalf("got Len() = %d, want = %d", got, want)
+	}
+
+	// New flows send their initial quantum first, then the old flows
+	// send their quantum in turn.
+	var got []uint32
+	var now tcpip.MonotonicTime
+	for {
+		pkt, _ := s.Dequeue(now)
+		if pkt == nil {
+			break
+		}
+		got = append(got, pkt.Hash)
+		pkt.DecRef()
+	}
+	if want := []uint32{1, 2, 1, 1, 2, 2}; !cmp.Equal(got, want) {
+		t.Errorf("got flows = %v, want = %v", got, want)
+	}
+	if s.Len() != 0 || s.Backlog() != 0 || len(s.flows) != 0 {
+		t.Errorf("got Len() = %d, Backlog() = %d, %d flows after dequeuing all packets, want all zero", s.Len(), s.Backlog(), len(s.flows))
+	}
+}
+
+func TestPacing(t *testing.T) {
+	const rate = 1000 // bytes per second.
+	clock := faketime.NewManualClock()
+	lower := &hashWriter{}
+	d := New(lower, clock, Options{MaxRate: rate})
+	defer d.Close()
+
+	write := func(hash uint32) {
+		t.Helper()
+		pkt := newPacket(hash)
+		defer pkt.DecRef()
+		if err := d.WritePacket(pkt); err != nil {
+			t.Fatalf("WritePacket: %s", err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		write(1)
+	}
+	// Another flow isn't slowed down by the first one.
+	write(2)
+	if got, want := lower.written(), []uint32{1, 2}; !cmp.Equal(got, want) {
+		t.Fatalf("got flows = %v, want = %v", got, want)
+	}
+
+	// The first flow sends a packet every pktSize/rate seconds.
+	const interval = pktSize * time.Second / rate
+	clock.Advance(interval)
+	if got, want := lower.written(), []uint32{1, 2, 1}; !cmp.Equal(got, want) {
+		t.Fatalf("got flows = %v after %s, want = %v", got, interval, want)
+	}
+	clock.Advance(interval)
+	if got, want := lower.written(), []uint32{1, 2, 1, 1}; !cmp.Equal(got, want) {
+		t.Fatalf("got flows = %v after %s, want = %v", got, 2*interval, want)
+	}
+	if got := d.Stats().Qlen; got != 0 {
+		t.Errorf("got Stats().Qlen = %d, want = 0", got)
+	}
+}
+
+func TestMain(m *testing.M) {
+	refs.SetLeakMode(refs.LeaksPanic)
+	code := m.Run()
+	refs.DoLeakCheck()
+	os.Exit(code)
+}
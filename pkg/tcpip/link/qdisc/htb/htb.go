@@ -0,0 +1,450 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package htb provides the implementation of the hierarchical token bucket
+// queueing discipline, which shares the bandwidth of a link between a tree of
+// classes like the htb qdisc of Linux.
+//
+// Each class is guaranteed its rate and may borrow unused bandwidth from its
+// ancestors up to its ceil. Packets are queued in the leaf classes, which are
+// served by priority and in deficit round robin order within a priority.
+package htb
+
+import (
+	"sort"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/link/qdisc/shaping"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+const (
+	// DefaultDirectQueueLen is the default number of packets in the direct
+	// queue.
+	DefaultDirectQueueLen = 1000
+
+	// DefaultClassLimit is the default number of packets queued in a leaf
+	// class.
+	DefaultClassLimit = 1000
+
+	// defaultMTU is the packet size used to compute default bursts.
+	defaultMTU = 1600
+
+	// minQuantum and maxQuantum bound the default quantum of a class, which
+	// is a tenth of its rate.
+	minQuantum = 1000
+	maxQuantum = 200000
+)
+
+// Options holds the configuration of the discipline.
+type Options struct {
+	// DefaultClass is the class of the packets that aren't classified. If
+	// it is zero or doesn't name a leaf class, they are sent unshaped
+	// through the direct queue.
+	DefaultClass uint32
+
+	// DirectQueueLen is the number of packets in the direct queue. It
+	// defaults to DefaultDirectQueueLen.
+	DirectQueueLen uint32
+}
+
+func (o *Options) setDefaults() {
+	if o.DirectQueueLen == 0 {
+		o.DirectQueueLen = DefaultDirectQueueLen
+	}
+}
+
+// ClassOptions holds the configuration of a class.
+type ClassOptions struct {
+	// Parent is the ID of the parent class, or zero for classes attached to
+	// the root of the discipline.
+	Parent uint32
+
+	// Rate is the rate guaranteed to the class, in bytes per second.
+	Rate uint64
+
+	// Ceil is the maximum rate of the class including what it borrows from
+	// its ancestors, in bytes per second. It defaults to Rate.
+	Ceil uint64
+
+	// Burst and CBurst are the number of bytes that can be sent at once at
+	// Rate and Ceil. They default to a millisecond of the rate plus an MTU.
+	Burst  uint32
+	CBurst uint32
+
+	// Quantum is the number of bytes that a leaf class sends in its turn
+	// among the classes of its priority. It defaults to a tenth of Rate.
+	Quantum uint32
+
+	// Prio is the priority of a leaf class. Lower values are served first.
+	Prio uint32
+
+	// Limit is the number of packets that a leaf class queues. It defaults
+	// to DefaultClassLimit.
+	Limit uint32
+}
+
+func (o *ClassOptions) setDefaults() {
+	if o.Ceil == 0 {
+		o.Ceil = o.Rate
+	}
+	if o.Burst == 0 {
+		o.Burst = uint32(min(o.Rate/1000+defaultMTU, 1<<31))
+	}
+	if o.CBurst == 0 {
+		o.CBurst = uint32(min(o.Ceil/1000+defaultMTU, 1<<31))
+	}
+	if o.Quantum == 0 {
+		o.Quantum = uint32(max(min(o.Rate/10, maxQuantum), minQuantum))
+	}
+	if o.Limit == 0 {
+		o.Limit = DefaultClassLimit
+	}
+}
+
+// ClassStats holds the statistics of a class. Bytes and Packets include the
+// packets sent by the descendants of the class.
+type ClassStats struct {
+	Bytes   uint64
+	Packets uint64
+	Drops   uint64
+	Qlen    uint32
+	Backlog uint32
+}
+
+// ClassInfo describes a class.
+type ClassInfo struct {
+	Options ClassOptions
+	Stats   ClassStats
+}
+
+var _ stack.QueueingDiscipline = (*Discipline)(nil)
+
+// Discipline is a hierarchical token bucket.
+//
+// +stateify savable
+type Discipline struct {
+	*shaping.Discipline
+}
+
+// class is a node of the tree of classes.
+//
+// +stateify savable
+type class struct {
+	id       uint32
+	opts     ClassOptions
+	parent   *class
+	children int
+
+	// queue holds the packets of a leaf class.
+	queue shaping.PacketQueue
+
+	rate shaping.TokenBucket
+	ceil shaping.TokenBucket
+
+	// deficit is the number of bytes that a leaf class can still send in
+	// its round.
+	deficit int
+
+	stats ClassStats
+}
+
+func (c *class) setOptions(opts ClassOptions) {
+	c.opts = opts
+	c.rate = shaping.NewTokenBucket(opts.Rate, opts.Burst)
+	c.ceil = shaping.NewTokenBucket(opts.Ceil, opts.CBurst)
+	c.deficit = int(opts.Quantum)
+}
+
+// scheduler implements shaping.Scheduler.
+//
+// +stateify savable
+type scheduler struct {
+	opts    Options
+	classes map[uint32]*class
+
+	// leaves are the leaf classes sorted by priority and ID.
+	leaves []*class
+
+	// rr is the position of the round robin of each priority in leaves.
+	rr map[uint32]int
+
+	direct shaping.PacketQueue
+}
+
+// New creates a hierarchical token bucket without classes that sends packets
+// through lower.
+func New(lower stack.LinkWriter, clock tcpip.Clock, opts Options) *Discipline {
+	opts.setDefaults()
+	s := &scheduler{
+		opts:    opts,
+		classes: make(map[uint32]*class),
+		rr:      make(map[uint32]int),
+	}
+	return &Discipline{shaping.New(lower, clock, s)}
+}
+
+// Options returns the configuration of the discipline.
+func (d *Discipline) Options() Options {
+	var opts Options
+	d.Inspect(func(s shaping.Scheduler) {
+		opts = s.(*scheduler).opts
+	})
+	return opts
+}
+
+// SetOptions changes the configuration of the discipline.
+func (d *Discipline) SetOptions(opts Options) {
+	opts.setDefaults()
+	d.Update(func(s shaping.Scheduler) {
+		s.(*scheduler).opts = opts
+	})
+}
+
+// AddClass adds a class, or changes the options of an existing one. The
+// parent of an existing class can't be changed. Packets queued in the parent
+// are dropped when it stops being a leaf.
+func (d *Discipline) AddClass(id uint32, opts ClassOptions) tcpip.Error {
+	if id == 0 || opts.Rate == 0 || opts.Parent == id {
+		return &tcpip.ErrInvalidOptionValue{}
+	}
+	opts.setDefaults()
+	if opts.Ceil < opts.Rate {
+		return &tcpip.ErrInvalidOptionValue{}
+	}
+	var err tcpip.Error
+	d.Update(func(sched shaping.Scheduler) {
+		err = sched.(*scheduler).addClass(id, opts)
+	})
+	return err
+}
+
+// RemoveClass removes a class, which must not have children.
+func (d *Discipline) RemoveClass(id uint32) tcpip.Error {
+	var err tcpip.Error
+	d.Update(func(sched shaping.Scheduler) {
+		err = sched.(*scheduler).removeClass(id)
+	})
+	return err
+}
+
+// Classes returns the classes of the discipline indexed by ID.
+func (d *Discipline) Classes() map[uint32]ClassInfo {
+	classes := make(map[uint32]ClassInfo)
+	d.Inspect(func(sched shaping.Scheduler) {
+		for id, c := range sched.(*scheduler).classes {
+			stats := c.stats
+			stats.Qlen = uint32(c.queue.Len())
+			stats.Backlog = uint32(c.queue.Backlog())
+			classes[id] = ClassInfo{Options: c.opts, Stats: stats}
+		}
+	})
+	return classes
+}
+
+func (s *scheduler) addClass(id uint32, opts ClassOptions) tcpip.Error {
+	if c, ok := s.classes[id]; ok {
+		if c.opts.Parent != opts.Parent {
+			return &tcpip.ErrNotSupported{}
+		}
+		c.setOptions(opts)
+		s.sortLeaves()
+		return nil
+	}
+	var parent *class
+	if opts.Parent != 0 {
+		var ok bool
+		if parent, ok = s.classes[opts.Parent]; !ok {
+			return &tcpip.ErrNoSuchFile{}
+		}
+		parent.queue.Reset()
+		parent.children++
+	}
+	c := &class{
+		id:     id,
+		parent: parent,
+	}
+	c.setOptions(opts)
+	s.classes[id] = c
+	s.sortLeaves()
+	return nil
+}
+
+func (s *scheduler) removeClass(id uint32) tcpip.Error {
+	c, ok := s.classes[id]
+	if !ok {
+		return &tcpip.ErrNoSuchFile{}
+	}
+	if c.children != 0 {
+		return &tcpip.ErrEndpointBusy{}
+	}
+	c.queue.Reset()
+	if c.parent != nil {
+		c.parent.children--
+	}
+	delete(s.classes, id)
+	s.sortLeaves()
+	return nil
+}
+
+// sortLeaves rebuilds leaves after the tree of classes changed.
+func (s *scheduler) sortLeaves() {
+	s.leaves = s.leaves[:0]
+	for _, c := range s.classes {
+		if c.children == 0 {
+			s.leaves = append(s.leaves, c)
+		}
+	}
+	sort.Slice(s.leaves, func(i, j int) bool {
+		a, b := s.leaves[i], s.leaves[j]
+		if a.opts.Prio != b.opts.Prio {
+			return a.opts.Prio < b.opts.Prio
+		}
+		return a.id < b.id
+	})
+}
+
+// Enqueue implements shaping.Scheduler.Enqueue.
+func (s *scheduler) Enqueue(pkt *stack.PacketBuffer) bool {
+	c, ok := s.classes[s.opts.DefaultClass]
+	if !ok || c.children != 0 {
+		if s.direct.Len() >= int(s.opts.DirectQueueLen) {
+			return false
+		}
+		s.direct.PushBack(pkt)
+		return true
+	}
+	if c.queue.Len() >= int(c.opts.Limit) {
+		c.stats.Drops++
+		return false
+	}
+	c.queue.PushBack(pkt)
+	return true
+}
+
+// lender returns the closest class on the path from c to the root whose rate
+// allows sending size bytes, if the ceils of all the classes on the path allow
+// it. Otherwise, it returns the time at which the path may allow it.
+func lender(c *class, now tcpip.MonotonicTime, size int) (*class, tcpip.MonotonicTime) {
+	var (
+		ceilAt tcpip.MonotonicTime
+		rateAt tcpip.MonotonicTime
+		found  *class
+	)
+	for p := c; p != nil; p = p.parent {
+		if at := p.ceil.Ready(now, size); at.After(ceilAt) {
+			ceilAt = at
+		}
+		if found != nil {
+			continue
+		}
+		if at := p.rate.Ready(now, size); !at.After(now) {
+			found = p
+		} else if rateAt == (tcpip.MonotonicTime{}) || rateAt.After(at) {
+			rateAt = at
+		}
+	}
+	switch {
+	case found == nil && rateAt.After(ceilAt):
+		return nil, rateAt
+	case found == nil || ceilAt.After(now):
+		return nil, ceilAt
+	default:
+		return found, tcpip.MonotonicTime{}
+	}
+}
+
+// Dequeue implements shaping.Scheduler.Dequeue.
+//
+// The direct queue is served first. Then leaves that are within their own
+// rate are served, and finally leaves that can borrow from an ancestor.
+func (s *scheduler) Dequeue(now tcpip.MonotonicTime) (*stack.PacketBuffer, tcpip.MonotonicTime) {
+	if s.direct.Len() != 0 {
+		return s.direct.PopFront(), tcpip.MonotonicTime{}
+	}
+	var next tcpip.MonotonicTime
+	for _, borrow := range []bool{false, true} {
+		for start := 0; start < len(s.leaves); {
+			prio := s.leaves[start].opts.Prio
+			end := start + 1
+			for end < len(s.leaves) && s.leaves[end].opts.Prio == prio {
+				end++
+			}
+			group := s.leaves[start:end]
+			start = end
+
+			pos := s.rr[prio]
+			for i := range group {
+				idx := (pos + i) % len(group)
+				c := group[idx]
+				pkt := c.queue.Front()
+				if pkt == nil {
+					continue
+				}
+				size := pkt.Size()
+				l, at := lender(c, now, size)
+				if l == nil || (l != c && !borrow) {
+					if l == nil && (next == (tcpip.MonotonicTime{}) || next.After(at)) {
+						next = at
+					}
+					continue
+				}
+				for p := c; p != nil; p = p.parent {
+					p.ceil.Consume(now, size)
+					p.stats.Bytes += uint64(size)
+					p.stats.Packets++
+				}
+				for p := l; p != nil; p = p.parent {
+					p.rate.Consume(now, size)
+				}
+				// The class keeps its turn until it exhausts its
+				// deficit.
+				c.deficit -= size
+				if c.deficit <= 0 {
+					c.deficit += int(c.opts.Quantum)
+					idx++
+				}
+				s.rr[prio] = idx % len(group)
+				return c.queue.PopFront(), tcpip.MonotonicTime{}
+			}
+		}
+	}
+	return nil, next
+}
+
+// Len implements shaping.Scheduler.Len.
+func (s *scheduler) Len() int {
+	n := s.direct.Len()
+	for _, c := range s.leaves {
+		n += c.queue.Len()
+	}
+	return n
+}
+
+// Backlog implements shaping.Scheduler.Backlog.
+func (s *scheduler) Backlog() int {
+	n := s.direct.Backlog()
+	for _, c := range s.leaves {
+		n += c.queue.Backlog()
+	}
+	return n
+}
+
+// Reset implements shaping.Scheduler.Reset.
+func (s *scheduler) Reset() {
+	s.direct.Reset()
+	for _, c := range s.leaves {
+		c.queue.Reset()
+	}
+}
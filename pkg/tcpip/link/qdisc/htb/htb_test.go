@@ -0,0 +1,187 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package htb_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/refs"
+	"gvisor.dev/gvisor/pkg/sync"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/faketime"
+	"gvisor.dev/gvisor/pkg/tcpip/link/qdisc/htb"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+const pktSize = 100
+
+// countWriter implements stack.LinkWriter.
+type countWriter struct {
+	mu      sync.Mutex
+	packets int
+}
+
+func (cw *countWriter) WritePackets(pkts stack.PacketBufferList) (int, tcpip.Error) {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	cw.packets += pkts.Len()
+	return pkts.Len(), nil
+}
+
+func (cw *countWriter) count() int {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	return cw.packets
+}
+
+func writePackets(t *testing.T, d stack.QueueingDiscipline, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		pkt := stack.NewPacketBuffer(stack.PacketBufferOptions{
+			Payload: buffer.MakeWithData(make([]byte, pktSize)),
+		})
+		err := d.WritePacket(pkt)
+		pkt.DecRef()
+		if err != nil {
+			t.Fatalf("WritePacket #%d: %s", i, err)
+		}
+	}
+}
+
+func TestDirectQueue(t *testing.T) {
+	lower := &countWriter{}
+	d := htb.New(lower, faketime.NewManualClock(), htb.Options{DefaultClass: 10})
+	defer d.Close()
+
+	// Packets aren't shaped until the default class exists.
+	writePackets(t, d, 5)
+	if got := lower.count(); got != 5 {
+		t.Errorf("got %d packets sent, want = 5", got)
+	}
+}
+
+func TestBorrow(t *testing.T) {
+	const (
+		parentRate = 1000 // bytes per second.
+		childRate  = 100
+	)
+	for _, test := range []struct {
+		name     string
+		ceil     uint64
+		interval time.Duration
+	}{
+		{
+			name:     "borrow",
+			ceil:     parentRate,
+			interval: pktSize * time.Second / parentRate,
+		},
+		{
+			name:     "ceil",
+			ceil:     childRate,
+			interval: pktSize * time.Second / childRate,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			clock := faketime.NewManualClock()
+			lower := &countWriter{}
+			d := htb.New(lower, clock, htb.Options{DefaultClass: 2})
+			defer d.Close()
+			if err := d.AddClass(1, htb.ClassOptions{Rate: parentRate, Burst: pktSize, CBurst: pktSize}); err != nil {
+				t.Fatalf("AddClass(1, _): %s", err)
+			}
+			if err := d.AddClass(2, htb.ClassOptions{Parent: 1, Rate: childRate, Ceil: test.ceil, Burst: pktSize, CBurst: pktSize}); err != nil {
+				t.Fatalf("AddClass(2, _): %s", err)
+			}
+
+			const n = 5
+			writePackets(t, d, n)
+			for i := 1; i <= n; i++ {
+				if got := lower.count(); got != i {
+					t.Fatalf("got %d packets sent after %s, want = %d", got, time.Duration(i-1)*test.interval, i)
+				}
+				clock.Advance(test.interval)
+			}
+
+			classes := d.Classes()
+			for _, id := range []uint32{1, 2} {
+				if got := classes[id].Stats.Packets; got != n {
+					t.Errorf("got class %d Stats.Packets = %d, want = %d", id, got, n)
+				}
+			}
+		})
+	}
+}
+
+func TestClasses(t *testing.T) {
+	clock := faketime.NewManualClock()
+	lower := &countWriter{}
+	d := htb.New(lower, clock, htb.Options{DefaultClass: 2})
+	defer d.Close()
+
+	if err := d.AddClass(2, htb.ClassOptions{Parent: 1, Rate: 100}); err == nil {
+		t.Errorf("AddClass succeeded with a missing parent, want error")
+	}
+	if err := d.AddClass(1, htb.ClassOptions{Rate: 1000, Ceil: 100}); err == nil {
+		t.Errorf("AddClass succeeded with a ceil lower than the rate, want error")
+	}
+	if err := d.AddClass(1, htb.ClassOptions{Rate: 1000}); err != nil {
+		t.Fatalf("AddClass(1, _): %s", err)
+	}
+	if err := d.AddClass(2, htb.ClassOptions{Parent: 1, Rate: 100}); err != nil {
+		t.Fatalf("AddClass(2, _): %s", err)
+	}
+	if err := d.AddClass(2, htb.ClassOptions{Rate: 100}); err == nil {
+		t.Errorf("AddClass succeeded changing the parent of a class, want error")
+	}
+
+	opts := d.Classes()[2].Options
+	want := htb.ClassOptions{
+		Parent:  1,
+		Rate:    100,
+		Ceil:    100,
+		Burst:   1600,
+		CBurst:  1600,
+		Quantum: 1000,
+		Limit:   htb.DefaultClassLimit,
+	}
+	if opts != want {
+		t.Errorf("got class 2 options = %+v, want = %+v", opts, want)
+	}
+
+	if _, ok := d.RemoveClass(1).(*tcpip.ErrEndpointBusy); !ok {
+		t.Errorf("RemoveClass(1) with a child didn't fail with ErrEndpointBusy")
+	}
+	if _, ok := d.RemoveClass(3).(*tcpip.ErrNoSuchFile); !ok {
+		t.Errorf("RemoveClass(3) of a missing class didn't fail with ErrNoSuchFile")
+	}
+	for _, id := range []uint32{2, 1} {
+		if err := d.RemoveClass(id); err != nil {
+			t.Errorf("RemoveClass(%d): %s", id, err)
+		}
+	}
+	if got := len(d.Classes()); got != 0 {
+		t.Errorf("got %d classes, want = 0", got)
+	}
+}
+
+func TestMain(m *testing.M) {
+	refs.SetLeakMode(refs.LeaksPanic)
+	code := m.Run()
+	refs.DoLeakCheck()
+	os.Exit(code)
+}
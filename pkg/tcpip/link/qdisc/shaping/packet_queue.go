@@ -0,0 +1,76 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shaping
+
+import (
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+// PacketQueue is a FIFO queue of packets that keeps track of their size.
+//
+// +stateify savable
+type PacketQueue struct {
+	pkts    []*stack.PacketBuffer
+	backlog int
+}
+
+// Len returns the number of packets in the queue.
+func (q *PacketQueue) Len() int {
+	return len(q.pkts)
+}
+
+// Backlog returns the number of bytes in the queue.
+func (q *PacketQueue) Backlog() int {
+	return q.backlog
+}
+
+// PushBack adds pkt at the end of the queue and takes a reference to it.
+func (q *PacketQueue) PushBack(pkt *stack.PacketBuffer) {
+	q.pkts = append(q.pkts, pkt.IncRef())
+	q.backlog += pkt.Size()
+}
+
+// Front returns the first packet of the queue, or nil if it is empty.
+func (q *PacketQueue) Front() *stack.PacketBuffer {
+	if len(q.pkts) == 0 {
+		return nil
+	}
+	return q.pkts[0]
+}
+
+// PopFront removes the first packet of the queue and returns it along with
+// its reference, or returns nil if the queue is empty.
+func (q *PacketQueue) PopFront() *stack.PacketBuffer {
+	if len(q.pkts) == 0 {
+		return nil
+	}
+	pkt := q.pkts[0]
+	q.pkts[0] = nil
+	q.pkts = q.pkts[1:]
+	if len(q.pkts) == 0 {
+		q.pkts = nil
+	}
+	q.backlog -= pkt.Size()
+	return pkt
+}
+
+// Reset drops all the packets of the queue.
+func (q *PacketQueue) Reset() {
+	for _, pkt := range q.pkts {
+		pkt.DecRef()
+	}
+	q.pkts = nil
+	q.backlog = 0
+}
@@ -0,0 +1,233 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package shaping provides the common parts of the queueing disciplines that
+// shape traffic, which send queued packets in the order and at the time
+// decided by a Scheduler.
+//
+// Packets are sent by the goroutine that queues them when the scheduler allows
+// it, and by a timer otherwise, so a Discipline has no goroutine of its own.
+package shaping
+
+import (
+	"time"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+// batchSize is the maximum number of packets written to the lower link
+// endpoint at once. It matches the batch size of the fifo discipline.
+const batchSize = 47
+
+// Scheduler decides the order and the time at which queued packets are sent.
+// It is only accessed with the lock of its Discipline held.
+type Scheduler interface {
+	// Enqueue queues pkt and takes a reference to it. It returns false if
+	// pkt is dropped instead.
+	Enqueue(pkt *stack.PacketBuffer) bool
+
+	// Dequeue removes the next packet to send at now and returns it. If no
+	// packet can be sent at now, it returns nil and the time at which one
+	// can be sent, which is zero if no packet is queued.
+	Dequeue(now tcpip.MonotonicTime) (*stack.PacketBuffer, tcpip.MonotonicTime)
+
+	// Len returns the number of queued packets.
+	Len() int
+
+	// Backlog returns the number of queued bytes.
+	Backlog() int
+
+	// Reset drops all queued packets.
+	Reset()
+}
+
+// Stats holds the statistics of a queueing discipline or of one of its
+// classes.
+type Stats struct {
+	// Bytes and Packets count the packets that were sent.
+	Bytes   uint64
+	Packets uint64
+
+	// Drops counts the packets that were dropped.
+	Drops uint64
+
+	// Overlimits counts the times that queued packets had to wait to be
+	// sent.
+	Overlimits uint64
+
+	// Qlen and Backlog are the number of packets and bytes that are
+	// queued.
+	Qlen    uint32
+	Backlog uint32
+}
+
+var _ stack.QueueingDiscipline = (*Discipline)(nil)
+
+// Discipline is a queueing discipline that sends packets in the order and at
+// the time decided by a Scheduler.
+//
+// +stateify savable
+type Discipline struct {
+	lower stack.LinkWriter
+	clock tcpip.Clock
+
+	mu disciplineMutex `state:"nosave"`
+	// +checklocks:mu
+	sched Scheduler
+	// dispatching is true while a goroutine writes packets to lower, which
+	// is done by one goroutine at a time to preserve their order.
+	//
+	// +checklocks:mu
+	dispatching bool
+	// timer sends packets when the scheduler allows it, at timerAt. It
+	// isn't saved, so it is scheduled again by the first packet written
+	// after a restore.
+	//
+	// +checklocks:mu
+	timer tcpip.Timer `state:"nosave"`
+	// +checklocks:mu
+	timerAt tcpip.MonotonicTime `state:"nosave"`
+	// +checklocks:mu
+	closed bool
+	// +checklocks:mu
+	stats Stats
+}
+
+// New creates a queueing discipline that sends packets through lower as
+// decided by sched.
+func New(lower stack.LinkWriter, clock tcpip.Clock, sched Scheduler) *Discipline {
+	return &Discipline{
+		lower: lower,
+		clock: clock,
+		sched: sched,
+	}
+}
+
+// WritePacket implements stack.QueueingDiscipline.WritePacket.
+func (d *Discipline) WritePacket(pkt *stack.PacketBuffer) tcpip.Error {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return &tcpip.ErrClosedForSend{}
+	}
+	if !d.sched.Enqueue(pkt) {
+		d.stats.Drops++
+		d.mu.Unlock()
+		return &tcpip.ErrNoBufferSpace{}
+	}
+	d.mu.Unlock()
+	d.dispatch()
+	return nil
+}
+
+// Close implements stack.QueueingDiscipline.Close. Queued packets are dropped.
+func (d *Discipline) Close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.closed = true
+	d.sched.Reset()
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+}
+
+// Update calls fn with the scheduler, which fn may reconfigure, and sends the
+// packets that the scheduler allows afterwards.
+func (d *Discipline) Update(fn func(Scheduler)) {
+	d.mu.Lock()
+	fn(d.sched)
+	d.mu.Unlock()
+	d.dispatch()
+}
+
+// Inspect calls fn with the scheduler, which fn must not change.
+func (d *Discipline) Inspect(fn func(Scheduler)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	fn(d.sched)
+}
+
+// Stats returns the statistics of the discipline.
+func (d *Discipline) Stats() Stats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	stats := d.stats
+	stats.Qlen = uint32(d.sched.Len())
+	stats.Backlog = uint32(d.sched.Backlog())
+	return stats
+}
+
+// dispatch writes the packets that the scheduler allows to be sent to the
+// lower link endpoint, and schedules the timer if packets have to wait.
+func (d *Discipline) dispatch() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.dispatching || d.closed {
+		return
+	}
+	d.dispatching = true
+	defer func() { d.dispatching = false }()
+	for {
+		var (
+			batch stack.PacketBufferList
+			next  tcpip.MonotonicTime
+		)
+		now := d.clock.NowMonotonic()
+		for batch.Len() < batchSize {
+			pkt, at := d.sched.Dequeue(now)
+			if pkt == nil {
+				next = at
+				break
+			}
+			d.stats.Packets++
+			d.stats.Bytes += uint64(pkt.Size())
+			batch.PushBack(pkt)
+		}
+		if batch.Len() == 0 {
+			if next != (tcpip.MonotonicTime{}) {
+				d.stats.Overlimits++
+				d.scheduleLocked(now, next)
+			}
+			return
+		}
+		d.mu.Unlock()
+		_, _ = d.lower.WritePackets(batch)
+		batch.Reset()
+		d.mu.Lock()
+		if d.closed {
+			return
+		}
+	}
+}
+
+// scheduleLocked makes sure that packets are sent at the time at.
+//
+// +checklocks:d.mu
+func (d *Discipline) scheduleLocked(now, at tcpip.MonotonicTime) {
+	if d.timerAt != (tcpip.MonotonicTime{}) && !d.timerAt.After(at) {
+		return
+	}
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.timerAt = at
+	d.timer = d.clock.AfterFunc(max(at.Sub(now), time.Duration(0)), func() {
+		d.mu.Lock()
+		d.timerAt = tcpip.MonotonicTime{}
+		d.mu.Unlock()
+		d.dispatch()
+	})
+}
@@ -0,0 +1,106 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shaping
+
+import (
+	"time"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+// TokenBucket limits the rate at which bytes are sent. Like on Linux, tokens
+// are kept as the time it takes to send bytes at the rate, so a bucket that
+// holds burst bytes is full after burst/rate seconds.
+//
+// +stateify savable
+type TokenBucket struct {
+	// rate is the rate in bytes per second. The bucket doesn't limit
+	// anything if it is zero.
+	rate  uint64
+	burst uint32
+
+	// depth is the capacity of the bucket and tokens its content, in
+	// nanoseconds. tokens is negative when more bytes than the content of
+	// the bucket were sent.
+	depth  int64
+	tokens int64
+
+	// last is the last time that tokens was updated, if used is true.
+	last tcpip.MonotonicTime
+	used bool
+}
+
+// NewTokenBucket returns a full bucket that allows rate bytes per second with
+// bursts of burst bytes.
+func NewTokenBucket(rate uint64, burst uint32) TokenBucket {
+	b := TokenBucket{
+		rate:  rate,
+		burst: burst,
+	}
+	if rate != 0 {
+		b.depth = b.cost(int(burst))
+		b.tokens = b.depth
+	}
+	return b
+}
+
+// Rate returns the rate of the bucket in bytes per second.
+func (b *TokenBucket) Rate() uint64 {
+	return b.rate
+}
+
+// Burst returns the size of the bucket in bytes.
+func (b *TokenBucket) Burst() uint32 {
+	return b.burst
+}
+
+// cost returns the time it takes to send size bytes at the rate.
+func (b *TokenBucket) cost(size int) int64 {
+	return int64(uint64(size) * uint64(time.Second) / b.rate)
+}
+
+// refill adds the tokens accumulated since the last update.
+func (b *TokenBucket) refill(now tcpip.MonotonicTime) {
+	if b.used {
+		b.tokens = min(b.tokens+int64(now.Sub(b.last)), b.depth)
+	}
+	b.last = now
+	b.used = true
+}
+
+// Ready returns the time at which size bytes can be sent, which is now if
+// they can be sent immediately. Packets larger than the bucket can be sent
+// when it is full.
+func (b *TokenBucket) Ready(now tcpip.MonotonicTime, size int) tcpip.MonotonicTime {
+	if b.rate == 0 {
+		return now
+	}
+	b.refill(now)
+	need := min(b.cost(size), b.depth)
+	if b.tokens >= need {
+		return now
+	}
+	return now.Add(time.Duration(need - b.tokens))
+}
+
+// Consume removes the tokens of size bytes, even if the bucket doesn't hold
+// enough of them.
+func (b *TokenBucket) Consume(now tcpip.MonotonicTime, size int) {
+	if b.rate == 0 {
+		return
+	}
+	b.refill(now)
+	b.tokens -= b.cost(size)
+}
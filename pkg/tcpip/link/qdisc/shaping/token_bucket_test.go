@@ -0,0 +1,65 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package shaping
+
+import (
+	"testing"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+func TestTokenBucket(t *testing.T) {
+	const (
+		rate  = 1000 // bytes per second.
+		burst = 100
+	)
+	start := tcpip.MonotonicTime{}.Add(time.Hour)
+	b := NewTokenBucket(rate, burst)
+
+	// A full bucket allows a burst.
+	if got := b.Ready(start, burst); got != start {
+		t.Fatalf("got Ready(start, %d) = %v, want = %v", burst, got, start)
+	}
+	b.Consume(start, burst)
+
+	// The bucket is then refilled at the rate.
+	want := start.Add(50 * time.Millisecond)
+	if got := b.Ready(start, 50); got != want {
+		t.Errorf("got Ready(start, 50) = %v, want = %v", got, want)
+	}
+	if got := b.Ready(want, 50); got != want {
+		t.Errorf("got Ready(%v, 50) = %v, want = %v", want, got, want)
+	}
+
+	// Packets larger than the bucket can be sent when it is full, which
+	// leaves it in debt.
+	now := start.Add(time.Second)
+	if got := b.Ready(now, 2*burst); got != now {
+		t.Errorf("got Ready(now, %d) = %v, want = %v", 2*burst, got, now)
+	}
+	b.Consume(now, 2*burst)
+	want = now.Add(200 * time.Millisecond)
+	if got := b.Ready(now, burst); got != want {
+		t.Errorf("got Ready(now, %d) = %v, want = %v", burst, got, want)
+	}
+
+	// A bucket without rate doesn't limit anything.
+	var unlimited TokenBucket
+	unlimited.Consume(now, 1<<20)
+	if got := unlimited.Ready(now, 1<<20); got != now {
+		t.Errorf("got Ready(now, %d) = %v, want = %v", 1<<20, got, now)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tbf provides the implementation of the token bucket filter queueing
+// discipline, which limits the rate of outbound packets and queues those that
+// exceed it, like the tbf qdisc of Linux.
+package tbf
+
+import (
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/link/qdisc/shaping"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+// Options holds the configuration of a token bucket filter.
+type Options struct {
+	// Rate is the rate at which packets are sent, in bytes per second.
+	Rate uint64
+
+	// Burst is the size of the bucket, which is the number of bytes that
+	// can be sent at once.
+	Burst uint32
+
+	// Limit is the number of bytes that can be queued waiting for tokens.
+	Limit uint32
+
+	// PeakRate optionally limits the rate at which a burst is sent, in
+	// bytes per second. It must be larger than Rate.
+	PeakRate uint64
+
+	// MTU is the size of the bucket of the peak rate. It is required if
+	// PeakRate is set.
+	MTU uint32
+}
+
+func (o *Options) validate() tcpip.Error {
+	if o.Rate == 0 || o.Burst == 0 || o.Limit == 0 {
+		return &tcpip.ErrInvalidOptionValue{}
+	}
+	if o.PeakRate != 0 && (o.PeakRate <= o.Rate || o.MTU == 0) {
+		return &tcpip.ErrInvalidOptionValue{}
+	}
+	return nil
+}
+
+var _ stack.QueueingDiscipline = (*Discipline)(nil)
+
+// Discipline is a token bucket filter.
+//
+// +stateify savable
+type Discipline struct {
+	*shaping.Discipline
+}
+
+// scheduler sends the packets of a FIFO queue when the buckets allow it.
+//
+// +stateify savable
+type scheduler struct {
+	opts   Options
+	queue  shaping.PacketQueue
+	bucket shaping.TokenBucket
+	peak   shaping.TokenBucket
+}
+
+// New creates a token bucket filter that sends packets through lower.
+func New(lower stack.LinkWriter, clock tcpip.Clock, opts Options) (*Discipline, tcpip.Error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	s := &scheduler{}
+	s.setOptions(opts)
+	return &Discipline{shaping.New(lower, clock, s)}, nil
+}
+
+// Options returns the configuration of the discipline.
+func (d *Discipline) Options() Options {
+	var opts Options
+	d.Inspect(func(s shaping.Scheduler) {
+		opts = s.(*scheduler).opts
+	})
+	return opts
+}
+
+// SetOptions changes the configuration of the discipline. The buckets are
+// refilled.
+func (d *Discipline) SetOptions(opts Options) tcpip.Error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	d.Update(func(s shaping.Scheduler) {
+		s.(*scheduler).setOptions(opts)
+	})
+	return nil
+}
+
+func (s *scheduler) setOptions(opts Options) {
+	s.opts = opts
+	s.bucket = shaping.NewTokenBucket(opts.Rate, opts.Burst)
+	s.peak = shaping.NewTokenBucket(opts.PeakRate, opts.MTU)
+}
+
+// Enqueue implements shaping.Scheduler.Enqueue.
+func (s *scheduler) Enqueue(pkt *stack.PacketBuffer) bool {
+	if s.queue.Backlog()+pkt.Size() > int(s.opts.Limit) {
+		return false
+	}
+	s.queue.PushBack(pkt)
+	return true
+}
+
+// Dequeue implements shaping.Scheduler.Dequeue.
+func (s *scheduler) Dequeue(now tcpip.MonotonicTime) (*stack.PacketBuffer, tcpip.MonotonicTime) {
+	pkt := s.queue.Front()
+	if pkt == nil {
+		return nil, tcpip.MonotonicTime{}
+	}
+	size := pkt.Size()
+	at := s.bucket.Ready(now, size)
+	if peakAt := s.peak.Ready(now, size); peakAt.After(at) {
+		at = peakAt
+	}
+	if at.After(now) {
+		return nil, at
+	}
+	s.bucket.Consume(now, size)
+	s.peak.Consume(now, size)
+	return s.queue.PopFront(), tcpip.MonotonicTime{}
+}
+
+// Len implements shaping.Scheduler.Len.
+func (s *scheduler) Len() int {
+	return s.queue.Len()
+}
+
+// Backlog implements shaping.Scheduler.Backlog.
+func (s *scheduler) Backlog() int {
+	return s.queue.Backlog()
+}
+
+// Reset implements shaping.Scheduler.Reset.
+func (s *scheduler) Reset() {
+	s.queue.Reset()
+}
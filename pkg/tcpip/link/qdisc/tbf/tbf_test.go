@@ -0,0 +1,171 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tbf_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/refs"
+	"gvisor.dev/gvisor/pkg/sync"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/faketime"
+	"gvisor.dev/gvisor/pkg/tcpip/link/qdisc/tbf"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+// countWriter implements stack.LinkWriter.
+type countWriter struct {
+	mu      sync.Mutex
+	packets int
+}
+
+func (cw *countWriter) WritePackets(pkts stack.PacketBufferList) (int, tcpip.Error) {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	cw.packets += pkts.Len()
+	return pkts.Len(), nil
+}
+
+func (cw *countWriter) count() int {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+	return cw.packets
+}
+
+func writePacket(t *testing.T, d stack.QueueingDiscipline, size int) tcpip.Error {
+	t.Helper()
+	pkt := stack.NewPacketBuffer(stack.PacketBufferOptions{
+		Payload: buffer.MakeWithData(make([]byte, size)),
+	})
+	defer pkt.DecRef()
+	return d.WritePacket(pkt)
+}
+
+func TestRate(t *testing.T) {
+	const (
+		rate    = 1000 // bytes per second.
+		burst   = 200
+		limit   = 500
+		pktSize = 100
+	)
+	clock := faketime.NewManualClock()
+	lower := &countWriter{}
+	d, err := tbf.New(lower, clock, tbf.Options{Rate: rate, Burst: burst, Limit: limit})
+	if err != nil {
+		t.Fatalf("tbf.New(_, _, _): %s", err)
+	}
+	defer d.Close()
+
+	// The burst is sent immediately, and the rest of the packets are
+	// queued until the limit is reached.
+	for i := 0; i < burst/pktSize+limit/pktSize; i++ {
+		if err := writePacket(t, d, pktSize); err != nil {
+			t.Fatalf("WritePacket #%d: %s", i, err)
+		}
+	}
+	if err := writePacket(t, d, pktSize); err == nil {
+		t.Errorf("WritePacket succeeded with a full queue, want error")
+	}
+	if got, want := lower.count(), burst/pktSize; got != want {
+		t.Errorf("got %d packets sent, want = %d", got, want)
+	}
+	if got, want := d.Stats().Backlog, uint32(limit); got != want {
+		t.Errorf("got Stats().Backlog = %d, want = %d", got, want)
+	}
+
+	// A packet is sent every pktSize/rate seconds afterwards.
+	const interval = pktSize * time.Second / rate
+	for i := 1; i <= limit/pktSize; i++ {
+		clock.Advance(interval)
+		if got, want := lower.count(), burst/pktSize+i; got != want {
+			t.Fatalf("got %d packets sent after %s, want = %d", got, time.Duration(i)*interval, want)
+		}
+	}
+	stats := d.Stats()
+	if got, want := stats.Packets, uint64(burst/pktSize+limit/pktSize); got != want {
+		t.Errorf("got Stats().Packets = %d, want = %d", got, want)
+	}
+	if got, want := stats.Drops, uint64(1); got != want {
+		t.Errorf("got Stats().Drops = %d, want = %d", got, want)
+	}
+}
+
+func TestSetOptions(t *testing.T) {
+	clock := faketime.NewManualClock()
+	lower := &countWriter{}
+	d, err := tbf.New(lower, clock, tbf.Options{Rate: 100, Burst: 100, Limit: 1000})
+	if err != nil {
+		t.Fatalf("tbf.New(_, _, _): %s", err)
+	}
+	defer d.Close()
+	for i := 0; i < 5; i++ {
+		if err := writePacket(t, d, 100); err != nil {
+			t.Fatalf("WritePacket #%d: %s", i, err)
+		}
+	}
+	if got := lower.count(); got != 1 {
+		t.Fatalf("got %d packets sent, want = 1", got)
+	}
+
+	// The queued packets are sent as soon as the new bucket allows it.
+	opts := tbf.Options{Rate: 1000, Burst: 1000, Limit: 1000}
+	if err := d.SetOptions(opts); err != nil {
+		t.Fatalf("SetOptions(%+v): %s", opts, err)
+	}
+	if got := lower.count(); got != 5 {
+		t.Errorf("got %d packets sent, want = 5", got)
+	}
+	if got := d.Options(); got != opts {
+		t.Errorf("got Options() = %+v, want = %+v", got, opts)
+	}
+
+	if err := d.SetOptions(tbf.Options{Rate: 1000, Burst: 1000, Limit: 1000, PeakRate: 500, MTU: 1500}); err == nil {
+		t.Errorf("SetOptions succeeded with a peak rate lower than the rate, want error")
+	}
+}
+
+func TestClose(t *testing.T) {
+	clock := faketime.NewManualClock()
+	lower := &countWriter{}
+	d, err := tbf.New(lower, clock, tbf.Options{Rate: 100, Burst: 100, Limit: 1000})
+	if err != nil {
+		t.Fatalf("tbf.New(_, _, _): %s", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := writePacket(t, d, 100); err != nil {
+			t.Fatalf("WritePacket #%d: %s", i, err)
+		}
+	}
+	d.Close()
+
+	// The queued packets are dropped.
+	clock.Advance(time.Minute)
+	if got := lower.count(); got != 1 {
+		t.Errorf("got %d packets sent, want = 1", got)
+	}
+	if err := writePacket(t, d, 100); err == nil {
+		t.Errorf("WritePacket succeeded after Close, want error")
+	}
+}
+
+func TestMain(m *testing.M) {
+	refs.SetLeakMode(refs.LeaksPanic)
+	code := m.Run()
+	refs.DoLeakCheck()
+	os.Exit(code)
+}
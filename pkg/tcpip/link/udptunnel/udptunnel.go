@@ -0,0 +1,517 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package udptunnel provides link endpoints that carry Ethernet frames over
+// UDP, such as VXLAN and Geneve devices.
+//
+// Frames are encapsulated over a UDP endpoint of an underlay stack. Like the
+// other Ethernet link endpoints, a tunnel endpoint is meant to be wrapped in an
+// ethernet.Endpoint before it is attached to a NIC.
+package udptunnel
+
+import (
+	"bytes"
+	"context"
+
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/sync"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/udp"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+// Kind is the encapsulation used by a tunnel endpoint.
+type Kind int
+
+const (
+	// VXLAN encapsulates frames as described in RFC 7348.
+	VXLAN Kind = iota
+
+	// Geneve encapsulates frames as described in RFC 8926.
+	Geneve
+)
+
+// String implements fmt.Stringer.
+func (k Kind) String() string {
+	switch k {
+	case VXLAN:
+		return "vxlan"
+	case Geneve:
+		return "geneve"
+	default:
+		return "unknown"
+	}
+}
+
+// headerSize returns the size of the tunnel header of k.
+func (k Kind) headerSize() int {
+	if k == Geneve {
+		return header.GeneveMinimumSize
+	}
+	return header.VXLANHeaderSize
+}
+
+// DefaultPort returns the IANA assigned UDP port of k.
+func (k Kind) DefaultPort() uint16 {
+	if k == Geneve {
+		return header.GeneveDefaultPort
+	}
+	return header.VXLANDefaultPort
+}
+
+const (
+	// maxFDBEntries is the maximum number of link addresses that are
+	// learned by a tunnel endpoint.
+	maxFDBEntries = 4096
+
+	// underlayMTU is the MTU of the underlay that is assumed when no MTU is
+	// configured.
+	underlayMTU = 1500
+)
+
+// Options holds the configuration of a tunnel endpoint.
+type Options struct {
+	// Kind is the encapsulation of the tunnel.
+	Kind Kind
+
+	// VNI is the virtual network identifier of the tunnel. Packets with
+	// other identifiers are dropped.
+	VNI uint32
+
+	// Remote is the address of the default remote tunnel endpoint, to
+	// which frames for unknown destinations are sent. It may be a multicast
+	// group, which is joined by the tunnel endpoint. If unspecified, such
+	// frames are dropped.
+	Remote tcpip.Address
+
+	// Local is the source address of encapsulated packets. If unspecified,
+	// it is picked by the underlay stack.
+	Local tcpip.Address
+
+	// Port is the destination UDP port of encapsulated packets, on which
+	// the tunnel endpoint also receives them. If zero, the port assigned to
+	// Kind is used.
+	Port uint16
+
+	// NIC is the underlay NIC used by the tunnel. If zero, packets are
+	// routed through any NIC.
+	NIC tcpip.NICID
+
+	// Learning enables learning the remote tunnel endpoints of the source
+	// link addresses of received frames.
+	Learning bool
+
+	// MTU is the MTU of the tunnel. If zero, it is computed from an
+	// Ethernet MTU and the encapsulation overhead.
+	MTU uint32
+}
+
+// fdbEntry is a forwarding database entry, which maps a link address to the
+// remote tunnel endpoint that it is behind.
+//
+// +stateify savable
+type fdbEntry struct {
+	remote tcpip.Address
+}
+
+var _ stack.LinkEndpoint = (*Endpoint)(nil)
+
+// Endpoint is a link endpoint that encapsulates Ethernet frames over UDP.
+//
+// +stateify savable
+type Endpoint struct {
+	opts  Options
+	wq    waiter.Queue
+	udpEP tcpip.Endpoint
+
+	// closed is closed when the endpoint is closed to stop the dispatch
+	// loop.
+	closed    chan struct{}  `state:"nosave"`
+	closeOnce sync.Once      `state:"nosave"`
+	wg        sync.WaitGroup `state:"nosave"`
+
+	mu endpointRWMutex `state:"nosave"`
+	// +checklocks:mu
+	dispatcher stack.NetworkDispatcher
+	// +checklocks:mu
+	linkAddr tcpip.LinkAddress
+	// +checklocks:mu
+	mtu uint32
+	// fdb maps link addresses to the remote tunnel endpoints that they are
+	// behind, as in the forwarding database of a bridge.
+	//
+	// +checklocks:mu
+	fdb map[stack.BridgeFDBKey]fdbEntry
+	// +checklocks:mu
+	onCloseAction func() `state:"nosave"`
+}
+
+// New creates a tunnel endpoint which encapsulates frames over a UDP endpoint
+// of s.
+func New(s *stack.Stack, opts Options) (*Endpoint, tcpip.Error) {
+	if opts.VNI > header.VXLANMaxVNI {
+		return nil, &tcpip.ErrInvalidOptionValue{}
+	}
+	if opts.Port == 0 {
+		opts.Port = opts.Kind.DefaultPort()
+	}
+	netProto := header.IPv4ProtocolNumber
+	if opts.Remote.Len() == header.IPv6AddressSize || opts.Local.Len() == header.IPv6AddressSize {
+		netProto = header.IPv6ProtocolNumber
+	}
+	if opts.MTU == 0 {
+		opts.MTU = underlayMTU - Overhead(opts.Kind, netProto)
+	}
+
+	e := &Endpoint{
+		opts:     opts,
+		closed:   make(chan struct{}),
+		linkAddr: tcpip.GetRandMacAddr(),
+		mtu:      opts.MTU,
+		fdb:      make(map[stack.BridgeFDBKey]fdbEntry),
+	}
+	ep, err := s.NewEndpoint(udp.ProtocolNumber, netProto, &e.wq)
+	if err != nil {
+		return nil, err
+	}
+	// Packets flooded to a multicast group must not come back to us.
+	ep.SocketOptions().SetMulticastLoop(false)
+	if opts.NIC != 0 {
+		if err := ep.SocketOptions().SetBindToDevice(int32(opts.NIC)); err != nil {
+			ep.Close()
+			return nil, err
+		}
+	}
+	if err := ep.Bind(tcpip.FullAddress{Addr: opts.Local, Port: opts.Port}); err != nil {
+		ep.Close()
+		return nil, err
+	}
+	if isMulticast(opts.Remote) {
+		if err := ep.SetSockOpt(&tcpip.AddMembershipOption{
+			NIC:           opts.NIC,
+			InterfaceAddr: opts.Local,
+			MulticastAddr: opts.Remote,
+		}); err != nil {
+			ep.Close()
+			return nil, err
+		}
+	}
+	e.udpEP = ep
+	e.startDispatchLoop()
+	return e, nil
+}
+
+func isMulticast(addr tcpip.Address) bool {
+	return header.IsV4MulticastAddress(addr) || header.IsV6MulticastAddress(addr)
+}
+
+// Overhead returns the number of bytes that the encapsulation of kind k over
+// the network protocol netProto adds to Ethernet frames.
+func Overhead(k Kind, netProto tcpip.NetworkProtocolNumber) uint32 {
+	n := uint32(header.EthernetMinimumSize + header.UDPMinimumSize + k.headerSize())
+	if netProto == header.IPv6ProtocolNumber {
+		return n + header.IPv6MinimumSize
+	}
+	return n + header.IPv4MinimumSize
+}
+
+// afterLoad is invoked by stateify.
+func (e *Endpoint) afterLoad(context.Context) {
+	e.closed = make(chan struct{})
+	e.startDispatchLoop()
+}
+
+func (e *Endpoint) startDispatchLoop() {
+	e.wg.Add(1)
+	go func() { // S/R-SAFE: restarted by afterLoad.
+		defer e.wg.Done()
+		e.dispatchLoop()
+	}()
+}
+
+// dispatchLoop reads encapsulated packets from the UDP endpoint and delivers
+// their frames until the endpoint is closed.
+func (e *Endpoint) dispatchLoop() {
+	we, ch := waiter.NewChannelEntry(waiter.ReadableEvents)
+	e.wq.EventRegister(&we)
+	defer e.wq.EventUnregister(&we)
+
+	var b bytes.Buffer
+	for {
+		b.Reset()
+		res, err := e.udpEP.Read(&b, tcpip.ReadOptions{NeedRemoteAddr: true})
+		switch err.(type) {
+		case nil:
+			e.deliver(b.Bytes(), res.RemoteAddr.Addr)
+		case *tcpip.ErrWouldBlock:
+			select {
+			case <-ch:
+			case <-e.closed:
+				return
+			}
+		case *tcpip.ErrClosedForReceive:
+			return
+		default:
+			// Errors such as ICMP errors for earlier packets don't
+			// prevent further reads.
+		}
+	}
+}
+
+// decapsulate returns the Ethernet frame carried in the UDP payload b, or nil
+// if b isn't a valid packet of the tunnel.
+func (e *Endpoint) decapsulate(b []byte) []byte {
+	switch e.opts.Kind {
+	case VXLAN:
+		h := header.VXLAN(b)
+		if !h.IsValid() || h.VNI() != e.opts.VNI {
+			return nil
+		}
+		b = b[header.VXLANHeaderSize:]
+	case Geneve:
+		h := header.Geneve(b)
+		if !h.IsValid() || h.VNI() != e.opts.VNI || h.Protocol() != header.GeneveProtocolTransparentEthernet {
+			return nil
+		}
+		// Control packets aren't delivered, and no options are
+		// supported, so packets with critical options must be dropped.
+		if h.Flags() != 0 {
+			return nil
+		}
+		b = b[h.HeaderLength():]
+	}
+	if len(b) < header.EthernetMinimumSize {
+		return nil
+	}
+	return b
+}
+
+// deliver delivers the frame carried in the UDP payload b, which was received
+// from remote.
+func (e *Endpoint) deliver(b []byte, remote tcpip.Address) {
+	frame := e.decapsulate(b)
+	if frame == nil {
+		return
+	}
+	eth := header.Ethernet(frame)
+
+	e.mu.Lock()
+	if src := eth.SourceAddress(); e.opts.Learning && !header.IsMulticastEthernetAddress(src) {
+		e.addFDBEntryLocked(src, remote)
+	}
+	d := e.dispatcher
+	e.mu.Unlock()
+	if d == nil {
+		return
+	}
+
+	pkt := stack.NewPacketBuffer(stack.PacketBufferOptions{
+		Payload: buffer.MakeWithData(frame),
+	})
+	d.DeliverNetworkPacket(eth.Type(), pkt)
+	pkt.DecRef()
+}
+
+// +checklocks:e.mu
+func (e *Endpoint) addFDBEntryLocked(addr tcpip.LinkAddress, remote tcpip.Address) {
+	key := stack.BridgeFDBKey(addr)
+	if _, ok := e.fdb[key]; !ok && len(e.fdb) >= maxFDBEntries {
+		return
+	}
+	e.fdb[key] = fdbEntry{remote: remote}
+}
+
+// AddFDBEntry adds or replaces the forwarding database entry of addr, so that
+// frames to addr are sent to the remote tunnel endpoint.
+func (e *Endpoint) AddFDBEntry(addr tcpip.LinkAddress, remote tcpip.Address) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.fdb[stack.BridgeFDBKey(addr)] = fdbEntry{remote: remote}
+}
+
+// RemoveFDBEntry removes the forwarding database entry of addr.
+func (e *Endpoint) RemoveFDBEntry(addr tcpip.LinkAddress) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.fdb, stack.BridgeFDBKey(addr))
+}
+
+// FindFDBEntry returns the remote tunnel endpoint which addr is behind, if
+// known.
+func (e *Endpoint) FindFDBEntry(addr tcpip.LinkAddress) (tcpip.Address, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	entry, ok := e.fdb[stack.BridgeFDBKey(addr)]
+	return entry.remote, ok
+}
+
+// remoteFor returns the remote tunnel endpoint to which frames to dst are
+// sent.
+func (e *Endpoint) remoteFor(dst tcpip.LinkAddress) tcpip.Address {
+	if !header.IsMulticastEthernetAddress(dst) {
+		e.mu.RLock()
+		entry, ok := e.fdb[stack.BridgeFDBKey(dst)]
+		e.mu.RUnlock()
+		if ok {
+			return entry.remote
+		}
+	}
+	return e.opts.Remote
+}
+
+// encapsulate returns the UDP payload that carries frame.
+func (e *Endpoint) encapsulate(frame buffer.Buffer) []byte {
+	hdrSize := e.opts.Kind.headerSize()
+	b := make([]byte, hdrSize+int(frame.Size()))
+	switch e.opts.Kind {
+	case VXLAN:
+		header.VXLAN(b).Encode(e.opts.VNI)
+	case Geneve:
+		header.Geneve(b).Encode(&header.GeneveFields{
+			Protocol: header.GeneveProtocolTransparentEthernet,
+			VNI:      e.opts.VNI,
+		})
+	}
+	frame.ReadAt(b[hdrSize:], 0)
+	return b
+}
+
+// WritePackets implements stack.LinkEndpoint.WritePackets.
+func (e *Endpoint) WritePackets(pkts stack.PacketBufferList) (int, tcpip.Error) {
+	n := 0
+	for _, pkt := range pkts.AsSlice() {
+		frame := pkt.ToBuffer()
+		if frame.Size() < header.EthernetMinimumSize {
+			frame.Release()
+			return n, &tcpip.ErrMalformedHeader{}
+		}
+		var dst [header.EthernetAddressSize]byte
+		frame.ReadAt(dst[:], 0)
+		remote := e.remoteFor(tcpip.LinkAddress(dst[:]))
+		if remote.Len() == 0 {
+			// There is nowhere to send the frame to.
+			frame.Release()
+			n++
+			continue
+		}
+		b := e.encapsulate(frame)
+		frame.Release()
+
+		var r bytes.Reader
+		r.Reset(b)
+		if _, err := e.udpEP.Write(&r, tcpip.WriteOptions{
+			To: &tcpip.FullAddress{Addr: remote, Port: e.opts.Port},
+		}); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Close implements stack.LinkEndpoint.Close.
+func (e *Endpoint) Close() {
+	e.closeOnce.Do(func() {
+		close(e.closed)
+		e.udpEP.Close()
+
+		e.mu.Lock()
+		action := e.onCloseAction
+		e.onCloseAction = nil
+		e.mu.Unlock()
+		if action != nil {
+			action()
+		}
+	})
+}
+
+// Wait implements stack.LinkEndpoint.Wait.
+func (e *Endpoint) Wait() {
+	e.wg.Wait()
+}
+
+// Attach implements stack.LinkEndpoint.Attach.
+func (e *Endpoint) Attach(dispatcher stack.NetworkDispatcher) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.dispatcher = dispatcher
+}
+
+// IsAttached implements stack.LinkEndpoint.IsAttached.
+func (e *Endpoint) IsAttached() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.dispatcher != nil
+}
+
+// MTU implements stack.LinkEndpoint.MTU.
+func (e *Endpoint) MTU() uint32 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.mtu
+}
+
+// SetMTU implements stack.LinkEndpoint.SetMTU.
+func (e *Endpoint) SetMTU(mtu uint32) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.mtu = mtu
+}
+
+// Capabilities implements stack.LinkEndpoint.Capabilities. Frames come from
+// the network, so their checksums aren't verified by the tunnel.
+func (*Endpoint) Capabilities() stack.LinkEndpointCapabilities {
+	return stack.CapabilitySaveRestore
+}
+
+// MaxHeaderLength implements stack.LinkEndpoint.MaxHeaderLength. The tunnel
+// header is added when the frame is written to the UDP endpoint, so no space
+// is reserved for it.
+func (*Endpoint) MaxHeaderLength() uint16 {
+	return 0
+}
+
+// LinkAddress implements stack.LinkEndpoint.LinkAddress.
+func (e *Endpoint) LinkAddress() tcpip.LinkAddress {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.linkAddr
+}
+
+// SetLinkAddress implements stack.LinkEndpoint.SetLinkAddress.
+func (e *Endpoint) SetLinkAddress(addr tcpip.LinkAddress) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.linkAddr = addr
+}
+
+// ARPHardwareType implements stack.LinkEndpoint.ARPHardwareType.
+func (*Endpoint) ARPHardwareType() header.ARPHardwareType {
+	return header.ARPHardwareNone
+}
+
+// AddHeader implements stack.LinkEndpoint.AddHeader.
+func (*Endpoint) AddHeader(*stack.PacketBuffer) {}
+
+// ParseHeader implements stack.LinkEndpoint.ParseHeader.
+func (*Endpoint) ParseHeader(*stack.PacketBuffer) bool { return true }
+
+// SetOnCloseAction implements stack.LinkEndpoint.SetOnCloseAction.
+func (e *Endpoint) SetOnCloseAction(action func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onCloseAction = action
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package udptunnel_test
+
+import (
+	"testing"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/internal/linktest"
+	"gvisor.dev/gvisor/pkg/tcpip/link/ethernet"
+	"gvisor.dev/gvisor/pkg/tcpip/link/udptunnel"
+	"gvisor.dev/gvisor/pkg/tcpip/link/veth"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/testutil"
+)
+
+const (
+	underlayNICID = 1
+	overlayNICID  = 2
+	testVNI       = 42
+	testTimeout   = 5 * time.Second
+)
+
+// tunnelHost is a stack with an underlay NIC and a tunnel NIC.
+type tunnelHost struct {
+	s        *stack.Stack
+	tunnel   *udptunnel.Endpoint
+	underlay tcpip.Address
+	overlay  tcpip.Address
+}
+
+func newTunnelHost(t *testing.T, underlayEP stack.LinkEndpoint, opts udptunnel.Options, underlay, overlay tcpip.Address) *tunnelHost {
+	t.Helper()
+	s := linktest.NewStack(t)
+	if err := s.CreateNIC(underlayNICID, ethernet.New(underlayEP)); err != nil {
+		t.Fatalf("CreateNIC(%d, _): %s", underlayNICID, err)
+	}
+	linktest.AddAddress(t, s, underlayNICID, tcpip.AddressWithPrefix{Address: underlay, PrefixLen: 24})
+
+	opts.Local = underlay
+	opts.NIC = underlayNICID
+	tunnel, err := udptunnel.New(s, opts)
+	if err != nil {
+		t.Fatalf("udptunnel.New(_, %+v): %s", opts, err)
+	}
+	if err := s.CreateNIC(overlayNICID, ethernet.New(tunnel)); err != nil {
+		t.Fatalf("CreateNIC(%d, _): %s", overlayNICID, err)
+	}
+	linktest.AddAddress(t, s, overlayNICID, tcpip.AddressWithPrefix{Address: overlay, PrefixLen: 24})
+	return &tunnelHost{s: s, tunnel: tunnel, underlay: underlay, overlay: overlay}
+}
+
+func TestTunnel(t *testing.T) {
+	var (
+		underlay1 = testutil.MustParse4("10.0.0.1")
+		underlay2 = testutil.MustParse4("10.0.0.2")
+		overlay1  = testutil.MustParse4("192.168.0.1")
+		overlay2  = testutil.MustParse4("192.168.0.2")
+	)
+	for _, tc := range []struct {
+		kind     udptunnel.Kind
+		learning bool
+	}{
+		{kind: udptunnel.VXLAN, learning: true},
+		{kind: udptunnel.Geneve},
+	} {
+		t.Run(tc.kind.String(), func(t *testing.T) {
+			ep1, ep2 := veth.NewPair(1500, veth.DefaultBacklogSize)
+			h1 := newTunnelHost(t, ep1, udptunnel.Options{Kind: tc.kind, VNI: testVNI, Remote: underlay2, Learning: tc.learning}, underlay1, overlay1)
+			h2 := newTunnelHost(t, ep2, udptunnel.Options{Kind: tc.kind, VNI: testVNI, Remote: underlay1, Learning: tc.learning}, underlay2, overlay2)
+
+			if got, want := h1.tunnel.MTU(), uint32(1450); got != want {
+				t.Errorf("got MTU() = %d, want = %d", got, want)
+			}
+
+			if !linktest.SendDatagrams(t, h1.s, h2.s, overlay2, testTimeout) {
+				t.Fatalf("timed out waiting for a datagram over the tunnel")
+			}
+
+			remote, ok := h2.tunnel.FindFDBEntry(h1.tunnel.LinkAddress())
+			if tc.learning {
+				if !ok || remote != underlay1 {
+					t.Errorf("got FindFDBEntry(%s) = (%s, %t), want = (%s, true)", h1.tunnel.LinkAddress(), remote, ok, underlay1)
+				}
+			} else if ok {
+				t.Errorf("got FindFDBEntry(%s) = (%s, true) without learning, want no entry", h1.tunnel.LinkAddress(), remote)
+			}
+		})
+	}
+}
+
+func TestFDB(t *testing.T) {
+	s := linktest.NewStack(t)
+	e, err := udptunnel.New(s, udptunnel.Options{Kind: udptunnel.VXLAN, VNI: testVNI})
+	if err != nil {
+		t.Fatalf("udptunnel.New: %s", err)
+	}
+	defer e.Close()
+
+	const linkAddr = tcpip.LinkAddress("\x02\x00\x00\x00\x00\x01")
+	remote := testutil.MustParse4("10.0.0.3")
+	if _, ok := e.FindFDBEntry(linkAddr); ok {
+		t.Errorf("got an FDB entry for %s before adding it", linkAddr)
+	}
+	e.AddFDBEntry(linkAddr, remote)
+	if got, ok := e.FindFDBEntry(linkAddr); !ok || got != remote {
+		t.Errorf("got FindFDBEntry(%s) = (%s, %t), want = (%s, true)", linkAddr, got, ok, remote)
+	}
+	e.RemoveFDBEntry(linkAddr)
+	if _, ok := e.FindFDBEntry(linkAddr); ok {
+		t.Errorf("got an FDB entry for %s after removing it", linkAddr)
+	}
+}
+
+func TestInvalidVNI(t *testing.T) {
+	s := linktest.NewStack(t)
+	opts := udptunnel.Options{Kind: udptunnel.VXLAN, VNI: header.VXLANMaxVNI + 1}
+	if _, err := udptunnel.New(s, opts); err == nil {
+		t.Errorf("udptunnel.New(_, %+v) succeeded, want error", opts)
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vlan provides an implementation of 802.1Q VLAN sub-interfaces.
+//
+// A VLAN endpoint receives the frames of its VLAN from its parent NIC with the
+// tag removed, and sends frames through the parent NIC with the tag added. It
+// exchanges whole Ethernet frames, so it must be wrapped by an ethernet
+// endpoint.
+package vlan
+
+import (
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+// Options holds the configuration of a VLAN endpoint.
+type Options struct {
+	// Parent is the NIC that carries the tagged frames.
+	Parent tcpip.NICID
+
+	// VID is the VLAN identifier.
+	VID uint16
+
+	// Protocol is the tag protocol, which is header.EthernetProtocol8021Q
+	// if unspecified, or header.EthernetProtocol8021AD.
+	Protocol tcpip.NetworkProtocolNumber
+}
+
+var _ stack.LinkEndpoint = (*Endpoint)(nil)
+var _ stack.VLANEndpoint = (*Endpoint)(nil)
+
+// Endpoint is a VLAN sub-interface of a NIC.
+//
+// +stateify savable
+type Endpoint struct {
+	stack *stack.Stack
+	opts  Options
+
+	mu endpointRWMutex `state:"nosave"`
+	// +checklocks:mu
+	dispatcher stack.NetworkDispatcher
+	// +checklocks:mu
+	linkAddr tcpip.LinkAddress
+	// +checklocks:mu
+	mtu uint32
+	// +checklocks:mu
+	closed bool
+	// +checklocks:mu
+	onCloseAction func() `state:"nosave"`
+}
+
+// New creates a VLAN endpoint and registers it with the parent NIC. Like on
+// Linux, the endpoint inherits the link address and the MTU of its parent.
+func New(s *stack.Stack, opts Options) (*Endpoint, tcpip.Error) {
+	if opts.Protocol == 0 {
+		opts.Protocol = header.EthernetProtocol8021Q
+	}
+	parent, ok := s.NICInfo()[opts.Parent]
+	if !ok {
+		return nil, &tcpip.ErrUnknownNICID{}
+	}
+	if parent.ARPHardwareType != header.ARPHardwareEther {
+		return nil, &tcpip.ErrNotSupported{}
+	}
+	e := &Endpoint{
+		stack:    s,
+		opts:     opts,
+		linkAddr: parent.LinkAddress,
+		mtu:      parent.MTU,
+	}
+	if err := s.RegisterVLANEndpoint(opts.Parent, opts.Protocol, opts.VID, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// VID returns the VLAN identifier of the endpoint.
+func (e *Endpoint) VID() uint16 {
+	return e.opts.VID
+}
+
+// Parent returns the NIC that carries the frames of the endpoint.
+func (e *Endpoint) Parent() tcpip.NICID {
+	return e.opts.Parent
+}
+
+// HandleVLANPacket implements stack.VLANEndpoint.HandleVLANPacket.
+func (e *Endpoint) HandleVLANPacket(pkt *stack.PacketBuffer) {
+	e.mu.RLock()
+	d := e.dispatcher
+	e.mu.RUnlock()
+	if d == nil {
+		return
+	}
+
+	// Rebuild the untagged frame: the addresses of the Ethernet header
+	// followed by the EtherType of the payload.
+	eth := header.Ethernet(pkt.LinkHeader().Slice())
+	hdr := make([]byte, header.EthernetMinimumSize)
+	copy(hdr, eth[:2*header.EthernetAddressSize])
+	payload := pkt.Data().ToBuffer()
+	typ, ok := payload.PullUp(2, 2)
+	if !ok {
+		payload.Release()
+		return
+	}
+	copy(hdr[2*header.EthernetAddressSize:], typ.AsSlice())
+	payload.TrimFront(header.VLANHeaderSize)
+	frame := buffer.MakeWithData(hdr)
+	frame.Merge(&payload)
+
+	newPkt := stack.NewPacketBuffer(stack.PacketBufferOptions{
+		Payload: frame,
+	})
+	d.DeliverNetworkPacket(0 /* protocol */, newPkt)
+	newPkt.DecRef()
+}
+
+// ParentRemoved implements stack.VLANEndpoint.ParentRemoved. Like on Linux,
+// the sub-interface is removed with its parent.
+func (e *Endpoint) ParentRemoved() {
+	e.Close()
+}
+
+// WritePackets implements stack.LinkEndpoint.WritePackets. The packets hold
+// Ethernet frames, which are tagged and written to the parent NIC.
+func (e *Endpoint) WritePackets(pkts stack.PacketBufferList) (int, tcpip.Error) {
+	n := 0
+	for _, pkt := range pkts.AsSlice() {
+		frame := pkt.ToBuffer()
+		hdr, ok := frame.PullUp(0, header.EthernetMinimumSize)
+		if !ok {
+			frame.Release()
+			n++
+			continue
+		}
+		eth := header.Ethernet(hdr.AsSlice())
+		tagged := make([]byte, header.EthernetMinimumSize+header.VLANHeaderSize)
+		header.Ethernet(tagged).Encode(&header.EthernetFields{
+			SrcAddr: eth.SourceAddress(),
+			DstAddr: eth.DestinationAddress(),
+			Type:    e.opts.Protocol,
+		})
+		header.VLAN(tagged[header.EthernetMinimumSize:]).Encode(&header.VLANFields{
+			VID:  e.opts.VID,
+			Type: eth.Type(),
+		})
+		frame.TrimFront(header.EthernetMinimumSize)
+		out := buffer.MakeWithData(tagged)
+		out.Merge(&frame)
+		if err := e.stack.WriteRawPacket(e.opts.Parent, e.opts.Protocol, out); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// Close implements stack.LinkEndpoint.Close.
+func (e *Endpoint) Close() {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return
+	}
+	e.closed = true
+	action := e.onCloseAction
+	e.onCloseAction = nil
+	e.mu.Unlock()
+
+	e.stack.UnregisterVLANEndpoint(e.opts.Parent, e.opts.Protocol, e.opts.VID)
+	if action != nil {
+		action()
+	}
+}
+
+// Wait implements stack.LinkEndpoint.Wait.
+func (*Endpoint) Wait() {}
+
+// Attach implements stack.LinkEndpoint.Attach.
+func (e *Endpoint) Attach(dispatcher stack.NetworkDispatcher) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.dispatcher = dispatcher
+}
+
+// IsAttached implements stack.LinkEndpoint.IsAttached.
+func (e *Endpoint) IsAttached() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.dispatcher != nil
+}
+
+// MTU implements stack.LinkEndpoint.MTU.
+func (e *Endpoint) MTU() uint32 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.mtu
+}
+
+// SetMTU implements stack.LinkEndpoint.SetMTU.
+func (e *Endpoint) SetMTU(mtu uint32) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.mtu = mtu
+}
+
+// Capabilities implements stack.LinkEndpoint.Capabilities.
+func (*Endpoint) Capabilities() stack.LinkEndpointCapabilities {
+	return stack.CapabilitySaveRestore
+}
+
+// MaxHeaderLength implements stack.LinkEndpoint.MaxHeaderLength. Frames are
+// copied when they are tagged, so no space is reserved for the tag.
+func (*Endpoint) MaxHeaderLength() uint16 {
+	return 0
+}
+
+// LinkAddress implements stack.LinkEndpoint.LinkAddress.
+func (e *Endpoint) LinkAddress() tcpip.LinkAddress {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.linkAddr
+}
+
+// SetLinkAddress implements stack.LinkEndpoint.SetLinkAddress.
+func (e *Endpoint) SetLinkAddress(addr tcpip.LinkAddress) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.linkAddr = addr
+}
+
+// ARPHardwareType implements stack.LinkEndpoint.ARPHardwareType.
+func (*Endpoint) ARPHardwareType() header.ARPHardwareType {
+	return header.ARPHardwareNone
+}
+
+// AddHeader implements stack.LinkEndpoint.AddHeader.
+func (*Endpoint) AddHeader(*stack.PacketBuffer) {}
+
+// ParseHeader implements stack.LinkEndpoint.ParseHeader.
+func (*Endpoint) ParseHeader(*stack.PacketBuffer) bool { return true }
+
+// SetOnCloseAction implements stack.LinkEndpoint.SetOnCloseAction.
+func (e *Endpoint) SetOnCloseAction(action func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onCloseAction = action
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vlan_test
+
+import (
+	"testing"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/internal/linktest"
+	"gvisor.dev/gvisor/pkg/tcpip/link/ethernet"
+	"gvisor.dev/gvisor/pkg/tcpip/link/veth"
+	"gvisor.dev/gvisor/pkg/tcpip/link/vlan"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/testutil"
+)
+
+const (
+	parentNICID = 1
+	vlanNICID   = 2
+	testTimeout = 5 * time.Second
+)
+
+// newVLANHost creates a stack with a parent NIC and a VLAN sub-interface vid
+// of it, which has the address addr.
+func newVLANHost(t *testing.T, parentEP stack.LinkEndpoint, vid uint16, addr tcpip.Address) (*stack.Stack, *vlan.Endpoint) {
+	t.Helper()
+	s := linktest.NewStack(t)
+	if err := s.CreateNIC(parentNICID, ethernet.New(parentEP)); err != nil {
+		t.Fatalf("CreateNIC(%d, _): %s", parentNICID, err)
+	}
+	ep, err := vlan.New(s, vlan.Options{Parent: parentNICID, VID: vid})
+	if err != nil {
+		t.Fatalf("vlan.New(_, {Parent: %d, VID: %d}): %s", parentNICID, vid, err)
+	}
+	if err := s.CreateNIC(vlanNICID, ethernet.New(ep)); err != nil {
+		t.Fatalf("CreateNIC(%d, _): %s", vlanNICID, err)
+	}
+	linktest.AddAddress(t, s, vlanNICID, tcpip.AddressWithPrefix{Address: addr, PrefixLen: 24})
+	return s, ep
+}
+
+func TestVLAN(t *testing.T) {
+	addr1 := testutil.MustParse4("192.168.100.1")
+	addr2 := testutil.MustParse4("192.168.100.2")
+	ep1, ep2 := veth.NewPair(1500, veth.DefaultBacklogSize)
+	s1, vlanEP := newVLANHost(t, ep1, 100, addr1)
+	s2, _ := newVLANHost(t, ep2, 100, addr2)
+
+	if got, want := vlanEP.MTU(), uint32(1500); got != want {
+		t.Errorf("got MTU() = %d, want = %d", got, want)
+	}
+	if got, want := vlanEP.LinkAddress(), ep1.LinkAddress(); got != want {
+		t.Errorf("got LinkAddress() = %s, want = %s", got, want)
+	}
+	if !linktest.SendDatagrams(t, s1, s2, addr2, testTimeout) {
+		t.Fatalf("timed out waiting for a datagram over the VLAN")
+	}
+	if !linktest.SendDatagrams(t, s2, s1, addr1, testTimeout) {
+		t.Fatalf("timed out waiting for a datagram over the VLAN")
+	}
+}
+
+func TestVLANMismatch(t *testing.T) {
+	addr1 := testutil.MustParse4("192.168.100.1")
+	addr2 := testutil.MustParse4("192.168.100.2")
+	ep1, ep2 := veth.NewPair(1500, veth.DefaultBacklogSize)
+	s1, _ := newVLANHost(t, ep1, 100, addr1)
+	s2, _ := newVLANHost(t, ep2, 200, addr2)
+
+	if linktest.SendDatagrams(t, s1, s2, addr2, 500*time.Millisecond) {
+		t.Errorf("got a datagram from another VLAN")
+	}
+}
+
+func TestParentRemoved(t *testing.T) {
+	ep1, _ := veth.NewPair(1500, veth.DefaultBacklogSize)
+	s, _ := newVLANHost(t, ep1, 100, testutil.MustParse4("192.168.100.1"))
+	if err := s.RemoveNIC(parentNICID); err != nil {
+		t.Fatalf("RemoveNIC(%d): %s", parentNICID, err)
+	}
+	if _, ok := s.NICInfo()[vlanNICID]; ok {
+		t.Errorf("got NIC %d after removing its parent, want it removed", vlanNICID)
+	}
+}
+
+func TestDuplicateVLAN(t *testing.T) {
+	ep1, _ := veth.NewPair(1500, veth.DefaultBacklogSize)
+	s, _ := newVLANHost(t, ep1, 100, testutil.MustParse4("192.168.100.1"))
+	if _, err := vlan.New(s, vlan.Options{Parent: parentNICID, VID: 100}); err == nil {
+		t.Errorf("vlan.New succeeded for a duplicate VLAN, want error")
+	}
+	if _, err := vlan.New(s, vlan.Options{Parent: parentNICID, VID: header.VLANMaxVID + 1}); err == nil {
+		t.Errorf("vlan.New succeeded for an invalid VLAN, want error")
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wireguard
+
+import (
+	"bytes"
+	"sort"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+// prefixKey identifies the subnets of an address length and prefix length.
+//
+// +stateify savable
+type prefixKey struct {
+	addrLen   int
+	prefixLen int
+}
+
+// allowedIPs is the cryptokey routing table of a device. It maps subnets to
+// the peers that they are routed to, and is looked up by longest prefix match.
+//
+// +stateify savable
+type allowedIPs struct {
+	// subnets maps each subnet to its peer.
+	subnets map[tcpip.Subnet]*peer
+
+	// prefixes counts the subnets of each address length and prefix length,
+	// so that lookups only try the prefix lengths in use.
+	prefixes map[prefixKey]int
+}
+
+func (a *allowedIPs) init() {
+	a.subnets = make(map[tcpip.Subnet]*peer)
+	a.prefixes = make(map[prefixKey]int)
+}
+
+func subnetKey(s tcpip.Subnet) prefixKey {
+	return prefixKey{addrLen: s.ID().Len(), prefixLen: s.Prefix()}
+}
+
+// insert routes the subnet to p, replacing the peer that it was routed to.
+func (a *allowedIPs) insert(s tcpip.Subnet, p *peer) {
+	if _, ok := a.subnets[s]; !ok {
+		a.prefixes[subnetKey(s)]++
+	}
+	a.subnets[s] = p
+}
+
+// remove removes the route of the subnet if it is routed to p.
+func (a *allowedIPs) remove(s tcpip.Subnet, p *peer) {
+	if a.subnets[s] != p {
+		return
+	}
+	delete(a.subnets, s)
+	key := subnetKey(s)
+	if a.prefixes[key]--; a.prefixes[key] == 0 {
+		delete(a.prefixes, key)
+	}
+}
+
+// removePeer removes all the subnets that are routed to p.
+func (a *allowedIPs) removePeer(p *peer) {
+	for s, q := range a.subnets {
+		if q == p {
+			a.remove(s, p)
+		}
+	}
+}
+
+// lookup returns the peer that addr is routed to, or nil if there is none.
+func (a *allowedIPs) lookup(addr tcpip.Address) *peer {
+	for prefixLen := addr.Len() * 8; prefixLen >= 0; prefixLen-- {
+		if a.prefixes[prefixKey{addrLen: addr.Len(), prefixLen: prefixLen}] == 0 {
+			continue
+		}
+		s := tcpip.AddressWithPrefix{Address: addr, PrefixLen: prefixLen}.Subnet()
+		if p, ok := a.subnets[s]; ok {
+			return p
+		}
+	}
+	return nil
+}
+
+// peerSubnets returns the subnets routed to p, with IPv4 subnets first and in
+// ascending order.
+func (a *allowedIPs) peerSubnets(p *peer) []tcpip.Subnet {
+	var subnets []tcpip.Subnet
+	for s, q := range a.subnets {
+		if q == p {
+			subnets = append(subnets, s)
+		}
+	}
+	sort.Slice(subnets, func(i, j int) bool {
+		si, sj := subnets[i].ID(), subnets[j].ID()
+		if si.Len() != sj.Len() {
+			return si.Len() < sj.Len()
+		}
+		if cmp := bytes.Compare(si.AsSlice(), sj.AsSlice()); cmp != 0 {
+			return cmp < 0
+		}
+		return subnets[i].Prefix() < subnets[j].Prefix()
+	})
+	return subnets
+}
@@ -0,0 +1,66 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wireguard
+
+import (
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/testutil"
+)
+
+func TestAllowedIPs(t *testing.T) {
+	var a allowedIPs
+	a.init()
+	p1, p2, p3 := &peer{id: 1}, &peer{id: 2}, &peer{id: 3}
+	a.insert(testutil.MustParseSubnet4("10.0.0.0/8"), p1)
+	a.insert(testutil.MustParseSubnet4("10.1.0.0/16"), p2)
+	a.insert(testutil.MustParseSubnet4("0.0.0.0/0"), p3)
+	a.insert(tcpip.AddressWithPrefix{Address: testutil.MustParse6("fd00::"), PrefixLen: 64}.Subnet(), p2)
+
+	for _, tc := range []struct {
+		addr tcpip.Address
+		want *peer
+	}{
+		{addr: testutil.MustParse4("10.2.0.1"), want: p1},
+		{addr: testutil.MustParse4("10.1.2.3"), want: p2},
+		{addr: testutil.MustParse4("192.168.0.1"), want: p3},
+		{addr: testutil.MustParse6("fd00::1"), want: p2},
+		{addr: testutil.MustParse6("fd01::1"), want: nil},
+	} {
+		if got := a.lookup(tc.addr); got != tc.want {
+			t.Errorf("got lookup(%s) = %+v, want = %+v", tc.addr, got, tc.want)
+		}
+	}
+
+	// Removing a peer falls back to shorter prefixes.
+	a.removePeer(p2)
+	if got := a.lookup(testutil.MustParse4("10.1.2.3")); got != p1 {
+		t.Errorf("got lookup(10.1.2.3) = %+v after removing its peer, want = %+v", got, p1)
+	}
+	if got := a.lookup(testutil.MustParse6("fd00::1")); got != nil {
+		t.Errorf("got lookup(fd00::1) = %+v after removing its peer, want = nil", got)
+	}
+	if got := a.peerSubnets(p2); len(got) != 0 {
+		t.Errorf("got peerSubnets(p2) = %v after removing it, want none", got)
+	}
+
+	// A subnet is only removed for the peer that it is routed to.
+	s := testutil.MustParseSubnet4("10.0.0.0/8")
+	a.remove(s, p3)
+	if got := a.lookup(testutil.MustParse4("10.0.0.1")); got != p1 {
+		t.Errorf("got lookup(10.0.0.1) = %+v, want = %+v", got, p1)
+	}
+}
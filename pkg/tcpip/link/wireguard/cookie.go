@@ -0,0 +1,172 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wireguard
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"io"
+
+	"golang.org/x/crypto/blake2s"
+	"golang.org/x/crypto/chacha20poly1305"
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+// cookieChecker verifies the MACs of the handshake messages received by a
+// device, and creates the cookies that the initiators must send when the
+// device is under load.
+//
+// +stateify savable
+type cookieChecker struct {
+	// mac1Key is the key of the first MAC of received messages, which is
+	// derived from the public key of the device.
+	mac1Key [blake2s.Size]byte
+
+	// cookieKey is the key with which cookies are encrypted.
+	cookieKey [blake2s.Size]byte
+
+	// secret is the secret from which cookies are derived. It is renewed
+	// every cookieRefreshTime.
+	secret        [blake2s.Size]byte
+	secretCreated tcpip.MonotonicTime
+}
+
+// init initializes c for the public key of a device.
+func (c *cookieChecker) init(publicKey *Key) {
+	c.mac1Key = hashString(labelMAC1, publicKey[:])
+	c.cookieKey = hashString(labelCookie, publicKey[:])
+	c.secretCreated = tcpip.MonotonicTime{}
+}
+
+// checkMAC1 returns whether the first MAC of the handshake message msg is
+// valid.
+func (c *cookieChecker) checkMAC1(msg []byte) bool {
+	off := mac1Offset(msg)
+	want := mac(c.mac1Key[:], msg[:off])
+	return hmac.Equal(want[:], msg[off:off+macSize])
+}
+
+// cookie returns the cookie of the address from which a message was received.
+func (c *cookieChecker) cookie(clock tcpip.Clock, rng io.Reader, from tcpip.FullAddress) ([macSize]byte, bool) {
+	now := clock.NowMonotonic()
+	if c.secretCreated == (tcpip.MonotonicTime{}) || now.Sub(c.secretCreated) >= cookieRefreshTime {
+		if _, err := io.ReadFull(rng, c.secret[:]); err != nil {
+			return [macSize]byte{}, false
+		}
+		c.secretCreated = now
+	}
+	var port [2]byte
+	binary.BigEndian.PutUint16(port[:], from.Port)
+	return mac(c.secret[:], from.Addr.AsSlice(), port[:]), true
+}
+
+// checkMAC2 returns whether the second MAC of the handshake message msg is
+// valid for the address from which it was received.
+func (c *cookieChecker) checkMAC2(clock tcpip.Clock, rng io.Reader, msg []byte, from tcpip.FullAddress) bool {
+	cookie, ok := c.cookie(clock, rng, from)
+	if !ok {
+		return false
+	}
+	off := mac2Offset(msg)
+	want := mac(cookie[:], msg[:off])
+	return hmac.Equal(want[:], msg[off:off+macSize])
+}
+
+// createReply creates the cookie reply message to the handshake message msg,
+// which was sent by the peer of index sender.
+func (c *cookieChecker) createReply(clock tcpip.Clock, rng io.Reader, msg []byte, sender uint32, from tcpip.FullAddress) ([]byte, bool) {
+	cookie, ok := c.cookie(clock, rng, from)
+	if !ok {
+		return nil, false
+	}
+	reply := make([]byte, messageCookieReplySize)
+	binary.LittleEndian.PutUint32(reply, messageCookieReplyType)
+	binary.LittleEndian.PutUint32(reply[cookieReceiverOffset:], sender)
+	nonce := reply[cookieNonceOffset:cookieOffset]
+	if _, err := io.ReadFull(rng, nonce); err != nil {
+		return nil, false
+	}
+	aead, err := chacha20poly1305.NewX(c.cookieKey[:])
+	if err != nil {
+		return nil, false
+	}
+	off := mac1Offset(msg)
+	aead.Seal(reply[cookieOffset:cookieOffset], nonce, cookie[:], msg[off:off+macSize])
+	return reply, true
+}
+
+// cookieState is the state of the cookies that a device received from a peer.
+//
+// +stateify savable
+type cookieState struct {
+	// mac1Key is the key of the first MAC of messages sent to the peer.
+	mac1Key [blake2s.Size]byte
+
+	// cookieKey is the key with which the peer encrypts its cookies.
+	cookieKey [blake2s.Size]byte
+
+	// cookie is the last cookie received from the peer.
+	cookie         [macSize]byte
+	cookieReceived tcpip.MonotonicTime
+
+	// lastMAC1 is the first MAC of the last handshake message sent to the
+	// peer, which authenticates its cookie replies.
+	lastMAC1    [macSize]byte
+	hasLastMAC1 bool
+}
+
+// init initializes s for the public key of a peer.
+func (s *cookieState) init(publicKey *Key) {
+	*s = cookieState{
+		mac1Key:   hashString(labelMAC1, publicKey[:]),
+		cookieKey: hashString(labelCookie, publicKey[:]),
+	}
+}
+
+// addMACs sets the MACs of the handshake message msg.
+func (s *cookieState) addMACs(clock tcpip.Clock, msg []byte) {
+	off1, off2 := mac1Offset(msg), mac2Offset(msg)
+	mac1 := mac(s.mac1Key[:], msg[:off1])
+	copy(msg[off1:], mac1[:])
+	s.lastMAC1 = mac1
+	s.hasLastMAC1 = true
+
+	// The cookie is only used while the peer surely still accepts it.
+	if s.cookieReceived == (tcpip.MonotonicTime{}) || clock.NowMonotonic().Sub(s.cookieReceived) >= cookieRefreshTime-rekeyTimeout {
+		clear(msg[off2:])
+		return
+	}
+	mac2 := mac(s.cookie[:], msg[:off2])
+	copy(msg[off2:], mac2[:])
+}
+
+// consumeReply consumes a cookie reply message.
+func (s *cookieState) consumeReply(clock tcpip.Clock, reply []byte) bool {
+	if !s.hasLastMAC1 {
+		return false
+	}
+	aead, err := chacha20poly1305.NewX(s.cookieKey[:])
+	if err != nil {
+		return false
+	}
+	cookie, err := aead.Open(nil, reply[cookieNonceOffset:cookieOffset], reply[cookieOffset:], s.lastMAC1[:])
+	if err != nil {
+		return false
+	}
+	copy(s.cookie[:], cookie)
+	s.cookieReceived = clock.NowMonotonic()
+	s.hasLastMAC1 = false
+	return true
+}
@@ -0,0 +1,626 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wireguard
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/subtle"
+	"encoding/binary"
+	"hash"
+	"io"
+	"time"
+
+	"golang.org/x/crypto/blake2s"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+// KeySize is the size of keys, which are Curve25519 keys or preshared keys.
+const KeySize = 32
+
+// Key is a Curve25519 private or public key, or a preshared key.
+type Key [KeySize]byte
+
+// IsZero returns whether k is all zeros, which stands for no key.
+func (k *Key) IsZero() bool {
+	return subtle.ConstantTimeCompare(k[:], make([]byte, KeySize)) == 1
+}
+
+// clamp clamps the private key k as described in RFC 7748.
+func (k *Key) clamp() {
+	k[0] &= 248
+	k[31] = (k[31] & 127) | 64
+}
+
+// PublicKey returns the public key of the private key k.
+func (k *Key) PublicKey() Key {
+	var pub Key
+	priv := *k
+	priv.clamp()
+	b, err := curve25519.X25519(priv[:], curve25519.Basepoint)
+	if err != nil {
+		// Only the zero key has no public key.
+		return pub
+	}
+	copy(pub[:], b)
+	return pub
+}
+
+// GeneratePrivateKey returns a new private key read from rng.
+func GeneratePrivateKey(rng io.Reader) (Key, error) {
+	var k Key
+	if _, err := io.ReadFull(rng, k[:]); err != nil {
+		return Key{}, err
+	}
+	k.clamp()
+	return k, nil
+}
+
+// dh returns the shared secret of a private key and a public key, or false if
+// pub is a low order point.
+func dh(priv, pub *Key) (Key, bool) {
+	var ss Key
+	b, err := curve25519.X25519(priv[:], pub[:])
+	if err != nil {
+		return ss, false
+	}
+	copy(ss[:], b)
+	return ss, true
+}
+
+const (
+	construction = "Noise_IKpsk2_25519_ChaChaPoly_BLAKE2s"
+	identifier   = "WireGuard v1 zx2c4 Jason@zx2c4.com"
+	labelMAC1    = "mac1----"
+	labelCookie  = "cookie--"
+)
+
+// Message types.
+const (
+	messageInitiationType  = 1
+	messageResponseType    = 2
+	messageCookieReplyType = 3
+	messageTransportType   = 4
+)
+
+// Message sizes.
+const (
+	macSize         = blake2s.Size128
+	tagSize         = chacha20poly1305.Overhead
+	timestampSize   = 12
+	cookieNonceSize = chacha20poly1305.NonceSizeX
+
+	messageInitiationSize      = 4 + 4 + KeySize + KeySize + tagSize + timestampSize + tagSize + 2*macSize
+	messageResponseSize        = 4 + 4 + 4 + KeySize + tagSize + 2*macSize
+	messageCookieReplySize     = 4 + 4 + cookieNonceSize + macSize + tagSize
+	messageTransportHeaderSize = 4 + 4 + 8
+	messageTransportMinSize    = messageTransportHeaderSize + tagSize
+
+	// messageTransportOverhead is the number of bytes that a transport
+	// message adds to the packet that it carries.
+	messageTransportOverhead = messageTransportHeaderSize + tagSize
+)
+
+// Field offsets of handshake messages.
+const (
+	initiationSenderOffset    = 4
+	initiationEphemeralOffset = 8
+	initiationStaticOffset    = initiationEphemeralOffset + KeySize
+	initiationTimestampOffset = initiationStaticOffset + KeySize + tagSize
+
+	responseSenderOffset    = 4
+	responseReceiverOffset  = 8
+	responseEphemeralOffset = 12
+	responseEmptyOffset     = responseEphemeralOffset + KeySize
+
+	cookieReceiverOffset = 4
+	cookieNonceOffset    = 8
+	cookieOffset         = cookieNonceOffset + cookieNonceSize
+
+	transportReceiverOffset = 4
+	transportCounterOffset  = 8
+)
+
+// mac1Offset returns the offset of the first MAC of a handshake message.
+func mac1Offset(msg []byte) int {
+	return len(msg) - 2*macSize
+}
+
+// mac2Offset returns the offset of the second MAC of a handshake message.
+func mac2Offset(msg []byte) int {
+	return len(msg) - macSize
+}
+
+func newHash() hash.Hash {
+	h, _ := blake2s.New256(nil)
+	return h
+}
+
+// mixHash returns HASH(h || data).
+func mixHash(h *[blake2s.Size]byte, data []byte) [blake2s.Size]byte {
+	d := newHash()
+	d.Write(h[:])
+	d.Write(data)
+	var sum [blake2s.Size]byte
+	d.Sum(sum[:0])
+	return sum
+}
+
+// hashString returns HASH(prefix || b).
+func hashString(prefix string, b []byte) [blake2s.Size]byte {
+	d := newHash()
+	d.Write([]byte(prefix))
+	d.Write(b)
+	var sum [blake2s.Size]byte
+	d.Sum(sum[:0])
+	return sum
+}
+
+// mac returns the keyed BLAKE2s-128 MAC of b.
+func mac(key []byte, b ...[]byte) [macSize]byte {
+	d, err := blake2s.New128(key)
+	if err != nil {
+		panic(err)
+	}
+	for _, p := range b {
+		d.Write(p)
+	}
+	var sum [macSize]byte
+	d.Sum(sum[:0])
+	return sum
+}
+
+func hmacSum(key []byte, b ...[]byte) [blake2s.Size]byte {
+	d := hmac.New(newHash, key)
+	for _, p := range b {
+		d.Write(p)
+	}
+	var sum [blake2s.Size]byte
+	d.Sum(sum[:0])
+	return sum
+}
+
+// kdf derives len(outs) keys from the chaining key ck and input, with the
+// HKDF of the Noise protocol framework. outs may alias ck.
+func kdf(ck *[blake2s.Size]byte, input []byte, outs ...*[blake2s.Size]byte) {
+	prk := hmacSum(ck[:], input)
+	var prev []byte
+	for i, out := range outs {
+		*out = hmacSum(prk[:], prev, []byte{byte(i + 1)})
+		prev = out[:]
+	}
+}
+
+var zeroNonce [chacha20poly1305.NonceSize]byte
+
+// seal appends the encryption of plaintext under key with a zero nonce to
+// dst.
+func seal(dst []byte, key *[blake2s.Size]byte, plaintext, ad []byte) []byte {
+	aead, err := chacha20poly1305.New(key[:])
+	if err != nil {
+		panic(err)
+	}
+	return aead.Seal(dst, zeroNonce[:], plaintext, ad)
+}
+
+// open decrypts ciphertext under key with a zero nonce.
+func open(key *[blake2s.Size]byte, ciphertext, ad []byte) ([]byte, bool) {
+	aead, err := chacha20poly1305.New(key[:])
+	if err != nil {
+		panic(err)
+	}
+	b, err := aead.Open(nil, zeroNonce[:], ciphertext, ad)
+	return b, err == nil
+}
+
+var (
+	initialChainKey = hashString(construction, nil)
+	initialHash     = mixHash(&initialChainKey, []byte(identifier))
+)
+
+// tai64nEpoch is the TAI64 label of the Unix epoch.
+const tai64nEpoch = 1<<62 + 10
+
+// tai64n returns the TAI64N timestamp of t. The nanoseconds are rounded down,
+// so that the timestamp doesn't leak precise timing.
+func tai64n(t time.Time) [timestampSize]byte {
+	var ts [timestampSize]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(t.Unix())+tai64nEpoch)
+	nsec := t.Nanosecond()
+	nsec -= nsec % int(time.Second/maxInitiationsPerSecond)
+	binary.BigEndian.PutUint32(ts[8:], uint32(nsec))
+	return ts
+}
+
+// handshakeState is the state of a handshake.
+type handshakeState int
+
+const (
+	handshakeZeroed handshakeState = iota
+	handshakeCreatedInitiation
+	handshakeConsumedInitiation
+	handshakeCreatedResponse
+	handshakeConsumedResponse
+)
+
+// handshake is the state of a Noise IK handshake with a peer.
+type handshake struct {
+	state           handshakeState
+	hash            [blake2s.Size]byte
+	chainKey        [blake2s.Size]byte
+	localEphemeral  Key
+	remoteEphemeral Key
+	localIndex      uint32
+	remoteIndex     uint32
+}
+
+// createInitiationLocked creates a handshake initiation message to p.
+//
+// +checklocks:e.mu
+func (e *Endpoint) createInitiationLocked(p *peer) ([]byte, bool) {
+	if e.privateKey.IsZero() || p.staticStatic.IsZero() {
+		return nil, false
+	}
+	e.removeHandshakeLocked(p)
+	hs := &p.handshake
+	hs.chainKey = initialChainKey
+	hs.hash = mixHash(&initialHash, p.publicKey[:])
+
+	msg := make([]byte, messageInitiationSize)
+	binary.LittleEndian.PutUint32(msg, messageInitiationType)
+
+	// e
+	var err error
+	if hs.localEphemeral, err = GeneratePrivateKey(e.stack.SecureRNG().Reader); err != nil {
+		return nil, false
+	}
+	ephemeral := hs.localEphemeral.PublicKey()
+	copy(msg[initiationEphemeralOffset:], ephemeral[:])
+	hs.hash = mixHash(&hs.hash, ephemeral[:])
+	kdf(&hs.chainKey, ephemeral[:], &hs.chainKey)
+
+	// es
+	ss, ok := dh(&hs.localEphemeral, &p.publicKey)
+	if !ok {
+		return nil, false
+	}
+	var key [blake2s.Size]byte
+	kdf(&hs.chainKey, ss[:], &hs.chainKey, &key)
+
+	// s
+	static := seal(msg[initiationStaticOffset:initiationStaticOffset], &key, e.publicKey[:], hs.hash[:])
+	hs.hash = mixHash(&hs.hash, static)
+
+	// ss
+	kdf(&hs.chainKey, p.staticStatic[:], &hs.chainKey, &key)
+
+	// {t}
+	ts := tai64n(e.stack.Clock().Now())
+	timestamp := seal(msg[initiationTimestampOffset:initiationTimestampOffset], &key, ts[:], hs.hash[:])
+	hs.hash = mixHash(&hs.hash, timestamp)
+
+	hs.localIndex = e.newIndexLocked(indexEntry{peer: p})
+	binary.LittleEndian.PutUint32(msg[initiationSenderOffset:], hs.localIndex)
+	hs.state = handshakeCreatedInitiation
+	p.cookies.addMACs(e.stack.Clock(), msg)
+	return msg, true
+}
+
+// consumeInitiationLocked consumes a handshake initiation message and returns the
+// peer that sent it.
+//
+// +checklocks:e.mu
+func (e *Endpoint) consumeInitiationLocked(msg []byte) *peer {
+	if e.privateKey.IsZero() {
+		return nil
+	}
+	chainKey := initialChainKey
+	h := mixHash(&initialHash, e.publicKey[:])
+
+	// e
+	var ephemeral Key
+	copy(ephemeral[:], msg[initiationEphemeralOffset:])
+	h = mixHash(&h, ephemeral[:])
+	kdf(&chainKey, ephemeral[:], &chainKey)
+
+	// es
+	ss, ok := dh(&e.privateKey, &ephemeral)
+	if !ok {
+		return nil
+	}
+	var key [blake2s.Size]byte
+	kdf(&chainKey, ss[:], &chainKey, &key)
+
+	// s
+	static := msg[initiationStaticOffset:initiationTimestampOffset]
+	b, ok := open(&key, static, h[:])
+	if !ok {
+		return nil
+	}
+	h = mixHash(&h, static)
+	var publicKey Key
+	copy(publicKey[:], b)
+	p, ok := e.peers[publicKey]
+	if !ok || p.staticStatic.IsZero() {
+		return nil
+	}
+
+	// ss
+	kdf(&chainKey, p.staticStatic[:], &chainKey, &key)
+
+	// {t}
+	timestamp := msg[initiationTimestampOffset:mac1Offset(msg)]
+	ts, ok := open(&key, timestamp, h[:])
+	if !ok {
+		return nil
+	}
+	h = mixHash(&h, timestamp)
+
+	// Reject replayed initiations, and initiations sent too often.
+	now := e.stack.Clock().NowMonotonic()
+	if bytes.Compare(ts, p.latestTimestamp[:]) <= 0 {
+		return nil
+	}
+	if p.lastInitiationConsumption != (tcpip.MonotonicTime{}) && now.Sub(p.lastInitiationConsumption) < time.Second/maxInitiationsPerSecond {
+		return nil
+	}
+
+	e.removeHandshakeLocked(p)
+	copy(p.latestTimestamp[:], ts)
+	p.lastInitiationConsumption = now
+	p.handshake = handshake{
+		state:           handshakeConsumedInitiation,
+		hash:            h,
+		chainKey:        chainKey,
+		remoteEphemeral: ephemeral,
+		remoteIndex:     binary.LittleEndian.Uint32(msg[initiationSenderOffset:]),
+	}
+	return p
+}
+
+// createResponseLocked creates a handshake response message to p.
+//
+// Preconditions: p consumed an initiation.
+//
+// +checklocks:e.mu
+func (e *Endpoint) createResponseLocked(p *peer) ([]byte, bool) {
+	hs := &p.handshake
+	if hs.state != handshakeConsumedInitiation {
+		return nil, false
+	}
+	msg := make([]byte, messageResponseSize)
+	binary.LittleEndian.PutUint32(msg, messageResponseType)
+	binary.LittleEndian.PutUint32(msg[responseReceiverOffset:], hs.remoteIndex)
+
+	// e
+	var err error
+	if hs.localEphemeral, err = GeneratePrivateKey(e.stack.SecureRNG().Reader); err != nil {
+		return nil, false
+	}
+	ephemeral := hs.localEphemeral.PublicKey()
+	copy(msg[responseEphemeralOffset:], ephemeral[:])
+	hs.hash = mixHash(&hs.hash, ephemeral[:])
+	kdf(&hs.chainKey, ephemeral[:], &hs.chainKey)
+
+	// ee
+	ss, ok := dh(&hs.localEphemeral, &hs.remoteEphemeral)
+	if !ok {
+		return nil, false
+	}
+	kdf(&hs.chainKey, ss[:], &hs.chainKey)
+
+	// se
+	if ss, ok = dh(&hs.localEphemeral, &p.publicKey); !ok {
+		return nil, false
+	}
+	kdf(&hs.chainKey, ss[:], &hs.chainKey)
+
+	// psk
+	var tau, key [blake2s.Size]byte
+	kdf(&hs.chainKey, p.presharedKey[:], &hs.chainKey, &tau, &key)
+	hs.hash = mixHash(&hs.hash, tau[:])
+
+	// {}
+	empty := seal(msg[responseEmptyOffset:responseEmptyOffset], &key, nil, hs.hash[:])
+	hs.hash = mixHash(&hs.hash, empty)
+
+	hs.localIndex = e.newIndexLocked(indexEntry{peer: p})
+	binary.LittleEndian.PutUint32(msg[responseSenderOffset:], hs.localIndex)
+	hs.state = handshakeCreatedResponse
+	p.cookies.addMACs(e.stack.Clock(), msg)
+	return msg, true
+}
+
+// consumeResponseLocked consumes a handshake response message and returns the peer
+// that sent it.
+//
+// +checklocks:e.mu
+func (e *Endpoint) consumeResponseLocked(msg []byte) *peer {
+	entry, ok := e.indices[binary.LittleEndian.Uint32(msg[responseReceiverOffset:])]
+	if !ok || entry.keypair != nil {
+		return nil
+	}
+	p := entry.peer
+	hs := &p.handshake
+	if hs.state != handshakeCreatedInitiation || e.privateKey.IsZero() {
+		return nil
+	}
+	chainKey := hs.chainKey
+	h := hs.hash
+
+	// e
+	var ephemeral Key
+	copy(ephemeral[:], msg[responseEphemeralOffset:])
+	h = mixHash(&h, ephemeral[:])
+	kdf(&chainKey, ephemeral[:], &chainKey)
+
+	// ee
+	ss, ok := dh(&hs.localEphemeral, &ephemeral)
+	if !ok {
+		return nil
+	}
+	kdf(&chainKey, ss[:], &chainKey)
+
+	// se
+	if ss, ok = dh(&e.privateKey, &ephemeral); !ok {
+		return nil
+	}
+	kdf(&chainKey, ss[:], &chainKey)
+
+	// psk
+	var tau, key [blake2s.Size]byte
+	kdf(&chainKey, p.presharedKey[:], &chainKey, &tau, &key)
+	h = mixHash(&h, tau[:])
+
+	// {}
+	empty := msg[responseEmptyOffset:mac1Offset(msg)]
+	if _, ok := open(&key, empty, h[:]); !ok {
+		return nil
+	}
+	h = mixHash(&h, empty)
+
+	hs.hash = h
+	hs.chainKey = chainKey
+	hs.remoteEphemeral = ephemeral
+	hs.remoteIndex = binary.LittleEndian.Uint32(msg[responseSenderOffset:])
+	hs.state = handshakeConsumedResponse
+	return p
+}
+
+// keypair holds the transport keys of a session with a peer.
+type keypair struct {
+	peer      *peer
+	send      cipher.AEAD
+	recv      cipher.AEAD
+	initiator bool
+	created   tcpip.MonotonicTime
+
+	localIndex  uint32
+	remoteIndex uint32
+
+	// sendNonce is the counter of the next sent transport message. It is
+	// protected by the endpoint mutex.
+	sendNonce uint64
+
+	replay replayFilter
+}
+
+// beginSessionLocked derives a keypair from the completed handshake with p, and
+// installs it.
+//
+// +checklocks:e.mu
+func (e *Endpoint) beginSessionLocked(p *peer) bool {
+	hs := &p.handshake
+	var initiator bool
+	switch hs.state {
+	case handshakeConsumedResponse:
+		initiator = true
+	case handshakeCreatedResponse:
+		initiator = false
+	default:
+		return false
+	}
+	var first, second [blake2s.Size]byte
+	kdf(&hs.chainKey, nil, &first, &second)
+	if !initiator {
+		first, second = second, first
+	}
+	send, err := chacha20poly1305.New(first[:])
+	if err != nil {
+		return false
+	}
+	recv, err := chacha20poly1305.New(second[:])
+	if err != nil {
+		return false
+	}
+	kp := &keypair{
+		peer:        p,
+		send:        send,
+		recv:        recv,
+		initiator:   initiator,
+		created:     e.stack.Clock().NowMonotonic(),
+		localIndex:  hs.localIndex,
+		remoteIndex: hs.remoteIndex,
+	}
+	// The keypair takes over the index of the handshake.
+	e.indices[kp.localIndex] = indexEntry{peer: p, keypair: kp}
+	p.handshake = handshake{}
+
+	if initiator {
+		if p.next != nil {
+			// The next keypair was never confirmed, so the
+			// current one is older than it.
+			e.removeKeypairLocked(p.previous)
+			p.previous = p.next
+			p.next = nil
+			e.removeKeypairLocked(p.current)
+		} else {
+			e.removeKeypairLocked(p.previous)
+			p.previous = p.current
+		}
+		p.current = kp
+	} else {
+		// The keypair is only used to send once the initiator
+		// confirms it by using it.
+		e.removeKeypairLocked(p.next)
+		p.next = kp
+		e.removeKeypairLocked(p.previous)
+		p.previous = nil
+	}
+	return true
+}
+
+// confirmKeypairLocked makes the next keypair of its peer the current one once it
+// is used by the initiator.
+//
+// +checklocks:e.mu
+func (e *Endpoint) confirmKeypairLocked(kp *keypair) bool {
+	p := kp.peer
+	if p.next != kp {
+		return false
+	}
+	e.removeKeypairLocked(p.previous)
+	p.previous = p.current
+	p.current = kp
+	p.next = nil
+	return true
+}
+
+// +checklocks:e.mu
+func (e *Endpoint) removeKeypairLocked(kp *keypair) {
+	if kp != nil {
+		delete(e.indices, kp.localIndex)
+	}
+}
+
+// +checklocks:e.mu
+func (e *Endpoint) removeHandshakeLocked(p *peer) {
+	if p.handshake.state != handshakeZeroed {
+		if entry, ok := e.indices[p.handshake.localIndex]; ok && entry.keypair == nil {
+			delete(e.indices, p.handshake.localIndex)
+		}
+	}
+	p.handshake = handshake{}
+}
+
+// transportNonce returns the AEAD nonce of a transport message counter.
+func transportNonce(counter uint64) []byte {
+	var nonce [chacha20poly1305.NonceSize]byte
+	binary.LittleEndian.PutUint64(nonce[4:], counter)
+	return nonce[:]
+}
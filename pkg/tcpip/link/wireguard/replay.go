@@ -0,0 +1,74 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wireguard
+
+import "gvisor.dev/gvisor/pkg/sync"
+
+const (
+	replayBlockBits  = 64
+	replayRingBlocks = 128
+
+	// replayWindowSize is the number of counters before the greatest
+	// received counter that are still accepted.
+	replayWindowSize = (replayRingBlocks - 1) * replayBlockBits
+)
+
+// replayFilter rejects the transport messages whose counter was already
+// received or is too old, with the sliding window of RFC 6479.
+type replayFilter struct {
+	mu sync.Mutex
+	// greatest is the greatest received counter.
+	//
+	// +checklocks:mu
+	greatest uint64
+	// ring holds a bit for each counter of the window.
+	//
+	// +checklocks:mu
+	ring [replayRingBlocks]uint64
+}
+
+// validate returns whether counter wasn't received yet, and marks it as
+// received.
+//
+// Preconditions: The message of counter is authenticated.
+func (f *replayFilter) validate(counter uint64) bool {
+	if counter >= rejectAfterMessages {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	block := counter / replayBlockBits
+	if counter > f.greatest {
+		// Slide the window, clearing the blocks that it enters.
+		current := f.greatest / replayBlockBits
+		n := block - current
+		if n > replayRingBlocks {
+			n = replayRingBlocks
+		}
+		for i := uint64(1); i <= n; i++ {
+			f.ring[(current+i)%replayRingBlocks] = 0
+		}
+		f.greatest = counter
+	} else if f.greatest-counter > replayWindowSize {
+		return false
+	}
+	bit := uint64(1) << (counter % replayBlockBits)
+	b := &f.ring[block%replayRingBlocks]
+	if *b&bit != 0 {
+		return false
+	}
+	*b |= bit
+	return true
+}
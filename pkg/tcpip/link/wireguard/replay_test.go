@@ -0,0 +1,46 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wireguard
+
+import "testing"
+
+func TestReplayFilter(t *testing.T) {
+	var f replayFilter
+	for _, tc := range []struct {
+		counter uint64
+		want    bool
+	}{
+		{counter: 0, want: true},
+		{counter: 0, want: false},
+		{counter: 2, want: true},
+		{counter: 1, want: true},
+		{counter: 1, want: false},
+		{counter: replayWindowSize + 2, want: true},
+		// 2 is still within the window, 1 is not.
+		{counter: 2, want: false},
+		{counter: 1, want: false},
+		{counter: 3, want: true},
+		// Jumping far ahead clears the whole window.
+		{counter: 10 * replayWindowSize, want: true},
+		{counter: 10*replayWindowSize - 1, want: true},
+		{counter: 9 * replayWindowSize, want: true},
+		{counter: 9*replayWindowSize - 1, want: false},
+		{counter: rejectAfterMessages, want: false},
+	} {
+		if got := f.validate(tc.counter); got != tc.want {
+			t.Errorf("got validate(%d) = %t, want = %t", tc.counter, got, tc.want)
+		}
+	}
+}
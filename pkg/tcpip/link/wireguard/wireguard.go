@@ -0,0 +1,1286 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package wireguard provides link endpoints that implement WireGuard devices.
+//
+// A device carries IP packets to its peers in transport messages, which are
+// encrypted with the keys of sessions established with the Noise IK handshake.
+// Messages are sent over UDP endpoints of the stack that the device belongs
+// to. Peers are selected by the allowed IPs that are routed to them, and
+// received packets are only delivered if their source address is allowed for
+// the peer that sent them.
+//
+// The protocol is described in "WireGuard: Next Generation Kernel Network
+// Tunnel" by Jason A. Donenfeld. The timers and the handling of sessions
+// follow the Linux implementation.
+package wireguard
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math"
+	"sort"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/atomicbitops"
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/sync"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/udp"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+// DefaultMTU is the MTU of devices, which leaves room for the transport
+// message overhead and the UDP and IPv6 headers in an Ethernet MTU.
+const DefaultMTU = 1420
+
+// Protocol parameters.
+const (
+	rekeyAfterMessages  = 1 << 60
+	rejectAfterMessages = math.MaxUint64 - (1 << 13)
+	rekeyAfterTime      = 120 * time.Second
+	rekeyAttemptTime    = 90 * time.Second
+	rekeyTimeout        = 5 * time.Second
+	rekeyTimeoutJitter  = time.Second / 3
+	rejectAfterTime     = 180 * time.Second
+	keepaliveTimeout    = 10 * time.Second
+	cookieRefreshTime   = 120 * time.Second
+
+	// maxTimerHandshakes is the number of handshake attempts after which
+	// a peer is given up on until more packets are sent to it.
+	maxTimerHandshakes = int(rekeyAttemptTime / rekeyTimeout)
+
+	// maxInitiationsPerSecond is the number of initiations that are
+	// accepted from a peer every second.
+	maxInitiationsPerSecond = 50
+
+	// maxStagedPackets is the number of packets that are kept for a peer
+	// while a session is established with it.
+	maxStagedPackets = 128
+
+	// underLoadHandshakes is the number of handshake messages received
+	// within a second after which a device is under load, and requires the
+	// initiators to prove that they own their address with a cookie.
+	underLoadHandshakes = 64
+
+	// paddingMultiple is the multiple to which packets are padded.
+	paddingMultiple = 16
+
+	// maxPortAttempts is the number of ephemeral ports that are tried when
+	// binding the sockets of a device to an unspecified port.
+	maxPortAttempts = 16
+)
+
+// Options holds the configuration of a device that is set when it is
+// created.
+type Options struct {
+	// MTU is the MTU of the device. If zero, DefaultMTU is used.
+	MTU uint32
+}
+
+// Config is a configuration change of a device. Nil fields are left
+// unchanged.
+type Config struct {
+	// PrivateKey is the private key of the device. A zero key removes the
+	// identity of the device, which then can't establish sessions.
+	PrivateKey *Key
+
+	// ListenPort is the UDP port on which the device sends and receives
+	// messages. If zero, an ephemeral port is picked.
+	ListenPort *uint16
+
+	// FwMark is the firewall mark of the messages sent by the device. It is
+	// reported back, but has no effect as the stack has no packet marks.
+	FwMark *uint32
+
+	// ReplacePeers removes all the peers before Peers are applied.
+	ReplacePeers bool
+
+	// Peers are the changes of peers.
+	Peers []PeerConfig
+}
+
+// PeerConfig is a configuration change of a peer, which is created unless it
+// is removed or UpdateOnly is set. Nil fields are left unchanged.
+type PeerConfig struct {
+	// PublicKey is the public key that identifies the peer.
+	PublicKey Key
+
+	// Remove removes the peer.
+	Remove bool
+
+	// UpdateOnly only changes the peer if it exists.
+	UpdateOnly bool
+
+	// PresharedKey is the key that is mixed into handshakes with the peer.
+	// A zero key means no preshared key.
+	PresharedKey *Key
+
+	// Endpoint is the address to which messages to the peer are sent. It is
+	// updated with the source address of the messages received from the
+	// peer.
+	Endpoint *tcpip.FullAddress
+
+	// PersistentKeepalive is the interval of the keepalives sent to the
+	// peer to keep stateful firewalls open. Zero disables them.
+	PersistentKeepalive *time.Duration
+
+	// ReplaceAllowedIPs removes the allowed IPs of the peer before
+	// AllowedIPs are added.
+	ReplaceAllowedIPs bool
+
+	// AllowedIPs are subnets that are routed to the peer, and from which
+	// the peer may send packets.
+	AllowedIPs []tcpip.Subnet
+}
+
+// Info is the state of a device.
+type Info struct {
+	PrivateKey Key
+	PublicKey  Key
+	ListenPort uint16
+	FwMark     uint32
+	Peers      []PeerInfo
+}
+
+// PeerInfo is the state of a peer.
+type PeerInfo struct {
+	PublicKey           Key
+	PresharedKey        Key
+	Endpoint            tcpip.FullAddress
+	PersistentKeepalive time.Duration
+	AllowedIPs          []tcpip.Subnet
+
+	// LastHandshake is the time of the last completed handshake, or the
+	// zero time if there was none.
+	LastHandshake time.Time
+
+	RxBytes uint64
+	TxBytes uint64
+}
+
+// timer is a job that knows whether it is scheduled.
+type timer struct {
+	job     *tcpip.Job
+	pending bool
+}
+
+// init initializes t to run fn with the mutex of e held.
+func (t *timer) init(e *Endpoint, fn func()) {
+	t.job = tcpip.NewJob(e.stack.Clock(), &e.mu, func() {
+		t.pending = false
+		fn()
+	})
+}
+
+func (t *timer) schedule(d time.Duration) {
+	t.job.Cancel()
+	t.job.Schedule(d)
+	t.pending = true
+}
+
+func (t *timer) cancel() {
+	t.job.Cancel()
+	t.pending = false
+}
+
+// peer is a peer of a device. Its fields are protected by the mutex of the
+// device.
+//
+// +stateify savable
+type peer struct {
+	// id orders peers by their creation.
+	id uint64
+
+	publicKey    Key
+	presharedKey Key
+
+	// staticStatic is the shared secret of the static keys of the device
+	// and the peer.
+	staticStatic Key
+
+	endpoint            tcpip.FullAddress
+	persistentKeepalive time.Duration
+
+	cookies cookieState
+
+	// latestTimestamp is the timestamp of the latest initiation consumed
+	// from the peer, which rejects replayed initiations.
+	latestTimestamp           [timestampSize]byte
+	lastInitiationConsumption tcpip.MonotonicTime
+
+	// Sessions don't survive save and restore, as the stack clock
+	// restarts. They are established again when packets are sent.
+	handshake handshake `state:"nosave"`
+	current   *keypair  `state:"nosave"`
+	previous  *keypair  `state:"nosave"`
+	next      *keypair  `state:"nosave"`
+
+	// staged are the packets waiting for a session.
+	staged []buffer.Buffer `state:"nosave"`
+
+	lastSentHandshake       tcpip.MonotonicTime
+	handshakeAttempts       int
+	sentLastMinuteHandshake bool
+	needAnotherKeepalive    bool
+
+	// lastHandshake is the time of the last completed handshake in
+	// nanoseconds since the Unix epoch, or zero.
+	lastHandshake int64
+
+	rxBytes uint64
+	txBytes uint64
+
+	// removed is set when the peer is removed from the device.
+	removed bool
+
+	retransmitHandshakeTimer timer `state:"nosave"`
+	sendKeepaliveTimer       timer `state:"nosave"`
+	newHandshakeTimer        timer `state:"nosave"`
+	zeroKeyMaterialTimer     timer `state:"nosave"`
+	persistentKeepaliveTimer timer `state:"nosave"`
+}
+
+// indexEntry is the handshake or the keypair that a local index stands for.
+type indexEntry struct {
+	peer *peer
+
+	// keypair is nil for the index of a handshake.
+	keypair *keypair
+}
+
+// socket is a UDP endpoint on which a device sends and receives messages.
+//
+// +stateify savable
+type socket struct {
+	ep tcpip.Endpoint
+	wq waiter.Queue
+}
+
+var _ stack.LinkEndpoint = (*Endpoint)(nil)
+
+// Endpoint is a link endpoint that implements a WireGuard device.
+//
+// +stateify savable
+type Endpoint struct {
+	stack *stack.Stack
+
+	// port is the port to which the sockets are bound. It is read without
+	// the mutex to drop the messages of the device that are routed back to
+	// it.
+	port atomicbitops.Uint32
+
+	// mtu is read without the mutex, as the stack may query it while the
+	// device sends messages through the stack.
+	mtu atomicbitops.Uint32
+
+	wg sync.WaitGroup `state:"nosave"`
+
+	mu endpointRWMutex `state:"nosave"`
+	// +checklocks:mu
+	closed bool
+	// +checklocks:mu
+	dispatcher stack.NetworkDispatcher
+	// +checklocks:mu
+	onCloseAction func() `state:"nosave"`
+	// +checklocks:mu
+	sock4 *socket
+	// sock6 is nil if the stack has no IPv6.
+	//
+	// +checklocks:mu
+	sock6 *socket
+
+	// +checklocks:mu
+	privateKey Key
+	// +checklocks:mu
+	publicKey Key
+	// +checklocks:mu
+	fwMark uint32
+	// +checklocks:mu
+	cookies cookieChecker
+
+	// +checklocks:mu
+	peers map[Key]*peer
+	// +checklocks:mu
+	nextPeerID uint64
+	// +checklocks:mu
+	allowedIPs allowedIPs
+
+	// indices maps local indices to the handshakes and keypairs that they
+	// stand for.
+	//
+	// +checklocks:mu
+	indices map[uint32]indexEntry `state:"nosave"`
+
+	// handshakesSecond is the second in which handshakesReceived
+	// handshake messages were received.
+	//
+	// +checklocks:mu
+	handshakesSecond int64
+	// +checklocks:mu
+	handshakesReceived int
+	// lastUnderLoad is when the device was last under load.
+	//
+	// +checklocks:mu
+	lastUnderLoad tcpip.MonotonicTime
+}
+
+// New creates a device whose messages are sent over UDP endpoints of s. The
+// endpoints are bound to an ephemeral port until one is configured.
+func New(s *stack.Stack, opts Options) (*Endpoint, tcpip.Error) {
+	if opts.MTU == 0 {
+		opts.MTU = DefaultMTU
+	}
+	e := &Endpoint{
+		stack:   s,
+		peers:   make(map[Key]*peer),
+		indices: make(map[uint32]indexEntry),
+	}
+	e.mtu.Store(opts.MTU)
+	e.allowedIPs.init()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.cookies.init(&e.publicKey)
+	if err := e.bindLocked(0); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// afterLoad is invoked by stateify.
+func (e *Endpoint) afterLoad(context.Context) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.indices = make(map[uint32]indexEntry)
+	for _, p := range e.peers {
+		e.initTimersLocked(p)
+	}
+	if e.closed {
+		return
+	}
+	for _, s := range []*socket{e.sock4, e.sock6} {
+		if s != nil {
+			e.startDispatchLoop(s)
+		}
+	}
+}
+
+// openSocket opens a UDP endpoint of netProto bound to port.
+func (e *Endpoint) openSocket(netProto tcpip.NetworkProtocolNumber, port uint16) (*socket, tcpip.Error) {
+	s := &socket{}
+	ep, err := e.stack.NewEndpoint(udp.ProtocolNumber, netProto, &s.wq)
+	if err != nil {
+		return nil, err
+	}
+	if netProto == header.IPv6ProtocolNumber {
+		ep.SocketOptions().SetV6Only(true)
+	}
+	if err := ep.Bind(tcpip.FullAddress{Port: port}); err != nil {
+		ep.Close()
+		return nil, err
+	}
+	s.ep = ep
+	return s, nil
+}
+
+// openSockets opens the IPv4 endpoint and, if the stack has IPv6, the IPv6
+// endpoint of a device, both bound to port.
+func (e *Endpoint) openSockets(port uint16) (*socket, *socket, tcpip.Error) {
+	sock4, err := e.openSocket(header.IPv4ProtocolNumber, port)
+	if err != nil {
+		return nil, nil, err
+	}
+	if e.stack.NetworkProtocolInstance(header.IPv6ProtocolNumber) == nil {
+		return sock4, nil, nil
+	}
+	if port == 0 {
+		addr, err := sock4.ep.GetLocalAddress()
+		if err != nil {
+			sock4.ep.Close()
+			return nil, nil, err
+		}
+		port = addr.Port
+	}
+	sock6, err := e.openSocket(header.IPv6ProtocolNumber, port)
+	if err != nil {
+		sock4.ep.Close()
+		return nil, nil, err
+	}
+	return sock4, sock6, nil
+}
+
+// bindLocked replaces the sockets of the device by sockets bound to port. If
+// port is zero, an ephemeral port that is free for both IPv4 and IPv6 is
+// picked.
+//
+// +checklocks:e.mu
+func (e *Endpoint) bindLocked(port uint16) tcpip.Error {
+	var (
+		sock4, sock6 *socket
+		err          tcpip.Error
+	)
+	for i := 0; i < maxPortAttempts; i++ {
+		sock4, sock6, err = e.openSockets(port)
+		if _, ok := err.(*tcpip.ErrPortInUse); !ok || port != 0 {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+	addr, err := sock4.ep.GetLocalAddress()
+	if err != nil {
+		sock4.ep.Close()
+		if sock6 != nil {
+			sock6.ep.Close()
+		}
+		return err
+	}
+	e.closeSocketsLocked()
+	e.sock4, e.sock6 = sock4, sock6
+	e.port.Store(uint32(addr.Port))
+	e.startDispatchLoop(sock4)
+	if sock6 != nil {
+		e.startDispatchLoop(sock6)
+	}
+	return nil
+}
+
+// +checklocks:e.mu
+func (e *Endpoint) closeSocketsLocked() {
+	for _, s := range []*socket{e.sock4, e.sock6} {
+		if s != nil {
+			s.ep.Close()
+		}
+	}
+	e.sock4, e.sock6 = nil, nil
+}
+
+func (e *Endpoint) startDispatchLoop(s *socket) {
+	e.wg.Add(1)
+	go func() { // S/R-SAFE: restarted by afterLoad.
+		defer e.wg.Done()
+		e.dispatchLoop(s)
+	}()
+}
+
+// dispatchLoop handles the messages received on s until it is closed.
+func (e *Endpoint) dispatchLoop(s *socket) {
+	we, ch := waiter.NewChannelEntry(waiter.ReadableEvents)
+	s.wq.EventRegister(&we)
+	defer s.wq.EventUnregister(&we)
+
+	var b bytes.Buffer
+	for {
+		b.Reset()
+		res, err := s.ep.Read(&b, tcpip.ReadOptions{NeedRemoteAddr: true})
+		switch err.(type) {
+		case nil:
+			e.handleMessage(b.Bytes(), tcpip.FullAddress{
+				Addr: res.RemoteAddr.Addr,
+				Port: res.RemoteAddr.Port,
+			})
+		case *tcpip.ErrWouldBlock:
+			<-ch
+		case *tcpip.ErrClosedForReceive:
+			return
+		default:
+			// Errors such as ICMP errors for earlier messages don't
+			// prevent further reads.
+		}
+	}
+}
+
+// sendLocked sends msg to the endpoint of p.
+//
+// +checklocks:e.mu
+func (e *Endpoint) sendLocked(p *peer, msg []byte) {
+	e.sendToLocked(msg, p.endpoint)
+}
+
+// sendToLocked sends msg to the address to. Messages that can't be sent are
+// dropped, like lost datagrams.
+//
+// +checklocks:e.mu
+func (e *Endpoint) sendToLocked(msg []byte, to tcpip.FullAddress) {
+	s := e.sock4
+	if to.Addr.Len() == header.IPv6AddressSize {
+		s = e.sock6
+	}
+	if s == nil || to.Addr.Len() == 0 {
+		return
+	}
+	var r bytes.Reader
+	r.Reset(msg)
+	s.ep.Write(&r, tcpip.WriteOptions{To: &to})
+}
+
+// Configure applies a configuration change to the device. Changes are applied
+// in order, and are not undone if one of them fails.
+func (e *Endpoint) Configure(c *Config) tcpip.Error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return &tcpip.ErrClosedForSend{}
+	}
+	if c.ListenPort != nil && uint32(*c.ListenPort) != e.port.Load() {
+		if err := e.bindLocked(*c.ListenPort); err != nil {
+			return err
+		}
+	}
+	if c.FwMark != nil {
+		e.fwMark = *c.FwMark
+	}
+	if c.PrivateKey != nil {
+		e.setPrivateKeyLocked(*c.PrivateKey)
+	}
+	if c.ReplacePeers {
+		for _, p := range e.peers {
+			e.removePeerLocked(p)
+		}
+	}
+	for i := range c.Peers {
+		e.configurePeerLocked(&c.Peers[i])
+	}
+	return nil
+}
+
+// +checklocks:e.mu
+func (e *Endpoint) setPrivateKeyLocked(key Key) {
+	var pub Key
+	if !key.IsZero() {
+		key.clamp()
+		pub = key.PublicKey()
+	}
+	if key == e.privateKey {
+		return
+	}
+	// The device can't be its own peer.
+	if p, ok := e.peers[pub]; ok && !key.IsZero() {
+		e.removePeerLocked(p)
+	}
+	e.privateKey = key
+	e.publicKey = pub
+	e.cookies.init(&pub)
+	for _, p := range e.peers {
+		e.precomputeLocked(p)
+		e.removeHandshakeLocked(p)
+		expireKeypair(p.current)
+		expireKeypair(p.next)
+	}
+}
+
+// expireKeypair prevents kp from being used to send, while the messages sent
+// with it can still be received.
+func expireKeypair(kp *keypair) {
+	if kp != nil {
+		kp.sendNonce = rejectAfterMessages
+	}
+}
+
+// +checklocks:e.mu
+func (e *Endpoint) precomputeLocked(p *peer) {
+	p.staticStatic = Key{}
+	if e.privateKey.IsZero() {
+		return
+	}
+	if ss, ok := dh(&e.privateKey, &p.publicKey); ok {
+		p.staticStatic = ss
+	}
+}
+
+// +checklocks:e.mu
+func (e *Endpoint) configurePeerLocked(c *PeerConfig) {
+	if !e.privateKey.IsZero() && c.PublicKey == e.publicKey {
+		return
+	}
+	p, ok := e.peers[c.PublicKey]
+	if c.Remove {
+		if ok {
+			e.removePeerLocked(p)
+		}
+		return
+	}
+	if !ok {
+		if c.UpdateOnly {
+			return
+		}
+		p = &peer{id: e.nextPeerID, publicKey: c.PublicKey}
+		e.nextPeerID++
+		p.cookies.init(&p.publicKey)
+		e.precomputeLocked(p)
+		e.initTimersLocked(p)
+		e.peers[p.publicKey] = p
+	}
+	if c.PresharedKey != nil {
+		p.presharedKey = *c.PresharedKey
+	}
+	if c.Endpoint != nil {
+		p.endpoint = *c.Endpoint
+	}
+	if c.ReplaceAllowedIPs {
+		e.allowedIPs.removePeer(p)
+	}
+	for _, s := range c.AllowedIPs {
+		e.allowedIPs.insert(s, p)
+	}
+	if c.PersistentKeepalive != nil {
+		enabled := p.persistentKeepalive == 0 && *c.PersistentKeepalive != 0
+		p.persistentKeepalive = *c.PersistentKeepalive
+		if enabled {
+			e.sendKeepaliveLocked(p)
+		}
+	}
+}
+
+// +checklocks:e.mu
+func (e *Endpoint) removePeerLocked(p *peer) {
+	delete(e.peers, p.publicKey)
+	e.allowedIPs.removePeer(p)
+	e.clearSessionsLocked(p)
+	e.purgeStagedLocked(p)
+	for _, t := range []*timer{&p.retransmitHandshakeTimer, &p.sendKeepaliveTimer, &p.newHandshakeTimer, &p.zeroKeyMaterialTimer, &p.persistentKeepaliveTimer} {
+		t.cancel()
+	}
+	p.removed = true
+}
+
+// clearSessionsLocked removes the handshake and the keypairs of p.
+//
+// +checklocks:e.mu
+func (e *Endpoint) clearSessionsLocked(p *peer) {
+	e.removeHandshakeLocked(p)
+	for _, kp := range []**keypair{&p.current, &p.previous, &p.next} {
+		e.removeKeypairLocked(*kp)
+		*kp = nil
+	}
+}
+
+// +checklocks:e.mu
+func (e *Endpoint) purgeStagedLocked(p *peer) {
+	for _, b := range p.staged {
+		b.Release()
+	}
+	p.staged = nil
+}
+
+// Info returns the state of the device.
+func (e *Endpoint) Info() Info {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	info := Info{
+		PrivateKey: e.privateKey,
+		PublicKey:  e.publicKey,
+		ListenPort: uint16(e.port.Load()),
+		FwMark:     e.fwMark,
+	}
+	peers := make([]*peer, 0, len(e.peers))
+	for _, p := range e.peers {
+		peers = append(peers, p)
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].id < peers[j].id })
+	for _, p := range peers {
+		pi := PeerInfo{
+			PublicKey:           p.publicKey,
+			PresharedKey:        p.presharedKey,
+			Endpoint:            p.endpoint,
+			PersistentKeepalive: p.persistentKeepalive,
+			AllowedIPs:          e.allowedIPs.peerSubnets(p),
+			RxBytes:             p.rxBytes,
+			TxBytes:             p.txBytes,
+		}
+		if p.lastHandshake != 0 {
+			pi.LastHandshake = time.Unix(0, p.lastHandshake)
+		}
+		info.Peers = append(info.Peers, pi)
+	}
+	return info
+}
+
+// newIndexLocked returns a new local index for entry.
+//
+// +checklocks:e.mu
+func (e *Endpoint) newIndexLocked(entry indexEntry) uint32 {
+	rng := e.stack.SecureRNG()
+	for {
+		index := rng.Uint32()
+		if _, ok := e.indices[index]; !ok {
+			e.indices[index] = entry
+			return index
+		}
+	}
+}
+
+// Timers, as described in section 6 of the WireGuard paper.
+
+// jitter returns a random delay which avoids handshakes being sent in lock
+// step.
+func (e *Endpoint) jitter() time.Duration {
+	return time.Duration(e.stack.InsecureRNG().Int63n(int64(rekeyTimeoutJitter)))
+}
+
+// +checklocks:e.mu
+func (e *Endpoint) initTimersLocked(p *peer) {
+	p.retransmitHandshakeTimer.init(e, func() {
+		if p.handshakeAttempts > maxTimerHandshakes {
+			// Give up until more packets are sent to the peer.
+			p.sendKeepaliveTimer.cancel()
+			e.purgeStagedLocked(p)
+			if !p.zeroKeyMaterialTimer.pending {
+				p.zeroKeyMaterialTimer.schedule(3 * rejectAfterTime)
+			}
+			return
+		}
+		p.handshakeAttempts++
+		e.sendInitiationLocked(p, true /* retry */)
+	})
+	p.sendKeepaliveTimer.init(e, func() {
+		e.sendKeepaliveLocked(p)
+		if p.needAnotherKeepalive {
+			p.needAnotherKeepalive = false
+			p.sendKeepaliveTimer.schedule(keepaliveTimeout)
+		}
+	})
+	p.newHandshakeTimer.init(e, func() {
+		// Data was sent without anything being received back.
+		e.sendInitiationLocked(p, false /* retry */)
+	})
+	p.zeroKeyMaterialTimer.init(e, func() {
+		e.clearSessionsLocked(p)
+	})
+	p.persistentKeepaliveTimer.init(e, func() {
+		if p.persistentKeepalive != 0 {
+			e.sendKeepaliveLocked(p)
+		}
+	})
+}
+
+// +checklocks:e.mu
+func (e *Endpoint) timersDataSentLocked(p *peer) {
+	if !p.newHandshakeTimer.pending {
+		p.newHandshakeTimer.schedule(keepaliveTimeout + rekeyTimeout + e.jitter())
+	}
+}
+
+// +checklocks:e.mu
+func (e *Endpoint) timersDataReceivedLocked(p *peer) {
+	if !p.sendKeepaliveTimer.pending {
+		p.sendKeepaliveTimer.schedule(keepaliveTimeout)
+	} else {
+		p.needAnotherKeepalive = true
+	}
+}
+
+// +checklocks:e.mu
+func (e *Endpoint) timersAuthenticatedPacketSentLocked(p *peer) {
+	p.sendKeepaliveTimer.cancel()
+	e.timersAuthenticatedPacketTraversalLocked(p)
+}
+
+// +checklocks:e.mu
+func (e *Endpoint) timersAuthenticatedPacketReceivedLocked(p *peer) {
+	p.newHandshakeTimer.cancel()
+	e.timersAuthenticatedPacketTraversalLocked(p)
+}
+
+// +checklocks:e.mu
+func (e *Endpoint) timersAuthenticatedPacketTraversalLocked(p *peer) {
+	if p.persistentKeepalive != 0 {
+		p.persistentKeepaliveTimer.schedule(p.persistentKeepalive)
+	}
+}
+
+// +checklocks:e.mu
+func (e *Endpoint) timersHandshakeCompleteLocked(p *peer) {
+	p.retransmitHandshakeTimer.cancel()
+	p.handshakeAttempts = 0
+	p.sentLastMinuteHandshake = false
+	p.lastHandshake = e.stack.Clock().Now().UnixNano()
+}
+
+// +checklocks:e.mu
+func (e *Endpoint) timersSessionDerivedLocked(p *peer) {
+	p.zeroKeyMaterialTimer.schedule(3 * rejectAfterTime)
+}
+
+// Sending.
+
+// sendInitiationLocked sends a handshake initiation to p, unless one was sent
+// recently. retry is set for the retransmissions of an initiation.
+//
+// +checklocks:e.mu
+func (e *Endpoint) sendInitiationLocked(p *peer, retry bool) {
+	if !retry {
+		p.handshakeAttempts = 0
+	}
+	now := e.stack.Clock().NowMonotonic()
+	if p.lastSentHandshake != (tcpip.MonotonicTime{}) && now.Sub(p.lastSentHandshake) < rekeyTimeout {
+		return
+	}
+	if p.endpoint.Addr.Len() == 0 {
+		return
+	}
+	msg, ok := e.createInitiationLocked(p)
+	if !ok {
+		return
+	}
+	p.lastSentHandshake = now
+	e.timersAuthenticatedPacketSentLocked(p)
+	e.sendLocked(p, msg)
+	p.retransmitHandshakeTimer.schedule(rekeyTimeout + e.jitter())
+}
+
+// stageLocked queues b to be sent to p once a session is established. The
+// oldest packet is dropped if the queue is full.
+//
+// +checklocks:e.mu
+func (e *Endpoint) stageLocked(p *peer, b buffer.Buffer) {
+	if len(p.staged) >= maxStagedPackets {
+		p.staged[0].Release()
+		p.staged = p.staged[1:]
+	}
+	p.staged = append(p.staged, b)
+}
+
+// sendKeepaliveLocked sends an empty transport message to p, unless packets
+// are already waiting to be sent.
+//
+// +checklocks:e.mu
+func (e *Endpoint) sendKeepaliveLocked(p *peer) {
+	if len(p.staged) == 0 {
+		e.stageLocked(p, buffer.Buffer{})
+	}
+	e.sendStagedLocked(p)
+}
+
+// sendStagedLocked sends the staged packets of p with the current keypair,
+// or initiates a handshake if there is no usable keypair.
+//
+// +checklocks:e.mu
+func (e *Endpoint) sendStagedLocked(p *peer) {
+	if len(p.staged) == 0 {
+		return
+	}
+	kp := p.current
+	now := e.stack.Clock().NowMonotonic()
+	if kp == nil || now.Sub(kp.created) >= rejectAfterTime {
+		e.sendInitiationLocked(p, false /* retry */)
+		return
+	}
+	mtu := e.mtu.Load()
+	for len(p.staged) > 0 {
+		if kp.sendNonce >= rejectAfterMessages {
+			e.sendInitiationLocked(p, false /* retry */)
+			return
+		}
+		b := p.staged[0]
+		p.staged = p.staged[1:]
+		keepalive := b.Size() == 0
+		msg := encrypt(kp, b, mtu)
+		b.Release()
+
+		p.txBytes += uint64(len(msg))
+		e.timersAuthenticatedPacketSentLocked(p)
+		e.sendLocked(p, msg)
+		if !keepalive {
+			e.timersDataSentLocked(p)
+		}
+	}
+	p.staged = nil
+
+	// Renew sessions before they expire.
+	if kp.initiator && (kp.sendNonce > rekeyAfterMessages || now.Sub(kp.created) >= rekeyAfterTime) {
+		e.sendInitiationLocked(p, false /* retry */)
+	}
+}
+
+// encrypt returns the transport message that carries packet b with the next
+// nonce of kp. The packet is padded to a multiple of paddingMultiple without
+// exceeding mtu.
+func encrypt(kp *keypair, b buffer.Buffer, mtu uint32) []byte {
+	nonce := kp.sendNonce
+	kp.sendNonce++
+	size := int(b.Size())
+	padded := (size + paddingMultiple - 1) &^ (paddingMultiple - 1)
+	if padded > int(mtu) {
+		padded = max(size, int(mtu))
+	}
+	msg := make([]byte, messageTransportHeaderSize+padded, messageTransportHeaderSize+padded+tagSize)
+	binary.LittleEndian.PutUint32(msg, messageTransportType)
+	binary.LittleEndian.PutUint32(msg[transportReceiverOffset:], kp.remoteIndex)
+	binary.LittleEndian.PutUint64(msg[transportCounterOffset:], nonce)
+	b.ReadAt(msg[messageTransportHeaderSize:messageTransportHeaderSize+size], 0)
+	return kp.send.Seal(msg[:messageTransportHeaderSize], transportNonce(nonce), msg[messageTransportHeaderSize:], nil)
+}
+
+// Receiving.
+
+// handleMessage handles a message received from the address from.
+func (e *Endpoint) handleMessage(b []byte, from tcpip.FullAddress) {
+	if len(b) < 4 {
+		return
+	}
+	switch t := binary.LittleEndian.Uint32(b); {
+	case t == messageInitiationType && len(b) == messageInitiationSize,
+		t == messageResponseType && len(b) == messageResponseSize:
+		e.handleHandshake(b, from)
+	case t == messageCookieReplyType && len(b) == messageCookieReplySize:
+		e.handleCookieReply(b)
+	case t == messageTransportType && len(b) >= messageTransportMinSize:
+		e.handleTransport(b, from)
+	}
+}
+
+// underLoadLocked returns whether the device receives so many handshake
+// messages that initiators must prove that they own their address.
+//
+// +checklocks:e.mu
+func (e *Endpoint) underLoadLocked() bool {
+	now := e.stack.Clock().NowMonotonic()
+	second := now.Sub(tcpip.MonotonicTime{}) / time.Second
+	if int64(second) != e.handshakesSecond {
+		e.handshakesSecond = int64(second)
+		e.handshakesReceived = 0
+	}
+	e.handshakesReceived++
+	if e.handshakesReceived > underLoadHandshakes {
+		e.lastUnderLoad = now
+		return true
+	}
+	return e.lastUnderLoad != (tcpip.MonotonicTime{}) && now.Sub(e.lastUnderLoad) < time.Second
+}
+
+// handleHandshake handles a handshake initiation or response.
+func (e *Endpoint) handleHandshake(msg []byte, from tcpip.FullAddress) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed || !e.cookies.checkMAC1(msg) {
+		return
+	}
+	rng := e.stack.SecureRNG().Reader
+	if e.underLoadLocked() && !e.cookies.checkMAC2(e.stack.Clock(), rng, msg, from) {
+		sender := binary.LittleEndian.Uint32(msg[initiationSenderOffset:])
+		if reply, ok := e.cookies.createReply(e.stack.Clock(), rng, msg, sender, from); ok {
+			e.sendToLocked(reply, from)
+		}
+		return
+	}
+
+	var p *peer
+	if binary.LittleEndian.Uint32(msg) == messageInitiationType {
+		if p = e.consumeInitiationLocked(msg); p == nil {
+			return
+		}
+		p.endpoint = from
+		resp, ok := e.createResponseLocked(p)
+		if !ok {
+			return
+		}
+		p.lastSentHandshake = e.stack.Clock().NowMonotonic()
+		if e.beginSessionLocked(p) {
+			e.timersSessionDerivedLocked(p)
+		}
+		e.timersAuthenticatedPacketSentLocked(p)
+		e.sendLocked(p, resp)
+	} else {
+		if p = e.consumeResponseLocked(msg); p == nil {
+			return
+		}
+		p.endpoint = from
+		if e.beginSessionLocked(p) {
+			e.timersSessionDerivedLocked(p)
+			e.timersHandshakeCompleteLocked(p)
+			// Confirm the session to the responder, sending the
+			// packets that waited for it.
+			e.sendKeepaliveLocked(p)
+		}
+	}
+	e.timersAuthenticatedPacketReceivedLocked(p)
+}
+
+// handleCookieReply handles a cookie reply to a handshake message.
+func (e *Endpoint) handleCookieReply(msg []byte) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	entry, ok := e.indices[binary.LittleEndian.Uint32(msg[cookieReceiverOffset:])]
+	if !ok {
+		return
+	}
+	// The handshake is retransmitted with the cookie by the retransmit
+	// timer.
+	entry.peer.cookies.consumeReply(e.stack.Clock(), msg)
+}
+
+// handleTransport handles a transport message.
+func (e *Endpoint) handleTransport(msg []byte, from tcpip.FullAddress) {
+	index := binary.LittleEndian.Uint32(msg[transportReceiverOffset:])
+	counter := binary.LittleEndian.Uint64(msg[transportCounterOffset:])
+
+	e.mu.RLock()
+	entry, ok := e.indices[index]
+	e.mu.RUnlock()
+	if !ok || entry.keypair == nil {
+		return
+	}
+	kp := entry.keypair
+	// Messages are decrypted in place.
+	ciphertext := msg[messageTransportHeaderSize:]
+	b, err := kp.recv.Open(ciphertext[:0], transportNonce(counter), ciphertext, nil)
+	if err != nil || !kp.replay.validate(counter) {
+		return
+	}
+
+	e.mu.Lock()
+	p := kp.peer
+	if e.closed || p.removed || e.indices[index].keypair != kp || e.stack.Clock().NowMonotonic().Sub(kp.created) >= rejectAfterTime {
+		e.mu.Unlock()
+		return
+	}
+	if e.confirmKeypairLocked(kp) {
+		e.timersHandshakeCompleteLocked(p)
+		e.sendStagedLocked(p)
+	}
+	// The peer may have roamed.
+	p.endpoint = from
+	p.rxBytes += uint64(len(msg))
+	e.timersAuthenticatedPacketReceivedLocked(p)
+	e.keepKeyFreshRecvLocked(p, kp)
+	if len(b) == 0 {
+		// Keepalives don't carry packets.
+		e.mu.Unlock()
+		return
+	}
+	e.timersDataReceivedLocked(p)
+
+	netProto, src, pkt, ok := innerPacket(b)
+	if !ok || e.allowedIPs.lookup(src) != p {
+		e.mu.Unlock()
+		return
+	}
+	d := e.dispatcher
+	e.mu.Unlock()
+	if d == nil {
+		return
+	}
+
+	pb := stack.NewPacketBuffer(stack.PacketBufferOptions{
+		Payload: buffer.MakeWithData(pkt),
+	})
+	d.DeliverNetworkPacket(netProto, pb)
+	pb.DecRef()
+}
+
+// keepKeyFreshRecvLocked renews the session of the initiator shortly before it
+// expires, if the responder can't do it as it doesn't initiate.
+//
+// +checklocks:e.mu
+func (e *Endpoint) keepKeyFreshRecvLocked(p *peer, kp *keypair) {
+	if p.sentLastMinuteHandshake || p.current != kp || !kp.initiator {
+		return
+	}
+	if e.stack.Clock().NowMonotonic().Sub(kp.created) >= rejectAfterTime-keepaliveTimeout-rekeyTimeout {
+		p.sentLastMinuteHandshake = true
+		e.sendInitiationLocked(p, false /* retry */)
+	}
+}
+
+// innerPacket parses the IP packet carried in the plaintext b. It returns its
+// network protocol, its source address and the packet without padding.
+func innerPacket(b []byte) (tcpip.NetworkProtocolNumber, tcpip.Address, []byte, bool) {
+	switch header.IPVersion(b) {
+	case header.IPv4Version:
+		if len(b) < header.IPv4MinimumSize {
+			return 0, tcpip.Address{}, nil, false
+		}
+		h := header.IPv4(b)
+		n := int(h.TotalLength())
+		if n < header.IPv4MinimumSize || n > len(b) {
+			return 0, tcpip.Address{}, nil, false
+		}
+		return header.IPv4ProtocolNumber, h.SourceAddress(), b[:n], true
+	case header.IPv6Version:
+		if len(b) < header.IPv6MinimumSize {
+			return 0, tcpip.Address{}, nil, false
+		}
+		h := header.IPv6(b)
+		n := header.IPv6MinimumSize + int(h.PayloadLength())
+		if n > len(b) {
+			return 0, tcpip.Address{}, nil, false
+		}
+		return header.IPv6ProtocolNumber, h.SourceAddress(), b[:n], true
+	default:
+		return 0, tcpip.Address{}, nil, false
+	}
+}
+
+// destination returns the destination address of the packet pkt.
+func destination(pkt *stack.PacketBuffer) (tcpip.Address, bool) {
+	switch pkt.NetworkProtocolNumber {
+	case header.IPv4ProtocolNumber:
+		h := header.IPv4(pkt.NetworkHeader().Slice())
+		if len(h) < header.IPv4MinimumSize {
+			return tcpip.Address{}, false
+		}
+		return h.DestinationAddress(), true
+	case header.IPv6ProtocolNumber:
+		h := header.IPv6(pkt.NetworkHeader().Slice())
+		if len(h) < header.IPv6MinimumSize {
+			return tcpip.Address{}, false
+		}
+		return h.DestinationAddress(), true
+	default:
+		return tcpip.Address{}, false
+	}
+}
+
+// isOwnMessage returns whether pkt is a message sent by the device, which is
+// routed back to it.
+func (e *Endpoint) isOwnMessage(pkt *stack.PacketBuffer) bool {
+	if pkt.TransportProtocolNumber != udp.ProtocolNumber {
+		return false
+	}
+	h := header.UDP(pkt.TransportHeader().Slice())
+	return len(h) >= header.UDPMinimumSize && uint32(h.SourcePort()) == e.port.Load()
+}
+
+// WritePackets implements stack.LinkEndpoint.WritePackets.
+func (e *Endpoint) WritePackets(pkts stack.PacketBufferList) (int, tcpip.Error) {
+	n := 0
+	for _, pkt := range pkts.AsSlice() {
+		if err := e.writePacket(pkt); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+func (e *Endpoint) writePacket(pkt *stack.PacketBuffer) tcpip.Error {
+	// Messages routed through the device itself would be encapsulated
+	// forever, and sending them would take the mutex again.
+	if e.isOwnMessage(pkt) {
+		return nil
+	}
+	dst, ok := destination(pkt)
+	if !ok {
+		return &tcpip.ErrMalformedHeader{}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return &tcpip.ErrClosedForSend{}
+	}
+	p := e.allowedIPs.lookup(dst)
+	if p == nil {
+		return &tcpip.ErrHostUnreachable{}
+	}
+	if p.endpoint.Addr.Len() == 0 {
+		return &tcpip.ErrDestinationRequired{}
+	}
+	e.stageLocked(p, pkt.ToBuffer())
+	e.sendStagedLocked(p)
+	return nil
+}
+
+// Close implements stack.LinkEndpoint.Close.
+func (e *Endpoint) Close() {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return
+	}
+	e.closed = true
+	for _, p := range e.peers {
+		e.removePeerLocked(p)
+	}
+	e.closeSocketsLocked()
+	action := e.onCloseAction
+	e.onCloseAction = nil
+	e.mu.Unlock()
+	if action != nil {
+		action()
+	}
+}
+
+// Wait implements stack.LinkEndpoint.Wait.
+func (e *Endpoint) Wait() {
+	e.wg.Wait()
+}
+
+// Attach implements stack.LinkEndpoint.Attach.
+func (e *Endpoint) Attach(dispatcher stack.NetworkDispatcher) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.dispatcher = dispatcher
+}
+
+// IsAttached implements stack.LinkEndpoint.IsAttached.
+func (e *Endpoint) IsAttached() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.dispatcher != nil
+}
+
+// MTU implements stack.LinkEndpoint.MTU.
+func (e *Endpoint) MTU() uint32 {
+	return e.mtu.Load()
+}
+
+// SetMTU implements stack.LinkEndpoint.SetMTU.
+func (e *Endpoint) SetMTU(mtu uint32) {
+	e.mtu.Store(mtu)
+}
+
+// Capabilities implements stack.LinkEndpoint.Capabilities.
+func (*Endpoint) Capabilities() stack.LinkEndpointCapabilities {
+	return stack.CapabilitySaveRestore
+}
+
+// MaxHeaderLength implements stack.LinkEndpoint.MaxHeaderLength. Packets are
+// encrypted into new messages, so no space is reserved for headers.
+func (*Endpoint) MaxHeaderLength() uint16 {
+	return 0
+}
+
+// LinkAddress implements stack.LinkEndpoint.LinkAddress. Devices carry IP
+// packets, so they have no link address.
+func (*Endpoint) LinkAddress() tcpip.LinkAddress {
+	return ""
+}
+
+// SetLinkAddress implements stack.LinkEndpoint.SetLinkAddress.
+func (*Endpoint) SetLinkAddress(tcpip.LinkAddress) {}
+
+// ARPHardwareType implements stack.LinkEndpoint.ARPHardwareType.
+func (*Endpoint) ARPHardwareType() header.ARPHardwareType {
+	return header.ARPHardwareNone
+}
+
+// AddHeader implements stack.LinkEndpoint.AddHeader.
+func (*Endpoint) AddHeader(*stack.PacketBuffer) {}
+
+// ParseHeader implements stack.LinkEndpoint.ParseHeader.
+func (*Endpoint) ParseHeader(*stack.PacketBuffer) bool { return true }
+
+// SetOnCloseAction implements stack.LinkEndpoint.SetOnCloseAction.
+func (e *Endpoint) SetOnCloseAction(action func()) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onCloseAction = action
+}
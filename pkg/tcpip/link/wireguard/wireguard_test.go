@@ -0,0 +1,265 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wireguard_test
+
+import (
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/internal/linktest"
+	"gvisor.dev/gvisor/pkg/tcpip/link/ethernet"
+	"gvisor.dev/gvisor/pkg/tcpip/link/veth"
+	"gvisor.dev/gvisor/pkg/tcpip/link/wireguard"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/testutil"
+)
+
+const (
+	underlayNICID = 1
+	deviceNICID   = 2
+	testTimeout   = 5 * time.Second
+)
+
+// host is a stack with an underlay NIC and a WireGuard NIC.
+type host struct {
+	s        *stack.Stack
+	device   *wireguard.Endpoint
+	key      wireguard.Key
+	underlay tcpip.Address
+	overlay  tcpip.Address
+}
+
+func newKey(t *testing.T) wireguard.Key {
+	t.Helper()
+	key, err := wireguard.GeneratePrivateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GeneratePrivateKey: %s", err)
+	}
+	return key
+}
+
+func newHost(t *testing.T, underlayEP stack.LinkEndpoint, underlay, overlay tcpip.Address, port uint16) *host {
+	t.Helper()
+	s := linktest.NewStack(t)
+	if err := s.CreateNIC(underlayNICID, ethernet.New(underlayEP)); err != nil {
+		t.Fatalf("CreateNIC(%d, _): %s", underlayNICID, err)
+	}
+	linktest.AddAddress(t, s, underlayNICID, tcpip.AddressWithPrefix{Address: underlay, PrefixLen: 24})
+
+	device, err := wireguard.New(s, wireguard.Options{})
+	if err != nil {
+		t.Fatalf("wireguard.New: %s", err)
+	}
+	if err := s.CreateNIC(deviceNICID, device); err != nil {
+		t.Fatalf("CreateNIC(%d, _): %s", deviceNICID, err)
+	}
+	linktest.AddAddress(t, s, deviceNICID, tcpip.AddressWithPrefix{Address: overlay, PrefixLen: 24})
+
+	key := newKey(t)
+	if err := device.Configure(&wireguard.Config{PrivateKey: &key, ListenPort: &port}); err != nil {
+		t.Fatalf("Configure: %s", err)
+	}
+	return &host{s: s, device: device, key: key, underlay: underlay, overlay: overlay}
+}
+
+// addPeer makes other a peer of h, which allows the overlay address of other.
+func (h *host) addPeer(t *testing.T, other *host, endpoint *tcpip.FullAddress) {
+	t.Helper()
+	c := wireguard.Config{
+		Peers: []wireguard.PeerConfig{{
+			PublicKey:  other.key.PublicKey(),
+			Endpoint:   endpoint,
+			AllowedIPs: []tcpip.Subnet{tcpip.AddressWithPrefix{Address: other.overlay, PrefixLen: 32}.Subnet()},
+		}},
+	}
+	if err := h.device.Configure(&c); err != nil {
+		t.Fatalf("Configure: %s", err)
+	}
+}
+
+// exchange sends datagrams from the overlay address of h1 to the overlay
+// address of h2 until one is received.
+func exchange(t *testing.T, h1, h2 *host) {
+	t.Helper()
+	if !linktest.SendDatagrams(t, h1.s, h2.s, h2.overlay, testTimeout) {
+		t.Fatalf("timed out waiting for a datagram over the device")
+	}
+}
+
+func TestTunnel(t *testing.T) {
+	ep1, ep2 := veth.NewPair(1500, veth.DefaultBacklogSize)
+	h1 := newHost(t, ep1, testutil.MustParse4("10.0.0.1"), testutil.MustParse4("192.168.0.1"), 51820)
+	h2 := newHost(t, ep2, testutil.MustParse4("10.0.0.2"), testutil.MustParse4("192.168.0.2"), 51821)
+
+	// Only h1 knows the endpoint of its peer. h2 learns it from the
+	// initiation of h1.
+	h1.addPeer(t, h2, &tcpip.FullAddress{Addr: h2.underlay, Port: 51821})
+	h2.addPeer(t, h1, nil)
+
+	exchange(t, h1, h2)
+	exchange(t, h2, h1)
+
+	for _, tc := range []struct {
+		h        *host
+		endpoint tcpip.FullAddress
+	}{
+		{h: h1, endpoint: tcpip.FullAddress{Addr: h2.underlay, Port: 51821}},
+		{h: h2, endpoint: tcpip.FullAddress{Addr: h1.underlay, Port: 51820}},
+	} {
+		info := tc.h.device.Info()
+		if len(info.Peers) != 1 {
+			t.Fatalf("got %d peers, want 1", len(info.Peers))
+		}
+		p := info.Peers[0]
+		if p.Endpoint != tc.endpoint {
+			t.Errorf("got peer endpoint %+v, want %+v", p.Endpoint, tc.endpoint)
+		}
+		if p.LastHandshake.IsZero() {
+			t.Errorf("got no handshake with the peer of %s", tc.h.overlay)
+		}
+		if p.RxBytes == 0 || p.TxBytes == 0 {
+			t.Errorf("got RxBytes = %d, TxBytes = %d, want both nonzero", p.RxBytes, p.TxBytes)
+		}
+	}
+}
+
+func TestPresharedKey(t *testing.T) {
+	ep1, ep2 := veth.NewPair(1500, veth.DefaultBacklogSize)
+	h1 := newHost(t, ep1, testutil.MustParse4("10.0.0.1"), testutil.MustParse4("192.168.0.1"), 51820)
+	h2 := newHost(t, ep2, testutil.MustParse4("10.0.0.2"), testutil.MustParse4("192.168.0.2"), 51820)
+	h1.addPeer(t, h2, &tcpip.FullAddress{Addr: h2.underlay, Port: 51820})
+	h2.addPeer(t, h1, &tcpip.FullAddress{Addr: h1.underlay, Port: 51820})
+
+	psk := newKey(t)
+	for _, c := range []struct {
+		h     *host
+		other *host
+	}{{h1, h2}, {h2, h1}} {
+		if err := c.h.device.Configure(&wireguard.Config{
+			Peers: []wireguard.PeerConfig{{PublicKey: c.other.key.PublicKey(), UpdateOnly: true, PresharedKey: &psk}},
+		}); err != nil {
+			t.Fatalf("Configure: %s", err)
+		}
+	}
+	exchange(t, h1, h2)
+}
+
+func TestConfigure(t *testing.T) {
+	s := linktest.NewStack(t)
+	device, err := wireguard.New(s, wireguard.Options{})
+	if err != nil {
+		t.Fatalf("wireguard.New: %s", err)
+	}
+	defer device.Close()
+
+	if got := device.MTU(); got != wireguard.DefaultMTU {
+		t.Errorf("got MTU() = %d, want = %d", got, wireguard.DefaultMTU)
+	}
+	if got := device.Info().ListenPort; got == 0 {
+		t.Errorf("got ListenPort = 0, want an ephemeral port")
+	}
+
+	key := newKey(t)
+	key1, key2, key3 := newKey(t), newKey(t), newKey(t)
+	peer1, peer2 := key1.PublicKey(), key2.PublicKey()
+	port := uint16(51820)
+	keepalive := 25 * time.Second
+	subnet1 := testutil.MustParseSubnet4("192.168.1.0/24")
+	subnet2 := testutil.MustParseSubnet4("192.168.2.0/24")
+	if err := device.Configure(&wireguard.Config{
+		PrivateKey: &key,
+		ListenPort: &port,
+		Peers: []wireguard.PeerConfig{
+			{
+				PublicKey:           peer1,
+				PersistentKeepalive: &keepalive,
+				AllowedIPs:          []tcpip.Subnet{subnet2, subnet1},
+			},
+			{PublicKey: peer2},
+			// The device can't be its own peer.
+			{PublicKey: key.PublicKey()},
+			// Peers aren't created by updates.
+			{PublicKey: key3.PublicKey(), UpdateOnly: true},
+		},
+	}); err != nil {
+		t.Fatalf("Configure: %s", err)
+	}
+	info := device.Info()
+	if info.PrivateKey != key || info.PublicKey != key.PublicKey() || info.ListenPort != port {
+		t.Errorf("got Info() = %+v, want the configured keys and port %d", info, port)
+	}
+	if len(info.Peers) != 2 || info.Peers[0].PublicKey != peer1 || info.Peers[1].PublicKey != peer2 {
+		t.Fatalf("got peers %+v, want %x and %x", info.Peers, peer1, peer2)
+	}
+	if got := info.Peers[0].PersistentKeepalive; got != keepalive {
+		t.Errorf("got PersistentKeepalive = %s, want = %s", got, keepalive)
+	}
+	if got := info.Peers[0].AllowedIPs; len(got) != 2 || got[0] != subnet1 || got[1] != subnet2 {
+		t.Errorf("got AllowedIPs = %v, want = [%s %s]", got, subnet1, subnet2)
+	}
+
+	// Moving a subnet to another peer removes it from the first one.
+	if err := device.Configure(&wireguard.Config{
+		Peers: []wireguard.PeerConfig{
+			{PublicKey: peer2, AllowedIPs: []tcpip.Subnet{subnet1}},
+		},
+	}); err != nil {
+		t.Fatalf("Configure: %s", err)
+	}
+	info = device.Info()
+	if got := info.Peers[0].AllowedIPs; len(got) != 1 || got[0] != subnet2 {
+		t.Errorf("got AllowedIPs = %v, want = [%s]", got, subnet2)
+	}
+	if got := info.Peers[1].AllowedIPs; len(got) != 1 || got[0] != subnet1 {
+		t.Errorf("got AllowedIPs = %v, want = [%s]", got, subnet1)
+	}
+
+	if err := device.Configure(&wireguard.Config{
+		Peers: []wireguard.PeerConfig{{PublicKey: peer1, Remove: true}},
+	}); err != nil {
+		t.Fatalf("Configure: %s", err)
+	}
+	if info := device.Info(); len(info.Peers) != 1 || info.Peers[0].PublicKey != peer2 {
+		t.Errorf("got peers %+v after removing %x, want %x", info.Peers, peer1, peer2)
+	}
+
+	if err := device.Configure(&wireguard.Config{ReplacePeers: true}); err != nil {
+		t.Fatalf("Configure: %s", err)
+	}
+	if info := device.Info(); len(info.Peers) != 0 {
+		t.Errorf("got peers %+v after replacing them, want none", info.Peers)
+	}
+}
+
+func TestListenPortInUse(t *testing.T) {
+	s := linktest.NewStack(t)
+	d1, err := wireguard.New(s, wireguard.Options{})
+	if err != nil {
+		t.Fatalf("wireguard.New: %s", err)
+	}
+	defer d1.Close()
+	d2, err := wireguard.New(s, wireguard.Options{})
+	if err != nil {
+		t.Fatalf("wireguard.New: %s", err)
+	}
+	defer d2.Close()
+
+	port := d1.Info().ListenPort
+	if err := d2.Configure(&wireguard.Config{ListenPort: &port}); err == nil {
+		t.Errorf("Configure(ListenPort: %d) succeeded with the port of another device, want error", port)
+	}
+}
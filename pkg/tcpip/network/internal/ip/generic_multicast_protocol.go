@@ -22,6 +22,7 @@ import (
 	"gvisor.dev/gvisor/pkg/sync"
 	"gvisor.dev/gvisor/pkg/tcpip"
 	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/network/internal/multicast"
 )
 
 const (
@@ -103,6 +104,17 @@ const (
 	DefaultQueryInterval = 125 * time.Second
 )
 
+// anySourceFilter is the source filter of a group joined without specifying
+// sources: packets sent by any source are received.
+var anySourceFilter = tcpip.MulticastSourceFilter{Mode: tcpip.MulticastFilterExclude}
+
+// isMemberFilter returns true if filter makes the socket a member of the
+// group. An INCLUDE mode filter without sources is equivalent to not being a
+// member.
+func isMemberFilter(filter tcpip.MulticastSourceFilter) bool {
+	return filter.Mode == tcpip.MulticastFilterExclude || len(filter.Sources) != 0
+}
+
 // multicastGroupState holds the Generic Multicast Protocol state for a
 // multicast group.
 //
@@ -111,6 +123,21 @@ type multicastGroupState struct {
 	// joins is the number of times the group has been joined.
 	joins uint64
 
+	// filter is the interface's reception state for the group, merged from
+	// the source filter of each join.
+	filter multicast.InterfaceState
+
+	// filterModeChangePending is true if a filter mode change record, carrying
+	// the current source list, must be sent in the remaining state change
+	// reports.
+	filterModeChangePending bool
+
+	// pendingAllowSources and pendingBlockSources hold the sources of the
+	// ALLOW_NEW_SOURCES and BLOCK_OLD_SOURCES records that must be sent in the
+	// remaining state change reports.
+	pendingAllowSources map[tcpip.Address]struct{}
+	pendingBlockSources map[tcpip.Address]struct{}
+
 	// transmissionLeft is the number of transmissions left to send.
 	transmissionLeft uint8
 
@@ -156,6 +183,183 @@ func (m *multicastGroupState) clearQueriedIncludeSources() {
 	}
 }
 
+func (m *multicastGroupState) clearPendingStateChange() {
+	m.filterModeChangePending = false
+	m.pendingAllowSources = nil
+	m.pendingBlockSources = nil
+}
+
+// recordStateChange records the change of the interface's reception state for
+// the group from oldFilter to newFilter, to be announced by state change
+// reports.
+//
+// Returns false if the change doesn't need to be announced.
+func (m *multicastGroupState) recordStateChange(oldFilter, newFilter tcpip.MulticastSourceFilter) bool {
+	if m.transmissionLeft == 0 {
+		// All reports of previous changes were sent.
+		m.clearPendingStateChange()
+	}
+
+	// As per RFC 3376 section 5.1 (for IGMPv3),
+	//
+	//   Old State         New State         State-Change Record Sent
+	//   ---------         ---------         ------------------------
+	//
+	//   INCLUDE (A)       INCLUDE (B)       ALLOW (B-A), BLOCK (A-B)
+	//
+	//   EXCLUDE (A)       EXCLUDE (B)       ALLOW (A-B), BLOCK (B-A)
+	//
+	//   INCLUDE (A)       EXCLUDE (B)       TO_EX (B)
+	//
+	//   EXCLUDE (A)       INCLUDE (B)       TO_IN (B)
+	//
+	//   [...]
+	//
+	//   If more changes to the same interface state entry occur before all
+	//   the retransmissions of the State-Change Report for the first change
+	//   have been completed, each such additional change triggers the
+	//   immediate transmission of a new State-Change Report.
+	//
+	//   The contents of the new report are calculated as follows.  As with
+	//   the first report, the interface state for the affected group before
+	//   and after the latest change is compared.  The report records
+	//   expressing the difference are built according to the table above.
+	//   However these records are not transmitted in a message but instead
+	//   merged with the contents of the pending report, to create the new
+	//   State-Change report.
+	//
+	// RFC 3810 section 6.1 defines the same rules for MLDv2.
+	if oldFilter.Mode != newFilter.Mode {
+		m.filterModeChangePending = true
+		m.pendingAllowSources = nil
+		m.pendingBlockSources = nil
+		return true
+	}
+
+	added := sourcesDifference(newFilter.Sources, oldFilter.Sources)
+	removed := sourcesDifference(oldFilter.Sources, newFilter.Sources)
+	if len(added) == 0 && len(removed) == 0 {
+		return false
+	}
+	if m.filterModeChangePending {
+		// The pending filter mode change record carries the current source list.
+		return true
+	}
+
+	allow, block := added, removed
+	if newFilter.Mode == tcpip.MulticastFilterExclude {
+		allow, block = removed, added
+	}
+	for _, source := range allow {
+		if m.pendingAllowSources == nil {
+			m.pendingAllowSources = make(map[tcpip.Address]struct{})
+		}
+		m.pendingAllowSources[source] = struct{}{}
+		delete(m.pendingBlockSources, source)
+	}
+	for _, source := range block {
+		if m.pendingBlockSources == nil {
+			m.pendingBlockSources = make(map[tcpip.Address]struct{})
+		}
+		m.pendingBlockSources[source] = struct{}{}
+		delete(m.pendingAllowSources, source)
+	}
+	return true
+}
+
+// addStateChangeRecords adds the records announcing the pending state change
+// of the group to reportBuilder.
+func (m *multicastGroupState) addStateChangeRecords(reportBuilder MulticastGroupProtocolV2ReportBuilder, groupAddress tcpip.Address) {
+	if m.filterModeChangePending {
+		filter := m.filter.Filter()
+		recordType := MulticastGroupProtocolV2ReportRecordChangeToIncludeMode
+		if filter.Mode == tcpip.MulticastFilterExclude {
+			recordType = MulticastGroupProtocolV2ReportRecordChangeToExcludeMode
+		}
+		reportBuilder.AddRecord(recordType, groupAddress, filter.Sources)
+		return
+	}
+
+	if len(m.pendingAllowSources) != 0 {
+		reportBuilder.AddRecord(MulticastGroupProtocolV2ReportRecordAllowNewSources, groupAddress, sourcesOf(m.pendingAllowSources))
+	}
+	if len(m.pendingBlockSources) != 0 {
+		reportBuilder.AddRecord(MulticastGroupProtocolV2ReportRecordBlockOldSources, groupAddress, sourcesOf(m.pendingBlockSources))
+	}
+}
+
+// addCurrentStateRecord adds the record describing the current reception state
+// of the group to reportBuilder.
+//
+// If queriedSources is not empty, the record responds to a source-specific
+// query and only describes the reception state of the queried sources.
+func (m *multicastGroupState) addCurrentStateRecord(reportBuilder MulticastGroupProtocolV2ReportBuilder, groupAddress tcpip.Address, queriedSources map[tcpip.Address]struct{}) {
+	filter := m.filter.Filter()
+	if len(queriedSources) == 0 {
+		recordType := MulticastGroupProtocolV2ReportRecordModeIsInclude
+		if filter.Mode == tcpip.MulticastFilterExclude {
+			recordType = MulticastGroupProtocolV2ReportRecordModeIsExclude
+		}
+		reportBuilder.AddRecord(recordType, groupAddress, filter.Sources)
+		return
+	}
+
+	// As per RFC 3376 section 5.2 (for IGMPv3),
+	//
+	//   Set of sources in the
+	//   pending response record      Current-State Record
+	//   -----------------------      --------------------
+	//
+	//   INCLUDE (A)                  IS_IN (A*B)
+	//
+	//   EXCLUDE (A)                  IS_IN (B-A)
+	//
+	//   If the resulting Current-State Record has an empty set of source
+	//   addresses, then no response is sent.
+	//
+	// where B is the set of queried sources. RFC 3810 section 6.3 defines the
+	// same rules for MLDv2.
+	var sources []tcpip.Address
+	for source := range queriedSources {
+		if m.filter.Allows(source) {
+			sources = append(sources, source)
+		}
+	}
+	if len(sources) == 0 {
+		return
+	}
+	multicast.SortAddresses(sources)
+	reportBuilder.AddRecord(MulticastGroupProtocolV2ReportRecordModeIsInclude, groupAddress, sources)
+}
+
+// sourcesDifference returns the sources in a that are not in b.
+func sourcesDifference(a, b []tcpip.Address) []tcpip.Address {
+	var diff []tcpip.Address
+	for _, source := range a {
+		found := false
+		for _, other := range b {
+			if source == other {
+				found = true
+				break
+			}
+		}
+		if !found {
+			diff = append(diff, source)
+		}
+	}
+	return diff
+}
+
+// sourcesOf returns the sorted sources of the set.
+func sourcesOf(set map[tcpip.Address]struct{}) []tcpip.Address {
+	sources := make([]tcpip.Address, 0, len(set))
+	for source := range set {
+		sources = append(sources, source)
+	}
+	multicast.SortAddresses(sources)
+	return sources
+}
+
 // GenericMulticastProtocolOptions holds options for the generic multicast
 // protocol.
 //
@@ -196,8 +400,8 @@ const (
 
 // MulticastGroupProtocolV2ReportBuilder is a builder for a V2 report.
 type MulticastGroupProtocolV2ReportBuilder interface {
-	// AddRecord adds a record to the report.
-	AddRecord(recordType MulticastGroupProtocolV2ReportRecordType, groupAddress tcpip.Address)
+	// AddRecord adds a record with the specified sources to the report.
+	AddRecord(recordType MulticastGroupProtocolV2ReportRecordType, groupAddress tcpip.Address, sources []tcpip.Address)
 
 	// Send sends the report.
 	//
@@ -406,6 +610,7 @@ func (g *GenericMulticastProtocolState) MakeAllNonMemberLocked() {
 			v2ReportBuilder.AddRecord(
 				MulticastGroupProtocolV2ReportRecordChangeToIncludeMode,
 				groupAddress,
+				nil, /* sources */
 			)
 		}
 	case protocolModeV1Compatibility:
@@ -502,7 +707,7 @@ func (g *GenericMulticastProtocolState) SendQueuedReportsLocked() {
 		if info.delayedReportJobFiresAt.IsZero() {
 			switch g.mode {
 			case protocolModeV2:
-				g.sendV2ReportAndMaybeScheduleChangedTimer(groupAddress, &info, MulticastGroupProtocolV2ReportRecordChangeToExcludeMode)
+				g.sendV2ReportAndMaybeScheduleChangedTimer(groupAddress, &info)
 			case protocolModeV1Compatibility, protocolModeV1:
 				g.maybeSendReportLocked(groupAddress, &info)
 			default:
@@ -518,11 +723,78 @@ func (g *GenericMulticastProtocolState) SendQueuedReportsLocked() {
 //
 // Precondition: g.protocolMU must be locked.
 func (g *GenericMulticastProtocolState) JoinGroupLocked(groupAddress tcpip.Address) {
+	g.joinGroupLocked(groupAddress, anySourceFilter)
+}
+
+// ChangeSourceFilterLocked replaces a join's source filter for the group.
+//
+// As an INCLUDE mode filter without sources is equivalent to not being a
+// member of the group, changing the filter from such a filter joins the group
+// and changing it to such a filter leaves the group. JoinGroupLocked and
+// LeaveGroupLocked are equivalent to changing the filter from and to such a
+// filter respectively, with an EXCLUDE mode filter without sources in-between.
+//
+// Returns false if oldFilter isn't a member filter but the group is not
+// currently joined.
+//
+// Precondition: g.protocolMU must be locked.
+func (g *GenericMulticastProtocolState) ChangeSourceFilterLocked(groupAddress tcpip.Address, oldFilter, newFilter tcpip.MulticastSourceFilter) bool {
+	wasMember, isMember := isMemberFilter(oldFilter), isMemberFilter(newFilter)
+	switch {
+	case !wasMember && !isMember:
+		return true
+	case !wasMember:
+		g.joinGroupLocked(groupAddress, newFilter)
+		return true
+	case !isMember:
+		return g.leaveGroupLocked(groupAddress, oldFilter)
+	}
+
+	info, ok := g.memberships[groupAddress]
+	if !ok || info.joins == 0 {
+		return false
+	}
+	prevFilter := info.filter.Filter()
+	info.filter.Remove(oldFilter)
+	info.filter.Add(newFilter)
+	g.filterChangedLocked(groupAddress, &info, prevFilter)
+	g.memberships[groupAddress] = info
+	return true
+}
+
+// filterChangedLocked announces the change of the interface's reception state
+// for a group that remains joined.
+//
+// Precondition: g.protocolMU must be locked.
+func (g *GenericMulticastProtocolState) filterChangedLocked(groupAddress tcpip.Address, info *multicastGroupState, oldFilter tcpip.MulticastSourceFilter) {
+	if !g.shouldPerformForGroup(groupAddress) {
+		return
+	}
+
+	switch g.mode {
+	case protocolModeV2:
+		if !info.recordStateChange(oldFilter, info.filter.Filter()) {
+			return
+		}
+		info.transmissionLeft = g.robustnessVariable
+		g.sendV2ReportAndMaybeScheduleChangedTimer(groupAddress, info)
+	case protocolModeV1Compatibility, protocolModeV1:
+		// Older versions of the protocol do not support source filtering; the
+		// group is still joined so there is nothing to report.
+	default:
+		panic(fmt.Sprintf("unrecognized mode = %d", g.mode))
+	}
+}
+
+func (g *GenericMulticastProtocolState) joinGroupLocked(groupAddress tcpip.Address, filter tcpip.MulticastSourceFilter) {
 	info, ok := g.memberships[groupAddress]
 	if ok {
 		info.joins++
 		if info.joins > 1 {
 			// The group has already been joined.
+			prevFilter := info.filter.Filter()
+			info.filter.Add(filter)
+			g.filterChangedLocked(groupAddress, &info, prevFilter)
 			g.memberships[groupAddress] = info
 			return
 		}
@@ -546,7 +818,7 @@ func (g *GenericMulticastProtocolState) JoinGroupLocked(groupAddress tcpip.Addre
 				switch g.mode {
 				case protocolModeV2:
 					reportBuilder := g.opts.Protocol.NewReportV2Builder()
-					reportBuilder.AddRecord(MulticastGroupProtocolV2ReportRecordModeIsExclude, groupAddress)
+					info.addCurrentStateRecord(reportBuilder, groupAddress, info.queriedIncludeSources)
 					// Nothing meaningful we can do with the error here - we only try to
 					// send a delayed report once.
 					_, _ = reportBuilder.Send()
@@ -563,6 +835,7 @@ func (g *GenericMulticastProtocolState) JoinGroupLocked(groupAddress tcpip.Addre
 		}
 	}
 
+	info.filter.Add(filter)
 	info.deleteScheduled = false
 	info.clearQueriedIncludeSources()
 	info.delayedReportJobFiresAt = time.Time{}
@@ -582,7 +855,6 @@ func (g *GenericMulticastProtocolState) IsLocallyJoinedRLocked(groupAddress tcpi
 func (g *GenericMulticastProtocolState) sendV2ReportAndMaybeScheduleChangedTimer(
 	groupAddress tcpip.Address,
 	info *multicastGroupState,
-	recordType MulticastGroupProtocolV2ReportRecordType,
 ) bool {
 	if info.transmissionLeft == 0 {
 		return false
@@ -590,13 +862,16 @@ func (g *GenericMulticastProtocolState) sendV2ReportAndMaybeScheduleChangedTimer
 
 	successfullySentAndHasMore := false
 
-	// Send a report immediately to announce us leaving the group.
+	// Send a report immediately to announce the state change.
 	reportBuilder := g.opts.Protocol.NewReportV2Builder()
-	reportBuilder.AddRecord(recordType, groupAddress)
+	info.addStateChangeRecords(reportBuilder, groupAddress)
 	if sent, err := reportBuilder.Send(); sent && err == nil {
 		info.transmissionLeft--
 
 		successfullySentAndHasMore = info.transmissionLeft != 0
+		if !successfullySentAndHasMore {
+			info.clearPendingStateChange()
+		}
 
 		// Use the interface-wide state changed report for further transmissions.
 		if successfullySentAndHasMore {
@@ -631,11 +906,10 @@ func (g *GenericMulticastProtocolState) scheduleStateChangedTimer() {
 				info.transmissionLeft--
 				nonEmptyReport = true
 
-				mode := MulticastGroupProtocolV2ReportRecordChangeToExcludeMode
-				if info.deleteScheduled {
-					mode = MulticastGroupProtocolV2ReportRecordChangeToIncludeMode
+				info.addStateChangeRecords(reportBuilder, groupAddress)
+				if info.transmissionLeft == 0 {
+					info.clearPendingStateChange()
 				}
-				reportBuilder.AddRecord(mode, groupAddress)
 
 				if info.deleteScheduled && info.transmissionLeft == 0 {
 					// No more transmissions left so we can actually delete the
@@ -668,14 +942,22 @@ func (g *GenericMulticastProtocolState) scheduleStateChangedTimer() {
 //
 // Precondition: g.protocolMU must be locked.
 func (g *GenericMulticastProtocolState) LeaveGroupLocked(groupAddress tcpip.Address) bool {
+	return g.leaveGroupLocked(groupAddress, anySourceFilter)
+}
+
+func (g *GenericMulticastProtocolState) leaveGroupLocked(groupAddress tcpip.Address, filter tcpip.MulticastSourceFilter) bool {
 	info, ok := g.memberships[groupAddress]
 	if !ok || info.joins == 0 {
 		return false
 	}
 
+	prevFilter := info.filter.Filter()
+	info.filter.Remove(filter)
 	info.joins--
 	if info.joins != 0 {
-		// If we still have outstanding joins, then do nothing further.
+		// If we still have outstanding joins, only announce the change of the
+		// sources we receive packets from.
+		g.filterChangedLocked(groupAddress, &info, prevFilter)
 		g.memberships[groupAddress] = info
 		return true
 	}
@@ -690,8 +972,9 @@ func (g *GenericMulticastProtocolState) LeaveGroupLocked(groupAddress tcpip.Addr
 
 	switch g.mode {
 	case protocolModeV2:
+		info.recordStateChange(prevFilter, info.filter.Filter())
 		info.transmissionLeft = g.robustnessVariable
-		if g.sendV2ReportAndMaybeScheduleChangedTimer(groupAddress, &info, MulticastGroupProtocolV2ReportRecordChangeToIncludeMode) {
+		if g.sendV2ReportAndMaybeScheduleChangedTimer(groupAddress, &info) {
 			g.memberships[groupAddress] = info
 		} else {
 			delete(g.memberships, groupAddress)
@@ -848,13 +1131,7 @@ func (g *GenericMulticastProtocolState) HandleQueryV2Locked(groupAddress tcpip.A
 
 					// A MODE_IS_EXCLUDE record without any sources indicates that we are
 					// interested in traffic from all sources for the group.
-					//
-					// We currently only hold groups if we have an active interest in the
-					// group.
-					reportBuilder.AddRecord(
-						MulticastGroupProtocolV2ReportRecordModeIsExclude,
-						groupAddress,
-					)
+					info.addCurrentStateRecord(reportBuilder, groupAddress, nil /* queriedSources */)
 				}
 
 				_, _ = reportBuilder.Send()
@@ -1011,11 +1288,14 @@ func (g *GenericMulticastProtocolState) initializeNewMemberLocked(groupAddress t
 
 	switch g.mode {
 	case protocolModeV2:
+		// Announce the change from not being a member of the group.
+		info.clearPendingStateChange()
+		info.recordStateChange(tcpip.MulticastSourceFilter{}, info.filter.Filter())
 		info.transmissionLeft = g.robustnessVariable
 		if callersV2ReportBuilder == nil {
-			g.sendV2ReportAndMaybeScheduleChangedTimer(groupAddress, info, MulticastGroupProtocolV2ReportRecordChangeToExcludeMode)
+			g.sendV2ReportAndMaybeScheduleChangedTimer(groupAddress, info)
 		} else {
-			callersV2ReportBuilder.AddRecord(MulticastGroupProtocolV2ReportRecordChangeToExcludeMode, groupAddress)
+			info.addStateChangeRecords(callersV2ReportBuilder, groupAddress)
 			info.transmissionLeft--
 		}
 	case protocolModeV1Compatibility, protocolModeV1:
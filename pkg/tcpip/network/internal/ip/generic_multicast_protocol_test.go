@@ -41,7 +41,7 @@ type mockMulticastGroupProtocolProtectedFields struct {
 	sendLeaveGroupAddrCount  map[tcpip.Address]int
 	makeQueuePackets         bool
 	disabled                 bool
-	sentV2Reports            map[tcpip.Address][]ip.MulticastGroupProtocolV2ReportRecordType
+	sentV2Reports            map[tcpip.Address][]mockReportV2Record
 }
 
 type mockMulticastGroupProtocol struct {
@@ -67,7 +67,7 @@ func (m *mockMulticastGroupProtocol) init(opts ip.GenericMulticastProtocolOption
 func (m *mockMulticastGroupProtocol) initLocked() {
 	m.mu.sendReportGroupAddrCount = make(map[tcpip.Address]int)
 	m.mu.sendLeaveGroupAddrCount = make(map[tcpip.Address]int)
-	m.mu.sentV2Reports = make(map[tcpip.Address][]ip.MulticastGroupProtocolV2ReportRecordType)
+	m.mu.sentV2Reports = make(map[tcpip.Address][]mockReportV2Record)
 }
 
 func (m *mockMulticastGroupProtocol) setEnabled(v bool) {
@@ -106,6 +106,12 @@ func (m *mockMulticastGroupProtocol) leaveGroup(addr tcpip.Address) bool {
 	return m.mu.genericMulticastGroup.LeaveGroupLocked(addr)
 }
 
+func (m *mockMulticastGroupProtocol) changeSourceFilter(addr tcpip.Address, oldFilter, newFilter tcpip.MulticastSourceFilter) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.mu.genericMulticastGroup.ChangeSourceFilterLocked(addr, oldFilter, newFilter)
+}
+
 func (m *mockMulticastGroupProtocol) handleReport(addr tcpip.Address) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -202,6 +208,7 @@ func (m *mockMulticastGroupProtocol) ShouldPerformProtocol(groupAddress tcpip.Ad
 type mockReportV2Record struct {
 	recordType   ip.MulticastGroupProtocolV2ReportRecordType
 	groupAddress tcpip.Address
+	sources      []tcpip.Address
 }
 
 type mockReportV2 struct {
@@ -214,13 +221,13 @@ type mockReportV2Builder struct {
 }
 
 // AddRecord implements ip.MulticastGroupProtocolV2ReportBuilder.
-func (b *mockReportV2Builder) AddRecord(recordType ip.MulticastGroupProtocolV2ReportRecordType, groupAddress tcpip.Address) {
-	b.report.records = append(b.report.records, mockReportV2Record{recordType: recordType, groupAddress: groupAddress})
+func (b *mockReportV2Builder) AddRecord(recordType ip.MulticastGroupProtocolV2ReportRecordType, groupAddress tcpip.Address, sources []tcpip.Address) {
+	b.report.records = append(b.report.records, mockReportV2Record{recordType: recordType, groupAddress: groupAddress, sources: sources})
 }
 
-func recordsToMap(m map[tcpip.Address][]ip.MulticastGroupProtocolV2ReportRecordType, records []mockReportV2Record) {
+func recordsToMap(m map[tcpip.Address][]mockReportV2Record, records []mockReportV2Record) {
 	for _, record := range records {
-		m[record.groupAddress] = append(m[record.groupAddress], record.recordType)
+		m[record.groupAddress] = append(m[record.groupAddress], record)
 	}
 }
 
@@ -274,7 +281,7 @@ func (m *mockMulticastGroupProtocol) check(fields checkFields) string {
 		sendLeaveGroupAddrCount[a] = 1
 	}
 
-	sentV2Reports := make(map[tcpip.Address][]ip.MulticastGroupProtocolV2ReportRecordType)
+	sentV2Reports := make(map[tcpip.Address][]mockReportV2Record)
 	for _, report := range fields.sentV2Reports {
 		recordsToMap(sentV2Reports, report.records)
 	}
@@ -1680,3 +1687,106 @@ func TestGetSetV1Mode(t *testing.T) {
 		t.Fatalf("mockMulticastGroupProtocol mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestSourceFilterReports(t *testing.T) {
+	const maxRespCode = 1
+
+	var (
+		src1 = tcpip.AddrFromSlice([]byte("\x0a\x00\x00\x01"))
+		src2 = tcpip.AddrFromSlice([]byte("\x0a\x00\x00\x02"))
+		src3 = tcpip.AddrFromSlice([]byte("\x0a\x00\x00\x03"))
+	)
+	include := func(sources ...tcpip.Address) tcpip.MulticastSourceFilter {
+		return tcpip.MulticastSourceFilter{Mode: tcpip.MulticastFilterInclude, Sources: sources}
+	}
+	exclude := func(sources ...tcpip.Address) tcpip.MulticastSourceFilter {
+		return tcpip.MulticastSourceFilter{Mode: tcpip.MulticastFilterExclude, Sources: sources}
+	}
+	report := func(recordType ip.MulticastGroupProtocolV2ReportRecordType, sources ...tcpip.Address) checkFields {
+		return checkFields{sentV2Reports: []mockReportV2{{records: []mockReportV2Record{
+			{
+				recordType:   recordType,
+				groupAddress: addr1,
+				sources:      sources,
+			},
+		}}}}
+	}
+
+	clock := faketime.NewManualClock()
+	mgp := mockMulticastGroupProtocol{t: t}
+	mgp.init(ip.GenericMulticastProtocolOptions{
+		Rand:                      rand.New(rand.NewSource(4)),
+		Clock:                     clock,
+		MaxUnsolicitedReportDelay: maxUnsolicitedReportDelay,
+	}, false /* v1Compatibility */)
+
+	// Each state change is announced immediately and retransmitted once.
+	changeAndCheck := func(oldFilter, newFilter tcpip.MulticastSourceFilter, want checkFields) {
+		t.Helper()
+
+		if !mgp.changeSourceFilter(addr1, oldFilter, newFilter) {
+			t.Fatalf("got mgp.changeSourceFilter(%s, %#v, %#v) = false, want = true", addr1, oldFilter, newFilter)
+		}
+		if diff := mgp.check(want); diff != "" {
+			t.Fatalf("mockMulticastGroupProtocol mismatch (-want +got):\n%s", diff)
+		}
+		clock.Advance(maxUnsolicitedReportDelay)
+		if diff := mgp.check(want); diff != "" {
+			t.Fatalf("mockMulticastGroupProtocol mismatch (-want +got):\n%s", diff)
+		}
+		clock.Advance(time.Hour)
+		if diff := mgp.check(checkFields{}); diff != "" {
+			t.Fatalf("mockMulticastGroupProtocol mismatch (-want +got):\n%s", diff)
+		}
+	}
+
+	if mgp.changeSourceFilter(addr1, include(src1), include(src2)) {
+		t.Fatalf("got mgp.changeSourceFilter(%s, _, _) = true for a group that isn't joined, want = false", addr1)
+	}
+
+	changeAndCheck(include(), include(src1), report(ip.MulticastGroupProtocolV2ReportRecordAllowNewSources, src1))
+	if !mgp.isLocallyJoined(addr1) {
+		t.Fatalf("got mgp.isLocallyJoined(%s) = false, want = true", addr1)
+	}
+	changeAndCheck(include(src1), include(src1, src2), report(ip.MulticastGroupProtocolV2ReportRecordAllowNewSources, src2))
+	changeAndCheck(include(src1, src2), include(src2), report(ip.MulticastGroupProtocolV2ReportRecordBlockOldSources, src1))
+	changeAndCheck(include(src2), exclude(src3), report(ip.MulticastGroupProtocolV2ReportRecordChangeToExcludeMode, src3))
+
+	// A second socket joining the group from any source leaves the interface in
+	// EXCLUDE mode without sources.
+	changeAndCheck(include(), exclude(), report(ip.MulticastGroupProtocolV2ReportRecordAllowNewSources, src3))
+
+	// Joining the group without changing the reception state isn't announced.
+	mgp.joinGroup(addr1)
+	clock.Advance(time.Hour)
+	if diff := mgp.check(checkFields{}); diff != "" {
+		t.Fatalf("mockMulticastGroupProtocol mismatch (-want +got):\n%s", diff)
+	}
+	if !mgp.leaveGroup(addr1) {
+		t.Fatalf("got mgp.leaveGroup(%s) = false, want = true", addr1)
+	}
+	changeAndCheck(exclude(), include(), report(ip.MulticastGroupProtocolV2ReportRecordBlockOldSources, src3))
+
+	// Queries are answered with the current state.
+	mgp.handleQueryV2(tcpip.Address{}, maxRespCode, header.MakeAddressIterator(addr1.Len(), bytes.NewBuffer(nil)), 0, 0)
+	clock.Advance(time.Hour)
+	if diff := mgp.check(report(ip.MulticastGroupProtocolV2ReportRecordModeIsExclude, src3)); diff != "" {
+		t.Fatalf("mockMulticastGroupProtocol mismatch (-want +got):\n%s", diff)
+	}
+	// Source-specific queries are answered with the queried sources that are
+	// received.
+	var queriedSources bytes.Buffer
+	for _, source := range []tcpip.Address{src1, src3} {
+		queriedSources.Write(source.AsSlice())
+	}
+	mgp.handleQueryV2(addr1, maxRespCode, header.MakeAddressIterator(addr1.Len(), &queriedSources), 0, 0)
+	clock.Advance(time.Hour)
+	if diff := mgp.check(report(ip.MulticastGroupProtocolV2ReportRecordModeIsInclude, src1)); diff != "" {
+		t.Fatalf("mockMulticastGroupProtocol mismatch (-want +got):\n%s", diff)
+	}
+
+	changeAndCheck(exclude(src3), include(), report(ip.MulticastGroupProtocolV2ReportRecordChangeToIncludeMode))
+	if mgp.isLocallyJoined(addr1) {
+		t.Fatalf("got mgp.isLocallyJoined(%s) = true, want = false", addr1)
+	}
+}
@@ -12,7 +12,8 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// Package multicast contains utilities for supporting multicast routing.
+// Package multicast contains utilities for supporting multicast routing and
+// source filtering.
 package multicast
 
 import (
@@ -0,0 +1,128 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multicast
+
+import (
+	"bytes"
+	"sort"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+// InterfaceState is the reception state of an interface for a multicast
+// address. It merges the source filters of every socket that joined the
+// address on the interface.
+//
+// As per RFC 3376 section 3.2 (for IGMPv3),
+//
+//   - If any of the socket records for the multicast address on the interface
+//     specify a filter mode of EXCLUDE, then the interface-level filter mode
+//     for the multicast address is EXCLUDE, and the interface-level source
+//     list for the multicast address is the intersection of the source lists
+//     of all socket records in EXCLUDE mode, minus those source addresses that
+//     appear in any socket record in INCLUDE mode.
+//
+//   - If all of the socket records for the multicast address on the interface
+//     specify a filter mode of INCLUDE, then the interface-level filter mode
+//     for the multicast address is INCLUDE, and the interface-level source
+//     list for the multicast address is the union of the source lists of all
+//     the socket records.
+//
+// RFC 3810 section 4.2 defines the same rules for MLDv2.
+//
+// The zero value is the state of an interface that has no socket records for
+// the multicast address, i.e. INCLUDE mode with an empty source list.
+//
+// +stateify savable
+type InterfaceState struct {
+	// excludeRecords is the number of socket records in EXCLUDE mode.
+	excludeRecords int
+
+	// excluded holds, for each source, the number of socket records in
+	// EXCLUDE mode that list it.
+	excluded map[tcpip.Address]int
+
+	// included holds, for each source, the number of socket records in
+	// INCLUDE mode that list it.
+	included map[tcpip.Address]int
+}
+
+// Add merges a socket record into the state.
+func (s *InterfaceState) Add(filter tcpip.MulticastSourceFilter) {
+	s.update(filter, 1)
+}
+
+// Remove removes a socket record previously merged into the state with Add.
+func (s *InterfaceState) Remove(filter tcpip.MulticastSourceFilter) {
+	s.update(filter, -1)
+}
+
+func (s *InterfaceState) update(filter tcpip.MulticastSourceFilter, delta int) {
+	counts := &s.included
+	if filter.Mode == tcpip.MulticastFilterExclude {
+		s.excludeRecords += delta
+		counts = &s.excluded
+	}
+	if len(filter.Sources) == 0 {
+		return
+	}
+	if *counts == nil {
+		*counts = make(map[tcpip.Address]int)
+	}
+	for _, source := range filter.Sources {
+		if n := (*counts)[source] + delta; n != 0 {
+			(*counts)[source] = n
+		} else {
+			delete(*counts, source)
+		}
+	}
+}
+
+// Filter returns the interface-level filter mode and source list. The sources
+// are sorted.
+func (s *InterfaceState) Filter() tcpip.MulticastSourceFilter {
+	var filter tcpip.MulticastSourceFilter
+	if s.excludeRecords == 0 {
+		filter.Mode = tcpip.MulticastFilterInclude
+		for source := range s.included {
+			filter.Sources = append(filter.Sources, source)
+		}
+	} else {
+		filter.Mode = tcpip.MulticastFilterExclude
+		for source, n := range s.excluded {
+			if n == s.excludeRecords && s.included[source] == 0 {
+				filter.Sources = append(filter.Sources, source)
+			}
+		}
+	}
+	SortAddresses(filter.Sources)
+	return filter
+}
+
+// Allows returns true if packets sent by source to the multicast address are
+// received by the interface.
+func (s *InterfaceState) Allows(source tcpip.Address) bool {
+	if s.included[source] != 0 {
+		return true
+	}
+	return s.excludeRecords != 0 && s.excluded[source] != s.excludeRecords
+}
+
+// SortAddresses sorts addrs in increasing order.
+func SortAddresses(addrs []tcpip.Address) {
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i].AsSlice(), addrs[j].AsSlice()) < 0
+	})
+}
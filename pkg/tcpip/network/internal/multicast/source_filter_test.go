@@ -0,0 +1,120 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package multicast
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/testutil"
+)
+
+func TestInterfaceState(t *testing.T) {
+	var (
+		src1 = testutil.MustParse4("192.0.2.1")
+		src2 = testutil.MustParse4("192.0.2.2")
+		src3 = testutil.MustParse4("192.0.2.3")
+	)
+	include := func(sources ...tcpip.Address) tcpip.MulticastSourceFilter {
+		return tcpip.MulticastSourceFilter{Mode: tcpip.MulticastFilterInclude, Sources: sources}
+	}
+	exclude := func(sources ...tcpip.Address) tcpip.MulticastSourceFilter {
+		return tcpip.MulticastSourceFilter{Mode: tcpip.MulticastFilterExclude, Sources: sources}
+	}
+
+	tests := []struct {
+		name    string
+		add     []tcpip.MulticastSourceFilter
+		remove  []tcpip.MulticastSourceFilter
+		want    tcpip.MulticastSourceFilter
+		allowed []tcpip.Address
+		blocked []tcpip.Address
+	}{
+		{
+			name:    "No records",
+			want:    include(),
+			blocked: []tcpip.Address{src1, src2, src3},
+		},
+		{
+			name:    "Any source",
+			add:     []tcpip.MulticastSourceFilter{exclude()},
+			want:    exclude(),
+			allowed: []tcpip.Address{src1, src2, src3},
+		},
+		{
+			name:    "Union of INCLUDE records",
+			add:     []tcpip.MulticastSourceFilter{include(src2, src1), include(src2)},
+			want:    include(src1, src2),
+			allowed: []tcpip.Address{src1, src2},
+			blocked: []tcpip.Address{src3},
+		},
+		{
+			name:    "Intersection of EXCLUDE records",
+			add:     []tcpip.MulticastSourceFilter{exclude(src1, src2), exclude(src2, src3)},
+			want:    exclude(src2),
+			allowed: []tcpip.Address{src1, src3},
+			blocked: []tcpip.Address{src2},
+		},
+		{
+			name:    "EXCLUDE minus INCLUDE records",
+			add:     []tcpip.MulticastSourceFilter{exclude(src1, src2), include(src1)},
+			want:    exclude(src2),
+			allowed: []tcpip.Address{src1, src3},
+			blocked: []tcpip.Address{src2},
+		},
+		{
+			name:    "Removed EXCLUDE record",
+			add:     []tcpip.MulticastSourceFilter{exclude(src1), include(src2)},
+			remove:  []tcpip.MulticastSourceFilter{exclude(src1)},
+			want:    include(src2),
+			allowed: []tcpip.Address{src2},
+			blocked: []tcpip.Address{src1, src3},
+		},
+		{
+			name:    "All records removed",
+			add:     []tcpip.MulticastSourceFilter{exclude(src1), include(src2)},
+			remove:  []tcpip.MulticastSourceFilter{include(src2), exclude(src1)},
+			want:    include(),
+			blocked: []tcpip.Address{src1, src2, src3},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var s InterfaceState
+			for _, f := range test.add {
+				s.Add(f)
+			}
+			for _, f := range test.remove {
+				s.Remove(f)
+			}
+
+			if diff := cmp.Diff(test.want, s.Filter()); diff != "" {
+				t.Errorf("s.Filter() mismatch (-want +got):\n%s", diff)
+			}
+			for _, source := range test.allowed {
+				if !s.Allows(source) {
+					t.Errorf("got s.Allows(%s) = false, want = true", source)
+				}
+			}
+			for _, source := range test.blocked {
+				if s.Allows(source) {
+					t.Errorf("got s.Allows(%s) = true, want = false", source)
+				}
+			}
+		})
+	}
+}
@@ -185,7 +185,7 @@ type igmpv3ReportBuilder struct {
 }
 
 // AddRecord implements ip.MulticastGroupProtocolV2ReportBuilder.
-func (b *igmpv3ReportBuilder) AddRecord(genericRecordType ip.MulticastGroupProtocolV2ReportRecordType, groupAddress tcpip.Address) {
+func (b *igmpv3ReportBuilder) AddRecord(genericRecordType ip.MulticastGroupProtocolV2ReportRecordType, groupAddress tcpip.Address, sources []tcpip.Address) {
 	var recordType header.IGMPv3ReportRecordType
 	switch genericRecordType {
 	case ip.MulticastGroupProtocolV2ReportRecordModeIsInclude:
@@ -207,7 +207,7 @@ func (b *igmpv3ReportBuilder) AddRecord(genericRecordType ip.MulticastGroupProto
 	b.records = append(b.records, header.IGMPv3ReportGroupAddressRecordSerializer{
 		RecordType:   recordType,
 		GroupAddress: groupAddress,
-		Sources:      nil,
+		Sources:      sources,
 	})
 }
 
@@ -582,6 +582,19 @@ func (igmp *igmpState) leaveGroup(groupAddress tcpip.Address) tcpip.Error {
 	return &tcpip.ErrBadLocalAddress{}
 }
 
+// changeSourceFilter replaces the source filter of a join of the group and
+// sends the reports announcing the change, if required.
+//
+// +checklocks:igmp.ep.mu
+func (igmp *igmpState) changeSourceFilter(groupAddress tcpip.Address, oldFilter, newFilter tcpip.MulticastSourceFilter) tcpip.Error {
+	// ChangeSourceFilterLocked returns false only if the group was not joined.
+	if igmp.genericMulticastProtocol.ChangeSourceFilterLocked(groupAddress, oldFilter, newFilter) {
+		return nil
+	}
+
+	return &tcpip.ErrBadLocalAddress{}
+}
+
 // softLeaveAll leaves all groups from the perspective of IGMP, but remains
 // joined locally.
 //
@@ -78,6 +78,7 @@ var _ stack.LinkResolvableNetworkEndpoint = (*endpoint)(nil)
 var _ stack.ForwardingNetworkEndpoint = (*endpoint)(nil)
 var _ stack.MulticastForwardingNetworkEndpoint = (*endpoint)(nil)
 var _ stack.GroupAddressableEndpoint = (*endpoint)(nil)
+var _ stack.SourceFilteringGroupAddressableEndpoint = (*endpoint)(nil)
 var _ stack.AddressableEndpoint = (*endpoint)(nil)
 var _ stack.NetworkEndpoint = (*endpoint)(nil)
 var _ IGMPEndpoint = (*endpoint)(nil)
@@ -1172,6 +1173,9 @@ func (e *endpoint) handleValidatedPacket(h header.IPv4, pkt *stack.PacketBuffer,
 		subnet := addressEndpoint.AddressWithPrefix().Subnet()
 		pkt.NetworkPacketInfo.LocalAddressBroadcast = subnet.IsBroadcast(dstAddr) || dstAddr == header.IPv4Broadcast
 		e.deliverPacketLocally(h, pkt, inNICName)
+	} else if pkt.TProxied() {
+		// A TPROXY target steered the packet to a local transparent socket.
+		e.deliverPacketLocally(h, pkt, inNICName)
 	} else if e.Forwarding() {
 		e.handleForwardingError(e.forwardUnicastPacket(pkt))
 	} else {
@@ -1503,6 +1507,18 @@ func (e *endpoint) leaveGroupLocked(addr tcpip.Address) tcpip.Error {
 	return e.igmp.leaveGroup(addr)
 }
 
+// ChangeGroupSourceFilter implements
+// stack.SourceFilteringGroupAddressableEndpoint.
+func (e *endpoint) ChangeGroupSourceFilter(addr tcpip.Address, oldFilter, newFilter tcpip.MulticastSourceFilter) tcpip.Error {
+	if !header.IsV4MulticastAddress(addr) {
+		return &tcpip.ErrBadAddress{}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.igmp.changeSourceFilter(addr, oldFilter, newFilter) // +checklocksforce: e.mu==e.igmp.ep.mu.
+}
+
 // IsInGroup implements stack.GroupAddressableEndpoint.
 func (e *endpoint) IsInGroup(addr tcpip.Address) bool {
 	e.mu.RLock()
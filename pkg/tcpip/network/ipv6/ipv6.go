@@ -174,6 +174,7 @@ var _ stack.LinkResolvableNetworkEndpoint = (*endpoint)(nil)
 var _ stack.ForwardingNetworkEndpoint = (*endpoint)(nil)
 var _ stack.MulticastForwardingNetworkEndpoint = (*endpoint)(nil)
 var _ stack.GroupAddressableEndpoint = (*endpoint)(nil)
+var _ stack.SourceFilteringGroupAddressableEndpoint = (*endpoint)(nil)
 var _ stack.AddressableEndpoint = (*endpoint)(nil)
 var _ stack.NetworkEndpoint = (*endpoint)(nil)
 var _ stack.NDPEndpoint = (*endpoint)(nil)
@@ -1377,6 +1378,9 @@ func (e *endpoint) handleValidatedPacket(h header.IPv6, pkt *stack.PacketBuffer,
 	// packet. Otherwise, attempt to forward the packet.
 	if addressEndpoint := e.AcquireAssignedAddress(dstAddr, e.nic.Promiscuous(), stack.CanBePrimaryEndpoint, true /* readOnly */); addressEndpoint != nil {
 		e.deliverPacketLocally(h, pkt, inNICName)
+	} else if pkt.TProxied() {
+		// A TPROXY target steered the packet to a local transparent socket.
+		e.deliverPacketLocally(h, pkt, inNICName)
 	} else if e.Forwarding() {
 		e.handleForwardingError(e.forwardUnicastPacket(pkt))
 	} else {
@@ -2279,6 +2283,18 @@ func (e *endpoint) leaveGroupLocked(addr tcpip.Address) tcpip.Error {
 	return e.mu.mld.leaveGroup(addr)
 }
 
+// ChangeGroupSourceFilter implements
+// stack.SourceFilteringGroupAddressableEndpoint.
+func (e *endpoint) ChangeGroupSourceFilter(addr tcpip.Address, oldFilter, newFilter tcpip.MulticastSourceFilter) tcpip.Error {
+	if !header.IsV6MulticastAddress(addr) {
+		return &tcpip.ErrBadAddress{}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.mu.mld.changeSourceFilter(addr, oldFilter, newFilter)
+}
+
 // IsInGroup implements stack.GroupAddressableEndpoint.
 func (e *endpoint) IsInGroup(addr tcpip.Address) bool {
 	e.mu.RLock()
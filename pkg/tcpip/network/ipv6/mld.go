@@ -133,7 +133,7 @@ type mldv2ReportBuilder struct {
 }
 
 // AddRecord implements ip.MulticastGroupProtocolV2ReportBuilder.
-func (b *mldv2ReportBuilder) AddRecord(genericRecordType ip.MulticastGroupProtocolV2ReportRecordType, groupAddress tcpip.Address) {
+func (b *mldv2ReportBuilder) AddRecord(genericRecordType ip.MulticastGroupProtocolV2ReportRecordType, groupAddress tcpip.Address, sources []tcpip.Address) {
 	var recordType header.MLDv2ReportRecordType
 	switch genericRecordType {
 	case ip.MulticastGroupProtocolV2ReportRecordModeIsInclude:
@@ -155,7 +155,7 @@ func (b *mldv2ReportBuilder) AddRecord(genericRecordType ip.MulticastGroupProtoc
 	b.records = append(b.records, header.MLDv2ReportMulticastAddressRecordSerializer{
 		RecordType:       recordType,
 		MulticastAddress: groupAddress,
-		Sources:          nil,
+		Sources:          sources,
 	})
 }
 
@@ -304,6 +304,19 @@ func (mld *mldState) leaveGroup(groupAddress tcpip.Address) tcpip.Error {
 	return &tcpip.ErrBadLocalAddress{}
 }
 
+// changeSourceFilter replaces the source filter of a join of the group and
+// sends the reports announcing the change, if required.
+//
+// Precondition: mld.ep.mu must be locked.
+func (mld *mldState) changeSourceFilter(groupAddress tcpip.Address, oldFilter, newFilter tcpip.MulticastSourceFilter) tcpip.Error {
+	// ChangeSourceFilterLocked returns false only if the group was not joined.
+	if mld.genericMulticastProtocol.ChangeSourceFilterLocked(groupAddress, oldFilter, newFilter) {
+		return nil
+	}
+
+	return &tcpip.ErrBadLocalAddress{}
+}
+
 // softLeaveAll leaves all groups from the perspective of MLD, but remains
 // joined locally.
 //
@@ -121,6 +121,23 @@ func (*DefaultSocketOptionsHandler) GetAcceptConn() bool {
 	return false
 }
 
+// ReusePortProgram is a program attached to a socket by
+// SO_ATTACH_REUSEPORT_CBPF, which selects the member of the SO_REUSEPORT group
+// of the socket that receives a packet.
+//
+// Unlike Linux, where the program belongs to the group, each member holds its
+// own program, and the group is steered by the program of its first member
+// that has one.
+type ReusePortProgram interface {
+	// Select returns the index of the member that receives the packet with
+	// the given transport payload, in the order in which the members were
+	// bound. It returns false if the program fails, in which case the member
+	// is selected by hashing the packet.
+	//
+	// Select is called while delivering packets, so it must not block.
+	Select(payload []byte) (uint32, bool)
+}
+
 // StackHandler holds methods to access the stack options. These must be
 // implemented by the stack.
 type StackHandler interface {
@@ -230,6 +247,14 @@ type SocketOptions struct {
 	// passing is enabled for IPv6.
 	ipv6RecvErrEnabled atomicbitops.Uint32
 
+	// transparentEnabled determines whether the socket may be bound to, and
+	// send from, addresses that are not assigned to the stack.
+	transparentEnabled atomicbitops.Uint32
+
+	// zeroCopyEnabled determines whether sends may be requested with
+	// MSG_ZEROCOPY.
+	zeroCopyEnabled atomicbitops.Uint32
+
 	// errQueue is the per-socket error queue. It is protected by errQueueMu.
 	errQueueMu sync.Mutex `state:"nosave"`
 	errQueue   sockErrorList
@@ -260,6 +285,21 @@ type SocketOptions struct {
 	// close. We currently implement this option for TCP socket only.
 	linger LingerOption
 
+	// reusePortProgram is the program attached by SO_ATTACH_REUSEPORT_CBPF.
+	// It is nil if no program is attached.
+	reusePortProgram ReusePortProgram
+
+	// reusePortProgramAttached is true if reusePortProgram isn't nil. It
+	// lets packet delivery skip mu when no program is attached.
+	reusePortProgramAttached atomicbitops.Uint32
+
+	// zeroCopyNextID is the ID of the next send requested with MSG_ZEROCOPY.
+	zeroCopyNextID uint32
+
+	// txTime determines the departure times of the packets sent with
+	// SCM_TXTIME.
+	txTime TxTimeOption
+
 	// rcvlowat specifies the minimum number of bytes which should be
 	// received to indicate the socket as readable.
 	rcvlowat atomicbitops.Int32
@@ -488,6 +528,47 @@ func (so *SocketOptions) SetReceiveOriginalDstAddress(v bool) {
 	storeAtomicBool(&so.receiveOriginalDstAddress, v)
 }
 
+// GetTransparent gets value for IP(V6)_TRANSPARENT option.
+func (so *SocketOptions) GetTransparent() bool {
+	return so.transparentEnabled.Load() != 0
+}
+
+// SetTransparent sets value for IP(V6)_TRANSPARENT option.
+func (so *SocketOptions) SetTransparent(v bool) {
+	storeAtomicBool(&so.transparentEnabled, v)
+}
+
+// GetZeroCopy gets value for SO_ZEROCOPY option.
+func (so *SocketOptions) GetZeroCopy() bool {
+	return so.zeroCopyEnabled.Load() != 0
+}
+
+// SetZeroCopy sets value for SO_ZEROCOPY option.
+func (so *SocketOptions) SetZeroCopy(v bool) {
+	storeAtomicBool(&so.zeroCopyEnabled, v)
+}
+
+// AllocZeroCopyID returns the ID of a new send requested with MSG_ZEROCOPY.
+// IDs start at zero and are consecutive, as in Linux.
+func (so *SocketOptions) AllocZeroCopyID() uint32 {
+	so.mu.Lock()
+	defer so.mu.Unlock()
+	id := so.zeroCopyNextID
+	so.zeroCopyNextID++
+	return id
+}
+
+// ReleaseZeroCopyID releases the ID of a send requested with MSG_ZEROCOPY
+// which sent nothing, so that the ID is used by the next send. It has no
+// effect if another ID has been allocated since.
+func (so *SocketOptions) ReleaseZeroCopyID(id uint32) {
+	so.mu.Lock()
+	defer so.mu.Unlock()
+	if so.zeroCopyNextID == id+1 {
+		so.zeroCopyNextID = id
+	}
+}
+
 // GetIPv4RecvError gets value for IP_RECVERR option.
 func (so *SocketOptions) GetIPv4RecvError() bool {
 	return so.ipv4RecvErrEnabled.Load() != 0
@@ -543,6 +624,40 @@ func (so *SocketOptions) SetLinger(linger LingerOption) {
 	so.mu.Unlock()
 }
 
+// GetTxTime gets value for SO_TXTIME option.
+func (so *SocketOptions) GetTxTime() TxTimeOption {
+	so.mu.Lock()
+	defer so.mu.Unlock()
+	return so.txTime
+}
+
+// SetTxTime sets value for SO_TXTIME option.
+func (so *SocketOptions) SetTxTime(txTime TxTimeOption) {
+	so.mu.Lock()
+	defer so.mu.Unlock()
+	so.txTime = txTime
+}
+
+// GetReusePortProgram gets the program attached by SO_ATTACH_REUSEPORT_CBPF.
+// It returns nil if no program is attached.
+func (so *SocketOptions) GetReusePortProgram() ReusePortProgram {
+	if so.reusePortProgramAttached.Load() == 0 {
+		return nil
+	}
+	so.mu.Lock()
+	defer so.mu.Unlock()
+	return so.reusePortProgram
+}
+
+// SetReusePortProgram sets the program attached by SO_ATTACH_REUSEPORT_CBPF.
+// A nil program detaches the program, as SO_DETACH_REUSEPORT_BPF does.
+func (so *SocketOptions) SetReusePortProgram(p ReusePortProgram) {
+	so.mu.Lock()
+	defer so.mu.Unlock()
+	so.reusePortProgram = p
+	storeAtomicBool(&so.reusePortProgramAttached, p != nil)
+}
+
 // GetExperimentOptionValue gets value for the experiment IP option header.
 func (so *SocketOptions) GetExperimentOptionValue() uint16 {
 	v := so.experimentOptionValue.Load()
@@ -569,6 +684,14 @@ const (
 
 	// SockExtErrorOriginICMP6 indicates an IPv6 ICMP error.
 	SockExtErrorOriginICMP6
+
+	// SockExtErrorOriginZeroCopy indicates the completion of sends requested
+	// with MSG_ZEROCOPY.
+	SockExtErrorOriginZeroCopy
+
+	// SockExtErrorOriginTxTime indicates a packet dropped because of its
+	// departure time.
+	SockExtErrorOriginTxTime
 )
 
 // IsICMPErr indicates if the error originated from an ICMP error.
@@ -618,6 +741,82 @@ func (l *LocalSockError) Info() uint32 {
 	return l.info
 }
 
+// ZeroCopySockError notifies the completion of the sends requested with
+// MSG_ZEROCOPY whose IDs are in [Lo, Hi].
+//
+// +stateify savable
+type ZeroCopySockError struct {
+	// Lo and Hi are the first and last IDs of the completed sends.
+	Lo uint32
+	Hi uint32
+
+	// Copied is true if the data of any of the sends was copied rather than
+	// sent from the memory of the application.
+	Copied bool
+}
+
+// Origin implements SockErrorCause.
+func (*ZeroCopySockError) Origin() SockErrOrigin {
+	return SockExtErrorOriginZeroCopy
+}
+
+// Type implements SockErrorCause.
+func (*ZeroCopySockError) Type() uint8 {
+	return 0
+}
+
+// Code implements SockErrorCause. It is SO_EE_CODE_ZEROCOPY_COPIED if the
+// data of the sends was copied.
+func (z *ZeroCopySockError) Code() uint8 {
+	if z.Copied {
+		return 1
+	}
+	return 0
+}
+
+// Info implements SockErrorCause.
+func (z *ZeroCopySockError) Info() uint32 {
+	return z.Lo
+}
+
+// TxTimeSockError notifies that a packet sent with the departure time Time
+// was dropped because of it.
+//
+// +stateify savable
+type TxTimeSockError struct {
+	// Time is the departure time of the packet.
+	Time int64
+
+	// Missed is true if the packet missed its departure time, and false if
+	// the departure time was invalid.
+	Missed bool
+}
+
+// Origin implements SockErrorCause.
+func (*TxTimeSockError) Origin() SockErrOrigin {
+	return SockExtErrorOriginTxTime
+}
+
+// Type implements SockErrorCause.
+func (*TxTimeSockError) Type() uint8 {
+	return 0
+}
+
+// Code implements SockErrorCause. It is SO_EE_CODE_TXTIME_MISSED if the packet
+// missed its departure time, and SO_EE_CODE_TXTIME_INVALID_PARAM otherwise.
+func (t *TxTimeSockError) Code() uint8 {
+	if t.Missed {
+		return 2
+	}
+	return 1
+}
+
+// Info implements SockErrorCause. It holds the low 32 bits of the departure
+// time.
+func (t *TxTimeSockError) Info() uint32 {
+	return uint32(t.Time)
+}
+
 // SockError represents a queue entry in the per-socket error queue.
 //
 // +stateify savable
@@ -676,6 +875,47 @@ func (so *SocketOptions) QueueErr(err *SockError) {
 	so.errQueue.PushBack(err)
 }
 
+// HasQueuedErr returns true if the error queue isn't empty.
+func (so *SocketOptions) HasQueuedErr() bool {
+	so.errQueueMu.Lock()
+	defer so.errQueueMu.Unlock()
+	return !so.errQueue.Empty()
+}
+
+// QueueZeroCopyCompletion queues the completion of the sends requested with
+// MSG_ZEROCOPY whose IDs are in [lo, hi]. As in Linux, it extends the
+// completion at the back of the error queue if the IDs follow its own.
+func (so *SocketOptions) QueueZeroCopyCompletion(lo, hi uint32, copied bool, net NetworkProtocolNumber) {
+	so.errQueueMu.Lock()
+	defer so.errQueueMu.Unlock()
+	if back := so.errQueue.Back(); back != nil {
+		if z, ok := back.Cause.(*ZeroCopySockError); ok && z.Hi+1 == lo && z.Copied == copied {
+			z.Hi = hi
+			return
+		}
+	}
+	so.errQueue.PushBack(&SockError{
+		Cause:    &ZeroCopySockError{Lo: lo, Hi: hi, Copied: copied},
+		NetProto: net,
+	})
+}
+
+// QueueTxTimeErr queues the error of a packet sent with the departure time
+// txTime that was dropped because of it. As in Linux, the error is
+// ErrCanceled if the departure time was missed, and ErrInvalidOptionValue
+// otherwise.
+func (so *SocketOptions) QueueTxTimeErr(txTime int64, missed bool, net NetworkProtocolNumber) {
+	var err Error = &ErrInvalidOptionValue{}
+	if missed {
+		err = &ErrCanceled{}
+	}
+	so.QueueErr(&SockError{
+		Err:      err,
+		Cause:    &TxTimeSockError{Time: txTime, Missed: missed},
+		NetProto: net,
+	})
+}
+
 // QueueLocalErr queues a local error onto the local queue.
 func (so *SocketOptions) QueueLocalErr(err Error, net NetworkProtocolNumber, info uint32, dst FullAddress, payload *buffer.View) {
 	so.QueueErr(&SockError{
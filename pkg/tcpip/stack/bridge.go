@@ -26,6 +26,9 @@ var _ NetworkLinkEndpoint = (*BridgeEndpoint)(nil)
 type bridgePort struct {
 	bridge *BridgeEndpoint
 	nic    *nic
+
+	// vlans is protected by bridge.mu.
+	vlans bridgeVLANs
 }
 
 // BridgeFDBKey is the MAC address of a device which a bridge port is associated with.
@@ -56,6 +59,10 @@ func (p *bridgePort) DeliverNetworkPacket(protocol tcpip.NetworkProtocolNumber,
 	eth := header.Ethernet(pkt.LinkHeader().Slice())
 	updateFDB := false
 	bridge.mu.RLock()
+	if bridge.vlanFiltering {
+		p.deliverVLANPacketLocked(pkt)
+		return
+	}
 	// Add an entry at the bridge FDB, it maps a MAC address
 	// to a bridge port where the traffic is received when
 	// the MAC address is not multicast.
@@ -107,11 +114,58 @@ func (p *bridgePort) DeliverNetworkPacket(protocol tcpip.NetworkProtocolNumber,
 func (p *bridgePort) DeliverLinkPacket(protocol tcpip.NetworkProtocolNumber, pkt *PacketBuffer) {
 }
 
+// deliverVLANPacketLocked is DeliverNetworkPacket for bridges that filter
+// VLANs. It releases the read lock of the bridge.
+//
+// +checklocksreleaseread:p.bridge.mu
+func (p *bridgePort) deliverVLANPacketLocked(pkt *PacketBuffer) {
+	bridge := p.bridge
+	tagVID, ok := frameVLAN(pkt)
+	if !ok {
+		bridge.mu.RUnlock()
+		return
+	}
+	vid, ok := p.vlans.ingressVLAN(tagVID)
+	if !ok {
+		bridge.mu.RUnlock()
+		return
+	}
+	eth := header.Ethernet(pkt.LinkHeader().Slice())
+	sourceAddress := eth.SourceAddress()
+	_, hasSourceFDB := bridge.fdbTable[BridgeFDBKey(sourceAddress)]
+	updateFDB := !header.IsMulticastEthernetAddress(sourceAddress) && !hasSourceFDB
+	if entry, exist := bridge.fdbTable[BridgeFDBKey(eth.DestinationAddress())]; !exist {
+		for _, port := range bridge.ports {
+			if p != port {
+				bridge.forwardVLANLocked(port, pkt, vid)
+			}
+		}
+	} else if entry.port != p {
+		bridge.forwardVLANLocked(entry.port, pkt, vid)
+	}
+	localPkt, protocol := bridge.localVLANPacketLocked(pkt, vid)
+	d := bridge.dispatcher
+	bridge.mu.RUnlock()
+
+	if updateFDB {
+		bridge.mu.Lock()
+		bridge.addFDBEntryLocked(sourceAddress, p, 0)
+		bridge.mu.Unlock()
+	}
+	if localPkt != nil {
+		if d != nil {
+			d.DeliverNetworkPacket(protocol, localPkt)
+		}
+		localPkt.DecRef()
+	}
+}
+
 // NewBridgeEndpoint creates a new bridge endpoint.
 func NewBridgeEndpoint(mtu uint32) *BridgeEndpoint {
 	b := &BridgeEndpoint{
-		mtu:  mtu,
-		addr: tcpip.GetRandMacAddr(),
+		mtu:   mtu,
+		addr:  tcpip.GetRandMacAddr(),
+		vlans: defaultBridgeVLANs(),
 	}
 	b.ports = make(map[tcpip.NICID]*bridgePort)
 	b.fdbTable = make(map[BridgeFDBKey]BridgeFDBEntry)
@@ -134,7 +188,14 @@ type BridgeEndpoint struct {
 	// +checklocks:mu
 	mtu uint32
 	// +checklocks:mu
-	fdbTable        map[BridgeFDBKey]BridgeFDBEntry
+	fdbTable map[BridgeFDBKey]BridgeFDBEntry
+	// +checklocks:mu
+	vlanFiltering bool
+	// vlans holds the VLANs of the bridge itself, which are used when VLAN
+	// filtering is enabled.
+	//
+	// +checklocks:mu
+	vlans           bridgeVLANs
 	maxHeaderLength atomicbitops.Uint32
 }
 
@@ -145,8 +206,21 @@ func (b *BridgeEndpoint) WritePackets(pkts PacketBufferList) (int, tcpip.Error)
 
 	pktsSlice := pkts.AsSlice()
 	n := len(pktsSlice)
+	for _, pkt := range pktsSlice {
+		// Packets that hold a whole frame, such as the frames of VLAN
+		// sub-interfaces and packet sockets, are forwarded as is.
+		if len(pkt.LinkHeader().Slice()) != 0 || b.vlanFiltering {
+			b.writeFrameLocked(pkt)
+		}
+	}
+	if b.vlanFiltering {
+		return n, nil
+	}
 	for _, p := range b.ports {
 		for _, pkt := range pktsSlice {
+			if len(pkt.LinkHeader().Slice()) != 0 {
+				continue
+			}
 			// In order to properly loop back to the inbound side we must create a
 			// fresh packet that only contains the underlying payload with no headers
 			// or struct fields set.
@@ -164,6 +238,50 @@ func (b *BridgeEndpoint) WritePackets(pkts PacketBufferList) (int, tcpip.Error)
 	return n, nil
 }
 
+// writeFrameLocked sends the packet pkt written by the bridge itself through
+// its ports as an Ethernet frame.
+//
+// +checklocksread:b.mu
+func (b *BridgeEndpoint) writeFrameLocked(pkt *PacketBuffer) {
+	var framePkt *PacketBuffer
+	if len(pkt.LinkHeader().Slice()) != 0 {
+		framePkt = pkt.IncRef()
+	} else {
+		frame := prependEthernetHeader(pkt.ToBuffer(), header.EthernetFields{
+			SrcAddr: pkt.EgressRoute.LocalLinkAddress,
+			DstAddr: pkt.EgressRoute.RemoteLinkAddress,
+			Type:    pkt.NetworkProtocolNumber,
+		}, header.VLANFields{}, false /* tagged */)
+		framePkt = newFramePacket(frame, 0)
+		framePkt.NetworkProtocolNumber = pkt.NetworkProtocolNumber
+	}
+	defer framePkt.DecRef()
+
+	if !b.vlanFiltering {
+		for _, p := range b.ports {
+			newPkt := NewPacketBuffer(PacketBufferOptions{
+				Payload:            framePkt.ToBuffer(),
+				ReserveHeaderBytes: int(p.nic.MaxHeaderLength()),
+			})
+			newPkt.NetworkProtocolNumber = framePkt.NetworkProtocolNumber
+			p.nic.writeRawPacketWithLinkHeaderInPayload(newPkt)
+			newPkt.DecRef()
+		}
+		return
+	}
+	tagVID, ok := frameVLAN(framePkt)
+	if !ok {
+		return
+	}
+	vid, ok := b.vlans.ingressVLAN(tagVID)
+	if !ok {
+		return
+	}
+	for _, p := range b.ports {
+		b.forwardVLANLocked(p, framePkt, vid)
+	}
+}
+
 // AddNIC adds the specified NIC to the bridge.
 func (b *BridgeEndpoint) AddNIC(n *nic) tcpip.Error {
 	b.mu.Lock()
@@ -172,6 +290,7 @@ func (b *BridgeEndpoint) AddNIC(n *nic) tcpip.Error {
 	port := &bridgePort{
 		nic:    n,
 		bridge: b,
+		vlans:  defaultBridgeVLANs(),
 	}
 	n.NetworkLinkEndpoint.Attach(port)
 	b.ports[n.id] = port
@@ -273,8 +392,9 @@ func (b *BridgeEndpoint) AddHeader(pkt *PacketBuffer) {
 }
 
 // ParseHeader implements stack.LinkEndpoint.ParseHeader.
-func (b *BridgeEndpoint) ParseHeader(*PacketBuffer) bool {
-	return true
+func (b *BridgeEndpoint) ParseHeader(pkt *PacketBuffer) bool {
+	_, ok := pkt.LinkHeader().Consume(header.EthernetMinimumSize)
+	return ok
 }
 
 // Close implements stack.LinkEndpoint.Close.
@@ -15,6 +15,7 @@
 package bridge_test
 
 import (
+	"bytes"
 	"os"
 	"testing"
 	"time"
@@ -254,6 +255,150 @@ func TestBridgeFDB(t *testing.T) {
 	}
 }
 
+// newVLANFrame returns an Ethernet frame with a tag of the VLAN vid, or without
+// a tag if vid is zero.
+func newVLANFrame(src, dst tcpip.LinkAddress, vid uint16, proto tcpip.NetworkProtocolNumber, payload []byte) []byte {
+	fields := header.EthernetFields{SrcAddr: src, DstAddr: dst, Type: proto}
+	hdrLen := header.EthernetMinimumSize
+	if vid != 0 {
+		fields.Type = header.EthernetProtocol8021Q
+		hdrLen += header.VLANHeaderSize
+	}
+	frame := make([]byte, hdrLen+len(payload))
+	header.Ethernet(frame).Encode(&fields)
+	if vid != 0 {
+		header.VLAN(frame[header.EthernetMinimumSize:]).Encode(&header.VLANFields{VID: vid, Type: proto})
+	}
+	copy(frame[hdrLen:], payload)
+	return frame
+}
+
+// The test verifies that a bridge with VLAN filtering only forwards frames
+// between the ports of their VLAN, and adds or removes their tags according
+// to the VLANs of the port that sends them.
+func TestBridgeVLANFiltering(t *testing.T) {
+	const (
+		channelLinkAddr1 = tcpip.LinkAddress("\x02\x02\x03\x04\x05\x04")
+		channelLinkAddr2 = tcpip.LinkAddress("\x02\x02\x03\x04\x05\x05")
+		remoteLinkAddr   = tcpip.LinkAddress("\x02\x02\x03\x04\x05\x08")
+		bridgeLinkAddr   = tcpip.LinkAddress("\x02\x02\x03\x04\x05\x09")
+
+		netProto = 55
+		nicID1   = 4
+		nicID2   = 5
+		bridgeID = 9
+		vid      = 100
+	)
+	ch1 := channel.New(1, header.EthernetMinimumSize, channelLinkAddr1)
+	ch2 := channel.New(1, header.EthernetMinimumSize, channelLinkAddr2)
+	bridgeEndpoint := stack.NewBridgeEndpoint(1500)
+	bridgeEndpoint.SetLinkAddress(bridgeLinkAddr)
+	s := stack.New(stack.Options{})
+
+	if err := s.CreateNIC(bridgeID, bridgeEndpoint); err != nil {
+		t.Fatalf("s.CreateNIC(%d, _): %s", bridgeID, err)
+	}
+	for _, nic := range []struct {
+		id tcpip.NICID
+		ep *channel.Endpoint
+	}{{nicID1, ch1}, {nicID2, ch2}} {
+		if err := s.CreateNIC(nic.id, ethernet.New(nic.ep)); err != nil {
+			t.Fatalf("s.CreateNIC(%d, _): %s", nic.id, err)
+		}
+		if err := s.SetNICCoordinator(nic.id, bridgeID); err != nil {
+			t.Fatalf("s.SetNICCoordinator(%d, %d): %s", nic.id, bridgeID, err)
+		}
+		if err := s.RemoveBridgeVLAN(nic.id, 1); err != nil {
+			t.Fatalf("s.RemoveBridgeVLAN(%d, 1): %s", nic.id, err)
+		}
+	}
+	// The first port is a trunk port, and the second one is an access port
+	// of the VLAN.
+	if err := s.AddBridgeVLAN(nicID1, vid, stack.BridgeVLANFlags{}); err != nil {
+		t.Fatalf("s.AddBridgeVLAN(%d, %d, {}): %s", nicID1, vid, err)
+	}
+	flags := stack.BridgeVLANFlags{PVID: true, Untagged: true}
+	if err := s.AddBridgeVLAN(nicID2, vid, flags); err != nil {
+		t.Fatalf("s.AddBridgeVLAN(%d, %d, %+v): %s", nicID2, vid, flags, err)
+	}
+	if err := s.SetBridgeVLANFiltering(bridgeID, true); err != nil {
+		t.Fatalf("s.SetBridgeVLANFiltering(%d, true): %s", bridgeID, err)
+	}
+	if got, err := bridgeEndpoint.VLANs(nicID2); err != nil || len(got) != 1 || got[vid] != flags {
+		t.Errorf("bridgeEndpoint.VLANs(%d) = (%v, %v), want = ({%d: %+v}, nil)", nicID2, got, err, vid, flags)
+	}
+
+	payload := []byte{1, 2, 3, 4}
+	tests := []struct {
+		name   string
+		in     *channel.Endpoint
+		out    *channel.Endpoint
+		src    tcpip.LinkAddress
+		inVID  uint16
+		outVID uint16
+		drop   bool
+	}{
+		{
+			name:   "tagged to untagged",
+			in:     ch1,
+			out:    ch2,
+			src:    channelLinkAddr1,
+			inVID:  vid,
+			outVID: 0,
+		},
+		{
+			name:   "untagged to tagged",
+			in:     ch2,
+			out:    ch1,
+			src:    channelLinkAddr2,
+			inVID:  0,
+			outVID: vid,
+		},
+		{
+			name:  "other VLAN",
+			in:    ch1,
+			out:   ch2,
+			src:   channelLinkAddr1,
+			inVID: vid + 1,
+			drop:  true,
+		},
+		{
+			name: "untagged without PVID",
+			in:   ch1,
+			out:  ch2,
+			src:  channelLinkAddr1,
+			drop: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pkt := stack.NewPacketBuffer(stack.PacketBufferOptions{
+				Payload: buffer.MakeWithData(newVLANFrame(test.src, remoteLinkAddr, test.inVID, netProto, payload)),
+			})
+			test.in.InjectInbound(0, pkt)
+			pkt.DecRef()
+
+			out := test.out.Read()
+			if test.drop {
+				if out != nil {
+					out.DecRef()
+					t.Fatal("got a forwarded packet, want none")
+				}
+				return
+			}
+			if out == nil {
+				t.Fatal("expected to read a packet")
+			}
+			frame := out.ToBuffer()
+			out.DecRef()
+			if got, want := frame.Flatten(), newVLANFrame(test.src, remoteLinkAddr, test.outVID, netProto, payload); !bytes.Equal(got, want) {
+				t.Errorf("got frame = %x, want = %x", got, want)
+			}
+			frame.Release()
+		})
+	}
+}
+
 func TestSetCoordinator(t *testing.T) {
 	const (
 		bridgeLinkAddr = tcpip.LinkAddress("\x02\x02\x03\x04\x05\x08")
@@ -0,0 +1,331 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+// bridgeDefaultPVID is the VLAN that bridge ports and the bridge itself are
+// members of when they are created, like the default_pvid of Linux bridges.
+const bridgeDefaultPVID = 1
+
+// BridgeVLANFlags holds the settings of a VLAN of a bridge port.
+type BridgeVLANFlags struct {
+	// PVID is true if untagged frames received by the port belong to the
+	// VLAN.
+	PVID bool
+
+	// Untagged is true if the frames of the VLAN are sent by the port
+	// without a tag.
+	Untagged bool
+}
+
+// bridgeVLANs holds the VLANs of a bridge port or of the bridge itself.
+//
+// +stateify savable
+type bridgeVLANs struct {
+	// pvid is the VLAN of untagged frames received by the port, or zero if
+	// they are dropped.
+	pvid uint16
+
+	// vids maps the VLANs of the port to whether their frames are sent
+	// untagged.
+	vids map[uint16]bool
+}
+
+func defaultBridgeVLANs() bridgeVLANs {
+	return bridgeVLANs{
+		pvid: bridgeDefaultPVID,
+		vids: map[uint16]bool{bridgeDefaultPVID: true},
+	}
+}
+
+func (v *bridgeVLANs) add(vid uint16, flags BridgeVLANFlags) {
+	if v.vids == nil {
+		v.vids = make(map[uint16]bool)
+	}
+	v.vids[vid] = flags.Untagged
+	if flags.PVID {
+		v.pvid = vid
+	} else if v.pvid == vid {
+		v.pvid = 0
+	}
+}
+
+func (v *bridgeVLANs) remove(vid uint16) {
+	delete(v.vids, vid)
+	if v.pvid == vid {
+		v.pvid = 0
+	}
+}
+
+func (v *bridgeVLANs) flags() map[uint16]BridgeVLANFlags {
+	flags := make(map[uint16]BridgeVLANFlags, len(v.vids))
+	for vid, untagged := range v.vids {
+		flags[vid] = BridgeVLANFlags{PVID: vid == v.pvid, Untagged: untagged}
+	}
+	return flags
+}
+
+// ingressVLAN returns the VLAN of a frame received by the port, whose tag holds
+// the VLAN identifier tagVID, or zero if the frame is untagged or priority
+// tagged. It returns false if the frame must be dropped.
+func (v *bridgeVLANs) ingressVLAN(tagVID uint16) (uint16, bool) {
+	vid := tagVID
+	if vid == 0 {
+		vid = v.pvid
+	}
+	if _, ok := v.vids[vid]; !ok || vid == 0 {
+		return 0, false
+	}
+	return vid, true
+}
+
+// frameVLAN returns the VLAN identifier of the tag of the Ethernet frame of
+// pkt, or zero if the frame is untagged. It returns false if the frame is
+// malformed.
+func frameVLAN(pkt *PacketBuffer) (uint16, bool) {
+	eth := header.Ethernet(pkt.LinkHeader().Slice())
+	if len(eth) < header.EthernetMinimumSize || eth.Type() != header.EthernetProtocol8021Q {
+		return 0, true
+	}
+	v, ok := pkt.Data().PullUp(header.VLANHeaderSize)
+	if !ok {
+		return 0, false
+	}
+	return header.VLAN(v).VID(), true
+}
+
+// vlanFrame returns a copy of the Ethernet frame of pkt, which belongs to the
+// VLAN vid, with a tag if tagged is true and without one otherwise.
+func vlanFrame(pkt *PacketBuffer, vid uint16, tagged bool) buffer.Buffer {
+	eth := header.Ethernet(pkt.LinkHeader().Slice())
+	frame := pkt.ToBuffer()
+	fields := header.EthernetFields{
+		SrcAddr: eth.SourceAddress(),
+		DstAddr: eth.DestinationAddress(),
+		Type:    eth.Type(),
+	}
+	tagFields := header.VLANFields{VID: vid}
+	hdrLen := header.EthernetMinimumSize
+	if fields.Type == header.EthernetProtocol8021Q {
+		v, _ := frame.PullUp(header.EthernetMinimumSize, header.VLANHeaderSize)
+		tag := header.VLAN(v.AsSlice())
+		if tagged && tag.VID() == vid {
+			return frame
+		}
+		fields.Type = tag.Type()
+		tagFields.Priority = tag.Priority()
+		hdrLen += header.VLANHeaderSize
+	} else if !tagged {
+		return frame
+	}
+	frame.TrimFront(int64(hdrLen))
+	return prependEthernetHeader(frame, fields, tagFields, tagged)
+}
+
+// prependEthernetHeader returns payload preceded by an Ethernet header with
+// fields, followed by a tag with tagFields if tagged is true.
+func prependEthernetHeader(payload buffer.Buffer, fields header.EthernetFields, tagFields header.VLANFields, tagged bool) buffer.Buffer {
+	hdrLen := header.EthernetMinimumSize
+	if tagged {
+		hdrLen += header.VLANHeaderSize
+		tagFields.Type = fields.Type
+		fields.Type = header.EthernetProtocol8021Q
+	}
+	hdr := make([]byte, hdrLen)
+	header.Ethernet(hdr).Encode(&fields)
+	if tagged {
+		header.VLAN(hdr[header.EthernetMinimumSize:]).Encode(&tagFields)
+	}
+	frame := buffer.MakeWithData(hdr)
+	frame.Merge(&payload)
+	return frame
+}
+
+// newFramePacket returns a packet that holds frame, with its Ethernet header
+// parsed.
+func newFramePacket(frame buffer.Buffer, reserve int) *PacketBuffer {
+	pkt := NewPacketBuffer(PacketBufferOptions{
+		ReserveHeaderBytes: reserve,
+		Payload:            frame,
+	})
+	pkt.LinkHeader().Consume(header.EthernetMinimumSize)
+	return pkt
+}
+
+// SetVLANFiltering enables or disables VLAN filtering. When VLAN filtering is
+// enabled, frames are only forwarded between the ports of their VLAN, and the
+// tags of forwarded frames are added or removed according to the VLANs of the
+// port that sends them.
+func (b *BridgeEndpoint) SetVLANFiltering(enabled bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.vlanFiltering = enabled
+}
+
+// VLANFiltering returns true if VLAN filtering is enabled.
+func (b *BridgeEndpoint) VLANFiltering() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.vlanFiltering
+}
+
+// vlansLocked returns the VLANs of the port id, or of the bridge itself if id
+// is zero.
+//
+// +checklocksread:b.mu
+func (b *BridgeEndpoint) vlansLocked(id tcpip.NICID) (*bridgeVLANs, tcpip.Error) {
+	if id == 0 {
+		return &b.vlans, nil
+	}
+	p, ok := b.ports[id]
+	if !ok {
+		return nil, &tcpip.ErrUnknownNICID{}
+	}
+	return &p.vlans, nil
+}
+
+// AddVLAN adds the VLAN vid to the port id, or to the bridge itself if id is
+// zero. The VLANs of the bridge itself determine the frames that the bridge
+// receives and the tags of the frames it sends.
+func (b *BridgeEndpoint) AddVLAN(id tcpip.NICID, vid uint16, flags BridgeVLANFlags) tcpip.Error {
+	if vid == 0 || vid > header.VLANMaxVID {
+		return &tcpip.ErrInvalidOptionValue{}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	vlans, err := b.vlansLocked(id)
+	if err != nil {
+		return err
+	}
+	vlans.add(vid, flags)
+	return nil
+}
+
+// RemoveVLAN removes the VLAN vid from the port id, or from the bridge itself
+// if id is zero.
+func (b *BridgeEndpoint) RemoveVLAN(id tcpip.NICID, vid uint16) tcpip.Error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	vlans, err := b.vlansLocked(id)
+	if err != nil {
+		return err
+	}
+	if _, ok := vlans.vids[vid]; !ok {
+		return &tcpip.ErrNoSuchFile{}
+	}
+	vlans.remove(vid)
+	return nil
+}
+
+// VLANs returns the VLANs of the port id, or of the bridge itself if id is
+// zero.
+func (b *BridgeEndpoint) VLANs(id tcpip.NICID) (map[uint16]BridgeVLANFlags, tcpip.Error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	vlans, err := b.vlansLocked(id)
+	if err != nil {
+		return nil, err
+	}
+	return vlans.flags(), nil
+}
+
+// forwardVLANLocked sends a copy of the frame of pkt, which belongs to the
+// VLAN vid, through port if the port is a member of the VLAN.
+//
+// +checklocksread:b.mu
+func (b *BridgeEndpoint) forwardVLANLocked(port *bridgePort, pkt *PacketBuffer, vid uint16) {
+	untagged, ok := port.vlans.vids[vid]
+	if !ok {
+		return
+	}
+	newPkt := newFramePacket(vlanFrame(pkt, vid, !untagged), int(port.nic.MaxHeaderLength()))
+	newPkt.NetworkProtocolNumber = pkt.NetworkProtocolNumber
+	port.nic.writeRawPacket(newPkt)
+	newPkt.DecRef()
+}
+
+// localVLANPacketLocked returns the packet that the bridge itself receives for
+// the frame of pkt, which belongs to the VLAN vid, along with its protocol. It
+// returns nil if the bridge isn't a member of the VLAN.
+//
+// +checklocksread:b.mu
+func (b *BridgeEndpoint) localVLANPacketLocked(pkt *PacketBuffer, vid uint16) (*PacketBuffer, tcpip.NetworkProtocolNumber) {
+	untagged, ok := b.vlans.vids[vid]
+	if !ok {
+		return nil, 0
+	}
+	newPkt := newFramePacket(vlanFrame(pkt, vid, !untagged), 0)
+	newPkt.PktType = pkt.PktType
+	return newPkt, header.Ethernet(newPkt.LinkHeader().Slice()).Type()
+}
+
+// bridgeVLANTarget returns the bridge of the NIC id, along with the ID of the
+// port that id designates, which is zero if id is the bridge itself.
+func (s *Stack) bridgeVLANTarget(id tcpip.NICID) (*BridgeEndpoint, tcpip.NICID, tcpip.Error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	n, ok := s.nics[id]
+	if !ok {
+		return nil, 0, &tcpip.ErrUnknownNICID{}
+	}
+	if b, ok := n.NetworkLinkEndpoint.(*BridgeEndpoint); ok {
+		return b, 0, nil
+	}
+	if n.Primary != nil {
+		if b, ok := n.Primary.NetworkLinkEndpoint.(*BridgeEndpoint); ok {
+			return b, id, nil
+		}
+	}
+	return nil, 0, &tcpip.ErrNotSupported{}
+}
+
+// SetBridgeVLANFiltering enables or disables VLAN filtering on the bridge NIC
+// id.
+func (s *Stack) SetBridgeVLANFiltering(id tcpip.NICID, enabled bool) tcpip.Error {
+	b, port, err := s.bridgeVLANTarget(id)
+	if err != nil {
+		return err
+	}
+	if port != 0 {
+		return &tcpip.ErrNotSupported{}
+	}
+	b.SetVLANFiltering(enabled)
+	return nil
+}
+
+// AddBridgeVLAN adds the VLAN vid to the NIC id, which is either a bridge port
+// or a bridge.
+func (s *Stack) AddBridgeVLAN(id tcpip.NICID, vid uint16, flags BridgeVLANFlags) tcpip.Error {
+	b, port, err := s.bridgeVLANTarget(id)
+	if err != nil {
+		return err
+	}
+	return b.AddVLAN(port, vid, flags)
+}
+
+// RemoveBridgeVLAN removes the VLAN vid from the NIC id, which is either a
+// bridge port or a bridge.
+func (s *Stack) RemoveBridgeVLAN(id tcpip.NICID, vid uint16) tcpip.Error {
+	b, port, err := s.bridgeVLANTarget(id)
+	if err != nil {
+		return err
+	}
+	return b.RemoveVLAN(port, vid)
+}
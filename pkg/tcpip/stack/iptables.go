@@ -215,6 +215,14 @@ func EmptyNATTable() Table {
 	}
 }
 
+// EmptyMangleTable returns a Table with no rules. The mangle table has a chain
+// for every hook.
+func EmptyMangleTable() Table {
+	return Table{
+		Rules: []Rule{},
+	}
+}
+
 // GetTable returns a table with the given id and IP version. It panics when an
 // invalid id is provided.
 func (it *IPTables) GetTable(id TableID, ipv6 bool) Table {
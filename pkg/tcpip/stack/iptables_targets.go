@@ -275,6 +275,65 @@ func (rt *RedirectTarget) Action(pkt *PacketBuffer, hook Hook, r *Route, address
 	return dnatAction(pkt, hook, r, rt.Port, address, true /* changePort */, true /* changeAddress */)
 }
 
+// TPROXYTarget steers incoming packets to a local socket without modifying
+// them, so that the socket sees the original destination. It is used with
+// sockets bound with IP_TRANSPARENT to implement transparent proxies.
+//
+// +stateify savable
+type TPROXYTarget struct {
+	// Addr is the address of the socket packets are steered to. If
+	// unspecified, the primary address of the incoming interface is used.
+	//
+	// Immutable.
+	Addr tcpip.Address
+
+	// Port is the port of the socket packets are steered to. If zero, the
+	// packet's destination port is used.
+	//
+	// Immutable.
+	Port uint16
+
+	// NetworkProtocol is the network protocol the target is used with.
+	//
+	// Immutable.
+	NetworkProtocol tcpip.NetworkProtocolNumber
+}
+
+// Action implements Target.Action.
+func (tt *TPROXYTarget) Action(pkt *PacketBuffer, hook Hook, _ *Route, addressEP AddressableEndpoint) (RuleVerdict, int) {
+	// Sanity check.
+	if tt.NetworkProtocol != pkt.NetworkProtocolNumber {
+		panic(fmt.Sprintf(
+			"TPROXYTarget.Action with NetworkProtocol %d called on packet with NetworkProtocolNumber %d",
+			tt.NetworkProtocol, pkt.NetworkProtocolNumber))
+	}
+
+	// Like Linux, only incoming packets can be steered.
+	if hook != Prerouting {
+		return RuleDrop, 0
+	}
+
+	port := tt.Port
+	if port == 0 {
+		switch pkt.TransportProtocolNumber {
+		case header.TCPProtocolNumber:
+			port = header.TCP(pkt.TransportHeader().Slice()).DestinationPort()
+		case header.UDPProtocolNumber:
+			port = header.UDP(pkt.TransportHeader().Slice()).DestinationPort()
+		default:
+			return RuleDrop, 0
+		}
+	}
+	addr := tt.Addr
+	if addr.Unspecified() {
+		// addressEP is expected to be set for the prerouting hook.
+		addr = addressEP.MainAddress().Address
+	}
+
+	pkt.SetTProxied(addr, port)
+	return RuleAccept, 0
+}
+
 // SNATTarget modifies the source port/IP in the outgoing packets.
 //
 // +stateify savable
@@ -0,0 +1,326 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+// IPVLANMode is the mode of the ipvlan interfaces of a NIC.
+type IPVLANMode int
+
+const (
+	// IPVLANModeL2 exchanges Ethernet frames with the link address of the
+	// parent NIC. The interfaces resolve their neighbors and receive
+	// multicast frames.
+	IPVLANModeL2 IPVLANMode = iota
+
+	// IPVLANModeL3 exchanges IP packets, which are routed through the
+	// parent NIC by the stack of the parent NIC.
+	IPVLANModeL3
+)
+
+// IPVLANEndpoint is the receiving side of an ipvlan interface. It is handed the
+// packets that are destined to its addresses, and in L2 mode the multicast
+// frames.
+type IPVLANEndpoint interface {
+	// HandleIPVLANPacket is called with a packet of the given protocol
+	// received by the parent NIC, or sent by another ipvlan interface of
+	// the parent NIC. The link header of the packet is parsed, and may be
+	// empty in L3 mode. The packet is still owned by the caller.
+	HandleIPVLANPacket(protocol tcpip.NetworkProtocolNumber, pkt *PacketBuffer)
+
+	// ParentRemoved is called after the parent NIC is removed. No packets
+	// are handed to the endpoint afterwards.
+	ParentRemoved()
+}
+
+// ipvlanPort holds the ipvlan interfaces of a NIC.
+//
+// +stateify savable
+type ipvlanPort struct {
+	// mode is the mode of all interfaces.
+	mode IPVLANMode
+
+	// eps holds the interfaces.
+	eps map[IPVLANEndpoint]struct{}
+
+	// addrs maps the addresses of the interfaces to them.
+	addrs map[tcpip.Address]IPVLANEndpoint
+}
+
+// RegisterIPVLANEndpoint registers ep as an ipvlan interface of the NIC parent.
+// Unlike on Linux, where the mode of all the interfaces of a NIC is changed
+// when an interface is added with another mode, all the interfaces must have
+// the same mode. A NIC with macvlan interfaces can't have ipvlan interfaces.
+func (s *Stack) RegisterIPVLANEndpoint(parent tcpip.NICID, mode IPVLANMode, ep IPVLANEndpoint) tcpip.Error {
+	s.mu.RLock()
+	_, ok := s.nics[parent]
+	s.mu.RUnlock()
+	if !ok {
+		return &tcpip.ErrUnknownNICID{}
+	}
+
+	s.upperMu.Lock()
+	defer s.upperMu.Unlock()
+	if _, ok := s.macvlanPorts[parent]; ok {
+		return &tcpip.ErrEndpointBusy{}
+	}
+	port, ok := s.ipvlanPorts[parent]
+	if !ok {
+		port = &ipvlanPort{
+			mode:  mode,
+			eps:   make(map[IPVLANEndpoint]struct{}),
+			addrs: make(map[tcpip.Address]IPVLANEndpoint),
+		}
+		if s.ipvlanPorts == nil {
+			s.ipvlanPorts = make(map[tcpip.NICID]*ipvlanPort)
+		}
+		s.ipvlanPorts[parent] = port
+	} else if port.mode != mode {
+		return &tcpip.ErrInvalidOptionValue{}
+	}
+	port.eps[ep] = struct{}{}
+	return nil
+}
+
+// UnregisterIPVLANEndpoint unregisters the ipvlan interface ep of the NIC
+// parent, and its addresses.
+func (s *Stack) UnregisterIPVLANEndpoint(parent tcpip.NICID, ep IPVLANEndpoint) {
+	s.upperMu.Lock()
+	defer s.upperMu.Unlock()
+	port, ok := s.ipvlanPorts[parent]
+	if !ok {
+		return
+	}
+	delete(port.eps, ep)
+	port.removeAddresses(ep)
+	if len(port.eps) == 0 {
+		delete(s.ipvlanPorts, parent)
+	}
+}
+
+// removeAddresses removes the addresses of ep from p.
+func (p *ipvlanPort) removeAddresses(ep IPVLANEndpoint) {
+	for addr, owner := range p.addrs {
+		if owner == ep {
+			delete(p.addrs, addr)
+		}
+	}
+}
+
+// AddIPVLANAddress adds addr to the addresses of the ipvlan interface ep of
+// the NIC parent. Like on Linux, an address can't be used by several ipvlan
+// interfaces of a NIC.
+func (s *Stack) AddIPVLANAddress(parent tcpip.NICID, ep IPVLANEndpoint, addr tcpip.Address) tcpip.Error {
+	s.upperMu.Lock()
+	defer s.upperMu.Unlock()
+	port, ok := s.ipvlanPorts[parent]
+	if !ok {
+		return &tcpip.ErrUnknownDevice{}
+	}
+	if _, ok := port.eps[ep]; !ok {
+		return &tcpip.ErrUnknownDevice{}
+	}
+	if owner, ok := port.addrs[addr]; ok && owner != ep {
+		return &tcpip.ErrDuplicateAddress{}
+	}
+	port.addrs[addr] = ep
+	return nil
+}
+
+// RemoveIPVLANAddress removes addr from the addresses of the ipvlan interface
+// ep of the NIC parent.
+func (s *Stack) RemoveIPVLANAddress(parent tcpip.NICID, ep IPVLANEndpoint, addr tcpip.Address) {
+	s.upperMu.Lock()
+	defer s.upperMu.Unlock()
+	if port, ok := s.ipvlanPorts[parent]; ok && port.addrs[addr] == ep {
+		delete(port.addrs, addr)
+	}
+}
+
+// RemoveIPVLANAddresses removes all the addresses of the ipvlan interface ep
+// of the NIC parent.
+func (s *Stack) RemoveIPVLANAddresses(parent tcpip.NICID, ep IPVLANEndpoint) {
+	s.upperMu.Lock()
+	defer s.upperMu.Unlock()
+	if port, ok := s.ipvlanPorts[parent]; ok {
+		port.removeAddresses(ep)
+	}
+}
+
+// ipvlanDestination returns the address that ipvlan interfaces demultiplex a
+// packet of the given protocol by, like drivers/net/ipvlan/ipvlan_core.c:
+// ipvlan_get_L3_hdr. ARP packets are demultiplexed by their target.
+func ipvlanDestination(protocol tcpip.NetworkProtocolNumber, pkt *PacketBuffer) (tcpip.Address, bool) {
+	switch protocol {
+	case header.IPv4ProtocolNumber:
+		v, ok := pkt.Data().PullUp(header.IPv4MinimumSize)
+		if !ok {
+			return tcpip.Address{}, false
+		}
+		return header.IPv4(v).DestinationAddress(), true
+	case header.IPv6ProtocolNumber:
+		v, ok := pkt.Data().PullUp(header.IPv6MinimumSize)
+		if !ok {
+			return tcpip.Address{}, false
+		}
+		return header.IPv6(v).DestinationAddress(), true
+	case header.ARPProtocolNumber:
+		v, ok := pkt.Data().PullUp(header.ARPSize)
+		if !ok {
+			return tcpip.Address{}, false
+		}
+		arp := header.ARP(v)
+		if !arp.IsValid() {
+			return tcpip.Address{}, false
+		}
+		return tcpip.AddrFrom4Slice(arp.ProtocolAddressTarget()), true
+	default:
+		return tcpip.Address{}, false
+	}
+}
+
+// ipvlanEndpoint returns the ipvlan interface of the NIC parent that a packet
+// of the given protocol is destined to.
+func (s *Stack) ipvlanEndpoint(parent tcpip.NICID, protocol tcpip.NetworkProtocolNumber, pkt *PacketBuffer) (IPVLANEndpoint, IPVLANMode, bool) {
+	dst, ok := ipvlanDestination(protocol, pkt)
+	if !ok {
+		return nil, 0, false
+	}
+	s.upperMu.RLock()
+	defer s.upperMu.RUnlock()
+	port, ok := s.ipvlanPorts[parent]
+	if !ok {
+		return nil, 0, false
+	}
+	ep, ok := port.addrs[dst]
+	return ep, port.mode, ok
+}
+
+// ipvlanFlood hands a multicast frame to the L2 mode ipvlan interfaces of the
+// NIC parent other than from.
+func (s *Stack) ipvlanFlood(parent tcpip.NICID, from IPVLANEndpoint, protocol tcpip.NetworkProtocolNumber, pkt *PacketBuffer) {
+	var eps []IPVLANEndpoint
+	s.upperMu.RLock()
+	if port, ok := s.ipvlanPorts[parent]; ok && port.mode == IPVLANModeL2 {
+		for ep := range port.eps {
+			if ep != from {
+				eps = append(eps, ep)
+			}
+		}
+	}
+	s.upperMu.RUnlock()
+	for _, ep := range eps {
+		ep.HandleIPVLANPacket(protocol, pkt)
+	}
+}
+
+// deliverIPVLANPacket delivers a packet received by the NIC parent to its
+// ipvlan interfaces, like drivers/net/ipvlan/ipvlan_core.c:
+// ipvlan_handle_frame. It returns true if the packet is consumed, and false if
+// the parent must handle it too.
+func (s *Stack) deliverIPVLANPacket(parent tcpip.NICID, protocol tcpip.NetworkProtocolNumber, pkt *PacketBuffer) bool {
+	// Parents of ipvlan interfaces are Ethernet NICs.
+	eth := header.Ethernet(pkt.LinkHeader().Slice())
+	if len(eth) < header.EthernetMinimumSize {
+		return false
+	}
+	s.upperMu.RLock()
+	_, ok := s.ipvlanPorts[parent]
+	s.upperMu.RUnlock()
+	if !ok {
+		return false
+	}
+	if header.IsMulticastEthernetAddress(eth.DestinationAddress()) {
+		s.ipvlanFlood(parent, nil /* from */, protocol, pkt)
+		return false
+	}
+	ep, mode, ok := s.ipvlanEndpoint(parent, protocol, pkt)
+	if !ok || (mode == IPVLANModeL3 && protocol == header.ARPProtocolNumber) {
+		// L3 mode interfaces don't resolve neighbors.
+		return false
+	}
+	ep.HandleIPVLANPacket(protocol, pkt)
+	return true
+}
+
+// ForwardIPVLANPacket handles a packet of the given protocol sent by the
+// ipvlan interface from of the NIC parent. The link header of the packet must
+// be parsed in L2 mode. Packets destined to the other ipvlan interfaces are
+// handed to them, and in L2 mode, multicast frames are handed to all of them
+// and unicast frames destined to the link address of parent and to none of
+// them are received by parent, like in drivers/net/ipvlan/ipvlan_core.c:
+// ipvlan_xmit_mode_l2. It returns true if the packet is consumed, and false if
+// it must be sent by the parent NIC.
+func (s *Stack) ForwardIPVLANPacket(parent tcpip.NICID, from IPVLANEndpoint, protocol tcpip.NetworkProtocolNumber, pkt *PacketBuffer) bool {
+	eth := header.Ethernet(pkt.LinkHeader().Slice())
+	l2 := len(eth) >= header.EthernetMinimumSize
+	if l2 && header.IsMulticastEthernetAddress(eth.DestinationAddress()) {
+		s.ipvlanFlood(parent, from, protocol, pkt)
+		return false
+	}
+	if l2 && eth.DestinationAddress() != eth.SourceAddress() {
+		return false
+	}
+	if ep, _, ok := s.ipvlanEndpoint(parent, protocol, pkt); ok {
+		if ep != from {
+			ep.HandleIPVLANPacket(protocol, pkt)
+		}
+		return true
+	}
+	if !l2 {
+		return false
+	}
+
+	// The frame is destined to parent.
+	s.mu.RLock()
+	nic, ok := s.nics[parent]
+	s.mu.RUnlock()
+	if !ok {
+		return true
+	}
+	rcvPkt := NewPacketBuffer(PacketBufferOptions{
+		Payload: BufferSince(pkt.LinkHeader()),
+	})
+	defer rcvPkt.DecRef()
+	rcvPkt.LinkHeader().Consume(len(eth))
+	rcvPkt.PktType = tcpip.PacketHost
+	nic.DeliverNetworkPacket(protocol, rcvPkt)
+	return true
+}
+
+// removeUpperEndpoints unregisters the macvlan and ipvlan interfaces of the NIC
+// parent and notifies them that it was removed.
+func (s *Stack) removeUpperEndpoints(parent tcpip.NICID) {
+	var eps []interface{ ParentRemoved() }
+	s.upperMu.Lock()
+	for _, entry := range s.macvlanPorts[parent] {
+		eps = append(eps, entry.ep)
+	}
+	delete(s.macvlanPorts, parent)
+	if port, ok := s.ipvlanPorts[parent]; ok {
+		for ep := range port.eps {
+			eps = append(eps, ep)
+		}
+		delete(s.ipvlanPorts, parent)
+	}
+	s.upperMu.Unlock()
+
+	for _, ep := range eps {
+		ep.ParentRemoved()
+	}
+}
@@ -0,0 +1,237 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+// MACVLANMode is the mode of a macvlan interface, which determines how it can
+// communicate with the other macvlan interfaces of its parent NIC.
+type MACVLANMode int
+
+const (
+	// MACVLANModePrivate forbids communication with the other macvlan
+	// interfaces, even if frames are sent back by the network.
+	MACVLANModePrivate MACVLANMode = iota
+
+	// MACVLANModeVEPA sends all frames to the network, which may send
+	// them back to the other macvlan interfaces.
+	MACVLANModeVEPA
+
+	// MACVLANModeBridge switches the frames to the other bridge mode
+	// macvlan interfaces locally.
+	MACVLANModeBridge
+)
+
+// MACVLANEndpoint is the receiving side of a macvlan interface. It is handed
+// the frames that are destined to its link address or that are multicast.
+type MACVLANEndpoint interface {
+	// HandleMACVLANPacket is called with a frame received by the parent
+	// NIC, or sent by another macvlan interface of the parent NIC. The
+	// Ethernet header of the frame is parsed. The packet is still owned by
+	// the caller.
+	HandleMACVLANPacket(pkt *PacketBuffer)
+
+	// ParentRemoved is called after the parent NIC is removed. No frames
+	// are handed to the endpoint afterwards.
+	ParentRemoved()
+}
+
+// macvlanEntry is a macvlan interface of a NIC.
+//
+// +stateify savable
+type macvlanEntry struct {
+	ep   MACVLANEndpoint
+	mode MACVLANMode
+}
+
+// RegisterMACVLANEndpoint registers ep to receive the frames destined to addr
+// that are received by the NIC parent. Like on Linux, addr must not be used by
+// the parent or by another macvlan interface, and a NIC with ipvlan interfaces
+// can't have macvlan interfaces.
+func (s *Stack) RegisterMACVLANEndpoint(parent tcpip.NICID, addr tcpip.LinkAddress, mode MACVLANMode, ep MACVLANEndpoint) tcpip.Error {
+	if !header.IsValidUnicastEthernetAddress(addr) {
+		return &tcpip.ErrInvalidOptionValue{}
+	}
+	s.mu.RLock()
+	nic, ok := s.nics[parent]
+	s.mu.RUnlock()
+	if !ok {
+		return &tcpip.ErrUnknownNICID{}
+	}
+	if nic.LinkAddress() == addr {
+		return &tcpip.ErrDuplicateAddress{}
+	}
+
+	s.upperMu.Lock()
+	defer s.upperMu.Unlock()
+	if _, ok := s.ipvlanPorts[parent]; ok {
+		return &tcpip.ErrEndpointBusy{}
+	}
+	if _, ok := s.macvlanPorts[parent][addr]; ok {
+		return &tcpip.ErrDuplicateAddress{}
+	}
+	if s.macvlanPorts == nil {
+		s.macvlanPorts = make(map[tcpip.NICID]map[tcpip.LinkAddress]macvlanEntry)
+	}
+	port, ok := s.macvlanPorts[parent]
+	if !ok {
+		port = make(map[tcpip.LinkAddress]macvlanEntry)
+		s.macvlanPorts[parent] = port
+	}
+	port[addr] = macvlanEntry{ep: ep, mode: mode}
+	return nil
+}
+
+// UnregisterMACVLANEndpoint unregisters the macvlan interface of the NIC
+// parent with the address addr.
+func (s *Stack) UnregisterMACVLANEndpoint(parent tcpip.NICID, addr tcpip.LinkAddress) {
+	s.upperMu.Lock()
+	defer s.upperMu.Unlock()
+	port := s.macvlanPorts[parent]
+	delete(port, addr)
+	if len(port) == 0 {
+		delete(s.macvlanPorts, parent)
+	}
+}
+
+// SetMACVLANAddress changes the address of the macvlan interface of the NIC
+// parent from oldAddr to newAddr. It may be called with the stack locked, so
+// it doesn't check that newAddr isn't the address of parent.
+func (s *Stack) SetMACVLANAddress(parent tcpip.NICID, oldAddr, newAddr tcpip.LinkAddress) tcpip.Error {
+	if oldAddr == newAddr {
+		return nil
+	}
+	if !header.IsValidUnicastEthernetAddress(newAddr) {
+		return &tcpip.ErrInvalidOptionValue{}
+	}
+	s.upperMu.Lock()
+	defer s.upperMu.Unlock()
+	port := s.macvlanPorts[parent]
+	entry, ok := port[oldAddr]
+	if !ok {
+		return &tcpip.ErrUnknownDevice{}
+	}
+	if _, ok := port[newAddr]; ok {
+		return &tcpip.ErrDuplicateAddress{}
+	}
+	delete(port, oldAddr)
+	port[newAddr] = entry
+	return nil
+}
+
+// deliverMACVLANPacket delivers a frame received by the NIC parent to its
+// macvlan interfaces, like drivers/net/macvlan.c:macvlan_handle_frame. It
+// returns true if the frame is consumed, and false if the parent must handle
+// it too.
+func (s *Stack) deliverMACVLANPacket(parent tcpip.NICID, pkt *PacketBuffer) bool {
+	eth := header.Ethernet(pkt.LinkHeader().Slice())
+	if len(eth) < header.EthernetMinimumSize {
+		return false
+	}
+	dst, src := eth.DestinationAddress(), eth.SourceAddress()
+
+	s.upperMu.RLock()
+	port, ok := s.macvlanPorts[parent]
+	if !ok {
+		s.upperMu.RUnlock()
+		return false
+	}
+	// Frames from the other macvlan interfaces were sent back by the
+	// network.
+	from, fromSibling := port[src]
+	if header.IsMulticastEthernetAddress(dst) {
+		var eps []MACVLANEndpoint
+		for addr, to := range port {
+			if fromSibling && !macvlanReflectable(addr, src, from.mode, to.mode) {
+				continue
+			}
+			eps = append(eps, to.ep)
+		}
+		s.upperMu.RUnlock()
+		for _, ep := range eps {
+			ep.HandleMACVLANPacket(pkt)
+		}
+		return false
+	}
+	to, ok := port[dst]
+	s.upperMu.RUnlock()
+	if !ok {
+		return false
+	}
+	if !fromSibling || macvlanReflectable(dst, src, from.mode, to.mode) {
+		to.ep.HandleMACVLANPacket(pkt)
+	}
+	return true
+}
+
+// macvlanReflectable returns true if a frame sent by the macvlan interface
+// src, which the network sent back, can be received by the macvlan interface
+// dst.
+func macvlanReflectable(dst, src tcpip.LinkAddress, srcMode, dstMode MACVLANMode) bool {
+	switch {
+	case dst == src:
+		return false
+	case srcMode == MACVLANModePrivate || dstMode == MACVLANModePrivate:
+		return false
+	case srcMode == MACVLANModeBridge && dstMode == MACVLANModeBridge:
+		// The frame was switched locally.
+		return false
+	default:
+		return true
+	}
+}
+
+// ForwardMACVLANPacket switches a frame sent by the bridge mode macvlan
+// interface with the address src of the NIC parent to the other bridge mode
+// macvlan interfaces of parent that it is destined to. The Ethernet header of
+// the frame must be parsed. It returns true if the frame is consumed, and
+// false if it must be sent by the parent NIC.
+func (s *Stack) ForwardMACVLANPacket(parent tcpip.NICID, src tcpip.LinkAddress, pkt *PacketBuffer) bool {
+	eth := header.Ethernet(pkt.LinkHeader().Slice())
+	if len(eth) < header.EthernetMinimumSize {
+		return false
+	}
+	dst := eth.DestinationAddress()
+
+	s.upperMu.RLock()
+	port := s.macvlanPorts[parent]
+	if from, ok := port[src]; !ok || from.mode != MACVLANModeBridge {
+		s.upperMu.RUnlock()
+		return false
+	}
+	if header.IsMulticastEthernetAddress(dst) {
+		var eps []MACVLANEndpoint
+		for addr, to := range port {
+			if addr != src && to.mode == MACVLANModeBridge {
+				eps = append(eps, to.ep)
+			}
+		}
+		s.upperMu.RUnlock()
+		for _, ep := range eps {
+			ep.HandleMACVLANPacket(pkt)
+		}
+		return false
+	}
+	to, ok := port[dst]
+	s.upperMu.RUnlock()
+	if !ok || to.mode != MACVLANModeBridge {
+		return false
+	}
+	to.ep.HandleMACVLANPacket(pkt)
+	return true
+}
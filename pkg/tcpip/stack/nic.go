@@ -80,6 +80,10 @@ type nic struct {
 	// +checklocks:packetEPsMu
 	packetEPs map[tcpip.NetworkProtocolNumber]*packetEndpointList
 
+	// qDiscMu protects qDisc, which can be replaced while packets are sent.
+	qDiscMu qDiscRWMutex `state:"nosave"`
+
+	// +checklocks:qDiscMu
 	qDisc QueueingDiscipline
 
 	// deliverLinkPackets specifies whether this NIC delivers packets to
@@ -337,7 +341,9 @@ func (n *nic) remove(closeLinkEndpoint bool) (func(), tcpip.Error) {
 
 	var deferAct func()
 	// Prevent packets from going down to the link before shutting the link down.
+	n.qDiscMu.RLock()
 	n.qDisc.Close()
+	n.qDiscMu.RUnlock()
 	n.NetworkLinkEndpoint.Attach(nil)
 	if closeLinkEndpoint {
 		ep := n.NetworkLinkEndpoint
@@ -429,7 +435,12 @@ func (n *nic) writeRawPacket(pkt *PacketBuffer) tcpip.Error {
 		n.DeliverLinkPacket(pkt.NetworkProtocolNumber, pkt)
 	}
 
-	if err := n.qDisc.WritePacket(pkt); err != nil {
+	// The lock isn't held while the packet is written, because writing may
+	// send packets through this NIC again.
+	n.qDiscMu.RLock()
+	qDisc := n.qDisc
+	n.qDiscMu.RUnlock()
+	if err := qDisc.WritePacket(pkt); err != nil {
 		if _, ok := err.(*tcpip.ErrNoBufferSpace); ok {
 			n.stats.txPacketsDroppedNoBufferSpace.Increment()
 		}
@@ -477,6 +488,10 @@ const (
 	// promiscuous indicates that the NIC's promiscuous flag should be observed
 	// when getting a NIC's address endpoint.
 	promiscuous
+
+	// transparent indicates that a temporary address endpoint should always
+	// be created, as for sockets bound with IP_TRANSPARENT.
+	transparent
 )
 
 func (n *nic) getAddress(protocol tcpip.NetworkProtocolNumber, dst tcpip.Address) AssignableAddressEndpoint {
@@ -494,8 +509,8 @@ func (n *nic) hasAddress(protocol tcpip.NetworkProtocolNumber, addr tcpip.Addres
 }
 
 // findEndpoint finds the endpoint, if any, with the given address.
-func (n *nic) findEndpoint(protocol tcpip.NetworkProtocolNumber, address tcpip.Address, peb PrimaryEndpointBehavior) AssignableAddressEndpoint {
-	return n.getAddressOrCreateTemp(protocol, address, peb, spoofing)
+func (n *nic) findEndpoint(protocol tcpip.NetworkProtocolNumber, address tcpip.Address, peb PrimaryEndpointBehavior, tempRef getAddressBehaviour) AssignableAddressEndpoint {
+	return n.getAddressOrCreateTemp(protocol, address, peb, tempRef)
 }
 
 // getAddressEpOrCreateTemp returns the address endpoint for the given protocol
@@ -514,6 +529,8 @@ func (n *nic) getAddressOrCreateTemp(protocol tcpip.NetworkProtocolNumber, addre
 		spoofingOrPromiscuous = n.Spoofing()
 	case promiscuous:
 		spoofingOrPromiscuous = n.Promiscuous()
+	case transparent:
+		spoofingOrPromiscuous = true
 	}
 	return n.getAddressOrCreateTempInner(protocol, address, spoofingOrPromiscuous, peb)
 }
@@ -739,6 +756,22 @@ func (n *nic) leaveGroup(protocol tcpip.NetworkProtocolNumber, addr tcpip.Addres
 	return gep.LeaveGroup(addr)
 }
 
+// changeGroupSourceFilter replaces the source filter of a join of the given
+// multicast address.
+func (n *nic) changeGroupSourceFilter(protocol tcpip.NetworkProtocolNumber, addr tcpip.Address, oldFilter, newFilter tcpip.MulticastSourceFilter) tcpip.Error {
+	ep := n.getNetworkEndpoint(protocol)
+	if ep == nil {
+		return &tcpip.ErrNotSupported{}
+	}
+
+	gep, ok := ep.(SourceFilteringGroupAddressableEndpoint)
+	if !ok {
+		return &tcpip.ErrNotSupported{}
+	}
+
+	return gep.ChangeGroupSourceFilter(addr, oldFilter, newFilter)
+}
+
 // isInGroup returns true if n has joined the multicast group addr.
 func (n *nic) isInGroup(addr tcpip.Address) bool {
 	for _, ep := range n.networkEndpoints {
@@ -770,8 +803,17 @@ func (n *nic) DeliverNetworkPacket(protocol tcpip.NetworkProtocolNumber, pkt *Pa
 	n.stats.rx.packets.Increment()
 	n.stats.rx.bytes.IncrementBy(uint64(pkt.Data().Size()))
 
+	// Frames destined to macvlan and ipvlan interfaces aren't handled by
+	// the NIC.
+	if n.stack.deliverMACVLANPacket(n.id, pkt) || n.stack.deliverIPVLANPacket(n.id, protocol, pkt) {
+		return
+	}
+
 	networkEndpoint := n.getNetworkEndpoint(protocol)
 	if networkEndpoint == nil {
+		if (protocol == header.EthernetProtocol8021Q || protocol == header.EthernetProtocol8021AD) && n.stack.deliverVLANPacket(n.id, protocol, pkt) {
+			return
+		}
 		n.stats.unknownL3ProtocolRcvdPacketCounts.Increment(uint64(protocol))
 		return
 	}
@@ -847,6 +889,9 @@ func (n *nic) DeliverLinkPacket(protocol tcpip.NetworkProtocolNumber, pkt *Packe
 func (n *nic) DeliverTransportPacket(protocol tcpip.TransportProtocolNumber, pkt *PacketBuffer) TransportPacketDisposition {
 	state, ok := n.stack.transportProtocols[protocol]
 	if !ok {
+		if n.stack.deliverTunnelPacket(protocol, pkt) {
+			return TransportPacketHandled
+		}
 		n.stats.unknownL4ProtocolRcvdPacketCounts.Increment(uint64(protocol))
 		return TransportPacketProtocolUnreachable
 	}
@@ -887,6 +932,12 @@ func (n *nic) DeliverTransportPacket(protocol tcpip.TransportProtocolNumber, pkt
 		}
 	}
 
+	// Like Linux, silently drop packets steered by a TPROXY target to a socket
+	// that doesn't exist rather than answering for their destination.
+	if pkt.TProxied() {
+		return TransportPacketHandled
+	}
+
 	// We could not find an appropriate destination for this packet so
 	// give the protocol specific error handler a chance to handle it.
 	// If it doesn't handle it then we should do so.
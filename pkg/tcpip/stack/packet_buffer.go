@@ -16,6 +16,7 @@ package stack
 import (
 	"fmt"
 	"io"
+	"time"
 
 	"gvisor.dev/gvisor/pkg/buffer"
 	"gvisor.dev/gvisor/pkg/sync"
@@ -134,6 +135,10 @@ type PacketBuffer struct {
 	// Only set for locally generated packets.
 	Owner tcpip.PacketOwner
 
+	// TxTime is the departure time of the packet. It is only set for
+	// packets sent with SCM_TXTIME.
+	TxTime *TxTime
+
 	// The following fields are only set by the qdisc layer when the packet
 	// is added to a queue.
 	EgressRoute RouteInfo
@@ -161,6 +166,12 @@ type PacketBuffer struct {
 	// NetworkPacketInfo holds an incoming packet's network-layer information.
 	NetworkPacketInfo NetworkPacketInfo
 
+	// tproxyDone indicates if a TPROXY target steered the packet to the local
+	// socket bound to tproxyAddr and tproxyPort.
+	tproxyDone bool
+	tproxyAddr tcpip.Address
+	tproxyPort uint16
+
 	tuple *tuple
 
 	// onRelease is a function to be run when the packet buffer is no longer
@@ -168,6 +179,40 @@ type PacketBuffer struct {
 	onRelease func() `state:"nosave"`
 }
 
+// TxTime is the departure time of a packet, which queueing disciplines may
+// honor.
+//
+// +stateify savable
+type TxTime struct {
+	// Time is the departure time in nanoseconds of the clock ClockID.
+	Time int64
+
+	// ClockID is the ID of the clock of Time.
+	ClockID int32
+
+	// DeadlineMode is true if Time is the latest time at which the packet
+	// is sent rather than the earliest one.
+	DeadlineMode bool
+
+	// Owner is notified if the packet is dropped because of its departure
+	// time.
+	Owner TxTimeOwner
+}
+
+// MonotonicTime returns the departure time as a time of the monotonic clock,
+// which is only meaningful if ClockID is the ID of that clock.
+func (t *TxTime) MonotonicTime() tcpip.MonotonicTime {
+	return tcpip.MonotonicTime{}.Add(time.Duration(t.Time))
+}
+
+// TxTimeOwner is the owner of packets sent with a departure time.
+type TxTimeOwner interface {
+	// OnTxTimeDrop is called when a packet sent with the departure time
+	// txTime is dropped because the departure time was invalid or, if
+	// missed is true, because it was missed.
+	OnTxTimeDrop(txTime int64, missed bool)
+}
+
 // NewPacketBuffer creates a new PacketBuffer with opts.
 func NewPacketBuffer(opts PacketBufferOptions) *PacketBuffer {
 	pk := pkPool.Get().(*PacketBuffer)
@@ -296,9 +341,10 @@ func (pk *PacketBuffer) AsViewList() (buffer.ViewList, int) {
 }
 
 // ToBuffer returns a caller-owned copy of the underlying storage of the whole
-// packet.
+// packet. Data referenced in place, such as that of a send requested with
+// MSG_ZEROCOPY, is copied, as the caller may hold the copy indefinitely.
 func (pk *PacketBuffer) ToBuffer() buffer.Buffer {
-	b := pk.buf.Clone()
+	b := pk.buf.CloneOwned()
 	b.TrimFront(int64(pk.headerOffset()))
 	return b
 }
@@ -380,6 +426,7 @@ func (pk *PacketBuffer) Clone() *PacketBuffer {
 	newPk.headers = pk.headers
 	newPk.Hash = pk.Hash
 	newPk.Owner = pk.Owner
+	newPk.TxTime = pk.TxTime
 	newPk.GSOOptions = pk.GSOOptions
 	newPk.EgressRoute = pk.EgressRoute
 	newPk.NetworkProtocolNumber = pk.NetworkProtocolNumber
@@ -390,11 +437,29 @@ func (pk *PacketBuffer) Clone() *PacketBuffer {
 	newPk.NICID = pk.NICID
 	newPk.RXChecksumValidated = pk.RXChecksumValidated
 	newPk.NetworkPacketInfo = pk.NetworkPacketInfo
+	newPk.tproxyDone = pk.tproxyDone
+	newPk.tproxyAddr = pk.tproxyAddr
+	newPk.tproxyPort = pk.tproxyPort
 	newPk.tuple = pk.tuple
 	newPk.InitRefs()
 	return newPk
 }
 
+// TProxied returns true if a TPROXY target steered the packet to a local
+// socket. Such packets are delivered locally even if their destination
+// address is not assigned to the stack.
+func (pk *PacketBuffer) TProxied() bool {
+	return pk.tproxyDone
+}
+
+// SetTProxied steers the packet to the local socket bound to addr and port. A
+// socket connected to the packet's addresses still takes precedence.
+func (pk *PacketBuffer) SetTProxied(addr tcpip.Address, port uint16) {
+	pk.tproxyDone = true
+	pk.tproxyAddr = addr
+	pk.tproxyPort = port
+}
+
 // ReserveHeaderBytes prepends reserved space for headers at the front
 // of the underlying buf. Can only be called once per packet.
 func (pk *PacketBuffer) ReserveHeaderBytes(reserved int) {
@@ -427,7 +492,9 @@ func (pk *PacketBuffer) Network() header.Network {
 func (pk *PacketBuffer) CloneToInbound() *PacketBuffer {
 	newPk := pkPool.Get().(*PacketBuffer)
 	newPk.reset()
-	newPk.buf = pk.buf.Clone()
+	// As in Linux, data referenced in place is copied before it is queued
+	// on a receiving socket.
+	newPk.buf = pk.buf.CloneOwned()
 	newPk.InitRefs()
 	// Treat unfilled header portion as reserved.
 	newPk.reserved = pk.AvailableHeaderBytes()
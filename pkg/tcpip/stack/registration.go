@@ -140,6 +140,32 @@ type TransportEndpoint interface {
 	Wait()
 }
 
+// MulticastSourceFilteringTransportEndpoint is a TransportEndpoint that only
+// receives multicast packets from the sources it selected.
+type MulticastSourceFilteringTransportEndpoint interface {
+	TransportEndpoint
+
+	// AllowsMulticastSource returns true if the endpoint receives the packets
+	// sent by source to the multicast group and received through the NIC.
+	//
+	// AllowsMulticastSource is called while delivering packets, so it must not
+	// take locks held by the endpoint while it registers with the stack.
+	AllowsMulticastSource(nicID tcpip.NICID, group, source tcpip.Address) bool
+}
+
+// ReusePortSteeringTransportEndpoint is a TransportEndpoint that can select
+// the member of its SO_REUSEPORT group that receives a packet.
+type ReusePortSteeringTransportEndpoint interface {
+	TransportEndpoint
+
+	// ReusePortProgram returns the program attached to the endpoint by
+	// SO_ATTACH_REUSEPORT_CBPF, or nil if none is attached.
+	//
+	// ReusePortProgram is called while delivering packets, so it must not
+	// take locks held by the endpoint while it registers with the stack.
+	ReusePortProgram() tcpip.ReusePortProgram
+}
+
 // RawTransportEndpoint is the interface that needs to be implemented by raw
 // transport protocol endpoints. RawTransportEndpoints receive the entire
 // packet - including the network and transport headers - as delivered to
@@ -409,6 +435,23 @@ type GroupAddressableEndpoint interface {
 	IsInGroup(group tcpip.Address) bool
 }
 
+// SourceFilteringGroupAddressableEndpoint is a GroupAddressableEndpoint that
+// supports filtering the sources of multicast packets, as described in RFC
+// 3376 for IPv4 and RFC 3810 for IPv6.
+type SourceFilteringGroupAddressableEndpoint interface {
+	GroupAddressableEndpoint
+
+	// ChangeGroupSourceFilter replaces the source filter of a join of the
+	// specified group.
+	//
+	// An INCLUDE mode filter without sources is equivalent to not being a
+	// member of the group, so changing the filter from such a filter joins the
+	// group and changing it to such a filter leaves the group. Joining the group
+	// with JoinGroup is equivalent to joining it with an EXCLUDE mode filter
+	// without sources.
+	ChangeGroupSourceFilter(group tcpip.Address, oldFilter, newFilter tcpip.MulticastSourceFilter) tcpip.Error
+}
+
 // PrimaryEndpointBehavior is an enumeration of an AddressEndpoint's primary
 // behavior.
 type PrimaryEndpointBehavior int
@@ -176,6 +176,38 @@ type Stack struct {
 
 	// saveRestoreEnabled indicates whether the stack is saved and restored.
 	saveRestoreEnabled bool
+
+	// tunnelMu protects tunnelEndpoints.
+	tunnelMu tunnelRWMutex `state:"nosave"`
+
+	// tunnelEndpoints holds the IP tunnels which receive the packets of
+	// tunneling protocols, keyed by the protocol that carries them and the
+	// tunneling protocol.
+	//
+	// +checklocks:tunnelMu
+	tunnelEndpoints map[protocolIDs][]TunnelEndpoint
+
+	// vlanMu protects vlanEndpoints.
+	vlanMu vlanRWMutex `state:"nosave"`
+
+	// vlanEndpoints holds the VLAN sub-interfaces of NICs.
+	//
+	// +checklocks:vlanMu
+	vlanEndpoints map[vlanKey]VLANEndpoint
+
+	// upperMu protects macvlanPorts and ipvlanPorts.
+	upperMu upperRWMutex `state:"nosave"`
+
+	// macvlanPorts holds the macvlan interfaces of NICs by their link
+	// address.
+	//
+	// +checklocks:upperMu
+	macvlanPorts map[tcpip.NICID]map[tcpip.LinkAddress]macvlanEntry
+
+	// ipvlanPorts holds the ipvlan interfaces of NICs.
+	//
+	// +checklocks:upperMu
+	ipvlanPorts map[tcpip.NICID]*ipvlanPort
 }
 
 // NetworkProtocolFactory instantiates a network protocol.
@@ -1010,6 +1042,10 @@ func (s *Stack) RemoveNIC(id tcpip.NICID) tcpip.Error {
 	if deferAct != nil {
 		deferAct()
 	}
+	if err == nil {
+		s.removeVLANEndpoints(id)
+		s.removeUpperEndpoints(id)
+	}
 	return err
 }
 
@@ -1111,6 +1147,54 @@ func (s *Stack) SetNICMTU(id tcpip.NICID, mtu uint32) tcpip.Error {
 	return nil
 }
 
+// SetNICQueueingDiscipline replaces the queueing discipline of a NIC with the
+// one returned by newQDisc, which sends packets through the link endpoint of
+// the NIC. If newQDisc is nil, packets are passed directly to the link
+// endpoint. The previous queueing discipline is closed, which drops the
+// packets that it holds. The NIC is unchanged if newQDisc fails.
+func (s *Stack) SetNICQueueingDiscipline(id tcpip.NICID, newQDisc func(LinkWriter) (QueueingDiscipline, tcpip.Error)) tcpip.Error {
+	s.mu.RLock()
+	nic, ok := s.nics[id]
+	s.mu.RUnlock()
+	if !ok {
+		return &tcpip.ErrUnknownNICID{}
+	}
+	lower := nic.NetworkLinkEndpoint.(LinkWriter)
+	var qDisc QueueingDiscipline
+	if newQDisc != nil {
+		var err tcpip.Error
+		if qDisc, err = newQDisc(lower); err != nil {
+			return err
+		}
+	} else {
+		qDisc = &delegatingQueueingDiscipline{LinkWriter: lower}
+	}
+
+	nic.qDiscMu.Lock()
+	old := nic.qDisc
+	nic.qDisc = qDisc
+	nic.qDiscMu.Unlock()
+	old.Close()
+	return nil
+}
+
+// NICQueueingDiscipline returns the queueing discipline of a NIC, or nil if
+// packets are passed directly to its link endpoint.
+func (s *Stack) NICQueueingDiscipline(id tcpip.NICID) (QueueingDiscipline, tcpip.Error) {
+	s.mu.RLock()
+	nic, ok := s.nics[id]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, &tcpip.ErrUnknownNICID{}
+	}
+	nic.qDiscMu.RLock()
+	defer nic.qDiscMu.RUnlock()
+	if _, ok := nic.qDisc.(*delegatingQueueingDiscipline); ok {
+		return nil, nil
+	}
+	return nic.qDisc, nil
+}
+
 // NICInfo captures the name and addresses assigned to a NIC.
 type NICInfo struct {
 	Name              string
@@ -1301,11 +1385,11 @@ func (s *Stack) GetMainNICAddress(id tcpip.NICID, protocol tcpip.NetworkProtocol
 	return nic.PrimaryAddress(protocol)
 }
 
-func (s *Stack) getAddressEP(nic *nic, localAddr, remoteAddr, srcHint tcpip.Address, netProto tcpip.NetworkProtocolNumber) AssignableAddressEndpoint {
+func (s *Stack) getAddressEP(nic *nic, localAddr, remoteAddr, srcHint tcpip.Address, netProto tcpip.NetworkProtocolNumber, tempRef getAddressBehaviour) AssignableAddressEndpoint {
 	if localAddr.BitLen() == 0 {
 		return nic.primaryEndpoint(netProto, remoteAddr, srcHint)
 	}
-	return nic.findEndpoint(netProto, localAddr, CanBePrimaryEndpoint)
+	return nic.findEndpoint(netProto, localAddr, CanBePrimaryEndpoint, tempRef)
 }
 
 // NewRouteForMulticast returns a Route that may be used to forward multicast
@@ -1321,7 +1405,7 @@ func (s *Stack) NewRouteForMulticast(nicID tcpip.NICID, remoteAddr tcpip.Address
 		return nil
 	}
 
-	if addressEndpoint := s.getAddressEP(nic, tcpip.Address{} /* localAddr */, remoteAddr, tcpip.Address{} /* srcHint */, netProto); addressEndpoint != nil {
+	if addressEndpoint := s.getAddressEP(nic, tcpip.Address{} /* localAddr */, remoteAddr, tcpip.Address{} /* srcHint */, netProto, spoofing); addressEndpoint != nil {
 		return constructAndValidateRoute(netProto, addressEndpoint, nic, nic, tcpip.Address{} /* gateway */, tcpip.Address{} /* localAddr */, remoteAddr, s.handleLocal, false /* multicastLoop */, 0 /* mtu */)
 	}
 	return nil
@@ -1433,9 +1517,9 @@ func isNICForwarding(nic *nic, proto tcpip.NetworkProtocolNumber) bool {
 // endpoint.
 //
 // +checklocksread:s.mu
-func (s *Stack) findRouteWithLocalAddrFromAnyInterfaceRLocked(outgoingNIC *nic, localAddr, remoteAddr, srcHint, gateway tcpip.Address, netProto tcpip.NetworkProtocolNumber, multicastLoop bool, mtu uint32) *Route {
+func (s *Stack) findRouteWithLocalAddrFromAnyInterfaceRLocked(outgoingNIC *nic, localAddr, remoteAddr, srcHint, gateway tcpip.Address, netProto tcpip.NetworkProtocolNumber, multicastLoop bool, mtu uint32, tempRef getAddressBehaviour) *Route {
 	for _, aNIC := range s.nics {
-		addressEndpoint := s.getAddressEP(aNIC, localAddr, remoteAddr, srcHint, netProto)
+		addressEndpoint := s.getAddressEP(aNIC, localAddr, remoteAddr, srcHint, netProto, tempRef)
 		if addressEndpoint == nil {
 			continue
 		}
@@ -1459,6 +1543,17 @@ func (s *Stack) findRouteWithLocalAddrFromAnyInterfaceRLocked(outgoingNIC *nic,
 // remote address is provided, the stack will use a remote address equal to the
 // local address.
 func (s *Stack) FindRoute(id tcpip.NICID, localAddr, remoteAddr tcpip.Address, netProto tcpip.NetworkProtocolNumber, multicastLoop bool) (*Route, tcpip.Error) {
+	return s.findRoute(id, localAddr, remoteAddr, netProto, multicastLoop, spoofing)
+}
+
+// FindTransparentRoute is like FindRoute but the local address, if provided,
+// need not be assigned to the stack. It is used by sockets bound with
+// IP_TRANSPARENT.
+func (s *Stack) FindTransparentRoute(id tcpip.NICID, localAddr, remoteAddr tcpip.Address, netProto tcpip.NetworkProtocolNumber, multicastLoop bool) (*Route, tcpip.Error) {
+	return s.findRoute(id, localAddr, remoteAddr, netProto, multicastLoop, transparent)
+}
+
+func (s *Stack) findRoute(id tcpip.NICID, localAddr, remoteAddr tcpip.Address, netProto tcpip.NetworkProtocolNumber, multicastLoop bool, tempRef getAddressBehaviour) (*Route, tcpip.Error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -1483,7 +1578,7 @@ func (s *Stack) FindRoute(id tcpip.NICID, localAddr, remoteAddr tcpip.Address, n
 	// through the interface if the interface is valid and enabled.
 	if id != 0 && !needRoute {
 		if nic, ok := s.nics[id]; ok && nic.Enabled() {
-			if addressEndpoint := s.getAddressEP(nic, localAddr, remoteAddr, tcpip.Address{} /* srcHint */, netProto); addressEndpoint != nil {
+			if addressEndpoint := s.getAddressEP(nic, localAddr, remoteAddr, tcpip.Address{} /* srcHint */, netProto, tempRef); addressEndpoint != nil {
 				return makeRoute(
 					netProto,
 					tcpip.Address{}, /* gateway */
@@ -1524,7 +1619,7 @@ func (s *Stack) FindRoute(id tcpip.NICID, localAddr, remoteAddr tcpip.Address, n
 			}
 
 			if id == 0 || id == route.NIC {
-				if addressEndpoint := s.getAddressEP(nic, localAddr, remoteAddr, route.SourceHint, netProto); addressEndpoint != nil {
+				if addressEndpoint := s.getAddressEP(nic, localAddr, remoteAddr, route.SourceHint, netProto, tempRef); addressEndpoint != nil {
 					var gateway tcpip.Address
 					if needRoute {
 						gateway = route.Gateway
@@ -1556,7 +1651,7 @@ func (s *Stack) FindRoute(id tcpip.NICID, localAddr, remoteAddr tcpip.Address, n
 					continue
 				}
 
-				if r := s.findRouteWithLocalAddrFromAnyInterfaceRLocked(nic, localAddr, remoteAddr, route.SourceHint, route.Gateway, netProto, multicastLoop, route.MTU); r != nil {
+				if r := s.findRouteWithLocalAddrFromAnyInterfaceRLocked(nic, localAddr, remoteAddr, route.SourceHint, route.Gateway, netProto, multicastLoop, route.MTU, tempRef); r != nil {
 					return r
 				}
 			}
@@ -1584,7 +1679,7 @@ func (s *Stack) FindRoute(id tcpip.NICID, localAddr, remoteAddr tcpip.Address, n
 		// Use the specified NIC to get the local address endpoint.
 		if id != 0 {
 			if aNIC, ok := s.nics[id]; ok {
-				if addressEndpoint := s.getAddressEP(aNIC, localAddr, remoteAddr, chosenRoute.SourceHint, netProto); addressEndpoint != nil {
+				if addressEndpoint := s.getAddressEP(aNIC, localAddr, remoteAddr, chosenRoute.SourceHint, netProto, tempRef); addressEndpoint != nil {
 					if r := constructAndValidateRoute(netProto, addressEndpoint, aNIC /* localAddressNIC */, nic /* outgoingNIC */, gateway, localAddr, remoteAddr, s.handleLocal, multicastLoop, chosenRoute.MTU); r != nil {
 						return r, nil
 					}
@@ -1598,7 +1693,7 @@ func (s *Stack) FindRoute(id tcpip.NICID, localAddr, remoteAddr tcpip.Address, n
 		if id == 0 {
 			// If an interface is not specified, try to find a NIC that holds the local
 			// address endpoint to construct a route.
-			if r := s.findRouteWithLocalAddrFromAnyInterfaceRLocked(nic, localAddr, remoteAddr, chosenRoute.SourceHint, gateway, netProto, multicastLoop, chosenRoute.MTU); r != nil {
+			if r := s.findRouteWithLocalAddrFromAnyInterfaceRLocked(nic, localAddr, remoteAddr, chosenRoute.SourceHint, gateway, netProto, multicastLoop, chosenRoute.MTU, tempRef); r != nil {
 				return r, nil
 			}
 		}
@@ -1830,7 +1925,13 @@ func (s *Stack) CompleteTransportEndpointCleanup(ep TransportEndpoint) {
 // FindTransportEndpoint finds an endpoint that most closely matches the provided
 // id. If no endpoint is found it returns nil.
 func (s *Stack) FindTransportEndpoint(netProto tcpip.NetworkProtocolNumber, transProto tcpip.TransportProtocolNumber, id TransportEndpointID, nicID tcpip.NICID) TransportEndpoint {
-	return s.demux.findTransportEndpoint(netProto, transProto, id, nicID)
+	return s.demux.findTransportEndpoint(netProto, transProto, id, nicID, false /* noWildcard */)
+}
+
+// FindBoundTransportEndpoint is like FindTransportEndpoint but ignores
+// endpoints bound to an unspecified local address.
+func (s *Stack) FindBoundTransportEndpoint(netProto tcpip.NetworkProtocolNumber, transProto tcpip.TransportProtocolNumber, id TransportEndpointID, nicID tcpip.NICID) TransportEndpoint {
+	return s.demux.findTransportEndpoint(netProto, transProto, id, nicID, true /* noWildcard */)
 }
 
 // RegisterRawTransportEndpoint registers the given endpoint with the stack
@@ -2166,6 +2267,20 @@ func (s *Stack) LeaveGroup(protocol tcpip.NetworkProtocolNumber, nicID tcpip.NIC
 	return &tcpip.ErrUnknownNICID{}
 }
 
+// ChangeGroupSourceFilter replaces the source filter of a join of the given
+// multicast group on the given NIC.
+//
+// See SourceFilteringGroupAddressableEndpoint.ChangeGroupSourceFilter.
+func (s *Stack) ChangeGroupSourceFilter(protocol tcpip.NetworkProtocolNumber, nicID tcpip.NICID, multicastAddr tcpip.Address, oldFilter, newFilter tcpip.MulticastSourceFilter) tcpip.Error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if nic, ok := s.nics[nicID]; ok {
+		return nic.changeGroupSourceFilter(protocol, multicastAddr, oldFilter, newFilter)
+	}
+	return &tcpip.ErrUnknownNICID{}
+}
+
 // IsInGroup returns true if the NIC with ID nicID has joined the multicast
 // group multicastAddr.
 func (s *Stack) IsInGroup(nicID tcpip.NICID, multicastAddr tcpip.Address) (bool, tcpip.Error) {
@@ -2404,7 +2519,7 @@ func (s *Stack) SetNICStack(id tcpip.NICID, peer *Stack) (tcpip.NICID, tcpip.Err
 	}
 
 	id = tcpip.NICID(peer.NextNICID())
-	return id, peer.CreateNICWithOptions(id, ne, NICOptions{Name: nic.Name()})
+	return id, peer.CreateNICWithOptions(id, ne, NICOptions{Name: nic.Name(), Context: nic.context})
 }
 
 // EnableSaveRestore marks the saveRestoreEnabled to true.
@@ -2539,6 +2539,80 @@ func TestCreateNICWithOptions(t *testing.T) {
 	}
 }
 
+// countingQDisc implements stack.QueueingDiscipline by passing packets to a
+// link writer and counting them.
+type countingQDisc struct {
+	lower   stack.LinkWriter
+	packets int
+	closed  bool
+}
+
+func (q *countingQDisc) WritePacket(pkt *stack.PacketBuffer) tcpip.Error {
+	q.packets++
+	var pkts stack.PacketBufferList
+	pkts.PushBack(pkt)
+	_, err := q.lower.WritePackets(pkts)
+	return err
+}
+
+func (q *countingQDisc) Close() {
+	q.closed = true
+}
+
+func TestSetNICQueueingDiscipline(t *testing.T) {
+	const nicID = 1
+	s := stack.New(stack.Options{})
+	defer s.Destroy()
+	ep := channel.New(2, defaultMTU, "")
+	if err := s.CreateNIC(nicID, ep); err != nil {
+		t.Fatalf("CreateNIC(%d, _): %s", nicID, err)
+	}
+	if qDisc, err := s.NICQueueingDiscipline(nicID); err != nil || qDisc != nil {
+		t.Fatalf("got NICQueueingDiscipline(%d) = (%v, %v), want = (nil, nil)", nicID, qDisc, err)
+	}
+
+	q := &countingQDisc{}
+	if err := s.SetNICQueueingDiscipline(nicID, func(stack.LinkWriter) (stack.QueueingDiscipline, tcpip.Error) {
+		return nil, &tcpip.ErrInvalidOptionValue{}
+	}); err == nil {
+		t.Fatalf("SetNICQueueingDiscipline(%d, _) succeeded with a failing queueing discipline, want error", nicID)
+	}
+	if err := s.SetNICQueueingDiscipline(nicID, func(lower stack.LinkWriter) (stack.QueueingDiscipline, tcpip.Error) {
+		q.lower = lower
+		return q, nil
+	}); err != nil {
+		t.Fatalf("SetNICQueueingDiscipline(%d, _): %s", nicID, err)
+	}
+	if qDisc, err := s.NICQueueingDiscipline(nicID); err != nil || qDisc != stack.QueueingDiscipline(q) {
+		t.Fatalf("got NICQueueingDiscipline(%d) = (%v, %v), want = (%v, nil)", nicID, qDisc, err, q)
+	}
+	if err := s.WriteRawPacket(nicID, fakeNetNumber, buffer.MakeWithData([]byte{1, 2, 3})); err != nil {
+		t.Fatalf("WriteRawPacket(%d, _, _): %s", nicID, err)
+	}
+	if q.packets != 1 || ep.Drain() != 1 {
+		t.Errorf("got %d packets through the queueing discipline, want = 1", q.packets)
+	}
+
+	// Packets are passed directly to the link endpoint after the queueing
+	// discipline is removed.
+	if err := s.SetNICQueueingDiscipline(nicID, nil); err != nil {
+		t.Fatalf("SetNICQueueingDiscipline(%d, nil): %s", nicID, err)
+	}
+	if !q.closed {
+		t.Errorf("replaced queueing discipline wasn't closed")
+	}
+	if err := s.WriteRawPacket(nicID, fakeNetNumber, buffer.MakeWithData([]byte{1, 2, 3})); err != nil {
+		t.Fatalf("WriteRawPacket(%d, _, _): %s", nicID, err)
+	}
+	if q.packets != 1 || ep.Drain() != 1 {
+		t.Errorf("got %d packets through the removed queueing discipline, want = 1", q.packets)
+	}
+
+	if _, ok := s.SetNICQueueingDiscipline(nicID+1, nil).(*tcpip.ErrUnknownNICID); !ok {
+		t.Errorf("SetNICQueueingDiscipline(%d, nil) didn't fail with ErrUnknownNICID", nicID+1)
+	}
+}
+
 func TestNICStats(t *testing.T) {
 	s := stack.New(stack.Options{
 		NetworkProtocols: []stack.NetworkProtocolFactory{fakeNetFactory},
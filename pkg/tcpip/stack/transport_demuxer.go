@@ -17,6 +17,7 @@ package stack
 import (
 	"fmt"
 
+	"gvisor.dev/gvisor/pkg/buffer"
 	"gvisor.dev/gvisor/pkg/tcpip"
 	"gvisor.dev/gvisor/pkg/tcpip/hash/jenkins"
 	"gvisor.dev/gvisor/pkg/tcpip/header"
@@ -111,6 +112,36 @@ func (eps *transportEndpoints) iterEndpointsLocked(id TransportEndpointID, yield
 	}
 }
 
+// findBoundEndpointLocked is like findEndpointLocked but ignores endpoints
+// bound to an unspecified local address.
+//
+// +checklocksread:eps.mu
+func (eps *transportEndpoints) findBoundEndpointLocked(id TransportEndpointID) *endpointsByNIC {
+	if ep, ok := eps.endpoints[id]; ok {
+		return ep
+	}
+	nid := id
+	nid.RemoteAddress = tcpip.Address{}
+	nid.RemotePort = 0
+	return eps.endpoints[nid]
+}
+
+// findTProxyEndpointLocked returns the endpoint a packet steered by a TPROXY
+// target to addr and port is delivered to. As in Linux, an endpoint connected
+// to the packet's original addresses takes precedence, e.g. an endpoint
+// accepted from a transparent listener.
+//
+// +checklocksread:eps.mu
+func (eps *transportEndpoints) findTProxyEndpointLocked(id TransportEndpointID, addr tcpip.Address, port uint16) *endpointsByNIC {
+	if ep, ok := eps.endpoints[id]; ok {
+		return ep
+	}
+	tid := id
+	tid.LocalAddress = addr
+	tid.LocalPort = port
+	return eps.findEndpointLocked(tid)
+}
+
 // findAllEndpointsLocked returns all endpointsByNIC in eps that match id, in
 // descending order of match quality.
 //
@@ -178,7 +209,7 @@ func (epsByNIC *endpointsByNIC) handlePacket(id TransportEndpointID, pkt *Packet
 		return true
 	}
 	// multiPortEndpoints are guaranteed to have at least one element.
-	transEP := mpep.selectEndpoint(id, epsByNIC.seed)
+	transEP := mpep.selectEndpoint(id, pkt, epsByNIC.seed)
 	if queuedProtocol, mustQueue := mpep.demux.queuedProtocols[protocolIDs{mpep.netProto, mpep.transProto}]; mustQueue {
 		queuedProtocol.QueuePacket(transEP, id, pkt)
 		epsByNIC.mu.RUnlock()
@@ -207,7 +238,7 @@ func (epsByNIC *endpointsByNIC) handleError(n *nic, id TransportEndpointID, tran
 	// broadcast like we are doing with handlePacket above?
 
 	// multiPortEndpoints are guaranteed to have at least one element.
-	transEP := mpep.selectEndpoint(id, epsByNIC.seed)
+	transEP := mpep.selectEndpoint(id, nil /* pkt */, epsByNIC.seed)
 	epsByNIC.mu.RUnlock()
 
 	transEP.HandleError(transErr, pkt)
@@ -377,7 +408,10 @@ func reciprocalScale(val, n uint32) uint32 {
 // selectEndpoint calculates a hash of destination and source addresses and
 // ports then uses it to select a socket. In this case, all packets from one
 // address will be sent to same endpoint.
-func (ep *multiPortEndpoint) selectEndpoint(id TransportEndpointID, seed uint32) TransportEndpoint {
+//
+// If pkt isn't nil and a member of a SO_REUSEPORT group has a program
+// attached, the program selects the socket instead.
+func (ep *multiPortEndpoint) selectEndpoint(id TransportEndpointID, pkt *PacketBuffer, seed uint32) TransportEndpoint {
 	ep.mu.RLock()
 	defer ep.mu.RUnlock()
 
@@ -385,10 +419,17 @@ func (ep *multiPortEndpoint) selectEndpoint(id TransportEndpointID, seed uint32)
 		return ep.endpoints[0]
 	}
 
-	if ep.flags.SharedFlags().ToFlags().Effective().MostRecent {
+	flags := ep.flags.SharedFlags().ToFlags().Effective()
+	if flags.MostRecent {
 		return ep.endpoints[len(ep.endpoints)-1]
 	}
 
+	if flags.LoadBalanced && pkt != nil {
+		if transEP, ok := ep.selectEndpointByProgramRLocked(pkt); ok {
+			return transEP
+		}
+	}
+
 	payload := []byte{
 		byte(id.LocalPort),
 		byte(id.LocalPort >> 8),
@@ -406,12 +447,65 @@ func (ep *multiPortEndpoint) selectEndpoint(id TransportEndpointID, seed uint32)
 	return ep.endpoints[idx]
 }
 
+// selectEndpointByProgramRLocked runs the program of the first endpoint that
+// has one over the transport payload of pkt, and returns the endpoint at the
+// index that it returns. It returns false if no endpoint has a program, or if
+// the program fails or returns an index out of range.
+//
+// +checklocksread:ep.mu
+func (ep *multiPortEndpoint) selectEndpointByProgramRLocked(pkt *PacketBuffer) (TransportEndpoint, bool) {
+	for _, e := range ep.endpoints {
+		sep, ok := e.(ReusePortSteeringTransportEndpoint)
+		if !ok {
+			continue
+		}
+		prog := sep.ReusePortProgram()
+		if prog == nil {
+			continue
+		}
+		idx, ok := prog.Select(contiguousPayload(pkt))
+		if !ok || idx >= uint32(len(ep.endpoints)) {
+			return nil, false
+		}
+		return ep.endpoints[idx], true
+	}
+	return nil, false
+}
+
+// contiguousPayload returns the transport payload of pkt as a single slice,
+// which must not be retained. Received packets usually hold their payload in a
+// single view, which is returned without copying; otherwise the payload is
+// made contiguous in place.
+func contiguousPayload(pkt *PacketBuffer) []byte {
+	var (
+		payload []byte
+		views   int
+	)
+	pkt.Data().AsRange().iterate(func(v *buffer.View) {
+		payload = v.AsSlice()
+		views++
+	})
+	if views <= 1 {
+		return payload
+	}
+	payload, _ = pkt.Data().PullUp(pkt.Data().Size())
+	return payload
+}
+
 func (ep *multiPortEndpoint) handlePacketAll(id TransportEndpointID, pkt *PacketBuffer) {
 	ep.mu.RLock()
 	queuedProtocol, mustQueue := ep.demux.queuedProtocols[protocolIDs{ep.netProto, ep.transProto}]
+	endpoints := ep.endpoints
+	if header.IsV4MulticastAddress(id.LocalAddress) || header.IsV6MulticastAddress(id.LocalAddress) {
+		endpoints = filterMulticastSource(endpoints, pkt.NICID, id.LocalAddress, id.RemoteAddress)
+		if len(endpoints) == 0 {
+			ep.mu.RUnlock()
+			return
+		}
+	}
 	// HandlePacket may modify pkt, so each endpoint needs
 	// its own copy except for the final one.
-	for _, endpoint := range ep.endpoints[:len(ep.endpoints)-1] {
+	for _, endpoint := range endpoints[:len(endpoints)-1] {
 		clone := pkt.Clone()
 		if mustQueue {
 			queuedProtocol.QueuePacket(endpoint, id, clone)
@@ -420,7 +514,7 @@ func (ep *multiPortEndpoint) handlePacketAll(id TransportEndpointID, pkt *Packet
 		}
 		clone.DecRef()
 	}
-	if endpoint := ep.endpoints[len(ep.endpoints)-1]; mustQueue {
+	if endpoint := endpoints[len(endpoints)-1]; mustQueue {
 		queuedProtocol.QueuePacket(endpoint, id, pkt)
 	} else {
 		endpoint.HandlePacket(id, pkt)
@@ -428,6 +522,29 @@ func (ep *multiPortEndpoint) handlePacketAll(id TransportEndpointID, pkt *Packet
 	ep.mu.RUnlock() // Don't use defer for performance reasons.
 }
 
+// filterMulticastSource returns the endpoints that receive the packets sent by
+// source to the multicast group through the NIC. endpoints is returned as is
+// if all of them do.
+func filterMulticastSource(endpoints []TransportEndpoint, nicID tcpip.NICID, group, source tcpip.Address) []TransportEndpoint {
+	allows := func(ep TransportEndpoint) bool {
+		fep, ok := ep.(MulticastSourceFilteringTransportEndpoint)
+		return !ok || fep.AllowsMulticastSource(nicID, group, source)
+	}
+	for i, ep := range endpoints {
+		if allows(ep) {
+			continue
+		}
+		filtered := append([]TransportEndpoint(nil), endpoints[:i]...)
+		for _, ep := range endpoints[i+1:] {
+			if allows(ep) {
+				filtered = append(filtered, ep)
+			}
+		}
+		return filtered
+	}
+	return endpoints
+}
+
 // singleRegisterEndpoint tries to add an endpoint to the multiPortEndpoint
 // list. The list might be empty already.
 func (ep *multiPortEndpoint) singleRegisterEndpoint(t TransportEndpoint, flags ports.Flags) tcpip.Error {
@@ -592,7 +709,12 @@ func (d *transportDemuxer) deliverPacket(protocol tcpip.TransportProtocolNumber,
 	}
 
 	eps.mu.RLock()
-	ep := eps.findEndpointLocked(id)
+	var ep *endpointsByNIC
+	if pkt.tproxyDone {
+		ep = eps.findTProxyEndpointLocked(id, pkt.tproxyAddr, pkt.tproxyPort)
+	} else {
+		ep = eps.findEndpointLocked(id)
+	}
 	eps.mu.RUnlock()
 	if ep == nil {
 		if protocol == header.UDPProtocolNumber {
@@ -656,15 +778,22 @@ func (d *transportDemuxer) deliverError(n *nic, net tcpip.NetworkProtocolNumber,
 	return true
 }
 
-// findTransportEndpoint find a single endpoint that most closely matches the provided id.
-func (d *transportDemuxer) findTransportEndpoint(netProto tcpip.NetworkProtocolNumber, transProto tcpip.TransportProtocolNumber, id TransportEndpointID, nicID tcpip.NICID) TransportEndpoint {
+// findTransportEndpoint find a single endpoint that most closely matches the
+// provided id. If noWildcard is true, endpoints bound to an unspecified local
+// address are ignored.
+func (d *transportDemuxer) findTransportEndpoint(netProto tcpip.NetworkProtocolNumber, transProto tcpip.TransportProtocolNumber, id TransportEndpointID, nicID tcpip.NICID, noWildcard bool) TransportEndpoint {
 	eps, ok := d.protocol[protocolIDs{netProto, transProto}]
 	if !ok {
 		return nil
 	}
 
 	eps.mu.RLock()
-	epsByNIC := eps.findEndpointLocked(id)
+	var epsByNIC *endpointsByNIC
+	if noWildcard {
+		epsByNIC = eps.findBoundEndpointLocked(id)
+	} else {
+		epsByNIC = eps.findEndpointLocked(id)
+	}
 	if epsByNIC == nil {
 		eps.mu.RUnlock()
 		return nil
@@ -681,7 +810,7 @@ func (d *transportDemuxer) findTransportEndpoint(netProto tcpip.NetworkProtocolN
 		}
 	}
 
-	ep := mpep.selectEndpoint(id, epsByNIC.seed)
+	ep := mpep.selectEndpoint(id, nil /* pkt */, epsByNIC.seed)
 	epsByNIC.mu.RUnlock()
 	return ep
 }
@@ -455,3 +455,112 @@ func TestBindToDeviceDistribution(t *testing.T) {
 		}
 	}
 }
+
+// reusePortProgramFunc is a tcpip.ReusePortProgram implemented by a function.
+type reusePortProgramFunc func(payload []byte) (uint32, bool)
+
+// Select implements tcpip.ReusePortProgram.Select.
+func (f reusePortProgramFunc) Select(payload []byte) (uint32, bool) {
+	return f(payload)
+}
+
+func TestReusePortProgram(t *testing.T) {
+	const nicID = 1
+	const nEndpoints = 3
+
+	// selectFirstByte selects the endpoint at the index held by the first
+	// byte of the payload.
+	selectFirstByte := reusePortProgramFunc(func(payload []byte) (uint32, bool) {
+		if len(payload) == 0 {
+			return 0, false
+		}
+		return uint32(payload[0]), true
+	})
+
+	tests := []struct {
+		name string
+		// programs holds the program of each endpoint.
+		programs [nEndpoints]tcpip.ReusePortProgram
+		// steered is true if packets are delivered to the endpoint at the
+		// index held by their first byte, and false if they are delivered
+		// by hashing.
+		steered bool
+	}{
+		{
+			name:     "FirstEndpoint",
+			programs: [nEndpoints]tcpip.ReusePortProgram{selectFirstByte},
+			steered:  true,
+		},
+		{
+			name:     "LastEndpoint",
+			programs: [nEndpoints]tcpip.ReusePortProgram{nil, nil, selectFirstByte},
+			steered:  true,
+		},
+		{
+			name: "Failure",
+			programs: [nEndpoints]tcpip.ReusePortProgram{reusePortProgramFunc(func([]byte) (uint32, bool) {
+				return 0, false
+			})},
+		},
+		{
+			name: "OutOfRange",
+			programs: [nEndpoints]tcpip.ReusePortProgram{reusePortProgramFunc(func([]byte) (uint32, bool) {
+				return nEndpoints, true
+			})},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newDualTestContextMultiNIC(t, defaultMTU, []tcpip.NICID{nicID})
+			defer c.s.Destroy()
+
+			var eps [nEndpoints]tcpip.Endpoint
+			var wqs [nEndpoints]waiter.Queue
+			for i := range eps {
+				ep, err := c.s.NewEndpoint(udp.ProtocolNumber, ipv4.ProtocolNumber, &wqs[i])
+				if err != nil {
+					t.Fatalf("NewEndpoint failed: %s", err)
+				}
+				defer ep.Close()
+				eps[i] = ep
+
+				ep.SocketOptions().SetReusePort(true)
+				ep.SocketOptions().SetReusePortProgram(test.programs[i])
+				if err := ep.Bind(tcpip.FullAddress{Addr: testDstAddrV4, Port: testDstPort}); err != nil {
+					t.Fatalf("ep.Bind(...) on endpoint %d failed: %s", i, err)
+				}
+			}
+
+			// Send packets from a single source, so that hashing delivers
+			// them to a single endpoint.
+			hdrs := &headers{srcPort: testSrcPort, dstPort: testDstPort}
+			hashed := -1
+			for i := 0; i < 3*nEndpoints; i++ {
+				payload := newPayload()
+				payload[0] = byte(i % nEndpoints)
+				c.sendV4Packet(payload, hdrs, nicID)
+
+				got := -1
+				for j, ep := range eps {
+					if _, err := ep.Read(io.Discard, tcpip.ReadOptions{}); err == nil {
+						got = j
+						break
+					}
+				}
+				switch {
+				case got == -1:
+					t.Fatalf("packet %d wasn't delivered", i)
+				case test.steered:
+					if want := int(payload[0]); got != want {
+						t.Errorf("packet %d delivered to endpoint %d, want %d", i, got, want)
+					}
+				case hashed == -1:
+					hashed = got
+				case got != hashed:
+					t.Errorf("packet %d delivered to endpoint %d, want %d", i, got, hashed)
+				}
+			}
+		})
+	}
+}
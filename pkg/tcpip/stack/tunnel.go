@@ -0,0 +1,89 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"slices"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+// TunnelEndpoint is the receiving side of an IP tunnel. It is handed the
+// packets of its tunneling protocol, such as IP-in-IP or GRE, which are
+// received for a local address.
+type TunnelEndpoint interface {
+	// HandleTunnelPacket is called with a packet of the tunneling protocol.
+	// The network header of the packet is parsed, and its data holds the
+	// header of the tunneling protocol followed by the tunneled packet.
+	//
+	// It returns false if the packet doesn't belong to the tunnel, in
+	// which case it is offered to other tunnels.
+	HandleTunnelPacket(pkt *PacketBuffer) bool
+}
+
+// RegisterTunnelEndpoint registers ep to receive packets of the tunneling
+// protocol transProto that are carried over netProto.
+//
+// The tunneling protocol must not be a transport protocol of the stack.
+func (s *Stack) RegisterTunnelEndpoint(netProto tcpip.NetworkProtocolNumber, transProto tcpip.TransportProtocolNumber, ep TunnelEndpoint) tcpip.Error {
+	if _, ok := s.networkProtocols[netProto]; !ok {
+		return &tcpip.ErrUnknownProtocol{}
+	}
+	if _, ok := s.transportProtocols[transProto]; ok {
+		return &tcpip.ErrNotSupported{}
+	}
+
+	s.tunnelMu.Lock()
+	defer s.tunnelMu.Unlock()
+	if s.tunnelEndpoints == nil {
+		s.tunnelEndpoints = make(map[protocolIDs][]TunnelEndpoint)
+	}
+	ids := protocolIDs{netProto, transProto}
+	s.tunnelEndpoints[ids] = append(s.tunnelEndpoints[ids], ep)
+	return nil
+}
+
+// UnregisterTunnelEndpoint unregisters ep, so that it no longer receives
+// packets of the tunneling protocol.
+func (s *Stack) UnregisterTunnelEndpoint(netProto tcpip.NetworkProtocolNumber, transProto tcpip.TransportProtocolNumber, ep TunnelEndpoint) {
+	s.tunnelMu.Lock()
+	defer s.tunnelMu.Unlock()
+	ids := protocolIDs{netProto, transProto}
+	eps := slices.DeleteFunc(s.tunnelEndpoints[ids], func(e TunnelEndpoint) bool {
+		return e == ep
+	})
+	if len(eps) == 0 {
+		delete(s.tunnelEndpoints, ids)
+		return
+	}
+	s.tunnelEndpoints[ids] = eps
+}
+
+// deliverTunnelPacket delivers a packet of an unknown transport protocol to
+// the first tunnel that accepts it. It returns true if a tunnel accepted the
+// packet.
+func (s *Stack) deliverTunnelPacket(protocol tcpip.TransportProtocolNumber, pkt *PacketBuffer) bool {
+	s.tunnelMu.RLock()
+	// Copy the list of tunnels to avoid packet handling under lock.
+	eps := slices.Clone(s.tunnelEndpoints[protocolIDs{pkt.NetworkProtocolNumber, protocol}])
+	s.tunnelMu.RUnlock()
+
+	for _, ep := range eps {
+		if ep.HandleTunnelPacket(pkt) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stack
+
+import (
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+// VLANEndpoint is the receiving side of a VLAN sub-interface. It is handed the
+// frames of its VLAN that are received by its parent NIC.
+type VLANEndpoint interface {
+	// HandleVLANPacket is called with a tagged frame of the VLAN. The
+	// Ethernet header of the frame is parsed, and its data starts with the
+	// rest of the VLAN tag.
+	HandleVLANPacket(pkt *PacketBuffer)
+
+	// ParentRemoved is called after the parent NIC is removed. No frames
+	// are handed to the endpoint afterwards.
+	ParentRemoved()
+}
+
+// vlanKey identifies a VLAN sub-interface.
+type vlanKey struct {
+	parent tcpip.NICID
+	tpid   tcpip.NetworkProtocolNumber
+	vid    uint16
+}
+
+// RegisterVLANEndpoint registers ep to receive the frames of the VLAN vid that
+// are received by the NIC parent with the tag protocol tpid, which is either
+// header.EthernetProtocol8021Q or header.EthernetProtocol8021AD.
+func (s *Stack) RegisterVLANEndpoint(parent tcpip.NICID, tpid tcpip.NetworkProtocolNumber, vid uint16, ep VLANEndpoint) tcpip.Error {
+	if tpid != header.EthernetProtocol8021Q && tpid != header.EthernetProtocol8021AD {
+		return &tcpip.ErrNotSupported{}
+	}
+	if vid > header.VLANMaxVID {
+		return &tcpip.ErrInvalidOptionValue{}
+	}
+	s.mu.RLock()
+	_, ok := s.nics[parent]
+	s.mu.RUnlock()
+	if !ok {
+		return &tcpip.ErrUnknownNICID{}
+	}
+
+	s.vlanMu.Lock()
+	defer s.vlanMu.Unlock()
+	key := vlanKey{parent: parent, tpid: tpid, vid: vid}
+	if _, ok := s.vlanEndpoints[key]; ok {
+		return &tcpip.ErrDuplicateNICID{}
+	}
+	if s.vlanEndpoints == nil {
+		s.vlanEndpoints = make(map[vlanKey]VLANEndpoint)
+	}
+	s.vlanEndpoints[key] = ep
+	return nil
+}
+
+// UnregisterVLANEndpoint unregisters the endpoint of the VLAN vid of the NIC
+// parent.
+func (s *Stack) UnregisterVLANEndpoint(parent tcpip.NICID, tpid tcpip.NetworkProtocolNumber, vid uint16) {
+	s.vlanMu.Lock()
+	defer s.vlanMu.Unlock()
+	delete(s.vlanEndpoints, vlanKey{parent: parent, tpid: tpid, vid: vid})
+}
+
+// deliverVLANPacket delivers a tagged frame received by the NIC parent to the
+// endpoint of its VLAN. It returns true if the VLAN has an endpoint.
+func (s *Stack) deliverVLANPacket(parent tcpip.NICID, tpid tcpip.NetworkProtocolNumber, pkt *PacketBuffer) bool {
+	v, ok := pkt.Data().PullUp(header.VLANHeaderSize)
+	if !ok {
+		return false
+	}
+	s.vlanMu.RLock()
+	ep, ok := s.vlanEndpoints[vlanKey{parent: parent, tpid: tpid, vid: header.VLAN(v).VID()}]
+	s.vlanMu.RUnlock()
+	if !ok {
+		return false
+	}
+	ep.HandleVLANPacket(pkt)
+	return true
+}
+
+// removeVLANEndpoints unregisters the VLAN endpoints of the NIC parent and
+// notifies them that it was removed.
+func (s *Stack) removeVLANEndpoints(parent tcpip.NICID) {
+	var eps []VLANEndpoint
+	s.vlanMu.Lock()
+	for key, ep := range s.vlanEndpoints {
+		if key.parent == parent {
+			delete(s.vlanEndpoints, key)
+			eps = append(eps, ep)
+		}
+	}
+	s.vlanMu.Unlock()
+
+	for _, ep := range eps {
+		ep.ParentRemoved()
+	}
+}
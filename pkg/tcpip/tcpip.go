@@ -42,6 +42,7 @@ import (
 	"time"
 
 	"gvisor.dev/gvisor/pkg/atomicbitops"
+	"gvisor.dev/gvisor/pkg/buffer"
 	"gvisor.dev/gvisor/pkg/rand"
 	"gvisor.dev/gvisor/pkg/sync"
 	"gvisor.dev/gvisor/pkg/waiter"
@@ -560,6 +561,18 @@ type Payloader interface {
 	Len() int
 }
 
+// PinnedPayloader is a Payloader whose data may be sent without copying it.
+type PinnedPayloader interface {
+	Payloader
+
+	// ReadPinned reads up to n bytes. The views of the returned buffer
+	// reference the data in place if pinned is true, and hold a copy of it
+	// otherwise. If release is not nil, it is called once the views no
+	// longer reference the data, which is before ReadPinned returns if the
+	// data is copied.
+	ReadPinned(n int, release func()) (buf buffer.Buffer, pinned bool, err error)
+}
+
 var _ Payloader = (*bytes.Buffer)(nil)
 var _ Payloader = (*bytes.Reader)(nil)
 
@@ -618,11 +631,37 @@ type SendableControlMessages struct {
 	// HopLimit is the IPv6 Hop Limit of the associated packet.
 	HopLimit uint8
 
+	// HasTClass indicates whether TClass is valid/set.
+	HasTClass bool
+
+	// TClass is the IPv6 traffic class of the associated packet.
+	TClass uint8
+
 	// HasIPv6PacketInfo indicates whether IPv6PacketInfo is set.
 	HasIPv6PacketInfo bool
 
-	// IPv6PacketInfo holds interface and address data on an incoming packet.
+	// IPv6PacketInfo holds the interface and source address of the
+	// associated packet.
 	IPv6PacketInfo IPv6PacketInfo
+
+	// HasTLSRecordType indicates whether TLSRecordType is valid/set.
+	HasTLSRecordType bool
+
+	// TLSRecordType is the type of the TLS records to send.
+	TLSRecordType uint8
+
+	// HasSCTPSndRcvInfo indicates whether SCTPSndRcvInfo is valid/set.
+	HasSCTPSndRcvInfo bool
+
+	// SCTPSndRcvInfo holds the stream and association to send the message on.
+	SCTPSndRcvInfo SCTPSndRcvInfo
+
+	// HasTxTime indicates whether TxTime is valid/set.
+	HasTxTime bool
+
+	// TxTime is the departure time of the associated packet, in nanoseconds
+	// of the clock set with SO_TXTIME.
+	TxTime int64
 }
 
 // ReceivableControlMessages contains socket control messages that can be
@@ -689,6 +728,19 @@ type ReceivableControlMessages struct {
 
 	// SockErr is the dequeued socket error on recvmsg(MSG_ERRQUEUE).
 	SockErr *SockError
+
+	// HasTLSRecordType indicates whether TLSRecordType is valid/set.
+	HasTLSRecordType bool
+
+	// TLSRecordType is the type of the TLS record the read data came from.
+	TLSRecordType uint8
+
+	// HasSCTPSndRcvInfo indicates whether SCTPSndRcvInfo is valid/set.
+	HasSCTPSndRcvInfo bool
+
+	// SCTPSndRcvInfo holds the stream and association of the SCTP message
+	// the read data came from.
+	SCTPSndRcvInfo SCTPSndRcvInfo
 }
 
 // PacketOwner is used to get UID and GID of the packet.
@@ -712,6 +764,11 @@ type ReadOptions struct {
 	// NeedLinkPacketInfo indicates whether to return the link-layer information,
 	// if supported.
 	NeedLinkPacketInfo bool
+
+	// NeedTLSRecordType indicates whether the caller can receive the type of
+	// the TLS record read. Reads of records that don't hold application data
+	// fail otherwise.
+	NeedTLSRecordType bool
 }
 
 // ReadResult represents result for a successful Endpoint.Read.
@@ -922,6 +979,18 @@ type WriteOptions struct {
 
 	// ControlMessages contains optional overrides used when writing a packet.
 	ControlMessages SendableControlMessages
+
+	// FastOpen has the same semantics as Linux's MSG_FASTOPEN: the endpoint
+	// is connected to To and the data is carried in the SYN if possible.
+	FastOpen bool
+
+	// ZeroCopy means that the write is part of a send requested with
+	// MSG_ZEROCOPY whose ID is ZeroCopyID. If the Payloader is a
+	// PinnedPayloader, the data is sent from its memory rather than copied.
+	// The completion of the send is queued on the error queue of the
+	// endpoint.
+	ZeroCopy   bool
+	ZeroCopyID uint32
 }
 
 // SockOptInt represents socket options which values have the int type.
@@ -1003,6 +1072,74 @@ const (
 	// PacketMMapReserveOption is used to set the packet mmap reserved space
 	// between the aligned header and the payload.
 	PacketMMapReserveOption
+
+	// TCPFastOpenQueueLenOption is used by SetSockOptInt/GetSockOptInt to
+	// enable TCP Fast Open on a listening endpoint and bound the number of
+	// Fast Open connections that have not completed their handshake. It
+	// has the same semantics as Linux's TCP_FASTOPEN.
+	TCPFastOpenQueueLenOption
+
+	// TCPFastOpenConnectOption is used by SetSockOptInt/GetSockOptInt to
+	// defer the SYN of a connect until the first write so that it may carry
+	// data. It has the same semantics as Linux's TCP_FASTOPEN_CONNECT.
+	TCPFastOpenConnectOption
+
+	// TCPFastOpenNoCookieOption is used by SetSockOptInt/GetSockOptInt to
+	// send or accept data in the SYN without a Fast Open cookie. It has the
+	// same semantics as Linux's TCP_FASTOPEN_NO_COOKIE.
+	TCPFastOpenNoCookieOption
+
+	// TCPRepairOption is used by SetSockOptInt/GetSockOptInt to put a TCP
+	// endpoint in or take it out of repair mode. It has the same semantics
+	// as Linux's TCP_REPAIR.
+	TCPRepairOption
+
+	// TCPRepairQueueOption is used by SetSockOptInt/GetSockOptInt to select
+	// the queue of a TCP endpoint in repair mode that is read, written and
+	// sequenced. It has the same semantics as Linux's TCP_REPAIR_QUEUE.
+	TCPRepairQueueOption
+
+	// TCPQueueSeqOption is used by SetSockOptInt/GetSockOptInt to get or
+	// set the sequence number of the queue selected by TCPRepairQueueOption.
+	// It has the same semantics as Linux's TCP_QUEUE_SEQ.
+	TCPQueueSeqOption
+
+	// SCTPNoDelayOption is used by SetSockOptInt/GetSockOptInt to disable
+	// the bundling of small SCTP messages. It has the same semantics as
+	// Linux's SCTP_NODELAY.
+	SCTPNoDelayOption
+
+	// SCTPDisableFragmentsOption is used by SetSockOptInt/GetSockOptInt to
+	// make SCTP fail writes of messages that don't fit in a single packet
+	// instead of fragmenting them. It has the same semantics as Linux's
+	// SCTP_DISABLE_FRAGMENTS.
+	SCTPDisableFragmentsOption
+)
+
+// TCPRepairOption values.
+const (
+	// TCPRepairOffNoWindowProbe takes the endpoint out of repair mode
+	// without probing the peer's window.
+	TCPRepairOffNoWindowProbe = -1
+
+	// TCPRepairOff takes the endpoint out of repair mode and probes the
+	// peer's window.
+	TCPRepairOff = 0
+
+	// TCPRepairOn puts the endpoint in repair mode.
+	TCPRepairOn = 1
+)
+
+// TCPRepairQueueOption values.
+const (
+	// TCPNoQueue selects no queue.
+	TCPNoQueue = 0
+
+	// TCPRecvQueue selects the receive queue.
+	TCPRecvQueue = 1
+
+	// TCPSendQueue selects the send queue.
+	TCPSendQueue = 2
 )
 
 const (
@@ -1093,6 +1230,32 @@ func (*TCPAlwaysUseSynCookies) isGettableTransportProtocolOption() {}
 
 func (*TCPAlwaysUseSynCookies) isSettableTransportProtocolOption() {}
 
+// TCPFastOpen is the set of TCP Fast Open features enabled on the stack. It
+// has the same semantics as Linux's net.ipv4.tcp_fastopen sysctl.
+//
+// See: https://tools.ietf.org/html/rfc7413.
+type TCPFastOpen int32
+
+func (*TCPFastOpen) isGettableTransportProtocolOption() {}
+
+func (*TCPFastOpen) isSettableTransportProtocolOption() {}
+
+const (
+	// TCPFastOpenClient enables sending data in the SYN of active opens.
+	TCPFastOpenClient TCPFastOpen = 0x1
+
+	// TCPFastOpenServer enables accepting data in the SYN of passive opens.
+	TCPFastOpenServer TCPFastOpen = 0x2
+
+	// TCPFastOpenClientNoCookie enables sending data in the SYN without a
+	// cookie.
+	TCPFastOpenClientNoCookie TCPFastOpen = 0x4
+
+	// TCPFastOpenServerNoCookie enables accepting data in the SYN without a
+	// cookie.
+	TCPFastOpenServerNoCookie TCPFastOpen = 0x200
+)
+
 const (
 	// TCPRACKLossDetection indicates RACK is used for loss detection and
 	// recovery.
@@ -1346,6 +1509,427 @@ func (*TCPDeferAcceptOption) isGettableSocketOption() {}
 
 func (*TCPDeferAcceptOption) isSettableSocketOption() {}
 
+// TCPFastOpenKeyOption is used by SetSockOpt/GetSockOpt to set/get the keys
+// used to generate and validate TCP Fast Open cookies. It holds a primary key
+// optionally followed by a backup key, each of TCPFastOpenKeyLength bytes.
+type TCPFastOpenKeyOption []byte
+
+func (*TCPFastOpenKeyOption) isGettableSocketOption() {}
+
+func (*TCPFastOpenKeyOption) isSettableSocketOption() {}
+
+func (*TCPFastOpenKeyOption) isGettableTransportProtocolOption() {}
+
+func (*TCPFastOpenKeyOption) isSettableTransportProtocolOption() {}
+
+// TCPFastOpenKeyLength is the length of a TCP Fast Open key.
+const TCPFastOpenKeyLength = 16
+
+// TCPULPOption is used by SetSockOpt/GetSockOpt to set/get the upper layer
+// protocol of a connected TCP endpoint.
+type TCPULPOption string
+
+func (*TCPULPOption) isGettableSocketOption() {}
+
+func (*TCPULPOption) isSettableSocketOption() {}
+
+// TCPULPTLS is the kernel TLS upper layer protocol, which encrypts and
+// decrypts TLS records once TLSTxOption and TLSRxOption are set.
+const TCPULPTLS TCPULPOption = "tls"
+
+// TLS protocol versions supported by kernel TLS.
+const (
+	TLSVersion12 = 0x0303
+	TLSVersion13 = 0x0304
+)
+
+// AEAD ciphers supported by kernel TLS. The values match Linux's.
+const (
+	TLSCipherAESGCM128        = 51
+	TLSCipherAESGCM256        = 52
+	TLSCipherChaCha20Poly1305 = 54
+)
+
+// TLSCryptoInfo holds the state with which kernel TLS protects the records in
+// one direction of a connection.
+//
+// +stateify savable
+type TLSCryptoInfo struct {
+	// Version is the TLS protocol version.
+	Version uint16
+
+	// CipherType is the AEAD cipher protecting the records.
+	CipherType uint16
+
+	// Key is the cipher key.
+	Key []byte
+
+	// Salt is the implicit part of the nonce. It is empty for
+	// ChaCha20-Poly1305, whose IV holds the whole static nonce.
+	Salt []byte
+
+	// IV is the per-connection part of the nonce.
+	IV []byte
+
+	// RecSeq is the sequence number of the next record.
+	RecSeq uint64
+}
+
+// TLSTxOption is used by SetSockOpt/GetSockOpt to set/get the state used to
+// encrypt the records sent on a TCP endpoint using TCPULPTLS.
+type TLSTxOption TLSCryptoInfo
+
+func (*TLSTxOption) isGettableSocketOption() {}
+
+func (*TLSTxOption) isSettableSocketOption() {}
+
+// TLSRxOption is used by SetSockOpt/GetSockOpt to set/get the state used to
+// decrypt the records received on a TCP endpoint using TCPULPTLS.
+type TLSRxOption TLSCryptoInfo
+
+func (*TLSRxOption) isGettableSocketOption() {}
+
+func (*TLSRxOption) isSettableSocketOption() {}
+
+// TCPRepairOpt is a TCP option negotiated by a connection, as restored by
+// TCPRepairOptionsOption.
+type TCPRepairOpt struct {
+	// Code is the kind of the option, one of header.TCPOptionMSS,
+	// header.TCPOptionWS, header.TCPOptionSACKPermitted and
+	// header.TCPOptionTS.
+	Code uint32
+
+	// Value is the value of the option. For header.TCPOptionWS, the low 16
+	// bits hold the send window scale and the high 16 bits hold the receive
+	// window scale.
+	Value uint32
+}
+
+// TCPRepairOptionsOption is used by SetSockOpt to restore the TCP options
+// negotiated by a connection made in repair mode. It has the same semantics
+// as Linux's TCP_REPAIR_OPTIONS.
+type TCPRepairOptionsOption []TCPRepairOpt
+
+func (*TCPRepairOptionsOption) isSettableSocketOption() {}
+
+// TCPRepairWindowOption is used by SetSockOpt/GetSockOpt to save and restore
+// the windows of a TCP endpoint in repair mode. It has the same semantics as
+// Linux's TCP_REPAIR_WINDOW.
+type TCPRepairWindowOption struct {
+	// SndWL1 is the sequence number of the segment that last updated the
+	// send window.
+	SndWL1 uint32
+
+	// SndWnd is the send window.
+	SndWnd uint32
+
+	// MaxWindow is the largest send window advertised by the peer.
+	MaxWindow uint32
+
+	// RcvWnd is the receive window.
+	RcvWnd uint32
+
+	// RcvWUP is the receive sequence number at which the receive window
+	// was last advertised.
+	RcvWUP uint32
+}
+
+func (*TCPRepairWindowOption) isGettableSocketOption() {}
+
+func (*TCPRepairWindowOption) isSettableSocketOption() {}
+
+// TCPMD5SigOption is used by SetSockOpt to install or remove a TCP MD5
+// signature key (RFC 2385) for peers within a prefix.
+type TCPMD5SigOption struct {
+	// Addr is the peer address the key applies to.
+	Addr Address
+
+	// PrefixLen is the number of leading bits of Addr a peer address must
+	// match for the key to apply.
+	PrefixLen int
+
+	// NIC restricts the key to peers reached through the given NIC. Zero
+	// means any NIC.
+	NIC NICID
+
+	// Key is the shared secret. An empty key removes the existing key for
+	// Addr, PrefixLen and NIC.
+	Key []byte
+}
+
+func (*TCPMD5SigOption) isSettableSocketOption() {}
+
+// SCTPAssocID identifies an association of an SCTP endpoint.
+type SCTPAssocID int32
+
+// SCTP association IDs that select more than a single association. The values
+// match Linux's.
+const (
+	// SCTPFutureAssoc selects the defaults of associations that are yet to
+	// be created. It is also the ID used by one-to-one style endpoints.
+	SCTPFutureAssoc SCTPAssocID = 0
+
+	// SCTPCurrentAssoc selects all existing associations.
+	SCTPCurrentAssoc SCTPAssocID = 1
+
+	// SCTPAllAssoc selects all existing and future associations.
+	SCTPAllAssoc SCTPAssocID = 2
+)
+
+// SCTPAssocState is the state of an SCTP association, as reported by
+// SCTPStatusOption. The values match Linux's.
+type SCTPAssocState int32
+
+// SCTP association states.
+const (
+	SCTPStateEmpty SCTPAssocState = iota
+	SCTPStateClosed
+	SCTPStateCookieWait
+	SCTPStateCookieEchoed
+	SCTPStateEstablished
+	SCTPStateShutdownPending
+	SCTPStateShutdownSent
+	SCTPStateShutdownReceived
+	SCTPStateShutdownAckSent
+)
+
+// SCTPSndRcvFlags are the flags of an SCTPSndRcvInfo.
+type SCTPSndRcvFlags uint16
+
+const (
+	// SCTPUnordered indicates that the message is delivered without regard
+	// for the order of the other messages of its stream.
+	SCTPUnordered SCTPSndRcvFlags = 1 << iota
+
+	// SCTPSackImmediately asks the peer to acknowledge the message without
+	// delay.
+	SCTPSackImmediately
+
+	// SCTPAbort aborts the association instead of sending the message. The
+	// message, if any, is sent to the peer as the reason for the abort.
+	SCTPAbort
+
+	// SCTPEOF gracefully shuts down the association once the message has
+	// been acknowledged.
+	SCTPEOF
+)
+
+// SCTPSndRcvInfo holds the parameters of a message sent or received on an
+// SCTP endpoint. It is exchanged with the sockets API as struct
+// sctp_sndrcvinfo.
+//
+// +stateify savable
+type SCTPSndRcvInfo struct {
+	// Stream is the stream of the message.
+	Stream uint16
+
+	// SSN is the stream sequence number of a received message.
+	SSN uint16
+
+	// Flags holds the flags of the message.
+	Flags SCTPSndRcvFlags
+
+	// PPID is the payload protocol identifier of the message, as it appears
+	// in DATA chunks.
+	PPID uint32
+
+	// Context is an opaque value reported back for messages that couldn't
+	// be sent.
+	Context uint32
+
+	// TimeToLive is the lifetime of a message sent with partial reliability,
+	// in milliseconds. Partial reliability is not supported, so it is only
+	// stored.
+	TimeToLive uint32
+
+	// TSN is the transmission sequence number of the first DATA chunk of a
+	// received message.
+	TSN uint32
+
+	// CumTSN is the cumulative TSN acknowledged by the receiver when the
+	// message was received.
+	CumTSN uint32
+
+	// AssocID is the association of the message.
+	AssocID SCTPAssocID
+}
+
+// SCTPInitMsgOption is used by SetSockOpt/GetSockOpt to set/get the parameters
+// of the associations an SCTP endpoint initiates. It has the same semantics as
+// Linux's SCTP_INITMSG.
+type SCTPInitMsgOption struct {
+	// NumOutStreams is the number of outbound streams requested from the
+	// peer.
+	NumOutStreams uint16
+
+	// MaxInStreams is the maximum number of inbound streams accepted from
+	// the peer.
+	MaxInStreams uint16
+
+	// MaxAttempts is the number of times the INIT chunk is sent before the
+	// association attempt is abandoned.
+	MaxAttempts uint16
+
+	// MaxInitTimeout bounds the retransmission timeout of the INIT chunk.
+	MaxInitTimeout time.Duration
+}
+
+func (*SCTPInitMsgOption) isGettableSocketOption() {}
+
+func (*SCTPInitMsgOption) isSettableSocketOption() {}
+
+// SCTPRTOInfoOption is used by SetSockOpt/GetSockOpt to set/get the
+// retransmission timeout bounds of SCTP associations. It has the same
+// semantics as Linux's SCTP_RTOINFO.
+type SCTPRTOInfoOption struct {
+	// AssocID is the association the option applies to.
+	AssocID SCTPAssocID
+
+	// Initial is the retransmission timeout used before the round trip time
+	// is measured. Zero leaves it unchanged.
+	Initial time.Duration
+
+	// Max is the maximum retransmission timeout. Zero leaves it unchanged.
+	Max time.Duration
+
+	// Min is the minimum retransmission timeout. Zero leaves it unchanged.
+	Min time.Duration
+}
+
+func (*SCTPRTOInfoOption) isGettableSocketOption() {}
+
+func (*SCTPRTOInfoOption) isSettableSocketOption() {}
+
+// SCTPAssocInfoOption is used by SetSockOpt/GetSockOpt to set/get association
+// wide parameters of SCTP associations. It has the same semantics as Linux's
+// SCTP_ASSOCINFO.
+type SCTPAssocInfoOption struct {
+	// AssocID is the association the option applies to.
+	AssocID SCTPAssocID
+
+	// MaxRetrans is the number of consecutive retransmissions after which
+	// the association is aborted. Zero leaves it unchanged.
+	MaxRetrans uint16
+
+	// NumPeerDestinations is the number of the peer's transport addresses.
+	NumPeerDestinations uint16
+
+	// PeerRwnd is the current receive window of the peer.
+	PeerRwnd uint32
+
+	// LocalRwnd is the current local receive window.
+	LocalRwnd uint32
+
+	// CookieLife is the lifetime of the state cookies sent to peers. Zero
+	// leaves it unchanged.
+	CookieLife time.Duration
+}
+
+func (*SCTPAssocInfoOption) isGettableSocketOption() {}
+
+func (*SCTPAssocInfoOption) isSettableSocketOption() {}
+
+// SCTPDefaultSendParamOption is used by SetSockOpt/GetSockOpt to set/get the
+// parameters of messages sent without an SCTPSndRcvInfo. It has the same
+// semantics as Linux's SCTP_DEFAULT_SEND_PARAM.
+type SCTPDefaultSendParamOption SCTPSndRcvInfo
+
+func (*SCTPDefaultSendParamOption) isGettableSocketOption() {}
+
+func (*SCTPDefaultSendParamOption) isSettableSocketOption() {}
+
+// SCTPEventsOption is used by SetSockOpt/GetSockOpt to set/get the SCTP events
+// a reader is informed of. It has the same semantics as Linux's SCTP_EVENTS,
+// but only supports the data I/O event.
+type SCTPEventsOption struct {
+	// DataIO indicates whether reads return the SCTPSndRcvInfo of the
+	// message read.
+	DataIO bool
+}
+
+func (*SCTPEventsOption) isGettableSocketOption() {}
+
+func (*SCTPEventsOption) isSettableSocketOption() {}
+
+// SCTPPeerAddrParamsOption is used by SetSockOpt/GetSockOpt to set/get the
+// parameters of the path to an SCTP peer. It has the same semantics as Linux's
+// SCTP_PEER_ADDR_PARAMS.
+type SCTPPeerAddrParamsOption struct {
+	// AssocID is the association the option applies to.
+	AssocID SCTPAssocID
+
+	// HeartbeatEnabled indicates whether the path is probed with heartbeats.
+	HeartbeatEnabled bool
+
+	// HeartbeatInterval is the interval at which an idle path is probed with
+	// heartbeats.
+	HeartbeatInterval time.Duration
+
+	// PathMaxRetrans is the number of consecutive retransmissions after
+	// which the path is considered unreachable.
+	PathMaxRetrans uint16
+
+	// PathMTU is the MTU of the path.
+	PathMTU uint32
+
+	// SackDelay is the maximum delay of acknowledgements.
+	SackDelay time.Duration
+}
+
+func (*SCTPPeerAddrParamsOption) isGettableSocketOption() {}
+
+func (*SCTPPeerAddrParamsOption) isSettableSocketOption() {}
+
+// SCTPStatusOption is used by GetSockOpt to get the status of an SCTP
+// association. It has the same semantics as Linux's SCTP_STATUS.
+type SCTPStatusOption struct {
+	// AssocID is the association to report the status of.
+	AssocID SCTPAssocID
+
+	// State is the state of the association.
+	State SCTPAssocState
+
+	// Rwnd is the current receive window of the peer.
+	Rwnd uint32
+
+	// UnackedData is the number of DATA chunks awaiting acknowledgement.
+	UnackedData uint16
+
+	// PendingData is the number of DATA chunks awaiting reassembly or
+	// ordered delivery.
+	PendingData uint16
+
+	// InStreams is the number of inbound streams.
+	InStreams uint16
+
+	// OutStreams is the number of outbound streams.
+	OutStreams uint16
+
+	// FragmentationPoint is the largest user data sent in a single DATA
+	// chunk.
+	FragmentationPoint uint32
+
+	// PrimaryAddr is the address of the peer.
+	PrimaryAddr FullAddress
+
+	// PrimaryActive indicates whether the path to the peer is reachable.
+	PrimaryActive bool
+
+	// PrimaryCwnd is the congestion window of the path to the peer.
+	PrimaryCwnd uint32
+
+	// PrimarySRTT is the smoothed round trip time of the path to the peer.
+	PrimarySRTT time.Duration
+
+	// PrimaryRTO is the retransmission timeout of the path to the peer.
+	PrimaryRTO time.Duration
+
+	// PrimaryMTU is the MTU of the path to the peer.
+	PrimaryMTU uint32
+}
+
+func (*SCTPStatusOption) isGettableSocketOption() {}
+
 // TCPMinRTOOption is use by SetSockOpt/GetSockOpt to allow overriding
 // default MinRTO used by the Stack.
 type TCPMinRTOOption time.Duration
@@ -1423,6 +2007,81 @@ type RemoveMembershipOption MembershipOption
 
 func (*RemoveMembershipOption) isSettableSocketOption() {}
 
+// MulticastFilterMode is the filter mode of a multicast source filter, as
+// described in RFC 3376 section 3.1 and RFC 3810 section 4.1.
+type MulticastFilterMode int
+
+const (
+	// MulticastFilterInclude indicates that only packets sent by the filter's
+	// sources are received.
+	MulticastFilterInclude MulticastFilterMode = iota
+
+	// MulticastFilterExclude indicates that packets sent by any source but the
+	// filter's sources are received.
+	MulticastFilterExclude
+)
+
+// MulticastSourceFilter is a filter on the sources of the packets received for
+// a multicast group.
+//
+// The zero value is an INCLUDE mode filter without sources, which is
+// equivalent to not being a member of the group.
+//
+// +stateify savable
+type MulticastSourceFilter struct {
+	Mode MulticastFilterMode
+
+	// Sources holds the filter's sources. A source must appear at most once.
+	Sources []Address
+}
+
+// SourceMembershipOption is used to identify a source of a multicast group on
+// an interface.
+type SourceMembershipOption struct {
+	NIC           NICID
+	InterfaceAddr Address
+	MulticastAddr Address
+	SourceAddr    Address
+}
+
+// AddSourceMembershipOption identifies a source to receive packets from for a
+// multicast group on some interface. The group is joined in INCLUDE mode if
+// it isn't joined yet.
+type AddSourceMembershipOption SourceMembershipOption
+
+func (*AddSourceMembershipOption) isSettableSocketOption() {}
+
+// RemoveSourceMembershipOption identifies a source to stop receiving packets
+// from for a multicast group joined in INCLUDE mode on some interface.
+type RemoveSourceMembershipOption SourceMembershipOption
+
+func (*RemoveSourceMembershipOption) isSettableSocketOption() {}
+
+// BlockSourceOption identifies a source to stop receiving packets from for a
+// multicast group joined in EXCLUDE mode on some interface.
+type BlockSourceOption SourceMembershipOption
+
+func (*BlockSourceOption) isSettableSocketOption() {}
+
+// UnblockSourceOption identifies a blocked source to receive packets from
+// again for a multicast group joined in EXCLUDE mode on some interface.
+type UnblockSourceOption SourceMembershipOption
+
+func (*UnblockSourceOption) isSettableSocketOption() {}
+
+// MulticastSourceFilterOption is used to get or replace the source filter of
+// a multicast group joined on some interface.
+type MulticastSourceFilterOption struct {
+	NIC           NICID
+	InterfaceAddr Address
+	MulticastAddr Address
+	Filter        MulticastSourceFilter
+}
+
+func (*MulticastSourceFilterOption) isGettableSocketOption() {}
+
+func (*MulticastSourceFilterOption) isSettableSocketOption() {}
+
 // SocketDetachFilterOption is used by SetSockOpt to detach a previously attached
 // classic BPF filter on a given endpoint.
 type SocketDetachFilterOption int
@@ -1473,6 +2132,26 @@ type LingerOption struct {
 	Timeout time.Duration
 }
 
+// TxTimeOption is the value of the SO_TXTIME option, which lets packets be
+// sent with a departure time.
+//
+// +stateify savable
+type TxTimeOption struct {
+	// Enabled is true if packets can be sent with a departure time.
+	Enabled bool
+
+	// ClockID is the ID of the clock of the departure times.
+	ClockID int32
+
+	// DeadlineMode is true if departure times are the latest times at
+	// which packets are sent rather than the earliest ones.
+	DeadlineMode bool
+
+	// ReportErrors is true if the packets that are dropped because of their
+	// departure time are reported on the error queue.
+	ReportErrors bool
+}
+
 // IPPacketInfo is the message structure for IP_PKTINFO.
 //
 // +stateify savable
@@ -1495,6 +2174,15 @@ type IPv6PacketInfo struct {
 	NIC  NICID
 }
 
+// IPv6PacketInfoOption is used by SetSockOpt/GetSockOpt to specify the
+// interface and source address of the packets sent without an IPV6_PKTINFO
+// control message.
+type IPv6PacketInfoOption IPv6PacketInfo
+
+func (*IPv6PacketInfoOption) isGettableSocketOption() {}
+
+func (*IPv6PacketInfoOption) isSettableSocketOption() {}
+
 // SendBufferSizeOption is used by stack.(Stack*).Option/SetOption to
 // get/set the default, min and max send buffer sizes.
 //
@@ -2282,6 +2970,18 @@ type TCPStats struct {
 	// dropped due to exceeding the maximum number of in-flight connection
 	// requests.
 	ForwardMaxInFlightDrop *StatCounter
+
+	// MD5NotFound is the number of segments dropped because a TCP MD5
+	// signature was expected but not present.
+	MD5NotFound *StatCounter
+
+	// MD5Unexpected is the number of segments dropped because they carried
+	// a TCP MD5 signature but no key was configured for the peer.
+	MD5Unexpected *StatCounter
+
+	// MD5Failure is the number of segments dropped because their TCP MD5
+	// signature did not match.
+	MD5Failure *StatCounter
 }
 
 // UDPStats collects UDP-specific stats.
@@ -2314,6 +3014,54 @@ type UDPStats struct {
 	ChecksumErrors *StatCounter
 }
 
+// SCTPStats collects SCTP-specific stats.
+//
+// +stateify savable
+type SCTPStats struct {
+	// PacketsReceived is the number of SCTP packets received via
+	// HandlePacket.
+	PacketsReceived *StatCounter
+
+	// PacketsSent is the number of SCTP packets sent.
+	PacketsSent *StatCounter
+
+	// PacketSendErrors is the number of SCTP packets failed to be sent.
+	PacketSendErrors *StatCounter
+
+	// ChecksumErrors is the number of SCTP packets dropped due to bad
+	// checksums.
+	ChecksumErrors *StatCounter
+
+	// MalformedPacketsReceived is the number of SCTP packets dropped because
+	// their chunks could not be parsed.
+	MalformedPacketsReceived *StatCounter
+
+	// OutOfTheBluePacketsReceived is the number of SCTP packets received
+	// that did not belong to an association.
+	OutOfTheBluePacketsReceived *StatCounter
+
+	// ActiveEstablishes is the number of associations established by
+	// sending an INIT chunk.
+	ActiveEstablishes *StatCounter
+
+	// PassiveEstablishes is the number of associations established by
+	// accepting an INIT chunk.
+	PassiveEstablishes *StatCounter
+
+	// Aborteds is the number of associations terminated by an ABORT chunk.
+	Aborteds *StatCounter
+
+	// Shutdowns is the number of associations terminated gracefully.
+	Shutdowns *StatCounter
+
+	// T3RtxExpireds is the number of times the retransmission timer of an
+	// association expired.
+	T3RtxExpireds *StatCounter
+
+	// FastRetransmits is the number of DATA chunks fast retransmitted.
+	FastRetransmits *StatCounter
+}
+
 // NICNeighborStats holds metrics for the neighbor table.
 //
 // +stateify savable
@@ -2514,6 +3262,9 @@ type Stats struct {
 
 	// UDP holds UDP-specific stats.
 	UDP UDPStats
+
+	// SCTP holds SCTP-specific stats.
+	SCTP SCTPStats
 }
 
 // ReceiveErrors collects packet receive errors within transport endpoint.
@@ -2472,7 +2472,7 @@ func TestNATICMPError(t *testing.T) {
 									ep2 := channel.New(1, header.IPv6MinimumMTU, "")
 									utils.SetupRouterStack(t, s, ep1, ep2)
 
-									ipv6 := test.netProto == ipv6.ProtocolNumber
+									isIPv6 := test.netProto == ipv6.ProtocolNumber
 									ipt := s.IPTables()
 
 									table := stack.Table{
@@ -2851,7 +2851,7 @@ func TestSNATHandlePortOrIdentConflicts(t *testing.T) {
 											ep2 := channel.New(1, header.IPv6MinimumMTU, "")
 											utils.SetupRouterStack(t, s, ep1, ep2)
 
-											ipv6 := test.netProto == ipv6.ProtocolNumber
+											isIPv6 := test.netProto == ipv6.ProtocolNumber
 											ipt := s.IPTables()
 
 											table := stack.Table{
@@ -3178,7 +3178,7 @@ func TestLocallyRoutedPackets(t *testing.T) {
 
 			// Set IPTables so we create entries in the conntrack table.
 			{
-				ipv6 := test.netProto == ipv6.ProtocolNumber
+				isIPv6 := test.netProto == ipv6.ProtocolNumber
 				ipt := s.IPTables()
 				filter := ipt.GetTable(stack.FilterID, ipv6)
 				ipt.ForceReplaceTable(stack.FilterID, filter, ipv6)
@@ -3497,7 +3497,7 @@ func TestRejectWith(t *testing.T) {
 							utils.SetupRouterStack(t, s, ep1, ep2)
 
 							{
-								ipv6 := test.netProto == ipv6.ProtocolNumber
+								isIPv6 := test.netProto == ipv6.ProtocolNumber
 								ipt := s.IPTables()
 								filter := ipt.GetTable(stack.FilterID, ipv6)
 								ruleIdx := filter.BuiltinChains[natHook.hook]
@@ -3728,3 +3728,122 @@ func genUDP6(offset int8) *stack.PacketBuffer {
 	buf := buffer.MakeWithData(append([]byte{}, hdr.View()...))
 	return stack.NewPacketBuffer(stack.PacketBufferOptions{Payload: buf})
 }
+
+func TestTPROXY(t *testing.T) {
+	const (
+		srcPort   = 5555
+		origPort  = 80
+		proxyPort = 8080
+	)
+
+	tests := []struct {
+		name        string
+		netProto    tcpip.NetworkProtocolNumber
+		genStack    func(*testing.T) (*stack.Stack, *channel.Endpoint)
+		genPacket   func(srcAddr, dstAddr tcpip.Address, srcPort, dstPort uint16, dataSize int) []byte
+		srcAddr     tcpip.Address
+		origDstAddr tcpip.Address
+	}{
+		{
+			name:        "IPv4",
+			netProto:    ipv4.ProtocolNumber,
+			genStack:    genStackV4,
+			genPacket:   udpv4Packet,
+			srcAddr:     srcAddrV4,
+			origDstAddr: testutil.MustParse4("192.0.2.1"),
+		},
+		{
+			name:        "IPv6",
+			netProto:    ipv6.ProtocolNumber,
+			genStack:    genStackV6,
+			genPacket:   udpv6Packet,
+			srcAddr:     srcAddrV6,
+			origDstAddr: testutil.MustParse6("2001:db8::1"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s, e := test.genStack(t)
+			defer s.Destroy()
+
+			var wq waiter.Queue
+			ep, err := s.NewEndpoint(udp.ProtocolNumber, test.netProto, &wq)
+			if err != nil {
+				t.Fatalf("s.NewEndpoint(%d, %d, _): %s", udp.ProtocolNumber, test.netProto, err)
+			}
+			defer ep.Close()
+			ep.SocketOptions().SetTransparent(true)
+			ep.SocketOptions().SetReceiveOriginalDstAddress(true)
+			if err := ep.Bind(tcpip.FullAddress{Port: proxyPort}); err != nil {
+				t.Fatalf("ep.Bind(_): %s", err)
+			}
+
+			inject := func() {
+				e.InjectInbound(test.netProto, stack.NewPacketBuffer(stack.PacketBufferOptions{
+					Payload: buffer.MakeWithData(test.genPacket(test.srcAddr, test.origDstAddr, srcPort, origPort, payloadSize)),
+				}))
+			}
+
+			// Without a TPROXY rule, the packet isn't addressed to the stack.
+			inject()
+			if got := s.Stats().IP.InvalidDestinationAddressesReceived.Value(); got != 1 {
+				t.Fatalf("got s.Stats().IP.InvalidDestinationAddressesReceived.Value() = %d, want = 1", got)
+			}
+
+			isIPv6 := test.netProto == ipv6.ProtocolNumber
+			s.IPTables().ForceReplaceTable(stack.MangleID, stack.Table{
+				Rules: []stack.Rule{
+					{
+						Filter: stack.IPHeaderFilter{
+							Protocol:      udp.ProtocolNumber,
+							CheckProtocol: true,
+						},
+						Target: &stack.TPROXYTarget{NetworkProtocol: test.netProto, Port: proxyPort},
+					},
+					{Target: &stack.AcceptTarget{NetworkProtocol: test.netProto}},
+					{Target: &stack.ErrorTarget{NetworkProtocol: test.netProto}},
+				},
+				BuiltinChains: [stack.NumHooks]int{
+					stack.Prerouting:  0,
+					stack.Input:       1,
+					stack.Forward:     1,
+					stack.Output:      1,
+					stack.Postrouting: 1,
+				},
+				Underflows: [stack.NumHooks]int{
+					stack.Prerouting:  1,
+					stack.Input:       1,
+					stack.Forward:     1,
+					stack.Output:      1,
+					stack.Postrouting: 1,
+				},
+			}, isIPv6)
+
+			// The packet is now delivered to the transparent endpoint, which sees
+			// the original destination.
+			inject()
+			var buf bytes.Buffer
+			res, err := ep.Read(&buf, tcpip.ReadOptions{NeedRemoteAddr: true})
+			if err != nil {
+				t.Fatalf("ep.Read(_, _): %s", err)
+			}
+			if diff := cmp.Diff(tcpip.ReadResult{
+				Count: payloadSize,
+				Total: payloadSize,
+				ControlMessages: tcpip.ReceivableControlMessages{
+					HasOriginalDstAddress: true,
+					OriginalDstAddress:    tcpip.FullAddress{NIC: nicID, Addr: test.origDstAddr, Port: origPort},
+				},
+				RemoteAddr: tcpip.FullAddress{NIC: nicID, Addr: test.srcAddr, Port: srcPort},
+			}, res, checker.IgnoreCmpPath(
+				"ControlMessages.HasInq",
+				"ControlMessages.Inq",
+				"ControlMessages.HasTimestamp",
+				"ControlMessages.Timestamp",
+			)); diff != "" {
+				t.Errorf("ep.Read: unexpected result (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
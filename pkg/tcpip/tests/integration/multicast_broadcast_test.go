@@ -731,6 +731,157 @@ func TestUDPAddRemoveMembershipSocketOption(t *testing.T) {
 	}
 }
 
+func TestUDPSourceSpecificMulticast(t *testing.T) {
+	const nicID = 1
+
+	data := []byte{1, 2, 3, 4}
+
+	tests := []struct {
+		name          string
+		proto         tcpip.NetworkProtocolNumber
+		localAddr     tcpip.AddressWithPrefix
+		sources       [2]tcpip.Address
+		rxUDP         func(*channel.Endpoint, tcpip.Address, tcpip.Address, []byte)
+		multicastAddr tcpip.Address
+	}{
+		{
+			name:          "IPv4",
+			proto:         header.IPv4ProtocolNumber,
+			localAddr:     utils.Ipv4Addr,
+			sources:       [2]tcpip.Address{utils.RemoteIPv4Addr, testutil.MustParse4("10.0.0.3")},
+			rxUDP:         rxIPv4UDP,
+			multicastAddr: testutil.MustParse4("232.1.2.3"),
+		},
+		{
+			name:          "IPv6",
+			proto:         header.IPv6ProtocolNumber,
+			localAddr:     utils.Ipv6Addr,
+			sources:       [2]tcpip.Address{utils.RemoteIPv6Addr, testutil.MustParse6("200b::3")},
+			rxUDP:         rxIPv6UDP,
+			multicastAddr: testutil.MustParse6("ff3e::1234"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := stack.New(stack.Options{
+				NetworkProtocols:   []stack.NetworkProtocolFactory{ipv4.NewProtocol, ipv6.NewProtocol},
+				TransportProtocols: []stack.TransportProtocolFactory{udp.NewProtocol},
+			})
+			defer s.Close()
+			e := channel.New(0, defaultMTU, "")
+			defer e.Close()
+			if err := s.CreateNIC(nicID, e); err != nil {
+				t.Fatalf("CreateNIC(%d, _): %s", nicID, err)
+			}
+			protoAddr := tcpip.ProtocolAddress{Protocol: test.proto, AddressWithPrefix: test.localAddr}
+			if err := s.AddProtocolAddress(nicID, protoAddr, stack.AddressProperties{}); err != nil {
+				t.Fatalf("AddProtocolAddress(%d, %+v, {}): %s", nicID, protoAddr, err)
+			}
+
+			var wq waiter.Queue
+			ep, err := s.NewEndpoint(udp.ProtocolNumber, test.proto, &wq)
+			if err != nil {
+				t.Fatalf("NewEndpoint(%d, %d, _): %s", udp.ProtocolNumber, test.proto, err)
+			}
+			defer ep.Close()
+
+			bindAddr := tcpip.FullAddress{Port: utils.LocalPort}
+			if err := ep.Bind(bindAddr); err != nil {
+				t.Fatalf("ep.Bind(%#v): %s", bindAddr, err)
+			}
+
+			// checkReceived checks that only the packets sent by the received
+			// sources are delivered to the endpoint.
+			checkReceived := func(received [2]bool) {
+				t.Helper()
+
+				for i, source := range test.sources {
+					test.rxUDP(e, source, test.multicastAddr, data)
+					var buf bytes.Buffer
+					res, err := ep.Read(&buf, tcpip.ReadOptions{NeedRemoteAddr: true})
+					if !received[i] {
+						if _, ok := err.(*tcpip.ErrWouldBlock); !ok {
+							t.Fatalf("got ep.Read for a packet from %s = (%#v, %v), want = (_, %s)", source, res, err, &tcpip.ErrWouldBlock{})
+						}
+						continue
+					}
+					if err != nil {
+						t.Fatalf("ep.Read for a packet from %s: %s", source, err)
+					}
+					if res.RemoteAddr.Addr != source {
+						t.Errorf("got res.RemoteAddr.Addr = %s, want = %s", res.RemoteAddr.Addr, source)
+					}
+					if diff := cmp.Diff(data, buf.Bytes()); diff != "" {
+						t.Errorf("got UDP payload mismatch (-want +got):\n%s", diff)
+					}
+				}
+			}
+			sourceOpt := func(source tcpip.Address) tcpip.SourceMembershipOption {
+				return tcpip.SourceMembershipOption{
+					NIC:           nicID,
+					MulticastAddr: test.multicastAddr,
+					SourceAddr:    source,
+				}
+			}
+
+			blockOpt := tcpip.BlockSourceOption(sourceOpt(test.sources[0]))
+			if err := ep.SetSockOpt(&blockOpt); err == nil {
+				t.Fatalf("ep.SetSockOpt(&%#v) = nil, want = %s", blockOpt, &tcpip.ErrInvalidOptionValue{})
+			}
+
+			addOpt := tcpip.AddSourceMembershipOption(sourceOpt(test.sources[0]))
+			if err := ep.SetSockOpt(&addOpt); err != nil {
+				t.Fatalf("ep.SetSockOpt(&%#v): %s", addOpt, err)
+			}
+			checkReceived([2]bool{true, false})
+			if err := ep.SetSockOpt(&addOpt); err == nil {
+				t.Fatalf("ep.SetSockOpt(&%#v) = nil, want = %s", addOpt, &tcpip.ErrBadLocalAddress{})
+			}
+
+			filterOpt := tcpip.MulticastSourceFilterOption{
+				NIC:           nicID,
+				MulticastAddr: test.multicastAddr,
+				Filter: tcpip.MulticastSourceFilter{
+					Mode:    tcpip.MulticastFilterExclude,
+					Sources: []tcpip.Address{test.sources[0]},
+				},
+			}
+			if err := ep.SetSockOpt(&filterOpt); err != nil {
+				t.Fatalf("ep.SetSockOpt(&%#v): %s", filterOpt, err)
+			}
+			checkReceived([2]bool{false, true})
+			gotFilterOpt := tcpip.MulticastSourceFilterOption{
+				NIC:           nicID,
+				MulticastAddr: test.multicastAddr,
+			}
+			if err := ep.GetSockOpt(&gotFilterOpt); err != nil {
+				t.Fatalf("ep.GetSockOpt(&%#v): %s", gotFilterOpt, err)
+			}
+			if diff := cmp.Diff(filterOpt, gotFilterOpt); diff != "" {
+				t.Errorf("source filter mismatch (-want +got):\n%s", diff)
+			}
+
+			unblockOpt := tcpip.UnblockSourceOption(sourceOpt(test.sources[0]))
+			if err := ep.SetSockOpt(&unblockOpt); err != nil {
+				t.Fatalf("ep.SetSockOpt(&%#v): %s", unblockOpt, err)
+			}
+			checkReceived([2]bool{true, true})
+
+			removeOpt := tcpip.RemoveMembershipOption{NIC: nicID, MulticastAddr: test.multicastAddr}
+			if err := ep.SetSockOpt(&removeOpt); err != nil {
+				t.Fatalf("ep.SetSockOpt(&%#v): %s", removeOpt, err)
+			}
+			checkReceived([2]bool{false, false})
+			if isInGroup, err := s.IsInGroup(nicID, test.multicastAddr); err != nil {
+				t.Fatalf("s.IsInGroup(%d, %s): %s", nicID, test.multicastAddr, err)
+			} else if isInGroup {
+				t.Fatalf("got s.IsInGroup(%d, %s) = true, want = false", nicID, test.multicastAddr)
+			}
+		})
+	}
+}
+
 func TestAddMembershipInterfacePrecedence(t *testing.T) {
 	const nicID = 1
 	multicastAddr := tcpip.AddrFromSlice([]byte("\xe0\x01\x02\x03"))
@@ -18,6 +18,7 @@ package network
 
 import (
 	"fmt"
+	"slices"
 
 	"gvisor.dev/gvisor/pkg/atomicbitops"
 	"gvisor.dev/gvisor/pkg/buffer"
@@ -55,8 +56,6 @@ type Endpoint struct {
 	// +checklocks:mu
 	connectedRoute *stack.Route `state:"nosave"`
 	// +checklocks:mu
-	multicastMemberships map[multicastMembership]struct{}
-	// +checklocks:mu
 	ipv4TTL uint8
 	// +checklocks:mu
 	ipv6HopLimit int16
@@ -73,6 +72,12 @@ type Endpoint struct {
 	ipv4TOS uint8
 	// +checklocks:mu
 	ipv6TClass uint8
+	// ipv6PacketInfo is the interface and source address of the IPv6 packets
+	// sent without an IPV6_PKTINFO control message, as set by the
+	// IPV6_PKTINFO socket option.
+	//
+	// +checklocks:mu
+	ipv6PacketInfo tcpip.IPv6PacketInfo
 
 	// Lock ordering: mu > infoMu.
 	infoMu sync.RWMutex `state:"nosave"`
@@ -88,6 +93,19 @@ type Endpoint struct {
 	// +checklocks:infoMu
 	info stack.TransportEndpointInfo
 
+	// Lock ordering: mu > multicastMu.
+	multicastMu sync.RWMutex `state:"nosave"`
+	// multicastMemberships holds the source filter of each multicast group
+	// joined by the endpoint.
+	//
+	// multicastMemberships has a dedicated mutex so that the source filters can
+	// be checked while delivering packets, for the same reasons as info. Writes
+	// must be performed with mu held so that they are synchronized with the
+	// joins and leaves of the groups.
+	//
+	// +checklocks:multicastMu
+	multicastMemberships map[multicastMembership]tcpip.MulticastSourceFilter
+
 	// state holds a transport.DatagramBasedEndpointState.
 	//
 	// state must be accessed with atomics so that we can avoid lock ordering
@@ -119,6 +137,8 @@ type multicastMembership struct {
 func (e *Endpoint) Init(s *stack.Stack, netProto tcpip.NetworkProtocolNumber, transProto tcpip.TransportProtocolNumber, ops *tcpip.SocketOptions, waiterQueue *waiter.Queue) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
+	e.multicastMu.Lock()
+	defer e.multicastMu.Unlock()
 	if e.multicastMemberships != nil {
 		panic(fmt.Sprintf("endpoint is already initialized; got e.multicastMemberships = %#v, want = nil", e.multicastMemberships))
 	}
@@ -146,7 +166,7 @@ func (e *Endpoint) Init(s *stack.Stack, netProto tcpip.NetworkProtocolNumber, tr
 
 	// Linux defaults to TTL=1.
 	e.multicastTTL = 1
-	e.multicastMemberships = make(map[multicastMembership]struct{})
+	e.multicastMemberships = make(map[multicastMembership]tcpip.MulticastSourceFilter)
 	e.setEndpointState(transport.DatagramEndpointStateInitial)
 }
 
@@ -180,10 +200,13 @@ func (e *Endpoint) Close() {
 		return
 	}
 
-	for mem := range e.multicastMemberships {
-		e.stack.LeaveGroup(e.netProto, mem.nicID, mem.multicastAddr)
-	}
+	e.multicastMu.Lock()
+	memberships := e.multicastMemberships
 	e.multicastMemberships = nil
+	e.multicastMu.Unlock()
+	for mem, filter := range memberships {
+		e.changeGroupFilter(mem, filter, tcpip.MulticastSourceFilter{})
+	}
 
 	if e.connectedRoute != nil {
 		e.connectedRoute.Release()
@@ -299,6 +322,28 @@ func (c *WriteContext) TryNewPacketBufferFromPayloader(reserveHdrBytes int, payl
 	return c.newPacketBufferLocked(reserveHdrBytes, data)
 }
 
+// TryNewPacketBufferFromPinnedPayloader is like
+// TryNewPacketBufferFromPayloader, but the packet references the data of
+// `payloader` in place if possible, in which case it also returns true.
+// `release` is passed to tcpip.PinnedPayloader.ReadPinned, so it isn't called
+// if the send buffer is full.
+func (c *WriteContext) TryNewPacketBufferFromPinnedPayloader(reserveHdrBytes int, payloader tcpip.PinnedPayloader, release func()) (*stack.PacketBuffer, bool) {
+	e := c.e
+
+	e.sendBufferSizeInUseMu.Lock()
+	defer e.sendBufferSizeInUseMu.Unlock()
+
+	if !e.hasSendSpaceRLocked() {
+		return nil, false
+	}
+	data, pinned, err := payloader.ReadPinned(payloader.Len(), release)
+	if err != nil {
+		data.Release()
+		return nil, false
+	}
+	return c.newPacketBufferLocked(reserveHdrBytes, data), pinned
+}
+
 // +checklocks:c.e.sendBufferSizeInUseMu
 func (c *WriteContext) newPacketBufferLocked(reserveHdrBytes int, data buffer.Buffer) *stack.PacketBuffer {
 	e := c.e
@@ -428,7 +473,13 @@ func (e *Endpoint) AcquireContextForWrite(opts tcpip.WriteOptions) (WriteContext
 		return WriteContext{}, &tcpip.ErrClosedForSend{}
 	}
 
-	ipv6PktInfoValid := e.effectiveNetProto == header.IPv6ProtocolNumber && opts.ControlMessages.HasIPv6PacketInfo
+	// An IPV6_PKTINFO control message overrides the IPV6_PKTINFO socket
+	// option.
+	pktInfo := e.ipv6PacketInfo
+	if opts.ControlMessages.HasIPv6PacketInfo {
+		pktInfo = opts.ControlMessages.IPv6PacketInfo
+	}
+	ipv6PktInfoValid := e.effectiveNetProto == header.IPv6ProtocolNumber && (opts.ControlMessages.HasIPv6PacketInfo || pktInfo != tcpip.IPv6PacketInfo{})
 
 	route := e.connectedRoute
 	to := opts.To
@@ -475,8 +526,8 @@ func (e *Endpoint) AcquireContextForWrite(opts tcpip.WriteOptions) (WriteContext
 			// Uphold strong-host semantics since (as of writing) the stack follows
 			// the strong host model.
 
-			pktInfoNICID := opts.ControlMessages.IPv6PacketInfo.NIC
-			pktInfoAddr := opts.ControlMessages.IPv6PacketInfo.Addr
+			pktInfoNICID := pktInfo.NIC
+			pktInfoAddr := pktInfo.Addr
 
 			if pktInfoNICID != 0 {
 				// If we are bound to an interface or specified the destination
@@ -557,6 +608,9 @@ func (e *Endpoint) AcquireContextForWrite(opts tcpip.WriteOptions) (WriteContext
 		}
 	case header.IPv6ProtocolNumber:
 		tos = e.ipv6TClass
+		if opts.ControlMessages.HasTClass {
+			tos = opts.ControlMessages.TClass
+		}
 		if opts.ControlMessages.HasHopLimit {
 			ttl = opts.ControlMessages.HopLimit
 		} else {
@@ -623,8 +677,13 @@ func (e *Endpoint) connectRouteRLocked(nicID tcpip.NICID, localAddr tcpip.Addres
 		}
 	}
 
-	// Find a route to the desired destination.
-	r, err := e.stack.FindRoute(nicID, localAddr, addr.Addr, netProto, e.ops.GetMulticastLoop())
+	// Find a route to the desired destination. Transparent sockets may send
+	// from addresses that are not assigned to the stack.
+	findRoute := e.stack.FindRoute
+	if e.ops.GetTransparent() {
+		findRoute = e.stack.FindTransparentRoute
+	}
+	r, err := findRoute(nicID, localAddr, addr.Addr, netProto, e.ops.GetMulticastLoop())
 	if err != nil {
 		return nil, 0, err
 	}
@@ -767,8 +826,10 @@ func (e *Endpoint) BindAndThen(addr tcpip.FullAddress, f func(tcpip.NetworkProto
 
 	nicID := addr.NIC
 	if addr.Addr.BitLen() != 0 && !e.isBroadcastOrMulticast(addr.NIC, netProto, addr.Addr) {
-		nicID = e.stack.CheckLocalAddress(nicID, netProto, addr.Addr)
-		if nicID == 0 {
+		if nic := e.stack.CheckLocalAddress(nicID, netProto, addr.Addr); nic != 0 {
+			nicID = nic
+		} else if !e.ops.GetTransparent() {
+			// Transparent sockets may be bound to any address.
 			return &tcpip.ErrBadLocalAddress{}
 		}
 	}
@@ -912,6 +973,196 @@ func (e *Endpoint) GetSockOptInt(opt tcpip.SockOptInt) (int, tcpip.Error) {
 	}
 }
 
+// The maximum number of sources in the source filter of a multicast group
+// joined by an endpoint. These are the defaults of Linux's
+// net.ipv4.igmp_max_msf and net.ipv6.mld_max_msf.
+const (
+	maxIPv4MulticastFilterSources = 10
+	maxIPv6MulticastFilterSources = 64
+)
+
+// isMemberFilter returns true if filter receives packets from some source, i.e.
+// it isn't the filter of a group that isn't joined.
+func isMemberFilter(filter tcpip.MulticastSourceFilter) bool {
+	return filter.Mode == tcpip.MulticastFilterExclude || len(filter.Sources) != 0
+}
+
+// isAnySourceFilter returns true if filter receives packets from any source, as
+// the filter of a group joined with AddMembershipOption does.
+func isAnySourceFilter(filter tcpip.MulticastSourceFilter) bool {
+	return filter.Mode == tcpip.MulticastFilterExclude && len(filter.Sources) == 0
+}
+
+// maxMulticastFilterSources returns the maximum number of sources in the source
+// filter of a multicast group.
+func (e *Endpoint) maxMulticastFilterSources() int {
+	if e.netProto == header.IPv4ProtocolNumber {
+		return maxIPv4MulticastFilterSources
+	}
+	return maxIPv6MulticastFilterSources
+}
+
+// multicastMembershipFor returns the membership of the multicast group on the
+// interface identified by nicID or interfaceAddr. If neither is specified, the
+// interface is the one used to send packets to the group.
+func (e *Endpoint) multicastMembershipFor(nicID tcpip.NICID, interfaceAddr, multicastAddr tcpip.Address) (multicastMembership, tcpip.Error) {
+	if !(header.IsV4MulticastAddress(multicastAddr) && e.netProto == header.IPv4ProtocolNumber) && !(header.IsV6MulticastAddress(multicastAddr) && e.netProto == header.IPv6ProtocolNumber) {
+		return multicastMembership{}, &tcpip.ErrInvalidOptionValue{}
+	}
+
+	if interfaceAddr.Unspecified() {
+		if nicID == 0 {
+			if r, err := e.stack.FindRoute(0, tcpip.Address{}, multicastAddr, e.netProto, false /* multicastLoop */); err == nil {
+				nicID = r.NICID()
+				r.Release()
+			}
+		}
+	} else {
+		nicID = e.stack.CheckLocalAddress(nicID, e.netProto, interfaceAddr)
+	}
+	if nicID == 0 {
+		return multicastMembership{}, &tcpip.ErrUnknownDevice{}
+	}
+
+	return multicastMembership{nicID: nicID, multicastAddr: multicastAddr}, nil
+}
+
+// multicastFilterLocked returns the source filter of a membership. The filter
+// of a group that isn't joined is an INCLUDE mode filter without sources.
+//
+// +checklocksread:e.mu
+func (e *Endpoint) multicastFilterLocked(mem multicastMembership) tcpip.MulticastSourceFilter {
+	e.multicastMu.RLock()
+	defer e.multicastMu.RUnlock()
+	return e.multicastMemberships[mem]
+}
+
+// setMulticastFilterLocked replaces the source filter of a membership, joining
+// or leaving the group as required.
+//
+// +checklocks:e.mu
+func (e *Endpoint) setMulticastFilterLocked(mem multicastMembership, filter tcpip.MulticastSourceFilter) tcpip.Error {
+	if err := e.changeGroupFilter(mem, e.multicastFilterLocked(mem), filter); err != nil {
+		return err
+	}
+
+	e.multicastMu.Lock()
+	defer e.multicastMu.Unlock()
+	if isMemberFilter(filter) {
+		e.multicastMemberships[mem] = filter
+	} else {
+		delete(e.multicastMemberships, mem)
+	}
+	return nil
+}
+
+// changeGroupFilter replaces the endpoint's source filter for a membership
+// with the stack.
+func (e *Endpoint) changeGroupFilter(mem multicastMembership, oldFilter, newFilter tcpip.MulticastSourceFilter) tcpip.Error {
+	switch {
+	case !isMemberFilter(oldFilter) && isAnySourceFilter(newFilter):
+		return e.stack.JoinGroup(e.netProto, mem.nicID, mem.multicastAddr)
+	case isAnySourceFilter(oldFilter) && !isMemberFilter(newFilter):
+		return e.stack.LeaveGroup(e.netProto, mem.nicID, mem.multicastAddr)
+	default:
+		return e.stack.ChangeGroupSourceFilter(e.netProto, mem.nicID, mem.multicastAddr, oldFilter, newFilter)
+	}
+}
+
+// changeMulticastSource adds a source to or removes a source from the source
+// filter of a multicast group, which must have the specified filter mode.
+//
+// As in Linux, adding a source to an INCLUDE mode filter joins the group if
+// required, removing the last source from an INCLUDE mode filter leaves the
+// group and the filter mode may only change while the filter has no sources.
+func (e *Endpoint) changeMulticastSource(opt tcpip.SourceMembershipOption, mode tcpip.MulticastFilterMode, add bool) tcpip.Error {
+	mem, err := e.multicastMembershipFor(opt.NIC, opt.InterfaceAddr, opt.MulticastAddr)
+	if err != nil {
+		return err
+	}
+	if opt.SourceAddr.Len() != opt.MulticastAddr.Len() {
+		return &tcpip.ErrInvalidOptionValue{}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	oldFilter := e.multicastFilterLocked(mem)
+	if !isMemberFilter(oldFilter) {
+		if !add || mode != tcpip.MulticastFilterInclude {
+			return &tcpip.ErrInvalidOptionValue{}
+		}
+	} else if oldFilter.Mode != mode && len(oldFilter.Sources) != 0 {
+		return &tcpip.ErrInvalidOptionValue{}
+	}
+
+	newFilter := tcpip.MulticastSourceFilter{Mode: mode}
+	i := slices.Index(oldFilter.Sources, opt.SourceAddr)
+	if add {
+		if i >= 0 {
+			return &tcpip.ErrBadLocalAddress{}
+		}
+		if len(oldFilter.Sources) >= e.maxMulticastFilterSources() {
+			return &tcpip.ErrNoBufferSpace{}
+		}
+		newFilter.Sources = append(slices.Clone(oldFilter.Sources), opt.SourceAddr)
+	} else {
+		if i < 0 {
+			return &tcpip.ErrBadLocalAddress{}
+		}
+		newFilter.Sources = slices.Delete(slices.Clone(oldFilter.Sources), i, i+1)
+	}
+
+	return e.setMulticastFilterLocked(mem, newFilter)
+}
+
+// setMulticastSourceFilter replaces the source filter of a joined multicast
+// group. As in Linux, an INCLUDE mode filter without sources leaves the group.
+func (e *Endpoint) setMulticastSourceFilter(opt *tcpip.MulticastSourceFilterOption) tcpip.Error {
+	mem, err := e.multicastMembershipFor(opt.NIC, opt.InterfaceAddr, opt.MulticastAddr)
+	if err != nil {
+		return err
+	}
+	if len(opt.Filter.Sources) > e.maxMulticastFilterSources() {
+		return &tcpip.ErrNoBufferSpace{}
+	}
+
+	newFilter := tcpip.MulticastSourceFilter{Mode: opt.Filter.Mode}
+	for _, source := range opt.Filter.Sources {
+		if source.Len() != opt.MulticastAddr.Len() {
+			return &tcpip.ErrInvalidOptionValue{}
+		}
+		if !slices.Contains(newFilter.Sources, source) {
+			newFilter.Sources = append(newFilter.Sources, source)
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !isMemberFilter(e.multicastFilterLocked(mem)) {
+		if !isMemberFilter(newFilter) {
+			return &tcpip.ErrBadLocalAddress{}
+		}
+		return &tcpip.ErrInvalidOptionValue{}
+	}
+
+	return e.setMulticastFilterLocked(mem, newFilter)
+}
+
+// AllowsMulticastSource returns true if the endpoint receives the packets sent
+// by source to the multicast group through the NIC. As in Linux, packets sent
+// to groups the endpoint did not join are received.
+func (e *Endpoint) AllowsMulticastSource(nicID tcpip.NICID, group, source tcpip.Address) bool {
+	e.multicastMu.RLock()
+	defer e.multicastMu.RUnlock()
+	filter, ok := e.multicastMemberships[multicastMembership{nicID: nicID, multicastAddr: group}]
+	if !ok {
+		return true
+	}
+	return slices.Contains(filter.Sources, source) == (filter.Mode == tcpip.MulticastFilterInclude)
+}
+
 // SetSockOpt sets the socket option.
 func (e *Endpoint) SetSockOpt(opt tcpip.SettableSocketOption) tcpip.Error {
 	switch v := opt.(type) {
@@ -952,75 +1203,60 @@ func (e *Endpoint) SetSockOpt(opt tcpip.SettableSocketOption) tcpip.Error {
 		e.multicastAddr = addr
 
 	case *tcpip.AddMembershipOption:
-		if !(header.IsV4MulticastAddress(v.MulticastAddr) && e.netProto == header.IPv4ProtocolNumber) && !(header.IsV6MulticastAddress(v.MulticastAddr) && e.netProto == header.IPv6ProtocolNumber) {
-			return &tcpip.ErrInvalidOptionValue{}
-		}
-
-		nicID := v.NIC
-
-		if v.InterfaceAddr.Unspecified() {
-			if nicID == 0 {
-				if r, err := e.stack.FindRoute(0, tcpip.Address{}, v.MulticastAddr, e.netProto, false /* multicastLoop */); err == nil {
-					nicID = r.NICID()
-					r.Release()
-				}
-			}
-		} else {
-			nicID = e.stack.CheckLocalAddress(nicID, e.netProto, v.InterfaceAddr)
-		}
-		if nicID == 0 {
-			return &tcpip.ErrUnknownDevice{}
+		mem, err := e.multicastMembershipFor(v.NIC, v.InterfaceAddr, v.MulticastAddr)
+		if err != nil {
+			return err
 		}
 
-		memToInsert := multicastMembership{nicID: nicID, multicastAddr: v.MulticastAddr}
-
 		e.mu.Lock()
 		defer e.mu.Unlock()
 
-		if _, ok := e.multicastMemberships[memToInsert]; ok {
+		if isMemberFilter(e.multicastFilterLocked(mem)) {
 			return &tcpip.ErrPortInUse{}
 		}
 
-		if err := e.stack.JoinGroup(e.netProto, nicID, v.MulticastAddr); err != nil {
-			return err
-		}
-
-		e.multicastMemberships[memToInsert] = struct{}{}
+		return e.setMulticastFilterLocked(mem, tcpip.MulticastSourceFilter{Mode: tcpip.MulticastFilterExclude})
 
 	case *tcpip.RemoveMembershipOption:
-		if !(header.IsV4MulticastAddress(v.MulticastAddr) && e.netProto == header.IPv4ProtocolNumber) && !(header.IsV6MulticastAddress(v.MulticastAddr) && e.netProto == header.IPv6ProtocolNumber) {
-			return &tcpip.ErrInvalidOptionValue{}
-		}
-
-		nicID := v.NIC
-		if v.InterfaceAddr.Unspecified() {
-			if nicID == 0 {
-				if r, err := e.stack.FindRoute(0, tcpip.Address{}, v.MulticastAddr, e.netProto, false /* multicastLoop */); err == nil {
-					nicID = r.NICID()
-					r.Release()
-				}
-			}
-		} else {
-			nicID = e.stack.CheckLocalAddress(nicID, e.netProto, v.InterfaceAddr)
-		}
-		if nicID == 0 {
-			return &tcpip.ErrUnknownDevice{}
+		mem, err := e.multicastMembershipFor(v.NIC, v.InterfaceAddr, v.MulticastAddr)
+		if err != nil {
+			return err
 		}
 
-		memToRemove := multicastMembership{nicID: nicID, multicastAddr: v.MulticastAddr}
-
 		e.mu.Lock()
 		defer e.mu.Unlock()
 
-		if _, ok := e.multicastMemberships[memToRemove]; !ok {
+		if !isMemberFilter(e.multicastFilterLocked(mem)) {
 			return &tcpip.ErrBadLocalAddress{}
 		}
 
-		if err := e.stack.LeaveGroup(e.netProto, nicID, v.MulticastAddr); err != nil {
-			return err
+		return e.setMulticastFilterLocked(mem, tcpip.MulticastSourceFilter{})
+
+	case *tcpip.AddSourceMembershipOption:
+		return e.changeMulticastSource(tcpip.SourceMembershipOption(*v), tcpip.MulticastFilterInclude, true /* add */)
+
+	case *tcpip.RemoveSourceMembershipOption:
+		return e.changeMulticastSource(tcpip.SourceMembershipOption(*v), tcpip.MulticastFilterInclude, false /* add */)
+
+	case *tcpip.BlockSourceOption:
+		return e.changeMulticastSource(tcpip.SourceMembershipOption(*v), tcpip.MulticastFilterExclude, true /* add */)
+
+	case *tcpip.UnblockSourceOption:
+		return e.changeMulticastSource(tcpip.SourceMembershipOption(*v), tcpip.MulticastFilterExclude, false /* add */)
+
+	case *tcpip.MulticastSourceFilterOption:
+		return e.setMulticastSourceFilter(v)
+
+	case *tcpip.IPv6PacketInfoOption:
+		// The source address must belong to the stack, and to the interface if
+		// one is specified.
+		if v.Addr.BitLen() != 0 && e.stack.CheckLocalAddress(v.NIC, header.IPv6ProtocolNumber, v.Addr) == 0 {
+			return &tcpip.ErrInvalidOptionValue{}
 		}
 
-		delete(e.multicastMemberships, memToRemove)
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.ipv6PacketInfo = tcpip.IPv6PacketInfo(*v)
 
 	case *tcpip.SocketDetachFilterOption:
 		return nil
@@ -1039,6 +1275,28 @@ func (e *Endpoint) GetSockOpt(opt tcpip.GettableSocketOption) tcpip.Error {
 		}
 		e.mu.Unlock()
 
+	case *tcpip.IPv6PacketInfoOption:
+		e.mu.RLock()
+		*o = tcpip.IPv6PacketInfoOption(e.ipv6PacketInfo)
+		e.mu.RUnlock()
+
+	case *tcpip.MulticastSourceFilterOption:
+		mem, err := e.multicastMembershipFor(o.NIC, o.InterfaceAddr, o.MulticastAddr)
+		if err != nil {
+			return err
+		}
+
+		e.multicastMu.RLock()
+		filter, ok := e.multicastMemberships[mem]
+		e.multicastMu.RUnlock()
+		if !ok {
+			return &tcpip.ErrBadLocalAddress{}
+		}
+		o.Filter = tcpip.MulticastSourceFilter{
+			Mode:    filter.Mode,
+			Sources: slices.Clone(filter.Sources),
+		}
+
 	default:
 		return &tcpip.ErrUnknownProtocolOption{}
 	}
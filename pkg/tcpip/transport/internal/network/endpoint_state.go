@@ -29,11 +29,13 @@ func (e *Endpoint) Resume(s *stack.Stack) {
 
 	e.stack = s
 
-	for m := range e.multicastMemberships {
-		if err := e.stack.JoinGroup(e.netProto, m.nicID, m.multicastAddr); err != nil {
-			panic(fmt.Sprintf("e.stack.JoinGroup(%d, %d, %s): %s", e.netProto, m.nicID, m.multicastAddr, err))
+	e.multicastMu.RLock()
+	for m, filter := range e.multicastMemberships {
+		if err := e.changeGroupFilter(m, tcpip.MulticastSourceFilter{}, filter); err != nil {
+			panic(fmt.Sprintf("e.changeGroupFilter(%#v, {}, %#v): %s", m, filter, err))
 		}
 	}
+	e.multicastMu.RUnlock()
 
 	info := e.Info()
 
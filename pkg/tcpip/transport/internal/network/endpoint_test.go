@@ -329,6 +329,196 @@ func TestBindNICID(t *testing.T) {
 	}
 }
 
+func TestIPv6PacketInfo(t *testing.T) {
+	const (
+		nicID        = 1
+		tclass       = 0x40
+		hopLimit     = 7
+		socketTClass = 0x80
+	)
+
+	otherNICAddr := testutil.MustParse6("a::2")
+	unknownAddr := testutil.MustParse6("c::1")
+
+	tests := []struct {
+		name            string
+		option          tcpip.IPv6PacketInfoOption
+		controlMessages tcpip.SendableControlMessages
+		wantErr         tcpip.Error
+		wantLocalAddr   tcpip.Address
+		wantTClass      uint8
+		wantHopLimit    uint8
+	}{
+		{
+			name:          "none",
+			wantLocalAddr: ipv6NICAddr,
+			wantTClass:    socketTClass,
+			wantHopLimit:  ipv6.DefaultTTL,
+		},
+		{
+			name:          "option",
+			option:        tcpip.IPv6PacketInfoOption{Addr: otherNICAddr},
+			wantLocalAddr: otherNICAddr,
+			wantTClass:    socketTClass,
+			wantHopLimit:  ipv6.DefaultTTL,
+		},
+		{
+			name: "control message",
+			controlMessages: tcpip.SendableControlMessages{
+				HasIPv6PacketInfo: true,
+				IPv6PacketInfo:    tcpip.IPv6PacketInfo{Addr: otherNICAddr, NIC: nicID},
+			},
+			wantLocalAddr: otherNICAddr,
+			wantTClass:    socketTClass,
+			wantHopLimit:  ipv6.DefaultTTL,
+		},
+		{
+			name:   "control message overrides option",
+			option: tcpip.IPv6PacketInfoOption{Addr: otherNICAddr},
+			controlMessages: tcpip.SendableControlMessages{
+				HasIPv6PacketInfo: true,
+				IPv6PacketInfo:    tcpip.IPv6PacketInfo{Addr: ipv6NICAddr},
+			},
+			wantLocalAddr: ipv6NICAddr,
+			wantTClass:    socketTClass,
+			wantHopLimit:  ipv6.DefaultTTL,
+		},
+		{
+			name: "unknown NIC",
+			controlMessages: tcpip.SendableControlMessages{
+				HasIPv6PacketInfo: true,
+				IPv6PacketInfo:    tcpip.IPv6PacketInfo{Addr: ipv6NICAddr, NIC: nicID + 1},
+			},
+			wantErr: &tcpip.ErrBadLocalAddress{},
+		},
+		{
+			name: "unknown address",
+			controlMessages: tcpip.SendableControlMessages{
+				HasIPv6PacketInfo: true,
+				IPv6PacketInfo:    tcpip.IPv6PacketInfo{Addr: unknownAddr},
+			},
+			wantErr: &tcpip.ErrBadLocalAddress{},
+		},
+		{
+			name: "traffic class and hop limit",
+			controlMessages: tcpip.SendableControlMessages{
+				HasTClass:   true,
+				TClass:      tclass,
+				HasHopLimit: true,
+				HopLimit:    hopLimit,
+			},
+			wantLocalAddr: ipv6NICAddr,
+			wantTClass:    tclass,
+			wantHopLimit:  hopLimit,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := stack.New(stack.Options{
+				NetworkProtocols:   []stack.NetworkProtocolFactory{ipv6.NewProtocol},
+				TransportProtocols: []stack.TransportProtocolFactory{udp.NewProtocol},
+				Clock:              &faketime.NullClock{},
+			})
+			defer s.Destroy()
+			e := channel.New(1, header.IPv6MinimumMTU, "")
+			defer e.Close()
+			if err := s.CreateNIC(nicID, e); err != nil {
+				t.Fatalf("s.CreateNIC(%d, _): %s", nicID, err)
+			}
+			for _, addr := range []tcpip.Address{ipv6NICAddr, otherNICAddr} {
+				protocolAddr := tcpip.ProtocolAddress{
+					Protocol:          ipv6.ProtocolNumber,
+					AddressWithPrefix: addr.WithPrefix(),
+				}
+				if err := s.AddProtocolAddress(nicID, protocolAddr, stack.AddressProperties{}); err != nil {
+					t.Fatalf("s.AddProtocolAddress(%d, %+v, {}): %s", nicID, protocolAddr, err)
+				}
+			}
+			s.SetRouteTable([]tcpip.Route{{Destination: ipv6RemoteAddr.WithPrefix().Subnet(), NIC: nicID}})
+
+			var ops tcpip.SocketOptions
+			var ep network.Endpoint
+			var wq waiter.Queue
+			ep.Init(s, ipv6.ProtocolNumber, udp.ProtocolNumber, &ops, &wq)
+			defer ep.Close()
+
+			if err := ep.SetSockOptInt(tcpip.IPv6TrafficClassOption, socketTClass); err != nil {
+				t.Fatalf("ep.SetSockOptInt(tcpip.IPv6TrafficClassOption, %d): %s", socketTClass, err)
+			}
+			if err := ep.SetSockOpt(&test.option); err != nil {
+				t.Fatalf("ep.SetSockOpt(&%#v): %s", test.option, err)
+			}
+			var got tcpip.IPv6PacketInfoOption
+			if err := ep.GetSockOpt(&got); err != nil {
+				t.Fatalf("ep.GetSockOpt(_): %s", err)
+			}
+			if got != test.option {
+				t.Errorf("got ep.GetSockOpt(_) = %#v, want = %#v", got, test.option)
+			}
+
+			connectAddr := tcpip.FullAddress{Addr: ipv6RemoteAddr}
+			if err := ep.Connect(connectAddr); err != nil {
+				t.Fatalf("ep.Connect(%#v): %s", connectAddr, err)
+			}
+
+			opts := tcpip.WriteOptions{ControlMessages: test.controlMessages}
+			ctx, err := ep.AcquireContextForWrite(opts)
+			if diff := cmp.Diff(test.wantErr, err); diff != "" {
+				t.Fatalf("ep.AcquireContextForWrite(%#v) error mismatch (-want +got):\n%s", opts, diff)
+			}
+			if err != nil {
+				return
+			}
+			defer ctx.Release()
+			if got := ctx.PacketInfo().LocalAddress; got != test.wantLocalAddr {
+				t.Errorf("got ctx.PacketInfo().LocalAddress = %s, want = %s", got, test.wantLocalAddr)
+			}
+
+			pkt := stack.NewPacketBuffer(stack.PacketBufferOptions{
+				ReserveHeaderBytes: int(ctx.PacketInfo().MaxHeaderLength),
+			})
+			defer pkt.DecRef()
+			if err := ctx.WritePacket(pkt, false /* headerIncluded */); err != nil {
+				t.Fatalf("ctx.WritePacket(_, false): %s", err)
+			}
+			sent := e.Read()
+			if sent == nil {
+				t.Fatal("expected packet to be read from link endpoint")
+			}
+			defer sent.DecRef()
+			payload := stack.PayloadSince(sent.NetworkHeader())
+			defer payload.Release()
+			checker.IPv6(t, payload,
+				checker.SrcAddr(test.wantLocalAddr),
+				checker.DstAddr(ipv6RemoteAddr),
+				checker.TOS(test.wantTClass, 0),
+				checker.TTL(test.wantHopLimit),
+			)
+		})
+	}
+}
+
+func TestIPv6PacketInfoOptionUnknownAddress(t *testing.T) {
+	s := stack.New(stack.Options{
+		NetworkProtocols:   []stack.NetworkProtocolFactory{ipv6.NewProtocol},
+		TransportProtocols: []stack.TransportProtocolFactory{udp.NewProtocol},
+		Clock:              &faketime.NullClock{},
+	})
+	defer s.Destroy()
+
+	var ops tcpip.SocketOptions
+	var ep network.Endpoint
+	var wq waiter.Queue
+	ep.Init(s, ipv6.ProtocolNumber, udp.ProtocolNumber, &ops, &wq)
+	defer ep.Close()
+
+	opt := tcpip.IPv6PacketInfoOption{Addr: ipv6NICAddr}
+	if diff := cmp.Diff(&tcpip.ErrInvalidOptionValue{}, ep.SetSockOpt(&opt)); diff != "" {
+		t.Errorf("ep.SetSockOpt(&%#v) error mismatch (-want +got):\n%s", opt, diff)
+	}
+}
+
 func TestMain(m *testing.M) {
 	refs.SetLeakMode(refs.LeaksPanic)
 	code := m.Run()
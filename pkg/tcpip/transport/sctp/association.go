@@ -0,0 +1,875 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sctp
+
+import (
+	"encoding/binary"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/seqnum"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+// assocState is the state of an association, as per RFC 9260 section 4.
+type assocState int
+
+const (
+	assocClosed assocState = iota
+	assocCookieWait
+	assocCookieEchoed
+	assocEstablished
+	assocShutdownPending
+	assocShutdownSent
+	assocShutdownReceived
+	assocShutdownAckSent
+)
+
+// connected returns true iff the association completed its handshake and
+// hasn't been terminated.
+func (s assocState) connected() bool {
+	return s >= assocEstablished
+}
+
+// sockState returns the state as reported by SCTP_STATUS.
+func (s assocState) sockState() tcpip.SCTPAssocState {
+	switch s {
+	case assocCookieWait:
+		return tcpip.SCTPStateCookieWait
+	case assocCookieEchoed:
+		return tcpip.SCTPStateCookieEchoed
+	case assocEstablished:
+		return tcpip.SCTPStateEstablished
+	case assocShutdownPending:
+		return tcpip.SCTPStateShutdownPending
+	case assocShutdownSent:
+		return tcpip.SCTPStateShutdownSent
+	case assocShutdownReceived:
+		return tcpip.SCTPStateShutdownReceived
+	case assocShutdownAckSent:
+		return tcpip.SCTPStateShutdownAckSent
+	default:
+		return tcpip.SCTPStateClosed
+	}
+}
+
+// heartbeatInfoSize is the size of the Heartbeat Info parameters sent by
+// associations. They hold the time the heartbeat was sent at and a nonce.
+const heartbeatInfoSize = header.SCTPParameterHeaderSize + 16
+
+// association is an SCTP association between an endpoint and a single peer
+// address.
+//
+// All fields are protected by ep.mu and all methods must be called with ep.mu
+// held.
+//
+// +stateify savable
+type association struct {
+	ep *Endpoint
+	id tcpip.SCTPAssocID
+
+	state assocState
+
+	// err is the error the association was terminated with.
+	err tcpip.Error
+
+	// connectNotified indicates whether the completion of the association
+	// setup was reported by Connect.
+	connectNotified bool
+
+	netProto tcpip.NetworkProtocolNumber
+	local    tcpip.FullAddress
+	remote   tcpip.FullAddress
+
+	// route is the route to the peer. It is released when the association
+	// is terminated.
+	route *stack.Route `state:"nosave"`
+
+	// localTag is the verification tag the peer must use and peerTag is the
+	// one packets sent to the peer must carry.
+	localTag uint32
+	peerTag  uint32
+
+	// initAttempts is the number of times the INIT or COOKIE ECHO chunk was
+	// sent.
+	initAttempts int
+
+	// cookie is the state cookie echoed to the peer while the association
+	// is in the COOKIE-ECHOED state.
+	cookie []byte
+
+	// t1Timer retransmits INIT and COOKIE ECHO chunks, t2Timer SHUTDOWN and
+	// SHUTDOWN ACK chunks, and rtxTimer DATA chunks.
+	t1Timer  tcpip.Timer `state:"nosave"`
+	t2Timer  tcpip.Timer `state:"nosave"`
+	rtxTimer tcpip.Timer `state:"nosave"`
+
+	// sackTimer delays acknowledgements and hbTimer sends heartbeats.
+	sackTimer tcpip.Timer `state:"nosave"`
+	hbTimer   tcpip.Timer `state:"nosave"`
+
+	// pmtu is the path MTU.
+	pmtu int
+
+	// srtt and rttvar are the smoothed round trip time and its variation.
+	// They are only valid once rttMeasured is true.
+	srtt        time.Duration
+	rttvar      time.Duration
+	rttMeasured bool
+	rto         time.Duration
+
+	rtoInitial     time.Duration
+	rtoMin         time.Duration
+	rtoMax         time.Duration
+	maxInitRTO     time.Duration
+	maxInitTries   int
+	maxRetrans     int
+	pathMaxRetrans int
+	hbEnabled      bool
+	hbInterval     time.Duration
+	sackDelay      time.Duration
+
+	// errorCount is the number of consecutive retransmission timeouts and
+	// unanswered heartbeats.
+	errorCount int
+
+	// hbOutstanding indicates whether a heartbeat awaits acknowledgement and
+	// hbNonce is its nonce.
+	hbOutstanding bool
+	hbNonce       uint64
+
+	snd sender
+	rcv receiver
+}
+
+// newAssociation creates an association of ep to remote that sends through r,
+// taking ownership of r.
+//
+// +checklocks:ep.mu
+func (ep *Endpoint) newAssociation(r *stack.Route, remote tcpip.FullAddress) *association {
+	a := &association{
+		ep:             ep,
+		netProto:       r.NetProto(),
+		local:          tcpip.FullAddress{NIC: r.NICID(), Addr: r.LocalAddress(), Port: ep.localPort},
+		remote:         tcpip.FullAddress{Addr: remote.Addr, Port: remote.Port},
+		route:          r,
+		pmtu:           int(r.MTU()),
+		rtoInitial:     ep.rtoInitial,
+		rtoMin:         ep.rtoMin,
+		rtoMax:         ep.rtoMax,
+		rto:            ep.rtoInitial,
+		maxInitRTO:     ep.initMsg.MaxInitTimeout,
+		maxInitTries:   int(ep.initMsg.MaxAttempts),
+		maxRetrans:     ep.assocMaxRetrans,
+		pathMaxRetrans: ep.pathMaxRetrans,
+		hbEnabled:      ep.hbEnabled,
+		hbInterval:     ep.hbInterval,
+		sackDelay:      ep.sackDelay,
+	}
+	if a.maxInitRTO == 0 {
+		a.maxInitRTO = a.rtoMax
+	}
+	a.snd.init(a)
+	a.rcv.init(a)
+	ep.addAssociationLocked(a)
+	return a
+}
+
+// now returns the current time.
+func (a *association) now() tcpip.MonotonicTime {
+	return a.ep.stack.Clock().NowMonotonic()
+}
+
+// startTimer arms *t to run f after d, replacing any timer already armed. f is
+// run with ep.mu held, and packets it queues are sent once ep.mu is released.
+func (a *association) startTimer(t *tcpip.Timer, d time.Duration, f func()) {
+	a.stopTimer(t)
+	var timer tcpip.Timer
+	timer = a.ep.stack.Clock().AfterFunc(d, func() {
+		a.ep.mu.Lock()
+		// The timer may have been stopped or replaced while it waited for
+		// the lock.
+		if *t != timer {
+			a.ep.mu.Unlock()
+			return
+		}
+		*t = nil
+		f()
+		a.ep.unlockAndFlush()
+	})
+	*t = timer
+}
+
+// stopTimer stops *t if it is armed.
+func (a *association) stopTimer(t *tcpip.Timer) {
+	if *t != nil {
+		(*t).Stop()
+		*t = nil
+	}
+}
+
+// stopTimers stops all the timers of the association.
+func (a *association) stopTimers() {
+	a.stopTimer(&a.t1Timer)
+	a.stopTimer(&a.t2Timer)
+	a.stopTimer(&a.rtxTimer)
+	a.stopTimer(&a.sackTimer)
+	a.stopTimer(&a.hbTimer)
+}
+
+// resumeTimers rearms the timers of a restored association.
+func (a *association) resumeTimers() {
+	switch a.state {
+	case assocCookieWait, assocCookieEchoed:
+		a.startTimer(&a.t1Timer, a.rto, a.onT1Timeout)
+	case assocShutdownSent, assocShutdownAckSent:
+		a.startTimer(&a.t2Timer, a.rto, a.onT2Timeout)
+	}
+	if a.snd.outstanding() {
+		a.startTimer(&a.rtxTimer, a.rto, a.snd.onRetransmitTimeout)
+	}
+	if a.rcv.sackPending {
+		a.startTimer(&a.sackTimer, a.sackDelay, a.rcv.sendSack)
+	}
+	a.startHeartbeatTimer()
+}
+
+// queueChunks queues a packet holding chunks for transmission to the peer.
+// chunks must be padded.
+func (a *association) queueChunks(chunks []byte) {
+	a.queueChunksWithTag(a.peerTag, chunks)
+}
+
+// queueChunksWithTag is like queueChunks, but the packet carries tag.
+func (a *association) queueChunksWithTag(tag uint32, chunks []byte) {
+	a.ep.queuePacket(a.route, header.SCTPFields{
+		SrcPort:         a.local.Port,
+		DstPort:         a.remote.Port,
+		VerificationTag: tag,
+	}, chunks)
+}
+
+// newChunk returns a padded chunk of type typ with the given value.
+func newChunk(typ header.SCTPChunkType, flags uint8, value []byte) []byte {
+	l := header.SCTPChunkHeaderSize + len(value)
+	b := make([]byte, header.SCTPPaddedLength(l))
+	header.SCTPChunk(b[:l]).EncodeHeader(typ, flags)
+	copy(b[header.SCTPChunkHeaderSize:], value)
+	return b
+}
+
+// newParameter returns a padded parameter of type typ with the given value.
+func newParameter(typ uint16, value []byte) []byte {
+	l := header.SCTPParameterHeaderSize + len(value)
+	b := make([]byte, header.SCTPPaddedLength(l))
+	header.SCTPParameter(b[:l]).Encode(typ)
+	copy(b[header.SCTPParameterHeaderSize:], value)
+	return b
+}
+
+// newInitChunk returns a padded INIT or INIT ACK chunk with the given fixed
+// fields and parameters.
+func newInitChunk(typ header.SCTPChunkType, f *header.SCTPInitChunkFields, params []byte) []byte {
+	l := header.SCTPInitChunkMinimumSize + len(params)
+	b := make([]byte, header.SCTPPaddedLength(l))
+	copy(b[header.SCTPInitChunkMinimumSize:], params)
+	header.SCTPInitChunk(b[:l]).Encode(typ, f)
+	return b
+}
+
+// validInit returns true iff c is an INIT or INIT ACK chunk whose fixed fields
+// are acceptable, as per RFC 9260 section 3.3.2.
+func validInit(c header.SCTPInitChunk) bool {
+	if len(c) < header.SCTPInitChunkMinimumSize {
+		return false
+	}
+	if c.InitiateTag() == 0 || c.OutboundStreams() == 0 || c.InboundStreams() == 0 {
+		return false
+	}
+	_, ok := header.ParseSCTPParameters(c.Parameters())
+	return ok
+}
+
+// randomTag returns a random non-zero verification tag.
+func (ep *Endpoint) randomTag() uint32 {
+	rng := ep.stack.SecureRNG()
+	for {
+		if tag := rng.Uint32(); tag != 0 {
+			return tag
+		}
+	}
+}
+
+// sendInit starts the association setup by sending an INIT chunk.
+func (a *association) sendInit() {
+	a.state = assocCookieWait
+	a.localTag = a.ep.randomTag()
+	rng := a.ep.stack.SecureRNG()
+	a.snd.setInitialTSN(rng.Uint32())
+	a.snd.outStreams = a.ep.initMsg.NumOutStreams
+	a.initAttempts = 0
+	a.ep.stack.Stats().SCTP.ActiveEstablishes.Increment()
+	a.retransmitInit()
+}
+
+// retransmitInit sends the INIT or COOKIE ECHO chunk of a handshake and arms
+// the T1 timer.
+func (a *association) retransmitInit() {
+	a.initAttempts++
+	switch a.state {
+	case assocCookieWait:
+		chunk := newInitChunk(header.SCTPChunkInit, &header.SCTPInitChunkFields{
+			InitiateTag:                    a.localTag,
+			AdvertisedReceiverWindowCredit: a.rcv.window(),
+			OutboundStreams:                a.ep.initMsg.NumOutStreams,
+			InboundStreams:                 a.ep.initMsg.MaxInStreams,
+			InitialTSN:                     a.snd.nextTSN,
+		}, a.supportedAddressTypes())
+		// The INIT chunk is always sent with a zero verification tag.
+		a.queueChunksWithTag(0, chunk)
+	case assocCookieEchoed:
+		a.queueChunks(newChunk(header.SCTPChunkCookieEcho, 0, a.cookie))
+	}
+	timeout := a.rto
+	if timeout > a.maxInitRTO {
+		timeout = a.maxInitRTO
+	}
+	a.startTimer(&a.t1Timer, timeout, a.onT1Timeout)
+}
+
+// supportedAddressTypes returns the Supported Address Types parameter of the
+// INIT chunks sent by the association.
+func (a *association) supportedAddressTypes() []byte {
+	var v []byte
+	switch a.netProto {
+	case header.IPv4ProtocolNumber:
+		v = binary.BigEndian.AppendUint16(v, uint16(header.SCTPParameterIPv4Address))
+	case header.IPv6ProtocolNumber:
+		v = binary.BigEndian.AppendUint16(v, uint16(header.SCTPParameterIPv6Address))
+	}
+	return newParameter(uint16(header.SCTPParameterSupportedAddressTypes), v)
+}
+
+// onT1Timeout retransmits the INIT or COOKIE ECHO chunk of a handshake, as per
+// RFC 9260 section 5.1.
+func (a *association) onT1Timeout() {
+	if a.state != assocCookieWait && a.state != assocCookieEchoed {
+		return
+	}
+	if a.initAttempts >= a.maxInitTries {
+		a.terminate(&tcpip.ErrTimeout{})
+		return
+	}
+	a.rto = min(2*a.rto, a.rtoMax)
+	a.retransmitInit()
+}
+
+// establish moves the association to the ESTABLISHED state.
+func (a *association) establish() {
+	a.state = assocEstablished
+	a.stopTimer(&a.t1Timer)
+	a.cookie = nil
+	a.errorCount = 0
+	a.startHeartbeatTimer()
+	a.ep.associationEstablishedLocked(a)
+	a.snd.transmit()
+}
+
+// setPeerInit records the parameters of the peer's INIT or INIT ACK chunk,
+// which were agreed upon as described by cookie.
+func (a *association) setPeerInit(c *stateCookie) {
+	a.peerTag = c.peerTag
+	a.snd.outStreams = c.outStreams
+	a.snd.peerRwnd = c.peerRwnd
+	a.rcv.setInitialTSN(c.peerInitialTSN, c.inStreams)
+}
+
+// handlePacket handles a packet the peer sent to the association.
+func (a *association) handlePacket(hdr header.SCTP, chunks []header.SCTPChunk) {
+	if !a.checkVerificationTag(hdr, chunks) {
+		return
+	}
+	a.handleChunks(chunks)
+}
+
+// checkVerificationTag returns true iff the packet carries the verification
+// tag required by RFC 9260 section 8.5.
+func (a *association) checkVerificationTag(hdr header.SCTP, chunks []header.SCTPChunk) bool {
+	tag := hdr.VerificationTag()
+	switch first := chunks[0]; first.Type() {
+	case header.SCTPChunkInit:
+		// INIT chunks must not be bundled and always carry a zero tag.
+		return tag == 0 && len(chunks) == 1
+	case header.SCTPChunkAbort, header.SCTPChunkShutdownComplete:
+		// Reflected tags are only allowed when the peer has no state for
+		// the association, as per RFC 9260 section 8.5.1.
+		if first.Flags()&header.SCTPFlagTBit != 0 {
+			return a.state != assocCookieWait && tag == a.peerTag
+		}
+		return tag == a.localTag
+	case header.SCTPChunkShutdownAck:
+		if tag == a.localTag {
+			return true
+		}
+		// A SHUTDOWN ACK received in COOKIE-WAIT or COOKIE-ECHOED comes from
+		// a peer that lost its state: it is treated as out of the blue.
+		return false
+	case header.SCTPChunkCookieEcho:
+		// The tag of a COOKIE ECHO chunk is checked against the cookie.
+		return true
+	default:
+		return tag == a.localTag
+	}
+}
+
+// handleChunks handles the chunks of a packet whose verification tag was
+// checked.
+func (a *association) handleChunks(chunks []header.SCTPChunk) {
+	gotData := false
+	immediate := false
+	for _, c := range chunks {
+		if a.state == assocClosed {
+			return
+		}
+		switch c.Type() {
+		case header.SCTPChunkData:
+			d := header.SCTPDataChunk(c)
+			if !a.state.connected() && a.state != assocCookieEchoed {
+				continue
+			}
+			gotData = true
+			if c.Flags()&header.SCTPDataFlagImmediate != 0 {
+				immediate = true
+			}
+			if !a.rcv.handleData(d) {
+				return
+			}
+		case header.SCTPChunkInit:
+			// Simultaneous setups and peer restarts are not supported, so
+			// INIT chunks received by an association are discarded.
+			return
+		case header.SCTPChunkInitAck:
+			a.handleInitAck(header.SCTPInitChunk(c))
+		case header.SCTPChunkSack:
+			if a.state.connected() {
+				a.snd.handleSack(header.SCTPSackChunk(c))
+			}
+		case header.SCTPChunkHeartbeat:
+			if a.state.connected() {
+				a.queueChunks(newChunk(header.SCTPChunkHeartbeatAck, 0, c.Value()))
+			}
+		case header.SCTPChunkHeartbeatAck:
+			a.handleHeartbeatAck(c)
+		case header.SCTPChunkAbort:
+			a.handleAbort()
+			return
+		case header.SCTPChunkShutdown:
+			a.handleShutdown(header.SCTPShutdownChunk(c))
+		case header.SCTPChunkShutdownAck:
+			a.handleShutdownAck()
+		case header.SCTPChunkError:
+			a.handleError(c)
+		case header.SCTPChunkCookieEcho:
+			a.handleCookieEcho(c)
+		case header.SCTPChunkCookieAck:
+			if a.state == assocCookieEchoed {
+				a.establish()
+			}
+		case header.SCTPChunkShutdownComplete:
+			if a.state == assocShutdownAckSent {
+				a.ep.stack.Stats().SCTP.Shutdowns.Increment()
+				a.terminate(nil)
+			}
+			return
+		default:
+			if !a.handleUnknownChunk(c) {
+				return
+			}
+		}
+	}
+	if gotData && a.state != assocClosed {
+		a.rcv.packetReceived(immediate)
+	}
+}
+
+// handleUnknownChunk handles a chunk of an unknown type as per RFC 9260
+// section 3.2.
+//
+// Returns false if the rest of the packet must be discarded.
+func (a *association) handleUnknownChunk(c header.SCTPChunk) bool {
+	if c.Type()&header.SCTPChunkTypeReportBit != 0 {
+		cause := newParameter(uint16(header.SCTPCauseUnrecognizedChunkType), c)
+		a.queueChunks(newChunk(header.SCTPChunkError, 0, cause))
+	}
+	return c.Type()&header.SCTPChunkTypeSkipBit != 0
+}
+
+// handleInitAck handles the INIT ACK chunk answering our INIT.
+func (a *association) handleInitAck(c header.SCTPInitChunk) {
+	if a.state != assocCookieWait {
+		return
+	}
+	if !validInit(c) {
+		a.abortWithCause(&tcpip.ErrConnectionRefused{}, header.SCTPCauseProtocolViolation, nil)
+		return
+	}
+	params, _ := header.ParseSCTPParameters(c.Parameters())
+	var cookie []byte
+	for _, p := range params {
+		switch p.Type() {
+		case header.SCTPParameterStateCookie:
+			cookie = append([]byte(nil), p.Value()...)
+		default:
+			// Parameters with the upper bits clear stop the processing of
+			// the chunk, as per RFC 9260 section 3.2.1.
+			if uint16(p.Type())&0x8000 == 0 && !knownParameter(p.Type()) {
+				return
+			}
+		}
+	}
+	if cookie == nil {
+		a.abortWithCause(&tcpip.ErrConnectionRefused{}, header.SCTPCauseMissingParameter, nil)
+		return
+	}
+
+	a.stopTimer(&a.t1Timer)
+	a.setPeerInit(&stateCookie{
+		peerTag:        c.InitiateTag(),
+		peerInitialTSN: c.InitialTSN(),
+		peerRwnd:       c.AdvertisedReceiverWindowCredit(),
+		outStreams:     min(a.ep.initMsg.NumOutStreams, c.InboundStreams()),
+		inStreams:      min(a.ep.initMsg.MaxInStreams, c.OutboundStreams()),
+	})
+	a.cookie = cookie
+	a.state = assocCookieEchoed
+	a.initAttempts = 0
+	a.retransmitInit()
+}
+
+// knownParameter returns true iff typ is a parameter type the association
+// understands, even if it ignores it.
+func knownParameter(typ header.SCTPParameterType) bool {
+	switch typ {
+	case header.SCTPParameterIPv4Address, header.SCTPParameterIPv6Address,
+		header.SCTPParameterCookiePreservative, header.SCTPParameterSupportedAddressTypes,
+		header.SCTPParameterStateCookie, header.SCTPParameterHeartbeatInfo:
+		return true
+	default:
+		return false
+	}
+}
+
+// handleCookieEcho handles a COOKIE ECHO chunk received by an existing
+// association, as per RFC 9260 section 5.2.4. Only the case of a peer that
+// didn't receive our COOKIE ACK is supported.
+func (a *association) handleCookieEcho(c header.SCTPChunk) {
+	cookie, ok := a.ep.protocol.decodeCookie(c.Value(), a.transportEndpointID())
+	if !ok || cookie.localTag != a.localTag || cookie.peerTag != a.peerTag {
+		return
+	}
+	if a.state == assocCookieEchoed {
+		a.establish()
+	}
+	a.queueChunks(newChunk(header.SCTPChunkCookieAck, 0, nil))
+}
+
+// transportEndpointID returns the ID of the association's transport
+// addresses.
+func (a *association) transportEndpointID() stack.TransportEndpointID {
+	return stack.TransportEndpointID{
+		LocalPort:     a.local.Port,
+		LocalAddress:  a.local.Addr,
+		RemotePort:    a.remote.Port,
+		RemoteAddress: a.remote.Addr,
+	}
+}
+
+// handleError handles an ERROR chunk.
+func (a *association) handleError(c header.SCTPChunk) {
+	if a.state == assocCookieEchoed && isStaleCookieError(c) {
+		// Start over with a new INIT, as per RFC 9260 section 5.2.6.
+		a.rto = min(2*a.rto, a.rtoMax)
+		a.state = assocCookieWait
+		a.cookie = nil
+		a.retransmitInit()
+	}
+}
+
+// handleAbort handles an ABORT chunk.
+func (a *association) handleAbort() {
+	a.ep.stack.Stats().SCTP.Aborteds.Increment()
+	if a.state == assocCookieWait || a.state == assocCookieEchoed {
+		a.terminate(&tcpip.ErrConnectionRefused{})
+		return
+	}
+	a.terminate(&tcpip.ErrConnectionReset{})
+}
+
+// abort aborts the association, sending an ABORT chunk to the peer.
+func (a *association) abort(err tcpip.Error) {
+	a.abortWithCause(err, 0, nil)
+}
+
+// abortWithCause aborts the association, sending an ABORT chunk carrying an
+// error cause with the given code and value if code isn't zero.
+func (a *association) abortWithCause(err tcpip.Error, code header.SCTPCauseCode, value []byte) {
+	if a.state == assocClosed {
+		return
+	}
+	// The peer's verification tag is only known once its INIT ACK was
+	// received.
+	if a.state != assocCookieWait {
+		var cause []byte
+		if code != 0 {
+			cause = newParameter(uint16(code), value)
+		}
+		a.queueChunks(newChunk(header.SCTPChunkAbort, 0, cause))
+	}
+	a.ep.stack.Stats().SCTP.Aborteds.Increment()
+	a.terminate(err)
+}
+
+// shutdown gracefully shuts the association down once all the data queued for
+// the peer is acknowledged, as per RFC 9260 section 9.2.
+func (a *association) shutdown() {
+	switch a.state {
+	case assocCookieWait, assocCookieEchoed:
+		a.abortWithCause(&tcpip.ErrConnectionAborted{}, header.SCTPCauseUserInitiatedAbort, nil)
+	case assocEstablished:
+		a.state = assocShutdownPending
+		a.maybeShutdown()
+	}
+}
+
+// maybeShutdown progresses a graceful shutdown once all the data queued for
+// the peer is acknowledged.
+func (a *association) maybeShutdown() {
+	if a.snd.pending() {
+		return
+	}
+	switch a.state {
+	case assocShutdownPending:
+		a.state = assocShutdownSent
+		a.stopTimer(&a.hbTimer)
+		a.errorCount = 0
+		a.sendShutdown()
+	case assocShutdownReceived:
+		a.state = assocShutdownAckSent
+		a.stopTimer(&a.hbTimer)
+		a.errorCount = 0
+		a.sendShutdown()
+	}
+}
+
+// sendShutdown sends a SHUTDOWN or SHUTDOWN ACK chunk, depending on the state
+// of the association, and arms the T2 timer.
+func (a *association) sendShutdown() {
+	switch a.state {
+	case assocShutdownSent:
+		chunk := make([]byte, header.SCTPShutdownChunkSize)
+		header.SCTPShutdownChunk(chunk).Encode(a.rcv.cumTSN)
+		a.queueChunks(chunk)
+		// The SHUTDOWN chunk acknowledges the received data.
+		a.rcv.sackSent()
+	case assocShutdownAckSent:
+		a.queueChunks(newChunk(header.SCTPChunkShutdownAck, 0, nil))
+	default:
+		return
+	}
+	a.startTimer(&a.t2Timer, a.rto, a.onT2Timeout)
+}
+
+// onT2Timeout retransmits SHUTDOWN and SHUTDOWN ACK chunks.
+func (a *association) onT2Timeout() {
+	a.errorCount++
+	if a.errorCount > a.maxRetrans {
+		a.abort(&tcpip.ErrTimeout{})
+		return
+	}
+	a.rto = min(2*a.rto, a.rtoMax)
+	a.sendShutdown()
+}
+
+// handleShutdown handles a SHUTDOWN chunk.
+func (a *association) handleShutdown(c header.SCTPShutdownChunk) {
+	if len(c) < header.SCTPShutdownChunkSize || !a.state.connected() {
+		return
+	}
+	a.snd.handleCumulativeAck(c.CumulativeTSNAck())
+	if a.state == assocClosed {
+		return
+	}
+	switch a.state {
+	case assocEstablished, assocShutdownPending:
+		a.state = assocShutdownReceived
+		a.ep.peerShutdownLocked(a)
+		a.maybeShutdown()
+	case assocShutdownSent:
+		// Both sides are shutting down, as per RFC 9260 section 9.2.
+		a.state = assocShutdownAckSent
+		a.ep.peerShutdownLocked(a)
+		a.sendShutdown()
+	}
+}
+
+// handleShutdownAck handles a SHUTDOWN ACK chunk.
+func (a *association) handleShutdownAck() {
+	switch a.state {
+	case assocShutdownSent, assocShutdownAckSent:
+		a.queueChunks(newChunk(header.SCTPChunkShutdownComplete, 0, nil))
+		a.ep.stack.Stats().SCTP.Shutdowns.Increment()
+		a.terminate(nil)
+	}
+}
+
+// startHeartbeatTimer arms the heartbeat timer, as per RFC 9260 section 8.3.
+func (a *association) startHeartbeatTimer() {
+	if !a.hbEnabled || !a.state.connected() || a.state >= assocShutdownSent {
+		a.stopTimer(&a.hbTimer)
+		return
+	}
+	a.startTimer(&a.hbTimer, a.hbInterval+a.rto, a.onHeartbeatTimeout)
+}
+
+// onHeartbeatTimeout counts the loss of the previous heartbeat, if any, and
+// probes the peer with a new one.
+func (a *association) onHeartbeatTimeout() {
+	if a.hbOutstanding {
+		a.hbOutstanding = false
+		a.errorCount++
+		a.rto = min(2*a.rto, a.rtoMax)
+		if a.errorCount > a.maxRetrans {
+			a.abort(&tcpip.ErrTimeout{})
+			return
+		}
+	}
+	// Paths with outstanding data are already probed by retransmissions.
+	if !a.snd.outstanding() {
+		a.sendHeartbeat()
+	}
+	a.startHeartbeatTimer()
+}
+
+// sendHeartbeat sends a HEARTBEAT chunk.
+func (a *association) sendHeartbeat() {
+	rng := a.ep.stack.SecureRNG()
+	a.hbNonce = uint64(rng.Uint32())<<32 | uint64(rng.Uint32())
+	a.hbOutstanding = true
+	info := make([]byte, 16)
+	binary.BigEndian.PutUint64(info[0:], uint64(a.now().Sub(tcpip.MonotonicTime{})))
+	binary.BigEndian.PutUint64(info[8:], a.hbNonce)
+	a.queueChunks(newChunk(header.SCTPChunkHeartbeat, 0, newParameter(uint16(header.SCTPParameterHeartbeatInfo), info)))
+}
+
+// handleHeartbeatAck handles a HEARTBEAT ACK chunk.
+func (a *association) handleHeartbeatAck(c header.SCTPChunk) {
+	if !a.hbOutstanding {
+		return
+	}
+	params, ok := header.ParseSCTPParameters(c.Value())
+	if !ok || len(params) != 1 || params[0].Type() != header.SCTPParameterHeartbeatInfo || len(params[0]) != heartbeatInfoSize {
+		return
+	}
+	info := params[0].Value()
+	if binary.BigEndian.Uint64(info[8:]) != a.hbNonce {
+		return
+	}
+	a.hbOutstanding = false
+	a.errorCount = 0
+	sent := tcpip.MonotonicTime{}.Add(time.Duration(binary.BigEndian.Uint64(info[0:])))
+	a.updateRTO(a.now().Sub(sent))
+}
+
+// updateRTO updates the retransmission timeout with a round trip time sample,
+// as per RFC 9260 section 6.3.1.
+func (a *association) updateRTO(rtt time.Duration) {
+	if rtt < 0 {
+		return
+	}
+	if !a.rttMeasured {
+		a.srtt = rtt
+		a.rttvar = rtt / 2
+		a.rttMeasured = true
+	} else {
+		diff := a.srtt - rtt
+		if diff < 0 {
+			diff = -diff
+		}
+		a.rttvar = (3*a.rttvar + diff) / 4
+		a.srtt = (7*a.srtt + rtt) / 8
+	}
+	a.rto = min(max(a.srtt+4*a.rttvar, a.rtoMin), a.rtoMax)
+}
+
+// pathActive returns true iff the peer is considered reachable.
+func (a *association) pathActive() bool {
+	return a.errorCount <= a.pathMaxRetrans
+}
+
+// terminate moves the association to the CLOSED state and releases its
+// resources. err is the error reported to the user, if any.
+func (a *association) terminate(err tcpip.Error) {
+	if a.state == assocClosed {
+		return
+	}
+	a.state = assocClosed
+	a.err = err
+	a.stopTimers()
+	a.snd.reset()
+	a.rcv.reset()
+	a.ep.removeAssociationLocked(a)
+	if a.route != nil {
+		a.route.Release()
+		a.route = nil
+	}
+}
+
+// status returns the SCTP_STATUS of the association.
+func (a *association) status() tcpip.SCTPStatusOption {
+	return tcpip.SCTPStatusOption{
+		AssocID:            a.id,
+		State:              a.state.sockState(),
+		Rwnd:               a.snd.peerRwnd,
+		UnackedData:        uint16(min(len(a.snd.inflight), 0xffff)),
+		PendingData:        uint16(min(a.rcv.pendingChunks(), 0xffff)),
+		InStreams:          a.rcv.inStreams,
+		OutStreams:         a.snd.outStreams,
+		FragmentationPoint: uint32(a.snd.fragmentationPoint()),
+		PrimaryAddr:        a.remote,
+		PrimaryActive:      a.pathActive(),
+		PrimaryCwnd:        uint32(a.snd.cwnd),
+		PrimarySRTT:        a.srtt,
+		PrimaryRTO:         a.rto,
+		PrimaryMTU:         uint32(a.pmtu),
+	}
+}
+
+// notify queues waiter events for the endpoint's waiters.
+func (a *association) notify(mask waiter.EventMask) {
+	a.ep.notifyMask |= mask
+}
+
+// tsnLess returns true iff TSN a precedes TSN b in serial number arithmetic.
+func tsnLess(a, b uint32) bool {
+	return seqnum.Value(a).LessThan(seqnum.Value(b))
+}
+
+// tsnLessEq returns true iff TSN a precedes or equals TSN b in serial number
+// arithmetic.
+func tsnLessEq(a, b uint32) bool {
+	return seqnum.Value(a).LessThanEq(seqnum.Value(b))
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sctp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+const (
+	cookieBodySize = 36
+	cookieMACSize  = sha256.Size
+	cookieSize     = cookieBodySize + cookieMACSize
+)
+
+// stateCookie holds the state of an association that a passive endpoint hands
+// to its peer in an INIT ACK chunk instead of keeping it, as per RFC 9260
+// section 5.1.3. The peer echoes it back in a COOKIE ECHO chunk.
+type stateCookie struct {
+	// created is the time the cookie was created at.
+	created tcpip.MonotonicTime
+
+	// life is the lifetime of the cookie.
+	life time.Duration
+
+	localTag        uint32
+	peerTag         uint32
+	localInitialTSN uint32
+	peerInitialTSN  uint32
+	peerRwnd        uint32
+	outStreams      uint16
+	inStreams       uint16
+}
+
+// cookieMAC authenticates a cookie body for the association identified by id.
+func (p *protocol) cookieMAC(body []byte, id stack.TransportEndpointID) []byte {
+	mac := hmac.New(sha256.New, p.secret[:])
+	mac.Write(body)
+	mac.Write(id.LocalAddress.AsSlice())
+	mac.Write(id.RemoteAddress.AsSlice())
+	var ports [4]byte
+	binary.BigEndian.PutUint16(ports[0:], id.LocalPort)
+	binary.BigEndian.PutUint16(ports[2:], id.RemotePort)
+	mac.Write(ports[:])
+	return mac.Sum(nil)
+}
+
+// encodeCookie encodes and authenticates c for the association identified by
+// id.
+func (p *protocol) encodeCookie(c *stateCookie, id stack.TransportEndpointID) []byte {
+	b := make([]byte, cookieSize)
+	binary.BigEndian.PutUint64(b[0:], uint64(c.created.Sub(tcpip.MonotonicTime{})))
+	binary.BigEndian.PutUint32(b[8:], uint32(c.life/time.Millisecond))
+	binary.BigEndian.PutUint32(b[12:], c.localTag)
+	binary.BigEndian.PutUint32(b[16:], c.peerTag)
+	binary.BigEndian.PutUint32(b[20:], c.localInitialTSN)
+	binary.BigEndian.PutUint32(b[24:], c.peerInitialTSN)
+	binary.BigEndian.PutUint32(b[28:], c.peerRwnd)
+	binary.BigEndian.PutUint16(b[32:], c.outStreams)
+	binary.BigEndian.PutUint16(b[34:], c.inStreams)
+	copy(b[cookieBodySize:], p.cookieMAC(b[:cookieBodySize], id))
+	return b
+}
+
+// decodeCookie authenticates and decodes a cookie echoed by the peer of the
+// association identified by id.
+//
+// Returns false if the cookie is not one this protocol created for id.
+func (p *protocol) decodeCookie(b []byte, id stack.TransportEndpointID) (stateCookie, bool) {
+	if len(b) != cookieSize {
+		return stateCookie{}, false
+	}
+	if !hmac.Equal(b[cookieBodySize:], p.cookieMAC(b[:cookieBodySize], id)) {
+		return stateCookie{}, false
+	}
+	return stateCookie{
+		created:         tcpip.MonotonicTime{}.Add(time.Duration(binary.BigEndian.Uint64(b[0:]))),
+		life:            time.Duration(binary.BigEndian.Uint32(b[8:])) * time.Millisecond,
+		localTag:        binary.BigEndian.Uint32(b[12:]),
+		peerTag:         binary.BigEndian.Uint32(b[16:]),
+		localInitialTSN: binary.BigEndian.Uint32(b[20:]),
+		peerInitialTSN:  binary.BigEndian.Uint32(b[24:]),
+		peerRwnd:        binary.BigEndian.Uint32(b[28:]),
+		outStreams:      binary.BigEndian.Uint16(b[32:]),
+		inStreams:       binary.BigEndian.Uint16(b[34:]),
+	}, true
+}
+
+// expired returns true iff the cookie's lifetime ended before now.
+func (c *stateCookie) expired(now tcpip.MonotonicTime) bool {
+	return c.created.Add(c.life).Before(now)
+}
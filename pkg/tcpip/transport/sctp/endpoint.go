@@ -0,0 +1,1690 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sctp
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/sync"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/ports"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/internal/network"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+// endpointState is the state of an endpoint.
+type endpointState uint32
+
+const (
+	// stateInitial is the state of an endpoint that is neither bound nor
+	// connected.
+	stateInitial endpointState = iota
+
+	// stateBound is the state of a bound endpoint.
+	stateBound
+
+	// stateListen is the state of an endpoint that accepts associations.
+	stateListen
+
+	// stateConnecting is the state of a one-to-one endpoint whose
+	// association is being set up.
+	stateConnecting
+
+	// stateConnected is the state of a one-to-one endpoint whose association
+	// was set up.
+	stateConnected
+
+	// stateClosed is the state of a closed endpoint, or of a one-to-one
+	// endpoint whose association was terminated.
+	stateClosed
+)
+
+// message is a user message received from a peer.
+//
+// +stateify savable
+type message struct {
+	data  []byte
+	info  tcpip.SCTPSndRcvInfo
+	from  tcpip.FullAddress
+	assoc *association
+}
+
+// Endpoint represents an SCTP endpoint. This struct serves as the interface
+// between users of the endpoint and the protocol implementation; it is legal to
+// have concurrent goroutines make calls into the endpoint, they are properly
+// synchronized.
+//
+// It implements tcpip.Endpoint.
+//
+// +stateify savable
+type Endpoint struct {
+	tcpip.DefaultSocketOptionsHandler
+
+	// The following fields are initialized at creation time and do not
+	// change throughout the lifetime of the endpoint.
+	stack       *stack.Stack
+	protocol    *protocol
+	waiterQueue *waiter.Queue
+	net         network.Endpoint
+	stats       tcpip.TransportEndpointStats
+	ops         tcpip.SocketOptions
+
+	lastErrorMu sync.Mutex `state:"nosave"`
+	lastError   tcpip.Error
+
+	// mu protects all the following fields and the associations of the
+	// endpoint.
+	mu sync.Mutex `state:"nosave"`
+
+	// +checklocks:mu
+	oneToMany bool
+	// +checklocks:mu
+	state endpointState
+	// +checklocks:mu
+	closed bool
+	// +checklocks:mu
+	owner tcpip.PacketOwner
+
+	// Values used to reserve a port or register a transport endpoint.
+	// +checklocks:mu
+	portFlags ports.Flags
+	// +checklocks:mu
+	boundBindToDevice tcpip.NICID
+	// +checklocks:mu
+	boundPortFlags ports.Flags
+	// +checklocks:mu
+	effectiveNetProtos []tcpip.NetworkProtocolNumber
+	// +checklocks:mu
+	localPort uint16
+	// +checklocks:mu
+	remotePort uint16
+
+	// boundDest is the peer the port of an accepted endpoint is reserved
+	// for.
+	//
+	// +checklocks:mu
+	boundDest tcpip.FullAddress
+
+	// backlog is the maximum number of accepted endpoints that may await
+	// Accept, and acceptQueue holds them.
+	//
+	// +checklocks:mu
+	backlog int
+	// +checklocks:mu
+	acceptQueue []*Endpoint
+
+	// assocs and peers hold the associations of the endpoint, by ID and by
+	// peer address. nextAssocID is the ID of the next association.
+	//
+	// +checklocks:mu
+	assocs map[tcpip.SCTPAssocID]*association
+	// +checklocks:mu
+	peers map[tcpip.FullAddress]*association
+	// +checklocks:mu
+	nextAssocID tcpip.SCTPAssocID
+
+	// conn is the association of a one-to-one endpoint. It is kept once
+	// terminated to report its error.
+	//
+	// +checklocks:mu
+	conn *association
+
+	// peerClosed indicates whether the peer of a one-to-one endpoint shut
+	// its association down, and errReported whether the error the
+	// association was terminated with was returned by Read or Write.
+	//
+	// +checklocks:mu
+	peerClosed bool
+	// +checklocks:mu
+	errReported bool
+
+	// +checklocks:mu
+	rcvList []*message
+	// +checklocks:mu
+	rcvBufUsed int
+	// +checklocks:mu
+	rcvClosed bool
+	// +checklocks:mu
+	sndBufUsed int
+	// +checklocks:mu
+	sndClosed bool
+
+	// SCTP socket options.
+	//
+	// +checklocks:mu
+	noDelay bool
+	// +checklocks:mu
+	disableFragments bool
+	// +checklocks:mu
+	maxSeg int
+	// +checklocks:mu
+	initMsg tcpip.SCTPInitMsgOption
+	// +checklocks:mu
+	rtoInitial time.Duration
+	// +checklocks:mu
+	rtoMin time.Duration
+	// +checklocks:mu
+	rtoMax time.Duration
+	// +checklocks:mu
+	assocMaxRetrans int
+	// +checklocks:mu
+	pathMaxRetrans int
+	// +checklocks:mu
+	hbEnabled bool
+	// +checklocks:mu
+	hbInterval time.Duration
+	// +checklocks:mu
+	sackDelay time.Duration
+	// +checklocks:mu
+	cookieLife time.Duration
+	// +checklocks:mu
+	defaultSend tcpip.SCTPSndRcvInfo
+	// +checklocks:mu
+	dataIOEvent bool
+
+	// out holds the packets to send once mu is released, notifyMask the
+	// events to notify waiters of, and pendingFlush the accepted endpoints
+	// whose packets must be sent then too. Packets are never sent while mu
+	// is held since they may be delivered synchronously to an endpoint that
+	// answers them.
+	//
+	// +checklocks:mu
+	out []outPacket `state:"nosave"`
+	// +checklocks:mu
+	notifyMask waiter.EventMask `state:"nosave"`
+	// +checklocks:mu
+	pendingFlush []*Endpoint `state:"nosave"`
+}
+
+func newEndpoint(s *stack.Stack, p *protocol, netProto tcpip.NetworkProtocolNumber, waiterQueue *waiter.Queue) *Endpoint {
+	e := &Endpoint{
+		stack:       s,
+		protocol:    p,
+		waiterQueue: waiterQueue,
+		assocs:      make(map[tcpip.SCTPAssocID]*association),
+		peers:       make(map[tcpip.FullAddress]*association),
+		nextAssocID: tcpip.SCTPAllAssoc + 1,
+		initMsg: tcpip.SCTPInitMsgOption{
+			NumOutStreams: DefaultOutStreams,
+			MaxInStreams:  DefaultMaxInStreams,
+			MaxAttempts:   DefaultMaxInitAttempts,
+		},
+		rtoInitial:      DefaultRTOInitial,
+		rtoMin:          DefaultRTOMin,
+		rtoMax:          DefaultRTOMax,
+		assocMaxRetrans: DefaultAssocMaxRetrans,
+		pathMaxRetrans:  DefaultPathMaxRetrans,
+		hbEnabled:       true,
+		hbInterval:      DefaultHeartbeatInterval,
+		sackDelay:       DefaultSackDelay,
+		cookieLife:      DefaultCookieLife,
+	}
+	e.ops.InitHandler(e, e.stack, tcpip.GetStackSendBufferLimits, tcpip.GetStackReceiveBufferLimits)
+	e.net.Init(s, netProto, ProtocolNumber, &e.ops, waiterQueue)
+
+	var ss tcpip.SendBufferSizeOption
+	if err := s.Option(&ss); err == nil {
+		e.ops.SetSendBufferSize(int64(ss.Default), false /* notify */)
+	}
+
+	var rs tcpip.ReceiveBufferSizeOption
+	if err := s.Option(&rs); err == nil {
+		e.ops.SetReceiveBufferSize(int64(rs.Default), false /* notify */)
+	}
+
+	return e
+}
+
+// SetOneToMany makes the endpoint a one-to-many style endpoint, as created by
+// socket(2) with SOCK_SEQPACKET. It must be called before the endpoint is
+// used.
+func (e *Endpoint) SetOneToMany() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.oneToMany = true
+}
+
+// unlockAndFlush releases e.mu, and then sends the packets queued while it was
+// held and notifies waiters of the events that occurred.
+//
+// +checklocksrelease:e.mu
+func (e *Endpoint) unlockAndFlush() {
+	out := e.out
+	e.out = nil
+	mask := e.notifyMask
+	e.notifyMask = 0
+	children := e.pendingFlush
+	e.pendingFlush = nil
+	owner := e.owner
+	e.mu.Unlock()
+
+	for i := range out {
+		p := &out[i]
+		if err := sendPacket(e.stack, p.route, e.networkHeaderParams(p.route), owner, &p.fields, p.payload); err == nil {
+			e.stats.PacketsSent.Increment()
+		} else {
+			e.stats.SendErrors.SendToNetworkFailed.Increment()
+		}
+		p.route.Release()
+	}
+	for _, c := range children {
+		c.mu.Lock()
+		c.unlockAndFlush()
+	}
+	if mask != 0 {
+		e.waiterQueue.Notify(mask)
+	}
+}
+
+// queuePacket queues a packet for transmission through r once e.mu is
+// released.
+//
+// +checklocks:e.mu
+func (e *Endpoint) queuePacket(r *stack.Route, fields header.SCTPFields, chunks []byte) {
+	r.Acquire()
+	e.out = append(e.out, outPacket{
+		route:   r,
+		fields:  fields,
+		payload: chunks,
+	})
+}
+
+// networkHeaderParams returns the network header parameters of packets sent
+// through r.
+func (e *Endpoint) networkHeaderParams(r *stack.Route) stack.NetworkHeaderParams {
+	params := stack.NetworkHeaderParams{
+		Protocol: ProtocolNumber,
+		TTL:      r.DefaultTTL(),
+	}
+	switch r.NetProto() {
+	case header.IPv4ProtocolNumber:
+		if v, err := e.net.GetSockOptInt(tcpip.IPv4TTLOption); err == nil && v != tcpip.UseDefaultIPv4TTL {
+			params.TTL = uint8(v)
+		}
+		if v, err := e.net.GetSockOptInt(tcpip.IPv4TOSOption); err == nil {
+			params.TOS = uint8(v)
+		}
+	case header.IPv6ProtocolNumber:
+		if v, err := e.net.GetSockOptInt(tcpip.IPv6HopLimitOption); err == nil && v != tcpip.UseDefaultIPv6HopLimit {
+			params.TTL = uint8(v)
+		}
+		if v, err := e.net.GetSockOptInt(tcpip.IPv6TrafficClassOption); err == nil {
+			params.TOS = uint8(v)
+		}
+	}
+	return params
+}
+
+// addAssociationLocked assigns an ID to a and adds it to the associations of
+// the endpoint.
+//
+// +checklocks:e.mu
+func (e *Endpoint) addAssociationLocked(a *association) {
+	a.id = e.nextAssocID
+	e.nextAssocID++
+	e.assocs[a.id] = a
+	e.peers[a.remote] = a
+	if !e.oneToMany {
+		e.conn = a
+		e.peerClosed = false
+		e.errReported = false
+	}
+}
+
+// removeAssociationLocked removes a terminated association from the endpoint.
+//
+// +checklocks:e.mu
+func (e *Endpoint) removeAssociationLocked(a *association) {
+	delete(e.assocs, a.id)
+	delete(e.peers, a.remote)
+	e.notifyMask |= waiter.EventHUp | waiter.ReadableEvents | waiter.WritableEvents
+	if !e.oneToMany {
+		if a.err != nil {
+			e.notifyMask |= waiter.EventErr
+			e.UpdateLastError(a.err)
+		}
+		e.releaseLocked()
+		return
+	}
+	if e.closed && len(e.assocs) == 0 {
+		e.releaseLocked()
+	}
+}
+
+// associationEstablishedLocked is called once the setup of a completes.
+//
+// +checklocks:e.mu
+func (e *Endpoint) associationEstablishedLocked(a *association) {
+	if !e.oneToMany {
+		e.state = stateConnected
+	}
+	e.notifyMask |= waiter.WritableEvents
+}
+
+// peerShutdownLocked is called when the peer of a shuts it down.
+//
+// +checklocks:e.mu
+func (e *Endpoint) peerShutdownLocked(a *association) {
+	if !e.oneToMany {
+		e.peerClosed = true
+		e.notifyMask |= waiter.ReadableEvents
+	}
+}
+
+// enqueueMessageLocked queues a user message for reading.
+//
+// +checklocks:e.mu
+func (e *Endpoint) enqueueMessageLocked(m *message) {
+	if e.rcvClosed {
+		return
+	}
+	e.rcvList = append(e.rcvList, m)
+	e.rcvBufUsed += len(m.data)
+	e.notifyMask |= waiter.ReadableEvents
+	e.stats.PacketsReceived.Increment()
+}
+
+// releaseSendBufferLocked releases send buffer space held by acknowledged or
+// discarded messages.
+//
+// +checklocks:e.mu
+func (e *Endpoint) releaseSendBufferLocked(n int) {
+	if n == 0 {
+		return
+	}
+	e.sndBufUsed -= n
+	e.notifyMask |= waiter.WritableEvents
+}
+
+// UniqueID implements stack.TransportEndpoint.UniqueID.
+func (e *Endpoint) UniqueID() uint64 {
+	return 0
+}
+
+// WakeupWriters implements tcpip.SocketOptionsHandler.WakeupWriters.
+func (e *Endpoint) WakeupWriters() {
+	e.waiterQueue.Notify(waiter.WritableEvents)
+}
+
+// LastError implements tcpip.Endpoint.LastError.
+func (e *Endpoint) LastError() tcpip.Error {
+	e.lastErrorMu.Lock()
+	defer e.lastErrorMu.Unlock()
+
+	err := e.lastError
+	e.lastError = nil
+	return err
+}
+
+// UpdateLastError implements tcpip.SocketOptionsHandler.UpdateLastError.
+func (e *Endpoint) UpdateLastError(err tcpip.Error) {
+	e.lastErrorMu.Lock()
+	e.lastError = err
+	e.lastErrorMu.Unlock()
+}
+
+// Abort implements stack.TransportEndpoint.Abort.
+func (e *Endpoint) Abort() {
+	e.close(true /* abortive */)
+}
+
+// Close implements tcpip.Endpoint.Close. Associations are shut down
+// gracefully, unless they hold unread data or SO_LINGER is set with a zero
+// timeout, in which case they are aborted.
+func (e *Endpoint) Close() {
+	linger := e.ops.GetLinger()
+	e.close(linger.Enabled && linger.Timeout == 0)
+}
+
+func (e *Endpoint) close(abortive bool) {
+	e.mu.Lock()
+	if e.closed {
+		e.mu.Unlock()
+		return
+	}
+	e.closed = true
+
+	unread := make(map[*association]bool)
+	for _, m := range e.rcvList {
+		unread[m.assoc] = true
+	}
+	e.rcvList = nil
+	e.rcvBufUsed = 0
+	e.rcvClosed = true
+	e.sndClosed = true
+
+	children := e.acceptQueue
+	e.acceptQueue = nil
+
+	for _, a := range e.assocs {
+		if abortive || unread[a] {
+			a.abortWithCause(&tcpip.ErrConnectionAborted{}, header.SCTPCauseUserInitiatedAbort, nil)
+		} else {
+			a.shutdown()
+		}
+	}
+	if len(e.assocs) == 0 {
+		e.releaseLocked()
+	}
+	e.notifyMask |= waiter.EventHUp | waiter.EventErr | waiter.ReadableEvents | waiter.WritableEvents
+	e.unlockAndFlush()
+
+	for _, c := range children {
+		c.Abort()
+	}
+}
+
+// releaseLocked unregisters the endpoint from the stack and releases its
+// port.
+//
+// +checklocks:e.mu
+func (e *Endpoint) releaseLocked() {
+	switch e.state {
+	case stateBound, stateListen, stateConnecting, stateConnected:
+		id := e.net.Info().ID
+		id.LocalPort = e.localPort
+		id.RemotePort = e.remotePort
+		e.stack.UnregisterTransportEndpoint(e.effectiveNetProtos, ProtocolNumber, id, e, e.boundPortFlags, e.boundBindToDevice)
+		e.stack.ReleasePort(ports.Reservation{
+			Networks:     e.effectiveNetProtos,
+			Transport:    ProtocolNumber,
+			Addr:         id.LocalAddress,
+			Port:         id.LocalPort,
+			Flags:        e.boundPortFlags,
+			BindToDevice: e.boundBindToDevice,
+			Dest:         e.boundDest,
+		})
+		e.boundBindToDevice = 0
+		e.boundPortFlags = ports.Flags{}
+	}
+	e.net.Close()
+	e.state = stateClosed
+}
+
+// ModerateRecvBuf implements tcpip.Endpoint.ModerateRecvBuf.
+func (*Endpoint) ModerateRecvBuf(int) {}
+
+// Read implements tcpip.Endpoint.Read. Each read returns data of a single user
+// message. The rest of a message that doesn't fit in dst is returned by the
+// following reads, and ReadResult.Total is the size of the part of the
+// message that wasn't read before.
+func (e *Endpoint) Read(dst io.Writer, opts tcpip.ReadOptions) (tcpip.ReadResult, tcpip.Error) {
+	e.mu.Lock()
+	if len(e.rcvList) == 0 {
+		err := e.readErrorLocked()
+		e.mu.Unlock()
+		return tcpip.ReadResult{}, err
+	}
+
+	m := e.rcvList[0]
+	res := tcpip.ReadResult{
+		Total: len(m.data),
+	}
+	if opts.NeedRemoteAddr {
+		res.RemoteAddr = m.from
+	}
+	if e.dataIOEvent {
+		res.ControlMessages.HasSCTPSndRcvInfo = true
+		res.ControlMessages.SCTPSndRcvInfo = m.info
+	}
+	n, err := dst.Write(m.data)
+	if n == 0 && err != nil {
+		e.mu.Unlock()
+		return tcpip.ReadResult{}, &tcpip.ErrBadBuffer{}
+	}
+	res.Count = n
+
+	if !opts.Peek {
+		if n == len(m.data) {
+			e.rcvList[0] = nil
+			e.rcvList = e.rcvList[1:]
+		} else {
+			m.data = m.data[n:]
+		}
+		e.rcvBufUsed -= n
+		if a := m.assoc; a.state != assocClosed {
+			a.rcv.maybeSendWindowUpdate()
+		}
+	}
+	e.unlockAndFlush()
+	return res, nil
+}
+
+// readErrorLocked returns the error of a read that finds no data.
+//
+// +checklocks:e.mu
+func (e *Endpoint) readErrorLocked() tcpip.Error {
+	if e.rcvClosed {
+		return &tcpip.ErrClosedForReceive{}
+	}
+	if e.oneToMany {
+		if e.state == stateInitial {
+			return &tcpip.ErrNotConnected{}
+		}
+		return &tcpip.ErrWouldBlock{}
+	}
+	switch e.state {
+	case stateInitial, stateBound, stateListen:
+		return &tcpip.ErrNotConnected{}
+	case stateClosed:
+		if e.conn != nil && e.conn.err != nil && !e.errReported {
+			e.errReported = true
+			return e.conn.err
+		}
+		return &tcpip.ErrClosedForReceive{}
+	}
+	if e.peerClosed {
+		return &tcpip.ErrClosedForReceive{}
+	}
+	return &tcpip.ErrWouldBlock{}
+}
+
+// Write implements tcpip.Endpoint.Write. Each write sends a single user
+// message.
+func (e *Endpoint) Write(p tcpip.Payloader, opts tcpip.WriteOptions) (int64, tcpip.Error) {
+	e.mu.Lock()
+	n, err := e.writeLocked(p, &opts)
+	e.unlockAndFlush()
+	return n, err
+}
+
+// +checklocks:e.mu
+func (e *Endpoint) writeLocked(p tcpip.Payloader, opts *tcpip.WriteOptions) (int64, tcpip.Error) {
+	if e.sndClosed {
+		return 0, &tcpip.ErrClosedForSend{}
+	}
+	info := e.defaultSend
+	if opts.ControlMessages.HasSCTPSndRcvInfo {
+		info = opts.ControlMessages.SCTPSndRcvInfo
+	}
+
+	a, err := e.writeAssociationLocked(opts.To, &info)
+	if err != nil {
+		return 0, err
+	}
+	if a.state >= assocShutdownPending {
+		return 0, &tcpip.ErrClosedForSend{}
+	}
+
+	size := p.Len()
+	if info.Flags&tcpip.SCTPAbort == 0 {
+		if size == 0 {
+			if info.Flags&tcpip.SCTPEOF != 0 {
+				a.shutdown()
+				return 0, nil
+			}
+			return 0, &tcpip.ErrInvalidOptionValue{}
+		}
+		if info.Stream >= a.snd.outStreams {
+			return 0, &tcpip.ErrInvalidOptionValue{}
+		}
+		if e.disableFragments && size > a.snd.fragmentationPoint() {
+			return 0, &tcpip.ErrMessageTooLong{}
+		}
+		sndBufSize := int(e.ops.GetSendBufferSize())
+		if size > sndBufSize {
+			return 0, &tcpip.ErrMessageTooLong{}
+		}
+		if e.sndBufUsed+size > sndBufSize {
+			return 0, &tcpip.ErrWouldBlock{}
+		}
+	}
+
+	v := make([]byte, size)
+	if _, err := io.ReadFull(p, v); err != nil {
+		return 0, &tcpip.ErrBadBuffer{}
+	}
+	if info.Flags&tcpip.SCTPAbort != 0 {
+		// The message is the reason of the abort, sent in a user initiated
+		// abort error cause.
+		a.abortWithCause(&tcpip.ErrConnectionAborted{}, header.SCTPCauseUserInitiatedAbort, v)
+		return int64(size), nil
+	}
+
+	e.sndBufUsed += size
+	a.snd.enqueue(v, &info)
+	a.snd.transmit()
+	if info.Flags&tcpip.SCTPEOF != 0 {
+		a.shutdown()
+	}
+	return int64(size), nil
+}
+
+// writeAssociationLocked returns the association a message is sent on,
+// setting up a new association for one-to-many endpoints if needed.
+//
+// +checklocks:e.mu
+func (e *Endpoint) writeAssociationLocked(to *tcpip.FullAddress, info *tcpip.SCTPSndRcvInfo) (*association, tcpip.Error) {
+	if !e.oneToMany {
+		switch e.state {
+		case stateConnecting:
+			return nil, &tcpip.ErrWouldBlock{}
+		case stateConnected:
+			return e.conn, nil
+		case stateClosed:
+			if e.conn != nil && e.conn.err != nil && !e.errReported {
+				e.errReported = true
+				return nil, e.conn.err
+			}
+			return nil, &tcpip.ErrClosedForSend{}
+		default:
+			return nil, &tcpip.ErrNotConnected{}
+		}
+	}
+
+	if to == nil {
+		if info.AssocID <= tcpip.SCTPAllAssoc {
+			return nil, &tcpip.ErrDestinationRequired{}
+		}
+		a, ok := e.assocs[info.AssocID]
+		if !ok {
+			return nil, &tcpip.ErrClosedForSend{}
+		}
+		return a, nil
+	}
+
+	addr, netProto, err := e.canonicalPeerLocked(*to)
+	if err != nil {
+		return nil, err
+	}
+	if a, ok := e.peers[addr]; ok {
+		return a, nil
+	}
+	if info.Flags&(tcpip.SCTPAbort|tcpip.SCTPEOF) != 0 {
+		return nil, &tcpip.ErrInvalidOptionValue{}
+	}
+	return e.connectOneToManyLocked(addr, netProto)
+}
+
+// canonicalPeerLocked returns the canonical form of a peer address used by a
+// one-to-many endpoint, and the network protocol used to reach it.
+//
+// +checklocks:e.mu
+func (e *Endpoint) canonicalPeerLocked(to tcpip.FullAddress) (tcpip.FullAddress, tcpip.NetworkProtocolNumber, tcpip.Error) {
+	if to.Port == 0 {
+		return tcpip.FullAddress{}, 0, &tcpip.ErrInvalidEndpointState{}
+	}
+	info := e.net.Info()
+	addr, netProto, err := info.AddrNetProtoLocked(to, e.ops.GetV6Only(), false /* bind */)
+	if err != nil {
+		return tcpip.FullAddress{}, 0, err
+	}
+	return tcpip.FullAddress{Addr: addr.Addr, Port: addr.Port}, netProto, nil
+}
+
+// connectOneToManyLocked sets up a new association of a one-to-many endpoint
+// to addr, binding the endpoint first if needed.
+//
+// +checklocks:e.mu
+func (e *Endpoint) connectOneToManyLocked(addr tcpip.FullAddress, netProto tcpip.NetworkProtocolNumber) (*association, tcpip.Error) {
+	switch e.state {
+	case stateInitial:
+		if err := e.bindLocked(tcpip.FullAddress{}); err != nil {
+			return nil, err
+		}
+	case stateBound, stateListen:
+	default:
+		return nil, &tcpip.ErrInvalidEndpointState{}
+	}
+
+	info := e.net.Info()
+	r, err := e.stack.FindRoute(info.BindNICID, info.ID.LocalAddress, addr.Addr, netProto, false /* multicastLoop */)
+	if err != nil {
+		return nil, err
+	}
+	a := e.newAssociation(r, addr)
+	a.sendInit()
+	return a, nil
+}
+
+// Disconnect implements tcpip.Endpoint.Disconnect.
+func (*Endpoint) Disconnect() tcpip.Error {
+	return &tcpip.ErrNotSupported{}
+}
+
+// Connect implements tcpip.Endpoint.Connect.
+func (e *Endpoint) Connect(addr tcpip.FullAddress) tcpip.Error {
+	e.mu.Lock()
+	err := e.connectLocked(addr)
+	e.unlockAndFlush()
+	return err
+}
+
+// +checklocks:e.mu
+func (e *Endpoint) connectLocked(addr tcpip.FullAddress) tcpip.Error {
+	if e.oneToMany {
+		peer, netProto, err := e.canonicalPeerLocked(addr)
+		if err != nil {
+			return err
+		}
+		a, ok := e.peers[peer]
+		if !ok {
+			if _, err := e.connectOneToManyLocked(peer, netProto); err != nil {
+				return err
+			}
+			return &tcpip.ErrConnectStarted{}
+		}
+		return connectResult(a)
+	}
+
+	switch e.state {
+	case stateInitial, stateBound:
+	case stateListen:
+		return &tcpip.ErrInvalidEndpointState{}
+	case stateConnecting, stateConnected:
+		return connectResult(e.conn)
+	case stateClosed:
+		if a := e.conn; a != nil && !a.connectNotified {
+			a.connectNotified = true
+			e.errReported = true
+			if a.err != nil {
+				return a.err
+			}
+			return &tcpip.ErrConnectionAborted{}
+		}
+		return &tcpip.ErrInvalidEndpointState{}
+	}
+	if addr.Port == 0 {
+		return &tcpip.ErrInvalidEndpointState{}
+	}
+
+	var remote tcpip.FullAddress
+	err := e.net.ConnectAndThen(addr, func(netProto tcpip.NetworkProtocolNumber, previousID, nextID stack.TransportEndpointID) tcpip.Error {
+		nextID.LocalPort = e.localPort
+		nextID.RemotePort = addr.Port
+		netProtos := []tcpip.NetworkProtocolNumber{netProto}
+
+		if e.localPort != 0 {
+			previousID.LocalPort = e.localPort
+			previousID.RemotePort = e.remotePort
+			e.stack.UnregisterTransportEndpoint(e.effectiveNetProtos, ProtocolNumber, previousID, e, e.boundPortFlags, e.boundBindToDevice)
+		}
+
+		nextID, btd, err := e.registerWithStack(netProtos, nextID)
+		if err != nil {
+			return err
+		}
+
+		e.localPort = nextID.LocalPort
+		e.remotePort = nextID.RemotePort
+		e.boundBindToDevice = btd
+		e.effectiveNetProtos = netProtos
+		remote = tcpip.FullAddress{Addr: nextID.RemoteAddress, Port: nextID.RemotePort}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	e.state = stateConnecting
+
+	local := e.net.GetLocalAddress()
+	r, err := e.stack.FindRoute(local.NIC, local.Addr, remote.Addr, e.effectiveNetProtos[0], false /* multicastLoop */)
+	if err != nil {
+		e.releaseLocked()
+		return err
+	}
+	a := e.newAssociation(r, remote)
+	a.sendInit()
+	return &tcpip.ErrConnectStarted{}
+}
+
+// connectResult returns the result of connecting again an endpoint whose
+// association to the peer is a.
+func connectResult(a *association) tcpip.Error {
+	switch {
+	case a.state == assocCookieWait || a.state == assocCookieEchoed:
+		return &tcpip.ErrAlreadyConnecting{}
+	case !a.connectNotified:
+		// The setup completed since the previous call.
+		a.connectNotified = true
+		return nil
+	default:
+		return &tcpip.ErrAlreadyConnected{}
+	}
+}
+
+// Shutdown implements tcpip.Endpoint.Shutdown. Shutting the write side of a
+// one-to-one endpoint down gracefully shuts its association down.
+func (e *Endpoint) Shutdown(flags tcpip.ShutdownFlags) tcpip.Error {
+	e.mu.Lock()
+	if !e.oneToMany && e.state != stateConnecting && e.state != stateConnected {
+		e.mu.Unlock()
+		return &tcpip.ErrNotConnected{}
+	}
+	if e.oneToMany && e.state == stateInitial {
+		e.mu.Unlock()
+		return &tcpip.ErrNotConnected{}
+	}
+	if flags&tcpip.ShutdownWrite != 0 && !e.sndClosed {
+		e.sndClosed = true
+		if !e.oneToMany {
+			e.conn.shutdown()
+		}
+		e.notifyMask |= waiter.WritableEvents
+	}
+	if flags&tcpip.ShutdownRead != 0 && !e.rcvClosed {
+		e.rcvClosed = true
+		e.notifyMask |= waiter.ReadableEvents
+	}
+	e.unlockAndFlush()
+	return nil
+}
+
+// Listen implements tcpip.Endpoint.Listen. A one-to-many endpoint only accepts
+// associations while it is listening; a zero backlog stops that.
+func (e *Endpoint) Listen(backlog int) tcpip.Error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	switch e.state {
+	case stateInitial:
+		if err := e.bindLocked(tcpip.FullAddress{}); err != nil {
+			return err
+		}
+	case stateBound, stateListen:
+	default:
+		return &tcpip.ErrInvalidEndpointState{}
+	}
+	if e.oneToMany && backlog == 0 {
+		e.state = stateBound
+		return nil
+	}
+	e.backlog = max(backlog, 0)
+	e.state = stateListen
+	return nil
+}
+
+// Accept implements tcpip.Endpoint.Accept.
+func (e *Endpoint) Accept(peerAddr *tcpip.FullAddress) (tcpip.Endpoint, *waiter.Queue, tcpip.Error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.oneToMany {
+		return nil, nil, &tcpip.ErrNotSupported{}
+	}
+	if e.state != stateListen {
+		return nil, nil, &tcpip.ErrInvalidEndpointState{}
+	}
+	if len(e.acceptQueue) == 0 {
+		return nil, nil, &tcpip.ErrWouldBlock{}
+	}
+	n := e.acceptQueue[0]
+	e.acceptQueue[0] = nil
+	e.acceptQueue = e.acceptQueue[1:]
+	if peerAddr != nil {
+		n.mu.Lock()
+		*peerAddr = n.conn.remote
+		n.mu.Unlock()
+	}
+	return n, n.waiterQueue, nil
+}
+
+// registerWithStack reserves a port for the endpoint if it has none and
+// registers it with the stack.
+//
+// +checklocks:e.mu
+func (e *Endpoint) registerWithStack(netProtos []tcpip.NetworkProtocolNumber, id stack.TransportEndpointID) (stack.TransportEndpointID, tcpip.NICID, tcpip.Error) {
+	bindToDevice := tcpip.NICID(e.ops.GetBindToDevice())
+	if e.localPort == 0 {
+		portRes := ports.Reservation{
+			Networks:     netProtos,
+			Transport:    ProtocolNumber,
+			Addr:         id.LocalAddress,
+			Port:         id.LocalPort,
+			Flags:        e.portFlags,
+			BindToDevice: bindToDevice,
+			Dest:         tcpip.FullAddress{},
+		}
+		port, err := e.stack.ReservePort(e.stack.SecureRNG(), portRes, nil /* testPort */)
+		if err != nil {
+			return id, bindToDevice, err
+		}
+		id.LocalPort = port
+	}
+	e.boundPortFlags = e.portFlags
+
+	err := e.stack.RegisterTransportEndpoint(netProtos, ProtocolNumber, id, e, e.boundPortFlags, bindToDevice)
+	if err != nil {
+		portRes := ports.Reservation{
+			Networks:     netProtos,
+			Transport:    ProtocolNumber,
+			Addr:         id.LocalAddress,
+			Port:         id.LocalPort,
+			Flags:        e.boundPortFlags,
+			BindToDevice: bindToDevice,
+			Dest:         tcpip.FullAddress{},
+		}
+		e.stack.ReleasePort(portRes)
+		e.boundPortFlags = ports.Flags{}
+	}
+	return id, bindToDevice, err
+}
+
+// +checklocks:e.mu
+func (e *Endpoint) bindLocked(addr tcpip.FullAddress) tcpip.Error {
+	if e.state != stateInitial {
+		return &tcpip.ErrInvalidEndpointState{}
+	}
+
+	err := e.net.BindAndThen(addr, func(boundNetProto tcpip.NetworkProtocolNumber, boundAddr tcpip.Address) tcpip.Error {
+		// Expand netProtos to include v4 and v6 if the caller is binding to a
+		// wildcard (empty) address, and this is an IPv6 endpoint with v6only
+		// set to false.
+		netProtos := []tcpip.NetworkProtocolNumber{boundNetProto}
+		if boundNetProto == header.IPv6ProtocolNumber && !e.ops.GetV6Only() && boundAddr == (tcpip.Address{}) && e.stack.CheckNetworkProtocol(header.IPv4ProtocolNumber) {
+			netProtos = []tcpip.NetworkProtocolNumber{
+				header.IPv6ProtocolNumber,
+				header.IPv4ProtocolNumber,
+			}
+		}
+
+		id := stack.TransportEndpointID{
+			LocalPort:    addr.Port,
+			LocalAddress: boundAddr,
+		}
+		id, btd, err := e.registerWithStack(netProtos, id)
+		if err != nil {
+			return err
+		}
+
+		e.localPort = id.LocalPort
+		e.boundBindToDevice = btd
+		e.effectiveNetProtos = netProtos
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	e.state = stateBound
+	return nil
+}
+
+// Bind implements tcpip.Endpoint.Bind.
+func (e *Endpoint) Bind(addr tcpip.FullAddress) tcpip.Error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.bindLocked(addr)
+}
+
+// GetLocalAddress implements tcpip.Endpoint.GetLocalAddress.
+func (e *Endpoint) GetLocalAddress() (tcpip.FullAddress, tcpip.Error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	addr := e.net.GetLocalAddress()
+	addr.Port = e.localPort
+	return addr, nil
+}
+
+// GetRemoteAddress implements tcpip.Endpoint.GetRemoteAddress.
+func (e *Endpoint) GetRemoteAddress() (tcpip.FullAddress, tcpip.Error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.oneToMany || e.conn == nil || e.state != stateConnected {
+		return tcpip.FullAddress{}, &tcpip.ErrNotConnected{}
+	}
+	return e.conn.remote, nil
+}
+
+// Readiness implements tcpip.Endpoint.Readiness.
+func (e *Endpoint) Readiness(mask waiter.EventMask) waiter.EventMask {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var result waiter.EventMask
+	writable := e.sndBufUsed < int(e.ops.GetSendBufferSize())
+	if len(e.rcvList) > 0 || e.rcvClosed {
+		result |= waiter.ReadableEvents
+	}
+	if e.sndClosed {
+		result |= waiter.WritableEvents
+	}
+	if e.oneToMany {
+		if writable && e.state != stateClosed {
+			result |= waiter.WritableEvents
+		}
+		return result & mask
+	}
+
+	switch e.state {
+	case stateListen:
+		if len(e.acceptQueue) > 0 {
+			result |= waiter.ReadableEvents
+		}
+	case stateConnected:
+		if writable {
+			result |= waiter.WritableEvents
+		}
+		if e.peerClosed {
+			result |= waiter.ReadableEvents
+		}
+	case stateClosed:
+		if e.conn != nil {
+			result |= waiter.ReadableEvents | waiter.WritableEvents | waiter.EventHUp
+			if e.conn.err != nil && !e.errReported {
+				result |= waiter.EventErr
+			}
+		}
+	}
+	return result & mask
+}
+
+// SetSockOptInt implements tcpip.Endpoint.SetSockOptInt.
+func (e *Endpoint) SetSockOptInt(opt tcpip.SockOptInt, v int) tcpip.Error {
+	switch opt {
+	case tcpip.SCTPNoDelayOption:
+		e.mu.Lock()
+		e.noDelay = v != 0
+		e.mu.Unlock()
+		return nil
+
+	case tcpip.SCTPDisableFragmentsOption:
+		e.mu.Lock()
+		e.disableFragments = v != 0
+		e.mu.Unlock()
+		return nil
+
+	case tcpip.MaxSegOption:
+		if v != 0 && (v < 8 || v > 0xffff-header.SCTPDataChunkHeaderSize) {
+			return &tcpip.ErrInvalidOptionValue{}
+		}
+		e.mu.Lock()
+		e.maxSeg = v
+		e.mu.Unlock()
+		return nil
+	}
+	return e.net.SetSockOptInt(opt, v)
+}
+
+// GetSockOptInt implements tcpip.Endpoint.GetSockOptInt.
+func (e *Endpoint) GetSockOptInt(opt tcpip.SockOptInt) (int, tcpip.Error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	switch opt {
+	case tcpip.SCTPNoDelayOption:
+		if e.noDelay {
+			return 1, nil
+		}
+		return 0, nil
+
+	case tcpip.SCTPDisableFragmentsOption:
+		if e.disableFragments {
+			return 1, nil
+		}
+		return 0, nil
+
+	case tcpip.MaxSegOption:
+		return e.maxSeg, nil
+
+	case tcpip.ReceiveQueueSizeOption:
+		if len(e.rcvList) == 0 {
+			return 0, nil
+		}
+		return len(e.rcvList[0].data), nil
+
+	case tcpip.SendQueueSizeOption:
+		return e.sndBufUsed, nil
+	}
+	return e.net.GetSockOptInt(opt)
+}
+
+// selectAssociationsLocked returns the associations an option with the given
+// association ID applies to, and whether it applies to the defaults of future
+// associations too.
+//
+// +checklocks:e.mu
+func (e *Endpoint) selectAssociationsLocked(id tcpip.SCTPAssocID) ([]*association, bool, tcpip.Error) {
+	if !e.oneToMany {
+		if e.conn != nil && e.conn.state != assocClosed {
+			return []*association{e.conn}, true, nil
+		}
+		return nil, true, nil
+	}
+	var all []*association
+	if id == tcpip.SCTPCurrentAssoc || id == tcpip.SCTPAllAssoc {
+		for _, a := range e.assocs {
+			all = append(all, a)
+		}
+	}
+	switch id {
+	case tcpip.SCTPFutureAssoc:
+		return nil, true, nil
+	case tcpip.SCTPCurrentAssoc:
+		return all, false, nil
+	case tcpip.SCTPAllAssoc:
+		return all, true, nil
+	}
+	a, ok := e.assocs[id]
+	if !ok {
+		return nil, false, &tcpip.ErrInvalidOptionValue{}
+	}
+	return []*association{a}, false, nil
+}
+
+// associationLocked returns the association whose options are reported for
+// the given association ID, or nil if the endpoint's defaults are reported.
+//
+// +checklocks:e.mu
+func (e *Endpoint) associationLocked(id tcpip.SCTPAssocID) (*association, tcpip.Error) {
+	if !e.oneToMany {
+		if e.conn != nil && e.conn.state != assocClosed {
+			return e.conn, nil
+		}
+		return nil, nil
+	}
+	if id <= tcpip.SCTPAllAssoc {
+		return nil, nil
+	}
+	a, ok := e.assocs[id]
+	if !ok {
+		return nil, &tcpip.ErrInvalidOptionValue{}
+	}
+	return a, nil
+}
+
+// SetSockOpt implements tcpip.Endpoint.SetSockOpt.
+func (e *Endpoint) SetSockOpt(opt tcpip.SettableSocketOption) tcpip.Error {
+	switch opt.(type) {
+	case *tcpip.SCTPInitMsgOption, *tcpip.SCTPRTOInfoOption, *tcpip.SCTPAssocInfoOption,
+		*tcpip.SCTPDefaultSendParamOption, *tcpip.SCTPEventsOption, *tcpip.SCTPPeerAddrParamsOption:
+	default:
+		return e.net.SetSockOpt(opt)
+	}
+
+	e.mu.Lock()
+	err := e.setSCTPSockOptLocked(opt)
+	e.unlockAndFlush()
+	return err
+}
+
+// +checklocks:e.mu
+func (e *Endpoint) setSCTPSockOptLocked(opt tcpip.SettableSocketOption) tcpip.Error {
+	switch v := opt.(type) {
+	case *tcpip.SCTPInitMsgOption:
+		if v.NumOutStreams != 0 {
+			e.initMsg.NumOutStreams = v.NumOutStreams
+		}
+		if v.MaxInStreams != 0 {
+			e.initMsg.MaxInStreams = v.MaxInStreams
+		}
+		if v.MaxAttempts != 0 {
+			e.initMsg.MaxAttempts = v.MaxAttempts
+		}
+		if v.MaxInitTimeout != 0 {
+			e.initMsg.MaxInitTimeout = v.MaxInitTimeout
+		}
+
+	case *tcpip.SCTPRTOInfoOption:
+		assocs, defaults, err := e.selectAssociationsLocked(v.AssocID)
+		if err != nil {
+			return err
+		}
+		initial, minRTO, maxRTO := e.rtoInitial, e.rtoMin, e.rtoMax
+		if len(assocs) == 1 && !defaults {
+			initial, minRTO, maxRTO = assocs[0].rtoInitial, assocs[0].rtoMin, assocs[0].rtoMax
+		}
+		if v.Initial != 0 {
+			initial = v.Initial
+		}
+		if v.Min != 0 {
+			minRTO = v.Min
+		}
+		if v.Max != 0 {
+			maxRTO = v.Max
+		}
+		if minRTO > maxRTO {
+			return &tcpip.ErrInvalidOptionValue{}
+		}
+		if defaults {
+			e.rtoInitial, e.rtoMin, e.rtoMax = initial, minRTO, maxRTO
+		}
+		for _, a := range assocs {
+			a.rtoInitial, a.rtoMin, a.rtoMax = initial, minRTO, maxRTO
+			a.rto = min(max(a.rto, minRTO), maxRTO)
+		}
+
+	case *tcpip.SCTPAssocInfoOption:
+		assocs, defaults, err := e.selectAssociationsLocked(v.AssocID)
+		if err != nil {
+			return err
+		}
+		if defaults {
+			if v.MaxRetrans != 0 {
+				e.assocMaxRetrans = int(v.MaxRetrans)
+			}
+			if v.CookieLife != 0 {
+				e.cookieLife = v.CookieLife
+			}
+		}
+		for _, a := range assocs {
+			if v.MaxRetrans != 0 {
+				a.maxRetrans = int(v.MaxRetrans)
+			}
+		}
+
+	case *tcpip.SCTPDefaultSendParamOption:
+		e.defaultSend = tcpip.SCTPSndRcvInfo(*v)
+
+	case *tcpip.SCTPEventsOption:
+		e.dataIOEvent = v.DataIO
+
+	case *tcpip.SCTPPeerAddrParamsOption:
+		if v.SackDelay > maxSackDelay {
+			return &tcpip.ErrInvalidOptionValue{}
+		}
+		assocs, defaults, err := e.selectAssociationsLocked(v.AssocID)
+		if err != nil {
+			return err
+		}
+		if defaults {
+			e.hbEnabled = v.HeartbeatEnabled
+			e.hbInterval = v.HeartbeatInterval
+			e.pathMaxRetrans = int(v.PathMaxRetrans)
+			e.sackDelay = v.SackDelay
+		}
+		for _, a := range assocs {
+			a.hbEnabled = v.HeartbeatEnabled
+			a.hbInterval = v.HeartbeatInterval
+			a.pathMaxRetrans = int(v.PathMaxRetrans)
+			a.sackDelay = v.SackDelay
+			a.startHeartbeatTimer()
+		}
+	}
+	return nil
+}
+
+// GetSockOpt implements tcpip.Endpoint.GetSockOpt.
+func (e *Endpoint) GetSockOpt(opt tcpip.GettableSocketOption) tcpip.Error {
+	switch o := opt.(type) {
+	case *tcpip.SCTPInitMsgOption:
+		e.mu.Lock()
+		*o = e.initMsg
+		e.mu.Unlock()
+
+	case *tcpip.SCTPRTOInfoOption:
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		a, err := e.associationLocked(o.AssocID)
+		if err != nil {
+			return err
+		}
+		if a != nil {
+			o.Initial, o.Min, o.Max = a.rtoInitial, a.rtoMin, a.rtoMax
+		} else {
+			o.Initial, o.Min, o.Max = e.rtoInitial, e.rtoMin, e.rtoMax
+		}
+
+	case *tcpip.SCTPAssocInfoOption:
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		a, err := e.associationLocked(o.AssocID)
+		if err != nil {
+			return err
+		}
+		o.CookieLife = e.cookieLife
+		if a != nil {
+			o.MaxRetrans = uint16(a.maxRetrans)
+			o.NumPeerDestinations = 1
+			o.PeerRwnd = a.snd.peerRwnd
+			o.LocalRwnd = a.rcv.window()
+		} else {
+			o.MaxRetrans = uint16(e.assocMaxRetrans)
+			o.NumPeerDestinations = 0
+			o.PeerRwnd = 0
+			o.LocalRwnd = 0
+		}
+
+	case *tcpip.SCTPDefaultSendParamOption:
+		e.mu.Lock()
+		*o = tcpip.SCTPDefaultSendParamOption(e.defaultSend)
+		e.mu.Unlock()
+
+	case *tcpip.SCTPEventsOption:
+		e.mu.Lock()
+		o.DataIO = e.dataIOEvent
+		e.mu.Unlock()
+
+	case *tcpip.SCTPPeerAddrParamsOption:
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		a, err := e.associationLocked(o.AssocID)
+		if err != nil {
+			return err
+		}
+		if a != nil {
+			o.HeartbeatEnabled = a.hbEnabled
+			o.HeartbeatInterval = a.hbInterval
+			o.PathMaxRetrans = uint16(a.pathMaxRetrans)
+			o.PathMTU = uint32(a.pmtu)
+			o.SackDelay = a.sackDelay
+		} else {
+			o.HeartbeatEnabled = e.hbEnabled
+			o.HeartbeatInterval = e.hbInterval
+			o.PathMaxRetrans = uint16(e.pathMaxRetrans)
+			o.PathMTU = 0
+			o.SackDelay = e.sackDelay
+		}
+
+	case *tcpip.SCTPStatusOption:
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		a, err := e.associationLocked(o.AssocID)
+		if err != nil {
+			return err
+		}
+		if a == nil {
+			return &tcpip.ErrInvalidOptionValue{}
+		}
+		*o = a.status()
+
+	default:
+		return e.net.GetSockOpt(opt)
+	}
+	return nil
+}
+
+// OnReuseAddressSet implements tcpip.SocketOptionsHandler.OnReuseAddressSet.
+func (e *Endpoint) OnReuseAddressSet(v bool) {
+	e.mu.Lock()
+	e.portFlags.MostRecent = v
+	e.mu.Unlock()
+}
+
+// OnReusePortSet implements tcpip.SocketOptionsHandler.OnReusePortSet.
+func (e *Endpoint) OnReusePortSet(v bool) {
+	e.mu.Lock()
+	e.portFlags.LoadBalanced = v
+	e.mu.Unlock()
+}
+
+// HasNIC implements tcpip.SocketOptionsHandler.HasNIC.
+func (e *Endpoint) HasNIC(id int32) bool {
+	return e.stack.HasNIC(tcpip.NICID(id))
+}
+
+// HandlePacket implements stack.TransportEndpoint.HandlePacket.
+func (e *Endpoint) HandlePacket(id stack.TransportEndpointID, pkt *stack.PacketBuffer) {
+	hdr, chunks, ok := e.protocol.parse(pkt)
+	if !ok {
+		e.stats.ReceiveErrors.MalformedPacketsReceived.Increment()
+		return
+	}
+	e.stack.Stats().SCTP.PacketsReceived.Increment()
+
+	e.mu.Lock()
+	if a, ok := e.peers[tcpip.FullAddress{Addr: id.RemoteAddress, Port: id.RemotePort}]; ok {
+		a.handlePacket(hdr, chunks)
+	} else {
+		e.handleNoAssociationLocked(id, pkt, hdr, chunks)
+	}
+	e.unlockAndFlush()
+}
+
+// handleNoAssociationLocked handles a packet from a peer the endpoint has no
+// association with.
+//
+// +checklocks:e.mu
+func (e *Endpoint) handleNoAssociationLocked(id stack.TransportEndpointID, pkt *stack.PacketBuffer, hdr header.SCTP, chunks []header.SCTPChunk) {
+	if e.state == stateListen {
+		switch chunks[0].Type() {
+		case header.SCTPChunkInit:
+			if len(chunks) == 1 && hdr.VerificationTag() == 0 {
+				e.handleInitLocked(id, pkt, header.SCTPInitChunk(chunks[0]))
+			}
+			return
+		case header.SCTPChunkCookieEcho:
+			e.handleCookieEchoLocked(id, pkt, hdr, chunks)
+			return
+		}
+	}
+
+	e.stack.Stats().SCTP.OutOfTheBluePacketsReceived.Increment()
+	reply, chunk, ok := outOfTheBlueReply(hdr, chunks)
+	if !ok {
+		return
+	}
+	e.replyLocked(id, pkt, reply, chunk)
+}
+
+// replyLocked queues a packet answering pkt.
+//
+// +checklocks:e.mu
+func (e *Endpoint) replyLocked(id stack.TransportEndpointID, pkt *stack.PacketBuffer, fields header.SCTPFields, chunks []byte) {
+	r, err := e.stack.FindRoute(pkt.NICID, id.LocalAddress, id.RemoteAddress, pkt.NetworkProtocolNumber, false /* multicastLoop */)
+	if err != nil {
+		return
+	}
+	e.queuePacket(r, fields, chunks)
+	r.Release()
+}
+
+// handleInitLocked answers an INIT chunk with an INIT ACK chunk carrying the
+// state of the new association in a cookie, as per RFC 9260 section 5.1.
+//
+// +checklocks:e.mu
+func (e *Endpoint) handleInitLocked(id stack.TransportEndpointID, pkt *stack.PacketBuffer, init header.SCTPInitChunk) {
+	if !validInit(init) {
+		return
+	}
+	params, _ := header.ParseSCTPParameters(init.Parameters())
+	for _, p := range params {
+		// Unknown parameters with the upper bit clear stop the processing
+		// of the chunk, as per RFC 9260 section 3.2.1.
+		if !knownParameter(p.Type()) && uint16(p.Type())&0x8000 == 0 {
+			return
+		}
+	}
+
+	rng := e.stack.SecureRNG()
+	cookie := stateCookie{
+		created:         e.stack.Clock().NowMonotonic(),
+		life:            e.cookieLife,
+		localTag:        e.randomTag(),
+		peerTag:         init.InitiateTag(),
+		localInitialTSN: rng.Uint32(),
+		peerInitialTSN:  init.InitialTSN(),
+		peerRwnd:        init.AdvertisedReceiverWindowCredit(),
+		outStreams:      min(e.initMsg.NumOutStreams, init.InboundStreams()),
+		inStreams:       min(e.initMsg.MaxInStreams, init.OutboundStreams()),
+	}
+	chunk := newInitChunk(header.SCTPChunkInitAck, &header.SCTPInitChunkFields{
+		InitiateTag:                    cookie.localTag,
+		AdvertisedReceiverWindowCredit: uint32(min(e.ops.GetReceiveBufferSize(), 1<<31)),
+		OutboundStreams:                cookie.outStreams,
+		InboundStreams:                 cookie.inStreams,
+		InitialTSN:                     cookie.localInitialTSN,
+	}, newParameter(uint16(header.SCTPParameterStateCookie), e.protocol.encodeCookie(&cookie, id)))
+	e.replyLocked(id, pkt, header.SCTPFields{
+		SrcPort:         id.LocalPort,
+		DstPort:         id.RemotePort,
+		VerificationTag: cookie.peerTag,
+	}, chunk)
+}
+
+// handleCookieEchoLocked sets up the association described by the cookie of a
+// COOKIE ECHO chunk, as per RFC 9260 section 5.1.5.
+//
+// +checklocks:e.mu
+func (e *Endpoint) handleCookieEchoLocked(id stack.TransportEndpointID, pkt *stack.PacketBuffer, hdr header.SCTP, chunks []header.SCTPChunk) {
+	cookie, ok := e.protocol.decodeCookie(chunks[0].Value(), id)
+	if !ok || hdr.VerificationTag() != cookie.localTag {
+		return
+	}
+	reply := header.SCTPFields{
+		SrcPort:         id.LocalPort,
+		DstPort:         id.RemotePort,
+		VerificationTag: cookie.peerTag,
+	}
+	if now := e.stack.Clock().NowMonotonic(); cookie.expired(now) {
+		// Report the staleness of the cookie in microseconds, as per RFC
+		// 9260 section 3.3.10.3.
+		var v [4]byte
+		binary.BigEndian.PutUint32(v[:], uint32(now.Sub(cookie.created.Add(cookie.life))/time.Microsecond))
+		e.replyLocked(id, pkt, reply, newChunk(header.SCTPChunkError, 0, newParameter(uint16(header.SCTPCauseStaleCookie), v[:])))
+		return
+	}
+
+	target := e
+	if !e.oneToMany {
+		if len(e.acceptQueue) >= e.backlog {
+			return
+		}
+		n, err := e.newAcceptedEndpointLocked(id, pkt)
+		if err != nil {
+			return
+		}
+		target = n
+		defer func() {
+			n.mu.Unlock()
+			e.acceptQueue = append(e.acceptQueue, n)
+			e.pendingFlush = append(e.pendingFlush, n)
+			e.notifyMask |= waiter.ReadableEvents
+		}()
+	}
+
+	r, err := e.stack.FindRoute(pkt.NICID, id.LocalAddress, id.RemoteAddress, pkt.NetworkProtocolNumber, false /* multicastLoop */)
+	if err != nil {
+		return
+	}
+	a := target.newAssociation(r, tcpip.FullAddress{Addr: id.RemoteAddress, Port: id.RemotePort})
+	a.localTag = cookie.localTag
+	a.snd.setInitialTSN(cookie.localInitialTSN)
+	a.setPeerInit(&cookie)
+	// The endpoint doesn't report the setup of accepted associations.
+	a.connectNotified = true
+	e.stack.Stats().SCTP.PassiveEstablishes.Increment()
+	a.queueChunks(newChunk(header.SCTPChunkCookieAck, 0, nil))
+	a.establish()
+	a.handleChunks(chunks[1:])
+}
+
+// newAcceptedEndpointLocked creates the endpoint of an association accepted
+// by a one-to-one listening endpoint. It is returned locked.
+//
+// +checklocks:e.mu
+func (e *Endpoint) newAcceptedEndpointLocked(id stack.TransportEndpointID, pkt *stack.PacketBuffer) (*Endpoint, tcpip.Error) {
+	n := newEndpoint(e.stack, e.protocol, pkt.NetworkProtocolNumber, &waiter.Queue{})
+	n.ops.SetSendBufferSize(e.ops.GetSendBufferSize(), false /* notify */)
+	n.ops.SetReceiveBufferSize(e.ops.GetReceiveBufferSize(), false /* notify */)
+	n.ops.SetLinger(e.ops.GetLinger())
+
+	n.mu.Lock()
+	n.owner = e.owner
+	n.noDelay = e.noDelay
+	n.disableFragments = e.disableFragments
+	n.maxSeg = e.maxSeg
+	n.initMsg = e.initMsg
+	n.rtoInitial, n.rtoMin, n.rtoMax = e.rtoInitial, e.rtoMin, e.rtoMax
+	n.assocMaxRetrans = e.assocMaxRetrans
+	n.pathMaxRetrans = e.pathMaxRetrans
+	n.hbEnabled = e.hbEnabled
+	n.hbInterval = e.hbInterval
+	n.sackDelay = e.sackDelay
+	n.cookieLife = e.cookieLife
+	n.defaultSend = e.defaultSend
+	n.dataIOEvent = e.dataIOEvent
+
+	if err := n.net.BindAndThen(tcpip.FullAddress{Addr: id.LocalAddress}, func(tcpip.NetworkProtocolNumber, tcpip.Address) tcpip.Error {
+		return nil
+	}); err != nil {
+		n.mu.Unlock()
+		return nil, err
+	}
+	remote := tcpip.FullAddress{Addr: id.RemoteAddress, Port: id.RemotePort}
+	err := n.net.ConnectAndThen(remote, func(netProto tcpip.NetworkProtocolNumber, _, nextID stack.TransportEndpointID) tcpip.Error {
+		nextID.LocalPort = id.LocalPort
+		nextID.RemotePort = id.RemotePort
+		netProtos := []tcpip.NetworkProtocolNumber{netProto}
+		portRes := ports.Reservation{
+			Networks:     netProtos,
+			Transport:    ProtocolNumber,
+			Addr:         nextID.LocalAddress,
+			Port:         nextID.LocalPort,
+			Flags:        e.boundPortFlags,
+			BindToDevice: e.boundBindToDevice,
+			Dest:         remote,
+		}
+		if !e.stack.ReserveTuple(portRes) {
+			return &tcpip.ErrPortInUse{}
+		}
+		if err := e.stack.RegisterTransportEndpoint(netProtos, ProtocolNumber, nextID, n, e.boundPortFlags, e.boundBindToDevice); err != nil {
+			e.stack.ReleasePort(portRes)
+			return err
+		}
+		n.localPort = nextID.LocalPort
+		n.remotePort = nextID.RemotePort
+		n.boundPortFlags = e.boundPortFlags
+		n.boundBindToDevice = e.boundBindToDevice
+		n.effectiveNetProtos = netProtos
+		n.boundDest = remote
+		return nil
+	})
+	if err != nil {
+		n.net.Close()
+		n.mu.Unlock()
+		return nil, err
+	}
+	n.state = stateConnected
+	return n, nil
+}
+
+// HandleError implements stack.TransportEndpoint.HandleError. SCTP relies on
+// its own retransmission timers to detect unreachable peers, so ICMP errors
+// are ignored.
+func (*Endpoint) HandleError(stack.TransportError, *stack.PacketBuffer) {}
+
+// State implements tcpip.Endpoint.State.
+func (e *Endpoint) State() uint32 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return uint32(e.state)
+}
+
+// Info implements tcpip.Endpoint.Info.
+func (e *Endpoint) Info() tcpip.EndpointInfo {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	info := e.net.Info()
+	info.ID.LocalPort = e.localPort
+	info.ID.RemotePort = e.remotePort
+	return &info
+}
+
+// Stats implements tcpip.Endpoint.Stats.
+func (e *Endpoint) Stats() tcpip.EndpointStats {
+	return &e.stats
+}
+
+// Wait implements stack.TransportEndpoint.Wait.
+func (*Endpoint) Wait() {}
+
+// SetOwner implements tcpip.Endpoint.SetOwner.
+func (e *Endpoint) SetOwner(owner tcpip.PacketOwner) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.owner = owner
+}
+
+// SocketOptions implements tcpip.Endpoint.SocketOptions.
+func (e *Endpoint) SocketOptions() *tcpip.SocketOptions {
+	return &e.ops
+}
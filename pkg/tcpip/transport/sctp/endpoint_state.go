@@ -0,0 +1,94 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sctp
+
+import (
+	"context"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/ports"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+// afterLoad is invoked by stateify.
+func (e *Endpoint) afterLoad(ctx context.Context) {
+	if e.stack.IsSaveRestoreEnabled() {
+		e.stack.RegisterRestoredEndpoint(e)
+	} else {
+		stack.RestoreStackFromContext(ctx).RegisterRestoredEndpoint(e)
+	}
+}
+
+// Restore implements tcpip.RestoredEndpoint.Restore.
+func (e *Endpoint) Restore(s *stack.Stack) {
+	e.mu.Lock()
+
+	e.net.Resume(s)
+	if !e.stack.IsSaveRestoreEnabled() {
+		e.stack = s
+	}
+	e.ops.InitHandler(e, e.stack, tcpip.GetStackSendBufferLimits, tcpip.GetStackReceiveBufferLimits)
+
+	switch e.state {
+	case stateInitial, stateClosed:
+	case stateBound, stateListen, stateConnecting, stateConnected:
+		id := e.net.Info().ID
+		id.LocalPort = e.localPort
+		id.RemotePort = e.remotePort
+		if e.boundDest != (tcpip.FullAddress{}) {
+			// Accepted endpoints share the port of their listener.
+			portRes := ports.Reservation{
+				Networks:     e.effectiveNetProtos,
+				Transport:    ProtocolNumber,
+				Addr:         id.LocalAddress,
+				Port:         id.LocalPort,
+				Flags:        e.boundPortFlags,
+				BindToDevice: e.boundBindToDevice,
+				Dest:         e.boundDest,
+			}
+			if !e.stack.ReserveTuple(portRes) {
+				panic("reserving sctp tuple failed during restore")
+			}
+			if err := e.stack.RegisterTransportEndpoint(e.effectiveNetProtos, ProtocolNumber, id, e, e.boundPortFlags, e.boundBindToDevice); err != nil {
+				panic("registering sctp endpoint with the stack failed during restore")
+			}
+			break
+		}
+		// Our saved state had a port, but we don't actually have a
+		// reservation. We need to remove the port from our state, but still
+		// pass it to the reservation machinery.
+		var err tcpip.Error
+		e.localPort = 0
+		id, e.boundBindToDevice, err = e.registerWithStack(e.effectiveNetProtos, id)
+		if err != nil {
+			panic("registering sctp endpoint with the stack failed during restore")
+		}
+		e.localPort = id.LocalPort
+		e.remotePort = id.RemotePort
+	default:
+		panic("unhandled state")
+	}
+
+	for _, a := range e.assocs {
+		r, err := e.stack.FindRoute(a.local.NIC, a.local.Addr, a.remote.Addr, a.netProto, false /* multicastLoop */)
+		if err != nil {
+			a.terminate(err)
+			continue
+		}
+		a.route = r
+		a.resumeTimers()
+	}
+	e.unlockAndFlush()
+}
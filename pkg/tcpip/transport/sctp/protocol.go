@@ -0,0 +1,271 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sctp contains the implementation of the SCTP transport protocol
+// (RFC 9260).
+//
+// Associations are single-homed: an association uses one local and one peer
+// address. Endpoints come in the two styles of the sockets API (RFC 6458):
+// one-to-one endpoints hold at most one association and are used like TCP
+// endpoints, while one-to-many endpoints multiplex any number of associations
+// and are used like UDP endpoints.
+package sctp
+
+import (
+	"io"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/header/parse"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/raw"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+const (
+	// ProtocolNumber is the sctp protocol number.
+	ProtocolNumber = header.SCTPProtocolNumber
+
+	// DefaultRTOInitial is the default retransmission timeout used before the
+	// round trip time of an association is measured.
+	DefaultRTOInitial = 3 * time.Second
+
+	// DefaultRTOMin is the default minimum retransmission timeout.
+	DefaultRTOMin = time.Second
+
+	// DefaultRTOMax is the default maximum retransmission timeout.
+	DefaultRTOMax = 60 * time.Second
+
+	// DefaultMaxInitAttempts is the default number of times an INIT chunk is
+	// sent before an association attempt is abandoned.
+	DefaultMaxInitAttempts = 8
+
+	// DefaultAssocMaxRetrans is the default number of consecutive
+	// retransmissions after which an association is aborted.
+	DefaultAssocMaxRetrans = 10
+
+	// DefaultPathMaxRetrans is the default number of consecutive
+	// retransmissions after which the path to a peer is considered
+	// unreachable.
+	DefaultPathMaxRetrans = 5
+
+	// DefaultHeartbeatInterval is the default interval at which idle
+	// associations are probed with heartbeats.
+	DefaultHeartbeatInterval = 30 * time.Second
+
+	// DefaultCookieLife is the default lifetime of the state cookies sent in
+	// INIT ACK chunks.
+	DefaultCookieLife = 60 * time.Second
+
+	// DefaultSackDelay is the default maximum delay of acknowledgements.
+	DefaultSackDelay = 200 * time.Millisecond
+
+	// DefaultOutStreams is the default number of outbound streams requested
+	// when an association is initiated.
+	DefaultOutStreams = 10
+
+	// DefaultMaxInStreams is the default maximum number of inbound streams.
+	DefaultMaxInStreams = 65535
+
+	// maxBurst is the maximum number of packets sent at once, as per RFC 9260
+	// section 16.
+	maxBurst = 4
+
+	// maxSackDelay is the maximum acknowledgement delay allowed by RFC 9260
+	// section 6.2.
+	maxSackDelay = 500 * time.Millisecond
+)
+
+// +stateify savable
+type protocol struct {
+	stack *stack.Stack
+
+	// secret authenticates the state cookies sent to peers. It is set at
+	// creation time and never changes.
+	secret [32]byte
+}
+
+// Number returns the sctp protocol number.
+func (*protocol) Number() tcpip.TransportProtocolNumber {
+	return ProtocolNumber
+}
+
+// NewEndpoint creates a new one-to-one style sctp endpoint.
+func (p *protocol) NewEndpoint(netProto tcpip.NetworkProtocolNumber, waiterQueue *waiter.Queue) (tcpip.Endpoint, tcpip.Error) {
+	return newEndpoint(p.stack, p, netProto, waiterQueue), nil
+}
+
+// NewRawEndpoint creates a new raw SCTP endpoint. It implements
+// stack.TransportProtocol.NewRawEndpoint.
+func (p *protocol) NewRawEndpoint(netProto tcpip.NetworkProtocolNumber, waiterQueue *waiter.Queue) (tcpip.Endpoint, tcpip.Error) {
+	return raw.NewEndpoint(p.stack, netProto, header.SCTPProtocolNumber, waiterQueue)
+}
+
+// MinimumPacketSize returns the minimum valid sctp packet size.
+func (*protocol) MinimumPacketSize() int {
+	return header.SCTPMinimumSize
+}
+
+// ParsePorts returns the source and destination ports stored in the given sctp
+// packet.
+func (*protocol) ParsePorts(v []byte) (src, dst uint16, err tcpip.Error) {
+	h := header.SCTP(v)
+	return h.SourcePort(), h.DestinationPort(), nil
+}
+
+// HandleUnknownDestinationPacket handles packets that are targeted at this
+// protocol but don't match any existing endpoint. These are "out of the blue"
+// packets and are answered as per RFC 9260 section 8.4.
+func (p *protocol) HandleUnknownDestinationPacket(id stack.TransportEndpointID, pkt *stack.PacketBuffer) stack.UnknownDestinationPacketDisposition {
+	hdr, chunks, ok := p.parse(pkt)
+	if !ok {
+		return stack.UnknownDestinationPacketMalformed
+	}
+	p.stack.Stats().SCTP.OutOfTheBluePacketsReceived.Increment()
+	reply, chunk, ok := outOfTheBlueReply(hdr, chunks)
+	if !ok {
+		return stack.UnknownDestinationPacketHandled
+	}
+	r, err := p.stack.FindRoute(pkt.NICID, id.LocalAddress, id.RemoteAddress, pkt.NetworkProtocolNumber, false /* multicastLoop */)
+	if err != nil {
+		return stack.UnknownDestinationPacketHandled
+	}
+	defer r.Release()
+	sendPacket(p.stack, r, stack.NetworkHeaderParams{
+		Protocol: ProtocolNumber,
+		TTL:      r.DefaultTTL(),
+	}, nil /* owner */, &reply, chunk)
+	return stack.UnknownDestinationPacketHandled
+}
+
+// parse validates the checksum of an incoming packet and splits its chunks.
+func (p *protocol) parse(pkt *stack.PacketBuffer) (header.SCTP, []header.SCTPChunk, bool) {
+	stats := p.stack.Stats().SCTP
+	payload := pkt.Data().AsRange().ToSlice()
+	hdr := header.SCTP(pkt.TransportHeader().Slice())
+	if len(hdr) < header.SCTPMinimumSize {
+		stats.MalformedPacketsReceived.Increment()
+		return nil, nil, false
+	}
+	if header.SCTPChecksum(hdr, payload) != hdr.Checksum() {
+		stats.ChecksumErrors.Increment()
+		return nil, nil, false
+	}
+	chunks, ok := header.ParseSCTPChunks(payload)
+	if !ok || len(chunks) == 0 {
+		stats.MalformedPacketsReceived.Increment()
+		return nil, nil, false
+	}
+	return hdr, chunks, true
+}
+
+// outOfTheBlueReply returns the reply to a packet that doesn't belong to any
+// association, as per RFC 9260 section 8.4.
+//
+// Returns false if the packet must be silently discarded.
+func outOfTheBlueReply(hdr header.SCTP, chunks []header.SCTPChunk) (header.SCTPFields, []byte, bool) {
+	reply := header.SCTPFields{
+		SrcPort:         hdr.DestinationPort(),
+		DstPort:         hdr.SourcePort(),
+		VerificationTag: hdr.VerificationTag(),
+	}
+	var typ header.SCTPChunkType
+	var flags uint8
+	for _, c := range chunks {
+		switch c.Type() {
+		case header.SCTPChunkAbort, header.SCTPChunkShutdownComplete, header.SCTPChunkCookieAck:
+			return header.SCTPFields{}, nil, false
+		case header.SCTPChunkError:
+			if isStaleCookieError(c) {
+				return header.SCTPFields{}, nil, false
+			}
+		case header.SCTPChunkShutdownAck:
+			typ, flags = header.SCTPChunkShutdownComplete, header.SCTPFlagTBit
+		}
+	}
+	switch {
+	case typ != 0:
+	case chunks[0].Type() == header.SCTPChunkInit:
+		// The ABORT carries the initiate tag of the INIT, with the T bit
+		// clear.
+		init := header.SCTPInitChunk(chunks[0])
+		if len(init) < header.SCTPInitChunkMinimumSize {
+			return header.SCTPFields{}, nil, false
+		}
+		typ = header.SCTPChunkAbort
+		reply.VerificationTag = init.InitiateTag()
+	default:
+		typ, flags = header.SCTPChunkAbort, header.SCTPFlagTBit
+	}
+
+	chunk := make([]byte, header.SCTPChunkHeaderSize)
+	header.SCTPChunk(chunk).EncodeHeader(typ, flags)
+	return reply, chunk, true
+}
+
+// isStaleCookieError returns true iff c is an ERROR chunk reporting a stale
+// cookie.
+func isStaleCookieError(c header.SCTPChunk) bool {
+	causes, ok := header.ParseSCTPParameters(c.Value())
+	if !ok {
+		return false
+	}
+	for _, cause := range causes {
+		if cause.CauseCode() == header.SCTPCauseStaleCookie {
+			return true
+		}
+	}
+	return false
+}
+
+// SetOption implements stack.TransportProtocol.SetOption.
+func (*protocol) SetOption(tcpip.SettableTransportProtocolOption) tcpip.Error {
+	return &tcpip.ErrUnknownProtocolOption{}
+}
+
+// Option implements stack.TransportProtocol.Option.
+func (*protocol) Option(tcpip.GettableTransportProtocolOption) tcpip.Error {
+	return &tcpip.ErrUnknownProtocolOption{}
+}
+
+// Close implements stack.TransportProtocol.Close.
+func (*protocol) Close() {}
+
+// Wait implements stack.TransportProtocol.Wait.
+func (*protocol) Wait() {}
+
+// Pause implements stack.TransportProtocol.Pause.
+func (*protocol) Pause() {}
+
+// Resume implements stack.TransportProtocol.Resume.
+func (*protocol) Resume() {}
+
+// Restore implements stack.TransportProtocol.Restore.
+func (*protocol) Restore() {}
+
+// Parse implements stack.TransportProtocol.Parse.
+func (*protocol) Parse(pkt *stack.PacketBuffer) bool {
+	return parse.SCTP(pkt)
+}
+
+// NewProtocol returns an SCTP transport protocol.
+func NewProtocol(s *stack.Stack) stack.TransportProtocol {
+	p := &protocol{stack: s}
+	rng := s.SecureRNG()
+	if _, err := io.ReadFull(rng.Reader, p.secret[:]); err != nil {
+		panic(err)
+	}
+	return p
+}
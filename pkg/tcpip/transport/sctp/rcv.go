@@ -0,0 +1,394 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sctp
+
+import (
+	"encoding/binary"
+	"sort"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+// maxDuplicateTSNs is the maximum number of duplicate TSNs reported in a SACK
+// chunk.
+const maxDuplicateTSNs = 16
+
+// rcvChunk is a received DATA chunk of a user message awaiting reassembly.
+//
+// +stateify savable
+type rcvChunk struct {
+	tsn    uint32
+	stream uint16
+	ssn    uint16
+	ppid   uint32
+	flags  uint8
+	data   []byte
+}
+
+// receiver holds the state of the data an association receives.
+//
+// +stateify savable
+type receiver struct {
+	a *association
+
+	// inStreams is the number of inbound streams and inSSNs holds the next
+	// stream sequence number expected on each of them.
+	inStreams uint16
+	inSSNs    []uint16
+
+	// cumTSN is the highest TSN received in sequence.
+	cumTSN uint32
+
+	// received holds the TSNs received out of sequence.
+	received map[uint32]struct{}
+
+	// frags holds the received chunks of incomplete user messages, by TSN.
+	frags map[uint32]*rcvChunk
+
+	// ordered holds the complete ordered messages that await the delivery
+	// of previous messages of their stream, by stream and SSN.
+	ordered map[uint32]*message
+
+	// pendingBytes is the number of bytes held by frags and ordered.
+	pendingBytes int
+
+	// dups holds the TSNs received more than once since the last SACK.
+	dups []uint32
+
+	// sackPending indicates whether some received DATA chunks weren't
+	// acknowledged and unackedPackets is the number of packets that carried
+	// them.
+	sackPending    bool
+	unackedPackets int
+
+	// advertisedRwnd is the receive window advertised by the last SACK.
+	advertisedRwnd uint32
+}
+
+// init initializes the receiver of a.
+func (r *receiver) init(a *association) {
+	r.a = a
+	r.received = make(map[uint32]struct{})
+	r.frags = make(map[uint32]*rcvChunk)
+	r.ordered = make(map[uint32]*message)
+}
+
+// setInitialTSN sets the TSN the peer uses for its first DATA chunk and the
+// number of inbound streams.
+func (r *receiver) setInitialTSN(tsn uint32, inStreams uint16) {
+	r.cumTSN = tsn - 1
+	r.inStreams = inStreams
+	r.inSSNs = make([]uint16, inStreams)
+	r.advertisedRwnd = r.window()
+}
+
+// reset discards the data awaiting delivery.
+func (r *receiver) reset() {
+	r.received = make(map[uint32]struct{})
+	r.frags = make(map[uint32]*rcvChunk)
+	r.ordered = make(map[uint32]*message)
+	r.pendingBytes = 0
+	r.dups = nil
+}
+
+// pendingChunks returns the number of received chunks and messages that await
+// reassembly or ordered delivery.
+func (r *receiver) pendingChunks() int {
+	return len(r.frags) + len(r.ordered)
+}
+
+// window returns the current receive window.
+func (r *receiver) window() uint32 {
+	ep := r.a.ep
+	avail := ep.ops.GetReceiveBufferSize() - int64(ep.rcvBufUsed) - int64(r.pendingBytes)
+	if avail < 0 {
+		return 0
+	}
+	return uint32(min(avail, 1<<31))
+}
+
+// handleData handles a DATA chunk, as per RFC 9260 section 6.2.
+//
+// Returns false if the rest of the packet must be discarded.
+func (r *receiver) handleData(d header.SCTPDataChunk) bool {
+	if len(d) < header.SCTPDataChunkHeaderSize {
+		return false
+	}
+	tsn := d.TSN()
+	if len(d.Payload()) == 0 {
+		// Empty DATA chunks abort the association, as per RFC 9260 section
+		// 6.2.
+		var v [4]byte
+		binary.BigEndian.PutUint32(v[:], tsn)
+		r.a.abortWithCause(&tcpip.ErrConnectionReset{}, header.SCTPCauseNoUserData, v[:])
+		return false
+	}
+	if r.isDuplicate(tsn) {
+		if len(r.dups) < maxDuplicateTSNs {
+			r.dups = append(r.dups, tsn)
+		}
+		return true
+	}
+	// Out of sequence chunks are dropped once the receive buffer is full, so
+	// that the peer retransmits them later. Chunks in sequence are always
+	// accepted so that messages larger than the buffer can be reassembled.
+	if tsn != r.cumTSN+1 && r.window() < uint32(len(d.Payload())) {
+		return true
+	}
+
+	r.markReceived(tsn)
+	stream := d.StreamID()
+	if stream >= r.inStreams {
+		var v [4]byte
+		binary.BigEndian.PutUint16(v[:], stream)
+		r.a.queueChunks(newChunk(header.SCTPChunkError, 0, newParameter(uint16(header.SCTPCauseInvalidStreamIdentifier), v[:])))
+		return true
+	}
+
+	c := &rcvChunk{
+		tsn:    tsn,
+		stream: stream,
+		ssn:    d.StreamSequence(),
+		ppid:   d.PayloadProtocolIdentifier(),
+		flags:  header.SCTPChunk(d).Flags(),
+		data:   append([]byte(nil), d.Payload()...),
+	}
+	const whole = header.SCTPDataFlagBeginning | header.SCTPDataFlagEnding
+	if c.flags&whole == whole {
+		r.deliver(c, c.data)
+		return true
+	}
+	r.frags[tsn] = c
+	r.pendingBytes += len(c.data)
+	r.reassemble(c)
+	return true
+}
+
+// isDuplicate returns true iff tsn was already received.
+func (r *receiver) isDuplicate(tsn uint32) bool {
+	if tsnLessEq(tsn, r.cumTSN) {
+		return true
+	}
+	_, ok := r.received[tsn]
+	return ok
+}
+
+// markReceived records the reception of tsn.
+func (r *receiver) markReceived(tsn uint32) {
+	if tsn != r.cumTSN+1 {
+		r.received[tsn] = struct{}{}
+		return
+	}
+	r.cumTSN = tsn
+	for {
+		if _, ok := r.received[r.cumTSN+1]; !ok {
+			break
+		}
+		delete(r.received, r.cumTSN+1)
+		r.cumTSN++
+	}
+}
+
+// reassemble delivers the user message c belongs to if all its fragments
+// were received.
+func (r *receiver) reassemble(c *rcvChunk) {
+	first := c
+	for first.flags&header.SCTPDataFlagBeginning == 0 {
+		prev, ok := r.frags[first.tsn-1]
+		if !ok || !sameMessage(prev, c) {
+			return
+		}
+		first = prev
+	}
+	size := 0
+	last := first
+	for {
+		size += len(last.data)
+		if last.flags&header.SCTPDataFlagEnding != 0 {
+			break
+		}
+		next, ok := r.frags[last.tsn+1]
+		if !ok || !sameMessage(next, c) || next.flags&header.SCTPDataFlagBeginning != 0 {
+			return
+		}
+		last = next
+	}
+
+	data := make([]byte, 0, size)
+	for tsn := first.tsn; ; tsn++ {
+		f := r.frags[tsn]
+		data = append(data, f.data...)
+		delete(r.frags, tsn)
+		if tsn == last.tsn {
+			break
+		}
+	}
+	r.pendingBytes -= size
+	r.deliver(first, data)
+}
+
+// sameMessage returns true iff the fragments a and b may belong to the same
+// user message.
+func sameMessage(a, b *rcvChunk) bool {
+	if a.stream != b.stream || a.flags&header.SCTPDataFlagUnordered != b.flags&header.SCTPDataFlagUnordered {
+		return false
+	}
+	return a.flags&header.SCTPDataFlagUnordered != 0 || a.ssn == b.ssn
+}
+
+// orderedKey returns the key of the ordered message with the given stream and
+// SSN.
+func orderedKey(stream, ssn uint16) uint32 {
+	return uint32(stream)<<16 | uint32(ssn)
+}
+
+// deliver hands a complete user message whose first chunk is first to the
+// endpoint, or holds it until the previous messages of its stream are
+// delivered.
+func (r *receiver) deliver(first *rcvChunk, data []byte) {
+	m := &message{
+		data: data,
+		from: r.a.remote,
+		info: tcpip.SCTPSndRcvInfo{
+			Stream:  first.stream,
+			SSN:     first.ssn,
+			PPID:    first.ppid,
+			TSN:     first.tsn,
+			CumTSN:  r.cumTSN,
+			AssocID: r.a.id,
+		},
+		assoc: r.a,
+	}
+	if first.flags&header.SCTPDataFlagUnordered != 0 {
+		m.info.Flags = tcpip.SCTPUnordered
+		m.info.SSN = 0
+		r.a.ep.enqueueMessageLocked(m)
+		return
+	}
+
+	stream := first.stream
+	if first.ssn != r.inSSNs[stream] {
+		if ssnLess(first.ssn, r.inSSNs[stream]) {
+			return
+		}
+		r.ordered[orderedKey(stream, first.ssn)] = m
+		r.pendingBytes += len(data)
+		return
+	}
+	r.a.ep.enqueueMessageLocked(m)
+	r.inSSNs[stream]++
+	for {
+		key := orderedKey(stream, r.inSSNs[stream])
+		next, ok := r.ordered[key]
+		if !ok {
+			return
+		}
+		delete(r.ordered, key)
+		r.pendingBytes -= len(next.data)
+		r.a.ep.enqueueMessageLocked(next)
+		r.inSSNs[stream]++
+	}
+}
+
+// ssnLess returns true iff SSN a precedes SSN b in serial number arithmetic.
+func ssnLess(a, b uint16) bool {
+	return int16(a-b) < 0
+}
+
+// packetReceived acknowledges the DATA chunks of a packet, as per RFC 9260
+// section 6.2. immediate indicates whether the peer asked for the SACK not to
+// be delayed.
+func (r *receiver) packetReceived(immediate bool) {
+	a := r.a
+	r.sackPending = true
+	r.unackedPackets++
+	if immediate || r.unackedPackets >= 2 || len(r.received) > 0 || len(r.dups) > 0 || a.sackDelay == 0 || a.state >= assocShutdownSent {
+		r.sendSack()
+		return
+	}
+	if a.sackTimer == nil {
+		a.startTimer(&a.sackTimer, a.sackDelay, r.sendSack)
+	}
+}
+
+// sendSack sends a SACK chunk.
+func (r *receiver) sendSack() {
+	a := r.a
+	if a.state == assocClosed {
+		return
+	}
+	room := a.pmtu - header.SCTPMinimumSize - header.SCTPSackChunkSize(0, len(r.dups))
+	gaps := r.gapAckBlocks(room / 4)
+	b := make([]byte, header.SCTPSackChunkSize(len(gaps), len(r.dups)))
+	header.SCTPSackChunk(b).Encode(&header.SCTPSackChunkFields{
+		CumulativeTSNAck:               r.cumTSN,
+		AdvertisedReceiverWindowCredit: r.window(),
+		GapAckBlocks:                   gaps,
+		DuplicateTSNs:                  r.dups,
+	})
+	a.queueChunks(b)
+	r.sackSent()
+}
+
+// sackSent records that the received DATA chunks were acknowledged.
+func (r *receiver) sackSent() {
+	r.sackPending = false
+	r.unackedPackets = 0
+	r.dups = nil
+	r.advertisedRwnd = r.window()
+	r.a.stopTimer(&r.a.sackTimer)
+}
+
+// gapAckBlocks returns at most limit gap ack blocks describing the TSNs
+// received out of sequence.
+func (r *receiver) gapAckBlocks(limit int) []header.SCTPGapAckBlock {
+	if len(r.received) == 0 {
+		return nil
+	}
+	offs := make([]uint32, 0, len(r.received))
+	for tsn := range r.received {
+		if off := tsn - r.cumTSN; off <= 0xffff {
+			offs = append(offs, off)
+		}
+	}
+	sort.Slice(offs, func(i, j int) bool { return offs[i] < offs[j] })
+	var gaps []header.SCTPGapAckBlock
+	for _, off := range offs {
+		if n := len(gaps); n > 0 && uint32(gaps[n-1].End)+1 == off {
+			gaps[n-1].End = uint16(off)
+			continue
+		}
+		if len(gaps) == limit {
+			break
+		}
+		gaps = append(gaps, header.SCTPGapAckBlock{Start: uint16(off), End: uint16(off)})
+	}
+	return gaps
+}
+
+// maybeSendWindowUpdate acknowledges the received data again once the user
+// read enough of it for the peer to benefit from the larger window.
+func (r *receiver) maybeSendWindowUpdate() {
+	a := r.a
+	if !a.state.connected() {
+		return
+	}
+	w := r.window()
+	threshold := max(uint32(a.ep.ops.GetReceiveBufferSize()>>4), uint32(a.pmtu))
+	if w > r.advertisedRwnd && w-r.advertisedRwnd >= threshold {
+		r.sendSack()
+	}
+}
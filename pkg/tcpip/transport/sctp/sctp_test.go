@@ -0,0 +1,419 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sctp_test
+
+import (
+	"bytes"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/faketime"
+	"gvisor.dev/gvisor/pkg/tcpip/link/loopback"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/testutil"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/sctp"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+const (
+	nicID      = 1
+	serverPort = 5000
+)
+
+var localAddr = testutil.MustParse4("127.0.0.1")
+
+type testContext struct {
+	t     *testing.T
+	s     *stack.Stack
+	clock *faketime.ManualClock
+}
+
+func newTestContext(t *testing.T) *testContext {
+	clock := faketime.NewManualClock()
+	s := stack.New(stack.Options{
+		NetworkProtocols:   []stack.NetworkProtocolFactory{ipv4.NewProtocol},
+		TransportProtocols: []stack.TransportProtocolFactory{sctp.NewProtocol},
+		Clock:              clock,
+	})
+	if err := s.CreateNIC(nicID, loopback.New()); err != nil {
+		t.Fatalf("CreateNIC(%d, _): %s", nicID, err)
+	}
+	protocolAddr := tcpip.ProtocolAddress{
+		Protocol:          ipv4.ProtocolNumber,
+		AddressWithPrefix: localAddr.WithPrefix(),
+	}
+	if err := s.AddProtocolAddress(nicID, protocolAddr, stack.AddressProperties{}); err != nil {
+		t.Fatalf("AddProtocolAddress(%d, %+v, {}): %s", nicID, protocolAddr, err)
+	}
+	s.SetRouteTable([]tcpip.Route{{Destination: localAddr.WithPrefix().Subnet(), NIC: nicID}})
+	t.Cleanup(func() {
+		s.Close()
+		s.Wait()
+		s.Destroy()
+	})
+	return &testContext{t: t, s: s, clock: clock}
+}
+
+func (c *testContext) newEndpoint(oneToMany bool) tcpip.Endpoint {
+	ep, err := c.s.NewEndpoint(sctp.ProtocolNumber, ipv4.ProtocolNumber, &waiter.Queue{})
+	if err != nil {
+		c.t.Fatalf("NewEndpoint: %s", err)
+	}
+	if oneToMany {
+		ep.(*sctp.Endpoint).SetOneToMany()
+	}
+	c.t.Cleanup(ep.Close)
+	return ep
+}
+
+func (c *testContext) listen(oneToMany bool) tcpip.Endpoint {
+	ep := c.newEndpoint(oneToMany)
+	if err := ep.Bind(tcpip.FullAddress{Port: serverPort}); err != nil {
+		c.t.Fatalf("Bind: %s", err)
+	}
+	if err := ep.Listen(10); err != nil {
+		c.t.Fatalf("Listen: %s", err)
+	}
+	return ep
+}
+
+// connect sets up an association between two one-to-one endpoints and returns
+// them.
+func (c *testContext) connect() (client, server tcpip.Endpoint) {
+	listener := c.listen(false /* oneToMany */)
+	client = c.newEndpoint(false /* oneToMany */)
+	if err := client.Connect(tcpip.FullAddress{Addr: localAddr, Port: serverPort}); err != nil {
+		if _, ok := err.(*tcpip.ErrConnectStarted); !ok {
+			c.t.Fatalf("Connect: %s", err)
+		}
+	}
+	if err := client.Connect(tcpip.FullAddress{Addr: localAddr, Port: serverPort}); err != nil {
+		c.t.Fatalf("Connect after setup: %s", err)
+	}
+	server, _, err := listener.Accept(nil)
+	if err != nil {
+		c.t.Fatalf("Accept: %s", err)
+	}
+	c.t.Cleanup(server.Close)
+	return client, server
+}
+
+func write(t *testing.T, ep tcpip.Endpoint, data []byte, opts tcpip.WriteOptions) {
+	t.Helper()
+	var r bytes.Reader
+	r.Reset(data)
+	if n, err := ep.Write(&r, opts); err != nil || n != int64(len(data)) {
+		t.Fatalf("Write(%q, %+v) = (%d, %v), want (%d, nil)", data, opts, n, err, len(data))
+	}
+}
+
+func read(t *testing.T, ep tcpip.Endpoint) ([]byte, tcpip.ReadResult) {
+	t.Helper()
+	var buf bytes.Buffer
+	res, err := ep.Read(&buf, tcpip.ReadOptions{NeedRemoteAddr: true})
+	if err != nil {
+		t.Fatalf("Read: %s", err)
+	}
+	return buf.Bytes(), res
+}
+
+func status(t *testing.T, ep tcpip.Endpoint) tcpip.SCTPStatusOption {
+	t.Helper()
+	var s tcpip.SCTPStatusOption
+	if err := ep.GetSockOpt(&s); err != nil {
+		t.Fatalf("GetSockOpt(SCTPStatusOption): %s", err)
+	}
+	return s
+}
+
+func TestConnectAccept(t *testing.T) {
+	c := newTestContext(t)
+	client, server := c.connect()
+
+	for _, ep := range []tcpip.Endpoint{client, server} {
+		if got := status(t, ep).State; got != tcpip.SCTPStateEstablished {
+			t.Errorf("got association state = %d, want = %d", got, tcpip.SCTPStateEstablished)
+		}
+	}
+	if got, want := c.s.Stats().SCTP.ActiveEstablishes.Value(), uint64(1); got != want {
+		t.Errorf("got ActiveEstablishes = %d, want = %d", got, want)
+	}
+	if got, want := c.s.Stats().SCTP.PassiveEstablishes.Value(), uint64(1); got != want {
+		t.Errorf("got PassiveEstablishes = %d, want = %d", got, want)
+	}
+	addr, err := server.GetRemoteAddress()
+	if err != nil {
+		t.Fatalf("GetRemoteAddress: %s", err)
+	}
+	clientAddr, err := client.GetLocalAddress()
+	if err != nil {
+		t.Fatalf("GetLocalAddress: %s", err)
+	}
+	if addr.Addr != clientAddr.Addr || addr.Port != clientAddr.Port {
+		t.Errorf("got server peer = %+v, want = %+v", addr, clientAddr)
+	}
+}
+
+func TestConnectRefused(t *testing.T) {
+	c := newTestContext(t)
+	ep := c.newEndpoint(false /* oneToMany */)
+	addr := tcpip.FullAddress{Addr: localAddr, Port: serverPort}
+	if err := ep.Connect(addr); err != nil {
+		if _, ok := err.(*tcpip.ErrConnectStarted); !ok {
+			t.Fatalf("Connect: %s", err)
+		}
+	}
+	err := ep.Connect(addr)
+	if _, ok := err.(*tcpip.ErrConnectionRefused); !ok {
+		t.Fatalf("got Connect() = %v, want = %s", err, &tcpip.ErrConnectionRefused{})
+	}
+}
+
+func TestMessageBoundaries(t *testing.T) {
+	c := newTestContext(t)
+	client, server := c.connect()
+
+	msgs := [][]byte{[]byte("first"), []byte("second message"), []byte("3")}
+	for _, m := range msgs {
+		write(t, client, m, tcpip.WriteOptions{})
+	}
+	// Nagle's algorithm holds small messages back until the first one is
+	// acknowledged, and acknowledgements are delayed.
+	c.clock.Advance(sctp.DefaultSackDelay)
+	for _, want := range msgs {
+		if got, _ := read(t, server); !bytes.Equal(got, want) {
+			t.Errorf("got Read() = %q, want = %q", got, want)
+		}
+	}
+}
+
+func TestFragmentation(t *testing.T) {
+	c := newTestContext(t)
+	client, server := c.connect()
+	if err := client.SetSockOptInt(tcpip.MaxSegOption, 100); err != nil {
+		t.Fatalf("SetSockOptInt(MaxSegOption, 100): %s", err)
+	}
+
+	want := bytes.Repeat([]byte("0123456789"), 100)
+	write(t, client, want, tcpip.WriteOptions{})
+	if got, _ := read(t, server); !bytes.Equal(got, want) {
+		t.Errorf("got Read() = %q, want = %q", got, want)
+	}
+}
+
+func TestStreams(t *testing.T) {
+	c := newTestContext(t)
+	client, server := c.connect()
+	if err := server.SetSockOpt(&tcpip.SCTPEventsOption{DataIO: true}); err != nil {
+		t.Fatalf("SetSockOpt(SCTPEventsOption): %s", err)
+	}
+
+	sends := []tcpip.SCTPSndRcvInfo{
+		{Stream: 0, PPID: 1},
+		{Stream: 3, PPID: 2},
+		{Stream: 3, PPID: 3},
+		{Stream: 0, PPID: 4},
+	}
+	for i := range sends {
+		write(t, client, []byte{byte(i)}, tcpip.WriteOptions{
+			ControlMessages: tcpip.SendableControlMessages{
+				HasSCTPSndRcvInfo: true,
+				SCTPSndRcvInfo:    sends[i],
+			},
+		})
+	}
+	c.clock.Advance(sctp.DefaultSackDelay)
+	wantSSNs := []uint16{0, 0, 1, 1}
+	for i, snd := range sends {
+		_, res := read(t, server)
+		if !res.ControlMessages.HasSCTPSndRcvInfo {
+			t.Fatalf("message %d has no SCTPSndRcvInfo", i)
+		}
+		info := res.ControlMessages.SCTPSndRcvInfo
+		if info.Stream != snd.Stream || info.PPID != snd.PPID || info.SSN != wantSSNs[i] {
+			t.Errorf("got message %d info = %+v, want stream = %d, ppid = %d, ssn = %d", i, info, snd.Stream, snd.PPID, wantSSNs[i])
+		}
+	}
+
+	// Streams beyond those negotiated are rejected.
+	outStreams := status(t, client).OutStreams
+	var r bytes.Reader
+	r.Reset([]byte("x"))
+	_, err := client.Write(&r, tcpip.WriteOptions{
+		ControlMessages: tcpip.SendableControlMessages{
+			HasSCTPSndRcvInfo: true,
+			SCTPSndRcvInfo:    tcpip.SCTPSndRcvInfo{Stream: outStreams},
+		},
+	})
+	if _, ok := err.(*tcpip.ErrInvalidOptionValue); !ok {
+		t.Errorf("got Write() on stream %d = %v, want = %s", outStreams, err, &tcpip.ErrInvalidOptionValue{})
+	}
+}
+
+func TestUnordered(t *testing.T) {
+	c := newTestContext(t)
+	client, server := c.connect()
+	if err := server.SetSockOpt(&tcpip.SCTPEventsOption{DataIO: true}); err != nil {
+		t.Fatalf("SetSockOpt(SCTPEventsOption): %s", err)
+	}
+
+	write(t, client, []byte("unordered"), tcpip.WriteOptions{
+		ControlMessages: tcpip.SendableControlMessages{
+			HasSCTPSndRcvInfo: true,
+			SCTPSndRcvInfo:    tcpip.SCTPSndRcvInfo{Flags: tcpip.SCTPUnordered},
+		},
+	})
+	got, res := read(t, server)
+	if string(got) != "unordered" {
+		t.Errorf("got Read() = %q, want = %q", got, "unordered")
+	}
+	if res.ControlMessages.SCTPSndRcvInfo.Flags&tcpip.SCTPUnordered == 0 {
+		t.Errorf("got flags = %#x, want SCTPUnordered set", res.ControlMessages.SCTPSndRcvInfo.Flags)
+	}
+}
+
+func TestPartialRead(t *testing.T) {
+	c := newTestContext(t)
+	client, server := c.connect()
+
+	write(t, client, []byte("abcdef"), tcpip.WriteOptions{})
+	var buf bytes.Buffer
+	res, err := server.Read(&tcpip.LimitedWriter{W: &buf, N: 2}, tcpip.ReadOptions{})
+	if err != nil {
+		t.Fatalf("Read: %s", err)
+	}
+	if res.Count != 2 || res.Total != 6 || buf.String() != "ab" {
+		t.Errorf("got Read() = (%+v, %q), want Count = 2, Total = 6, data = %q", res, buf.String(), "ab")
+	}
+	if got, _ := read(t, server); string(got) != "cdef" {
+		t.Errorf("got Read() = %q, want = %q", got, "cdef")
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	c := newTestContext(t)
+	client, _ := c.connect()
+
+	if got := status(t, client).PrimaryRTO; got != sctp.DefaultRTOInitial {
+		t.Fatalf("got RTO = %s, want = %s", got, sctp.DefaultRTOInitial)
+	}
+	// The heartbeat acknowledgement measures the round trip time, which is
+	// zero on the manual clock, so the RTO drops to its minimum.
+	c.clock.Advance(sctp.DefaultHeartbeatInterval + sctp.DefaultRTOInitial)
+	s := status(t, client)
+	if s.PrimaryRTO != sctp.DefaultRTOMin {
+		t.Errorf("got RTO = %s, want = %s", s.PrimaryRTO, sctp.DefaultRTOMin)
+	}
+	if s.State != tcpip.SCTPStateEstablished || !s.PrimaryActive {
+		t.Errorf("got status = %+v, want an established association with an active path", s)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	c := newTestContext(t)
+	client, server := c.connect()
+
+	write(t, client, []byte("bye"), tcpip.WriteOptions{})
+	client.Close()
+	// The association is shut down once the data is acknowledged.
+	c.clock.Advance(sctp.DefaultSackDelay)
+
+	if got, _ := read(t, server); string(got) != "bye" {
+		t.Errorf("got Read() = %q, want = %q", got, "bye")
+	}
+	var buf bytes.Buffer
+	if _, err := server.Read(&buf, tcpip.ReadOptions{}); err == nil {
+		t.Fatalf("got Read() = nil, want error")
+	} else if _, ok := err.(*tcpip.ErrClosedForReceive); !ok {
+		t.Errorf("got Read() = %s, want = %s", err, &tcpip.ErrClosedForReceive{})
+	}
+	// Both associations were shut down gracefully.
+	if got, want := c.s.Stats().SCTP.Shutdowns.Value(), uint64(2); got != want {
+		t.Errorf("got Shutdowns = %d, want = %d", got, want)
+	}
+}
+
+func TestAbort(t *testing.T) {
+	c := newTestContext(t)
+	client, server := c.connect()
+
+	client.SocketOptions().SetLinger(tcpip.LingerOption{Enabled: true})
+	client.Close()
+
+	var buf bytes.Buffer
+	_, err := server.Read(&buf, tcpip.ReadOptions{})
+	if _, ok := err.(*tcpip.ErrConnectionReset); !ok {
+		t.Errorf("got Read() = %v, want = %s", err, &tcpip.ErrConnectionReset{})
+	}
+}
+
+func TestOneToMany(t *testing.T) {
+	c := newTestContext(t)
+	server := c.listen(true /* oneToMany */)
+	clients := []tcpip.Endpoint{c.newEndpoint(true /* oneToMany */), c.newEndpoint(true /* oneToMany */)}
+
+	to := tcpip.FullAddress{Addr: localAddr, Port: serverPort}
+	for i, ep := range clients {
+		write(t, ep, []byte{byte(i)}, tcpip.WriteOptions{To: &to})
+	}
+	if err := server.SetSockOpt(&tcpip.SCTPEventsOption{DataIO: true}); err != nil {
+		t.Fatalf("SetSockOpt(SCTPEventsOption): %s", err)
+	}
+
+	ids := make(map[tcpip.SCTPAssocID]bool)
+	for i, ep := range clients {
+		got, res := read(t, server)
+		if !bytes.Equal(got, []byte{byte(i)}) {
+			t.Errorf("got Read() = %v, want = %v", got, []byte{byte(i)})
+		}
+		addr, err := ep.GetLocalAddress()
+		if err != nil {
+			t.Fatalf("GetLocalAddress: %s", err)
+		}
+		if res.RemoteAddr.Port != addr.Port {
+			t.Errorf("got sender port = %d, want = %d", res.RemoteAddr.Port, addr.Port)
+		}
+		ids[res.ControlMessages.SCTPSndRcvInfo.AssocID] = true
+	}
+	if len(ids) != len(clients) {
+		t.Errorf("got association IDs = %v, want %d distinct IDs", ids, len(clients))
+	}
+
+	// Reply on the first association by ID.
+	for id := range ids {
+		write(t, server, []byte("reply"), tcpip.WriteOptions{
+			ControlMessages: tcpip.SendableControlMessages{
+				HasSCTPSndRcvInfo: true,
+				SCTPSndRcvInfo:    tcpip.SCTPSndRcvInfo{AssocID: id},
+			},
+		})
+		break
+	}
+	replies := 0
+	for _, ep := range clients {
+		var buf bytes.Buffer
+		if _, err := ep.Read(&buf, tcpip.ReadOptions{}); err == nil {
+			replies++
+		}
+	}
+	if replies != 1 {
+		t.Errorf("got %d replies, want = 1", replies)
+	}
+
+	if _, _, err := server.Accept(nil); err == nil {
+		t.Errorf("got Accept() = nil, want error on a one-to-many endpoint")
+	}
+}
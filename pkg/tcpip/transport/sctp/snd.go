@@ -0,0 +1,531 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sctp
+
+import (
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+// fastRetransmitThreshold is the number of miss indications after which a
+// DATA chunk is fast retransmitted, as per RFC 9260 section 7.2.4.
+const fastRetransmitThreshold = 3
+
+// sndChunk is a DATA chunk queued for transmission or awaiting
+// acknowledgement.
+//
+// +stateify savable
+type sndChunk struct {
+	// tsn is assigned when the chunk is first transmitted.
+	tsn    uint32
+	stream uint16
+	ssn    uint16
+	ppid   uint32
+	flags  uint8
+	data   []byte
+
+	// sentAt is the time the chunk was last transmitted at.
+	sentAt tcpip.MonotonicTime
+
+	// transmissions is the number of times the chunk was transmitted.
+	transmissions int
+
+	// gapAcked indicates whether the peer reported the chunk in a gap ack
+	// block of its last SACK.
+	gapAcked bool
+
+	// misses is the number of miss indications of the chunk.
+	misses int
+
+	// retransmit indicates whether the chunk awaits retransmission. Chunks
+	// awaiting retransmission don't count in the flight size.
+	retransmit bool
+}
+
+// sender holds the state of the data an association sends.
+//
+// +stateify savable
+type sender struct {
+	a *association
+
+	// outStreams is the number of outbound streams and outSSNs holds the
+	// next stream sequence number of each ordered stream.
+	outStreams uint16
+	outSSNs    []uint16
+
+	// nextTSN is the TSN of the next DATA chunk transmitted for the first
+	// time.
+	nextTSN uint32
+
+	// cumAcked is the cumulative TSN ack of the peer's last SACK.
+	cumAcked uint32
+
+	// queue holds the DATA chunks never transmitted, in order.
+	queue      []*sndChunk
+	queueBytes int
+
+	// inflight holds the transmitted DATA chunks that weren't cumulatively
+	// acknowledged, in TSN order.
+	inflight []*sndChunk
+
+	// flightSize is the number of bytes in flight.
+	flightSize int
+
+	// peerRwnd is the receive window of the peer, less the bytes in flight.
+	peerRwnd uint32
+
+	// Congestion control state, as per RFC 9260 section 7.2.
+	cwnd              int
+	ssthresh          int
+	partialBytesAcked int
+	fastRecovery      bool
+	fastRecoveryExit  uint32
+
+	// rttPending indicates whether the chunk with TSN rttTSN, transmitted at
+	// rttSentAt, is used to measure the round trip time.
+	rttPending bool
+	rttTSN     uint32
+	rttSentAt  tcpip.MonotonicTime
+}
+
+// init initializes the sender of a.
+func (s *sender) init(a *association) {
+	s.a = a
+	s.cwnd = min(4*a.pmtu, max(2*a.pmtu, 4380))
+	s.ssthresh = 1 << 30
+}
+
+// setInitialTSN sets the TSN of the first DATA chunk.
+func (s *sender) setInitialTSN(tsn uint32) {
+	s.nextTSN = tsn
+	s.cumAcked = tsn - 1
+}
+
+// outstanding returns true iff some transmitted DATA chunks weren't
+// cumulatively acknowledged.
+func (s *sender) outstanding() bool {
+	return len(s.inflight) > 0
+}
+
+// pending returns true iff some DATA chunks weren't acknowledged yet.
+func (s *sender) pending() bool {
+	return len(s.queue) > 0 || len(s.inflight) > 0
+}
+
+// reset discards the queued DATA chunks, releasing their send buffer space.
+func (s *sender) reset() {
+	freed := s.queueBytes
+	for _, c := range s.inflight {
+		freed += len(c.data)
+	}
+	s.queue = nil
+	s.queueBytes = 0
+	s.inflight = nil
+	s.flightSize = 0
+	s.a.ep.releaseSendBufferLocked(freed)
+}
+
+// fragmentationPoint returns the largest user data sent in a single DATA
+// chunk.
+func (s *sender) fragmentationPoint() int {
+	frag := s.a.pmtu - header.SCTPMinimumSize - header.SCTPDataChunkHeaderSize
+	if maxSeg := s.a.ep.maxSeg; maxSeg != 0 && maxSeg < frag {
+		frag = maxSeg
+	}
+	// Keep fragments aligned so that they don't need padding.
+	return frag &^ 3
+}
+
+// enqueue splits a user message into DATA chunks and queues them for
+// transmission. The caller must have reserved send buffer space for the
+// message.
+func (s *sender) enqueue(data []byte, info *tcpip.SCTPSndRcvInfo) {
+	var flags uint8
+	var ssn uint16
+	if info.Flags&tcpip.SCTPUnordered != 0 {
+		flags |= header.SCTPDataFlagUnordered
+	} else {
+		if s.outSSNs == nil {
+			s.outSSNs = make([]uint16, s.outStreams)
+		}
+		ssn = s.outSSNs[info.Stream]
+		s.outSSNs[info.Stream]++
+	}
+	frag := s.fragmentationPoint()
+	for first := true; first || len(data) > 0; first = false {
+		n := min(len(data), frag)
+		c := &sndChunk{
+			stream: info.Stream,
+			ssn:    ssn,
+			ppid:   info.PPID,
+			flags:  flags,
+			data:   data[:n:n],
+		}
+		if first {
+			c.flags |= header.SCTPDataFlagBeginning
+		}
+		data = data[n:]
+		if len(data) == 0 {
+			c.flags |= header.SCTPDataFlagEnding
+			if info.Flags&tcpip.SCTPSackImmediately != 0 {
+				c.flags |= header.SCTPDataFlagImmediate
+			}
+		}
+		s.queue = append(s.queue, c)
+		s.queueBytes += n
+	}
+}
+
+// appendDataChunk appends c, encoded as a padded DATA chunk, to b.
+func appendDataChunk(b []byte, c *sndChunk) []byte {
+	l := header.SCTPDataChunkHeaderSize + len(c.data)
+	off := len(b)
+	b = append(b, make([]byte, header.SCTPPaddedLength(l))...)
+	d := header.SCTPDataChunk(b[off : off+l])
+	copy(d[header.SCTPDataChunkHeaderSize:], c.data)
+	d.Encode(&header.SCTPDataChunkFields{
+		Flags:                     c.flags,
+		TSN:                       c.tsn,
+		StreamID:                  c.stream,
+		StreamSequence:            c.ssn,
+		PayloadProtocolIdentifier: c.ppid,
+	})
+	return b
+}
+
+// dataChunkSize returns the size of c, encoded as a padded DATA chunk.
+func dataChunkSize(c *sndChunk) int {
+	return header.SCTPPaddedLength(header.SCTPDataChunkHeaderSize + len(c.data))
+}
+
+// transmit sends the DATA chunks awaiting retransmission and then new DATA
+// chunks, as allowed by the congestion and receive windows.
+func (s *sender) transmit() {
+	switch s.a.state {
+	case assocEstablished, assocShutdownPending, assocShutdownReceived:
+	default:
+		return
+	}
+	for burst := 0; burst < maxBurst; burst++ {
+		b := s.buildPacket()
+		if b == nil {
+			break
+		}
+		s.a.queueChunks(b)
+	}
+	if s.outstanding() && s.a.rtxTimer == nil {
+		s.a.startTimer(&s.a.rtxTimer, s.a.rto, s.onRetransmitTimeout)
+	}
+}
+
+// buildPacket returns the DATA chunks of the next packet to send, or nil if
+// no DATA chunk can be sent.
+func (s *sender) buildPacket() []byte {
+	room := s.a.pmtu - header.SCTPMinimumSize
+	now := s.a.now()
+	var b []byte
+
+	// Retransmissions go first, as per RFC 9260 section 6.1.
+	for _, c := range s.inflight {
+		if !c.retransmit {
+			continue
+		}
+		if s.flightSize >= s.cwnd || len(b)+dataChunkSize(c) > room {
+			return b
+		}
+		b = appendDataChunk(b, c)
+		c.retransmit = false
+		c.misses = 0
+		c.transmissions++
+		c.sentAt = now
+		s.flightSize += len(c.data)
+	}
+
+	for len(s.queue) > 0 {
+		c := s.queue[0]
+		if c.stream >= s.outStreams {
+			// The peer accepted fewer streams than were requested when the
+			// message was queued.
+			s.queue = s.queue[1:]
+			s.queueBytes -= len(c.data)
+			s.a.ep.releaseSendBufferLocked(len(c.data))
+			continue
+		}
+		if s.flightSize >= s.cwnd || len(b)+dataChunkSize(c) > room {
+			break
+		}
+		// A chunk may be sent to a closed window only as a probe when no
+		// data is in flight, as per RFC 9260 section 6.1.
+		if uint32(len(c.data)) > s.peerRwnd && s.flightSize > 0 {
+			break
+		}
+		// Small chunks are delayed while data is in flight unless Nagle's
+		// algorithm is disabled, as per RFC 9260 section 6.1.
+		if b == nil && !s.a.ep.noDelay && s.flightSize > 0 && s.queueBytes < room-header.SCTPDataChunkHeaderSize {
+			break
+		}
+		s.queue = s.queue[1:]
+		s.queueBytes -= len(c.data)
+		c.tsn = s.nextTSN
+		s.nextTSN++
+		c.transmissions = 1
+		c.sentAt = now
+		b = appendDataChunk(b, c)
+		s.inflight = append(s.inflight, c)
+		s.flightSize += len(c.data)
+		s.peerRwnd = subSat(s.peerRwnd, uint32(len(c.data)))
+		if !s.rttPending {
+			s.rttPending = true
+			s.rttTSN = c.tsn
+			s.rttSentAt = now
+		}
+	}
+	return b
+}
+
+// onRetransmitTimeout handles the expiration of the T3-rtx timer, as per RFC
+// 9260 section 6.3.3.
+func (s *sender) onRetransmitTimeout() {
+	a := s.a
+	if !s.outstanding() {
+		return
+	}
+	a.ep.stack.Stats().SCTP.T3RtxExpireds.Increment()
+	a.errorCount++
+	if a.errorCount > a.maxRetrans {
+		a.abort(&tcpip.ErrTimeout{})
+		return
+	}
+	a.rto = min(2*a.rto, a.rtoMax)
+	s.ssthresh = max(s.cwnd/2, 4*a.pmtu)
+	s.cwnd = a.pmtu
+	s.partialBytesAcked = 0
+	s.fastRecovery = false
+	s.rttPending = false
+	for _, c := range s.inflight {
+		if !c.gapAcked && !c.retransmit {
+			c.retransmit = true
+			s.flightSize -= len(c.data)
+		}
+	}
+	s.transmit()
+	a.startTimer(&a.rtxTimer, a.rto, s.onRetransmitTimeout)
+}
+
+// handleCumulativeAck handles a cumulative TSN ack carried by a SHUTDOWN
+// chunk.
+func (s *sender) handleCumulativeAck(cum uint32) {
+	if !tsnLess(s.cumAcked, cum) {
+		return
+	}
+	s.ack(cum, nil, s.peerRwnd+uint32(s.flightSize))
+}
+
+// handleSack handles a SACK chunk, as per RFC 9260 section 6.2.1.
+func (s *sender) handleSack(c header.SCTPSackChunk) {
+	if !c.IsValid() {
+		return
+	}
+	cum := c.CumulativeTSNAck()
+	if tsnLess(cum, s.cumAcked) {
+		// The SACK is older than one already processed.
+		return
+	}
+	if !tsnLess(cum, s.nextTSN) {
+		// The peer acknowledges data that was never sent.
+		s.a.abortWithCause(&tcpip.ErrConnectionAborted{}, header.SCTPCauseProtocolViolation, nil)
+		return
+	}
+	gaps := make([]header.SCTPGapAckBlock, c.NumGapAckBlocks())
+	for i := range gaps {
+		gaps[i] = c.GapAckBlock(i)
+	}
+	s.ack(cum, gaps, c.AdvertisedReceiverWindowCredit())
+}
+
+// ack processes the acknowledgement of the DATA chunks up to cum and in the
+// given gap ack blocks.
+func (s *sender) ack(cum uint32, gaps []header.SCTPGapAckBlock, rwnd uint32) {
+	a := s.a
+	now := a.now()
+	flightBefore := s.flightSize
+	advanced := cum != s.cumAcked
+	bytesAcked := 0
+	freed := 0
+
+	// Drop the cumulatively acknowledged chunks.
+	n := 0
+	for ; n < len(s.inflight); n++ {
+		c := s.inflight[n]
+		if tsnLess(cum, c.tsn) {
+			break
+		}
+		if !c.gapAcked && !c.retransmit {
+			s.flightSize -= len(c.data)
+			bytesAcked += len(c.data)
+		}
+		freed += len(c.data)
+		if s.rttPending && c.tsn == s.rttTSN {
+			s.rttPending = false
+			// Retransmitted chunks don't give valid samples, as per RFC
+			// 9260 section 6.3.1.
+			if c.transmissions == 1 {
+				a.updateRTO(now.Sub(s.rttSentAt))
+			}
+		}
+	}
+	s.inflight = append(s.inflight[:0:0], s.inflight[n:]...)
+	s.cumAcked = cum
+
+	// Process the gap ack blocks.
+	var highestNewlyAcked uint32
+	newlyAcked := false
+	for _, c := range s.inflight {
+		off := c.tsn - cum
+		acked := false
+		for _, g := range gaps {
+			if off >= uint32(g.Start) && off <= uint32(g.End) {
+				acked = true
+				break
+			}
+		}
+		switch {
+		case acked && !c.gapAcked:
+			c.gapAcked = true
+			if c.retransmit {
+				c.retransmit = false
+			} else {
+				s.flightSize -= len(c.data)
+				bytesAcked += len(c.data)
+			}
+			highestNewlyAcked = c.tsn
+			newlyAcked = true
+		case !acked && c.gapAcked:
+			// The peer reneged on the chunk.
+			c.gapAcked = false
+			c.retransmit = true
+		}
+	}
+
+	// Count miss indications and fast retransmit, as per RFC 9260 section
+	// 7.2.4.
+	if newlyAcked {
+		for _, c := range s.inflight {
+			if !tsnLess(c.tsn, highestNewlyAcked) {
+				break
+			}
+			if c.gapAcked || c.retransmit {
+				continue
+			}
+			c.misses++
+			if c.misses < fastRetransmitThreshold {
+				continue
+			}
+			if !s.fastRecovery {
+				s.fastRecovery = true
+				s.fastRecoveryExit = s.inflight[len(s.inflight)-1].tsn
+				s.ssthresh = max(s.cwnd/2, 4*a.pmtu)
+				s.cwnd = s.ssthresh
+				s.partialBytesAcked = 0
+			}
+			c.retransmit = true
+			s.flightSize -= len(c.data)
+			if s.rttPending && c.tsn == s.rttTSN {
+				s.rttPending = false
+			}
+			a.ep.stack.Stats().SCTP.FastRetransmits.Increment()
+		}
+	}
+	if s.fastRecovery && !tsnLess(cum, s.fastRecoveryExit) {
+		s.fastRecovery = false
+	}
+
+	// Adjust the congestion window, as per RFC 9260 section 7.2.1 and
+	// 7.2.2.
+	if advanced && !s.fastRecovery {
+		full := flightBefore+a.pmtu > s.cwnd
+		if s.cwnd <= s.ssthresh {
+			if full {
+				s.cwnd += min(bytesAcked, a.pmtu)
+			}
+		} else {
+			s.partialBytesAcked += bytesAcked
+			if s.partialBytesAcked >= s.cwnd && full {
+				s.partialBytesAcked -= s.cwnd
+				s.cwnd += a.pmtu
+			}
+		}
+	}
+	if len(s.inflight) == 0 {
+		s.partialBytesAcked = 0
+	}
+
+	s.peerRwnd = subSat(rwnd, uint32(s.flightSize))
+
+	if advanced {
+		a.errorCount = 0
+	}
+	switch {
+	case len(s.inflight) == 0:
+		a.stopTimer(&a.rtxTimer)
+	case advanced:
+		a.startTimer(&a.rtxTimer, a.rto, s.onRetransmitTimeout)
+	}
+
+	a.ep.releaseSendBufferLocked(freed)
+	s.transmit()
+	a.maybeShutdown()
+}
+
+// subSat returns a-b, or zero if b is larger than a.
+func subSat(a, b uint32) uint32 {
+	if b > a {
+		return 0
+	}
+	return a - b
+}
+
+// outPacket is a packet queued for transmission once the lock of its endpoint
+// is released.
+type outPacket struct {
+	// route holds a reference that is released once the packet is sent.
+	route   *stack.Route
+	fields  header.SCTPFields
+	payload []byte
+}
+
+// sendPacket sends a packet holding the given chunks through r.
+func sendPacket(s *stack.Stack, r *stack.Route, params stack.NetworkHeaderParams, owner tcpip.PacketOwner, fields *header.SCTPFields, chunks []byte) tcpip.Error {
+	pkt := stack.NewPacketBuffer(stack.PacketBufferOptions{
+		ReserveHeaderBytes: header.SCTPMinimumSize + int(r.MaxHeaderLength()),
+		Payload:            buffer.MakeWithData(chunks),
+	})
+	defer pkt.DecRef()
+
+	hdr := header.SCTP(pkt.TransportHeader().Push(header.SCTPMinimumSize))
+	pkt.TransportProtocolNumber = ProtocolNumber
+	hdr.Encode(fields)
+	hdr.SetChecksum(header.SCTPChecksum(hdr, chunks))
+	pkt.Owner = owner
+
+	if err := r.WritePacket(params, pkt); err != nil {
+		s.Stats().SCTP.PacketSendErrors.Increment()
+		return err
+	}
+	s.Stats().SCTP.PacketsSent.Increment()
+	return nil
+}
@@ -190,7 +190,7 @@ func (l *listenContext) createConnectingEndpoint(s *segment, rcvdSynOpts header.
 		netProto = s.pkt.NetworkProtocolNumber
 	}
 
-	route, err := l.stack.FindRoute(s.pkt.NICID, s.pkt.Network().DestinationAddress(), s.pkt.Network().SourceAddress(), s.pkt.NetworkProtocolNumber, false /* multicastLoop */)
+	route, err := findReplyRoute(l.stack, s)
 	if err != nil {
 		return nil, err // +checklocksignore
 	}
@@ -209,6 +209,11 @@ func (l *listenContext) createConnectingEndpoint(s *segment, rcvdSynOpts header.
 	n.maybeEnableTimestamp(rcvdSynOpts)
 	n.maybeEnableSACKPermitted(rcvdSynOpts)
 
+	if l.listenEP != nil {
+		n.ops.SetTransparent(l.listenEP.ops.GetTransparent())
+		n.inheritMD5Keys(l.listenEP)
+	}
+
 	n.initGSO()
 
 	// Bootstrap the auto tuning algorithm. Starting at zero will result in
@@ -222,10 +227,21 @@ func (l *listenContext) createConnectingEndpoint(s *segment, rcvdSynOpts header.
 	return n, nil
 }
 
+// findReplyRoute returns a route to reply to the segment s received by a
+// listening endpoint. Segments steered to a transparent listener by a TPROXY
+// target may be addressed to an address that is not assigned to the stack.
+func findReplyRoute(st *stack.Stack, s *segment) (*stack.Route, tcpip.Error) {
+	net := s.pkt.Network()
+	if s.pkt.TProxied() {
+		return st.FindTransparentRoute(s.pkt.NICID, net.DestinationAddress(), net.SourceAddress(), s.pkt.NetworkProtocolNumber, false /* multicastLoop */)
+	}
+	return st.FindRoute(s.pkt.NICID, net.DestinationAddress(), net.SourceAddress(), s.pkt.NetworkProtocolNumber, false /* multicastLoop */)
+}
+
 // startHandshake creates a new endpoint in connecting state and then sends
 // the SYN-ACK for the TCP 3-way handshake. It returns the state of the
 // handshake in progress, which includes the new endpoint in the SYN-RCVD
-// state.
+// state. fo specifies how TCP Fast Open applies to the handshake.
 //
 // On success, a handshake h is returned.
 //
@@ -233,7 +249,7 @@ func (l *listenContext) createConnectingEndpoint(s *segment, rcvdSynOpts header.
 // modified.
 //
 // Precondition: if l.listenEP != nil, l.listenEP.mu must be locked.
-func (l *listenContext) startHandshake(s *segment, opts header.TCPSynOptions, queue *waiter.Queue, owner tcpip.PacketOwner) (h *handshake, _ tcpip.Error) {
+func (l *listenContext) startHandshake(s *segment, opts header.TCPSynOptions, queue *waiter.Queue, owner tcpip.PacketOwner, fo fastOpenSyn) (h *handshake, _ tcpip.Error) {
 	// Create new endpoint.
 	irs := s.sequenceNumber
 	isn := generateSecureISN(s.id, l.stack.Clock(), l.protocol.seqnumSecret)
@@ -268,6 +284,13 @@ func (l *listenContext) startHandshake(s *segment, opts header.TCPSynOptions, qu
 			return nil, &tcpip.ErrConnectionAborted{} // +checklocksignore
 		}
 
+		if err := ep.initPassiveMPTCPLocked(l.listenEP, s, fo); err != nil { // +checklocksforce
+			ep.mu.Unlock()
+			ep.Close()
+
+			return nil, err // +checklocksignore
+		}
+
 		deferAccept = l.listenEP.deferAccept
 	}
 
@@ -292,7 +315,14 @@ func (l *listenContext) startHandshake(s *segment, opts header.TCPSynOptions, qu
 
 	// Initialize and start the handshake.
 	h = ep.newPassiveHandshake(isn, irs, opts, deferAccept)
-	h.listenEP = l.listenEP
+	// Subflows joining a Multipath TCP connection are not accepted.
+	if ep.mptcp == nil || !ep.mptcp.join {
+		h.listenEP = l.listenEP
+	}
+	if fo.accept {
+		h.acceptFastOpenDataLocked(s)
+	}
+	h.fastOpenCookie = fo.cookie
 	h.start()
 	h.ep.mu.Unlock()
 	return h, nil
@@ -307,7 +337,7 @@ func (l *listenContext) performHandshake(s *segment, opts header.TCPSynOptions,
 	queue.EventRegister(&waitEntry)
 	defer queue.EventUnregister(&waitEntry)
 
-	h, err := l.startHandshake(s, opts, queue, owner)
+	h, err := l.startHandshake(s, opts, queue, owner, fastOpenSyn{})
 	if err != nil {
 		return nil, err
 	}
@@ -422,6 +452,11 @@ type acceptQueue struct {
 	// in progress.
 	pendingEndpoints map[*Endpoint]struct{}
 
+	// fastOpenPending is a set of TCP Fast Open endpoints that were added
+	// to endpoints before their handshake completed. It may contain
+	// endpoints which are no longer in the SYN-RCVD state.
+	fastOpenPending map[*Endpoint]struct{}
+
 	// capacity is the maximum number of endpoints that can be in endpoints.
 	capacity int
 }
@@ -453,6 +488,9 @@ func (e *Endpoint) handleListenSegment(ctx *listenContext, s *segment) tcpip.Err
 		return nil
 
 	case s.flags.Contains(header.TCPFlagSyn):
+		if e.mptcpEnabled && s.mptcpOptions.HasJoin {
+			return e.handleMPTCPJoinSynLocked(ctx, s)
+		}
 		if e.acceptQueueIsFull() {
 			e.stack.Stats().TCP.ListenOverflowSynDrop.Increment()
 			e.stats.ReceiveErrors.ListenOverflowSynDrop.Increment()
@@ -462,6 +500,7 @@ func (e *Endpoint) handleListenSegment(ctx *listenContext, s *segment) tcpip.Err
 
 		opts := parseSynSegmentOptions(s)
 
+		fastOpened := false
 		useSynCookies, err := func() (bool, tcpip.Error) {
 			var alwaysUseSynCookies tcpip.TCPAlwaysUseSynCookies
 			if err := e.stack.TransportProtocolOption(header.TCPProtocolNumber, &alwaysUseSynCookies); err != nil {
@@ -481,25 +520,37 @@ func (e *Endpoint) handleListenSegment(ctx *listenContext, s *segment) tcpip.Err
 				return true, nil
 			}
 
-			h, err := ctx.startHandshake(s, opts, &waiter.Queue{}, e.owner)
+			fo := e.handleFastOpenSynLocked(s, opts)
+			h, err := ctx.startHandshake(s, opts, &waiter.Queue{}, e.owner, fo)
 			if err != nil {
 				e.stack.Stats().TCP.FailedConnectionAttempts.Increment()
 				e.stats.FailedConnectionAttempts.Increment()
 				return false, err
 			}
-			e.acceptQueue.pendingEndpoints[h.ep] = struct{}{}
+			if fo.accept {
+				// The endpoint can be accepted right away so that
+				// the data in the SYN can be read.
+				e.acceptQueue.fastOpenPending[h.ep] = struct{}{}
+				e.acceptQueue.endpoints.PushBack(h.ep)
+				fastOpened = true
+			} else {
+				e.acceptQueue.pendingEndpoints[h.ep] = struct{}{}
+			}
 
 			return false, nil
 		}()
 		if err != nil {
 			return err
 		}
+		if fastOpened {
+			e.waiterQueue.Notify(waiter.ReadableEvents)
+		}
 		if !useSynCookies {
 			return nil
 		}
 
 		net := s.pkt.Network()
-		route, err := e.stack.FindRoute(s.pkt.NICID, net.DestinationAddress(), net.SourceAddress(), s.pkt.NetworkProtocolNumber, false /* multicastLoop */)
+		route, err := findReplyRoute(e.stack, s)
 		if err != nil {
 			return err
 		}
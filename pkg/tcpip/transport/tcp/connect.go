@@ -21,6 +21,7 @@ import (
 	"math"
 	"time"
 
+	"gvisor.dev/gvisor/pkg/buffer"
 	"gvisor.dev/gvisor/pkg/sync"
 	"gvisor.dev/gvisor/pkg/tcpip"
 	"gvisor.dev/gvisor/pkg/tcpip/checksum"
@@ -112,6 +113,20 @@ type handshake struct {
 	// sendSYNOpts is the cached values for the SYN options to be sent.
 	sendSYNOpts header.TCPSynOptions
 
+	// fastOpenCookie, if not nil, is sent in a TCP Fast Open option of the
+	// SYN/SYN-ACK. An empty cookie is a cookie request.
+	fastOpenCookie []byte
+
+	// synData is the data sent in the SYN of an active TCP Fast Open.
+	synData []byte
+
+	// synDataAcked is the length of synData acknowledged by the SYN-ACK.
+	synDataAcked seqnum.Size
+
+	// fastOpenLen is the length of the data accepted from the SYN of a
+	// passive TCP Fast Open. It is zero otherwise.
+	fastOpenLen seqnum.Size
+
 	// sampleRTTWithTSOnly is true when the segment was retransmitted or we can't
 	// tell; then RTT can only be sampled when the incoming segment has timestamp
 	// options enabled.
@@ -275,9 +290,10 @@ func (h *handshake) resetToSynRcvd(iss seqnum.Value, irs seqnum.Value, opts head
 }
 
 // checkAck checks if the ACK number, if present, of a segment received during
-// a TCP 3-way handshake is valid.
+// a TCP 3-way handshake is valid. It may acknowledge any part of the data sent
+// in the SYN.
 func (h *handshake) checkAck(s *segment) bool {
-	return !(s.flags.Contains(header.TCPFlagAck) && s.ackNumber != h.iss+1)
+	return !s.flags.Contains(header.TCPFlagAck) || s.ackNumber.InRange(h.iss+1, h.iss.Add(seqnum.Size(len(h.synData))+2))
 }
 
 // synSentState handles a segment received when the TCP 3-way handshake is in
@@ -334,16 +350,30 @@ func (h *handshake) synSentState(s *segment) tcpip.Error {
 	// If this is a SYN ACK response, we only need to acknowledge the SYN
 	// and the handshake is completed.
 	if s.flags.Contains(header.TCPFlagAck) {
+		if err := h.ep.mptcpHandleSynAckLocked(s); err != nil {
+			h.ep.sendEmptyRaw(header.TCPFlagRst, s.ackNumber, 0, 0)
+			return err
+		}
 		h.state = handshakeCompleted
+		h.handleFastOpenSynAckLocked(s, rcvSynOpts)
 		h.transitionToStateEstablishedLocked(s)
 
-		h.ep.sendEmptyRaw(header.TCPFlagAck, h.iss+1, h.ackNum, h.rcvWnd>>h.effectiveRcvWndScale())
+		h.ep.sendEmptyRaw(header.TCPFlagAck, h.iss.Add(h.synDataAcked+1), h.ackNum, h.rcvWnd>>h.effectiveRcvWndScale())
 		return nil
 	}
 
 	// A SYN segment was received, but no ACK in it. We acknowledge the SYN
 	// but resend our own SYN and wait for it to be acknowledged in the
 	// SYN-RCVD state.
+	//
+	// Multipath TCP doesn't support simultaneous open: the initial subflow
+	// falls back to TCP and other subflows are reset.
+	if sub := h.ep.mptcp; sub != nil {
+		if sub.join {
+			return &tcpip.ErrConnectionAborted{}
+		}
+		h.ep.mptcpFallbackLocked()
+	}
 	h.state = handshakeSynRcvd
 	ttl := calculateTTL(h.ep.route, h.ep.ipv4TTL, h.ep.ipv6HopLimit)
 	amss := h.ep.amss
@@ -426,7 +456,7 @@ func (h *handshake) synRcvdState(s *segment) tcpip.Error {
 		return nil
 	}
 
-	if s.flags.Contains(header.TCPFlagSyn) && s.sequenceNumber != h.ackNum-1 {
+	if s.flags.Contains(header.TCPFlagSyn) && s.sequenceNumber != h.ackNum-1-seqnum.Value(h.fastOpenLen) {
 		// We received two SYN segments with different sequence
 		// numbers, so we reset this and restart the whole
 		// process, except that we don't reset the timer.
@@ -482,11 +512,12 @@ func (h *handshake) synRcvdState(s *segment) tcpip.Error {
 			return nil
 		}
 
-		// Drop the ACK if the accept queue is full.
+		// Drop the ACK if the accept queue is full. A TCP Fast Open
+		// endpoint is already in the accept queue.
 		// https://github.com/torvalds/linux/blob/7acac4b3196/net/ipv4/tcp_ipv4.c#L1523
 		// We could abort the connection as well with a tunable as in
 		// https://github.com/torvalds/linux/blob/7acac4b3196/net/ipv4/tcp_minisocks.c#L788
-		if listenEP := h.listenEP; listenEP != nil && listenEP.acceptQueueIsFull() {
+		if listenEP := h.listenEP; listenEP != nil && h.fastOpenLen == 0 && listenEP.acceptQueueIsFull() {
 			listenEP.stack.Stats().DroppedPackets.Increment()
 			return nil
 		}
@@ -496,6 +527,11 @@ func (h *handshake) synRcvdState(s *segment) tcpip.Error {
 			h.ep.updateRecentTimestamp(s.parsedOptions.TSVal, h.ackNum, s.sequenceNumber)
 		}
 
+		if err := h.ep.mptcpHandleAckLocked(s); err != nil {
+			h.ep.sendEmptyRaw(header.TCPFlagRst, s.ackNumber, 0, 0)
+			return err
+		}
+
 		h.state = handshakeCompleted
 		h.transitionToStateEstablishedLocked(s)
 
@@ -587,8 +623,14 @@ func (h *handshake) start() {
 		}
 	}
 
+	if h.fastOpenCookie != nil {
+		synOpts.FastOpen = true
+		synOpts.FastOpenCookie = h.fastOpenCookie
+	}
+	synOpts.MPTCP = h.ep.mptcpSynOptionLocked()
+
 	h.sendSYNOpts = synOpts
-	h.ep.sendSynTCP(h.ep.route, tcpFields{
+	h.ep.sendSynDataTCP(h.ep.route, tcpFields{
 		id:        h.ep.TransportEndpointInfo.ID,
 		ttl:       calculateTTL(h.ep.route, h.ep.ipv4TTL, h.ep.ipv6HopLimit),
 		tos:       h.ep.sendTOS,
@@ -597,7 +639,7 @@ func (h *handshake) start() {
 		ack:       h.ackNum,
 		rcvWnd:    h.rcvWnd,
 		expOptVal: h.ep.getExperimentOptionValue(h.ep.route),
-	}, synOpts)
+	}, synOpts, h.synData)
 }
 
 // retransmitHandler handles retransmissions of un-acked SYNs.
@@ -657,7 +699,7 @@ func (h *handshake) transitionToStateEstablishedLocked(s *segment) {
 	// Transfer handshake state to TCP connection. We disable
 	// receive window scaling if the peer doesn't support it
 	// (indicated by a negative send window scale).
-	h.ep.snd = newSender(h.ep, h.iss, h.ackNum-1, h.sndWnd, h.mss, h.sndWndScale)
+	h.ep.snd = newSender(h.ep, h.iss.Add(h.synDataAcked), h.ackNum-1, h.sndWnd, h.mss, h.sndWndScale)
 
 	now := h.ep.stack.Clock().NowMonotonic()
 
@@ -688,6 +730,12 @@ func (h *handshake) transitionToStateEstablishedLocked(s *segment) {
 	// is if our SYN reached the remote and their ACK reached us.
 	h.ep.route.ConfirmReachable()
 
+	if len(h.synData) != 0 {
+		h.requeueSynDataLocked()
+	}
+
+	h.ep.mptcpEstablishedLocked(h.iss, h.ackNum-1)
+
 	// Tell waiters that the endpoint is connected and writable.
 	h.ep.waiterQueue.Notify(waiter.WritableEvents)
 }
@@ -761,15 +809,24 @@ func makeSynOptions(opts header.TCPSynOptions) []byte {
 	// if wscale: NOP WINDOW 3 ws(1)
 	// if sack_blocks: NOP NOP SACK ((2 + (#blocks * 8))
 	//	[for each block] start_seq(4) end_seq(4)
+	// if mptcp: MPTCP (variable)
 	// if fastopen_cookie:
 	//	if exp: EXP (4 + len(cookie)) FASTOPEN_MAGIC(2)
 	// 	else: FASTOPEN (2 + len(cookie))
 	//	cookie(variable) [padding to four bytes]
 	//
 	options := getOptions()
+	offset := 0
+
+	// The MD5 signature option is filled in once the segment is built.
+	if opts.MD5 {
+		offset += header.EncodeNOP(options[offset:])
+		offset += header.EncodeNOP(options[offset:])
+		offset += header.EncodeMD5Option(options[offset:])
+	}
 
 	// Always encode the mss.
-	offset := header.EncodeMSSOption(uint32(opts.MSS), options)
+	offset += header.EncodeMSSOption(uint32(opts.MSS), options[offset:])
 
 	// Special ordering is required here. If both TS and SACK are enabled,
 	// then the SACK option precedes TS, with no padding. If they are
@@ -793,12 +850,18 @@ func makeSynOptions(opts header.TCPSynOptions) []byte {
 		offset += header.EncodeWSOption(opts.WS, options[offset:])
 	}
 
-	// Padding to the end; note that this never apply unless we add a
-	// fastopen option, we always expect the offset to remain the same.
-	if delta := header.AddTCPOptionPadding(options, offset); delta != 0 {
-		panic("unexpected option encoding")
+	// Multipath TCP SYN options are a multiple of four bytes long.
+	offset += copy(options[offset:], opts.MPTCP)
+
+	// Initialize the Fast Open option.
+	if opts.FastOpen {
+		offset += header.EncodeFastOpenOption(opts.FastOpenCookie, options[offset:])
 	}
 
+	// Padding to the end; note that this only applies after the fastopen
+	// option.
+	offset += header.AddTCPOptionPadding(options, offset)
+
 	return options[:offset]
 }
 
@@ -816,16 +879,28 @@ type tcpFields struct {
 	txHash    uint32
 	df        bool
 	expOptVal uint16
+	md5Key    []byte
 }
 
 func (e *Endpoint) sendSynTCP(r *stack.Route, tf tcpFields, opts header.TCPSynOptions) tcpip.Error {
+	return e.sendSynDataTCP(r, tf, opts, nil /* data */)
+}
+
+// sendSynDataTCP is like sendSynTCP, but the SYN also carries data as done by
+// TCP Fast Open.
+func (e *Endpoint) sendSynDataTCP(r *stack.Route, tf tcpFields, opts header.TCPSynOptions, data []byte) tcpip.Error {
+	tf.md5Key = e.md5KeyFor(r.RemoteAddress(), r.NICID())
+	opts.MD5 = tf.md5Key != nil
 	tf.opts = makeSynOptions(opts)
 	// We ignore SYN send errors and let the callers re-attempt send.
 	hdrSize := header.TCPMinimumSize + int(r.MaxHeaderLength()) + len(tf.opts)
 	if r.NetProto() == header.IPv6ProtocolNumber && tf.expOptVal != 0 {
 		hdrSize += header.IPv6ExperimentHdrLength
 	}
-	p := stack.NewPacketBuffer(stack.PacketBufferOptions{ReserveHeaderBytes: hdrSize})
+	p := stack.NewPacketBuffer(stack.PacketBufferOptions{
+		ReserveHeaderBytes: hdrSize,
+		Payload:            buffer.MakeWithData(data),
+	})
 	defer p.DecRef()
 	if err := e.sendTCP(r, tf, p, stack.GSO{}); err != nil {
 		e.stats.SendErrors.SynSendToNetworkFailed.Increment()
@@ -859,6 +934,9 @@ func buildTCPHdr(r *stack.Route, tf tcpFields, pkt *stack.PacketBuffer, gso stac
 		WindowSize: uint16(tf.rcvWnd),
 	})
 	copy(tcp[header.TCPMinimumSize:], tf.opts)
+	if tf.md5Key != nil {
+		signMD5(r, tf.md5Key, pkt)
+	}
 
 	xsum := r.PseudoHeaderChecksum(ProtocolNumber, uint16(pkt.Size()))
 	// Only calculate the checksum if offloading isn't supported.
@@ -940,7 +1018,10 @@ func sendTCP(r *stack.Route, tf tcpFields, pkt *stack.PacketBuffer, gso stack.GS
 		tf.rcvWnd = math.MaxUint16
 	}
 
-	if r.Loop()&stack.PacketLoop == 0 && gso.Type == stack.GSOGvisor && int(gso.MSS) < pkt.Data().Size() {
+	// Segments split by the host would carry the signature of the whole
+	// batch, so signed batches are always split here.
+	batch := gso.Type == stack.GSOGvisor || (tf.md5Key != nil && gso.Type != stack.GSONone)
+	if r.Loop()&stack.PacketLoop == 0 && batch && int(gso.MSS) < pkt.Data().Size() {
 		return sendTCPBatch(r, tf, pkt, gso, owner)
 	}
 
@@ -966,11 +1047,18 @@ func sendTCP(r *stack.Route, tf tcpFields, pkt *stack.PacketBuffer, gso stack.GS
 	return nil
 }
 
-// makeOptions makes an options slice.
-func (e *Endpoint) makeOptions(sackBlocks []header.SACKBlock) []byte {
+// makeOptions makes an options slice. If md5 is true, room is made for a TCP
+// MD5 signature option. The Multipath TCP options mptcp are appended last.
+func (e *Endpoint) makeOptions(sackBlocks []header.SACKBlock, md5 bool, mptcp []byte) []byte {
 	options := getOptions()
 	offset := 0
 
+	if md5 {
+		offset += header.EncodeNOP(options[offset:])
+		offset += header.EncodeNOP(options[offset:])
+		offset += header.EncodeMD5Option(options[offset:])
+	}
+
 	// N.B. the ordering here matches the ordering used by Linux internally
 	// and described in the raw makeOptions function. We don't include
 	// unnecessary cases here (post connection.)
@@ -991,11 +1079,13 @@ func (e *Endpoint) makeOptions(sackBlocks []header.SACKBlock) []byte {
 		offset += header.EncodeNOP(options[offset:])
 		offset += header.EncodeTSOption(e.tsValNow(), e.recentTimestamp(), options[offset:])
 	}
-	if e.SACKPermitted && len(sackBlocks) > 0 {
+	// The SACK option is only added if at least one block fits.
+	if e.SACKPermitted && len(sackBlocks) > 0 && maxOptionSize-offset-len(mptcp) >= 4+8 {
 		offset += header.EncodeNOP(options[offset:])
 		offset += header.EncodeNOP(options[offset:])
-		offset += header.EncodeSACKBlocks(sackBlocks, options[offset:])
+		offset += header.EncodeSACKBlocks(sackBlocks, options[offset:maxOptionSize-len(mptcp)])
 	}
+	offset += copy(options[offset:], mptcp)
 
 	// We expect the above to produce an aligned offset.
 	if delta := header.AddTCPOptionPadding(options, offset); delta != 0 {
@@ -1012,20 +1102,27 @@ func (e *Endpoint) makeOptions(sackBlocks []header.SACKBlock) []byte {
 func (e *Endpoint) sendEmptyRaw(flags header.TCPFlags, seq, ack seqnum.Value, rcvWnd seqnum.Size) tcpip.Error {
 	pkt := stack.NewPacketBuffer(stack.PacketBufferOptions{})
 	defer pkt.DecRef()
-	return e.sendRaw(pkt, flags, seq, ack, rcvWnd)
+	return e.sendRaw(pkt, flags, seq, ack, rcvWnd, 0 /* dsn */)
 }
 
 // sendRaw sends a TCP segment to the endpoint's peer. This method takes
-// ownership of pkt. pkt must not have any headers set.
+// ownership of pkt. pkt must not have any headers set. dsn is the Multipath
+// TCP data sequence number of the payload, if any.
 //
 // +checklocks:e.mu
 // +checklocksalias:e.snd.ep.mu=e.mu
-func (e *Endpoint) sendRaw(pkt *stack.PacketBuffer, flags header.TCPFlags, seq, ack seqnum.Value, rcvWnd seqnum.Size) tcpip.Error {
+func (e *Endpoint) sendRaw(pkt *stack.PacketBuffer, flags header.TCPFlags, seq, ack seqnum.Value, rcvWnd seqnum.Size, dsn uint64) tcpip.Error {
 	var sackBlocks []header.SACKBlock
 	if e.EndpointState() == StateEstablished && e.rcv.pendingRcvdSegments.Len() > 0 && (flags&header.TCPFlagAck != 0) {
 		sackBlocks = e.sack.Blocks[:e.sack.NumBlocks]
 	}
-	options := e.makeOptions(sackBlocks)
+	var mptcp []byte
+	if e.mptcp != nil {
+		var b [header.MPTCPDSSMaxLength + 2]byte
+		mptcp = b[:e.mptcpOptionsLocked(b[:], flags, seq, pkt.Data().Size(), dsn)]
+	}
+	md5Key := e.md5KeyFor(e.route.RemoteAddress(), e.route.NICID())
+	options := e.makeOptions(sackBlocks, md5Key != nil, mptcp)
 	defer putOptions(options)
 	hdrSize := header.TCPMinimumSize + int(e.route.MaxHeaderLength()) + len(options)
 	expOptVal := e.getExperimentOptionValue(e.route)
@@ -1043,6 +1140,7 @@ func (e *Endpoint) sendRaw(pkt *stack.PacketBuffer, flags header.TCPFlags, seq,
 		rcvWnd:    rcvWnd,
 		opts:      options,
 		df:        e.pmtud == tcpip.PMTUDiscoveryWant || e.pmtud == tcpip.PMTUDiscoveryDo,
+		md5Key:    md5Key,
 		expOptVal: expOptVal,
 	}, pkt, e.gso)
 }
@@ -1303,6 +1401,12 @@ func (e *Endpoint) handleSegmentLocked(s *segment) (cont bool, err tcpip.Error)
 		// send window scale.
 		s.window <<= e.snd.SndWndScale
 
+		if e.mptcp != nil {
+			if err := e.mptcpHandleSegmentLocked(s); err != nil {
+				return false, err
+			}
+		}
+
 		// RFC 793, page 41 states that "once in the ESTABLISHED
 		// state all segments must carry current acknowledgment
 		// information."
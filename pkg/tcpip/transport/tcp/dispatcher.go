@@ -165,7 +165,9 @@ func handleConnecting(ep *Endpoint) {
 	if ep.EndpointState() == StateEstablished && ep.h.listenEP != nil {
 		ep.isConnectNotified = true
 		ep.stack.Stats().TCP.PassiveConnectionOpenings.Increment()
-		if !deliverAccepted(ep) {
+		// TCP Fast Open endpoints are delivered to the accept queue
+		// before the handshake completes.
+		if ep.h.fastOpenLen == 0 && !deliverAccepted(ep) {
 			ep.resetConnectionLocked(&tcpip.ErrConnectionAborted{})
 			cleanup()
 			return
@@ -456,6 +458,11 @@ func (d *dispatcher) queuePacket(stackEP stack.TransportEndpoint, id stack.Trans
 		return
 	}
 
+	if !ep.verifyMD5(s) {
+		ep.stack.Stats().DroppedPackets.Increment()
+		return
+	}
+
 	ep.stack.Stats().TCP.ValidSegmentsReceived.Increment()
 	ep.stats.SegmentsReceived.Increment()
 	if (s.flags & header.TCPFlagRst) != 0 {
@@ -287,6 +287,14 @@ func (*Stats) IsEndpointStats() {}
 type sndQueueInfo struct {
 	sndQueueMu sync.Mutex `state:"nosave"`
 	TCPSndBufState
+
+	// fastOpenDeferred is true if the SYN of a TCP Fast Open connect is
+	// deferred until the first write.
+	fastOpenDeferred bool
+
+	// mptcp is the Multipath TCP connection of a primary endpoint, whose
+	// other subflows can take data written to the endpoint.
+	mptcp *mptcpConn
 }
 
 // CloneState clones sq into other. It is not thread safe
@@ -382,6 +390,14 @@ type Endpoint struct {
 	// +checklocks:rcvQueueMu
 	TCPRcvBufState
 
+	// tlsRxEnabled is true if received records are decrypted by kernel
+	// TLS, in which case tlsRxReady is true if a record can be read.
+	//
+	// +checklocks:rcvQueueMu
+	tlsRxEnabled bool
+	// +checklocks:rcvQueueMu
+	tlsRxReady bool
+
 	// rcvMemUsed tracks the total amount of memory in use by received segments
 	// held in rcvQueue, pendingRcvdSegments and the segment queue. This is used to
 	// compute the window and the actual available buffer space. This is distinct
@@ -513,6 +529,55 @@ type Endpoint struct {
 	// listener.
 	deferAccept time.Duration
 
+	// fastOpenQueueLen is the maximum number of TCP Fast Open connections
+	// pending on a listening endpoint, as set by TCP_FASTOPEN. Fast Open
+	// is disabled for passive opens if it is zero.
+	fastOpenQueueLen int
+
+	// fastOpenConnect is true if the SYN of a connect is deferred until the
+	// first write, as set by TCP_FASTOPEN_CONNECT.
+	fastOpenConnect bool
+
+	// fastOpenNoCookie is true if data may be sent or accepted in the SYN
+	// without a Fast Open cookie, as set by TCP_FASTOPEN_NO_COOKIE.
+	fastOpenNoCookie bool
+
+	// fastOpenKey if not nil overrides the stack's keys used by a listening
+	// endpoint to generate and validate Fast Open cookies.
+	fastOpenKey tcpip.TCPFastOpenKeyOption
+
+	// tlsULP is true if the kernel TLS upper layer protocol is attached to
+	// the endpoint, as set by TCP_ULP. tlsTx and tlsRx then hold the state
+	// protecting the records sent and received, once configured.
+	tlsULP bool
+	tlsTx  *tlsState
+	tlsRx  *tlsRxState
+
+	// repair is true if the endpoint is in repair mode, as set by
+	// TCP_REPAIR. repairQueue is the queue selected by TCP_REPAIR_QUEUE,
+	// and repairSndNxt and repairRcvNxt are the sequence numbers set by
+	// TCP_QUEUE_SEQ that a connection made in repair mode starts from.
+	repair       bool
+	repairQueue  int
+	repairSndNxt seqnum.Value
+	repairRcvNxt seqnum.Value
+
+	// md5Mu protects md5Keys. It is separate from mu as inbound segments
+	// are verified before they are queued to the endpoint.
+	md5Mu sync.RWMutex `state:"nosave"`
+
+	// md5Keys are the TCP MD5 signature keys set by TCP_MD5SIG.
+	//
+	// +checklocks:md5Mu
+	md5Keys []md5Key
+
+	// mptcpEnabled is true if the endpoint is a Multipath TCP socket, as
+	// created with IPPROTO_MPTCP. mptcp holds the state of the subflow of
+	// the endpoint once a Multipath TCP handshake starts; it is reset to nil
+	// if the connection falls back to TCP.
+	mptcpEnabled bool
+	mptcp        *mptcpSubflow
+
 	// acceptMu protects accepQueue
 	acceptMu sync.Mutex `state:"nosave"`
 
@@ -602,6 +667,14 @@ type Endpoint struct {
 	//
 	// +checklocks:mu
 	pmtud tcpip.PMTUDStrategy
+
+	// zeroCopyCompleted is the ID of the last send requested with
+	// MSG_ZEROCOPY whose completion was queued, if zeroCopyAnyCompleted.
+	//
+	// +checklocks:mu
+	zeroCopyCompleted uint32
+	// +checklocks:mu
+	zeroCopyAnyCompleted bool
 }
 
 // calculateAdvertisedMSS calculates the MSS to advertise.
@@ -930,9 +1003,30 @@ func (e *Endpoint) Readiness(mask waiter.EventMask) waiter.EventMask {
 		// connected when SO_LINGER is set.
 		result |= waiter.EventHUp
 
-	case StateConnecting, StateSynSent, StateSynRecv:
+	case StateConnecting:
 		// Ready for nothing.
 
+	case StateSynSent:
+		// A connect deferred by TCP Fast Open is writable so that the
+		// first write can send the SYN.
+		if (mask & waiter.WritableEvents) != 0 {
+			e.sndQueueInfo.sndQueueMu.Lock()
+			if e.sndQueueInfo.fastOpenDeferred {
+				result |= waiter.WritableEvents
+			}
+			e.sndQueueInfo.sndQueueMu.Unlock()
+		}
+
+	case StateSynRecv:
+		// A TCP Fast Open endpoint may have data from the SYN to read.
+		if (mask & waiter.ReadableEvents) != 0 {
+			e.rcvQueueMu.Lock()
+			if e.RcvBufUsed > 0 {
+				result |= waiter.ReadableEvents
+			}
+			e.rcvQueueMu.Unlock()
+		}
+
 	case StateClose, StateError, StateTimeWait:
 		// Ready for anything.
 		result = mask
@@ -958,13 +1052,25 @@ func (e *Endpoint) Readiness(mask waiter.EventMask) waiter.EventMask {
 			if e.sndQueueInfo.SndClosed {
 				e.updateConnDirectionState(connDirectionStateSndClosed)
 			}
+			mptcp := e.sndQueueInfo.mptcp
 			e.sndQueueInfo.sndQueueMu.Unlock()
+
+			// Data written to a Multipath TCP connection can also be
+			// queued on its other subflows.
+			if result&waiter.WritableEvents == 0 && mptcp != nil && mptcp.writable(e) {
+				result |= waiter.WritableEvents
+			}
 		}
 
 		// Determine if the endpoint is readable if requested.
 		if (mask & waiter.ReadableEvents) != 0 {
 			e.rcvQueueMu.Lock()
-			if e.RcvBufUsed > 0 || e.RcvClosed {
+			// Kernel TLS only reads whole records.
+			readable := e.RcvBufUsed > 0
+			if e.tlsRxEnabled {
+				readable = e.tlsRxReady
+			}
+			if readable || e.RcvClosed {
 				result |= waiter.ReadableEvents
 			}
 			if e.RcvClosed {
@@ -979,6 +1085,10 @@ func (e *Endpoint) Readiness(mask waiter.EventMask) waiter.EventMask {
 		result |= waiter.EventRdHUp
 	}
 
+	if mask&waiter.EventErr != 0 && e.ops.HasQueuedErr() {
+		result |= waiter.EventErr
+	}
+
 	return result
 }
 
@@ -1016,6 +1126,9 @@ func (e *Endpoint) purgeWriteQueue() {
 		e.sndQueueInfo.sndQueueMu.Lock()
 		defer e.sndQueueInfo.sndQueueMu.Unlock()
 		e.snd.updateWriteNext(nil)
+		if e.mptcp != nil && e.mptcp.join {
+			e.mptcpReinjectLocked()
+		}
 		for s := e.snd.writeList.Front(); s != nil; s = e.snd.writeList.Front() {
 			e.snd.writeList.Remove(s)
 			s.DecRef()
@@ -1032,6 +1145,9 @@ func (e *Endpoint) Abort() {
 	e.LockUser()
 	defer e.UnlockUser()
 	defer e.purgeReadQueue()
+	if sub := e.mptcp; sub != nil && !sub.join {
+		defer sub.conn.close(true /* abort */)
+	}
 	// Reset all connected endpoints.
 	switch state := e.EndpointState(); {
 	case state.connected():
@@ -1051,6 +1167,10 @@ func (e *Endpoint) Close() {
 		e.UnlockUser()
 		return
 	}
+	if sub := e.mptcp; sub != nil && !sub.join {
+		// The other subflows are closed with the primary endpoint.
+		defer sub.conn.close(false /* abort */)
+	}
 
 	// We always want to purge the read queue, but do so after the checks in
 	// shutdownLocked.
@@ -1070,6 +1190,11 @@ func (e *Endpoint) Close() {
 
 // +checklocks:e.mu
 func (e *Endpoint) closeLocked() {
+	if e.repair && e.EndpointState().connected() {
+		e.closeRepairLocked()
+		return
+	}
+
 	linger := e.SocketOptions().GetLinger()
 	if linger.Enabled && linger.Timeout == 0 {
 		s := e.EndpointState()
@@ -1155,6 +1280,7 @@ func (e *Endpoint) closePendingAcceptableConnectionsLocked() {
 
 	pendingEndpoints := e.acceptQueue.pendingEndpoints
 	e.acceptQueue.pendingEndpoints = nil
+	e.acceptQueue.fastOpenPending = nil
 
 	completedEndpoints := make([]*Endpoint, 0, e.acceptQueue.endpoints.Len())
 	for n := e.acceptQueue.endpoints.Front(); n != nil; n = n.Next() {
@@ -1226,6 +1352,9 @@ func (e *Endpoint) cleanupLocked() {
 	}
 
 	e.purgeWriteQueue()
+	if e.mptcp != nil {
+		e.mptcpCleanupLocked()
+	}
 	// Only purge the read queue here if the socket is fully closed by the
 	// user.
 	if e.closed {
@@ -1401,6 +1530,18 @@ func (e *Endpoint) Read(dst io.Writer, opts tcpip.ReadOptions) (tcpip.ReadResult
 	e.LockUser()
 	defer e.UnlockUser()
 
+	if e.repair {
+		return e.readRepairLocked(dst, opts)
+	}
+
+	if e.tlsRx != nil {
+		return e.readTLSLocked(dst, opts)
+	}
+
+	if e.mptcp != nil {
+		e.mptcpSpliceLocked()
+	}
+
 	if err := e.checkReadLocked(); err != nil {
 		if _, ok := err.(*tcpip.ErrClosedForReceive); ok {
 			e.stats.ReadErrors.ReadClosed.Increment()
@@ -1408,6 +1549,27 @@ func (e *Endpoint) Read(dst io.Writer, opts tcpip.ReadOptions) (tcpip.ReadResult
 		return tcpip.ReadResult{}, err
 	}
 
+	done, err := e.readRcvQueueLocked(dst, opts.Peek)
+	if e.mptcp != nil && done > 0 && !opts.Peek {
+		e.mptcpUpdateWindowsLocked()
+	}
+
+	// If something is read, we must report it. Report error when nothing is read.
+	if done == 0 && err != nil {
+		return tcpip.ReadResult{}, &tcpip.ErrBadBuffer{}
+	}
+	return tcpip.ReadResult{
+		Count: done,
+		Total: done,
+	}, nil
+}
+
+// readRcvQueueLocked reads the data of the receive queue into dst, and removes
+// it from the queue unless peek is true. It returns the number of bytes read
+// and the error that stopped the read, if any.
+//
+// +checklocks:e.mu
+func (e *Endpoint) readRcvQueueLocked(dst io.Writer, peek bool) (int, error) {
 	var err error
 	done := 0
 	// N.B. Here we get the first segment to be processed. It is safe to not
@@ -1417,11 +1579,11 @@ func (e *Endpoint) Read(dst io.Writer, opts tcpip.ReadOptions) (tcpip.ReadResult
 	s := e.rcvQueue.Front()
 	for s != nil {
 		var n int
-		n, err = s.ReadTo(dst, opts.Peek)
+		n, err = s.ReadTo(dst, peek)
 		// Book keeping first then error handling.
 		done += n
 
-		if opts.Peek {
+		if peek {
 			s = s.Next()
 		} else {
 			sendNonZeroWindowUpdate := false
@@ -1460,15 +1622,7 @@ func (e *Endpoint) Read(dst io.Writer, opts tcpip.ReadOptions) (tcpip.ReadResult
 			break
 		}
 	}
-
-	// If something is read, we must report it. Report error when nothing is read.
-	if done == 0 && err != nil {
-		return tcpip.ReadResult{}, &tcpip.ErrBadBuffer{}
-	}
-	return tcpip.ReadResult{
-		Count: done,
-		Total: done,
-	}, nil
+	return done, err
 }
 
 // checkRead checks that endpoint is in a readable state.
@@ -1481,7 +1635,10 @@ func (e *Endpoint) checkReadLocked() tcpip.Error {
 	// An application can initiate a non-blocking connect and then block
 	// on a receive. It can expect to read any data after the handshake
 	// is complete. RFC793, section 3.9, p58.
-	if e.EndpointState() == StateSynSent {
+	//
+	// Similarly, a TCP Fast Open endpoint in SYN-RCVD state can be read
+	// once the handshake is complete.
+	if s := e.EndpointState(); s == StateSynSent || (s == StateSynRecv && e.RcvBufUsed == 0) {
 		return &tcpip.ErrWouldBlock{}
 	}
 
@@ -1548,10 +1705,12 @@ func (e *Endpoint) isEndpointWritableLocked() (int, tcpip.Error) {
 	return avail, nil
 }
 
-// readFromPayloader reads a slice from the Payloader.
+// readFromPayloader reads a slice from the Payloader. The data of a write
+// requested with MSG_ZEROCOPY is referenced in place if possible, in which
+// case it returns true.
 // +checklocks:e.mu
 // +checklocks:e.sndQueueInfo.sndQueueMu
-func (e *Endpoint) readFromPayloader(p tcpip.Payloader, opts tcpip.WriteOptions, avail int) (buffer.Buffer, tcpip.Error) {
+func (e *Endpoint) readFromPayloader(p tcpip.Payloader, opts tcpip.WriteOptions, avail int) (buffer.Buffer, bool, tcpip.Error) {
 	// We can release locks while copying data.
 	//
 	// This is not possible if atomic is set, because we can't allow the
@@ -1577,13 +1736,22 @@ func (e *Endpoint) readFromPayloader(p tcpip.Payloader, opts tcpip.WriteOptions,
 		avail = l
 	}
 	if avail == 0 {
-		return payload, nil
+		return payload, false, nil
+	}
+	if pp, ok := p.(tcpip.PinnedPayloader); ok && opts.ZeroCopy {
+		// The views are released once the segment is acknowledged.
+		buf, pinned, err := pp.ReadPinned(avail, nil /* release */)
+		if err != nil {
+			buf.Release()
+			return buffer.Buffer{}, false, &tcpip.ErrBadBuffer{}
+		}
+		return buf, pinned, nil
 	}
 	if _, err := payload.WriteFromReaderAndLimitedReader(p, int64(avail), limRdr); err != nil {
 		payload.Release()
-		return buffer.Buffer{}, &tcpip.ErrBadBuffer{}
+		return buffer.Buffer{}, false, &tcpip.ErrBadBuffer{}
 	}
-	return payload, nil
+	return payload, false, nil
 }
 
 // queueSegment reads data from the payloader and returns a segment to be sent.
@@ -1599,7 +1767,7 @@ func (e *Endpoint) queueSegment(p tcpip.Payloader, opts tcpip.WriteOptions) (*se
 		return nil, 0, err
 	}
 
-	buf, err := e.readFromPayloader(p, opts, avail)
+	buf, pinned, err := e.readFromPayloader(p, opts, avail)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -1629,8 +1797,30 @@ func (e *Endpoint) queueSegment(p tcpip.Payloader, opts tcpip.WriteOptions) (*se
 
 	// Add data to the send queue.
 	size := int(buf.Size())
+	if e.tlsTx != nil {
+		buf = e.sealTLSLocked(buf, opts.ControlMessages)
+		pinned = false
+	}
 	s := newOutgoingSegment(e.TransportEndpointInfo.ID, e.stack.Clock(), buf)
-	e.sndQueueInfo.SndBufUsed += size
+	if e.mptcp != nil {
+		s.dsn = e.mptcp.conn.allocDSN(int(buf.Size()))
+	}
+	if opts.ZeroCopy {
+		// The send is complete once its last byte is acknowledged, which
+		// is now in s. As in Linux, it is reported as copied if any of its
+		// data was.
+		copied := !pinned
+		if prev := e.snd.writeList.Back(); prev != nil && prev.zeroCopy.valid && prev.zeroCopy.hi == opts.ZeroCopyID {
+			copied = copied || prev.zeroCopy.copied
+			if prev.zeroCopy.lo == prev.zeroCopy.hi {
+				prev.zeroCopy = zeroCopyRange{}
+			} else {
+				prev.zeroCopy.hi--
+			}
+		}
+		s.zeroCopy.add(opts.ZeroCopyID, copied)
+	}
+	e.sndQueueInfo.SndBufUsed += int(buf.Size())
 	e.snd.writeList.PushBack(s)
 
 	return s, size, nil
@@ -1645,6 +1835,34 @@ func (e *Endpoint) Write(p tcpip.Payloader, opts tcpip.WriteOptions) (int64, tcp
 	e.LockUser()
 	defer e.UnlockUser()
 
+	if e.repair {
+		return e.writeRepairLocked(p, opts)
+	}
+
+	if opts.FastOpen || e.EndpointState() == StateSynSent {
+		if n, ok, err := e.writeFastOpenLocked(p, opts); ok {
+			// The data of the SYN isn't tracked per send, so the
+			// send completes once queued.
+			if n > 0 && opts.ZeroCopy {
+				e.completeZeroCopyLocked(zeroCopyRange{valid: true, lo: opts.ZeroCopyID, hi: opts.ZeroCopyID, copied: true})
+			}
+			return n, err
+		}
+	}
+
+	if e.mptcp != nil {
+		if sub := e.mptcpSelectSubflowLocked(); sub != nil {
+			if n, ok, err := e.mptcpWriteSubflowLocked(sub, p, opts); ok {
+				// Data queued on another subflow is acknowledged on
+				// its endpoint, so the send completes once queued.
+				if n > 0 && opts.ZeroCopy {
+					e.completeZeroCopyLocked(zeroCopyRange{valid: true, lo: opts.ZeroCopyID, hi: opts.ZeroCopyID, copied: true})
+				}
+				return n, err
+			}
+		}
+	}
+
 	// Return if either we didn't queue anything or if an error occurred while
 	// attempting to queue data.
 	nextSeg, n, err := e.queueSegment(p, opts)
@@ -1656,6 +1874,29 @@ func (e *Endpoint) Write(p tcpip.Payloader, opts tcpip.WriteOptions) (int64, tcp
 	return int64(n), nil
 }
 
+// completeZeroCopyLocked queues the completion of the sends requested with
+// MSG_ZEROCOPY in r.
+//
+// +checklocks:e.mu
+func (e *Endpoint) completeZeroCopyLocked(r zeroCopyRange) {
+	if !r.valid {
+		return
+	}
+	// The data of a send may be queued by several writes, the first of which
+	// may be acknowledged before the others are queued. Each send completes
+	// only once.
+	if e.zeroCopyAnyCompleted && int32(r.lo-e.zeroCopyCompleted) <= 0 {
+		if int32(r.hi-e.zeroCopyCompleted) <= 0 {
+			return
+		}
+		r.lo = e.zeroCopyCompleted + 1
+	}
+	e.zeroCopyCompleted = r.hi
+	e.zeroCopyAnyCompleted = true
+	e.ops.QueueZeroCopyCompletion(r.lo, r.hi, r.copied, e.NetProto)
+	e.waiterQueue.Notify(waiter.EventErr)
+}
+
 // selectWindowLocked returns the new window without checking for shrinking or scaling
 // applied.
 // +checklocks:e.mu
@@ -1935,10 +2176,74 @@ func (e *Endpoint) SetSockOptInt(opt tcpip.SockOptInt, v int) tcpip.Error {
 		e.LockUser()
 		e.windowClamp = uint32(v)
 		e.UnlockUser()
+
+	case tcpip.TCPFastOpenQueueLenOption:
+		if v < 0 {
+			return &tcpip.ErrInvalidOptionValue{}
+		}
+		e.LockUser()
+		defer e.UnlockUser()
+		if !e.fastOpenConfigurableLocked() {
+			return &tcpip.ErrInvalidOptionValue{}
+		}
+		e.fastOpenQueueLen = v
+
+	case tcpip.TCPFastOpenConnectOption:
+		if v < 0 || v > 1 {
+			return &tcpip.ErrInvalidOptionValue{}
+		}
+		if e.protocol.fastOpenMode()&tcpip.TCPFastOpenClient == 0 {
+			return &tcpip.ErrNotSupported{}
+		}
+		e.LockUser()
+		defer e.UnlockUser()
+		if s := e.EndpointState(); s != StateInitial && s != StateBound && s != StateClose {
+			return &tcpip.ErrInvalidOptionValue{}
+		}
+		e.fastOpenConnect = v != 0
+
+	case tcpip.TCPFastOpenNoCookieOption:
+		if v < 0 || v > 1 {
+			return &tcpip.ErrInvalidOptionValue{}
+		}
+		e.LockUser()
+		defer e.UnlockUser()
+		if !e.fastOpenConfigurableLocked() {
+			return &tcpip.ErrInvalidOptionValue{}
+		}
+		e.fastOpenNoCookie = v != 0
+
+	case tcpip.TCPRepairOption:
+		e.LockUser()
+		defer e.UnlockUser()
+		return e.setRepairLocked(v)
+
+	case tcpip.TCPRepairQueueOption:
+		e.LockUser()
+		defer e.UnlockUser()
+		return e.setRepairQueueLocked(v)
+
+	case tcpip.TCPQueueSeqOption:
+		e.LockUser()
+		defer e.UnlockUser()
+		return e.setQueueSeqLocked(seqnum.Value(v))
 	}
 	return nil
 }
 
+// fastOpenConfigurableLocked returns true if the TCP Fast Open options of a
+// listening endpoint may be changed.
+//
+// +checklocks:e.mu
+func (e *Endpoint) fastOpenConfigurableLocked() bool {
+	switch e.EndpointState() {
+	case StateInitial, StateBound, StateClose, StateListen:
+		return true
+	default:
+		return false
+	}
+}
+
 // HasNIC returns true if the NICID is defined in the stack or id is 0.
 func (e *Endpoint) HasNIC(id int32) bool {
 	return id == 0 || e.stack.HasNIC(tcpip.NICID(id))
@@ -2028,6 +2333,50 @@ func (e *Endpoint) SetSockOpt(opt tcpip.SettableSocketOption) tcpip.Error {
 		e.deferAccept = time.Duration(*v)
 		e.UnlockUser()
 
+	case *tcpip.TCPFastOpenKeyOption:
+		if !validFastOpenKey(*v) {
+			return &tcpip.ErrInvalidOptionValue{}
+		}
+		e.LockUser()
+		e.fastOpenKey = append(tcpip.TCPFastOpenKeyOption(nil), *v...)
+		e.UnlockUser()
+
+	case *tcpip.TCPULPOption:
+		if *v != tcpip.TCPULPTLS {
+			return &tcpip.ErrNoSuchFile{}
+		}
+		e.LockUser()
+		defer e.UnlockUser()
+		if e.EndpointState() != StateEstablished {
+			return &tcpip.ErrNotConnected{}
+		}
+		e.tlsULP = true
+
+	case *tcpip.TLSTxOption:
+		e.LockUser()
+		defer e.UnlockUser()
+		return e.setTLSLocked(tcpip.TLSCryptoInfo(*v), false /* rx */)
+
+	case *tcpip.TLSRxOption:
+		e.LockUser()
+		defer e.UnlockUser()
+		return e.setTLSLocked(tcpip.TLSCryptoInfo(*v), true /* rx */)
+
+	case *tcpip.TCPRepairOptionsOption:
+		e.LockUser()
+		defer e.UnlockUser()
+		return e.setRepairOptionsLocked(*v)
+
+	case *tcpip.TCPRepairWindowOption:
+		e.LockUser()
+		defer e.UnlockUser()
+		return e.setRepairWindowLocked(*v)
+
+	case *tcpip.TCPMD5SigOption:
+		e.LockUser()
+		defer e.UnlockUser()
+		return e.setMD5KeyLocked(v)
+
 	case *tcpip.SocketDetachFilterOption:
 		return nil
 
@@ -2120,6 +2469,56 @@ func (e *Endpoint) GetSockOptInt(opt tcpip.SockOptInt) (int, tcpip.Error) {
 		e.UnlockUser()
 		return v, nil
 
+	case tcpip.TCPFastOpenQueueLenOption:
+		e.LockUser()
+		v := e.fastOpenQueueLen
+		e.UnlockUser()
+		return v, nil
+
+	case tcpip.TCPFastOpenConnectOption:
+		e.LockUser()
+		v := 0
+		if e.fastOpenConnect {
+			v = 1
+		}
+		e.UnlockUser()
+		return v, nil
+
+	case tcpip.TCPFastOpenNoCookieOption:
+		e.LockUser()
+		v := 0
+		if e.fastOpenNoCookie {
+			v = 1
+		}
+		e.UnlockUser()
+		return v, nil
+
+	case tcpip.TCPRepairOption:
+		e.LockUser()
+		v := 0
+		if e.repair {
+			v = 1
+		}
+		e.UnlockUser()
+		return v, nil
+
+	case tcpip.TCPRepairQueueOption:
+		e.LockUser()
+		defer e.UnlockUser()
+		if !e.repair {
+			return -1, &tcpip.ErrInvalidOptionValue{}
+		}
+		return e.repairQueue, nil
+
+	case tcpip.TCPQueueSeqOption:
+		e.LockUser()
+		defer e.UnlockUser()
+		seq, err := e.queueSeqLocked()
+		if err != nil {
+			return -1, err
+		}
+		return int(int32(seq)), nil
+
 	case tcpip.MulticastTTLOption:
 		return 1, nil
 
@@ -2192,6 +2591,50 @@ func (e *Endpoint) GetSockOpt(opt tcpip.GettableSocketOption) tcpip.Error {
 		*o = tcpip.TCPDeferAcceptOption(e.deferAccept)
 		e.UnlockUser()
 
+	case *tcpip.TCPFastOpenKeyOption:
+		e.LockUser()
+		key := e.fastOpenKey
+		e.UnlockUser()
+		if key == nil {
+			return e.stack.TransportProtocolOption(ProtocolNumber, o)
+		}
+		*o = append(tcpip.TCPFastOpenKeyOption(nil), key...)
+
+	case *tcpip.TCPULPOption:
+		e.LockUser()
+		*o = ""
+		if e.tlsULP {
+			*o = tcpip.TCPULPTLS
+		}
+		e.UnlockUser()
+
+	case *tcpip.TLSTxOption:
+		e.LockUser()
+		info, err := e.getTLSLocked(false /* rx */)
+		e.UnlockUser()
+		if err != nil {
+			return err
+		}
+		*o = tcpip.TLSTxOption(info)
+
+	case *tcpip.TLSRxOption:
+		e.LockUser()
+		info, err := e.getTLSLocked(true /* rx */)
+		e.UnlockUser()
+		if err != nil {
+			return err
+		}
+		*o = tcpip.TLSRxOption(info)
+
+	case *tcpip.TCPRepairWindowOption:
+		e.LockUser()
+		w, err := e.repairWindowLocked()
+		e.UnlockUser()
+		if err != nil {
+			return err
+		}
+		*o = w
+
 	case *tcpip.OriginalDestinationOption:
 		e.LockUser()
 		ipt := e.stack.IPTables()
@@ -2231,7 +2674,7 @@ func (*Endpoint) Disconnect() tcpip.Error {
 func (e *Endpoint) Connect(addr tcpip.FullAddress) tcpip.Error {
 	e.LockUser()
 	defer e.UnlockUser()
-	err := e.connect(addr, true)
+	err := e.connect(addr, true /* handshake */, e.fastOpenConnect)
 	if err != nil {
 		if !err.IgnoreStats() {
 			// Connect failed. Let's wake up any waiters.
@@ -2377,10 +2820,21 @@ func (e *Endpoint) registerEndpoint(addr tcpip.FullAddress, netProto tcpip.Netwo
 	return nil
 }
 
-// connect connects the endpoint to its peer.
+// findRoute finds a route to remoteAddr. Transparent endpoints may use a local
+// address that is not assigned to the stack.
+func (e *Endpoint) findRoute(nicID tcpip.NICID, localAddr, remoteAddr tcpip.Address, netProto tcpip.NetworkProtocolNumber) (*stack.Route, tcpip.Error) {
+	if e.ops.GetTransparent() {
+		return e.stack.FindTransparentRoute(nicID, localAddr, remoteAddr, netProto, false /* multicastLoop */)
+	}
+	return e.stack.FindRoute(nicID, localAddr, remoteAddr, netProto, false /* multicastLoop */)
+}
+
+// connect connects the endpoint to its peer. If fastOpen is true and data may
+// be sent in the SYN, the SYN is deferred until the first write and connect
+// returns nil.
 // +checklocks:e.mu
 // +checklocksalias:e.snd.ep.mu=e.mu
-func (e *Endpoint) connect(addr tcpip.FullAddress, handshake bool) tcpip.Error {
+func (e *Endpoint) connect(addr tcpip.FullAddress, handshake, fastOpen bool) tcpip.Error {
 	connectingAddr := addr.Addr
 
 	addr, netProto, err := e.checkV4MappedLocked(addr, false /* bind */)
@@ -2434,7 +2888,7 @@ func (e *Endpoint) connect(addr tcpip.FullAddress, handshake bool) tcpip.Error {
 	}
 
 	// Find a route to the desired destination.
-	r, err := e.stack.FindRoute(nicID, e.TransportEndpointInfo.ID.LocalAddress, addr.Addr, netProto, false /* multicastLoop */)
+	r, err := e.findRoute(nicID, e.TransportEndpointInfo.ID.LocalAddress, addr.Addr, netProto)
 	if err != nil {
 		return err
 	}
@@ -2481,9 +2935,22 @@ func (e *Endpoint) connect(addr tcpip.FullAddress, handshake bool) tcpip.Error {
 		return &tcpip.ErrConnectStarted{}
 	}
 
+	if e.repair {
+		e.connectRepairLocked()
+		return nil
+	}
+
+	// TCP Fast Open is not supported with Multipath TCP.
+	if !fastOpen {
+		e.initActiveMPTCPLocked()
+	}
+
 	// Start a new handshake.
 	h := e.newHandshake()
 	e.setEndpointState(StateSynSent)
+	if fastOpen && h.startFastOpenLocked() {
+		return nil
+	}
 	h.start()
 	e.stack.Stats().TCP.ActiveConnectionOpenings.Increment()
 
@@ -2511,7 +2978,11 @@ func (e *Endpoint) Shutdown(flags tcpip.ShutdownFlags) tcpip.Error {
 		return nil
 	}
 
-	return e.shutdownLocked(flags)
+	err := e.shutdownLocked(flags)
+	if flags&tcpip.ShutdownWrite != 0 && e.mptcp != nil && !e.mptcp.join {
+		e.mptcpShutdownSubflowsLocked()
+	}
+	return err
 }
 
 // +checklocks:e.mu
@@ -2546,6 +3017,11 @@ func (e *Endpoint) shutdownLocked(flags tcpip.ShutdownFlags) tcpip.Error {
 
 		// Close for write.
 		if e.shutdownFlags&tcpip.ShutdownWrite != 0 {
+			if e.mptcp != nil && !e.mptcp.join {
+				// The FIN of the primary endpoint carries the
+				// DATA_FIN of the connection.
+				e.mptcp.conn.closeSend()
+			}
 			e.sndQueueInfo.sndQueueMu.Lock()
 			if e.sndQueueInfo.SndClosed {
 				// Already closed.
@@ -2627,6 +3103,9 @@ func (e *Endpoint) listen(backlog int) tcpip.Error {
 		if e.acceptQueue.pendingEndpoints == nil {
 			e.acceptQueue.pendingEndpoints = make(map[*Endpoint]struct{})
 		}
+		if e.acceptQueue.fastOpenPending == nil {
+			e.acceptQueue.fastOpenPending = make(map[*Endpoint]struct{})
+		}
 
 		e.shutdownFlags = 0
 		e.updateConnDirectionState(connDirectionStateOpen)
@@ -2672,6 +3151,9 @@ func (e *Endpoint) listen(backlog int) tcpip.Error {
 	if e.acceptQueue.pendingEndpoints == nil {
 		e.acceptQueue.pendingEndpoints = make(map[*Endpoint]struct{})
 	}
+	if e.acceptQueue.fastOpenPending == nil {
+		e.acceptQueue.fastOpenPending = make(map[*Endpoint]struct{})
+	}
 	if e.acceptQueue.capacity == 0 {
 		e.acceptQueue.capacity = backlog
 	}
@@ -2758,7 +3240,11 @@ func (e *Endpoint) bindLocked(addr tcpip.FullAddress) (err tcpip.Error) {
 	if addr.Addr.Len() != 0 {
 		nic = e.stack.CheckLocalAddress(addr.NIC, netProto, addr.Addr)
 		if nic == 0 {
-			return &tcpip.ErrBadLocalAddress{}
+			// Transparent sockets may be bound to any address.
+			if !e.ops.GetTransparent() {
+				return &tcpip.ErrBadLocalAddress{}
+			}
+			nic = addr.NIC
 		}
 		e.TransportEndpointInfo.ID.LocalAddress = addr.Addr
 	}
@@ -2989,6 +3475,10 @@ func (e *Endpoint) updateSndBufferUsage(v int) {
 //
 // +checklocks:e.mu
 func (e *Endpoint) readyToRead(s *segment) {
+	if e.mptcp != nil {
+		e.mptcpReadyToRead(s)
+		return
+	}
 	e.rcvQueueMu.Lock()
 	if s != nil {
 		e.RcvBufUsed += s.payloadSize()
@@ -2997,6 +3487,7 @@ func (e *Endpoint) readyToRead(s *segment) {
 	} else {
 		e.RcvClosed = true
 	}
+	e.updateTLSRxReadyLocked()
 	e.rcvQueueMu.Unlock()
 	e.waiterQueue.Notify(waiter.ReadableEvents)
 }
@@ -3131,7 +3622,12 @@ func (e *Endpoint) maybeEnableSACKPermitted(synOpts header.TCPSynOptions) {
 // maxOptionSize return the maximum size of TCP options.
 func (e *Endpoint) maxOptionSize() (size int) {
 	var maxSackBlocks [header.TCPMaxSACKBlocks]header.SACKBlock
-	options := e.makeOptions(maxSackBlocks[:])
+	var mptcp []byte
+	if e.mptcp != nil {
+		// Room for the largest DSS option, which needs padding.
+		mptcp = make([]byte, header.MPTCPDSSMaxLength+2)
+	}
+	options := e.makeOptions(maxSackBlocks[:], e.hasMD5Keys(), mptcp)
 	size = len(options)
 	putOptions(options)
 
@@ -3246,6 +3742,14 @@ func (e *Endpoint) SocketOptions() *tcpip.SocketOptions {
 	return &e.ops
 }
 
+var _ stack.ReusePortSteeringTransportEndpoint = (*Endpoint)(nil)
+
+// ReusePortProgram implements
+// stack.ReusePortSteeringTransportEndpoint.ReusePortProgram.
+func (e *Endpoint) ReusePortProgram() tcpip.ReusePortProgram {
+	return e.ops.GetReusePortProgram()
+}
+
 // GetTCPSendBufferLimits is used to get send buffer size limits for TCP.
 func GetTCPSendBufferLimits(sh tcpip.StackHandler) tcpip.SendBufferSizeOption {
 	// This type assertion is safe because only the TCP stack calls this
@@ -0,0 +1,336 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/seqnum"
+)
+
+// This file implements TCP Fast Open as specified in RFC 7413.
+
+const (
+	// fastOpenCookieSize is the size of the cookies generated by listening
+	// endpoints. It matches Linux's TCP_FASTOPEN_COOKIE_SIZE.
+	fastOpenCookieSize = 8
+
+	// maxFastOpenCacheEntries is the maximum number of server cookies
+	// remembered by the client side cache.
+	maxFastOpenCacheEntries = 1024
+)
+
+// validFastOpenKey returns true if key holds a primary key optionally followed
+// by a backup key.
+func validFastOpenKey(key []byte) bool {
+	return len(key) == tcpip.TCPFastOpenKeyLength || len(key) == 2*tcpip.TCPFastOpenKeyLength
+}
+
+// fastOpenCookie returns the cookie generated with key for a client at remote
+// connecting to local. See RFC 7413, section 4.1.2.
+func fastOpenCookie(key []byte, remote, local tcpip.Address) []byte {
+	h := hmac.New(sha256.New, key)
+
+	// Per hash.Hash.Writer:
+	//
+	// It never returns an error.
+	_, _ = h.Write(remote.AsSlice())
+	_, _ = h.Write(local.AsSlice())
+	return h.Sum(nil)[:fastOpenCookieSize]
+}
+
+// fastOpenMode returns the TCP Fast Open features enabled on the stack.
+func (p *protocol) fastOpenMode() tcpip.TCPFastOpen {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.fastOpen
+}
+
+// cachedFastOpenCookie returns the cookie previously received from the server
+// at addr, if any.
+func (p *protocol) cachedFastOpenCookie(addr tcpip.Address) ([]byte, bool) {
+	p.fastOpenCacheMu.Lock()
+	defer p.fastOpenCacheMu.Unlock()
+	cookie, ok := p.fastOpenCache[addr]
+	return cookie, ok
+}
+
+// cacheFastOpenCookie remembers the cookie received from the server at addr.
+func (p *protocol) cacheFastOpenCookie(addr tcpip.Address, cookie []byte) {
+	p.fastOpenCacheMu.Lock()
+	defer p.fastOpenCacheMu.Unlock()
+	if _, ok := p.fastOpenCache[addr]; !ok && len(p.fastOpenCache) >= maxFastOpenCacheEntries {
+		// Evict an arbitrary entry to make room.
+		for a := range p.fastOpenCache {
+			delete(p.fastOpenCache, a)
+			break
+		}
+	}
+	p.fastOpenCache[addr] = append([]byte(nil), cookie...)
+}
+
+// fastOpenSyn describes how a listening endpoint handles a SYN that may use
+// TCP Fast Open.
+type fastOpenSyn struct {
+	// accept is true if the data carried by the SYN is accepted.
+	accept bool
+
+	// cookie, if not nil, is the cookie sent to the client in the SYN-ACK.
+	cookie []byte
+}
+
+// fastOpenKeysLocked returns the keys with which the listening endpoint e
+// generates and validates cookies, primary key first.
+//
+// +checklocks:e.mu
+func (e *Endpoint) fastOpenKeysLocked() [][]byte {
+	key := []byte(e.fastOpenKey)
+	if key == nil {
+		e.protocol.mu.RLock()
+		key = e.protocol.fastOpenKey
+		e.protocol.mu.RUnlock()
+	}
+	var keys [][]byte
+	for ; len(key) >= tcpip.TCPFastOpenKeyLength; key = key[tcpip.TCPFastOpenKeyLength:] {
+		keys = append(keys, key[:tcpip.TCPFastOpenKeyLength])
+	}
+	return keys
+}
+
+// handleFastOpenSynLocked determines how the listening endpoint e handles the
+// TCP Fast Open option, if any, of the SYN s. See RFC 7413, section 4.2.2, and
+// Linux's net/ipv4/tcp_fastopen.c:tcp_try_fastopen().
+//
+// +checklocks:e.mu
+// +checklocks:e.acceptMu
+func (e *Endpoint) handleFastOpenSynLocked(s *segment, opts header.TCPSynOptions) fastOpenSyn {
+	mode := e.protocol.fastOpenMode()
+	if mode&tcpip.TCPFastOpenServer == 0 || e.fastOpenQueueLen == 0 {
+		return fastOpenSyn{}
+	}
+	hasData := s.payloadSize() > 0
+	noCookie := mode&tcpip.TCPFastOpenServerNoCookie != 0 || e.fastOpenNoCookie
+	if !opts.FastOpen && !(hasData && noCookie) {
+		return fastOpenSyn{}
+	}
+
+	// Fast Open connections stay pending until their handshake completes,
+	// however that happens, so prune the ones that are done before
+	// checking the limit.
+	for n := range e.acceptQueue.fastOpenPending {
+		if n.EndpointState() != StateSynRecv {
+			delete(e.acceptQueue.fastOpenPending, n)
+		}
+	}
+	if len(e.acceptQueue.fastOpenPending) >= e.fastOpenQueueLen {
+		return fastOpenSyn{}
+	}
+	// An accepted connection goes to the accept queue right away, so it
+	// must have room for it. Otherwise drop the data and complete a
+	// regular handshake.
+	if e.acceptQueue.isFull() {
+		return fastOpenSyn{}
+	}
+
+	keys := e.fastOpenKeysLocked()
+	valid := noCookie
+	for _, key := range keys {
+		if len(opts.FastOpenCookie) != 0 && hmac.Equal(opts.FastOpenCookie, fastOpenCookie(key, s.id.RemoteAddress, s.id.LocalAddress)) {
+			valid = true
+			break
+		}
+	}
+	if valid && hasData {
+		return fastOpenSyn{accept: true}
+	}
+	if !opts.FastOpen {
+		return fastOpenSyn{}
+	}
+	// Send a cookie, replacing any invalid one, for use by future
+	// connections.
+	return fastOpenSyn{cookie: fastOpenCookie(keys[0], s.id.RemoteAddress, s.id.LocalAddress)}
+}
+
+// acceptFastOpenDataLocked queues the data carried by the SYN s of a passive
+// handshake so that it can be read before the handshake completes.
+//
+// +checklocks:h.ep.mu
+func (h *handshake) acceptFastOpenDataLocked(s *segment) {
+	h.fastOpenLen = seqnum.Size(s.payloadSize())
+	h.ackNum = h.ackNum.Add(h.fastOpenLen)
+	// The data is already available to the application, so there is
+	// nothing to defer the accept for.
+	h.deferAccept = 0
+
+	c := s.clone()
+	c.setOwner(h.ep, recvQ)
+	h.ep.readyToRead(c)
+	c.DecRef()
+}
+
+// startFastOpenLocked prepares an active handshake to use TCP Fast Open. It
+// returns true if data may be sent in the SYN, in which case the SYN is
+// deferred until the data is written. Otherwise the SYN is sent as usual and
+// requests a cookie for future connections.
+//
+// +checklocks:h.ep.mu
+func (h *handshake) startFastOpenLocked() bool {
+	e := h.ep
+	mode := e.protocol.fastOpenMode()
+	if mode&tcpip.TCPFastOpenClient == 0 {
+		return false
+	}
+	cookie, ok := e.protocol.cachedFastOpenCookie(e.TransportEndpointInfo.ID.RemoteAddress)
+	switch {
+	case ok:
+		h.fastOpenCookie = cookie
+	case mode&tcpip.TCPFastOpenClientNoCookie != 0 || e.fastOpenNoCookie:
+		// Send data in the SYN without the Fast Open option.
+	default:
+		h.fastOpenCookie = []byte{}
+		return false
+	}
+
+	// Don't retransmit a SYN that wasn't sent yet.
+	h.retransmitTimer.stop()
+	e.sndQueueInfo.sndQueueMu.Lock()
+	e.sndQueueInfo.fastOpenDeferred = true
+	e.sndQueueInfo.sndQueueMu.Unlock()
+	return true
+}
+
+// sendFastOpenSynLocked sends the SYN of a handshake deferred by
+// startFastOpenLocked with as much data from p as fits in it. It returns the
+// number of bytes sent.
+//
+// +checklocks:e.mu
+func (e *Endpoint) sendFastOpenSynLocked(p tcpip.Payloader, opts tcpip.WriteOptions) (int64, tcpip.Error) {
+	e.sndQueueInfo.sndQueueMu.Lock()
+	e.sndQueueInfo.fastOpenDeferred = false
+	avail := e.getSendBufferSize() - e.sndQueueInfo.SndBufUsed
+	// The data and options must fit in the SYN. The peer's MSS is not known
+	// yet, so use our own as Linux does.
+	if mss := int(calculateAdvertisedMSS(e.userMSS, e.route)) - header.TCPOptionsMaximumSize; avail > mss {
+		avail = mss
+	}
+	if avail < 0 {
+		avail = 0
+	}
+	// Copy the data without releasing any lock, as the handshake can't be
+	// started concurrently. The data of a send requested with MSG_ZEROCOPY
+	// is copied too, as it is held in the SYN.
+	opts.Atomic = true
+	opts.ZeroCopy = false
+	buf, _, err := e.readFromPayloader(p, opts, avail)
+	if err != nil {
+		e.sndQueueInfo.sndQueueMu.Unlock()
+		return 0, err
+	}
+	data := buf.Flatten()
+	buf.Release()
+	e.sndQueueInfo.SndBufUsed += len(data)
+	e.sndQueueInfo.sndQueueMu.Unlock()
+
+	h := e.h
+	h.synData = data
+	h.retransmitTimer.reinit(InitialRTO)
+	h.start()
+	e.stack.Stats().TCP.ActiveConnectionOpenings.Increment()
+	return int64(len(data)), nil
+}
+
+// writeFastOpenLocked handles a write that may carry data in the SYN, that is a
+// write with tcpip.WriteOptions.FastOpen set on an unconnected endpoint or the
+// first write after a connect deferred by TCP_FASTOPEN_CONNECT. It returns
+// false if the write should proceed as usual.
+//
+// +checklocks:e.mu
+func (e *Endpoint) writeFastOpenLocked(p tcpip.Payloader, opts tcpip.WriteOptions) (int64, bool, tcpip.Error) {
+	switch e.EndpointState() {
+	case StateInitial, StateBound:
+		if !opts.FastOpen {
+			return 0, false, nil
+		}
+		if e.protocol.fastOpenMode()&tcpip.TCPFastOpenClient == 0 {
+			return 0, true, &tcpip.ErrNotSupported{}
+		}
+		if opts.To == nil {
+			return 0, true, &tcpip.ErrDestinationRequired{}
+		}
+		// If the SYN can't carry data, it is sent right away and the
+		// connect returns tcpip.ErrConnectStarted.
+		if err := e.connect(*opts.To, true /* handshake */, true /* fastOpen */); err != nil {
+			return 0, true, err
+		}
+		n, err := e.sendFastOpenSynLocked(p, opts)
+		return n, true, err
+
+	case StateSynSent:
+		e.sndQueueInfo.sndQueueMu.Lock()
+		deferred := e.sndQueueInfo.fastOpenDeferred
+		e.sndQueueInfo.sndQueueMu.Unlock()
+		if !deferred {
+			return 0, false, nil
+		}
+		n, err := e.sendFastOpenSynLocked(p, opts)
+		return n, true, err
+
+	default:
+		if opts.FastOpen && e.EndpointState().connected() {
+			return 0, true, &tcpip.ErrAlreadyConnected{}
+		}
+		return 0, false, nil
+	}
+}
+
+// handleFastOpenSynAckLocked processes the TCP Fast Open option of the SYN-ACK
+// s, and determines how much of the data sent in the SYN it acknowledges.
+//
+// +checklocks:h.ep.mu
+func (h *handshake) handleFastOpenSynAckLocked(s *segment, opts header.TCPSynOptions) {
+	if len(opts.FastOpenCookie) != 0 {
+		h.ep.protocol.cacheFastOpenCookie(h.ep.TransportEndpointInfo.ID.RemoteAddress, opts.FastOpenCookie)
+	}
+	if len(h.synData) != 0 {
+		// checkAck guarantees that the ACK is within the data.
+		h.synDataAcked = (h.iss + 1).Size(s.ackNumber)
+	}
+}
+
+// requeueSynDataLocked releases the data sent in the SYN once the handshake is
+// complete, and queues the part not acknowledged by the peer to be sent
+// again. See RFC 7413, section 4.2.1.
+//
+// +checklocks:h.ep.mu
+// +checklocksalias:h.ep.snd.ep.mu=h.ep.mu
+func (h *handshake) requeueSynDataLocked() {
+	e := h.ep
+	acked := int(h.synDataAcked)
+	rest := h.synData[acked:]
+	h.synData = nil
+	if acked > 0 {
+		e.updateSndBufferUsage(acked)
+	}
+	if len(rest) == 0 {
+		return
+	}
+	s := newOutgoingSegment(e.TransportEndpointInfo.ID, e.stack.Clock(), buffer.MakeWithData(rest))
+	e.snd.writeList.PushBack(s)
+	e.sendData(s)
+}
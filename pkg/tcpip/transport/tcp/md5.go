@@ -0,0 +1,229 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcp
+
+import (
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/binary"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+// This file implements the TCP MD5 signature option, see RFC 2385. Segments
+// exchanged with a peer for which a key is configured carry an MD5 digest of
+// the segment and the key; segments with a missing or invalid digest are
+// dropped before they reach the endpoint.
+
+// maxMD5KeyLen is the maximum length of a TCP MD5 signature key. It matches
+// Linux's TCP_MD5SIG_MAXKEYLEN.
+const maxMD5KeyLen = 80
+
+// md5Key is a TCP MD5 signature key for the peers within a prefix.
+//
+// +stateify savable
+type md5Key struct {
+	// prefix is the set of peer addresses the key applies to.
+	prefix tcpip.AddressWithPrefix
+
+	// nic restricts the key to peers reached through a NIC. Zero means any
+	// NIC.
+	nic tcpip.NICID
+
+	// key is the shared secret.
+	key []byte
+}
+
+// matches returns true if the key applies to addr reached through nic.
+func (k *md5Key) matches(addr tcpip.Address, nic tcpip.NICID) bool {
+	if k.nic != 0 && k.nic != nic {
+		return false
+	}
+	subnet := k.prefix.Subnet()
+	return subnet.Contains(addr)
+}
+
+// setMD5KeyLocked installs, replaces or, if opt.Key is empty, removes the key
+// for the peers described by opt.
+//
+// +checklocks:e.mu
+// +checklocksalias:e.snd.ep.mu=e.mu
+func (e *Endpoint) setMD5KeyLocked(opt *tcpip.TCPMD5SigOption) tcpip.Error {
+	if len(opt.Key) > maxMD5KeyLen || opt.PrefixLen < 0 || opt.PrefixLen > opt.Addr.BitLen() {
+		return &tcpip.ErrInvalidOptionValue{}
+	}
+	oldOptSize := e.maxOptionSize()
+	if err := e.updateMD5Keys(opt); err != nil {
+		return err
+	}
+
+	// Segments of an established connection must make room for the
+	// signature option.
+	if e.EndpointState().connected() {
+		e.snd.MaxPayloadSize -= e.maxOptionSize() - oldOptSize
+		if e.snd.gso {
+			e.gso.MSS = uint16(e.snd.MaxPayloadSize)
+		}
+	}
+	return nil
+}
+
+// updateMD5Keys applies opt to the endpoint's key list.
+func (e *Endpoint) updateMD5Keys(opt *tcpip.TCPMD5SigOption) tcpip.Error {
+	prefix := tcpip.AddressWithPrefix{Address: opt.Addr, PrefixLen: opt.PrefixLen}
+
+	e.md5Mu.Lock()
+	defer e.md5Mu.Unlock()
+	for i := range e.md5Keys {
+		k := &e.md5Keys[i]
+		if k.prefix != prefix || k.nic != opt.NIC {
+			continue
+		}
+		if len(opt.Key) == 0 {
+			e.md5Keys = append(e.md5Keys[:i], e.md5Keys[i+1:]...)
+		} else {
+			k.key = append([]byte(nil), opt.Key...)
+		}
+		return nil
+	}
+	if len(opt.Key) == 0 {
+		return &tcpip.ErrNoSuchFile{}
+	}
+	e.md5Keys = append(e.md5Keys, md5Key{
+		prefix: prefix,
+		nic:    opt.NIC,
+		key:    append([]byte(nil), opt.Key...),
+	})
+	return nil
+}
+
+// md5KeyFor returns the key to sign segments exchanged with addr through nic,
+// or nil if there is none. The key with the longest matching prefix wins.
+func (e *Endpoint) md5KeyFor(addr tcpip.Address, nic tcpip.NICID) []byte {
+	e.md5Mu.RLock()
+	defer e.md5Mu.RUnlock()
+	var best *md5Key
+	for i := range e.md5Keys {
+		k := &e.md5Keys[i]
+		if !k.matches(addr, nic) {
+			continue
+		}
+		if best == nil || k.prefix.PrefixLen > best.prefix.PrefixLen || (k.prefix.PrefixLen == best.prefix.PrefixLen && k.nic != 0) {
+			best = k
+		}
+	}
+	if best == nil {
+		return nil
+	}
+	return best.key
+}
+
+// hasMD5Keys returns true if any TCP MD5 signature key is configured.
+func (e *Endpoint) hasMD5Keys() bool {
+	e.md5Mu.RLock()
+	defer e.md5Mu.RUnlock()
+	return len(e.md5Keys) > 0
+}
+
+// inheritMD5Keys copies the keys of the listening endpoint l to e.
+func (e *Endpoint) inheritMD5Keys(l *Endpoint) {
+	l.md5Mu.RLock()
+	keys := append([]md5Key(nil), l.md5Keys...)
+	l.md5Mu.RUnlock()
+
+	e.md5Mu.Lock()
+	e.md5Keys = keys
+	e.md5Mu.Unlock()
+}
+
+// verifyMD5 checks the TCP MD5 signature of an inbound segment. It returns
+// false, accounting for the reason in the stack's stats, if the segment must
+// be dropped.
+func (e *Endpoint) verifyMD5(s *segment) bool {
+	net := s.pkt.Network()
+	key := e.md5KeyFor(net.SourceAddress(), s.pkt.NICID)
+	off, ok := header.TCPMD5Option(s.options)
+	switch {
+	case key == nil && !ok:
+		return true
+	case key == nil:
+		e.stack.Stats().TCP.MD5Unexpected.Increment()
+		return false
+	case !ok:
+		e.stack.Stats().TCP.MD5NotFound.Increment()
+		return false
+	}
+
+	digest := tcpMD5Digest(key, net.SourceAddress(), net.DestinationAddress(), header.TCP(s.pkt.TransportHeader().Slice()), s.pkt.Data())
+	if subtle.ConstantTimeCompare(digest[:], s.options[off+2:off+header.TCPOptionMD5Length]) != 1 {
+		e.stack.Stats().TCP.MD5Failure.Increment()
+		return false
+	}
+	return true
+}
+
+// signMD5 fills in the digest of the TCP MD5 signature option carried by the
+// segment built in pkt.
+func signMD5(r *stack.Route, key []byte, pkt *stack.PacketBuffer) {
+	tcp := header.TCP(pkt.TransportHeader().Slice())
+	opts := tcp[header.TCPMinimumSize:tcp.DataOffset()]
+	off, ok := header.TCPMD5Option(opts)
+	if !ok {
+		panic("TCP MD5 signature option missing from signed segment")
+	}
+	digest := tcpMD5Digest(key, r.LocalAddress(), r.RemoteAddress(), tcp, pkt.Data())
+	copy(opts[off+2:], digest[:])
+}
+
+// tcpMD5Digest computes the digest of a segment as described in RFC 2385,
+// section 2.0: the pseudo-header, the TCP header without options and with a
+// zero checksum, the payload and finally the key.
+func tcpMD5Digest(key []byte, src, dst tcpip.Address, tcp header.TCP, payload stack.PacketData) [header.TCPMD5DigestSize]byte {
+	h := md5.New()
+	segLen := len(tcp) + payload.Size()
+
+	var pseudo [2*header.IPv6AddressSize + 8]byte
+	n := 0
+	if src.Len() == header.IPv4AddressSize {
+		src4, dst4 := src.As4(), dst.As4()
+		n += copy(pseudo[n:], src4[:])
+		n += copy(pseudo[n:], dst4[:])
+		pseudo[n+1] = uint8(ProtocolNumber)
+		binary.BigEndian.PutUint16(pseudo[n+2:], uint16(segLen))
+		n += 4
+	} else {
+		src16, dst16 := src.As16(), dst.As16()
+		n += copy(pseudo[n:], src16[:])
+		n += copy(pseudo[n:], dst16[:])
+		binary.BigEndian.PutUint32(pseudo[n:], uint32(segLen))
+		pseudo[n+7] = uint8(ProtocolNumber)
+		n += 8
+	}
+	h.Write(pseudo[:n])
+
+	var hdr [header.TCPMinimumSize]byte
+	copy(hdr[:], tcp)
+	header.TCP(hdr[:]).SetChecksum(0)
+	h.Write(hdr[:])
+
+	payload.ReadTo(h, true /* peek */)
+	h.Write(key)
+
+	var digest [header.TCPMD5DigestSize]byte
+	h.Sum(digest[:0])
+	return digest
+}
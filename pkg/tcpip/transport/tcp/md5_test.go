@@ -0,0 +1,98 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcp
+
+import (
+	"bytes"
+	"crypto/md5"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+func TestMD5KeyFor(t *testing.T) {
+	var e Endpoint
+	for _, opt := range []tcpip.TCPMD5SigOption{
+		{Addr: tcpip.AddrFrom4([4]byte{10, 0, 0, 0}), PrefixLen: 8, Key: []byte("wide")},
+		{Addr: tcpip.AddrFrom4([4]byte{10, 1, 0, 0}), PrefixLen: 16, Key: []byte("narrow")},
+		{Addr: tcpip.AddrFrom4([4]byte{10, 1, 0, 0}), PrefixLen: 16, NIC: 2, Key: []byte("nic")},
+	} {
+		if err := e.updateMD5Keys(&opt); err != nil {
+			t.Fatalf("updateMD5Keys(%+v) = %s", opt, err)
+		}
+	}
+
+	for _, tc := range []struct {
+		addr tcpip.Address
+		nic  tcpip.NICID
+		want []byte
+	}{
+		{tcpip.AddrFrom4([4]byte{10, 2, 0, 1}), 1, []byte("wide")},
+		{tcpip.AddrFrom4([4]byte{10, 1, 0, 1}), 1, []byte("narrow")},
+		{tcpip.AddrFrom4([4]byte{10, 1, 0, 1}), 2, []byte("nic")},
+		{tcpip.AddrFrom4([4]byte{11, 0, 0, 1}), 1, nil},
+	} {
+		if got := e.md5KeyFor(tc.addr, tc.nic); !bytes.Equal(got, tc.want) {
+			t.Errorf("md5KeyFor(%s, %d) = %q, want = %q", tc.addr, tc.nic, got, tc.want)
+		}
+	}
+
+	del := tcpip.TCPMD5SigOption{Addr: tcpip.AddrFrom4([4]byte{10, 1, 0, 0}), PrefixLen: 16}
+	if err := e.updateMD5Keys(&del); err != nil {
+		t.Fatalf("updateMD5Keys(%+v) = %s", del, err)
+	}
+	if err := e.updateMD5Keys(&del); err == nil {
+		t.Errorf("updateMD5Keys(%+v) = nil, want = %s", del, &tcpip.ErrNoSuchFile{})
+	} else if _, ok := err.(*tcpip.ErrNoSuchFile); !ok {
+		t.Errorf("updateMD5Keys(%+v) = %s, want = %s", del, err, &tcpip.ErrNoSuchFile{})
+	}
+}
+
+func TestTCPMD5Digest(t *testing.T) {
+	src := tcpip.AddrFrom4([4]byte{192, 168, 0, 1})
+	dst := tcpip.AddrFrom4([4]byte{192, 168, 0, 2})
+	payload := []byte("payload")
+	key := []byte("secret")
+
+	tcp := make(header.TCP, header.TCPMinimumSize+4+header.TCPOptionMD5Length)
+	tcp.Encode(&header.TCPFields{
+		SrcPort:    179,
+		DstPort:    40000,
+		SeqNum:     1,
+		DataOffset: uint8(len(tcp)),
+		Flags:      header.TCPFlagAck,
+		WindowSize: 1000,
+		Checksum:   0xffff,
+	})
+	pkt := stack.NewPacketBuffer(stack.PacketBufferOptions{
+		Payload: buffer.MakeWithData(payload),
+	})
+	defer pkt.DecRef()
+
+	var want bytes.Buffer
+	want.Write([]byte{192, 168, 0, 1, 192, 168, 0, 2, 0, 6, 0, byte(len(tcp) + len(payload))})
+	hdr := append([]byte(nil), tcp[:header.TCPMinimumSize]...)
+	hdr[header.TCPChecksumOffset], hdr[header.TCPChecksumOffset+1] = 0, 0
+	want.Write(hdr)
+	want.Write(payload)
+	want.Write(key)
+
+	if got, want := tcpMD5Digest(key, src, dst, tcp, pkt.Data()), md5.Sum(want.Bytes()); got != want {
+		t.Errorf("tcpMD5Digest(...) = %x, want = %x", got, want)
+	}
+}
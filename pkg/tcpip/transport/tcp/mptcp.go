@@ -0,0 +1,1176 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcp
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"sort"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/atomicbitops"
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/sync"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/seqnum"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+// This file implements Multipath TCP, see RFC 8684. A Multipath TCP
+// connection is made of subflows, each of them a regular TCP connection
+// handled by its own endpoint. The endpoint of the initial subflow, the
+// primary endpoint, is the one the application uses: data written to it is
+// spread over the subflows by a scheduler, and data received on any subflow is
+// put back in order, following the data sequence numbers carried by DSS
+// options, in the receive queue of the primary endpoint.
+//
+// The connection is created by the MP_CAPABLE handshake of the initial
+// subflow. When this end opened the connection, a simple path manager then
+// joins a subflow from each other local address able to reach the peer with
+// the MP_JOIN handshake. A connection whose peer does not support Multipath
+// TCP, or whose options are stripped on the way, falls back to TCP as long as
+// it only has its initial subflow.
+//
+// Data queued on a subflow which is reset is sent again on the primary
+// endpoint. The primary endpoint is however not replaced if it fails, and DSS
+// checksums, ADD_ADDR and REMOVE_ADDR are not supported.
+
+// mptcpMaxSubflows is the maximum number of subflows of a Multipath TCP
+// connection, including the initial one. It matches Linux's default
+// net.mptcp.subflows limit plus the initial subflow.
+const mptcpMaxSubflows = 3
+
+// mptcpKeyHash returns the token and the initial data sequence number derived
+// from a Multipath TCP key.
+func mptcpKeyHash(key uint64) (token uint32, idsn uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], key)
+	sum := sha256.Sum256(b[:])
+	return binary.BigEndian.Uint32(sum[:4]), binary.BigEndian.Uint64(sum[sha256.Size-8:])
+}
+
+// mptcpJoinHMAC returns the HMAC authenticating an MP_JOIN handshake, keyed by
+// the keys key1 and key2 of the connection over the nonces nonce1 and nonce2
+// of the subflow.
+func mptcpJoinHMAC(key1, key2 uint64, nonce1, nonce2 uint32) [sha256.Size]byte {
+	var key [16]byte
+	binary.BigEndian.PutUint64(key[:], key1)
+	binary.BigEndian.PutUint64(key[8:], key2)
+	var msg [8]byte
+	binary.BigEndian.PutUint32(msg[:], nonce1)
+	binary.BigEndian.PutUint32(msg[4:], nonce2)
+	mac := hmac.New(sha256.New, key[:])
+	mac.Write(msg[:])
+	var sum [sha256.Size]byte
+	mac.Sum(sum[:0])
+	return sum
+}
+
+// expandDSN returns the 64-bit data sequence number closest to ref whose low
+// 32 bits are v.
+func expandDSN(v uint32, ref uint64) uint64 {
+	return ref + uint64(int64(int32(v-uint32(ref))))
+}
+
+// mptcpConn is a Multipath TCP connection.
+//
+// +stateify savable
+type mptcpConn struct {
+	// primary is the endpoint of the initial subflow.
+	primary *Endpoint
+
+	// localKey, localToken and localIDSN identify the connection on this
+	// end. They are immutable.
+	localKey   uint64
+	localToken uint32
+	localIDSN  uint64
+
+	// remoteKey, remoteToken and remoteIDSN identify the connection on the
+	// peer. They are set once by the handshake of the initial subflow,
+	// before any other subflow can join.
+	remoteKey   uint64
+	remoteToken uint32
+	remoteIDSN  uint64
+
+	// sndUna is the oldest data sequence number not acknowledged by the
+	// peer.
+	sndUna atomicbitops.Uint64
+
+	// rcvAck is the data acknowledgment sent to the peer. It follows rcvNxt
+	// so that it can be read when building options without holding mu.
+	rcvAck atomicbitops.Uint64
+
+	// sndClosed is true once the DATA_FIN, whose data sequence number is
+	// dataFin, has been queued. dataFin is set before sndClosed.
+	sndClosed atomicbitops.Bool
+	dataFin   atomicbitops.Uint64
+
+	mu sync.Mutex `state:"nosave"`
+
+	// remoteKeySet is true once the key of the peer is known.
+	//
+	// +checklocks:mu
+	remoteKeySet bool
+
+	// subflows holds the endpoints of the established subflows which can
+	// carry data, the primary endpoint first.
+	//
+	// +checklocks:mu
+	subflows []*Endpoint
+
+	// joins holds the endpoints of the subflows being joined.
+	//
+	// +checklocks:mu
+	joins map[*Endpoint]struct{}
+
+	// sndNxt is the data sequence number of the next byte written.
+	//
+	// +checklocks:mu
+	sndNxt uint64
+
+	// rcvNxt is the data sequence number of the next byte to be received.
+	//
+	// +checklocks:mu
+	rcvNxt uint64
+
+	// oooQueue holds the data received ahead of rcvNxt, sorted by data
+	// sequence number.
+	//
+	// +checklocks:mu
+	oooQueue segmentList
+
+	// readyQueue holds the data received in order which has not been moved
+	// to the receive queue of the primary endpoint yet. It is accounted for
+	// in the RcvBufUsed of the primary endpoint.
+	//
+	// +checklocks:mu
+	readyQueue segmentList
+
+	// rcvFin is true once a DATA_FIN was received, whose data sequence
+	// number is rcvFinDSN.
+	//
+	// +checklocks:mu
+	rcvFin bool
+	// +checklocks:mu
+	rcvFinDSN uint64
+
+	// nextAddrID is the address ID of the next subflow joined by this end.
+	//
+	// +checklocks:mu
+	nextAddrID uint8
+
+	// closed is true once the application closed the connection.
+	//
+	// +checklocks:mu
+	closed bool
+}
+
+// mptcpSubflow is the Multipath TCP state of the endpoint of a subflow.
+//
+// +stateify savable
+type mptcpSubflow struct {
+	conn *mptcpConn
+
+	// join is true if the subflow joined the connection with MP_JOIN, and
+	// false for the initial subflow.
+	join bool
+
+	// active is true if the subflow was opened by this end.
+	active bool
+
+	// ready is true once the subflow can carry data. Subflows joined by
+	// this end are ready once the peer acknowledges the third ACK.
+	ready bool
+
+	// capableAcked is true once the peer acknowledged the keys sent in the
+	// MP_CAPABLE option of the third ACK of the initial subflow.
+	capableAcked bool
+
+	// addrID is the address ID of the subflow.
+	addrID uint8
+
+	// localNonce and remoteNonce are the nonces of an MP_JOIN handshake.
+	localNonce  uint32
+	remoteNonce uint32
+
+	// ackHMAC is the HMAC sent in the third ACK of an MP_JOIN handshake.
+	ackHMAC [header.MPTCPJoinHMACSize]byte
+
+	// iss and irs are the initial sequence numbers of the subflow, which
+	// subflow sequence numbers of mappings are relative to.
+	iss seqnum.Value
+	irs seqnum.Value
+
+	// hasMapping is true once a data sequence mapping was received. The
+	// last one maps mapLen bytes from subflow sequence number mapSeq to data
+	// sequence number mapDSN.
+	hasMapping bool
+	mapDSN     uint64
+	mapSeq     seqnum.Value
+	mapLen     seqnum.Size
+
+	// finRcvd is true once a FIN was received on the subflow.
+	finRcvd bool
+}
+
+// newMPTCPConn creates a Multipath TCP connection with a key whose token is
+// unique among the connections of the protocol, and registers it so that
+// subflows can join it.
+func (p *protocol) newMPTCPConn(primary *Endpoint) *mptcpConn {
+	c := &mptcpConn{
+		primary:    primary,
+		joins:      make(map[*Endpoint]struct{}),
+		nextAddrID: 1,
+	}
+	rng := p.stack.SecureRNG()
+	p.mptcpMu.Lock()
+	defer p.mptcpMu.Unlock()
+	if p.mptcpConns == nil {
+		p.mptcpConns = make(map[uint32]*mptcpConn)
+	}
+	for {
+		c.localKey = rng.Uint64()
+		c.localToken, c.localIDSN = mptcpKeyHash(c.localKey)
+		if _, ok := p.mptcpConns[c.localToken]; !ok {
+			break
+		}
+	}
+	p.mptcpConns[c.localToken] = c
+	c.mu.Lock()
+	c.sndNxt = c.localIDSN + 1
+	c.mu.Unlock()
+	c.sndUna.Store(c.localIDSN + 1)
+	return c
+}
+
+// unregisterMPTCPConn removes c from the connections subflows can join.
+func (p *protocol) unregisterMPTCPConn(c *mptcpConn) {
+	p.mptcpMu.Lock()
+	defer p.mptcpMu.Unlock()
+	if p.mptcpConns[c.localToken] == c {
+		delete(p.mptcpConns, c.localToken)
+	}
+}
+
+// mptcpConnByToken returns the connection whose local token is token, or nil.
+func (p *protocol) mptcpConnByToken(token uint32) *mptcpConn {
+	p.mptcpMu.Lock()
+	defer p.mptcpMu.Unlock()
+	return p.mptcpConns[token]
+}
+
+// setRemoteKey records the key of the peer, exchanged by the MP_CAPABLE
+// handshake.
+func (c *mptcpConn) setRemoteKey(key uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remoteKey = key
+	c.remoteToken, c.remoteIDSN = mptcpKeyHash(key)
+	c.remoteKeySet = true
+	c.rcvNxt = c.remoteIDSN + 1
+	c.rcvAck.Store(c.rcvNxt)
+}
+
+// addJoin records that the endpoint of a new subflow is joining c. It returns
+// false if no more subflows can join c.
+func (c *mptcpConn) addJoin(ep *Endpoint) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || !c.remoteKeySet || len(c.subflows)+len(c.joins) >= mptcpMaxSubflows {
+		return false
+	}
+	c.joins[ep] = struct{}{}
+	return true
+}
+
+// addSubflow makes the endpoint of an established subflow available to carry
+// data.
+func (c *mptcpConn) addSubflow(ep *Endpoint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.joins, ep)
+	// A closed connection closes the subflows which were joining it.
+	if !c.closed {
+		c.subflows = append(c.subflows, ep)
+	}
+}
+
+// removeSubflow forgets about the endpoint of a subflow which is gone.
+func (c *mptcpConn) removeSubflow(ep *Endpoint) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.joins, ep)
+	for i, sub := range c.subflows {
+		if sub == ep {
+			c.subflows = append(c.subflows[:i], c.subflows[i+1:]...)
+			break
+		}
+	}
+}
+
+// readySubflows returns the endpoints of the subflows which can carry data,
+// the primary endpoint first.
+func (c *mptcpConn) readySubflows() []*Endpoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]*Endpoint(nil), c.subflows...)
+}
+
+// allocDSN assigns data sequence numbers to n bytes of data written to the
+// connection, and returns the first one.
+func (c *mptcpConn) allocDSN(n int) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	dsn := c.sndNxt
+	c.sndNxt += uint64(n)
+	return dsn
+}
+
+// closeSend assigns the data sequence number of the DATA_FIN, if the
+// connection is not already closed for sending.
+func (c *mptcpConn) closeSend() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sndClosed.Load() {
+		return
+	}
+	c.dataFin.Store(c.sndNxt)
+	c.sndNxt++
+	c.sndClosed.Store(true)
+}
+
+// updateSndUna processes a data acknowledgment received from the peer.
+func (c *mptcpConn) updateSndUna(ack uint64, ack64 bool) {
+	for {
+		una := c.sndUna.Load()
+		if !ack64 {
+			ack = expandDSN(uint32(ack), una)
+		}
+		if int64(ack-una) <= 0 || c.sndUna.CompareAndSwap(una, ack) {
+			return
+		}
+	}
+}
+
+// close closes the connection once the application closed or aborted the
+// primary endpoint: the other subflows are closed too, and data not read yet
+// is released.
+func (c *mptcpConn) close(abort bool) {
+	c.mu.Lock()
+	c.closed = true
+	subflows := c.subflows
+	joins := c.joins
+	c.subflows = nil
+	c.joins = make(map[*Endpoint]struct{})
+	for _, q := range []*segmentList{&c.oooQueue, &c.readyQueue} {
+		for s := q.Front(); s != nil; s = q.Front() {
+			q.Remove(s)
+			s.DecRef()
+		}
+	}
+	c.mu.Unlock()
+
+	c.primary.protocol.unregisterMPTCPConn(c)
+	for _, ep := range subflows {
+		switch {
+		case ep == c.primary:
+		case abort:
+			ep.Abort()
+		default:
+			ep.Close()
+		}
+	}
+	for ep := range joins {
+		ep.Close()
+	}
+}
+
+// EnableMPTCP makes the endpoint a Multipath TCP socket, as created with
+// IPPROTO_MPTCP. The connection it establishes or, if listening, accepts
+// uses Multipath TCP if the peer supports it, and plain TCP otherwise.
+func (e *Endpoint) EnableMPTCP() {
+	e.LockUser()
+	e.mptcpEnabled = true
+	e.UnlockUser()
+}
+
+// initActiveMPTCPLocked prepares the Multipath TCP handshake of an endpoint
+// about to connect.
+//
+// +checklocks:e.mu
+func (e *Endpoint) initActiveMPTCPLocked() {
+	if e.mptcp == nil {
+		// Multipath TCP options don't fit with the MD5 signature option.
+		if !e.mptcpEnabled || e.hasMD5Keys() {
+			return
+		}
+		e.mptcp = &mptcpSubflow{
+			conn:   e.protocol.newMPTCPConn(e),
+			active: true,
+		}
+	}
+	// Data sequence mappings are per segment.
+	e.gso = stack.GSO{}
+}
+
+// initPassiveMPTCPLocked prepares the Multipath TCP handshake of an endpoint
+// created by the listening endpoint l from the SYN s, if it carries an
+// MP_CAPABLE or MP_JOIN option. An error is returned if s joins a connection
+// which can't be joined.
+//
+// +checklocks:e.mu
+// +checklocks:l.mu
+func (e *Endpoint) initPassiveMPTCPLocked(l *Endpoint, s *segment, fo fastOpenSyn) tcpip.Error {
+	if !l.mptcpEnabled || e.hasMD5Keys() {
+		return nil
+	}
+	opts := s.mptcpOptions
+	switch {
+	case opts.HasJoin && opts.JoinLength == header.MPTCPJoinSynLength:
+		c := e.protocol.mptcpConnByToken(opts.Join.Token)
+		if c == nil || !c.addJoin(e) {
+			return &tcpip.ErrConnectionAborted{}
+		}
+		rng := e.stack.SecureRNG()
+		e.mptcp = &mptcpSubflow{
+			conn:        c,
+			join:        true,
+			addrID:      opts.Join.AddrID,
+			localNonce:  rng.Uint32(),
+			remoteNonce: opts.Join.Nonce,
+		}
+		// Events of the subflow are those of the connection.
+		e.waiterQueue = c.primary.waiterQueue
+
+	case opts.HasCapable && opts.Capable.NumKeys == 0 && opts.Capable.Version == header.MPTCPVersion && opts.Capable.Flags&header.MPTCPCapableChecksum == 0:
+		// TCP Fast Open is not supported with Multipath TCP.
+		if fo.accept || fo.cookie != nil {
+			return nil
+		}
+		e.mptcpEnabled = true
+		e.mptcp = &mptcpSubflow{conn: e.protocol.newMPTCPConn(e)}
+
+	default:
+		return nil
+	}
+	e.gso = stack.GSO{}
+	return nil
+}
+
+// handleMPTCPJoinSynLocked handles a SYN received by a listening endpoint
+// which joins an existing Multipath TCP connection. The subflow bypasses the
+// accept queue.
+//
+// +checklocks:e.mu
+func (e *Endpoint) handleMPTCPJoinSynLocked(ctx *listenContext, s *segment) tcpip.Error {
+	opts := parseSynSegmentOptions(s)
+	if _, err := ctx.startHandshake(s, opts, &waiter.Queue{}, e.owner, fastOpenSyn{}); err != nil {
+		e.stack.Stats().TCP.FailedConnectionAttempts.Increment()
+		e.stats.FailedConnectionAttempts.Increment()
+		return replyWithReset(e.stack, s, e.sendTOS, e.ipv4TTL, e.ipv6HopLimit)
+	}
+	return nil
+}
+
+// mptcpSynOptionLocked returns the Multipath TCP option of the SYN or SYN-ACK
+// sent by the endpoint, if any.
+//
+// +checklocks:e.mu
+func (e *Endpoint) mptcpSynOptionLocked() []byte {
+	sub := e.mptcp
+	if sub == nil {
+		return nil
+	}
+	c := sub.conn
+	b := make([]byte, header.MPTCPJoinSynAckLength)
+	var n int
+	switch {
+	case !sub.join && sub.active:
+		n = header.EncodeMPTCPCapableOption(header.MPTCPCapable{
+			Version: header.MPTCPVersion,
+			Flags:   header.MPTCPCapableHMACSHA256,
+		}, b)
+	case !sub.join:
+		n = header.EncodeMPTCPCapableOption(header.MPTCPCapable{
+			Version:   header.MPTCPVersion,
+			Flags:     header.MPTCPCapableHMACSHA256,
+			NumKeys:   1,
+			SenderKey: c.localKey,
+		}, b)
+	case sub.active:
+		n = header.EncodeMPTCPJoinSynOption(header.MPTCPJoin{
+			AddrID: sub.addrID,
+			Token:  c.remoteToken,
+			Nonce:  sub.localNonce,
+		}, b)
+	default:
+		mac := mptcpJoinHMAC(c.localKey, c.remoteKey, sub.localNonce, sub.remoteNonce)
+		n = header.EncodeMPTCPJoinSynAckOption(header.MPTCPJoin{
+			AddrID:        sub.addrID,
+			Nonce:         sub.localNonce,
+			TruncatedHMAC: binary.BigEndian.Uint64(mac[:]),
+		}, b)
+	}
+	return b[:n]
+}
+
+// mptcpHandleSynAckLocked processes the Multipath TCP option of the SYN-ACK
+// received by an endpoint in SYN-SENT state. An error is returned if the
+// subflow must be reset.
+//
+// +checklocks:e.mu
+func (e *Endpoint) mptcpHandleSynAckLocked(s *segment) tcpip.Error {
+	sub := e.mptcp
+	if sub == nil {
+		return nil
+	}
+	c := sub.conn
+	opts := s.mptcpOptions
+	if !sub.join {
+		if !opts.HasCapable || opts.Capable.NumKeys != 1 || opts.Capable.Version != header.MPTCPVersion || opts.Capable.Flags&header.MPTCPCapableChecksum != 0 {
+			e.mptcpFallbackLocked()
+			return nil
+		}
+		c.setRemoteKey(opts.Capable.SenderKey)
+		return nil
+	}
+
+	if !opts.HasJoin || opts.JoinLength != header.MPTCPJoinSynAckLength {
+		return &tcpip.ErrConnectionAborted{}
+	}
+	sub.remoteNonce = opts.Join.Nonce
+	want := mptcpJoinHMAC(c.remoteKey, c.localKey, sub.remoteNonce, sub.localNonce)
+	if binary.BigEndian.Uint64(want[:]) != opts.Join.TruncatedHMAC {
+		return &tcpip.ErrConnectionAborted{}
+	}
+	mac := mptcpJoinHMAC(c.localKey, c.remoteKey, sub.localNonce, sub.remoteNonce)
+	copy(sub.ackHMAC[:], mac[:])
+	return nil
+}
+
+// mptcpHandleAckLocked processes the Multipath TCP option of the ACK
+// completing the handshake of an endpoint in SYN-RCVD state. An error is
+// returned if the subflow must be reset.
+//
+// +checklocks:e.mu
+func (e *Endpoint) mptcpHandleAckLocked(s *segment) tcpip.Error {
+	sub := e.mptcp
+	if sub == nil {
+		return nil
+	}
+	c := sub.conn
+	opts := s.mptcpOptions
+	if !sub.join {
+		// The keys are either in the third ACK or, if it was lost, in the
+		// first data segment.
+		if !opts.HasCapable || opts.Capable.NumKeys != 2 || opts.Capable.ReceiverKey != c.localKey {
+			e.mptcpFallbackLocked()
+			return nil
+		}
+		c.setRemoteKey(opts.Capable.SenderKey)
+		return nil
+	}
+
+	if !opts.HasJoin || opts.JoinLength != header.MPTCPJoinAckLength {
+		return &tcpip.ErrConnectionAborted{}
+	}
+	want := mptcpJoinHMAC(c.remoteKey, c.localKey, sub.remoteNonce, sub.localNonce)
+	if subtle.ConstantTimeCompare(want[:header.MPTCPJoinHMACSize], opts.Join.HMAC[:]) != 1 {
+		return &tcpip.ErrConnectionAborted{}
+	}
+	return nil
+}
+
+// mptcpEstablishedLocked is called once the handshake of a subflow completed.
+//
+// +checklocks:e.mu
+func (e *Endpoint) mptcpEstablishedLocked(iss, irs seqnum.Value) {
+	sub := e.mptcp
+	if sub == nil {
+		return
+	}
+	sub.iss, sub.irs = iss, irs
+	c := sub.conn
+	switch {
+	case !sub.join:
+		sub.ready = true
+		c.addSubflow(e)
+		e.sndQueueInfo.sndQueueMu.Lock()
+		e.sndQueueInfo.mptcp = c
+		e.sndQueueInfo.sndQueueMu.Unlock()
+		if sub.active {
+			netProto := e.route.NetProto()
+			nicID := e.route.NICID()
+			local := e.TransportEndpointInfo.ID.LocalAddress
+			remote := tcpip.FullAddress{
+				Addr: e.TransportEndpointInfo.ID.RemoteAddress,
+				Port: e.TransportEndpointInfo.ID.RemotePort,
+			}
+			e.stack.Clock().AfterFunc(0, func() {
+				c.createSubflows(netProto, nicID, local, remote)
+			})
+		}
+	case !sub.active:
+		// The fourth ACK of the MP_JOIN handshake tells the peer that the
+		// subflow is ready.
+		sub.ready = true
+		c.addSubflow(e)
+		e.snd.sendAck()
+	}
+}
+
+// createSubflows is the path manager of connections opened by this end. It
+// joins a subflow from each other local address of the same family as the
+// initial subflow which can reach the peer, up to mptcpMaxSubflows subflows.
+// Loopback addresses are only used to reach loopback peers.
+func (c *mptcpConn) createSubflows(netProto tcpip.NetworkProtocolNumber, nicID tcpip.NICID, local tcpip.Address, remote tcpip.FullAddress) {
+	st := c.primary.stack
+	nics := st.NICInfo()
+	loopback := nics[nicID].Flags.Loopback
+	var addrs []tcpip.Address
+	for id, protocolAddrs := range st.AllAddresses() {
+		if nics[id].Flags.Loopback != loopback {
+			continue
+		}
+		for _, pa := range protocolAddrs {
+			addr := pa.AddressWithPrefix.Address
+			if pa.Protocol != netProto || addr == local || !mptcpSubflowAddress(addr) {
+				continue
+			}
+			addrs = append(addrs, addr)
+		}
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return bytes.Compare(addrs[i].AsSlice(), addrs[j].AsSlice()) < 0
+	})
+	for _, addr := range addrs {
+		r, err := st.FindRoute(0, addr, remote.Addr, netProto, false /* multicastLoop */)
+		if err != nil {
+			continue
+		}
+		r.Release()
+		if !c.joinSubflow(netProto, addr, remote) {
+			return
+		}
+	}
+}
+
+// mptcpSubflowAddress returns true if subflows can be opened from the local
+// address addr.
+func mptcpSubflowAddress(addr tcpip.Address) bool {
+	switch addr.Len() {
+	case header.IPv4AddressSize:
+		return addr != header.IPv4Broadcast && !header.IsV4MulticastAddress(addr)
+	case header.IPv6AddressSize:
+		return !header.IsV6MulticastAddress(addr) && !header.IsV6LinkLocalUnicastAddress(addr)
+	}
+	return false
+}
+
+// joinSubflow opens a subflow of the connection from the local address addr.
+// It returns false if no more subflows can join the connection.
+func (c *mptcpConn) joinSubflow(netProto tcpip.NetworkProtocolNumber, addr tcpip.Address, remote tcpip.FullAddress) bool {
+	p := c.primary
+	ep := newEndpoint(p.stack, p.protocol, netProto, p.waiterQueue)
+	ep.LockUser()
+	ep.owner = p.owner
+	if !c.addJoin(ep) {
+		ep.UnlockUser()
+		ep.Close()
+		return false
+	}
+	c.mu.Lock()
+	addrID := c.nextAddrID
+	c.nextAddrID++
+	c.mu.Unlock()
+	rng := p.stack.SecureRNG()
+	ep.mptcp = &mptcpSubflow{
+		conn:       c,
+		join:       true,
+		active:     true,
+		addrID:     addrID,
+		localNonce: rng.Uint32(),
+	}
+	ep.UnlockUser()
+
+	if err := ep.Bind(tcpip.FullAddress{Addr: addr}); err != nil {
+		c.removeSubflow(ep)
+		ep.Close()
+		return true
+	}
+	switch err := ep.Connect(remote); err.(type) {
+	case nil, *tcpip.ErrConnectStarted:
+	default:
+		c.removeSubflow(ep)
+		ep.Close()
+	}
+	return true
+}
+
+// mptcpOptionsLocked encodes into b the Multipath TCP options of a segment
+// sent on an established subflow, padded to a multiple of four bytes, and
+// returns their length. dsn is the data sequence number of the payload.
+//
+// +checklocks:e.mu
+// +checklocksalias:e.snd.ep.mu=e.mu
+func (e *Endpoint) mptcpOptionsLocked(b []byte, flags header.TCPFlags, seq seqnum.Value, payloadLen int, dsn uint64) int {
+	sub := e.mptcp
+	if e.snd == nil || flags&header.TCPFlagRst != 0 {
+		return 0
+	}
+	c := sub.conn
+	switch {
+	case sub.join && !sub.ready:
+		// Until the peer acknowledges it, the third ACK is repeated.
+		return header.EncodeMPTCPJoinAckOption(header.MPTCPJoin{HMAC: sub.ackHMAC}, b)
+	case !sub.join && sub.active && !sub.capableAcked:
+		capable := header.MPTCPCapable{
+			Version:     header.MPTCPVersion,
+			Flags:       header.MPTCPCapableHMACSHA256,
+			NumKeys:     2,
+			SenderKey:   c.localKey,
+			ReceiverKey: c.remoteKey,
+		}
+		if payloadLen == 0 {
+			return header.EncodeMPTCPCapableOption(capable, b)
+		}
+		// The first data segment carries the keys again with its
+		// data-level length, in case the third ACK was lost.
+		if dsn == c.localIDSN+1 && seq == sub.iss+1 {
+			capable.HasDataLen = true
+			capable.DataLen = uint16(payloadLen)
+			n := header.EncodeNOP(b)
+			n += header.EncodeNOP(b[n:])
+			return n + header.EncodeMPTCPCapableOption(capable, b[n:])
+		}
+	}
+
+	dss := header.MPTCPDSS{
+		HasAck: true,
+		Ack:    c.rcvAck.Load(),
+	}
+	switch {
+	case payloadLen > 0 && e.snd.SndUna.LessThan(seq.Add(seqnum.Size(payloadLen))):
+		dss.HasMapping = true
+		dss.DSN = dsn
+		dss.SubflowSeq = uint32(seq - sub.iss)
+		dss.DataLen = uint16(payloadLen)
+	case flags&header.TCPFlagFin != 0 && c.sndClosed.Load():
+		// The DATA_FIN is not mapped to subflow data.
+		dss.HasMapping = true
+		dss.DSN = c.dataFin.Load()
+		dss.DataLen = 1
+		dss.DataFin = true
+	}
+	n := 0
+	if dss.HasMapping {
+		n += header.EncodeNOP(b)
+		n += header.EncodeNOP(b[n:])
+	}
+	return n + header.EncodeMPTCPDSSOption(dss, b[n:])
+}
+
+// mptcpHandleSegmentLocked processes the Multipath TCP options of a segment
+// received on an established subflow, and sets the data sequence number of
+// its payload. An error is returned if the subflow must be reset.
+//
+// +checklocks:e.mu
+// +checklocksalias:e.rcv.ep.mu=e.mu
+func (e *Endpoint) mptcpHandleSegmentLocked(s *segment) tcpip.Error {
+	sub := e.mptcp
+	c := sub.conn
+	if sub.join && !sub.ready {
+		sub.ready = true
+		c.addSubflow(e)
+	}
+
+	opts := s.mptcpOptions
+	switch {
+	case opts.HasDSS:
+		sub.capableAcked = true
+		dss := opts.DSS
+		if dss.HasAck {
+			c.updateSndUna(dss.Ack, dss.Ack64)
+		}
+		if dss.HasMapping {
+			dsn := dss.DSN
+			if !dss.DSN64 {
+				dsn = expandDSN(uint32(dsn), c.rcvAck.Load())
+			}
+			dataLen := seqnum.Size(dss.DataLen)
+			if dss.DataFin && dataLen > 0 {
+				// The DATA_FIN occupies the last byte of the mapping.
+				dataLen--
+				c.receivedDataFin(dsn + uint64(dataLen))
+			}
+			if dataLen > 0 {
+				sub.setMapping(dsn, sub.irs.Add(seqnum.Size(dss.SubflowSeq)), dataLen)
+			}
+		}
+	case opts.HasCapable && opts.Capable.HasDataLen && !sub.join:
+		// The first data of the peer on the initial subflow.
+		sub.setMapping(c.remoteIDSN+1, sub.irs+1, seqnum.Size(opts.Capable.DataLen))
+	}
+
+	n := seqnum.Size(s.payloadSize())
+	if n == 0 {
+		return nil
+	}
+	end := s.sequenceNumber.Add(n)
+	if end.LessThanEq(e.rcv.RcvNxt) {
+		// Already received data needs no mapping.
+		return nil
+	}
+	if sub.hasMapping && sub.mapSeq.LessThanEq(s.sequenceNumber) && end.LessThanEq(sub.mapSeq.Add(sub.mapLen)) {
+		s.dsn = sub.mapDSN + uint64(sub.mapSeq.Size(s.sequenceNumber))
+		return nil
+	}
+	// Data without a mapping before any mapping was received means that
+	// the peer or a middlebox doesn't do Multipath TCP after all. This is
+	// only recoverable while there is a single subflow.
+	if !sub.join && !sub.hasMapping && len(c.readySubflows()) == 1 {
+		e.mptcpFallbackLocked()
+		return nil
+	}
+	return &tcpip.ErrConnectionAborted{}
+}
+
+// setMapping records a data sequence mapping received on the subflow.
+func (sub *mptcpSubflow) setMapping(dsn uint64, seq seqnum.Value, n seqnum.Size) {
+	sub.hasMapping = true
+	sub.mapDSN = dsn
+	sub.mapSeq = seq
+	sub.mapLen = n
+}
+
+// receivedDataFin records the DATA_FIN received from the peer.
+func (c *mptcpConn) receivedDataFin(dsn uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.rcvFin {
+		c.rcvFin = true
+		c.rcvFinDSN = dsn
+	}
+}
+
+// mptcpReadyToRead is readyToRead for the subflows of a Multipath TCP
+// connection: the data of all subflows is put in data sequence order before
+// it is made available to the primary endpoint.
+//
+// +checklocks:e.mu
+func (e *Endpoint) mptcpReadyToRead(s *segment) {
+	c := e.mptcp.conn
+	p := c.primary
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return
+	}
+	if s != nil {
+		c.insertLocked(s)
+	} else {
+		e.mptcp.finRcvd = true
+	}
+	c.deliverLocked()
+	c.mu.Unlock()
+	p.waiterQueue.Notify(waiter.ReadableEvents)
+}
+
+// insertLocked adds a segment received on any subflow to the out-of-order
+// queue, unless its data was already received.
+//
+// +checklocks:c.mu
+func (c *mptcpConn) insertLocked(s *segment) {
+	if end := s.dsn + uint64(s.payloadSize()); int64(end-c.rcvNxt) <= 0 {
+		return
+	}
+	s.IncRef()
+	q := &c.oooQueue
+	for o := q.Back(); o != nil; o = o.Prev() {
+		if int64(o.dsn-s.dsn) <= 0 {
+			q.InsertAfter(o, s)
+			return
+		}
+	}
+	q.PushFront(s)
+}
+
+// deliverLocked moves the data received in order from the out-of-order queue
+// to the ready queue, and processes the DATA_FIN once all data before it was
+// received.
+//
+// +checklocks:c.mu
+func (c *mptcpConn) deliverLocked() {
+	p := c.primary
+	p.rcvQueueMu.Lock()
+	defer p.rcvQueueMu.Unlock()
+	for s := c.oooQueue.Front(); s != nil; s = c.oooQueue.Front() {
+		if int64(s.dsn-c.rcvNxt) > 0 {
+			break
+		}
+		c.oooQueue.Remove(s)
+		end := s.dsn + uint64(s.payloadSize())
+		if int64(end-c.rcvNxt) <= 0 {
+			s.DecRef()
+			continue
+		}
+		if diff := c.rcvNxt - s.dsn; diff > 0 {
+			s.TrimFront(seqnum.Size(diff))
+			s.dsn += diff
+		}
+		c.rcvNxt = end
+		p.RcvBufUsed += s.payloadSize()
+		c.readyQueue.PushBack(s)
+	}
+	if c.rcvFin && c.rcvNxt == c.rcvFinDSN {
+		c.rcvNxt++
+		p.RcvClosed = true
+	}
+	c.rcvAck.Store(c.rcvNxt)
+
+	// The peer closing all the subflows also ends the data stream.
+	if len(c.subflows) != 0 {
+		allClosed := true
+		for _, ep := range c.subflows {
+			if !ep.mptcp.finRcvd { // +checklocksignore
+				allClosed = false
+				break
+			}
+		}
+		if allClosed {
+			p.RcvClosed = true
+		}
+	}
+}
+
+// mptcpSpliceLocked moves the data received in order by the connection to the
+// receive queue of the primary endpoint e.
+//
+// +checklocks:e.mu
+func (e *Endpoint) mptcpSpliceLocked() {
+	c := e.mptcp.conn
+	c.mu.Lock()
+	e.rcvQueue.PushBackList(&c.readyQueue)
+	c.mu.Unlock()
+}
+
+// mptcpUpdateWindowsLocked sends window updates on the subflows other than
+// the primary endpoint e whose window was closed by data now read.
+//
+// +checklocks:e.mu
+func (e *Endpoint) mptcpUpdateWindowsLocked() {
+	for _, sub := range e.mptcp.conn.readySubflows() {
+		if sub == e {
+			continue
+		}
+		sub.LockUser()
+		if sub.EndpointState().connected() && sub.rcv.currentWindow() < seqnum.Size(sub.amss) && sub.selectWindow() >= seqnum.Size(sub.amss) {
+			sub.rcv.nonZeroWindow() // +checklocksforce:sub.rcv.ep.mu
+		}
+		sub.UnlockUser()
+	}
+}
+
+// mptcpSelectSubflowLocked picks the subflow on which data written to the
+// primary endpoint e is sent: the one with the lowest smoothed round-trip time
+// among those with room in their send buffer, the primary endpoint on ties. It
+// returns nil if the data is sent on e.
+//
+// +checklocks:e.mu
+func (e *Endpoint) mptcpSelectSubflowLocked() *Endpoint {
+	subflows := e.mptcp.conn.readySubflows()
+	if len(subflows) < 2 {
+		return nil
+	}
+	var best *Endpoint
+	var bestRTT time.Duration
+	for _, sub := range subflows {
+		space, srtt := sub.mptcpSendState()
+		if space <= 0 {
+			continue
+		}
+		if best == nil || srtt < bestRTT {
+			best, bestRTT = sub, srtt
+		}
+	}
+	if best == e {
+		return nil
+	}
+	return best
+}
+
+// mptcpSendState returns the room in the send buffer of the endpoint of a
+// subflow and its smoothed round-trip time.
+func (e *Endpoint) mptcpSendState() (int, time.Duration) {
+	if e.EndpointState() != StateEstablished {
+		return 0, 0
+	}
+	e.sndQueueInfo.sndQueueMu.Lock()
+	space := e.getSendBufferSize() - e.sndQueueInfo.SndBufUsed
+	if e.sndQueueInfo.SndClosed {
+		space = 0
+	}
+	e.sndQueueInfo.sndQueueMu.Unlock()
+
+	// The sender is set before the subflow is ready.
+	snd := e.snd // +checklocksignore
+	snd.rtt.Lock()
+	srtt := snd.rtt.TCPRTTState.SRTT
+	snd.rtt.Unlock()
+	return space, srtt
+}
+
+// writable returns true if a subflow other than the primary endpoint p has
+// room in its send buffer.
+func (c *mptcpConn) writable(p *Endpoint) bool {
+	for _, sub := range c.readySubflows() {
+		if sub == p {
+			continue
+		}
+		if space, _ := sub.mptcpSendState(); space > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// mptcpWriteSubflowLocked queues the data written to the primary endpoint e on
+// the subflow sub. It returns false if the data must be queued on e instead.
+//
+// +checklocks:e.mu
+func (e *Endpoint) mptcpWriteSubflowLocked(sub *Endpoint, p tcpip.Payloader, opts tcpip.WriteOptions) (int64, bool, tcpip.Error) {
+	sub.LockUser()
+	defer sub.UnlockUser()
+	// Don't release the lock while copying, so that nothing is read from
+	// p if the subflow can't take the data.
+	opts.Atomic = true
+	// Sends requested with MSG_ZEROCOPY are completed by e.
+	opts.ZeroCopy = false
+	seg, n, err := sub.queueSegment(p, opts) // +checklocksforce:sub.snd.ep.mu
+	if err != nil {
+		if _, ok := err.(*tcpip.ErrBadBuffer); ok {
+			return 0, true, err
+		}
+		return 0, false, nil
+	}
+	if n == 0 {
+		return 0, true, nil
+	}
+	sub.sendData(seg) // +checklocksforce:sub.snd.ep.mu
+	return int64(n), true, nil
+}
+
+// mptcpReinjection is data of a subflow being reset which is sent again on the
+// primary endpoint.
+type mptcpReinjection struct {
+	dsn uint64
+	buf buffer.Buffer
+}
+
+// mptcpReinjectLocked hands the data queued on the endpoint of a joined
+// subflow being reset, and not acknowledged at the data level, over to the
+// primary endpoint.
+//
+// +checklocks:e.mu
+// +checklocksalias:e.snd.ep.mu=e.mu
+func (e *Endpoint) mptcpReinjectLocked() {
+	c := e.mptcp.conn
+	una := c.sndUna.Load()
+	var data []mptcpReinjection
+	for s := e.snd.writeList.Front(); s != nil; s = s.Next() {
+		n := uint64(s.payloadSize())
+		if n == 0 || int64(s.dsn+n-una) <= 0 {
+			continue
+		}
+		data = append(data, mptcpReinjection{dsn: s.dsn, buf: s.pkt.Data().ToBuffer()})
+	}
+	if len(data) == 0 {
+		return
+	}
+	// The primary endpoint can't be locked while holding e.mu.
+	e.stack.Clock().AfterFunc(0, func() {
+		c.reinject(data)
+	})
+}
+
+// reinject queues data of a subflow which was reset on the primary endpoint.
+func (c *mptcpConn) reinject(data []mptcpReinjection) {
+	p := c.primary
+	p.LockUser()
+	defer p.UnlockUser()
+	for _, r := range data {
+		if p.mptcp == nil || p.EndpointState() != StateEstablished {
+			r.buf.Release()
+			continue
+		}
+		p.sndQueueInfo.sndQueueMu.Lock()
+		if p.sndQueueInfo.SndClosed {
+			p.sndQueueInfo.sndQueueMu.Unlock()
+			r.buf.Release()
+			continue
+		}
+		s := newOutgoingSegment(p.TransportEndpointInfo.ID, p.stack.Clock(), r.buf)
+		s.dsn = r.dsn
+		p.sndQueueInfo.SndBufUsed += s.payloadSize()
+		p.snd.writeList.PushBack(s)
+		p.sndQueueInfo.sndQueueMu.Unlock()
+		p.sendData(s)
+	}
+}
+
+// mptcpShutdownSubflowsLocked sends the DATA_FIN on the subflows other than the
+// primary endpoint e, once it was shut down for writing.
+//
+// +checklocks:e.mu
+func (e *Endpoint) mptcpShutdownSubflowsLocked() {
+	for _, sub := range e.mptcp.conn.readySubflows() {
+		if sub != e {
+			sub.Shutdown(tcpip.ShutdownWrite)
+		}
+	}
+}
+
+// mptcpFallbackLocked turns the initial subflow of a Multipath TCP connection
+// into a plain TCP connection.
+//
+// +checklocks:e.mu
+func (e *Endpoint) mptcpFallbackLocked() {
+	c := e.mptcp.conn
+	e.mptcpSpliceLocked()
+	e.mptcp = nil
+	e.sndQueueInfo.sndQueueMu.Lock()
+	e.sndQueueInfo.mptcp = nil
+	e.sndQueueInfo.sndQueueMu.Unlock()
+	c.close(true /* abort */)
+}
+
+// mptcpCleanupLocked is called when the endpoint of a subflow releases its
+// resources.
+//
+// +checklocks:e.mu
+func (e *Endpoint) mptcpCleanupLocked() {
+	c := e.mptcp.conn
+	if e == c.primary {
+		// No subflow can join the connection anymore.
+		e.protocol.unregisterMPTCPConn(c)
+		return
+	}
+	c.removeSubflow(e)
+}
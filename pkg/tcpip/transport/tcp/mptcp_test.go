@@ -0,0 +1,301 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcp
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/link/loopback"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+	"gvisor.dev/gvisor/pkg/tcpip/testutil"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+const (
+	mptcpTestNICID   = 1
+	mptcpTestPort    = 5000
+	mptcpTestTimeout = 10 * time.Second
+)
+
+var (
+	mptcpTestAddr1 = testutil.MustParse4("127.0.0.1")
+	mptcpTestAddr2 = testutil.MustParse4("127.0.0.2")
+)
+
+// newMPTCPTestStack returns a stack with a loopback NIC with two addresses,
+// so that Multipath TCP connections can have two subflows.
+func newMPTCPTestStack(t *testing.T) *stack.Stack {
+	s := stack.New(stack.Options{
+		NetworkProtocols:   []stack.NetworkProtocolFactory{ipv4.NewProtocol},
+		TransportProtocols: []stack.TransportProtocolFactory{NewProtocol},
+	})
+	if err := s.CreateNIC(mptcpTestNICID, loopback.New()); err != nil {
+		t.Fatalf("CreateNIC(%d, _): %s", mptcpTestNICID, err)
+	}
+	for _, addr := range []tcpip.Address{mptcpTestAddr1, mptcpTestAddr2} {
+		protocolAddr := tcpip.ProtocolAddress{
+			Protocol:          ipv4.ProtocolNumber,
+			AddressWithPrefix: tcpip.AddressWithPrefix{Address: addr, PrefixLen: 8},
+		}
+		if err := s.AddProtocolAddress(mptcpTestNICID, protocolAddr, stack.AddressProperties{}); err != nil {
+			t.Fatalf("AddProtocolAddress(%d, %+v, {}): %s", mptcpTestNICID, protocolAddr, err)
+		}
+	}
+	subnet := tcpip.AddressWithPrefix{Address: mptcpTestAddr1, PrefixLen: 8}.Subnet()
+	s.SetRouteTable([]tcpip.Route{{Destination: subnet, NIC: mptcpTestNICID}})
+	t.Cleanup(func() {
+		s.Close()
+		s.Wait()
+		s.Destroy()
+	})
+	return s
+}
+
+type mptcpTestEndpoint struct {
+	*Endpoint
+	wq *waiter.Queue
+}
+
+func newMPTCPTestEndpoint(t *testing.T, s *stack.Stack, mptcp bool) mptcpTestEndpoint {
+	t.Helper()
+	wq := &waiter.Queue{}
+	ep, err := s.NewEndpoint(ProtocolNumber, ipv4.ProtocolNumber, wq)
+	if err != nil {
+		t.Fatalf("NewEndpoint: %s", err)
+	}
+	if mptcp {
+		ep.(*Endpoint).EnableMPTCP()
+	}
+	t.Cleanup(ep.Close)
+	return mptcpTestEndpoint{ep.(*Endpoint), wq}
+}
+
+// wait blocks until one of the events of mask is ready on ep. It returns
+// false on timeout.
+func (ep mptcpTestEndpoint) wait(mask waiter.EventMask) bool {
+	we, ch := waiter.NewChannelEntry(mask)
+	ep.wq.EventRegister(&we)
+	defer ep.wq.EventUnregister(&we)
+	if ep.Readiness(mask) != 0 {
+		return true
+	}
+	select {
+	case <-ch:
+		return true
+	case <-time.After(mptcpTestTimeout):
+		return false
+	}
+}
+
+// conn returns the Multipath TCP connection of ep, if it didn't fall back to
+// TCP.
+func (ep mptcpTestEndpoint) conn() *mptcpConn {
+	ep.LockUser()
+	defer ep.UnlockUser()
+	if ep.mptcp == nil {
+		return nil
+	}
+	return ep.mptcp.conn
+}
+
+// connectMPTCPTest connects a client endpoint to a listening endpoint, each of
+// them a Multipath TCP socket or not, and returns the client endpoint and the
+// accepted one.
+func connectMPTCPTest(t *testing.T, s *stack.Stack, clientMPTCP, serverMPTCP bool) (client, server mptcpTestEndpoint) {
+	t.Helper()
+	listener := newMPTCPTestEndpoint(t, s, serverMPTCP)
+	if err := listener.Bind(tcpip.FullAddress{Port: mptcpTestPort}); err != nil {
+		t.Fatalf("Bind: %s", err)
+	}
+	if err := listener.Listen(10); err != nil {
+		t.Fatalf("Listen: %s", err)
+	}
+
+	client = newMPTCPTestEndpoint(t, s, clientMPTCP)
+	switch err := client.Connect(tcpip.FullAddress{Addr: mptcpTestAddr1, Port: mptcpTestPort}); err.(type) {
+	case nil, *tcpip.ErrConnectStarted:
+	default:
+		t.Fatalf("Connect: %s", err)
+	}
+	if !client.wait(waiter.WritableEvents) {
+		t.Fatalf("timed out connecting")
+	}
+	if err := client.LastError(); err != nil {
+		t.Fatalf("Connect: %s", err)
+	}
+
+	if !listener.wait(waiter.ReadableEvents) {
+		t.Fatalf("timed out accepting")
+	}
+	ep, wq, err := listener.Accept(nil)
+	if err != nil {
+		t.Fatalf("Accept: %s", err)
+	}
+	t.Cleanup(ep.Close)
+	return client, mptcpTestEndpoint{ep.(*Endpoint), wq}
+}
+
+// waitSubflows waits until the Multipath TCP connection c has n subflows.
+func waitSubflows(t *testing.T, c *mptcpConn, n int) []*Endpoint {
+	t.Helper()
+	deadline := time.Now().Add(mptcpTestTimeout)
+	for {
+		subflows := c.readySubflows()
+		if len(subflows) == n {
+			return subflows
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("got %d subflows, want %d", len(subflows), n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// writeAll writes data to ep and shuts it down for writing.
+func writeAll(ep mptcpTestEndpoint, data []byte) error {
+	var r bytes.Reader
+	r.Reset(data)
+	for r.Len() > 0 {
+		_, err := ep.Write(&r, tcpip.WriteOptions{})
+		switch err.(type) {
+		case nil:
+		case *tcpip.ErrWouldBlock:
+			if !ep.wait(waiter.WritableEvents) {
+				return fmt.Errorf("timed out writing")
+			}
+		default:
+			return fmt.Errorf("Write: %s", err)
+		}
+	}
+	if err := ep.Shutdown(tcpip.ShutdownWrite); err != nil {
+		return fmt.Errorf("Shutdown: %s", err)
+	}
+	return nil
+}
+
+// readAll reads from ep until EOF.
+func readAll(ep mptcpTestEndpoint) ([]byte, error) {
+	var buf bytes.Buffer
+	for {
+		_, err := ep.Read(&buf, tcpip.ReadOptions{})
+		switch err.(type) {
+		case nil:
+		case *tcpip.ErrWouldBlock:
+			if !ep.wait(waiter.ReadableEvents) {
+				return nil, fmt.Errorf("timed out reading")
+			}
+		case *tcpip.ErrClosedForReceive:
+			return buf.Bytes(), nil
+		default:
+			return nil, fmt.Errorf("Read: %s", err)
+		}
+	}
+}
+
+func TestMPTCPTransfer(t *testing.T) {
+	s := newMPTCPTestStack(t)
+	client, server := connectMPTCPTest(t, s, true /* clientMPTCP */, true /* serverMPTCP */)
+	clientConn, serverConn := client.conn(), server.conn()
+	if clientConn == nil || serverConn == nil {
+		t.Fatalf("got connections (%v, %v), want Multipath TCP on both ends", clientConn, serverConn)
+	}
+	if clientConn.localToken != serverConn.remoteToken || clientConn.remoteToken != serverConn.localToken {
+		t.Errorf("tokens don't match: client (%#x, %#x), server (%#x, %#x)", clientConn.localToken, clientConn.remoteToken, serverConn.localToken, serverConn.remoteToken)
+	}
+
+	// The path manager joins a subflow from the second address.
+	subflows := waitSubflows(t, clientConn, 2)
+	waitSubflows(t, serverConn, 2)
+	join := subflows[1]
+	if got := join.TransportEndpointInfo.ID.LocalAddress; got != mptcpTestAddr2 {
+		t.Errorf("got joined subflow from %s, want %s", got, mptcpTestAddr2)
+	}
+
+	data := make([]byte, 1<<20)
+	rand.Read(data)
+	writeErr := make(chan error, 1)
+	go func() {
+		writeErr <- writeAll(client, data)
+	}()
+	got, err := readAll(server)
+	if err != nil {
+		t.Fatalf("readAll: %s", err)
+	}
+	if err := <-writeErr; err != nil {
+		t.Fatalf("writeAll: %s", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("got %d bytes, want %d bytes sent", len(got), len(data))
+	}
+	if join.stats.SegmentsSent.Value() == 0 {
+		t.Errorf("no segment sent on the joined subflow")
+	}
+}
+
+func TestMPTCPFallback(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		clientMPTCP bool
+		serverMPTCP bool
+	}{
+		{"PlainServer", true, false},
+		{"PlainClient", false, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newMPTCPTestStack(t)
+			client, server := connectMPTCPTest(t, s, tc.clientMPTCP, tc.serverMPTCP)
+			if c := client.conn(); c != nil {
+				t.Errorf("client uses Multipath TCP, want fallback to TCP")
+			}
+			if c := server.conn(); c != nil {
+				t.Errorf("server uses Multipath TCP, want fallback to TCP")
+			}
+
+			data := []byte("fallback")
+			if err := writeAll(client, data); err != nil {
+				t.Fatalf("writeAll: %s", err)
+			}
+			got, err := readAll(server)
+			if err != nil {
+				t.Fatalf("readAll: %s", err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Errorf("got %q, want %q", got, data)
+			}
+		})
+	}
+}
+
+func TestExpandDSN(t *testing.T) {
+	for _, tc := range []struct {
+		v    uint32
+		ref  uint64
+		want uint64
+	}{
+		{0x10, 0x1_0000_0000, 0x1_0000_0010},
+		{0xffff_fff0, 0x1_0000_0010, 0xffff_fff0},
+		{0x10, 0x1_ffff_fff0, 0x2_0000_0010},
+	} {
+		if got := expandDSN(tc.v, tc.ref); got != tc.want {
+			t.Errorf("expandDSN(%#x, %#x) = %#x, want %#x", tc.v, tc.ref, got, tc.want)
+		}
+	}
+}
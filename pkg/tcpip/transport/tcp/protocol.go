@@ -107,8 +107,22 @@ type protocol struct {
 	maxRTO                     time.Duration
 	maxRetries                 uint32
 	synRetries                 uint8
+	fastOpen                   tcpip.TCPFastOpen
+	fastOpenKey                tcpip.TCPFastOpenKeyOption
 	dispatcher                 dispatcher
 
+	// fastOpenCache holds the TCP Fast Open cookies received from servers,
+	// keyed by server address. It is bounded by maxFastOpenCacheEntries.
+	fastOpenCacheMu sync.Mutex `state:"nosave"`
+	// +checklocks:fastOpenCacheMu
+	fastOpenCache map[tcpip.Address][]byte
+
+	// mptcpConns holds the Multipath TCP connections subflows can join,
+	// keyed by local token.
+	mptcpMu sync.Mutex `state:"nosave"`
+	// +checklocks:mptcpMu
+	mptcpConns map[uint32]*mptcpConn
+
 	// probe, if not nil, will be invoked any time an endpoint receives a
 	// TCP segment.
 	//
@@ -388,6 +402,24 @@ func (p *protocol) SetOption(option tcpip.SettableTransportProtocolOption) tcpip
 		p.mu.Unlock()
 		return nil
 
+	case *tcpip.TCPFastOpen:
+		if *v < 0 {
+			return &tcpip.ErrInvalidOptionValue{}
+		}
+		p.mu.Lock()
+		p.fastOpen = *v
+		p.mu.Unlock()
+		return nil
+
+	case *tcpip.TCPFastOpenKeyOption:
+		if !validFastOpenKey(*v) {
+			return &tcpip.ErrInvalidOptionValue{}
+		}
+		p.mu.Lock()
+		p.fastOpenKey = append(tcpip.TCPFastOpenKeyOption(nil), *v...)
+		p.mu.Unlock()
+		return nil
+
 	default:
 		return &tcpip.ErrUnknownProtocolOption{}
 	}
@@ -492,6 +524,18 @@ func (p *protocol) Option(option tcpip.GettableTransportProtocolOption) tcpip.Er
 		p.mu.RUnlock()
 		return nil
 
+	case *tcpip.TCPFastOpen:
+		p.mu.RLock()
+		*v = p.fastOpen
+		p.mu.RUnlock()
+		return nil
+
+	case *tcpip.TCPFastOpenKeyOption:
+		p.mu.RLock()
+		*v = append(tcpip.TCPFastOpenKeyOption(nil), p.fastOpenKey...)
+		p.mu.RUnlock()
+		return nil
+
 	default:
 		return &tcpip.ErrUnknownProtocolOption{}
 	}
@@ -569,6 +613,10 @@ func newProtocol(s *stack.Stack, cc string, probe TCPProbeFunc) stack.TransportP
 	if n, err := rng.Reader.Read(tsOffsetSecret[:]); err != nil || n != len(tsOffsetSecret) {
 		panic(fmt.Sprintf("Read() failed: %v", err))
 	}
+	fastOpenKey := make(tcpip.TCPFastOpenKeyOption, tcpip.TCPFastOpenKeyLength)
+	if n, err := rng.Reader.Read(fastOpenKey); err != nil || n != len(fastOpenKey) {
+		panic(fmt.Sprintf("Read() failed: %v", err))
+	}
 	p := protocol{
 		stack: s,
 		sendBufferSize: tcpip.TCPSendBufferSizeRangeOption{
@@ -593,6 +641,9 @@ func newProtocol(s *stack.Stack, cc string, probe TCPProbeFunc) stack.TransportP
 		maxRTO:                     MaxRTO,
 		maxRetries:                 MaxRetries,
 		recovery:                   tcpip.TCPRACKLossDetection,
+		fastOpen:                   tcpip.TCPFastOpenClient,
+		fastOpenKey:                fastOpenKey,
+		fastOpenCache:              make(map[tcpip.Address][]byte),
 		seqnumSecret:               seqnumSecret,
 		tsOffsetSecret:             tsOffsetSecret,
 		probe:                      probe,
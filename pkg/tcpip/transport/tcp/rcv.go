@@ -216,6 +216,7 @@ func (r *receiver) consumeSegment(s *segment, segSeq seqnum.Value, segLen seqnum
 			segLen -= diff
 			segSeq.UpdateForward(diff)
 			s.sequenceNumber.UpdateForward(diff)
+			s.dsn += uint64(diff)
 			s.TrimFront(diff)
 		}
 
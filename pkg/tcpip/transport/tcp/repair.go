@@ -0,0 +1,379 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcp
+
+import (
+	"io"
+
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/seqnum"
+)
+
+// This file implements the repair mode of Linux's TCP_REPAIR, which allows a
+// connection to be saved from one endpoint and recreated on another without
+// the peer noticing.
+//
+// In repair mode:
+//   - Connect establishes the connection without a handshake, starting from
+//     the sequence numbers set with TCP_QUEUE_SEQ.
+//   - Write appends data to the queue selected with TCP_REPAIR_QUEUE without
+//     sending it. Data appended to the send queue is sent once the endpoint
+//     leaves repair mode.
+//   - Read peeks at the data of the selected queue.
+//   - Close drops the connection without sending a FIN or a RST.
+
+// setRepairLocked puts the endpoint in or takes it out of repair mode.
+//
+// +checklocks:e.mu
+// +checklocksalias:e.snd.ep.mu=e.mu
+func (e *Endpoint) setRepairLocked(v int) tcpip.Error {
+	// Like Linux, listening endpoints cannot be repaired.
+	if e.EndpointState() == StateListen {
+		return &tcpip.ErrNotPermitted{}
+	}
+
+	switch v {
+	case tcpip.TCPRepairOn:
+		e.repair = true
+		e.repairQueue = tcpip.TCPNoQueue
+
+	case tcpip.TCPRepairOff, tcpip.TCPRepairOffNoWindowProbe:
+		wasRepair := e.repair
+		e.repair = false
+		if !wasRepair || !e.EndpointState().connected() {
+			return nil
+		}
+		// Like Linux, send an out of window ACK so that the peer
+		// replies with its current window.
+		if v == tcpip.TCPRepairOff && e.EndpointState() == StateEstablished {
+			e.snd.sendEmptySegment(header.TCPFlagAck, e.snd.SndUna-1)
+		}
+		// Send the data queued in repair mode.
+		e.sendData(nil)
+
+	default:
+		return &tcpip.ErrInvalidOptionValue{}
+	}
+	return nil
+}
+
+// setRepairQueueLocked selects the queue that is read, written and sequenced
+// in repair mode.
+//
+// +checklocks:e.mu
+func (e *Endpoint) setRepairQueueLocked(v int) tcpip.Error {
+	if !e.repair {
+		return &tcpip.ErrNotPermitted{}
+	}
+	switch v {
+	case tcpip.TCPNoQueue, tcpip.TCPRecvQueue, tcpip.TCPSendQueue:
+		e.repairQueue = v
+		return nil
+	default:
+		return &tcpip.ErrInvalidOptionValue{}
+	}
+}
+
+// setQueueSeqLocked sets the sequence number that the selected queue of a
+// connection made in repair mode starts from.
+//
+// +checklocks:e.mu
+func (e *Endpoint) setQueueSeqLocked(seq seqnum.Value) tcpip.Error {
+	switch e.EndpointState() {
+	case StateInitial, StateBound, StateClose:
+	default:
+		return &tcpip.ErrNotPermitted{}
+	}
+	switch e.repairQueue {
+	case tcpip.TCPSendQueue:
+		e.repairSndNxt = seq
+	case tcpip.TCPRecvQueue:
+		e.repairRcvNxt = seq
+	default:
+		return &tcpip.ErrInvalidOptionValue{}
+	}
+	return nil
+}
+
+// queueSeqLocked returns the sequence number following the data of the
+// selected queue.
+//
+// +checklocks:e.mu
+// +checklocksalias:e.snd.ep.mu=e.mu
+// +checklocksalias:e.rcv.ep.mu=e.mu
+func (e *Endpoint) queueSeqLocked() (seqnum.Value, tcpip.Error) {
+	connected := e.EndpointState().connected()
+	switch e.repairQueue {
+	case tcpip.TCPSendQueue:
+		if !connected {
+			return e.repairSndNxt, nil
+		}
+		seq := e.snd.SndUna
+		for s := e.snd.writeList.Front(); s != nil; s = s.Next() {
+			seq = seq.Add(seqnum.Size(s.payloadSize()))
+		}
+		return seq, nil
+
+	case tcpip.TCPRecvQueue:
+		if !connected {
+			return e.repairRcvNxt, nil
+		}
+		return e.rcv.RcvNxt, nil
+
+	default:
+		return 0, &tcpip.ErrInvalidOptionValue{}
+	}
+}
+
+// connectRepairLocked establishes the connection of an endpoint in repair
+// mode without a handshake. Like Linux, no TCP options are in use until they
+// are restored with TCP_REPAIR_OPTIONS, and the send window is closed until
+// it is restored with TCP_REPAIR_WINDOW or updated by the peer.
+//
+// +checklocks:e.mu
+func (e *Endpoint) connectRepairLocked() {
+	e.TSOffset = e.protocol.tsOffset(e.TransportEndpointInfo.ID.LocalAddress, e.TransportEndpointInfo.ID.RemoteAddress)
+	e.SendTSOk = false
+	e.SACKPermitted = false
+
+	rcvWnd := seqnum.Size(e.initialReceiveWindow())
+	e.snd = newSender(e, e.repairSndNxt-1, e.repairRcvNxt-1, 0 /* sndWnd */, header.TCPDefaultMSS, -1 /* sndWndScale */)
+
+	e.rcvQueueMu.Lock()
+	e.rcv = newReceiver(e, e.repairRcvNxt-1, rcvWnd, 0 /* rcvWndScale */)
+	e.RcvAutoParams.PrevCopiedBytes = int(rcvWnd)
+	e.rcvQueueMu.Unlock()
+
+	e.isConnectNotified = true
+	e.setEndpointState(StateEstablished)
+	e.ops.SetSendBufferSize(e.computeTCPSendBufferSize(), false /* notify */)
+}
+
+// setRepairOptionsLocked restores the TCP options negotiated by a connection
+// made in repair mode.
+//
+// +checklocks:e.mu
+// +checklocksalias:e.snd.ep.mu=e.mu
+// +checklocksalias:e.rcv.ep.mu=e.mu
+func (e *Endpoint) setRepairOptionsLocked(opts tcpip.TCPRepairOptionsOption) tcpip.Error {
+	if !e.repair {
+		return &tcpip.ErrInvalidOptionValue{}
+	}
+	if e.EndpointState() != StateEstablished {
+		return &tcpip.ErrNotPermitted{}
+	}
+
+	var mss uint16
+	sndWndScale, rcvWndScale := e.snd.SndWndScale, e.rcv.RcvWndScale
+	sackPermitted, sendTSOk := e.SACKPermitted, e.SendTSOk
+	for _, opt := range opts {
+		switch opt.Code {
+		case header.TCPOptionMSS:
+			if opt.Value < header.TCPMinimumMSS || opt.Value > header.TCPMaximumMSS {
+				return &tcpip.ErrInvalidOptionValue{}
+			}
+			mss = uint16(opt.Value)
+
+		case header.TCPOptionWS:
+			snd, rcv := opt.Value&0xffff, opt.Value>>16
+			if snd > header.MaxWndScale || rcv > header.MaxWndScale {
+				return &tcpip.ErrInvalidOptionValue{}
+			}
+			sndWndScale, rcvWndScale = uint8(snd), uint8(rcv)
+
+		case header.TCPOptionSACKPermitted:
+			if opt.Value != 0 {
+				return &tcpip.ErrInvalidOptionValue{}
+			}
+			sackPermitted = true
+
+		case header.TCPOptionTS:
+			if opt.Value != 0 {
+				return &tcpip.ErrInvalidOptionValue{}
+			}
+			sendTSOk = true
+		}
+	}
+
+	e.snd.SndWndScale = sndWndScale
+	e.rcv.RcvWndScale = rcvWndScale
+	e.SendTSOk = sendTSOk
+	if e.SACKPermitted != sackPermitted {
+		e.SACKPermitted = sackPermitted
+		e.snd.lr = e.snd.initLossRecovery()
+	}
+	if mss != 0 {
+		e.snd.MaxPayloadSize = int(mss) - e.maxOptionSize()
+		e.snd.updateMaxPayloadSize(int(e.route.MTU()), 0)
+		if e.snd.gso {
+			e.gso.MSS = uint16(e.snd.MaxPayloadSize)
+		}
+		e.scoreboard = NewSACKScoreboard(uint16(e.snd.MaxPayloadSize), e.snd.SndUna-1)
+	}
+	return nil
+}
+
+// repairWindowLocked returns the windows of the connection.
+//
+// +checklocks:e.mu
+// +checklocksalias:e.snd.ep.mu=e.mu
+// +checklocksalias:e.rcv.ep.mu=e.mu
+func (e *Endpoint) repairWindowLocked() (tcpip.TCPRepairWindowOption, tcpip.Error) {
+	if !e.repair {
+		return tcpip.TCPRepairWindowOption{}, &tcpip.ErrNotPermitted{}
+	}
+	if !e.EndpointState().connected() {
+		return tcpip.TCPRepairWindowOption{}, &tcpip.ErrNotConnected{}
+	}
+	return tcpip.TCPRepairWindowOption{
+		SndWL1:    uint32(e.snd.sndWL1),
+		SndWnd:    uint32(e.snd.SndWnd),
+		MaxWindow: uint32(e.snd.maxSndWnd),
+		RcvWnd:    uint32(e.rcv.rcvWnd),
+		RcvWUP:    uint32(e.rcv.rcvWUP),
+	}, nil
+}
+
+// setRepairWindowLocked restores the windows of a connection made in repair
+// mode.
+//
+// +checklocks:e.mu
+// +checklocksalias:e.snd.ep.mu=e.mu
+// +checklocksalias:e.rcv.ep.mu=e.mu
+func (e *Endpoint) setRepairWindowLocked(w tcpip.TCPRepairWindowOption) tcpip.Error {
+	if !e.repair {
+		return &tcpip.ErrNotPermitted{}
+	}
+	if !e.EndpointState().connected() {
+		return &tcpip.ErrNotConnected{}
+	}
+	rcvNxt := e.rcv.RcvNxt
+	if rcvNxt.Add(seqnum.Size(w.RcvWnd)).LessThan(seqnum.Value(w.SndWL1)) || rcvNxt.LessThan(seqnum.Value(w.RcvWUP)) {
+		return &tcpip.ErrInvalidOptionValue{}
+	}
+	e.snd.sndWL1 = seqnum.Value(w.SndWL1)
+	e.snd.SndWnd = seqnum.Size(w.SndWnd)
+	e.snd.maxSndWnd = seqnum.Size(w.MaxWindow)
+	e.rcv.rcvWnd = seqnum.Size(w.RcvWnd)
+	e.rcv.rcvWUP = seqnum.Value(w.RcvWUP)
+	e.rcv.RcvAcc = e.rcv.rcvWUP.Add(e.rcv.rcvWnd)
+	if e.rcv.RcvAcc.LessThan(rcvNxt) {
+		e.rcv.RcvAcc = rcvNxt
+	}
+	return nil
+}
+
+// writeRepairLocked appends the data of p to the selected queue.
+//
+// +checklocks:e.mu
+// +checklocksalias:e.snd.ep.mu=e.mu
+// +checklocksalias:e.rcv.ep.mu=e.mu
+func (e *Endpoint) writeRepairLocked(p tcpip.Payloader, opts tcpip.WriteOptions) (int64, tcpip.Error) {
+	switch e.repairQueue {
+	case tcpip.TCPSendQueue:
+		nextSeg, n, err := e.queueSegment(p, opts)
+		if n == 0 || err != nil {
+			return 0, err
+		}
+		// This only records the next segment to send, as no data is sent
+		// in repair mode.
+		e.sendData(nextSeg)
+		return int64(n), nil
+
+	case tcpip.TCPRecvQueue:
+		if !e.EndpointState().connected() {
+			return 0, &tcpip.ErrClosedForSend{}
+		}
+		var buf buffer.Buffer
+		if _, err := buf.WriteFromReader(p, int64(p.Len())); err != nil {
+			buf.Release()
+			return 0, &tcpip.ErrBadBuffer{}
+		}
+		n := buf.Size()
+		if n == 0 {
+			return 0, nil
+		}
+		s := newOutgoingSegment(e.TransportEndpointInfo.ID, e.stack.Clock(), buf)
+		s.sequenceNumber = e.rcv.RcvNxt
+		e.readyToRead(s)
+		s.DecRef()
+		e.rcv.RcvNxt = e.rcv.RcvNxt.Add(seqnum.Size(n))
+		if e.rcv.RcvAcc.LessThan(e.rcv.RcvNxt) {
+			e.rcv.RcvAcc = e.rcv.RcvNxt
+		}
+		return n, nil
+
+	default:
+		return 0, &tcpip.ErrInvalidOptionValue{}
+	}
+}
+
+// readRepairLocked peeks at the data of the selected queue.
+//
+// +checklocks:e.mu
+// +checklocksalias:e.snd.ep.mu=e.mu
+func (e *Endpoint) readRepairLocked(dst io.Writer, opts tcpip.ReadOptions) (tcpip.ReadResult, tcpip.Error) {
+	if !opts.Peek {
+		return tcpip.ReadResult{}, &tcpip.ErrNotPermitted{}
+	}
+
+	var (
+		done int
+		err  error
+	)
+	switch e.repairQueue {
+	case tcpip.TCPSendQueue:
+		if !e.EndpointState().connected() {
+			return tcpip.ReadResult{}, nil
+		}
+		for s := e.snd.writeList.Front(); s != nil; s = s.Next() {
+			var n int
+			n, err = s.ReadTo(dst, true /* peek */)
+			done += n
+			if err != nil {
+				break
+			}
+		}
+
+	case tcpip.TCPRecvQueue:
+		if err := e.checkReadLocked(); err != nil {
+			return tcpip.ReadResult{}, err
+		}
+		done, err = e.readRcvQueueLocked(dst, true /* peek */)
+
+	default:
+		return tcpip.ReadResult{}, &tcpip.ErrInvalidOptionValue{}
+	}
+
+	if done == 0 && err != nil {
+		return tcpip.ReadResult{}, &tcpip.ErrBadBuffer{}
+	}
+	return tcpip.ReadResult{
+		Count: done,
+		Total: done,
+	}, nil
+}
+
+// closeRepairLocked drops the connection of an endpoint in repair mode
+// without notifying the peer, so that it may live on elsewhere.
+//
+// +checklocks:e.mu
+func (e *Endpoint) closeRepairLocked() {
+	e.purgePendingRcvQueue()
+	e.transitionToStateCloseLocked()
+	e.closeNoShutdownLocked()
+}
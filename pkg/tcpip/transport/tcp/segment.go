@@ -90,6 +90,49 @@ type segment struct {
 
 	// lost indicates if the segment is marked as lost by RACK.
 	lost bool
+
+	// mptcpOptions stores the parsed values from the Multipath TCP options in
+	// the segment.
+	mptcpOptions header.MPTCPOptions
+
+	// dsn is the Multipath TCP data sequence number of the payload.
+	dsn uint64
+
+	// zeroCopy holds the IDs of the sends requested with MSG_ZEROCOPY whose
+	// last byte is in the segment. The sends are complete once the segment
+	// is acknowledged.
+	zeroCopy zeroCopyRange
+}
+
+// zeroCopyRange is a range of IDs of sends requested with MSG_ZEROCOPY.
+//
+// +stateify savable
+type zeroCopyRange struct {
+	valid bool
+	lo    uint32
+	hi    uint32
+
+	// copied is true if the data of any of the sends was copied rather than
+	// referenced in place.
+	copied bool
+}
+
+// add adds id, which follows the IDs in r, to r.
+func (r *zeroCopyRange) add(id uint32, copied bool) {
+	if !r.valid {
+		*r = zeroCopyRange{valid: true, lo: id, hi: id, copied: copied}
+		return
+	}
+	r.hi = id
+	r.copied = r.copied || copied
+}
+
+// merge adds the IDs in oth, which follow the IDs in r, to r.
+func (r *zeroCopyRange) merge(oth zeroCopyRange) {
+	if oth.valid {
+		r.add(oth.lo, oth.copied)
+		r.hi = oth.hi
+	}
 }
 
 func newIncomingSegment(id stack.TransportEndpointID, clock tcpip.Clock, pkt *stack.PacketBuffer) (*segment, error) {
@@ -124,6 +167,7 @@ func newIncomingSegment(id stack.TransportEndpointID, clock tcpip.Clock, pkt *st
 	s.id = id
 	s.options = hdr[header.TCPMinimumSize:]
 	s.parsedOptions = header.ParseTCPOptions(hdr[header.TCPMinimumSize:])
+	s.mptcpOptions = header.ParseMPTCPOptions(hdr[header.TCPMinimumSize:])
 	s.sequenceNumber = seqnum.Value(hdr.SequenceNumber())
 	s.ackNumber = seqnum.Value(hdr.AckNumber())
 	s.flags = hdr.Flags()
@@ -161,6 +205,7 @@ func (s *segment) clone() *segment {
 	t.ep = s.ep
 	t.qFlags = s.qFlags
 	t.dataMemSize = s.dataMemSize
+	t.dsn = s.dsn
 	t.pkt = s.pkt.Clone()
 	return t
 }
@@ -177,6 +222,8 @@ func (s *segment) merge(oth *segment) {
 	s.pkt.Data().Merge(oth.pkt.Data())
 	s.dataMemSize = s.pkt.MemSize()
 	oth.dataMemSize = oth.pkt.MemSize()
+	s.zeroCopy.merge(oth.zeroCopy)
+	oth.zeroCopy = zeroCopyRange{}
 }
 
 // setOwner sets the owning endpoint for this segment. Its required
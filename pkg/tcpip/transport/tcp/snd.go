@@ -111,6 +111,13 @@ type sender struct {
 	// the first segment that was retransmitted due to RTO expiration.
 	firstRetransmittedSegXmitTime tcpip.MonotonicTime
 
+	// sndWL1 is the sequence number of the segment that last updated the
+	// send window, SND.WL1 in RFC 9293 section 3.3.1.
+	sndWL1 seqnum.Value
+
+	// maxSndWnd is the largest send window advertised by the peer.
+	maxSndWnd seqnum.Size
+
 	// zeroWindowProbing is set if the sender is currently probing
 	// for zero receive window.
 	zeroWindowProbing bool `state:"nosave"`
@@ -267,7 +274,9 @@ func newSender(ep *Endpoint, iss, irs seqnum.Value, sndWnd seqnum.Size, mss uint
 			},
 			RTO: 1 * time.Second,
 		},
-		gso: ep.gso.Type != stack.GSONone,
+		sndWL1:    irs,
+		maxSndWnd: sndWnd,
+		gso:       ep.gso.Type != stack.GSONone,
 		writeList: protectedWriteList{
 			set: make(map[*segment]struct{}),
 		},
@@ -690,6 +699,8 @@ func (s *sender) splitSeg(seg *segment, size int) {
 	nSeg := seg.clone()
 	nSeg.pkt.Data().TrimFront(size)
 	nSeg.sequenceNumber.UpdateForward(seqnum.Size(size))
+	nSeg.dsn += uint64(size)
+	nSeg.zeroCopy, seg.zeroCopy = seg.zeroCopy, zeroCopyRange{}
 	s.writeList.InsertAfter(seg, nSeg)
 
 	// The segment being split does not carry PUSH flag because it is
@@ -849,6 +860,12 @@ func (s *sender) maybeSendSegment(seg *segment, limit int, end seqnum.Value) (se
 					nextTooBig = true
 					break
 				}
+				// A Multipath TCP mapping covers contiguous data
+				// only, which reinjected data may not be.
+				if s.ep.mptcp != nil && nSeg.dsn != seg.dsn+uint64(seg.payloadSize()) {
+					nextTooBig = true
+					break
+				}
 				seg.merge(nSeg)
 				s.writeList.Remove(nSeg)
 				nSeg.DecRef()
@@ -1016,7 +1033,7 @@ func (s *sender) sendZeroWindowProbe() {
 		Payload: buffer.MakeWithData(zeroProbeJunk),
 	})
 	defer pkt.DecRef()
-	s.sendSegmentFromPacketBuffer(pkt, header.TCPFlagAck, s.SndUna-1)
+	s.sendSegmentFromPacketBuffer(pkt, header.TCPFlagAck, s.SndUna-1, 0 /* dsn */)
 
 	// Rearm the timer to continue probing.
 	s.resendTimer.enable(s.RTO)
@@ -1079,6 +1096,11 @@ func (s *sender) postXmit(dataSent bool, shouldScheduleProbe bool) {
 // when the send window opens up.
 // +checklocks:s.ep.mu
 func (s *sender) sendData() {
+	// Data queued in repair mode is only sent once the endpoint leaves it.
+	if s.ep.repair {
+		return
+	}
+
 	limit := s.MaxPayloadSize
 	if s.gso {
 		limit = int(s.ep.gso.MaxSize - header.TCPTotalHeaderMaximumSize - 1)
@@ -1597,6 +1619,10 @@ func (s *sender) handleRcvdSegment(rcvdSeg *segment) {
 
 	// Stash away the current window size.
 	s.SndWnd = rcvdSeg.window
+	s.sndWL1 = rcvdSeg.sequenceNumber
+	if s.SndWnd > s.maxSndWnd {
+		s.maxSndWnd = s.SndWnd
+	}
 
 	// Disable zero window probing if remote advertises a non-zero receive
 	// window. This can be with an ACK to the zero window probe (where the
@@ -1669,6 +1695,7 @@ func (s *sender) handleRcvdSegment(rcvdSeg *segment) {
 				prevCount := s.pCount(seg, s.MaxPayloadSize)
 				seg.TrimFront(ackLeft)
 				seg.sequenceNumber.UpdateForward(ackLeft)
+				seg.dsn += uint64(ackLeft)
 				s.Outstanding -= prevCount - s.pCount(seg, s.MaxPayloadSize)
 				break
 			}
@@ -1693,6 +1720,7 @@ func (s *sender) handleRcvdSegment(rcvdSeg *segment) {
 			} else {
 				s.SackedOut -= s.pCount(seg, s.MaxPayloadSize)
 			}
+			s.ep.completeZeroCopyLocked(seg.zeroCopy)
 			seg.DecRef()
 			ackLeft -= datalen
 		}
@@ -1801,7 +1829,7 @@ func (s *sender) sendSegment(seg *segment) tcpip.Error {
 	seg.xmitCount++
 	seg.lost = false
 
-	err := s.sendSegmentFromPacketBuffer(seg.pkt, seg.flags, seg.sequenceNumber)
+	err := s.sendSegmentFromPacketBuffer(seg.pkt, seg.flags, seg.sequenceNumber, seg.dsn)
 
 	// Every time a packet containing data is sent (including a
 	// retransmission), if SACK is enabled and we are retransmitting data
@@ -1821,11 +1849,11 @@ func (s *sender) sendSegment(seg *segment) tcpip.Error {
 }
 
 // sendSegmentFromPacketBuffer sends a new segment containing the given payload,
-// flags and sequence number.
+// flags, sequence number and data sequence number.
 // +checklocks:s.ep.mu
 // +checklocksalias:s.ep.rcv.ep.mu=s.ep.mu
 // +checklocksalias:s.ep.rcv.ep.snd.ep.mu=s.ep.mu
-func (s *sender) sendSegmentFromPacketBuffer(pkt *stack.PacketBuffer, flags header.TCPFlags, seq seqnum.Value) tcpip.Error {
+func (s *sender) sendSegmentFromPacketBuffer(pkt *stack.PacketBuffer, flags header.TCPFlags, seq seqnum.Value, dsn uint64) tcpip.Error {
 	s.LastSendTime = s.ep.stack.Clock().NowMonotonic()
 	if seq == s.RTTMeasureSeqNum {
 		s.RTTMeasureTime = s.LastSendTime
@@ -1841,7 +1869,7 @@ func (s *sender) sendSegmentFromPacketBuffer(pkt *stack.PacketBuffer, flags head
 	pkt = pkt.Clone()
 	defer pkt.DecRef()
 
-	return s.ep.sendRaw(pkt, flags, seq, rcvNxt, rcvWnd)
+	return s.ep.sendRaw(pkt, flags, seq, rcvNxt, rcvWnd, dsn)
 }
 
 // sendEmptySegment sends a new empty segment, flags and sequence number.
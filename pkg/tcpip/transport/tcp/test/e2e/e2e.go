@@ -146,6 +146,15 @@ func SetStackTCPRecovery(t *testing.T, c *context.Context, recovery int) {
 	}
 }
 
+// SetStackTCPFastOpen sets the tcpip.TCPFastOpen option of the context stack to
+// the specified mode.
+func SetStackTCPFastOpen(t *testing.T, c *context.Context, mode tcpip.TCPFastOpen) {
+	t.Helper()
+	if err := c.Stack().SetTransportProtocolOption(header.TCPProtocolNumber, &mode); err != nil {
+		t.Fatalf("c.s.SetTransportProtocolOption(%d, &%T(%d)): %s", header.TCPProtocolNumber, mode, mode, err)
+	}
+}
+
 // SendAndReceiveWithSACK creates a SACK enabled connection w/ RACK enabled if
 // enableRACK is true. It then proceeds to write a large payload and verifies
 // that numPackets were received.
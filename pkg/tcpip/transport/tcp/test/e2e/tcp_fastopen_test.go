@@ -0,0 +1,427 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcp_fastopen_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"gvisor.dev/gvisor/pkg/refs"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/checker"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
+	"gvisor.dev/gvisor/pkg/tcpip/seqnum"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/tcp"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/tcp/test/e2e"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/tcp/testing/context"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+const (
+	// testISS is the initial sequence number used by the test side of
+	// connections.
+	testISS = seqnum.Value(context.TestInitialSequenceNumber)
+
+	// testRcvWnd is the window advertised by the test side of connections.
+	testRcvWnd = 30000
+)
+
+var testData = []byte("fast open data")
+
+// fastOpenOptions returns SYN options carrying a TCP Fast Open option with
+// cookie. An empty cookie requests one.
+func fastOpenOptions(cookie []byte) []byte {
+	opts := make([]byte, header.TCPOptionsMaximumSize)
+	n := header.EncodeMSSOption(e2e.DefaultIPv4MSS, opts)
+	n += header.EncodeFastOpenOption(cookie, opts[n:])
+	for ; n%4 != 0; n++ {
+		opts[n] = header.TCPOptionNOP
+	}
+	return opts[:n]
+}
+
+// listenFastOpen makes c.EP a listening endpoint that accepts data in the SYN.
+func listenFastOpen(t *testing.T, c *context.Context) {
+	t.Helper()
+	e2e.SetStackTCPFastOpen(t, c, tcpip.TCPFastOpenServer)
+	c.Create(-1)
+	if err := c.EP.Bind(tcpip.FullAddress{Port: context.StackPort}); err != nil {
+		t.Fatalf("Bind failed: %s", err)
+	}
+	if err := c.EP.SetSockOptInt(tcpip.TCPFastOpenQueueLenOption, 5); err != nil {
+		t.Fatalf("SetSockOptInt(TCPFastOpenQueueLenOption, 5) failed: %s", err)
+	}
+	if err := c.EP.Listen(10); err != nil {
+		t.Fatalf("Listen failed: %s", err)
+	}
+}
+
+// sendFastOpenSyn sends a SYN from srcPort carrying data and a TCP Fast Open
+// option with cookie to the listening endpoint. It returns the sequence number,
+// acknowledgement number and options of the SYN-ACK.
+func sendFastOpenSyn(t *testing.T, c *context.Context, srcPort uint16, cookie, data []byte) (seqnum.Value, seqnum.Value, header.TCPSynOptions) {
+	t.Helper()
+	c.SendPacket(data, &context.Headers{
+		SrcPort: srcPort,
+		DstPort: context.StackPort,
+		Flags:   header.TCPFlagSyn,
+		SeqNum:  testISS,
+		RcvWnd:  testRcvWnd,
+		TCPOpts: fastOpenOptions(cookie),
+	})
+
+	b := c.GetPacket()
+	defer b.Release()
+	checker.IPv4(t, b, checker.TCP(
+		checker.SrcPort(context.StackPort),
+		checker.DstPort(srcPort),
+		checker.TCPFlags(header.TCPFlagSyn|header.TCPFlagAck),
+	))
+	tcpHdr := header.TCP(header.IPv4(b.AsSlice()).Payload())
+	return seqnum.Value(tcpHdr.SequenceNumber()), seqnum.Value(tcpHdr.AckNumber()), header.ParseSynOptions(tcpHdr.Options(), true /* isAck */)
+}
+
+// requestCookie requests a cookie from the listening endpoint with a SYN from
+// srcPort, and returns it.
+func requestCookie(t *testing.T, c *context.Context, srcPort uint16) []byte {
+	t.Helper()
+	_, ack, opts := sendFastOpenSyn(t, c, srcPort, []byte{}, nil)
+	if want := testISS + 1; ack != want {
+		t.Fatalf("got SYN-ACK ack = %d, want = %d", ack, want)
+	}
+	if !opts.FastOpen || len(opts.FastOpenCookie) == 0 {
+		t.Fatalf("got SYN-ACK options = %+v, want a Fast Open cookie", opts)
+	}
+	return opts.FastOpenCookie
+}
+
+// acceptFastOpen waits for a connection on the listening endpoint.
+func acceptFastOpen(t *testing.T, c *context.Context) tcpip.Endpoint {
+	t.Helper()
+	we, ch := waiter.NewChannelEntry(waiter.ReadableEvents)
+	c.WQ.EventRegister(&we)
+	defer c.WQ.EventUnregister(&we)
+
+	ep, _, err := c.EP.Accept(nil)
+	if _, ok := err.(*tcpip.ErrWouldBlock); ok {
+		select {
+		case <-ch:
+			ep, _, err = c.EP.Accept(nil)
+		case <-time.After(1 * time.Second):
+			t.Fatalf("Timed out waiting for accept")
+		}
+	}
+	if err != nil {
+		t.Fatalf("Accept failed: %s", err)
+	}
+	return ep
+}
+
+// TestFastOpenCookieRequest tests that a listening endpoint replies to a
+// cookie request with a cookie for the client's address.
+func TestFastOpenCookieRequest(t *testing.T) {
+	c := context.New(t, e2e.DefaultMTU)
+	defer c.Cleanup()
+	listenFastOpen(t, c)
+
+	cookie := requestCookie(t, c, context.TestPort)
+	if got, want := len(cookie), 8; got != want {
+		t.Errorf("got cookie length = %d, want = %d", got, want)
+	}
+	// Cookies are specific to the client address, not the connection.
+	if got := requestCookie(t, c, context.TestPort+1); !bytes.Equal(got, cookie) {
+		t.Errorf("got cookie = %x, want = %x", got, cookie)
+	}
+}
+
+// TestFastOpenValidCookie tests that the data in a SYN presenting a valid
+// cookie is acknowledged by the SYN-ACK, and can be read before the handshake
+// completes.
+func TestFastOpenValidCookie(t *testing.T) {
+	c := context.New(t, e2e.DefaultMTU)
+	defer c.Cleanup()
+	listenFastOpen(t, c)
+
+	cookie := requestCookie(t, c, context.TestPort)
+	srcPort := uint16(context.TestPort + 1)
+	irs, ack, opts := sendFastOpenSyn(t, c, srcPort, cookie, testData)
+	if want := testISS.Add(seqnum.Size(len(testData)) + 1); ack != want {
+		t.Errorf("got SYN-ACK ack = %d, want = %d", ack, want)
+	}
+	if opts.FastOpen {
+		t.Errorf("got SYN-ACK options = %+v, want no Fast Open option", opts)
+	}
+
+	ep := acceptFastOpen(t, c)
+	defer ep.Close()
+	if got, want := tcp.EndpointState(ep.State()), tcp.StateSynRecv; got != want {
+		t.Errorf("got accepted endpoint state = %s, want = %s", got, want)
+	}
+	var buf bytes.Buffer
+	if _, err := ep.Read(&buf, tcpip.ReadOptions{}); err != nil {
+		t.Fatalf("Read failed: %s", err)
+	}
+	if diff := cmp.Diff(testData, buf.Bytes()); diff != "" {
+		t.Errorf("data read mismatch (-want +got):\n%s", diff)
+	}
+
+	c.SendPacket(nil, &context.Headers{
+		SrcPort: srcPort,
+		DstPort: context.StackPort,
+		Flags:   header.TCPFlagAck,
+		SeqNum:  ack,
+		AckNum:  irs + 1,
+		RcvWnd:  testRcvWnd,
+	})
+	for start := time.Now(); tcp.EndpointState(ep.State()) != tcp.StateEstablished; time.Sleep(10 * time.Millisecond) {
+		if time.Since(start) > time.Second {
+			t.Fatalf("got accepted endpoint state = %s, want = %s", tcp.EndpointState(ep.State()), tcp.StateEstablished)
+		}
+	}
+}
+
+// TestFastOpenInvalidCookie tests that the data in a SYN presenting an invalid
+// cookie is ignored, and that the SYN-ACK carries a valid cookie.
+func TestFastOpenInvalidCookie(t *testing.T) {
+	c := context.New(t, e2e.DefaultMTU)
+	defer c.Cleanup()
+	listenFastOpen(t, c)
+
+	cookie := requestCookie(t, c, context.TestPort)
+	invalid := bytes.Clone(cookie)
+	invalid[0] ^= 0xff
+	srcPort := uint16(context.TestPort + 1)
+	irs, ack, opts := sendFastOpenSyn(t, c, srcPort, invalid, testData)
+	if want := testISS + 1; ack != want {
+		t.Errorf("got SYN-ACK ack = %d, want = %d", ack, want)
+	}
+	if !opts.FastOpen || !bytes.Equal(opts.FastOpenCookie, cookie) {
+		t.Errorf("got SYN-ACK options = %+v, want Fast Open cookie %x", opts, cookie)
+	}
+
+	// The connection can't be accepted until the handshake completes.
+	if _, _, err := c.EP.Accept(nil); err == nil {
+		t.Fatalf("Accept succeeded before the handshake completed")
+	} else if _, ok := err.(*tcpip.ErrWouldBlock); !ok {
+		t.Fatalf("got Accept error = %s, want = %s", err, &tcpip.ErrWouldBlock{})
+	}
+
+	c.SendPacket(nil, &context.Headers{
+		SrcPort: srcPort,
+		DstPort: context.StackPort,
+		Flags:   header.TCPFlagAck,
+		SeqNum:  testISS + 1,
+		AckNum:  irs + 1,
+		RcvWnd:  testRcvWnd,
+	})
+	ep := acceptFastOpen(t, c)
+	defer ep.Close()
+	var buf bytes.Buffer
+	if _, err := ep.Read(&buf, tcpip.ReadOptions{}); err == nil {
+		t.Errorf("got Read = %x, want no data", buf.Bytes())
+	} else if _, ok := err.(*tcpip.ErrWouldBlock); !ok {
+		t.Errorf("got Read error = %s, want = %s", err, &tcpip.ErrWouldBlock{})
+	}
+}
+
+// getSyn receives a SYN sent by the stack to the test side, and returns its
+// source port, sequence number, options and payload.
+func getSyn(t *testing.T, c *context.Context) (uint16, seqnum.Value, header.TCPSynOptions, []byte) {
+	t.Helper()
+	b := c.GetPacket()
+	defer b.Release()
+	checker.IPv4(t, b, checker.TCP(
+		checker.DstPort(context.TestPort),
+		checker.TCPFlags(header.TCPFlagSyn),
+	))
+	tcpHdr := header.TCP(header.IPv4(b.AsSlice()).Payload())
+	opts := header.ParseSynOptions(tcpHdr.Options(), false /* isAck */)
+	return tcpHdr.SourcePort(), seqnum.Value(tcpHdr.SequenceNumber()), opts, bytes.Clone(tcpHdr.Payload())
+}
+
+// sendSynAck replies to a SYN from the stack's port with a SYN-ACK
+// acknowledging up to ack, with options opts.
+func sendSynAck(c *context.Context, port uint16, ack seqnum.Value, opts []byte) {
+	c.SendPacket(nil, &context.Headers{
+		SrcPort: context.TestPort,
+		DstPort: port,
+		Flags:   header.TCPFlagSyn | header.TCPFlagAck,
+		SeqNum:  testISS,
+		AckNum:  ack,
+		RcvWnd:  testRcvWnd,
+		TCPOpts: opts,
+	})
+}
+
+// newFastOpenEndpoint creates an endpoint with TCP_FASTOPEN_CONNECT set.
+func newFastOpenEndpoint(t *testing.T, c *context.Context) (tcpip.Endpoint, *waiter.Queue) {
+	t.Helper()
+	var wq waiter.Queue
+	ep, err := c.Stack().NewEndpoint(tcp.ProtocolNumber, ipv4.ProtocolNumber, &wq)
+	if err != nil {
+		t.Fatalf("NewEndpoint failed: %s", err)
+	}
+	if err := ep.SetSockOptInt(tcpip.TCPFastOpenConnectOption, 1); err != nil {
+		t.Fatalf("SetSockOptInt(TCPFastOpenConnectOption, 1) failed: %s", err)
+	}
+	return ep, &wq
+}
+
+// cacheCookie makes the stack connect with a cookie request, and replies with
+// cookie. It returns the connected endpoint.
+func cacheCookie(t *testing.T, c *context.Context, cookie []byte) tcpip.Endpoint {
+	t.Helper()
+	ep, _ := newFastOpenEndpoint(t, c)
+	// Without a cookie, the SYN is sent right away with a cookie request.
+	if err := ep.Connect(tcpip.FullAddress{Addr: context.TestAddr, Port: context.TestPort}); err == nil {
+		t.Fatalf("Connect succeeded without a cookie, want %s", &tcpip.ErrConnectStarted{})
+	} else if _, ok := err.(*tcpip.ErrConnectStarted); !ok {
+		t.Fatalf("got Connect error = %s, want = %s", err, &tcpip.ErrConnectStarted{})
+	}
+	port, iss, opts, payload := getSyn(t, c)
+	if !opts.FastOpen || len(opts.FastOpenCookie) != 0 {
+		t.Fatalf("got SYN options = %+v, want a Fast Open cookie request", opts)
+	}
+	if len(payload) != 0 {
+		t.Fatalf("got SYN payload = %x, want none", payload)
+	}
+
+	sendSynAck(c, port, iss+1, fastOpenOptions(cookie))
+	b := c.GetPacket()
+	defer b.Release()
+	checker.IPv4(t, b, checker.TCP(
+		checker.DstPort(context.TestPort),
+		checker.TCPFlags(header.TCPFlagAck),
+		checker.TCPSeqNum(uint32(iss)+1),
+		checker.TCPAckNum(uint32(testISS)+1),
+	))
+	return ep
+}
+
+var testCookie = []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+// TestFastOpenConnect tests that the SYN of a connect with TCP_FASTOPEN_CONNECT
+// carries the cached cookie and the data of the first write.
+func TestFastOpenConnect(t *testing.T) {
+	c := context.New(t, e2e.DefaultMTU)
+	defer c.Cleanup()
+
+	first := cacheCookie(t, c, testCookie)
+	defer first.Close()
+
+	ep, _ := newFastOpenEndpoint(t, c)
+	defer ep.Close()
+	// With a cookie, the SYN is deferred until the first write.
+	if err := ep.Connect(tcpip.FullAddress{Addr: context.TestAddr, Port: context.TestPort}); err != nil {
+		t.Fatalf("Connect failed: %s", err)
+	}
+	c.CheckNoPacket("SYN sent before the first write")
+
+	var r bytes.Reader
+	r.Reset(testData)
+	if n, err := ep.Write(&r, tcpip.WriteOptions{}); err != nil {
+		t.Fatalf("Write failed: %s", err)
+	} else if n != int64(len(testData)) {
+		t.Fatalf("got Write = %d, want = %d", n, len(testData))
+	}
+	port, iss, opts, payload := getSyn(t, c)
+	if !opts.FastOpen || !bytes.Equal(opts.FastOpenCookie, testCookie) {
+		t.Errorf("got SYN options = %+v, want Fast Open cookie %x", opts, testCookie)
+	}
+	if diff := cmp.Diff(testData, payload); diff != "" {
+		t.Errorf("SYN payload mismatch (-want +got):\n%s", diff)
+	}
+
+	sendSynAck(c, port, iss.Add(seqnum.Size(len(testData))+1), nil)
+	b := c.GetPacket()
+	defer b.Release()
+	checker.IPv4(t, b, checker.TCP(
+		checker.DstPort(context.TestPort),
+		checker.TCPFlags(header.TCPFlagAck),
+		checker.TCPSeqNum(uint32(iss)+uint32(len(testData))+1),
+		checker.TCPAckNum(uint32(testISS)+1),
+	))
+}
+
+// TestFastOpenSendTo tests that a write with MSG_FASTOPEN on an unconnected
+// endpoint connects it with the data in the SYN. The SYN-ACK acknowledges only
+// part of the data, so the rest must be sent again after the handshake.
+func TestFastOpenSendTo(t *testing.T) {
+	c := context.New(t, e2e.DefaultMTU)
+	defer c.Cleanup()
+
+	first := cacheCookie(t, c, testCookie)
+	defer first.Close()
+
+	var wq waiter.Queue
+	ep, err := c.Stack().NewEndpoint(tcp.ProtocolNumber, ipv4.ProtocolNumber, &wq)
+	if err != nil {
+		t.Fatalf("NewEndpoint failed: %s", err)
+	}
+	defer ep.Close()
+
+	var r bytes.Reader
+	r.Reset(testData)
+	to := tcpip.FullAddress{Addr: context.TestAddr, Port: context.TestPort}
+	if n, err := ep.Write(&r, tcpip.WriteOptions{FastOpen: true, To: &to}); err != nil {
+		t.Fatalf("Write failed: %s", err)
+	} else if n != int64(len(testData)) {
+		t.Fatalf("got Write = %d, want = %d", n, len(testData))
+	}
+	port, iss, opts, payload := getSyn(t, c)
+	if !opts.FastOpen || !bytes.Equal(opts.FastOpenCookie, testCookie) {
+		t.Errorf("got SYN options = %+v, want Fast Open cookie %x", opts, testCookie)
+	}
+	if diff := cmp.Diff(testData, payload); diff != "" {
+		t.Errorf("SYN payload mismatch (-want +got):\n%s", diff)
+	}
+
+	const acked = 4
+	sendSynAck(c, port, iss.Add(acked+1), nil)
+	// The unacknowledged data is sent again, possibly after a bare ACK of the
+	// SYN-ACK.
+	for {
+		b := c.GetPacket()
+		checker.IPv4(t, b, checker.TCP(
+			checker.DstPort(context.TestPort),
+			checker.TCPFlagsMatch(header.TCPFlagAck, header.TCPFlagAck|header.TCPFlagSyn),
+			checker.TCPSeqNum(uint32(iss)+acked+1),
+			checker.TCPAckNum(uint32(testISS)+1),
+		))
+		payload := bytes.Clone(header.TCP(header.IPv4(b.AsSlice()).Payload()).Payload())
+		b.Release()
+		if len(payload) == 0 {
+			continue
+		}
+		if diff := cmp.Diff(testData[acked:], payload); diff != "" {
+			t.Errorf("requeued data mismatch (-want +got):\n%s", diff)
+		}
+		break
+	}
+}
+
+func TestMain(m *testing.M) {
+	refs.SetLeakMode(refs.LeaksPanic)
+	code := m.Run()
+	// Allow TCP async work to complete to avoid false reports of leaks.
+	// TODO(gvisor.dev/issue/5940): Use fake clock in tests.
+	time.Sleep(1 * time.Second)
+	refs.DoLeakCheck()
+	os.Exit(code)
+}
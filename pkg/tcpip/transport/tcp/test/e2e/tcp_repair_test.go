@@ -0,0 +1,365 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcp_repair_test
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"gvisor.dev/gvisor/pkg/refs"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/checker"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/seqnum"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/tcp"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/tcp/test/e2e"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/tcp/testing/context"
+)
+
+const (
+	// sndSeq is the sequence number the send queue of repaired connections
+	// starts from.
+	sndSeq = seqnum.Value(1000)
+
+	// rcvSeq is the sequence number the receive queue of repaired
+	// connections starts from.
+	rcvSeq = seqnum.Value(context.TestInitialSequenceNumber + 1)
+)
+
+// setQueueSeq sets the sequence number that queue of c.EP starts from.
+func setQueueSeq(t *testing.T, c *context.Context, queue int, seq seqnum.Value) {
+	t.Helper()
+	if err := c.EP.SetSockOptInt(tcpip.TCPRepairQueueOption, queue); err != nil {
+		t.Fatalf("SetSockOptInt(TCPRepairQueueOption, %d) failed: %s", queue, err)
+	}
+	if err := c.EP.SetSockOptInt(tcpip.TCPQueueSeqOption, int(seq)); err != nil {
+		t.Fatalf("SetSockOptInt(TCPQueueSeqOption, %d) failed: %s", seq, err)
+	}
+}
+
+// queueSeq returns the sequence number following the data of queue of c.EP.
+func queueSeq(t *testing.T, c *context.Context, queue int) seqnum.Value {
+	t.Helper()
+	if err := c.EP.SetSockOptInt(tcpip.TCPRepairQueueOption, queue); err != nil {
+		t.Fatalf("SetSockOptInt(TCPRepairQueueOption, %d) failed: %s", queue, err)
+	}
+	v, err := c.EP.GetSockOptInt(tcpip.TCPQueueSeqOption)
+	if err != nil {
+		t.Fatalf("GetSockOptInt(TCPQueueSeqOption) failed: %s", err)
+	}
+	return seqnum.Value(v)
+}
+
+// setRepair puts c.EP in or takes it out of repair mode.
+func setRepair(t *testing.T, c *context.Context, v int) {
+	t.Helper()
+	if err := c.EP.SetSockOptInt(tcpip.TCPRepairOption, v); err != nil {
+		t.Fatalf("SetSockOptInt(TCPRepairOption, %d) failed: %s", v, err)
+	}
+}
+
+// repairConnect makes c.EP a connection established in repair mode, starting
+// from sndSeq and rcvSeq.
+func repairConnect(t *testing.T, c *context.Context) {
+	t.Helper()
+	c.Create(-1)
+	setRepair(t, c, tcpip.TCPRepairOn)
+	setQueueSeq(t, c, tcpip.TCPSendQueue, sndSeq)
+	setQueueSeq(t, c, tcpip.TCPRecvQueue, rcvSeq)
+	if err := c.EP.Bind(tcpip.FullAddress{Port: context.StackPort}); err != nil {
+		t.Fatalf("Bind failed: %s", err)
+	}
+	if err := c.EP.Connect(tcpip.FullAddress{Addr: context.TestAddr, Port: context.TestPort}); err != nil {
+		t.Fatalf("Connect failed: %s", err)
+	}
+}
+
+// repairWindow returns the windows of c.EP.
+func repairWindow(t *testing.T, c *context.Context) tcpip.TCPRepairWindowOption {
+	t.Helper()
+	var w tcpip.TCPRepairWindowOption
+	if err := c.EP.GetSockOpt(&w); err != nil {
+		t.Fatalf("GetSockOpt(&%T) failed: %s", w, err)
+	}
+	return w
+}
+
+// openSendWindow restores a send window of sndWnd bytes on c.EP.
+func openSendWindow(t *testing.T, c *context.Context, sndWnd uint32) {
+	t.Helper()
+	w := repairWindow(t, c)
+	w.SndWnd = sndWnd
+	w.MaxWindow = sndWnd
+	if err := c.EP.SetSockOpt(&w); err != nil {
+		t.Fatalf("SetSockOpt(&%+v) failed: %s", w, err)
+	}
+}
+
+// waitSendWindow waits for the send window of c.EP to be updated to sndWnd by
+// the peer, and returns the windows of c.EP in repair mode.
+func waitSendWindow(t *testing.T, c *context.Context, sndWnd uint32) tcpip.TCPRepairWindowOption {
+	t.Helper()
+	for start := time.Now(); ; time.Sleep(10 * time.Millisecond) {
+		setRepair(t, c, tcpip.TCPRepairOn)
+		w := repairWindow(t, c)
+		if w.SndWnd == sndWnd {
+			return w
+		}
+		if time.Since(start) > time.Second {
+			t.Fatalf("got SndWnd = %d, want = %d", w.SndWnd, sndWnd)
+		}
+		setRepair(t, c, tcpip.TCPRepairOffNoWindowProbe)
+	}
+}
+
+// writeQueue appends data to queue of c.EP.
+func writeQueue(t *testing.T, c *context.Context, queue int, data []byte) {
+	t.Helper()
+	if err := c.EP.SetSockOptInt(tcpip.TCPRepairQueueOption, queue); err != nil {
+		t.Fatalf("SetSockOptInt(TCPRepairQueueOption, %d) failed: %s", queue, err)
+	}
+	var r bytes.Reader
+	r.Reset(data)
+	if n, err := c.EP.Write(&r, tcpip.WriteOptions{}); err != nil {
+		t.Fatalf("Write failed: %s", err)
+	} else if n != int64(len(data)) {
+		t.Fatalf("got Write = %d, want = %d", n, len(data))
+	}
+}
+
+// sendAck sends an ACK from the peer of c.EP.
+func sendAck(c *context.Context, seq, ack seqnum.Value, rcvWnd seqnum.Size, payload []byte) {
+	c.SendPacket(payload, &context.Headers{
+		SrcPort: context.TestPort,
+		DstPort: context.StackPort,
+		Flags:   header.TCPFlagAck,
+		SeqNum:  seq,
+		AckNum:  ack,
+		RcvWnd:  rcvWnd,
+	})
+}
+
+// TestRepairConnect tests that a connect in repair mode establishes the
+// connection silently, and that the connection starts from the restored
+// sequence numbers.
+func TestRepairConnect(t *testing.T) {
+	c := context.New(t, e2e.DefaultMTU)
+	defer c.Cleanup()
+
+	repairConnect(t, c)
+	c.CheckNoPacket("packet sent by a connect in repair mode")
+	if got, want := tcp.EndpointState(c.EP.State()), tcp.StateEstablished; got != want {
+		t.Fatalf("got endpoint state = %s, want = %s", got, want)
+	}
+	if got := queueSeq(t, c, tcpip.TCPSendQueue); got != sndSeq {
+		t.Errorf("got send queue sequence number = %d, want = %d", got, sndSeq)
+	}
+	if got := queueSeq(t, c, tcpip.TCPRecvQueue); got != rcvSeq {
+		t.Errorf("got receive queue sequence number = %d, want = %d", got, rcvSeq)
+	}
+
+	// Leaving repair mode probes the peer's window with an out of window
+	// ACK.
+	setRepair(t, c, tcpip.TCPRepairOff)
+	b := c.GetPacket()
+	defer b.Release()
+	checker.IPv4(t, b, checker.TCP(
+		checker.DstPort(context.TestPort),
+		checker.TCPFlags(header.TCPFlagAck),
+		checker.TCPSeqNum(uint32(sndSeq)-1),
+		checker.TCPAckNum(uint32(rcvSeq)),
+	))
+}
+
+// TestRepairQueueWrite tests that data written to the queues of a connection
+// in repair mode is not sent, and is handled as if it had been exchanged with
+// the peer once the connection leaves repair mode.
+func TestRepairQueueWrite(t *testing.T) {
+	c := context.New(t, e2e.DefaultMTU)
+	defer c.Cleanup()
+
+	repairConnect(t, c)
+	rcvData := []byte("received data")
+	sndData := []byte("sent data")
+	writeQueue(t, c, tcpip.TCPRecvQueue, rcvData)
+	writeQueue(t, c, tcpip.TCPSendQueue, sndData)
+	c.CheckNoPacket("packet sent by a write in repair mode")
+
+	if got, want := queueSeq(t, c, tcpip.TCPRecvQueue), rcvSeq.Add(seqnum.Size(len(rcvData))); got != want {
+		t.Errorf("got receive queue sequence number = %d, want = %d", got, want)
+	}
+	if got, want := queueSeq(t, c, tcpip.TCPSendQueue), sndSeq.Add(seqnum.Size(len(sndData))); got != want {
+		t.Errorf("got send queue sequence number = %d, want = %d", got, want)
+	}
+	var buf bytes.Buffer
+	if _, err := c.EP.Read(&buf, tcpip.ReadOptions{Peek: true}); err != nil {
+		t.Fatalf("Read of the send queue failed: %s", err)
+	}
+	if diff := cmp.Diff(sndData, buf.Bytes()); diff != "" {
+		t.Errorf("send queue mismatch (-want +got):\n%s", diff)
+	}
+
+	openSendWindow(t, c, 30000)
+	setRepair(t, c, tcpip.TCPRepairOffNoWindowProbe)
+	b := c.GetPacket()
+	defer b.Release()
+	checker.IPv4(t, b, checker.TCP(
+		checker.DstPort(context.TestPort),
+		checker.TCPSeqNum(uint32(sndSeq)),
+		checker.TCPAckNum(uint32(rcvSeq)+uint32(len(rcvData))),
+		checker.Payload(sndData),
+	))
+
+	buf.Reset()
+	if _, err := c.EP.Read(&buf, tcpip.ReadOptions{}); err != nil {
+		t.Fatalf("Read failed: %s", err)
+	}
+	if diff := cmp.Diff(rcvData, buf.Bytes()); diff != "" {
+		t.Errorf("received data mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// TestRepairWindow tests that the windows of a connection can be restored in
+// repair mode, and that the saved windows follow the peer's updates.
+func TestRepairWindow(t *testing.T) {
+	c := context.New(t, e2e.DefaultMTU)
+	defer c.Cleanup()
+
+	repairConnect(t, c)
+	w := repairWindow(t, c)
+	if w.SndWnd != 0 {
+		t.Errorf("got restored send window = %d, want = 0", w.SndWnd)
+	}
+
+	// The receive window can't have been advertised past the data
+	// received.
+	invalid := w
+	invalid.RcvWUP = uint32(rcvSeq) + 1
+	if err := c.EP.SetSockOpt(&invalid); err == nil {
+		t.Errorf("SetSockOpt(&%+v) succeeded, want %s", invalid, &tcpip.ErrInvalidOptionValue{})
+	}
+
+	want := tcpip.TCPRepairWindowOption{
+		SndWL1:    uint32(rcvSeq) - 10,
+		SndWnd:    5000,
+		MaxWindow: 10000,
+		RcvWnd:    w.RcvWnd / 2,
+		RcvWUP:    uint32(rcvSeq) - 20,
+	}
+	if err := c.EP.SetSockOpt(&want); err != nil {
+		t.Fatalf("SetSockOpt(&%+v) failed: %s", want, err)
+	}
+	if diff := cmp.Diff(want, repairWindow(t, c)); diff != "" {
+		t.Errorf("restored window mismatch (-want +got):\n%s", diff)
+	}
+
+	// Let the peer update the send window.
+	setRepair(t, c, tcpip.TCPRepairOffNoWindowProbe)
+	peerData := []byte("peer data")
+	sendAck(c, rcvSeq, sndSeq, 20000, peerData)
+	b := c.GetPacket()
+	defer b.Release()
+	checker.IPv4(t, b, checker.TCP(
+		checker.DstPort(context.TestPort),
+		checker.TCPFlags(header.TCPFlagAck),
+		checker.TCPSeqNum(uint32(sndSeq)),
+		checker.TCPAckNum(uint32(rcvSeq)+uint32(len(peerData))),
+	))
+
+	w = waitSendWindow(t, c, 20000)
+	if got, want := w.SndWL1, uint32(rcvSeq); got != want {
+		t.Errorf("got SndWL1 = %d, want = %d", got, want)
+	}
+	if got, want := w.MaxWindow, uint32(20000); got != want {
+		t.Errorf("got MaxWindow = %d, want = %d", got, want)
+	}
+
+	// A smaller window leaves the largest one unchanged.
+	setRepair(t, c, tcpip.TCPRepairOffNoWindowProbe)
+	seq := rcvSeq.Add(seqnum.Size(len(peerData)))
+	sendAck(c, seq, sndSeq, 1000, nil)
+	w = waitSendWindow(t, c, 1000)
+	if got, want := w.SndWL1, uint32(seq); got != want {
+		t.Errorf("got SndWL1 = %d, want = %d", got, want)
+	}
+	if got, want := w.MaxWindow, uint32(20000); got != want {
+		t.Errorf("got MaxWindow = %d, want = %d", got, want)
+	}
+}
+
+// TestRepairOptions tests that the options negotiated by a connection can be
+// restored in repair mode.
+func TestRepairOptions(t *testing.T) {
+	c := context.New(t, e2e.DefaultMTU)
+	defer c.Cleanup()
+
+	repairConnect(t, c)
+	const mss = 500
+	opts := tcpip.TCPRepairOptionsOption{
+		{Code: header.TCPOptionMSS, Value: mss},
+		{Code: header.TCPOptionWS, Value: 2},
+		{Code: header.TCPOptionTS},
+	}
+	if err := c.EP.SetSockOpt(&opts); err != nil {
+		t.Fatalf("SetSockOpt(&%+v) failed: %s", opts, err)
+	}
+
+	data := make([]byte, 2*mss)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	writeQueue(t, c, tcpip.TCPSendQueue, data)
+	openSendWindow(t, c, 30000)
+	setRepair(t, c, tcpip.TCPRepairOffNoWindowProbe)
+
+	// Segments carry timestamps, and are sized for the restored MSS less
+	// the timestamp option.
+	payload := mss - e2e.TSOptionSize
+	b := c.GetPacket()
+	defer b.Release()
+	checker.IPv4(t, b, checker.TCP(
+		checker.DstPort(context.TestPort),
+		checker.TCPSeqNum(uint32(sndSeq)),
+		checker.TCPTimestampChecker(true, 0, 0),
+		checker.Payload(data[:payload]),
+	))
+
+	// The peer's windows are scaled.
+	opt := [12]byte{header.TCPOptionNOP, header.TCPOptionNOP}
+	header.EncodeTSOption(1, 0, opt[2:])
+	c.SendPacket(nil, &context.Headers{
+		SrcPort: context.TestPort,
+		DstPort: context.StackPort,
+		Flags:   header.TCPFlagAck,
+		SeqNum:  rcvSeq,
+		AckNum:  sndSeq,
+		RcvWnd:  100,
+		TCPOpts: opt[:],
+	})
+	waitSendWindow(t, c, 100<<2)
+}
+
+func TestMain(m *testing.M) {
+	refs.SetLeakMode(refs.LeaksPanic)
+	code := m.Run()
+	// Allow TCP async work to complete to avoid false reports of leaks.
+	// TODO(gvisor.dev/issue/5940): Use fake clock in tests.
+	time.Sleep(1 * time.Second)
+	refs.DoLeakCheck()
+	os.Exit(code)
+}
@@ -2896,6 +2896,150 @@ func TestSimpleSend(t *testing.T) {
 	})
 }
 
+func TestZeroCopySend(t *testing.T) {
+	c := context.New(t, e2e.DefaultMTU)
+	defer c.Cleanup()
+
+	c.CreateConnected(context.TestInitialSequenceNumber, 30000, -1 /* epRcvBuf */)
+	c.EP.SocketOptions().SetDelayOption(false)
+
+	we, ch := waiter.NewChannelEntry(waiter.EventErr)
+	c.WQ.EventRegister(&we)
+	defer c.WQ.EventUnregister(&we)
+
+	// Send 0 is data[:2], send 1 is data[2:6] queued by two writes, and send
+	// 2 is data[6:].
+	data := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	writes := []struct {
+		offset, size int
+		id           uint32
+	}{
+		{offset: 0, size: 2, id: 0},
+		{offset: 2, size: 2, id: 1},
+		{offset: 4, size: 2, id: 1},
+		{offset: 6, size: 2, id: 2},
+	}
+	for _, w := range writes {
+		var r bytes.Reader
+		r.Reset(data[w.offset:][:w.size])
+		if _, err := c.EP.Write(&r, tcpip.WriteOptions{ZeroCopy: true, ZeroCopyID: w.id}); err != nil {
+			t.Fatalf("Write failed: %s", err)
+		}
+		c.ReceiveAndCheckPacket(data, w.offset, w.size)
+	}
+	if sockErr := c.EP.SocketOptions().PeekErr(); sockErr != nil {
+		t.Fatalf("got completion %+v before the data is acknowledged", sockErr)
+	}
+
+	checkCompletion := func(want tcpip.ZeroCopySockError) {
+		t.Helper()
+		select {
+		case <-ch:
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for a completion")
+		}
+		sockErr := c.EP.SocketOptions().DequeueErr()
+		if sockErr == nil {
+			t.Fatal("got no completion on the error queue")
+		}
+		if diff := cmp.Diff(&want, sockErr.Cause); diff != "" {
+			t.Errorf("completion mismatch (-want +got):\n%s", diff)
+		}
+		if sockErr := c.EP.SocketOptions().PeekErr(); sockErr != nil {
+			t.Errorf("got unexpected completion %+v", sockErr)
+		}
+	}
+
+	// Acknowledging the first write of send 1 completes only send 0.
+	iss := seqnum.Value(context.TestInitialSequenceNumber).Add(1)
+	c.SendAck(iss, 4)
+	checkCompletion(tcpip.ZeroCopySockError{Lo: 0, Hi: 0, Copied: true})
+
+	c.SendAck(iss, len(data))
+	checkCompletion(tcpip.ZeroCopySockError{Lo: 1, Hi: 2, Copied: true})
+}
+
+// pinnedReader is a tcpip.PinnedPayloader that references its data in place.
+// released is closed once the data is no longer referenced.
+type pinnedReader struct {
+	data     []byte
+	released chan struct{}
+}
+
+// Read implements io.Reader.Read.
+func (r *pinnedReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+// Len implements tcpip.Payloader.Len.
+func (r *pinnedReader) Len() int {
+	return len(r.data)
+}
+
+// ReadPinned implements tcpip.PinnedPayloader.ReadPinned.
+func (r *pinnedReader) ReadPinned(n int, release func()) (buffer.Buffer, bool, error) {
+	v := buffer.NewViewWithExternalData(r.data[:n], func() {
+		close(r.released)
+		if release != nil {
+			release()
+		}
+	})
+	r.data = r.data[n:]
+	return buffer.MakeWithView(v), true, nil
+}
+
+func TestZeroCopySendPinned(t *testing.T) {
+	c := context.New(t, e2e.DefaultMTU)
+	defer c.Cleanup()
+
+	c.CreateConnected(context.TestInitialSequenceNumber, 30000, -1 /* epRcvBuf */)
+
+	we, ch := waiter.NewChannelEntry(waiter.EventErr)
+	c.WQ.EventRegister(&we)
+	defer c.WQ.EventUnregister(&we)
+
+	data := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	r := &pinnedReader{data: data, released: make(chan struct{})}
+	if _, err := c.EP.Write(r, tcpip.WriteOptions{ZeroCopy: true, ZeroCopyID: 0}); err != nil {
+		t.Fatalf("Write failed: %s", err)
+	}
+	c.ReceiveAndCheckPacket(data, 0, len(data))
+
+	// The data is referenced in place until it is acknowledged.
+	select {
+	case <-r.released:
+		t.Fatal("the data was released before it was acknowledged")
+	default:
+	}
+	if sockErr := c.EP.SocketOptions().PeekErr(); sockErr != nil {
+		t.Fatalf("got completion %+v before the data is acknowledged", sockErr)
+	}
+
+	c.SendAck(seqnum.Value(context.TestInitialSequenceNumber).Add(1), len(data))
+	select {
+	case <-ch:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for a completion")
+	}
+	sockErr := c.EP.SocketOptions().DequeueErr()
+	if sockErr == nil {
+		t.Fatal("got no completion on the error queue")
+	}
+	if diff := cmp.Diff(&tcpip.ZeroCopySockError{Lo: 0, Hi: 0}, sockErr.Cause); diff != "" {
+		t.Errorf("completion mismatch (-want +got):\n%s", diff)
+	}
+	select {
+	case <-r.released:
+	case <-time.After(time.Second):
+		t.Fatal("the data was not released once acknowledged")
+	}
+}
+
 func TestZeroWindowSend(t *testing.T) {
 	c := context.New(t, e2e.DefaultMTU)
 	defer c.Cleanup()
@@ -0,0 +1,494 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcp
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"gvisor.dev/gvisor/pkg/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/waiter"
+)
+
+// This file implements kernel TLS, the "tls" upper layer protocol. Once an
+// application has completed a TLS handshake, it hands the traffic keys to the
+// endpoint, which then encrypts the data written into TLS records and decrypts
+// the records received. See Linux's net/tls.
+
+const (
+	// tlsRecordHeaderSize is the size of a TLS record header.
+	tlsRecordHeaderSize = 5
+
+	// tlsMaxPlaintextSize is the maximum size of the data held by a record.
+	tlsMaxPlaintextSize = 1 << 14
+
+	// tlsRecordTypeApplicationData is the type of records holding
+	// application data.
+	tlsRecordTypeApplicationData = 23
+
+	// tlsRecordVersion is the version of all record headers, for both TLS
+	// 1.2 and 1.3.
+	tlsRecordVersion = 0x0303
+)
+
+// tlsState holds the state protecting the records in one direction of a
+// connection.
+//
+// +stateify savable
+type tlsState struct {
+	info tcpip.TLSCryptoInfo
+
+	// aead is created from info on first use.
+	aead cipher.AEAD `state:"nosave"`
+}
+
+// newTLSState returns the state protecting records with info, after validating
+// it.
+func newTLSState(info tcpip.TLSCryptoInfo) (*tlsState, tcpip.Error) {
+	if info.Version != tcpip.TLSVersion12 && info.Version != tcpip.TLSVersion13 {
+		return nil, &tcpip.ErrInvalidOptionValue{}
+	}
+	var keySize, saltSize, ivSize int
+	switch info.CipherType {
+	case tcpip.TLSCipherAESGCM128:
+		keySize, saltSize, ivSize = 16, 4, 8
+	case tcpip.TLSCipherAESGCM256:
+		keySize, saltSize, ivSize = 32, 4, 8
+	case tcpip.TLSCipherChaCha20Poly1305:
+		keySize, saltSize, ivSize = chacha20poly1305.KeySize, 0, chacha20poly1305.NonceSize
+	default:
+		return nil, &tcpip.ErrInvalidOptionValue{}
+	}
+	if len(info.Key) != keySize || len(info.Salt) != saltSize || len(info.IV) != ivSize {
+		return nil, &tcpip.ErrInvalidOptionValue{}
+	}
+	return &tlsState{info: cloneTLSCryptoInfo(info)}, nil
+}
+
+// cloneTLSCryptoInfo returns a copy of info that doesn't share its slices.
+func cloneTLSCryptoInfo(info tcpip.TLSCryptoInfo) tcpip.TLSCryptoInfo {
+	info.Key = append([]byte(nil), info.Key...)
+	info.Salt = append([]byte(nil), info.Salt...)
+	info.IV = append([]byte(nil), info.IV...)
+	return info
+}
+
+// cipher returns the AEAD protecting the records.
+func (t *tlsState) cipher() cipher.AEAD {
+	if t.aead != nil {
+		return t.aead
+	}
+	var err error
+	if t.info.CipherType == tcpip.TLSCipherChaCha20Poly1305 {
+		t.aead, err = chacha20poly1305.New(t.info.Key)
+	} else {
+		var block cipher.Block
+		if block, err = aes.NewCipher(t.info.Key); err == nil {
+			t.aead, err = cipher.NewGCM(block)
+		}
+	}
+	if err != nil {
+		// The key size was validated by newTLSState.
+		panic(fmt.Sprintf("invalid TLS key: %v", err))
+	}
+	return t.aead
+}
+
+// explicitNonce returns true if records carry the part of the nonce that
+// changes with each record, which is only the case for AES-GCM in TLS 1.2. See
+// RFC 5288, section 3.
+func (t *tlsState) explicitNonce() bool {
+	return t.info.Version == tcpip.TLSVersion12 && t.info.CipherType != tcpip.TLSCipherChaCha20Poly1305
+}
+
+// overhead returns the number of bytes a record adds to its data, not
+// counting the header.
+func (t *tlsState) overhead() int {
+	n := t.cipher().Overhead()
+	if t.explicitNonce() {
+		n += len(t.info.IV)
+	}
+	if t.info.Version == tcpip.TLSVersion13 {
+		// The record type is encrypted along with the data.
+		n++
+	}
+	return n
+}
+
+// nonce returns the nonce protecting the current record, given the explicit
+// part of the nonce carried by the record if any.
+func (t *tlsState) nonce(explicit []byte) []byte {
+	nonce := make([]byte, 0, t.cipher().NonceSize())
+	nonce = append(nonce, t.info.Salt...)
+	if t.explicitNonce() {
+		return append(nonce, explicit...)
+	}
+	// The sequence number is XORed into the static nonce. See RFC 8446,
+	// section 5.3, and RFC 7905, section 2.
+	nonce = append(nonce, t.info.IV...)
+	var seq [8]byte
+	binary.BigEndian.PutUint64(seq[:], t.info.RecSeq)
+	for i, b := range seq {
+		nonce[len(nonce)-len(seq)+i] ^= b
+	}
+	return nonce
+}
+
+// additionalData returns the additional data authenticated along with the
+// record whose header is hdr and whose data is n bytes long.
+func (t *tlsState) additionalData(hdr []byte, n int) []byte {
+	if t.info.Version == tcpip.TLSVersion13 {
+		// See RFC 8446, section 5.2.
+		return hdr
+	}
+	// See RFC 5246, section 6.2.3.3.
+	ad := make([]byte, 13)
+	binary.BigEndian.PutUint64(ad, t.info.RecSeq)
+	ad[8] = hdr[0]
+	binary.BigEndian.PutUint16(ad[9:], tlsRecordVersion)
+	binary.BigEndian.PutUint16(ad[11:], uint16(n))
+	return ad
+}
+
+// advance moves to the next record.
+func (t *tlsState) advance() {
+	t.info.RecSeq++
+	if t.explicitNonce() {
+		// Like Linux, use the IV as a counter providing the explicit
+		// nonces.
+		for i := len(t.info.IV) - 1; i >= 0; i-- {
+			t.info.IV[i]++
+			if t.info.IV[i] != 0 {
+				break
+			}
+		}
+	}
+}
+
+// seal returns the record of type typ holding data, which must not be larger
+// than tlsMaxPlaintextSize.
+func (t *tlsState) seal(typ uint8, data []byte) []byte {
+	aead := t.cipher()
+	outerType := typ
+	if t.info.Version == tcpip.TLSVersion13 {
+		// Records look like application data, and their actual type is
+		// encrypted along with the data. See RFC 8446, section 5.2.
+		data = append(append(make([]byte, 0, len(data)+1), data...), typ)
+		outerType = tlsRecordTypeApplicationData
+	}
+	var explicit []byte
+	if t.explicitNonce() {
+		explicit = t.info.IV
+	}
+	size := len(explicit) + len(data) + aead.Overhead()
+	rec := make([]byte, tlsRecordHeaderSize, tlsRecordHeaderSize+size)
+	rec[0] = outerType
+	binary.BigEndian.PutUint16(rec[1:], tlsRecordVersion)
+	binary.BigEndian.PutUint16(rec[3:], uint16(size))
+	rec = append(rec, explicit...)
+	rec = aead.Seal(rec, t.nonce(explicit), data, t.additionalData(rec[:tlsRecordHeaderSize], len(data)))
+	t.advance()
+	return rec
+}
+
+// recordSize returns the size of the record whose header is hdr, or false if
+// the header is invalid.
+func (t *tlsState) recordSize(hdr []byte) (int, bool) {
+	if binary.BigEndian.Uint16(hdr[1:]) != tlsRecordVersion {
+		return 0, false
+	}
+	if t.info.Version == tcpip.TLSVersion13 && hdr[0] != tlsRecordTypeApplicationData {
+		return 0, false
+	}
+	n := int(binary.BigEndian.Uint16(hdr[3:]))
+	if overhead := t.overhead(); n < overhead || n > tlsMaxPlaintextSize+overhead {
+		return 0, false
+	}
+	return tlsRecordHeaderSize + n, true
+}
+
+// open returns the type and the data of the record rec, whose size was
+// validated by recordSize.
+func (t *tlsState) open(rec []byte) (uint8, []byte, tcpip.Error) {
+	aead := t.cipher()
+	hdr, body := rec[:tlsRecordHeaderSize], rec[tlsRecordHeaderSize:]
+	var explicit []byte
+	if t.explicitNonce() {
+		explicit, body = body[:len(t.info.IV)], body[len(t.info.IV):]
+	}
+	data, err := aead.Open(nil, t.nonce(explicit), body, t.additionalData(hdr, len(body)-aead.Overhead()))
+	if err != nil {
+		return 0, nil, &tcpip.ErrBadMessage{}
+	}
+	t.advance()
+	typ := hdr[0]
+	if t.info.Version == tcpip.TLSVersion13 {
+		// Strip the padding, then the actual record type.
+		i := len(data) - 1
+		for i >= 0 && data[i] == 0 {
+			i--
+		}
+		if i < 0 {
+			return 0, nil, &tcpip.ErrBadMessage{}
+		}
+		typ, data = data[i], data[:i]
+	}
+	return typ, data, nil
+}
+
+// tlsRxState holds the state of the receive direction of a connection using
+// kernel TLS.
+//
+// +stateify savable
+type tlsRxState struct {
+	tlsState
+
+	// typ is the type of the last record decrypted, and data its unread
+	// data.
+	typ  uint8
+	data []byte
+
+	// failed is true once a record couldn't be decrypted, after which
+	// nothing can be read.
+	failed bool
+}
+
+// setTLSLocked sets the state protecting the records received if rx is true,
+// or sent otherwise.
+//
+// +checklocks:e.mu
+func (e *Endpoint) setTLSLocked(info tcpip.TLSCryptoInfo, rx bool) tcpip.Error {
+	if !e.tlsULP {
+		return &tcpip.ErrUnknownProtocolOption{}
+	}
+	if (rx && e.tlsRx != nil) || (!rx && e.tlsTx != nil) {
+		return &tcpip.ErrEndpointBusy{}
+	}
+	t, err := newTLSState(info)
+	if err != nil {
+		return err
+	}
+	if !rx {
+		e.tlsTx = t
+		return nil
+	}
+
+	// Data already received is made of records too.
+	e.tlsRx = &tlsRxState{tlsState: *t}
+	e.rcvQueueMu.Lock()
+	e.tlsRxEnabled = true
+	e.updateTLSRxReadyLocked()
+	ready := e.tlsRxReady
+	e.rcvQueueMu.Unlock()
+	if ready {
+		e.waiterQueue.Notify(waiter.ReadableEvents)
+	}
+	return nil
+}
+
+// getTLSLocked returns the state protecting the records received if rx is
+// true, or sent otherwise.
+//
+// +checklocks:e.mu
+func (e *Endpoint) getTLSLocked(rx bool) (tcpip.TLSCryptoInfo, tcpip.Error) {
+	if !e.tlsULP {
+		return tcpip.TLSCryptoInfo{}, &tcpip.ErrUnknownProtocolOption{}
+	}
+	var t *tlsState
+	if rx {
+		if e.tlsRx != nil {
+			t = &e.tlsRx.tlsState
+		}
+	} else {
+		t = e.tlsTx
+	}
+	if t == nil {
+		return tcpip.TLSCryptoInfo{}, &tcpip.ErrEndpointBusy{}
+	}
+	return cloneTLSCryptoInfo(t.info), nil
+}
+
+// sealTLSLocked returns the records holding the data of buf, which it takes
+// ownership of. The records are of the type given by cm, or hold application
+// data.
+//
+// +checklocks:e.mu
+func (e *Endpoint) sealTLSLocked(buf buffer.Buffer, cm tcpip.SendableControlMessages) buffer.Buffer {
+	typ := uint8(tlsRecordTypeApplicationData)
+	if cm.HasTLSRecordType {
+		typ = cm.TLSRecordType
+	}
+	data := buf.Flatten()
+	buf.Release()
+
+	var records buffer.Buffer
+	for len(data) > 0 {
+		n := min(len(data), tlsMaxPlaintextSize)
+		records.Append(buffer.NewViewWithData(e.tlsTx.seal(typ, data[:n])))
+		data = data[n:]
+	}
+	return records
+}
+
+// nextTLSRecordSizeLocked returns the size of the next record in the receive
+// queue, or 0 if its header wasn't received yet. It returns false if the
+// header is invalid.
+//
+// +checklocks:e.mu
+// +checklocks:e.rcvQueueMu
+func (e *Endpoint) nextTLSRecordSizeLocked() (int, bool) {
+	if e.RcvBufUsed < tlsRecordHeaderSize {
+		return 0, true
+	}
+	hdr := make([]byte, 0, tlsRecordHeaderSize)
+	for s := e.rcvQueue.Front(); s != nil && len(hdr) < tlsRecordHeaderSize; s = s.Next() {
+		hdr = append(hdr, s.pkt.Data().AsRange().Capped(tlsRecordHeaderSize-len(hdr)).ToSlice()...)
+	}
+	return e.tlsRx.recordSize(hdr)
+}
+
+// updateTLSRxReadyLocked updates whether a record can be read from an endpoint
+// decrypting the records received.
+//
+// +checklocks:e.mu
+// +checklocks:e.rcvQueueMu
+func (e *Endpoint) updateTLSRxReadyLocked() {
+	if !e.tlsRxEnabled {
+		return
+	}
+	if rx := e.tlsRx; rx.failed || len(rx.data) != 0 {
+		e.tlsRxReady = true
+		return
+	}
+	// An invalid record is ready so that the reader gets the error.
+	size, ok := e.nextTLSRecordSizeLocked()
+	e.tlsRxReady = !ok || (size != 0 && e.RcvBufUsed >= size)
+}
+
+// readTLSRecordLocked removes the next record from the receive queue and
+// decrypts it.
+//
+// +checklocks:e.mu
+func (e *Endpoint) readTLSRecordLocked() tcpip.Error {
+	rx := e.tlsRx
+	if rx.failed {
+		return &tcpip.ErrBadMessage{}
+	}
+	e.rcvQueueMu.Lock()
+	size, ok := e.nextTLSRecordSizeLocked()
+	ready := size != 0 && e.RcvBufUsed >= size
+	e.rcvQueueMu.Unlock()
+	if !ok {
+		rx.failed = true
+		return &tcpip.ErrBadMessage{}
+	}
+	if !ready {
+		if err := e.checkReadLocked(); err != nil {
+			return err
+		}
+		// Only part of the record was received.
+		e.rcvQueueMu.Lock()
+		closed := e.RcvClosed
+		e.rcvQueueMu.Unlock()
+		if closed || !e.EndpointState().connected() {
+			return &tcpip.ErrClosedForReceive{}
+		}
+		return &tcpip.ErrWouldBlock{}
+	}
+
+	var rec bytes.Buffer
+	rec.Grow(size)
+	// The read stops with io.ErrShortWrite at the end of the record.
+	e.readRcvQueueLocked(&tcpip.LimitedWriter{W: &rec, N: int64(size)}, false /* peek */)
+	typ, data, err := rx.open(rec.Bytes())
+	if err != nil {
+		rx.failed = true
+		return err
+	}
+	rx.typ, rx.data = typ, data
+	return nil
+}
+
+// readTLSLocked implements Read for an endpoint decrypting the records
+// received. Like Linux, it reads the data of consecutive records of the same
+// type, and the data of records that don't hold application data can only be
+// read by callers able to receive their type.
+//
+// +checklocks:e.mu
+func (e *Endpoint) readTLSLocked(dst io.Writer, opts tcpip.ReadOptions) (tcpip.ReadResult, tcpip.Error) {
+	rx := e.tlsRx
+	var (
+		typ  uint8
+		done int
+		werr error
+	)
+	for {
+		if len(rx.data) == 0 {
+			// Peeking doesn't go past the record already decrypted.
+			if done != 0 && opts.Peek {
+				break
+			}
+			if err := e.readTLSRecordLocked(); err != nil {
+				if done != 0 {
+					// Report the error on the next read.
+					break
+				}
+				e.rcvQueueMu.Lock()
+				e.updateTLSRxReadyLocked()
+				e.rcvQueueMu.Unlock()
+				return tcpip.ReadResult{}, err
+			}
+		}
+		if done != 0 && rx.typ != typ {
+			break
+		}
+		typ = rx.typ
+		if typ != tlsRecordTypeApplicationData && !opts.NeedTLSRecordType {
+			return tcpip.ReadResult{}, &tcpip.ErrInputOutput{}
+		}
+
+		var n int
+		n, werr = dst.Write(rx.data)
+		done += n
+		if !opts.Peek {
+			rx.data = rx.data[n:]
+		}
+		if werr != nil || typ != tlsRecordTypeApplicationData || opts.Peek {
+			break
+		}
+	}
+
+	e.rcvQueueMu.Lock()
+	e.updateTLSRxReadyLocked()
+	e.rcvQueueMu.Unlock()
+
+	if done == 0 && werr != nil {
+		return tcpip.ReadResult{}, &tcpip.ErrBadBuffer{}
+	}
+	return tcpip.ReadResult{
+		Count: done,
+		Total: done,
+		ControlMessages: tcpip.ReceivableControlMessages{
+			HasTLSRecordType: true,
+			TLSRecordType:    typ,
+		},
+	}, nil
+}
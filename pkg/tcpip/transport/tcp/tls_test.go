@@ -0,0 +1,131 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tcp
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+func tlsTestInfo(version, cipherType uint16) tcpip.TLSCryptoInfo {
+	info := tcpip.TLSCryptoInfo{
+		Version:    version,
+		CipherType: cipherType,
+		RecSeq:     41,
+	}
+	switch cipherType {
+	case tcpip.TLSCipherAESGCM128:
+		info.Key, info.Salt, info.IV = make([]byte, 16), []byte{1, 2, 3, 4}, []byte{0, 0, 0, 0, 0, 0, 0, 0xff}
+	case tcpip.TLSCipherAESGCM256:
+		info.Key, info.Salt, info.IV = make([]byte, 32), []byte{1, 2, 3, 4}, []byte{0, 0, 0, 0, 0, 0, 0, 0xff}
+	case tcpip.TLSCipherChaCha20Poly1305:
+		info.Key, info.IV = make([]byte, 32), []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	}
+	for i := range info.Key {
+		info.Key[i] = byte(i)
+	}
+	return info
+}
+
+func TestTLSSealOpen(t *testing.T) {
+	for _, version := range []uint16{tcpip.TLSVersion12, tcpip.TLSVersion13} {
+		for _, cipherType := range []uint16{tcpip.TLSCipherAESGCM128, tcpip.TLSCipherAESGCM256, tcpip.TLSCipherChaCha20Poly1305} {
+			t.Run(fmt.Sprintf("%#x/%d", version, cipherType), func(t *testing.T) {
+				tx, err := newTLSState(tlsTestInfo(version, cipherType))
+				if err != nil {
+					t.Fatalf("newTLSState(tx) = %s", err)
+				}
+				rx, err := newTLSState(tlsTestInfo(version, cipherType))
+				if err != nil {
+					t.Fatalf("newTLSState(rx) = %s", err)
+				}
+				for i, want := range []struct {
+					typ  uint8
+					data []byte
+				}{
+					{tlsRecordTypeApplicationData, []byte("hello")},
+					{21 /* alert */, []byte{1, 0}},
+					{tlsRecordTypeApplicationData, nil},
+					{tlsRecordTypeApplicationData, bytes.Repeat([]byte{'x'}, tlsMaxPlaintextSize)},
+				} {
+					rec := tx.seal(want.typ, want.data)
+					size, ok := rx.recordSize(rec[:tlsRecordHeaderSize])
+					if !ok || size != len(rec) {
+						t.Fatalf("record %d: recordSize(...) = %d, %t, want = %d, true", i, size, ok, len(rec))
+					}
+					typ, data, err := rx.open(rec)
+					if err != nil {
+						t.Fatalf("record %d: open(...) = %s", i, err)
+					}
+					if typ != want.typ || !bytes.Equal(data, want.data) {
+						t.Errorf("record %d: open(...) = %d, %q, want = %d, %q", i, typ, data, want.typ, want.data)
+					}
+				}
+				if got, want := tx.info.RecSeq, uint64(45); got != want {
+					t.Errorf("got tx.info.RecSeq = %d, want = %d", got, want)
+				}
+				if got, want := rx.info.RecSeq, uint64(45); got != want {
+					t.Errorf("got rx.info.RecSeq = %d, want = %d", got, want)
+				}
+
+				rec := tx.seal(tlsRecordTypeApplicationData, []byte("tampered"))
+				rec[len(rec)-1] ^= 1
+				if _, _, err := rx.open(rec); err == nil {
+					t.Errorf("open(tampered record) succeeded")
+				}
+			})
+		}
+	}
+}
+
+func TestTLSExplicitNonce(t *testing.T) {
+	tx, err := newTLSState(tlsTestInfo(tcpip.TLSVersion12, tcpip.TLSCipherAESGCM128))
+	if err != nil {
+		t.Fatalf("newTLSState(...) = %s", err)
+	}
+	for _, want := range [][]byte{
+		{0, 0, 0, 0, 0, 0, 0, 0xff},
+		{0, 0, 0, 0, 0, 0, 1, 0},
+	} {
+		rec := tx.seal(tlsRecordTypeApplicationData, []byte("data"))
+		if got := rec[tlsRecordHeaderSize : tlsRecordHeaderSize+8]; !bytes.Equal(got, want) {
+			t.Errorf("got explicit nonce = %x, want = %x", got, want)
+		}
+	}
+}
+
+func TestNewTLSStateInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		modify func(*tcpip.TLSCryptoInfo)
+	}{
+		{"version", func(info *tcpip.TLSCryptoInfo) { info.Version = 0x0302 }},
+		{"cipher", func(info *tcpip.TLSCryptoInfo) { info.CipherType = 53 }},
+		{"key", func(info *tcpip.TLSCryptoInfo) { info.Key = info.Key[:8] }},
+		{"salt", func(info *tcpip.TLSCryptoInfo) { info.Salt = nil }},
+		{"iv", func(info *tcpip.TLSCryptoInfo) { info.IV = append(info.IV, 0) }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			info := tlsTestInfo(tcpip.TLSVersion12, tcpip.TLSCipherAESGCM128)
+			tc.modify(&info)
+			if _, err := newTLSState(info); err == nil {
+				t.Errorf("newTLSState(%+v) succeeded", info)
+			}
+		})
+	}
+}
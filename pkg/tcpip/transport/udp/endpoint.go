@@ -21,6 +21,7 @@ import (
 	"math"
 	"time"
 
+	"gvisor.dev/gvisor/pkg/atomicbitops"
 	"gvisor.dev/gvisor/pkg/sync"
 	"gvisor.dev/gvisor/pkg/tcpip"
 	"gvisor.dev/gvisor/pkg/tcpip/checksum"
@@ -463,12 +464,41 @@ func (e *endpoint) write(p tcpip.Payloader, opts tcpip.WriteOptions) (int64, tcp
 
 	dataSz := p.Len()
 	pktInfo := udpInfo.ctx.PacketInfo()
-	pkt := udpInfo.ctx.TryNewPacketBufferFromPayloader(header.UDPMinimumSize+int(pktInfo.MaxHeaderLength), p)
+	reserveHdrBytes := header.UDPMinimumSize + int(pktInfo.MaxHeaderLength)
+	var (
+		pkt *stack.PacketBuffer
+		zc  *zeroCopySend
+	)
+	if opts.ZeroCopy {
+		zc = &zeroCopySend{e: e, id: opts.ZeroCopyID, copied: true}
+		zc.pending.Store(2)
+	}
+	if pp, ok := p.(tcpip.PinnedPayloader); ok && zc != nil {
+		var pinned bool
+		pkt, pinned = udpInfo.ctx.TryNewPacketBufferFromPinnedPayloader(reserveHdrBytes, pp, zc.done)
+		zc.copied = !pinned
+	} else {
+		pkt = udpInfo.ctx.TryNewPacketBufferFromPayloader(reserveHdrBytes, p)
+		if zc != nil {
+			// The data was copied, so the packet doesn't reference it.
+			zc.done()
+		}
+	}
 	if pkt == nil {
 		return 0, &tcpip.ErrWouldBlock{}
 	}
 	defer pkt.DecRef()
 
+	if opts.ControlMessages.HasTxTime {
+		txTime := e.ops.GetTxTime()
+		pkt.TxTime = &stack.TxTime{
+			Time:         opts.ControlMessages.TxTime,
+			ClockID:      txTime.ClockID,
+			DeadlineMode: txTime.DeadlineMode,
+			Owner:        e,
+		}
+	}
+
 	// Initialize the UDP header.
 	udp := header.UDP(pkt.TransportHeader().Push(header.UDPMinimumSize))
 	pkt.TransportProtocolNumber = ProtocolNumber
@@ -521,9 +551,52 @@ func (e *endpoint) write(p tcpip.Payloader, opts tcpip.WriteOptions) (int64, tcp
 
 	// Track count of packets sent.
 	e.stack.Stats().UDP.PacketsSent.Increment()
+
+	if zc != nil {
+		zc.done()
+	}
 	return int64(dataSz), nil
 }
 
+// zeroCopySend is a send requested with MSG_ZEROCOPY. As in Linux, it
+// completes once its datagram is written and no packet references its data
+// anymore, which may be after the datagram is sent if the data is referenced
+// in place.
+type zeroCopySend struct {
+	e  *endpoint
+	id uint32
+
+	// copied is true if the data was copied rather than referenced in
+	// place.
+	copied bool
+
+	// pending is the number of calls to done that are still expected: one
+	// once the datagram is written and one once its data is no longer
+	// referenced. The send fails if the datagram isn't written, in which
+	// case it never completes.
+	pending atomicbitops.Int32
+}
+
+// done queues the completion of the send on the last call.
+func (z *zeroCopySend) done() {
+	if z.pending.Add(-1) != 0 {
+		return
+	}
+	z.e.ops.QueueZeroCopyCompletion(z.id, z.id, z.copied, z.e.net.NetProto())
+	z.e.waiterQueue.Notify(waiter.EventErr)
+}
+
+var _ stack.TxTimeOwner = (*endpoint)(nil)
+
+// OnTxTimeDrop implements stack.TxTimeOwner.
+func (e *endpoint) OnTxTimeDrop(txTime int64, missed bool) {
+	if !e.ops.GetTxTime().ReportErrors {
+		return
+	}
+	e.ops.QueueTxTimeErr(txTime, missed, e.net.NetProto())
+	e.waiterQueue.Notify(waiter.EventErr)
+}
+
 // OnReuseAddressSet implements tcpip.SocketOptionsHandler.
 func (e *endpoint) OnReuseAddressSet(v bool) {
 	e.mu.Lock()
@@ -887,12 +960,28 @@ func (e *endpoint) Readiness(mask waiter.EventMask) waiter.EventMask {
 	e.lastErrorMu.Lock()
 	hasError := e.lastError != nil
 	e.lastErrorMu.Unlock()
-	if hasError {
+	if hasError || (mask&waiter.EventErr != 0 && e.ops.HasQueuedErr()) {
 		result |= waiter.EventErr
 	}
 	return result
 }
 
+var _ stack.MulticastSourceFilteringTransportEndpoint = (*endpoint)(nil)
+
+// AllowsMulticastSource implements
+// stack.MulticastSourceFilteringTransportEndpoint.
+func (e *endpoint) AllowsMulticastSource(nicID tcpip.NICID, group, source tcpip.Address) bool {
+	return e.net.AllowsMulticastSource(nicID, group, source)
+}
+
+var _ stack.ReusePortSteeringTransportEndpoint = (*endpoint)(nil)
+
+// ReusePortProgram implements
+// stack.ReusePortSteeringTransportEndpoint.ReusePortProgram.
+func (e *endpoint) ReusePortProgram() tcpip.ReusePortProgram {
+	return e.ops.GetReusePortProgram()
+}
+
 // HandlePacket is called by the stack when new packets arrive to this transport
 // endpoint.
 func (e *endpoint) HandlePacket(id stack.TransportEndpointID, pkt *stack.PacketBuffer) {
@@ -1040,12 +1129,38 @@ func (e *endpoint) onICMPError(err tcpip.Error, transErr stack.TransportError, p
 
 // HandleError implements stack.TransportEndpoint.
 func (e *endpoint) HandleError(transErr stack.TransportError, pkt *stack.PacketBuffer) {
-	// TODO(gvisor.dev/issues/5270): Handle all transport errors.
+	if e.net.State() != transport.DatagramEndpointStateConnected {
+		return
+	}
+
 	switch transErr.Kind() {
-	case stack.DestinationPortUnreachableTransportError:
-		if e.net.State() == transport.DatagramEndpointStateConnected {
-			e.onICMPError(&tcpip.ErrConnectionRefused{}, transErr, pkt)
+	case stack.PacketTooBigTransportError:
+		// Like Linux, only report the path MTU, which is held by the
+		// error info, to the endpoints that receive errors.
+		var recvErr bool
+		switch pkt.NetworkProtocolNumber {
+		case header.IPv4ProtocolNumber:
+			recvErr = e.SocketOptions().GetIPv4RecvError()
+		case header.IPv6ProtocolNumber:
+			recvErr = e.SocketOptions().GetIPv6RecvError()
 		}
+		if recvErr {
+			e.onICMPError(&tcpip.ErrMessageTooLong{}, transErr, pkt)
+		}
+	case stack.DestinationHostUnreachableTransportError:
+		e.onICMPError(&tcpip.ErrHostUnreachable{}, transErr, pkt)
+	case stack.DestinationNetworkUnreachableTransportError:
+		e.onICMPError(&tcpip.ErrNetworkUnreachable{}, transErr, pkt)
+	case stack.DestinationPortUnreachableTransportError:
+		e.onICMPError(&tcpip.ErrConnectionRefused{}, transErr, pkt)
+	case stack.DestinationProtoUnreachableTransportError:
+		e.onICMPError(&tcpip.ErrUnknownProtocolOption{}, transErr, pkt)
+	case stack.SourceRouteFailedTransportError:
+		e.onICMPError(&tcpip.ErrNotSupported{}, transErr, pkt)
+	case stack.SourceHostIsolatedTransportError:
+		e.onICMPError(&tcpip.ErrNoNet{}, transErr, pkt)
+	case stack.DestinationHostDownTransportError:
+		e.onICMPError(&tcpip.ErrHostDown{}, transErr, pkt)
 	}
 }
 
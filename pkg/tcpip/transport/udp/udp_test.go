@@ -23,6 +23,7 @@ import (
 	"math/rand"
 	"os"
 	"testing"
+	"time"
 
 	"gvisor.dev/gvisor/pkg/buffer"
 	"gvisor.dev/gvisor/pkg/refs"
@@ -2293,6 +2294,176 @@ func TestWritePayloadSizeTooBig(t *testing.T) {
 	}
 }
 
+func TestWriteZeroCopy(t *testing.T) {
+	c := context.New(t, []stack.TransportProtocolFactory{udp.NewProtocol, icmp.NewProtocol6, icmp.NewProtocol4})
+	defer c.Cleanup()
+
+	c.CreateEndpoint(ipv4.ProtocolNumber, udp.ProtocolNumber)
+	if err := c.EP.Connect(tcpip.FullAddress{Addr: context.TestAddr, Port: context.TestPort}); err != nil {
+		c.T.Fatalf("Connect failed: %s", err)
+	}
+
+	write := func(opts tcpip.WriteOptions) {
+		t.Helper()
+		var r bytes.Reader
+		r.Reset(make([]byte, arbitraryPayloadSize))
+		if _, err := c.EP.Write(&r, opts); err != nil {
+			t.Fatalf("Write failed: %s", err)
+		}
+		if pkt := c.LinkEP.Read(); pkt == nil {
+			t.Fatal("Packet wasn't written out")
+		} else {
+			pkt.DecRef()
+		}
+	}
+	checkCompletion := func(want tcpip.ZeroCopySockError) {
+		t.Helper()
+		sockErr := c.EP.SocketOptions().DequeueErr()
+		if sockErr == nil {
+			t.Fatal("got no completion on the error queue")
+		}
+		z, ok := sockErr.Cause.(*tcpip.ZeroCopySockError)
+		if !ok {
+			t.Fatalf("got cause %T, want *tcpip.ZeroCopySockError", sockErr.Cause)
+		}
+		if *z != want {
+			t.Errorf("got completion %+v, want %+v", *z, want)
+		}
+		if sockErr.Err != nil || sockErr.NetProto != ipv4.ProtocolNumber {
+			t.Errorf("got (Err, NetProto) = (%v, %d), want (nil, %d)", sockErr.Err, sockErr.NetProto, ipv4.ProtocolNumber)
+		}
+	}
+
+	// The completions of consecutive sends are coalesced; writes without
+	// MSG_ZEROCOPY queue none.
+	write(tcpip.WriteOptions{ZeroCopy: true, ZeroCopyID: 0})
+	write(tcpip.WriteOptions{})
+	write(tcpip.WriteOptions{ZeroCopy: true, ZeroCopyID: 1})
+	if got := c.EP.Readiness(waiter.EventErr); got != waiter.EventErr {
+		t.Errorf("got c.EP.Readiness(waiter.EventErr) = %#x, want = %#x", got, waiter.EventErr)
+	}
+	checkCompletion(tcpip.ZeroCopySockError{Lo: 0, Hi: 1, Copied: true})
+
+	write(tcpip.WriteOptions{ZeroCopy: true, ZeroCopyID: 2})
+	checkCompletion(tcpip.ZeroCopySockError{Lo: 2, Hi: 2, Copied: true})
+	if sockErr := c.EP.SocketOptions().DequeueErr(); sockErr != nil {
+		t.Errorf("got unexpected error %+v on the error queue", sockErr)
+	}
+	if got := c.EP.Readiness(waiter.EventErr); got != 0 {
+		t.Errorf("got c.EP.Readiness(waiter.EventErr) = %#x, want = 0", got)
+	}
+
+	// Data referenced in place completes once the packet is released.
+	data := []byte{1, 2, 3, 4}
+	if _, err := c.EP.Write(&pinnedReader{data: data}, tcpip.WriteOptions{ZeroCopy: true, ZeroCopyID: 3}); err != nil {
+		t.Fatalf("Write failed: %s", err)
+	}
+	pkt := c.LinkEP.Read()
+	if pkt == nil {
+		t.Fatal("Packet wasn't written out")
+	}
+	if got := pkt.Data().AsRange().ToSlice(); !bytes.Equal(got, data) {
+		t.Errorf("got payload %v, want %v", got, data)
+	}
+	if sockErr := c.EP.SocketOptions().PeekErr(); sockErr != nil {
+		t.Errorf("got completion %+v while the packet references the data", sockErr)
+	}
+	pkt.DecRef()
+	checkCompletion(tcpip.ZeroCopySockError{Lo: 3, Hi: 3})
+}
+
+// pinnedReader is a tcpip.PinnedPayloader that references its data in place.
+type pinnedReader struct {
+	data []byte
+}
+
+// Read implements io.Reader.Read.
+func (r *pinnedReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+// Len implements tcpip.Payloader.Len.
+func (r *pinnedReader) Len() int {
+	return len(r.data)
+}
+
+// ReadPinned implements tcpip.PinnedPayloader.ReadPinned.
+func (r *pinnedReader) ReadPinned(n int, release func()) (buffer.Buffer, bool, error) {
+	if release == nil {
+		release = func() {}
+	}
+	v := buffer.NewViewWithExternalData(r.data[:n], release)
+	r.data = r.data[n:]
+	return buffer.MakeWithView(v), true, nil
+}
+
+func TestWriteTxTime(t *testing.T) {
+	const (
+		clockID = 1
+		txTime  = int64(time.Second)
+	)
+	c := context.New(t, []stack.TransportProtocolFactory{udp.NewProtocol, icmp.NewProtocol6, icmp.NewProtocol4})
+	defer c.Cleanup()
+
+	c.CreateEndpoint(ipv4.ProtocolNumber, udp.ProtocolNumber)
+	if err := c.EP.Connect(tcpip.FullAddress{Addr: context.TestAddr, Port: context.TestPort}); err != nil {
+		c.T.Fatalf("Connect failed: %s", err)
+	}
+	c.EP.SocketOptions().SetTxTime(tcpip.TxTimeOption{Enabled: true, ClockID: clockID, ReportErrors: true})
+
+	write := func(opts tcpip.WriteOptions) *stack.TxTime {
+		t.Helper()
+		var r bytes.Reader
+		r.Reset(make([]byte, arbitraryPayloadSize))
+		if _, err := c.EP.Write(&r, opts); err != nil {
+			t.Fatalf("Write failed: %s", err)
+		}
+		pkt := c.LinkEP.Read()
+		if pkt == nil {
+			t.Fatal("Packet wasn't written out")
+		}
+		defer pkt.DecRef()
+		return pkt.TxTime
+	}
+
+	if got := write(tcpip.WriteOptions{}); got != nil {
+		t.Errorf("got TxTime = %+v for a write without departure time, want nil", got)
+	}
+	got := write(tcpip.WriteOptions{ControlMessages: tcpip.SendableControlMessages{HasTxTime: true, TxTime: txTime}})
+	if got == nil || got.Time != txTime || got.ClockID != clockID || got.DeadlineMode {
+		t.Fatalf("got TxTime = %+v, want Time = %d and ClockID = %d", got, txTime, clockID)
+	}
+
+	// The packets dropped because of their departure time are reported on
+	// the error queue.
+	got.Owner.OnTxTimeDrop(txTime, true /* missed */)
+	if got := c.EP.Readiness(waiter.EventErr); got != waiter.EventErr {
+		t.Errorf("got c.EP.Readiness(waiter.EventErr) = %#x, want = %#x", got, waiter.EventErr)
+	}
+	sockErr := c.EP.SocketOptions().DequeueErr()
+	if sockErr == nil {
+		t.Fatal("got no error on the error queue")
+	}
+	if cause, ok := sockErr.Cause.(*tcpip.TxTimeSockError); !ok || *cause != (tcpip.TxTimeSockError{Time: txTime, Missed: true}) {
+		t.Errorf("got cause %+v, want missed departure time %d", sockErr.Cause, txTime)
+	}
+	if _, ok := sockErr.Err.(*tcpip.ErrCanceled); !ok || sockErr.NetProto != ipv4.ProtocolNumber {
+		t.Errorf("got (Err, NetProto) = (%v, %d), want (%s, %d)", sockErr.Err, sockErr.NetProto, &tcpip.ErrCanceled{}, ipv4.ProtocolNumber)
+	}
+
+	// They aren't unless the socket asked for it.
+	c.EP.SocketOptions().SetTxTime(tcpip.TxTimeOption{Enabled: true, ClockID: clockID})
+	got.Owner.OnTxTimeDrop(txTime, false /* missed */)
+	if sockErr := c.EP.SocketOptions().DequeueErr(); sockErr != nil {
+		t.Errorf("got unexpected error %+v on the error queue", sockErr)
+	}
+}
+
 func TestSetExperimentOption(t *testing.T) {
 	opts := context.Options{
 		EnableExperimentIPOption: true,
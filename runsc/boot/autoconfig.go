@@ -0,0 +1,220 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package boot
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"gvisor.dev/gvisor/pkg/log"
+	"gvisor.dev/gvisor/pkg/sync"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/dhcp"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/network/ipv6"
+	"gvisor.dev/gvisor/pkg/tcpip/stack"
+)
+
+// routeUpdate is a change of the routing table learned from a router
+// advertisement.
+type routeUpdate struct {
+	route  tcpip.Route
+	remove bool
+}
+
+// autoConfig configures the NICs of the root network stack when the sandbox
+// runs with --net-config=auto. IPv4 is configured by a DHCPv4 client on each
+// NIC, and IPv6 by stateless address autoconfiguration, with the routes
+// learned from router advertisements.
+//
+// autoConfig implements ipv6.NDPDispatcher. NDP events can't call into the
+// stack, so the route updates that they carry are queued and applied by
+// another goroutine.
+//
+// Autoconfiguration isn't resumed after restore: the NICs keep the
+// configuration that they had when they were saved.
+//
+// +stateify savable
+type autoConfig struct {
+	mu sync.Mutex `state:"nosave"`
+
+	// stack is the stack whose NICs are configured. It is nil until the
+	// links are created.
+	//
+	// +checklocks:mu
+	stack *stack.Stack `state:"nosave"`
+
+	// pending holds the route updates that haven't been applied yet.
+	//
+	// +checklocks:mu
+	pending []routeUpdate `state:"nosave"`
+
+	// applying indicates that a goroutine is applying the pending route
+	// updates.
+	//
+	// +checklocks:mu
+	applying bool `state:"nosave"`
+
+	// clients holds the DHCPv4 clients of the NICs.
+	//
+	// +checklocks:mu
+	clients []*dhcp.Client `state:"nosave"`
+}
+
+var _ ipv6.NDPDispatcher = (*autoConfig)(nil)
+
+// ipv6Options returns the options of the IPv6 protocol of the stack, which
+// handle router advertisements and report their routes to a.
+func (a *autoConfig) ipv6Options() ipv6.Options {
+	return ipv6.Options{
+		NDPConfigs:       ipv6.DefaultNDPConfigurations(),
+		AutoGenLinkLocal: true,
+		NDPDisp:          a,
+	}
+}
+
+// start starts configuring the NICs of s, and starts a DHCPv4 client on the
+// NICs nics. It must be called once the links are created, since the routes
+// that it adds would be replaced by the routing table of the links.
+func (a *autoConfig) start(s *stack.Stack, nics []tcpip.NICID) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.stack != nil {
+		return fmt.Errorf("network autoconfiguration already started")
+	}
+	a.stack = s
+	for _, id := range nics {
+		c, err := dhcp.NewClient(s, dhcp.Options{
+			NIC: id,
+			Configured: func(cfg dhcp.Config) {
+				log.Infof("DHCP configured NIC %d with %+v", id, cfg)
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("creating DHCP client of NIC %d: %s", id, err)
+		}
+		if err := c.Start(); err != nil {
+			return fmt.Errorf("starting DHCP client of NIC %d: %s", id, err)
+		}
+		a.clients = append(a.clients, c)
+	}
+	a.applyLocked()
+	return nil
+}
+
+// update queues u, and applies it once the links are created.
+func (a *autoConfig) update(u routeUpdate) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pending = append(a.pending, u)
+	a.applyLocked()
+}
+
+// applyLocked starts applying the pending route updates if they aren't being
+// applied.
+//
+// +checklocks:a.mu
+func (a *autoConfig) applyLocked() {
+	if a.stack == nil || a.applying || len(a.pending) == 0 {
+		return
+	}
+	a.applying = true
+	go a.apply() // S/R-SAFE: autoconfiguration isn't resumed after restore.
+}
+
+// apply applies the pending route updates until there are none.
+func (a *autoConfig) apply() {
+	for {
+		a.mu.Lock()
+		if len(a.pending) == 0 {
+			a.applying = false
+			a.mu.Unlock()
+			return
+		}
+		s, updates := a.stack, a.pending
+		a.pending = nil
+		a.mu.Unlock()
+
+		for _, u := range updates {
+			if u.remove {
+				log.Infof("Removing route %s learned from a router advertisement", u.route)
+				s.RemoveRoutes(u.route.Equal)
+				continue
+			}
+			// Router advertisements refresh the routes that they
+			// already announced.
+			if slices.ContainsFunc(s.GetRouteTable(), u.route.Equal) {
+				continue
+			}
+			log.Infof("Adding route %s learned from a router advertisement", u.route)
+			s.AddRoute(u.route)
+		}
+	}
+}
+
+// OnDuplicateAddressDetectionResult implements
+// ipv6.NDPDispatcher.OnDuplicateAddressDetectionResult.
+func (*autoConfig) OnDuplicateAddressDetectionResult(tcpip.NICID, tcpip.Address, stack.DADResult) {
+}
+
+// OnOffLinkRouteUpdated implements ipv6.NDPDispatcher.OnOffLinkRouteUpdated.
+func (a *autoConfig) OnOffLinkRouteUpdated(id tcpip.NICID, dest tcpip.Subnet, router tcpip.Address, _ header.NDPRoutePreference) {
+	a.update(routeUpdate{route: tcpip.Route{Destination: dest, Gateway: router, NIC: id}})
+}
+
+// OnOffLinkRouteInvalidated implements
+// ipv6.NDPDispatcher.OnOffLinkRouteInvalidated.
+func (a *autoConfig) OnOffLinkRouteInvalidated(id tcpip.NICID, dest tcpip.Subnet, router tcpip.Address) {
+	a.update(routeUpdate{route: tcpip.Route{Destination: dest, Gateway: router, NIC: id}, remove: true})
+}
+
+// OnOnLinkPrefixDiscovered implements
+// ipv6.NDPDispatcher.OnOnLinkPrefixDiscovered.
+func (a *autoConfig) OnOnLinkPrefixDiscovered(id tcpip.NICID, prefix tcpip.Subnet) {
+	a.update(routeUpdate{route: tcpip.Route{Destination: prefix, NIC: id}})
+}
+
+// OnOnLinkPrefixInvalidated implements
+// ipv6.NDPDispatcher.OnOnLinkPrefixInvalidated.
+func (a *autoConfig) OnOnLinkPrefixInvalidated(id tcpip.NICID, prefix tcpip.Subnet) {
+	a.update(routeUpdate{route: tcpip.Route{Destination: prefix, NIC: id}, remove: true})
+}
+
+// OnAutoGenAddress implements ipv6.NDPDispatcher.OnAutoGenAddress.
+func (*autoConfig) OnAutoGenAddress(id tcpip.NICID, addr tcpip.AddressWithPrefix) stack.AddressDispatcher {
+	log.Infof("SLAAC configured NIC %d with %s", id, addr)
+	return nil
+}
+
+// OnAutoGenAddressDeprecated implements
+// ipv6.NDPDispatcher.OnAutoGenAddressDeprecated.
+func (*autoConfig) OnAutoGenAddressDeprecated(tcpip.NICID, tcpip.AddressWithPrefix) {}
+
+// OnAutoGenAddressInvalidated implements
+// ipv6.NDPDispatcher.OnAutoGenAddressInvalidated.
+func (*autoConfig) OnAutoGenAddressInvalidated(id tcpip.NICID, addr tcpip.AddressWithPrefix) {
+	log.Infof("SLAAC removed %s from NIC %d", addr, id)
+}
+
+// OnRecursiveDNSServerOption implements
+// ipv6.NDPDispatcher.OnRecursiveDNSServerOption.
+func (*autoConfig) OnRecursiveDNSServerOption(tcpip.NICID, []tcpip.Address, time.Duration) {}
+
+// OnDNSSearchListOption implements ipv6.NDPDispatcher.OnDNSSearchListOption.
+func (*autoConfig) OnDNSSearchListOption(tcpip.NICID, []string, time.Duration) {}
+
+// OnDHCPv6Configuration implements ipv6.NDPDispatcher.OnDHCPv6Configuration.
+func (*autoConfig) OnDHCPv6Configuration(tcpip.NICID, ipv6.DHCPv6ConfigurationFromNDPRA) {}
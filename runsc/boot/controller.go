@@ -209,8 +209,9 @@ func (c *controller) registerHandlers() {
 
 	if eps, ok := l.k.RootNetworkNamespace().Stack().(*netstack.Stack); ok {
 		c.srv.Register(&Network{
-			Stack:  eps.Stack,
-			Kernel: l.k,
+			Stack:      eps.Stack,
+			Kernel:     l.k,
+			autoConfig: l.autoConfig,
 		})
 	}
 
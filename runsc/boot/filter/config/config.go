@@ -45,6 +45,7 @@ type Options struct {
 	ControllerFD          uint32
 	CgoEnabled            bool
 	PluginNetwork         bool
+	CoreDumpDir           bool
 }
 
 // isInstrumentationEnabled returns whether there are any
@@ -73,6 +74,7 @@ func (opt Options) ConfigKey() string {
 	sb.WriteString(fmt.Sprintf("TPUProxy=%t ", opt.TPUProxy))
 	sb.WriteString(fmt.Sprintf("CgoEnabled=%t ", opt.CgoEnabled))
 	sb.WriteString(fmt.Sprintf("PluginNetwork=%t ", opt.PluginNetwork))
+	sb.WriteString(fmt.Sprintf("CoreDumpDir=%t ", opt.CoreDumpDir))
 	return strings.TrimSpace(sb.String())
 }
 
@@ -161,6 +163,9 @@ func rules(opt Options, vars precompiledseccomp.Values) (seccomp.SyscallRules, s
 	if opt.PluginNetwork {
 		s.Merge(plugin.SeccompFilters())
 	}
+	if opt.CoreDumpDir {
+		s.Merge(coreDumpFilters())
+	}
 
 	s.Merge(opt.Platform.SyscallFilters(vars))
 	return s, seccomp.DenyNewExecMappings
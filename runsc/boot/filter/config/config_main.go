@@ -329,6 +329,24 @@ func selfPIDFilters(pid uint64) seccomp.SyscallRules {
 	})
 }
 
+// coreDumpFilters returns syscalls made to write core dumps to a host
+// directory.
+func coreDumpFilters() seccomp.SyscallRules {
+	return seccomp.MakeSyscallRules(map[uintptr]seccomp.SyscallRule{
+		unix.SYS_OPENAT: seccomp.PerArg{
+			seccomp.NonNegativeFD{},
+			seccomp.AnyValue{},
+			seccomp.EqualTo(unix.O_CREAT | unix.O_EXCL | unix.O_WRONLY | unix.O_NOFOLLOW | unix.O_CLOEXEC),
+			seccomp.EqualTo(0600),
+		},
+		unix.SYS_UNLINKAT: seccomp.PerArg{
+			seccomp.NonNegativeFD{},
+			seccomp.AnyValue{},
+			seccomp.EqualTo(0),
+		},
+	})
+}
+
 // hostFilesystemFilters contains syscalls that are needed by directfs.
 func hostFilesystemFilters() seccomp.SyscallRules {
 	// Directfs allows FD-based filesystem syscalls. We deny these syscalls with
@@ -31,7 +31,6 @@ import (
 	"gvisor.dev/gvisor/pkg/cleanup"
 	"gvisor.dev/gvisor/pkg/context"
 	"gvisor.dev/gvisor/pkg/coverage"
-	"gvisor.dev/gvisor/pkg/cpuid"
 	"gvisor.dev/gvisor/pkg/fd"
 	"gvisor.dev/gvisor/pkg/gomaxprocs"
 	"gvisor.dev/gvisor/pkg/log"
@@ -74,6 +73,7 @@ import (
 	"gvisor.dev/gvisor/pkg/tcpip/stack"
 	"gvisor.dev/gvisor/pkg/tcpip/transport/icmp"
 	"gvisor.dev/gvisor/pkg/tcpip/transport/raw"
+	"gvisor.dev/gvisor/pkg/tcpip/transport/sctp"
 	"gvisor.dev/gvisor/pkg/tcpip/transport/tcp"
 	"gvisor.dev/gvisor/pkg/tcpip/transport/udp"
 	"gvisor.dev/gvisor/runsc/boot/filter"
@@ -90,8 +90,11 @@ import (
 
 	// Include other supported socket providers.
 	_ "gvisor.dev/gvisor/pkg/sentry/socket/netlink"
+	_ "gvisor.dev/gvisor/pkg/sentry/socket/netlink/genetlink"
 	_ "gvisor.dev/gvisor/pkg/sentry/socket/netlink/route"
+	_ "gvisor.dev/gvisor/pkg/sentry/socket/netlink/taskstats"
 	_ "gvisor.dev/gvisor/pkg/sentry/socket/netlink/uevent"
+	_ "gvisor.dev/gvisor/pkg/sentry/socket/netlink/wireguard"
 	_ "gvisor.dev/gvisor/pkg/sentry/socket/unix"
 )
 
@@ -226,6 +229,10 @@ type Loader struct {
 	// apply to the entire pod.
 	mountHints *PodMountHints
 
+	// coreDumpDir is the host directory to which core dumps are written, or
+	// nil if core dumps are written inside the sandbox.
+	coreDumpDir *fd.FD
+
 	// productName is the value to show in
 	// /sys/devices/virtual/dmi/id/product_name.
 	productName string
@@ -275,6 +282,10 @@ type Loader struct {
 	// saveRestoreNet indicates if the saved network stack should be used
 	// during restore.
 	saveRestoreNet bool
+
+	// autoConfig configures the NICs of the root network stack with
+	// --net-config=auto. It is nil otherwise.
+	autoConfig *autoConfig
 }
 
 // execID uniquely identifies a sentry process that is executed in a container.
@@ -376,6 +387,10 @@ type Args struct {
 	NvidiaDriverVersion nvconf.DriverVersion
 	// HostTHP contains host transparent hugepage settings.
 	HostTHP HostTHP
+	// CoreDumpDirFD is the file descriptor of the host directory to which
+	// core dumps are written, or -1 if core dumps are written inside the
+	// sandbox.
+	CoreDumpDirFD int
 
 	SaveFDs []*fd.FD
 }
@@ -563,7 +578,10 @@ func New(args Args) (*Loader, error) {
 		return nil, fmt.Errorf("getting root credentials")
 	}
 	// Create root network namespace/stack.
-	netns, err := newRootNetworkNamespace(args.Conf, tk, creds.UserNamespace)
+	if args.Conf.Network == config.NetworkSandbox && args.Conf.NetConfig == config.NetConfigAuto {
+		l.autoConfig = &autoConfig{}
+	}
+	netns, err := newRootNetworkNamespace(args.Conf, tk, creds.UserNamespace, l.autoConfig)
 	if err != nil {
 		return nil, fmt.Errorf("creating network: %w", err)
 	}
@@ -615,7 +633,7 @@ func New(args Args) (*Loader, error) {
 		DisconnectOnSave: args.Conf.NetDisconnectOk,
 	}
 	if err = l.k.Init(kernel.InitKernelArgs{
-		FeatureSet:           cpuid.HostFeatureSet().Fixed(),
+		FeatureSet:           platform.FeatureSet(l.k.Platform),
 		Timekeeper:           tk,
 		RootUserNamespace:    creds.UserNamespace,
 		RootNetworkNamespace: netns,
@@ -672,6 +690,11 @@ func New(args Args) (*Loader, error) {
 	defer hostFilesystem.DecRef(l.k.SupervisorContext())
 	l.k.SetHostMount(l.k.VFS().NewDisconnectedMount(hostFilesystem, nil, &vfs.MountOptions{}))
 
+	if args.CoreDumpDirFD >= 0 {
+		l.coreDumpDir = fd.New(args.CoreDumpDirFD)
+		l.k.SetCoreDumpHostDir(l.coreDumpDir)
+	}
+
 	if args.PodInitConfigFD >= 0 {
 		if err := setupSeccheck(args.PodInitConfigFD, args.SinkFDs); err != nil {
 			log.Warningf("unable to configure event session: %v", err)
@@ -913,6 +936,7 @@ func (l *Loader) installSeccompFilters() error {
 			ControllerFD:          uint32(l.ctrl.srv.FD()),
 			CgoEnabled:            config.CgoEnabled,
 			PluginNetwork:         l.root.conf.Network == config.NetworkPlugin,
+			CoreDumpDir:           l.coreDumpDir != nil,
 		}
 		if err := filter.Install(opts); err != nil {
 			return fmt.Errorf("installing seccomp filters: %w", err)
@@ -1537,7 +1561,7 @@ func (l *Loader) WaitExit() linux.WaitStatus {
 	return l.k.GlobalInit().ExitStatus()
 }
 
-func newRootNetworkNamespace(conf *config.Config, clock tcpip.Clock, userns *auth.UserNamespace) (*inet.Namespace, error) {
+func newRootNetworkNamespace(conf *config.Config, clock tcpip.Clock, userns *auth.UserNamespace, autoConfig *autoConfig) (*inet.Namespace, error) {
 	// Create an empty network stack because the network namespace may be empty at
 	// this point. Netns is configured before Run() is called. Netstack is
 	// configured using a control uRPC message. Host network is configured inside
@@ -1554,7 +1578,11 @@ func newRootNetworkNamespace(conf *config.Config, clock tcpip.Clock, userns *aut
 		return inet.NewRootNamespace(hostinet.NewStack(), nil, userns), nil
 
 	case config.NetworkNone, config.NetworkSandbox:
-		s, err := newEmptySandboxNetworkStack(clock, conf.AllowPacketEndpointWrite)
+		var ipv6Opts ipv6.Options
+		if autoConfig != nil {
+			ipv6Opts = autoConfig.ipv6Options()
+		}
+		s, err := newEmptySandboxNetworkStack(clock, conf.AllowPacketEndpointWrite, ipv6Opts)
 		if err != nil {
 			return nil, err
 		}
@@ -1572,13 +1600,14 @@ func newRootNetworkNamespace(conf *config.Config, clock tcpip.Clock, userns *aut
 
 }
 
-func newEmptySandboxNetworkStack(clock tcpip.Clock, allowPacketEndpointWrite bool) (*netstack.Stack, error) {
-	netProtos := []stack.NetworkProtocolFactory{ipv4.NewProtocol, ipv6.NewProtocol, arp.NewProtocol}
+func newEmptySandboxNetworkStack(clock tcpip.Clock, allowPacketEndpointWrite bool, ipv6Opts ipv6.Options) (*netstack.Stack, error) {
+	netProtos := []stack.NetworkProtocolFactory{ipv4.NewProtocol, ipv6.NewProtocolWithOptions(ipv6Opts), arp.NewProtocol}
 	transProtos := []stack.TransportProtocolFactory{
 		tcp.NewProtocol,
 		udp.NewProtocol,
 		icmp.NewProtocol4,
 		icmp.NewProtocol6,
+		sctp.NewProtocol,
 	}
 	s := netstack.Stack{Stack: stack.New(stack.Options{
 		NetworkProtocols:   netProtos,
@@ -1633,7 +1662,7 @@ type sandboxNetstackCreator struct {
 
 // CreateStack implements kernel.NetworkStackCreator.CreateStack.
 func (f *sandboxNetstackCreator) CreateStack() (inet.Stack, error) {
-	s, err := newEmptySandboxNetworkStack(f.clock, f.allowPacketEndpointWrite)
+	s, err := newEmptySandboxNetworkStack(f.clock, f.allowPacketEndpointWrite, ipv6.Options{})
 	if err != nil {
 		return nil, err
 	}
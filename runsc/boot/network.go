@@ -24,6 +24,7 @@ import (
 	"syscall"
 
 	"golang.org/x/sys/unix"
+	"gvisor.dev/gvisor/pkg/abi/linux"
 	"gvisor.dev/gvisor/pkg/hostos"
 	"gvisor.dev/gvisor/pkg/log"
 	"gvisor.dev/gvisor/pkg/sentry/kernel"
@@ -33,7 +34,11 @@ import (
 	"gvisor.dev/gvisor/pkg/tcpip/link/ethernet"
 	"gvisor.dev/gvisor/pkg/tcpip/link/fdbased"
 	"gvisor.dev/gvisor/pkg/tcpip/link/loopback"
+	"gvisor.dev/gvisor/pkg/tcpip/link/qdisc/etf"
 	"gvisor.dev/gvisor/pkg/tcpip/link/qdisc/fifo"
+	"gvisor.dev/gvisor/pkg/tcpip/link/qdisc/fq"
+	"gvisor.dev/gvisor/pkg/tcpip/link/qdisc/htb"
+	"gvisor.dev/gvisor/pkg/tcpip/link/qdisc/tbf"
 	"gvisor.dev/gvisor/pkg/tcpip/link/sniffer"
 	"gvisor.dev/gvisor/pkg/tcpip/link/xdp"
 	"gvisor.dev/gvisor/pkg/tcpip/network/ipv4"
@@ -77,6 +82,9 @@ type Network struct {
 	// PluginStack is a third-party network stack to use in place of
 	// netstack when non-nil.
 	PluginStack plugin.PluginStack
+
+	// autoConfig configures the fdbased and XDP links when non-nil.
+	autoConfig *autoConfig
 }
 
 // Route represents a route in the network stack.
@@ -110,6 +118,7 @@ type FDBasedLink struct {
 	RXChecksumOffload bool
 	LinkAddress       net.HardwareAddr
 	QDisc             config.QueueingDiscipline
+	QDiscParams       config.QDiscParams
 	Neighbors         []Neighbor
 
 	// NumChannels controls how many underlying FDs are to be used to
@@ -144,6 +153,7 @@ type XDPLink struct {
 	RXChecksumOffload bool
 	LinkAddress       net.HardwareAddr
 	QDisc             config.QueueingDiscipline
+	QDiscParams       config.QDiscParams
 	Neighbors         []Neighbor
 	GVisorGRO         bool
 	Bind              BindOpt
@@ -287,6 +297,9 @@ func (n *Network) CreateLinksAndRoutes(args *CreateLinksAndRoutesArgs, _ *struct
 
 	nicids := make(map[string]tcpip.NICID)
 
+	// Collect the NICs that configure themselves.
+	var autoNICs []tcpip.NICID
+
 	// Collect routes from all links.
 	var routes []tcpip.Route
 
@@ -341,6 +354,7 @@ func (n *Network) CreateLinksAndRoutes(args *CreateLinksAndRoutesArgs, _ *struct
 		for _, link := range args.FDBasedLinks {
 			nicID := n.Stack.NextNICID()
 			nicids[link.Name] = nicID
+			autoNICs = append(autoNICs, nicID)
 
 			FDs := make([]int, 0, link.NumChannels)
 			for j := 0; j < link.NumChannels; j++ {
@@ -391,12 +405,9 @@ func (n *Network) CreateLinksAndRoutes(args *CreateLinksAndRoutesArgs, _ *struct
 				linkEP = sniffer.New(linkEP)
 			}
 
-			var qDisc stack.QueueingDiscipline
-			switch link.QDisc {
-			case config.QDiscNone:
-			case config.QDiscFIFO:
-				log.Infof("Enabling FIFO QDisc on %q", link.Name)
-				qDisc = fifo.New(linkEP, runtime.GOMAXPROCS(0), 1000)
+			qDisc, err := n.newQDisc(linkEP, link.Name, link.QDisc, link.QDiscParams)
+			if err != nil {
+				return err
 			}
 
 			log.Infof("Enabling interface %q with id %d on addresses %+v (%v) w/ %d channels", link.Name, nicID, link.Addresses, mac, link.NumChannels)
@@ -430,6 +441,7 @@ func (n *Network) CreateLinksAndRoutes(args *CreateLinksAndRoutesArgs, _ *struct
 		link := args.XDPLinks[0]
 		nicID := n.Stack.NextNICID()
 		nicids[link.Name] = nicID
+		autoNICs = append(autoNICs, nicID)
 
 		// Get the AF_XDP socket.
 		oldFD := args.FilePayload.Files[fdOffset].Fd()
@@ -484,12 +496,9 @@ func (n *Network) CreateLinksAndRoutes(args *CreateLinksAndRoutesArgs, _ *struct
 			linkEP = sniffer.New(linkEP)
 		}
 
-		var qDisc stack.QueueingDiscipline
-		switch link.QDisc {
-		case config.QDiscNone:
-		case config.QDiscFIFO:
-			log.Infof("Enabling FIFO QDisc on %q", link.Name)
-			qDisc = fifo.New(linkEP, runtime.GOMAXPROCS(0), 1000)
+		qDisc, err := n.newQDisc(linkEP, link.Name, link.QDisc, link.QDiscParams)
+		if err != nil {
+			return err
 		}
 
 		log.Infof("Enabling interface %q with id %d on addresses %+v (%v) w/ %d channels", link.Name, nicID, link.Addresses, mac, link.NumChannels)
@@ -544,6 +553,13 @@ func (n *Network) CreateLinksAndRoutes(args *CreateLinksAndRoutesArgs, _ *struct
 	log.Infof("Setting routes %+v", routes)
 	n.Stack.SetRouteTable(routes)
 
+	if n.autoConfig != nil {
+		log.Infof("Starting autoconfiguration of NICs %v", autoNICs)
+		if err := n.autoConfig.start(n.Stack, autoNICs); err != nil {
+			return err
+		}
+	}
+
 	// Set NAT table rules if necessary.
 	if args.NATBlob {
 		log.Infof("Replacing NAT table")
@@ -560,6 +576,66 @@ func (n *Network) CreateLinksAndRoutes(args *CreateLinksAndRoutesArgs, _ *struct
 	return nil
 }
 
+// htbClass is the class of the htb queueing discipline, which is 1:1 like in
+// the usual tc(8) configurations.
+const htbClass = 1<<16 | 1
+
+// newQDisc creates the queueing discipline of a link, or returns nil if
+// packets are passed directly to the link endpoint.
+func (n *Network) newQDisc(linkEP stack.LinkEndpoint, name string, kind config.QueueingDiscipline, params config.QDiscParams) (stack.QueueingDiscipline, error) {
+	switch kind {
+	case config.QDiscNone:
+		return nil, nil
+	case config.QDiscFIFO:
+		log.Infof("Enabling FIFO QDisc on %q", name)
+		return fifo.New(linkEP, runtime.GOMAXPROCS(0), 1000), nil
+	case config.QDiscTBF:
+		log.Infof("Enabling TBF QDisc on %q with %+v", name, params)
+		qDisc, err := tbf.New(linkEP, n.Stack.Clock(), tbf.Options{
+			Rate:  params.Rate,
+			Burst: params.Burst,
+			Limit: params.Limit,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("creating TBF QDisc on %q, rate, burst and limit are required: %v", name, err)
+		}
+		return qDisc, nil
+	case config.QDiscFQ:
+		log.Infof("Enabling FQ QDisc on %q with %+v", name, params)
+		return fq.New(linkEP, n.Stack.Clock(), fq.Options{
+			Limit:     params.Limit,
+			FlowLimit: params.FlowLimit,
+			Quantum:   params.Quantum,
+			MaxRate:   params.MaxRate,
+		}), nil
+	case config.QDiscHTB:
+		log.Infof("Enabling HTB QDisc on %q with %+v", name, params)
+		qDisc := htb.New(linkEP, n.Stack.Clock(), htb.Options{DefaultClass: htbClass})
+		if err := qDisc.AddClass(htbClass, htb.ClassOptions{
+			Rate:    params.Rate,
+			Ceil:    params.Ceil,
+			Burst:   params.Burst,
+			Quantum: params.Quantum,
+			Limit:   params.Limit,
+		}); err != nil {
+			qDisc.Close()
+			return nil, fmt.Errorf("creating HTB QDisc on %q, rate is required: %v", name, err)
+		}
+		return qDisc, nil
+	case config.QDiscETF:
+		log.Infof("Enabling ETF QDisc on %q with %+v", name, params)
+		// The departure times are in the clock of the stack, which is
+		// the monotonic clock of the sandbox.
+		return etf.New(linkEP, n.Stack.Clock(), etf.Options{
+			ClockID:      linux.CLOCK_MONOTONIC,
+			Delta:        params.Delta,
+			DeadlineMode: params.DeadlineMode,
+			Limit:        params.Limit,
+		}), nil
+	}
+	panic(fmt.Sprintf("invalid qdisc %d", kind))
+}
+
 // createNICWithAddrs creates a NIC in the network stack and adds the given
 // addresses.
 func (n *Network) createNICWithAddrs(id tcpip.NICID, ep stack.LinkEndpoint, opts stack.NICOptions, addrs []IPWithPrefix) error {
@@ -257,6 +257,9 @@ func (r *restorer) restore(l *Loader) error {
 	if err := l.k.LoadFrom(ctx, r.stateFile, r.asyncMFLoader == nil, nil, oldInetStack, time.NewCalibratedClocks(), &vfs.CompleteRestoreOptions{}, l.saveRestoreNet); err != nil {
 		return fmt.Errorf("failed to load kernel: %w", err)
 	}
+	if l.coreDumpDir != nil {
+		l.k.SetCoreDumpHostDir(l.coreDumpDir)
+	}
 
 	if r.asyncMFLoader != nil {
 		if r.background {
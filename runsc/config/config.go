@@ -19,11 +19,14 @@ package config
 
 import (
 	"fmt"
+	"math"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"gvisor.dev/gvisor/pkg/log"
 	"gvisor.dev/gvisor/pkg/refs"
@@ -81,6 +84,11 @@ type Config struct {
 	// CoverageReport is the path to write Go coverage information, if not empty.
 	CoverageReport string `flag:"coverage-report"`
 
+	// CoreDumpDir is the host directory to which application core dumps are
+	// written, if not empty. Otherwise, core dumps are written inside the
+	// sandbox as specified by /proc/sys/kernel/core_pattern.
+	CoreDumpDir string `flag:"core-dump-dir"`
+
 	// DebugLogFormat is the log format for debug.
 	DebugLogFormat string `flag:"debug-log-format"`
 
@@ -114,6 +122,10 @@ type Config struct {
 	// Network indicates what type of network to use.
 	Network NetworkType `flag:"network"`
 
+	// NetConfig indicates how the interfaces of the sandbox network are
+	// configured.
+	NetConfig NetConfigMode `flag:"net-config"`
+
 	// EnableRaw indicates whether raw sockets should be enabled. Raw
 	// sockets are disabled by stripping CAP_NET_RAW from the list of
 	// capabilities.
@@ -142,6 +154,10 @@ type Config struct {
 	// for non-loopback interfaces.
 	QDisc QueueingDiscipline `flag:"qdisc"`
 
+	// QDiscOptions holds the parameters of the shaping queueing disciplines
+	// and the per-interface overrides of QDisc.
+	QDiscOptions QDiscOptions `flag:"qdisc-options"`
+
 	// LogPackets indicates that all network packets should be logged.
 	LogPackets bool `flag:"log-packets"`
 
@@ -404,6 +420,14 @@ func (c *Config) validate() error {
 	if c.NumNetworkChannels <= 0 {
 		return fmt.Errorf("num_network_channels must be > 0, got: %d", c.NumNetworkChannels)
 	}
+	if c.NetConfig == NetConfigAuto {
+		if c.Network != NetworkSandbox {
+			return fmt.Errorf("net-config=auto requires network=sandbox, got network=%v", c.Network)
+		}
+		if c.XDP.Mode != XDPModeOff {
+			return fmt.Errorf("net-config=auto is incompatible with XDP")
+		}
+	}
 	// Require profile flags to explicitly opt-in to profiling with
 	// -profile rather than implying it since these options have security
 	// implications.
@@ -668,6 +692,55 @@ func (n NetworkType) String() string {
 	panic(fmt.Sprintf("Invalid network type %d", n))
 }
 
+// NetConfigMode tells how the interfaces of the sandbox network are
+// configured.
+type NetConfigMode int
+
+const (
+	// NetConfigHost copies the addresses and routes of the host interfaces
+	// when the sandbox starts.
+	NetConfigHost NetConfigMode = iota
+
+	// NetConfigAuto lets the interfaces configure themselves with DHCPv4 and
+	// IPv6 stateless address autoconfiguration, and keeps their
+	// configuration up to date with the leases and router advertisements
+	// that they receive.
+	NetConfigAuto
+)
+
+func netConfigModePtr(v NetConfigMode) *NetConfigMode {
+	return &v
+}
+
+// Set implements flag.Value. Set(String()) should be idempotent.
+func (n *NetConfigMode) Set(v string) error {
+	switch v {
+	case "host":
+		*n = NetConfigHost
+	case "auto":
+		*n = NetConfigAuto
+	default:
+		return fmt.Errorf("invalid net-config mode %q", v)
+	}
+	return nil
+}
+
+// Get implements flag.Value.
+func (n *NetConfigMode) Get() any {
+	return *n
+}
+
+// String implements flag.Value.
+func (n NetConfigMode) String() string {
+	switch n {
+	case NetConfigHost:
+		return "host"
+	case NetConfigAuto:
+		return "auto"
+	}
+	panic(fmt.Sprintf("Invalid net-config mode %d", n))
+}
+
 // QueueingDiscipline is used to specify the kind of Queueing Discipline to
 // apply for a give FDBasedLink.
 type QueueingDiscipline int
@@ -678,6 +751,22 @@ const (
 
 	// QDiscFIFO applies a simple fifo based queue to the underlying FD.
 	QDiscFIFO
+
+	// QDiscTBF limits the rate of the underlying FD with a token bucket
+	// filter.
+	QDiscTBF
+
+	// QDiscFQ paces the flows sent through the underlying FD and shares it
+	// fairly between them.
+	QDiscFQ
+
+	// QDiscHTB limits the rate of the underlying FD with a hierarchical
+	// token bucket holding a single class.
+	QDiscHTB
+
+	// QDiscETF sends the packets written to the underlying FD at the
+	// departure time set with SCM_TXTIME.
+	QDiscETF
 )
 
 func queueingDisciplinePtr(v QueueingDiscipline) *QueueingDiscipline {
@@ -691,6 +780,14 @@ func (q *QueueingDiscipline) Set(v string) error {
 		*q = QDiscNone
 	case "fifo":
 		*q = QDiscFIFO
+	case "tbf":
+		*q = QDiscTBF
+	case "fq":
+		*q = QDiscFQ
+	case "htb":
+		*q = QDiscHTB
+	case "etf":
+		*q = QDiscETF
 	default:
 		return fmt.Errorf("invalid qdisc %q", v)
 	}
@@ -709,10 +806,283 @@ func (q QueueingDiscipline) String() string {
 		return "none"
 	case QDiscFIFO:
 		return "fifo"
+	case QDiscTBF:
+		return "tbf"
+	case QDiscFQ:
+		return "fq"
+	case QDiscHTB:
+		return "htb"
+	case QDiscETF:
+		return "etf"
 	}
 	panic(fmt.Sprintf("Invalid qdisc %d", q))
 }
 
+// QDiscParams holds the parameters of the queueing discipline of an
+// interface. Rates are in bytes per second and sizes in bytes. Zero values
+// select the defaults of the queueing discipline.
+type QDiscParams struct {
+	// Kind replaces the QDisc flag for the interface if HasKind is true.
+	Kind    QueueingDiscipline
+	HasKind bool
+
+	// Rate is the rate of tbf and of the class of htb.
+	Rate uint64
+
+	// Ceil is the ceil of the class of htb.
+	Ceil uint64
+
+	// MaxRate is the maximum rate of a flow of fq.
+	MaxRate uint64
+
+	// Burst is the burst of tbf and of the class of htb.
+	Burst uint32
+
+	// Limit is the number of bytes queued by tbf and the number of packets
+	// queued by fq, etf and by the class of htb.
+	Limit uint32
+
+	// Quantum is the quantum of fq and of the class of htb.
+	Quantum uint32
+
+	// FlowLimit is the number of packets queued in a flow of fq.
+	FlowLimit uint32
+
+	// Delta is the time before their departure time at which etf sends
+	// packets.
+	Delta time.Duration
+
+	// DeadlineMode makes etf treat departure times as deadlines.
+	DeadlineMode bool
+}
+
+// QDiscOptions holds the parameters of the queueing disciplines indexed by
+// interface name. The parameters of the empty name apply to all interfaces
+// that don't have their own.
+//
+// The flag is a list of entries separated by ';' in the form
+// [<interface>:]<key>=<value>,... The keys are kind, rate, ceil, maxrate,
+// burst, limit, quantum, flow_limit, delta and deadline_mode. Rates, sizes and
+// times accept the units of tc(8), e.g. "10mbit", "32kb" and "500us". Rates
+// without unit are in bits per second, and times without unit in nanoseconds
+// like the delta of the etf qdisc of tc(8).
+type QDiscOptions map[string]QDiscParams
+
+// Lookup returns the queueing discipline and its parameters for the given
+// interface, where def is the default queueing discipline.
+func (o QDiscOptions) Lookup(iface string, def QueueingDiscipline) (QueueingDiscipline, QDiscParams) {
+	params, ok := o[iface]
+	if !ok {
+		params = o[""]
+	}
+	if params.HasKind {
+		return params.Kind, params
+	}
+	return def, params
+}
+
+// Set implements flag.Value. Set(String()) should be idempotent.
+func (o *QDiscOptions) Set(v string) error {
+	opts := make(QDiscOptions)
+	for _, entry := range strings.Split(v, ";") {
+		if entry == "" {
+			continue
+		}
+		var iface string
+		if i := strings.IndexByte(entry, ':'); i >= 0 {
+			iface, entry = entry[:i], entry[i+1:]
+			if iface == "" {
+				return fmt.Errorf("invalid qdisc options %q: empty interface name", v)
+			}
+		}
+		if _, ok := opts[iface]; ok {
+			return fmt.Errorf("invalid qdisc options %q: duplicate interface %q", v, iface)
+		}
+		var params QDiscParams
+		for _, kv := range strings.Split(entry, ",") {
+			key, val, ok := strings.Cut(kv, "=")
+			if !ok {
+				return fmt.Errorf("invalid qdisc option %q: expected <key>=<value>", kv)
+			}
+			var err error
+			switch key {
+			case "kind":
+				err = params.Kind.Set(val)
+				params.HasKind = true
+			case "rate":
+				params.Rate, err = parseRate(val)
+			case "ceil":
+				params.Ceil, err = parseRate(val)
+			case "maxrate":
+				params.MaxRate, err = parseRate(val)
+			case "burst":
+				params.Burst, err = parseSize(val)
+			case "limit":
+				params.Limit, err = parseSize(val)
+			case "quantum":
+				params.Quantum, err = parseSize(val)
+			case "flow_limit":
+				params.FlowLimit, err = parseSize(val)
+			case "delta":
+				params.Delta, err = parseTime(val)
+			case "deadline_mode":
+				params.DeadlineMode, err = strconv.ParseBool(val)
+			default:
+				return fmt.Errorf("invalid qdisc option %q: unknown key %q", kv, key)
+			}
+			if err != nil {
+				return fmt.Errorf("invalid qdisc option %q: %w", kv, err)
+			}
+		}
+		opts[iface] = params
+	}
+	*o = opts
+	return nil
+}
+
+// Get implements flag.Value.
+func (o *QDiscOptions) Get() any {
+	return *o
+}
+
+// String implements flag.Value.
+func (o *QDiscOptions) String() string {
+	ifaces := make([]string, 0, len(*o))
+	for iface := range *o {
+		ifaces = append(ifaces, iface)
+	}
+	sort.Strings(ifaces)
+	entries := make([]string, 0, len(ifaces))
+	for _, iface := range ifaces {
+		params := (*o)[iface]
+		var kvs []string
+		if params.HasKind {
+			kvs = append(kvs, "kind="+params.Kind.String())
+		}
+		for _, rate := range []struct {
+			key string
+			val uint64
+		}{{"rate", params.Rate}, {"ceil", params.Ceil}, {"maxrate", params.MaxRate}} {
+			if rate.val != 0 {
+				kvs = append(kvs, fmt.Sprintf("%s=%dbps", rate.key, rate.val))
+			}
+		}
+		for _, size := range []struct {
+			key string
+			val uint32
+		}{{"burst", params.Burst}, {"limit", params.Limit}, {"quantum", params.Quantum}, {"flow_limit", params.FlowLimit}} {
+			if size.val != 0 {
+				kvs = append(kvs, fmt.Sprintf("%s=%d", size.key, size.val))
+			}
+		}
+		if params.Delta != 0 {
+			kvs = append(kvs, fmt.Sprintf("delta=%d", params.Delta.Nanoseconds()))
+		}
+		if params.DeadlineMode {
+			kvs = append(kvs, "deadline_mode=true")
+		}
+		entry := strings.Join(kvs, ",")
+		if iface != "" {
+			entry = iface + ":" + entry
+		}
+		entries = append(entries, entry)
+	}
+	return strings.Join(entries, ";")
+}
+
+// splitUnit splits a number from its unit.
+func splitUnit(v string) (uint64, string, error) {
+	i := strings.IndexFunc(v, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	if i < 0 {
+		i = len(v)
+	}
+	n, err := strconv.ParseUint(v[:i], 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid number %q", v)
+	}
+	return n, strings.ToLower(v[i:]), nil
+}
+
+// parseRate parses a rate like tc(8), and returns it in bytes per second.
+func parseRate(v string) (uint64, error) {
+	n, unit, err := splitUnit(v)
+	if err != nil {
+		return 0, err
+	}
+	bits := map[string]uint64{
+		"":     1,
+		"bit":  1,
+		"kbit": 1e3,
+		"mbit": 1e6,
+		"gbit": 1e9,
+		"bps":  8,
+		"kbps": 8e3,
+		"mbps": 8e6,
+		"gbps": 8e9,
+	}
+	mult, ok := bits[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown rate unit %q", unit)
+	}
+	return n * mult / 8, nil
+}
+
+// parseSize parses a size like tc(8), and returns it in bytes.
+func parseSize(v string) (uint32, error) {
+	n, unit, err := splitUnit(v)
+	if err != nil {
+		return 0, err
+	}
+	bytes := map[string]uint64{
+		"":   1,
+		"b":  1,
+		"k":  1 << 10,
+		"kb": 1 << 10,
+		"m":  1 << 20,
+		"mb": 1 << 20,
+		"g":  1 << 30,
+		"gb": 1 << 30,
+	}
+	mult, ok := bytes[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown size unit %q", unit)
+	}
+	if n*mult > 1<<32-1 {
+		return 0, fmt.Errorf("size %q is too large", v)
+	}
+	return uint32(n * mult), nil
+}
+
+// parseTime parses a time like tc(8), and returns it. Times without unit are
+// in nanoseconds.
+func parseTime(v string) (time.Duration, error) {
+	n, unit, err := splitUnit(v)
+	if err != nil {
+		return 0, err
+	}
+	units := map[string]time.Duration{
+		"":     time.Nanosecond,
+		"ns":   time.Nanosecond,
+		"nsec": time.Nanosecond,
+		"us":   time.Microsecond,
+		"usec": time.Microsecond,
+		"ms":   time.Millisecond,
+		"msec": time.Millisecond,
+		"s":    time.Second,
+		"sec":  time.Second,
+	}
+	mult, ok := units[unit]
+	if !ok {
+		return 0, fmt.Errorf("unknown time unit %q", unit)
+	}
+	if n > uint64(math.MaxInt64/mult) {
+		return 0, fmt.Errorf("time %q is too large", v)
+	}
+	return time.Duration(n) * mult, nil
+}
+
 func leakModePtr(v refs.LeakMode) *refs.LeakMode {
 	return &v
 }
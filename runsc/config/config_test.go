@@ -19,6 +19,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"gvisor.dev/gvisor/runsc/flag"
@@ -201,6 +202,21 @@ func TestInvalidFlags(t *testing.T) {
 			value: "invalid",
 			error: "invalid qdisc",
 		},
+		{
+			name:  "qdisc-options",
+			value: "rate=10foo",
+			error: "unknown rate unit",
+		},
+		{
+			name:  "qdisc-options",
+			value: "eth0:kind=tbf,invalid=1",
+			error: "unknown key",
+		},
+		{
+			name:  "qdisc-options",
+			value: "kind=etf,delta=1min",
+			error: "unknown time unit",
+		},
 		{
 			name:  "watchdog-action",
 			value: "invalid",
@@ -242,6 +258,59 @@ func TestInvalidFlags(t *testing.T) {
 	}
 }
 
+func TestQDiscOptions(t *testing.T) {
+	var opts QDiscOptions
+	if err := opts.Set("kind=tbf,rate=8mbit,burst=32kb,limit=64kb;eth1:kind=fq,maxrate=1mbps,flow_limit=50;eth2:kind=etf,delta=500us,deadline_mode=true"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	want := QDiscOptions{
+		"": {
+			Kind:    QDiscTBF,
+			HasKind: true,
+			Rate:    1000000,
+			Burst:   32 << 10,
+			Limit:   64 << 10,
+		},
+		"eth1": {
+			Kind:      QDiscFQ,
+			HasKind:   true,
+			MaxRate:   1000000,
+			FlowLimit: 50,
+		},
+		"eth2": {
+			Kind:         QDiscETF,
+			HasKind:      true,
+			Delta:        500 * time.Microsecond,
+			DeadlineMode: true,
+		},
+	}
+	if diff := cmp.Diff(want, opts); diff != "" {
+		t.Errorf("Set: unexpected options (-want +got):\n%s", diff)
+	}
+
+	for _, tc := range []struct {
+		iface string
+		kind  QueueingDiscipline
+	}{
+		{iface: "eth0", kind: QDiscTBF},
+		{iface: "eth1", kind: QDiscFQ},
+		{iface: "eth2", kind: QDiscETF},
+	} {
+		if kind, params := opts.Lookup(tc.iface, QDiscFIFO); kind != tc.kind || params != want[tc.iface] && params != want[""] {
+			t.Errorf("Lookup(%q, fifo) = (%v, %+v), want kind = %v", tc.iface, kind, params, tc.kind)
+		}
+	}
+
+	// String returns a value that Set accepts.
+	var got QDiscOptions
+	if err := got.Set(opts.String()); err != nil {
+		t.Fatalf("Set(%q): %v", opts.String(), err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Set(%q): unexpected options (-want +got):\n%s", opts.String(), diff)
+	}
+}
+
 func TestValidationFail(t *testing.T) {
 	for _, tc := range []struct {
 		name  string
@@ -202,7 +202,9 @@ func createInterfacesAndRoutesFromNS(conn *urpc.Client, nsPath string, conf *con
 			}
 			ipAddrs = append(ipAddrs, ipNet)
 		}
-		if len(ipAddrs) == 0 {
+		// Interfaces that configure themselves don't need addresses.
+		autoConfig := conf.NetConfig == config.NetConfigAuto
+		if len(ipAddrs) == 0 && !autoConfig {
 			log.Warningf("No usable IP addresses found for interface %q, skipping", iface.Name)
 			continue
 		}
@@ -226,9 +228,15 @@ func createInterfacesAndRoutesFromNS(conn *urpc.Client, nsPath string, conf *con
 
 		// Scrape the routes before removing the address, since that
 		// will remove the routes as well.
-		routes, defv4, defv6, err := routesForIface(iface, disableIPv6)
-		if err != nil {
-			return fmt.Errorf("getting routes for interface %q: %v", iface.Name, err)
+		var (
+			routes       []boot.Route
+			defv4, defv6 *boot.Route
+		)
+		if !autoConfig {
+			routes, defv4, defv6, err = routesForIface(iface, disableIPv6)
+			if err != nil {
+				return fmt.Errorf("getting routes for interface %q: %v", iface.Name, err)
+			}
 		}
 		if defv4 != nil {
 			if !args.Defaultv4Gateway.Route.Empty() {
@@ -257,8 +265,12 @@ func createInterfacesAndRoutesFromNS(conn *urpc.Client, nsPath string, conf *con
 		// and remove them from the host.
 		var addresses []boot.IPWithPrefix
 		for _, addr := range ipAddrs {
-			prefix, _ := addr.Mask.Size()
-			addresses = append(addresses, boot.IPWithPrefix{Address: addr.IP, PrefixLen: prefix})
+			// Interfaces that configure themselves only have
+			// their addresses removed from the host.
+			if !autoConfig {
+				prefix, _ := addr.Mask.Size()
+				addresses = append(addresses, boot.IPWithPrefix{Address: addr.IP, PrefixLen: prefix})
+			}
 
 			// Steal IP address from NIC.
 			if err := removeAddress(ifaceLink, addr.String()); err != nil {
@@ -273,6 +285,7 @@ func createInterfacesAndRoutesFromNS(conn *urpc.Client, nsPath string, conf *con
 			}
 		}
 
+		qDisc, qDiscParams := conf.QDiscOptions.Lookup(iface.Name, conf.QDisc)
 		if conf.XDP.Mode == config.XDPModeNS {
 			xdpSockFDs, err := createSocketXDP(iface)
 			if err != nil {
@@ -286,7 +299,8 @@ func createInterfacesAndRoutesFromNS(conn *urpc.Client, nsPath string, conf *con
 				TXChecksumOffload: conf.TXChecksumOffload,
 				RXChecksumOffload: conf.RXChecksumOffload,
 				NumChannels:       conf.NumNetworkChannels,
-				QDisc:             conf.QDisc,
+				QDisc:             qDisc,
+				QDiscParams:       qDiscParams,
 				Neighbors:         neighbors,
 				LinkAddress:       linkAddress,
 				Addresses:         addresses,
@@ -301,7 +315,8 @@ func createInterfacesAndRoutesFromNS(conn *urpc.Client, nsPath string, conf *con
 				RXChecksumOffload:    conf.RXChecksumOffload,
 				NumChannels:          conf.NumNetworkChannels,
 				ProcessorsPerChannel: conf.NetworkProcessorsPerChannel,
-				QDisc:                conf.QDisc,
+				QDisc:                qDisc,
+				QDiscParams:          qDiscParams,
 				Neighbors:            neighbors,
 				LinkAddress:          linkAddress,
 				Addresses:            addresses,
@@ -909,6 +909,9 @@ func (s *Sandbox) createSandboxProcess(conf *config.Config, args *Args, startSyn
 	if err := donations.OpenAndDonate("user-log-fd", args.UserLog, os.O_CREATE|os.O_WRONLY|os.O_APPEND); err != nil {
 		return err
 	}
+	if err := donations.OpenAndDonate("core-dump-dir-fd", conf.CoreDumpDir, unix.O_RDONLY|unix.O_DIRECTORY); err != nil {
+		return fmt.Errorf("donating core dump directory: %w", err)
+	}
 	const profFlags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
 	profile.UpdatePaths(conf, s.StartTime)
 	if err := donations.OpenAndDonate("profile-block-fd", conf.ProfileBlock, profFlags); err != nil {
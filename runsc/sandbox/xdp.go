@@ -214,6 +214,7 @@ func prepareRedirectInterfaceArgs(bind boot.BindOpt, conf *config.Config) (boot.
 		}
 		linkAddress := ifaceLink.Attrs().HardwareAddr
 
+		qDisc, qDiscParams := conf.QDiscOptions.Lookup(iface.Name, conf.QDisc)
 		xdplink := boot.XDPLink{
 			Name:              iface.Name,
 			InterfaceIndex:    iface.Index,
@@ -221,7 +222,8 @@ func prepareRedirectInterfaceArgs(bind boot.BindOpt, conf *config.Config) (boot.
 			TXChecksumOffload: conf.TXChecksumOffload,
 			RXChecksumOffload: conf.RXChecksumOffload,
 			NumChannels:       conf.NumNetworkChannels,
-			QDisc:             conf.QDisc,
+			QDisc:             qDisc,
+			QDiscParams:       qDiscParams,
 			Neighbors:         neighbors,
 			LinkAddress:       linkAddress,
 			Addresses:         []boot.IPWithPrefix{addr},
@@ -0,0 +1,187 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#include <elf.h>
+#include <fcntl.h>
+#include <signal.h>
+#include <string.h>
+#include <sys/resource.h>
+#include <sys/stat.h>
+#include <sys/types.h>
+#include <sys/wait.h>
+#include <unistd.h>
+
+#include <string>
+
+#include "gmock/gmock.h"
+#include "gtest/gtest.h"
+#include "test/util/cleanup.h"
+#include "test/util/file_descriptor.h"
+#include "test/util/fs_util.h"
+#include "test/util/logging.h"
+#include "test/util/posix_error.h"
+#include "test/util/temp_path.h"
+#include "test/util/test_util.h"
+
+namespace gvisor {
+namespace testing {
+
+namespace {
+
+constexpr char kCoredumpFilter[] = "/proc/self/coredump_filter";
+constexpr char kCorePattern[] = "/proc/sys/kernel/core_pattern";
+
+// Writes filter to /proc/self/coredump_filter.
+PosixError SetCoredumpFilter(std::string const& filter) {
+  ASSIGN_OR_RETURN_ERRNO(auto fd, Open(kCoredumpFilter, O_WRONLY));
+  if (WriteFd(fd.get(), filter.data(), filter.size()) < 0) {
+    return PosixError(errno, "write");
+  }
+  return NoError();
+}
+
+// Forks a child that kills itself with SIGABRT in dir, with the given
+// RLIMIT_CORE, and returns its wait status.
+PosixErrorOr<int> AbortChildIn(std::string const& dir, rlim_t core_limit) {
+  pid_t const child_pid = fork();
+  if (child_pid == 0) {
+    TEST_PCHECK(chdir(dir.c_str()) == 0);
+    struct rlimit const rl = {core_limit, core_limit};
+    TEST_PCHECK(setrlimit(RLIMIT_CORE, &rl) == 0);
+    TEST_PCHECK(signal(SIGABRT, SIG_DFL) != SIG_ERR);
+    sigset_t set;
+    sigemptyset(&set);
+    TEST_PCHECK(sigprocmask(SIG_SETMASK, &set, nullptr) == 0);
+    raise(SIGABRT);
+    _exit(1);
+  }
+  if (child_pid < 0) {
+    return PosixError(errno, "fork");
+  }
+  int status;
+  if (RetryEINTR(waitpid)(child_pid, &status, 0) != child_pid) {
+    return PosixError(errno, "waitpid");
+  }
+  return status;
+}
+
+TEST(CoredumpFilterTest, Default) {
+  EXPECT_THAT(GetContents(kCoredumpFilter),
+              IsPosixErrorOkAndHolds("00000033\n"));
+}
+
+TEST(CoredumpFilterTest, SetAndInherit) {
+  std::string const old_filter =
+      ASSERT_NO_ERRNO_AND_VALUE(GetContents(kCoredumpFilter));
+  auto cleanup = Cleanup(
+      [&] { ASSERT_NO_ERRNO(SetCoredumpFilter(old_filter)); });
+
+  ASSERT_NO_ERRNO(SetCoredumpFilter("0x3"));
+  EXPECT_THAT(GetContents(kCoredumpFilter),
+              IsPosixErrorOkAndHolds("00000003\n"));
+
+  // The filter is inherited across fork.
+  pid_t const child_pid = fork();
+  if (child_pid == 0) {
+    TEST_CHECK(GetContents(kCoredumpFilter).ValueOrDie() == "00000003\n");
+    _exit(0);
+  }
+  ASSERT_THAT(child_pid, SyscallSucceeds());
+  int status;
+  ASSERT_THAT(RetryEINTR(waitpid)(child_pid, &status, 0),
+              SyscallSucceedsWithValue(child_pid));
+  EXPECT_TRUE(WIFEXITED(status) && WEXITSTATUS(status) == 0)
+      << "status = " << status;
+}
+
+TEST(CoredumpFilterTest, InvalidValue) {
+  FileDescriptor const fd =
+      ASSERT_NO_ERRNO_AND_VALUE(Open(kCoredumpFilter, O_WRONLY));
+  EXPECT_THAT(WriteFd(fd.get(), "foo", 3), SyscallFailsWithErrno(EINVAL));
+}
+
+TEST(CoreDumpTest, WritesCoreFile) {
+  // Core dumps may be sent elsewhere by the host (e.g. to systemd-coredump).
+  SKIP_IF(ASSERT_NO_ERRNO_AND_VALUE(GetContents(kCorePattern)) != "core\n");
+
+  auto const dir = ASSERT_NO_ERRNO_AND_VALUE(TempPath::CreateDir());
+  int const status =
+      ASSERT_NO_ERRNO_AND_VALUE(AbortChildIn(dir.path(), RLIM_INFINITY));
+  EXPECT_TRUE(WIFSIGNALED(status) && WTERMSIG(status) == SIGABRT)
+      << "status = " << status;
+  EXPECT_TRUE(WCOREDUMP(status)) << "status = " << status;
+
+  FileDescriptor const fd = ASSERT_NO_ERRNO_AND_VALUE(
+      Open(JoinPath(dir.path(), "core"), O_RDONLY));
+  struct stat st;
+  ASSERT_THAT(fstat(fd.get(), &st), SyscallSucceeds());
+  EXPECT_EQ(st.st_mode & 0777, 0600);
+
+  Elf64_Ehdr ehdr;
+  ASSERT_THAT(ReadFd(fd.get(), &ehdr, sizeof(ehdr)),
+              SyscallSucceedsWithValue(sizeof(ehdr)));
+  EXPECT_EQ(memcmp(ehdr.e_ident, ELFMAG, SELFMAG), 0);
+  EXPECT_EQ(ehdr.e_ident[EI_CLASS], ELFCLASS64);
+  EXPECT_EQ(ehdr.e_type, ET_CORE);
+  EXPECT_GT(ehdr.e_phnum, 1);
+
+  // The first program header describes the notes.
+  Elf64_Phdr phdr;
+  ASSERT_THAT(pread(fd.get(), &phdr, sizeof(phdr), ehdr.e_phoff),
+              SyscallSucceedsWithValue(sizeof(phdr)));
+  EXPECT_EQ(phdr.p_type, PT_NOTE);
+  EXPECT_GT(phdr.p_filesz, 0);
+}
+
+TEST(CoreDumpTest, NoCoreFileWithZeroLimit) {
+  SKIP_IF(ASSERT_NO_ERRNO_AND_VALUE(GetContents(kCorePattern)) != "core\n");
+
+  auto const dir = ASSERT_NO_ERRNO_AND_VALUE(TempPath::CreateDir());
+  int const status = ASSERT_NO_ERRNO_AND_VALUE(AbortChildIn(dir.path(), 0));
+  EXPECT_TRUE(WIFSIGNALED(status) && WTERMSIG(status) == SIGABRT)
+      << "status = " << status;
+  EXPECT_FALSE(WCOREDUMP(status)) << "status = " << status;
+  EXPECT_THAT(Exists(JoinPath(dir.path(), "core")),
+              IsPosixErrorOkAndHolds(false));
+}
+
+TEST(CoreDumpTest, CoreFileTruncatedAtLimit) {
+  SKIP_IF(ASSERT_NO_ERRNO_AND_VALUE(GetContents(kCorePattern)) != "core\n");
+
+  // The dump is larger than the limit, but the part that fits is written.
+  rlim_t const limit = 2 * kPageSize;
+  auto const dir = ASSERT_NO_ERRNO_AND_VALUE(TempPath::CreateDir());
+  int const status = ASSERT_NO_ERRNO_AND_VALUE(AbortChildIn(dir.path(), limit));
+  EXPECT_TRUE(WIFSIGNALED(status) && WTERMSIG(status) == SIGABRT)
+      << "status = " << status;
+  EXPECT_TRUE(WCOREDUMP(status)) << "status = " << status;
+
+  FileDescriptor const fd = ASSERT_NO_ERRNO_AND_VALUE(
+      Open(JoinPath(dir.path(), "core"), O_RDONLY));
+  struct stat st;
+  ASSERT_THAT(fstat(fd.get(), &st), SyscallSucceeds());
+  EXPECT_GE(st.st_size, static_cast<off_t>(sizeof(Elf64_Ehdr)));
+  EXPECT_LE(st.st_size, static_cast<off_t>(limit));
+
+  Elf64_Ehdr ehdr;
+  ASSERT_THAT(ReadFd(fd.get(), &ehdr, sizeof(ehdr)),
+              SyscallSucceedsWithValue(sizeof(ehdr)));
+  EXPECT_EQ(memcmp(ehdr.e_ident, ELFMAG, SELFMAG), 0);
+  EXPECT_EQ(ehdr.e_type, ET_CORE);
+}
+
+}  // namespace
+
+}  // namespace testing
+}  // namespace gvisor
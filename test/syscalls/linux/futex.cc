@@ -28,6 +28,7 @@
 
 #include "gtest/gtest.h"
 #include "absl/memory/memory.h"
+#include "absl/synchronization/notification.h"
 #include "absl/time/clock.h"
 #include "absl/time/time.h"
 #include "test/util/cleanup.h"
@@ -833,6 +834,45 @@ TEST(RobustFutexTest, PthreadMutexAttr) {
   }
 }
 
+// Tests that a waiter blocked on a robust mutex is woken with EOWNERDEAD when
+// the owning thread exits, for both normal and priority-inheritance mutexes.
+TEST(RobustFutexTest, WaiterWokenOnOwnerDeath) {
+  for (int protocol : {PTHREAD_PRIO_NONE, PTHREAD_PRIO_INHERIT}) {
+    pthread_mutexattr_t attr;
+    pthread_mutex_t mtx;
+    TEST_PCHECK(pthread_mutexattr_init(&attr) == 0);
+    TEST_PCHECK(pthread_mutexattr_setrobust(&attr, PTHREAD_MUTEX_ROBUST) == 0);
+    TEST_PCHECK(pthread_mutexattr_setprotocol(&attr, protocol) == 0);
+    TEST_PCHECK(pthread_mutex_init(&mtx, &attr) == 0);
+
+    absl::Notification locked;
+    absl::Notification release;
+    ScopedThread owner([&] {
+      TEST_PCHECK(pthread_mutex_lock(&mtx) == 0);
+      locked.Notify();
+      release.WaitForNotification();
+      pthread_exit(NULL);
+    });
+    locked.WaitForNotification();
+
+    ScopedThread waiter([&] {
+      // Blocks until the owner exits.
+      EXPECT_EQ(pthread_mutex_lock(&mtx), EOWNERDEAD);
+      EXPECT_EQ(pthread_mutex_consistent(&mtx), 0);
+      EXPECT_EQ(pthread_mutex_unlock(&mtx), 0);
+    });
+
+    // Give the waiter a chance to block before the owner dies.
+    absl::SleepFor(absl::Milliseconds(100));
+    release.Notify();
+    owner.Join();
+    waiter.Join();
+
+    EXPECT_EQ(pthread_mutex_destroy(&mtx), 0);
+    EXPECT_EQ(pthread_mutexattr_destroy(&attr), 0);
+  }
+}
+
 #endif  // __ANDROID__
 
 }  // namespace
@@ -14,9 +14,11 @@
 
 #include "test/syscalls/linux/ip_socket_test_util.h"
 
+#include <linux/errqueue.h>
 #include <net/if.h>
 #include <netinet/in.h>
 #include <netpacket/packet.h>
+#include <poll.h>
 #include <sys/socket.h>
 
 #include <cstring>
@@ -353,5 +355,42 @@ void RecvIPv6PktInfo(int sock, char buf[], size_t* buf_size,
   RecvCmsg(sock, SOL_IPV6, IPV6_PKTINFO, buf, buf_size, out_pktinfo);
 }
 
+void RecvZeroCopyCompletions(int sock, int cmsg_level, int cmsg_type,
+                             uint32_t lo, uint32_t hi) {
+  // The completions of consecutive sends may be coalesced, so each one holds
+  // a range of IDs.
+  uint32_t next = lo;
+  while (next <= hi) {
+    // POLLERR is always polled for.
+    struct pollfd pfd = {.fd = sock};
+    ASSERT_THAT(RetryEINTR(poll)(&pfd, 1, /*timeout=*/5000),
+                SyscallSucceedsWithValue(1));
+    ASSERT_NE(pfd.revents & POLLERR, 0);
+
+    alignas(struct cmsghdr) char control[CMSG_SPACE(
+        sizeof(struct sock_extended_err) + sizeof(struct sockaddr_in6))];
+    msghdr msg = {
+        .msg_control = control,
+        .msg_controllen = sizeof(control),
+    };
+    ASSERT_THAT(RetryEINTR(recvmsg)(sock, &msg, MSG_ERRQUEUE),
+                SyscallSucceedsWithValue(0));
+    EXPECT_NE(msg.msg_flags & MSG_ERRQUEUE, 0);
+
+    struct cmsghdr* cmsg = CMSG_FIRSTHDR(&msg);
+    ASSERT_THAT(cmsg, NotNull());
+    ASSERT_EQ(cmsg->cmsg_level, cmsg_level);
+    ASSERT_EQ(cmsg->cmsg_type, cmsg_type);
+    struct sock_extended_err err;
+    std::copy_n(CMSG_DATA(cmsg), sizeof(err), reinterpret_cast<char*>(&err));
+    EXPECT_EQ(err.ee_errno, 0);
+    ASSERT_EQ(err.ee_origin, SO_EE_ORIGIN_ZEROCOPY);
+    ASSERT_EQ(err.ee_info, next);
+    ASSERT_GE(err.ee_data, err.ee_info);
+    ASSERT_LE(err.ee_data, hi);
+    next = err.ee_data + 1;
+  }
+}
+
 }  // namespace testing
 }  // namespace gvisor
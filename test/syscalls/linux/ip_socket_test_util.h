@@ -176,6 +176,12 @@ void RecvPktInfo(int sock, char buf[], size_t* buf_size,
 void RecvIPv6PktInfo(int sock, char buf[], size_t* buf_size,
                      in6_pktinfo* out_pktinfo);
 
+// RecvZeroCopyCompletions waits for and dequeues the completions of the
+// MSG_ZEROCOPY sends with IDs lo through hi from the error queue of sock. The
+// completions are expected in cmsgs of the given level and type.
+void RecvZeroCopyCompletions(int sock, int cmsg_level, int cmsg_type,
+                             uint32_t lo, uint32_t hi);
+
 }  // namespace testing
 }  // namespace gvisor
 
@@ -0,0 +1,146 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#include <arpa/inet.h>
+#include <netinet/in.h>
+#include <string.h>
+#include <sys/socket.h>
+#include <sys/types.h>
+#include <unistd.h>
+
+#include <cstring>
+
+#include "gtest/gtest.h"
+#include "test/util/file_descriptor.h"
+#include "test/util/posix_error.h"
+#include "test/util/socket_util.h"
+#include "test/util/test_util.h"
+
+#ifndef IPPROTO_MPTCP
+#define IPPROTO_MPTCP 262
+#endif
+
+namespace gvisor {
+namespace testing {
+
+namespace {
+
+// MPTCPSupported returns true if Multipath TCP sockets can be created. Hosts
+// may have Multipath TCP disabled.
+bool MPTCPSupported() {
+  int fd = socket(AF_INET, SOCK_STREAM, IPPROTO_MPTCP);
+  if (fd < 0) {
+    return false;
+  }
+  close(fd);
+  return true;
+}
+
+// BindLoopback binds fd to an ephemeral loopback port and returns the bound
+// address.
+PosixErrorOr<sockaddr_in> BindLoopback(int fd) {
+  sockaddr_in addr = {};
+  addr.sin_family = AF_INET;
+  addr.sin_addr.s_addr = htonl(INADDR_LOOPBACK);
+  RETURN_ERROR_IF_SYSCALL_FAIL(
+      bind(fd, reinterpret_cast<sockaddr*>(&addr), sizeof(addr)));
+  socklen_t addrlen = sizeof(addr);
+  RETURN_ERROR_IF_SYSCALL_FAIL(
+      getsockname(fd, reinterpret_cast<sockaddr*>(&addr), &addrlen));
+  return addr;
+}
+
+TEST(MPTCPSocketTest, Protocol) {
+  SKIP_IF(!MPTCPSupported());
+
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(AF_INET, SOCK_STREAM, IPPROTO_MPTCP));
+  int protocol = 0;
+  socklen_t len = sizeof(protocol);
+  ASSERT_THAT(getsockopt(fd.get(), SOL_SOCKET, SO_PROTOCOL, &protocol, &len),
+              SyscallSucceeds());
+  EXPECT_EQ(protocol, IPPROTO_MPTCP);
+
+  int type = 0;
+  len = sizeof(type);
+  ASSERT_THAT(getsockopt(fd.get(), SOL_SOCKET, SO_TYPE, &type, &len),
+              SyscallSucceeds());
+  EXPECT_EQ(type, SOCK_STREAM);
+}
+
+TEST(MPTCPSocketTest, DatagramNotSupported) {
+  EXPECT_THAT(socket(AF_INET, SOCK_DGRAM, IPPROTO_MPTCP),
+              SyscallFailsWithErrno(EPROTONOSUPPORT));
+}
+
+TEST(MPTCPSocketTest, ConnectSendRecv) {
+  SKIP_IF(!MPTCPSupported());
+
+  FileDescriptor listener =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(AF_INET, SOCK_STREAM, IPPROTO_MPTCP));
+  sockaddr_in addr = ASSERT_NO_ERRNO_AND_VALUE(BindLoopback(listener.get()));
+  ASSERT_THAT(listen(listener.get(), 1), SyscallSucceeds());
+
+  FileDescriptor client =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(AF_INET, SOCK_STREAM, IPPROTO_MPTCP));
+  ASSERT_THAT(
+      connect(client.get(), reinterpret_cast<sockaddr*>(&addr), sizeof(addr)),
+      SyscallSucceeds());
+  FileDescriptor server =
+      ASSERT_NO_ERRNO_AND_VALUE(Accept(listener.get(), nullptr, nullptr));
+
+  constexpr char kData[] = "multipath";
+  ASSERT_THAT(send(client.get(), kData, sizeof(kData), 0),
+              SyscallSucceedsWithValue(sizeof(kData)));
+  char buf[sizeof(kData)] = {};
+  ASSERT_THAT(RecvTimeout(server.get(), buf, sizeof(buf), 5 /* seconds */),
+              IsPosixErrorOkAndHolds(sizeof(kData)));
+  EXPECT_EQ(memcmp(buf, kData, sizeof(kData)), 0);
+
+  // Shutting down the client for writing is seen as EOF by the server.
+  ASSERT_THAT(shutdown(client.get(), SHUT_WR), SyscallSucceeds());
+  ASSERT_THAT(RecvTimeout(server.get(), buf, sizeof(buf), 5 /* seconds */),
+              IsPosixErrorOkAndHolds(0));
+}
+
+TEST(MPTCPSocketTest, FallbackToTCP) {
+  SKIP_IF(!MPTCPSupported());
+
+  // A plain TCP listener accepts connections from Multipath TCP clients.
+  FileDescriptor listener =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(AF_INET, SOCK_STREAM, IPPROTO_TCP));
+  sockaddr_in addr = ASSERT_NO_ERRNO_AND_VALUE(BindLoopback(listener.get()));
+  ASSERT_THAT(listen(listener.get(), 1), SyscallSucceeds());
+
+  FileDescriptor client =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(AF_INET, SOCK_STREAM, IPPROTO_MPTCP));
+  ASSERT_THAT(
+      connect(client.get(), reinterpret_cast<sockaddr*>(&addr), sizeof(addr)),
+      SyscallSucceeds());
+  FileDescriptor server =
+      ASSERT_NO_ERRNO_AND_VALUE(Accept(listener.get(), nullptr, nullptr));
+
+  constexpr char kData[] = "fallback";
+  ASSERT_THAT(send(client.get(), kData, sizeof(kData), 0),
+              SyscallSucceedsWithValue(sizeof(kData)));
+  char buf[sizeof(kData)] = {};
+  ASSERT_THAT(RecvTimeout(server.get(), buf, sizeof(buf), 5 /* seconds */),
+              IsPosixErrorOkAndHolds(sizeof(kData)));
+  EXPECT_EQ(memcmp(buf, kData, sizeof(kData)), 0);
+}
+
+}  // namespace
+
+}  // namespace testing
+}  // namespace gvisor
@@ -0,0 +1,119 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#include <signal.h>
+#include <sys/mman.h>
+#include <sys/syscall.h>
+#include <unistd.h>
+
+#include <cerrno>
+
+#include "gtest/gtest.h"
+#include "test/util/memory_util.h"
+#include "test/util/test_util.h"
+
+#ifndef PKEY_DISABLE_ACCESS
+#define PKEY_DISABLE_ACCESS 0x1
+#endif
+#ifndef PKEY_DISABLE_WRITE
+#define PKEY_DISABLE_WRITE 0x2
+#endif
+
+namespace gvisor {
+namespace testing {
+
+namespace {
+
+int PkeyAlloc(unsigned int flags, unsigned int rights) {
+  return syscall(SYS_pkey_alloc, flags, rights);
+}
+
+int PkeyFree(int pkey) { return syscall(SYS_pkey_free, pkey); }
+
+int PkeyMprotect(void* addr, size_t len, int prot, int pkey) {
+  return syscall(SYS_pkey_mprotect, addr, len, prot, pkey);
+}
+
+// Returns true if a pkey_alloc failure with errno err indicates that
+// protection keys are unsupported.
+bool PkeysUnsupported(int err) {
+  return err == ENOSPC || err == EINVAL || err == ENOSYS;
+}
+
+TEST(PkeysTest, AllocInvalidFlags) {
+  EXPECT_THAT(PkeyAlloc(1, 0), SyscallFailsWithErrno(EINVAL));
+}
+
+TEST(PkeysTest, AllocInvalidRights) {
+  EXPECT_THAT(PkeyAlloc(0, PKEY_DISABLE_ACCESS | PKEY_DISABLE_WRITE | 0x4),
+              SyscallFailsWithErrno(EINVAL));
+}
+
+TEST(PkeysTest, MprotectInvalidPkey) {
+  Mapping m = ASSERT_NO_ERRNO_AND_VALUE(
+      MmapAnon(kPageSize, PROT_READ | PROT_WRITE, MAP_PRIVATE));
+  EXPECT_THAT(PkeyMprotect(m.ptr(), m.len(), PROT_READ, 16),
+              SyscallFailsWithErrno(EINVAL));
+  EXPECT_THAT(PkeyMprotect(m.ptr(), m.len(), PROT_READ, -2),
+              SyscallFailsWithErrno(EINVAL));
+}
+
+TEST(PkeysTest, AllocFree) {
+  int const pkey = PkeyAlloc(0, 0);
+  SKIP_IF(pkey < 0 && PkeysUnsupported(errno));
+  ASSERT_THAT(pkey, SyscallSucceeds());
+
+  EXPECT_GT(pkey, 0);
+  EXPECT_THAT(PkeyFree(pkey), SyscallSucceeds());
+  EXPECT_THAT(PkeyFree(pkey), SyscallFailsWithErrno(EINVAL));
+}
+
+TEST(PkeysTest, MprotectWithFreedPkey) {
+  int const pkey = PkeyAlloc(0, 0);
+  SKIP_IF(pkey < 0 && PkeysUnsupported(errno));
+  ASSERT_THAT(pkey, SyscallSucceeds());
+
+  Mapping m = ASSERT_NO_ERRNO_AND_VALUE(
+      MmapAnon(kPageSize, PROT_READ | PROT_WRITE, MAP_PRIVATE));
+  EXPECT_THAT(PkeyMprotect(m.ptr(), m.len(), PROT_READ | PROT_WRITE, pkey),
+              SyscallSucceeds());
+  ASSERT_THAT(PkeyFree(pkey), SyscallSucceeds());
+  EXPECT_THAT(PkeyMprotect(m.ptr(), m.len(), PROT_READ | PROT_WRITE, pkey),
+              SyscallFailsWithErrno(EINVAL));
+}
+
+TEST(PkeysTest, WriteDisabled) {
+  int const pkey = PkeyAlloc(0, PKEY_DISABLE_WRITE);
+  SKIP_IF(pkey < 0 && PkeysUnsupported(errno));
+  ASSERT_THAT(pkey, SyscallSucceeds());
+
+  Mapping m = ASSERT_NO_ERRNO_AND_VALUE(
+      MmapAnon(kPageSize, PROT_READ | PROT_WRITE, MAP_PRIVATE));
+  ASSERT_THAT(PkeyMprotect(m.ptr(), m.len(), PROT_READ | PROT_WRITE, pkey),
+              SyscallSucceeds());
+
+  // Reads are still permitted.
+  volatile char* p = reinterpret_cast<volatile char*>(m.ptr());
+  EXPECT_EQ(*p, 0);
+
+  // Writes are not.
+  EXPECT_EXIT(*p = 1, ::testing::KilledBySignal(SIGSEGV), "");
+
+  EXPECT_THAT(PkeyFree(pkey), SyscallSucceeds());
+}
+
+}  // namespace
+
+}  // namespace testing
+}  // namespace gvisor
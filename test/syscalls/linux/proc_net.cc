@@ -584,6 +584,32 @@ TEST(ProcSysNetIpv4Recovery, CanReadAndWrite) {
   EXPECT_EQ(strcmp(buf, "100\n"), 0);
 }
 
+TEST(ProcSysNetIpv4FastOpen, CanReadAndWrite) {
+  DisableSave ds;
+
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability((CAP_NET_ADMIN))) ||
+          IsRunningWithHostinet());
+
+  auto const fd = ASSERT_NO_ERRNO_AND_VALUE(
+      Open("/proc/sys/net/ipv4/tcp_fastopen", O_RDWR));
+
+  char orig[16] = {'\0'};
+  ASSERT_THAT(PreadFd(fd.get(), orig, sizeof(orig) - 1, 0),
+              SyscallSucceeds());
+
+  // Enable both client and server Fast Open.
+  char kMessage[] = "3";
+  EXPECT_THAT(PwriteFd(fd.get(), kMessage, strlen(kMessage), 0),
+              SyscallSucceedsWithValue(strlen(kMessage)));
+  char buf[16] = {'\0'};
+  EXPECT_THAT(PreadFd(fd.get(), buf, sizeof(buf) - 1, 0),
+              SyscallSucceedsWithValue(strlen(kMessage) + 1));
+  EXPECT_EQ(strcmp(buf, "3\n"), 0);
+
+  EXPECT_THAT(PwriteFd(fd.get(), orig, strlen(orig), 0),
+              SyscallSucceedsWithValue(strlen(orig)));
+}
+
 TEST(ProcSysNetIpv4IpForward, Exists) {
   auto fd = ASSERT_NO_ERRNO_AND_VALUE(Open(kIpForward, O_RDONLY));
 }
@@ -0,0 +1,294 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#include <arpa/inet.h>
+#include <linux/sctp.h>
+#include <netinet/in.h>
+#include <string.h>
+#include <sys/socket.h>
+#include <sys/types.h>
+#include <unistd.h>
+
+#include <cstring>
+
+#include "gtest/gtest.h"
+#include "test/util/file_descriptor.h"
+#include "test/util/posix_error.h"
+#include "test/util/socket_util.h"
+#include "test/util/test_util.h"
+
+#ifndef SOL_SCTP
+#define SOL_SCTP 132
+#endif
+
+namespace gvisor {
+namespace testing {
+
+namespace {
+
+// SCTPSupported returns true if SCTP sockets of the given type can be created.
+// Hosts may not have the SCTP module loaded.
+bool SCTPSupported(int type) {
+  int fd = socket(AF_INET, type, IPPROTO_SCTP);
+  if (fd < 0) {
+    return false;
+  }
+  close(fd);
+  return true;
+}
+
+sockaddr_in LoopbackAddr(uint16_t port) {
+  sockaddr_in addr = {};
+  addr.sin_family = AF_INET;
+  addr.sin_addr.s_addr = htonl(INADDR_LOOPBACK);
+  addr.sin_port = port;
+  return addr;
+}
+
+// BindLoopback binds fd to an ephemeral loopback port and returns the bound
+// address.
+PosixErrorOr<sockaddr_in> BindLoopback(int fd) {
+  sockaddr_in addr = LoopbackAddr(0);
+  RETURN_ERROR_IF_SYSCALL_FAIL(
+      bind(fd, reinterpret_cast<sockaddr*>(&addr), sizeof(addr)));
+  socklen_t addrlen = sizeof(addr);
+  RETURN_ERROR_IF_SYSCALL_FAIL(
+      getsockname(fd, reinterpret_cast<sockaddr*>(&addr), &addrlen));
+  return addr;
+}
+
+TEST(SCTPSocketTest, StreamConnectSendRecv) {
+  SKIP_IF(!SCTPSupported(SOCK_STREAM));
+
+  FileDescriptor listener =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(AF_INET, SOCK_STREAM, IPPROTO_SCTP));
+  sockaddr_in addr = ASSERT_NO_ERRNO_AND_VALUE(BindLoopback(listener.get()));
+  ASSERT_THAT(listen(listener.get(), 1), SyscallSucceeds());
+
+  FileDescriptor client =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(AF_INET, SOCK_STREAM, IPPROTO_SCTP));
+  ASSERT_THAT(connect(client.get(), reinterpret_cast<sockaddr*>(&addr),
+                      sizeof(addr)),
+              SyscallSucceeds());
+  FileDescriptor server =
+      ASSERT_NO_ERRNO_AND_VALUE(Accept(listener.get(), nullptr, nullptr));
+
+  constexpr char kFirst[] = "first";
+  constexpr char kSecond[] = "second message";
+  ASSERT_THAT(send(client.get(), kFirst, sizeof(kFirst), 0),
+              SyscallSucceedsWithValue(sizeof(kFirst)));
+  ASSERT_THAT(send(client.get(), kSecond, sizeof(kSecond), 0),
+              SyscallSucceedsWithValue(sizeof(kSecond)));
+
+  // Message boundaries are preserved, and MSG_EOR marks complete messages.
+  char buf[64] = {};
+  struct iovec iov = {buf, sizeof(buf)};
+  struct msghdr msg = {};
+  msg.msg_iov = &iov;
+  msg.msg_iovlen = 1;
+  ASSERT_THAT(RetryEINTR(recvmsg)(server.get(), &msg, 0),
+              SyscallSucceedsWithValue(sizeof(kFirst)));
+  EXPECT_STREQ(buf, kFirst);
+  EXPECT_TRUE(msg.msg_flags & MSG_EOR);
+
+  // A short read returns the rest of the message in the next read.
+  iov.iov_len = 4;
+  msg.msg_flags = 0;
+  ASSERT_THAT(RetryEINTR(recvmsg)(server.get(), &msg, 0),
+              SyscallSucceedsWithValue(4));
+  EXPECT_FALSE(msg.msg_flags & MSG_EOR);
+  iov.iov_base = buf + 4;
+  iov.iov_len = sizeof(buf) - 4;
+  msg.msg_flags = 0;
+  ASSERT_THAT(RetryEINTR(recvmsg)(server.get(), &msg, 0),
+              SyscallSucceedsWithValue(sizeof(kSecond) - 4));
+  EXPECT_STREQ(buf, kSecond);
+  EXPECT_TRUE(msg.msg_flags & MSG_EOR);
+}
+
+TEST(SCTPSocketTest, StreamConnectRefused) {
+  SKIP_IF(!SCTPSupported(SOCK_STREAM));
+
+  // Find a port nothing listens on.
+  FileDescriptor bound =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(AF_INET, SOCK_STREAM, IPPROTO_SCTP));
+  sockaddr_in addr = ASSERT_NO_ERRNO_AND_VALUE(BindLoopback(bound.get()));
+
+  FileDescriptor client =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(AF_INET, SOCK_STREAM, IPPROTO_SCTP));
+  EXPECT_THAT(connect(client.get(), reinterpret_cast<sockaddr*>(&addr),
+                      sizeof(addr)),
+              SyscallFailsWithErrno(ECONNREFUSED));
+}
+
+TEST(SCTPSocketTest, SeqpacketSndRcvInfo) {
+  SKIP_IF(!SCTPSupported(SOCK_SEQPACKET));
+
+  FileDescriptor server =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(AF_INET, SOCK_SEQPACKET, IPPROTO_SCTP));
+  sockaddr_in addr = ASSERT_NO_ERRNO_AND_VALUE(BindLoopback(server.get()));
+  ASSERT_THAT(listen(server.get(), 1), SyscallSucceeds());
+
+  // Only subscribe to the sctp_data_io_event field.
+  uint8_t data_io = 1;
+  ASSERT_THAT(setsockopt(server.get(), SOL_SCTP, SCTP_EVENTS, &data_io,
+                         sizeof(data_io)),
+              SyscallSucceeds());
+
+  FileDescriptor client =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(AF_INET, SOCK_SEQPACKET, IPPROTO_SCTP));
+
+  // Send a message on stream 1 and set up the association implicitly.
+  constexpr char kData[] = "data";
+  char send_cmsg[CMSG_SPACE(sizeof(struct sctp_sndrcvinfo))] = {};
+  struct iovec iov = {const_cast<char*>(kData), sizeof(kData)};
+  struct msghdr msg = {};
+  msg.msg_name = &addr;
+  msg.msg_namelen = sizeof(addr);
+  msg.msg_iov = &iov;
+  msg.msg_iovlen = 1;
+  msg.msg_control = send_cmsg;
+  msg.msg_controllen = sizeof(send_cmsg);
+  struct cmsghdr* cmsg = CMSG_FIRSTHDR(&msg);
+  cmsg->cmsg_level = SOL_SCTP;
+  cmsg->cmsg_type = SCTP_SNDRCV;
+  cmsg->cmsg_len = CMSG_LEN(sizeof(struct sctp_sndrcvinfo));
+  struct sctp_sndrcvinfo sinfo = {};
+  sinfo.sinfo_stream = 1;
+  sinfo.sinfo_ppid = htonl(42);
+  memcpy(CMSG_DATA(cmsg), &sinfo, sizeof(sinfo));
+  ASSERT_THAT(RetryEINTR(sendmsg)(client.get(), &msg, 0),
+              SyscallSucceedsWithValue(sizeof(kData)));
+
+  char buf[16] = {};
+  char recv_cmsg[CMSG_SPACE(sizeof(struct sctp_sndrcvinfo))] = {};
+  sockaddr_in from = {};
+  struct iovec riov = {buf, sizeof(buf)};
+  struct msghdr rmsg = {};
+  rmsg.msg_name = &from;
+  rmsg.msg_namelen = sizeof(from);
+  rmsg.msg_iov = &riov;
+  rmsg.msg_iovlen = 1;
+  rmsg.msg_control = recv_cmsg;
+  rmsg.msg_controllen = sizeof(recv_cmsg);
+  ASSERT_THAT(RetryEINTR(recvmsg)(server.get(), &rmsg, 0),
+              SyscallSucceedsWithValue(sizeof(kData)));
+  EXPECT_STREQ(buf, kData);
+  EXPECT_TRUE(rmsg.msg_flags & MSG_EOR);
+
+  sockaddr_in client_addr = {};
+  socklen_t addrlen = sizeof(client_addr);
+  ASSERT_THAT(getsockname(client.get(),
+                          reinterpret_cast<sockaddr*>(&client_addr), &addrlen),
+              SyscallSucceeds());
+  EXPECT_EQ(from.sin_port, client_addr.sin_port);
+
+  cmsg = CMSG_FIRSTHDR(&rmsg);
+  ASSERT_NE(cmsg, nullptr);
+  EXPECT_EQ(cmsg->cmsg_level, SOL_SCTP);
+  EXPECT_EQ(cmsg->cmsg_type, SCTP_SNDRCV);
+  ASSERT_EQ(cmsg->cmsg_len, CMSG_LEN(sizeof(struct sctp_sndrcvinfo)));
+  struct sctp_sndrcvinfo rinfo = {};
+  memcpy(&rinfo, CMSG_DATA(cmsg), sizeof(rinfo));
+  EXPECT_EQ(rinfo.sinfo_stream, 1);
+  EXPECT_EQ(rinfo.sinfo_ssn, 0);
+  EXPECT_EQ(rinfo.sinfo_ppid, htonl(42));
+  EXPECT_NE(rinfo.sinfo_assoc_id, 0);
+}
+
+TEST(SCTPSocketTest, SeqpacketAcceptNotSupported) {
+  SKIP_IF(!SCTPSupported(SOCK_SEQPACKET));
+
+  FileDescriptor sock =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(AF_INET, SOCK_SEQPACKET, IPPROTO_SCTP));
+  ASSERT_NO_ERRNO(BindLoopback(sock.get()));
+  ASSERT_THAT(listen(sock.get(), 1), SyscallSucceeds());
+  EXPECT_THAT(accept(sock.get(), nullptr, nullptr),
+              SyscallFailsWithErrno(EOPNOTSUPP));
+}
+
+TEST(SCTPSocketTest, InitMsg) {
+  SKIP_IF(!SCTPSupported(SOCK_STREAM));
+
+  FileDescriptor sock =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(AF_INET, SOCK_STREAM, IPPROTO_SCTP));
+  struct sctp_initmsg init = {};
+  init.sinit_num_ostreams = 5;
+  init.sinit_max_instreams = 7;
+  init.sinit_max_attempts = 3;
+  ASSERT_THAT(setsockopt(sock.get(), SOL_SCTP, SCTP_INITMSG, &init,
+                         sizeof(init)),
+              SyscallSucceeds());
+
+  struct sctp_initmsg got = {};
+  socklen_t len = sizeof(got);
+  ASSERT_THAT(getsockopt(sock.get(), SOL_SCTP, SCTP_INITMSG, &got, &len),
+              SyscallSucceeds());
+  EXPECT_EQ(len, sizeof(got));
+  EXPECT_EQ(got.sinit_num_ostreams, 5);
+  EXPECT_EQ(got.sinit_max_instreams, 7);
+  EXPECT_EQ(got.sinit_max_attempts, 3);
+}
+
+TEST(SCTPSocketTest, NoDelay) {
+  SKIP_IF(!SCTPSupported(SOCK_STREAM));
+
+  FileDescriptor sock =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(AF_INET, SOCK_STREAM, IPPROTO_SCTP));
+  int v = -1;
+  socklen_t len = sizeof(v);
+  ASSERT_THAT(getsockopt(sock.get(), SOL_SCTP, SCTP_NODELAY, &v, &len),
+              SyscallSucceeds());
+  EXPECT_EQ(v, 0);
+
+  v = 1;
+  ASSERT_THAT(setsockopt(sock.get(), SOL_SCTP, SCTP_NODELAY, &v, sizeof(v)),
+              SyscallSucceeds());
+  v = -1;
+  ASSERT_THAT(getsockopt(sock.get(), SOL_SCTP, SCTP_NODELAY, &v, &len),
+              SyscallSucceeds());
+  EXPECT_EQ(v, 1);
+}
+
+TEST(SCTPSocketTest, Status) {
+  SKIP_IF(!SCTPSupported(SOCK_STREAM));
+
+  FileDescriptor listener =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(AF_INET, SOCK_STREAM, IPPROTO_SCTP));
+  sockaddr_in addr = ASSERT_NO_ERRNO_AND_VALUE(BindLoopback(listener.get()));
+  ASSERT_THAT(listen(listener.get(), 1), SyscallSucceeds());
+
+  FileDescriptor client =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(AF_INET, SOCK_STREAM, IPPROTO_SCTP));
+  ASSERT_THAT(connect(client.get(), reinterpret_cast<sockaddr*>(&addr),
+                      sizeof(addr)),
+              SyscallSucceeds());
+
+  struct sctp_status status = {};
+  socklen_t len = sizeof(status);
+  ASSERT_THAT(
+      getsockopt(client.get(), SOL_SCTP, SCTP_STATUS, &status, &len),
+      SyscallSucceeds());
+  EXPECT_EQ(status.sstat_state, SCTP_ESTABLISHED);
+  EXPECT_GT(status.sstat_outstrms, 0);
+  EXPECT_GT(status.sstat_fragmentation_point, 0);
+  const sockaddr_in* primary =
+      reinterpret_cast<const sockaddr_in*>(&status.sstat_primary.spinfo_address);
+  EXPECT_EQ(primary->sin_port, addr.sin_port);
+}
+
+}  // namespace
+
+}  // namespace testing
+}  // namespace gvisor
@@ -12,10 +12,13 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+#include <sched.h>
 #include <signal.h>
 #include <sys/ipc.h>
 #include <sys/sem.h>
+#include <sys/syscall.h>
 #include <sys/types.h>
+#include <unistd.h>
 
 #include <atomic>
 #include <cerrno>
@@ -551,6 +554,100 @@ TEST(SemaphoreTest, SemCtlGetPidFork) {
       << " status " << status;
 }
 
+TEST(SemaphoreTest, SemOpUndoOnExit) {
+  AutoSem sem(semget(IPC_PRIVATE, 2, 0600 | IPC_CREAT));
+  ASSERT_THAT(sem.get(), SyscallSucceeds());
+  ASSERT_THAT(semctl(sem.get(), 0, SETVAL, 1), SyscallSucceeds());
+
+  const pid_t child_pid = fork();
+  if (child_pid == 0) {
+    struct sembuf bufs[] = {{0, -1, SEM_UNDO}, {1, 3, SEM_UNDO}};
+    TEST_PCHECK(semop(sem.get(), bufs, ABSL_ARRAYSIZE(bufs)) == 0);
+    TEST_PCHECK(semctl(sem.get(), 0, GETVAL) == 0);
+    TEST_PCHECK(semctl(sem.get(), 1, GETVAL) == 3);
+    _exit(0);
+  }
+  ASSERT_THAT(child_pid, SyscallSucceeds());
+
+  int status;
+  ASSERT_THAT(RetryEINTR(waitpid)(child_pid, &status, 0),
+              SyscallSucceedsWithValue(child_pid));
+  EXPECT_TRUE(WIFEXITED(status) && WEXITSTATUS(status) == 0)
+      << " status " << status;
+
+  // The child's adjustments must have been reverted when it exited.
+  EXPECT_THAT(semctl(sem.get(), 0, GETVAL), SyscallSucceedsWithValue(1));
+  EXPECT_THAT(semctl(sem.get(), 1, GETVAL), SyscallSucceedsWithValue(0));
+  EXPECT_THAT(semctl(sem.get(), 0, GETPID),
+              SyscallSucceedsWithValue(child_pid));
+}
+
+TEST(SemaphoreTest, SemOpUndoSetValClears) {
+  AutoSem sem(semget(IPC_PRIVATE, 1, 0600 | IPC_CREAT));
+  ASSERT_THAT(sem.get(), SyscallSucceeds());
+
+  const pid_t child_pid = fork();
+  if (child_pid == 0) {
+    struct sembuf buf = {0, 2, SEM_UNDO};
+    TEST_PCHECK(semop(sem.get(), &buf, 1) == 0);
+    // SETVAL discards the adjustment made above.
+    TEST_PCHECK(semctl(sem.get(), 0, SETVAL, 5) == 0);
+    _exit(0);
+  }
+  ASSERT_THAT(child_pid, SyscallSucceeds());
+
+  int status;
+  ASSERT_THAT(RetryEINTR(waitpid)(child_pid, &status, 0),
+              SyscallSucceedsWithValue(child_pid));
+  EXPECT_TRUE(WIFEXITED(status) && WEXITSTATUS(status) == 0)
+      << " status " << status;
+
+  EXPECT_THAT(semctl(sem.get(), 0, GETVAL), SyscallSucceedsWithValue(5));
+}
+
+TEST(SemaphoreTest, SemOpUndoRange) {
+  AutoSem sem(semget(IPC_PRIVATE, 1, 0600 | IPC_CREAT));
+  ASSERT_THAT(sem.get(), SyscallSucceeds());
+  ASSERT_THAT(semctl(sem.get(), 0, SETVAL, kSemVmx), SyscallSucceeds());
+
+  const pid_t child_pid = fork();
+  if (child_pid == 0) {
+    struct sembuf buf = {0, -kSemVmx, SEM_UNDO};
+    TEST_PCHECK(semop(sem.get(), &buf, 1) == 0);
+    buf = {0, 1, 0};
+    TEST_PCHECK(semop(sem.get(), &buf, 1) == 0);
+    // The adjustment is already SEMAEM and can't grow any further.
+    buf = {0, -1, SEM_UNDO};
+    TEST_PCHECK(semop(sem.get(), &buf, 1) == -1 && errno == ERANGE);
+    TEST_PCHECK(semctl(sem.get(), 0, GETVAL) == 1);
+    _exit(0);
+  }
+  ASSERT_THAT(child_pid, SyscallSucceeds());
+
+  int status;
+  ASSERT_THAT(RetryEINTR(waitpid)(child_pid, &status, 0),
+              SyscallSucceedsWithValue(child_pid));
+  EXPECT_TRUE(WIFEXITED(status) && WEXITSTATUS(status) == 0)
+      << " status " << status;
+
+  // The value is clamped to SEMVMX when the adjustment is applied.
+  EXPECT_THAT(semctl(sem.get(), 0, GETVAL), SyscallSucceedsWithValue(kSemVmx));
+}
+
+TEST(SemaphoreTest, CloneSysvsemNewIpc) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SYS_ADMIN)));
+
+  // A child in a new IPC namespace can't share its parent's semaphore
+  // adjustments.
+  const pid_t child_pid =
+      syscall(SYS_clone, CLONE_NEWIPC | CLONE_SYSVSEM | SIGCHLD, nullptr,
+              nullptr, nullptr, nullptr);
+  if (child_pid == 0) {
+    _exit(0);
+  }
+  EXPECT_THAT(child_pid, SyscallFailsWithErrno(EINVAL));
+}
+
 TEST(SemaphoreTest, SemIpcSet) {
   // Drop CAP_IPC_OWNER which allows us to bypass semaphore permissions.
   AutoCapability cap(CAP_IPC_OWNER, false);
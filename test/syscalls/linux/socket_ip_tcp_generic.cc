@@ -28,6 +28,7 @@
 #include "absl/memory/memory.h"
 #include "absl/time/clock.h"
 #include "absl/time/time.h"
+#include "test/syscalls/linux/ip_socket_test_util.h"
 #include "test/util/save_util.h"
 #include "test/util/socket_util.h"
 #include "test/util/temp_path.h"
@@ -1512,5 +1513,42 @@ TEST_P(TCPSocketPairTest, WaitTillTimeoutWithCorkOption) {
               SyscallSucceedsWithValue(sizeof(buf)));
 }
 
+TEST_P(TCPSocketPairTest, ZeroCopySend) {
+  // Hostinet doesn't support SO_ZEROCOPY.
+  SKIP_IF(IsRunningWithHostinet());
+
+  auto sockets = ASSERT_NO_ERRNO_AND_VALUE(NewSocketPair());
+  ASSERT_THAT(setsockopt(sockets->first_fd(), SOL_SOCKET, SO_ZEROCOPY,
+                         &kSockOptOn, sizeof(kSockOptOn)),
+              SyscallSucceeds());
+
+  constexpr int kSends = 3;
+  char buf[1024];
+  RandomizeBuffer(buf, sizeof(buf));
+  for (int i = 0; i < kSends; i++) {
+    ASSERT_THAT(
+        RetryEINTR(send)(sockets->first_fd(), buf, sizeof(buf), MSG_ZEROCOPY),
+        SyscallSucceedsWithValue(sizeof(buf)));
+  }
+
+  // Read the data so that it's acknowledged.
+  std::vector<char> received(kSends * sizeof(buf));
+  ASSERT_THAT(RetryEINTR(recv)(sockets->second_fd(), received.data(),
+                               received.size(), MSG_WAITALL),
+              SyscallSucceedsWithValue(received.size()));
+  for (int i = 0; i < kSends; i++) {
+    EXPECT_EQ(memcmp(received.data() + i * sizeof(buf), buf, sizeof(buf)), 0);
+  }
+
+  int level = SOL_IP;
+  int type = IP_RECVERR;
+  if (sockets->first_addr()->sa_family == AF_INET6) {
+    level = SOL_IPV6;
+    type = IPV6_RECVERR;
+  }
+  ASSERT_NO_FATAL_FAILURE(RecvZeroCopyCompletions(sockets->first_fd(), level,
+                                                  type, 0, kSends - 1));
+}
+
 }  // namespace testing
 }  // namespace gvisor
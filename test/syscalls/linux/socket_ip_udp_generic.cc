@@ -14,6 +14,7 @@
 
 #include "test/syscalls/linux/socket_ip_udp_generic.h"
 
+#include <arpa/inet.h>
 #include <errno.h>
 #ifdef __linux__
 #include <linux/errqueue.h>
@@ -29,6 +30,7 @@
 #include <sys/un.h>
 
 #include "gtest/gtest.h"
+#include "test/util/capability_util.h"
 #include "test/util/socket_util.h"
 #include "test/util/test_util.h"
 
@@ -412,6 +414,126 @@ TEST_P(UDPSocketPairTest, SetAndGetReceiveOrigDstAddr) {
   EXPECT_EQ(get_len, sizeof(get));
 }
 
+// Test getsockopt for a socket which is not set with IP_TRANSPARENT option.
+TEST_P(UDPSocketPairTest, TransparentDefault) {
+  auto sockets = ASSERT_NO_ERRNO_AND_VALUE(NewSocketPair());
+
+  int get = -1;
+  socklen_t get_len = sizeof(get);
+  int level = SOL_IP;
+  int type = IP_TRANSPARENT;
+  if (sockets->first_addr()->sa_family == AF_INET6) {
+    level = SOL_IPV6;
+    type = IPV6_TRANSPARENT;
+  }
+  ASSERT_THAT(getsockopt(sockets->first_fd(), level, type, &get, &get_len),
+              SyscallSucceedsWithValue(0));
+  EXPECT_EQ(get_len, sizeof(get));
+  EXPECT_EQ(get, kSockOptOff);
+}
+
+// Test setsockopt and getsockopt for a socket with IP_TRANSPARENT option.
+TEST_P(UDPSocketPairTest, SetAndGetTransparent) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)));
+  auto sockets = ASSERT_NO_ERRNO_AND_VALUE(NewSocketPair());
+
+  int level = SOL_IP;
+  int type = IP_TRANSPARENT;
+  if (sockets->first_addr()->sa_family == AF_INET6) {
+    level = SOL_IPV6;
+    type = IPV6_TRANSPARENT;
+  }
+
+  ASSERT_THAT(setsockopt(sockets->first_fd(), level, type, &kSockOptOn,
+                         sizeof(kSockOptOn)),
+              SyscallSucceedsWithValue(0));
+
+  int get = -1;
+  socklen_t get_len = sizeof(get);
+  ASSERT_THAT(getsockopt(sockets->first_fd(), level, type, &get, &get_len),
+              SyscallSucceedsWithValue(0));
+  EXPECT_EQ(get, kSockOptOn);
+  EXPECT_EQ(get_len, sizeof(get));
+
+  ASSERT_THAT(setsockopt(sockets->first_fd(), level, type, &kSockOptOff,
+                         sizeof(kSockOptOff)),
+              SyscallSucceedsWithValue(0));
+
+  ASSERT_THAT(getsockopt(sockets->first_fd(), level, type, &get, &get_len),
+              SyscallSucceedsWithValue(0));
+  EXPECT_EQ(get, kSockOptOff);
+  EXPECT_EQ(get_len, sizeof(get));
+}
+
+// Test that IP_TRANSPARENT requires CAP_NET_ADMIN or CAP_NET_RAW.
+TEST_P(UDPSocketPairTest, SetTransparentWithoutCapability) {
+  SKIP_IF(ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)) ||
+          ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_RAW)));
+  auto sockets = ASSERT_NO_ERRNO_AND_VALUE(NewSocketPair());
+
+  int level = SOL_IP;
+  int type = IP_TRANSPARENT;
+  if (sockets->first_addr()->sa_family == AF_INET6) {
+    level = SOL_IPV6;
+    type = IPV6_TRANSPARENT;
+  }
+  EXPECT_THAT(setsockopt(sockets->first_fd(), level, type, &kSockOptOn,
+                         sizeof(kSockOptOn)),
+              SyscallFailsWithErrno(EPERM));
+}
+
+// Test that a socket with IP_TRANSPARENT can bind to a non-local address.
+TEST_P(UDPSocketPairTest, TransparentBindNonLocalAddress) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)));
+  auto sockets = ASSERT_NO_ERRNO_AND_VALUE(NewSocketPair());
+  const int family = sockets->first_addr()->sa_family;
+
+  // Use addresses reserved for documentation, which are not assigned to any
+  // interface.
+  sockaddr_storage addr = {};
+  socklen_t addrlen;
+  int level = SOL_IP;
+  int type = IP_TRANSPARENT;
+  if (family == AF_INET6) {
+    level = SOL_IPV6;
+    type = IPV6_TRANSPARENT;
+    auto* addr6 = reinterpret_cast<sockaddr_in6*>(&addr);
+    addr6->sin6_family = AF_INET6;
+    ASSERT_EQ(inet_pton(AF_INET6, "2001:db8::1", &addr6->sin6_addr), 1);
+    addrlen = sizeof(*addr6);
+  } else {
+    auto* addr4 = reinterpret_cast<sockaddr_in*>(&addr);
+    addr4->sin_family = AF_INET;
+    ASSERT_EQ(inet_pton(AF_INET, "192.0.2.1", &addr4->sin_addr), 1);
+    addrlen = sizeof(*addr4);
+  }
+
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(family, SOCK_DGRAM, IPPROTO_UDP));
+  EXPECT_THAT(bind(fd.get(), AsSockAddr(&addr), addrlen),
+              SyscallFailsWithErrno(EADDRNOTAVAIL));
+
+  ASSERT_THAT(
+      setsockopt(fd.get(), level, type, &kSockOptOn, sizeof(kSockOptOn)),
+      SyscallSucceeds());
+  ASSERT_THAT(bind(fd.get(), AsSockAddr(&addr), addrlen), SyscallSucceeds());
+
+  sockaddr_storage bound = {};
+  socklen_t bound_len = sizeof(bound);
+  ASSERT_THAT(getsockname(fd.get(), AsSockAddr(&bound), &bound_len),
+              SyscallSucceeds());
+  ASSERT_EQ(bound_len, addrlen);
+  if (family == AF_INET6) {
+    EXPECT_EQ(memcmp(&reinterpret_cast<sockaddr_in6*>(&bound)->sin6_addr,
+                     &reinterpret_cast<sockaddr_in6*>(&addr)->sin6_addr,
+                     sizeof(in6_addr)),
+              0);
+  } else {
+    EXPECT_EQ(reinterpret_cast<sockaddr_in*>(&bound)->sin_addr.s_addr,
+              reinterpret_cast<sockaddr_in*>(&addr)->sin_addr.s_addr);
+  }
+}
+
 // Holds TOS or TClass information for IPv4 or IPv6 respectively.
 struct RecvTosOption {
   int level;
@@ -15,6 +15,7 @@
 #include "test/syscalls/linux/socket_ipv4_udp_unbound.h"
 
 #include <arpa/inet.h>
+#include <linux/filter.h>
 #include <sys/socket.h>
 #include <sys/types.h>
 #include <sys/un.h>
@@ -1879,6 +1880,85 @@ TEST_P(IPv4UDPUnboundSocketTest, ReuseAddrReusePortDistribution) {
               IsPosixErrorOkAndHolds(kMessageSize));
 }
 
+// Check that a classic BPF program attached by SO_ATTACH_REUSEPORT_CBPF selects
+// the receiver of the REUSEPORT group.
+TEST_P(IPv4UDPUnboundSocketTest, ReusePortCBPFSteering) {
+  auto receiver1 = ASSERT_NO_ERRNO_AND_VALUE(NewSocket());
+  auto receiver2 = ASSERT_NO_ERRNO_AND_VALUE(NewSocket());
+
+  // The program returns the first byte of the payload, which selects the
+  // receiver at that index, in the order in which they were bound.
+  struct sock_filter code[] = {
+      BPF_STMT(BPF_LD | BPF_B | BPF_ABS, 0),
+      BPF_STMT(BPF_RET | BPF_A, 0),
+  };
+  struct sock_fprog prog = {
+      .len = sizeof(code) / sizeof(code[0]),
+      .filter = code,
+  };
+
+  // The program can only be attached to REUSEPORT sockets.
+  EXPECT_THAT(setsockopt(receiver1->get(), SOL_SOCKET,
+                         SO_ATTACH_REUSEPORT_CBPF, &prog, sizeof(prog)),
+              SyscallFailsWithErrno(EINVAL));
+
+  for (const auto& receiver : {receiver1.get(), receiver2.get()}) {
+    ASSERT_THAT(setsockopt(receiver->get(), SOL_SOCKET, SO_REUSEPORT,
+                           &kSockOptOn, sizeof(kSockOptOn)),
+                SyscallSucceeds());
+  }
+  ASSERT_THAT(setsockopt(receiver1->get(), SOL_SOCKET,
+                         SO_ATTACH_REUSEPORT_CBPF, &prog, sizeof(prog)),
+              SyscallSucceeds());
+
+  auto addr = V4Loopback();
+  ASSERT_THAT(bind(receiver1->get(), AsSockAddr(&addr.addr), addr.addr_len),
+              SyscallSucceeds());
+  socklen_t addr_len = addr.addr_len;
+  ASSERT_THAT(getsockname(receiver1->get(), AsSockAddr(&addr.addr), &addr_len),
+              SyscallSucceeds());
+  EXPECT_EQ(addr_len, addr.addr_len);
+  ASSERT_THAT(bind(receiver2->get(), AsSockAddr(&addr.addr), addr.addr_len),
+              SyscallSucceeds());
+
+  constexpr int kMessageSize = 10;
+  constexpr int kMessages = 10;
+
+  // Saving during each iteration of the following loop is too expensive.
+  DisableSave ds;
+
+  for (int i = 0; i < kMessages; ++i) {
+    // Use a new socket each time so that hashing would spread the messages
+    // across the receivers.
+    auto sender = ASSERT_NO_ERRNO_AND_VALUE(NewSocket());
+    char send_buf[kMessageSize] = {static_cast<char>(i % 2)};
+    ASSERT_THAT(
+        RetryEINTR(sendto)(sender->get(), send_buf, sizeof(send_buf), 0,
+                           AsSockAddr(&addr.addr), addr.addr_len),
+        SyscallSucceedsWithValue(sizeof(send_buf)));
+  }
+
+  ds.reset();
+
+  // Check that each receiver got the messages that select it.
+  for (int i = 0; i < kMessages; ++i) {
+    char recv_buf[kMessageSize] = {};
+    FileDescriptor* receiver = i % 2 == 0 ? receiver1.get() : receiver2.get();
+    ASSERT_THAT(RecvTimeout(receiver->get(), recv_buf, sizeof(recv_buf),
+                            kPositiveTimeoutSecs),
+                IsPosixErrorOkAndHolds(kMessageSize));
+    EXPECT_EQ(recv_buf[0], i % 2);
+  }
+
+  constexpr int kUnused = 0;
+  EXPECT_THAT(setsockopt(receiver1->get(), SOL_SOCKET, SO_DETACH_REUSEPORT_BPF,
+                         &kUnused, sizeof(kUnused)),
+              SyscallSucceeds());
+  EXPECT_THAT(setsockopt(receiver1->get(), SOL_SOCKET, SO_DETACH_REUSEPORT_BPF,
+                         &kUnused, sizeof(kUnused)),
+              SyscallFailsWithErrno(ENOENT));
+}
+
 // Test that socket will receive packet info control message.
 TEST_P(IPv4UDPUnboundSocketTest, SetAndReceiveIPPKTINFO) {
   // TODO(gvisor.dev/issue/1202): ioctl() is not supported by hostinet.
@@ -2338,5 +2418,261 @@ TEST_P(IPv4UDPUnboundSocketTest, IpMulticastIPPacketInfo) {
   EXPECT_EQ(received_pktinfo.ipi_addr.s_addr, group.imr_multiaddr.s_addr);
 }
 
+// Check that IP_ADD_SOURCE_MEMBERSHIP joins a group in INCLUDE mode and that
+// IP_DROP_SOURCE_MEMBERSHIP leaves it once the last source is dropped.
+TEST_P(IPv4UDPUnboundSocketTest, IpAddDropSourceMembership) {
+  // TODO(b/267210840): Get multicast working with hostinet.
+  SKIP_IF(IsRunningWithHostinet());
+
+  auto sender = ASSERT_NO_ERRNO_AND_VALUE(NewSocket());
+  auto receiver = ASSERT_NO_ERRNO_AND_VALUE(NewSocket());
+
+  auto sender_addr = V4Loopback();
+  ASSERT_THAT(
+      bind(sender->get(), AsSockAddr(&sender_addr.addr), sender_addr.addr_len),
+      SyscallSucceeds());
+  auto receiver_addr = V4Any();
+  ASSERT_THAT(bind(receiver->get(), AsSockAddr(&receiver_addr.addr),
+                   receiver_addr.addr_len),
+              SyscallSucceeds());
+  socklen_t receiver_addr_len = receiver_addr.addr_len;
+  ASSERT_THAT(getsockname(receiver->get(), AsSockAddr(&receiver_addr.addr),
+                          &receiver_addr_len),
+              SyscallSucceeds());
+
+  ip_mreq_source mreq = {};
+  mreq.imr_multiaddr.s_addr = inet_addr(kMulticastAddress);
+  mreq.imr_interface.s_addr = htonl(INADDR_LOOPBACK);
+  mreq.imr_sourceaddr.s_addr = htonl(INADDR_LOOPBACK);
+  ASSERT_THAT(setsockopt(receiver->get(), IPPROTO_IP, IP_ADD_SOURCE_MEMBERSHIP,
+                         &mreq, sizeof(mreq)),
+              SyscallSucceeds());
+  EXPECT_THAT(setsockopt(receiver->get(), IPPROTO_IP, IP_ADD_SOURCE_MEMBERSHIP,
+                         &mreq, sizeof(mreq)),
+              SyscallFailsWithErrno(EADDRNOTAVAIL));
+  // The group is joined in INCLUDE mode.
+  EXPECT_THAT(setsockopt(receiver->get(), IPPROTO_IP, IP_BLOCK_SOURCE, &mreq,
+                         sizeof(mreq)),
+              SyscallFailsWithErrno(EINVAL));
+
+  auto send_addr = V4Multicast();
+  reinterpret_cast<sockaddr_in*>(&send_addr.addr)->sin_port =
+      reinterpret_cast<sockaddr_in*>(&receiver_addr.addr)->sin_port;
+  char send_buf[200];
+  RandomizeBuffer(send_buf, sizeof(send_buf));
+  ASSERT_THAT(
+      RetryEINTR(sendto)(sender->get(), send_buf, sizeof(send_buf), 0,
+                         AsSockAddr(&send_addr.addr), send_addr.addr_len),
+      SyscallSucceedsWithValue(sizeof(send_buf)));
+  char recv_buf[sizeof(send_buf)] = {};
+  ASSERT_THAT(RecvTimeout(receiver->get(), recv_buf, sizeof(recv_buf),
+                          kPositiveTimeoutSecs),
+              IsPosixErrorOkAndHolds(sizeof(recv_buf)));
+  EXPECT_EQ(0, memcmp(send_buf, recv_buf, sizeof(send_buf)));
+
+  ASSERT_THAT(setsockopt(receiver->get(), IPPROTO_IP, IP_DROP_SOURCE_MEMBERSHIP,
+                         &mreq, sizeof(mreq)),
+              SyscallSucceeds());
+  ASSERT_THAT(
+      RetryEINTR(sendto)(sender->get(), send_buf, sizeof(send_buf), 0,
+                         AsSockAddr(&send_addr.addr), send_addr.addr_len),
+      SyscallSucceedsWithValue(sizeof(send_buf)));
+  EXPECT_THAT(RecvTimeout(receiver->get(), recv_buf, sizeof(recv_buf),
+                          kNegativeTimeoutSecs),
+              PosixErrorIs(EAGAIN, ::testing::_));
+
+  // The group was left with the last source.
+  EXPECT_THAT(setsockopt(receiver->get(), IPPROTO_IP, IP_DROP_SOURCE_MEMBERSHIP,
+                         &mreq, sizeof(mreq)),
+              SyscallFailsWithErrno(EINVAL));
+}
+
+// Check that IP_BLOCK_SOURCE and IP_UNBLOCK_SOURCE filter the sources of a
+// group joined with IP_ADD_MEMBERSHIP.
+TEST_P(IPv4UDPUnboundSocketTest, IpBlockUnblockSource) {
+  // TODO(b/267210840): Get multicast working with hostinet.
+  SKIP_IF(IsRunningWithHostinet());
+
+  auto sender = ASSERT_NO_ERRNO_AND_VALUE(NewSocket());
+  auto receiver = ASSERT_NO_ERRNO_AND_VALUE(NewSocket());
+
+  auto sender_addr = V4Loopback();
+  ASSERT_THAT(
+      bind(sender->get(), AsSockAddr(&sender_addr.addr), sender_addr.addr_len),
+      SyscallSucceeds());
+  auto receiver_addr = V4Any();
+  ASSERT_THAT(bind(receiver->get(), AsSockAddr(&receiver_addr.addr),
+                   receiver_addr.addr_len),
+              SyscallSucceeds());
+  socklen_t receiver_addr_len = receiver_addr.addr_len;
+  ASSERT_THAT(getsockname(receiver->get(), AsSockAddr(&receiver_addr.addr),
+                          &receiver_addr_len),
+              SyscallSucceeds());
+
+  ip_mreq_source mreq = {};
+  mreq.imr_multiaddr.s_addr = inet_addr(kMulticastAddress);
+  mreq.imr_interface.s_addr = htonl(INADDR_LOOPBACK);
+  mreq.imr_sourceaddr.s_addr = htonl(INADDR_LOOPBACK);
+  // Sources may only be blocked once the group is joined.
+  EXPECT_THAT(setsockopt(receiver->get(), IPPROTO_IP, IP_BLOCK_SOURCE, &mreq,
+                         sizeof(mreq)),
+              SyscallFailsWithErrno(EINVAL));
+
+  ip_mreq group = {};
+  group.imr_multiaddr = mreq.imr_multiaddr;
+  group.imr_interface = mreq.imr_interface;
+  ASSERT_THAT(setsockopt(receiver->get(), IPPROTO_IP, IP_ADD_MEMBERSHIP, &group,
+                         sizeof(group)),
+              SyscallSucceeds());
+  ASSERT_THAT(setsockopt(receiver->get(), IPPROTO_IP, IP_BLOCK_SOURCE, &mreq,
+                         sizeof(mreq)),
+              SyscallSucceeds());
+
+  auto send_addr = V4Multicast();
+  reinterpret_cast<sockaddr_in*>(&send_addr.addr)->sin_port =
+      reinterpret_cast<sockaddr_in*>(&receiver_addr.addr)->sin_port;
+  char send_buf[200];
+  RandomizeBuffer(send_buf, sizeof(send_buf));
+  ASSERT_THAT(
+      RetryEINTR(sendto)(sender->get(), send_buf, sizeof(send_buf), 0,
+                         AsSockAddr(&send_addr.addr), send_addr.addr_len),
+      SyscallSucceedsWithValue(sizeof(send_buf)));
+  char recv_buf[sizeof(send_buf)] = {};
+  EXPECT_THAT(RecvTimeout(receiver->get(), recv_buf, sizeof(recv_buf),
+                          kNegativeTimeoutSecs),
+              PosixErrorIs(EAGAIN, ::testing::_));
+
+  ASSERT_THAT(setsockopt(receiver->get(), IPPROTO_IP, IP_UNBLOCK_SOURCE, &mreq,
+                         sizeof(mreq)),
+              SyscallSucceeds());
+  ASSERT_THAT(
+      RetryEINTR(sendto)(sender->get(), send_buf, sizeof(send_buf), 0,
+                         AsSockAddr(&send_addr.addr), send_addr.addr_len),
+      SyscallSucceedsWithValue(sizeof(send_buf)));
+  ASSERT_THAT(RecvTimeout(receiver->get(), recv_buf, sizeof(recv_buf),
+                          kPositiveTimeoutSecs),
+              IsPosixErrorOkAndHolds(sizeof(recv_buf)));
+  EXPECT_EQ(0, memcmp(send_buf, recv_buf, sizeof(send_buf)));
+}
+
+// Check that IP_MSFILTER sets and gets the source filter of a joined group.
+TEST_P(IPv4UDPUnboundSocketTest, IpMsfilter) {
+  // TODO(b/267210840): Get multicast working with hostinet.
+  SKIP_IF(IsRunningWithHostinet());
+
+  auto socket = ASSERT_NO_ERRNO_AND_VALUE(NewSocket());
+
+  char buf[IP_MSFILTER_SIZE(2)] = {};
+  ip_msfilter* filter = reinterpret_cast<ip_msfilter*>(buf);
+  filter->imsf_multiaddr.s_addr = inet_addr(kMulticastAddress);
+  filter->imsf_interface.s_addr = htonl(INADDR_LOOPBACK);
+  filter->imsf_fmode = MCAST_EXCLUDE;
+  filter->imsf_numsrc = 2;
+  filter->imsf_slist[0].s_addr = inet_addr("127.0.0.2");
+  filter->imsf_slist[1].s_addr = inet_addr("127.0.0.3");
+
+  // The group must be joined.
+  EXPECT_THAT(
+      setsockopt(socket->get(), IPPROTO_IP, IP_MSFILTER, buf, sizeof(buf)),
+      SyscallFailsWithErrno(EINVAL));
+  socklen_t len = sizeof(buf);
+  EXPECT_THAT(getsockopt(socket->get(), IPPROTO_IP, IP_MSFILTER, buf, &len),
+              SyscallFailsWithErrno(EADDRNOTAVAIL));
+
+  ip_mreq group = {};
+  group.imr_multiaddr = filter->imsf_multiaddr;
+  group.imr_interface = filter->imsf_interface;
+  ASSERT_THAT(setsockopt(socket->get(), IPPROTO_IP, IP_ADD_MEMBERSHIP, &group,
+                         sizeof(group)),
+              SyscallSucceeds());
+  ASSERT_THAT(
+      setsockopt(socket->get(), IPPROTO_IP, IP_MSFILTER, buf, sizeof(buf)),
+      SyscallSucceeds());
+
+  // Only the requested number of sources is returned along with the total
+  // number of sources.
+  char got_buf[IP_MSFILTER_SIZE(1)] = {};
+  ip_msfilter* got = reinterpret_cast<ip_msfilter*>(got_buf);
+  got->imsf_multiaddr = filter->imsf_multiaddr;
+  got->imsf_interface = filter->imsf_interface;
+  got->imsf_numsrc = 1;
+  len = sizeof(got_buf);
+  ASSERT_THAT(getsockopt(socket->get(), IPPROTO_IP, IP_MSFILTER, got_buf, &len),
+              SyscallSucceeds());
+  EXPECT_EQ(len, IP_MSFILTER_SIZE(1));
+  EXPECT_EQ(got->imsf_fmode, MCAST_EXCLUDE);
+  EXPECT_EQ(got->imsf_numsrc, 2u);
+  EXPECT_THAT(got->imsf_slist[0].s_addr,
+              ::testing::AnyOf(filter->imsf_slist[0].s_addr,
+                               filter->imsf_slist[1].s_addr));
+
+  // An INCLUDE mode filter without sources leaves the group.
+  filter->imsf_fmode = MCAST_INCLUDE;
+  filter->imsf_numsrc = 0;
+  ASSERT_THAT(setsockopt(socket->get(), IPPROTO_IP, IP_MSFILTER, buf,
+                         IP_MSFILTER_SIZE(0)),
+              SyscallSucceeds());
+  EXPECT_THAT(setsockopt(socket->get(), IPPROTO_IP, IP_DROP_MEMBERSHIP, &group,
+                         sizeof(group)),
+              SyscallFailsWithErrno(EADDRNOTAVAIL));
+}
+
+// Check that the protocol-independent MCAST_JOIN_SOURCE_GROUP and
+// MCAST_LEAVE_SOURCE_GROUP options filter the sources of a group.
+TEST_P(IPv4UDPUnboundSocketTest, McastJoinLeaveSourceGroup) {
+  // TODO(b/267210840): Get multicast working with hostinet.
+  SKIP_IF(IsRunningWithHostinet());
+
+  auto sender = ASSERT_NO_ERRNO_AND_VALUE(NewSocket());
+  auto receiver = ASSERT_NO_ERRNO_AND_VALUE(NewSocket());
+
+  auto sender_addr = V4Loopback();
+  ASSERT_THAT(
+      bind(sender->get(), AsSockAddr(&sender_addr.addr), sender_addr.addr_len),
+      SyscallSucceeds());
+  auto receiver_addr = V4Any();
+  ASSERT_THAT(bind(receiver->get(), AsSockAddr(&receiver_addr.addr),
+                   receiver_addr.addr_len),
+              SyscallSucceeds());
+  socklen_t receiver_addr_len = receiver_addr.addr_len;
+  ASSERT_THAT(getsockname(receiver->get(), AsSockAddr(&receiver_addr.addr),
+                          &receiver_addr_len),
+              SyscallSucceeds());
+
+  group_source_req req = {};
+  req.gsr_interface = ASSERT_NO_ERRNO_AND_VALUE(GetLoopbackIndex());
+  sockaddr_in* group = reinterpret_cast<sockaddr_in*>(&req.gsr_group);
+  group->sin_family = AF_INET;
+  group->sin_addr.s_addr = inet_addr(kMulticastAddress);
+  sockaddr_in* source = reinterpret_cast<sockaddr_in*>(&req.gsr_source);
+  source->sin_family = AF_INET;
+  // Only receive packets from a source other than the sender.
+  source->sin_addr.s_addr = inet_addr("127.0.0.2");
+  ASSERT_THAT(setsockopt(receiver->get(), IPPROTO_IP, MCAST_JOIN_SOURCE_GROUP,
+                         &req, sizeof(req)),
+              SyscallSucceeds());
+
+  auto send_addr = V4Multicast();
+  reinterpret_cast<sockaddr_in*>(&send_addr.addr)->sin_port =
+      reinterpret_cast<sockaddr_in*>(&receiver_addr.addr)->sin_port;
+  char send_buf[200];
+  RandomizeBuffer(send_buf, sizeof(send_buf));
+  ASSERT_THAT(
+      RetryEINTR(sendto)(sender->get(), send_buf, sizeof(send_buf), 0,
+                         AsSockAddr(&send_addr.addr), send_addr.addr_len),
+      SyscallSucceedsWithValue(sizeof(send_buf)));
+  char recv_buf[sizeof(send_buf)] = {};
+  EXPECT_THAT(RecvTimeout(receiver->get(), recv_buf, sizeof(recv_buf),
+                          kNegativeTimeoutSecs),
+              PosixErrorIs(EAGAIN, ::testing::_));
+
+  ASSERT_THAT(setsockopt(receiver->get(), IPPROTO_IP, MCAST_LEAVE_SOURCE_GROUP,
+                         &req, sizeof(req)),
+              SyscallSucceeds());
+  EXPECT_THAT(setsockopt(receiver->get(), IPPROTO_IP, MCAST_LEAVE_SOURCE_GROUP,
+                         &req, sizeof(req)),
+              SyscallFailsWithErrno(EINVAL));
+}
+
 }  // namespace testing
 }  // namespace gvisor
@@ -120,6 +120,170 @@ TEST_P(IPv6UDPUnboundSocketTest, IPv6PacketInfo) {
   EXPECT_THAT(CMSG_NXTHDR(&recv_msg, cmsg), IsNull());
 }
 
+// Sends a datagram from sender to receiver with the control message of the
+// given level, type and value, and returns the result of sendmsg.
+template <typename T>
+PosixErrorOr<int> SendWithControlMessage(int sender, const TestAddress& to,
+                                         int level, int type, const T& value) {
+  char send_buf[16] = {};
+  iovec iov = {
+      .iov_base = send_buf,
+      .iov_len = sizeof(send_buf),
+  };
+  char cmsg_buf[CMSG_SPACE(sizeof(T))] = {};
+  msghdr msg = {
+      .msg_name = const_cast<sockaddr_storage*>(&to.addr),
+      .msg_namelen = to.addr_len,
+      .msg_iov = &iov,
+      .msg_iovlen = 1,
+      .msg_control = cmsg_buf,
+      .msg_controllen = sizeof(cmsg_buf),
+  };
+  cmsghdr* cmsg = CMSG_FIRSTHDR(&msg);
+  cmsg->cmsg_level = level;
+  cmsg->cmsg_type = type;
+  cmsg->cmsg_len = CMSG_LEN(sizeof(T));
+  memcpy(CMSG_DATA(cmsg), &value, sizeof(T));
+  int ret = RetryEINTR(sendmsg)(sender, &msg, 0);
+  if (ret < 0) {
+    return PosixError(errno, "sendmsg");
+  }
+  return ret;
+}
+
+TEST_P(IPv6UDPUnboundSocketTest, SendIPv6PacketInfo) {
+  auto sender = ASSERT_NO_ERRNO_AND_VALUE(NewSocket());
+  auto receiver = ASSERT_NO_ERRNO_AND_VALUE(NewSocket());
+
+  auto receiver_addr = V6Loopback();
+  ASSERT_THAT(bind(receiver->get(), AsSockAddr(&receiver_addr.addr),
+                   receiver_addr.addr_len),
+              SyscallSucceeds());
+  socklen_t receiver_addr_len = receiver_addr.addr_len;
+  ASSERT_THAT(getsockname(receiver->get(), AsSockAddr(&receiver_addr.addr),
+                          &receiver_addr_len),
+              SyscallSucceeds());
+  ASSERT_EQ(receiver_addr_len, receiver_addr.addr_len);
+
+  in6_pktinfo pktinfo = {};
+  pktinfo.ipi6_addr = in6addr_loopback;
+  pktinfo.ipi6_ifindex = ASSERT_NO_ERRNO_AND_VALUE(GetLoopbackIndex());
+  ASSERT_THAT(SendWithControlMessage(sender->get(), receiver_addr,
+                                     IPPROTO_IPV6, IPV6_PKTINFO, pktinfo),
+              IsPosixErrorOkAndHolds(16));
+
+  // The datagram is sent from the address of the control message.
+  char recv_buf[17];
+  sockaddr_in6 src = {};
+  socklen_t src_len = sizeof(src);
+  ASSERT_THAT(RetryEINTR(recvfrom)(receiver->get(), recv_buf, sizeof(recv_buf),
+                                   0, AsSockAddr(&src), &src_len),
+              SyscallSucceedsWithValue(16));
+  ASSERT_EQ(src_len, sizeof(src));
+  EXPECT_EQ(memcmp(&src.sin6_addr, &in6addr_loopback, sizeof(in6_addr)), 0);
+}
+
+TEST_P(IPv6UDPUnboundSocketTest, SetStickyIPv6PacketInfo) {
+  auto sender = ASSERT_NO_ERRNO_AND_VALUE(NewSocket());
+  auto receiver = ASSERT_NO_ERRNO_AND_VALUE(NewSocket());
+
+  auto receiver_addr = V6Loopback();
+  ASSERT_THAT(bind(receiver->get(), AsSockAddr(&receiver_addr.addr),
+                   receiver_addr.addr_len),
+              SyscallSucceeds());
+  socklen_t receiver_addr_len = receiver_addr.addr_len;
+  ASSERT_THAT(getsockname(receiver->get(), AsSockAddr(&receiver_addr.addr),
+                          &receiver_addr_len),
+              SyscallSucceeds());
+  ASSERT_EQ(receiver_addr_len, receiver_addr.addr_len);
+
+  // The option must hold a whole in6_pktinfo, with a local address.
+  in6_pktinfo pktinfo = {};
+  EXPECT_THAT(setsockopt(sender->get(), IPPROTO_IPV6, IPV6_PKTINFO, &pktinfo,
+                         sizeof(pktinfo) - 1),
+              SyscallFailsWithErrno(EINVAL));
+  ASSERT_EQ(inet_pton(AF_INET6, "2001:db8::1", &pktinfo.ipi6_addr), 1);
+  EXPECT_THAT(setsockopt(sender->get(), IPPROTO_IPV6, IPV6_PKTINFO, &pktinfo,
+                         sizeof(pktinfo)),
+              SyscallFailsWithErrno(EINVAL));
+
+  pktinfo.ipi6_addr = in6addr_loopback;
+  ASSERT_THAT(setsockopt(sender->get(), IPPROTO_IPV6, IPV6_PKTINFO, &pktinfo,
+                         sizeof(pktinfo)),
+              SyscallSucceeds());
+
+  char send_buf[16] = {};
+  ASSERT_THAT(RetryEINTR(sendto)(sender->get(), send_buf, sizeof(send_buf), 0,
+                                 AsSockAddr(&receiver_addr.addr),
+                                 receiver_addr.addr_len),
+              SyscallSucceedsWithValue(sizeof(send_buf)));
+
+  char recv_buf[sizeof(send_buf) + 1];
+  sockaddr_in6 src = {};
+  socklen_t src_len = sizeof(src);
+  ASSERT_THAT(RetryEINTR(recvfrom)(receiver->get(), recv_buf, sizeof(recv_buf),
+                                   0, AsSockAddr(&src), &src_len),
+              SyscallSucceedsWithValue(sizeof(send_buf)));
+  ASSERT_EQ(src_len, sizeof(src));
+  EXPECT_EQ(memcmp(&src.sin6_addr, &in6addr_loopback, sizeof(in6_addr)), 0);
+}
+
+TEST_P(IPv6UDPUnboundSocketTest, SendTClassAndHopLimit) {
+  auto sender = ASSERT_NO_ERRNO_AND_VALUE(NewSocket());
+  auto receiver = ASSERT_NO_ERRNO_AND_VALUE(NewSocket());
+
+  auto receiver_addr = V6Loopback();
+  ASSERT_THAT(bind(receiver->get(), AsSockAddr(&receiver_addr.addr),
+                   receiver_addr.addr_len),
+              SyscallSucceeds());
+  socklen_t receiver_addr_len = receiver_addr.addr_len;
+  ASSERT_THAT(getsockname(receiver->get(), AsSockAddr(&receiver_addr.addr),
+                          &receiver_addr_len),
+              SyscallSucceeds());
+  ASSERT_EQ(receiver_addr_len, receiver_addr.addr_len);
+  ASSERT_THAT(setsockopt(receiver->get(), IPPROTO_IPV6, IPV6_RECVTCLASS,
+                         &kSockOptOn, sizeof(kSockOptOn)),
+              SyscallSucceeds());
+
+  // Values out of [-1, 255] are rejected.
+  for (int type : {IPV6_TCLASS, IPV6_HOPLIMIT}) {
+    for (int value : {-2, 256}) {
+      EXPECT_THAT(SendWithControlMessage(sender->get(), receiver_addr,
+                                         IPPROTO_IPV6, type, value),
+                  PosixErrorIs(EINVAL, ::testing::_))
+          << "type = " << type << ", value = " << value;
+    }
+  }
+
+  // -1 selects the value of the socket.
+  constexpr int kSocketTClass = 0x20;
+  ASSERT_THAT(setsockopt(sender->get(), IPPROTO_IPV6, IPV6_TCLASS,
+                         &kSocketTClass, sizeof(kSocketTClass)),
+              SyscallSucceeds());
+  for (int type : {IPV6_TCLASS, IPV6_HOPLIMIT}) {
+    ASSERT_THAT(SendWithControlMessage(sender->get(), receiver_addr,
+                                       IPPROTO_IPV6, type, -1),
+                IsPosixErrorOkAndHolds(16));
+    char buf[16];
+    size_t buf_size = sizeof(buf);
+    int tclass;
+    ASSERT_NO_FATAL_FAILURE(
+        RecvTClass(receiver->get(), buf, &buf_size, &tclass));
+    EXPECT_EQ(tclass, kSocketTClass) << "type = " << type;
+  }
+
+  // The traffic class of the control message overrides the socket's.
+  constexpr int kTClass = 0x40;
+  ASSERT_THAT(SendWithControlMessage(sender->get(), receiver_addr,
+                                     IPPROTO_IPV6, IPV6_TCLASS, kTClass),
+              IsPosixErrorOkAndHolds(16));
+  char buf[16];
+  size_t buf_size = sizeof(buf);
+  int tclass;
+  ASSERT_NO_FATAL_FAILURE(RecvTClass(receiver->get(), buf, &buf_size, &tclass));
+  EXPECT_EQ(tclass, kTClass);
+}
+
 // Test that socket will receive IP_RECVORIGDSTADDR control message.
 TEST_P(IPv6UDPUnboundSocketTest, SetAndReceiveIPReceiveOrigDstAddr) {
   auto sender = ASSERT_NO_ERRNO_AND_VALUE(NewSocket());
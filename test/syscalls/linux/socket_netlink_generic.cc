@@ -0,0 +1,514 @@
+// Copyright 2026 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+#include <arpa/inet.h>
+#include <linux/genetlink.h>
+#include <linux/if_link.h>
+#include <linux/netlink.h>
+#include <linux/rtnetlink.h>
+#include <linux/taskstats.h>
+#include <linux/wireguard.h>
+#include <net/if.h>
+#include <netinet/in.h>
+#include <sys/socket.h>
+#include <sys/types.h>
+#include <unistd.h>
+
+#include <algorithm>
+#include <cstddef>
+#include <cstdint>
+#include <cstring>
+#include <map>
+#include <string>
+#include <vector>
+
+#include "gtest/gtest.h"
+#include "absl/strings/str_cat.h"
+#include "test/syscalls/linux/socket_netlink_util.h"
+#include "test/util/capability_util.h"
+#include "test/util/file_descriptor.h"
+#include "test/util/posix_error.h"
+#include "test/util/socket_util.h"
+#include "test/util/test_util.h"
+
+// Tests for NETLINK_GENERIC sockets.
+
+namespace gvisor {
+namespace testing {
+
+namespace {
+
+constexpr uint32_t kSeq = 12345;
+
+// GenlRequest is a generic netlink request.
+struct GenlRequest {
+  struct nlmsghdr hdr;
+  struct genlmsghdr genl;
+  char buf[1024];
+};
+
+void InitGenlRequest(GenlRequest* req, uint16_t family, uint8_t cmd,
+                     uint16_t flags) {
+  req->hdr.nlmsg_len = NLMSG_LENGTH(GENL_HDRLEN);
+  req->hdr.nlmsg_type = family;
+  req->hdr.nlmsg_flags = NLM_F_REQUEST | flags;
+  req->hdr.nlmsg_seq = kSeq;
+  req->genl.cmd = cmd;
+  req->genl.version = 1;
+}
+
+struct nlattr* Tail(struct nlmsghdr* hdr) {
+  return reinterpret_cast<struct nlattr*>(reinterpret_cast<char*>(hdr) +
+                                          NLMSG_ALIGN(hdr->nlmsg_len));
+}
+
+void AddAttr(struct nlmsghdr* hdr, size_t maxlen, uint16_t type,
+             const void* data, size_t len) {
+  ASSERT_LE(NLMSG_ALIGN(hdr->nlmsg_len) + NLA_ALIGN(NLA_HDRLEN + len), maxlen);
+  struct nlattr* nla = Tail(hdr);
+  nla->nla_type = type;
+  nla->nla_len = NLA_HDRLEN + len;
+  memcpy(reinterpret_cast<char*>(nla) + NLA_HDRLEN, data, len);
+  hdr->nlmsg_len = NLMSG_ALIGN(hdr->nlmsg_len) + NLA_ALIGN(nla->nla_len);
+}
+
+// StartNest adds a nested attribute, which must be closed with EndNest after
+// its attributes are added.
+struct nlattr* StartNest(struct nlmsghdr* hdr, size_t maxlen, uint16_t type) {
+  struct nlattr* nest = Tail(hdr);
+  AddAttr(hdr, maxlen, type | NLA_F_NESTED, nullptr, 0);
+  return nest;
+}
+
+void EndNest(struct nlmsghdr* hdr, struct nlattr* nest) {
+  nest->nla_len = reinterpret_cast<char*>(Tail(hdr)) -
+                  reinterpret_cast<char*>(nest);
+}
+
+// Attrs returns the attributes in the given buffer by type. Array entries have
+// the same type, so only the last one is kept.
+std::map<uint16_t, std::string> Attrs(const char* data, size_t len) {
+  std::map<uint16_t, std::string> attrs;
+  while (len >= NLA_HDRLEN) {
+    const struct nlattr* nla = reinterpret_cast<const struct nlattr*>(data);
+    if (nla->nla_len < NLA_HDRLEN || nla->nla_len > len) {
+      break;
+    }
+    attrs[nla->nla_type & NLA_TYPE_MASK] =
+        std::string(data + NLA_HDRLEN, nla->nla_len - NLA_HDRLEN);
+    size_t aligned = NLA_ALIGN(nla->nla_len);
+    if (aligned > len) {
+      break;
+    }
+    data += aligned;
+    len -= aligned;
+  }
+  return attrs;
+}
+
+// GenlAttrs returns the attributes of a generic netlink message.
+std::map<uint16_t, std::string> GenlAttrs(const struct nlmsghdr* hdr) {
+  const char* data = reinterpret_cast<const char*>(NLMSG_DATA(hdr));
+  return Attrs(data + GENL_HDRLEN, NLMSG_PAYLOAD(hdr, GENL_HDRLEN));
+}
+
+// ResolveFamily returns the identifier of the family with the given name.
+PosixErrorOr<uint16_t> ResolveFamily(const FileDescriptor& fd,
+                                     const char* name) {
+  GenlRequest req = {};
+  InitGenlRequest(&req, GENL_ID_CTRL, CTRL_CMD_GETFAMILY, 0);
+  AddAttr(&req.hdr, sizeof(req), CTRL_ATTR_FAMILY_NAME, name,
+          strlen(name) + 1);
+
+  uint16_t id = 0;
+  int err = 0;
+  RETURN_IF_ERRNO(NetlinkRequestResponseSingle(
+      fd, &req, req.hdr.nlmsg_len, [&](const struct nlmsghdr* hdr) {
+        if (hdr->nlmsg_type == NLMSG_ERROR) {
+          err = -reinterpret_cast<const struct nlmsgerr*>(NLMSG_DATA(hdr))
+                     ->error;
+          return;
+        }
+        auto attrs = GenlAttrs(hdr);
+        auto it = attrs.find(CTRL_ATTR_FAMILY_ID);
+        if (it != attrs.end() && it->second.size() == sizeof(id)) {
+          memcpy(&id, it->second.data(), sizeof(id));
+        }
+      }));
+  if (err != 0) {
+    return PosixError(err, name);
+  }
+  if (id == 0) {
+    return PosixError(EINVAL, absl::StrCat("no identifier for ", name));
+  }
+  return id;
+}
+
+TEST(NetlinkGenericTest, ResolveController) {
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_GENERIC));
+  EXPECT_THAT(ResolveFamily(fd, "nlctrl"),
+              IsPosixErrorOkAndHolds(GENL_ID_CTRL));
+}
+
+TEST(NetlinkGenericTest, UnknownFamily) {
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_GENERIC));
+
+  GenlRequest req = {};
+  InitGenlRequest(&req, GENL_ID_CTRL, CTRL_CMD_GETFAMILY, NLM_F_ACK);
+  const char name[] = "no_such_family";
+  AddAttr(&req.hdr, sizeof(req), CTRL_ATTR_FAMILY_NAME, name, sizeof(name));
+  EXPECT_THAT(NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len),
+              PosixErrorIs(ENOENT, ::testing::_));
+}
+
+TEST(NetlinkGenericTest, DumpFamilies) {
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_GENERIC));
+
+  GenlRequest req = {};
+  InitGenlRequest(&req, GENL_ID_CTRL, CTRL_CMD_GETFAMILY, NLM_F_DUMP);
+
+  bool found = false;
+  ASSERT_NO_ERRNO(NetlinkRequestResponse(
+      fd, &req, req.hdr.nlmsg_len,
+      [&](const struct nlmsghdr* hdr) {
+        EXPECT_EQ(hdr->nlmsg_type, GENL_ID_CTRL);
+        auto attrs = GenlAttrs(hdr);
+        auto name = attrs.find(CTRL_ATTR_FAMILY_NAME);
+        ASSERT_NE(name, attrs.end());
+        if (strcmp(name->second.c_str(), "nlctrl") == 0) {
+          found = true;
+          EXPECT_NE(attrs.find(CTRL_ATTR_OPS), attrs.end());
+        }
+      },
+      false));
+  EXPECT_TRUE(found);
+}
+
+TEST(NetlinkGenericTest, PolicyViolation) {
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_GENERIC));
+
+  // CTRL_ATTR_FAMILY_ID is a u16.
+  GenlRequest req = {};
+  InitGenlRequest(&req, GENL_ID_CTRL, CTRL_CMD_GETFAMILY, NLM_F_ACK);
+  uint8_t id = GENL_ID_CTRL;
+  AddAttr(&req.hdr, sizeof(req), CTRL_ATTR_FAMILY_ID, &id, sizeof(id));
+  EXPECT_THAT(NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len),
+              PosixErrorIs(ERANGE, ::testing::_));
+}
+
+TEST(NetlinkGenericTest, ControllerPolicy) {
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_GENERIC));
+
+  GenlRequest req = {};
+  InitGenlRequest(&req, GENL_ID_CTRL, CTRL_CMD_GETPOLICY, NLM_F_DUMP);
+  const char name[] = "nlctrl";
+  AddAttr(&req.hdr, sizeof(req), CTRL_ATTR_FAMILY_NAME, name, sizeof(name));
+
+  bool found = false;
+  ASSERT_NO_ERRNO(NetlinkRequestResponse(
+      fd, &req, req.hdr.nlmsg_len,
+      [&](const struct nlmsghdr* hdr) {
+        ASSERT_EQ(hdr->nlmsg_type, GENL_ID_CTRL);
+        auto attrs = GenlAttrs(hdr);
+        auto policy = attrs.find(CTRL_ATTR_POLICY);
+        if (policy == attrs.end()) {
+          return;
+        }
+        // Each message describes a single attribute of a single policy.
+        auto policies = Attrs(policy->second.data(), policy->second.size());
+        ASSERT_EQ(policies.size(), 1u);
+        const std::string& types = policies.begin()->second;
+        auto type = Attrs(types.data(), types.size());
+        ASSERT_EQ(type.size(), 1u);
+        if (type.begin()->first != CTRL_ATTR_FAMILY_NAME) {
+          return;
+        }
+        const std::string& desc = type.begin()->second;
+        auto desc_attrs = Attrs(desc.data(), desc.size());
+        uint32_t attr_type;
+        ASSERT_EQ(desc_attrs[NL_POLICY_TYPE_ATTR_TYPE].size(),
+                  sizeof(attr_type));
+        memcpy(&attr_type, desc_attrs[NL_POLICY_TYPE_ATTR_TYPE].data(),
+               sizeof(attr_type));
+        EXPECT_EQ(attr_type, NL_ATTR_TYPE_NUL_STRING);
+        found = true;
+      },
+      false));
+  EXPECT_TRUE(found);
+}
+
+TEST(NetlinkGenericTest, ControllerNotifyGroup) {
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_GENERIC));
+
+  GenlRequest req = {};
+  InitGenlRequest(&req, GENL_ID_CTRL, CTRL_CMD_GETFAMILY, 0);
+  const char name[] = "nlctrl";
+  AddAttr(&req.hdr, sizeof(req), CTRL_ATTR_FAMILY_NAME, name, sizeof(name));
+
+  uint32_t group = 0;
+  ASSERT_NO_ERRNO(NetlinkRequestResponseSingle(
+      fd, &req, req.hdr.nlmsg_len, [&](const struct nlmsghdr* hdr) {
+        ASSERT_EQ(hdr->nlmsg_type, GENL_ID_CTRL);
+        auto attrs = GenlAttrs(hdr);
+        const std::string& groups = attrs[CTRL_ATTR_MCAST_GROUPS];
+        for (const auto& entry : Attrs(groups.data(), groups.size())) {
+          auto group_attrs = Attrs(entry.second.data(), entry.second.size());
+          if (strcmp(group_attrs[CTRL_ATTR_MCAST_GRP_NAME].c_str(),
+                     "notify") != 0) {
+            continue;
+          }
+          ASSERT_EQ(group_attrs[CTRL_ATTR_MCAST_GRP_ID].size(),
+                    sizeof(group));
+          memcpy(&group, group_attrs[CTRL_ATTR_MCAST_GRP_ID].data(),
+                 sizeof(group));
+        }
+      }));
+  ASSERT_NE(group, 0u);
+
+  ASSERT_THAT(setsockopt(fd.get(), SOL_NETLINK, NETLINK_ADD_MEMBERSHIP,
+                         &group, sizeof(group)),
+              SyscallSucceeds());
+
+  uint32_t groups[2] = {};
+  socklen_t len = sizeof(groups);
+  ASSERT_THAT(getsockopt(fd.get(), SOL_NETLINK, NETLINK_LIST_MEMBERSHIPS,
+                         groups, &len),
+              SyscallSucceeds());
+  ASSERT_GE(len, sizeof(groups[0]) * ((group + 31) / 32));
+  EXPECT_NE(groups[(group - 1) / 32] & (1u << ((group - 1) % 32)), 0u);
+
+  ASSERT_THAT(setsockopt(fd.get(), SOL_NETLINK, NETLINK_DROP_MEMBERSHIP,
+                         &group, sizeof(group)),
+              SyscallSucceeds());
+  len = sizeof(groups);
+  ASSERT_THAT(getsockopt(fd.get(), SOL_NETLINK, NETLINK_LIST_MEMBERSHIPS,
+                         groups, &len),
+              SyscallSucceeds());
+  EXPECT_EQ(groups[(group - 1) / 32] & (1u << ((group - 1) % 32)), 0u);
+}
+
+TEST(NetlinkGenericTest, TaskstatsPid) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)));
+
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_GENERIC));
+  PosixErrorOr<uint16_t> family = ResolveFamily(fd, TASKSTATS_GENL_NAME);
+  // Linux may not have TASKSTATS.
+  SKIP_IF(!IsRunningOnGvisor() && !family.ok());
+  ASSERT_NO_ERRNO(family);
+
+  GenlRequest req = {};
+  InitGenlRequest(&req, family.ValueOrDie(), TASKSTATS_CMD_GET, 0);
+  uint32_t pid = getpid();
+  AddAttr(&req.hdr, sizeof(req), TASKSTATS_CMD_ATTR_PID, &pid, sizeof(pid));
+
+  ASSERT_NO_ERRNO(NetlinkRequestResponseSingle(
+      fd, &req, req.hdr.nlmsg_len, [&](const struct nlmsghdr* hdr) {
+        ASSERT_EQ(hdr->nlmsg_type, family.ValueOrDie());
+        auto attrs = GenlAttrs(hdr);
+        const std::string& aggr = attrs[TASKSTATS_TYPE_AGGR_PID];
+        auto aggr_attrs = Attrs(aggr.data(), aggr.size());
+
+        uint32_t got_pid;
+        ASSERT_EQ(aggr_attrs[TASKSTATS_TYPE_PID].size(), sizeof(got_pid));
+        memcpy(&got_pid, aggr_attrs[TASKSTATS_TYPE_PID].data(),
+               sizeof(got_pid));
+        EXPECT_EQ(got_pid, pid);
+
+        struct taskstats stats = {};
+        const std::string& data = aggr_attrs[TASKSTATS_TYPE_STATS];
+        ASSERT_GE(data.size(), offsetof(struct taskstats, ac_stime));
+        memcpy(&stats, data.data(), std::min(data.size(), sizeof(stats)));
+        EXPECT_GT(stats.version, 0);
+        EXPECT_EQ(stats.ac_pid, pid);
+        EXPECT_EQ(stats.ac_uid, getuid());
+        EXPECT_EQ(stats.ac_ppid, static_cast<uint32_t>(getppid()));
+        EXPECT_NE(stats.ac_comm[0], '\0');
+      }));
+}
+
+TEST(NetlinkGenericTest, TaskstatsNoProcess) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)));
+
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_GENERIC));
+  PosixErrorOr<uint16_t> family = ResolveFamily(fd, TASKSTATS_GENL_NAME);
+  // Linux may not have TASKSTATS.
+  SKIP_IF(!IsRunningOnGvisor() && !family.ok());
+  ASSERT_NO_ERRNO(family);
+
+  GenlRequest req = {};
+  InitGenlRequest(&req, family.ValueOrDie(), TASKSTATS_CMD_GET, NLM_F_ACK);
+  uint32_t pid = 0x7fffffff;
+  AddAttr(&req.hdr, sizeof(req), TASKSTATS_CMD_ATTR_PID, &pid, sizeof(pid));
+  EXPECT_THAT(NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len),
+              PosixErrorIs(ESRCH, ::testing::_));
+}
+
+// AddWireGuardLink creates a WireGuard interface with the given name.
+PosixError AddWireGuardLink(const char* name) {
+  ASSIGN_OR_RETURN_ERRNO(FileDescriptor fd, NetlinkBoundSocket(NETLINK_ROUTE));
+
+  struct {
+    struct nlmsghdr hdr;
+    struct ifinfomsg ifm;
+    char buf[256];
+  } req = {};
+  req.hdr.nlmsg_len = NLMSG_LENGTH(sizeof(struct ifinfomsg));
+  req.hdr.nlmsg_type = RTM_NEWLINK;
+  req.hdr.nlmsg_flags = NLM_F_REQUEST | NLM_F_ACK | NLM_F_CREATE;
+  req.hdr.nlmsg_seq = kSeq;
+  req.ifm.ifi_family = AF_UNSPEC;
+  AddAttr(&req.hdr, sizeof(req), IFLA_IFNAME, name, strlen(name) + 1);
+  struct nlattr* linkinfo = StartNest(&req.hdr, sizeof(req), IFLA_LINKINFO);
+  const char kind[] = "wireguard";
+  AddAttr(&req.hdr, sizeof(req), IFLA_INFO_KIND, kind, strlen(kind));
+  EndNest(&req.hdr, linkinfo);
+  return NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len);
+}
+
+TEST(NetlinkGenericTest, WireGuardDevice) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)));
+  SKIP_IF(IsRunningWithHostinet());
+
+  const char name[] = "wg_genl";
+  PosixError err = AddWireGuardLink(name);
+  // Linux may not have WireGuard.
+  SKIP_IF(!IsRunningOnGvisor() && err.errno_value() == EOPNOTSUPP);
+  ASSERT_NO_ERRNO(err);
+
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_GENERIC));
+  uint16_t family = ASSERT_NO_ERRNO_AND_VALUE(ResolveFamily(fd, "wireguard"));
+
+  uint8_t private_key[WG_KEY_LEN];
+  uint8_t peer_key[WG_KEY_LEN];
+  for (int i = 0; i < WG_KEY_LEN; i++) {
+    private_key[i] = i + 1;
+    peer_key[i] = 0xff - i;
+  }
+  constexpr uint16_t kListenPort = 51820;
+  constexpr uint16_t kKeepalive = 25;
+  constexpr uint8_t kCIDR = 24;
+
+  GenlRequest set = {};
+  InitGenlRequest(&set, family, WG_CMD_SET_DEVICE, NLM_F_ACK);
+  AddAttr(&set.hdr, sizeof(set), WGDEVICE_A_IFNAME, name, sizeof(name));
+  AddAttr(&set.hdr, sizeof(set), WGDEVICE_A_PRIVATE_KEY, private_key,
+          sizeof(private_key));
+  AddAttr(&set.hdr, sizeof(set), WGDEVICE_A_LISTEN_PORT, &kListenPort,
+          sizeof(kListenPort));
+  struct nlattr* peers = StartNest(&set.hdr, sizeof(set), WGDEVICE_A_PEERS);
+  struct nlattr* peer = StartNest(&set.hdr, sizeof(set), 0);
+  AddAttr(&set.hdr, sizeof(set), WGPEER_A_PUBLIC_KEY, peer_key,
+          sizeof(peer_key));
+  AddAttr(&set.hdr, sizeof(set), WGPEER_A_PERSISTENT_KEEPALIVE_INTERVAL,
+          &kKeepalive, sizeof(kKeepalive));
+  struct nlattr* ips = StartNest(&set.hdr, sizeof(set), WGPEER_A_ALLOWEDIPS);
+  struct nlattr* ip = StartNest(&set.hdr, sizeof(set), 0);
+  uint16_t af = AF_INET;
+  struct in_addr addr = {};
+  addr.s_addr = htonl(0x0a000000);
+  AddAttr(&set.hdr, sizeof(set), WGALLOWEDIP_A_FAMILY, &af, sizeof(af));
+  AddAttr(&set.hdr, sizeof(set), WGALLOWEDIP_A_IPADDR, &addr, sizeof(addr));
+  AddAttr(&set.hdr, sizeof(set), WGALLOWEDIP_A_CIDR_MASK, &kCIDR,
+          sizeof(kCIDR));
+  EndNest(&set.hdr, ip);
+  EndNest(&set.hdr, ips);
+  EndNest(&set.hdr, peer);
+  EndNest(&set.hdr, peers);
+  ASSERT_NO_ERRNO(NetlinkRequestAckOrError(fd, kSeq, &set, set.hdr.nlmsg_len));
+
+  GenlRequest get = {};
+  InitGenlRequest(&get, family, WG_CMD_GET_DEVICE, NLM_F_DUMP);
+  AddAttr(&get.hdr, sizeof(get), WGDEVICE_A_IFNAME, name, sizeof(name));
+
+  int messages = 0;
+  ASSERT_NO_ERRNO(NetlinkRequestResponse(
+      fd, &get, get.hdr.nlmsg_len,
+      [&](const struct nlmsghdr* hdr) {
+        ASSERT_EQ(hdr->nlmsg_type, family);
+        messages++;
+        auto attrs = GenlAttrs(hdr);
+
+        uint16_t port;
+        ASSERT_EQ(attrs[WGDEVICE_A_LISTEN_PORT].size(), sizeof(port));
+        memcpy(&port, attrs[WGDEVICE_A_LISTEN_PORT].data(), sizeof(port));
+        EXPECT_EQ(port, kListenPort);
+        EXPECT_EQ(attrs[WGDEVICE_A_PRIVATE_KEY],
+                  std::string(reinterpret_cast<char*>(private_key),
+                              sizeof(private_key)));
+        EXPECT_EQ(attrs[WGDEVICE_A_PUBLIC_KEY].size(), WG_KEY_LEN);
+
+        // There is a single peer.
+        const std::string& peers = attrs[WGDEVICE_A_PEERS];
+        auto peer = Attrs(peers.data(), peers.size());
+        ASSERT_EQ(peer.size(), 1u);
+        const std::string& entry = peer.begin()->second;
+        auto peer_attrs = Attrs(entry.data(), entry.size());
+        EXPECT_EQ(peer_attrs[WGPEER_A_PUBLIC_KEY],
+                  std::string(reinterpret_cast<char*>(peer_key),
+                              sizeof(peer_key)));
+        uint16_t keepalive;
+        ASSERT_EQ(peer_attrs[WGPEER_A_PERSISTENT_KEEPALIVE_INTERVAL].size(),
+                  sizeof(keepalive));
+        memcpy(&keepalive,
+               peer_attrs[WGPEER_A_PERSISTENT_KEEPALIVE_INTERVAL].data(),
+               sizeof(keepalive));
+        EXPECT_EQ(keepalive, kKeepalive);
+
+        const std::string& allowed = peer_attrs[WGPEER_A_ALLOWEDIPS];
+        auto ips = Attrs(allowed.data(), allowed.size());
+        ASSERT_EQ(ips.size(), 1u);
+        const std::string& ip = ips.begin()->second;
+        auto ip_attrs = Attrs(ip.data(), ip.size());
+        EXPECT_EQ(ip_attrs[WGALLOWEDIP_A_CIDR_MASK],
+                  std::string(1, static_cast<char>(kCIDR)));
+        EXPECT_EQ(ip_attrs[WGALLOWEDIP_A_IPADDR],
+                  std::string(reinterpret_cast<char*>(&addr), sizeof(addr)));
+      },
+      false));
+  EXPECT_EQ(messages, 1);
+}
+
+TEST(NetlinkGenericTest, WireGuardNotWireGuardDevice) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)));
+  SKIP_IF(IsRunningWithHostinet());
+
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_GENERIC));
+  PosixErrorOr<uint16_t> family = ResolveFamily(fd, "wireguard");
+  // Linux may not have WireGuard.
+  SKIP_IF(!IsRunningOnGvisor() && !family.ok());
+  ASSERT_NO_ERRNO(family);
+
+  GenlRequest req = {};
+  InitGenlRequest(&req, family.ValueOrDie(), WG_CMD_GET_DEVICE,
+                  NLM_F_DUMP | NLM_F_ACK);
+  const char name[] = "lo";
+  AddAttr(&req.hdr, sizeof(req), WGDEVICE_A_IFNAME, name, sizeof(name));
+  EXPECT_THAT(NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len),
+              PosixErrorIs(EOPNOTSUPP, ::testing::_));
+}
+
+}  // namespace
+
+}  // namespace testing
+}  // namespace gvisor
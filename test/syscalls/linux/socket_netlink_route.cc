@@ -17,10 +17,15 @@
 #include <ifaddrs.h>
 #include <linux/fib_rules.h>
 #include <linux/if.h>
+#include <linux/if_arp.h>
+#include <linux/if_bridge.h>
+#include <linux/if_tunnel.h>
 #include <linux/netlink.h>
+#include <linux/pkt_sched.h>
 #include <linux/rtnetlink.h>
 #include <linux/veth.h>
 #include <string.h>
+#include <sys/ioctl.h>
 #include <sys/socket.h>
 #include <sys/types.h>
 #include <unistd.h>
@@ -30,6 +35,7 @@
 #include <cstdint>
 #include <functional>
 #include <iostream>
+#include <map>
 #include <string>
 #include <tuple>
 #include <vector>
@@ -41,6 +47,7 @@
 #include "test/syscalls/linux/socket_netlink_util.h"
 #include "test/util/cleanup.h"
 #include "test/util/file_descriptor.h"
+#include "test/util/fs_util.h"
 #include "test/util/linux_capability_util.h"
 #include "test/util/posix_error.h"
 #include "test/util/save_util.h"
@@ -59,6 +66,7 @@ constexpr uint32_t kSeq = 12345;
 using ::testing::_;
 using ::testing::AnyOf;
 using ::testing::Eq;
+using ::testing::HasSubstr;
 
 // Parameters for SockOptTest. They are:
 // 0: Socket option to query.
@@ -1791,6 +1799,572 @@ TEST(NetlinkRouteTest, VethAdd) {
   EXPECT_NO_ERRNO(NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len));
 }
 
+// TunnelRequest is an RTM_NEWLINK request for a VXLAN or Geneve interface.
+struct TunnelRequest {
+  struct nlmsghdr hdr;
+  struct ifinfomsg ifm;
+  char buf[1024];
+};
+
+// InitTunnelRequest initializes req to create an interface of the given kind,
+// and returns the IFLA_INFO_DATA attribute, which must be closed after the
+// attributes of the interface are added.
+struct rtattr* InitTunnelRequest(TunnelRequest* req, const char* name,
+                                 const char* kind, struct rtattr** linkinfo) {
+  req->hdr.nlmsg_len = NLMSG_LENGTH(sizeof(struct ifinfomsg));
+  req->hdr.nlmsg_type = RTM_NEWLINK;
+  req->hdr.nlmsg_flags = NLM_F_REQUEST | NLM_F_ACK | NLM_F_CREATE;
+  req->hdr.nlmsg_seq = kSeq;
+  req->ifm.ifi_family = AF_UNSPEC;
+
+  addattr(&req->hdr, sizeof(*req), IFLA_IFNAME, name, strlen(name));
+  *linkinfo = NLMSG_TAIL(&req->hdr);
+  addattr(&req->hdr, sizeof(*req), IFLA_LINKINFO, nullptr, 0);
+  addattr(&req->hdr, sizeof(*req), IFLA_INFO_KIND, kind, strlen(kind));
+  struct rtattr* data = NLMSG_TAIL(&req->hdr);
+  addattr(&req->hdr, sizeof(*req), IFLA_INFO_DATA, nullptr, 0);
+  return data;
+}
+
+// FinishTunnelRequest closes the attributes opened by InitTunnelRequest.
+void FinishTunnelRequest(TunnelRequest* req, struct rtattr* linkinfo,
+                         struct rtattr* data) {
+  data->rta_len = (uint64_t)NLMSG_TAIL(&req->hdr) - (uint64_t)data;
+  linkinfo->rta_len = (uint64_t)NLMSG_TAIL(&req->hdr) - (uint64_t)linkinfo;
+}
+
+TEST(NetlinkRouteTest, VxlanAdd) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)));
+  SKIP_IF(IsRunningWithHostinet());
+
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_ROUTE));
+
+  TunnelRequest req = {};
+  struct rtattr* linkinfo;
+  struct rtattr* data =
+      InitTunnelRequest(&req, "vxlan_test", "vxlan", &linkinfo);
+  uint32_t id = 42;
+  addattr(&req.hdr, sizeof(req), IFLA_VXLAN_ID, &id, sizeof(id));
+  in_addr_t group = inet_addr("192.0.2.2");
+  addattr(&req.hdr, sizeof(req), IFLA_VXLAN_GROUP, &group, sizeof(group));
+  uint16_t port = htons(4789);
+  addattr(&req.hdr, sizeof(req), IFLA_VXLAN_PORT, &port, sizeof(port));
+  uint8_t learning = 1;
+  addattr(&req.hdr, sizeof(req), IFLA_VXLAN_LEARNING, &learning,
+          sizeof(learning));
+  FinishTunnelRequest(&req, linkinfo, data);
+  EXPECT_NO_ERRNO(NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len));
+}
+
+TEST(NetlinkRouteTest, VxlanInvalidVNI) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)));
+  SKIP_IF(IsRunningWithHostinet());
+
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_ROUTE));
+
+  TunnelRequest req = {};
+  struct rtattr* linkinfo;
+  struct rtattr* data =
+      InitTunnelRequest(&req, "vxlan_bad", "vxlan", &linkinfo);
+  uint32_t id = 1 << 24;
+  addattr(&req.hdr, sizeof(req), IFLA_VXLAN_ID, &id, sizeof(id));
+  FinishTunnelRequest(&req, linkinfo, data);
+  EXPECT_THAT(NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len),
+              PosixErrorIs(ERANGE, _));
+}
+
+TEST(NetlinkRouteTest, GeneveAdd) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)));
+  SKIP_IF(IsRunningWithHostinet());
+
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_ROUTE));
+
+  TunnelRequest req = {};
+  struct rtattr* linkinfo;
+  struct rtattr* data =
+      InitTunnelRequest(&req, "geneve_test", "geneve", &linkinfo);
+  uint32_t id = 7;
+  addattr(&req.hdr, sizeof(req), IFLA_GENEVE_ID, &id, sizeof(id));
+  in_addr_t remote = inet_addr("192.0.2.1");
+  addattr(&req.hdr, sizeof(req), IFLA_GENEVE_REMOTE, &remote, sizeof(remote));
+  FinishTunnelRequest(&req, linkinfo, data);
+  EXPECT_NO_ERRNO(NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len));
+}
+
+// ExpectTunnelDevice checks that the interface name exists with the device
+// type arphrd and is listed in /proc/net/dev.
+void ExpectTunnelDevice(const char* name, uint16_t arphrd) {
+  FileDescriptor sock =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(AF_INET, SOCK_DGRAM, 0));
+  struct ifreq ifr = {};
+  strncpy(ifr.ifr_name, name, sizeof(ifr.ifr_name) - 1);
+  ASSERT_THAT(ioctl(sock.get(), SIOCGIFHWADDR, &ifr), SyscallSucceeds());
+  EXPECT_EQ(ifr.ifr_hwaddr.sa_family, arphrd);
+
+  std::string dev = ASSERT_NO_ERRNO_AND_VALUE(GetContents("/proc/net/dev"));
+  EXPECT_THAT(dev, HasSubstr(absl::StrFormat("%s:", name)));
+}
+
+TEST(NetlinkRouteTest, IpipAdd) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)));
+  SKIP_IF(IsRunningWithHostinet());
+
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_ROUTE));
+
+  TunnelRequest req = {};
+  struct rtattr* linkinfo;
+  struct rtattr* data = InitTunnelRequest(&req, "ipip_test", "ipip", &linkinfo);
+  in_addr_t remote = inet_addr("192.0.2.1");
+  addattr(&req.hdr, sizeof(req), IFLA_IPTUN_REMOTE, &remote, sizeof(remote));
+  uint8_t ttl = 64;
+  addattr(&req.hdr, sizeof(req), IFLA_IPTUN_TTL, &ttl, sizeof(ttl));
+  FinishTunnelRequest(&req, linkinfo, data);
+  ASSERT_NO_ERRNO(NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len));
+  ExpectTunnelDevice("ipip_test", ARPHRD_TUNNEL);
+}
+
+TEST(NetlinkRouteTest, SitAdd) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)));
+  SKIP_IF(IsRunningWithHostinet());
+
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_ROUTE));
+
+  TunnelRequest req = {};
+  struct rtattr* linkinfo;
+  struct rtattr* data = InitTunnelRequest(&req, "sit_test", "sit", &linkinfo);
+  in_addr_t remote = inet_addr("192.0.2.1");
+  addattr(&req.hdr, sizeof(req), IFLA_IPTUN_REMOTE, &remote, sizeof(remote));
+  FinishTunnelRequest(&req, linkinfo, data);
+  ASSERT_NO_ERRNO(NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len));
+  ExpectTunnelDevice("sit_test", ARPHRD_SIT);
+}
+
+TEST(NetlinkRouteTest, GreAdd) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)));
+  SKIP_IF(IsRunningWithHostinet());
+
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_ROUTE));
+
+  TunnelRequest req = {};
+  struct rtattr* linkinfo;
+  struct rtattr* data = InitTunnelRequest(&req, "gre_test", "gre", &linkinfo);
+  in_addr_t remote = inet_addr("192.0.2.1");
+  addattr(&req.hdr, sizeof(req), IFLA_GRE_REMOTE, &remote, sizeof(remote));
+  // GRE flags and keys are in network byte order.
+  uint16_t flags = GRE_KEY;
+  addattr(&req.hdr, sizeof(req), IFLA_GRE_IFLAGS, &flags, sizeof(flags));
+  addattr(&req.hdr, sizeof(req), IFLA_GRE_OFLAGS, &flags, sizeof(flags));
+  uint32_t key = htonl(42);
+  addattr(&req.hdr, sizeof(req), IFLA_GRE_IKEY, &key, sizeof(key));
+  addattr(&req.hdr, sizeof(req), IFLA_GRE_OKEY, &key, sizeof(key));
+  FinishTunnelRequest(&req, linkinfo, data);
+  ASSERT_NO_ERRNO(NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len));
+  ExpectTunnelDevice("gre_test", ARPHRD_IPGRE);
+}
+
+// InterfaceIndex returns the index of the interface name.
+int InterfaceIndex(const char* name) {
+  FileDescriptor sock = Socket(AF_INET, SOCK_DGRAM, 0).ValueOrDie();
+  struct ifreq ifr = {};
+  strncpy(ifr.ifr_name, name, sizeof(ifr.ifr_name) - 1);
+  if (ioctl(sock.get(), SIOCGIFINDEX, &ifr) < 0) {
+    return 0;
+  }
+  return ifr.ifr_ifindex;
+}
+
+// AddLink creates an interface of the given kind without attributes, and
+// returns its index.
+PosixErrorOr<int> AddLink(const FileDescriptor& fd, const char* name,
+                          const char* kind) {
+  TunnelRequest req = {};
+  struct rtattr* linkinfo;
+  struct rtattr* data = InitTunnelRequest(&req, name, kind, &linkinfo);
+  FinishTunnelRequest(&req, linkinfo, data);
+  RETURN_IF_ERRNO(NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len));
+  int index = InterfaceIndex(name);
+  if (index == 0) {
+    return PosixError(ENODEV, absl::StrFormat("no interface %s", name));
+  }
+  return index;
+}
+
+TEST(NetlinkRouteTest, VlanAdd) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)));
+  SKIP_IF(IsRunningWithHostinet());
+
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_ROUTE));
+  int parent = ASSERT_NO_ERRNO_AND_VALUE(AddLink(fd, "vlan_parent", "veth"));
+
+  TunnelRequest req = {};
+  struct rtattr* linkinfo;
+  struct rtattr* data =
+      InitTunnelRequest(&req, "vlan_parent.10", "vlan", &linkinfo);
+  uint16_t id = 10;
+  addattr(&req.hdr, sizeof(req), IFLA_VLAN_ID, &id, sizeof(id));
+  FinishTunnelRequest(&req, linkinfo, data);
+  addattr(&req.hdr, sizeof(req), IFLA_LINK, &parent, sizeof(parent));
+  ASSERT_NO_ERRNO(NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len));
+  ExpectTunnelDevice("vlan_parent.10", ARPHRD_ETHER);
+
+  // The VLAN can't be added twice to the same parent.
+  TunnelRequest dup = {};
+  data = InitTunnelRequest(&dup, "vlan_dup", "vlan", &linkinfo);
+  addattr(&dup.hdr, sizeof(dup), IFLA_VLAN_ID, &id, sizeof(id));
+  FinishTunnelRequest(&dup, linkinfo, data);
+  addattr(&dup.hdr, sizeof(dup), IFLA_LINK, &parent, sizeof(parent));
+  EXPECT_THAT(NetlinkRequestAckOrError(fd, kSeq, &dup, dup.hdr.nlmsg_len),
+              PosixErrorIs(EEXIST, _));
+}
+
+TEST(NetlinkRouteTest, VlanWithoutParent) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)));
+  SKIP_IF(IsRunningWithHostinet());
+
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_ROUTE));
+
+  TunnelRequest req = {};
+  struct rtattr* linkinfo;
+  struct rtattr* data = InitTunnelRequest(&req, "vlan_bad", "vlan", &linkinfo);
+  uint16_t id = 10;
+  addattr(&req.hdr, sizeof(req), IFLA_VLAN_ID, &id, sizeof(id));
+  FinishTunnelRequest(&req, linkinfo, data);
+  EXPECT_THAT(NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len),
+              PosixErrorIs(EINVAL, _));
+}
+
+TEST(NetlinkRouteTest, MacvlanAdd) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)));
+  SKIP_IF(IsRunningWithHostinet());
+
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_ROUTE));
+  int parent =
+      ASSERT_NO_ERRNO_AND_VALUE(AddLink(fd, "macvlan_parent", "veth"));
+
+  TunnelRequest req = {};
+  struct rtattr* linkinfo;
+  struct rtattr* data =
+      InitTunnelRequest(&req, "macvlan_test", "macvlan", &linkinfo);
+  uint32_t mode = MACVLAN_MODE_BRIDGE;
+  addattr(&req.hdr, sizeof(req), IFLA_MACVLAN_MODE, &mode, sizeof(mode));
+  FinishTunnelRequest(&req, linkinfo, data);
+  addattr(&req.hdr, sizeof(req), IFLA_LINK, &parent, sizeof(parent));
+  ASSERT_NO_ERRNO(NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len));
+  ExpectTunnelDevice("macvlan_test", ARPHRD_ETHER);
+
+  // A NIC can't have both macvlan and ipvlan interfaces.
+  TunnelRequest ipvlan = {};
+  data = InitTunnelRequest(&ipvlan, "ipvlan_busy", "ipvlan", &linkinfo);
+  FinishTunnelRequest(&ipvlan, linkinfo, data);
+  addattr(&ipvlan.hdr, sizeof(ipvlan), IFLA_LINK, &parent, sizeof(parent));
+  EXPECT_THAT(
+      NetlinkRequestAckOrError(fd, kSeq, &ipvlan, ipvlan.hdr.nlmsg_len),
+      PosixErrorIs(EBUSY, _));
+}
+
+TEST(NetlinkRouteTest, IpvlanAdd) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)));
+  SKIP_IF(IsRunningWithHostinet());
+
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_ROUTE));
+  int parent = ASSERT_NO_ERRNO_AND_VALUE(AddLink(fd, "ipvlan_parent", "veth"));
+
+  TunnelRequest req = {};
+  struct rtattr* linkinfo;
+  struct rtattr* data =
+      InitTunnelRequest(&req, "ipvlan_test", "ipvlan", &linkinfo);
+  uint16_t mode = IPVLAN_MODE_L2;
+  addattr(&req.hdr, sizeof(req), IFLA_IPVLAN_MODE, &mode, sizeof(mode));
+  FinishTunnelRequest(&req, linkinfo, data);
+  addattr(&req.hdr, sizeof(req), IFLA_LINK, &parent, sizeof(parent));
+  ASSERT_NO_ERRNO(NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len));
+  ExpectTunnelDevice("ipvlan_test", ARPHRD_ETHER);
+
+  // Like on Linux, ipvlan interfaces share the link address of their parent.
+  FileDescriptor sock =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(AF_INET, SOCK_DGRAM, 0));
+  struct ifreq parent_ifr = {};
+  strncpy(parent_ifr.ifr_name, "ipvlan_parent",
+          sizeof(parent_ifr.ifr_name) - 1);
+  ASSERT_THAT(ioctl(sock.get(), SIOCGIFHWADDR, &parent_ifr), SyscallSucceeds());
+  struct ifreq ifr = {};
+  strncpy(ifr.ifr_name, "ipvlan_test", sizeof(ifr.ifr_name) - 1);
+  ASSERT_THAT(ioctl(sock.get(), SIOCGIFHWADDR, &ifr), SyscallSucceeds());
+  EXPECT_EQ(memcmp(ifr.ifr_hwaddr.sa_data, parent_ifr.ifr_hwaddr.sa_data, 6),
+            0);
+}
+
+TEST(NetlinkRouteTest, MacvlanWithoutParent) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)));
+  SKIP_IF(IsRunningWithHostinet());
+
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_ROUTE));
+
+  TunnelRequest req = {};
+  struct rtattr* linkinfo;
+  struct rtattr* data =
+      InitTunnelRequest(&req, "macvlan_bad", "macvlan", &linkinfo);
+  FinishTunnelRequest(&req, linkinfo, data);
+  EXPECT_THAT(NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len),
+              PosixErrorIs(EINVAL, _));
+}
+
+// BridgeVlanRequest is an RTM_SETLINK or RTM_DELLINK request without link
+// info.
+struct BridgeVlanRequest {
+  struct nlmsghdr hdr;
+  struct ifinfomsg ifm;
+  char buf[256];
+};
+
+// LinkSetMaster adds the interface index to the bridge master.
+PosixError LinkSetMaster(const FileDescriptor& fd, int index, int master) {
+  BridgeVlanRequest req = {};
+  req.hdr.nlmsg_len = NLMSG_LENGTH(sizeof(struct ifinfomsg));
+  req.hdr.nlmsg_type = RTM_SETLINK;
+  req.hdr.nlmsg_flags = NLM_F_REQUEST | NLM_F_ACK;
+  req.hdr.nlmsg_seq = kSeq;
+  req.ifm.ifi_family = AF_UNSPEC;
+  req.ifm.ifi_index = index;
+  addattr(&req.hdr, sizeof(req), IFLA_MASTER, &master, sizeof(master));
+  return NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len);
+}
+
+// BridgeVlan sends an AF_BRIDGE request of the given type for the VLAN vid of
+// the interface index.
+PosixError BridgeVlan(const FileDescriptor& fd, uint16_t type, int index,
+                      uint16_t vid, uint16_t flags) {
+  BridgeVlanRequest req = {};
+  req.hdr.nlmsg_len = NLMSG_LENGTH(sizeof(struct ifinfomsg));
+  req.hdr.nlmsg_type = type;
+  req.hdr.nlmsg_flags = NLM_F_REQUEST | NLM_F_ACK;
+  req.hdr.nlmsg_seq = kSeq;
+  req.ifm.ifi_family = AF_BRIDGE;
+  req.ifm.ifi_index = index;
+
+  struct rtattr* spec = NLMSG_TAIL(&req.hdr);
+  addattr(&req.hdr, sizeof(req), IFLA_AF_SPEC, nullptr, 0);
+  struct bridge_vlan_info info = {};
+  info.flags = flags;
+  info.vid = vid;
+  addattr(&req.hdr, sizeof(req), IFLA_BRIDGE_VLAN_INFO, &info, sizeof(info));
+  spec->rta_len = (uint64_t)NLMSG_TAIL(&req.hdr) - (uint64_t)spec;
+  return NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len);
+}
+
+TEST(NetlinkRouteTest, BridgeVlan) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)));
+  SKIP_IF(IsRunningWithHostinet());
+
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_ROUTE));
+
+  TunnelRequest req = {};
+  struct rtattr* linkinfo;
+  struct rtattr* data = InitTunnelRequest(&req, "br_vlan", "bridge", &linkinfo);
+  uint8_t filtering = 1;
+  addattr(&req.hdr, sizeof(req), IFLA_BR_VLAN_FILTERING, &filtering,
+          sizeof(filtering));
+  FinishTunnelRequest(&req, linkinfo, data);
+  ASSERT_NO_ERRNO(NetlinkRequestAckOrError(fd, kSeq, &req, req.hdr.nlmsg_len));
+  int bridge = InterfaceIndex("br_vlan");
+  ASSERT_NE(bridge, 0);
+
+  int port = ASSERT_NO_ERRNO_AND_VALUE(AddLink(fd, "br_vlan_port", "veth"));
+  ASSERT_NO_ERRNO(LinkSetMaster(fd, port, bridge));
+
+  ASSERT_NO_ERRNO(
+      BridgeVlan(fd, RTM_SETLINK, port, 100,
+                 BRIDGE_VLAN_INFO_PVID | BRIDGE_VLAN_INFO_UNTAGGED));
+  EXPECT_THAT(BridgeVlan(fd, RTM_SETLINK, port, 4095, 0),
+              PosixErrorIs(EINVAL, _));
+  ASSERT_NO_ERRNO(BridgeVlan(fd, RTM_DELLINK, port, 100, 0));
+  EXPECT_THAT(BridgeVlan(fd, RTM_DELLINK, port, 100, 0),
+              PosixErrorIs(ENOENT, _));
+}
+
+// TcRequest is an RTM_*QDISC or RTM_*TCLASS request.
+struct TcRequest {
+  struct nlmsghdr hdr;
+  struct tcmsg tcm;
+  char buf[1024];
+};
+
+// InitTcRequest initializes req and returns the TCA_OPTIONS attribute, which
+// must be closed after the options are added. The kind is omitted if it is
+// null.
+struct rtattr* InitTcRequest(TcRequest* req, uint16_t type, uint16_t flags,
+                             int index, uint32_t handle, uint32_t parent,
+                             const char* kind) {
+  req->hdr.nlmsg_len = NLMSG_LENGTH(sizeof(struct tcmsg));
+  req->hdr.nlmsg_type = type;
+  req->hdr.nlmsg_flags = NLM_F_REQUEST | NLM_F_ACK | flags;
+  req->hdr.nlmsg_seq = kSeq;
+  req->tcm.tcm_family = AF_UNSPEC;
+  req->tcm.tcm_ifindex = index;
+  req->tcm.tcm_handle = handle;
+  req->tcm.tcm_parent = parent;
+
+  if (kind != nullptr) {
+    addattr(&req->hdr, sizeof(*req), TCA_KIND, kind, strlen(kind) + 1);
+  }
+  struct rtattr* opts = NLMSG_TAIL(&req->hdr);
+  addattr(&req->hdr, sizeof(*req), TCA_OPTIONS, nullptr, 0);
+  return opts;
+}
+
+// SendTcRequest closes the options of req and sends it.
+PosixError SendTcRequest(const FileDescriptor& fd, TcRequest* req,
+                         struct rtattr* opts) {
+  opts->rta_len = (uint64_t)NLMSG_TAIL(&req->hdr) - (uint64_t)opts;
+  return NetlinkRequestAckOrError(fd, kSeq, req, req->hdr.nlmsg_len);
+}
+
+// AddTbf adds a TBF root queueing discipline with handle 1: to the interface
+// index.
+PosixError AddTbf(const FileDescriptor& fd, int index, uint16_t flags) {
+  TcRequest req = {};
+  struct rtattr* opts = InitTcRequest(&req, RTM_NEWQDISC, flags, index,
+                                      TC_H_MAKE(1 << 16, 0), TC_H_ROOT, "tbf");
+  struct tc_tbf_qopt qopt = {};
+  qopt.rate.rate = 125000;  // 1mbit.
+  qopt.limit = 65536;
+  uint32_t burst = 32768;
+  addattr(&req.hdr, sizeof(req), TCA_TBF_PARMS, &qopt, sizeof(qopt));
+  addattr(&req.hdr, sizeof(req), TCA_TBF_BURST, &burst, sizeof(burst));
+  return SendTcRequest(fd, &req, opts);
+}
+
+// DumpTc dumps the queueing disciplines or the classes of the interface index,
+// and returns the kinds found indexed by handle.
+PosixErrorOr<std::map<uint32_t, std::string>> DumpTc(const FileDescriptor& fd,
+                                                     uint16_t type,
+                                                     int index) {
+  struct request {
+    struct nlmsghdr hdr;
+    struct tcmsg tcm;
+  };
+  struct request req = {};
+  req.hdr.nlmsg_len = sizeof(req);
+  req.hdr.nlmsg_type = type;
+  req.hdr.nlmsg_flags = NLM_F_REQUEST | NLM_F_DUMP;
+  req.hdr.nlmsg_seq = kSeq;
+  req.tcm.tcm_family = AF_UNSPEC;
+  req.tcm.tcm_ifindex = index;
+
+  std::map<uint32_t, std::string> kinds;
+  RETURN_IF_ERRNO(NetlinkRequestResponse(
+      fd, &req, sizeof(req),
+      [&](const struct nlmsghdr* hdr) {
+        const struct tcmsg* tcm =
+            reinterpret_cast<const struct tcmsg*>(NLMSG_DATA(hdr));
+        if (tcm->tcm_ifindex != index) {
+          return;
+        }
+        int len = hdr->nlmsg_len - NLMSG_LENGTH(sizeof(*tcm));
+        for (const struct rtattr* rta = reinterpret_cast<const struct rtattr*>(
+                 reinterpret_cast<const char*>(tcm) +
+                 NLMSG_ALIGN(sizeof(*tcm)));
+             RTA_OK(rta, len); rta = RTA_NEXT(rta, len)) {
+          if (rta->rta_type == TCA_KIND) {
+            kinds[tcm->tcm_handle] =
+                std::string(reinterpret_cast<const char*>(RTA_DATA(rta)));
+          }
+        }
+      },
+      false));
+  return kinds;
+}
+
+TEST(NetlinkRouteTest, QdiscTbf) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)));
+  SKIP_IF(IsRunningWithHostinet());
+
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_ROUTE));
+  int index = ASSERT_NO_ERRNO_AND_VALUE(AddLink(fd, "tc_tbf", "veth"));
+
+  ASSERT_NO_ERRNO(AddTbf(fd, index, NLM_F_CREATE | NLM_F_EXCL));
+  EXPECT_THAT(AddTbf(fd, index, NLM_F_CREATE | NLM_F_EXCL),
+              PosixErrorIs(EEXIST, _));
+  // Changing the options of the queueing discipline is allowed.
+  ASSERT_NO_ERRNO(AddTbf(fd, index, 0));
+  auto kinds = ASSERT_NO_ERRNO_AND_VALUE(DumpTc(fd, RTM_GETQDISC, index));
+  EXPECT_EQ(kinds[TC_H_MAKE(1 << 16, 0)], "tbf");
+
+  // Replace it with FQ.
+  TcRequest req = {};
+  struct rtattr* opts =
+      InitTcRequest(&req, RTM_NEWQDISC, NLM_F_CREATE | NLM_F_REPLACE, index,
+                    TC_H_MAKE(1 << 16, 0), TC_H_ROOT, "fq");
+  uint32_t flow_limit = 50;
+  addattr(&req.hdr, sizeof(req), TCA_FQ_FLOW_PLIMIT, &flow_limit,
+          sizeof(flow_limit));
+  ASSERT_NO_ERRNO(SendTcRequest(fd, &req, opts));
+  kinds = ASSERT_NO_ERRNO_AND_VALUE(DumpTc(fd, RTM_GETQDISC, index));
+  EXPECT_EQ(kinds[TC_H_MAKE(1 << 16, 0)], "fq");
+
+  TcRequest del = {};
+  opts = InitTcRequest(&del, RTM_DELQDISC, 0, index, 0, TC_H_ROOT, nullptr);
+  ASSERT_NO_ERRNO(SendTcRequest(fd, &del, opts));
+  EXPECT_THAT(SendTcRequest(fd, &del, opts), PosixErrorIs(ENOENT, _));
+}
+
+TEST(NetlinkRouteTest, QdiscHtbClass) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)));
+  SKIP_IF(IsRunningWithHostinet());
+
+  FileDescriptor fd =
+      ASSERT_NO_ERRNO_AND_VALUE(NetlinkBoundSocket(NETLINK_ROUTE));
+  int index = ASSERT_NO_ERRNO_AND_VALUE(AddLink(fd, "tc_htb", "veth"));
+
+  const uint32_t handle = TC_H_MAKE(1 << 16, 0);
+  TcRequest req = {};
+  struct rtattr* opts = InitTcRequest(
+      &req, RTM_NEWQDISC, NLM_F_CREATE | NLM_F_EXCL, index, handle, TC_H_ROOT,
+      "htb");
+  struct tc_htb_glob glob = {};
+  glob.version = 3;
+  glob.rate2quantum = 10;
+  glob.defcls = 0x10;
+  addattr(&req.hdr, sizeof(req), TCA_HTB_INIT, &glob, sizeof(glob));
+  ASSERT_NO_ERRNO(SendTcRequest(fd, &req, opts));
+
+  const uint32_t classid = TC_H_MAKE(handle, 0x10);
+  TcRequest class_req = {};
+  opts = InitTcRequest(&class_req, RTM_NEWTCLASS, NLM_F_CREATE | NLM_F_EXCL,
+                       index, classid, handle, "htb");
+  struct tc_htb_opt hopt = {};
+  hopt.rate.rate = 125000;
+  hopt.ceil.rate = 125000;
+  hopt.buffer = 1000000;
+  hopt.cbuffer = 1000000;
+  addattr(&class_req.hdr, sizeof(class_req), TCA_HTB_PARMS, &hopt,
+          sizeof(hopt));
+  ASSERT_NO_ERRNO(SendTcRequest(fd, &class_req, opts));
+  EXPECT_THAT(SendTcRequest(fd, &class_req, opts), PosixErrorIs(EEXIST, _));
+
+  auto kinds = ASSERT_NO_ERRNO_AND_VALUE(DumpTc(fd, RTM_GETTCLASS, index));
+  EXPECT_EQ(kinds[classid], "htb");
+
+  TcRequest del = {};
+  opts =
+      InitTcRequest(&del, RTM_DELTCLASS, 0, index, classid, handle, nullptr);
+  ASSERT_NO_ERRNO(SendTcRequest(fd, &del, opts));
+  EXPECT_THAT(SendTcRequest(fd, &del, opts), PosixErrorIs(ENOENT, _));
+}
+
 TEST(NetlinkRouteTest, LookupAllAddrOrder) {
   // Run the test multiple times to identify any flakiness with the order of
   // addresses returned. The order should be IPv4(AF_INET = 2) addresses
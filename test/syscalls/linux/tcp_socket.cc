@@ -15,9 +15,11 @@
 #include <fcntl.h>
 
 #include <memory>
+#include <string>
 
 #ifdef __linux__
 #include <linux/filter.h>
+#include <linux/tls.h>
 #include <sys/epoll.h>
 #endif  // __linux__
 #include <errno.h>
@@ -36,6 +38,7 @@
 #include "absl/status/statusor.h"
 #include "absl/time/clock.h"
 #include "absl/time/time.h"
+#include "test/util/capability_util.h"
 #include "test/util/file_descriptor.h"
 #include "test/util/posix_error.h"
 #include "test/util/socket_util.h"
@@ -148,6 +151,25 @@ static void FillSocketBuffers(int sender, int receiver) {
   ASSERT_THAT(fcntl(sender, F_SETFL, orig_opts), SyscallSucceeds());
 }
 
+// Attaches the kernel TLS upper layer protocol to fd. On Linux, this fails with
+// ENOENT if the tls module isn't loaded.
+int AttachTLS(int fd) {
+  constexpr char kTLS[] = "tls";
+  return setsockopt(fd, IPPROTO_TCP, TCP_ULP, kTLS, sizeof(kTLS));
+}
+
+// Returns the crypto info of a TLS 1.2 connection using AES-GCM-128.
+tls12_crypto_info_aes_gcm_128 TLSCryptoInfo() {
+  tls12_crypto_info_aes_gcm_128 info = {};
+  info.info.version = TLS_1_2_VERSION;
+  info.info.cipher_type = TLS_CIPHER_AES_GCM_128;
+  memset(info.iv, 0x01, sizeof(info.iv));
+  memset(info.key, 0x02, sizeof(info.key));
+  memset(info.salt, 0x03, sizeof(info.salt));
+  memset(info.rec_seq, 0x04, sizeof(info.rec_seq));
+  return info;
+}
+
 // Fixture for tests parameterized by the address family to use (AF_INET and
 // AF_INET6) when creating sockets.
 class TcpSocketTest : public ::testing::TestWithParam<int> {
@@ -967,6 +989,136 @@ TEST_P(TcpSocketTest, SendUnblocksOnSendBufferIncrease) {
   send_thread.Join();
 }
 
+TEST_P(TcpSocketTest, RepairMigratesConnection) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN)));
+
+  // Leave some data unread on the connected socket.
+  constexpr char kUnread[] = "unread";
+  ASSERT_THAT(RetryEINTR(send)(accepted_.get(), kUnread, sizeof(kUnread), 0),
+              SyscallSucceedsWithValue(sizeof(kUnread)));
+  struct pollfd pfd = {connected_.get(), POLLIN, 0};
+  ASSERT_THAT(RetryEINTR(poll)(&pfd, 1, kTimeoutMillis),
+              SyscallSucceedsWithValue(1));
+
+  // Save the connection.
+  ASSERT_THAT(setsockopt(connected_.get(), IPPROTO_TCP, TCP_REPAIR,
+                         &kSockOptOn, sizeof(kSockOptOn)),
+              SyscallSucceeds());
+
+  int queue = TCP_SEND_QUEUE;
+  ASSERT_THAT(setsockopt(connected_.get(), IPPROTO_TCP, TCP_REPAIR_QUEUE,
+                         &queue, sizeof(queue)),
+              SyscallSucceeds());
+  uint32_t snd_seq;
+  socklen_t len = sizeof(snd_seq);
+  ASSERT_THAT(
+      getsockopt(connected_.get(), IPPROTO_TCP, TCP_QUEUE_SEQ, &snd_seq, &len),
+      SyscallSucceeds());
+
+  queue = TCP_RECV_QUEUE;
+  ASSERT_THAT(setsockopt(connected_.get(), IPPROTO_TCP, TCP_REPAIR_QUEUE,
+                         &queue, sizeof(queue)),
+              SyscallSucceeds());
+  uint32_t rcv_seq;
+  len = sizeof(rcv_seq);
+  ASSERT_THAT(
+      getsockopt(connected_.get(), IPPROTO_TCP, TCP_QUEUE_SEQ, &rcv_seq, &len),
+      SyscallSucceeds());
+
+  // The queues can only be peeked at in repair mode.
+  char buf[sizeof(kUnread)];
+  EXPECT_THAT(RetryEINTR(recv)(connected_.get(), buf, sizeof(buf), 0),
+              SyscallFailsWithErrno(EPERM));
+  ASSERT_THAT(RetryEINTR(recv)(connected_.get(), buf, sizeof(buf), MSG_PEEK),
+              SyscallSucceedsWithValue(sizeof(kUnread)));
+  EXPECT_EQ(memcmp(buf, kUnread, sizeof(kUnread)), 0);
+
+  struct tcp_repair_window window;
+  len = sizeof(window);
+  ASSERT_THAT(getsockopt(connected_.get(), IPPROTO_TCP, TCP_REPAIR_WINDOW,
+                         &window, &len),
+              SyscallSucceeds());
+
+  sockaddr_storage local, peer;
+  socklen_t addrlen = sizeof(local);
+  ASSERT_THAT(getsockname(connected_.get(), AsSockAddr(&local), &addrlen),
+              SyscallSucceeds());
+  ASSERT_THAT(getpeername(connected_.get(), AsSockAddr(&peer), &addrlen),
+              SyscallSucceeds());
+
+  // Closing a socket in repair mode doesn't notify the peer.
+  connected_.reset();
+
+  // Restore the connection on a new socket.
+  FileDescriptor s =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(GetParam(), SOCK_STREAM, IPPROTO_TCP));
+  ASSERT_THAT(
+      setsockopt(s.get(), IPPROTO_TCP, TCP_REPAIR, &kSockOptOn, sizeof(int)),
+      SyscallSucceeds());
+  ASSERT_THAT(
+      setsockopt(s.get(), SOL_SOCKET, SO_REUSEADDR, &kSockOptOn, sizeof(int)),
+      SyscallSucceeds());
+  ASSERT_THAT(bind(s.get(), AsSockAddr(&local), addrlen), SyscallSucceeds());
+
+  queue = TCP_SEND_QUEUE;
+  ASSERT_THAT(
+      setsockopt(s.get(), IPPROTO_TCP, TCP_REPAIR_QUEUE, &queue, sizeof(queue)),
+      SyscallSucceeds());
+  ASSERT_THAT(setsockopt(s.get(), IPPROTO_TCP, TCP_QUEUE_SEQ, &snd_seq,
+                         sizeof(snd_seq)),
+              SyscallSucceeds());
+  queue = TCP_RECV_QUEUE;
+  ASSERT_THAT(
+      setsockopt(s.get(), IPPROTO_TCP, TCP_REPAIR_QUEUE, &queue, sizeof(queue)),
+      SyscallSucceeds());
+  rcv_seq -= sizeof(kUnread);
+  ASSERT_THAT(setsockopt(s.get(), IPPROTO_TCP, TCP_QUEUE_SEQ, &rcv_seq,
+                         sizeof(rcv_seq)),
+              SyscallSucceeds());
+
+  // Connecting in repair mode doesn't perform a handshake.
+  ASSERT_THAT(RetryEINTR(connect)(s.get(), AsSockAddr(&peer), addrlen),
+              SyscallSucceeds());
+
+  // Both loopback stacks negotiate timestamps and SACK.
+  struct tcp_repair_opt opts[] = {
+      {TCPOPT_TIMESTAMP, 0},
+      {TCPOPT_SACK_PERMITTED, 0},
+  };
+  ASSERT_THAT(
+      setsockopt(s.get(), IPPROTO_TCP, TCP_REPAIR_OPTIONS, opts, sizeof(opts)),
+      SyscallSucceeds());
+  ASSERT_THAT(setsockopt(s.get(), IPPROTO_TCP, TCP_REPAIR_WINDOW, &window,
+                         sizeof(window)),
+              SyscallSucceeds());
+
+  // Writing in repair mode fills the selected queue.
+  ASSERT_THAT(RetryEINTR(send)(s.get(), kUnread, sizeof(kUnread), 0),
+              SyscallSucceedsWithValue(sizeof(kUnread)));
+  ASSERT_THAT(
+      setsockopt(s.get(), IPPROTO_TCP, TCP_REPAIR, &kSockOptOff, sizeof(int)),
+      SyscallSucceeds());
+
+  // The restored socket reads the unread data and talks to the peer.
+  ASSERT_THAT(RetryEINTR(recv)(s.get(), buf, sizeof(buf), 0),
+              SyscallSucceedsWithValue(sizeof(kUnread)));
+  EXPECT_EQ(memcmp(buf, kUnread, sizeof(kUnread)), 0);
+
+  constexpr char kPing[] = "ping";
+  ASSERT_THAT(RetryEINTR(send)(accepted_.get(), kPing, sizeof(kPing), 0),
+              SyscallSucceedsWithValue(sizeof(kPing)));
+  ASSERT_THAT(RetryEINTR(recv)(s.get(), buf, sizeof(kPing), 0),
+              SyscallSucceedsWithValue(sizeof(kPing)));
+  EXPECT_EQ(memcmp(buf, kPing, sizeof(kPing)), 0);
+
+  constexpr char kPong[] = "pong";
+  ASSERT_THAT(RetryEINTR(send)(s.get(), kPong, sizeof(kPong), 0),
+              SyscallSucceedsWithValue(sizeof(kPong)));
+  ASSERT_THAT(RetryEINTR(recv)(accepted_.get(), buf, sizeof(kPong), 0),
+              SyscallSucceedsWithValue(sizeof(kPong)));
+  EXPECT_EQ(memcmp(buf, kPong, sizeof(kPong)), 0);
+}
+
 INSTANTIATE_TEST_SUITE_P(AllInetTests, TcpSocketTest,
                          ::testing::Values(AF_INET, AF_INET6));
 
@@ -2010,6 +2162,228 @@ TEST_P(SimpleTcpSocketTest, SetTCPDeferAcceptGreaterThanZero) {
   EXPECT_EQ(get, kTCPDeferAccept);
 }
 
+TEST_P(SimpleTcpSocketTest, SetTCPFastOpen) {
+  FileDescriptor s =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(GetParam(), SOCK_STREAM, IPPROTO_TCP));
+
+  constexpr int kNeg = -1;
+  EXPECT_THAT(
+      setsockopt(s.get(), IPPROTO_TCP, TCP_FASTOPEN, &kNeg, sizeof(kNeg)),
+      SyscallFailsWithErrno(EINVAL));
+
+  // kTCPFastOpen is the maximum number of pending Fast Open requests.
+  constexpr int kTCPFastOpen = 5;
+  ASSERT_THAT(setsockopt(s.get(), IPPROTO_TCP, TCP_FASTOPEN, &kTCPFastOpen,
+                         sizeof(kTCPFastOpen)),
+              SyscallSucceeds());
+  int get = -1;
+  socklen_t get_len = sizeof(get);
+  ASSERT_THAT(getsockopt(s.get(), IPPROTO_TCP, TCP_FASTOPEN, &get, &get_len),
+              SyscallSucceeds());
+  EXPECT_EQ(get_len, sizeof(get));
+  EXPECT_EQ(get, kTCPFastOpen);
+}
+
+TEST_P(SimpleTcpSocketTest, SetTCPFastOpenConnect) {
+  FileDescriptor s =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(GetParam(), SOCK_STREAM, IPPROTO_TCP));
+
+  int get = -1;
+  socklen_t get_len = sizeof(get);
+  ASSERT_THAT(
+      getsockopt(s.get(), IPPROTO_TCP, TCP_FASTOPEN_CONNECT, &get, &get_len),
+      SyscallSucceeds());
+  EXPECT_EQ(get_len, sizeof(get));
+  EXPECT_EQ(get, 0);
+
+  constexpr int kInvalid = 2;
+  EXPECT_THAT(setsockopt(s.get(), IPPROTO_TCP, TCP_FASTOPEN_CONNECT, &kInvalid,
+                         sizeof(kInvalid)),
+              SyscallFailsWithErrno(EINVAL));
+
+  ASSERT_THAT(setsockopt(s.get(), IPPROTO_TCP, TCP_FASTOPEN_CONNECT,
+                         &kSockOptOn, sizeof(kSockOptOn)),
+              SyscallSucceeds());
+  ASSERT_THAT(
+      getsockopt(s.get(), IPPROTO_TCP, TCP_FASTOPEN_CONNECT, &get, &get_len),
+      SyscallSucceeds());
+  EXPECT_EQ(get_len, sizeof(get));
+  EXPECT_EQ(get, kSockOptOn);
+}
+
+TEST_P(SimpleTcpSocketTest, SetTCPULP) {
+  SKIP_IF(IsRunningWithHostinet());
+  FileDescriptor s =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(GetParam(), SOCK_STREAM, IPPROTO_TCP));
+
+  // No upper layer protocol is attached by default.
+  char name[16] = {};
+  socklen_t name_len = sizeof(name);
+  ASSERT_THAT(getsockopt(s.get(), IPPROTO_TCP, TCP_ULP, name, &name_len),
+              SyscallSucceeds());
+  EXPECT_EQ(name_len, 0);
+
+  constexpr char kUnknown[] = "unknown";
+  EXPECT_THAT(
+      setsockopt(s.get(), IPPROTO_TCP, TCP_ULP, kUnknown, sizeof(kUnknown)),
+      SyscallFailsWithErrno(ENOENT));
+
+  // The tls upper layer protocol can only be attached to connected sockets.
+  int ret = AttachTLS(s.get());
+  SKIP_IF(!IsRunningOnGvisor() && ret != 0 && errno == ENOENT);
+  EXPECT_THAT(ret, SyscallFailsWithErrno(ENOTCONN));
+}
+
+TEST_P(SimpleTcpSocketTest, SetTCPRepair) {
+  FileDescriptor s =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(GetParam(), SOCK_STREAM, IPPROTO_TCP));
+
+  int get = -1;
+  socklen_t get_len = sizeof(get);
+  ASSERT_THAT(getsockopt(s.get(), IPPROTO_TCP, TCP_REPAIR, &get, &get_len),
+              SyscallSucceeds());
+  EXPECT_EQ(get, 0);
+
+  // A queue can only be selected in repair mode.
+  int queue = TCP_RECV_QUEUE;
+  EXPECT_THAT(
+      setsockopt(s.get(), IPPROTO_TCP, TCP_REPAIR_QUEUE, &queue, sizeof(queue)),
+      SyscallFailsWithErrno(EPERM));
+
+  if (!ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN))) {
+    EXPECT_THAT(
+        setsockopt(s.get(), IPPROTO_TCP, TCP_REPAIR, &kSockOptOn, sizeof(int)),
+        SyscallFailsWithErrno(EPERM));
+    return;
+  }
+
+  ASSERT_THAT(
+      setsockopt(s.get(), IPPROTO_TCP, TCP_REPAIR, &kSockOptOn, sizeof(int)),
+      SyscallSucceeds());
+  ASSERT_THAT(getsockopt(s.get(), IPPROTO_TCP, TCP_REPAIR, &get, &get_len),
+              SyscallSucceeds());
+  EXPECT_EQ(get, kSockOptOn);
+
+  constexpr int kInvalidQueue = 3;
+  EXPECT_THAT(setsockopt(s.get(), IPPROTO_TCP, TCP_REPAIR_QUEUE,
+                         &kInvalidQueue, sizeof(kInvalidQueue)),
+              SyscallFailsWithErrno(EINVAL));
+  ASSERT_THAT(
+      setsockopt(s.get(), IPPROTO_TCP, TCP_REPAIR_QUEUE, &queue, sizeof(queue)),
+      SyscallSucceeds());
+  ASSERT_THAT(
+      getsockopt(s.get(), IPPROTO_TCP, TCP_REPAIR_QUEUE, &get, &get_len),
+      SyscallSucceeds());
+  EXPECT_EQ(get, queue);
+
+  constexpr uint32_t kSeq = 0xdeadbeef;
+  ASSERT_THAT(
+      setsockopt(s.get(), IPPROTO_TCP, TCP_QUEUE_SEQ, &kSeq, sizeof(kSeq)),
+      SyscallSucceeds());
+  uint32_t seq = 0;
+  socklen_t seq_len = sizeof(seq);
+  ASSERT_THAT(getsockopt(s.get(), IPPROTO_TCP, TCP_QUEUE_SEQ, &seq, &seq_len),
+              SyscallSucceeds());
+  EXPECT_EQ(seq, kSeq);
+}
+
+// SetTCPMD5Key installs key on fd for the peer at addr.
+PosixError SetTCPMD5Key(int fd, const sockaddr_storage& addr,
+                        const std::string& key) {
+  struct tcp_md5sig sig = {};
+  sig.tcpm_addr = addr;
+  sig.tcpm_keylen = key.size();
+  memcpy(sig.tcpm_key, key.data(), key.size());
+  RETURN_ERROR_IF_SYSCALL_FAIL(
+      setsockopt(fd, IPPROTO_TCP, TCP_MD5SIG, &sig, sizeof(sig)));
+  return NoError();
+}
+
+TEST_P(SimpleTcpSocketTest, SetTCPMD5Sig) {
+  FileDescriptor s =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(GetParam(), SOCK_STREAM, IPPROTO_TCP));
+  sockaddr_storage addr =
+      ASSERT_NO_ERRNO_AND_VALUE(InetLoopbackAddrZeroPort(GetParam()));
+
+  struct tcp_md5sig sig = {};
+  sig.tcpm_addr = addr;
+
+  // Removing a key that doesn't exist fails.
+  EXPECT_THAT(setsockopt(s.get(), IPPROTO_TCP, TCP_MD5SIG, &sig, sizeof(sig)),
+              SyscallFailsWithErrno(ENOENT));
+
+  sig.tcpm_keylen = TCP_MD5SIG_MAXKEYLEN + 1;
+  EXPECT_THAT(setsockopt(s.get(), IPPROTO_TCP, TCP_MD5SIG, &sig, sizeof(sig)),
+              SyscallFailsWithErrno(EINVAL));
+
+  ASSERT_NO_ERRNO(SetTCPMD5Key(s.get(), addr, "secret"));
+  sig.tcpm_keylen = 0;
+  EXPECT_THAT(setsockopt(s.get(), IPPROTO_TCP, TCP_MD5SIG, &sig, sizeof(sig)),
+              SyscallSucceeds());
+
+  // A prefix longer than the address is invalid.
+  sig.tcpm_flags = TCP_MD5SIG_FLAG_PREFIX;
+  sig.tcpm_prefixlen = 129;
+  sig.tcpm_keylen = 6;
+  EXPECT_THAT(
+      setsockopt(s.get(), IPPROTO_TCP, TCP_MD5SIG_EXT, &sig, sizeof(sig)),
+      SyscallFailsWithErrno(EINVAL));
+}
+
+TEST_P(SimpleTcpSocketTest, TCPMD5SigConnect) {
+  sockaddr_storage addr =
+      ASSERT_NO_ERRNO_AND_VALUE(InetLoopbackAddrZeroPort(GetParam()));
+  socklen_t addrlen = sizeof(addr);
+
+  FileDescriptor listener =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(GetParam(), SOCK_STREAM, IPPROTO_TCP));
+  ASSERT_NO_ERRNO(SetTCPMD5Key(listener.get(), addr, "secret"));
+  ASSERT_THAT(bind(listener.get(), AsSockAddr(&addr), addrlen),
+              SyscallSucceeds());
+  ASSERT_THAT(listen(listener.get(), SOMAXCONN), SyscallSucceeds());
+  ASSERT_THAT(getsockname(listener.get(), AsSockAddr(&addr), &addrlen),
+              SyscallSucceeds());
+
+  FileDescriptor client =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(GetParam(), SOCK_STREAM, IPPROTO_TCP));
+  ASSERT_NO_ERRNO(SetTCPMD5Key(client.get(), addr, "secret"));
+  ASSERT_THAT(RetryEINTR(connect)(client.get(), AsSockAddr(&addr), addrlen),
+              SyscallSucceeds());
+  FileDescriptor accepted =
+      ASSERT_NO_ERRNO_AND_VALUE(Accept(listener.get(), nullptr, nullptr));
+
+  constexpr char kData[] = "signed";
+  ASSERT_THAT(RetryEINTR(send)(client.get(), kData, sizeof(kData), 0),
+              SyscallSucceedsWithValue(sizeof(kData)));
+  char buf[sizeof(kData)] = {};
+  ASSERT_THAT(RetryEINTR(recv)(accepted.get(), buf, sizeof(buf), MSG_WAITALL),
+              SyscallSucceedsWithValue(sizeof(kData)));
+  EXPECT_STREQ(buf, kData);
+}
+
+TEST_P(SimpleTcpSocketTest, TCPMD5SigMissingKeyDropsSegments) {
+  sockaddr_storage addr =
+      ASSERT_NO_ERRNO_AND_VALUE(InetLoopbackAddrZeroPort(GetParam()));
+  socklen_t addrlen = sizeof(addr);
+
+  FileDescriptor listener =
+      ASSERT_NO_ERRNO_AND_VALUE(Socket(GetParam(), SOCK_STREAM, IPPROTO_TCP));
+  ASSERT_NO_ERRNO(SetTCPMD5Key(listener.get(), addr, "secret"));
+  ASSERT_THAT(bind(listener.get(), AsSockAddr(&addr), addrlen),
+              SyscallSucceeds());
+  ASSERT_THAT(listen(listener.get(), SOMAXCONN), SyscallSucceeds());
+  ASSERT_THAT(getsockname(listener.get(), AsSockAddr(&addr), &addrlen),
+              SyscallSucceeds());
+
+  // The unsigned SYN is dropped, so the connection never completes.
+  FileDescriptor client = ASSERT_NO_ERRNO_AND_VALUE(
+      Socket(GetParam(), SOCK_STREAM | SOCK_NONBLOCK, IPPROTO_TCP));
+  ASSERT_THAT(connect(client.get(), AsSockAddr(&addr), addrlen),
+              SyscallFailsWithErrno(EINPROGRESS));
+  struct pollfd pfd = {.fd = client.get(), .events = POLLOUT};
+  EXPECT_THAT(RetryEINTR(poll)(&pfd, 1, 1000), SyscallSucceedsWithValue(0));
+}
+
 TEST_P(SimpleTcpSocketTest, RecvOnClosedSocket) {
   auto s =
       ASSERT_NO_ERRNO_AND_VALUE(Socket(GetParam(), SOCK_STREAM, IPPROTO_TCP));
@@ -2291,8 +2665,8 @@ TEST_P(SimpleTcpSocketTest, CloseNonConnectedLingerOption) {
       .fd = s.get(),
       .events = POLLHUP,
   };
-  constexpr int kPollTimeoutMs = 0;
-  ASSERT_THAT(RetryEINTR(poll)(&poll_fd, 1, kPollTimeoutMs),
+  constexpr int kTimeoutMillis = 0;
+  ASSERT_THAT(RetryEINTR(poll)(&poll_fd, 1, kTimeoutMillis),
               SyscallSucceedsWithValue(1));
 
   auto const start_time = absl::Now();
@@ -2374,6 +2748,134 @@ TEST_P(TcpSocketTest, SetPMTUD) {
   }
 }
 
+TEST_P(TcpSocketTest, SetTLSCryptoInfo) {
+  SKIP_IF(IsRunningWithHostinet());
+  int ret = AttachTLS(connected_.get());
+  SKIP_IF(!IsRunningOnGvisor() && ret != 0 && errno == ENOENT);
+  ASSERT_THAT(ret, SyscallSucceeds());
+
+  char name[16] = {};
+  socklen_t name_len = sizeof(name);
+  ASSERT_THAT(
+      getsockopt(connected_.get(), IPPROTO_TCP, TCP_ULP, name, &name_len),
+      SyscallSucceeds());
+  EXPECT_EQ(name_len, sizeof(name));
+  EXPECT_STREQ(name, "tls");
+  EXPECT_THAT(AttachTLS(connected_.get()), SyscallFailsWithErrno(EEXIST));
+
+  // The crypto info can only be read once it is set.
+  tls12_crypto_info_aes_gcm_128 got = {};
+  socklen_t got_len = sizeof(got);
+  EXPECT_THAT(getsockopt(connected_.get(), SOL_TLS, TLS_TX, &got, &got_len),
+              SyscallFailsWithErrno(EBUSY));
+
+  tls12_crypto_info_aes_gcm_128 info = TLSCryptoInfo();
+  info.info.version = 0;
+  EXPECT_THAT(
+      setsockopt(connected_.get(), SOL_TLS, TLS_TX, &info, sizeof(info)),
+      SyscallFailsWithErrno(EINVAL));
+  info = TLSCryptoInfo();
+  EXPECT_THAT(
+      setsockopt(connected_.get(), SOL_TLS, TLS_TX, &info, sizeof(info) - 1),
+      SyscallFailsWithErrno(EINVAL));
+  ASSERT_THAT(
+      setsockopt(connected_.get(), SOL_TLS, TLS_TX, &info, sizeof(info)),
+      SyscallSucceeds());
+  EXPECT_THAT(
+      setsockopt(connected_.get(), SOL_TLS, TLS_TX, &info, sizeof(info)),
+      SyscallFailsWithErrno(EBUSY));
+
+  // Reading only the header returns the version and the cipher.
+  got_len = sizeof(got.info) - 1;
+  EXPECT_THAT(getsockopt(connected_.get(), SOL_TLS, TLS_TX, &got, &got_len),
+              SyscallFailsWithErrno(EINVAL));
+  got_len = sizeof(got.info);
+  ASSERT_THAT(getsockopt(connected_.get(), SOL_TLS, TLS_TX, &got, &got_len),
+              SyscallSucceeds());
+  EXPECT_EQ(got_len, sizeof(got.info));
+  EXPECT_EQ(got.info.version, info.info.version);
+  EXPECT_EQ(got.info.cipher_type, info.info.cipher_type);
+  EXPECT_THAT(got.key, ::testing::Each(0));
+
+  got_len = sizeof(got) - 1;
+  EXPECT_THAT(getsockopt(connected_.get(), SOL_TLS, TLS_TX, &got, &got_len),
+              SyscallFailsWithErrno(EINVAL));
+  got_len = sizeof(got);
+  ASSERT_THAT(getsockopt(connected_.get(), SOL_TLS, TLS_TX, &got, &got_len),
+              SyscallSucceeds());
+  EXPECT_EQ(got_len, sizeof(got));
+  EXPECT_EQ(memcmp(&got, &info, sizeof(got)), 0);
+
+  // The receive direction is configured separately.
+  got_len = sizeof(got);
+  EXPECT_THAT(getsockopt(connected_.get(), SOL_TLS, TLS_RX, &got, &got_len),
+              SyscallFailsWithErrno(EBUSY));
+}
+
+TEST_P(TcpSocketTest, TLSSendRecv) {
+  SKIP_IF(IsRunningWithHostinet());
+  int ret = AttachTLS(connected_.get());
+  SKIP_IF(!IsRunningOnGvisor() && ret != 0 && errno == ENOENT);
+  ASSERT_THAT(ret, SyscallSucceeds());
+  ASSERT_THAT(AttachTLS(accepted_.get()), SyscallSucceeds());
+
+  const tls12_crypto_info_aes_gcm_128 info = TLSCryptoInfo();
+  ASSERT_THAT(
+      setsockopt(connected_.get(), SOL_TLS, TLS_TX, &info, sizeof(info)),
+      SyscallSucceeds());
+  ASSERT_THAT(
+      setsockopt(accepted_.get(), SOL_TLS, TLS_RX, &info, sizeof(info)),
+      SyscallSucceeds());
+
+  constexpr char kData[] = "application data";
+  ASSERT_THAT(RetryEINTR(send)(connected_.get(), kData, sizeof(kData), 0),
+              SyscallSucceedsWithValue(sizeof(kData)));
+  char buf[sizeof(kData)] = {};
+  ASSERT_THAT(RetryEINTR(recv)(accepted_.get(), buf, sizeof(buf), 0),
+              SyscallSucceedsWithValue(sizeof(kData)));
+  EXPECT_STREQ(buf, kData);
+
+  // Send a handshake record.
+  constexpr uint8_t kHandshake = 22;
+  constexpr char kHandshakeData[] = "handshake";
+  struct iovec iov = {};
+  iov.iov_base = const_cast<char*>(kHandshakeData);
+  iov.iov_len = sizeof(kHandshakeData);
+  char control[CMSG_SPACE(sizeof(kHandshake))] = {};
+  struct msghdr msg = {};
+  msg.msg_iov = &iov;
+  msg.msg_iovlen = 1;
+  msg.msg_control = control;
+  msg.msg_controllen = sizeof(control);
+  struct cmsghdr* cmsg = CMSG_FIRSTHDR(&msg);
+  cmsg->cmsg_level = SOL_TLS;
+  cmsg->cmsg_type = TLS_SET_RECORD_TYPE;
+  cmsg->cmsg_len = CMSG_LEN(sizeof(kHandshake));
+  memcpy(CMSG_DATA(cmsg), &kHandshake, sizeof(kHandshake));
+  ASSERT_THAT(RetryEINTR(sendmsg)(connected_.get(), &msg, 0),
+              SyscallSucceedsWithValue(sizeof(kHandshakeData)));
+
+  // Records that don't hold application data can't be received without
+  // their type.
+  EXPECT_THAT(RetryEINTR(recv)(accepted_.get(), buf, sizeof(buf), 0),
+              SyscallFailsWithErrno(EIO));
+
+  memset(buf, 0, sizeof(buf));
+  memset(control, 0, sizeof(control));
+  iov.iov_base = buf;
+  iov.iov_len = sizeof(buf);
+  msg.msg_controllen = sizeof(control);
+  ASSERT_THAT(RetryEINTR(recvmsg)(accepted_.get(), &msg, 0),
+              SyscallSucceedsWithValue(sizeof(kHandshakeData)));
+  EXPECT_STREQ(buf, kHandshakeData);
+  cmsg = CMSG_FIRSTHDR(&msg);
+  ASSERT_NE(cmsg, nullptr);
+  EXPECT_EQ(cmsg->cmsg_level, SOL_TLS);
+  EXPECT_EQ(cmsg->cmsg_type, TLS_GET_RECORD_TYPE);
+  EXPECT_EQ(cmsg->cmsg_len, CMSG_LEN(sizeof(kHandshake)));
+  EXPECT_EQ(*CMSG_DATA(cmsg), kHandshake);
+}
+
 TEST_P(SimpleTcpSocketTest, GetSocketAcceptConnWithShutdown) {
   // TODO(b/171345701): Fix the TCP state for listening socket on shutdown.
   SKIP_IF(IsRunningOnGvisor());
@@ -25,6 +25,7 @@
 #ifdef __linux__
 #include <linux/errqueue.h>
 #include <linux/filter.h>
+#include <linux/net_tstamp.h>
 #endif  // __linux__
 #include <netinet/in.h>
 #include <poll.h>
@@ -43,6 +44,7 @@
 #include "absl/time/time.h"
 #include "test/syscalls/linux/ip_socket_test_util.h"
 #include "test/syscalls/linux/unix_domain_socket_test_util.h"
+#include "test/util/capability_util.h"
 #include "test/util/file_descriptor.h"
 #include "test/util/posix_error.h"
 #include "test/util/socket_util.h"
@@ -958,6 +960,132 @@ TEST_P(UdpSocketTest, RecvErrorConnRefused) {
   ASSERT_EQ(err, 0);
   ASSERT_EQ(optlen, sizeof(err));
 }
+
+TEST_P(UdpSocketTest, ZeroCopySend) {
+  // Hostinet doesn't support SO_ZEROCOPY.
+  SKIP_IF(IsRunningWithHostinet());
+
+  ASSERT_NO_ERRNO(BindLoopback());
+  ASSERT_THAT(connect(sock_.get(), bind_addr_, addrlen_), SyscallSucceeds());
+
+  // MSG_ZEROCOPY is ignored without SO_ZEROCOPY.
+  char buf[100];
+  RandomizeBuffer(buf, sizeof(buf));
+  ASSERT_THAT(send(sock_.get(), buf, sizeof(buf), MSG_ZEROCOPY),
+              SyscallSucceedsWithValue(sizeof(buf)));
+  msghdr msg = {};
+  EXPECT_THAT(recvmsg(sock_.get(), &msg, MSG_ERRQUEUE),
+              SyscallFailsWithErrno(EAGAIN));
+
+  int v = 2;
+  EXPECT_THAT(
+      setsockopt(sock_.get(), SOL_SOCKET, SO_ZEROCOPY, &v, sizeof(v)),
+      SyscallFailsWithErrno(EINVAL));
+  ASSERT_THAT(setsockopt(sock_.get(), SOL_SOCKET, SO_ZEROCOPY, &kSockOptOn,
+                         sizeof(kSockOptOn)),
+              SyscallSucceeds());
+  int got;
+  socklen_t got_len = sizeof(got);
+  ASSERT_THAT(
+      getsockopt(sock_.get(), SOL_SOCKET, SO_ZEROCOPY, &got, &got_len),
+      SyscallSucceeds());
+  EXPECT_EQ(got, kSockOptOn);
+
+  // Each send takes an ID, starting from zero.
+  constexpr int kSends = 3;
+  for (int i = 0; i < kSends; i++) {
+    ASSERT_THAT(send(sock_.get(), buf, sizeof(buf), MSG_ZEROCOPY),
+                SyscallSucceedsWithValue(sizeof(buf)));
+  }
+  int level = SOL_IP;
+  int type = IP_RECVERR;
+  if (GetParam() == AF_INET6) {
+    level = SOL_IPV6;
+    type = IPV6_RECVERR;
+  }
+  ASSERT_NO_FATAL_FAILURE(
+      RecvZeroCopyCompletions(sock_.get(), level, type, 0, kSends - 1));
+  EXPECT_THAT(recvmsg(sock_.get(), &msg, MSG_ERRQUEUE),
+              SyscallFailsWithErrno(EAGAIN));
+}
+
+TEST_P(UdpSocketTest, TxTime) {
+  // Hostinet doesn't support SO_TXTIME.
+  SKIP_IF(IsRunningWithHostinet());
+
+  ASSERT_NO_ERRNO(BindLoopback());
+  ASSERT_THAT(connect(sock_.get(), bind_addr_, addrlen_), SyscallSucceeds());
+
+  char buf[100];
+  RandomizeBuffer(buf, sizeof(buf));
+  struct timespec now;
+  ASSERT_THAT(clock_gettime(CLOCK_MONOTONIC, &now), SyscallSucceeds());
+  uint64_t txtime = now.tv_sec * 1000000000ULL + now.tv_nsec + 1000000;
+  char control[CMSG_SPACE(sizeof(txtime))] = {};
+  struct iovec iov = {buf, sizeof(buf)};
+  msghdr msg = {};
+  msg.msg_iov = &iov;
+  msg.msg_iovlen = 1;
+  msg.msg_control = control;
+  msg.msg_controllen = sizeof(control);
+  struct cmsghdr* cmsg = CMSG_FIRSTHDR(&msg);
+  cmsg->cmsg_level = SOL_SOCKET;
+  cmsg->cmsg_type = SCM_TXTIME;
+  cmsg->cmsg_len = CMSG_LEN(sizeof(txtime));
+  memcpy(CMSG_DATA(cmsg), &txtime, sizeof(txtime));
+
+  // Departure times can't be set without SO_TXTIME.
+  EXPECT_THAT(sendmsg(sock_.get(), &msg, 0), SyscallFailsWithErrno(EINVAL));
+
+  struct sock_txtime opt = {};
+  socklen_t opt_len = sizeof(opt);
+  ASSERT_THAT(getsockopt(sock_.get(), SOL_SOCKET, SO_TXTIME, &opt, &opt_len),
+              SyscallSucceeds());
+  EXPECT_EQ(opt_len, sizeof(opt));
+  EXPECT_EQ(opt.clockid, 0);
+  EXPECT_EQ(opt.flags, 0);
+
+  opt.clockid = CLOCK_MONOTONIC;
+  opt.flags = SOF_TXTIME_FLAGS_LAST << 1;
+  EXPECT_THAT(setsockopt(sock_.get(), SOL_SOCKET, SO_TXTIME, &opt, sizeof(opt)),
+              SyscallFailsWithErrno(EINVAL));
+  opt.flags = SOF_TXTIME_REPORT_ERRORS;
+  EXPECT_THAT(
+      setsockopt(sock_.get(), SOL_SOCKET, SO_TXTIME, &opt, sizeof(opt) - 1),
+      SyscallFailsWithErrno(EINVAL));
+
+  // Other clocks than the monotonic one require CAP_NET_ADMIN.
+  opt.clockid = CLOCK_TAI;
+  int ret = setsockopt(sock_.get(), SOL_SOCKET, SO_TXTIME, &opt, sizeof(opt));
+  if (ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_NET_ADMIN))) {
+    EXPECT_THAT(ret, SyscallSucceeds());
+  } else {
+    EXPECT_THAT(ret, SyscallFailsWithErrno(EPERM));
+  }
+
+  opt.clockid = CLOCK_MONOTONIC;
+  ASSERT_THAT(setsockopt(sock_.get(), SOL_SOCKET, SO_TXTIME, &opt, sizeof(opt)),
+              SyscallSucceeds());
+  struct sock_txtime got = {};
+  ASSERT_THAT(getsockopt(sock_.get(), SOL_SOCKET, SO_TXTIME, &got, &opt_len),
+              SyscallSucceeds());
+  EXPECT_EQ(got.clockid, CLOCK_MONOTONIC);
+  EXPECT_EQ(got.flags, SOF_TXTIME_REPORT_ERRORS);
+
+  // The queueing discipline of the loopback interface ignores departure
+  // times, so the packet is received right away.
+  ASSERT_THAT(sendmsg(sock_.get(), &msg, 0),
+              SyscallSucceedsWithValue(sizeof(buf)));
+  char received[sizeof(buf)];
+  EXPECT_THAT(RecvTimeout(bind_.get(), received, sizeof(received),
+                          1 /*timeout*/),
+              IsPosixErrorOkAndHolds(sizeof(received)));
+  EXPECT_EQ(memcmp(buf, received, sizeof(buf)), 0);
+
+  // The departure time must be a 64-bit value.
+  cmsg->cmsg_len = CMSG_LEN(sizeof(uint32_t));
+  EXPECT_THAT(sendmsg(sock_.get(), &msg, 0), SyscallFailsWithErrno(EINVAL));
+}
 #endif  // __linux__
 
 TEST_P(UdpSocketTest, ZerolengthWriteAllowed) {
@@ -266,6 +266,57 @@ TEST(UidGidRootTest, Setresgid) {
   });
 }
 
+TEST(UidGidRootTest, Setfsuid) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(IsRoot()));
+
+  ScopedThread([&] {
+    const uid_t uid = absl::GetFlag(FLAGS_scratch_uid1);
+
+    // setfsuid(-1) never changes the fsuid, and returns the current one.
+    EXPECT_THAT(syscall(SYS_setfsuid, -1), SyscallSucceedsWithValue(0));
+
+    // "On success, the previous value of fsuid is returned." - setfsuid(2)
+    EXPECT_THAT(syscall(SYS_setfsuid, uid), SyscallSucceedsWithValue(0));
+    EXPECT_THAT(syscall(SYS_setfsuid, -1), SyscallSucceedsWithValue(uid));
+    EXPECT_NO_ERRNO(CheckUIDs(0, 0, 0));
+
+    // Changing the fsuid from 0 to nonzero drops filesystem capabilities from
+    // the effective set, and changing it back restores them.
+    EXPECT_FALSE(ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_DAC_OVERRIDE)));
+    EXPECT_TRUE(ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_SETUID)));
+    EXPECT_THAT(syscall(SYS_setfsuid, 0), SyscallSucceedsWithValue(uid));
+    EXPECT_TRUE(ASSERT_NO_ERRNO_AND_VALUE(HaveCapability(CAP_DAC_OVERRIDE)));
+
+    // "Whenever the effective user ID is changed, fsuid will also be changed
+    // to the new value of the effective user ID." - setfsuid(2)
+    ASSERT_THAT(syscall(SYS_setresuid, -1, uid, -1), SyscallSucceeds());
+    EXPECT_THAT(syscall(SYS_setfsuid, -1), SyscallSucceedsWithValue(uid));
+
+    // Without CAP_SETUID, the fsuid may only be set to the real, effective,
+    // saved or current filesystem user ID.
+    const uid_t other = absl::GetFlag(FLAGS_scratch_uid2);
+    ASSERT_THAT(syscall(SYS_setresuid, uid, uid, uid), SyscallSucceeds());
+    EXPECT_THAT(syscall(SYS_setfsuid, other), SyscallSucceedsWithValue(uid));
+    EXPECT_THAT(syscall(SYS_setfsuid, -1), SyscallSucceedsWithValue(uid));
+  });
+}
+
+TEST(UidGidRootTest, Setfsgid) {
+  SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(IsRoot()));
+
+  ScopedThread([&] {
+    const gid_t gid = absl::GetFlag(FLAGS_scratch_gid1);
+
+    EXPECT_THAT(syscall(SYS_setfsgid, -1), SyscallSucceedsWithValue(0));
+    EXPECT_THAT(syscall(SYS_setfsgid, gid), SyscallSucceedsWithValue(0));
+    EXPECT_THAT(syscall(SYS_setfsgid, -1), SyscallSucceedsWithValue(gid));
+    EXPECT_NO_ERRNO(CheckGIDs(0, 0, 0));
+
+    ASSERT_THAT(syscall(SYS_setresgid, -1, 0, -1), SyscallSucceeds());
+    EXPECT_THAT(syscall(SYS_setfsgid, -1), SyscallSucceedsWithValue(0));
+  });
+}
+
 TEST(UidGidRootTest, Setgroups) {
   SKIP_IF(!ASSERT_NO_ERRNO_AND_VALUE(IsRoot()));
 